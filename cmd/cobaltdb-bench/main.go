@@ -4,18 +4,30 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
 	"time"
 
 	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/logger"
 )
 
 var (
-	flagHelp       bool
-	flagInMemory   bool
-	flagPath       string
-	flagRows       int
-	flagBenchmarks string
+	flagHelp        bool
+	flagInMemory    bool
+	flagPath        string
+	flagRows        int
+	flagBenchmarks  string
+	flagReuseData   bool
+	flagSeed        int64
+	flagConcurrency int
+	flagWorkload    string
+	flagOps         int
+	flagFormat      string
+	flagOutput      string
+	flagStmt        string
+	flagBatch       int
 )
 
 func init() {
@@ -25,6 +37,15 @@ func init() {
 	flag.StringVar(&flagPath, "path", ":memory:", "Database path")
 	flag.IntVar(&flagRows, "rows", 10000, "Number of rows for benchmarks")
 	flag.StringVar(&flagBenchmarks, "bench", "all", "Benchmarks to run: all, insert, select, update, delete, transaction")
+	flag.BoolVar(&flagReuseData, "reuse-data", false, "Keep benchmark tables already populated with the same -rows and -seed")
+	flag.Int64Var(&flagSeed, "seed", 1, "Seed for generated data (0 = random)")
+	flag.IntVar(&flagConcurrency, "concurrency", 1, "Number of parallel workers")
+	flag.StringVar(&flagWorkload, "workload", "", "Run a read/update mix instead of -bench: ycsb-a, ycsb-b, ycsb-c or mix:<read%>")
+	flag.IntVar(&flagOps, "ops", 10000, "Number of -workload operations")
+	flag.StringVar(&flagFormat, "format", "text", "Result format: text, csv or json")
+	flag.StringVar(&flagOutput, "o", "", "Write csv/json results to this file instead of stdout")
+	flag.StringVar(&flagStmt, "stmt", stmtCached, "How statements are sent: cached, literal or prepared")
+	flag.IntVar(&flagBatch, "batch", 1, "Statements per transaction in per-operation benchmarks (1 = autocommit)")
 }
 
 func main() {
@@ -35,7 +56,10 @@ func main() {
 		os.Exit(0)
 	}
 
-	runBenchmarks()
+	if err := runBenchmarks(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func printHelp() {
@@ -51,237 +75,325 @@ Options:
   -path <path>        Database file path
   -rows <n>           Number of rows (default: 10000)
   -bench <name>       Benchmark to run: all, insert, select, update, delete, transaction
+  -reuse-data         Keep tables a previous run populated with the same -rows and -seed
+  -seed <n>           Seed for generated data (default: 1, 0 = random)
+  -concurrency <n>    Number of parallel workers (default: 1)
+  -workload <name>    Run a read/update mix instead of -bench: ycsb-a (50% reads),
+                      ycsb-b (95%), ycsb-c (100%) or mix:<read%>
+  -ops <n>            Number of -workload operations (default: 10000)
+  -format <fmt>       Result format: text, csv or json (default: text)
+  -o <file>           Write csv/json results to a file instead of stdout
+  -stmt <mode>        How per-operation benchmarks send statements (default: cached):
+                        cached    ? placeholders; the statement cache parses once
+                        literal   values inlined into the SQL; every call is parsed
+                        prepared  DB.Prepare once, then Stmt.Exec/Query
+  -batch <n>          Statements per transaction in per-operation benchmarks
+                      (default: 1 = autocommit)
+
+Every result reports ops/sec and p50/p95/p99 latency. With -format csv or
+json, progress goes to stderr and the results to stdout or -o.
 
 Examples:
   cobaltdb-bench
   cobaltdb-bench -rows 50000
   cobaltdb-bench -bench insert
+  cobaltdb-bench -memory=false -path bench.db -bench select -reuse-data
+  cobaltdb-bench -workload ycsb-a -concurrency 8 -ops 100000
+  cobaltdb-bench -concurrency 4 -format json -o results.json
+  cobaltdb-bench -bench insert -stmt literal
+  cobaltdb-bench -workload ycsb-a -stmt prepared -batch 100
 `)
 }
 
-func runBenchmarks() {
-	fmt.Printf("CobaltDB Benchmark Tool\n")
-	fmt.Printf("========================\n")
-	fmt.Printf("Rows: %d\n", flagRows)
-	fmt.Printf("Mode: %s\n", func() string {
+// report collects benchmark results. Text-format results are printed as they
+// arrive; with csv or json, progress goes to stderr and the results are
+// written once at the end.
+type report struct {
+	log     io.Writer
+	text    bool
+	results []benchResult
+}
+
+func (r *report) section(title string) {
+	fmt.Fprintln(r.log, title)
+}
+
+func (r *report) add(results ...benchResult) {
+	r.results = append(r.results, results...)
+	if r.text {
+		for _, res := range results {
+			printResult(r.log, res)
+		}
+	}
+}
+
+func runBenchmarks() error {
+	rep := &report{log: os.Stdout, text: flagFormat == "text"}
+	if !rep.text {
+		if err := writeResults(io.Discard, flagFormat, nil); err != nil {
+			return err
+		}
+		rep.log = os.Stderr
+	}
+	var wl workload
+	if flagWorkload != "" {
+		var err error
+		if wl, err = parseWorkload(flagWorkload); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(rep.log, "CobaltDB Benchmark Tool\n")
+	fmt.Fprintf(rep.log, "========================\n")
+	if flagSeed == 0 {
+		flagSeed = time.Now().UnixNano()
+	}
+	fmt.Fprintf(rep.log, "Rows: %d\n", flagRows)
+	fmt.Fprintf(rep.log, "Seed: %d\n", flagSeed)
+	fmt.Fprintf(rep.log, "Concurrency: %d\n", max(flagConcurrency, 1))
+	fmt.Fprintf(rep.log, "Statements: %s, batch %d\n", flagStmt, max(flagBatch, 1))
+	fmt.Fprintf(rep.log, "Mode: %s\n", func() string {
 		if flagInMemory {
 			return "in-memory"
 		}
 		return "disk"
 	}())
-	fmt.Println()
+	fmt.Fprintln(rep.log)
 
 	ctx := context.Background()
 
-	db, err := engine.Open(flagPath, &engine.Options{CoreStorage: engine.CoreStorage{InMemory: flagInMemory}})
+	// Engine logs follow the progress output, keeping csv/json stdout clean.
+	db, err := engine.Open(flagPath, &engine.Options{CoreStorage: engine.CoreStorage{
+		InMemory: flagInMemory,
+		Logger:   logger.New(logger.InfoLevel, rep.log),
+	}})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("opening database: %w", err)
 	}
 	defer db.Close()
+	r, err := newRunner(db, ctx, flagStmt, flagBatch)
+	if err != nil {
+		return err
+	}
+
+	if flagReuseData && flagInMemory {
+		fmt.Fprintln(rep.log, "Note: -reuse-data has no effect on an in-memory database")
+		fmt.Fprintln(rep.log)
+	}
 
-	// Run selected benchmarks
-	switch flagBenchmarks {
-	case "all":
-		runAllBenchmarks(db, ctx)
-	case "insert":
-		runInsertBenchmark(db, ctx)
-	case "select":
-		runSelectBenchmark(db, ctx)
-	case "update":
-		runUpdateBenchmark(db, ctx)
-	case "delete":
-		runDeleteBenchmark(db, ctx)
-	case "transaction":
-		runTransactionBenchmark(db, ctx)
+	// Run the workload or the selected benchmarks
+	switch {
+	case flagWorkload != "":
+		runWorkload(db, ctx, wl, rep, r)
+	case flagBenchmarks == "all":
+		runAllBenchmarks(db, ctx, rep, r)
+	case flagBenchmarks == "insert":
+		runInsertBenchmark(db, ctx, rep, r)
+	case flagBenchmarks == "select":
+		runSelectBenchmark(db, ctx, rep, r)
+	case flagBenchmarks == "update":
+		runUpdateBenchmark(db, ctx, rep, r)
+	case flagBenchmarks == "delete":
+		runDeleteBenchmark(db, ctx, rep, r)
+	case flagBenchmarks == "transaction":
+		runTransactionBenchmark(db, ctx, rep)
 	default:
-		fmt.Printf("Unknown benchmark: %s\n", flagBenchmarks)
+		return fmt.Errorf("unknown benchmark: %s", flagBenchmarks)
+	}
+
+	if rep.text {
+		return nil
+	}
+	if flagOutput == "" {
+		return writeResults(os.Stdout, flagFormat, rep.results)
+	}
+	f, err := os.Create(flagOutput)
+	if err != nil {
+		return err
+	}
+	if err := writeResults(f, flagFormat, rep.results); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing results: %w", err)
 	}
+	return f.Close()
 }
 
-func runAllBenchmarks(db *engine.DB, ctx context.Context) {
-	runInsertBenchmark(db, ctx)
-	runSelectBenchmark(db, ctx)
-	runUpdateBenchmark(db, ctx)
-	runDeleteBenchmark(db, ctx)
-	runTransactionBenchmark(db, ctx)
+func runAllBenchmarks(db *engine.DB, ctx context.Context, rep *report, r *runner) {
+	runInsertBenchmark(db, ctx, rep, r)
+	runSelectBenchmark(db, ctx, rep, r)
+	runUpdateBenchmark(db, ctx, rep, r)
+	runDeleteBenchmark(db, ctx, rep, r)
+	runTransactionBenchmark(db, ctx, rep)
 }
 
-func runInsertBenchmark(db *engine.DB, ctx context.Context) {
-	fmt.Println("=== INSERT Benchmark ===")
+// exec adapts a statement to a benchOp.
+func exec(db *engine.DB, ctx context.Context, sql string, args ...interface{}) (string, error) {
+	_, err := db.Exec(ctx, sql, args...)
+	return "", err
+}
+
+func runInsertBenchmark(db *engine.DB, ctx context.Context, rep *report, r *runner) {
+	rep.section("=== INSERT Benchmark ===")
 
 	// Setup
 	db.Exec(ctx, "DROP TABLE IF EXISTS bench_insert")
 	db.Exec(ctx, "CREATE TABLE bench_insert (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
 
 	// Benchmark
-	start := time.Now()
-	for i := 0; i < flagRows; i++ {
-		db.Exec(ctx, "INSERT INTO bench_insert (name, age) VALUES (?, ?)", fmt.Sprintf("user-%d", i), i%100)
-	}
-	elapsed := time.Since(start)
-
-	ops := float64(flagRows) / elapsed.Seconds()
-	fmt.Printf("Time: %v\n", elapsed)
-	fmt.Printf("Ops/sec: %.2f\n", ops)
-	fmt.Printf("Avg time/op: %.2f ns\n", float64(elapsed.Nanoseconds())/float64(flagRows))
-	fmt.Println()
+	rep.add(r.run("Insert", flagRows, func(w, i int) (string, error) {
+		return "", r.exec(w, "INSERT INTO bench_insert (name, age) VALUES (?, ?)", fmt.Sprintf("user-%d", i), i%100)
+	})...)
 }
 
-func runSelectBenchmark(db *engine.DB, ctx context.Context) {
-	fmt.Println("=== SELECT Benchmark ===")
+func runSelectBenchmark(db *engine.DB, ctx context.Context, rep *report, r *runner) {
+	rep.section("=== SELECT Benchmark ===")
 
 	// Setup
-	db.Exec(ctx, "DROP TABLE IF EXISTS bench_select")
-	db.Exec(ctx, "CREATE TABLE bench_select (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
-	db.Exec(ctx, "CREATE INDEX idx_bench_select_age ON bench_select(age)")
-	for i := 0; i < flagRows; i++ {
-		db.Exec(ctx, "INSERT INTO bench_select (name, age) VALUES (?, ?)", fmt.Sprintf("user-%d", i), i%100)
-	}
+	rep.prepare(db, ctx, "bench_select")
 
 	// Benchmark - Full scan
-	start := time.Now()
-	for i := 0; i < 100; i++ {
-		rows, _ := db.Query(ctx, "SELECT * FROM bench_select")
-		rows.Close()
-	}
-	elapsed := time.Since(start)
-
-	ops := float64(100) / elapsed.Seconds()
-	fmt.Printf("Full Table Scan - Time: %v\n", elapsed)
-	fmt.Printf("Ops/sec: %.2f\n", ops)
-	fmt.Println()
+	rep.add(r.run("Full Table Scan", 100, func(w, _ int) (string, error) {
+		return "", r.query(w, "SELECT * FROM bench_select")
+	})...)
 
 	// With indexed WHERE (PK lookup)
-	fmt.Println("=== SELECT with PK Lookup ===")
-	start = time.Now()
-	for i := 0; i < 1000; i++ {
-		rows, _ := db.Query(ctx, "SELECT * FROM bench_select WHERE id = ?", i%(flagRows-1)+1)
-		rows.Close()
-	}
-	elapsed = time.Since(start)
-
-	ops = float64(1000) / elapsed.Seconds()
-	fmt.Printf("PK Lookup - Time: %v\n", elapsed)
-	fmt.Printf("Ops/sec: %.2f\n", ops)
-	fmt.Println()
+	rep.add(r.run("PK Lookup", 1000, func(w, i int) (string, error) {
+		return "", r.query(w, "SELECT * FROM bench_select WHERE id = ?", i%(flagRows-1)+1)
+	})...)
 
 	// With indexed WHERE (secondary index - equality)
-	fmt.Println("=== SELECT with Index Lookup ===")
-	start = time.Now()
-	for i := 0; i < 1000; i++ {
-		rows, _ := db.Query(ctx, "SELECT * FROM bench_select WHERE age = ?", i%100)
-		rows.Close()
-	}
-	elapsed = time.Since(start)
-
-	ops = float64(1000) / elapsed.Seconds()
-	fmt.Printf("Index Lookup - Time: %v\n", elapsed)
-	fmt.Printf("Ops/sec: %.2f\n", ops)
-	fmt.Println()
+	rep.add(r.run("Index Lookup", 1000, func(w, i int) (string, error) {
+		return "", r.query(w, "SELECT * FROM bench_select WHERE age = ?", i%100)
+	})...)
 }
 
-func runUpdateBenchmark(db *engine.DB, ctx context.Context) {
-	fmt.Println("=== UPDATE Benchmark ===")
+func runUpdateBenchmark(db *engine.DB, ctx context.Context, rep *report, r *runner) {
+	rep.section("=== UPDATE Benchmark ===")
 
 	// Setup
-	db.Exec(ctx, "DROP TABLE IF EXISTS bench_update")
-	db.Exec(ctx, "CREATE TABLE bench_update (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
-	db.Exec(ctx, "CREATE INDEX idx_bench_update_age ON bench_update(age)")
-	for i := 0; i < flagRows; i++ {
-		db.Exec(ctx, "INSERT INTO bench_update (name, age) VALUES (?, ?)", fmt.Sprintf("user-%d", i), i%100)
-	}
+	rep.prepare(db, ctx, "bench_update")
+	invalidateTable(db, ctx, "bench_update")
 
 	// Single row update with PK
-	fmt.Println("--- PK Lookup Update ---")
-	start := time.Now()
-	for i := 0; i < 1000; i++ {
-		db.Exec(ctx, "UPDATE bench_update SET age = ? WHERE id = ?", i+1000, i%(flagRows-1)+1)
-	}
-	elapsed := time.Since(start)
-
-	ops := float64(1000) / elapsed.Seconds()
-	fmt.Printf("PK Update - Time: %v\n", elapsed)
-	fmt.Printf("Ops/sec: %.2f\n", ops)
-	fmt.Println()
+	rep.add(r.run("PK Update", 1000, func(w, i int) (string, error) {
+		return "", r.exec(w, "UPDATE bench_update SET age = ? WHERE id = ?", i+1000, i%(flagRows-1)+1)
+	})...)
 
 	// Multi-row update (full scan)
-	fmt.Println("--- Full Scan Update ---")
-	start = time.Now()
-	db.Exec(ctx, "UPDATE bench_update SET age = ? WHERE age < ?", 999, 50)
-	elapsed = time.Since(start)
-
-	fmt.Printf("Full Scan Update (all age < 50) - Time: %v\n", elapsed)
-	fmt.Println()
+	rep.add(timeOnce("Full Scan Update (all age < 50)", func() error {
+		_, err := exec(db, ctx, "UPDATE bench_update SET age = ? WHERE age < ?", 999, 50)
+		return err
+	}))
 }
 
-func runDeleteBenchmark(db *engine.DB, ctx context.Context) {
-	fmt.Println("=== DELETE Benchmark ===")
+func runDeleteBenchmark(db *engine.DB, ctx context.Context, rep *report, r *runner) {
+	rep.section("=== DELETE Benchmark ===")
 
 	// Setup
-	db.Exec(ctx, "DROP TABLE IF EXISTS bench_delete")
-	db.Exec(ctx, "CREATE TABLE bench_delete (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
-	db.Exec(ctx, "CREATE INDEX idx_bench_delete_age ON bench_delete(age)")
-	for i := 0; i < flagRows; i++ {
-		db.Exec(ctx, "INSERT INTO bench_delete (name, age) VALUES (?, ?)", fmt.Sprintf("user-%d", i), i%100)
-	}
+	rep.prepare(db, ctx, "bench_delete")
+	invalidateTable(db, ctx, "bench_delete")
 
-	// Single row delete with PK
-	fmt.Println("--- PK Lookup Delete ---")
-	start := time.Now()
-	for i := 0; i < 1000; i++ {
+	// Single row delete with PK, putting the row back for the next run
+	rep.add(r.run("PK Delete", 1000, func(w, i int) (string, error) {
 		id := i%(flagRows-1) + 1
-		db.Exec(ctx, "DELETE FROM bench_delete WHERE id = ?", id)
-		db.Exec(ctx, "INSERT INTO bench_delete (id, name, age) VALUES (?, ?, ?)", id, fmt.Sprintf("user-%d", id), id%100)
-	}
-	elapsed := time.Since(start)
-
-	ops := float64(1000) / elapsed.Seconds()
-	fmt.Printf("PK Delete - Time: %v\n", elapsed)
-	fmt.Printf("Ops/sec: %.2f\n", ops)
-	fmt.Println()
+		if err := r.exec(w, "DELETE FROM bench_delete WHERE id = ?", id); err != nil {
+			return "", err
+		}
+		return "", r.exec(w, "INSERT INTO bench_delete (id, name, age) VALUES (?, ?, ?)", id, fmt.Sprintf("user-%d", id), id%100)
+	})...)
 
 	// Multi-row delete (full scan)
-	fmt.Println("--- Full Scan Delete ---")
-	start = time.Now()
-	db.Exec(ctx, "DELETE FROM bench_delete WHERE age < ?", 50)
-	elapsed = time.Since(start)
-
-	fmt.Printf("Full Scan Delete (all age < 50) - Time: %v\n", elapsed)
-	fmt.Println()
+	rep.add(timeOnce("Full Scan Delete (all age < 50)", func() error {
+		_, err := exec(db, ctx, "DELETE FROM bench_delete WHERE age < ?", 50)
+		return err
+	}))
 }
 
-func runTransactionBenchmark(db *engine.DB, ctx context.Context) {
-	fmt.Println("=== TRANSACTION Benchmark ===")
+func runTransactionBenchmark(db *engine.DB, ctx context.Context, rep *report) {
+	rep.section("=== TRANSACTION Benchmark ===")
 
 	// Setup
 	db.Exec(ctx, "DROP TABLE IF EXISTS bench_tx")
 	db.Exec(ctx, "CREATE TABLE bench_tx (id INTEGER PRIMARY KEY, name TEXT)")
 
 	// Single statement in transaction
-	start := time.Now()
-	for i := 0; i < 1000; i++ {
-		tx, _ := db.Begin(ctx)
-		tx.Exec(ctx, "INSERT INTO bench_tx (name) VALUES (?)", fmt.Sprintf("user-%d", i))
-		tx.Commit()
-	}
-	elapsed := time.Since(start)
-
-	ops := float64(1000) / elapsed.Seconds()
-	fmt.Printf("Auto-commit - Time: %v\n", elapsed)
-	fmt.Printf("Ops/sec: %.2f\n", ops)
-	fmt.Println()
+	rep.add(runOps("Auto-commit", 1000, flagConcurrency, func(_, i int) (string, error) {
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO bench_tx (name) VALUES (?)", fmt.Sprintf("user-%d", i)); err != nil {
+			_ = tx.Rollback()
+			return "", err
+		}
+		return "", tx.Commit()
+	}, nil)...)
 
 	// Batch insert in transaction
 	db.Exec(ctx, "DELETE FROM bench_tx")
-	start = time.Now()
-	tx, _ := db.Begin(ctx)
-	for i := 0; i < 1000; i++ {
-		tx.Exec(ctx, "INSERT INTO bench_tx (name) VALUES (?)", fmt.Sprintf("user-%d", i))
+	rep.add(timeOnce("Batch (1000 rows)", func() error {
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 1000; i++ {
+			if _, err := tx.Exec(ctx, "INSERT INTO bench_tx (name) VALUES (?)", fmt.Sprintf("user-%d", i)); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		return tx.Commit()
+	}))
+}
+
+// prepare runs prepareTable for a benchmark and notes a reused table.
+func (r *report) prepare(db *engine.DB, ctx context.Context, table string) {
+	if prepareTable(db, ctx, table, flagReuseData) {
+		fmt.Fprintf(r.log, "Reusing %s (%d rows)\n", table, flagRows)
 	}
-	tx.Commit()
-	elapsed = time.Since(start)
+}
+
+// prepareTable creates table with flagRows generated rows and an index on
+// age. With reuse it keeps a table that an earlier run populated with the
+// same row count and seed, as recorded in bench_meta, and reports whether it
+// did.
+func prepareTable(db *engine.DB, ctx context.Context, table string, reuse bool) bool {
+	db.Exec(ctx, "CREATE TABLE IF NOT EXISTS bench_meta (name TEXT PRIMARY KEY, row_count INTEGER, seed INTEGER)")
+	if reuse && tableReusable(db, ctx, table) {
+		return true
+	}
+
+	invalidateTable(db, ctx, table)
+	db.Exec(ctx, "DROP TABLE IF EXISTS "+table)
+	db.Exec(ctx, "CREATE TABLE "+table+" (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	db.Exec(ctx, "CREATE INDEX idx_"+table+"_age ON "+table+"(age)")
+	rng := rand.New(rand.NewSource(flagSeed)) // #nosec G404 - benchmark data, not security sensitive.
+	for i := 0; i < flagRows; i++ {
+		db.Exec(ctx, "INSERT INTO "+table+" (name, age) VALUES (?, ?)", fmt.Sprintf("user-%d", rng.Intn(flagRows*10)), rng.Intn(100))
+	}
+	db.Exec(ctx, "INSERT INTO bench_meta (name, row_count, seed) VALUES (?, ?, ?)", table, flagRows, flagSeed)
+	return false
+}
+
+// tableReusable reports whether table holds the rows prepareTable would
+// generate for the current -rows and -seed.
+func tableReusable(db *engine.DB, ctx context.Context, table string) bool {
+	var rowCount, seed int64
+	if err := db.QueryRow(ctx, "SELECT row_count, seed FROM bench_meta WHERE name = ?", table).Scan(&rowCount, &seed); err != nil {
+		return false
+	}
+	if rowCount != int64(flagRows) || seed != flagSeed {
+		return false
+	}
+	var count int64
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+		return false
+	}
+	return count == rowCount
+}
 
-	fmt.Printf("Batch (1000 rows) - Time: %v\n", elapsed)
-	fmt.Println()
+// invalidateTable forgets table's metadata before a benchmark changes its
+// rows, so the next -reuse-data run regenerates it.
+func invalidateTable(db *engine.DB, ctx context.Context, table string) {
+	db.Exec(ctx, "DELETE FROM bench_meta WHERE name = ?", table)
 }
@@ -2,10 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
 )
 
 func TestPrintHelp(t *testing.T) {
@@ -32,6 +41,232 @@ func TestPrintHelp(t *testing.T) {
 	}
 }
 
+func TestPrepareTableReuse(t *testing.T) {
+	oldRows, oldSeed := flagRows, flagSeed
+	defer func() { flagRows, flagSeed = oldRows, oldSeed }()
+	flagRows, flagSeed = 50, 7
+
+	ctx := context.Background()
+	db, err := engine.Open(filepath.Join(t.TempDir(), "bench.db"), &engine.Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	names := func() string {
+		rows, err := db.Query(ctx, "SELECT name, age FROM bench_select ORDER BY id")
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		defer rows.Close()
+		var b strings.Builder
+		for rows.Next() {
+			var name string
+			var age int
+			if err := rows.Scan(&name, &age); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			b.WriteString(name)
+		}
+		return b.String()
+	}
+
+	if prepareTable(db, ctx, "bench_select", true) {
+		t.Fatal("first prepareTable should populate the table")
+	}
+	first := names()
+	if !prepareTable(db, ctx, "bench_select", true) {
+		t.Fatal("prepareTable should reuse a table with the same rows and seed")
+	}
+	if prepareTable(db, ctx, "bench_select", false) {
+		t.Fatal("prepareTable without reuse should repopulate")
+	}
+	if got := names(); got != first {
+		t.Fatal("the same seed should generate the same data")
+	}
+
+	invalidateTable(db, ctx, "bench_select")
+	if prepareTable(db, ctx, "bench_select", true) {
+		t.Fatal("prepareTable should repopulate an invalidated table")
+	}
+	flagSeed = 8
+	if prepareTable(db, ctx, "bench_select", true) {
+		t.Fatal("prepareTable should repopulate for a different seed")
+	}
+	if names() == first {
+		t.Fatal("a different seed should generate different data")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	for _, tt := range []struct {
+		p    float64
+		want time.Duration
+	}{{50, 50 * time.Millisecond}, {95, 95 * time.Millisecond}, {99, 99 * time.Millisecond}, {100, 100 * time.Millisecond}, {0, time.Millisecond}} {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no samples = %v, want 0", got)
+	}
+}
+
+func TestRunOps(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	results := runOps("mixed", 200, 4, func(w, i int) (string, error) {
+		mu.Lock()
+		seen[i] = true
+		mu.Unlock()
+		if i%4 == 0 {
+			return "write", errors.New("conflict")
+		}
+		return "read", nil
+	}, nil)
+	if len(seen) != 200 {
+		t.Fatalf("ran %d distinct ops, want 200", len(seen))
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want overall, read and write", len(results))
+	}
+	total, read, write := results[0], results[1], results[2]
+	if total.Name != "mixed" || total.Ops != 200 || total.Errors != 50 || total.Concurrency != 4 {
+		t.Errorf("overall result = %+v", total)
+	}
+	if read.Name != "mixed read" || read.Ops != 150 || read.Errors != 0 {
+		t.Errorf("read result = %+v", read)
+	}
+	if write.Name != "mixed write" || write.Ops != 50 || write.Errors != 50 {
+		t.Errorf("write result = %+v", write)
+	}
+	if total.P50US > total.P95US || total.P95US > total.P99US {
+		t.Errorf("percentiles out of order: %+v", total)
+	}
+
+	if results := runOps("plain", 10, 0, func(int, int) (string, error) { return "", nil }, nil); len(results) != 1 || results[0].Concurrency != 1 {
+		t.Errorf("runOps without kinds = %+v, want one result on one worker", results)
+	}
+}
+
+func TestParseWorkload(t *testing.T) {
+	for in, want := range map[string]int{"ycsb-a": 50, "YCSB-B": 95, "ycsb-c": 100, "mix:80": 80, "mix:0": 0} {
+		wl, err := parseWorkload(in)
+		if err != nil || wl.readPct != want {
+			t.Errorf("parseWorkload(%q) = %+v, %v; want %d%% reads", in, wl, err, want)
+		}
+	}
+	for _, in := range []string{"ycsb-z", "mix:", "mix:101", "mix:-1", "mix:abc"} {
+		if _, err := parseWorkload(in); err == nil {
+			t.Errorf("parseWorkload(%q) should fail", in)
+		}
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	results := []benchResult{{Name: "PK Lookup", Stmt: "prepared", Batch: 10, Ops: 10, Concurrency: 2, ElapsedMS: 1.5, OpsPerSec: 6666.667, P50US: 1, P95US: 2, P99US: 3}}
+
+	var buf bytes.Buffer
+	if err := writeResults(&buf, "csv", results); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	want := "name,stmt,batch,ops,concurrency,errors,elapsed_ms,ops_per_sec,p50_us,p95_us,p99_us\nPK Lookup,prepared,10,10,2,0,1.500,6666.667,1.000,2.000,3.000\n"
+	if buf.String() != want {
+		t.Errorf("csv = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeResults(&buf, "json", results); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var decoded []benchResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0] != results[0] {
+		t.Errorf("json round trip = %+v, %v", decoded, err)
+	}
+
+	if err := writeResults(&buf, "xml", results); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestRunWorkload(t *testing.T) {
+	oldRows, oldOps, oldConcurrency := flagRows, flagOps, flagConcurrency
+	defer func() { flagRows, flagOps, flagConcurrency = oldRows, oldOps, oldConcurrency }()
+	flagRows, flagOps, flagConcurrency = 50, 200, 3
+
+	db, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	rep := &report{log: io.Discard}
+	r, err := newRunner(db, context.Background(), stmtCached, 1)
+	if err != nil {
+		t.Fatalf("newRunner: %v", err)
+	}
+	runWorkload(db, context.Background(), workload{name: "ycsb-b", readPct: 95}, rep, r)
+	if len(rep.results) != 3 {
+		t.Fatalf("got %d results, want overall, read and update: %+v", len(rep.results), rep.results)
+	}
+	total := rep.results[0]
+	if total.Ops != 200 || rep.results[1].Ops+rep.results[2].Ops != 200 {
+		t.Errorf("results = %+v", rep.results)
+	}
+	if rep.results[1].Name != "ycsb-b read" || rep.results[1].Ops < rep.results[2].Ops {
+		t.Errorf("expected mostly reads: %+v", rep.results)
+	}
+}
+
+func TestRunnerModes(t *testing.T) {
+	oldRows, oldConcurrency := flagRows, flagConcurrency
+	defer func() { flagRows, flagConcurrency = oldRows, oldConcurrency }()
+	flagRows, flagConcurrency = 20, 2
+
+	ctx := context.Background()
+	db, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if _, err := newRunner(db, ctx, "bogus", 1); err == nil {
+		t.Fatal("expected an error for an unknown statement mode")
+	}
+
+	for _, mode := range []string{stmtCached, stmtLiteral, stmtPrepared} {
+		for _, batch := range []int{1, 7} {
+			db.Exec(ctx, "DROP TABLE IF EXISTS bench_modes")
+			db.Exec(ctx, "CREATE TABLE bench_modes (id INTEGER PRIMARY KEY, name TEXT)")
+			r, err := newRunner(db, ctx, mode, batch)
+			if err != nil {
+				t.Fatalf("newRunner(%s, %d): %v", mode, batch, err)
+			}
+			results := r.run("modes", 50, func(w, i int) (string, error) {
+				if err := r.exec(w, "INSERT INTO bench_modes (name) VALUES (?)", fmt.Sprintf("it's %d", i)); err != nil {
+					return "", err
+				}
+				return "", r.query(w, "SELECT name FROM bench_modes WHERE id = ?", 1)
+			})
+			res := results[0]
+			if res.Errors != 0 || res.Ops != 50 || res.Stmt != mode || res.Batch != batch {
+				t.Fatalf("%s/%d: result = %+v", mode, batch, res)
+			}
+			var count int64
+			if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM bench_modes").Scan(&count); err != nil || count != 50 {
+				t.Fatalf("%s/%d: %d rows committed (%v), want 50", mode, batch, count, err)
+			}
+		}
+	}
+
+	if got, want := inlineArgs("SELECT ?, ?, ?", []interface{}{"o'k", 3, 1.5}), "SELECT 'o''k', 3, 1.5"; got != want {
+		t.Errorf("inlineArgs = %q, want %q", got, want)
+	}
+}
+
 func TestRunBenchmarks(t *testing.T) {
 	t.Run("AllBenchmarks", func(t *testing.T) {
 		// This should not panic
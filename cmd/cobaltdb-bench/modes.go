@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+)
+
+// Statement modes for -stmt.
+const (
+	// stmtCached sends SQL with ? placeholders, so the engine's statement
+	// cache parses each distinct statement once.
+	stmtCached = "cached"
+	// stmtLiteral inlines the values into the SQL text, so every call is
+	// parsed.
+	stmtLiteral = "literal"
+	// stmtPrepared prepares each statement once with DB.Prepare and runs it
+	// through the Stmt.
+	stmtPrepared = "prepared"
+)
+
+// runner runs the statements of the per-operation benchmarks in the -stmt
+// mode, grouping each worker's operations into transactions of -batch
+// statements. A batch is a BEGIN ... COMMIT on the worker's goroutine, so it
+// covers prepared statements too.
+type runner struct {
+	db    *engine.DB
+	ctx   context.Context
+	mode  string
+	batch int
+
+	mu       sync.Mutex
+	prepared map[string]*engine.Stmt
+	pending  []int // Statements in each worker's open batch
+}
+
+func newRunner(db *engine.DB, ctx context.Context, mode string, batch int) (*runner, error) {
+	switch mode {
+	case stmtCached, stmtLiteral, stmtPrepared:
+	default:
+		return nil, fmt.Errorf("unknown statement mode %q (want cached, literal or prepared)", mode)
+	}
+	if batch < 1 {
+		batch = 1
+	}
+	return &runner{
+		db:       db,
+		ctx:      ctx,
+		mode:     mode,
+		batch:    batch,
+		prepared: make(map[string]*engine.Stmt),
+		pending:  make([]int, max(flagConcurrency, 1)),
+	}, nil
+}
+
+// run measures ops operations like runOps and labels the results with the
+// runner's modes.
+func (r *runner) run(name string, ops int, op benchOp) []benchResult {
+	results := runOps(name, ops, flagConcurrency, op, r.commit)
+	for i := range results {
+		results[i].Stmt = r.mode
+		results[i].Batch = r.batch
+	}
+	return results
+}
+
+// exec runs a statement on worker w.
+func (r *runner) exec(w int, sql string, args ...interface{}) error {
+	return r.inBatch(w, func() error {
+		switch r.mode {
+		case stmtLiteral:
+			_, err := r.db.Exec(r.ctx, inlineArgs(sql, args))
+			return err
+		case stmtPrepared:
+			stmt, err := r.stmt(sql)
+			if err != nil {
+				return err
+			}
+			_, err = stmt.Exec(r.ctx, args...)
+			return err
+		default:
+			_, err := r.db.Exec(r.ctx, sql, args...)
+			return err
+		}
+	})
+}
+
+// query runs a query on worker w and reads all its rows.
+func (r *runner) query(w int, sql string, args ...interface{}) error {
+	return r.inBatch(w, func() error {
+		var rows *engine.Rows
+		var err error
+		switch r.mode {
+		case stmtLiteral:
+			rows, err = r.db.Query(r.ctx, inlineArgs(sql, args))
+		case stmtPrepared:
+			var stmt *engine.Stmt
+			if stmt, err = r.stmt(sql); err == nil {
+				rows, err = stmt.Query(r.ctx, args...)
+			}
+		default:
+			rows, err = r.db.Query(r.ctx, sql, args...)
+		}
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+		}
+		return rows.Close()
+	})
+}
+
+// inBatch runs fn inside worker w's current batch, starting one if needed
+// and committing it once it holds -batch statements. A failed statement
+// rolls its batch back.
+func (r *runner) inBatch(w int, fn func() error) error {
+	if r.batch == 1 {
+		return fn()
+	}
+	if r.pending[w] == 0 {
+		if _, err := r.db.Exec(r.ctx, "BEGIN"); err != nil {
+			return err
+		}
+	}
+	if err := fn(); err != nil {
+		r.db.AbortConnTransaction()
+		r.pending[w] = 0
+		return err
+	}
+	r.pending[w]++
+	if r.pending[w] < r.batch {
+		return nil
+	}
+	return r.commit(w)
+}
+
+// commit commits worker w's open batch, if any.
+func (r *runner) commit(w int) error {
+	if r.pending[w] == 0 {
+		return nil
+	}
+	r.pending[w] = 0
+	_, err := r.db.Exec(r.ctx, "COMMIT")
+	return err
+}
+
+func (r *runner) stmt(sql string) (*engine.Stmt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stmt, ok := r.prepared[sql]; ok {
+		return stmt, nil
+	}
+	stmt, err := r.db.Prepare(r.ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	r.prepared[sql] = stmt
+	return stmt, nil
+}
+
+// inlineArgs replaces each ? in sql with the matching argument as a SQL
+// literal. The benchmark statements have no ? inside string literals.
+func inlineArgs(sql string, args []interface{}) string {
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(sql); i++ {
+		if sql[i] != '?' || n >= len(args) {
+			b.WriteByte(sql[i])
+			continue
+		}
+		switch v := args[n].(type) {
+		case string:
+			b.WriteString("'" + strings.ReplaceAll(v, "'", "''") + "'")
+		case int:
+			b.WriteString(strconv.Itoa(v))
+		default:
+			fmt.Fprintf(&b, "%v", v)
+		}
+		n++
+	}
+	return b.String()
+}
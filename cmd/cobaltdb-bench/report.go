@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// benchResult is the measurement of one benchmark, as printed and as written
+// by -format csv|json.
+type benchResult struct {
+	Name        string  `json:"name"`
+	Stmt        string  `json:"stmt,omitempty"`  // -stmt mode, for per-operation benchmarks
+	Batch       int     `json:"batch,omitempty"` // -batch size, for per-operation benchmarks
+	Ops         int     `json:"ops"`
+	Concurrency int     `json:"concurrency"`
+	Errors      int     `json:"errors"`
+	ElapsedMS   float64 `json:"elapsed_ms"`
+	OpsPerSec   float64 `json:"ops_per_sec"`
+	P50US       float64 `json:"p50_us"`
+	P95US       float64 `json:"p95_us"`
+	P99US       float64 `json:"p99_us"`
+}
+
+// benchOp runs operation i on worker w. It returns the operation's kind for
+// workloads that mix kinds ("" otherwise).
+type benchOp func(w, i int) (kind string, err error)
+
+// runOps runs ops operations on concurrency workers and measures each one.
+// Each worker calls done, if set, after its last operation; the time counts
+// toward the elapsed time and a failure as an error. The first result covers
+// every operation; when op reports kinds, one more result per kind follows,
+// named "<name> <kind>".
+func runOps(name string, ops, concurrency int, op benchOp, done func(w int) error) []benchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	type sample struct {
+		kind string
+		d    time.Duration
+		err  bool
+	}
+	perWorker := make([][]sample, concurrency)
+	doneErrs := make([]int, concurrency)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= ops {
+					if done != nil && done(w) != nil {
+						doneErrs[w]++
+					}
+					return
+				}
+				opStart := time.Now()
+				kind, err := op(w, i)
+				perWorker[w] = append(perWorker[w], sample{kind: kind, d: time.Since(opStart), err: err != nil})
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var all []time.Duration
+	byKind := make(map[string][]time.Duration)
+	errs := make(map[string]int)
+	var kinds []string
+	for w, samples := range perWorker {
+		errs[""] += doneErrs[w]
+		for _, s := range samples {
+			all = append(all, s.d)
+			if s.kind != "" {
+				if _, ok := byKind[s.kind]; !ok {
+					kinds = append(kinds, s.kind)
+				}
+				byKind[s.kind] = append(byKind[s.kind], s.d)
+			}
+			if s.err {
+				errs[""]++
+				errs[s.kind]++
+			}
+		}
+	}
+
+	results := []benchResult{newBenchResult(name, concurrency, elapsed, all, errs[""])}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		results = append(results, newBenchResult(name+" "+kind, concurrency, elapsed, byKind[kind], errs[kind]))
+	}
+	return results
+}
+
+// timeOnce measures a single operation.
+func timeOnce(name string, fn func() error) benchResult {
+	return runOps(name, 1, 1, func(int, int) (string, error) { return "", fn() }, nil)[0]
+}
+
+func newBenchResult(name string, concurrency int, elapsed time.Duration, latencies []time.Duration, errors int) benchResult {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	r := benchResult{
+		Name:        name,
+		Ops:         len(latencies),
+		Concurrency: concurrency,
+		Errors:      errors,
+		ElapsedMS:   float64(elapsed) / float64(time.Millisecond),
+		P50US:       micros(percentile(latencies, 50)),
+		P95US:       micros(percentile(latencies, 95)),
+		P99US:       micros(percentile(latencies, 99)),
+	}
+	if elapsed > 0 {
+		r.OpsPerSec = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return r
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
+
+// printResult writes r in the tool's text format.
+func printResult(w io.Writer, r benchResult) {
+	fmt.Fprintf(w, "%s - Time: %v\n", r.Name, time.Duration(r.ElapsedMS*float64(time.Millisecond)).Round(time.Microsecond))
+	if r.Ops > 1 {
+		fmt.Fprintf(w, "Ops/sec: %.2f\n", r.OpsPerSec)
+		fmt.Fprintf(w, "Latency p50/p95/p99: %.1fµs / %.1fµs / %.1fµs\n", r.P50US, r.P95US, r.P99US)
+	}
+	if r.Errors > 0 {
+		fmt.Fprintf(w, "Errors: %d of %d ops\n", r.Errors, r.Ops)
+	}
+	fmt.Fprintln(w)
+}
+
+var csvHeader = []string{"name", "stmt", "batch", "ops", "concurrency", "errors", "elapsed_ms", "ops_per_sec", "p50_us", "p95_us", "p99_us"}
+
+// writeResults writes results as "csv" or "json".
+func writeResults(w io.Writer, format string, results []benchResult) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if results == nil {
+			results = []benchResult{}
+		}
+		return enc.Encode(results)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		f := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+		for _, r := range results {
+			batch := ""
+			if r.Batch > 0 {
+				batch = strconv.Itoa(r.Batch)
+			}
+			record := []string{r.Name, r.Stmt, batch, strconv.Itoa(r.Ops), strconv.Itoa(r.Concurrency), strconv.Itoa(r.Errors),
+				f(r.ElapsedMS), f(r.OpsPerSec), f(r.P50US), f(r.P95US), f(r.P99US)}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown output format %q (want text, csv or json)", format)
+	}
+}
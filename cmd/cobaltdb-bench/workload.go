@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+)
+
+// workload is a key-value mix of point reads and point updates in the style
+// of YCSB.
+type workload struct {
+	name    string
+	readPct int
+}
+
+var namedWorkloads = map[string]int{
+	"ycsb-a": 50,  // update heavy
+	"ycsb-b": 95,  // read mostly
+	"ycsb-c": 100, // read only
+}
+
+// parseWorkload accepts a YCSB core workload name (ycsb-a, ycsb-b, ycsb-c)
+// or mix:<read percent>, such as mix:80.
+func parseWorkload(s string) (workload, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if pct, ok := namedWorkloads[name]; ok {
+		return workload{name: name, readPct: pct}, nil
+	}
+	if rest, ok := strings.CutPrefix(name, "mix:"); ok {
+		pct, err := strconv.Atoi(rest)
+		if err != nil || pct < 0 || pct > 100 {
+			return workload{}, fmt.Errorf("invalid workload %q: read percent must be 0-100", s)
+		}
+		return workload{name: name, readPct: pct}, nil
+	}
+	return workload{}, fmt.Errorf("unknown workload %q (want ycsb-a, ycsb-b, ycsb-c or mix:<read%%>)", s)
+}
+
+// runWorkload runs flagOps operations of wl against bench_ycsb, choosing
+// keys uniformly. Each worker draws its keys and operations from its own
+// generator seeded from -seed.
+func runWorkload(db *engine.DB, ctx context.Context, wl workload, rep *report, r *runner) {
+	rep.section(fmt.Sprintf("=== Workload %s (%d%% reads) ===", wl.name, wl.readPct))
+
+	rep.prepare(db, ctx, "bench_ycsb")
+	if wl.readPct < 100 {
+		invalidateTable(db, ctx, "bench_ycsb")
+	}
+
+	rngs := make([]*rand.Rand, max(flagConcurrency, 1))
+	for w := range rngs {
+		rngs[w] = rand.New(rand.NewSource(flagSeed + int64(w) + 1)) // #nosec G404 - benchmark data, not security sensitive.
+	}
+	rep.add(r.run(wl.name, flagOps, func(w, _ int) (string, error) {
+		rng := rngs[w]
+		id := rng.Intn(flagRows) + 1
+		if rng.Intn(100) < wl.readPct {
+			return "read", r.query(w, "SELECT * FROM bench_ycsb WHERE id = ?", id)
+		}
+		return "update", r.exec(w, "UPDATE bench_ycsb SET age = ? WHERE id = ?", rng.Intn(100), id)
+	})...)
+}
@@ -47,7 +47,10 @@ func (c *backupCommand) Run(args []string, path string, inMemory bool) {
 		fmt.Println("       backup delete <id>")
 		closeDBAndExit(db, 1)
 	}
-	handleBackupCommand(args, db)
+	if err := handleBackupCommand(args, db); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		closeDBAndExit(db, 1)
+	}
 }
 
 type metricsCommand struct{}
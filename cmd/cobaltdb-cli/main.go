@@ -15,7 +15,9 @@ import (
 	"time"
 
 	"github.com/chzyer/readline"
+	"github.com/cobaltdb/cobaltdb/pkg/client"
 	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
 )
 
 var (
@@ -38,6 +40,10 @@ type sessionState struct {
 	mode    string
 	timer   bool
 	headers bool
+	remote  *client.Conn // non-nil while .connect'ed to a cobaltdb-server
+	// readPassword reads a password without echoing it; nil when the session
+	// is not interactive.
+	readPassword func(prompt string) ([]byte, error)
 }
 
 func newSessionState() *sessionState {
@@ -156,6 +162,10 @@ Interactive Commands:
   .export <tbl> <csv>  Export table to CSV
   .dump [file.sql]       Export database as SQL
   .restore <file.sql>    Restore database from SQL
+  .read <file.sql>       Execute the statements in a SQL file
+  .connect <url> [user] [pass]  Connect to a cobaltdb-server (tcp://host:4200[/db]);
+                         prompts for the password when it is left out
+  .disconnect            Return to the local database
 `, version)
 }
 
@@ -219,6 +229,8 @@ func isQuery(sql string) bool {
 		return true
 	case len(sql) >= 7 && (sql[0] == 'E' || sql[0] == 'e') && strings.EqualFold(sql[:7], "EXPLAIN"):
 		return true
+	case len(sql) >= 6 && (sql[0] == 'P' || sql[0] == 'p') && strings.EqualFold(sql[:6], "PRAGMA"):
+		return true
 	}
 	return false
 }
@@ -231,12 +243,8 @@ func executeSQLInteractive(db *engine.DB, sql string, state *sessionState) bool
 	// same connection so transaction control and multi-statement scripts work
 	// instead of silently dropping everything after the first statement.
 	errored := false
-	for _, stmt := range splitSQLStatements(sql) {
-		stmt = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
-		if stmt == "" {
-			continue
-		}
-		if executeOneStatement(db, stmt, state) {
+	for _, stmt := range query.SplitScript(sql) {
+		if executeOneStatement(db, stmt.SQL, state) {
 			errored = true
 		}
 	}
@@ -276,15 +284,7 @@ func executeOneStatement(db *engine.DB, sql string, state *sessionState) bool {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			return true
 		}
-		if result.RowsAffected > 0 {
-			fmt.Printf("Rows affected: %d\n", result.RowsAffected)
-		}
-		if result.LastInsertID > 0 {
-			fmt.Printf("Last insert ID: %d\n", result.LastInsertID)
-		}
-		if result.RowsAffected == 0 && result.LastInsertID == 0 {
-			fmt.Println("OK")
-		}
+		printExecResult(result.RowsAffected, result.LastInsertID)
 	}
 
 	if state.timer {
@@ -293,7 +293,20 @@ func executeOneStatement(db *engine.DB, sql string, state *sessionState) bool {
 	return false
 }
 
-func printRowsWithMode(rows *engine.Rows, state *sessionState) error {
+// printExecResult reports the outcome of a statement that returns no rows.
+func printExecResult(rowsAffected, lastInsertID int64) {
+	if rowsAffected > 0 {
+		fmt.Printf("Rows affected: %d\n", rowsAffected)
+	}
+	if lastInsertID > 0 {
+		fmt.Printf("Last insert ID: %d\n", lastInsertID)
+	}
+	if rowsAffected == 0 && lastInsertID == 0 {
+		fmt.Println("OK")
+	}
+}
+
+func printRowsWithMode(rows rowSource, state *sessionState) error {
 	cols := rows.Columns()
 	if len(cols) == 0 {
 		return nil
@@ -312,7 +325,7 @@ func printRowsWithMode(rows *engine.Rows, state *sessionState) error {
 	return nil
 }
 
-func printRowsTable(rows *engine.Rows, cols []string, headers bool) {
+func printRowsTable(rows rowSource, cols []string, headers bool) {
 	colCount := len(cols)
 	widths := make([]int, colCount)
 	for i, col := range cols {
@@ -362,7 +375,7 @@ func printRowsTable(rows *engine.Rows, cols []string, headers bool) {
 	fmt.Printf("(%d rows)\n", count)
 }
 
-func printRowsCSV(rows *engine.Rows, cols []string, headers bool) error {
+func printRowsCSV(rows rowSource, cols []string, headers bool) error {
 	writer := csv.NewWriter(os.Stdout)
 	if headers {
 		if err := writer.Write(cols); err != nil {
@@ -411,7 +424,7 @@ func writeFormat(w io.Writer, format string, args ...interface{}) error {
 	return err
 }
 
-func printRowsJSON(rows *engine.Rows, cols []string) {
+func printRowsJSON(rows rowSource, cols []string) {
 	count := 0
 	colCount := len(cols)
 	var results []map[string]interface{}
@@ -436,7 +449,7 @@ func printRowsJSON(rows *engine.Rows, cols []string) {
 	fmt.Printf("(%d rows)\n", count)
 }
 
-func printRowsLine(rows *engine.Rows, cols []string) {
+func printRowsLine(rows rowSource, cols []string) {
 	count := 0
 	colCount := len(cols)
 	for rows.Next() {
@@ -504,7 +517,8 @@ func formatValue(v interface{}) string {
 }
 
 type cliCompleter struct {
-	db *engine.DB
+	db    *engine.DB
+	state *sessionState
 }
 
 var sqlKeywords = []string{
@@ -517,14 +531,15 @@ var sqlKeywords = []string{
 	"LIKE", "IS", "COUNT", "SUM", "AVG", "MAX", "MIN", "BEGIN", "COMMIT",
 	"ROLLBACK", "PRIMARY", "KEY", "FOREIGN", "REFERENCES", "UNIQUE",
 	"NOT NULL", "DEFAULT", "AUTOINCREMENT", "INTEGER", "TEXT", "REAL",
-	"BLOB", "BOOLEAN", "DATE", "DATETIME",
+	"BLOB", "BOOLEAN", "DATE", "DATETIME", "UUID",
 }
 
 var metaCommands = []string{
 	".tables", ".schema", ".quit", ".exit", ".help",
 	".mode", ".timer", ".headers",
 	".backup", ".metrics", ".status", ".vacuum", ".analyze",
-	".import", ".export", ".dump", ".restore",
+	".import", ".export", ".dump", ".restore", ".read",
+	".connect", ".disconnect",
 }
 
 func (c *cliCompleter) Do(line []rune, pos int) ([][]rune, int) {
@@ -567,8 +582,9 @@ func (c *cliCompleter) Do(line []rune, pos int) ([][]rune, int) {
 		return strToRunes(suggestions), len([]rune(words[len(words)-1]))
 	}
 
-	// Table name completion after FROM, INTO, JOIN, UPDATE, TABLE
-	if c.db != nil {
+	// Table name completion after FROM, INTO, JOIN, UPDATE, TABLE. Local
+	// table names are meaningless while connected to a remote server.
+	if c.db != nil && (c.state == nil || c.state.remote == nil) {
 		switch lastWord {
 		case "FROM", "INTO", "JOIN", "UPDATE", "TABLE", "DROP", "ALTER":
 			suggestions = append(suggestions, c.db.Tables()...)
@@ -593,103 +609,162 @@ func strToRunes(strs []string) [][]rune {
 	return out
 }
 
+// runInteractive runs the shell on one database handle for the whole
+// session, so in-memory data and transactions opened with BEGIN carry over
+// from one input to the next. The handle is closed when the session ends.
 func runInteractive(path string, inMemory bool) {
 	db := openDB(path, inMemory)
-	defer db.Close()
-
-	// Setup history directory
-	homeDir, _ := os.UserHomeDir()
-	historyFile := filepath.Join(homeDir, ".cobaltdb_history")
+	defer func() {
+		if err := db.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing database: %v\n", err)
+		}
+	}()
 
-	completer := &cliCompleter{db: db}
+	state := newSessionState()
+	defer state.disconnect()
+	completer := &cliCompleter{db: db, state: state}
 	l, err := readline.NewEx(&readline.Config{
 		Prompt:          "cobaltdb> ",
-		HistoryFile:     historyFile,
+		HistoryFile:     historyFilePath(),
 		AutoComplete:    completer,
 		InterruptPrompt: "^C",
 		EOFPrompt:       ".quit",
 		HistoryLimit:    10000,
+		// Whole statements are saved, not each line of them.
+		DisableAutoSaveHistory: true,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize readline: %v\n", err)
 		return
 	}
 	defer l.Close()
+	state.readPassword = l.ReadPassword
 
 	fmt.Println("CobaltDB Interactive CLI v2.0")
 	fmt.Println("Type '.help' for commands, '.quit' to exit")
-	fmt.Println("End SQL statements with ';' (multi-line supported)")
+	fmt.Println("End SQL statements with ';' (multi-line supported, Ctrl-C cancels)")
 	fmt.Println()
 
-	state := newSessionState()
-	var sqlBuffer strings.Builder
-	inMultiLine := false
-
+	var buf statementBuffer
 	for {
-		if inMultiLine {
-			l.SetPrompt("      ...> ")
+		if buf.pending() {
+			l.SetPrompt(state.continuationPrompt())
 		} else {
-			l.SetPrompt("cobaltdb> ")
+			l.SetPrompt(state.prompt())
 		}
 
 		line, err := l.Readline()
+		if errors.Is(err, readline.ErrInterrupt) && buf.pending() {
+			// Ctrl-C abandons the statement being typed.
+			buf.reset()
+			continue
+		}
 		if err != nil {
 			// EOF or interrupt
-			if sqlBuffer.Len() > 0 {
-				executeSQLInteractive(db, sqlBuffer.String(), state)
+			if buf.pending() {
+				runSessionSQL(db, buf.sql(), state)
 			}
 			fmt.Println("\nGoodbye!")
 			break
 		}
 
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+		// Meta commands only when not in multi-line mode
+		if !buf.pending() {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if strings.HasPrefix(line, ".") {
+				_ = l.SaveHistory(metaHistoryEntry(line))
+				if handleMetaCommand(line, db, state) {
+					fmt.Println("Goodbye!")
+					return
+				}
+				continue
+			}
 		}
 
-		// Meta commands only when not in multi-line mode
-		if !inMultiLine && strings.HasPrefix(line, ".") {
-			handleMetaCommand(line, db, state)
-			continue
+		if buf.add(line) {
+			_ = l.SaveHistory(buf.historyEntry())
+			runSessionSQL(db, buf.sql(), state)
+			buf.reset()
 		}
+	}
+}
+
+// historyFilePath returns the file interactive history is kept in, or ""
+// (no persistent history) when there is no home directory.
+func historyFilePath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil || homeDir == "" {
+		return ""
+	}
+	return filepath.Join(homeDir, ".cobaltdb_history")
+}
 
-		// Accumulate SQL
-		if sqlBuffer.Len() > 0 {
-			sqlBuffer.WriteString(" ")
+// statementBuffer collects interactive input lines until they form complete
+// statements. A ';' inside a string literal, comment or trigger body does
+// not end the input; the transaction commands BEGIN, COMMIT and ROLLBACK and
+// USE also run without one.
+type statementBuffer struct {
+	lines []string
+}
+
+// add appends a line and reports whether the buffer is ready to run.
+func (b *statementBuffer) add(line string) bool {
+	b.lines = append(b.lines, line)
+	text := strings.TrimSpace(b.sql())
+	if query.ScriptComplete(text) {
+		if len(query.SplitScript(text)) == 0 {
+			// Only whitespace or comments: nothing to run.
+			b.reset()
+			return false
 		}
-		sqlBuffer.WriteString(line)
+		return true
+	}
+	upper := strings.ToUpper(text)
+	return len(b.lines) == 1 && (strings.HasPrefix(upper, "BEGIN") || strings.HasPrefix(upper, "COMMIT") ||
+		strings.HasPrefix(upper, "ROLLBACK") || strings.HasPrefix(upper, "USE "))
+}
 
-		// Check if statement is complete (ends with semicolon)
-		trimmed := strings.TrimSpace(sqlBuffer.String())
-		if strings.HasSuffix(trimmed, ";") {
-			// Remove trailing semicolon and execute
-			sql := strings.TrimSuffix(trimmed, ";")
-			executeSQLInteractive(db, sql, state)
-			sqlBuffer.Reset()
-			inMultiLine = false
-		} else {
-			// Check for single-line commands that don't need semicolons
-			upper := strings.ToUpper(trimmed)
-			if strings.HasPrefix(upper, "BEGIN") || strings.HasPrefix(upper, "COMMIT") ||
-				strings.HasPrefix(upper, "ROLLBACK") || strings.HasPrefix(upper, "USE ") {
-				executeSQLInteractive(db, trimmed, state)
-				sqlBuffer.Reset()
-				inMultiLine = false
-			} else {
-				inMultiLine = true
-			}
+func (b *statementBuffer) pending() bool {
+	return len(b.lines) > 0
+}
+
+func (b *statementBuffer) sql() string {
+	return strings.Join(b.lines, "\n")
+}
+
+// historyEntry returns the buffered statement on one line for the history
+// file, without lines that hold only a comment.
+func (b *statementBuffer) historyEntry() string {
+	parts := make([]string, 0, len(b.lines))
+	for _, line := range b.lines {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			parts = append(parts, line)
 		}
 	}
+	return strings.Join(parts, " ")
+}
+
+func (b *statementBuffer) reset() {
+	b.lines = b.lines[:0]
 }
 
-func handleMetaCommand(line string, db *engine.DB, state *sessionState) {
+// handleMetaCommand runs a dot command and reports whether it ends the
+// session.
+func handleMetaCommand(line string, db *engine.DB, state *sessionState) (quit bool) {
 	parts := strings.Fields(line)
 	cmd := strings.ToLower(parts[0])
 
+	if handleRemoteMetaCommand(cmd, parts, state) {
+		return false
+	}
+
 	switch cmd {
 	case ".quit", ".exit":
-		fmt.Println("Goodbye!")
-		os.Exit(0)
+		return true
 
 	case ".help":
 		printHelp()
@@ -788,7 +863,9 @@ func handleMetaCommand(line string, db *engine.DB, state *sessionState) {
 			fmt.Println("       .backup delete <id>")
 			return
 		}
-		handleBackupCommand(parts[1:], db)
+		if err := handleBackupCommand(parts[1:], db); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 
 	case ".metrics":
 		data, err := db.GetMetrics()
@@ -847,14 +924,26 @@ func handleMetaCommand(line string, db *engine.DB, state *sessionState) {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		}
 
+	case ".read":
+		if len(parts) < 2 {
+			fmt.Println("Usage: .read <file.sql>")
+			return
+		}
+		if err := readScriptFile(db, parts[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+
 	default:
 		fmt.Printf("Unknown command: %s\nType '.help' for available commands.\n", cmd)
 	}
+	return false
 }
 
 // Subcommand handlers
 
-func handleBackupCommand(args []string, db *engine.DB) {
+// handleBackupCommand runs a backup subcommand on the session's database and
+// returns its error instead of exiting, so an interactive session survives it.
+func handleBackupCommand(args []string, db *engine.DB) error {
 	ctx := context.Background()
 	sub := strings.ToLower(args[0])
 
@@ -866,8 +955,7 @@ func handleBackupCommand(args []string, db *engine.DB) {
 		}
 		b, err := db.CreateBackup(ctx, backupType)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating backup: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("creating backup: %w", err)
 		}
 		fmt.Printf("Backup created: %s\n", b.ID)
 		fmt.Printf("Type: %s, Size: %d bytes\n", backupType, b.Size)
@@ -876,7 +964,7 @@ func handleBackupCommand(args []string, db *engine.DB) {
 		backups := db.ListBackups()
 		if len(backups) == 0 {
 			fmt.Println("No backups found.")
-			return
+			return nil
 		}
 		fmt.Printf("%-30s %-12s %-20s %10s\n", "ID", "Type", "Completed", "Size")
 		fmt.Println(strings.Repeat("-", 80))
@@ -890,33 +978,29 @@ func handleBackupCommand(args []string, db *engine.DB) {
 
 	case "restore":
 		if len(args) < 2 {
-			fmt.Println("Usage: backup restore <id>")
-			os.Exit(1)
+			return errors.New("usage: backup restore <id>")
 		}
 		id := args[1]
 		targetPath := db.Path() + ".restored"
 		mgr := db.GetBackupManager()
 		if err := mgr.Restore(ctx, id, targetPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error restoring backup: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("restoring backup: %w", err)
 		}
 		fmt.Printf("Backup restored to: %s\n", targetPath)
 
 	case "delete":
 		if len(args) < 2 {
-			fmt.Println("Usage: backup delete <id>")
-			os.Exit(1)
+			return errors.New("usage: backup delete <id>")
 		}
 		if err := db.DeleteBackup(args[1]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error deleting backup: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("deleting backup: %w", err)
 		}
 		fmt.Println("Backup deleted.")
 
 	default:
-		fmt.Printf("Unknown backup subcommand: %s\n", sub)
-		os.Exit(1)
+		return fmt.Errorf("unknown backup subcommand: %s", sub)
 	}
+	return nil
 }
 
 func printStatus(db *engine.DB) {
@@ -1395,6 +1479,15 @@ func dumpDatabase(db *engine.DB, filePath string) (err error) {
 			return fmt.Errorf("write procedure schema: %w", err)
 		}
 	}
+	if err := writeLine(out); err != nil {
+		return fmt.Errorf("write procedure separator: %w", err)
+	}
+
+	for _, ddl := range db.SequenceDDL() {
+		if err := writeLine(out, ddl); err != nil {
+			return fmt.Errorf("write sequence schema: %w", err)
+		}
+	}
 
 	if outputFile != nil {
 		fmt.Printf("Dumped %d tables to %s\n", len(tables), filePath)
@@ -1827,6 +1920,28 @@ func restoreDatabase(db *engine.DB, filePath string) error {
 	return nil
 }
 
+// readScriptFile runs the statements of a SQL file with DB.ExecScript.
+// Unlike .restore it adds no transaction of its own, so a failing statement
+// leaves the ones before it applied unless the file manages a transaction.
+func readScriptFile(db *engine.DB, filePath string) error {
+	filePath, err := cleanCLIFilePath(filePath)
+	if err != nil {
+		return fmt.Errorf("invalid script path: %w", err)
+	}
+	data, err := readCLIRestoreInputFile(filePath)
+	if err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+	result, err := db.ExecScript(context.Background(), string(data))
+	if err != nil {
+		// Do not leave a transaction opened by the script dangling.
+		db.AbortConnTransaction()
+		return fmt.Errorf("%s: %w", filePath, err)
+	}
+	fmt.Printf("Executed %s (%d rows affected)\n", filePath, result.RowsAffected)
+	return nil
+}
+
 func readCLIRestoreInputFile(path string) ([]byte, error) {
 	info, err := os.Lstat(path)
 	if err != nil {
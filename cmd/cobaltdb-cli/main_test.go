@@ -1215,6 +1215,119 @@ func TestRestoreDatabaseRejectsSymlink(t *testing.T) {
 	}
 }
 
+func TestInteractiveSessionKeepsState(t *testing.T) {
+	db, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	state := newSessionState()
+
+	// Each input is run on the session's handle, so a transaction spans inputs.
+	captureStdout(t, func() {
+		for _, sql := range []string{
+			"CREATE TABLE session_items (id INTEGER PRIMARY KEY)",
+			"BEGIN",
+			"INSERT INTO session_items VALUES (1)",
+			"ROLLBACK",
+			"BEGIN",
+			"INSERT INTO session_items VALUES (2)",
+			"COMMIT",
+		} {
+			if runSessionSQL(db, sql, state) {
+				t.Fatalf("%s failed", sql)
+			}
+		}
+	})
+	var count interface{}
+	if err := db.QueryRow(context.Background(), "SELECT COUNT(*) FROM session_items WHERE id = 2").Scan(&count); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if formatValue(count) != "1" || db.InConnTransaction() {
+		t.Fatalf("count = %v, open transaction = %v; want 1, false", count, db.InConnTransaction())
+	}
+
+	// Meta commands report errors and .quit instead of exiting the process.
+	if err := handleBackupCommand([]string{"bogus"}, db); err == nil {
+		t.Fatal("expected an error for an unknown backup subcommand")
+	}
+	var quit bool
+	captureStdout(t, func() { quit = handleMetaCommand(".tables", db, state) })
+	if quit {
+		t.Fatal(".tables ended the session")
+	}
+	if !handleMetaCommand(".quit", db, state) {
+		t.Fatal(".quit did not end the session")
+	}
+}
+
+func TestStatementBuffer(t *testing.T) {
+	var buf statementBuffer
+	for _, line := range []string{"SELECT 'a;", "b' AS v -- note;", "FROM t"} {
+		if buf.add(line) {
+			t.Fatalf("buffer ready after %q", line)
+		}
+	}
+	if !buf.add("  ;") {
+		t.Fatal("buffer not ready after the terminating ';'")
+	}
+	if got, want := buf.sql(), "SELECT 'a;\nb' AS v -- note;\nFROM t\n  ;"; got != want {
+		t.Fatalf("sql() = %q, want %q", got, want)
+	}
+	buf.reset()
+
+	if buf.add("-- a comment") || buf.pending() {
+		t.Fatal("comment-only input should be dropped")
+	}
+	if !buf.add("BEGIN") {
+		t.Fatal("BEGIN should run without ';'")
+	}
+	buf.reset()
+
+	buf.add("-- header")
+	buf.add("CREATE TRIGGER tr AFTER INSERT ON t BEGIN")
+	if buf.add("  DELETE FROM u;") {
+		t.Fatal("buffer ready inside a trigger body")
+	}
+	if !buf.add("END;") {
+		t.Fatal("buffer not ready after END;")
+	}
+	if got, want := buf.historyEntry(), "CREATE TRIGGER tr AFTER INSERT ON t BEGIN DELETE FROM u; END;"; got != want {
+		t.Fatalf("historyEntry() = %q, want %q", got, want)
+	}
+}
+
+func TestReadScriptFile(t *testing.T) {
+	db, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	scriptPath := filepath.Join(t.TempDir(), "script.sql")
+	sql := `
+CREATE TABLE script_notes (id INTEGER PRIMARY KEY, body TEXT);
+INSERT INTO script_notes VALUES (1, 'a; b');
+SELECT * FROM script_notes;
+INSERT INTO script_notes VALUES (1, 'duplicate');
+INSERT INTO script_notes VALUES (2, 'not reached');
+`
+	if err := os.WriteFile(scriptPath, []byte(sql), 0600); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	err = readScriptFile(db, scriptPath)
+	if err == nil || !strings.Contains(err.Error(), "statement 4 (line 5)") {
+		t.Fatalf("expected error for statement 4, got %v", err)
+	}
+	var count interface{}
+	if err := db.QueryRow(context.Background(), "SELECT COUNT(*) FROM script_notes").Scan(&count); err != nil {
+		t.Fatalf("count script rows: %v", err)
+	}
+	if formatValue(count) != "1" {
+		t.Fatalf("script rows = %v, want 1", count)
+	}
+}
+
 func TestReadCLIRestoreInputFileValidatesOpenedFile(t *testing.T) {
 	dir := t.TempDir()
 	restorePath := filepath.Join(dir, "restore.sql")
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/client"
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// rowSource is the subset of a result set the printers need. It is satisfied
+// by *engine.Rows for the local database and by remoteRows for .connect'ed
+// sessions.
+type rowSource interface {
+	Columns() []string
+	Next() bool
+	Scan(dest ...interface{}) error
+}
+
+// remoteRows iterates a fully materialized wire protocol result.
+type remoteRows struct {
+	result *client.Result
+	pos    int
+}
+
+func (r *remoteRows) Columns() []string { return r.result.Columns }
+
+func (r *remoteRows) Next() bool {
+	if r.pos >= len(r.result.Rows) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *remoteRows) Scan(dest ...interface{}) error {
+	if r.pos == 0 || r.pos > len(r.result.Rows) {
+		return fmt.Errorf("scan called without a current row")
+	}
+	row := r.result.Rows[r.pos-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("expected %d destination arguments, got %d", len(row), len(dest))
+	}
+	for i, d := range dest {
+		p, ok := d.(*interface{})
+		if !ok {
+			return fmt.Errorf("unsupported scan destination %T", d)
+		}
+		*p = row[i]
+	}
+	return nil
+}
+
+// prompt returns the primary prompt. Remote sessions show who and where the
+// user is connected, psql-style: '#' for admins and '>' for everyone else, so
+// a privileged session is obvious before a statement is typed.
+func (s *sessionState) prompt() string {
+	if s.remote == nil {
+		return "cobaltdb> "
+	}
+	return remotePrompt(s.remote.Username(), s.remote.Addr(), s.remote.Database(), s.remote.IsAdmin())
+}
+
+// continuationPrompt returns the prompt for subsequent lines of a statement,
+// padded to the width of the primary prompt.
+func (s *sessionState) continuationPrompt() string {
+	if s.remote == nil {
+		return "      ...> "
+	}
+	p := s.prompt()
+	if len(p) <= 5 {
+		return "...> "
+	}
+	return strings.Repeat(" ", len(p)-5) + "...> "
+}
+
+func remotePrompt(user, addr, database string, isAdmin bool) string {
+	var b strings.Builder
+	if user != "" {
+		b.WriteString(user)
+		b.WriteByte('@')
+	}
+	b.WriteString(addr)
+	if database != "" {
+		b.WriteByte('/')
+		b.WriteString(database)
+	}
+	if isAdmin {
+		b.WriteString("# ")
+	} else {
+		b.WriteString("> ")
+	}
+	return b.String()
+}
+
+// disconnect closes the remote connection, if any, and returns the session
+// to the local database.
+func (s *sessionState) disconnect() {
+	if s.remote == nil {
+		return
+	}
+	if err := s.remote.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error closing connection: %v\n", err)
+	}
+	s.remote = nil
+}
+
+// localOnlyCommands are meta commands that operate on the local engine
+// directly and have no wire protocol equivalent.
+var localOnlyCommands = map[string]bool{
+	".indexes": true, ".stats": true, ".status": true, ".backup": true,
+	".import": true, ".export": true, ".dump": true, ".restore": true,
+	".read": true,
+}
+
+// metaHistoryEntry returns the history entry for a meta command line. A
+// password typed on a .connect line is left out so it never reaches the
+// history file.
+func metaHistoryEntry(line string) string {
+	parts := strings.Fields(line)
+	if len(parts) > 3 && strings.EqualFold(parts[0], ".connect") {
+		return strings.Join(parts[:3], " ")
+	}
+	return line
+}
+
+// handleRemoteMetaCommand handles .connect/.disconnect and the meta commands
+// whose behavior changes while connected to a server. It reports whether the
+// command was consumed.
+func handleRemoteMetaCommand(cmd string, parts []string, state *sessionState) bool {
+	switch cmd {
+	case ".connect":
+		if len(parts) < 2 {
+			fmt.Println("Usage: .connect tcp://host[:port][/database] [user] [password]")
+			return true
+		}
+		opts := &client.Options{}
+		if len(parts) > 2 {
+			opts.Username = parts[2]
+		}
+		if len(parts) > 3 {
+			opts.Password = parts[3]
+		} else if opts.Username != "" && state.readPassword != nil {
+			password, err := state.readPassword("Password for " + opts.Username + ": ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return true
+			}
+			opts.Password = string(password)
+		}
+		conn, err := client.Dial(context.Background(), parts[1], opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return true
+		}
+		state.disconnect()
+		state.remote = conn
+		fmt.Printf("Connected to %s\n", conn.Addr())
+		return true
+
+	case ".disconnect":
+		if state.remote == nil {
+			fmt.Println("Not connected to a server.")
+			return true
+		}
+		addr := state.remote.Addr()
+		state.disconnect()
+		fmt.Printf("Disconnected from %s\n", addr)
+		return true
+	}
+
+	if state.remote == nil {
+		return false
+	}
+	switch cmd {
+	case ".tables":
+		executeRemoteSQL(state, "SHOW TABLES")
+		return true
+	case ".schema":
+		if len(parts) < 2 {
+			executeRemoteSQL(state, "SHOW TABLES")
+			return true
+		}
+		// The name is quoted and sent as one statement so it cannot end
+		// the SHOW early or smuggle in a second statement.
+		table, err := quoteSQLIdentifier(strings.Join(parts[1:], " "))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return true
+		}
+		runRemoteStatement(state, "SHOW CREATE TABLE "+table)
+		return true
+	}
+	if localOnlyCommands[cmd] {
+		fmt.Printf("%s is not available while connected to a server. Use .disconnect first.\n", cmd)
+		return true
+	}
+	return false
+}
+
+// runSessionSQL executes sql against the remote server when connected and
+// against the local database otherwise.
+func runSessionSQL(db *engine.DB, sql string, state *sessionState) bool {
+	if state.remote != nil {
+		return executeRemoteSQL(state, sql)
+	}
+	return executeSQLInteractive(db, sql, state)
+}
+
+// executeRemoteSQL sends each statement in sql to the connected server. The
+// server accepts one statement per request, so scripts are split client-side.
+func executeRemoteSQL(state *sessionState, sql string) bool {
+	errored := false
+	for _, stmt := range query.SplitScript(sql) {
+		if runRemoteStatement(state, stmt.SQL) {
+			errored = true
+		}
+	}
+	return errored
+}
+
+// runRemoteStatement sends a single statement to the connected server and
+// prints its result. It reports whether the statement failed.
+func runRemoteStatement(state *sessionState, stmt string) bool {
+	start := time.Now()
+	result, err := executeRemoteStatement(state.remote, stmt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true
+	}
+	if result.Columns != nil {
+		if err := printRowsWithMode(&remoteRows{result: result}, state); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return true
+		}
+	} else {
+		printExecResult(result.RowsAffected, result.LastInsertID)
+	}
+	if state.timer {
+		fmt.Printf("Query executed in %s\n", time.Since(start).Round(time.Microsecond))
+	}
+	return false
+}
+
+// executeRemoteStatement runs stmt and, when the server returns a paged
+// result, fetches the remaining pages so the printers see the whole result.
+func executeRemoteStatement(conn *client.Conn, stmt string) (*client.Result, error) {
+	ctx := context.Background()
+	result, err := conn.Execute(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	for result.HasMore {
+		page, err := conn.Fetch(ctx, result.CursorID)
+		if err != nil {
+			_ = conn.CloseCursor(ctx, result.CursorID)
+			return nil, err
+		}
+		result.Rows = append(result.Rows, page.Rows...)
+		result.HasMore = page.HasMore
+	}
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/server"
+)
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func TestRemotePrompt(t *testing.T) {
+	tests := []struct {
+		user, addr, database string
+		admin                bool
+		want                 string
+	}{
+		{"admin", "db1:4200", "sales", true, "admin@db1:4200/sales# "},
+		{"alice", "db1:4200", "", false, "alice@db1:4200> "},
+		{"", "127.0.0.1:4200", "", false, "127.0.0.1:4200> "},
+	}
+	for _, tt := range tests {
+		if got := remotePrompt(tt.user, tt.addr, tt.database, tt.admin); got != tt.want {
+			t.Errorf("remotePrompt(%q, %q, %q, %v) = %q, want %q", tt.user, tt.addr, tt.database, tt.admin, got, tt.want)
+		}
+	}
+
+	state := newSessionState()
+	if state.prompt() != "cobaltdb> " {
+		t.Errorf("local prompt = %q", state.prompt())
+	}
+}
+
+func TestConnectMetaCommand(t *testing.T) {
+	remoteDB, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer remoteDB.Close()
+	srv, err := server.New(server.NewProductionServer(remoteDB, server.DefaultProductionConfig()), &server.Config{
+		AuthEnabled:      true,
+		DefaultAdminUser: "admin",
+		DefaultAdminPass: "Str0ng!Pass#2026",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer srv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() { _ = srv.ListenOnListener(ln) }()
+
+	localDB := openDB("", true)
+	defer localDB.Close()
+	state := newSessionState()
+	defer state.disconnect()
+
+	out := captureStdout(t, func() {
+		handleMetaCommand(".connect tcp://"+ln.Addr().String()+"/main admin Str0ng!Pass#2026", localDB, state)
+	})
+	if state.remote == nil {
+		t.Fatalf(".connect did not connect: %s", out)
+	}
+	if want := "admin@" + ln.Addr().String() + "/main# "; state.prompt() != want {
+		t.Errorf("prompt = %q, want %q", state.prompt(), want)
+	}
+
+	out = captureStdout(t, func() {
+		runSessionSQL(localDB, "CREATE TABLE remote_t (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO remote_t VALUES (1, 'alice')", state)
+		runSessionSQL(localDB, "SELECT name FROM remote_t", state)
+	})
+	if !strings.Contains(out, "alice") {
+		t.Errorf("remote query output missing row: %s", out)
+	}
+	if len(localDB.Tables()) != 0 {
+		t.Errorf("statements leaked into the local database: %v", localDB.Tables())
+	}
+
+	out = captureStdout(t, func() {
+		handleMetaCommand(".schema remote_t", localDB, state)
+	})
+	if !strings.Contains(out, "CREATE TABLE") {
+		t.Errorf(".schema remote_t = %q", out)
+	}
+	captureStdout(t, func() { handleMetaCommand(".schema x;DROP/**/TABLE/**/remote_t", localDB, state) })
+	out = captureStdout(t, func() { runSessionSQL(localDB, "SELECT name FROM remote_t", state) })
+	if !strings.Contains(out, "alice") {
+		t.Errorf(".schema argument ran as SQL, remote_t is gone: %s", out)
+	}
+
+	out = captureStdout(t, func() { handleMetaCommand(".dump out.sql", localDB, state) })
+	if !strings.Contains(out, "not available") {
+		t.Errorf(".dump while connected = %q", out)
+	}
+
+	captureStdout(t, func() { handleMetaCommand(".disconnect", localDB, state) })
+	if state.remote != nil || state.prompt() != "cobaltdb> " {
+		t.Errorf("after .disconnect: remote=%v prompt=%q", state.remote, state.prompt())
+	}
+
+	// Leaving the password out prompts for it.
+	var asked string
+	state.readPassword = func(prompt string) ([]byte, error) {
+		asked = prompt
+		return []byte("Str0ng!Pass#2026"), nil
+	}
+	out = captureStdout(t, func() {
+		handleMetaCommand(".connect tcp://"+ln.Addr().String()+"/main admin", localDB, state)
+	})
+	if state.remote == nil || asked != "Password for admin: " {
+		t.Fatalf(".connect with a prompted password: asked %q, output %s", asked, out)
+	}
+}
+
+func TestMetaHistoryEntry(t *testing.T) {
+	tests := []struct{ line, want string }{
+		{".connect tcp://db1:4200/main admin s3cret", ".connect tcp://db1:4200/main admin"},
+		{".CONNECT tcp://db1:4200 admin s3cret", ".CONNECT tcp://db1:4200 admin"},
+		{".connect tcp://db1:4200 admin", ".connect tcp://db1:4200 admin"},
+		{".tables", ".tables"},
+	}
+	for _, tt := range tests {
+		if got := metaHistoryEntry(tt.line); got != tt.want {
+			t.Errorf("metaHistoryEntry(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cobaltdb/cobaltdb/pkg/cluster"
 	"github.com/cobaltdb/cobaltdb/pkg/engine"
 	cblogger "github.com/cobaltdb/cobaltdb/pkg/logger"
 	"github.com/cobaltdb/cobaltdb/pkg/protocol"
@@ -43,6 +44,7 @@ func main() {
 		enableMySQL = flag.Bool("mysql", true, "enable MySQL protocol")
 		inMemory    = flag.Bool("memory", false, "use in-memory storage")
 		cacheSize   = flag.Int("cache", 1024, "cache size in pages")
+		configFile  = flag.String("config", "", "engine configuration file (TOML); COBALTDB_<GROUP>_<SETTING> variables override it")
 		authEnabled = flag.Bool("auth", true, "enable authentication")
 		adminUser   = flag.String("admin-user", "admin", "default admin username")
 		adminPass   = flag.String("admin-pass", "", "admin password (generated securely if not set)")
@@ -61,6 +63,13 @@ func main() {
 		allowCleartextAuth   = flag.Bool("allow-cleartext-auth", false, "allow authenticated non-loopback listeners without encrypted transport")
 		shutdownTimeout      = flag.Duration("shutdown-timeout", 30*time.Second, "graceful shutdown timeout")
 		drainTimeout         = flag.Duration("drain-timeout", 10*time.Second, "connection drain timeout")
+
+		// High availability
+		clusterEnabled = flag.Bool("cluster", false, "run as a member of a Raft cluster; only the elected leader takes writes")
+		clusterID      = flag.String("cluster-id", "", "this member's ID, unique in the cluster")
+		clusterAddr    = flag.String("cluster-addr", "127.0.0.1:4300", "address for traffic between cluster members")
+		clusterPeers   = flag.String("cluster-peers", "", "the other members, as id=host:port pairs separated by commas")
+		clusterToken   = flag.String("cluster-token", "", "shared secret members prove they hold; member traffic is not encrypted, so the cluster network must be trusted")
 	)
 	flag.Parse()
 
@@ -94,6 +103,14 @@ func main() {
 		log.Fatalf("Invalid environment configuration: %v", err)
 	}
 
+	envString("COBALTDB_CLUSTER_ID", clusterID)
+	envString("COBALTDB_CLUSTER_ADDR", clusterAddr)
+	envString("COBALTDB_CLUSTER_PEERS", clusterPeers)
+	envString("COBALTDB_CLUSTER_TOKEN", clusterToken)
+	if err := envBool("COBALTDB_CLUSTER_ENABLED", clusterEnabled); err != nil {
+		log.Fatalf("Invalid environment configuration: %v", err)
+	}
+
 	// Override admin credentials from environment variables if set.
 	if envUser := os.Getenv("COBALTDB_ADMIN_USER"); envUser != "" {
 		*adminUser = envUser
@@ -128,13 +145,32 @@ func main() {
 	}
 	serverLogger := cblogger.New(cblogger.InfoLevel, os.Stderr)
 
-	// Open database
-	opts := &engine.Options{
-		CoreStorage: engine.CoreStorage{
-			CacheSize:  *cacheSize,
-			InMemory:   *inMemory,
-			WALEnabled: engine.BoolPtr(!*inMemory),
-		},
+	// Open database. -cache and -memory (or their environment variables)
+	// take precedence over the engine configuration.
+	envString("COBALTDB_CONFIG", configFile)
+	opts, err := engine.LoadConfig(*configFile)
+	if err != nil {
+		log.Fatalf("Invalid engine configuration: %v", err)
+	}
+	setFlags := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if _, ok := os.LookupEnv("COBALTDB_CACHE_SIZE"); ok || setFlags["cache"] {
+		opts.CoreStorage.CacheSize = *cacheSize
+	}
+	if _, ok := os.LookupEnv("COBALTDB_IN_MEMORY"); ok || setFlags["memory"] {
+		opts.CoreStorage.InMemory = *inMemory
+	}
+	*inMemory = opts.CoreStorage.InMemory
+	if *inMemory {
+		opts.CoreStorage.WALEnabled = engine.BoolPtr(false)
+	}
+
+	var clusterConfig *cluster.Config
+	if *clusterEnabled {
+		clusterConfig, err = newClusterConfig(*clusterID, *clusterAddr, *clusterPeers, *clusterToken, *address, *inMemory)
+		if err != nil {
+			log.Fatalf("Invalid cluster configuration: %v", err)
+		}
 	}
 
 	var dbPath string
@@ -147,6 +183,9 @@ func main() {
 		}
 		*dataDir = cleanDataDir
 		dbPath = filepath.Join(cleanDataDir, "cobalt.cb")
+		if clusterConfig != nil {
+			clusterConfig.Dir = filepath.Join(cleanDataDir, "cluster")
+		}
 	}
 
 	db, err := engine.Open(dbPath, opts)
@@ -216,6 +255,13 @@ func main() {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
+	// The cluster starts first and stops last, so the database never takes
+	// writes outside it.
+	if clusterConfig != nil {
+		clusterConfig.Logger = serverLogger
+		prodServer.Lifecycle.RegisterComponent(&ClusterComponent{db: db, config: *clusterConfig})
+	}
+
 	// Register wire server as a lifecycle component
 	wireComponent := &WireServerComponent{
 		server: srv,
@@ -254,6 +300,9 @@ func main() {
 	if *enableMySQL {
 		log.Printf("MySQL protocol listening on: %s", *mysqlAddr)
 	}
+	if clusterConfig != nil {
+		log.Printf("Cluster member %s listening on: %s", clusterConfig.ID, clusterConfig.Addr)
+	}
 	if *enableHealthServer {
 		log.Printf("Health server listening on: %s", *healthAddr)
 		log.Printf("Health endpoints: /health, /ready, /healthz")
@@ -349,6 +398,25 @@ func applyEnvOverrides(
 	return nil
 }
 
+// newClusterConfig validates the -cluster flags. Dir is left for the caller,
+// under the data directory.
+func newClusterConfig(id, addr, peers, token, clientAddr string, inMemory bool) (*cluster.Config, error) {
+	if inMemory {
+		return nil, fmt.Errorf("a cluster member needs on-disk storage; -memory cannot be used with -cluster")
+	}
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("-cluster-id is required")
+	}
+	peerMap, err := cluster.ParsePeers(peers)
+	if err != nil {
+		return nil, err
+	}
+	if len(peerMap) > 0 && token == "" && !isLoopbackListenAddress(addr) {
+		log.Printf("[SECURITY WARNING] Cluster traffic on %s is unauthenticated. Set -cluster-token.", addr)
+	}
+	return &cluster.Config{ID: strings.TrimSpace(id), Addr: addr, Peers: peerMap, Token: token, ClientAddr: clientAddr}, nil
+}
+
 func prepareDataDir(path string) (string, error) {
 	cleanPath := filepath.Clean(strings.TrimSpace(path))
 	if cleanPath == "" || cleanPath == "." {
@@ -604,3 +672,44 @@ func (m *MySQLServerComponent) Health() server.HealthStatus {
 		Message: "MySQL protocol server running",
 	}
 }
+
+// ClusterComponent runs the database as a member of a Raft cluster
+type ClusterComponent struct {
+	db     *engine.DB
+	config cluster.Config
+	node   *cluster.Node
+}
+
+func (c *ClusterComponent) Name() string {
+	return "cluster"
+}
+
+func (c *ClusterComponent) Start(ctx context.Context) error {
+	node, err := cluster.Start(c.db, c.config)
+	if err != nil {
+		return err
+	}
+	c.node = node
+	return nil
+}
+
+func (c *ClusterComponent) Stop(ctx context.Context) error {
+	if c.node == nil {
+		return nil
+	}
+	return c.node.Close()
+}
+
+func (c *ClusterComponent) Health() server.HealthStatus {
+	if c.node == nil {
+		return server.HealthStatus{Healthy: false, Message: "cluster member not started"}
+	}
+	status := c.node.Status()
+	if status.Leader == "" {
+		return server.HealthStatus{Healthy: false, Message: fmt.Sprintf("no cluster leader in term %d", status.Term)}
+	}
+	return server.HealthStatus{
+		Healthy: true,
+		Message: fmt.Sprintf("%s in term %d, leader %s, applied %d of %d", status.Role, status.Term, status.Leader, status.AppliedIndex, status.CommitIndex),
+	}
+}
@@ -579,6 +579,33 @@ func TestValidateAdminCredentials(t *testing.T) {
 	}
 }
 
+func TestNewClusterConfig(t *testing.T) {
+	cfg, err := newClusterConfig(" a ", "127.0.0.1:4300", "b=127.0.0.1:4301,c=127.0.0.1:4302", "secret", ":4200", false)
+	if err != nil {
+		t.Fatalf("newClusterConfig: %v", err)
+	}
+	if cfg.ID != "a" || cfg.ClientAddr != ":4200" || cfg.Token != "secret" || len(cfg.Peers) != 2 || cfg.Peers["c"] != "127.0.0.1:4302" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	for name, tt := range map[string]struct {
+		id, peers string
+		inMemory  bool
+		wantError string
+	}{
+		"RejectsInMemory": {id: "a", inMemory: true, wantError: "-memory"},
+		"RequiresID":      {id: " ", wantError: "-cluster-id"},
+		"RejectsBadPeers": {id: "a", peers: "b", wantError: "peer"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newClusterConfig(tt.id, "127.0.0.1:4300", tt.peers, "", ":4200", tt.inMemory)
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
 // TestDatabasePathConstruction tests database path construction
 func TestDatabasePathConstruction(t *testing.T) {
 	tests := []struct {
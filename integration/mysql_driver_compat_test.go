@@ -3,6 +3,7 @@ package integration
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -10,7 +11,7 @@ import (
 
 	"github.com/cobaltdb/cobaltdb/pkg/engine"
 	"github.com/cobaltdb/cobaltdb/pkg/protocol"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 func TestMySQLGoSQLDriverCompatibility(t *testing.T) {
@@ -223,3 +224,57 @@ func TestMySQLServerErrorRobustness(t *testing.T) {
 		t.Errorf("count = %d, want 1", n)
 	}
 }
+
+func TestMySQLErrorNumbers(t *testing.T) {
+	engineDB, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("engine.Open: %v", err)
+	}
+	defer engineDB.Close()
+
+	srv := protocol.NewMySQLServer(engineDB, "5.7.0-CobaltDB-Test")
+	if err := srv.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer srv.Close()
+
+	dsn := fmt.Sprintf("admin@tcp(%s)/?timeout=3s&readTimeout=3s&writeTimeout=3s", srv.Addr().String())
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, "CREATE TABLE coded (id INTEGER PRIMARY KEY, v INTEGER NOT NULL)"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO coded VALUES (1, 1)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	tests := []struct {
+		sql    string
+		number uint16
+		state  string
+	}{
+		{"INSERT INTO coded VALUES (1, 2)", 1062, "23000"},
+		{"INSERT INTO coded VALUES (2, NULL)", 1048, "23000"},
+		{"SELECT * FROM missing", 1146, "42S02"},
+		{"CREATE TABLE coded (id INTEGER)", 1050, "42S01"},
+		{"SELEC 1", 1064, "42000"},
+	}
+	for _, tt := range tests {
+		_, err := db.ExecContext(ctx, tt.sql)
+		var myErr *mysql.MySQLError
+		if !errors.As(err, &myErr) {
+			t.Errorf("%s: err = %v, want a MySQL error", tt.sql, err)
+			continue
+		}
+		if myErr.Number != tt.number || string(myErr.SQLState[:]) != tt.state {
+			t.Errorf("%s: error %d (%s), want %d (%s)", tt.sql, myErr.Number, myErr.SQLState[:], tt.number, tt.state)
+		}
+	}
+}
@@ -48,6 +48,9 @@ type Permission struct {
 	Actions  []string // SELECT, INSERT, UPDATE, DELETE, CREATE, DROP, etc.
 }
 
+// ActionUnmask is the permission to read a table's MASKED columns unmasked.
+const ActionUnmask = "UNMASK"
+
 // Session represents an authenticated session
 type Session struct {
 	Token     string
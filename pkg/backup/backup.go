@@ -47,9 +47,14 @@ type Config struct {
 	IncludeWAL bool
 	// Verify backup after creation
 	Verify bool
-	// Encrypt backups
+	// Encrypt backups with AES-256 (requires EncryptionKey or KeyFile)
 	Encrypt bool
-	// Encryption key file
+	// EncryptionKey is the backup key, kept separate from any database
+	// encryption key (at least 16 bytes). When a key is configured every
+	// backup file is signed with HMAC-SHA256 and Restore rejects files whose
+	// signature does not verify, or that are not signed at all.
+	EncryptionKey []byte
+	// KeyFile holds the backup key (raw or hex-encoded) when EncryptionKey is empty
 	KeyFile string
 }
 
@@ -162,6 +167,9 @@ type Backup struct {
 	// WALPathIsFile is true when the source database exposes WAL as a single
 	// file instead of a directory of segment files.
 	WALPathIsFile bool
+	// Signed and Encrypted record how the backup files were sealed.
+	Signed    bool
+	Encrypted bool
 }
 
 // Metadata stores backup metadata
@@ -187,6 +195,10 @@ type Manager struct {
 	mu           sync.Mutex
 	// Database interface
 	db Database
+	// Backup keys, derived on first use
+	keysOnce    sync.Once
+	sealKeys    *backupKeys
+	sealKeysErr error
 }
 
 // Database interface for backup operations
@@ -227,6 +239,7 @@ func cloneConfig(config *Config) *Config {
 		return nil
 	}
 	cloned := *config
+	cloned.EncryptionKey = append([]byte(nil), config.EncryptionKey...)
 	return &cloned
 }
 
@@ -429,12 +442,15 @@ func (m *Manager) copyDatabase(ctx context.Context, backup *Backup) error {
 		}
 	}()
 
-	var writer io.Writer = dstFile
+	writer, finishSeal, err := m.backupSink(dstFile, backup)
+	if err != nil {
+		return err
+	}
 	var compressor *gzip.Writer
 
 	// Add compression if enabled
 	if m.config.CompressionLevel > 0 {
-		compressor, err = gzip.NewWriterLevel(dstFile, m.config.CompressionLevel)
+		compressor, err = gzip.NewWriterLevel(writer, m.config.CompressionLevel)
 		if err != nil {
 			return fmt.Errorf("failed to create compressor: %w", err)
 		}
@@ -482,6 +498,9 @@ func (m *Manager) copyDatabase(ctx context.Context, backup *Backup) error {
 			return fmt.Errorf("failed to finalize compression: %w", err)
 		}
 	}
+	if err := finishSeal(); err != nil {
+		return err
+	}
 	if err := dstFile.Sync(); err != nil {
 		return fmt.Errorf("failed to sync backup file: %w", err)
 	}
@@ -560,10 +579,13 @@ func (m *Manager) copyDatabaseDelta(ctx context.Context, backup *Backup) error {
 		}
 	}()
 
-	var writer io.Writer = dstFile
+	writer, finishSeal, err := m.backupSink(dstFile, backup)
+	if err != nil {
+		return err
+	}
 	var compressor *gzip.Writer
 	if m.config.CompressionLevel > 0 {
-		compressor, err = gzip.NewWriterLevel(dstFile, m.config.CompressionLevel)
+		compressor, err = gzip.NewWriterLevel(writer, m.config.CompressionLevel)
 		if err != nil {
 			return fmt.Errorf("failed to create compressor: %w", err)
 		}
@@ -628,6 +650,9 @@ func (m *Manager) copyDatabaseDelta(ctx context.Context, backup *Backup) error {
 			return fmt.Errorf("failed to finalize compression: %w", err)
 		}
 	}
+	if err := finishSeal(); err != nil {
+		return err
+	}
 	if err := dstFile.Sync(); err != nil {
 		return fmt.Errorf("failed to sync backup file: %w", err)
 	}
@@ -688,7 +713,7 @@ func (m *Manager) copyWALFiles(ctx context.Context, backup *Backup) error {
 
 	if !walInfo.IsDir() {
 		const walFileName = "wal"
-		if err := copyFile(walPath, filepath.Join(walBackupDir, walFileName)); err != nil {
+		if err := m.sealFile(walPath, filepath.Join(walBackupDir, walFileName)); err != nil {
 			return fmt.Errorf("failed to copy WAL file: %w", err)
 		}
 		backup.WALFiles = append(backup.WALFiles, walFileName)
@@ -709,7 +734,7 @@ func (m *Manager) copyWALFiles(ctx context.Context, backup *Backup) error {
 		srcPath := filepath.Join(walPath, entry.Name())
 		dstPath := filepath.Join(walBackupDir, entry.Name())
 
-		if err := copyFile(srcPath, dstPath); err != nil {
+		if err := m.sealFile(srcPath, dstPath); err != nil {
 			return fmt.Errorf("failed to copy WAL file %s: %w", entry.Name(), err)
 		}
 
@@ -721,27 +746,11 @@ func (m *Manager) copyWALFiles(ctx context.Context, backup *Backup) error {
 
 // verifyBackup verifies the integrity of a backup
 func (m *Manager) verifyBackup(backup *Backup) error {
-	path, err := m.managedBackupFilePath(backup.Destination)
-	if err != nil {
-		return fmt.Errorf("invalid backup file: %w", err)
-	}
-	file, err := os.Open(path) // #nosec G304 - backup path is validated under BackupDir before opening.
+	reader, err := m.openBackupReader(backup)
 	if err != nil {
-		return fmt.Errorf("failed to open backup file: %w", err)
-	}
-	defer file.Close()
-
-	var reader io.Reader = file
-
-	// Handle compressed backups
-	if filepath.Ext(path) == ".gz" {
-		gzReader, err := gzip.NewReader(file)
-		if err != nil {
-			return fmt.Errorf("failed to create gzip reader: %w", err)
-		}
-		defer gzReader.Close()
-		reader = gzReader
+		return err
 	}
+	defer reader.Close()
 
 	// Calculate checksum
 	if backup.Size < 0 || backup.Size == math.MaxInt64 {
@@ -947,7 +956,7 @@ func (m *Manager) stageRestoreWAL(backup *Backup, targetPath string) (*stagedRes
 			staged.cleanup()
 			return nil, fmt.Errorf("invalid WAL file name: %w", err)
 		}
-		if err := copyFile(srcPath, tmpWALPath); err != nil {
+		if err := m.unsealFile(srcPath, tmpWALPath); err != nil {
 			staged.cleanup()
 			return nil, fmt.Errorf("failed to restore WAL file: %w", err)
 		}
@@ -970,7 +979,7 @@ func (m *Manager) stageRestoreWAL(backup *Backup, targetPath string) (*stagedRes
 		}
 		dstPath := filepath.Join(tmpWALPath, walFile)
 
-		if err := copyFile(srcPath, dstPath); err != nil {
+		if err := m.unsealFile(srcPath, dstPath); err != nil {
 			staged.cleanup()
 			return nil, fmt.Errorf("failed to restore WAL file %s: %w", walFile, err)
 		}
@@ -1121,12 +1130,17 @@ func (m *Manager) openBackupReader(backup *Backup) (io.ReadCloser, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open backup file: %w", err)
 	}
+	body, err := m.openSealed(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
 
 	if filepath.Ext(path) != ".gz" {
-		return file, nil
+		return &compoundReadCloser{Reader: body, closers: []io.Closer{file}}, nil
 	}
 
-	gzReader, err := gzip.NewReader(file)
+	gzReader, err := gzip.NewReader(body)
 	if err != nil {
 		_ = file.Close()
 		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
@@ -1926,6 +1940,14 @@ func generateBackupID() string {
 }
 
 func copyFile(srcPath, dstPath string) error {
+	return copyFileWith(srcPath, dstPath, func(dst io.Writer, src *os.File) error {
+		_, err := io.Copy(dst, src)
+		return err
+	})
+}
+
+// copyFileWith atomically replaces dstPath with the output of transform.
+func copyFileWith(srcPath, dstPath string, transform func(dst io.Writer, src *os.File) error) error {
 	srcPath, err := cleanBackupFilePath(srcPath)
 	if err != nil {
 		return err
@@ -1950,7 +1972,7 @@ func copyFile(srcPath, dstPath string) error {
 		}
 	}()
 
-	if _, err = io.Copy(dstFile, srcFile); err != nil {
+	if err := transform(dstFile, srcFile); err != nil {
 		return err
 	}
 	if err := dstFile.Sync(); err != nil {
@@ -0,0 +1,282 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// Sealed backup files wrap the (optionally compressed) payload in an
+// encrypt-then-MAC envelope:
+//
+//	magic "CBDBSEAL" | flags (1 byte) | IV (16 bytes) | body | HMAC-SHA256 (32 bytes)
+//
+// The body is AES-256-CTR ciphertext when sealFlagEncrypted is set and the
+// plain payload otherwise. The HMAC covers everything before it and is checked
+// over the whole file before any byte of the body is handed to a reader, so a
+// tampered or truncated backup is rejected before it is restored.
+const (
+	sealMagic         = "CBDBSEAL"
+	sealFlagEncrypted = 1 << 0
+	sealIVSize        = aes.BlockSize
+	sealHeaderSize    = len(sealMagic) + 1 + sealIVSize
+	sealMACSize       = sha256.Size
+	minBackupKeyBytes = 16
+)
+
+var (
+	// ErrBackupKeyRequired is returned when a backup must be encrypted or a
+	// sealed backup must be read but no backup key is configured.
+	ErrBackupKeyRequired = errors.New("backup key required: set Config.EncryptionKey or Config.KeyFile")
+	// ErrBackupTampered is returned when a sealed backup fails HMAC verification.
+	ErrBackupTampered = errors.New("backup signature mismatch: file is corrupt or has been tampered with")
+	// ErrBackupUnsigned is returned when a backup key is configured but a
+	// backup file in the restore chain is not sealed.
+	ErrBackupUnsigned = errors.New("backup is not signed")
+)
+
+// backupKeys holds the keys derived from the configured backup key.
+type backupKeys struct {
+	enc []byte
+	mac []byte
+}
+
+// keys returns the derived backup keys, or nil when no key is configured.
+func (m *Manager) keys() (*backupKeys, error) {
+	m.keysOnce.Do(func() {
+		m.sealKeys, m.sealKeysErr = loadBackupKeys(m.config)
+	})
+	return m.sealKeys, m.sealKeysErr
+}
+
+func loadBackupKeys(config *Config) (*backupKeys, error) {
+	secret := config.EncryptionKey
+	if len(secret) == 0 && config.KeyFile != "" {
+		path, err := cleanBackupFilePath(config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup key file: %w", err)
+		}
+		file, err := openRegularBackupFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open backup key file: %w", err)
+		}
+		raw, err := io.ReadAll(io.LimitReader(file, 4096))
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup key file: %w", err)
+		}
+		raw = bytes.TrimSpace(raw)
+		// Accept hex-encoded keys (as written by `openssl rand -hex 32`) as
+		// well as raw key bytes.
+		if decoded, err := hex.DecodeString(string(raw)); err == nil {
+			raw = decoded
+		}
+		secret = raw
+	}
+	if len(secret) == 0 {
+		if config.Encrypt {
+			return nil, ErrBackupKeyRequired
+		}
+		return nil, nil
+	}
+	if len(secret) < minBackupKeyBytes {
+		return nil, fmt.Errorf("backup key must be at least %d bytes, got %d", minBackupKeyBytes, len(secret))
+	}
+
+	enc, err := hkdf.Key(sha256.New, secret, nil, "cobaltdb backup encryption", 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive backup encryption key: %w", err)
+	}
+	mac, err := hkdf.Key(sha256.New, secret, nil, "cobaltdb backup signature", 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive backup signature key: %w", err)
+	}
+	return &backupKeys{enc: enc, mac: mac}, nil
+}
+
+// sealWriter encrypts (optionally) and MACs everything written to it. Close
+// appends the HMAC trailer but does not close the underlying writer.
+type sealWriter struct {
+	dst     io.Writer
+	stream  cipher.Stream
+	mac     hash.Hash
+	scratch []byte
+	closed  bool
+}
+
+func newSealWriter(dst io.Writer, keys *backupKeys, encrypt bool) (*sealWriter, error) {
+	header := make([]byte, sealHeaderSize)
+	copy(header, sealMagic)
+	iv := header[len(sealMagic)+1:]
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("failed to generate backup IV: %w", err)
+	}
+
+	w := &sealWriter{dst: dst, mac: hmac.New(sha256.New, keys.mac)}
+	if encrypt {
+		header[len(sealMagic)] = sealFlagEncrypted
+		block, err := aes.NewCipher(keys.enc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create backup cipher: %w", err)
+		}
+		w.stream = cipher.NewCTR(block, iv)
+	}
+	if _, err := writeFull(dst, header); err != nil {
+		return nil, fmt.Errorf("failed to write backup header: %w", err)
+	}
+	_, _ = w.mac.Write(header)
+	return w, nil
+}
+
+func (w *sealWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed backup writer")
+	}
+	out := p
+	if w.stream != nil {
+		if cap(w.scratch) < len(p) {
+			w.scratch = make([]byte, len(p))
+		}
+		out = w.scratch[:len(p)]
+		w.stream.XORKeyStream(out, p)
+	}
+	n, err := writeFull(w.dst, out)
+	_, _ = w.mac.Write(out[:n])
+	return n, err
+}
+
+func (w *sealWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if _, err := writeFull(w.dst, w.mac.Sum(nil)); err != nil {
+		return fmt.Errorf("failed to write backup signature: %w", err)
+	}
+	return nil
+}
+
+// backupSink returns the writer a backup payload should be written to. When
+// a backup key is configured the payload is sealed; finish must be called
+// after the last write to append the signature.
+func (m *Manager) backupSink(dst io.Writer, backup *Backup) (io.Writer, func() error, error) {
+	keys, err := m.keys()
+	if err != nil {
+		return nil, nil, err
+	}
+	if keys == nil {
+		return dst, func() error { return nil }, nil
+	}
+	w, err := newSealWriter(dst, keys, m.config.Encrypt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if backup != nil {
+		backup.Signed = true
+		backup.Encrypted = m.config.Encrypt
+	}
+	return w, w.Close, nil
+}
+
+// openSealed verifies and unwraps a sealed file, returning a reader over the
+// plain payload. Unsealed files are returned unchanged when no backup key is
+// configured and rejected with ErrBackupUnsigned otherwise.
+func (m *Manager) openSealed(file *os.File) (io.Reader, error) {
+	keys, err := m.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, sealHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read backup header: %w", err)
+	}
+	if n < len(sealMagic) || string(header[:len(sealMagic)]) != sealMagic {
+		if keys != nil {
+			return nil, fmt.Errorf("%w: %s", ErrBackupUnsigned, file.Name())
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind backup file: %w", err)
+		}
+		return file, nil
+	}
+	if keys == nil {
+		return nil, ErrBackupKeyRequired
+	}
+	if n < sealHeaderSize {
+		return nil, ErrBackupTampered
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup file: %w", err)
+	}
+	bodySize := info.Size() - int64(sealHeaderSize) - int64(sealMACSize)
+	if bodySize < 0 {
+		return nil, ErrBackupTampered
+	}
+
+	mac := hmac.New(sha256.New, keys.mac)
+	_, _ = mac.Write(header)
+	if _, err := io.CopyN(mac, file, bodySize); err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	trailer := make([]byte, sealMACSize)
+	if _, err := io.ReadFull(file, trailer); err != nil {
+		return nil, fmt.Errorf("failed to read backup signature: %w", err)
+	}
+	if !hmac.Equal(mac.Sum(nil), trailer) {
+		return nil, fmt.Errorf("%w: %s", ErrBackupTampered, file.Name())
+	}
+
+	if _, err := file.Seek(int64(sealHeaderSize), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind backup file: %w", err)
+	}
+	var body io.Reader = io.LimitReader(file, bodySize)
+	if header[len(sealMagic)]&sealFlagEncrypted != 0 {
+		block, err := aes.NewCipher(keys.enc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create backup cipher: %w", err)
+		}
+		body = cipher.StreamReader{S: cipher.NewCTR(block, header[len(sealMagic)+1:]), R: body}
+	}
+	return body, nil
+}
+
+// sealFile copies srcPath to dstPath, sealing it when a backup key is set.
+func (m *Manager) sealFile(srcPath, dstPath string) error {
+	return copyFileWith(srcPath, dstPath, func(dst io.Writer, src *os.File) error {
+		sink, finish, err := m.backupSink(dst, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(sink, src); err != nil {
+			return err
+		}
+		return finish()
+	})
+}
+
+// unsealFile copies a file written by sealFile back to its plain form,
+// verifying its signature first.
+func (m *Manager) unsealFile(srcPath, dstPath string) error {
+	return copyFileWith(srcPath, dstPath, func(dst io.Writer, src *os.File) error {
+		body, err := m.openSealed(src)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, body)
+		return err
+	})
+}
@@ -0,0 +1,174 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testBackupKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newSealedTestManager(t *testing.T, compression int, encrypt bool) (*Manager, string, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	dbFile := filepath.Join(tempDir, "test.db")
+	content := strings.Repeat("secret customer record ", 200)
+	if err := os.WriteFile(dbFile, []byte(content), 0600); err != nil {
+		t.Fatalf("create db: %v", err)
+	}
+	walDir := filepath.Join(tempDir, "wal")
+	if err := os.Mkdir(walDir, 0750); err != nil {
+		t.Fatalf("create wal dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(walDir, "000001.wal"), []byte("secret wal record"), 0600); err != nil {
+		t.Fatalf("create wal: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.BackupDir = filepath.Join(tempDir, "backups")
+	config.CompressionLevel = compression
+	config.Encrypt = encrypt
+	config.EncryptionKey = testBackupKey
+	return NewManager(config, &MockDatabase{dbPath: dbFile, walPath: walDir, lsn: 1}), tempDir, content
+}
+
+func TestEncryptedBackupRoundTrip(t *testing.T) {
+	for _, compression := range []int{0, 6} {
+		mgr, tempDir, content := newSealedTestManager(t, compression, true)
+		ctx := context.Background()
+
+		backup, err := mgr.CreateBackup(ctx, TypeFull)
+		if err != nil {
+			t.Fatalf("CreateBackup: %v", err)
+		}
+		if !backup.Signed || !backup.Encrypted {
+			t.Fatalf("backup not marked sealed: signed=%v encrypted=%v", backup.Signed, backup.Encrypted)
+		}
+
+		raw, err := os.ReadFile(backup.Destination)
+		if err != nil {
+			t.Fatalf("read backup: %v", err)
+		}
+		if !bytes.HasPrefix(raw, []byte(sealMagic)) {
+			t.Fatal("backup file is missing the seal header")
+		}
+		if bytes.Contains(raw, []byte("secret")) {
+			t.Fatal("encrypted backup leaks plaintext")
+		}
+		walRaw, err := os.ReadFile(filepath.Join(mgr.config.BackupDir, backup.ID+"_wal", "000001.wal"))
+		if err != nil {
+			t.Fatalf("read WAL backup: %v", err)
+		}
+		if bytes.Contains(walRaw, []byte("secret")) {
+			t.Fatal("encrypted WAL backup leaks plaintext")
+		}
+
+		target := filepath.Join(tempDir, "restored", "test.db")
+		if err := mgr.Restore(ctx, backup.ID, target); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		restored, err := os.ReadFile(target)
+		if err != nil {
+			t.Fatalf("read restored: %v", err)
+		}
+		if string(restored) != content {
+			t.Fatal("restored content does not match the original")
+		}
+		restoredWAL, err := os.ReadFile(filepath.Join(target+".wal", "000001.wal"))
+		if err != nil {
+			t.Fatalf("read restored WAL: %v", err)
+		}
+		if string(restoredWAL) != "secret wal record" {
+			t.Fatalf("restored WAL = %q", restoredWAL)
+		}
+	}
+}
+
+func TestSignedBackupRejectsTampering(t *testing.T) {
+	mgr, tempDir, _ := newSealedTestManager(t, 0, false)
+	ctx := context.Background()
+
+	backup, err := mgr.CreateBackup(ctx, TypeFull)
+	if err != nil {
+		t.Fatalf("CreateBackup: %v", err)
+	}
+	if !backup.Signed || backup.Encrypted {
+		t.Fatalf("signed-only backup: signed=%v encrypted=%v", backup.Signed, backup.Encrypted)
+	}
+
+	raw, err := os.ReadFile(backup.Destination)
+	if err != nil {
+		t.Fatalf("read backup: %v", err)
+	}
+	raw[sealHeaderSize+10] ^= 0xff
+	if err := os.WriteFile(backup.Destination, raw, 0600); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+
+	target := filepath.Join(tempDir, "restored.db")
+	err = mgr.Restore(ctx, backup.ID, target)
+	if !errors.Is(err, ErrBackupTampered) {
+		t.Fatalf("Restore = %v, want ErrBackupTampered", err)
+	}
+	if _, statErr := os.Stat(target); !os.IsNotExist(statErr) {
+		t.Fatalf("tampered backup was restored: %v", statErr)
+	}
+}
+
+func TestSealedBackupKeyChecks(t *testing.T) {
+	mgr, tempDir, _ := newSealedTestManager(t, 0, true)
+	ctx := context.Background()
+	backup, err := mgr.CreateBackup(ctx, TypeFull)
+	if err != nil {
+		t.Fatalf("CreateBackup: %v", err)
+	}
+	target := filepath.Join(tempDir, "restored.db")
+
+	wrongKey := cloneConfig(mgr.config)
+	wrongKey.EncryptionKey = []byte("ffffffffffffffffffffffffffffffff")
+	if err := NewManager(wrongKey, mgr.db).Restore(ctx, backup.ID, target); !errors.Is(err, ErrBackupTampered) {
+		t.Fatalf("Restore with wrong key = %v, want ErrBackupTampered", err)
+	}
+
+	noKey := cloneConfig(mgr.config)
+	noKey.Encrypt = false
+	noKey.EncryptionKey = nil
+	if err := NewManager(noKey, mgr.db).Restore(ctx, backup.ID, target); !errors.Is(err, ErrBackupKeyRequired) {
+		t.Fatalf("Restore without key = %v, want ErrBackupKeyRequired", err)
+	}
+
+	// A plain backup cannot stand in for a signed one once a key is set.
+	plain, err := NewManager(noKey, mgr.db).CreateBackup(ctx, TypeFull)
+	if err != nil {
+		t.Fatalf("CreateBackup without key: %v", err)
+	}
+	if err := NewManager(mgr.config, mgr.db).Restore(ctx, plain.ID, target); !errors.Is(err, ErrBackupUnsigned) {
+		t.Fatalf("Restore unsigned = %v, want ErrBackupUnsigned", err)
+	}
+
+	encryptNoKey := cloneConfig(noKey)
+	encryptNoKey.Encrypt = true
+	if _, err := NewManager(encryptNoKey, mgr.db).CreateBackup(ctx, TypeFull); !errors.Is(err, ErrBackupKeyRequired) {
+		t.Fatalf("CreateBackup encrypted without key = %v, want ErrBackupKeyRequired", err)
+	}
+}
+
+func TestBackupKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "backup.key")
+	if err := os.WriteFile(keyFile, []byte("00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff\n"), 0600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	keys, err := loadBackupKeys(&Config{KeyFile: keyFile, Encrypt: true})
+	if err != nil || keys == nil {
+		t.Fatalf("loadBackupKeys = %v, %v", keys, err)
+	}
+
+	if _, err := loadBackupKeys(&Config{EncryptionKey: []byte("short")}); err == nil {
+		t.Fatal("expected short key to be rejected")
+	}
+}
@@ -0,0 +1,431 @@
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// AppendStore is an append-only TreeStore for tables with very high insert
+// rates, such as event and audit tables. Stored records are never rewritten
+// in place: Put and Delete append a record to a segment of chained pages, so
+// a flush writes only the pages past the previous flush instead of
+// reserializing the whole tree. Compaction rewrites the live records into a
+// fresh segment once superseded records outnumber them, and on demand.
+//
+// Root page layout (after the page header):
+//
+//	[0:4]   magic "APND"
+//	[4:8]   first segment page ID (0 = empty segment)
+//	[8:12]  last segment page ID
+//	[12:16] bytes used in the last segment page
+//	[16:24] records in the segment, live or superseded
+//
+// Segment pages are linked through the page header's RightPtr. The records
+// form one byte stream across them: op (1 byte), key length (uint16), value
+// length (uint32), key, value.
+type AppendStore struct {
+	mu         sync.RWMutex
+	pool       *storage.BufferPool
+	rootPageID uint32
+	data       map[string][]byte // live records
+	pending    []byte            // encoded records not yet flushed
+	segment    []uint32          // segment page IDs, in order
+	tailUsed   int               // bytes used in the last segment page
+	stored     int               // records in the segment, live or superseded
+	pendingN   int               // records in pending
+}
+
+const (
+	appendOpPut    = 1
+	appendOpDelete = 2
+
+	appendRecordHeader = 1 + 2 + 4
+	appendPageSpace    = storage.PageSize - storage.PageHeaderSize
+
+	// appendCompactMinRecords keeps small stores from compacting on every
+	// flush.
+	appendCompactMinRecords = 1024
+)
+
+var appendMagic = []byte("APND")
+
+// NewAppendStore creates an empty append store.
+func NewAppendStore(pool *storage.BufferPool) (*AppendStore, error) {
+	root, err := pool.NewPage(storage.PageTypeLeaf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create root page: %w", err)
+	}
+	pool.Unpin(root)
+
+	s := &AppendStore{
+		pool:       pool,
+		rootPageID: root.ID(),
+		data:       make(map[string][]byte),
+	}
+	if err := s.writeRootLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenAppendStore opens an append store and replays its segment.
+func OpenAppendStore(pool *storage.BufferPool, rootPageID uint32) (*AppendStore, error) {
+	s := &AppendStore{
+		pool:       pool,
+		rootPageID: rootPageID,
+		data:       make(map[string][]byte),
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load append store at root %d: %w", rootPageID, err)
+	}
+	return s, nil
+}
+
+func (s *AppendStore) load() error {
+	root, err := s.pool.GetPage(s.rootPageID)
+	if err != nil {
+		return err
+	}
+	meta := root.Data()[storage.PageHeaderSize:]
+	if string(meta[0:4]) != string(appendMagic) {
+		s.pool.Unpin(root)
+		return fmt.Errorf("%w: root page %d is not an append store", storage.ErrPageCorrupted, s.rootPageID)
+	}
+	first := binary.LittleEndian.Uint32(meta[4:8])
+	last := binary.LittleEndian.Uint32(meta[8:12])
+	s.tailUsed = int(binary.LittleEndian.Uint32(meta[12:16]))
+	s.stored = int(binary.LittleEndian.Uint64(meta[16:24]))
+	s.pool.Unpin(root)
+	if first == 0 {
+		return nil
+	}
+	if s.tailUsed > appendPageSpace {
+		return fmt.Errorf("%w: tail usage %d exceeds page space", storage.ErrPageCorrupted, s.tailUsed)
+	}
+
+	var stream []byte
+	limit := int(s.pool.AllocatedPageCount())
+	for pageID := first; ; {
+		if len(s.segment) >= limit {
+			return fmt.Errorf("%w: segment chain does not end", storage.ErrPageCorrupted)
+		}
+		page, err := s.pool.GetPage(pageID)
+		if err != nil {
+			return err
+		}
+		data := page.Data()
+		next := binary.LittleEndian.Uint32(data[12:16])
+		s.segment = append(s.segment, pageID)
+		if pageID == last {
+			stream = append(stream, data[storage.PageHeaderSize:storage.PageHeaderSize+s.tailUsed]...)
+			s.pool.Unpin(page)
+			break
+		}
+		stream = append(stream, data[storage.PageHeaderSize:]...)
+		s.pool.Unpin(page)
+		if next == 0 {
+			return fmt.Errorf("%w: segment chain ends before page %d", storage.ErrPageCorrupted, last)
+		}
+		pageID = next
+	}
+
+	records := 0
+	for off := 0; off < len(stream); records++ {
+		if off+appendRecordHeader > len(stream) {
+			return fmt.Errorf("%w: truncated record header at offset %d", storage.ErrPageCorrupted, off)
+		}
+		op := stream[off]
+		keyLen := int(binary.LittleEndian.Uint16(stream[off+1 : off+3]))
+		valLen := int(binary.LittleEndian.Uint32(stream[off+3 : off+7]))
+		off += appendRecordHeader
+		if keyLen == 0 || off+keyLen+valLen > len(stream) {
+			return fmt.Errorf("%w: truncated record at offset %d", storage.ErrPageCorrupted, off)
+		}
+		key := string(stream[off : off+keyLen])
+		off += keyLen
+		switch op {
+		case appendOpPut:
+			s.data[key] = slices.Clone(stream[off : off+valLen])
+		case appendOpDelete:
+			delete(s.data, key)
+		default:
+			return fmt.Errorf("%w: unknown record op %d", storage.ErrPageCorrupted, op)
+		}
+		off += valLen
+	}
+	if records != s.stored {
+		return fmt.Errorf("%w: segment holds %d records, root says %d", storage.ErrPageCorrupted, records, s.stored)
+	}
+	return nil
+}
+
+// RootPageID returns the root page ID of the store.
+func (s *AppendStore) RootPageID() uint32 {
+	return s.rootPageID
+}
+
+// PageIDs returns the IDs of the pages holding the store: the root page
+// followed by its segment pages.
+func (s *AppendStore) PageIDs() []uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]uint32, 0, 1+len(s.segment))
+	ids = append(ids, s.rootPageID)
+	return append(ids, s.segment...)
+}
+
+// Get retrieves a value by key.
+func (s *AppendStore) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrInvalidKey
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.data[string(key)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return cloneBytes(val), nil
+}
+
+// Put appends a record setting key to value.
+func (s *AppendStore) Put(key, value []byte) error {
+	if err := validateAppendRecord(key, value); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.putLocked(key, value)
+	return nil
+}
+
+// PutBatch appends a record for each key-value pair.
+func (s *AppendStore) PutBatch(keys [][]byte, values [][]byte) error {
+	if len(keys) != len(values) {
+		return errors.New("key and value count mismatch")
+	}
+	for i, key := range keys {
+		if err := validateAppendRecord(key, values[i]); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, key := range keys {
+		s.putLocked(key, values[i])
+	}
+	return nil
+}
+
+// Delete appends a record removing key.
+func (s *AppendStore) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrInvalidKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[string(key)]; !ok {
+		return ErrKeyNotFound
+	}
+	s.deleteLocked(key)
+	return nil
+}
+
+// DeleteBatch appends a record removing each key that exists.
+func (s *AppendStore) DeleteBatch(keys [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := s.data[string(key)]; ok {
+			s.deleteLocked(key)
+		}
+	}
+	return nil
+}
+
+func validateAppendRecord(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrInvalidKey
+	}
+	if len(key) > MaxKeyLength {
+		return ErrKeyTooLong
+	}
+	if len(value) == 0 {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+func (s *AppendStore) putLocked(key, value []byte) {
+	s.data[string(key)] = slices.Clone(value)
+	s.pending = appendRecord(s.pending, appendOpPut, key, value)
+	s.pendingN++
+}
+
+func (s *AppendStore) deleteLocked(key []byte) {
+	delete(s.data, string(key))
+	s.pending = appendRecord(s.pending, appendOpDelete, key, nil)
+	s.pendingN++
+}
+
+func appendRecord(buf []byte, op byte, key, value []byte) []byte {
+	var hdr [appendRecordHeader]byte
+	hdr[0] = op
+	binary.LittleEndian.PutUint16(hdr[1:3], uint16(len(key)))
+	binary.LittleEndian.PutUint32(hdr[3:7], uint32(len(value)))
+	buf = append(buf, hdr[:]...)
+	buf = append(buf, key...)
+	return append(buf, value...)
+}
+
+// Scan returns an iterator over the live records between startKey and
+// endKey, inclusive, in key order.
+func (s *AppendStore) Scan(startKey, endKey []byte) (TreeIterator, error) {
+	s.mu.RLock()
+	pairs := make([]kvPair, 0, len(s.data))
+	for k, v := range s.data {
+		if startKey != nil && k < string(startKey) {
+			continue
+		}
+		if endKey != nil && k > string(endKey) {
+			continue
+		}
+		pairs = append(pairs, kvPair{k, cloneBytes(v)})
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(pairs, func(a, b kvPair) int {
+		return strings.Compare(a.key, b.key)
+	})
+	return &Iterator{pairs: pairs}, nil
+}
+
+// Size returns the number of live records.
+func (s *AppendStore) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data)
+}
+
+// Flush appends the records written since the last flush to the segment,
+// compacting it first when superseded records outnumber live ones.
+func (s *AppendStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pendingN == 0 {
+		return nil
+	}
+	total := s.stored + s.pendingN
+	if total >= appendCompactMinRecords && 2*len(s.data) < total {
+		return s.compactLocked()
+	}
+	if err := s.appendLocked(s.pending); err != nil {
+		return err
+	}
+	s.stored = total
+	s.pending = nil
+	s.pendingN = 0
+	return s.writeRootLocked()
+}
+
+// Compact rewrites the live records into a fresh segment and frees the
+// pages of the old one.
+func (s *AppendStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compactLocked()
+}
+
+func (s *AppendStore) compactLocked() error {
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var buf []byte
+	for _, k := range keys {
+		buf = appendRecord(buf, appendOpPut, []byte(k), s.data[k])
+	}
+
+	old := s.segment
+	s.segment = nil
+	s.tailUsed = 0
+	if err := s.appendLocked(buf); err != nil {
+		return err
+	}
+	s.stored = len(keys)
+	s.pending = nil
+	s.pendingN = 0
+	if err := s.writeRootLocked(); err != nil {
+		return err
+	}
+	// The root page written above no longer reaches the old segment.
+	for _, pageID := range old {
+		s.pool.FreePage(pageID)
+	}
+	return nil
+}
+
+// appendLocked writes buf after the last byte of the segment, linking new
+// pages onto the chain as the tail fills up.
+func (s *AppendStore) appendLocked(buf []byte) error {
+	for len(buf) > 0 {
+		if len(s.segment) == 0 || s.tailUsed == appendPageSpace {
+			page, err := s.pool.NewPage(storage.PageTypeOverflow)
+			if err != nil {
+				return fmt.Errorf("failed to allocate segment page: %w", err)
+			}
+			pageID := page.ID()
+			s.pool.Unpin(page)
+			if len(s.segment) > 0 {
+				if err := s.writePage(s.segment[len(s.segment)-1], func(data []byte) {
+					binary.LittleEndian.PutUint32(data[12:16], pageID)
+				}); err != nil {
+					return err
+				}
+			}
+			s.segment = append(s.segment, pageID)
+			s.tailUsed = 0
+		}
+		n := 0
+		if err := s.writePage(s.segment[len(s.segment)-1], func(data []byte) {
+			n = copy(data[storage.PageHeaderSize+s.tailUsed:], buf)
+		}); err != nil {
+			return err
+		}
+		s.tailUsed += n
+		buf = buf[n:]
+	}
+	return nil
+}
+
+func (s *AppendStore) writeRootLocked() error {
+	return s.writePage(s.rootPageID, func(data []byte) {
+		meta := data[storage.PageHeaderSize:]
+		clear(meta[:24])
+		copy(meta[0:4], appendMagic)
+		if len(s.segment) > 0 {
+			binary.LittleEndian.PutUint32(meta[4:8], s.segment[0])
+			binary.LittleEndian.PutUint32(meta[8:12], s.segment[len(s.segment)-1])
+		}
+		binary.LittleEndian.PutUint32(meta[12:16], uint32(s.tailUsed))
+		binary.LittleEndian.PutUint64(meta[16:24], uint64(s.stored))
+	})
+}
+
+func (s *AppendStore) writePage(pageID uint32, fn func([]byte)) error {
+	page, err := s.pool.GetPage(pageID)
+	if err != nil {
+		return err
+	}
+	page.WithDataWrite(fn)
+	page.SetDirty(true)
+	s.pool.Unpin(page)
+	return nil
+}
@@ -0,0 +1,131 @@
+package btree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+func appendKey(i int) []byte { return []byte(fmt.Sprintf("k%05d", i)) }
+
+func TestAppendStoreReopen(t *testing.T) {
+	pool := storage.NewBufferPool(100, storage.NewMemory())
+	defer pool.Close()
+
+	s, err := NewAppendStore(pool)
+	if err != nil {
+		t.Fatalf("NewAppendStore: %v", err)
+	}
+	value := strings.Repeat("v", 300)
+	for i := 0; i < 100; i++ {
+		if err := s.Put(appendKey(i), []byte(value)); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	// A second flush appends to the segment written by the first.
+	if err := s.Put(appendKey(5), []byte("updated")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Delete(appendKey(7)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(appendKey(7)); err != ErrKeyNotFound {
+		t.Fatalf("second Delete = %v, want ErrKeyNotFound", err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	reopened, err := OpenAppendStore(pool, s.RootPageID())
+	if err != nil {
+		t.Fatalf("OpenAppendStore: %v", err)
+	}
+	if got := reopened.Size(); got != 99 {
+		t.Fatalf("Size = %d, want 99", got)
+	}
+	if got, err := reopened.Get(appendKey(5)); err != nil || string(got) != "updated" {
+		t.Fatalf("Get(k5) = %q, %v; want updated", got, err)
+	}
+	if _, err := reopened.Get(appendKey(7)); err != ErrKeyNotFound {
+		t.Fatalf("Get(k7) = %v, want ErrKeyNotFound", err)
+	}
+
+	iter, err := reopened.Scan(appendKey(10), appendKey(19))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	defer iter.Close()
+	for i := 10; i <= 19; i++ {
+		key, _, err := iter.Next()
+		if err != nil || string(key) != string(appendKey(i)) {
+			t.Fatalf("Next = %q, %v; want %q", key, err, appendKey(i))
+		}
+	}
+	if iter.HasNext() {
+		t.Fatal("Scan returned keys past the end key")
+	}
+}
+
+func TestAppendStoreCompactionFreesSegment(t *testing.T) {
+	pool := storage.NewBufferPool(100, storage.NewMemory())
+	defer pool.Close()
+
+	s, err := NewAppendStore(pool)
+	if err != nil {
+		t.Fatalf("NewAppendStore: %v", err)
+	}
+	value := []byte(strings.Repeat("v", 100))
+	for round := 0; round < 4; round++ {
+		for i := 0; i < 500; i++ {
+			if err := s.Put(appendKey(i), value); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+		}
+		if round < 2 {
+			if err := s.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+		}
+	}
+	before := len(s.PageIDs())
+
+	// 1000 stored and 1000 pending records with 500 live: this flush compacts.
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if after := len(s.PageIDs()); after >= before {
+		t.Fatalf("pages after compaction = %d, want fewer than %d", after, before)
+	}
+	if free := pool.PageUsage().FreePages; free == 0 {
+		t.Fatal("compaction did not free the old segment")
+	}
+
+	reopened, err := OpenAppendStore(pool, s.RootPageID())
+	if err != nil {
+		t.Fatalf("OpenAppendStore: %v", err)
+	}
+	if got := reopened.Size(); got != 500 {
+		t.Fatalf("Size after compaction = %d, want 500", got)
+	}
+}
+
+func TestOpenAppendStoreRejectsBTreeRoot(t *testing.T) {
+	pool := storage.NewBufferPool(100, storage.NewMemory())
+	defer pool.Close()
+
+	tree, err := NewBTree(pool)
+	if err != nil {
+		t.Fatalf("NewBTree: %v", err)
+	}
+	if err := tree.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if _, err := OpenAppendStore(pool, tree.RootPageID()); err == nil {
+		t.Fatal("OpenAppendStore accepted a B+Tree root page")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"sync/atomic"
 
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
 )
 
 var (
@@ -248,90 +249,27 @@ func (t *BTree) LoadError() error {
 
 // loadFromPages loads serialized key-value pairs from root + overflow pages into shards
 func (t *BTree) loadFromPages() error {
-	root, err := t.pool.GetPage(t.rootPageID)
+	stream, err := t.readStream()
 	if err != nil {
-		return fmt.Errorf("failed to load root page %d: %w", t.rootPageID, err)
+		return err
 	}
-	defer t.pool.Unpin(root)
-
-	pageData := root.Data()[storage.PageHeaderSize:]
-	if len(pageData) < 8 {
-		return nil
-	}
-
-	totalCount := binary.LittleEndian.Uint32(pageData[0:4])
-	overflowCount := binary.LittleEndian.Uint32(pageData[4:8])
-
-	if totalCount == 0 {
+	if stream.count == 0 {
 		return nil
 	}
-
-	headerSize := 8 + 4*int(overflowCount)
-	if headerSize > len(pageData) {
-		return fmt.Errorf("corrupt root page %d: header size %d exceeds page data %d", t.rootPageID, headerSize, len(pageData))
-	}
-
-	t.overflowPages = make([]uint32, overflowCount)
-	for i := uint32(0); i < overflowCount; i++ {
-		off := 8 + 4*int(i)
-		t.overflowPages[i] = binary.LittleEndian.Uint32(pageData[off : off+4])
-	}
-
-	var allData []byte
-	allData = append(allData, pageData[headerSize:]...)
-	for _, pgID := range t.overflowPages {
-		pg, err := t.pool.GetPage(pgID)
-		if err != nil {
-			return fmt.Errorf("failed to load overflow page %d: %w", pgID, err)
-		}
-		allData = append(allData, pg.Data()[storage.PageHeaderSize:]...)
-		t.pool.Unpin(pg)
-	}
-
-	const minSerializedEntrySize = 2 + 4 // keyLen uint16 + valLen uint32
-	maxPossibleEntries := len(allData) / minSerializedEntrySize
-	if int64(totalCount) > int64(maxPossibleEntries) {
-		return fmt.Errorf("corrupt root page %d: entry count %d exceeds maximum possible entries %d for %d bytes", t.rootPageID, totalCount, maxPossibleEntries, len(allData))
-	}
+	t.overflowPages = stream.overflowIDs
 
 	// Pre-size shard maps to eliminate growth allocations during load.
-	perShard := int(totalCount)/numShards + 1
+	perShard := int(stream.count)/numShards + 1
 	for i := range t.shards {
 		t.shards[i].data = make(map[string][]byte, perShard)
 	}
 
-	offset := 0
 	loadedCount := int64(0)
-	for i := uint32(0); i < totalCount; i++ {
-		if offset+2 > len(allData) {
-			return fmt.Errorf("corrupt root page %d: truncated key length for entry %d", t.rootPageID, i)
-		}
-		keyLen := int(binary.LittleEndian.Uint16(allData[offset : offset+2]))
-		offset += 2
-		if keyLen == 0 {
-			return fmt.Errorf("corrupt root page %d: empty key for entry %d", t.rootPageID, i)
-		}
-		if offset+keyLen > len(allData) {
-			return fmt.Errorf("corrupt root page %d: key length %d for entry %d exceeds remaining data %d", t.rootPageID, keyLen, i, len(allData)-offset)
-		}
-		key := make([]byte, keyLen)
-		copy(key, allData[offset:offset+keyLen])
-		offset += keyLen
-		if offset+4 > len(allData) {
-			return fmt.Errorf("corrupt root page %d: truncated value length for entry %d", t.rootPageID, i)
-		}
-		valLen := int(binary.LittleEndian.Uint32(allData[offset : offset+4]))
-		offset += 4
-		if offset+valLen > len(allData) {
-			return fmt.Errorf("corrupt root page %d: value length %d for entry %d exceeds remaining data %d", t.rootPageID, valLen, i, len(allData)-offset)
-		}
-		val := make([]byte, valLen)
-		copy(val, allData[offset:offset+valLen])
-		offset += valLen
-
-		keyStr := string(key)
-		t.shards[shardIndex(keyStr)].data[keyStr] = val
+	if _, err := stream.entries(t.rootPageID, func(key string, val []byte) {
+		t.shards[shardIndex(key)].data[key] = val
 		loadedCount++
+	}); err != nil {
+		return err
 	}
 	atomic.StoreInt64(&t.keyCount, loadedCount)
 	return nil
@@ -340,81 +278,14 @@ func (t *BTree) loadFromPages() error {
 // readKVFromPages reads all key-value pairs from disk pages without modifying tree state.
 func (t *BTree) readKVFromPages() (map[string][]byte, error) {
 	result := make(map[string][]byte)
-
-	root, err := t.pool.GetPage(t.rootPageID)
+	stream, err := t.readStream()
 	if err != nil {
 		return result, err
 	}
-	defer t.pool.Unpin(root)
-
-	pageData := root.Data()[storage.PageHeaderSize:]
-	if len(pageData) < 8 {
-		return result, fmt.Errorf("corrupt root page %d: header too short", t.rootPageID)
-	}
-
-	totalCount := binary.LittleEndian.Uint32(pageData[0:4])
-	overflowCount := binary.LittleEndian.Uint32(pageData[4:8])
-
-	if totalCount == 0 {
-		return result, nil
-	}
-
-	headerSize := 8 + 4*int(overflowCount)
-	if headerSize > len(pageData) {
-		return result, fmt.Errorf("corrupt root page %d: header size %d exceeds page data %d", t.rootPageID, headerSize, len(pageData))
-	}
-
-	overflowIDs := make([]uint32, overflowCount)
-	for i := uint32(0); i < overflowCount; i++ {
-		off := 8 + 4*int(i)
-		overflowIDs[i] = binary.LittleEndian.Uint32(pageData[off : off+4])
-	}
-
-	var allData []byte
-	allData = append(allData, pageData[headerSize:]...)
-	for _, pgID := range overflowIDs {
-		pg, err := t.pool.GetPage(pgID)
-		if err != nil {
-			return result, fmt.Errorf("failed to load overflow page %d: %w", pgID, err)
-		}
-		allData = append(allData, pg.Data()[storage.PageHeaderSize:]...)
-		t.pool.Unpin(pg)
-	}
-
-	const minSerializedEntrySize = 2 + 4 // keyLen uint16 + valLen uint32
-	maxPossibleEntries := len(allData) / minSerializedEntrySize
-	if int64(totalCount) > int64(maxPossibleEntries) {
-		return result, fmt.Errorf("corrupt root page %d: entry count %d exceeds maximum possible entries %d for %d bytes", t.rootPageID, totalCount, maxPossibleEntries, len(allData))
-	}
-
-	offset := 0
-	for i := uint32(0); i < totalCount; i++ {
-		if offset+2 > len(allData) {
-			return result, fmt.Errorf("corrupt root page %d: truncated key length for entry %d", t.rootPageID, i)
-		}
-		keyLen := int(binary.LittleEndian.Uint16(allData[offset : offset+2]))
-		offset += 2
-		if keyLen == 0 {
-			return result, fmt.Errorf("corrupt root page %d: empty key for entry %d", t.rootPageID, i)
-		}
-		if offset+keyLen > len(allData) {
-			return result, fmt.Errorf("corrupt root page %d: key length %d for entry %d exceeds remaining data %d", t.rootPageID, keyLen, i, len(allData)-offset)
-		}
-		key := string(allData[offset : offset+keyLen])
-		offset += keyLen
-		if offset+4 > len(allData) {
-			return result, fmt.Errorf("corrupt root page %d: truncated value length for entry %d", t.rootPageID, i)
-		}
-		valLen := int(binary.LittleEndian.Uint32(allData[offset : offset+4]))
-		offset += 4
-		if offset+valLen > len(allData) {
-			return result, fmt.Errorf("corrupt root page %d: value length %d for entry %d exceeds remaining data %d", t.rootPageID, valLen, i, len(allData)-offset)
-		}
-		val := make([]byte, valLen)
-		copy(val, allData[offset:offset+valLen])
-		offset += valLen
-
+	if _, err := stream.entries(t.rootPageID, func(key string, val []byte) {
 		result[key] = val
+	}); err != nil {
+		return result, err
 	}
 	return result, nil
 }
@@ -424,6 +295,16 @@ func (t *BTree) RootPageID() uint32 {
 	return t.rootPageID
 }
 
+// PageIDs returns the IDs of the pages holding the tree: the root page
+// followed by its overflow pages.
+func (t *BTree) PageIDs() []uint32 {
+	t.flushMu.Lock()
+	defer t.flushMu.Unlock()
+	ids := make([]uint32, 0, 1+len(t.overflowPages))
+	ids = append(ids, t.rootPageID)
+	return append(ids, t.overflowPages...)
+}
+
 // SetMemoryLimit sets the memory limit for the BTree (0 = unlimited)
 func (t *BTree) SetMemoryLimit(limit int64) {
 	atomic.StoreInt64(&t.memoryLimit, limit)
@@ -641,7 +522,13 @@ func (t *BTree) putStringInternal(keyCopy string, value []byte) error {
 // PutBatch inserts or updates multiple key-value pairs.  It groups keys by
 // shard, acquires shard locks in deterministic order to avoid deadlock, and
 // applies writes atomically with respect to memory-limit failures.
-func (t *BTree) PutBatch(keys [][]byte, values [][]byte) error {
+func (t *BTree) PutBatch(keys [][]byte, values [][]byte) (err error) {
+	span := tracing.StartBound("btree.PutBatch")
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.Int64("cobaltdb.keys", int64(len(keys))))
+	}
+	defer func() { tracing.End(span, err) }()
+
 	if len(keys) != len(values) {
 		return errors.New("key and value count mismatch")
 	}
@@ -759,7 +646,13 @@ func (t *BTree) PutBatch(keys [][]byte, values [][]byte) error {
 }
 
 // DeleteBatch removes multiple keys in a single operation.
-func (t *BTree) DeleteBatch(keys [][]byte) error {
+func (t *BTree) DeleteBatch(keys [][]byte) (err error) {
+	span := tracing.StartBound("btree.DeleteBatch")
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.Int64("cobaltdb.keys", int64(len(keys))))
+	}
+	defer func() { tracing.End(span, err) }()
+
 	if len(keys) == 0 {
 		return nil
 	}
@@ -898,10 +791,18 @@ func (t *BTree) flushInternal() error {
 	t.flushMu.Lock()
 	defer t.flushMu.Unlock()
 
-	// Re-check dirty after acquiring flushMu.
-	if atomic.LoadInt32(&t.dirty) == 0 {
+	// Clear dirty before taking the snapshot: a write the snapshot misses
+	// marks the tree dirty again, so the next flush writes it. Clearing it
+	// after the pages are written would drop such a write.
+	if !atomic.CompareAndSwapInt32(&t.dirty, 1, 0) {
 		return nil
 	}
+	flushed := false
+	defer func() {
+		if !flushed {
+			atomic.StoreInt32(&t.dirty, 1)
+		}
+	}()
 
 	// Snapshot each shard individually so writers to other shards can proceed
 	// while we serialize the flushed data.
@@ -925,34 +826,9 @@ func (t *BTree) flushInternal() error {
 		t.shards[i].mu.RUnlock()
 	}
 
-	t.flushBuf.Reset()
-	var count uint32
-	var lenBuf [4]byte
-	var err error
-
-	if !hasEvicted {
-		count, err = checkedUint32Len(len(dataSnap), "entry count")
-		if err != nil {
-			return err
-		}
-		for k, v := range dataSnap {
-			keyLen, err := checkedUint16Len(len(k), "key length")
-			if err != nil {
-				return err
-			}
-			valueLen, err := checkedUint32Len(len(v), "value length")
-			if err != nil {
-				return err
-			}
-			binary.LittleEndian.PutUint16(lenBuf[:2], keyLen)
-			t.flushBuf.Write(lenBuf[:2])
-			t.flushBuf.WriteString(k)
-			binary.LittleEndian.PutUint32(lenBuf[:4], valueLen)
-			t.flushBuf.Write(lenBuf[:4])
-			t.flushBuf.Write(v)
-		}
-	} else {
-		toSerialize := make(map[string][]byte, memCount)
+	toSerialize := dataSnap
+	if hasEvicted {
+		toSerialize = make(map[string][]byte, memCount)
 		diskData, err := t.readKVFromPages()
 		if err != nil {
 			return err
@@ -965,29 +841,41 @@ func (t *BTree) flushInternal() error {
 		for k, v := range dataSnap {
 			toSerialize[k] = v
 		}
-		count, err = checkedUint32Len(len(toSerialize), "entry count")
-		if err != nil {
+	}
+	count, err := checkedUint32Len(len(toSerialize), "entry count")
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(toSerialize))
+	for k := range toSerialize {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	// In key order, consecutive entries share key prefixes and a flush that
+	// changes a few keys leaves most pages as they were.
+	t.flushBuf.Reset()
+	prev := ""
+	for _, k := range keys {
+		if err := appendEntry(t.flushBuf, prev, k, toSerialize[k]); err != nil {
 			return err
 		}
-		for k, v := range toSerialize {
-			keyLen, err := checkedUint16Len(len(k), "key length")
-			if err != nil {
-				return err
-			}
-			valueLen, err := checkedUint32Len(len(v), "value length")
-			if err != nil {
-				return err
-			}
-			binary.LittleEndian.PutUint16(lenBuf[:2], keyLen)
-			t.flushBuf.Write(lenBuf[:2])
-			t.flushBuf.WriteString(k)
-			binary.LittleEndian.PutUint32(lenBuf[:4], valueLen)
-			t.flushBuf.Write(lenBuf[:4])
-			t.flushBuf.Write(v)
-		}
+		prev = k
 	}
 
-	kvData := t.flushBuf.Bytes()
+	if err := t.writeSerialized(count, t.flushBuf.Bytes()); err != nil {
+		return err
+	}
+	flushed = true
+	return nil
+}
+
+// writeSerialized stores count serialized entries, kvData, in the root page
+// and as many overflow pages as they need, rewriting only the pages whose
+// content changed since the last write. Callers hold flushMu.
+func (t *BTree) writeSerialized(count uint32, kvData []byte) error {
+	var lenBuf [4]byte
+	var err error
 
 	// Calculate overflow pages
 	overflowCount := uint32(0)
@@ -1027,6 +915,8 @@ func (t *BTree) flushInternal() error {
 	}
 
 	for len(t.overflowPages) > int(overflowCount) {
+		// The root page rewritten below no longer lists the page.
+		t.pool.FreePage(t.overflowPages[len(t.overflowPages)-1])
 		t.overflowPages = t.overflowPages[:len(t.overflowPages)-1]
 	}
 	for len(t.overflowPages) < int(overflowCount) {
@@ -1054,7 +944,7 @@ func (t *BTree) flushInternal() error {
 	h.Reset()
 	binary.LittleEndian.PutUint32(lenBuf[:4], count)
 	_, _ = h.Write(lenBuf[:4])
-	binary.LittleEndian.PutUint32(lenBuf[:4], overflowCount)
+	binary.LittleEndian.PutUint32(lenBuf[:4], overflowCount|rootFlagPrefixKeys)
 	_, _ = h.Write(lenBuf[:4])
 	for _, pgID := range t.overflowPages {
 		binary.LittleEndian.PutUint32(lenBuf[:4], pgID)
@@ -1093,19 +983,12 @@ func (t *BTree) flushInternal() error {
 
 	canSkip := len(t.lastPageHashes) == numPages
 
-	// Write root page if changed.
+	// Collect the bodies of the pages that changed.
+	var writes []pageWrite
 	if !canSkip || newPageHashes[0] != t.lastPageHashes[0] {
-		root, err := t.pool.GetPage(t.rootPageID)
-		if err != nil {
-			return err
-		}
-		root.WithDataWrite(func(data []byte) {
-			rootBuf := data[storage.PageHeaderSize:]
-			for i := range rootBuf {
-				rootBuf[i] = 0
-			}
+		writes = append(writes, pageWrite{id: t.rootPageID, fill: func(rootBuf []byte) {
 			binary.LittleEndian.PutUint32(rootBuf[0:4], count)
-			binary.LittleEndian.PutUint32(rootBuf[4:8], overflowCount)
+			binary.LittleEndian.PutUint32(rootBuf[4:8], overflowCount|rootFlagPrefixKeys)
 			for i, pgID := range t.overflowPages {
 				off := 8 + 4*i
 				if off+4 > len(rootBuf) {
@@ -1116,12 +999,8 @@ func (t *BTree) flushInternal() error {
 			if rootDataWriteLen > 0 {
 				copy(rootBuf[rootHeaderSize:], kvData[:rootDataWriteLen])
 			}
-		})
-		root.SetDirty(true)
-		t.pool.Unpin(root)
+		}})
 	}
-
-	// Write overflow pages if changed.
 	dataWritten = rootDataWriteLen
 	for i, pgID := range t.overflowPages {
 		writeLen := usablePageSize
@@ -1130,27 +1009,80 @@ func (t *BTree) flushInternal() error {
 			writeLen = remaining
 		}
 		if !canSkip || newPageHashes[i+1] != t.lastPageHashes[i+1] {
-			pg, err := t.pool.GetPage(pgID)
+			chunk := kvData[dataWritten : dataWritten+writeLen]
+			writes = append(writes, pageWrite{id: pgID, fill: func(pgBuf []byte) {
+				copy(pgBuf, chunk)
+			}})
+		}
+		dataWritten += writeLen
+	}
+	if err := t.writePages(writes); err != nil {
+		return err
+	}
+
+	t.lastPageHashes = newPageHashes
+	return nil
+}
+
+// pageWrite is a page flushInternal rewrites: fill writes the page's new
+// body into a zeroed buffer.
+type pageWrite struct {
+	id   uint32
+	fill func(body []byte)
+}
+
+// writePages rewrites the bodies of the pages in writes. When the pool has a
+// WAL, the new pages are first logged as one group of page images, so a
+// crash part way through cannot leave the root listing overflow pages that
+// hold another flush's data; recovery restores every page of the tree as of
+// the same flush.
+func (t *BTree) writePages(writes []pageWrite) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	if !t.pool.LogsPageImages() {
+		for _, w := range writes {
+			pg, err := t.pool.GetPage(w.id)
 			if err != nil {
-				return fmt.Errorf("failed to get overflow page %d: %w", pgID, err)
+				return fmt.Errorf("failed to get page %d: %w", w.id, err)
 			}
 			pg.WithDataWrite(func(data []byte) {
-				pgBuf := data[storage.PageHeaderSize:]
-				for j := range pgBuf {
-					pgBuf[j] = 0
-				}
-				if writeLen > 0 {
-					copy(pgBuf, kvData[dataWritten:dataWritten+writeLen])
-				}
+				body := data[storage.PageHeaderSize:]
+				clear(body)
+				w.fill(body)
 			})
 			pg.SetDirty(true)
 			t.pool.Unpin(pg)
 		}
-		dataWritten += writeLen
+		return nil
 	}
 
-	t.lastPageHashes = newPageHashes
-	atomic.StoreInt32(&t.dirty, 0)
+	images := make([]storage.PageImage, len(writes))
+	for i, w := range writes {
+		pg, err := t.pool.GetPage(w.id)
+		if err != nil {
+			return fmt.Errorf("failed to get page %d: %w", w.id, err)
+		}
+		image := make([]byte, storage.PageSize)
+		pg.WithDataWrite(func(data []byte) {
+			copy(image[:storage.PageHeaderSize], data)
+		})
+		t.pool.Unpin(pg)
+		w.fill(image[storage.PageHeaderSize:])
+		images[i] = storage.PageImage{PageID: w.id, Data: image}
+	}
+	if err := t.pool.LogPageImages(images); err != nil {
+		return fmt.Errorf("failed to log page images: %w", err)
+	}
+	for _, img := range images {
+		pg, err := t.pool.GetPage(img.PageID)
+		if err != nil {
+			return fmt.Errorf("failed to get page %d: %w", img.PageID, err)
+		}
+		pg.SetData(img.Data)
+		pg.SetDirty(true)
+		t.pool.Unpin(pg)
+	}
 	return nil
 }
 
@@ -1211,14 +1143,12 @@ type Iterator struct {
 }
 
 // Scan returns an iterator for range scanning
-func (t *BTree) Scan(startKey, endKey []byte) (TreeIterator, error) {
-	// Pre-size slice to avoid reallocations; t.Size() is an upper bound.
-	approxSize := t.Size()
-	pairs := make([]kvPair, 0, approxSize)
+func (t *BTree) Scan(startKey, endKey []byte) (_ TreeIterator, err error) {
+	span := tracing.StartBound("btree.Scan")
+	defer func() { tracing.End(span, err) }()
 
-	var seen map[string]bool
-	var evicted map[string]bool
-	hasEvicted := false
+	// Pre-size slice to avoid reallocations; t.Size() is an upper bound.
+	pairs := make([]kvPair, 0, t.Size())
 
 	startStr := ""
 	if startKey != nil {
@@ -1228,72 +1158,67 @@ func (t *BTree) Scan(startKey, endKey []byte) (TreeIterator, error) {
 	if endKey != nil {
 		endStr = string(endKey)
 	}
+	if err := t.eachEntry(func(k string, v []byte) {
+		if startKey != nil && strings.Compare(k, startStr) < 0 {
+			return
+		}
+		if endKey != nil && strings.Compare(k, endStr) > 0 {
+			return
+		}
+		pairs = append(pairs, kvPair{k, cloneBytes(v)})
+	}); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(pairs, func(a, b kvPair) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	return &Iterator{
+		tree:      t,
+		pairs:     pairs,
+		idx:       0,
+		endKey:    endStr,
+		hasEndKey: endKey != nil,
+		done:      false,
+	}, nil
+}
+
+// eachEntry calls fn with every entry of the tree, in no particular order,
+// including those evicted to disk. Entries in memory are passed while their
+// shard is read-locked, so fn must copy a value it keeps.
+func (t *BTree) eachEntry(fn func(k string, v []byte)) error {
+	// A shard never holds a key both in memory and as evicted, so the evicted
+	// keys seen while it is locked are exactly the ones to read from disk.
+	var evicted map[string]bool
 
 	// Snapshot each shard individually so writers to other shards can proceed.
 	for i := 0; i < numShards; i++ {
 		t.shards[i].mu.RLock()
 		for k, v := range t.shards[i].data {
-			if startKey != nil && strings.Compare(k, startStr) < 0 {
-				continue
-			}
-			if endKey != nil && strings.Compare(k, endStr) > 0 {
-				continue
-			}
-			pairs = append(pairs, kvPair{k, cloneBytes(v)})
-			if hasEvicted {
-				seen[k] = true
-			}
+			fn(k, v)
 		}
-		if len(t.shards[i].evicted) > 0 {
-			if !hasEvicted {
-				hasEvicted = true
-				seen = make(map[string]bool, approxSize)
-				// Mark all previously collected keys
-				for j := 0; j < len(pairs); j++ {
-					seen[pairs[j].key] = true
-				}
-			}
+		for k := range t.shards[i].evicted {
 			if evicted == nil {
 				evicted = make(map[string]bool)
 			}
-			for k := range t.shards[i].evicted {
-				evicted[k] = true
-			}
+			evicted[k] = true
 		}
 		t.shards[i].mu.RUnlock()
 	}
 
-	if hasEvicted {
+	if len(evicted) > 0 {
 		diskData, err := t.readKVFromPages()
 		if err != nil {
-			return nil, err
+			return err
 		}
 		for k, v := range diskData {
-			if !evicted[k] || seen[k] {
-				continue
+			if evicted[k] {
+				fn(k, v)
 			}
-			if startKey != nil && strings.Compare(k, startStr) < 0 {
-				continue
-			}
-			if endKey != nil && strings.Compare(k, endStr) > 0 {
-				continue
-			}
-			pairs = append(pairs, kvPair{k, cloneBytes(v)})
 		}
 	}
-
-	slices.SortFunc(pairs, func(a, b kvPair) int {
-		return strings.Compare(a.key, b.key)
-	})
-
-	return &Iterator{
-		tree:      t,
-		pairs:     pairs,
-		idx:       0,
-		endKey:    endStr,
-		hasEndKey: endKey != nil,
-		done:      false,
-	}, nil
+	return nil
 }
 
 // Next advances the iterator
@@ -1375,7 +1300,10 @@ func (t *BTree) Size() int {
 
 // Flush writes all in-memory data to disk pages (with multi-page overflow support)
 func (t *BTree) Flush() error {
-	return t.flushInternal()
+	span := tracing.StartBound("btree.Flush")
+	err := t.flushInternal()
+	tracing.End(span, err)
+	return err
 }
 
 // Cell represents a key-value pair in a leaf node (kept for compatibility)
@@ -0,0 +1,78 @@
+package btree
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// TestFlushLogsPageImages checks that a flush interrupted after writing only
+// its root page is completed from the WAL: without the page images the root
+// would list overflow pages never written to disk.
+func TestFlushLogsPageImages(t *testing.T) {
+	walPath := filepath.Join(t.TempDir(), "tree.wal")
+	wal, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := storage.NewMemory()
+	pool := storage.NewBufferPool(256, backend)
+	pool.SetWAL(wal)
+
+	tree, err := NewBTree(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	value := make([]byte, 100)
+	for i := 0; i < 200; i++ {
+		if err := tree.Put([]byte(fmt.Sprintf("key%03d", i)), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Crash after the root page, alone, reached the disk.
+	root, err := pool.GetPage(tree.RootPageID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.FlushPage(root); err != nil {
+		t.Fatal(err)
+	}
+	pool.Unpin(root)
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wal2, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal2.Close()
+	pool2 := storage.NewBufferPool(256, backend)
+	defer pool2.Close()
+	if err := wal2.Recover(pool2); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+
+	reopened, err := OpenBTreeStrict(pool2, tree.RootPageID())
+	if err != nil {
+		t.Fatalf("OpenBTreeStrict: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		if _, err := reopened.Get([]byte(fmt.Sprintf("key%03d", i))); err != nil {
+			t.Fatalf("key%03d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package btree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// ErrUnsortedKeys is returned by BulkLoader.Add for a key that does not sort
+// after the previous one.
+var ErrUnsortedKeys = errors.New("bulk load keys must be strictly ascending")
+
+// BulkLoader builds a B+Tree from entries supplied in ascending key order.
+// It serializes each entry as it arrives, sharing key prefixes as a flush
+// does, and writes the pages in one pass when finished, instead of growing
+// the tree one Put at a time:
+//
+//	b := btree.NewBulkLoader(pool)
+//	for ... {
+//		if err := b.Add(key, value); err != nil { ... }
+//	}
+//	tree, err := b.Finish()
+//
+// Nothing is allocated in the pool until Finish.
+type BulkLoader struct {
+	pool    *storage.BufferPool
+	limit   int64
+	buf     bytes.Buffer
+	count   uint32
+	lastKey string
+	done    bool
+}
+
+// NewBulkLoader returns a loader for a new tree in pool with the default
+// memory limit.
+func NewBulkLoader(pool *storage.BufferPool) *BulkLoader {
+	return NewBulkLoaderWithLimit(pool, DefaultMemoryLimit)
+}
+
+// NewBulkLoaderWithLimit returns a loader for a new tree in pool with the
+// given memory limit (0 = unlimited).
+func NewBulkLoaderWithLimit(pool *storage.BufferPool, limit int64) *BulkLoader {
+	return &BulkLoader{pool: pool, limit: limit}
+}
+
+// Add appends an entry. Its key must sort strictly after the previous key.
+func (b *BulkLoader) Add(key, value []byte) error {
+	if b.done {
+		return errors.New("bulk loader already finished")
+	}
+	if len(key) == 0 {
+		return ErrInvalidKey
+	}
+	if len(key) > MaxKeyLength {
+		return ErrKeyTooLong
+	}
+	if len(value) == 0 {
+		return ErrInvalidValue
+	}
+	if b.count > 0 && string(key) <= b.lastKey {
+		return fmt.Errorf("%w: %q after %q", ErrUnsortedKeys, key, b.lastKey)
+	}
+	if b.count == ^uint32(0) {
+		return fmt.Errorf("entry count exceeds %d", ^uint32(0))
+	}
+	if err := appendEntry(&b.buf, b.lastKey, string(key), value); err != nil {
+		return err
+	}
+	b.count++
+	b.lastKey = string(key)
+	return nil
+}
+
+// Len returns the number of entries added so far.
+func (b *BulkLoader) Len() int {
+	return int(b.count)
+}
+
+// Finish writes the entries to a new tree's pages and returns the tree,
+// loaded as OpenBTree would load it. The loader cannot be used afterwards.
+func (b *BulkLoader) Finish() (*BTree, error) {
+	if b.done {
+		return nil, errors.New("bulk loader already finished")
+	}
+	b.done = true
+
+	t, err := NewBTreeWithLimit(b.pool, b.limit)
+	if err != nil {
+		return nil, err
+	}
+	t.flushMu.Lock()
+	defer t.flushMu.Unlock()
+	if err := t.writeSerialized(b.count, b.buf.Bytes()); err != nil {
+		return nil, err
+	}
+	b.buf = bytes.Buffer{}
+	if err := t.loadFromPages(); err != nil {
+		return nil, fmt.Errorf("failed to load bulk-loaded B+Tree: %w", err)
+	}
+	return t, nil
+}
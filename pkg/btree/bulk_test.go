@@ -0,0 +1,79 @@
+package btree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+func TestBulkLoader(t *testing.T) {
+	pool := storage.NewBufferPool(256, storage.NewMemory())
+	defer pool.Close()
+
+	b := NewBulkLoader(pool)
+	const n = 2000
+	for i := 0; i < n; i++ {
+		if err := b.Add([]byte(fmt.Sprintf("key%05d", i)), []byte(fmt.Sprintf("value%d", i))); err != nil {
+			t.Fatalf("Add %d: %v", i, err)
+		}
+	}
+	if err := b.Add([]byte("key00010"), []byte("v")); !errors.Is(err, ErrUnsortedKeys) {
+		t.Fatalf("out-of-order Add err = %v, want ErrUnsortedKeys", err)
+	}
+	if err := b.Add([]byte("key01999"), []byte("v")); !errors.Is(err, ErrUnsortedKeys) {
+		t.Fatalf("duplicate Add err = %v, want ErrUnsortedKeys", err)
+	}
+
+	tree, err := b.Finish()
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if tree.Size() != n {
+		t.Fatalf("Size = %d, want %d", tree.Size(), n)
+	}
+	if _, err := b.Finish(); err == nil {
+		t.Fatal("second Finish succeeded")
+	}
+
+	// The pages hold the tree as a flush would have written it.
+	reopened, err := OpenBTreeStrict(pool, tree.RootPageID())
+	if err != nil {
+		t.Fatalf("OpenBTreeStrict: %v", err)
+	}
+	iter, err := reopened.Scan(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	i := 0
+	for iter.HasNext() {
+		key, value, err := iter.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("key%05d", i); string(key) != want {
+			t.Fatalf("entry %d key = %q, want %q", i, key, want)
+		}
+		if want := fmt.Sprintf("value%d", i); !bytes.Equal(value, []byte(want)) {
+			t.Fatalf("entry %d value = %q, want %q", i, value, want)
+		}
+		i++
+	}
+	if i != n {
+		t.Fatalf("scanned %d entries, want %d", i, n)
+	}
+
+	// The tree accepts writes like any other.
+	if err := tree.Put([]byte("key99999"), []byte("late")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OpenBTreeStrict(pool, tree.RootPageID()); err != nil || got.Size() != n+1 {
+		t.Fatalf("after Put and Flush: size %d, err %v", got.Size(), err)
+	}
+}
@@ -17,6 +17,7 @@ type TreeStore interface {
 	Put(key, value []byte) error
 	Delete(key []byte) error
 	Scan(startKey, endKey []byte) (TreeIterator, error)
+	ScanRange(opts ScanOptions) (TreeIterator, error)
 	PutBatch(keys [][]byte, values [][]byte) error
 	DeleteBatch(keys [][]byte) error
 	Size() int
@@ -0,0 +1,234 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// A tree stores its entries as one byte stream that starts in the root page,
+// after a header listing the overflow pages, and continues through them:
+//
+//	root: [count:4][overflow:4][overflowPageID:4]...[entries...]
+//
+// Flushes write the entries in key order, each key without the prefix it
+// shares with the key before it, and set rootFlagPrefixKeys in the overflow
+// word:
+//
+//	[shared:2][suffixLen:2][suffix][valueLen:4][value]
+//
+// Trees written before prefix compression hold [keyLen:2][key][valueLen:4]
+// [value] entries in no particular order. They are read as they are and
+// compressed by their next flush.
+const rootFlagPrefixKeys uint32 = 1 << 31
+
+// minSerializedEntrySize is the smallest entry of either layout.
+const minSerializedEntrySize = 2 + 4 // keyLen uint16 + valLen uint32
+
+// sharedPrefixLen returns the length of the longest common prefix of a and b.
+func sharedPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// appendEntry appends the entry for key and value to buf, given the key of
+// the entry before it ("" for the first).
+func appendEntry(buf *bytes.Buffer, prev, key string, value []byte) error {
+	if _, err := checkedUint16Len(len(key), "key length"); err != nil {
+		return err
+	}
+	valueLen, err := checkedUint32Len(len(value), "value length")
+	if err != nil {
+		return err
+	}
+	shared := sharedPrefixLen(prev, key)
+	var lenBuf [4]byte
+	binary.LittleEndian.PutUint16(lenBuf[0:2], uint16(shared))
+	binary.LittleEndian.PutUint16(lenBuf[2:4], uint16(len(key)-shared))
+	buf.Write(lenBuf[:4])
+	buf.WriteString(key[shared:])
+	binary.LittleEndian.PutUint32(lenBuf[:4], valueLen)
+	buf.Write(lenBuf[:4])
+	buf.Write(value)
+	return nil
+}
+
+// treeStream is the serialized content of a tree as read from its pages.
+type treeStream struct {
+	count       uint32
+	prefixKeys  bool
+	overflowIDs []uint32
+	headerSize  int    // bytes of the root header
+	data        []byte // the entries, followed by zero padding
+}
+
+// readStream reads the root and overflow pages of the tree.
+func (t *BTree) readStream() (*treeStream, error) {
+	root, err := t.pool.GetPage(t.rootPageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load root page %d: %w", t.rootPageID, err)
+	}
+	defer t.pool.Unpin(root)
+
+	pageData := root.Data()[storage.PageHeaderSize:]
+	if len(pageData) < 8 {
+		return nil, fmt.Errorf("corrupt root page %d: header too short", t.rootPageID)
+	}
+	s := &treeStream{count: binary.LittleEndian.Uint32(pageData[0:4])}
+	if s.count == 0 {
+		return s, nil
+	}
+	overflowWord := binary.LittleEndian.Uint32(pageData[4:8])
+	s.prefixKeys = overflowWord&rootFlagPrefixKeys != 0
+	overflowCount := overflowWord &^ rootFlagPrefixKeys
+
+	s.headerSize = 8 + 4*int(overflowCount)
+	if s.headerSize > len(pageData) {
+		return nil, fmt.Errorf("corrupt root page %d: header size %d exceeds page data %d", t.rootPageID, s.headerSize, len(pageData))
+	}
+	s.overflowIDs = make([]uint32, overflowCount)
+	for i := range s.overflowIDs {
+		off := 8 + 4*i
+		s.overflowIDs[i] = binary.LittleEndian.Uint32(pageData[off : off+4])
+	}
+
+	s.data = make([]byte, 0, len(pageData)-s.headerSize+len(s.overflowIDs)*usablePageSize)
+	s.data = append(s.data, pageData[s.headerSize:]...)
+	for _, pgID := range s.overflowIDs {
+		pg, err := t.pool.GetPage(pgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load overflow page %d: %w", pgID, err)
+		}
+		s.data = append(s.data, pg.Data()[storage.PageHeaderSize:]...)
+		t.pool.Unpin(pg)
+	}
+
+	maxPossibleEntries := len(s.data) / minSerializedEntrySize
+	if int64(s.count) > int64(maxPossibleEntries) {
+		return nil, fmt.Errorf("corrupt root page %d: entry count %d exceeds maximum possible entries %d for %d bytes", t.rootPageID, s.count, maxPossibleEntries, len(s.data))
+	}
+	return s, nil
+}
+
+// entries calls fn with each entry of the stream and returns the number of
+// bytes they occupy. The value passed to fn is a copy.
+func (s *treeStream) entries(rootPageID uint32, fn func(key string, value []byte)) (int, error) {
+	data := s.data
+	offset := 0
+	prev := ""
+	for i := uint32(0); i < s.count; i++ {
+		var key string
+		if s.prefixKeys {
+			if offset+4 > len(data) {
+				return 0, fmt.Errorf("corrupt root page %d: truncated key length for entry %d", rootPageID, i)
+			}
+			shared := int(binary.LittleEndian.Uint16(data[offset : offset+2]))
+			suffixLen := int(binary.LittleEndian.Uint16(data[offset+2 : offset+4]))
+			offset += 4
+			if shared > len(prev) {
+				return 0, fmt.Errorf("corrupt root page %d: shared prefix %d for entry %d exceeds previous key length %d", rootPageID, shared, i, len(prev))
+			}
+			if shared+suffixLen == 0 {
+				return 0, fmt.Errorf("corrupt root page %d: empty key for entry %d", rootPageID, i)
+			}
+			if offset+suffixLen > len(data) {
+				return 0, fmt.Errorf("corrupt root page %d: key length %d for entry %d exceeds remaining data %d", rootPageID, suffixLen, i, len(data)-offset)
+			}
+			key = prev[:shared] + string(data[offset:offset+suffixLen])
+			offset += suffixLen
+		} else {
+			if offset+2 > len(data) {
+				return 0, fmt.Errorf("corrupt root page %d: truncated key length for entry %d", rootPageID, i)
+			}
+			keyLen := int(binary.LittleEndian.Uint16(data[offset : offset+2]))
+			offset += 2
+			if keyLen == 0 {
+				return 0, fmt.Errorf("corrupt root page %d: empty key for entry %d", rootPageID, i)
+			}
+			if offset+keyLen > len(data) {
+				return 0, fmt.Errorf("corrupt root page %d: key length %d for entry %d exceeds remaining data %d", rootPageID, keyLen, i, len(data)-offset)
+			}
+			key = string(data[offset : offset+keyLen])
+			offset += keyLen
+		}
+		if offset+4 > len(data) {
+			return 0, fmt.Errorf("corrupt root page %d: truncated value length for entry %d", rootPageID, i)
+		}
+		valLen := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if valLen < 0 || offset+valLen > len(data) {
+			return 0, fmt.Errorf("corrupt root page %d: value length %d for entry %d exceeds remaining data %d", rootPageID, valLen, i, len(data)-offset)
+		}
+		val := make([]byte, valLen)
+		copy(val, data[offset:offset+valLen])
+		offset += valLen
+
+		fn(key, val)
+		prev = key
+	}
+	return offset, nil
+}
+
+// PageStats describes how a tree's entries occupy its pages, as of its last
+// flush.
+type PageStats struct {
+	Entries int `json:"entries"`
+	// Depth counts levels of pages: 1 for a tree held in its root page, 2
+	// when entries continue into overflow pages.
+	Depth int `json:"depth"`
+	Pages int `json:"pages"`
+	// KeyBytes is the length of the keys, and StoredKeyBytes what they take
+	// after prefix compression.
+	KeyBytes       int64 `json:"key_bytes"`
+	StoredKeyBytes int64 `json:"stored_key_bytes"`
+	// UsedBytes counts the root header and the entries; FillFactor is their
+	// share of the pages' usable space.
+	UsedBytes  int64   `json:"used_bytes"`
+	FillFactor float64 `json:"fill_factor"`
+}
+
+// PageStats reads the tree's pages and reports how full they are. Changes
+// not yet flushed are not counted.
+func (t *BTree) PageStats() (PageStats, error) {
+	t.flushMu.Lock()
+	defer t.flushMu.Unlock()
+
+	stream, err := t.readStream()
+	if err != nil {
+		return PageStats{}, err
+	}
+	stats := PageStats{
+		Entries: int(stream.count),
+		Depth:   1,
+		Pages:   1 + len(stream.overflowIDs),
+	}
+	if len(stream.overflowIDs) > 0 {
+		stats.Depth = 2
+	}
+	prev := ""
+	used, err := stream.entries(t.rootPageID, func(key string, _ []byte) {
+		stats.KeyBytes += int64(len(key))
+		if stream.prefixKeys {
+			stats.StoredKeyBytes += int64(len(key) - sharedPrefixLen(prev, key))
+		} else {
+			stats.StoredKeyBytes += int64(len(key))
+		}
+		prev = key
+	})
+	if err != nil {
+		return PageStats{}, err
+	}
+	stats.UsedBytes = int64(stream.headerSize + used)
+	if stream.count == 0 {
+		stats.UsedBytes = 8
+	}
+	stats.FillFactor = float64(stats.UsedBytes) / float64(stats.Pages*usablePageSize)
+	return stats, nil
+}
@@ -0,0 +1,104 @@
+package btree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+func TestOpenBTreeReadsUncompressedLayout(t *testing.T) {
+	pool := storage.NewBufferPool(100, storage.NewMemory())
+	defer pool.Close()
+
+	rootPage, err := pool.NewPage(storage.PageTypeLeaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootID := rootPage.ID()
+	pageData := rootPage.Data()[storage.PageHeaderSize:]
+	binary.LittleEndian.PutUint32(pageData[0:4], 2) // totalCount
+	binary.LittleEndian.PutUint32(pageData[4:8], 0) // overflowCount, no flags
+	off := 8
+	for _, kv := range [][2]string{{"key2", "b"}, {"key1", "a"}} {
+		binary.LittleEndian.PutUint16(pageData[off:], uint16(len(kv[0])))
+		off += 2 + copy(pageData[off+2:], kv[0])
+		binary.LittleEndian.PutUint32(pageData[off:], uint32(len(kv[1])))
+		off += 4 + copy(pageData[off+4:], kv[1])
+	}
+	rootPage.SetDirty(true)
+	pool.Unpin(rootPage)
+
+	tree, err := OpenBTreeStrict(pool, rootID)
+	if err != nil {
+		t.Fatalf("OpenBTreeStrict: %v", err)
+	}
+	if v, err := tree.Get([]byte("key1")); err != nil || string(v) != "a" {
+		t.Fatalf("key1 = %q, %v", v, err)
+	}
+
+	// The next flush rewrites it compressed.
+	if err := tree.Put([]byte("key3"), []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := tree.PageStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 3 || stats.KeyBytes != 12 || stats.StoredKeyBytes != 6 {
+		t.Fatalf("stats = %+v, want 3 entries, 12 key bytes stored in 6", stats)
+	}
+	reopened, err := OpenBTreeStrict(pool, rootID)
+	if err != nil || reopened.Size() != 3 {
+		t.Fatalf("reopen: size %d, err %v", reopened.Size(), err)
+	}
+}
+
+func TestPageStatsPrefixCompression(t *testing.T) {
+	pool := storage.NewBufferPool(256, storage.NewMemory())
+	defer pool.Close()
+	tree, err := NewBTree(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		if err := tree.Put([]byte(fmt.Sprintf("%020d", i)), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := tree.PageStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != n || stats.KeyBytes != 20*n {
+		t.Fatalf("stats = %+v, want %d entries of 20-byte keys", stats, n)
+	}
+	// Consecutive keys share 17 to 19 leading digits.
+	if stats.StoredKeyBytes > 4*n {
+		t.Errorf("stored key bytes = %d, want at most %d", stats.StoredKeyBytes, 4*n)
+	}
+	// Uncompressed, 1000 entries of 27 bytes need 7 pages.
+	if stats.Pages > 3 || stats.Depth != 2 {
+		t.Errorf("pages = %d, depth = %d; want at most 3 pages over 2 levels", stats.Pages, stats.Depth)
+	}
+	if stats.FillFactor <= 0.5 || stats.FillFactor > 1 {
+		t.Errorf("fill factor = %v", stats.FillFactor)
+	}
+
+	empty, err := NewBTree(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := empty.PageStats(); err != nil || stats.Entries != 0 || stats.Pages != 1 || stats.Depth != 1 {
+		t.Fatalf("empty tree stats = %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,124 @@
+package btree
+
+import (
+	"container/heap"
+	"slices"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
+)
+
+// ScanOptions selects the entries returned by ScanRange.
+type ScanOptions struct {
+	// Start and End bound the keys to the half-open range [Start, End).
+	// A nil bound leaves that side open.
+	Start, End []byte
+	// Reverse returns the entries in descending key order.
+	Reverse bool
+	// Limit caps the number of entries returned (0 = no limit). The first
+	// Limit entries in iteration order are kept, so a reverse scan with a
+	// limit keeps the largest keys.
+	Limit int
+}
+
+// contains reports whether key lies within the bounds.
+func (o ScanOptions) contains(key string) bool {
+	if o.Start != nil && key < string(o.Start) {
+		return false
+	}
+	if o.End != nil && key >= string(o.End) {
+		return false
+	}
+	return true
+}
+
+// before reports whether a comes before b in iteration order.
+func (o ScanOptions) before(a, b string) bool {
+	if o.Reverse {
+		return a > b
+	}
+	return a < b
+}
+
+// rangeCollector gathers the entries of a ScanRange from an unordered walk of
+// a tree. With a limit it keeps only the best Limit entries in a heap whose
+// root is the last of them in iteration order, so values that are dropped
+// are never copied and only Limit entries are sorted.
+type rangeCollector struct {
+	opts  ScanOptions
+	pairs []kvPair
+}
+
+func newRangeCollector(opts ScanOptions, sizeHint int) *rangeCollector {
+	if opts.Limit > 0 && opts.Limit < sizeHint {
+		sizeHint = opts.Limit
+	}
+	return &rangeCollector{opts: opts, pairs: make([]kvPair, 0, sizeHint)}
+}
+
+// add offers an entry; value is copied only if it is kept.
+func (c *rangeCollector) add(key string, value []byte) {
+	if !c.opts.contains(key) {
+		return
+	}
+	if c.opts.Limit <= 0 {
+		c.pairs = append(c.pairs, kvPair{key, cloneBytes(value)})
+		return
+	}
+	if len(c.pairs) < c.opts.Limit {
+		heap.Push(c, kvPair{key, cloneBytes(value)})
+		return
+	}
+	if c.opts.before(key, c.pairs[0].key) {
+		c.pairs[0] = kvPair{key, cloneBytes(value)}
+		heap.Fix(c, 0)
+	}
+}
+
+// iterator returns the kept entries in iteration order.
+func (c *rangeCollector) iterator() *Iterator {
+	slices.SortFunc(c.pairs, func(a, b kvPair) int {
+		if c.opts.Reverse {
+			return strings.Compare(b.key, a.key)
+		}
+		return strings.Compare(a.key, b.key)
+	})
+	return &Iterator{pairs: c.pairs}
+}
+
+// heap.Interface, ordered so that the root is the last entry in iteration
+// order.
+func (c *rangeCollector) Len() int           { return len(c.pairs) }
+func (c *rangeCollector) Less(i, j int) bool { return c.opts.before(c.pairs[j].key, c.pairs[i].key) }
+func (c *rangeCollector) Swap(i, j int)      { c.pairs[i], c.pairs[j] = c.pairs[j], c.pairs[i] }
+func (c *rangeCollector) Push(x any)         { c.pairs = append(c.pairs, x.(kvPair)) }
+func (c *rangeCollector) Pop() any {
+	p := c.pairs[len(c.pairs)-1]
+	c.pairs = c.pairs[:len(c.pairs)-1]
+	return p
+}
+
+// ScanRange returns an iterator over the entries selected by opts. Unlike
+// Scan, the end bound is exclusive, the entries can be returned in
+// descending order, and with a limit only that many are copied and sorted.
+func (t *BTree) ScanRange(opts ScanOptions) (_ TreeIterator, err error) {
+	span := tracing.StartBound("btree.ScanRange")
+	defer func() { tracing.End(span, err) }()
+
+	c := newRangeCollector(opts, t.Size())
+	if err := t.eachEntry(c.add); err != nil {
+		return nil, err
+	}
+	return c.iterator(), nil
+}
+
+// ScanRange returns an iterator over the live records selected by opts.
+func (s *AppendStore) ScanRange(opts ScanOptions) (TreeIterator, error) {
+	s.mu.RLock()
+	c := newRangeCollector(opts, len(s.data))
+	for k, v := range s.data {
+		c.add(k, v)
+	}
+	s.mu.RUnlock()
+	return c.iterator(), nil
+}
@@ -0,0 +1,130 @@
+package btree
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+func scanKeys(t *testing.T, tree TreeStore, opts ScanOptions) []string {
+	t.Helper()
+	iter, err := tree.ScanRange(opts)
+	if err != nil {
+		t.Fatalf("ScanRange(%+v): %v", opts, err)
+	}
+	defer iter.Close()
+	var keys []string
+	for iter.HasNext() {
+		key, value, err := iter.NextString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(value) != "v"+key {
+			t.Fatalf("value of %q = %q", key, value)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestScanRange(t *testing.T) {
+	pool := storage.NewBufferPool(256, storage.NewMemory())
+	defer pool.Close()
+	tree, err := NewBTree(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendStore, err := NewAppendStore(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("k%02d", i)
+		for _, s := range []TreeStore{tree, appendStore} {
+			if err := s.Put([]byte(key), []byte("v"+key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		opts  ScanOptions
+		first string
+		last  string
+		n     int
+	}{
+		{"all", ScanOptions{}, "k00", "k99", 100},
+		{"reverse", ScanOptions{Reverse: true}, "k99", "k00", 100},
+		{"half-open", ScanOptions{Start: []byte("k10"), End: []byte("k20")}, "k10", "k19", 10},
+		{"reverse half-open", ScanOptions{Start: []byte("k10"), End: []byte("k20"), Reverse: true}, "k19", "k10", 10},
+		{"limit", ScanOptions{Limit: 5}, "k00", "k04", 5},
+		{"reverse limit", ScanOptions{Reverse: true, Limit: 10}, "k99", "k90", 10},
+		{"reverse bounded limit", ScanOptions{End: []byte("k50"), Reverse: true, Limit: 3}, "k49", "k47", 3},
+		{"limit past range", ScanOptions{Start: []byte("k95"), Limit: 10}, "k95", "k99", 5},
+		{"empty", ScanOptions{Start: []byte("k50"), End: []byte("k50")}, "", "", 0},
+	}
+	for _, s := range []struct {
+		name string
+		tree TreeStore
+	}{{"btree", tree}, {"append", appendStore}} {
+		for _, tt := range tests {
+			keys := scanKeys(t, s.tree, tt.opts)
+			if len(keys) != tt.n {
+				t.Fatalf("%s %s: %d keys, want %d: %v", s.name, tt.name, len(keys), tt.n, keys)
+			}
+			if tt.n > 0 && (keys[0] != tt.first || keys[len(keys)-1] != tt.last) {
+				t.Fatalf("%s %s: keys %s..%s, want %s..%s", s.name, tt.name, keys[0], keys[len(keys)-1], tt.first, tt.last)
+			}
+			for i := 1; i < len(keys); i++ {
+				if (keys[i] < keys[i-1]) != tt.opts.Reverse {
+					t.Fatalf("%s %s: %q follows %q", s.name, tt.name, keys[i], keys[i-1])
+				}
+			}
+		}
+	}
+
+	// First rewinds to the first entry in iteration order.
+	iter, err := tree.ScanRange(ScanOptions{Reverse: true, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter.NextString()
+	iter.NextString()
+	if !iter.First() {
+		t.Fatal("First returned false")
+	}
+	if key, _, _ := iter.NextString(); key != "k99" {
+		t.Fatalf("after First key = %q, want k99", key)
+	}
+}
+
+func TestScanRangeEvicted(t *testing.T) {
+	pool := storage.NewBufferPool(256, storage.NewMemory())
+	defer pool.Close()
+	tree, err := NewBTreeWithLimit(pool, 8*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 1000
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("k%04d", i)
+		if err := tree.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatal(err)
+		}
+		if i%100 == 99 {
+			if err := tree.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	keys := scanKeys(t, tree, ScanOptions{Reverse: true, Limit: 5})
+	if fmt.Sprint(keys) != "[k0999 k0998 k0997 k0996 k0995]" {
+		t.Fatalf("reverse limit keys = %v", keys)
+	}
+	if keys := scanKeys(t, tree, ScanOptions{}); len(keys) != n {
+		t.Fatalf("full scan returned %d keys, want %d", len(keys), n)
+	}
+}
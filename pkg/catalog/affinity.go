@@ -0,0 +1,281 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+)
+
+// TypeAffinity selects how values written to typed columns are checked.
+type TypeAffinity int
+
+const (
+	// TypeAffinityLoose follows SQLite: values that losslessly fit the column
+	// type are converted ('42' into INTEGER, 3 into REAL, 1 into BOOLEAN) and
+	// incompatible ones are stored unchanged. This is the default.
+	TypeAffinityLoose TypeAffinity = iota
+	// TypeAffinityStrict converts compatible values the same way and rejects
+	// the rest with ErrTypeMismatch.
+	TypeAffinityStrict
+)
+
+// ErrTypeMismatch is returned in strict affinity mode when a written value
+// cannot be converted to its column's type.
+var ErrTypeMismatch = sqlerr.ErrTypeMismatch
+
+// SetTypeAffinity sets the affinity mode for subsequent writes.
+func (c *Catalog) SetTypeAffinity(mode TypeAffinity) {
+	c.affinity.Store(int32(mode))
+}
+
+// TypeAffinity returns the affinity mode used for writes.
+func (c *Catalog) TypeAffinity() TypeAffinity {
+	return TypeAffinity(c.affinity.Load())
+}
+
+// applyColumnAffinity converts the values of row in place to their columns'
+// types. Temporal and UUID columns are always normalized; VECTOR columns are
+// left to their own validation.
+func (c *Catalog) applyColumnAffinity(table *TableDef, row []interface{}) error {
+	strict := c.TypeAffinity() == TypeAffinityStrict
+	for i := range table.Columns {
+		if i >= len(row) || row[i] == nil {
+			continue
+		}
+		col := &table.Columns[i]
+		if isTemporalColumnType(col.Type) {
+			// Unrecognizable dates are rejected in either mode.
+			v, err := normalizeTemporalValue(col, row[i])
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+			}
+			row[i] = v
+			continue
+		}
+		if strings.EqualFold(col.Type, "UUID") {
+			v, err := normalizeUUIDValue(col, row[i])
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+			}
+			row[i] = v
+			continue
+		}
+		v, ok := coerceToColumnType(col.Type, row[i])
+		if !ok {
+			if strict {
+				return fmt.Errorf("%w: cannot store %s value %q in %s column '%s'",
+					ErrTypeMismatch, affinityValueKind(row[i]), ValueToStringKey(row[i]), col.Type, col.Name)
+			}
+			continue
+		}
+		row[i] = v
+	}
+	return nil
+}
+
+// keyColumnAffinity converts a primary key value the way the stored row will
+// be converted, so that '1' and 1 address the same row of an INTEGER key.
+func keyColumnAffinity(table *TableDef, colName string, v interface{}) interface{} {
+	idx := table.GetColumnIndex(colName)
+	if idx < 0 {
+		return v
+	}
+	col := &table.Columns[idx]
+	if isTemporalColumnType(col.Type) {
+		if nv, err := normalizeTemporalValue(col, v); err == nil {
+			return nv
+		}
+		return v
+	}
+	if strings.EqualFold(col.Type, "UUID") {
+		if nv, err := normalizeUUIDValue(col, v); err == nil {
+			return nv
+		}
+		return v
+	}
+	if nv, ok := coerceToColumnType(col.Type, v); ok {
+		return nv
+	}
+	return v
+}
+
+// coerceToColumnType converts v to the canonical Go representation of
+// colType, reporting false when the conversion would lose information.
+func coerceToColumnType(colType string, v interface{}) (interface{}, bool) {
+	switch strings.ToUpper(colType) {
+	case "INTEGER":
+		return coerceInteger(v)
+	case "REAL":
+		return coerceReal(v)
+	case "TEXT":
+		return coerceText(v)
+	case "BOOLEAN":
+		return coerceBoolean(v)
+	case "JSON":
+		return coerceJSON(v)
+	case "BLOB":
+		switch v.(type) {
+		case []byte, string, StringBox:
+			return v, true
+		}
+		return nil, false
+	}
+	return v, true
+}
+
+func coerceInteger(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case int64:
+		return val, true
+	case int:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case float64:
+		// Whole floats (autoincrement values, arithmetic results) are kept
+		// as they are; only fractional ones are rejected.
+		if _, ok := floatToInteger(val); ok {
+			return val, true
+		}
+	case bool:
+		if val {
+			return int64(1), true
+		}
+		return int64(0), true
+	case string, StringBox:
+		s := strings.TrimSpace(ValueToStringKey(val))
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return floatToInteger(f)
+		}
+	}
+	return nil, false
+}
+
+// floatToInteger accepts whole floats within the exactly representable
+// int64 range.
+func floatToInteger(f float64) (interface{}, bool) {
+	if f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+		return nil, false
+	}
+	return int64(f), true
+}
+
+func coerceReal(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int64:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case string, StringBox:
+		f, err := strconv.ParseFloat(strings.TrimSpace(ValueToStringKey(val)), 64)
+		if err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func coerceText(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case string, StringBox:
+		return val, true
+	case int64, int, int32, float64, bool:
+		return ValueToStringKey(val), true
+	case []byte:
+		return string(val), true
+	}
+	return nil, false
+}
+
+func coerceBoolean(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case bool:
+		return val, true
+	case int64:
+		return integerToBool(val)
+	case int:
+		return integerToBool(int64(val))
+	case float64:
+		if val == 0 || val == 1 {
+			return val == 1, true
+		}
+	case string, StringBox:
+		switch strings.ToLower(strings.TrimSpace(ValueToStringKey(val))) {
+		case "true", "t", "yes", "y", "on", "1":
+			return true, true
+		case "false", "f", "no", "n", "off", "0":
+			return false, true
+		}
+	}
+	return nil, false
+}
+
+// coerceJSON stores JSON text minified, so equal documents written with
+// different whitespace compare and index alike. Numbers and booleans are JSON
+// values already; text that is not valid JSON does not fit the column.
+func coerceJSON(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case int64, int, int32, float64, bool:
+		return val, true
+	case string, StringBox, []byte:
+		var text []byte
+		if b, ok := val.([]byte); ok {
+			text = b
+		} else {
+			text = []byte(ValueToStringKey(val))
+		}
+		if len(text) > maxJSONDocumentBytes {
+			return nil, false
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, text); err != nil {
+			return nil, false
+		}
+		if buf.Len() == len(text) {
+			if s, ok := val.(string); ok {
+				return s, true
+			}
+		}
+		return buf.String(), true
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return nil, false
+		}
+		return string(b), true
+	}
+	return nil, false
+}
+
+func integerToBool(n int64) (interface{}, bool) {
+	if n == 0 || n == 1 {
+		return n == 1, true
+	}
+	return nil, false
+}
+
+func affinityValueKind(v interface{}) string {
+	switch v.(type) {
+	case int64, int, int32:
+		return "INTEGER"
+	case float64:
+		return "REAL"
+	case bool:
+		return "BOOLEAN"
+	case []byte:
+		return "BLOB"
+	}
+	return "TEXT"
+}
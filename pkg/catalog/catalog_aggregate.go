@@ -82,9 +82,11 @@ func (c *Catalog) computeAggregatesWithGroupBy(table *TableDef, stmt *query.Sele
 		}
 	}
 
+	endAggregate := c.budget().beginOperator(OperatorAggregate, "")
 	var groups map[string][][]interface{}
 	var groupOrder []string
-	if _, exists := c.tableTrees[stmt.From.Name]; exists {
+	var spill *groupSpill
+	if _, exists := c.tableTrees[stmt.From.Name]; exists || table.Type == "attached" {
 		// Materialize all raw values first, merging committed data with pending
 		// buffered writes for read-your-writes visibility.
 		var allValues [][]byte
@@ -100,7 +102,20 @@ func (c *Catalog) computeAggregatesWithGroupBy(table *TableDef, stmt *query.Sele
 		for _, k := range effectiveKeys {
 			allValues = append(allValues, effectiveData[k])
 		}
-		groups, groupOrder, err = c.buildGroupByGroups(table, stmt, args, groupBySpecs, allValues)
+		if c.accumulableGroupBy(selectCols) {
+			acc, err := c.accumulateGroups(table, stmt, args, groupBySpecs, selectCols, allValues)
+			if err != nil {
+				return returnColumns, nil, err
+			}
+			var resultRows [][]interface{}
+			if len(acc.order) == 0 {
+				resultRows = c.computeEmptyGroupResult(nil, stmt, selectCols, table, args)
+			}
+			resultRows = append(resultRows, c.accumulatedGroupResultRows(acc, stmt, selectCols, table, args)...)
+			endAggregate(len(resultRows))
+			return returnColumns, c.applyGroupByPostProcessing(resultRows, stmt, selectCols, args), nil
+		}
+		groups, groupOrder, spill, err = c.buildGroupByGroups(table, stmt, args, groupBySpecs, allValues)
 		if err != nil {
 			return returnColumns, nil, err
 		}
@@ -109,6 +124,15 @@ func (c *Catalog) computeAggregatesWithGroupBy(table *TableDef, stmt *query.Sele
 			groups, groupOrder = c.buildGroupByGroupsFromRows(table, stmt, args, groupBySpecs, cteRes.rows)
 		}
 	}
+	if spill != nil {
+		defer spill.close()
+		resultRows, err := c.computeSpilledGroupResultRows(spill, stmt, selectCols, table, args)
+		if err != nil {
+			return returnColumns, nil, err
+		}
+		endAggregate(len(resultRows))
+		return returnColumns, c.applyGroupByPostProcessing(resultRows, stmt, selectCols, args), nil
+	}
 	if groups == nil {
 		// Return empty result for GROUP BY on non-existent table
 		return returnColumns, [][]interface{}{}, nil
@@ -120,6 +144,7 @@ func (c *Catalog) computeAggregatesWithGroupBy(table *TableDef, stmt *query.Sele
 	// Compute aggregate result for each group
 	groupResultRows := c.computeGroupResultRows(groups, groupOrder, stmt, selectCols, table, args)
 	resultRows = append(resultRows, groupResultRows...)
+	endAggregate(len(resultRows))
 
 	// Apply ORDER BY, DISTINCT, OFFSET, LIMIT
 	resultRows = c.applyGroupByPostProcessing(resultRows, stmt, selectCols, args)
@@ -191,6 +216,10 @@ func (c *Catalog) computeGroupResultRows(groups map[string][][]interface{}, grou
 						values = append(values, v)
 					}
 				}
+				if v, ok := c.userAggregateResult(ci.aggregateType, values, ci.isDistinct); ok {
+					resultRow[i] = v
+					continue
+				}
 				resultRow[i] = computeAggregateValue(c.selectColInfoWithGroupConcatSeparator(ci, aggregateRows, table.Columns, args), values, aggregateRows)
 				if ci.aggregateType == "GROUP_CONCAT" && resultRow[i] != nil {
 					if joined, ok := resultRow[i].(string); ok && len(joined) > maxStringResultLen {
@@ -233,11 +262,12 @@ func (c *Catalog) computeGroupResultRows(groups map[string][][]interface{}, grou
 // applyGroupByPostProcessing applies ORDER BY, DISTINCT, OFFSET, LIMIT to grouped results.
 func (c *Catalog) applyGroupByPostProcessing(resultRows [][]interface{}, stmt *query.SelectStmt, selectCols []selectColInfo, args []interface{}) [][]interface{} {
 	if len(stmt.OrderBy) > 0 {
-		resultRows = c.applyGroupByOrderBy(resultRows, selectCols, stmt.OrderBy)
+		resultRows = c.applyGroupByOrderBy(resultRows, selectCols, stmt.OrderBy, args)
 	}
 	if stmt.Distinct {
 		resultRows = c.applyDistinct(resultRows)
 	}
+	endLimit := c.budget().beginLimit(stmt)
 	if stmt.Offset != nil {
 		offsetVal, err := evaluateExpression(c, nil, nil, stmt.Offset, args)
 		if err == nil {
@@ -258,75 +288,99 @@ func (c *Catalog) applyGroupByPostProcessing(resultRows [][]interface{}, stmt *q
 			}
 		}
 	}
+	endLimit(len(resultRows))
 	return resultRows
 }
 
+// groupByRLS returns the context row-level security checks the rows of a
+// table GROUP BY against, and whether they must be checked.
+func (c *Catalog) groupByRLS(table *TableDef) (context.Context, bool) {
+	rlsCtx := c.rlsCtx
+	if rlsCtx == nil {
+		rlsCtx = context.Background()
+	}
+	rlsUser, _ := rlsContext(rlsCtx)
+	return rlsCtx, rlsUser != "" && c.enableRLS && c.rlsManager != nil && c.rlsManager.IsEnabled(table.Name)
+}
+
+// groupByRow decodes one stored row of a table GROUP BY and computes its
+// group key. ok is false for a row that is deleted, hidden by row-level
+// security or filtered out by WHERE.
+func (c *Catalog) groupByRow(table *TableDef, stmt *query.SelectStmt, args []interface{}, specs []groupBySpec, rlsCtx context.Context, applyRLS bool, valueData []byte) (fullRow []interface{}, key string, ok bool, err error) {
+	fullRow, live, err := decodeLiveRow(valueData, len(table.Columns))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("group by: failed to decode row in table %s: %w", table.Name, err)
+	}
+	if !live {
+		return nil, "", false, nil
+	}
+
+	if applyRLS {
+		allowed, err := c.checkRowAccessLocked(rlsCtx, table.Name, table.Columns, fullRow, security.PolicySelect)
+		if err != nil || !allowed {
+			return nil, "", false, nil
+		}
+	}
+
+	if stmt.Where != nil {
+		matched, err := evaluateWhere(c, fullRow, table.Columns, stmt.Where, args)
+		if err != nil || !matched {
+			return nil, "", false, nil
+		}
+	}
+
+	var groupKey strings.Builder
+	groupKey.Grow(len(specs) * 16)
+	for i, spec := range specs {
+		if i > 0 {
+			groupKey.WriteString("\x00")
+		}
+		if spec.index >= 0 && spec.index < len(fullRow) {
+			groupKey.WriteString(typeTaggedKey(fullRow[spec.index]))
+		} else if spec.expr != nil {
+			val, err := evaluateExpression(c, fullRow, table.Columns, spec.expr, args)
+			if err == nil {
+				groupKey.WriteString(typeTaggedKey(val))
+			}
+		}
+	}
+	return fullRow, groupKey.String(), true, nil
+}
+
 // buildGroupByGroups scans raw values and groups rows by GROUP BY columns.
-func (c *Catalog) buildGroupByGroups(table *TableDef, stmt *query.SelectStmt, args []interface{}, specs []groupBySpec, allValues [][]byte) (map[string][][]interface{}, []string, error) {
+// Groups that outgrow the statement's spill memory move to a groupSpill,
+// returned instead of the groups.
+func (c *Catalog) buildGroupByGroups(table *TableDef, stmt *query.SelectStmt, args []interface{}, specs []groupBySpec, allValues [][]byte) (map[string][][]interface{}, []string, *groupSpill, error) {
 	groups := make(map[string][][]interface{})
 	var groupOrder []string
 
 	canParallel := c.parallelWorkers > 0 &&
 		len(allValues) >= c.parallelThreshold &&
-		!hasSubqueries(stmt)
-	rlsCtx := c.rlsCtx
-	if rlsCtx == nil {
-		rlsCtx = context.Background()
-	}
-	rlsUser, _ := rlsContext(rlsCtx)
-	applyRLS := rlsUser != "" && c.enableRLS && c.rlsManager != nil && c.rlsManager.IsEnabled(table.Name)
+		!hasSubqueries(stmt) &&
+		c.budget().spillMemory() == 0
+	rlsCtx, applyRLS := c.groupByRLS(table)
 
 	if canParallel {
 		for _, valueData := range allValues {
 			if _, err := decodeVersionedRow(valueData, len(table.Columns)); err != nil {
-				return nil, nil, fmt.Errorf("group by: failed to decode row in table %s: %w", table.Name, err)
+				return nil, nil, nil, fmt.Errorf("group by: failed to decode row in table %s: %w", table.Name, err)
 			}
 		}
 		groups = parallel.ParallelGroupBy(allValues, c.parallelWorkers, c.parallelThreshold,
 			func(chunk [][]byte) map[string][][]interface{} {
 				localGroups := make(map[string][][]interface{})
 				for _, valueData := range chunk {
-					fullRow, live, _ := decodeLiveRow(valueData, len(table.Columns)) // prevalidated before parallel grouping
-					if !live {
-						continue
-					}
-					if applyRLS {
-						allowed, err := c.checkRowAccessLocked(rlsCtx, table.Name, table.Columns, fullRow, security.PolicySelect)
-						if err != nil || !allowed {
-							continue
-						}
-					}
-					if stmt.Where != nil {
-						matched, err := evaluateWhere(c, fullRow, table.Columns, stmt.Where, args)
-						if err != nil {
-							continue
-						}
-						if !matched {
-							continue
-						}
-					}
-					var groupKey strings.Builder
-					groupKey.Grow(len(specs) * 16)
-					for i, spec := range specs {
-						if i > 0 {
-							groupKey.WriteString("\x00")
-						}
-						if spec.index >= 0 && spec.index < len(fullRow) {
-							groupKey.WriteString(typeTaggedKey(fullRow[spec.index]))
-						} else if spec.expr != nil {
-							val, err := evaluateExpression(c, fullRow, table.Columns, spec.expr, args)
-							if err == nil {
-								groupKey.WriteString(typeTaggedKey(val))
-							}
-						}
+					fullRow, key, ok, _ := c.groupByRow(table, stmt, args, specs, rlsCtx, applyRLS, valueData) // prevalidated before parallel grouping
+					if ok {
+						localGroups[key] = append(localGroups[key], fullRow)
 					}
-					key := groupKey.String()
-					localGroups[key] = append(localGroups[key], fullRow)
 				}
 				return localGroups
 			})
-		for k := range groups {
+		budget := c.budget()
+		for k, rows := range groups {
 			groupOrder = append(groupOrder, k)
+			budget.chargeRows(rows)
 		}
 		// Go map iteration is randomized, so without this a GROUP BY with no
 		// ORDER BY would emit groups in a different order on every run once the
@@ -334,60 +388,67 @@ func (c *Catalog) buildGroupByGroups(table *TableDef, stmt *query.SelectStmt, ar
 		// (and stable across runs) ordering.
 		sort.Strings(groupOrder)
 	} else {
-		for _, valueData := range allValues {
-			fullRow, live, err := decodeLiveRow(valueData, len(table.Columns))
+		budget := c.budget()
+		spillLimit := budget.spillMemory()
+		var held int64
+		var firstSeen map[string]int64
+		if spillLimit > 0 {
+			firstSeen = make(map[string]int64)
+		}
+		var spill *groupSpill
+		for seq, valueData := range allValues {
+			fullRow, key, ok, err := c.groupByRow(table, stmt, args, specs, rlsCtx, applyRLS, valueData)
 			if err != nil {
-				return nil, nil, fmt.Errorf("group by: failed to decode row in table %s: %w", table.Name, err)
+				if spill != nil {
+					spill.close()
+				}
+				return nil, nil, nil, err
 			}
-			if !live {
+			if !ok {
 				continue
 			}
-
-			if applyRLS {
-				allowed, err := c.checkRowAccessLocked(rlsCtx, table.Name, table.Columns, fullRow, security.PolicySelect)
-				if err != nil || !allowed {
-					continue
+			if spill != nil {
+				if err := spill.add(int64(seq), key, fullRow); err != nil {
+					spill.close()
+					return nil, nil, nil, fmt.Errorf("group by: %w", err)
 				}
+				continue
 			}
-
-			if stmt.Where != nil {
-				matched, err := evaluateWhere(c, fullRow, table.Columns, stmt.Where, args)
-				if err != nil {
-					continue
-				}
-				if !matched {
-					continue
+			if _, exists := groups[key]; !exists {
+				groupOrder = append(groupOrder, key)
+				if firstSeen != nil {
+					firstSeen[key] = int64(seq)
 				}
 			}
-
-			var groupKey strings.Builder
-			for i, spec := range specs {
-				if i > 0 {
-					groupKey.WriteString("\x00")
-				}
-				if spec.index >= 0 && spec.index < len(fullRow) {
-					groupKey.WriteString(typeTaggedKey(fullRow[spec.index]))
-				} else if spec.expr != nil {
-					val, err := evaluateExpression(c, fullRow, table.Columns, spec.expr, args)
-					if err == nil {
-						groupKey.WriteString(typeTaggedKey(val))
+			groups[key] = append(groups[key], fullRow)
+			if !budget.chargeRow(fullRow) {
+				break
+			}
+			if spillLimit > 0 && !spillable(fullRow) {
+				spillLimit = 0 // the groups stay in memory
+			}
+			if spillLimit > 0 {
+				if held += rowFootprint(fullRow); held > spillLimit {
+					spill, err = budget.spillGroups(groups, groupOrder, firstSeen)
+					if err != nil {
+						return nil, nil, nil, fmt.Errorf("group by: %w", err)
 					}
+					groups, groupOrder = nil, nil
 				}
 			}
-			key := groupKey.String()
-			if _, exists := groups[key]; !exists {
-				groupOrder = append(groupOrder, key)
-			}
-			groups[key] = append(groups[key], fullRow)
+		}
+		if spill != nil {
+			return nil, nil, spill, nil
 		}
 	}
 
-	return groups, groupOrder, nil
+	return groups, groupOrder, nil, nil
 }
 
 func (c *Catalog) buildGroupByGroupsFromRows(table *TableDef, stmt *query.SelectStmt, args []interface{}, specs []groupBySpec, rows [][]interface{}) (map[string][][]interface{}, []string) {
 	groups := make(map[string][][]interface{})
 	var groupOrder []string
+	budget := c.budget()
 	for _, fullRow := range rows {
 		if stmt.Where != nil {
 			matched, err := evaluateWhere(c, fullRow, table.Columns, stmt.Where, args)
@@ -415,6 +476,9 @@ func (c *Catalog) buildGroupByGroupsFromRows(table *TableDef, stmt *query.Select
 			groupOrder = append(groupOrder, key)
 		}
 		groups[key] = append(groups[key], fullRow)
+		if !budget.chargeRow(fullRow) {
+			break
+		}
 	}
 	return groups, groupOrder
 }
@@ -447,6 +511,10 @@ func (c *Catalog) computeAggregatesForExpr(expr query.Expression, groupRows [][]
 				values = append(values, v)
 			}
 		}
+		if v, ok := c.userAggregateResult(toUpperFast(fc.Name), values, fc.Distinct); ok {
+			aggResults[fc] = v
+			continue
+		}
 		aggResults[fc] = reduceBasicAggregateWithSeparator(toUpperFast(fc.Name), values, len(aggregateRows), isCountStar, fc.Distinct, c.groupConcatSeparatorForRows(fc, aggregateRows, exprColumns, args))
 	}
 	return aggResults
@@ -619,6 +687,9 @@ func reduceBasicAggregateWithSeparator(funcName string, values []interface{}, gr
 }
 
 func (c *Catalog) collectAggregateInput(ci selectColInfo, row []interface{}, columns []ColumnDef, args []interface{}, fallback func() (interface{}, bool)) (interface{}, bool) {
+	if c.userAggregate(strings.ToUpper(ci.aggregateType)) != nil {
+		return c.collectUserAggregateArgs(ci.aggregateArgs, row, columns, args)
+	}
 	switch strings.ToUpper(ci.aggregateType) {
 	case "JSON_OBJECTAGG":
 		if len(ci.aggregateArgs) < 2 {
@@ -747,22 +818,21 @@ func (c *Catalog) filterAggregateRows(filter query.Expression, rows [][]interfac
 	return filtered
 }
 
+// compareOrderByValues orders two values for one ORDER BY key. NULLs sort
+// last for ASC and first for DESC unless NULLS FIRST/LAST says otherwise.
 func compareOrderByValues(left, right interface{}, ob *query.OrderByExpr) int {
 	if left == nil || right == nil {
-		switch {
-		case left == nil && right == nil:
+		if left == nil && right == nil {
 			return 0
-		case ob.NullsSpecified && ob.NullsFirst:
-			if left == nil {
-				return -1
-			}
-			return 1
-		case ob.NullsSpecified && !ob.NullsFirst:
-			if left == nil {
-				return 1
-			}
+		}
+		nullsFirst := ob.Desc
+		if ob.NullsSpecified {
+			nullsFirst = ob.NullsFirst
+		}
+		if (left == nil) == nullsFirst {
 			return -1
 		}
+		return 1
 	}
 	cmp := compareValues(left, right)
 	if ob.Desc {
@@ -824,10 +894,15 @@ func (c *Catalog) evaluateExprWithGroupAggregatesJoin(expr query.Expression, gro
 	return evaluateExpression(c, baseRow, allColumns, replaced, args)
 }
 
-func (c *Catalog) applyGroupByOrderBy(rows [][]interface{}, selectCols []selectColInfo, orderBy []*query.OrderByExpr) [][]interface{} {
+func (c *Catalog) applyGroupByOrderBy(rows [][]interface{}, selectCols []selectColInfo, orderBy []*query.OrderByExpr, args []interface{}) [][]interface{} {
 	if len(rows) == 0 || len(orderBy) == 0 {
 		return rows
 	}
+	endSort := c.budget().beginOperator(OperatorSort, "")
+	defer func() { endSort(len(rows)) }()
+	if !c.budget().chargeRows(rows) {
+		return rows
+	}
 
 	// Build a map from column name to selectCols index
 	nameToIndex := make(map[string]int)
@@ -840,149 +915,129 @@ func (c *Catalog) applyGroupByOrderBy(rows [][]interface{}, selectCols []selectC
 		}
 	}
 
+	// Terms naming an output column or aggregate sort by that column. Other
+	// expressions, such as SUM(a) * -1 or LENGTH(name), are evaluated once
+	// per grouped row and sorted by as an extra trailing column.
+	width := len(rows[0])
+	termCols := make([]int, len(orderBy))
+	var computed []*query.OrderByExpr
+	for k, ob := range orderBy {
+		idx, ok := groupOrderByColumn(ob.Expr, selectCols, nameToIndex)
+		if !ok {
+			idx = width + len(computed)
+			computed = append(computed, ob)
+		}
+		termCols[k] = idx
+	}
+
 	sorted := make([][]interface{}, len(rows))
 	copy(sorted, rows)
-
-	sort.Slice(sorted, func(i, j int) bool {
-		for _, ob := range orderBy {
-			// Get the column name from the ORDER BY expression
-			var colName string
-			var exprArgMatch query.Expression // for expression-arg aggregates
-			if ident, ok := ob.Expr.(*query.Identifier); ok {
-				colName = ident.Name
-			} else if fn, ok := ob.Expr.(*query.FunctionCall); ok {
-				// Handle aggregate in ORDER BY
-				colName = fn.Name + "("
-				if len(fn.Args) > 0 {
-					switch arg := fn.Args[0].(type) {
-					case *query.Identifier:
-						colName += arg.Name + ")"
-					case *query.QualifiedIdentifier:
-						colName += arg.Column + ")"
-					case *query.StarExpr:
-						colName += "*)"
-					default:
-						// Expression argument (e.g., SUM(price * quantity))
-						// Use "*)" for name matching but also store for direct comparison
-						colName += "*)"
-						exprArgMatch = fn.Args[0]
-					}
-				} else {
-					colName += "*)"
-				}
-			} else if qi, ok := ob.Expr.(*query.QualifiedIdentifier); ok {
-				colName = qi.Column
-			} else if nl, ok := ob.Expr.(*query.NumberLiteral); ok {
-				// Positional ORDER BY (ORDER BY 1, 2, etc.)
-				pos := int(nl.Value) - 1 // 1-based to 0-based
-				if pos >= 0 && pos < len(selectCols) {
-					vi := sorted[i][pos]
-					vj := sorted[j][pos]
-					if vi == nil && vj == nil {
-						continue
-					}
-					if vi == nil {
-						return !ob.Desc
-					}
-					if vj == nil {
-						return ob.Desc
-					}
-					viF, viNum := toFloat64(vi)
-					vjF, vjNum := toFloat64(vj)
-					if viNum && vjNum {
-						if viF < vjF {
-							return !ob.Desc
-						} else if viF > vjF {
-							return ob.Desc
-						}
-						continue
-					}
-					viS := ValueToStringKey(vi)
-					vjS := ValueToStringKey(vj)
-					if viS < vjS {
-						return !ob.Desc
-					} else if viS > vjS {
-						return ob.Desc
-					}
+	if len(computed) > 0 {
+		for i, row := range sorted {
+			extended := make([]interface{}, width, width+len(computed))
+			copy(extended, row)
+			for _, ob := range computed {
+				val, err := evaluateExpression(c, nil, nil, resolveAggregateInExpr(ob.Expr, selectCols, row), args)
+				if err != nil {
+					val = nil
 				}
-				continue
+				extended = append(extended, val)
 			}
+			sorted[i] = extended
+		}
+	}
 
-			idx, ok := nameToIndex[toUpperFast(colName)]
-			if !ok {
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for k, ob := range orderBy {
+			idx := termCols[k]
+			if idx < 0 || idx >= len(sorted[i]) || idx >= len(sorted[j]) {
 				continue
 			}
-
-			// For expression-arg aggregates, verify we found the right one
-			// (multiple aggregates could share "SUM(*)" name but have different expressions)
-			if exprArgMatch != nil {
-				// Try to find exact match by aggregateExpr
-				foundExact := false
-				for k, ci := range selectCols {
-					if ci.isAggregate && ci.aggregateExpr == exprArgMatch {
-						idx = k
-						foundExact = true
-						break
-					}
+			vi, vj := sorted[i][idx], sorted[j][idx]
+			if vi == nil || vj == nil {
+				if cmp := compareOrderByValues(vi, vj, ob); cmp != 0 {
+					return cmp < 0
 				}
-				_ = foundExact // Fallback: use the name-matched index
-			}
-
-			// Compare values
-			vi := sorted[i][idx]
-			vj := sorted[j][idx]
-
-			// Handle nil values
-			if vi == nil && vj == nil {
 				continue
 			}
-			if vi == nil {
-				return !ob.Desc
-			}
-			if vj == nil {
-				return ob.Desc
-			}
-
-			// Integer-typed values compare directly as int64 (avoid float64
-			// precision loss for values > 2^53; see compareValues).
-			if viI, iok := compareAsInt64(vi); iok {
-				if vjI, jok := compareAsInt64(vj); jok {
-					if viI < vjI {
-						return !ob.Desc
-					} else if viI > vjI {
-						return ob.Desc
-					}
-					continue
-				}
-			}
-
-			// Compare based on type
-			viF, viNum := toFloat64(vi)
-			vjF, vjNum := toFloat64(vj)
-			if viNum && vjNum {
-				if viF < vjF {
-					return !ob.Desc
-				} else if viF > vjF {
-					return ob.Desc
-				}
-				continue
-			}
-			if viS, ok1 := toString(vi); ok1 {
-				if vjS, ok2 := toString(vj); ok2 {
-					if viS < vjS {
-						return !ob.Desc
-					} else if viS > vjS {
-						return ob.Desc
-					}
+			if cmp := compareCollated(vi, vj, orderByCollation(ob, selectCols, idx)); cmp != 0 {
+				if ob.Desc {
+					return cmp > 0
 				}
+				return cmp < 0
 			}
 		}
 		return false
 	})
 
+	if len(computed) > 0 {
+		for i := range sorted {
+			sorted[i] = sorted[i][:width]
+		}
+	}
 	return sorted
 }
 
+// groupOrderByColumn returns the grouped-row column an ORDER BY term sorts
+// by: a position, an output name or alias, or an aggregate in the select
+// list. ok is false for expressions that must be evaluated per row. A
+// constant, or a position the rows lack, sorts nothing and yields -1.
+func groupOrderByColumn(expr query.Expression, selectCols []selectColInfo, nameToIndex map[string]int) (int, bool) {
+	var colName string
+	var exprArgMatch query.Expression // for expression-arg aggregates
+	switch e := expr.(type) {
+	case *query.NumberLiteral:
+		if pos, ok := orderByPosition(e); ok && pos >= 1 && pos <= len(selectCols) {
+			return pos - 1, true
+		}
+		return -1, true
+	case *query.Identifier:
+		colName = e.Name
+	case *query.QualifiedIdentifier:
+		colName = e.Column
+	case *query.FunctionCall:
+		if !isAggregateCall(e) {
+			return 0, false
+		}
+		// Handle aggregate in ORDER BY
+		colName = e.Name + "("
+		if len(e.Args) > 0 {
+			switch arg := e.Args[0].(type) {
+			case *query.Identifier:
+				colName += arg.Name + ")"
+			case *query.QualifiedIdentifier:
+				colName += arg.Column + ")"
+			case *query.StarExpr:
+				colName += "*)"
+			default:
+				// Expression argument (e.g., SUM(price * quantity))
+				// Use "*)" for name matching but also store for direct comparison
+				colName += "*)"
+				exprArgMatch = e.Args[0]
+			}
+		} else {
+			colName += "*)"
+		}
+	default:
+		return 0, false
+	}
+
+	idx, ok := nameToIndex[toUpperFast(colName)]
+	if !ok {
+		return 0, false
+	}
+	// Multiple aggregates could share the "SUM(*)" name but have different
+	// expressions; prefer the one with this very argument.
+	if exprArgMatch != nil {
+		for k, ci := range selectCols {
+			if ci.isAggregate && ci.aggregateExpr == exprArgMatch {
+				return k, true
+			}
+		}
+	}
+	return idx, true
+}
+
 func addHiddenOrderByCols(orderBy []*query.OrderByExpr, selectCols []selectColInfo, table *TableDef) ([]selectColInfo, int) {
 	if len(orderBy) == 0 || table == nil {
 		return selectCols, 0
@@ -1003,8 +1058,9 @@ func addHiddenOrderByCols(orderBy []*query.OrderByExpr, selectCols []selectColIn
 				colIdx := table.GetColumnIndex(expr.Name)
 				if colIdx >= 0 {
 					selectCols = append(selectCols, selectColInfo{
-						name:  expr.Name,
-						index: colIdx,
+						name:      expr.Name,
+						index:     colIdx,
+						collation: table.Columns[colIdx].Collation,
 					})
 					added++
 				}
@@ -1379,8 +1435,24 @@ func resolveAggregateInExpr(expr query.Expression, selectCols []selectColInfo, r
 					return valueToLiteral(row[i])
 				}
 			}
+			return e
 		}
-		return e
+		// Scalar function over grouped values, e.g. LENGTH(name) or
+		// COALESCE(SUM(x), 0)
+		newArgs := make([]query.Expression, len(e.Args))
+		changed := false
+		for i, arg := range e.Args {
+			newArgs[i] = resolveAggregateInExpr(arg, selectCols, row)
+			if newArgs[i] != arg {
+				changed = true
+			}
+		}
+		if !changed {
+			return e
+		}
+		resolved := *e
+		resolved.Args = newArgs
+		return &resolved
 	case *query.Identifier:
 		// Try to find identifier in selectCols (works for both aggregate aliases and regular columns)
 		for i, sc := range selectCols {
@@ -1434,6 +1506,15 @@ func resolveAggregateInExpr(expr query.Expression, selectCols []selectColInfo, r
 			Expr: resolveAggregateInExpr(e.Expr, selectCols, row),
 			Not:  e.Not,
 		}
+	case *query.CollateExpr:
+		return &query.CollateExpr{
+			Expr:      resolveAggregateInExpr(e.Expr, selectCols, row),
+			Collation: e.Collation,
+		}
+	case *query.CastExpr:
+		resolved := *e
+		resolved.Expr = resolveAggregateInExpr(e.Expr, selectCols, row)
+		return &resolved
 	default:
 		return e
 	}
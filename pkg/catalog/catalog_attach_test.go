@@ -0,0 +1,51 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// A statement's query time is taken before it copies an attached table, so
+// the copied rows must be visible at an earlier time than the copy's.
+func TestAttachedTableRowsVisibleAtEarlierQueryTime(t *testing.T) {
+	c, other := newTestCatalog(t), newTestCatalog(t)
+	createTestTable(t, other, "orders", []*query.ColumnDef{{Name: "id", Type: query.TokenInteger, PrimaryKey: true}})
+	insertTestRow(t, other, "orders", []query.Expression{nr(10)})
+	if err := c.Attach("aux", other); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	table, err := c.getQualifiedTableLocked("aux", "orders")
+	if err != nil {
+		t.Fatalf("getQualifiedTableLocked: %v", err)
+	}
+	trees, err := c.getAttachedTableTrees(table)
+	if err != nil {
+		t.Fatalf("getAttachedTableTrees: %v", err)
+	}
+	iter, err := trees[0].Scan(nil, nil)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	defer iter.Close()
+	queryTime := time.Now().Add(-time.Second)
+	visible := 0
+	for iter.HasNext() {
+		_, value, err := iter.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		vrow, err := decodeVersionedRow(value, len(table.Columns))
+		if err != nil {
+			t.Fatalf("decodeVersionedRow: %v", err)
+		}
+		if vrow.Version.isVisibleAt(queryTime) {
+			visible++
+		}
+	}
+	if visible != 1 {
+		t.Fatalf("visible rows = %d, want 1", visible)
+	}
+}
@@ -0,0 +1,565 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+)
+
+// Bloom index blocks. A block is built with bloomBlockRows rows and split in
+// two once it grows past bloomBlockMaxRows; its filter is sized for the
+// larger count at bloomBitsPerRow bits per row, which keeps the false
+// positive rate below 1% with bloomHashes hash functions. Filters are never
+// cleared: deleted and updated-away values stay set, and the halves of a
+// split block both keep the full filter, so a block may be visited needlessly
+// but never skipped wrongly. The WHERE clause is re-checked on every row.
+const (
+	bloomBlockRows    = 128
+	bloomBlockMaxRows = 2 * bloomBlockRows
+	bloomBitsPerRow   = 10
+	bloomHashes       = 7
+	bloomWords        = bloomBlockMaxRows * bloomBitsPerRow / 64
+)
+
+type bloomBlock struct {
+	keys []string // sorted storage keys of the block's rows
+	bits [bloomWords]uint64
+}
+
+// bloomHash returns the two halves used for double hashing valueKey.
+func bloomHash(valueKey string) (uint32, uint32) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(valueKey))
+	sum := h.Sum64()
+	return uint32(sum), uint32(sum>>32) | 1 // #nosec G115 -- splitting a hash
+}
+
+func (b *bloomBlock) add(valueKey string) {
+	h1, h2 := bloomHash(valueKey)
+	for i := uint32(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % (bloomWords * 64)
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloomBlock) mayContain(valueKey string) bool {
+	h1, h2 := bloomHash(valueKey)
+	for i := uint32(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % (bloomWords * 64)
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomValueKey is the filter entry for v in col. It uses the B+Tree index
+// key encoding, so the index agrees with exact-match index lookups on which
+// values are equal.
+func bloomValueKey(col *ColumnDef, v interface{}) string {
+	return typeTaggedKey(collatedIndexValue(col, v))
+}
+
+// CreateBloomIndex creates a bloom filter index over a column, serving
+// col = value and col IN (values) lookups.
+func (c *Catalog) CreateBloomIndex(stmt *query.CreateIndexStmt) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.invalidateSchemaCache()
+	if c.indexNameTakenLocked(stmt.Index) {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrIndexExists
+	}
+
+	table, err := c.getTableLocked(stmt.Table)
+	if err != nil {
+		return err
+	}
+	if stmt.Unique {
+		return fmt.Errorf("bloom index %s cannot be UNIQUE", stmt.Index)
+	}
+	if len(stmt.Columns) != 1 || stmt.JSONPath != "" {
+		return fmt.Errorf("bloom index %s must cover exactly one column", stmt.Index)
+	}
+	colIdx := table.GetColumnIndex(stmt.Columns[0])
+	if colIdx < 0 {
+		return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found in table '%s'", stmt.Columns[0], stmt.Table)
+	}
+
+	bloom := &BloomIndexDef{
+		Name:      stmt.Index,
+		TableName: table.Name,
+		Column:    table.Columns[colIdx].Name,
+	}
+	if err := c.buildBloomIndexLocked(bloom, table); err != nil {
+		return err
+	}
+	if !table.Temporary {
+		if err := c.storeBloomIndexDef(bloom); err != nil {
+			return err
+		}
+	}
+
+	c.bloomIndexes[bloom.Name] = bloom
+	c.bloomCount.Store(int32(len(c.bloomIndexes))) // #nosec G115 -- index count
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{
+			action:    undoCreateBloomIndex,
+			indexName: bloom.Name,
+		})
+	}
+	return nil
+}
+
+func (c *Catalog) storeBloomIndexDef(bloom *BloomIndexDef) error {
+	data, err := json.Marshal(bloom)
+	if err != nil {
+		return err
+	}
+	if c.tree != nil {
+		return c.tree.Put([]byte("bloom:"+bloom.Name), data)
+	}
+	return nil
+}
+
+// dropBloomIndexLocked drops the bloom index name.
+func (c *Catalog) dropBloomIndexLocked(name string) error {
+	bloom, exists := c.bloomIndexes[name]
+	if !exists {
+		return ErrIndexNotFound
+	}
+	if err := c.deleteCatalogDef("bloom:" + name); err != nil {
+		return fmt.Errorf("failed to delete index metadata %s: %w", name, err)
+	}
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{
+			action:        undoDropBloomIndex,
+			indexName:     name,
+			bloomIndexDef: bloom,
+		})
+	}
+	delete(c.bloomIndexes, name)
+	c.bloomCount.Store(int32(len(c.bloomIndexes))) // #nosec G115 -- index count
+	return nil
+}
+
+// dropBloomIndexesForTableLocked drops the bloom indexes of a dropped table.
+func (c *Catalog) dropBloomIndexesForTableLocked(tableName string) error {
+	for name, bloom := range c.bloomIndexes {
+		if bloom.TableName == tableName {
+			if err := c.dropBloomIndexLocked(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetBloomIndex returns the definition of the bloom index name, without its
+// blocks.
+func (c *Catalog) GetBloomIndex(name string) (*BloomIndexDef, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	bloom, exists := c.bloomIndexes[name]
+	if !exists {
+		return nil, ErrIndexNotFound
+	}
+	return &BloomIndexDef{Name: bloom.Name, TableName: bloom.TableName, Column: bloom.Column}, nil
+}
+
+// buildBloomIndexLocked fills bloom from the table's rows. As for GIN
+// indexes, rows with buffered writes in the current transaction are indexed
+// under both their committed and their pending values.
+func (c *Catalog) buildBloomIndexLocked(bloom *BloomIndexDef, table *TableDef) error {
+	colIdx := table.GetColumnIndex(bloom.Column)
+	values := make(map[string][]string)
+	addRow := func(key string, value []byte) error {
+		row, ok, err := decodeLiveRow(value, len(table.Columns))
+		if err != nil || !ok || colIdx >= len(row) {
+			return err
+		}
+		values[key] = append(values[key], bloomValueKey(&table.Columns[colIdx], row[colIdx]))
+		return nil
+	}
+	if tree, exists := c.tableTrees[bloom.TableName]; exists && colIdx >= 0 {
+		iter, err := tree.Scan(nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to scan table %s for bloom index %s: %w", bloom.TableName, bloom.Name, err)
+		}
+		defer iter.Close()
+		for iter.HasNext() {
+			key, value, err := iter.Next()
+			if err != nil {
+				return fmt.Errorf("failed to read row for bloom index %s: %w", bloom.Name, err)
+			}
+			if err := addRow(string(key), value); err != nil {
+				return fmt.Errorf("failed to decode row for bloom index %s: %w", bloom.Name, err)
+			}
+		}
+		for key, pw := range c.pendingWritesForTable(bloom.TableName) {
+			if pw.Value == nil {
+				continue
+			}
+			if err := addRow(key, pw.Value); err != nil {
+				return fmt.Errorf("failed to decode pending row for bloom index %s: %w", bloom.Name, err)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	bloom.blocks = nil
+	for start := 0; start < len(keys); start += bloomBlockRows {
+		block := &bloomBlock{keys: slices.Clone(keys[start:min(start+bloomBlockRows, len(keys))])}
+		for _, key := range block.keys {
+			for _, v := range values[key] {
+				block.add(v)
+			}
+		}
+		bloom.blocks = append(bloom.blocks, block)
+	}
+	return nil
+}
+
+// blockFor returns the index of the block whose key range holds key.
+func (bloom *BloomIndexDef) blockFor(key string) int {
+	i, _ := slices.BinarySearchFunc(bloom.blocks, key, func(b *bloomBlock, key string) int {
+		return strings.Compare(b.keys[0], key)
+	})
+	if i < len(bloom.blocks) && bloom.blocks[i].keys[0] == key {
+		return i
+	}
+	return max(i-1, 0)
+}
+
+// addBloomRow records that the row stored under key holds valueKey.
+func addBloomRow(bloom *BloomIndexDef, key, valueKey string) {
+	if len(bloom.blocks) == 0 {
+		block := &bloomBlock{keys: []string{key}}
+		block.add(valueKey)
+		bloom.blocks = []*bloomBlock{block}
+		return
+	}
+	i := bloom.blockFor(key)
+	block := bloom.blocks[i]
+	block.keys = insertSortedKey(block.keys, key)
+	block.add(valueKey)
+	if len(block.keys) > bloomBlockMaxRows {
+		half := len(block.keys) / 2
+		upper := &bloomBlock{keys: slices.Clone(block.keys[half:]), bits: block.bits}
+		block.keys = slices.Clip(block.keys[:half])
+		bloom.blocks = slices.Insert(bloom.blocks, i+1, upper)
+	}
+}
+
+// removeBloomRow forgets the row stored under key. Its value stays in the
+// block's filter.
+func removeBloomRow(bloom *BloomIndexDef, key string) {
+	if len(bloom.blocks) == 0 {
+		return
+	}
+	i := bloom.blockFor(key)
+	block := bloom.blocks[i]
+	j, found := slices.BinarySearch(block.keys, key)
+	if !found {
+		return
+	}
+	block.keys = slices.Delete(block.keys, j, j+1)
+	if len(block.keys) == 0 {
+		bloom.blocks = slices.Delete(bloom.blocks, i, i+1)
+	}
+}
+
+// indexBloomRow applies a row write to the bloom indexes of table: the row
+// under oldKey (if any) is removed and row (if any) is added under newKey.
+// Callers hold bloomMu.
+func (c *Catalog) indexBloomRow(table *TableDef, oldKey, newKey string, row []interface{}) {
+	for _, bloom := range c.bloomIndexes {
+		if bloom.TableName != table.Name {
+			continue
+		}
+		if oldKey != "" && oldKey != newKey {
+			removeBloomRow(bloom, oldKey)
+		}
+		if row == nil {
+			removeBloomRow(bloom, newKey)
+			continue
+		}
+		colIdx := table.GetColumnIndex(bloom.Column)
+		if colIdx < 0 || colIdx >= len(row) {
+			continue
+		}
+		addBloomRow(bloom, newKey, bloomValueKey(&table.Columns[colIdx], row[colIdx]))
+	}
+}
+
+// updateBloomIndexesForWrite keeps the table's bloom indexes in step with a
+// row write applied directly to the table tree, like updateGINIndexesForWrite.
+func (c *Catalog) updateBloomIndexesForWrite(table *TableDef, oldKey, newKey []byte, row []interface{}) {
+	if c.bloomCount.Load() == 0 {
+		return
+	}
+	c.bloomMu.Lock()
+	defer c.bloomMu.Unlock()
+	if row == nil {
+		c.indexBloomRow(table, "", string(oldKey), nil)
+		return
+	}
+	c.indexBloomRow(table, string(oldKey), string(newKey), row)
+}
+
+// bloomPendingWrites returns the buffered writes of ts that touch tables with
+// bloom indexes, to be applied by applyBloomWrites once the commit succeeds.
+func (c *Catalog) bloomPendingWrites(ts *catalogTxnState) []PendingWrite {
+	if ts == nil || len(ts.pendingWrites) == 0 || c.bloomCount.Load() == 0 {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var writes []PendingWrite
+	for _, pw := range ts.pendingWrites {
+		for _, bloom := range c.bloomIndexes {
+			if bloom.TableName == pw.TreeName {
+				writes = append(writes, pw)
+				break
+			}
+		}
+	}
+	return writes
+}
+
+// applyBloomWrites indexes committed buffered writes, in commit order.
+func (c *Catalog) applyBloomWrites(writes []PendingWrite) {
+	if len(writes) == 0 {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.bloomMu.Lock()
+	defer c.bloomMu.Unlock()
+	for _, pw := range writes {
+		table, ok := c.tables[pw.TreeName]
+		if !ok {
+			continue
+		}
+		var row []interface{}
+		if pw.Value != nil {
+			if live, ok, err := decodeLiveRow(pw.Value, len(table.Columns)); err == nil && ok {
+				row = live
+			}
+		}
+		c.indexBloomRow(table, "", pw.Key, row)
+	}
+}
+
+// rebuildBloomIndexesAfterUndo rebuilds the bloom indexes of the tables whose
+// rows undoLog[from:] restored, as rebuildGINIndexesAfterUndo does.
+func (c *Catalog) rebuildBloomIndexesAfterUndo(undoLog []undoEntry, from int) {
+	if c.bloomCount.Load() == 0 {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.bloomMu.Lock()
+	defer c.bloomMu.Unlock()
+	for _, bloom := range c.bloomIndexes {
+		restored := false
+		for i := max(from, 0); i < len(undoLog) && !restored; i++ {
+			restored = undoLog[i].tableName == bloom.TableName
+		}
+		if !restored {
+			continue
+		}
+		if table, err := c.getTableLocked(bloom.TableName); err == nil {
+			// The old blocks may miss restored rows, so a failed rebuild
+			// takes the index out of use until the catalog is reloaded.
+			bloom.stale = c.buildBloomIndexLocked(bloom, table) != nil
+		}
+	}
+}
+
+// loadBloomIndexesLocked loads the bloom index definitions and builds their
+// blocks. It runs after the tables have been loaded.
+func (c *Catalog) loadBloomIndexesLocked() error {
+	iter, err := c.tree.Scan([]byte("bloom:"), []byte("bloom;"))
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to scan bloom index metadata: %w", err)
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		key, value, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("load catalog: failed to read bloom index metadata: %w", err)
+		}
+		name, ok := strings.CutPrefix(string(key), "bloom:")
+		if !ok {
+			continue
+		}
+		var bloom BloomIndexDef
+		if err := json.Unmarshal(value, &bloom); err != nil {
+			return fmt.Errorf("load catalog: failed to parse bloom index metadata %s: %w", name, err)
+		}
+		if bloom.Name == "" {
+			bloom.Name = name
+		}
+		table, ok := c.tables[bloom.TableName]
+		if !ok {
+			continue
+		}
+		if err := c.buildBloomIndexLocked(&bloom, table); err != nil {
+			return fmt.Errorf("load catalog: %w", err)
+		}
+		c.bloomIndexes[bloom.Name] = &bloom
+	}
+	c.bloomCount.Store(int32(len(c.bloomIndexes))) // #nosec G115 -- index count
+	return nil
+}
+
+// findBloomLookup matches col = value, value = col and col IN (values)
+// against the table's bloom indexes and returns the index with the filter
+// entries to probe. With args nil only the shape is checked and the entries
+// are left empty.
+func (c *Catalog) findBloomLookup(tableName string, expr query.Expression, args []interface{}) (*BloomIndexDef, []string, bool) {
+	var colExpr query.Expression
+	var valueExprs []query.Expression
+	switch e := expr.(type) {
+	case *query.BinaryExpr:
+		if e.Operator != query.TokenEq {
+			return nil, nil, false
+		}
+		colExpr, valueExprs = e.Left, []query.Expression{e.Right}
+		if _, ok := e.Left.(*query.Identifier); !ok {
+			if _, ok := e.Left.(*query.QualifiedIdentifier); !ok {
+				colExpr, valueExprs = e.Right, []query.Expression{e.Left}
+			}
+		}
+	case *query.InExpr:
+		if e.Not || e.Subquery != nil || len(e.List) == 0 {
+			return nil, nil, false
+		}
+		colExpr, valueExprs = e.Expr, e.List
+	default:
+		return nil, nil, false
+	}
+	var colName string
+	switch col := colExpr.(type) {
+	case *query.Identifier:
+		colName = col.Name
+	case *query.QualifiedIdentifier:
+		if !strings.EqualFold(col.Table, tableName) {
+			return nil, nil, false
+		}
+		colName = col.Column
+	default:
+		return nil, nil, false
+	}
+	var bloom *BloomIndexDef
+	for _, b := range c.bloomIndexes {
+		if b.TableName == tableName && !b.stale && strings.EqualFold(b.Column, colName) {
+			bloom = b
+			break
+		}
+	}
+	if bloom == nil {
+		return nil, nil, false
+	}
+	table, ok := c.tables[tableName]
+	if !ok {
+		return nil, nil, false
+	}
+	col := &table.Columns[table.GetColumnIndex(bloom.Column)]
+
+	var valueKeys []string
+	for _, ve := range valueExprs {
+		if _, ok := ve.(*query.PlaceholderExpr); ok && args == nil {
+			continue
+		}
+		v := c.extractLiteralValue(ve, args)
+		if v == nil {
+			return nil, nil, false
+		}
+		valueKeys = append(valueKeys, bloomValueKey(col, v))
+	}
+	return bloom, valueKeys, true
+}
+
+// useBloomIndexForWhere returns the storage keys of the rows that may satisfy
+// where according to the table's bloom indexes, in key order. AND intersects
+// the candidates of its servable sides; OR needs both sides servable.
+func (c *Catalog) useBloomIndexForWhere(tableName string, where query.Expression, args []interface{}) ([]string, bool) {
+	if bin, ok := where.(*query.BinaryExpr); ok {
+		switch bin.Operator {
+		case query.TokenAnd:
+			left, lok := c.useBloomIndexForWhere(tableName, bin.Left, args)
+			right, rok := c.useBloomIndexForWhere(tableName, bin.Right, args)
+			switch {
+			case lok && rok:
+				return intersectSortedKeys(left, right), true
+			case lok:
+				return left, true
+			}
+			return right, rok
+		case query.TokenOr:
+			left, lok := c.useBloomIndexForWhere(tableName, bin.Left, args)
+			if !lok {
+				return nil, false
+			}
+			right, rok := c.useBloomIndexForWhere(tableName, bin.Right, args)
+			if !rok {
+				return nil, false
+			}
+			return unionSortedKeys(left, right), true
+		}
+	}
+	bloom, valueKeys, ok := c.findBloomLookup(tableName, where, args)
+	if !ok || len(valueKeys) == 0 {
+		return nil, false
+	}
+	c.bloomMu.RLock()
+	defer c.bloomMu.RUnlock()
+	var keys []string
+	for _, block := range bloom.blocks {
+		for _, v := range valueKeys {
+			if block.mayContain(v) {
+				keys = append(keys, block.keys...)
+				break
+			}
+		}
+	}
+	return keys, true
+}
+
+// BloomIndexForWhere names a bloom index the planner can use for where on
+// tableName, or returns "" if there is none. It is used by EXPLAIN.
+func (c *Catalog) BloomIndexForWhere(tableName string, where query.Expression) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var find func(query.Expression) string
+	find = func(expr query.Expression) string {
+		if bin, ok := expr.(*query.BinaryExpr); ok && (bin.Operator == query.TokenAnd || bin.Operator == query.TokenOr) {
+			left, right := find(bin.Left), find(bin.Right)
+			if bin.Operator == query.TokenOr && (left == "" || right == "") {
+				return ""
+			}
+			if left != "" {
+				return left
+			}
+			return right
+		}
+		if bloom, _, ok := c.findBloomLookup(tableName, expr, nil); ok {
+			return bloom.Name
+		}
+		return ""
+	}
+	return find(where)
+}
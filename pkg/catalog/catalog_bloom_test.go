@@ -0,0 +1,123 @@
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+func bloomCandidates(t *testing.T, c *Catalog, sql string, args ...interface{}) ([]string, bool) {
+	t.Helper()
+	stmt, err := query.Parse(sql)
+	if err != nil {
+		t.Fatalf("parse %q: %v", sql, err)
+	}
+	sel := stmt.(*query.SelectStmt)
+	return c.useBloomIndexForWhere(sel.From.Name, sel.Where, args)
+}
+
+func TestBloomIndexSkipsBlocks(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)")
+	for i := 0; i < 1000; i++ {
+		jcExec(t, c, fmt.Sprintf("INSERT INTO users VALUES (%d, 'user%d@example.com')", i, i))
+	}
+	jcExec(t, c, "CREATE INDEX idx_users_email ON users USING BLOOM (email)")
+
+	pkKey := func(id int) string {
+		key, _ := formatKeyComponent(int64(id))
+		return key
+	}
+	lookup := func(sql string, args ...interface{}) []string {
+		t.Helper()
+		keys, ok := bloomCandidates(t, c, sql, args...)
+		if !ok {
+			t.Fatalf("%s: bloom index not used", sql)
+		}
+		return keys
+	}
+
+	keys := lookup("SELECT * FROM users WHERE email = 'user500@example.com'")
+	if !slices.Contains(keys, pkKey(500)) {
+		t.Fatalf("candidates miss the matching row")
+	}
+	if len(keys) >= 1000/2 {
+		t.Fatalf("%d candidates for a single value; blocks were not skipped", len(keys))
+	}
+	if keys := lookup("SELECT * FROM users WHERE email = ?", "nobody@example.com"); len(keys) > 2*bloomBlockRows {
+		t.Errorf("%d candidates for a missing value", len(keys))
+	}
+	keys = lookup("SELECT * FROM users WHERE email IN ('user1@example.com', 'user999@example.com')")
+	if !slices.Contains(keys, pkKey(1)) || !slices.Contains(keys, pkKey(999)) {
+		t.Fatalf("IN candidates miss a matching row")
+	}
+	if !slices.IsSorted(keys) {
+		t.Fatalf("candidates are not in key order")
+	}
+	for _, sql := range []string{
+		"SELECT * FROM users WHERE email <> 'x'",
+		"SELECT * FROM users WHERE email = 'x' OR id > 3",
+		"SELECT * FROM users WHERE email NOT IN ('x')",
+	} {
+		if _, ok := bloomCandidates(t, c, sql); ok {
+			t.Errorf("%s: bloom index used, want a full scan", sql)
+		}
+	}
+
+	// Writes keep every live row reachable, through block splits too.
+	for i := 1000; i < 1600; i++ {
+		jcExec(t, c, fmt.Sprintf("INSERT INTO users VALUES (%d, 'user%d@example.com')", i, i))
+	}
+	jcExec(t, c, "UPDATE users SET email = 'moved@example.com' WHERE id = 7")
+	jcExec(t, c, "DELETE FROM users WHERE id = 8")
+	for _, tt := range []struct {
+		email string
+		id    int
+	}{{"user1500@example.com", 1500}, {"moved@example.com", 7}, {"user999@example.com", 999}} {
+		if keys := lookup("SELECT * FROM users WHERE email = ?", tt.email); !slices.Contains(keys, pkKey(tt.id)) {
+			t.Errorf("candidates for %s miss row %d", tt.email, tt.id)
+		}
+	}
+	if keys := lookup("SELECT * FROM users WHERE email = 'user8@example.com'"); slices.Contains(keys, pkKey(8)) {
+		t.Errorf("deleted row 8 is still a candidate")
+	}
+
+	c.BeginTransaction(10)
+	jcExec(t, c, "DELETE FROM users WHERE id = 9")
+	if err := c.RollbackTransaction(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if keys := lookup("SELECT * FROM users WHERE email = 'user9@example.com'"); !slices.Contains(keys, pkKey(9)) {
+		t.Errorf("row 9 restored by rollback is not a candidate")
+	}
+
+	r, err := c.ExecuteQuery("SELECT id FROM users WHERE email = 'moved@example.com'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Rows) != 1 || fmt.Sprint(r.Rows[0][0]) != "7" {
+		t.Fatalf("rows = %v, want [[7]]", r.Rows)
+	}
+
+	if err := c.DropIndex("idx_users_email"); err != nil {
+		t.Fatalf("DropIndex: %v", err)
+	}
+	if _, ok := bloomCandidates(t, c, "SELECT * FROM users WHERE email = 'x'"); ok {
+		t.Fatal("bloom index used after DROP INDEX")
+	}
+}
+
+func TestBloomIndexNameConflicts(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE t (id INTEGER PRIMARY KEY, a TEXT, b TEXT)")
+	jcExec(t, c, "CREATE INDEX idx_t ON t USING BLOOM (a)")
+	if _, err := c.ExecuteQuery("CREATE INDEX idx_t ON t (b)"); err == nil {
+		t.Fatal("expected an error reusing a bloom index name")
+	}
+	if _, err := c.ExecuteQuery("CREATE INDEX idx_t2 ON t USING BLOOM (a, b)"); err == nil {
+		t.Fatal("expected an error for a multi-column bloom index")
+	}
+	jcExec(t, c, "CREATE INDEX IF NOT EXISTS idx_t ON t USING BLOOM (a)")
+}
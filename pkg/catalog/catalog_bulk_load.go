@@ -0,0 +1,66 @@
+package catalog
+
+import "fmt"
+
+// DeferIndexes stops the maintenance of table's non-unique indexes until
+// finish is called, which rebuilds them from the table in one pass. Bulk
+// loads use it to avoid updating every index once per row. Unique indexes
+// are still maintained so their constraints hold during the load.
+//
+// Deferred indexes are marked IndexBuilding, so queries scan the table
+// instead of using them. The mark is persisted, so an index a crash leaves
+// unbuilt is rebuilt by Load. finish must be called even if the load fails.
+func (c *Catalog) DeferIndexes(table string) (finish func() error, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	def, err := c.getTableLocked(table)
+	if err != nil {
+		return nil, err
+	}
+	table = def.Name
+	if _, loaded := c.deferredIndexTables.LoadOrStore(table, struct{}{}); loaded {
+		return nil, fmt.Errorf("indexes of table %s are already deferred", table)
+	}
+	var deferred []*IndexDef
+	for _, idx := range c.indexes {
+		if idx.TableName == table && !idx.Unique && idx.Status == IndexActive {
+			idx.Status = IndexBuilding
+			deferred = append(deferred, idx)
+			if err := c.storeIndexDef(idx); err != nil {
+				for _, d := range deferred {
+					d.Status = IndexActive
+					_ = c.storeIndexDef(d)
+				}
+				c.deferredIndexTables.Delete(table)
+				return nil, fmt.Errorf("defer index %s: %w", idx.Name, err)
+			}
+		}
+	}
+	return func() error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.deferredIndexTables.Delete(table)
+		c.invalidateQueryCache(table)
+		if err := c.rebuildTableIndexesLocked(table); err != nil {
+			// The indexes stay out of use rather than serve partial results.
+			return fmt.Errorf("rebuild indexes of %s: %w", table, err)
+		}
+		for _, idx := range deferred {
+			idx.Status = IndexActive
+			if err := c.storeIndexDef(idx); err != nil {
+				return fmt.Errorf("store index %s: %w", idx.Name, err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// skipDeferredIndex reports whether writes to table skip idx until a bulk
+// load finishes; see DeferIndexes.
+func (c *Catalog) skipDeferredIndex(table string, idx *IndexDef) bool {
+	if idx.Unique {
+		return false
+	}
+	_, ok := c.deferredIndexTables.Load(table)
+	return ok
+}
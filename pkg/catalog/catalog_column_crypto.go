@@ -0,0 +1,93 @@
+package catalog
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownColumnKey is returned by ENCRYPT and DECRYPT for a key ID no
+// key is registered under.
+var ErrUnknownColumnKey = errors.New("unknown column encryption key")
+
+// SetColumnKey registers the AES key, of 16, 24 or 32 bytes, that ENCRYPT
+// and DECRYPT use for key ID id; a nil key removes it. The catalog keeps
+// keys only in memory, so they must be registered again on each open.
+func (c *Catalog) SetColumnKey(id string, key []byte) error {
+	if id == "" {
+		return fmt.Errorf("column key ID must not be empty")
+	}
+	if key == nil {
+		c.columnKeys.Delete(id)
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("column key %s: %w", id, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("column key %s: %w", id, err)
+	}
+	c.columnKeys.Store(id, aead)
+	return nil
+}
+
+// columnCipher returns the cipher of key ID id.
+func (c *Catalog) columnCipher(funcName string, arg interface{}) (cipher.AEAD, string, error) {
+	id, ok := toString(arg)
+	if !ok {
+		return nil, "", fmt.Errorf("%s: key ID must be a string", funcName)
+	}
+	if c != nil {
+		if aead, ok := c.columnKeys.Load(id); ok {
+			return aead.(cipher.AEAD), id, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%s: %w %q", funcName, ErrUnknownColumnKey, id)
+}
+
+// evaluateColumnCryptoFunction handles ENCRYPT and DECRYPT. ENCRYPT(value,
+// key_id) seals the value's text with AES-GCM under the key registered as
+// key_id, returning the nonce and ciphertext base64-encoded; DECRYPT(value,
+// key_id) opens it again. The key ID is bound to the ciphertext, so a value
+// only decrypts with the key ID it was encrypted with.
+func (c *Catalog) evaluateColumnCryptoFunction(funcName string, args []interface{}) (interface{}, bool, error) {
+	if funcName != "ENCRYPT" && funcName != "DECRYPT" {
+		return nil, false, nil
+	}
+	if len(args) != 2 {
+		return nil, true, fmt.Errorf("%s requires 2 arguments", funcName)
+	}
+	aead, id, err := c.columnCipher(funcName, args[1])
+	if err != nil {
+		return nil, true, err
+	}
+	if args[0] == nil {
+		return nil, true, nil
+	}
+	if funcName == "ENCRYPT" {
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(valueText(args[0]))+aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, true, fmt.Errorf("ENCRYPT: %w", err)
+		}
+		sealed := aead.Seal(nonce, nonce, []byte(valueText(args[0])), []byte(id))
+		return base64.StdEncoding.EncodeToString(sealed), true, nil
+	}
+	text, ok := toString(args[0])
+	if !ok {
+		return nil, true, fmt.Errorf("DECRYPT: value must be a string")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(text)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, true, fmt.Errorf("DECRYPT: value is not an ENCRYPT result")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, true, fmt.Errorf("DECRYPT: value was not encrypted with key %q or was altered", id)
+	}
+	return string(plain), true, nil
+}
@@ -0,0 +1,341 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// dictSampleBudget caps the bytes of rows a dictionary is trained on.
+	dictSampleBudget = 4 << 20
+	// dictMaxSize is the largest dictionary trained for a table.
+	dictMaxSize = 32 << 10
+	// dictMinSamples is the fewest rows a dictionary is trained on.
+	dictMinSamples = 16
+)
+
+// zstdMagic starts every zstd frame. Stored rows are JSON, which never starts
+// with it, so it tells compressed rows from rows written without a dictionary.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// rowCodec compresses row payloads with a table's dictionary.
+type rowCodec struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+// newRowCodec returns a codec that compresses with dictionary and also
+// decompresses rows written with the previous dictionaries.
+func newRowCodec(dictionary []byte, previous ...[]byte) (*rowCodec, error) {
+	enc, err := zstd.NewWriter(nil,
+		zstd.WithEncoderDict(dictionary),
+		zstd.WithEncoderCRC(false),
+		zstd.WithEncoderLevel(zstd.SpeedDefault))
+	if err != nil {
+		return nil, fmt.Errorf("compression dictionary: %w", err)
+	}
+	dicts := [][]byte{dictionary}
+	for _, d := range previous {
+		if len(d) > 0 {
+			dicts = append(dicts, d)
+		}
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dicts...), zstd.WithDecoderConcurrency(0))
+	if err != nil {
+		enc.Close()
+		return nil, fmt.Errorf("compression dictionary: %w", err)
+	}
+	return &rowCodec{enc: enc, dec: dec}, nil
+}
+
+// dictTree is a table tree whose row payloads are compressed with the
+// table's dictionary. Rows are compressed on write and decompressed on read,
+// so the rest of the catalog, the WAL and replay only see plain rows. Rows
+// that do not shrink are stored plain, and plain rows read back unchanged.
+type dictTree struct {
+	btree.TreeStore
+	codec atomic.Pointer[rowCodec] // nil = store rows plain
+}
+
+// openDictTree wraps a table tree opened at load when the table has a
+// dictionary.
+func openDictTree(tree btree.TreeStore, dictionary []byte) (btree.TreeStore, error) {
+	if len(dictionary) == 0 || tree == nil {
+		return tree, nil
+	}
+	codec, err := newRowCodec(dictionary)
+	if err != nil {
+		return nil, err
+	}
+	t := &dictTree{TreeStore: tree}
+	t.codec.Store(codec)
+	return t, nil
+}
+
+// rewrapTree returns replacement wrapped the way old is, for maintenance
+// that rebuilds a table into a new tree.
+func rewrapTree(old, replacement btree.TreeStore) btree.TreeStore {
+	dt, ok := old.(*dictTree)
+	if !ok {
+		return replacement
+	}
+	t := &dictTree{TreeStore: replacement}
+	t.codec.Store(dt.codec.Load())
+	return t
+}
+
+func (t *dictTree) encode(value []byte) []byte {
+	codec := t.codec.Load()
+	if codec == nil || len(value) == 0 {
+		return value
+	}
+	compressed := codec.enc.EncodeAll(value, make([]byte, 0, len(value)/2))
+	if len(compressed) >= len(value) {
+		return value
+	}
+	return compressed
+}
+
+func (t *dictTree) decode(value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, zstdMagic) {
+		return value, nil
+	}
+	codec := t.codec.Load()
+	if codec == nil {
+		return nil, fmt.Errorf("compressed row without a compression dictionary")
+	}
+	plain, err := codec.dec.DecodeAll(value, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompress row: %w", err)
+	}
+	return plain, nil
+}
+
+func (t *dictTree) Get(key []byte) ([]byte, error) {
+	value, err := t.TreeStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return t.decode(value)
+}
+
+func (t *dictTree) Put(key, value []byte) error {
+	return t.TreeStore.Put(key, t.encode(value))
+}
+
+func (t *dictTree) PutBatch(keys [][]byte, values [][]byte) error {
+	encoded := make([][]byte, len(values))
+	for i, v := range values {
+		encoded[i] = t.encode(v)
+	}
+	return t.TreeStore.PutBatch(keys, encoded)
+}
+
+func (t *dictTree) Scan(startKey, endKey []byte) (btree.TreeIterator, error) {
+	iter, err := t.TreeStore.Scan(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	return &dictIterator{TreeIterator: iter, tree: t}, nil
+}
+
+func (t *dictTree) ScanRange(opts btree.ScanOptions) (btree.TreeIterator, error) {
+	iter, err := t.TreeStore.ScanRange(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &dictIterator{TreeIterator: iter, tree: t}, nil
+}
+
+// recode rewrites every row of the tree with codec (nil = plain).
+func (t *dictTree) recode(codec *rowCodec) error {
+	iter, err := t.Scan(nil, nil)
+	if err != nil {
+		return err
+	}
+	var keys, values [][]byte
+	for iter.HasNext() {
+		key, value, err := iter.Next()
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		if key == nil {
+			break
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	iter.Close()
+	t.codec.Store(codec)
+	if len(keys) == 0 {
+		return nil
+	}
+	return t.PutBatch(keys, values)
+}
+
+type dictIterator struct {
+	btree.TreeIterator
+	tree *dictTree
+}
+
+func (it *dictIterator) Next() ([]byte, []byte, error) {
+	key, value, err := it.TreeIterator.Next()
+	if err != nil || key == nil {
+		return key, value, err
+	}
+	value, err = it.tree.decode(value)
+	return key, value, err
+}
+
+func (it *dictIterator) NextString() (string, []byte, error) {
+	key, value, err := it.TreeIterator.NextString()
+	if err != nil || value == nil {
+		return key, value, err
+	}
+	value, err = it.tree.decode(value)
+	return key, value, err
+}
+
+// trainRowDictionary builds a zstd dictionary from rows sampled evenly
+// across tree, at most dictSampleBudget bytes of them.
+func trainRowDictionary(tree btree.TreeStore) ([]byte, error) {
+	iter, err := tree.Scan(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var samples [][]byte
+	size, stride := 0, 1
+	for n := 1; iter.HasNext(); n++ {
+		key, value, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			break
+		}
+		if n%stride != 0 {
+			continue
+		}
+		samples = append(samples, append([]byte(nil), value...))
+		size += len(value)
+		// Over budget: keep every other sample and sample half as often.
+		for size > dictSampleBudget && len(samples) > 1 {
+			kept := samples[:0]
+			size = 0
+			for i := 1; i < len(samples); i += 2 {
+				kept = append(kept, samples[i])
+				size += len(samples[i])
+			}
+			samples = kept
+			stride *= 2
+		}
+	}
+	if len(samples) < dictMinSamples {
+		return nil, fmt.Errorf("need at least %d rows to train a compression dictionary, have %d", dictMinSamples, len(samples))
+	}
+	return dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: dictMaxSize,
+		HashBytes:   6,
+		ZstdLevel:   zstd.SpeedDefault,
+	})
+}
+
+// SetCompressionDictionary trains a zstd dictionary on a sample of table's
+// rows and recompresses the table with it. Rows of text-heavy tables repeat
+// the same keys, words and formats, which a shared dictionary compresses far
+// better than each row can on its own. Calling it again retrains the
+// dictionary on the current rows.
+func (c *Catalog) SetCompressionDictionary(table string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	def, err := c.getTableLocked(table)
+	if err != nil {
+		return err
+	}
+	return c.trainCompressionDictionaryLocked(def)
+}
+
+// DropCompressionDictionary decompresses table's rows and stops compressing
+// new ones. It is a no-op for a table without a dictionary.
+func (c *Catalog) DropCompressionDictionary(table string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	def, err := c.getTableLocked(table)
+	if err != nil {
+		return err
+	}
+	dt, ok := c.tableTrees[def.Name].(*dictTree)
+	if !ok {
+		return nil
+	}
+	if err := dt.recode(nil); err != nil {
+		return fmt.Errorf("decompress table %s: %w", def.Name, err)
+	}
+	c.tableTrees[def.Name] = dt.TreeStore
+	def.CompressionDict = nil
+	return c.storeTableDef(def)
+}
+
+// RefreshCompressionDictionaries retrains the dictionaries of table, or of
+// every table that has one when table is empty, so they keep up with
+// changes in the data.
+func (c *Catalog) RefreshCompressionDictionaries(table string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if table != "" {
+		def, err := c.getTableLocked(table)
+		if err != nil {
+			return err
+		}
+		if len(def.CompressionDict) == 0 {
+			return nil
+		}
+		return c.trainCompressionDictionaryLocked(def)
+	}
+	for _, def := range c.tables {
+		if len(def.CompressionDict) == 0 {
+			continue
+		}
+		if err := c.trainCompressionDictionaryLocked(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Must be called with c.mu held (write lock).
+func (c *Catalog) trainCompressionDictionaryLocked(def *TableDef) error {
+	if def.Partition != nil {
+		return fmt.Errorf("compression dictionaries are not supported on partitioned table %s", def.Name)
+	}
+	tree, ok := c.tableTrees[def.Name]
+	if !ok {
+		return fmt.Errorf("table %s has no storage", def.Name)
+	}
+	dictionary, err := trainRowDictionary(tree)
+	if err != nil {
+		return fmt.Errorf("train compression dictionary for %s: %w", def.Name, err)
+	}
+	codec, err := newRowCodec(dictionary, def.CompressionDict)
+	if err != nil {
+		return err
+	}
+	dt, ok := tree.(*dictTree)
+	if !ok {
+		dt = &dictTree{TreeStore: tree}
+	}
+	if err := dt.recode(codec); err != nil {
+		return fmt.Errorf("compress table %s: %w", def.Name, err)
+	}
+	c.tableTrees[def.Name] = dt
+	def.CompressionDict = dictionary
+	return c.storeTableDef(def)
+}
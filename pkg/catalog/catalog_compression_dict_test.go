@@ -0,0 +1,93 @@
+package catalog
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+func TestCompressionDictionary(t *testing.T) {
+	c := newTestCatalog(t)
+	createTestTable(t, c, "logs", []*query.ColumnDef{
+		{Name: "id", Type: query.TokenInteger, PrimaryKey: true},
+		{Name: "msg", Type: query.TokenText},
+	})
+	for i := 1; i <= 300; i++ {
+		insertTestRow(t, c, "logs", []query.Expression{
+			&query.NumberLiteral{Value: float64(i)},
+			&query.StringLiteral{Value: fmt.Sprintf("GET /api/v1/orders/%d HTTP/1.1 status=200 user-agent=Mozilla/5.0 (X11; Linux x86_64) region=eu-west-%d", i, i%3)},
+		})
+	}
+	storedBytes := func() int {
+		tree := c.tableTrees["logs"]
+		if dt, ok := tree.(*dictTree); ok {
+			tree = dt.TreeStore
+		}
+		iter, err := tree.Scan(nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer iter.Close()
+		n := 0
+		for iter.HasNext() {
+			_, v, err := iter.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			n += len(v)
+		}
+		return n
+	}
+	count := func() []interface{} {
+		_, rows := sel(t, c, &query.SelectStmt{
+			Columns: []query.Expression{&query.Identifier{Name: "msg"}},
+			From:    &query.TableRef{Name: "logs"},
+			Where: &query.BinaryExpr{
+				Left: &query.Identifier{Name: "id"}, Operator: query.TokenEq, Right: &query.NumberLiteral{Value: 42},
+			},
+		})
+		if len(rows) != 1 {
+			t.Fatalf("rows = %v", rows)
+		}
+		return rows[0]
+	}
+
+	plain := storedBytes()
+	want := count()
+	if err := c.SetCompressionDictionary("logs"); err != nil {
+		t.Fatal(err)
+	}
+	def, _ := c.GetTable("logs")
+	if len(def.CompressionDict) == 0 {
+		t.Fatal("dictionary not stored in the table definition")
+	}
+	if compressed := storedBytes(); compressed*2 > plain {
+		t.Errorf("stored %d bytes with a dictionary, %d without", compressed, plain)
+	}
+	if got := count(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("row = %v, want %v", got, want)
+	}
+	first := def.CompressionDict
+	if err := c.RefreshCompressionDictionaries(""); err != nil {
+		t.Fatal(err)
+	}
+	if def, _ := c.GetTable("logs"); string(def.CompressionDict) == string(first) {
+		t.Error("refresh did not retrain the dictionary")
+	}
+
+	if err := c.DropCompressionDictionary("logs"); err != nil {
+		t.Fatal(err)
+	}
+	if got := storedBytes(); got != plain {
+		t.Errorf("stored %d bytes after dropping the dictionary, want %d", got, plain)
+	}
+	if got := count(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("row = %v, want %v", got, want)
+	}
+
+	createTestTable(t, c, "tiny", []*query.ColumnDef{{Name: "id", Type: query.TokenInteger, PrimaryKey: true}})
+	if err := c.SetCompressionDictionary("tiny"); err == nil {
+		t.Error("expected an error training on an empty table")
+	}
+}
@@ -22,7 +22,6 @@ package catalog
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/cobaltdb/cobaltdb/pkg/btree"
 	"github.com/cobaltdb/cobaltdb/pkg/cache"
@@ -30,9 +29,12 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/parallel"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
 	"github.com/cobaltdb/cobaltdb/pkg/security"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
 	"github.com/cobaltdb/cobaltdb/pkg/txn"
 	"io"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -41,11 +43,11 @@ import (
 )
 
 var (
-	ErrTableExists    = errors.New("table already exists")
-	ErrTableNotFound  = errors.New("table not found")
-	ErrColumnNotFound = errors.New("column not found")
-	ErrIndexExists    = errors.New("index already exists")
-	ErrIndexNotFound  = errors.New("index not found")
+	ErrTableExists    = sqlerr.ErrTableExists
+	ErrTableNotFound  = sqlerr.ErrTableNotFound
+	ErrColumnNotFound = sqlerr.ErrColumnNotFound
+	ErrIndexExists    = sqlerr.ErrIndexExists
+	ErrIndexNotFound  = sqlerr.ErrIndexNotFound
 )
 
 // TableDef represents a table definition
@@ -77,8 +79,25 @@ type TableDef struct {
 	AutoIncSeq  int64           `json:"auto_inc_seq"`        // Per-table auto-increment counter
 	Partition   *PartitionInfo  `json:"partition,omitempty"` // Table partitioning info
 	Temporary   bool            `json:"-"`                   // Session-local table, not persisted
+	// CompressionDict is the zstd dictionary row payloads are compressed
+	// with (nil = rows stored plain).
+	CompressionDict []byte `json:"compression_dict,omitempty"`
+	// Engine is the storage engine holding the rows ("" = B+Tree).
+	Engine string `json:"engine,omitempty"`
+	// TTLColumn is the column whose time expires a row ("" = rows never
+	// expire); the engine's TTL sweeper deletes expired rows.
+	TTLColumn string `json:"ttl_column,omitempty"`
+	// HistoryTable is the table keeping the replaced row versions of a
+	// table created WITH SYSTEM VERSIONING ("" = not versioned). HistoryOf
+	// is set on that history table to the versioned table's name.
+	HistoryTable string `json:"history_table,omitempty"`
+	HistoryOf    string `json:"history_of,omitempty"`
 	// Performance: cache column indices (not persisted)
 	columnIndices map[string]int `json:"-"`
+	// rowIDHidden marks a per-query copy whose last column is ROWID.
+	rowIDHidden bool
+	// storedAutoIncSeq is the AutoIncSeq last written to the catalog tree.
+	storedAutoIncSeq int64
 }
 
 type CheckDef struct {
@@ -121,6 +140,7 @@ type ColumnDef struct {
 	sourceTbl     string           `json:"-"`                    // Source table name for JOIN column disambiguation
 	Collation     string           `json:"collation,omitempty"`  // Optional column collation name
 	Dimensions    int              `json:"dimensions,omitempty"` // For VECTOR type: number of dimensions
+	Mask          *ColumnMask      `json:"mask,omitempty"`       // Mask of a MASKED column
 }
 
 // IndexDef represents an index definition
@@ -140,6 +160,7 @@ type IndexDef struct {
 	RootPageID uint32      `json:"root_page_id"`
 	Status     IndexStatus `json:"status"`
 	Temporary  bool        `json:"-"`
+	JSONPath   string      `json:"json_path,omitempty"` // indexes JSON_EXTRACT(Columns[0], JSONPath) instead of the column
 }
 
 // selectColInfo holds information about selected columns in a query
@@ -162,6 +183,7 @@ type selectColInfo struct {
 	windowExpr       *query.WindowExpr // window function expression
 	hasEmbeddedAgg   bool              // true when expression (CASE, etc.) contains aggregate calls
 	originalExpr     query.Expression  // the original expression for hasEmbeddedAgg columns
+	collation        string            // COLLATE of the source column, for ORDER BY
 
 	// embeddedWindows holds window functions nested inside originalExpr (e.g.
 	// SUM(x) OVER () + 1); they are computed then substituted during projection.
@@ -177,6 +199,7 @@ type TableSnapshot struct {
 	IndexMatches []string        // row keys matching WHERE index lookup
 	UseIndex     bool            // whether index lookup was used
 	SchemaVer    uint64          // schema version at capture time (for cache invalidation)
+	Rows         RowSink         // receives the rows instead of the result slice (nil = collected)
 }
 
 // MaterializedViewDef represents a materialized view definition
@@ -196,8 +219,12 @@ type FTSIndexDef struct {
 	Name      string   `json:"name"`
 	TableName string   `json:"table_name"`
 	Columns   []string `json:"columns"`
-	// Inverted index: word -> list of row IDs
+	// Inverted index: word -> sorted IDs of the rows containing it
 	Index map[string][]int64 `json:"index"`
+	// Docs holds the term frequencies of each indexed row, keyed by row ID.
+	// Together with TotalTerms it supplies the corpus statistics for BM25.
+	Docs       map[int64]map[string]int `json:"docs,omitempty"`
+	TotalTerms int64                    `json:"total_terms,omitempty"`
 }
 
 // JSONIndexDef represents a JSON index definition (GIN-like)
@@ -211,6 +238,35 @@ type JSONIndexDef struct {
 	NumIndex  map[string][]int64 `json:"num_index,omitempty"` // for numeric values (string key to avoid precision issues)
 }
 
+// GINIndexDef is an inverted index over a JSON column (CREATE INDEX ... USING
+// GIN). Only the definition is persisted; the postings are rebuilt when the
+// catalog loads.
+type GINIndexDef struct {
+	Name      string `json:"name"`
+	TableName string `json:"table_name"`
+	Column    string `json:"column"`
+	// Postings maps each term to the sorted storage keys of the rows whose
+	// document contains it.
+	Postings map[string][]string `json:"-"`
+	// Docs holds the terms of each indexed row, keyed by storage key.
+	Docs map[string][]string `json:"-"`
+}
+
+// BloomIndexDef is a bloom filter index over a column (CREATE INDEX ... USING
+// BLOOM). The table's rows are split into blocks of consecutive storage keys,
+// each with a bloom filter of its column values, so an equality lookup only
+// visits the blocks that may hold the value. Only the definition is
+// persisted; the blocks are rebuilt when the catalog loads.
+type BloomIndexDef struct {
+	Name      string `json:"name"`
+	TableName string `json:"table_name"`
+	Column    string `json:"column"`
+	// blocks are ordered by their first storage key and cover disjoint key
+	// ranges.
+	blocks []*bloomBlock
+	stale  bool // blocks could not be rebuilt; lookups must scan
+}
+
 // undoAction represents the type of undo operation
 type undoAction int
 
@@ -239,6 +295,10 @@ const (
 	undoDropTrigger                              // Undo DROP TRIGGER by restoring the trigger
 	undoCreateProcedure                          // Undo CREATE PROCEDURE by dropping the procedure
 	undoDropProcedure                            // Undo DROP PROCEDURE by restoring the procedure
+	undoCreateSequence                           // Undo CREATE SEQUENCE by dropping the sequence
+	undoDropSequence                             // Undo DROP SEQUENCE by restoring the sequence
+	undoCreateSchema                             // Undo CREATE SCHEMA by dropping the schema
+	undoDropSchema                               // Undo DROP SCHEMA by restoring the schema
 	undoCreateMaterializedView                   // Undo CREATE MATERIALIZED VIEW by dropping the view
 	undoDropMaterializedView                     // Undo DROP MATERIALIZED VIEW by restoring the view
 	undoCreateForeignTable                       // Undo CREATE FOREIGN TABLE by dropping the foreign table
@@ -246,6 +306,10 @@ const (
 	undoEnableRLSTable                           // Undo ALTER TABLE ENABLE ROW LEVEL SECURITY
 	undoCreateRLSPolicy                          // Undo CREATE POLICY by dropping the policy
 	undoDropRLSPolicy                            // Undo DROP POLICY by restoring the policy
+	undoCreateGINIndex                           // Undo CREATE INDEX ... USING GIN by dropping the index
+	undoDropGINIndex                             // Undo DROP INDEX for a GIN index by restoring it
+	undoCreateBloomIndex                         // Undo CREATE INDEX ... USING BLOOM by dropping the index
+	undoDropBloomIndex                           // Undo DROP INDEX for a bloom index by restoring it
 )
 
 // indexUndoEntry records an index modification for rollback
@@ -274,6 +338,8 @@ type undoEntry struct {
 	indexName      string                     // For undoCreateIndex: index name to drop
 	ftsIndexDef    *FTSIndexDef               // For undoDropFTSIndex: original full-text index definition
 	vectorIndexDef *VectorIndexDef            // For undoDropVectorIndex: original vector index definition
+	ginIndexDef    *GINIndexDef               // For undoDropGINIndex: original GIN index
+	bloomIndexDef  *BloomIndexDef             // For undoDropBloomIndex: original bloom index
 	// ALTER TABLE undo fields
 	oldColumns           []ColumnDef                 // For undoAlterAddColumn/undoAlterDropColumn: original columns
 	oldForeignKeys       []ForeignKeyDef             // For undoAlterForeignKeys: original foreign keys
@@ -295,6 +361,8 @@ type undoEntry struct {
 	procedureName        string                      // For procedure undo actions
 	procedureStmt        *query.CreateProcedureStmt  // For undoDropProcedure: original procedure
 	procedureSQL         string                      // For procedure undo actions
+	sequenceDef          *SequenceDef                // For sequence undo actions
+	schemaName           string                      // For schema undo actions
 	materializedViewName string                      // For materialized view undo actions
 	materializedViewDef  *MaterializedViewDef        // For undoDropMaterializedView: original view
 	materializedViewSQL  string                      // For materialized view undo actions
@@ -309,17 +377,19 @@ type undoEntry struct {
 
 // catalogTxnState holds per-transaction state for multi-transaction support.
 type catalogTxnState struct {
-	txnID           uint64
-	txnActive       bool
-	undoLog         []undoEntry
-	savepoints      []savepointEntry
-	managerTxn      interface{}                        // *txn.Transaction when txnManager bridge is active
-	pendingWrites   []PendingWrite                     // buffered DML for commit-time application
-	pendingWriteMap map[string]map[string]PendingWrite // table -> key -> latest write (O(1) lookup)
-	readValues      map[txn.WriteKey][]byte            // key -> value at time of read (for MVCC validation)
-	rowBuf          [8]interface{}                     // reused per-transaction scratch buffer for INSERT
-	valueDataBuf    []byte                             // reused per-transaction buffer for encoded row values
-	treeCache       map[string]btree.TreeStore         // cached tree references to avoid c.mu in commit
+	txnID                uint64
+	txnActive            bool
+	undoLog              []undoEntry
+	savepoints           []savepointEntry
+	managerTxn           interface{}                        // *txn.Transaction when txnManager bridge is active
+	pendingWrites        []PendingWrite                     // buffered DML for commit-time application
+	pendingWriteMap      map[string]map[string]PendingWrite // table -> key -> latest write (O(1) lookup)
+	readValues           map[txn.WriteKey][]byte            // key -> value at time of read (for MVCC validation)
+	rowBuf               [8]interface{}                     // reused per-transaction scratch buffer for INSERT
+	valueDataBuf         []byte                             // reused per-transaction buffer for encoded row values
+	treeCache            map[string]btree.TreeStore         // cached tree references to avoid c.mu in commit
+	schemaRecords        []*storage.WALRecord               // DDL WAL records not yet written
+	schemaRecordsWritten int                                // DDL WAL records already written
 }
 
 // getPendingWriteMap returns the pending-write map, building it lazily from
@@ -356,6 +426,8 @@ type Catalog struct {
 	tree                 btree.TreeStore
 	tables               map[string]*TableDef
 	foreignTables        map[string]*ForeignTableDef // Foreign table definitions
+	attached             map[string]*Catalog         // Attached databases by lower-cased schema name
+	schemas              map[string]struct{}         // CREATE SCHEMA schemas; their tables are keyed schema.table
 	indexes              map[string]*IndexDef
 	indexTrees           map[string]btree.TreeStore // B+Trees for indexes
 	pool                 *storage.BufferPool
@@ -371,10 +443,19 @@ type Catalog struct {
 	triggerDepth         int                                   // current trigger recursion depth (guarded by c.mu)
 	procedures           map[string]*query.CreateProcedureStmt // Procedures store their definition
 	procedureSQL         map[string]string                     // Original CREATE PROCEDURE SQL for persistence
+	sequences            map[string]*SequenceDef               // CREATE SEQUENCE sequences
+	sequenceMu           sync.Mutex                            // Guards sequence values; NEXTVAL runs under c.mu.RLock
 	materializedViews    map[string]*MaterializedViewDef       // Materialized views
 	materializedViewSQL  map[string]string                     // Original CREATE MATERIALIZED VIEW SQL for persistence
 	ftsIndexes           map[string]*FTSIndexDef               // Full-text search indexes
+	ftsMu                sync.RWMutex                          // Guards the contents of ftsIndexes entries (DML may update them under c.mu.RLock)
 	jsonIndexes          map[string]*JSONIndexDef              // JSON indexes for fast JSON queries
+	ginIndexes           map[string]*GINIndexDef               // Inverted (GIN) indexes over JSON columns
+	ginMu                sync.RWMutex                          // Guards the contents of ginIndexes entries, like ftsMu
+	ginCount             atomic.Int32                          // len(ginIndexes), so commits skip GIN work without c.mu
+	bloomIndexes         map[string]*BloomIndexDef             // Bloom filter indexes
+	bloomMu              sync.RWMutex                          // Guards the blocks of bloomIndexes entries, like ginMu
+	bloomCount           atomic.Int32                          // len(bloomIndexes), so writes skip bloom work without c.mu
 	vectorIndexes        map[string]*VectorIndexDef            // Vector (HNSW) indexes for similarity search
 	stats                map[string]*StatsTableStats           // Table statistics for ANALYZE
 	cteResults           map[string]*cteResultSet              // Temporary CTE result cache for recursive CTEs
@@ -395,7 +476,12 @@ type Catalog struct {
 	// Dead tuple tracking for AutoVacuum
 	deadTuples map[string]int64 // table name -> count of soft-deleted rows
 	liveTuples map[string]int64 // table name -> count of live rows
-	vacuumMu   sync.RWMutex     // protects deadTuples and liveTuples
+	modTuples  map[string]int64 // table name -> rows written since its last ANALYZE
+	vacuumMu   sync.RWMutex     // protects deadTuples, liveTuples and modTuples
+
+	// Dropped and rebuilt trees whose pages go to the freelist at the next Save
+	releasedTrees []btree.TreeStore
+	releaseMu     sync.Mutex // protects releasedTrees
 
 	// Schema versioning and cache for lock-free metadata lookups.
 	// schemaVersion increments on every DDL; schemaCache stores TableDefs
@@ -406,8 +492,11 @@ type Catalog struct {
 	schemaCacheMu sync.RWMutex
 
 	// Parallel execution options
-	parallelWorkers   int // 0 = disabled
-	parallelThreshold int // min rows to trigger parallel
+	parallelWorkers   int            // 0 = disabled
+	parallelThreshold int            // min rows to trigger parallel
+	maxParallelism    int            // max partitions per scan or join (0 = parallelWorkers)
+	parallelPool      *parallel.Pool // shared workers for partitioned scans and joins
+	unorderedScans    bool           // SELECTs without ORDER BY may skip key order
 
 	// goroutineTxnShards maps goroutine ID -> txn state using 16 independently
 	// locked shards. This eliminates the single-RWMutex bottleneck under high
@@ -417,6 +506,16 @@ type Catalog struct {
 		m  map[uint64]*catalogTxnState
 	}
 
+	// stmtBudgets maps goroutine ID -> *statementBudget for statements run
+	// under BeginStatement; activeBudgets lets work outside any statement
+	// skip the lookup.
+	stmtBudgets   sync.Map
+	activeBudgets atomic.Int32
+
+	// deferredIndexTables holds the tables whose non-unique indexes are not
+	// maintained during a bulk load; see DeferIndexes.
+	deferredIndexTables sync.Map // table name -> struct{}
+
 	// commitMu shards the commit critical section by (table,key) hash so that
 	// transactions touching disjoint rows can validate and write in parallel.
 	commitMu [256]sync.Mutex
@@ -424,6 +523,49 @@ type Catalog struct {
 	// txnStatePool recycles per-transaction state structs to reduce GC pressure
 	// from high-frequency Begin/Commit cycles.
 	txnStatePool sync.Pool
+
+	// random makes RANDOM() reproducible after SET seed; nil means RANDOM()
+	// draws from crypto/rand.
+	random atomic.Pointer[seededRandom]
+
+	// affinity holds the TypeAffinity applied to written values.
+	affinity atomic.Int32
+
+	// userFuncs holds functions registered with RegisterFunction and
+	// RegisterAggregate; userFuncMu serializes registrations.
+	userFuncs  atomic.Pointer[userFunctions]
+	userFuncMu sync.Mutex
+
+	// foreignKeysOff suspends FOREIGN KEY enforcement; see SetForeignKeys.
+	foreignKeysOff atomic.Bool
+
+	// likeNoCase makes LIKE ignore case; see SetCaseSensitiveLike.
+	likeNoCase atomic.Bool
+
+	// rowLockTimeout is the row lock wait, in nanoseconds; see
+	// SetRowLockTimeout.
+	rowLockTimeout atomic.Int64
+
+	// changeCapture receives committed row changes; see SetChangeCapture.
+	changeCapture atomic.Pointer[ChangeCaptureFunc]
+
+	// flashback rebuilds rows for AS OF queries; see SetFlashbackSource.
+	flashback atomic.Pointer[FlashbackFunc]
+	// flags caches which table features the schema uses; see
+	// tableFlagsLocked.
+	flags atomic.Pointer[tableFlags]
+	// unmasking holds the tables being read unmasked to mask them.
+	unmasking map[string]bool
+	// columnKeys maps key IDs to the ciphers ENCRYPT and DECRYPT use; see
+	// SetColumnKey.
+	columnKeys sync.Map
+
+	// preparedRows maps each row a prepared transaction writes to that
+	// transaction's ID; see PrepareTransaction. preparedCount is its
+	// length, so commits skip the check without preparedMu.
+	preparedRows  map[txn.WriteKey]string
+	preparedMu    sync.Mutex
+	preparedCount atomic.Int32
 }
 
 func (c *Catalog) commitLockIdx(treeName string, key string) int {
@@ -446,6 +588,7 @@ type savepointEntry struct {
 	name            string
 	undoPos         int // Position in undoLog at time of savepoint creation
 	pendingWritePos int // Position in pendingWrites at time of savepoint creation (buffered mode)
+	schemaRecordPos int // Position in schemaRecords at time of savepoint creation
 }
 
 // cteResultSet holds pre-computed results for recursive CTEs
@@ -475,10 +618,13 @@ func New(tree btree.TreeStore, pool *storage.BufferPool, wal *storage.WAL) *Cata
 		triggerSQL:          make(map[string]string),
 		procedures:          make(map[string]*query.CreateProcedureStmt),
 		procedureSQL:        make(map[string]string),
+		sequences:           make(map[string]*SequenceDef),
 		materializedViews:   make(map[string]*MaterializedViewDef),
 		materializedViewSQL: make(map[string]string),
 		ftsIndexes:          make(map[string]*FTSIndexDef),
 		jsonIndexes:         make(map[string]*JSONIndexDef),
+		ginIndexes:          make(map[string]*GINIndexDef),
+		bloomIndexes:        make(map[string]*BloomIndexDef),
 		vectorIndexes:       make(map[string]*VectorIndexDef),
 		stats:               make(map[string]*StatsTableStats),
 		rlsPolicies:         make(map[string]*security.Policy),
@@ -505,6 +651,37 @@ func (c *Catalog) SetParallelOptions(workers, threshold int) {
 	defer c.mu.Unlock()
 	c.parallelWorkers = workers
 	c.parallelThreshold = threshold
+	c.parallelPool = nil
+	if workers > 0 {
+		c.parallelPool = parallel.NewPool(workers)
+	}
+}
+
+// SetMaxParallelism caps how many partitions a single table scan or
+// hash-join probe is split into (0 = one per parallel worker).
+func (c *Catalog) SetMaxParallelism(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxParallelism = n
+}
+
+// parallelism returns how many partitions an operator over n rows should
+// use; 1 means run serially.
+func (c *Catalog) parallelism(n int) int {
+	if c.parallelPool == nil || n < c.parallelThreshold {
+		return 1
+	}
+	parts := c.parallelPool.Size()
+	if c.maxParallelism > 0 && c.maxParallelism < parts {
+		parts = c.maxParallelism
+	}
+	if parts > n {
+		parts = n
+	}
+	if parts < 1 {
+		parts = 1
+	}
+	return parts
 }
 
 // ensureVacuumMaps lazily initializes dead/live tuple tracking maps.
@@ -640,14 +817,14 @@ func (cat *Catalog) resolveFromTable(name string) (*TableDef, error) {
 		return table, nil
 	}
 
-	return nil, fmt.Errorf("table '%s' not found", name)
+	return nil, sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table '%s' not found", name)
 }
 
 // selectLocked executes a SELECT while assuming cat.mu is already held.
 // It never releases the lock; for the outermost Select path that may unlock
 // during the scan, use selectLockedInternal directly.
 func (cat *Catalog) selectLocked(stmt *query.SelectStmt, args []interface{}) ([]string, [][]interface{}, error) {
-	return cat.selectLockedInternal(stmt, args, false)
+	return cat.selectLockedInternal(stmt, args, false, nil)
 }
 
 // selectUnlocked performs the table scan and post-processing for a SELECT.
@@ -706,7 +883,7 @@ func (cat *Catalog) selectUnlocked(snap TableSnapshot, stmt *query.SelectStmt, a
 // true and the statement has no subqueries, the catalog read lock is released
 // during the heavy table scan so concurrent writes can proceed. Recursive calls
 // (subqueries, JOIN resolution, views) must pass canReleaseLock=false.
-func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interface{}, canReleaseLock bool) ([]string, [][]interface{}, error) {
+func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interface{}, canReleaseLock bool, sink RowSink) ([]string, [][]interface{}, error) {
 	if err := validateSelectBounds(cat, stmt, args); err != nil {
 		return nil, nil, err
 	}
@@ -725,7 +902,10 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 	}
 
 	// Resolve positional references in GROUP BY and ORDER BY (e.g., GROUP BY 1, ORDER BY 2)
-	stmt = resolvePositionalRefs(stmt)
+	stmt, err := resolvePositionalRefs(stmt)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Handle AS OF temporal queries
 	queryTime := time.Now()
@@ -742,44 +922,68 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 		return cat.executeScalarSelect(stmt, args)
 	}
 
+	// Tables read as of a time or masked are replaced by rows of their own,
+	// and read like CTE results below.
+	if stmt.AsOf != nil || cat.masksTablesLocked() {
+		removeReplaced, err := cat.installReplacedRows(stmt, queryTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer removeReplaced()
+		if cteRes, ok := cat.cteResults[toLowerFast(stmt.From.Name)]; ok {
+			if result, handled := cat.handleCTEResult(stmt, args, cteRes); handled {
+				return result.cols, result.rows, result.err
+			}
+		}
+	}
+
 	rlsNeedsBaseRows := cat.selectNeedsFullRowsForRLS(stmt.From.Name)
+	usesRowID := selectReferencesRowID(stmt)
 
 	// Fast path: SELECT COUNT(*) FROM table [WHERE ...] — skip row decoding
-	if !rlsNeedsBaseRows {
+	if !rlsNeedsBaseRows && !usesRowID {
+		endAggregate := cat.budget().beginOperator(OperatorAggregate, "")
 		if cols, rows, ok, err := cat.tryCountStarFastPath(stmt, args, queryTime); err != nil {
 			return nil, nil, err
 		} else if ok {
+			endAggregate(len(rows))
 			return cols, rows, nil
 		}
 	}
 
 	// Fast path: SELECT SUM/AVG/MIN/MAX/COUNT(col) FROM table — streaming aggregates
-	if !rlsNeedsBaseRows {
+	if !rlsNeedsBaseRows && !usesRowID {
+		endAggregate := cat.budget().beginOperator(OperatorAggregate, "")
 		if cols, rows, ok, err := cat.trySimpleAggregateFastPath(stmt, args); err != nil {
 			return nil, nil, err
 		} else if ok {
+			endAggregate(len(rows))
 			return cols, rows, nil
 		}
 	}
 
 	// Handle derived tables: FROM (SELECT ...) AS alias or FROM (SELECT ... UNION ...) AS alias
-	if stmt.From.Subquery != nil || stmt.From.SubqueryStmt != nil {
+	if (stmt.From.Subquery != nil || stmt.From.SubqueryStmt != nil) && len(stmt.Joins) == 0 {
 		subCols, subRows, err := cat.executeDerivedTable(stmt.From, args)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error in derived table '%s': %w", stmt.From.Alias, err)
 		}
-		if len(stmt.Joins) == 0 {
-			return cat.applyOuterQuery(stmt, subCols, subRows, args)
-		}
-		// Derived table with JOINs: store as temporary CTE result so
-		// executeSelectWithJoin can resolve it
-		if cat.cteResults == nil {
-			cat.cteResults = make(map[string]*cteResultSet)
+		return cat.applyOuterQuery(stmt, subCols, subRows, args)
+	}
+	// Derived tables on either side of a JOIN: store as temporary CTE results
+	// so executeSelectWithJoin can resolve them
+	if len(stmt.Joins) > 0 {
+		restore, err := cat.materializeDerivedTables(stmt, args)
+		if err != nil {
+			return nil, nil, err
 		}
-		dtName := toLowerFast(stmt.From.Alias)
-		cat.cteResults[dtName] = &cteResultSet{columns: subCols, rows: subRows}
-		defer delete(cat.cteResults, dtName)
-		// Fall through to normal JOIN handling
+		defer restore()
+	}
+
+	// Turn JOIN ... USING and NATURAL JOIN into ON conditions
+	stmt, err = cat.resolveJoinUsing(stmt)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Check if it's a pre-computed CTE result (from recursive CTE execution)
@@ -802,6 +1006,11 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 	if err != nil {
 		return nil, nil, err
 	}
+	if usesRowID && len(stmt.Joins) == 0 && cat.tables[table.Name] == table {
+		if table, err = withRowIDColumn(table, stmt); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	mainTableRef := stmt.From.Name
 	if stmt.From.Alias != "" {
@@ -818,6 +1027,9 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 	if !hasAggregates && exprHasAggregate(stmt.Having) {
 		hasAggregates = true
 	}
+	if table.rowIDHidden && (hasAggregates || len(stmt.GroupBy) > 0) {
+		return nil, nil, fmt.Errorf("ROWID is not supported in aggregate queries")
+	}
 
 	// Handle JOINs if present
 	if len(stmt.Joins) > 0 {
@@ -833,8 +1045,9 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 			}
 			// Apply ORDER BY for JOIN+GROUP BY results
 			if len(stmt.OrderBy) > 0 {
-				rows = cat.applyGroupByOrderBy(rows, augSelectCols, stmt.OrderBy)
+				rows = cat.applyGroupByOrderBy(rows, augSelectCols, stmt.OrderBy, args)
 			}
+			endLimit := cat.budget().beginLimit(stmt)
 			// Apply OFFSET
 			if stmt.Offset != nil {
 				offsetVal, err := evaluateExpression(cat, nil, nil, stmt.Offset, args)
@@ -857,6 +1070,7 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 					}
 				}
 			}
+			endLimit(len(rows))
 			// Remove hidden columns from result rows
 			if hiddenCount > 0 {
 				visibleCount := len(augSelectCols) - hiddenCount
@@ -896,6 +1110,9 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 	// Add hidden ORDER BY columns not in SELECT list
 	hiddenOrderByCols := 0
 	if len(stmt.OrderBy) > 0 {
+		if err := checkOrderByPositions(stmt.OrderBy, len(selectCols)); err != nil {
+			return nil, nil, err
+		}
 		selectCols, hiddenOrderByCols = addHiddenOrderByCols(stmt.OrderBy, selectCols, table)
 	}
 
@@ -929,7 +1146,12 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 	var indexMatches []string
 	var useIndex bool
 	if stmt.Where != nil {
+		io, endIO := cat.budget().beginTableIO(table.Name)
 		indexMatches, useIndex, err = cat.useIndexForQueryWithArgs(stmt.From.Name, stmt.Where, args)
+		if useIndex {
+			io.addIndexEntries(len(indexMatches))
+		}
+		endIO()
 		if err != nil {
 			return nil, nil, err
 		}
@@ -953,6 +1175,12 @@ func (cat *Catalog) selectLockedInternal(stmt *query.SelectStmt, args []interfac
 			isMV = true
 		}
 	}
+	if sink != nil && !isMV && !useIndex && !collectFullRows && hiddenOrderByCols == 0 && !table.rowIDHidden && cat.canStreamSelect(stmt, table, trees) {
+		if err := sink.Columns(returnColumns); err != nil {
+			return nil, nil, err
+		}
+		tableSnapshot.Rows = sink
+	}
 	canUnlock := canReleaseLock && !hasSubqueries(stmt)
 	if canUnlock {
 		cat.mu.RUnlock()
@@ -981,6 +1209,9 @@ func (cat *Catalog) selectNeedsFullRowsForRLS(tableName string) bool {
 // capture all needed metadata while holding the lock, then release the lock
 // before scanning.
 func (cat *Catalog) scanTableRowsWithSnapshot(snap TableSnapshot, stmt *query.SelectStmt, args []interface{}, hasWindowFuncs bool, queryTime time.Time, trees []btree.TreeStore, mvRows [][]interface{}, isMV bool, parallelWorkers int, parallelThreshold int) ([][]interface{}, [][]interface{}, error) {
+	if snap.Rows != nil {
+		return nil, nil, cat.streamTableRows(snap, stmt, args, queryTime, trees[0])
+	}
 	return cat.scanTableRows(snap.Def, stmt, args, snap.Columns, hasWindowFuncs, queryTime, trees, snap.IndexMatches, snap.UseIndex, mvRows, isMV, parallelWorkers, parallelThreshold)
 }
 
@@ -992,6 +1223,11 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 	if len(trees) == 0 && !isMV {
 		return nil, nil, nil
 	}
+	budget := cat.budget()
+	io, endIO := budget.beginTableIO(table.Name)
+	defer endIO()
+	endScan := budget.beginScan(table.Name, stmt.Where != nil)
+	defer func() { endScan(len(rows)) }()
 
 	// Compute early termination limit for LIMIT/OFFSET without ORDER BY/DISTINCT/window.
 	earlyLimit := 0
@@ -1011,6 +1247,9 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 	}
 
 	if useIndex {
+		if cat.stableScanOrder(stmt) {
+			indexMatches = sortedKeys(indexMatches)
+		}
 		for _, pk := range indexMatches {
 			var valueData []byte
 			var found bool
@@ -1034,7 +1273,8 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 			if !found {
 				continue
 			}
-			selectedRow, fullRow, ok, err := cat.filterAndProjectRow(valueData, table, stmt, selectCols, args, queryTime, hasWindowFuncs)
+			io.addRow(len(valueData))
+			selectedRow, fullRow, ok, err := cat.filterAndProjectRow(pk, valueData, table, stmt, selectCols, args, queryTime, hasWindowFuncs)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -1079,10 +1319,25 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 			!stmt.Distinct &&
 			!hasWindowFuncs &&
 			!hasSubqueries(stmt) &&
+			!table.rowIDHidden &&
 			stmt.Limit == nil &&
 			stmt.Offset == nil
 
+		pkNeed, pkDesc, pkOrdered := 0, false, false
 		if len(trees) == 1 && !hasPending {
+			pkNeed, pkDesc, pkOrdered = cat.pkOrderLimit(table, stmt, args, hasWindowFuncs)
+		}
+		if pkOrdered {
+			pkRows, ok, err := cat.scanPKOrder(table, stmt, args, selectCols, queryTime, trees[0], pkNeed, pkDesc, io)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				rows = pkRows
+				return rows, nil, nil
+			}
+		}
+		if len(trees) == 1 && !hasPending && !(canParallel && cat.parallelism(trees[0].Size()) > 1) {
 			iter, err := trees[0].Scan(nil, nil)
 			if err != nil {
 				return nil, nil, fmt.Errorf("select: failed to scan table %s: %w", table.Name, err)
@@ -1093,19 +1348,20 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 			if hasWindowFuncs && cap(windowFullRows) == 0 {
 				windowFullRows = make([][]interface{}, 0, trees[0].Size())
 			}
-			numCols := len(table.Columns)
+			numCols := table.storedColumnCount()
 			flatCap := int(trees[0].Size()) * numCols
 			flatBuf := make([]interface{}, 0, flatCap)
 			stringBuf := make([]string, flatCap)
 			rowIdx := 0
 			stringIdx := 0
 
-			for iter.HasNext() {
-				_, valueData, err := iter.NextString()
+			for iter.HasNext() && budget.alive() {
+				key, valueData, err := iter.NextString()
 				if err != nil {
 					iter.Close()
 					return nil, nil, fmt.Errorf("select: failed to read table %s: %w", table.Name, err)
 				}
+				io.addRow(len(valueData))
 
 				start := rowIdx * numCols
 				end := start + numCols
@@ -1132,6 +1388,9 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 					continue
 				}
 				fullRow := vrow.Data
+				if table.rowIDHidden {
+					fullRow = appendRowID(fullRow, key)
+				}
 				if stmt.Where != nil {
 					matched, err := evaluateWhere(cat, fullRow, table.Columns, stmt.Where, args)
 					if err != nil || !matched {
@@ -1174,6 +1433,7 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 						iter.Close()
 						return nil, nil, fmt.Errorf("select: failed to read table %s: %w", table.Name, err)
 					}
+					io.addRow(len(valueData))
 					pairs = append(pairs, kvPair{k, valueData})
 					seen[k] = len(pairs) - 1
 				}
@@ -1181,6 +1441,7 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 			}
 
 			// Read-your-writes: overlay buffered writes (INSERT, UPDATE, DELETE).
+			merged := len(trees) > 1
 			if hasPending {
 				if m, ok := ts.getPendingWriteMap()[table.Name]; ok {
 					for _, pw := range m {
@@ -1190,26 +1451,54 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 						} else {
 							pairs = append(pairs, kvPair{k, pw.Value})
 							seen[k] = len(pairs) - 1
+							merged = true
 						}
 					}
 				}
 			}
+			// Partitions and buffered inserts arrive out of key order.
+			if merged && cat.stableScanOrder(stmt) {
+				slices.SortFunc(pairs, func(a, b kvPair) int { return strings.Compare(a.key, b.key) })
+			}
 
 			canParallel = canParallel && len(pairs) >= parallelThreshold
 
-			if canParallel {
-				values := make([][]byte, len(pairs))
-				for i, p := range pairs {
-					if _, err := decodeVersionedRow(p.value, len(table.Columns)); err != nil {
-						return nil, nil, fmt.Errorf("select: failed to decode row in table %s: %w", table.Name, err)
-					}
-					values[i] = p.value
-				}
-				results := parallel.ParallelSelectRows(values, parallelWorkers, parallelThreshold,
-					func(chunk [][]byte) [][]interface{} {
-						chunkRows, _, _ := cat.processRowChunk(chunk, table, selectCols, stmt, args, queryTime, false)
-						return chunkRows
+			if parts := cat.parallelism(len(pairs)); canParallel && parts > 1 {
+				// Partitions are decoded on the worker pool and concatenated
+				// in key order, so the result matches a serial scan. Workers
+				// decode in blocks under the statement's budget and all stop
+				// at the first error or breached limit.
+				var chunkErr error
+				var errOnce sync.Once
+				shared := budget.share(cat)
+				results := parallel.MapRanges(cat.parallelPool, len(pairs), parts, func(start, end int) [][]interface{} {
+					var chunkRows [][]interface{}
+					shared.run(func(b *statementBudget) error {
+						for ; start < end; start += budgetCheckInterval {
+							blockEnd := min(start+budgetCheckInterval, end)
+							if !b.aliveAfter(blockEnd - start) {
+								return nil
+							}
+							values := make([][]byte, blockEnd-start)
+							for i := range values {
+								values[i] = pairs[start+i].value
+							}
+							blockRows, _, err := cat.processRowChunk(values, table, selectCols, stmt, args, queryTime, false)
+							if err != nil {
+								errOnce.Do(func() { chunkErr = err })
+								return err
+							}
+							chunkRows = append(chunkRows, blockRows...)
+						}
+						return nil
 					})
+					return chunkRows
+				})
+				if err := shared.settle(); chunkErr != nil {
+					return nil, nil, chunkErr
+				} else if err != nil {
+					return rows, windowFullRows, nil
+				}
 				rows = append(rows, results...)
 			} else {
 				if cap(rows) == 0 {
@@ -1219,7 +1508,10 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 					windowFullRows = make([][]interface{}, 0, len(pairs))
 				}
 				for _, p := range pairs {
-					selectedRow, fullRow, ok, err := cat.filterAndProjectRow(p.value, table, stmt, selectCols, args, queryTime, hasWindowFuncs)
+					if !budget.alive() {
+						break
+					}
+					selectedRow, fullRow, ok, err := cat.filterAndProjectRow(p.key, p.value, table, stmt, selectCols, args, queryTime, hasWindowFuncs)
 					if err != nil {
 						return nil, nil, err
 					}
@@ -1243,8 +1535,9 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 	return rows, windowFullRows, nil
 }
 
-// filterAndProjectRow decodes a versioned row, checks visibility at queryTime,
-// evaluates the WHERE clause, and projects the selected columns.
+// filterAndProjectRow decodes a versioned row stored under key, checks
+// visibility at queryTime, evaluates the WHERE clause, and projects the
+// selected columns.
 // Returns (selectedRow, fullRow, ok, err) where:
 //   - selectedRow is the projected row to append to results
 //   - fullRow is the decoded row data (for window function tracking)
@@ -1253,8 +1546,8 @@ func (cat *Catalog) scanTableRows(table *TableDef, stmt *query.SelectStmt, args
 //
 // This consolidates the decode → visibility → WHERE → project pattern used
 // across index scans, MV scans, and B-tree sequential scans.
-func (cat *Catalog) filterAndProjectRow(valueData []byte, table *TableDef, stmt *query.SelectStmt, selectCols []selectColInfo, args []interface{}, queryTime time.Time, hasWindowFuncs bool) (selectedRow []interface{}, fullRow []interface{}, ok bool, err error) {
-	vrow, err := decodeVersionedRow(valueData, len(table.Columns))
+func (cat *Catalog) filterAndProjectRow(key string, valueData []byte, table *TableDef, stmt *query.SelectStmt, selectCols []selectColInfo, args []interface{}, queryTime time.Time, hasWindowFuncs bool) (selectedRow []interface{}, fullRow []interface{}, ok bool, err error) {
+	vrow, err := decodeVersionedRow(valueData, table.storedColumnCount())
 	if err != nil {
 		return nil, nil, false, fmt.Errorf("select: failed to decode row in table %s: %w", table.Name, err)
 	}
@@ -1262,6 +1555,9 @@ func (cat *Catalog) filterAndProjectRow(valueData []byte, table *TableDef, stmt
 		return nil, nil, false, nil
 	}
 	fullRow = vrow.Data
+	if table.rowIDHidden {
+		fullRow = appendRowID(fullRow, key)
+	}
 	if stmt.Where != nil {
 		matched, err := evaluateWhere(cat, fullRow, table.Columns, stmt.Where, args)
 		if err != nil || !matched {
@@ -1277,6 +1573,10 @@ func (cat *Catalog) filterAndProjectRow(valueData []byte, table *TableDef, stmt
 // writes for read-your-writes visibility.
 func (c *Catalog) getEffectiveTableData(table *TableDef) (map[string][]byte, error) {
 	result := make(map[string][]byte)
+	io, endIO := c.budget().beginTableIO(table.Name)
+	defer endIO()
+	endScan := c.budget().beginOperator(OperatorScan, table.Name)
+	defer func() { endScan(len(result)) }()
 	trees, _ := c.getTableTreesForScan(table)
 	for _, tree := range trees {
 		iter, err := tree.Scan(nil, nil)
@@ -1289,6 +1589,7 @@ func (c *Catalog) getEffectiveTableData(table *TableDef) (map[string][]byte, err
 				iter.Close()
 				return nil, fmt.Errorf("select: failed to read join table %s: %w", table.Name, err)
 			}
+			io.addRow(len(valueData))
 			if !bytesContainDeletedAt(valueData) {
 				result[k] = valueData
 				continue
@@ -1323,62 +1624,86 @@ func (c *Catalog) getEffectiveTableData(table *TableDef) (map[string][]byte, err
 	return result, nil
 }
 
-func resolvePositionalRefs(stmt *query.SelectStmt) *query.SelectStmt {
+// resolvePositionalRefs replaces GROUP BY and ORDER BY positions (GROUP BY
+// 1, ORDER BY 2) with the select-list expressions they name, and select
+// aliases inside ORDER BY expressions (ORDER BY total * -1) with the aliased
+// expressions. A position outside the select list is an error. Positions
+// are left alone when the select list has a *, whose width is known only
+// once the tables are; the sort resolves them against the expanded columns.
+func resolvePositionalRefs(stmt *query.SelectStmt) (*query.SelectStmt, error) {
 	if stmt == nil {
-		return stmt
+		return stmt, nil
+	}
+
+	hasStar := false
+	aggregated := len(stmt.GroupBy) > 0
+	aliases := make(map[string]query.Expression)
+	for _, col := range stmt.Columns {
+		switch e := col.(type) {
+		case *query.StarExpr:
+			hasStar = true
+		case *query.AliasExpr:
+			// Window results are computed after the rows are, so an
+			// expression over one cannot be evaluated per row.
+			if !query.ExprContainsWindow(e.Expr) {
+				aliases[toLowerFast(e.Alias)] = e.Expr
+			}
+		}
+		if exprHasAggregate(col) {
+			aggregated = true
+		}
 	}
 
 	modified := false
 
 	// Resolve GROUP BY positional references
 	var newGroupBy []query.Expression
-	if len(stmt.GroupBy) > 0 {
+	if len(stmt.GroupBy) > 0 && !hasStar {
 		newGroupBy = make([]query.Expression, len(stmt.GroupBy))
 		for i, gb := range stmt.GroupBy {
-			if nl, ok := gb.(*query.NumberLiteral); ok {
-				pos := int(nl.Value)
-				if pos >= 1 && pos <= len(stmt.Columns) {
-					// Replace with the SELECT column expression (unwrap alias if present)
-					col := stmt.Columns[pos-1]
-					if ae, ok := col.(*query.AliasExpr); ok {
-						newGroupBy[i] = ae.Expr
-					} else {
-						newGroupBy[i] = col
-					}
-					modified = true
-					continue
-				}
+			if pos, ok := orderByPosition(gb); ok && pos >= 1 && pos <= len(stmt.Columns) {
+				// Replace with the SELECT column expression (unwrap alias if present)
+				newGroupBy[i] = unwrapAlias(stmt.Columns[pos-1])
+				modified = true
+				continue
 			}
 			newGroupBy[i] = gb
 		}
 	}
 
-	// Resolve ORDER BY positional references
+	// Resolve ORDER BY positional references and aliases in expressions.
+	// Aggregate queries sort the grouped rows, where aliases are columns.
 	var newOrderBy []*query.OrderByExpr
 	if len(stmt.OrderBy) > 0 {
 		newOrderBy = make([]*query.OrderByExpr, len(stmt.OrderBy))
 		for i, ob := range stmt.OrderBy {
-			if nl, ok := ob.Expr.(*query.NumberLiteral); ok {
-				pos := int(nl.Value)
-				if pos >= 1 && pos <= len(stmt.Columns) {
-					col := stmt.Columns[pos-1]
-					var expr query.Expression
-					if ae, ok := col.(*query.AliasExpr); ok {
-						expr = ae.Expr
-					} else {
-						expr = col
+			expr := ob.Expr
+			if pos, ok := orderByPosition(ob.Expr); ok {
+				if !hasStar {
+					if pos < 1 || pos > len(stmt.Columns) {
+						return nil, fmt.Errorf("ORDER BY position %d is not in select list", pos)
 					}
-					newOrderBy[i] = &query.OrderByExpr{Expr: expr, Desc: ob.Desc, NullsFirst: ob.NullsFirst, NullsSpecified: ob.NullsSpecified}
-					modified = true
-					continue
+					expr = unwrapAlias(stmt.Columns[pos-1])
 				}
+			} else if !aggregated && len(aliases) > 0 {
+				switch ob.Expr.(type) {
+				case *query.Identifier, *query.QualifiedIdentifier:
+					// Matched against the output columns by name.
+				default:
+					expr = substituteSelectAliases(ob.Expr, aliases)
+				}
+			}
+			if expr != ob.Expr {
+				newOrderBy[i] = &query.OrderByExpr{Expr: expr, Desc: ob.Desc, NullsFirst: ob.NullsFirst, NullsSpecified: ob.NullsSpecified}
+				modified = true
+				continue
 			}
 			newOrderBy[i] = ob
 		}
 	}
 
 	if !modified {
-		return stmt
+		return stmt, nil
 	}
 
 	// Return a shallow copy with resolved references
@@ -1389,7 +1714,136 @@ func resolvePositionalRefs(stmt *query.SelectStmt) *query.SelectStmt {
 	if newOrderBy != nil {
 		result.OrderBy = newOrderBy
 	}
-	return &result
+	return &result, nil
+}
+
+// orderByPosition returns the select-list position an ORDER BY or GROUP BY
+// term names, if the term is an unsigned integer literal. Other constants,
+// such as ORDER BY 1.5, sort by a constant as in PostgreSQL.
+func orderByPosition(expr query.Expression) (int, bool) {
+	nl, ok := expr.(*query.NumberLiteral)
+	if !ok || nl.Value < 0 || nl.Value != math.Trunc(nl.Value) {
+		return 0, false
+	}
+	for i := 0; i < len(nl.Raw); i++ {
+		if nl.Raw[i] < '0' || nl.Raw[i] > '9' {
+			return 0, false
+		}
+	}
+	if nl.Value > math.MaxInt32 {
+		return math.MaxInt32, true
+	}
+	return int(nl.Value), true
+}
+
+// checkOrderByPositions reports an ORDER BY position outside a select list
+// of width columns. It catches positions resolvePositionalRefs leaves for
+// after * expansion.
+func checkOrderByPositions(orderBy []*query.OrderByExpr, width int) error {
+	for _, ob := range orderBy {
+		if pos, ok := orderByPosition(ob.Expr); ok && (pos < 1 || pos > width) {
+			return fmt.Errorf("ORDER BY position %d is not in select list", pos)
+		}
+	}
+	return nil
+}
+
+func unwrapAlias(expr query.Expression) query.Expression {
+	if ae, ok := expr.(*query.AliasExpr); ok {
+		return ae.Expr
+	}
+	return expr
+}
+
+// substituteSelectAliases replaces identifiers naming a select alias in expr
+// with the aliased expression, as MySQL and SQLite do in ORDER BY. Subqueries
+// are left alone; their names resolve against their own tables.
+func substituteSelectAliases(expr query.Expression, aliases map[string]query.Expression) query.Expression {
+	switch e := expr.(type) {
+	case *query.Identifier:
+		if aliased, ok := aliases[toLowerFast(e.Name)]; ok {
+			return aliased
+		}
+		return e
+	case *query.BinaryExpr:
+		left := substituteSelectAliases(e.Left, aliases)
+		right := substituteSelectAliases(e.Right, aliases)
+		if left != e.Left || right != e.Right {
+			return &query.BinaryExpr{Left: left, Operator: e.Operator, Right: right}
+		}
+		return e
+	case *query.UnaryExpr:
+		inner := substituteSelectAliases(e.Expr, aliases)
+		if inner != e.Expr {
+			return &query.UnaryExpr{Operator: e.Operator, Expr: inner}
+		}
+		return e
+	case *query.FunctionCall:
+		newArgs := make([]query.Expression, len(e.Args))
+		changed := false
+		for i, arg := range e.Args {
+			newArgs[i] = substituteSelectAliases(arg, aliases)
+			if newArgs[i] != arg {
+				changed = true
+			}
+		}
+		if changed {
+			copied := *e
+			copied.Args = newArgs
+			return &copied
+		}
+		return e
+	case *query.CastExpr:
+		inner := substituteSelectAliases(e.Expr, aliases)
+		if inner != e.Expr {
+			copied := *e
+			copied.Expr = inner
+			return &copied
+		}
+		return e
+	case *query.CollateExpr:
+		inner := substituteSelectAliases(e.Expr, aliases)
+		if inner != e.Expr {
+			return &query.CollateExpr{Expr: inner, Collation: e.Collation}
+		}
+		return e
+	case *query.IsNullExpr:
+		inner := substituteSelectAliases(e.Expr, aliases)
+		if inner != e.Expr {
+			return &query.IsNullExpr{Expr: inner, Not: e.Not}
+		}
+		return e
+	case *query.BetweenExpr:
+		inner := substituteSelectAliases(e.Expr, aliases)
+		lower := substituteSelectAliases(e.Lower, aliases)
+		upper := substituteSelectAliases(e.Upper, aliases)
+		if inner != e.Expr || lower != e.Lower || upper != e.Upper {
+			return &query.BetweenExpr{Expr: inner, Lower: lower, Upper: upper, Not: e.Not}
+		}
+		return e
+	case *query.CaseExpr:
+		changed := false
+		sub := func(x query.Expression) query.Expression {
+			if x == nil {
+				return nil
+			}
+			y := substituteSelectAliases(x, aliases)
+			if y != x {
+				changed = true
+			}
+			return y
+		}
+		resolved := &query.CaseExpr{Expr: sub(e.Expr), Else: sub(e.Else)}
+		for _, w := range e.Whens {
+			resolved.Whens = append(resolved.Whens, &query.WhenClause{Condition: sub(w.Condition), Result: sub(w.Result)})
+		}
+		if changed {
+			return resolved
+		}
+		return e
+	default:
+		return e
+	}
 }
 
 func resolveOuterRefsInQuery(subquery *query.SelectStmt, outerRow []interface{}, outerColumns []ColumnDef) *query.SelectStmt {
@@ -1575,8 +2029,9 @@ func resolveOuterRefsInExpr(expr query.Expression, outerRow []interface{}, outer
 	case *query.LikeExpr:
 		expr := resolveOuterRefsInExpr(e.Expr, outerRow, outerColumns, innerTables)
 		pattern := resolveOuterRefsInExpr(e.Pattern, outerRow, outerColumns, innerTables)
-		if expr != e.Expr || pattern != e.Pattern {
-			return &query.LikeExpr{Expr: expr, Pattern: pattern, Not: e.Not}
+		escape := resolveOuterRefsInExpr(e.Escape, outerRow, outerColumns, innerTables)
+		if expr != e.Expr || pattern != e.Pattern || escape != e.Escape {
+			return &query.LikeExpr{Expr: expr, Pattern: pattern, Not: e.Not, Escape: escape}
 		}
 		return e
 	case *query.CaseExpr:
@@ -1595,6 +2050,12 @@ func resolveOuterRefsInExpr(expr query.Expression, outerRow []interface{}, outer
 			return &query.AliasExpr{Expr: inner, Alias: e.Alias}
 		}
 		return e
+	case *query.CollateExpr:
+		inner := resolveOuterRefsInExpr(e.Expr, outerRow, outerColumns, innerTables)
+		if inner != e.Expr {
+			return &query.CollateExpr{Expr: inner, Collation: e.Collation}
+		}
+		return e
 	default:
 		return e
 	}
@@ -1618,8 +2079,12 @@ func tokenTypeToColumnType(t query.TokenType) string {
 		return "DATE"
 	case query.TokenTimestamp:
 		return "TIMESTAMP"
+	case query.TokenDatetime:
+		return "DATETIME"
 	case query.TokenVector:
 		return "VECTOR"
+	case query.TokenUUID:
+		return "UUID"
 	default:
 		return "TEXT"
 	}
@@ -1734,6 +2199,9 @@ func (c *Catalog) getTableTreesForScan(table *TableDef) ([]btree.TreeStore, erro
 }
 
 func (c *Catalog) getTableTreesForScanWithOptions(table *TableDef, scanOptions fdw.ScanOptions) ([]btree.TreeStore, error) {
+	if table.Type == "attached" {
+		return c.getAttachedTableTrees(table)
+	}
 	// Foreign table: materialize FDW data into a temporary B-tree
 	if table.Type == "foreign" {
 		ft, ok := c.foreignTables[table.Name]
@@ -1778,7 +2246,7 @@ func (c *Catalog) getTableTreesForScanWithOptions(table *TableDef, scanOptions f
 				}
 			}
 			key := []byte("fdw:" + strconv.Itoa(rowIndex))
-			val, err := encodeVersionedRow(row, nil)
+			val, err := encodeVersionedRow(row, &materializedRowTime)
 			if err != nil {
 				return err
 			}
@@ -1909,6 +2377,10 @@ func exprToSQL(expr query.Expression) string {
 		return "*"
 	case *query.AliasExpr:
 		return exprToSQL(e.Expr) + " AS " + e.Alias
+	case *query.CollateExpr:
+		return exprToSQL(e.Expr) + " COLLATE " + e.Collation
+	case *query.JSONContainsExpr:
+		return fmt.Sprintf("(%s @> %s)", exprToSQL(e.Column), exprToSQL(e.Value))
 	case *query.BinaryExpr:
 		left := exprToSQL(e.Left)
 		right := exprToSQL(e.Right)
@@ -1970,6 +2442,20 @@ func exprToSQL(expr query.Expression) string {
 			args = append(args, exprToSQL(arg))
 		}
 		return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", "))
+	case *query.CaseExpr:
+		var b strings.Builder
+		b.WriteString("CASE")
+		if e.Expr != nil {
+			b.WriteString(" " + exprToSQL(e.Expr))
+		}
+		for _, w := range e.Whens {
+			fmt.Fprintf(&b, " WHEN %s THEN %s", exprToSQL(w.Condition), exprToSQL(w.Result))
+		}
+		if e.Else != nil {
+			b.WriteString(" ELSE " + exprToSQL(e.Else))
+		}
+		b.WriteString(" END")
+		return b.String()
 	default:
 		return fmt.Sprintf("%v", expr)
 	}
@@ -2023,7 +2509,10 @@ func buildCompositeIndexKey(table *TableDef, idxDef *IndexDef, row []interface{}
 		if colIdx < 0 || colIdx >= len(row) || row[colIdx] == nil {
 			return "", false
 		}
-		return typeTaggedKey(row[colIdx]), true
+		if idxDef.JSONPath != "" {
+			return jsonPathIndexKey(row[colIdx], idxDef.JSONPath)
+		}
+		return typeTaggedKey(collatedIndexValue(&table.Columns[colIdx], row[colIdx])), true
 	}
 	// Composite key: concatenate all column values
 	var parts []string
@@ -2032,7 +2521,7 @@ func buildCompositeIndexKey(table *TableDef, idxDef *IndexDef, row []interface{}
 		if colIdx < 0 || colIdx >= len(row) || row[colIdx] == nil {
 			return "", false
 		}
-		parts = append(parts, typeTaggedKey(row[colIdx]))
+		parts = append(parts, typeTaggedKey(collatedIndexValue(&table.Columns[colIdx], row[colIdx])))
 	}
 	return strings.Join(parts, "\x00"), true
 }
@@ -2227,6 +2716,8 @@ func EvalExpression(expr query.Expression, args []interface{}) (interface{}, err
 		return evalFunctionCallValue(toUpperFast(e.Name), evalArgs)
 	case *query.AliasExpr:
 		return EvalExpression(e.Expr, args)
+	case *query.CollateExpr:
+		return EvalExpression(e.Expr, args)
 	default:
 		return nil, fmt.Errorf("unsupported expression type: %T", expr)
 	}
@@ -2348,7 +2839,10 @@ func (cat *Catalog) evaluateTemporalExpr(expr *query.TemporalExpr, args []interf
 				if err != nil {
 					parsed, err = time.Parse("2006-01-02", v)
 					if err != nil {
-						return nil, fmt.Errorf("cannot parse timestamp: %v", v)
+						var ok bool
+						if parsed, ok = parseAgoExpr(v); !ok {
+							return nil, fmt.Errorf("cannot parse timestamp: %v", v)
+						}
 					}
 				}
 			}
@@ -2422,6 +2916,34 @@ func parseSystemTimeExpr(expr string) time.Time {
 	return now
 }
 
+// parseAgoExpr parses a time relative to now such as "5 minutes ago".
+func parseAgoExpr(expr string) (time.Time, bool) {
+	expr = strings.TrimSpace(toLowerFast(expr))
+	rest, ok := strings.CutSuffix(expr, " ago")
+	if !ok {
+		return time.Time{}, false
+	}
+	var num int
+	var unit string
+	if n, err := fmt.Sscanf(rest, "%d %s", &num, &unit); err != nil || n != 2 || num < 0 {
+		return time.Time{}, false
+	}
+	now := time.Now()
+	switch strings.TrimSuffix(unit, "s") {
+	case "second", "sec":
+		return now.Add(-time.Duration(num) * time.Second), true
+	case "minute", "min":
+		return now.Add(-time.Duration(num) * time.Minute), true
+	case "hour", "hr":
+		return now.Add(-time.Duration(num) * time.Hour), true
+	case "day":
+		return now.AddDate(0, 0, -num), true
+	case "week":
+		return now.AddDate(0, 0, -7*num), true
+	}
+	return time.Time{}, false
+}
+
 // applyOffsetLimit applies OFFSET and LIMIT to a result set.
 //
 //nolint:unused // retained for coverage and future shared offset/limit path reuse.
@@ -2525,11 +3047,13 @@ func hasSubqueriesInExpr(expr query.Expression) bool {
 	case *query.BetweenExpr:
 		return hasSubqueriesInExpr(e.Expr) || hasSubqueriesInExpr(e.Lower) || hasSubqueriesInExpr(e.Upper)
 	case *query.LikeExpr:
-		return hasSubqueriesInExpr(e.Expr) || hasSubqueriesInExpr(e.Pattern)
+		return hasSubqueriesInExpr(e.Expr) || hasSubqueriesInExpr(e.Pattern) || hasSubqueriesInExpr(e.Escape)
 	case *query.IsNullExpr:
 		return hasSubqueriesInExpr(e.Expr)
 	case *query.AliasExpr:
 		return hasSubqueriesInExpr(e.Expr)
+	case *query.CollateExpr:
+		return hasSubqueriesInExpr(e.Expr)
 	default:
 		return false
 	}
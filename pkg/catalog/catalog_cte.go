@@ -97,7 +97,14 @@ func (c *Catalog) ExecuteCTE(stmt *query.SelectStmtWithCTE, args []interface{})
 	}
 
 	// Execute the main query (already holding lock)
-	columns, rows, err := c.selectLocked(stmt.Select, args)
+	var columns []string
+	var rows [][]interface{}
+	var err error
+	if stmt.Union != nil {
+		columns, rows, err = c.executeCTEUnion(stmt.Union, args)
+	} else {
+		columns, rows, err = c.selectLocked(stmt.Select, args)
+	}
 
 	// Restore original views and clean up CTE results
 	for _, cte := range stmt.CTEs {
@@ -115,24 +122,97 @@ func (c *Catalog) ExecuteCTE(stmt *query.SelectStmtWithCTE, args []interface{})
 	return columns, rows, err
 }
 
+// executeDerivedTable runs the subquery of a derived table in a FROM clause
+// and returns its rows, with the columns renamed by the alias's column list
+// when there is one.
 func (c *Catalog) executeDerivedTable(ref *query.TableRef, args []interface{}) ([]string, [][]interface{}, error) {
-	if ref.SubqueryStmt != nil {
+	var cols []string
+	var rows [][]interface{}
+	var err error
+	switch {
+	case ref.SubqueryStmt != nil:
 		switch s := ref.SubqueryStmt.(type) {
 		case *query.UnionStmt:
-			return c.executeCTEUnion(s, args)
+			cols, rows, err = c.executeCTEUnion(s, args)
 		case *query.SelectStmt:
-			return c.selectLocked(s, args)
+			cols, rows, err = c.selectLocked(s, args)
 		default:
 			return nil, nil, fmt.Errorf("unsupported derived table statement type: %T", ref.SubqueryStmt)
 		}
+	case ref.Subquery != nil:
+		cols, rows, err = c.selectLocked(ref.Subquery, args)
+	default:
+		return nil, nil, fmt.Errorf("derived table has no subquery")
+	}
+	if err != nil || len(ref.Columns) == 0 {
+		return cols, rows, err
+	}
+	if len(ref.Columns) != len(cols) {
+		return nil, nil, fmt.Errorf("derived table %s has %d columns but its column list names %d", ref.Alias, len(cols), len(ref.Columns))
+	}
+	return ref.Columns, rows, nil
+}
+
+// materializeDerivedTables runs every derived table in a FROM clause with
+// joins once and registers its rows under the table's alias, the way a
+// materialized CTE is registered. Column resolution, USING and * then see the
+// derived table's actual columns, and the joins read its rows instead of
+// running the subquery again. The returned func restores whatever results the
+// aliases shadowed.
+func (c *Catalog) materializeDerivedTables(stmt *query.SelectStmt, args []interface{}) (func(), error) {
+	refs := []*query.TableRef{stmt.From}
+	for _, join := range stmt.Joins {
+		refs = append(refs, join.Table)
+	}
+	shadowed := make(map[string]*cteResultSet)
+	var registered []string
+	restore := func() {
+		for i := len(registered) - 1; i >= 0; i-- {
+			name := registered[i]
+			if prev := shadowed[name]; prev != nil {
+				c.cteResults[name] = prev
+			} else {
+				delete(c.cteResults, name)
+			}
+		}
 	}
-	if ref.Subquery != nil {
-		return c.selectLocked(ref.Subquery, args)
+	for _, ref := range refs {
+		if ref == nil || (ref.Subquery == nil && ref.SubqueryStmt == nil) {
+			continue
+		}
+		cols, rows, err := c.executeDerivedTable(ref, args)
+		if err != nil {
+			restore()
+			return nil, fmt.Errorf("error in derived table '%s': %w", ref.Alias, err)
+		}
+		if c.cteResults == nil {
+			c.cteResults = make(map[string]*cteResultSet)
+		}
+		name := toLowerFast(ref.Alias)
+		if _, done := shadowed[name]; !done {
+			shadowed[name] = c.cteResults[name]
+			registered = append(registered, name)
+		}
+		c.cteResults[name] = &cteResultSet{columns: cols, rows: rows}
 	}
-	return nil, nil, fmt.Errorf("derived table has no subquery")
+	return restore, nil
 }
 
 func (c *Catalog) executeCTEUnion(stmt *query.UnionStmt, args []interface{}) ([]string, [][]interface{}, error) {
+	// ORDER BY, LIMIT and OFFSET apply to the whole chain: run the chain
+	// without them as a derived table and apply them to its rows.
+	if len(stmt.OrderBy) > 0 || stmt.Limit != nil || stmt.Offset != nil {
+		chain := *stmt
+		chain.OrderBy, chain.Limit, chain.Offset = nil, nil, nil
+		return c.selectLocked(&query.SelectStmt{
+			Columns: []query.Expression{&query.StarExpr{}},
+			From:    &query.TableRef{Name: "set_op_result", Alias: "set_op_result", SubqueryStmt: &chain},
+			OrderBy: stmt.OrderBy,
+			Limit:   stmt.Limit,
+			Offset:  stmt.Offset,
+		}, args)
+	}
+
 	// Execute left side
 	var leftCols []string
 	var leftRows [][]interface{}
@@ -155,7 +235,9 @@ func (c *Catalog) executeCTEUnion(stmt *query.UnionStmt, args []interface{}) ([]
 	if err != nil {
 		return nil, nil, err
 	}
-	_ = rightCols
+	if len(leftCols) != len(rightCols) {
+		return nil, nil, fmt.Errorf("each set operation query must have the same number of columns: left has %d, right has %d", len(leftCols), len(rightCols))
+	}
 
 	// Combine results based on set operation type
 	var allRows [][]interface{}
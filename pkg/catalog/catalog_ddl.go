@@ -9,11 +9,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cobaltdb/cobaltdb/pkg/btree"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
 	"github.com/cobaltdb/cobaltdb/pkg/security"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
 )
 
 // validIdentifierName checks if a table or column name is valid
@@ -38,6 +41,12 @@ func validateTableName(name string) error {
 	if name == "" {
 		return fmt.Errorf("table name cannot be empty")
 	}
+	if schema, table := query.SplitTableName(name); schema != "" {
+		if err := validateTableName(schema); err != nil {
+			return err
+		}
+		return validateTableName(table)
+	}
 	stripped := stripQuotes(name)
 	if !validIdentifierName.MatchString(stripped) {
 		return fmt.Errorf("invalid table name %q: must start with letter or underscore, contain only alphanumeric characters and underscores, and be 1-64 characters", stripped)
@@ -107,10 +116,23 @@ func (c *Catalog) CreateTable(stmt *query.CreateTableStmt) error {
 	defer c.mu.Unlock()
 	defer c.invalidateSchemaCache()
 
+	if stmt.SystemVersioning {
+		return c.createVersionedTableLocked(stmt)
+	}
+	return c.createTableLocked(stmt, "")
+}
+
+// createTableLocked creates the table of stmt; historyOf names the
+// system-versioned table it is the history table of, if any. Must be called
+// with c.mu held.
+func (c *Catalog) createTableLocked(stmt *query.CreateTableStmt, historyOf string) error {
 	// Validate table name
 	if err := validateTableName(stmt.Table); err != nil {
 		return err
 	}
+	if err := c.checkTableSchemaLocked(stmt.Table); err != nil {
+		return err
+	}
 
 	if _, exists := c.tables[stmt.Table]; exists {
 		if stmt.IfNotExists {
@@ -125,8 +147,23 @@ func (c *Catalog) CreateTable(stmt *query.CreateTableStmt) error {
 		return ErrTableExists
 	}
 
-	// Create new B+Tree for the table's data
-	tree, err := btree.NewBTree(c.pool)
+	engine, err := tableEngine(stmt.Engine)
+	if err != nil {
+		return err
+	}
+	if engine == EngineAppend && stmt.Partition != nil {
+		return fmt.Errorf("table %s: %s tables cannot be partitioned", stmt.Table, EngineAppend)
+	}
+
+	masks := make([]*ColumnMask, len(stmt.Columns))
+	for i, col := range stmt.Columns {
+		if masks[i], err = columnMaskOf(col); err != nil {
+			return fmt.Errorf("table %s: %w", stmt.Table, err)
+		}
+	}
+
+	// Create the tree for the table's data
+	tree, err := c.newTableTree(engine)
 	if err != nil {
 		return err
 	}
@@ -140,6 +177,7 @@ func (c *Catalog) CreateTable(stmt *query.CreateTableStmt) error {
 		ForeignKeys: make([]ForeignKeyDef, len(stmt.ForeignKeys)),
 		Checks:      make([]CheckDef, len(stmt.CheckConstraints)),
 		Temporary:   stmt.Temporary,
+		Engine:      engine,
 	}
 
 	// Handle partitioning if specified
@@ -214,6 +252,7 @@ func (c *Catalog) CreateTable(stmt *query.CreateTableStmt) error {
 			defaultExpr:   col.Default,
 			Collation:     col.Collation,
 			Dimensions:    col.Dimensions,
+			Mask:          masks[i],
 		}
 		if col.PrimaryKey {
 			tableDef.PrimaryKey = append(tableDef.PrimaryKey, col.Name)
@@ -258,6 +297,17 @@ func (c *Catalog) CreateTable(stmt *query.CreateTableStmt) error {
 		}
 		tableDef.ForeignKeys[i] = normalizedFK
 	}
+	if stmt.TTLColumn != "" {
+		ttlColumn, err := resolveTTLColumn(tableDef, stmt.TTLColumn)
+		if err != nil {
+			return err
+		}
+		tableDef.TTLColumn = ttlColumn
+	}
+	if stmt.SystemVersioning {
+		tableDef.HistoryTable = stmt.Table + historyTableSuffix
+	}
+	tableDef.HistoryOf = historyOf
 	for i, check := range stmt.CheckConstraints {
 		tableDef.Checks[i] = CheckDef{
 			Name:     check.Name,
@@ -291,7 +341,7 @@ func (c *Catalog) CreateTable(stmt *query.CreateTableStmt) error {
 		})
 	}
 
-	return nil
+	return c.logTableSchema(storage.WALCreateTable, stmt.Table, tableDef)
 }
 
 func (c *Catalog) CreateCollection(stmt *query.CreateCollectionStmt) error {
@@ -339,7 +389,7 @@ func (c *Catalog) CreateCollection(stmt *query.CreateCollectionStmt) error {
 			tableName: stmt.Name,
 		})
 	}
-	return nil
+	return c.logTableSchema(storage.WALCreateTable, stmt.Name, tableDef)
 }
 
 func (c *Catalog) DropCollection(stmt *query.DropCollectionStmt) error {
@@ -363,13 +413,17 @@ func (c *Catalog) storeTableDef(table *TableDef) error {
 		return nil
 	}
 	key := []byte("tbl:" + table.Name)
+	seq := atomic.LoadInt64(&table.AutoIncSeq)
 	data, err := json.Marshal(table)
 	if err != nil {
 		return err
 	}
 
 	if c.tree != nil {
-		return c.tree.Put(key, data)
+		if err := c.tree.Put(key, data); err != nil {
+			return err
+		}
+		atomic.StoreInt64(&table.storedAutoIncSeq, seq)
 	}
 	return nil
 }
@@ -433,7 +487,7 @@ func (c *Catalog) validateForeignKeyDefLocked(table *TableDef, fk *query.Foreign
 				}
 				return queryForeignKeyToCatalog(fk), nil
 			}
-			return ForeignKeyDef{}, fmt.Errorf("FOREIGN KEY constraint failed: referenced table '%s' not found", fk.ReferencedTable)
+			return ForeignKeyDef{}, sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: referenced table '%s' not found", fk.ReferencedTable)
 		}
 	}
 	refColumns := append([]string(nil), fk.ReferencedColumns...)
@@ -506,7 +560,7 @@ func (c *Catalog) AlterTableAddForeignKeyConstraint(ctx context.Context, stmt *q
 			oldForeignKeys: oldFKs,
 		})
 	}
-	return nil
+	return c.logTableSchema(storage.WALAlterTable, stmt.Table, table)
 }
 
 func (c *Catalog) AlterTableAddCheckConstraint(stmt *query.AlterTableStmt) error {
@@ -551,7 +605,7 @@ func (c *Catalog) AlterTableAddCheckConstraint(stmt *query.AlterTableStmt) error
 			oldChecks: oldChecks,
 		})
 	}
-	return nil
+	return c.logTableSchema(storage.WALAlterTable, stmt.Table, table)
 }
 
 func (c *Catalog) validateCheckConstraintsLocked(table *TableDef) error {
@@ -619,7 +673,7 @@ func (c *Catalog) DropTableConstraint(tableName, constraintName string) error {
 					oldForeignKeys: oldFKs,
 				})
 			}
-			return nil
+			return c.logTableSchema(storage.WALAlterTable, tableName, table)
 		}
 	}
 	for i, check := range table.Checks {
@@ -637,7 +691,7 @@ func (c *Catalog) DropTableConstraint(tableName, constraintName string) error {
 					oldChecks: oldChecks,
 				})
 			}
-			return nil
+			return c.logTableSchema(storage.WALAlterTable, tableName, table)
 		}
 	}
 
@@ -662,9 +716,13 @@ func (c *Catalog) DropTableConstraint(tableName, constraintName string) error {
 			indexTree: c.indexTrees[constraintName],
 		})
 	}
+	c.releaseTreeLocked(c.indexTrees[constraintName])
 	delete(c.indexes, constraintName)
 	delete(c.indexTrees, constraintName)
-	return nil
+	if idxDef.Temporary {
+		return nil
+	}
+	return c.logSchemaDrop(storage.WALDropIndex, "idx:"+constraintName)
 }
 
 func (c *Catalog) DropTable(stmt *query.DropTableStmt) error {
@@ -679,7 +737,11 @@ func (c *Catalog) DropTable(stmt *query.DropTableStmt) error {
 		}
 	}
 
-	if err := c.ensureTableNotReferencedByForeignKeyLocked(stmt.Table); err != nil {
+	if stmt.Cascade {
+		if err := c.dropDependentsLocked(stmt.Table); err != nil {
+			return err
+		}
+	} else if err := c.ensureNoDependentsLocked("table", stmt.Table); err != nil {
 		return err
 	}
 
@@ -802,12 +864,14 @@ func (c *Catalog) DropTable(stmt *query.DropTableStmt) error {
 	}
 
 	// Clean up table data B-tree
+	c.releaseTreeLocked(c.tableTrees[stmt.Table])
 	delete(c.tableTrees, stmt.Table)
 
 	// Clean up indexes associated with this table
 	if tableDef != nil {
 		for idxName, idxDef := range c.indexes {
 			if idxDef.TableName == stmt.Table {
+				c.releaseTreeLocked(c.indexTrees[idxName])
 				delete(c.indexes, idxName)
 				delete(c.indexTrees, idxName)
 			}
@@ -823,28 +887,22 @@ func (c *Catalog) DropTable(stmt *query.DropTableStmt) error {
 	}
 
 	// Clean up views that reference this table (triggers, FTS indexes, stats)
+	if err := c.dropFTSIndexesForTableLocked(stmt.Table); err != nil {
+		return err
+	}
+	if err := c.dropGINIndexesForTableLocked(stmt.Table); err != nil {
+		return err
+	}
+	if err := c.dropBloomIndexesForTableLocked(stmt.Table); err != nil {
+		return err
+	}
 	delete(c.stats, stmt.Table)
 	delete(c.tables, stmt.Table)
 
-	return nil
-}
-
-func (c *Catalog) ensureTableNotReferencedByForeignKeyLocked(tableName string) error {
-	for refTableName, table := range c.tables {
-		if strings.EqualFold(refTableName, tableName) {
-			continue
-		}
-		for _, fk := range table.ForeignKeys {
-			if strings.EqualFold(fk.ReferencedTable, tableName) {
-				constraint := fk.Name
-				if constraint == "" {
-					constraint = "<unnamed>"
-				}
-				return fmt.Errorf("cannot drop table %s: referenced by foreign key %s on table %s", tableName, constraint, refTableName)
-			}
-		}
+	if !exists || tableDef.Temporary {
+		return nil
 	}
-	return nil
+	return c.logSchemaDrop(storage.WALDropTable, "tbl:"+stmt.Table)
 }
 
 func (c *Catalog) ensureColumnNotUsedByForeignKeyLocked(tableName, colName string) error {
@@ -1035,6 +1093,9 @@ func (v *checkColumnRefVisitor) VisitJSONContainsExpr(expr *query.JSONContainsEx
 func (v *checkColumnRefVisitor) VisitAliasExpr(expr *query.AliasExpr, ctx interface{}) interface{} {
 	return expr
 }
+func (v *checkColumnRefVisitor) VisitCollateExpr(expr *query.CollateExpr, ctx interface{}) interface{} {
+	return expr
+}
 func (v *checkColumnRefVisitor) VisitMatchExpr(expr *query.MatchExpr, ctx interface{}) interface{} {
 	return expr
 }
@@ -1126,7 +1187,8 @@ func (c *Catalog) CleanupFailedCreateTable(tableName string) error {
 			return fmt.Errorf("failed to delete index metadata %s during CREATE TABLE cleanup: %w", idxName, err)
 		}
 	}
-	if _, exists := c.tables[tableName]; exists {
+	tableDef, exists := c.tables[tableName]
+	if exists {
 		if err := c.deleteCatalogDef("tbl:" + tableName); err != nil {
 			return fmt.Errorf("failed to delete table metadata %s during CREATE TABLE cleanup: %w", tableName, err)
 		}
@@ -1145,7 +1207,10 @@ func (c *Catalog) CleanupFailedCreateTable(tableName string) error {
 	delete(c.stats, tableName)
 	delete(c.tables, tableName)
 	c.pruneFailedCreateTableUndoLocked(tableName, indexNames)
-	return nil
+	if !exists || tableDef.Temporary {
+		return nil
+	}
+	return c.logSchemaDrop(storage.WALDropTable, "tbl:"+tableName)
 }
 
 func (c *Catalog) pruneFailedCreateTableUndoLocked(tableName string, indexNames []string) {
@@ -1222,14 +1287,24 @@ func (c *Catalog) AlterTableAddColumn(stmt *query.AlterTableStmt) error {
 	if !exists {
 		return ErrTableNotFound
 	}
+	if err := c.checkColumnsNotVersioned(table); err != nil {
+		return err
+	}
 
 	// Check if column already exists
 	for _, col := range table.Columns {
 		if col.Name == stmt.Column.Name {
+			if stmt.TargetIfNotExists {
+				return nil
+			}
 			return fmt.Errorf("column %s already exists in table %s", stmt.Column.Name, stmt.Table)
 		}
 	}
 
+	mask, err := columnMaskOf(&stmt.Column)
+	if err != nil {
+		return fmt.Errorf("table %s: %w", stmt.Table, err)
+	}
 	newCol := ColumnDef{
 		Name:          stmt.Column.Name,
 		Type:          tokenTypeToColumnType(stmt.Column.Type),
@@ -1243,6 +1318,7 @@ func (c *Catalog) AlterTableAddColumn(stmt *query.AlterTableStmt) error {
 		Check:         stmt.Column.Check,
 		defaultExpr:   stmt.Column.Default,
 		Dimensions:    stmt.Column.Dimensions,
+		Mask:          mask,
 	}
 
 	// Backfill existing rows with the default value for the new column
@@ -1312,17 +1388,25 @@ func (c *Catalog) AlterTableAddColumn(stmt *query.AlterTableStmt) error {
 	table.Columns = append(table.Columns, newCol)
 	table.buildColumnIndexCache()
 
+	rewrites := make([]struct{ key, val []byte }, len(updates))
 	if treeExists {
 		// Apply updates
-		for _, u := range updates {
+		for i, u := range updates {
 			if err := tree.Put(u.key, u.data); err != nil {
 				return fmt.Errorf("failed to update row during ALTER TABLE backfill: %w", err)
 			}
+			rewrites[i].key, rewrites[i].val = u.key, u.data
 		}
 	}
 
 	// Store updated table definition
-	return c.storeTableDef(table)
+	if err := c.storeTableDef(table); err != nil {
+		return err
+	}
+	if err := c.logRowRewrites(table, rewrites); err != nil {
+		return err
+	}
+	return c.logTableSchema(storage.WALAlterTable, stmt.Table, table)
 }
 
 func (c *Catalog) AlterTableDropColumn(stmt *query.AlterTableStmt) error {
@@ -1339,6 +1423,9 @@ func (c *Catalog) AlterTableDropColumn(stmt *query.AlterTableStmt) error {
 	if !exists {
 		return ErrTableNotFound
 	}
+	if err := c.checkColumnsNotVersioned(table); err != nil {
+		return err
+	}
 
 	// Column name to drop can be in Column.Name (from parser) or NewName (legacy/tests)
 	colName := stmt.Column.Name
@@ -1358,7 +1445,10 @@ func (c *Catalog) AlterTableDropColumn(stmt *query.AlterTableStmt) error {
 		}
 	}
 	if colIdx < 0 {
-		return fmt.Errorf("column '%s' does not exist in table '%s'", colName, stmt.Table)
+		if stmt.TargetIfExists {
+			return nil
+		}
+		return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' does not exist in table '%s'", colName, stmt.Table)
 	}
 
 	// Save original column count before modification
@@ -1368,6 +1458,9 @@ func (c *Catalog) AlterTableDropColumn(stmt *query.AlterTableStmt) error {
 	if table.isPrimaryKeyColumn(table.Columns[colIdx].Name) {
 		return fmt.Errorf("cannot drop PRIMARY KEY column '%s'", colName)
 	}
+	if strings.EqualFold(table.TTLColumn, colName) {
+		return fmt.Errorf("cannot drop TTL column '%s'", colName)
+	}
 	if err := c.ensureColumnNotUsedByForeignKeyLocked(stmt.Table, colName); err != nil {
 		return err
 	}
@@ -1514,12 +1607,21 @@ func (c *Catalog) AlterTableDropColumn(stmt *query.AlterTableStmt) error {
 	for idxName := range dropIndexes {
 		delete(c.indexes, idxName)
 		delete(c.indexTrees, idxName)
+		c.releaseTreeLocked(dropIdxTrees[idxName])
 	}
 
 	if err := c.storeTableDef(table); err != nil {
 		return restoreDroppedIndexMetadata(err)
 	}
-	return nil
+	for _, idxName := range deletedIndexNames {
+		if err := c.logSchemaDrop(storage.WALDropIndex, "idx:"+idxName); err != nil {
+			return err
+		}
+	}
+	if err := c.logRowRewrites(table, updates); err != nil {
+		return err
+	}
+	return c.logTableSchema(storage.WALAlterTable, stmt.Table, table)
 }
 
 func (c *Catalog) AlterTableRename(stmt *query.AlterTableStmt) error {
@@ -1541,39 +1643,53 @@ func (c *Catalog) AlterTableRename(stmt *query.AlterTableStmt) error {
 	if !exists {
 		return ErrTableNotFound
 	}
+	if err := c.checkColumnsNotVersioned(table); err != nil {
+		return err
+	}
 
 	if _, exists := c.tables[stmt.NewName]; exists {
-		return fmt.Errorf("table '%s' already exists", stmt.NewName)
+		return sqlerr.Errorf(sqlerr.CodeDuplicateTable, "table '%s' already exists", stmt.NewName)
+	}
+
+	if err := c.renameTableLocked(stmt.Table, stmt.NewName); err != nil {
+		return err
 	}
+	return c.logTableSchema(storage.WALAlterTable, stmt.Table, table)
+}
 
-	if err := c.deleteCatalogDef("tbl:" + stmt.Table); err != nil {
-		return fmt.Errorf("failed to delete renamed table metadata %s: %w", stmt.Table, err)
+// renameTableLocked renames table oldName to newName, moving its tree,
+// indexes, statistics and the foreign keys that reference it. Must be called
+// with c.mu held.
+func (c *Catalog) renameTableLocked(oldName, newName string) error {
+	table := c.tables[oldName]
+	if err := c.deleteCatalogDef("tbl:" + oldName); err != nil {
+		return fmt.Errorf("failed to delete renamed table metadata %s: %w", oldName, err)
 	}
 
 	// Save undo entry before modification
 	if c.isCurrentTxnActive() {
 		c.appendUndoEntry(undoEntry{
 			action:    undoAlterRename,
-			tableName: stmt.Table,
-			oldName:   stmt.Table,
-			newName:   stmt.NewName,
+			tableName: oldName,
+			oldName:   oldName,
+			newName:   newName,
 		})
 	}
 
 	// Update table name in all maps
-	delete(c.tables, stmt.Table)
-	c.tables[stmt.NewName] = table
+	delete(c.tables, oldName)
+	c.tables[newName] = table
 
-	if tree, exists := c.tableTrees[stmt.Table]; exists {
-		delete(c.tableTrees, stmt.Table)
-		c.tableTrees[stmt.NewName] = tree
+	if tree, exists := c.tableTrees[oldName]; exists {
+		delete(c.tableTrees, oldName)
+		c.tableTrees[newName] = tree
 	}
 
 	// Update index references
 	var changedIndexes []*IndexDef
 	for _, idxDef := range c.indexes {
-		if idxDef.TableName == stmt.Table {
-			idxDef.TableName = stmt.NewName
+		if idxDef.TableName == oldName {
+			idxDef.TableName = newName
 			changedIndexes = append(changedIndexes, idxDef)
 		}
 	}
@@ -1581,35 +1697,35 @@ func (c *Catalog) AlterTableRename(stmt *query.AlterTableStmt) error {
 	changedFKTables := make(map[string]*TableDef)
 	for tableName, tbl := range c.tables {
 		for i := range tbl.ForeignKeys {
-			if strings.EqualFold(tbl.ForeignKeys[i].ReferencedTable, stmt.Table) {
-				tbl.ForeignKeys[i].ReferencedTable = stmt.NewName
+			if strings.EqualFold(tbl.ForeignKeys[i].ReferencedTable, oldName) {
+				tbl.ForeignKeys[i].ReferencedTable = newName
 				changedFKTables[tableName] = tbl
 			}
 		}
 	}
 
 	// Update stats
-	if stats, exists := c.stats[stmt.Table]; exists {
-		delete(c.stats, stmt.Table)
-		c.stats[stmt.NewName] = stats
+	if stats, exists := c.stats[oldName]; exists {
+		delete(c.stats, oldName)
+		c.stats[newName] = stats
 	}
 
-	table.Name = stmt.NewName
+	table.Name = newName
 
 	if err := c.storeTableDef(table); err != nil {
 		return fmt.Errorf("failed to persist renamed table: %w", err)
 	}
 	for _, idxDef := range changedIndexes {
 		if err := c.storeIndexDef(idxDef); err != nil {
-			return fmt.Errorf("failed to store index metadata %s after renaming table %s to %s: %w", idxDef.Name, stmt.Table, stmt.NewName, err)
+			return fmt.Errorf("failed to store index metadata %s after renaming table %s to %s: %w", idxDef.Name, oldName, newName, err)
 		}
 	}
 	for tableName, tbl := range changedFKTables {
-		if tableName == stmt.NewName {
+		if tableName == newName {
 			continue
 		}
 		if err := c.storeTableDef(tbl); err != nil {
-			return fmt.Errorf("failed to store foreign key metadata for table %s after renaming referenced table %s to %s: %w", tableName, stmt.Table, stmt.NewName, err)
+			return fmt.Errorf("failed to store foreign key metadata for table %s after renaming referenced table %s to %s: %w", tableName, oldName, newName, err)
 		}
 	}
 
@@ -1640,6 +1756,9 @@ func (c *Catalog) AlterTableRenameColumn(stmt *query.AlterTableStmt) error {
 	if !exists {
 		return ErrTableNotFound
 	}
+	if err := c.checkColumnsNotVersioned(table); err != nil {
+		return err
+	}
 
 	found := false
 	for i, col := range table.Columns {
@@ -1667,11 +1786,12 @@ func (c *Catalog) AlterTableRenameColumn(stmt *query.AlterTableStmt) error {
 		}
 	}
 	if !found {
-		return fmt.Errorf("column '%s' does not exist in table '%s'", stmt.OldName, stmt.Table)
+		return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' does not exist in table '%s'", stmt.OldName, stmt.Table)
 	}
 
 	table.buildColumnIndexCache()
 	renameCheckColumnReferences(table, stmt.OldName, stmt.NewName)
+	renameTTLColumn(table, stmt.OldName, stmt.NewName)
 
 	// Update index column references
 	var changedIndexes []*IndexDef
@@ -1710,6 +1830,17 @@ func (c *Catalog) AlterTableRenameColumn(stmt *query.AlterTableStmt) error {
 		if err := c.storeTableDef(tbl); err != nil {
 			return fmt.Errorf("failed to store foreign key metadata for table %s after renaming column %s.%s: %w", tableName, stmt.Table, stmt.OldName, err)
 		}
+		if err := c.logTableSchema(storage.WALAlterTable, tableName, tbl); err != nil {
+			return err
+		}
+	}
+	if err := c.logTableSchema(storage.WALAlterTable, stmt.Table, table); err != nil {
+		return err
+	}
+	for _, idxDef := range changedIndexes {
+		if err := c.logIndexSchema(idxDef); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -1750,6 +1881,9 @@ func (c *Catalog) getTableLocked(name string) (*TableDef, error) {
 			}
 			return synthetic, nil
 		}
+		if schema, name := query.SplitTableName(name); schema != "" {
+			return c.getQualifiedTableLocked(schema, name)
+		}
 		return nil, ErrTableNotFound
 	}
 	return table, nil
@@ -1780,6 +1914,12 @@ func (c *Catalog) putCachedTable(name string, def *TableDef) {
 	c.schemaCacheMu.Unlock()
 }
 
+// SchemaVersion returns a number that changes with every schema change, for
+// callers caching anything derived from the schema.
+func (c *Catalog) SchemaVersion() uint64 {
+	return c.schemaVersion.Load()
+}
+
 // invalidateSchemaCache clears all cached entries and bumps schemaVersion.
 // Call after any DDL operation.
 func (c *Catalog) invalidateSchemaCache() {
@@ -1838,7 +1978,10 @@ func (c *Catalog) createViewSQL(name string, viewQuery *query.SelectStmt, sql st
 			viewTemporary: temporary,
 		})
 	}
-	return nil
+	if temporary {
+		return nil
+	}
+	return c.logSQLSchema(storage.WALCreateView, "view:"+name, name, c.viewSQL[name])
 }
 
 func (c *Catalog) CreateOrReplaceViewSQL(name string, viewQuery *query.SelectStmt, sql string) error {
@@ -1894,7 +2037,13 @@ func (c *Catalog) createOrReplaceViewSQL(name string, viewQuery *query.SelectStm
 			})
 		}
 	}
-	return nil
+	if temporary {
+		if existed && !oldTemporary {
+			return c.logSchemaDrop(storage.WALDropView, "view:"+name)
+		}
+		return nil
+	}
+	return c.logSQLSchema(storage.WALCreateView, "view:"+name, name, c.viewSQL[name])
 }
 
 func (c *Catalog) GetView(name string) (*query.SelectStmt, error) {
@@ -1927,6 +2076,8 @@ func (c *Catalog) getViewLocked(name string) (*query.SelectStmt, error) {
 	return view, nil
 }
 
+// DropView removes a view. It fails if other views depend on it; see
+// DropViewCascade.
 func (c *Catalog) DropView(name string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -1934,6 +2085,28 @@ func (c *Catalog) DropView(name string) error {
 	if _, exists := c.views[name]; !exists {
 		return ErrTableNotFound
 	}
+	if err := c.ensureNoDependentsLocked("view", name); err != nil {
+		return err
+	}
+	return c.dropViewLocked(name)
+}
+
+// DropViewCascade removes a view and the views that depend on it.
+func (c *Catalog) DropViewCascade(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.invalidateSchemaCache()
+	if _, exists := c.views[name]; !exists {
+		return ErrTableNotFound
+	}
+	if err := c.dropDependentsLocked(name); err != nil {
+		return err
+	}
+	return c.dropViewLocked(name)
+}
+
+// dropViewLocked removes a view. Must be called with c.mu held.
+func (c *Catalog) dropViewLocked(name string) error {
 	temporary := c.viewTemporary[name]
 	if !temporary {
 		if err := c.deleteCatalogDef("view:" + name); err != nil {
@@ -1952,7 +2125,10 @@ func (c *Catalog) DropView(name string) error {
 	delete(c.views, name)
 	delete(c.viewSQL, name)
 	delete(c.viewTemporary, name)
-	return nil
+	if temporary {
+		return nil
+	}
+	return c.logSchemaDrop(storage.WALDropView, "view:"+name)
 }
 
 func (c *Catalog) HasTableOrView(name string) bool {
@@ -1975,7 +2151,7 @@ func (c *Catalog) CreateTriggerSQL(stmt *query.CreateTriggerStmt, sql string) er
 	_, tableExists := c.tables[stmt.Table]
 	_, viewExists := c.views[stmt.Table]
 	if !tableExists && !viewExists {
-		return fmt.Errorf("table or view not found: %s", stmt.Table)
+		return sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table or view not found: %s", stmt.Table)
 	}
 
 	if _, exists := c.triggers[stmt.Name]; exists {
@@ -1997,7 +2173,7 @@ func (c *Catalog) CreateTriggerSQL(stmt *query.CreateTriggerStmt, sql string) er
 			triggerSQL:  stmt.RawSQL,
 		})
 	}
-	return nil
+	return c.logSQLSchema(storage.WALCreateTrigger, "trg:"+stmt.Name, stmt.Name, stmt.RawSQL)
 }
 
 func (c *Catalog) GetTrigger(name string) (*query.CreateTriggerStmt, error) {
@@ -2042,7 +2218,7 @@ func (c *Catalog) DropTrigger(name string) error {
 	}
 	delete(c.triggers, name)
 	delete(c.triggerSQL, name)
-	return nil
+	return c.logSchemaDrop(storage.WALDropTrigger, "trg:"+name)
 }
 
 func (c *Catalog) GetTriggersForTable(tableName string, event string) []*query.CreateTriggerStmt {
@@ -2318,6 +2494,7 @@ func (c *Catalog) resolveTriggerExpr(expr query.Expression, newRow []interface{}
 			Expr:    c.resolveTriggerExpr(e.Expr, newRow, oldRow, columns),
 			Pattern: c.resolveTriggerExpr(e.Pattern, newRow, oldRow, columns),
 			Not:     e.Not,
+			Escape:  c.resolveTriggerExpr(e.Escape, newRow, oldRow, columns),
 		}
 	}
 	return expr
@@ -2530,7 +2707,7 @@ func (c *Catalog) executeInsteadOfUpdateTrigger(ctx context.Context, trigger *qu
 		// It's a table - scan it
 		tree, exists := c.tableTrees[stmt.Table]
 		if !exists {
-			return 0, 0, fmt.Errorf("table not found: %s", stmt.Table)
+			return 0, 0, sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table not found: %s", stmt.Table)
 		}
 		iter, err := tree.Scan(nil, nil)
 		if err != nil {
@@ -2602,7 +2779,7 @@ func (c *Catalog) executeInsteadOfDeleteTrigger(ctx context.Context, trigger *qu
 		// It's a table - scan it
 		tree, exists := c.tableTrees[stmt.Table]
 		if !exists {
-			return 0, 0, fmt.Errorf("table not found: %s", stmt.Table)
+			return 0, 0, sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table not found: %s", stmt.Table)
 		}
 		iter, err := tree.Scan(nil, nil)
 		if err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/btree"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
 	"github.com/cobaltdb/cobaltdb/pkg/txn"
 )
 
@@ -32,7 +33,14 @@ type deleteSnapshot struct {
 	useIndex    bool
 }
 
-func (c *Catalog) Delete(ctx context.Context, stmt *query.DeleteStmt, args []interface{}) (int64, int64, error) {
+func (c *Catalog) Delete(ctx context.Context, stmt *query.DeleteStmt, args []interface{}) (_ int64, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.Delete")
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.String("db.sql.table", stmt.Table))
+	}
+	defer func() { tracing.End(span, err) }()
+	defer tracing.Bind(ctx)()
+
 	// Fast path: resolve table metadata from schema cache without lock.
 	table, ver, cacheHit := c.getCachedTable(stmt.Table)
 	if !cacheHit {
@@ -146,6 +154,7 @@ func (c *Catalog) Delete(ctx context.Context, stmt *query.DeleteStmt, args []int
 	}
 
 	c.invalidateQueryCache(stmt.Table)
+	c.noteModifiedRows(stmt.Table, rowsAffected)
 
 	c.setLastReturning(returningRows, returningCols)
 
@@ -421,6 +430,7 @@ func (c *Catalog) deleteLocked(ctx context.Context, stmt *query.DeleteStmt, args
 
 	// Invalidate query cache for the affected table
 	c.invalidateQueryCache(stmt.Table)
+	c.noteModifiedRows(stmt.Table, rowsAffected)
 
 	// Store returning rows for retrieval
 	c.setLastReturning(returningRows, returningCols)
@@ -713,6 +723,9 @@ func (c *Catalog) applyDeleteEntryDirect(
 	if err := c.updateVectorIndexesForDelete(stmt.Table, string(key)); err != nil {
 		return err
 	}
+	c.updateFTSIndexesForWrite(table, key, nil, nil)
+	c.updateGINIndexesForWrite(table, key, nil, nil)
+	c.updateBloomIndexesForWrite(table, key, nil, nil)
 
 	// Log to WAL before applying change.
 	if c.wal != nil && txnActive {
@@ -725,7 +738,7 @@ func (c *Catalog) applyDeleteEntryDirect(
 			Type:  storage.WALDelete,
 			Data:  walData,
 		}
-		if err := c.wal.Append(record); err != nil {
+		if err := c.appendRowWAL(ts, record); err != nil {
 			return err
 		}
 	}
@@ -784,17 +797,23 @@ func (c *Catalog) applyDeleteEntryDirect(
 }
 
 func (c *Catalog) rollbackAppliedDeleteEntries(tableName string, entries []deleteEntry) error {
+	// The indexes are rebuilt even if a row cannot be restored: a row whose
+	// soft delete failed is still live and must stay indexed.
+	var restoreErr error
 	for i := len(entries) - 1; i >= 0; i-- {
 		entry := entries[i]
 		deleteTree, exists := c.tableTrees[entry.treeName]
 		if !exists {
 			return fmt.Errorf("partition tree %s not found", entry.treeName)
 		}
-		if err := deleteTree.Put(entry.key, entry.value); err != nil {
-			return fmt.Errorf("restore deleted row: %w", err)
+		if err := deleteTree.Put(entry.key, entry.value); err != nil && restoreErr == nil {
+			restoreErr = fmt.Errorf("restore deleted row: %w", err)
 		}
 	}
-	return c.rebuildTableIndexesLocked(tableName)
+	if err := c.rebuildTableIndexesLocked(tableName); err != nil {
+		return err
+	}
+	return restoreErr
 }
 
 // bufferDeleteEntries buffers soft-deleted rows and their index mutations for
@@ -860,6 +879,10 @@ func (c *Catalog) applyDeleteEntryBuffered(
 	if err := c.updateVectorIndexesForDelete(stmt.Table, string(key)); err != nil {
 		return err
 	}
+	c.updateFTSIndexesForWrite(table, key, nil, nil)
+	// GIN and bloom indexes pick the delete up from the pending writes at
+	// commit; removing the row now would lose it if the transaction rolls
+	// back.
 
 	// Soft-delete encoding: mark deleted → re-encode.
 	version.markDeleted(time.Now())
@@ -893,6 +916,9 @@ func (c *Catalog) applyDeleteEntryBuffered(
 	}
 
 	// Buffer the write for commit-time application.
+	if err := c.lockRow(ts, entry.treeName, string(key)); err != nil {
+		return err
+	}
 	c.appendPendingWriteTs(ts, PendingWrite{
 		TreeName:     entry.treeName,
 		Key:          string(key),
@@ -0,0 +1,158 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// Dependent is a catalog object that depends on a table or view, so that
+// dropping the table or view would break it.
+type Dependent struct {
+	Kind  string // "view", "materialized view", "foreign key" or "system-versioned table"
+	Name  string // the view, or the foreign key constraint ("" if unnamed)
+	Table string // for a foreign key, the table it is defined on
+}
+
+func (d Dependent) String() string {
+	if d.Kind == "foreign key" {
+		name := d.Name
+		if name == "" {
+			name = "<unnamed>"
+		}
+		return fmt.Sprintf("foreign key %s on table %s", name, d.Table)
+	}
+	return d.Kind + " " + d.Name
+}
+
+// Dependents returns the objects that depend on the table or view name:
+// the views and materialized views that read it, the foreign keys of other
+// tables that reference it, and the system-versioned table it keeps the
+// history of. Dependencies are read from the stored
+// definitions, so they are always current.
+func (c *Catalog) Dependents(name string) []Dependent {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dependentsLocked(name)
+}
+
+func (c *Catalog) dependentsLocked(name string) []Dependent {
+	var deps []Dependent
+	for viewName, view := range c.views {
+		if !strings.EqualFold(viewName, name) && readsRelation(view, name) {
+			deps = append(deps, Dependent{Kind: "view", Name: viewName})
+		}
+	}
+	for mvName, mv := range c.materializedViews {
+		if mv.Query != nil && readsRelation(mv.Query, name) {
+			deps = append(deps, Dependent{Kind: "materialized view", Name: mvName})
+		}
+	}
+	for tableName, table := range c.tables {
+		if strings.EqualFold(tableName, name) {
+			continue
+		}
+		for _, fk := range table.ForeignKeys {
+			if strings.EqualFold(fk.ReferencedTable, name) {
+				deps = append(deps, Dependent{Kind: "foreign key", Name: fk.Name, Table: tableName})
+			}
+		}
+		if table.HistoryTable != "" && strings.EqualFold(table.HistoryTable, name) {
+			deps = append(deps, Dependent{Kind: "system-versioned table", Name: tableName})
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].String() < deps[j].String() })
+	return deps
+}
+
+// readsRelation reports whether a view query reads the table or view name.
+func readsRelation(stmt *query.SelectStmt, name string) bool {
+	for _, table := range query.ReferencedTables(stmt) {
+		if strings.EqualFold(table, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureNoDependentsLocked fails, listing the dependents, if any object
+// depends on the table or view name: DROP's RESTRICT behavior.
+func (c *Catalog) ensureNoDependentsLocked(kind, name string) error {
+	deps := c.dependentsLocked(name)
+	if len(deps) == 0 {
+		return nil
+	}
+	list := make([]string, len(deps))
+	for i, dep := range deps {
+		list[i] = dep.String()
+	}
+	return fmt.Errorf("cannot drop %s %s because other objects depend on it: %s (use DROP ... CASCADE to drop them too)",
+		kind, name, strings.Join(list, ", "))
+}
+
+// dropDependentsLocked drops what depends on the table or view name, for
+// DROP ... CASCADE: views that read it, and their own dependents, are
+// dropped, and foreign keys that reference it are removed from their
+// tables. Must be called with c.mu held.
+func (c *Catalog) dropDependentsLocked(name string) error {
+	for _, dep := range c.dependentsLocked(name) {
+		switch dep.Kind {
+		case "view":
+			if _, exists := c.views[dep.Name]; !exists {
+				continue // already dropped as a dependent of another view
+			}
+			if err := c.dropDependentsLocked(dep.Name); err != nil {
+				return err
+			}
+			if err := c.dropViewLocked(dep.Name); err != nil {
+				return err
+			}
+		case "materialized view":
+			if err := c.dropMaterializedViewLocked(dep.Name); err != nil {
+				return err
+			}
+		case "foreign key":
+			if err := c.dropReferencingForeignKeysLocked(dep.Table, name); err != nil {
+				return err
+			}
+		case "system-versioned table":
+			return fmt.Errorf("cannot drop %s, the history table of system-versioned table %s: drop %s first", name, dep.Name, dep.Name)
+		}
+	}
+	return nil
+}
+
+// dropReferencingForeignKeysLocked removes the foreign keys of tableName
+// that reference refTable. Must be called with c.mu held.
+func (c *Catalog) dropReferencingForeignKeysLocked(tableName, refTable string) error {
+	table, exists := c.tables[tableName]
+	if !exists {
+		return nil
+	}
+	oldFKs := cloneForeignKeys(table.ForeignKeys)
+	kept := table.ForeignKeys[:0:0]
+	for _, fk := range table.ForeignKeys {
+		if !strings.EqualFold(fk.ReferencedTable, refTable) {
+			kept = append(kept, fk)
+		}
+	}
+	if len(kept) == len(oldFKs) {
+		return nil
+	}
+	table.ForeignKeys = kept
+	if err := c.storeTableDef(table); err != nil {
+		table.ForeignKeys = oldFKs
+		return err
+	}
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{
+			action:         undoAlterForeignKeys,
+			tableName:      tableName,
+			oldForeignKeys: oldFKs,
+		})
+	}
+	return c.logTableSchema(storage.WALAlterTable, tableName, table)
+}
@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const maxStringResultLen = 10 * 1024 * 1024 // 10 MB cap for string functions
@@ -22,6 +24,24 @@ type functionHandler func(args []interface{}) (interface{}, error)
 // Handlers return (value, error). The map covers scalar functions;
 // aggregate, special-syntax, and fallthrough functions remain in the switch.
 var scalarFunctionHandlers = map[string]functionHandler{
+	"MASK":           evalMask,
+	"MASK_FIRST":     evalMaskFirst,
+	"MASK_LAST":      evalMaskLast,
+	"MASK_EMAIL":     evalMaskEmail,
+	"MD5":            digestFunc("MD5", hashAlgorithms["MD5"]),
+	"SHA1":           digestFunc("SHA1", hashAlgorithms["SHA1"]),
+	"SHA256":         digestFunc("SHA256", hashAlgorithms["SHA256"]),
+	"SHA512":         digestFunc("SHA512", hashAlgorithms["SHA512"]),
+	"SHA2":           evalSHA2,
+	"HMAC":           evalHMAC,
+	"CRC32":          evalCRC32,
+	"TO_BASE64":      evalToBase64,
+	"FROM_BASE64":    evalFromBase64,
+	"ILIKE":          evalILike,
+	"REGEXP_LIKE":    evalRegexpLikeValue,
+	"REGEXP_MATCH":   evalRegexpLikeValue,
+	"REGEXP_REPLACE": evalRegexpReplace,
+	"REGEXP_EXTRACT": evalRegexpExtract,
 	"NULLIF": func(args []interface{}) (interface{}, error) {
 		if len(args) < 2 {
 			return nil, fmt.Errorf("NULLIF requires 2 arguments")
@@ -55,6 +75,17 @@ var scalarFunctionHandlers = map[string]functionHandler{
 		}
 		return float64(n.Int64()), nil
 	},
+	"RANDOMBLOB": func(args []interface{}) (interface{}, error) {
+		size, err := randomBlobSize(args)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("RANDOMBLOB: %w", err)
+		}
+		return b, nil
+	},
 	"IIF": func(args []interface{}) (interface{}, error) {
 		if len(args) < 3 {
 			return nil, fmt.Errorf("IIF requires 3 arguments")
@@ -97,46 +128,49 @@ var scalarFunctionHandlers = map[string]functionHandler{
 			return "text", nil
 		}
 	},
-	"TIME": func(args []interface{}) (interface{}, error) {
-		if len(args) < 1 || args[0] == nil {
-			return nil, nil
-		}
-		if t, ok := parseFlexibleTime(ValueToStringKey(args[0])); ok {
-			return t.Format("15:04:05"), nil
-		}
-		return args[0], nil
-	},
-	"DATETIME": func(args []interface{}) (interface{}, error) {
-		if len(args) < 1 || args[0] == nil {
-			return nil, nil
-		}
-		if t, ok := parseFlexibleTime(ValueToStringKey(args[0])); ok {
-			return t.Format("2006-01-02 15:04:05"), nil
-		}
-		return args[0], nil
-	},
+	"DATE":        dateTimeFunc(dateLayout),
+	"TIME":        dateTimeFunc(timeLayout),
+	"DATETIME":    dateTimeFunc(datetimeLayout),
+	"UUID":        uuidFunc,
+	"UUID_V7":     uuidV7Func,
+	"UUID_TO_BIN": uuidToBinFunc,
+	"BIN_TO_UUID": binToUUIDFunc,
 	"NOW": func(args []interface{}) (interface{}, error) {
-		return time.Now().Format("2006-01-02 15:04:05"), nil
+		return currentTime().Format(datetimeLayout), nil
 	},
 	"CURRENT_TIMESTAMP": func(args []interface{}) (interface{}, error) {
-		return time.Now().Format("2006-01-02 15:04:05"), nil
+		return currentTime().Format(datetimeLayout), nil
 	},
 	"CURRENT_TIME": func(args []interface{}) (interface{}, error) {
-		return time.Now().Format("15:04:05"), nil
+		return currentTime().Format(timeLayout), nil
 	},
 	"CURRENT_DATE": func(args []interface{}) (interface{}, error) {
-		return time.Now().Format("2006-01-02"), nil
+		return currentTime().Format(dateLayout), nil
 	},
 	"STRFTIME": func(args []interface{}) (interface{}, error) {
-		if len(args) < 2 || args[0] == nil || args[1] == nil {
+		if len(args) < 2 || args[0] == nil {
 			return nil, nil
 		}
-		t, ok := parseFlexibleTime(ValueToStringKey(args[1]))
+		t, ok := evalTimeArgs(args[1:])
 		if !ok {
 			return nil, nil
 		}
 		return applyStrftime(ValueToStringKey(args[0]), t), nil
 	},
+	"JULIANDAY": func(args []interface{}) (interface{}, error) {
+		t, ok := evalTimeArgs(args)
+		if !ok {
+			return nil, nil
+		}
+		return julianDay(t), nil
+	},
+	"UNIXEPOCH": func(args []interface{}) (interface{}, error) {
+		t, ok := evalTimeArgs(args)
+		if !ok {
+			return nil, nil
+		}
+		return t.Unix(), nil
+	},
 	"GROUP_CONCAT": func(args []interface{}) (interface{}, error) {
 		if len(args) >= 1 && args[0] != nil {
 			return ValueToStringKey(args[0]), nil
@@ -149,15 +183,6 @@ var scalarFunctionHandlers = map[string]functionHandler{
 	"MAX": func(args []interface{}) (interface{}, error) {
 		return scalarMinMax(args, true), nil
 	},
-	"DATE": func(args []interface{}) (interface{}, error) {
-		if len(args) < 1 || args[0] == nil {
-			return nil, nil
-		}
-		if t, ok := parseFlexibleTime(ValueToStringKey(args[0])); ok {
-			return t.Format("2006-01-02"), nil
-		}
-		return args[0], nil
-	},
 	"YEAR":       dateFieldFunc(func(t time.Time) int64 { return int64(t.Year()) }),
 	"MONTH":      dateFieldFunc(func(t time.Time) int64 { return int64(t.Month()) }),
 	"DAY":        dateFieldFunc(func(t time.Time) int64 { return int64(t.Day()) }),
@@ -262,7 +287,7 @@ func parseFlexibleTime(s string) (time.Time, bool) {
 		return time.Time{}, false
 	}
 	if strings.EqualFold(s, "now") {
-		return time.Now().UTC(), true
+		return currentTime(), true
 	}
 	formats := []string{
 		"2006-01-02 15:04:05.999999999",
@@ -276,16 +301,21 @@ func parseFlexibleTime(s string) (time.Time, bool) {
 		"2006-01",
 		"2006",
 		"15:04:05",
+		"15:04",
+		"2006-01-02 15:04:05.999999999Z07:00",
 		time.RFC3339,
 	}
 	for _, f := range formats {
 		if t, err := time.Parse(f, s); err == nil {
-			return t, true
+			return t.UTC(), true
 		}
 	}
 	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
 		return time.Unix(n, 0).UTC(), true
 	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return epochToTime(f), true
+	}
 	return time.Time{}, false
 }
 
@@ -311,6 +341,10 @@ func applyStrftime(format string, t time.Time) string {
 			fmt.Fprintf(&b, "%02d", t.Minute())
 		case 'S':
 			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'f':
+			fmt.Fprintf(&b, "%02d.%03d", t.Second(), t.Nanosecond()/int(time.Millisecond))
+		case 'J':
+			b.WriteString(strconv.FormatFloat(julianDay(t), 'f', -1, 64))
 		case 'j':
 			fmt.Fprintf(&b, "%03d", t.YearDay())
 		case 'w':
@@ -504,18 +538,79 @@ func (ctx *EvalContext) EvalPlaceholder(index int) (interface{}, error) {
 	return nil, fmt.Errorf("placeholder index out of range")
 }
 
+// SetCaseSensitiveLike sets whether LIKE tells upper and lower case apart,
+// like SQLite's PRAGMA case_sensitive_like. LIKE is case-sensitive by
+// default.
+func (c *Catalog) SetCaseSensitiveLike(sensitive bool) {
+	if c.likeNoCase.Swap(!sensitive) == !sensitive {
+		return
+	}
+	// Cached LIKE results were computed under the other setting.
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.queryCache != nil {
+		c.queryCache.InvalidateAll()
+	}
+}
+
+// CaseSensitiveLike reports whether LIKE is case-sensitive.
+func (c *Catalog) CaseSensitiveLike() bool {
+	return !c.likeNoCase.Load()
+}
+
+// likeEscapeChar returns the character an ESCAPE clause names, or 0 when
+// there is none. An empty string means no escape, as in PostgreSQL; longer
+// strings are an error.
+func likeEscapeChar(escape interface{}) (rune, error) {
+	if escape == nil {
+		return 0, nil
+	}
+	escStr := ValueToStringKey(escape)
+	if escStr == "" {
+		return 0, nil
+	}
+	r, size := utf8.DecodeRuneInString(escStr)
+	if size != len(escStr) {
+		return 0, fmt.Errorf("ESCAPE expression must be a single character, got %q", escStr)
+	}
+	return r, nil
+}
+
+// evalILike implements ILIKE(str, pattern [, escape]), which
+// `x [NOT] ILIKE pattern [ESCAPE e]` parses to: LIKE that ignores case
+// whatever PRAGMA case_sensitive_like says.
+func evalILike(args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("ILIKE requires 2 arguments")
+	}
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	var escape interface{}
+	if len(args) > 2 {
+		escape = args[2]
+	}
+	escapeChar, err := likeEscapeChar(escape)
+	if err != nil {
+		return nil, err
+	}
+	return matchLikeSimple(strings.ToLower(ValueToStringKey(args[0])), strings.ToLower(ValueToStringKey(args[1])), unicode.ToLower(escapeChar)), nil
+}
+
 func (ctx *EvalContext) EvalLike(val, pattern, escape interface{}, not bool) (interface{}, error) {
 	if val == nil || pattern == nil {
 		return nil, nil
 	}
 	leftStr := ValueToStringKey(val)
 	patternStr := ValueToStringKey(pattern)
-	escapeChar := byte(0)
-	if escape != nil {
-		escStr := ValueToStringKey(escape)
-		if len(escStr) == 1 {
-			escapeChar = escStr[0]
-		}
+	escapeChar, err := likeEscapeChar(escape)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Catalog != nil && ctx.Catalog.likeNoCase.Load() {
+		leftStr = strings.ToLower(leftStr)
+		patternStr = strings.ToLower(patternStr)
+		escapeChar = unicode.ToLower(escapeChar)
 	}
 	var matched bool
 	if escapeChar != 0 {
@@ -529,16 +624,6 @@ func (ctx *EvalContext) EvalLike(val, pattern, escape interface{}, not bool) (in
 	return matched, nil
 }
 
-func evalRegexpLikeValue(args []interface{}) (interface{}, error) {
-	if len(args) < 2 {
-		return nil, fmt.Errorf("REGEXP_LIKE requires 2 arguments")
-	}
-	if args[0] == nil || args[1] == nil {
-		return nil, nil
-	}
-	return RegexMatch(ValueToStringKey(args[0]), ValueToStringKey(args[1]))
-}
-
 func evalBooleanTestFunction(funcName string, args []interface{}) (interface{}, bool) {
 	switch funcName {
 	case "IS_TRUE", "IS_FALSE", "IS_UNKNOWN":
@@ -584,31 +669,11 @@ func (ctx *EvalContext) EvalIn(val interface{}, list []interface{}, not bool) (i
 }
 
 func (ctx *EvalContext) EvalInSubquery(val interface{}, q *query.SelectStmt, not bool) (interface{}, error) {
-	if val == nil {
-		return nil, nil
-	}
-	subq := resolveOuterRefsInQuery(q, ctx.Row, ctx.Columns)
-	_, rows, err := ctx.Catalog.selectLocked(subq, ctx.Args)
+	set, err := ctx.Catalog.inSubqueryResult(q, ctx.Row, ctx.Columns, ctx.Args)
 	if err != nil {
 		return false, err
 	}
-	hasNull := false
-	for _, row := range rows {
-		if len(row) == 0 {
-			continue
-		}
-		if row[0] == nil {
-			hasNull = true
-			continue
-		}
-		if compareValues(val, row[0]) == 0 {
-			return !not, nil
-		}
-	}
-	if hasNull {
-		return nil, nil
-	}
-	return not, nil
+	return set.contains(val, not), nil
 }
 
 func (ctx *EvalContext) EvalBetween(val, lower, upper interface{}, not bool) (interface{}, error) {
@@ -635,12 +700,12 @@ func (ctx *EvalContext) EvalIsNull(val interface{}, not bool) (bool, error) {
 func (ctx *EvalContext) EvalFunctionCall(name string, args []interface{}, distinct bool) (interface{}, error) {
 	funcName := name
 
-	if val, handled := evalBooleanTestFunction(funcName, args); handled {
-		return val, nil
+	if fn := ctx.Catalog.userFunction(funcName); fn != nil {
+		return callUserFunction(funcName, fn, args)
 	}
 
-	if funcName == "REGEXP_LIKE" {
-		return evalRegexpLikeValue(args)
+	if val, handled := evalBooleanTestFunction(funcName, args); handled {
+		return val, nil
 	}
 
 	// Short-circuit evaluation for COALESCE/IFNULL
@@ -681,23 +746,44 @@ func (ctx *EvalContext) EvalFunctionCall(name string, args []interface{}, distin
 		return nil, nil
 	}
 
+	if val, handled, err := ctx.Catalog.seededRandomFunction(funcName, args); handled {
+		return val, err
+	}
+
+	if val, handled, err := ctx.Catalog.evaluateSequenceFunction(funcName, args); handled {
+		return val, err
+	}
+	if val, handled, err := ctx.Catalog.evaluateSessionFunction(funcName, args); handled {
+		return val, err
+	}
+	if val, handled, err := ctx.Catalog.evaluateColumnCryptoFunction(funcName, args); handled {
+		return val, err
+	}
+
 	// Dispatch from the scalar function table (covers NULLIF, TYPEOF, DATE/TIME, etc.)
 	if handler, ok := scalarFunctionHandlers[funcName]; ok {
 		return handler(args)
 	}
 
 	// Check for JSON functions
-	return evaluateJSONFunction(funcName, args)
+	return evaluateJSONFunction(ctx.Catalog.budget().jsonDocs(), funcName, args)
 }
 
 func (ctx *EvalContext) EvalAlias(inner interface{}) (interface{}, error) {
 	return inner, nil
 }
 
+// EvalCase picks the result of the first WHEN that holds. With a non-nil
+// operand (a simple CASE) a WHEN holds when its value equals the operand;
+// otherwise when its condition is true, numbers being true when non-zero.
 func (ctx *EvalContext) EvalCase(expr interface{}, whens [][2]interface{}, elseVal interface{}) (interface{}, error) {
 	for _, w := range whens {
 		cond, result := w[0], w[1]
-		if cond == true {
+		if expr != nil {
+			if cond != nil && compareValues(expr, cond) == 0 {
+				return result, nil
+			}
+		} else if toBool(cond) {
 			return result, nil
 		}
 	}
@@ -780,7 +866,7 @@ func (ctx *EvalContext) EvalJSONPath(jsonVal interface{}, path string, asText bo
 	if !ok {
 		return nil, nil
 	}
-	result, err := JSONExtract(jsonStr, path)
+	result, err := jsonExtract(ctx.Catalog.budget().jsonDocs(), jsonStr, path)
 	if err != nil {
 		return nil, err
 	}
@@ -796,18 +882,20 @@ func (ctx *EvalContext) EvalJSONPath(jsonVal interface{}, path string, asText bo
 	return result, nil
 }
 
+// EvalJSONContains evaluates doc @> candidate. A NULL operand is false.
 func (ctx *EvalContext) EvalJSONContains(jsonVal, val interface{}) (bool, error) {
-	// Simple JSON contains: check if val string is a substring of jsonVal string
-	// This is a simplified implementation; full JSONContains would parse JSON
-	jsonStr, ok := toString(jsonVal)
-	if !ok {
+	if jsonVal == nil || val == nil {
 		return false, nil
 	}
-	valStr, ok := toString(val)
-	if !ok {
-		return false, nil
+	target, err := parseJSONDocumentArg("@>", jsonVal)
+	if err != nil {
+		return false, err
 	}
-	return strings.Contains(jsonStr, valStr), nil
+	candidate, err := parseJSONDocumentArg("@>", val)
+	if err != nil {
+		return false, err
+	}
+	return jsonContains(target, candidate), nil
 }
 
 func (ctx *EvalContext) EvalMatch(expr *query.MatchExpr, row []interface{}) (interface{}, error) {
@@ -1193,6 +1281,20 @@ func evaluateFunctionCall(c *Catalog, row []interface{}, columns []ColumnDef, ex
 		return val, err
 	}
 
+	if val, handled, err := c.seededRandomFunction(funcName, evalArgs); handled {
+		return val, err
+	}
+
+	if val, handled, err := c.evaluateSequenceFunction(funcName, evalArgs); handled {
+		return val, err
+	}
+	if val, handled, err := c.evaluateSessionFunction(funcName, evalArgs); handled {
+		return val, err
+	}
+	if val, handled, err := c.evaluateColumnCryptoFunction(funcName, evalArgs); handled {
+		return val, err
+	}
+
 	// Try dispatch map for scalar functions that moved out of the switch
 	if handler, ok := scalarFunctionHandlers[funcName]; ok {
 		return handler(evalArgs)
@@ -1203,29 +1305,6 @@ func evaluateFunctionCall(c *Catalog, row []interface{}, columns []ColumnDef, ex
 		// Handled above with short-circuit evaluation
 		return nil, nil
 
-	case "DATE", "TIME", "DATETIME":
-		// Simple date/time functions - return current time for now
-		// Full implementation would require time parsing
-		if len(evalArgs) < 1 {
-			return nil, nil
-		}
-		return evalArgs[0], nil
-
-	case "NOW", "CURRENT_TIMESTAMP", "CURRENT_TIME", "CURRENT_DATE":
-		// Return current timestamp
-		now := time.Now()
-		return now.Format("2006-01-02 15:04:05"), nil
-
-	case "STRFTIME":
-		if len(evalArgs) < 2 {
-			return nil, fmt.Errorf("STRFTIME requires 2 arguments")
-		}
-		// Simple strftime - just return the input for now
-		if evalArgs[1] == nil {
-			return nil, nil
-		}
-		return ValueToStringKey(evalArgs[1]), nil
-
 	case "GROUP_CONCAT":
 		// GROUP_CONCAT is handled in aggregate path; scalar fallback just returns the value
 		if len(evalArgs) >= 1 && evalArgs[0] != nil {
@@ -1235,7 +1314,7 @@ func evaluateFunctionCall(c *Catalog, row []interface{}, columns []ColumnDef, ex
 
 	default:
 		// Check for JSON functions
-		return evaluateJSONFunction(funcName, evalArgs)
+		return evaluateJSONFunction(c.budget().jsonDocs(), funcName, evalArgs)
 	}
 }
 
@@ -1814,6 +1893,9 @@ func looksLikeNumber(s string) bool {
 // evaluateMatchExprLocked evaluates MATCH ... AGAINST for full-text search.
 // Caller must hold c.mu (read or write lock).
 func evaluateMatchExprLocked(c *Catalog, row []interface{}, columns []ColumnDef, expr *query.MatchExpr, args []interface{}) (interface{}, error) {
+	if expr.Operator {
+		return evaluateFTSMatchLocked(c, row, columns, expr, args)
+	}
 	// Get the pattern value
 	patternVal, err := evaluateExpression(c, row, columns, expr.Pattern, args)
 	if err != nil {
@@ -2037,8 +2119,6 @@ func evalFunctionCallValue(funcName string, evalArgs []interface{}) (interface{}
 	}
 
 	switch funcName {
-	case "REGEXP_LIKE":
-		return evalRegexpLikeValue(evalArgs)
 	case "COALESCE":
 		for _, a := range evalArgs {
 			if a != nil {
@@ -2297,6 +2377,10 @@ func evalFunctionCallValue(funcName string, evalArgs []interface{}) (interface{}
 		if val, handled, err := evaluateMathFunction(funcName, evalArgs); handled {
 			return val, err
 		}
+		// ...and the scalar table, so defaults like CURRENT_TIMESTAMP evaluate.
+		if handler, ok := scalarFunctionHandlers[funcName]; ok {
+			return handler(evalArgs)
+		}
 		return nil, fmt.Errorf("unsupported function in value expression: %s", funcName)
 	}
 }
@@ -2439,12 +2523,15 @@ func concatValues(a, b interface{}) string {
 	return ValueToStringKey(a) + ValueToStringKey(b)
 }
 
-func matchLikeSimple(s, pattern string, escapeChar ...byte) bool {
+// matchLikeSimple reports whether s matches the LIKE pattern. % matches any
+// run of characters and _ exactly one character (not byte); escapeChar, if
+// given, makes the character after it literal.
+func matchLikeSimple(s, pattern string, escapeChar ...rune) bool {
 	if pattern == "" {
 		return s == ""
 	}
 
-	var esc byte
+	var esc rune
 	if len(escapeChar) > 0 {
 		esc = escapeChar[0]
 	}
@@ -2456,15 +2543,18 @@ func matchLikeSimple(s, pattern string, escapeChar ...byte) bool {
 		char := pattern[pIdx]
 
 		// Handle escape character
-		if esc != 0 && char == esc && pIdx+1 < len(pattern) {
-			pIdx++ // skip escape char
-			// Next char is literal
-			if sIdx < len(s) && s[sIdx] == pattern[pIdx] {
-				sIdx++
-				pIdx++
-				continue
+		if esc != 0 {
+			if r, size := utf8.DecodeRuneInString(pattern[pIdx:]); r == esc && pIdx+size < len(pattern) {
+				pIdx += size // skip escape char
+				// Next char is literal
+				lit, litSize := utf8.DecodeRuneInString(pattern[pIdx:])
+				if got, gotSize := utf8.DecodeRuneInString(s[sIdx:]); got == lit && gotSize == litSize {
+					sIdx += gotSize
+					pIdx += litSize
+					continue
+				}
+				return false
 			}
-			return false
 		}
 
 		// Handle %
@@ -2484,20 +2574,22 @@ func matchLikeSimple(s, pattern string, escapeChar ...byte) bool {
 				if sIdx >= len(s) {
 					break
 				}
-				sIdx++
+				_, size := utf8.DecodeRuneInString(s[sIdx:])
+				sIdx += size
 			}
 			return false
 		}
 
 		// Handle _
 		if char == '_' {
-			sIdx++
+			_, size := utf8.DecodeRuneInString(s[sIdx:])
+			sIdx += size
 			pIdx++
 			continue
 		}
 
 		// Literal match
-		if sIdx < len(s) && s[sIdx] == char {
+		if s[sIdx] == char {
 			sIdx++
 			pIdx++
 			continue
@@ -2520,44 +2612,18 @@ func evaluateIn(c *Catalog, row []interface{}, columns []ColumnDef, expr *query.
 		return false, err
 	}
 
-	// SQL three-valued logic: if left is NULL, IN/NOT IN returns NULL (unknown)
-	if left == nil {
-		return nil, nil
-	}
-
 	// Handle subquery: IN (SELECT ...)
 	if expr.Subquery != nil {
-		subq := resolveOuterRefsInQuery(expr.Subquery, row, columns)
-		_, subqueryRows, err := c.selectLocked(subq, args)
+		set, err := c.inSubqueryResult(expr.Subquery, row, columns, args)
 		if err != nil {
 			return false, err
 		}
-		found := false
-		hasNull := false
-		for _, subRow := range subqueryRows {
-			if len(subRow) > 0 {
-				if subRow[0] == nil {
-					hasNull = true
-				} else if compareValues(left, subRow[0]) == 0 {
-					found = true
-					break
-				}
-			}
-		}
-		if found {
-			if expr.Not {
-				return false, nil
-			}
-			return true, nil
-		}
-		// SQL three-valued logic: NOT IN with NULLs in list and no match → NULL (unknown)
-		if hasNull {
-			return nil, nil
-		}
-		if expr.Not {
-			return true, nil
-		}
-		return false, nil
+		return set.contains(left, expr.Not), nil
+	}
+
+	// SQL three-valued logic: if left is NULL, IN/NOT IN returns NULL (unknown)
+	if left == nil {
+		return nil, nil
 	}
 
 	// Evaluate all values in the list
@@ -0,0 +1,145 @@
+package catalog
+
+import (
+	"crypto/hmac"
+	"crypto/md5"  // #nosec G501 -- MD5() is a checksum function, not used for security.
+	"crypto/sha1" // #nosec G505 -- SHA1() is a checksum function, not used for security.
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"strings"
+)
+
+// Hash and encoding functions. They work on the bytes of their argument: a
+// BLOB as is, text as its UTF-8 bytes, other values as their text form.
+// Digests are returned as lowercase hex text, as in MySQL and PostgreSQL.
+
+// valueBytes returns the bytes hash and encoding functions operate on.
+func valueBytes(v interface{}) []byte {
+	switch val := v.(type) {
+	case []byte:
+		return val
+	case string:
+		return []byte(val)
+	}
+	return []byte(ValueToStringKey(v))
+}
+
+// hashAlgorithms maps the algorithm names HMAC accepts to their hashes.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"MD5":    md5.New,
+	"SHA1":   sha1.New,
+	"SHA224": sha256.New224,
+	"SHA256": sha256.New,
+	"SHA384": sha512.New384,
+	"SHA512": sha512.New,
+}
+
+// digestFunc builds a handler returning the hex digest of its argument.
+func digestFunc(name string, newHash func() hash.Hash) functionHandler {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) < 1 {
+			return nil, fmt.Errorf("%s requires 1 argument", name)
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		h := newHash()
+		h.Write(valueBytes(args[0]))
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+}
+
+// evalSHA2 implements SHA2(value, bits) for bits 224, 256 (or 0), 384 and
+// 512. Other lengths yield NULL, as in MySQL.
+func evalSHA2(args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("SHA2 requires 2 arguments")
+	}
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	bits, _ := toFloat64(args[1])
+	var h hash.Hash
+	switch bits {
+	case 224:
+		h = sha256.New224()
+	case 0, 256:
+		h = sha256.New()
+	case 384:
+		h = sha512.New384()
+	case 512:
+		h = sha512.New()
+	default:
+		return nil, nil
+	}
+	h.Write(valueBytes(args[0]))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// evalHMAC implements HMAC(key, message [, algorithm]): the hex HMAC of
+// message, with SHA256 unless another algorithm is named.
+func evalHMAC(args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("HMAC requires at least 2 arguments")
+	}
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	algorithm := "SHA256"
+	if len(args) >= 3 && args[2] != nil {
+		algorithm = strings.ReplaceAll(strings.ToUpper(ValueToStringKey(args[2])), "-", "")
+	}
+	newHash, ok := hashAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("HMAC: unsupported algorithm %q", ValueToStringKey(args[2]))
+	}
+	mac := hmac.New(newHash, valueBytes(args[0]))
+	mac.Write(valueBytes(args[1]))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// evalCRC32 implements CRC32(value): the IEEE checksum as an integer.
+func evalCRC32(args []interface{}) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("CRC32 requires 1 argument")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	return int64(crc32.ChecksumIEEE(valueBytes(args[0]))), nil
+}
+
+// evalToBase64 implements TO_BASE64(value) with standard padded encoding.
+func evalToBase64(args []interface{}) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("TO_BASE64 requires 1 argument")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	return base64.StdEncoding.EncodeToString(valueBytes(args[0])), nil
+}
+
+// evalFromBase64 implements FROM_BASE64(text): the decoded bytes as a blob.
+// Malformed input yields NULL, as in MySQL.
+func evalFromBase64(args []interface{}) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("FROM_BASE64 requires 1 argument")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	s := strings.TrimSpace(ValueToStringKey(args[0]))
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		if b, err = base64.RawStdEncoding.DecodeString(s); err != nil {
+			return nil, nil
+		}
+	}
+	return b, nil
+}
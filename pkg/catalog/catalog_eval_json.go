@@ -101,7 +101,9 @@ func jsonSetValueArg(args []interface{}, idx int) string {
 	}
 }
 
-func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, error) {
+// evaluateJSONFunction evaluates a JSON function. docs, when not nil, holds
+// the documents the statement has already parsed.
+func evaluateJSONFunction(docs *jsonDocCache, funcName string, args []interface{}) (interface{}, error) {
 	switch funcName {
 	case "JSON_EXTRACT":
 		if len(args) < 2 {
@@ -140,24 +142,53 @@ func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, err
 			}
 		}
 
-		return JSONExtract(jsonData, path)
+		if len(args) == 2 {
+			return jsonExtract(docs, jsonData, path)
+		}
+		// With several paths the result is a JSON array of the selected
+		// values, as in SQLite.
+		results := make([]interface{}, 0, len(args)-1)
+		for i := 1; i < len(args); i++ {
+			p, _ := jsonArgString(args, i)
+			v, err := jsonExtract(docs, jsonData, p)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, v)
+		}
+		out, err := json.Marshal(results)
+		if err != nil {
+			return nil, fmt.Errorf("JSON_EXTRACT: %w", err)
+		}
+		return string(out), nil
 
 	case "JSON_SET":
-		if len(args) < 3 {
-			return nil, fmt.Errorf("JSON_SET requires 3 arguments")
+		if len(args) < 3 || len(args)%2 == 0 {
+			return nil, fmt.Errorf("JSON_SET requires a document and path/value pairs")
 		}
 		jsonData, _ := jsonArgString(args, 0)
-		path, _ := jsonArgString(args, 1)
-		value := jsonSetValueArg(args, 2)
-		return JSONSet(jsonData, path, value)
+		for i := 1; i+1 < len(args); i += 2 {
+			path, _ := jsonArgString(args, i)
+			var err error
+			if jsonData, err = JSONSet(jsonData, path, jsonSetValueArg(args, i+1)); err != nil {
+				return nil, err
+			}
+		}
+		return jsonData, nil
 
 	case "JSON_REMOVE":
 		if len(args) < 2 {
 			return nil, fmt.Errorf("JSON_REMOVE requires 2 arguments")
 		}
 		jsonData, _ := jsonArgString(args, 0)
-		path, _ := jsonArgString(args, 1)
-		return JSONRemove(jsonData, path)
+		for i := 1; i < len(args); i++ {
+			path, _ := jsonArgString(args, i)
+			var err error
+			if jsonData, err = JSONRemove(jsonData, path); err != nil {
+				return nil, err
+			}
+		}
+		return jsonData, nil
 
 	case "JSON_VALID":
 		if len(args) < 1 {
@@ -170,7 +201,7 @@ func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, err
 		if !ok {
 			return false, nil
 		}
-		return IsValidJSON(str), nil
+		return isValidJSON(docs, str), nil
 
 	case "JSON_ARRAY_LENGTH":
 		if len(args) < 1 {
@@ -183,7 +214,7 @@ func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, err
 		if !ok {
 			return 0, nil
 		}
-		length, err := JSONArrayLength(jsonData)
+		length, err := jsonArrayLength(docs, jsonData)
 		if err != nil {
 			return nil, err
 		}
@@ -204,7 +235,7 @@ func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, err
 		if len(args) > 1 {
 			path, _ = jsonArgString(args, 1)
 		}
-		return JSONType(jsonData, path)
+		return jsonType(docs, jsonData, path)
 
 	case "JSON_KEYS":
 		if len(args) < 1 {
@@ -217,7 +248,7 @@ func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, err
 		if !ok {
 			return nil, nil
 		}
-		return JSONKeys(jsonData)
+		return jsonKeys(docs, jsonData)
 
 	case "JSON_PRETTY":
 		if len(args) < 1 {
@@ -279,40 +310,6 @@ func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, err
 		}
 		return JSONUnquote(str)
 
-	case "REGEXP_MATCH":
-		if len(args) < 2 {
-			return nil, fmt.Errorf("REGEXP_MATCH requires 2 arguments")
-		}
-		str, _ := jsonArgString(args, 0)
-		pattern, _ := jsonArgString(args, 1)
-		if str == "" || pattern == "" {
-			return false, nil
-		}
-		return RegexMatch(str, pattern)
-
-	case "REGEXP_REPLACE":
-		if len(args) < 3 {
-			return nil, fmt.Errorf("REGEXP_REPLACE requires 3 arguments")
-		}
-		str, _ := jsonArgString(args, 0)
-		pattern, _ := jsonArgString(args, 1)
-		replacement, _ := jsonArgString(args, 2)
-		if str == "" || pattern == "" {
-			return str, nil
-		}
-		return RegexReplace(str, pattern, replacement)
-
-	case "REGEXP_EXTRACT":
-		if len(args) < 2 {
-			return nil, fmt.Errorf("REGEXP_EXTRACT requires 2 arguments")
-		}
-		str, _ := jsonArgString(args, 0)
-		pattern, _ := jsonArgString(args, 1)
-		if str == "" || pattern == "" {
-			return []string{}, nil
-		}
-		return RegexExtract(str, pattern)
-
 	case "JSON_OBJECT":
 		if len(args)%2 != 0 {
 			return nil, fmt.Errorf("JSON_OBJECT requires an even number of arguments")
@@ -347,7 +344,179 @@ func evaluateJSONFunction(funcName string, args []interface{}) (interface{}, err
 		}
 		return string(out), nil
 
+	case "JSON_CONTAINS":
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("JSON_CONTAINS requires 2 or 3 arguments")
+		}
+		if args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		target, err := parseJSONDocumentArg("JSON_CONTAINS", args[0])
+		if err != nil {
+			return nil, err
+		}
+		candidate, err := parseJSONDocumentArg("JSON_CONTAINS", args[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 3 {
+			path, _ := jsonArgString(args, 2)
+			jp, err := getCachedJSONPath(path)
+			if err != nil {
+				return nil, err
+			}
+			var found bool
+			if target, found = jsonPathLookup(target, jp.Segments); !found {
+				return nil, nil
+			}
+		}
+		return jsonContains(target, candidate), nil
+
+	case "JSON_CONTAINS_PATH":
+		if len(args) < 3 {
+			return nil, fmt.Errorf("JSON_CONTAINS_PATH requires a document, 'one' or 'all', and at least one path")
+		}
+		for _, arg := range args {
+			if arg == nil {
+				return nil, nil
+			}
+		}
+		doc, err := parseJSONDocumentArg("JSON_CONTAINS_PATH", args[0])
+		if err != nil {
+			return nil, err
+		}
+		mode, _ := jsonArgString(args, 1)
+		all := strings.EqualFold(mode, "all")
+		if !all && !strings.EqualFold(mode, "one") {
+			return nil, fmt.Errorf("JSON_CONTAINS_PATH mode must be 'one' or 'all', got %q", mode)
+		}
+		for i := 2; i < len(args); i++ {
+			path, _ := jsonArgString(args, i)
+			jp, err := getCachedJSONPath(path)
+			if err != nil {
+				return nil, err
+			}
+			_, found := jsonPathLookup(doc, jp.Segments)
+			if found != all {
+				return found, nil
+			}
+		}
+		return all, nil
+
 	default:
 		return nil, fmt.Errorf("unknown function: %s", funcName)
 	}
 }
+
+// decodeJSONDocument decodes JSON text (a string or []byte) or passes through
+// an already decoded value. It reports false for text that is not valid JSON.
+func decodeJSONDocument(v interface{}) (interface{}, bool) {
+	if s, ok := toString(v); ok {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(s), &doc); err != nil {
+			return nil, false
+		}
+		return doc, true
+	}
+	doc, err := normalizeJSONDocument(v)
+	return doc, err == nil
+}
+
+func parseJSONDocumentArg(funcName string, v interface{}) (interface{}, error) {
+	doc, ok := decodeJSONDocument(v)
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid JSON document", funcName)
+	}
+	return doc, nil
+}
+
+// jsonContains reports whether target contains candidate, as in MySQL's
+// JSON_CONTAINS and PostgreSQL's @>: an object contains an object whose keys
+// all map to contained values, an array contains an array whose elements are
+// each contained in some element, an array contains a non-array contained in
+// one of its elements, and scalars contain equal scalars (numbers compare by
+// value).
+func jsonContains(target, candidate interface{}) bool {
+	switch t := target.(type) {
+	case map[string]interface{}:
+		c, ok := candidate.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, cv := range c {
+			tv, exists := t[k]
+			if !exists || !jsonContains(tv, cv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		if c, ok := candidate.([]interface{}); ok {
+			for _, cv := range c {
+				if !jsonContains(t, cv) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, tv := range t {
+			if jsonContains(tv, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+	switch candidate.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	if tf, ok := toFloat64Safe(target); ok {
+		cf, ok := toFloat64Safe(candidate)
+		return ok && tf == cf
+	}
+	return target == candidate
+}
+
+// jsonPathLookup returns the value at a parsed path and whether it exists.
+// A * segment matches any element of an array or member of an object.
+func jsonPathLookup(doc interface{}, segments []string) (interface{}, bool) {
+	for i, seg := range segments {
+		switch {
+		case seg == "*":
+			var children []interface{}
+			switch v := doc.(type) {
+			case []interface{}:
+				children = v
+			case map[string]interface{}:
+				for _, child := range v {
+					children = append(children, child)
+				}
+			}
+			for _, child := range children {
+				if val, ok := jsonPathLookup(child, segments[i+1:]); ok {
+					return val, true
+				}
+			}
+			return nil, false
+		case strings.HasPrefix(seg, "[") && strings.HasSuffix(seg, "]"):
+			arr, ok := doc.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			idx, err := jsonArrayIndex(seg, len(arr))
+			if err != nil || idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			doc = arr[idx]
+		default:
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = obj[seg]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return doc, true
+}
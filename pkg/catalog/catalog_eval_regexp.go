@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Regular expression functions use Go's RE2 syntax and the shared compiled
+// pattern cache. `x REGEXP p` and `x RLIKE p` parse to REGEXP_LIKE(x, p).
+// Non-text arguments are matched as their text form; NULL in gives NULL out.
+
+// compileRegexpArg compiles pattern with the optional match flags MySQL and
+// PostgreSQL accept: i (case-insensitive), c (case-sensitive), m (multi-line)
+// and n or s (dot matches newline). Later flags override earlier ones.
+func compileRegexpArg(funcName, pattern string, flags interface{}) (*regexp.Regexp, error) {
+	if flags != nil {
+		var prefix strings.Builder
+		for _, f := range ValueToStringKey(flags) {
+			switch f {
+			case 'i':
+				prefix.WriteString("(?i)")
+			case 'c':
+				prefix.WriteString("(?-i)")
+			case 'm':
+				prefix.WriteString("(?m)")
+			case 'n', 's':
+				prefix.WriteString("(?s)")
+			default:
+				return nil, fmt.Errorf("%s: unknown match flag %q", funcName, f)
+			}
+		}
+		pattern = prefix.String() + pattern
+	}
+	re, err := getCachedRegexp(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid regex pattern: %w", funcName, err)
+	}
+	return re, nil
+}
+
+// evalRegexpLikeValue implements REGEXP_LIKE(str, pattern [, flags]) and
+// its alias REGEXP_MATCH.
+func evalRegexpLikeValue(args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("REGEXP_LIKE requires 2 arguments")
+	}
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	var flags interface{}
+	if len(args) > 2 {
+		flags = args[2]
+	}
+	re, err := compileRegexpArg("REGEXP_LIKE", ValueToStringKey(args[1]), flags)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(ValueToStringKey(args[0])), nil
+}
+
+// evalRegexpReplace implements REGEXP_REPLACE(str, pattern, replacement
+// [, flags]). Every match is replaced; $1 or ${name} in the replacement
+// refer to capture groups.
+func evalRegexpReplace(args []interface{}) (interface{}, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("REGEXP_REPLACE requires 3 arguments")
+	}
+	if args[0] == nil || args[1] == nil || args[2] == nil {
+		return nil, nil
+	}
+	var flags interface{}
+	if len(args) > 3 {
+		flags = args[3]
+	}
+	re, err := compileRegexpArg("REGEXP_REPLACE", ValueToStringKey(args[1]), flags)
+	if err != nil {
+		return nil, err
+	}
+	result := re.ReplaceAllString(ValueToStringKey(args[0]), ValueToStringKey(args[2]))
+	if len(result) > maxStringResultLen {
+		return nil, fmt.Errorf("REGEXP_REPLACE result exceeds maximum length of %d bytes", maxStringResultLen)
+	}
+	return result, nil
+}
+
+// evalRegexpExtract implements REGEXP_EXTRACT(str, pattern [, group]): the
+// first match, or the given capture group of it, and NULL when nothing
+// matches.
+func evalRegexpExtract(args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("REGEXP_EXTRACT requires 2 arguments")
+	}
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	re, err := compileRegexpArg("REGEXP_EXTRACT", ValueToStringKey(args[1]), nil)
+	if err != nil {
+		return nil, err
+	}
+	group := 0
+	if len(args) > 2 && args[2] != nil {
+		g, ok := toFloat64(args[2])
+		if !ok || g < 0 || int(g) > re.NumSubexp() {
+			return nil, fmt.Errorf("REGEXP_EXTRACT: pattern has no group %s", ValueToStringKey(args[2]))
+		}
+		group = int(g)
+	}
+	m := re.FindStringSubmatchIndex(ValueToStringKey(args[0]))
+	if m == nil || m[2*group] < 0 {
+		return nil, nil
+	}
+	return ValueToStringKey(args[0])[m[2*group]:m[2*group+1]], nil
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
-	"regexp"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -46,12 +45,16 @@ func evaluateStringFunction(funcName string, evalArgs []interface{}) (funcResult
 		return evalStringConcatWS(evalArgs), true
 	case "REPLACE":
 		return evalStringReplace(evalArgs), true
-	case "INSTR":
+	case "INSTR", "STRPOS":
 		return evalStringInstr(evalArgs), true
 	case "LOCATE", "POSITION":
 		return evalStringLocate(evalArgs), true
 	case "SUBSTRING_INDEX":
 		return evalStringSubstringIndex(evalArgs), true
+	case "SPLIT_PART":
+		return evalStringSplitPart(evalArgs), true
+	case "TRANSLATE":
+		return evalStringTranslate(evalArgs), true
 	case "ASCII":
 		return evalStringAscii(evalArgs), true
 	case "PRINTF":
@@ -70,6 +73,8 @@ func evaluateStringFunction(funcName string, evalArgs []interface{}) (funcResult
 		return evalStringRPad(evalArgs), true
 	case "HEX":
 		return evalStringHex(evalArgs), true
+	case "UNHEX":
+		return evalStringUnhex(evalArgs), true
 	case "UNICODE":
 		return evalStringUnicode(evalArgs), true
 	case "CHAR":
@@ -249,6 +254,45 @@ func evalStringReplace(evalArgs []interface{}) funcResult {
 	return funcResult{result, nil}
 }
 
+// evalStringTranslate implements TRANSLATE(str, from, to): each character of
+// str found in from is replaced by the character at the same position in to,
+// or dropped when to is shorter.
+func evalStringTranslate(evalArgs []interface{}) funcResult {
+	if len(evalArgs) < 3 {
+		return funcResult{nil, fmt.Errorf("TRANSLATE requires 3 arguments")}
+	}
+	if evalArgs[0] == nil || evalArgs[1] == nil || evalArgs[2] == nil {
+		return funcResult{nil, nil}
+	}
+	str, _ := argString(evalArgs, 0)
+	from, _ := argString(evalArgs, 1)
+	to, _ := argString(evalArgs, 2)
+	toRunes := []rune(to)
+	mapping := make(map[rune]rune, len(from))
+	i := 0
+	for _, r := range from {
+		if _, seen := mapping[r]; !seen {
+			mapping[r] = -1
+			if i < len(toRunes) {
+				mapping[r] = toRunes[i]
+			}
+		}
+		i++
+	}
+	var b strings.Builder
+	b.Grow(len(str))
+	for _, r := range str {
+		if m, ok := mapping[r]; ok {
+			if m >= 0 {
+				b.WriteRune(m)
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return funcResult{b.String(), nil}
+}
+
 func evalStringInstr(evalArgs []interface{}) funcResult {
 	if len(evalArgs) < 2 {
 		return funcResult{nil, fmt.Errorf("INSTR requires 2 arguments")}
@@ -326,6 +370,36 @@ func evalStringSubstringIndex(evalArgs []interface{}) funcResult {
 	return funcResult{strings.Join(parts[len(parts)-count:], delim), nil}
 }
 
+// evalStringSplitPart implements SPLIT_PART(str, delim, n): the n-th field of
+// str split on delim, counting from the end when n is negative, and "" past
+// the last field (PostgreSQL).
+func evalStringSplitPart(evalArgs []interface{}) funcResult {
+	if len(evalArgs) < 3 {
+		return funcResult{nil, fmt.Errorf("SPLIT_PART requires 3 arguments")}
+	}
+	if evalArgs[0] == nil || evalArgs[1] == nil || evalArgs[2] == nil {
+		return funcResult{nil, nil}
+	}
+	str, _ := argString(evalArgs, 0)
+	delim, _ := argString(evalArgs, 1)
+	nf, _ := toFloat64(evalArgs[2])
+	n := int(nf)
+	if n == 0 {
+		return funcResult{nil, fmt.Errorf("SPLIT_PART field position must not be zero")}
+	}
+	parts := []string{str}
+	if delim != "" {
+		parts = strings.Split(str, delim)
+	}
+	if n < 0 {
+		n += len(parts) + 1
+	}
+	if n < 1 || n > len(parts) {
+		return funcResult{"", nil}
+	}
+	return funcResult{parts[n-1], nil}
+}
+
 // evalStringAscii implements ASCII(str): the numeric code of the first byte, 0
 // for an empty string.
 func evalStringAscii(evalArgs []interface{}) funcResult {
@@ -465,14 +539,17 @@ func evalStringRight(evalArgs []interface{}) funcResult {
 }
 
 func evalStringLPad(evalArgs []interface{}) funcResult {
-	if len(evalArgs) < 3 {
-		return funcResult{nil, fmt.Errorf("LPAD requires 3 arguments")}
+	if len(evalArgs) < 2 {
+		return funcResult{nil, fmt.Errorf("LPAD requires 2 or 3 arguments")}
 	}
 	if evalArgs[0] == nil {
 		return funcResult{nil, nil}
 	}
 	str := ValueToStringKey(evalArgs[0])
-	pad := ValueToStringKey(evalArgs[2])
+	pad := " "
+	if len(evalArgs) >= 3 {
+		pad = ValueToStringKey(evalArgs[2])
+	}
 	ti, err := boundedStringSizeArg(evalArgs[1], "LPAD", maxStringResultLen)
 	if err != nil {
 		return funcResult{nil, err}
@@ -496,14 +573,17 @@ func evalStringLPad(evalArgs []interface{}) funcResult {
 }
 
 func evalStringRPad(evalArgs []interface{}) funcResult {
-	if len(evalArgs) < 3 {
-		return funcResult{nil, fmt.Errorf("RPAD requires 3 arguments")}
+	if len(evalArgs) < 2 {
+		return funcResult{nil, fmt.Errorf("RPAD requires 2 or 3 arguments")}
 	}
 	if evalArgs[0] == nil {
 		return funcResult{nil, nil}
 	}
 	str := ValueToStringKey(evalArgs[0])
-	pad := ValueToStringKey(evalArgs[2])
+	pad := " "
+	if len(evalArgs) >= 3 {
+		pad = ValueToStringKey(evalArgs[2])
+	}
 	ti, err := boundedStringSizeArg(evalArgs[1], "RPAD", maxStringResultLen)
 	if err != nil {
 		return funcResult{nil, err}
@@ -544,6 +624,22 @@ func evalStringHex(evalArgs []interface{}) funcResult {
 	return funcResult{strings.ToUpper(hex.EncodeToString([]byte(str))), nil}
 }
 
+// evalStringUnhex decodes a hex string into a blob. Malformed input yields
+// NULL, as in MySQL and SQLite.
+func evalStringUnhex(evalArgs []interface{}) funcResult {
+	if len(evalArgs) < 1 {
+		return funcResult{nil, fmt.Errorf("UNHEX requires 1 argument")}
+	}
+	if evalArgs[0] == nil {
+		return funcResult{nil, nil}
+	}
+	b, err := hex.DecodeString(ValueToStringKey(evalArgs[0]))
+	if err != nil {
+		return funcResult{nil, nil}
+	}
+	return funcResult{b, nil}
+}
+
 func evalStringUnicode(evalArgs []interface{}) funcResult {
 	if len(evalArgs) < 1 {
 		return funcResult{nil, fmt.Errorf("UNICODE requires 1 argument")}
@@ -593,10 +689,86 @@ func evalStringGlob(evalArgs []interface{}) funcResult {
 	if evalArgs[0] == nil || evalArgs[1] == nil {
 		return funcResult{nil, nil}
 	}
-	pattern := ValueToStringKey(evalArgs[0])
-	str := ValueToStringKey(evalArgs[1])
-	regexPattern := "^" + strings.ReplaceAll(strings.ReplaceAll(
-		regexp.QuoteMeta(pattern), `\*`, ".*"), `\?`, ".") + "$"
-	matched, _ := regexp.MatchString(regexPattern, str)
-	return funcResult{matched, nil}
+	return funcResult{matchGlob(ValueToStringKey(evalArgs[1]), ValueToStringKey(evalArgs[0])), nil}
+}
+
+// matchGlob reports whether s matches the GLOB pattern, with SQLite's rules:
+// matching is case-sensitive, * matches any run of characters, ? exactly one
+// character, and [...] one character from a set. A set may hold ranges (a-z)
+// and starts with ^ to negate; a ] right after [ or [^ is literal.
+func matchGlob(s, pattern string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); {
+				if matchGlob(s[i:], pattern) {
+					return true
+				}
+				if i == len(s) {
+					break
+				}
+				_, size := utf8.DecodeRuneInString(s[i:])
+				i += size
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+			_, size := utf8.DecodeRuneInString(s)
+			s, pattern = s[size:], pattern[1:]
+		case '[':
+			if s == "" {
+				return false
+			}
+			r, size := utf8.DecodeRuneInString(s)
+			matched, rest, ok := matchGlobSet(r, pattern[1:])
+			if !ok {
+				// An unterminated set matches nothing, as in SQLite.
+				return false
+			}
+			if !matched {
+				return false
+			}
+			s, pattern = s[size:], rest
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+			s, pattern = s[1:], pattern[1:]
+		}
+	}
+	return s == ""
+}
+
+// matchGlobSet matches r against the set that starts pattern (just past the
+// '['), returning the pattern after the closing ']'. ok is false when the
+// set is never closed.
+func matchGlobSet(r rune, pattern string) (matched bool, rest string, ok bool) {
+	negate := strings.HasPrefix(pattern, "^")
+	if negate {
+		pattern = pattern[1:]
+	}
+	for i, first := 0, true; i < len(pattern); first = false {
+		lo, size := utf8.DecodeRuneInString(pattern[i:])
+		if lo == ']' && !first {
+			return matched != negate, pattern[i+1:], true
+		}
+		i += size
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size = utf8.DecodeRuneInString(pattern[i+1:])
+			i += 1 + size
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+	return false, "", false
 }
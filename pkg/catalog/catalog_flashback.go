@@ -0,0 +1,73 @@
+package catalog
+
+import (
+	"sort"
+	"time"
+)
+
+// FlashbackFunc returns, for each row of table written by a transaction
+// committed after at, the row's value at that time: nil for a row that did
+// not exist then. Rows it leaves out read as they are now. It fails when
+// the changes since at are no longer retained.
+type FlashbackFunc func(table string, at time.Time) (map[string][]interface{}, error)
+
+// SetFlashbackSource makes AS OF queries on tables that are not
+// system-versioned read the rows fn rebuilds for the query's time; nil
+// restores plain row version visibility.
+func (c *Catalog) SetFlashbackSource(fn FlashbackFunc) {
+	if fn == nil {
+		c.flashback.Store(nil)
+		return
+	}
+	c.flashback.Store(&fn)
+}
+
+// flashbackFunc returns the source set with SetFlashbackSource, or nil.
+func (c *Catalog) flashbackFunc() FlashbackFunc {
+	if fn := c.flashback.Load(); fn != nil {
+		return *fn
+	}
+	return nil
+}
+
+// flashbackRows returns table's rows as of queryTime: its live rows, with
+// those written since replaced by their values from flashback. Rows keep
+// the table's current columns.
+func (cat *Catalog) flashbackRows(table *TableDef, queryTime time.Time, flashback FlashbackFunc) (*cteResultSet, error) {
+	past, err := flashback(table.Name, queryTime)
+	if err != nil {
+		return nil, err
+	}
+	n := len(table.Columns)
+	res := &cteResultSet{columns: make([]string, n)}
+	for i, col := range table.Columns {
+		res.columns[i] = col.Name
+	}
+	type keyedRow struct {
+		key string
+		row []interface{}
+	}
+	rows := make([]keyedRow, 0, len(past))
+	err = cat.scanVersionRows(table.Name, n, func(key string, row []interface{}, version RowVersion) {
+		if _, changed := past[key]; !changed && version.DeletedAt == 0 {
+			rows = append(rows, keyedRow{key, row})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for key, row := range past {
+		if row == nil {
+			continue
+		}
+		fitted := make([]interface{}, n)
+		copy(fitted, row)
+		rows = append(rows, keyedRow{key, fitted})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].key < rows[j].key })
+	res.rows = make([][]interface{}, len(rows))
+	for i, r := range rows {
+		res.rows[i] = r.row
+	}
+	return res, nil
+}
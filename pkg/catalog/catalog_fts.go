@@ -2,7 +2,12 @@ package catalog
 
 import (
 	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
 	"sort"
+
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 )
 
 func (c *Catalog) CreateFTSIndex(name, tableName string, columns []string) error {
@@ -22,7 +27,7 @@ func (c *Catalog) CreateFTSIndex(name, tableName string, columns []string) error
 	// Verify columns exist
 	for _, col := range columns {
 		if table.GetColumnIndex(col) == -1 {
-			return fmt.Errorf("column %s not found in table %s", col, tableName)
+			return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column %s not found in table %s", col, tableName)
 		}
 	}
 
@@ -33,38 +38,8 @@ func (c *Catalog) CreateFTSIndex(name, tableName string, columns []string) error
 		Index:     make(map[string][]int64),
 	}
 
-	// Build the index from existing data
-	tree, exists := c.tableTrees[tableName]
-	if exists {
-		pendingWrites := c.pendingWritesForTable(tableName)
-		iter, err := tree.Scan(nil, nil)
-		if err != nil {
-			return fmt.Errorf("failed to scan table %s for FTS index %s: %w", tableName, name, err)
-		}
-		defer iter.Close()
-		for iter.HasNext() {
-			key, value, iterErr := iter.Next()
-			if iterErr != nil {
-				return fmt.Errorf("failed to read row for FTS index %s: %w", name, iterErr)
-			}
-			if key == nil || len(value) == 0 {
-				break
-			}
-			if _, shadowed := pendingWrites[string(key)]; shadowed {
-				continue
-			}
-			if err := c.addRowToFTSIndexLocked(ftsIndex, table, key, value); err != nil {
-				return fmt.Errorf("failed to decode row for FTS index %s: %w", name, err)
-			}
-		}
-		for key, pw := range pendingWrites {
-			if pw.Value == nil {
-				continue
-			}
-			if err := c.addRowToFTSIndexLocked(ftsIndex, table, []byte(key), pw.Value); err != nil {
-				return fmt.Errorf("failed to decode pending row for FTS index %s: %w", name, err)
-			}
-		}
+	if err := c.buildFTSIndexLocked(ftsIndex, table); err != nil {
+		return err
 	}
 
 	c.ftsIndexes[name] = ftsIndex
@@ -95,27 +70,114 @@ func (c *Catalog) addRowToFTSIndexLocked(ftsIndex *FTSIndexDef, table *TableDef,
 	return nil
 }
 
-func (c *Catalog) indexRowForFTS(ftsIndex *FTSIndexDef, row map[string]interface{}, key []byte) {
-	// Extract row ID from key - use a simple hash of the key
-	rowID := int64(0)
-	for _, b := range key {
-		rowID = rowID*31 + int64(b)
+// buildFTSIndexLocked fills ftsIndex from the table's rows, including the
+// current transaction's pending writes.
+func (c *Catalog) buildFTSIndexLocked(ftsIndex *FTSIndexDef, table *TableDef) error {
+	ftsIndex.Index = make(map[string][]int64)
+	ftsIndex.Docs = make(map[int64]map[string]int)
+	ftsIndex.TotalTerms = 0
+	tree, exists := c.tableTrees[ftsIndex.TableName]
+	if !exists {
+		return nil
+	}
+	pendingWrites := c.pendingWritesForTable(ftsIndex.TableName)
+	iter, err := tree.Scan(nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan table %s for FTS index %s: %w", ftsIndex.TableName, ftsIndex.Name, err)
 	}
-	if rowID < 0 {
-		rowID = -rowID
+	defer iter.Close()
+	for iter.HasNext() {
+		key, value, iterErr := iter.Next()
+		if iterErr != nil {
+			return fmt.Errorf("failed to read row for FTS index %s: %w", ftsIndex.Name, iterErr)
+		}
+		if key == nil || len(value) == 0 {
+			break
+		}
+		if _, shadowed := pendingWrites[string(key)]; shadowed {
+			continue
+		}
+		if err := c.addRowToFTSIndexLocked(ftsIndex, table, key, value); err != nil {
+			return fmt.Errorf("failed to decode row for FTS index %s: %w", ftsIndex.Name, err)
+		}
+	}
+	for key, pw := range pendingWrites {
+		if pw.Value == nil {
+			continue
+		}
+		if err := c.addRowToFTSIndexLocked(ftsIndex, table, []byte(key), pw.Value); err != nil {
+			return fmt.Errorf("failed to decode pending row for FTS index %s: %w", ftsIndex.Name, err)
+		}
 	}
+	return nil
+}
+
+// indexRowForFTS adds (or replaces) the row stored under key in ftsIndex.
+func (c *Catalog) indexRowForFTS(ftsIndex *FTSIndexDef, row map[string]interface{}, key []byte) {
+	rowID := ftsRowID(key)
+	removeFTSDoc(ftsIndex, rowID)
 
-	// Index each column
+	terms := make(map[string]int)
+	n := 0
 	for _, col := range ftsIndex.Columns {
 		if val, exists := row[col]; exists && val != nil {
-			text := ValueToStringKey(val)
-			words := tokenize(text)
-			for _, word := range words {
-				word = toLowerFast(word)
-				ftsIndex.Index[word] = append(ftsIndex.Index[word], rowID)
+			for _, word := range ftsTokens(ValueToStringKey(val)) {
+				terms[word]++
+				n++
 			}
 		}
 	}
+	if ftsIndex.Index == nil {
+		ftsIndex.Index = make(map[string][]int64)
+	}
+	if ftsIndex.Docs == nil {
+		ftsIndex.Docs = make(map[int64]map[string]int)
+	}
+	for word := range terms {
+		ftsIndex.Index[word] = insertSortedID(ftsIndex.Index[word], rowID)
+	}
+	ftsIndex.Docs[rowID] = terms
+	ftsIndex.TotalTerms += int64(n)
+}
+
+// removeFTSDoc drops a row's postings and statistics from ftsIndex.
+func removeFTSDoc(ftsIndex *FTSIndexDef, rowID int64) {
+	terms, ok := ftsIndex.Docs[rowID]
+	if !ok {
+		return
+	}
+	for word, n := range terms {
+		ftsIndex.TotalTerms -= int64(n)
+		ids := ftsIndex.Index[word]
+		i := sort.Search(len(ids), func(i int) bool { return ids[i] >= rowID })
+		if i < len(ids) && ids[i] == rowID {
+			ids = append(ids[:i], ids[i+1:]...)
+		}
+		if len(ids) == 0 {
+			delete(ftsIndex.Index, word)
+		} else {
+			ftsIndex.Index[word] = ids
+		}
+	}
+	delete(ftsIndex.Docs, rowID)
+}
+
+func insertSortedID(ids []int64, id int64) []int64 {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	if i < len(ids) && ids[i] == id {
+		return ids
+	}
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+// ftsRowID maps a row's storage key to the ID used in the inverted index.
+func ftsRowID(key []byte) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write(key)
+	return int64(h.Sum64() &^ (1 << 63)) // #nosec G115 -- sign bit cleared
 }
 
 func (c *Catalog) DropFTSIndex(name string) error {
@@ -134,6 +196,9 @@ func (c *Catalog) DropFTSIndex(name string) error {
 			ftsIndexDef: cloneFTSIndexDef(ftsIndex),
 		})
 	}
+	if err := c.deleteCatalogDef("fts:" + name); err != nil {
+		return fmt.Errorf("failed to delete FTS index metadata %s: %w", name, err)
+	}
 	delete(c.ftsIndexes, name)
 	return nil
 }
@@ -146,7 +211,10 @@ func (c *Catalog) SearchFTS(indexName string, query string) ([]int64, error) {
 		return nil, fmt.Errorf("FTS index %s not found", indexName)
 	}
 
-	words := tokenize(query)
+	c.ftsMu.RLock()
+	defer c.ftsMu.RUnlock()
+
+	words := ftsTokens(query)
 	if len(words) == 0 {
 		return []int64{}, nil
 	}
@@ -156,7 +224,6 @@ func (c *Catalog) SearchFTS(indexName string, query string) ([]int64, error) {
 	first := true
 
 	for _, word := range words {
-		word = toLowerFast(word)
 		rows, exists := ftsIndex.Index[word]
 		if !exists {
 			return []int64{}, nil // Word not found, no matches
@@ -223,6 +290,13 @@ func cloneFTSIndexDef(idx *FTSIndexDef) *FTSIndexDef {
 			cloned.Index[word] = append([]int64(nil), rows...)
 		}
 	}
+	if idx.Docs != nil {
+		cloned.Docs = make(map[int64]map[string]int, len(idx.Docs))
+		for id, terms := range idx.Docs {
+			cloned.Docs[id] = maps.Clone(terms)
+		}
+	}
+	cloned.TotalTerms = idx.TotalTerms
 	return cloned
 }
 
@@ -236,3 +310,108 @@ func (c *Catalog) ListFTSIndexes() []string {
 	sort.Strings(names)
 	return names
 }
+
+func (c *Catalog) hasFTSIndexForTableLocked(tableName string) bool {
+	for _, ftsIndex := range c.ftsIndexes {
+		if ftsIndex.TableName == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFTSIndexesForWrite keeps the table's full-text indexes in step with a
+// row write: the row under oldKey (if any) is removed and row, when non-nil,
+// is indexed under newKey.
+func (c *Catalog) updateFTSIndexesForWrite(table *TableDef, oldKey, newKey []byte, row []interface{}) {
+	if len(c.ftsIndexes) == 0 {
+		return
+	}
+	c.ftsMu.Lock()
+	defer c.ftsMu.Unlock()
+	for _, ftsIndex := range c.ftsIndexes {
+		if ftsIndex.TableName != table.Name {
+			continue
+		}
+		if oldKey != nil {
+			removeFTSDoc(ftsIndex, ftsRowID(oldKey))
+		}
+		if row == nil {
+			continue
+		}
+		rowMap := make(map[string]interface{}, len(ftsIndex.Columns))
+		for _, col := range ftsIndex.Columns {
+			if i := table.GetColumnIndex(col); i >= 0 && i < len(row) {
+				rowMap[col] = row[i]
+			}
+		}
+		c.indexRowForFTS(ftsIndex, rowMap, newKey)
+	}
+}
+
+// dropFTSIndexesForTableLocked drops the full-text indexes of a dropped table.
+func (c *Catalog) dropFTSIndexesForTableLocked(tableName string) error {
+	for name, ftsIndex := range c.ftsIndexes {
+		if ftsIndex.TableName != tableName {
+			continue
+		}
+		if c.isCurrentTxnActive() {
+			c.appendUndoEntry(undoEntry{
+				action:      undoDropFTSIndex,
+				indexName:   name,
+				ftsIndexDef: cloneFTSIndexDef(ftsIndex),
+			})
+		}
+		if err := c.deleteCatalogDef("fts:" + name); err != nil {
+			return fmt.Errorf("failed to delete FTS index metadata %s for dropped table %s: %w", name, tableName, err)
+		}
+		delete(c.ftsIndexes, name)
+	}
+	return nil
+}
+
+// ftsTablesInUndoLog returns the tables with full-text indexes that undo
+// entries from undoLog[from:] write to.
+func (c *Catalog) ftsTablesInUndoLog(undoLog []undoEntry, from int) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.ftsIndexes) == 0 {
+		return nil
+	}
+	var tables []string
+	seen := make(map[string]bool)
+	for i := max(from, 0); i < len(undoLog); i++ {
+		name := undoLog[i].tableName
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if c.hasFTSIndexForTableLocked(name) {
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
+
+// rebuildFTSIndexes rebuilds the full-text indexes of tables from their
+// current rows. Index maintenance is not undo-logged, so a rollback rebuilds
+// the indexes of the tables it restored instead.
+func (c *Catalog) rebuildFTSIndexes(tables []string) {
+	if len(tables) == 0 {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.ftsMu.Lock()
+	defer c.ftsMu.Unlock()
+	for _, ftsIndex := range c.ftsIndexes {
+		if !slices.Contains(tables, ftsIndex.TableName) {
+			continue
+		}
+		if table, err := c.getTableLocked(ftsIndex.TableName); err == nil {
+			// A failed rebuild leaves an empty index: MATCH still evaluates
+			// rows directly and only BM25's corpus statistics suffer.
+			_ = c.buildFTSIndexLocked(ftsIndex, table)
+		}
+	}
+}
@@ -0,0 +1,677 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+)
+
+// GIN terms. A document contributes a key term for every object key path
+// ("k" + path) and a value term for every scalar ("v" + path + sep + value).
+// Arrays are transparent: their elements are indexed under the array's own
+// path. If a @> b then every term of b is a term of a, so intersecting the
+// postings of b's terms yields a superset of the matching rows; the WHERE
+// clause is re-checked on each candidate.
+const (
+	ginKeySep   = "\x1f" // precedes each key of a path
+	ginValueSep = "\x1e" // separates a path from a scalar value
+)
+
+// CreateGINIndex creates an inverted index over a JSON column, serving
+// col @> value, JSON_CONTAINS(col, value) and JSON_CONTAINS_PATH(col, ...)
+// lookups.
+func (c *Catalog) CreateGINIndex(stmt *query.CreateIndexStmt) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.invalidateSchemaCache()
+	if c.indexNameTakenLocked(stmt.Index) {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return ErrIndexExists
+	}
+
+	table, err := c.getTableLocked(stmt.Table)
+	if err != nil {
+		return err
+	}
+	if stmt.Unique {
+		return fmt.Errorf("GIN index %s cannot be UNIQUE", stmt.Index)
+	}
+	if len(stmt.Columns) != 1 || stmt.JSONPath != "" {
+		return fmt.Errorf("GIN index %s must cover exactly one JSON column", stmt.Index)
+	}
+	colIdx := table.GetColumnIndex(stmt.Columns[0])
+	if colIdx < 0 {
+		return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found in table '%s'", stmt.Columns[0], stmt.Table)
+	}
+	if col := table.Columns[colIdx]; !strings.EqualFold(col.Type, "JSON") {
+		return fmt.Errorf("column '%s' is not JSON type", col.Name)
+	}
+
+	gin := &GINIndexDef{
+		Name:      stmt.Index,
+		TableName: table.Name,
+		Column:    table.Columns[colIdx].Name,
+	}
+	if err := c.buildGINIndexLocked(gin, table); err != nil {
+		return err
+	}
+	if !table.Temporary {
+		if err := c.storeGINIndexDef(gin); err != nil {
+			return err
+		}
+	}
+
+	c.ginIndexes[gin.Name] = gin
+	c.ginCount.Store(int32(len(c.ginIndexes))) // #nosec G115 -- index count
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{
+			action:    undoCreateGINIndex,
+			indexName: gin.Name,
+		})
+	}
+	return nil
+}
+
+// indexNameTakenLocked reports whether a B+Tree or GIN index is called name.
+func (c *Catalog) storeGINIndexDef(gin *GINIndexDef) error {
+	data, err := json.Marshal(gin)
+	if err != nil {
+		return err
+	}
+	if c.tree != nil {
+		return c.tree.Put([]byte("gin:"+gin.Name), data)
+	}
+	return nil
+}
+
+// dropGINIndexLocked drops the GIN index name.
+func (c *Catalog) dropGINIndexLocked(name string) error {
+	gin, exists := c.ginIndexes[name]
+	if !exists {
+		return ErrIndexNotFound
+	}
+	if err := c.deleteCatalogDef("gin:" + name); err != nil {
+		return fmt.Errorf("failed to delete index metadata %s: %w", name, err)
+	}
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{
+			action:      undoDropGINIndex,
+			indexName:   name,
+			ginIndexDef: gin,
+		})
+	}
+	delete(c.ginIndexes, name)
+	c.ginCount.Store(int32(len(c.ginIndexes))) // #nosec G115 -- index count
+	return nil
+}
+
+// dropGINIndexesForTableLocked drops the GIN indexes of a dropped table.
+func (c *Catalog) dropGINIndexesForTableLocked(tableName string) error {
+	for name, gin := range c.ginIndexes {
+		if gin.TableName == tableName {
+			if err := c.dropGINIndexLocked(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetGINIndex returns the definition of the GIN index name, without its
+// postings.
+func (c *Catalog) GetGINIndex(name string) (*GINIndexDef, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	gin, exists := c.ginIndexes[name]
+	if !exists {
+		return nil, ErrIndexNotFound
+	}
+	return &GINIndexDef{Name: gin.Name, TableName: gin.TableName, Column: gin.Column}, nil
+}
+
+// buildGINIndexLocked fills gin from the table's rows. Rows the current
+// transaction has buffered writes for are indexed under both their committed
+// and their pending contents, so the index stays a superset whether the
+// transaction commits or rolls back.
+func (c *Catalog) buildGINIndexLocked(gin *GINIndexDef, table *TableDef) error {
+	colIdx := table.GetColumnIndex(gin.Column)
+	docs := make(map[string][]string)
+	addRow := func(key string, value []byte) error {
+		row, ok, err := decodeLiveRow(value, len(table.Columns))
+		if err != nil || !ok || colIdx >= len(row) {
+			return err
+		}
+		docs[key] = mergeGINTerms(docs[key], ginDocumentTerms(row[colIdx]))
+		return nil
+	}
+	if tree, exists := c.tableTrees[gin.TableName]; exists && colIdx >= 0 {
+		iter, err := tree.Scan(nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to scan table %s for GIN index %s: %w", gin.TableName, gin.Name, err)
+		}
+		defer iter.Close()
+		for iter.HasNext() {
+			key, value, err := iter.Next()
+			if err != nil {
+				return fmt.Errorf("failed to read row for GIN index %s: %w", gin.Name, err)
+			}
+			if err := addRow(string(key), value); err != nil {
+				return fmt.Errorf("failed to decode row for GIN index %s: %w", gin.Name, err)
+			}
+		}
+		for key, pw := range c.pendingWritesForTable(gin.TableName) {
+			if pw.Value == nil {
+				continue
+			}
+			if err := addRow(key, pw.Value); err != nil {
+				return fmt.Errorf("failed to decode pending row for GIN index %s: %w", gin.Name, err)
+			}
+		}
+	}
+
+	gin.Postings = make(map[string][]string)
+	gin.Docs = make(map[string][]string, len(docs))
+	for key, terms := range docs {
+		if len(terms) == 0 {
+			continue
+		}
+		gin.Docs[key] = terms
+		for _, term := range terms {
+			gin.Postings[term] = append(gin.Postings[term], key)
+		}
+	}
+	for _, keys := range gin.Postings {
+		slices.Sort(keys)
+	}
+	return nil
+}
+
+// indexGINRow replaces the terms of the row stored under key with those of
+// row; a nil row just removes it.
+func indexGINRow(gin *GINIndexDef, table *TableDef, key string, row []interface{}) {
+	removeGINDoc(gin, key)
+	colIdx := table.GetColumnIndex(gin.Column)
+	if row == nil || colIdx < 0 || colIdx >= len(row) {
+		return
+	}
+	terms := ginDocumentTerms(row[colIdx])
+	if len(terms) == 0 {
+		return
+	}
+	if gin.Postings == nil {
+		gin.Postings = make(map[string][]string)
+	}
+	if gin.Docs == nil {
+		gin.Docs = make(map[string][]string)
+	}
+	for _, term := range terms {
+		gin.Postings[term] = insertSortedKey(gin.Postings[term], key)
+	}
+	gin.Docs[key] = terms
+}
+
+func removeGINDoc(gin *GINIndexDef, key string) {
+	terms, ok := gin.Docs[key]
+	if !ok {
+		return
+	}
+	for _, term := range terms {
+		keys := gin.Postings[term]
+		if i, found := slices.BinarySearch(keys, key); found {
+			keys = slices.Delete(keys, i, i+1)
+		}
+		if len(keys) == 0 {
+			delete(gin.Postings, term)
+		} else {
+			gin.Postings[term] = keys
+		}
+	}
+	delete(gin.Docs, key)
+}
+
+func insertSortedKey(keys []string, key string) []string {
+	i, found := slices.BinarySearch(keys, key)
+	if found {
+		return keys
+	}
+	return slices.Insert(keys, i, key)
+}
+
+// updateGINIndexesForWrite keeps the table's GIN indexes in step with a row
+// write applied directly to the table tree, like updateFTSIndexesForWrite.
+func (c *Catalog) updateGINIndexesForWrite(table *TableDef, oldKey, newKey []byte, row []interface{}) {
+	if c.ginCount.Load() == 0 {
+		return
+	}
+	c.ginMu.Lock()
+	defer c.ginMu.Unlock()
+	for _, gin := range c.ginIndexes {
+		if gin.TableName != table.Name {
+			continue
+		}
+		if oldKey != nil {
+			removeGINDoc(gin, string(oldKey))
+		}
+		if row != nil {
+			indexGINRow(gin, table, string(newKey), row)
+		}
+	}
+}
+
+// ginPendingWrites returns the buffered writes of ts that touch tables with
+// GIN indexes, to be applied by applyGINWrites once the commit succeeds.
+func (c *Catalog) ginPendingWrites(ts *catalogTxnState) []PendingWrite {
+	if ts == nil || len(ts.pendingWrites) == 0 || c.ginCount.Load() == 0 {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var writes []PendingWrite
+	for _, pw := range ts.pendingWrites {
+		for _, gin := range c.ginIndexes {
+			if gin.TableName == pw.TreeName {
+				writes = append(writes, pw)
+				break
+			}
+		}
+	}
+	return writes
+}
+
+// applyGINWrites indexes committed buffered writes, in commit order.
+func (c *Catalog) applyGINWrites(writes []PendingWrite) {
+	if len(writes) == 0 {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.ginMu.Lock()
+	defer c.ginMu.Unlock()
+	for _, pw := range writes {
+		table, ok := c.tables[pw.TreeName]
+		if !ok {
+			continue
+		}
+		var row []interface{}
+		if pw.Value != nil {
+			if live, ok, err := decodeLiveRow(pw.Value, len(table.Columns)); err == nil && ok {
+				row = live
+			}
+		}
+		for _, gin := range c.ginIndexes {
+			if gin.TableName == pw.TreeName {
+				indexGINRow(gin, table, pw.Key, row)
+			}
+		}
+	}
+}
+
+// rebuildGINIndexesAfterUndo rebuilds the GIN indexes of the tables whose
+// rows undoLog[from:] restored. Like full-text indexes, GIN postings are not
+// undo-logged.
+func (c *Catalog) rebuildGINIndexesAfterUndo(undoLog []undoEntry, from int) {
+	if c.ginCount.Load() == 0 {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.ginMu.Lock()
+	defer c.ginMu.Unlock()
+	for _, gin := range c.ginIndexes {
+		restored := false
+		for i := max(from, 0); i < len(undoLog) && !restored; i++ {
+			restored = undoLog[i].tableName == gin.TableName
+		}
+		if !restored {
+			continue
+		}
+		if table, err := c.getTableLocked(gin.TableName); err == nil {
+			// A failed rebuild leaves the old postings, which may miss rows;
+			// rebuild from scratch rather than trust them.
+			if c.buildGINIndexLocked(gin, table) != nil {
+				gin.Postings, gin.Docs = nil, nil
+			}
+		}
+	}
+}
+
+// loadGINIndexesLocked loads the GIN index definitions and builds their
+// postings. It runs after the tables have been loaded.
+func (c *Catalog) loadGINIndexesLocked() error {
+	iter, err := c.tree.Scan([]byte("gin:"), []byte("gin;"))
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to scan GIN index metadata: %w", err)
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		key, value, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("load catalog: failed to read GIN index metadata: %w", err)
+		}
+		name, ok := strings.CutPrefix(string(key), "gin:")
+		if !ok {
+			continue
+		}
+		var gin GINIndexDef
+		if err := json.Unmarshal(value, &gin); err != nil {
+			return fmt.Errorf("load catalog: failed to parse GIN index metadata %s: %w", name, err)
+		}
+		if gin.Name == "" {
+			gin.Name = name
+		}
+		table, ok := c.tables[gin.TableName]
+		if !ok {
+			continue
+		}
+		if err := c.buildGINIndexLocked(&gin, table); err != nil {
+			return fmt.Errorf("load catalog: %w", err)
+		}
+		c.ginIndexes[gin.Name] = &gin
+	}
+	c.ginCount.Store(int32(len(c.ginIndexes))) // #nosec G115 -- index count
+	return nil
+}
+
+// ginLookup is a GIN-servable predicate: rows must contain all terms (or,
+// with any set, at least one of them).
+type ginLookup struct {
+	gin   *GINIndexDef
+	terms []string
+	any   bool
+}
+
+// findGINLookup matches col @> value, JSON_CONTAINS(col, value) and
+// JSON_CONTAINS_PATH(col, 'one'|'all', path, ...) against the table's GIN
+// indexes. Values are resolved from literals and args; with args nil only the
+// shape is checked and terms is left empty.
+func (c *Catalog) findGINLookup(tableName string, expr query.Expression, args []interface{}) (ginLookup, bool) {
+	var colExpr query.Expression
+	var candidate query.Expression
+	var paths []query.Expression
+	mode := ""
+	switch e := expr.(type) {
+	case *query.JSONContainsExpr:
+		colExpr, candidate = e.Column, e.Value
+	case *query.FunctionCall:
+		switch {
+		case strings.EqualFold(e.Name, "JSON_CONTAINS") && len(e.Args) == 2:
+			colExpr, candidate = e.Args[0], e.Args[1]
+		case strings.EqualFold(e.Name, "JSON_CONTAINS_PATH") && len(e.Args) >= 3:
+			colExpr, paths = e.Args[0], e.Args[2:]
+			m, ok := e.Args[1].(*query.StringLiteral)
+			if !ok {
+				return ginLookup{}, false
+			}
+			mode = strings.ToLower(m.Value)
+			if mode != "one" && mode != "all" {
+				return ginLookup{}, false
+			}
+		default:
+			return ginLookup{}, false
+		}
+	default:
+		return ginLookup{}, false
+	}
+	var colName string
+	switch col := colExpr.(type) {
+	case *query.Identifier:
+		colName = col.Name
+	case *query.QualifiedIdentifier:
+		if !strings.EqualFold(col.Table, tableName) {
+			return ginLookup{}, false
+		}
+		colName = col.Column
+	default:
+		return ginLookup{}, false
+	}
+	var gin *GINIndexDef
+	for _, g := range c.ginIndexes {
+		if g.TableName == tableName && strings.EqualFold(g.Column, colName) {
+			gin = g
+			break
+		}
+	}
+	if gin == nil {
+		return ginLookup{}, false
+	}
+
+	lookup := ginLookup{gin: gin, any: mode == "one"}
+	if candidate != nil {
+		if _, ok := candidate.(*query.PlaceholderExpr); ok && args == nil {
+			return lookup, true
+		}
+		text, ok := toString(c.extractLiteralValue(candidate, args))
+		if !ok {
+			return ginLookup{}, false
+		}
+		var doc interface{}
+		if err := json.Unmarshal([]byte(text), &doc); err != nil {
+			return ginLookup{}, false
+		}
+		lookup.terms = ginDocumentTerms(doc)
+		// {} and [] are contained in every document of the same type, so
+		// there is nothing to look up.
+		return lookup, len(lookup.terms) > 0
+	}
+	for _, p := range paths {
+		if _, ok := p.(*query.PlaceholderExpr); ok && args == nil {
+			continue
+		}
+		text, ok := toString(c.extractLiteralValue(p, args))
+		if !ok {
+			return ginLookup{}, false
+		}
+		term, ok := ginPathTerm(text)
+		if !ok {
+			return ginLookup{}, false
+		}
+		lookup.terms = append(lookup.terms, term)
+	}
+	return lookup, true
+}
+
+// ginPathTerm returns the key term for a JSON path of object keys (array
+// subscripts are skipped, as arrays are transparent to the index). Paths with
+// wildcards, and the root path, have no term.
+func ginPathTerm(path string) (string, bool) {
+	jp, err := getCachedJSONPath(path)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteByte('k')
+	keys := 0
+	for _, seg := range jp.Segments {
+		if seg == "*" || seg == "**" {
+			return "", false
+		}
+		if strings.HasPrefix(seg, "[") && strings.HasSuffix(seg, "]") {
+			continue
+		}
+		b.WriteString(ginKeySep)
+		b.WriteString(seg)
+		keys++
+	}
+	return b.String(), keys > 0
+}
+
+// useGINIndexForWhere returns the storage keys of the rows that may satisfy
+// where according to the table's GIN indexes, in key order. AND intersects
+// the candidates of its servable sides; OR needs both sides servable.
+func (c *Catalog) useGINIndexForWhere(tableName string, where query.Expression, args []interface{}) ([]string, bool) {
+	if bin, ok := where.(*query.BinaryExpr); ok {
+		switch bin.Operator {
+		case query.TokenAnd:
+			left, lok := c.useGINIndexForWhere(tableName, bin.Left, args)
+			right, rok := c.useGINIndexForWhere(tableName, bin.Right, args)
+			switch {
+			case lok && rok:
+				return intersectSortedKeys(left, right), true
+			case lok:
+				return left, true
+			}
+			return right, rok
+		case query.TokenOr:
+			left, lok := c.useGINIndexForWhere(tableName, bin.Left, args)
+			if !lok {
+				return nil, false
+			}
+			right, rok := c.useGINIndexForWhere(tableName, bin.Right, args)
+			if !rok {
+				return nil, false
+			}
+			return unionSortedKeys(left, right), true
+		}
+		return nil, false
+	}
+	lookup, ok := c.findGINLookup(tableName, where, args)
+	if !ok || len(lookup.terms) == 0 {
+		return nil, false
+	}
+	c.ginMu.RLock()
+	defer c.ginMu.RUnlock()
+	var keys []string
+	for i, term := range lookup.terms {
+		postings := lookup.gin.Postings[term]
+		switch {
+		case i == 0:
+			keys = slices.Clone(postings)
+		case lookup.any:
+			keys = unionSortedKeys(keys, postings)
+		default:
+			keys = intersectSortedKeys(keys, postings)
+		}
+	}
+	return keys, true
+}
+
+// GINIndexForWhere names a GIN index the planner can use for where on
+// tableName, or returns "" if there is none. It is used by EXPLAIN.
+func (c *Catalog) GINIndexForWhere(tableName string, where query.Expression) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var find func(query.Expression) string
+	find = func(expr query.Expression) string {
+		if bin, ok := expr.(*query.BinaryExpr); ok && (bin.Operator == query.TokenAnd || bin.Operator == query.TokenOr) {
+			left, right := find(bin.Left), find(bin.Right)
+			if bin.Operator == query.TokenOr && (left == "" || right == "") {
+				return ""
+			}
+			if left != "" {
+				return left
+			}
+			return right
+		}
+		if lookup, ok := c.findGINLookup(tableName, expr, nil); ok {
+			return lookup.gin.Name
+		}
+		return ""
+	}
+	return find(where)
+}
+
+func intersectSortedKeys(a, b []string) []string {
+	out := make([]string, 0, min(len(a), len(b)))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch strings.Compare(a[i], b[j]) {
+		case 0:
+			out = append(out, a[i])
+			i++
+			j++
+		case -1:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
+
+func unionSortedKeys(a, b []string) []string {
+	out := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch strings.Compare(a[i], b[j]) {
+		case 0:
+			out = append(out, a[i])
+			i++
+			j++
+		case -1:
+			out = append(out, a[i])
+			i++
+		default:
+			out = append(out, b[j])
+			j++
+		}
+	}
+	out = append(out, a[i:]...)
+	return append(out, b[j:]...)
+}
+
+// ginDocumentTerms returns the sorted GIN terms of a JSON document, given as
+// JSON text or as a decoded value. Text that is not valid JSON has no terms.
+func ginDocumentTerms(v interface{}) []string {
+	doc, ok := decodeJSONDocument(v)
+	if !ok {
+		return nil
+	}
+	set := make(map[string]struct{})
+	appendGINTerms(set, doc, "")
+	terms := make([]string, 0, len(set))
+	for term := range set {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+func appendGINTerms(set map[string]struct{}, v interface{}, path string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			p := path + ginKeySep + k
+			set["k"+p] = struct{}{}
+			appendGINTerms(set, child, p)
+		}
+	case []interface{}:
+		for _, elem := range val {
+			appendGINTerms(set, elem, path)
+		}
+	default:
+		set["v"+path+ginValueSep+ginScalarKey(val)] = struct{}{}
+	}
+}
+
+// ginScalarKey encodes a JSON scalar; numbers that compare equal share a key.
+func ginScalarKey(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "z"
+	case bool:
+		if val {
+			return "b1"
+		}
+		return "b0"
+	case string:
+		return "s" + val
+	}
+	if f, ok := toFloat64Safe(v); ok {
+		return "n" + float64Key(f)
+	}
+	return "x" + ValueToStringKey(v)
+}
+
+// mergeGINTerms returns the sorted union of two sorted term lists.
+func mergeGINTerms(a, b []string) []string {
+	if len(a) == 0 {
+		return b
+	}
+	return unionSortedKeys(a, b)
+}
@@ -0,0 +1,170 @@
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+func TestJSONContainsSemantics(t *testing.T) {
+	tests := []struct {
+		target, candidate string
+		want              bool
+	}{
+		{`{"a":1,"b":2}`, `{"a":1}`, true},
+		{`{"a":1}`, `{"a":1,"b":2}`, false},
+		{`{"a":{"b":[1,2,3]}}`, `{"a":{"b":[3]}}`, true},
+		{`{"a":{"b":[1,2,3]}}`, `{"a":{"b":[4]}}`, false},
+		{`[1,2,[3,4]]`, `[[4]]`, true},
+		{`[1,2,3]`, `2`, true},
+		{`{"n":1.0}`, `{"n":1}`, true},
+		{`{"n":"1"}`, `{"n":1}`, false},
+		{`{"a":null}`, `{"a":null}`, true},
+		{`{"a":1}`, `{}`, true},
+	}
+	for _, tt := range tests {
+		target, ok := decodeJSONDocument(tt.target)
+		if !ok {
+			t.Fatalf("decode %s failed", tt.target)
+		}
+		candidate, ok := decodeJSONDocument(tt.candidate)
+		if !ok {
+			t.Fatalf("decode %s failed", tt.candidate)
+		}
+		if got := jsonContains(target, candidate); got != tt.want {
+			t.Errorf("jsonContains(%s, %s) = %v, want %v", tt.target, tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestJSONContainsFunctions(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE js (id INTEGER PRIMARY KEY, doc JSON)")
+	jcExec(t, c, `INSERT INTO js VALUES (1, '{"a":{"b":[1,2]},"c":"x"}')`)
+
+	tests := []struct {
+		sql, want string
+	}{
+		{`SELECT JSON_CONTAINS(doc, '{"c":"x"}') FROM js`, "true"},
+		{`SELECT JSON_CONTAINS(doc, '2', '$.a.b') FROM js`, "true"},
+		{`SELECT JSON_CONTAINS(doc, '3', '$.a.b') FROM js`, "false"},
+		{`SELECT JSON_CONTAINS_PATH(doc, 'one', '$.a.b', '$.z') FROM js`, "true"},
+		{`SELECT JSON_CONTAINS_PATH(doc, 'all', '$.a.b', '$.z') FROM js`, "false"},
+		{`SELECT doc @> '{"a":{"b":[2]}}' FROM js`, "true"},
+		{`SELECT doc @> '{"c":"xy"}' FROM js`, "false"},
+	}
+	for _, tt := range tests {
+		if got := jcScalar(t, c, tt.sql); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func ginCandidates(t *testing.T, c *Catalog, sql string) ([]string, bool) {
+	t.Helper()
+	stmt, err := query.Parse(sql)
+	if err != nil {
+		t.Fatalf("parse %q: %v", sql, err)
+	}
+	sel := stmt.(*query.SelectStmt)
+	return c.useGINIndexForWhere(sel.From.Name, sel.Where, nil)
+}
+
+func TestGINIndexCandidates(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE events (id INTEGER PRIMARY KEY, data JSON, note TEXT)")
+	jcExec(t, c, `INSERT INTO events VALUES
+		(1, '{"status":"active","tags":["a","b"]}', 'x'),
+		(2, '{"status":"closed","owner":{"name":"ann"}}', 'y'),
+		(3, '{"status":"active","owner":{"name":"bob"}}', 'z')`)
+	jcExec(t, c, "CREATE INDEX idx_events_data ON events USING GIN (data)")
+
+	count := func(sql string) int {
+		t.Helper()
+		keys, ok := ginCandidates(t, c, sql)
+		if !ok {
+			t.Fatalf("%s: GIN index not used", sql)
+		}
+		return len(keys)
+	}
+	tests := []struct {
+		sql  string
+		want int
+	}{
+		{`SELECT * FROM events WHERE data @> '{"status":"active"}'`, 2},
+		{`SELECT * FROM events WHERE data @> '{"tags":["b"]}'`, 1},
+		{`SELECT * FROM events WHERE JSON_CONTAINS(data, '{"owner":{"name":"ann"}}')`, 1},
+		{`SELECT * FROM events WHERE JSON_CONTAINS_PATH(data, 'one', '$.owner.name')`, 2},
+		{`SELECT * FROM events WHERE JSON_CONTAINS_PATH(data, 'one', '$.tags', '$.owner')`, 3},
+		{`SELECT * FROM events WHERE JSON_CONTAINS_PATH(data, 'all', '$.tags', '$.owner')`, 0},
+		{`SELECT * FROM events WHERE data @> '{"status":"active"}' AND note = 'z'`, 2},
+		{`SELECT * FROM events WHERE data @> '{"status":"gone"}' OR data @> '{"tags":["a"]}'`, 1},
+	}
+	for _, tt := range tests {
+		if got := count(tt.sql); got != tt.want {
+			t.Errorf("%s: %d candidates, want %d", tt.sql, got, tt.want)
+		}
+	}
+	for _, sql := range []string{
+		`SELECT * FROM events WHERE note = 'x'`,
+		`SELECT * FROM events WHERE data @> '{}'`,
+		`SELECT * FROM events WHERE data @> '{"status":"active"}' OR note = 'x'`,
+	} {
+		if _, ok := ginCandidates(t, c, sql); ok {
+			t.Errorf("%s: GIN index used, want a full scan", sql)
+		}
+	}
+
+	// The index follows writes, including those buffered in a transaction.
+	jcExec(t, c, `UPDATE events SET data = '{"status":"closed"}' WHERE id = 1`)
+	jcExec(t, c, `DELETE FROM events WHERE id = 3`)
+	jcExec(t, c, `INSERT INTO events VALUES (4, '{"status":"active"}', 'w')`)
+	if got := count(`SELECT * FROM events WHERE data @> '{"status":"active"}'`); got != 1 {
+		t.Fatalf("active candidates after writes = %d, want 1", got)
+	}
+	if got := count(`SELECT * FROM events WHERE data @> '{"status":"closed"}'`); got != 2 {
+		t.Fatalf("closed candidates after writes = %d, want 2", got)
+	}
+
+	c.BeginTransaction(10)
+	jcExec(t, c, `INSERT INTO events VALUES (5, '{"status":"active"}', 'v')`)
+	jcExec(t, c, `DELETE FROM events WHERE id = 4`)
+	if err := c.RollbackTransaction(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if got := count(`SELECT * FROM events WHERE data @> '{"status":"active"}'`); got != 1 {
+		t.Fatalf("active candidates after rollback = %d, want 1", got)
+	}
+
+	r, err := c.ExecuteQuery(`SELECT id FROM events WHERE data @> '{"status":"closed"}' ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, row := range r.Rows {
+		ids = append(ids, fmt.Sprint(row[0]))
+	}
+	if !slices.Equal(ids, []string{"1", "2"}) {
+		t.Fatalf("closed ids = %v, want [1 2]", ids)
+	}
+
+	if err := c.DropIndex("idx_events_data"); err != nil {
+		t.Fatalf("DropIndex: %v", err)
+	}
+	if _, ok := ginCandidates(t, c, `SELECT * FROM events WHERE data @> '{"status":"active"}'`); ok {
+		t.Fatal("GIN index used after DROP INDEX")
+	}
+}
+
+func TestGINIndexRequiresJSONColumn(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE t (id INTEGER PRIMARY KEY, body TEXT)")
+	if _, err := c.ExecuteQuery("CREATE INDEX idx_t_body ON t USING GIN (body)"); err == nil {
+		t.Fatal("expected an error for a GIN index on a TEXT column")
+	}
+	if _, err := c.ExecuteQuery("CREATE INDEX idx_t_body ON t USING HASH (body)"); err == nil {
+		t.Fatal("expected an error for an unsupported index method")
+	}
+}
@@ -0,0 +1,275 @@
+package catalog
+
+import (
+	"sort"
+
+	"github.com/cobaltdb/cobaltdb/pkg/parallel"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// A table GROUP BY whose columns are all plain COUNT, SUM, AVG, MIN and MAX
+// aggregates or values of the group's first row does not collect the rows
+// of each group: groupAccumulators folds every row into its group's running
+// state as the table is scanned, so a group holds one row however many it
+// covers. Other GROUP BYs collect their rows with buildGroupByGroups.
+
+// groupAccumulators is the running state of the groups of a GROUP BY.
+type groupAccumulators struct {
+	cols   []selectColInfo
+	colIdx []int // Table column each aggregate of a column name reads, or -1
+	groups map[string]*groupState
+	order  []string // Group keys in the order their first rows were seen
+	err    error    // Decode error that stopped the scan
+}
+
+// groupState is the state of one group: its first row and, for each
+// aggregate column, the aggregate so far.
+type groupState struct {
+	first []interface{}
+	aggs  []aggregateState
+}
+
+// aggregateState is the running state of one aggregate column.
+type aggregateState struct {
+	count  int64       // COUNT: rows or non-NULL values; AVG: numeric values
+	sum    float64     // SUM and AVG
+	hasSum bool        // SUM saw a numeric value
+	value  interface{} // MIN and MAX
+}
+
+// accumulableGroupBy reports whether groupAccumulators can compute the
+// columns of a GROUP BY.
+func (c *Catalog) accumulableGroupBy(selectCols []selectColInfo) bool {
+	for _, ci := range selectCols {
+		if ci.isWindow || ci.hasEmbeddedAgg {
+			return false
+		}
+		if !ci.isAggregate {
+			continue
+		}
+		switch ci.aggregateType {
+		case "COUNT", "SUM", "AVG", "MIN", "MAX":
+		default:
+			return false
+		}
+		if ci.isDistinct || len(ci.aggregateOrderBy) > 0 || c.userAggregate(ci.aggregateType) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// accumulateGroups scans allValues into groupAccumulators, in parallel
+// chunks when the table is large enough.
+func (c *Catalog) accumulateGroups(table *TableDef, stmt *query.SelectStmt, args []interface{}, specs []groupBySpec, selectCols []selectColInfo, allValues [][]byte) (*groupAccumulators, error) {
+	rlsCtx, applyRLS := c.groupByRLS(table)
+	colIdx := make([]int, len(selectCols))
+	for i, ci := range selectCols {
+		colIdx[i] = -1
+		if ci.isAggregate && ci.aggregateExpr == nil && ci.aggregateCol != "*" {
+			colIdx[i] = table.GetColumnIndex(ci.aggregateCol)
+		}
+	}
+	workers := 1
+	if c.parallelWorkers > 0 && !hasSubqueries(stmt) {
+		workers = c.parallelWorkers
+	}
+	inParallel := workers > 1 && len(allValues) >= c.parallelThreshold
+	// The statement budget belongs to this goroutine, so parallel chunks
+	// are charged after they are merged.
+	budget := c.budget()
+	if inParallel {
+		budget = nil
+	}
+
+	partials := parallel.ParallelAggregate(allValues, workers, c.parallelThreshold,
+		func(chunk [][]byte) []interface{} {
+			acc := &groupAccumulators{cols: selectCols, colIdx: colIdx, groups: make(map[string]*groupState)}
+			for _, valueData := range chunk {
+				fullRow, key, ok, err := c.groupByRow(table, stmt, args, specs, rlsCtx, applyRLS, valueData)
+				if err != nil {
+					acc.err = err
+					break
+				}
+				if !ok {
+					if !budget.alive() {
+						break
+					}
+					continue
+				}
+				if acc.add(c, key, fullRow, table.Columns, args) {
+					if !budget.chargeRow(fullRow) {
+						break
+					}
+				} else if !budget.alive() {
+					break
+				}
+			}
+			return []interface{}{acc}
+		},
+		func(dst, src []interface{}) {
+			dst[0].(*groupAccumulators).merge(src[0].(*groupAccumulators))
+		})
+	acc := partials[0].(*groupAccumulators)
+	if acc.err != nil {
+		return nil, acc.err
+	}
+	if inParallel {
+		budget = c.budget()
+		for _, st := range acc.groups {
+			budget.chargeRow(st.first)
+		}
+		// Like buildGroupByGroups, parallel grouping emits groups in key
+		// order.
+		sort.Strings(acc.order)
+	}
+	return acc, nil
+}
+
+// add folds row into the group key and reports whether it started a new
+// group.
+func (a *groupAccumulators) add(c *Catalog, key string, row []interface{}, columns []ColumnDef, args []interface{}) bool {
+	st, exists := a.groups[key]
+	if !exists {
+		st = &groupState{first: row, aggs: make([]aggregateState, len(a.cols))}
+		a.groups[key] = st
+		a.order = append(a.order, key)
+	}
+	for i, ci := range a.cols {
+		if !ci.isAggregate {
+			continue
+		}
+		if ci.aggregateFilter != nil {
+			if ok, err := evaluateWhere(c, row, columns, ci.aggregateFilter, args); err != nil || !ok {
+				continue
+			}
+		}
+		s := &st.aggs[i]
+		if ci.aggregateType == "COUNT" && ci.aggregateCol == "*" {
+			s.count++
+			continue
+		}
+		var v interface{}
+		if ci.aggregateExpr != nil {
+			var err error
+			if v, err = evaluateExpression(c, row, columns, ci.aggregateExpr, args); err != nil {
+				continue
+			}
+		} else if ci.aggregateCol != "*" {
+			idx := a.colIdx[i]
+			if idx < 0 || idx >= len(row) {
+				continue
+			}
+			v = row[idx]
+		} else {
+			v = int64(1)
+		}
+		if v == nil {
+			continue
+		}
+		switch ci.aggregateType {
+		case "COUNT":
+			s.count++
+		case "SUM", "AVG":
+			if f, ok := toFloat64(v); ok {
+				s.sum += f
+				s.hasSum = true
+				s.count++
+			}
+		case "MIN":
+			if s.value == nil || compareValues(v, s.value) < 0 {
+				s.value = v
+			}
+		case "MAX":
+			if s.value == nil || compareValues(v, s.value) > 0 {
+				s.value = v
+			}
+		}
+	}
+	return !exists
+}
+
+// merge folds the groups of src, scanned after a's, into a.
+func (a *groupAccumulators) merge(src *groupAccumulators) {
+	if a.err == nil {
+		a.err = src.err
+	}
+	for _, key := range src.order {
+		from := src.groups[key]
+		st, exists := a.groups[key]
+		if !exists {
+			a.groups[key] = from
+			a.order = append(a.order, key)
+			continue
+		}
+		for i, ci := range a.cols {
+			if !ci.isAggregate {
+				continue
+			}
+			s, f := &st.aggs[i], from.aggs[i]
+			s.count += f.count
+			s.sum += f.sum
+			s.hasSum = s.hasSum || f.hasSum
+			if f.value == nil {
+				continue
+			}
+			if s.value == nil ||
+				(ci.aggregateType == "MIN" && compareValues(f.value, s.value) < 0) ||
+				(ci.aggregateType == "MAX" && compareValues(f.value, s.value) > 0) {
+				s.value = f.value
+			}
+		}
+	}
+}
+
+// result returns the value of aggregate column ci.
+func (s *aggregateState) result(ci selectColInfo) interface{} {
+	switch ci.aggregateType {
+	case "COUNT":
+		return s.count
+	case "SUM":
+		if s.hasSum {
+			return s.sum
+		}
+	case "AVG":
+		if s.count > 0 {
+			return s.sum / float64(s.count)
+		}
+	case "MIN", "MAX":
+		return s.value
+	}
+	return nil
+}
+
+// accumulatedGroupResultRows returns the result row of each group, like
+// computeGroupResultRows.
+func (c *Catalog) accumulatedGroupResultRows(acc *groupAccumulators, stmt *query.SelectStmt, selectCols []selectColInfo, table *TableDef, args []interface{}) [][]interface{} {
+	var resultRows [][]interface{}
+	for _, key := range acc.order {
+		st := acc.groups[key]
+		resultRow := make([]interface{}, len(selectCols))
+		for i, ci := range selectCols {
+			if ci.isAggregate {
+				resultRow[i] = st.aggs[i].result(ci)
+			} else if ci.index >= 0 && ci.index < len(st.first) {
+				resultRow[i] = st.first[ci.index]
+			} else if ci.index == -1 && i < len(stmt.Columns) {
+				expr := stmt.Columns[i]
+				if ae, ok := expr.(*query.AliasExpr); ok {
+					expr = ae.Expr
+				}
+				if val, err := evaluateExpression(c, st.first, table.Columns, expr, args); err == nil {
+					resultRow[i] = val
+				}
+			}
+		}
+		if stmt.Having != nil {
+			havingMatched, err := evaluateHaving(c, resultRow, selectCols, table.Columns, stmt.Having, args)
+			if err != nil || !havingMatched {
+				continue
+			}
+		}
+		resultRows = append(resultRows, resultRow)
+	}
+	return resultRows
+}
@@ -60,7 +60,7 @@ func isAggregateCall(fc *query.FunctionCall) bool {
 	if (name == "MIN" || name == "MAX") && len(fc.Args) > 1 {
 		return false
 	}
-	return isAggregateFuncName(name)
+	return isAggregateFuncName(name) || isUserAggregateName(name)
 }
 
 func toInt(v interface{}) (int, bool) {
@@ -78,6 +78,10 @@ func toInt(v interface{}) (int, bool) {
 		}
 		return int(val), true
 	default:
+		// Other integer types, e.g. parameters decoded from msgpack.
+		if i, ok := compareAsInt64(v); ok && i <= int64(math.MaxInt) && i >= int64(math.MinInt) {
+			return int(i), true
+		}
 		return 0, false
 	}
 }
@@ -1,20 +1,37 @@
 package catalog
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/cobaltdb/cobaltdb/pkg/btree"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
 )
 
 func (c *Catalog) CreateIndex(stmt *query.CreateIndexStmt) error {
+	switch stmt.Method {
+	case "":
+	case "GIN":
+		return c.CreateGINIndex(stmt)
+	case "BLOOM":
+		return c.CreateBloomIndex(stmt)
+	default:
+		return fmt.Errorf("unsupported index method %s", stmt.Method)
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	defer c.invalidateSchemaCache()
-	if !stmt.IfNotExists {
-		if _, exists := c.indexes[stmt.Index]; exists {
-			return ErrIndexExists
+	if c.indexNameTakenLocked(stmt.Index) {
+		if stmt.IfNotExists {
+			return nil // Index already exists, silently succeed
 		}
+		return ErrIndexExists
 	}
 
 	// Verify table exists
@@ -26,33 +43,23 @@ func (c *Catalog) CreateIndex(stmt *query.CreateIndexStmt) error {
 	// Verify all index columns exist in the table
 	for _, colName := range stmt.Columns {
 		if table.GetColumnIndex(colName) < 0 {
-			return fmt.Errorf("column '%s' not found in table '%s'", colName, stmt.Table)
+			return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found in table '%s'", colName, stmt.Table)
 		}
 	}
-
-	// Create B+Tree for the index
-	indexTree, err := btree.NewBTree(c.pool)
-	if err != nil {
-		return err
+	if stmt.JSONPath != "" {
+		if _, err := ParseJSONPath(stmt.JSONPath); err != nil {
+			return fmt.Errorf("invalid JSON path for index %s: %w", stmt.Index, err)
+		}
 	}
 
 	indexDef := &IndexDef{
-		Name:       stmt.Index,
-		TableName:  stmt.Table,
-		Columns:    stmt.Columns,
-		Unique:     stmt.Unique,
-		RootPageID: indexTree.RootPageID(),
-		Status:     IndexBuilding,
-		Temporary:  table.Temporary,
-	}
-
-	c.indexes[stmt.Index] = indexDef
-	c.indexTrees[stmt.Index] = indexTree
-
-	if err := c.storeIndexDef(indexDef); err != nil {
-		delete(c.indexes, stmt.Index)
-		delete(c.indexTrees, stmt.Index)
-		return err
+		Name:      stmt.Index,
+		TableName: stmt.Table,
+		Columns:   stmt.Columns,
+		Unique:    stmt.Unique,
+		Status:    IndexBuilding,
+		Temporary: table.Temporary,
+		JSONPath:  stmt.JSONPath,
 	}
 
 	// For small tables (≤1000 rows) build synchronously so tests and
@@ -61,17 +68,31 @@ func (c *Catalog) CreateIndex(stmt *query.CreateIndexStmt) error {
 	// in <100ms and doesn't block concurrent reads/writes.
 	tree := c.tableTrees[stmt.Table]
 	pendingWrites := c.pendingWritesForTable(stmt.Table)
-	if tree != nil && (tree.Size() <= 1000 || len(pendingWrites) > 0) {
-		if err := c.populateIndexVisibleRowsLocked(indexTree, indexDef, table, tree, pendingWrites); err != nil {
-			delete(c.indexes, stmt.Index)
-			delete(c.indexTrees, stmt.Index)
-			if deleteErr := c.deleteCatalogDef("idx:" + stmt.Index); deleteErr != nil {
-				return fmt.Errorf("failed to populate index %s: %w; cleanup failed: %v", stmt.Index, err, deleteErr)
-			}
+	buildNow := tree != nil && (tree.Size() <= 1000 || len(pendingWrites) > 0)
+	var indexTree *btree.BTree
+	if buildNow {
+		indexTree, err = c.bulkLoadIndexLocked(indexDef, table, tree, pendingWrites)
+		if err != nil {
 			return fmt.Errorf("failed to populate index %s: %w", stmt.Index, err)
 		}
 		indexDef.Status = IndexActive
 	} else {
+		indexTree, err = btree.NewBTree(c.pool)
+		if err != nil {
+			return err
+		}
+	}
+	indexDef.RootPageID = indexTree.RootPageID()
+
+	c.indexes[stmt.Index] = indexDef
+	c.indexTrees[stmt.Index] = indexTree
+
+	if err := c.storeIndexDef(indexDef); err != nil {
+		delete(c.indexes, stmt.Index)
+		delete(c.indexTrees, stmt.Index)
+		return err
+	}
+	if !buildNow {
 		go c.buildIndexInBackground(stmt.Index, stmt.Table, table)
 	}
 
@@ -83,7 +104,7 @@ func (c *Catalog) CreateIndex(stmt *query.CreateIndexStmt) error {
 		})
 	}
 
-	return nil
+	return c.logIndexSchema(indexDef)
 }
 
 func (c *Catalog) pendingWritesForTable(tableName string) map[string]PendingWrite {
@@ -94,52 +115,71 @@ func (c *Catalog) pendingWritesForTable(tableName string) map[string]PendingWrit
 	return ts.getPendingWriteMap()[tableName]
 }
 
-func (c *Catalog) populateIndexVisibleRowsLocked(indexTree btree.TreeStore, indexDef *IndexDef, table *TableDef, tree btree.TreeStore, pendingWrites map[string]PendingWrite) error {
+// bulkLoadIndexLocked builds the tree of indexDef from the rows of table
+// visible to the current transaction: those of tree, shadowed by
+// pendingWrites. The entries are sorted and bulk-loaded instead of put one at
+// a time. Must be called with c.mu held.
+func (c *Catalog) bulkLoadIndexLocked(indexDef *IndexDef, table *TableDef, tree btree.TreeStore, pendingWrites map[string]PendingWrite) (*btree.BTree, error) {
+	type indexEntry struct {
+		key    string
+		rowKey []byte
+	}
+	var entries []indexEntry
+	add := func(key, valueData []byte) error {
+		row, err := decodeRow(valueData, len(table.Columns))
+		if err != nil {
+			return fmt.Errorf("failed to decode row in table %s while populating index %s: %w", table.Name, indexDef.Name, err)
+		}
+		indexKey, ok := buildCompositeIndexKey(table, indexDef, row)
+		if !ok {
+			return nil
+		}
+		if !indexDef.Unique {
+			indexKey += "\x00" + string(key)
+		}
+		entries = append(entries, indexEntry{key: indexKey, rowKey: key})
+		return nil
+	}
+
 	iter, err := tree.Scan(nil, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer iter.Close()
 	for iter.HasNext() {
 		key, valueData, iterErr := iter.Next()
 		if iterErr != nil {
-			return iterErr
+			return nil, iterErr
 		}
 		if _, shadowed := pendingWrites[string(key)]; shadowed {
 			continue
 		}
-		if err := c.addIndexRowLocked(indexTree, indexDef, table, key, valueData); err != nil {
-			return err
+		if err := add(bytes.Clone(key), valueData); err != nil {
+			return nil, err
 		}
 	}
 	for key, pw := range pendingWrites {
 		if pw.Value == nil {
 			continue
 		}
-		if err := c.addIndexRowLocked(indexTree, indexDef, table, []byte(key), pw.Value); err != nil {
-			return err
+		if err := add([]byte(key), pw.Value); err != nil {
+			return nil, err
 		}
 	}
-	return nil
-}
 
-func (c *Catalog) addIndexRowLocked(indexTree btree.TreeStore, indexDef *IndexDef, table *TableDef, key, valueData []byte) error {
-	row, err := decodeRow(valueData, len(table.Columns))
-	if err != nil {
-		return fmt.Errorf("failed to decode row in table %s while populating index %s: %w", table.Name, indexDef.Name, err)
-	}
-	indexKey, ok := buildCompositeIndexKey(table, indexDef, row)
-	if !ok {
-		return nil
-	}
-	if indexDef.Unique {
-		if existingKey, err := indexTree.Get([]byte(indexKey)); err == nil && string(existingKey) != string(key) {
-			return fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, indexDef.Name)
+	slices.SortFunc(entries, func(a, b indexEntry) int { return strings.Compare(a.key, b.key) })
+	loader := btree.NewBulkLoader(c.pool)
+	for i, e := range entries {
+		// Non-unique keys end with the row key, so only a unique index
+		// can repeat one.
+		if i > 0 && e.key == entries[i-1].key {
+			return nil, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", e.key, indexDef.Name)
+		}
+		if err := loader.Add([]byte(e.key), e.rowKey); err != nil {
+			return nil, err
 		}
-		return indexTree.Put([]byte(indexKey), key)
 	}
-	compoundKey := indexKey + "\x00" + string(key)
-	return indexTree.Put([]byte(compoundKey), key)
+	return loader.Finish()
 }
 
 // populateIndexLocked fills an index tree from a table scan. Must be called
@@ -165,7 +205,7 @@ func (c *Catalog) populateIndexLocked(indexTree btree.TreeStore, indexDef *Index
 		}
 		if indexDef.Unique {
 			if existingKey, err := indexTree.Get([]byte(indexKey)); err == nil && string(existingKey) != string(key) {
-				return fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, indexDef.Name)
+				return sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, indexDef.Name)
 			}
 			if err := indexTree.Put([]byte(indexKey), key); err != nil {
 				return err
@@ -200,6 +240,8 @@ func (c *Catalog) buildIndexInBackground(indexName, tableName string, table *Tab
 	c.mu.Lock()
 	if def, exists := c.indexes[indexName]; exists && def.Status == IndexBuilding {
 		def.Status = IndexActive
+		// If this fails the index is rebuilt on the next Load.
+		_ = c.storeIndexDef(def)
 	}
 	c.mu.Unlock()
 }
@@ -246,12 +288,18 @@ func (c *Catalog) findUsableIndexWithArgs(tableName string, where query.Expressi
 		}
 
 		if expr.Operator == query.TokenEq {
+			if name, col, val := c.findJSONPathIndex(tableName, expr.Left, expr.Right, args); name != "" {
+				return name, col, val
+			}
+			if name, col, val := c.findJSONPathIndex(tableName, expr.Right, expr.Left, args); name != "" {
+				return name, col, val
+			}
 			// Check if left side is a column identifier
 			if ident, ok := expr.Left.(*query.Identifier); ok {
 				colName := ident.Name
 				// Check if there's an index on this column
 				for idxName, idxDef := range c.indexes {
-					if idxDef.Status == IndexActive && idxDef.TableName == tableName && len(idxDef.Columns) > 0 && idxDef.Columns[0] == colName {
+					if idxDef.Status == IndexActive && idxDef.TableName == tableName && idxDef.JSONPath == "" && len(idxDef.Columns) > 0 && idxDef.Columns[0] == colName {
 						// Get the value to search for
 						searchVal := c.extractLiteralValue(expr.Right, args)
 						if searchVal == nil {
@@ -261,7 +309,7 @@ func (c *Catalog) findUsableIndexWithArgs(tableName string, where query.Expressi
 					}
 				}
 				// Check if this is the PRIMARY KEY column
-				if table, exists := c.tables[tableName]; exists && len(table.PrimaryKey) == 1 && table.PrimaryKey[0] == colName {
+				if table, exists := c.tables[tableName]; exists && len(table.PrimaryKey) == 1 && table.PrimaryKey[0] == colName && !hasCollatedColumn(table, colName) {
 					searchVal := c.extractLiteralValue(expr.Right, args)
 					if searchVal != nil {
 						return "__PK__", colName, searchVal
@@ -272,7 +320,7 @@ func (c *Catalog) findUsableIndexWithArgs(tableName string, where query.Expressi
 			if ident, ok := expr.Right.(*query.Identifier); ok {
 				colName := ident.Name
 				for idxName, idxDef := range c.indexes {
-					if idxDef.Status == IndexActive && idxDef.TableName == tableName && len(idxDef.Columns) > 0 && idxDef.Columns[0] == colName {
+					if idxDef.Status == IndexActive && idxDef.TableName == tableName && idxDef.JSONPath == "" && len(idxDef.Columns) > 0 && idxDef.Columns[0] == colName {
 						searchVal := c.extractLiteralValue(expr.Left, args)
 						if searchVal == nil {
 							continue
@@ -281,7 +329,7 @@ func (c *Catalog) findUsableIndexWithArgs(tableName string, where query.Expressi
 					}
 				}
 				// Check if this is the PRIMARY KEY column
-				if table, exists := c.tables[tableName]; exists && len(table.PrimaryKey) == 1 && table.PrimaryKey[0] == colName {
+				if table, exists := c.tables[tableName]; exists && len(table.PrimaryKey) == 1 && table.PrimaryKey[0] == colName && !hasCollatedColumn(table, colName) {
 					searchVal := c.extractLiteralValue(expr.Left, args)
 					if searchVal != nil {
 						return "__PK__", colName, searchVal
@@ -327,10 +375,150 @@ func (c *Catalog) useIndexForQueryWithArgs(tableName string, where query.Express
 	if idxName != "" && searchVal != nil {
 		return c.useIndexForExactMatch(idxName, searchVal)
 	}
+	if keys, ok, err := c.useIndexForLikePrefix(tableName, where, args); ok || err != nil {
+		return keys, ok, err
+	}
+	if keys, ok := c.useGINIndexForWhere(tableName, where, args); ok {
+		return keys, true, nil
+	}
+	if keys, ok := c.useBloomIndexForWhere(tableName, where, args); ok {
+		return keys, true, nil
+	}
 
 	return nil, false, nil
 }
 
+// useIndexForLikePrefix narrows `col LIKE 'abc%'` on a TEXT column that
+// leads an index, or is the primary key, to the keys starting with the
+// pattern's literal prefix. The keys are candidates: callers still apply
+// the WHERE clause. Case-insensitive LIKE and collated columns, whose keys
+// do not sort by the raw text, scan the table instead.
+func (c *Catalog) useIndexForLikePrefix(tableName string, where query.Expression, args []interface{}) ([]string, bool, error) {
+	switch e := where.(type) {
+	case *query.BinaryExpr:
+		if e.Operator != query.TokenAnd {
+			return nil, false, nil
+		}
+		if keys, ok, err := c.useIndexForLikePrefix(tableName, e.Left, args); ok || err != nil {
+			return keys, ok, err
+		}
+		return c.useIndexForLikePrefix(tableName, e.Right, args)
+	case *query.LikeExpr:
+		if e.Not || c.likeNoCase.Load() {
+			return nil, false, nil
+		}
+		ident, ok := e.Expr.(*query.Identifier)
+		if !ok {
+			return nil, false, nil
+		}
+		table, exists := c.tables[tableName]
+		if !exists {
+			return nil, false, nil
+		}
+		colIdx := table.GetColumnIndex(ident.Name)
+		if colIdx < 0 || !strings.EqualFold(table.Columns[colIdx].Type, "TEXT") || hasCollatedColumn(table, ident.Name) {
+			return nil, false, nil
+		}
+		pattern, ok := c.extractLiteralValue(e.Pattern, args).(string)
+		if !ok {
+			return nil, false, nil
+		}
+		var esc rune
+		if e.Escape != nil {
+			escape := c.extractLiteralValue(e.Escape, args)
+			if escape == nil {
+				return nil, false, nil
+			}
+			var err error
+			if esc, err = likeEscapeChar(escape); err != nil {
+				return nil, false, nil
+			}
+		}
+		prefix := likePrefix(pattern, esc)
+		if prefix == "" {
+			return nil, false, nil
+		}
+		start := typeTaggedKey(prefix)
+		end := start + "\xff"
+
+		for idxName, idxDef := range c.indexes {
+			if idxDef.Status != IndexActive || idxDef.TableName != tableName || idxDef.JSONPath != "" || len(idxDef.Columns) == 0 || idxDef.Columns[0] != ident.Name {
+				continue
+			}
+			indexTree, ok := c.indexTrees[idxName]
+			if !ok {
+				continue
+			}
+			keys, err := scanIndexValues(indexTree, start, end)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to scan index %s: %w", idxName, err)
+			}
+			return keys, true, nil
+		}
+		if len(table.PrimaryKey) == 1 && table.PrimaryKey[0] == ident.Name && table.Partition == nil {
+			tree, ok := c.tableTrees[tableName]
+			if !ok {
+				return nil, false, nil
+			}
+			iter, err := tree.Scan([]byte(start), []byte(end))
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to scan table %s: %w", tableName, err)
+			}
+			defer iter.Close()
+			var keys []string
+			for iter.HasNext() {
+				k, _, err := iter.NextString()
+				if err != nil {
+					return nil, false, fmt.Errorf("failed to read table %s: %w", tableName, err)
+				}
+				keys = append(keys, k)
+			}
+			return keys, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// likePrefix returns the literal text a LIKE pattern starts with, up to its
+// first wildcard.
+func likePrefix(pattern string, esc rune) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		if esc != 0 && r == esc {
+			if i >= len(pattern) {
+				break
+			}
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+		} else if r == '%' || r == '_' {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// scanIndexValues returns the primary keys stored under index keys in
+// [start, end].
+func scanIndexValues(indexTree btree.TreeStore, start, end string) ([]string, error) {
+	iter, err := indexTree.Scan([]byte(start), []byte(end))
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var keys []string
+	for iter.HasNext() {
+		_, pkData, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, string(pkData))
+	}
+	return keys, nil
+}
+
 func (c *Catalog) useIndexForExactMatch(idxName string, searchVal interface{}) ([]string, bool, error) {
 	// Special case: PRIMARY KEY lookup
 	if idxName == "__PK__" {
@@ -352,6 +540,11 @@ func (c *Catalog) useIndexForExactMatch(idxName string, searchVal interface{}) (
 		return nil, false, nil
 	}
 
+	if table, ok := c.tables[idxDef.TableName]; ok && idxDef.JSONPath == "" && len(idxDef.Columns) > 0 {
+		if colIdx := table.GetColumnIndex(idxDef.Columns[0]); colIdx >= 0 {
+			searchVal = collatedIndexValue(&table.Columns[colIdx], searchVal)
+		}
+	}
 	indexKey := typeTaggedKey(searchVal)
 	var result []string
 
@@ -396,6 +589,9 @@ func (c *Catalog) ListIndexesByTable() map[string][][]string {
 
 	result := make(map[string][][]string)
 	for _, idxDef := range c.indexes {
+		if idxDef.JSONPath != "" {
+			continue
+		}
 		result[idxDef.TableName] = append(result[idxDef.TableName], idxDef.Columns)
 	}
 	// Treat primary keys as existing indexes
@@ -407,10 +603,28 @@ func (c *Catalog) ListIndexesByTable() map[string][][]string {
 	return result
 }
 
+// indexNameTakenLocked reports whether name is used by an index of any kind.
+func (c *Catalog) indexNameTakenLocked(name string) bool {
+	if _, exists := c.indexes[name]; exists {
+		return true
+	}
+	if _, exists := c.ginIndexes[name]; exists {
+		return true
+	}
+	_, exists := c.bloomIndexes[name]
+	return exists
+}
+
 func (c *Catalog) DropIndex(name string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	defer c.invalidateSchemaCache()
+	if _, isGIN := c.ginIndexes[name]; isGIN {
+		return c.dropGINIndexLocked(name)
+	}
+	if _, isBloom := c.bloomIndexes[name]; isBloom {
+		return c.dropBloomIndexLocked(name)
+	}
 	idxDef, exists := c.indexes[name]
 	if !exists {
 		return ErrIndexNotFound
@@ -429,10 +643,14 @@ func (c *Catalog) DropIndex(name string) error {
 		})
 	}
 
+	c.releaseTreeLocked(c.indexTrees[name])
 	delete(c.indexes, name)
 	delete(c.indexTrees, name)
 
-	return nil
+	if idxDef.Temporary {
+		return nil
+	}
+	return c.logSchemaDrop(storage.WALDropIndex, "idx:"+name)
 }
 
 func (c *Catalog) DropUniqueConstraint(name string) error {
@@ -459,9 +677,13 @@ func (c *Catalog) DropUniqueConstraint(name string) error {
 		})
 	}
 
+	c.releaseTreeLocked(c.indexTrees[name])
 	delete(c.indexes, name)
 	delete(c.indexTrees, name)
-	return nil
+	if idxDef.Temporary {
+		return nil
+	}
+	return c.logSchemaDrop(storage.WALDropIndex, "idx:"+name)
 }
 
 // rebuildTableIndexesLocked rebuilds all regular B-tree indexes for a single
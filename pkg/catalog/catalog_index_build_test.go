@@ -41,3 +41,73 @@ func TestPopulateIndexReturnsPutError(t *testing.T) {
 		t.Fatalf("expected index put error, got %v", err)
 	}
 }
+
+func TestLikePrefixUsesIndex(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	for _, sql := range []string{
+		"CREATE TABLE lp (id INTEGER PRIMARY KEY, name TEXT, code TEXT COLLATE NOCASE)",
+		"CREATE INDEX lp_name ON lp (name)",
+		"CREATE INDEX lp_code ON lp (code)",
+		"INSERT INTO lp VALUES (1, 'apple', 'x'), (2, 'apricot', 'y'), (3, 'banana', 'z'), (4, 'a%b', 'w')",
+	} {
+		if _, err := c.ExecuteQuery(sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+	}
+
+	for where, want := range map[string]int{
+		"name LIKE 'ap%'":             2,
+		"name LIKE 'apr_cot'":         1,
+		"id > 0 AND name LIKE 'b%'":   1,
+		"name LIKE 'a!%%' ESCAPE '!'": 1,
+		"name LIKE '%a'":              -1, // no literal prefix
+		"name NOT LIKE 'ap%'":         -1,
+		"code LIKE 'x%'":              -1, // collated keys do not sort by raw text
+		"UPPER(name) LIKE 'AP%'":      -1,
+		"name LIKE 'a%' ESCAPE name":  -1,
+	} {
+		stmt, err := query.Parse("SELECT * FROM lp WHERE " + where)
+		if err != nil {
+			t.Fatalf("Parse %s: %v", where, err)
+		}
+		keys, used, err := c.useIndexForQueryWithArgs("lp", stmt.(*query.SelectStmt).Where, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", where, err)
+		}
+		if want < 0 {
+			if used {
+				t.Errorf("%s used an index", where)
+			}
+			continue
+		}
+		if !used || len(keys) != want {
+			t.Errorf("%s: used=%v with %d keys, want %d", where, used, len(keys), want)
+		}
+	}
+
+	c.SetCaseSensitiveLike(false)
+	stmt, _ := query.Parse("SELECT * FROM lp WHERE name LIKE 'ap%'")
+	if _, used, _ := c.useIndexForQueryWithArgs("lp", stmt.(*query.SelectStmt).Where, nil); used {
+		t.Error("case-insensitive LIKE used a prefix scan")
+	}
+}
+
+func TestLikePrefix(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		esc     rune
+		want    string
+	}{
+		{"abc%", 0, "abc"},
+		{"ab_d", 0, "ab"},
+		{"%abc", 0, ""},
+		{"abc", 0, "abc"},
+		{`a\%b%`, '\\', "a%b"},
+		{"é!_x%", '!', "é_x"},
+		{"ab!", '!', "ab"},
+	} {
+		if got := likePrefix(tt.pattern, tt.esc); got != tt.want {
+			t.Errorf("likePrefix(%q, %q) = %q, want %q", tt.pattern, tt.esc, got, tt.want)
+		}
+	}
+}
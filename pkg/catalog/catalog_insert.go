@@ -12,7 +12,9 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/btree"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
 	"github.com/cobaltdb/cobaltdb/pkg/security"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
 	"github.com/cobaltdb/cobaltdb/pkg/txn"
 )
 
@@ -133,11 +135,11 @@ func (c *Catalog) checkUniqueConstraintsSnapshot(tree btree.TreeStore, table *Ta
 		colLower := strings.ToLower(col.Name)
 		found := false
 		for _, idx := range idxSnap {
-			if !idx.def.Unique || len(idx.def.Columns) != 1 || strings.ToLower(idx.def.Columns[0]) != colLower {
+			if !idx.def.Unique || idx.def.JSONPath != "" || len(idx.def.Columns) != 1 || strings.ToLower(idx.def.Columns[0]) != colLower {
 				continue
 			}
 			if idx.tree != nil {
-				idxKey := typeTaggedKey(rowValues[i])
+				idxKey := typeTaggedKey(collatedIndexValue(&table.Columns[i], rowValues[i]))
 				if pkData, err := idx.tree.Get([]byte(idxKey)); err == nil {
 					duplicateKey = append([]byte(nil), pkData...)
 				}
@@ -162,11 +164,11 @@ func (c *Catalog) checkUniqueConstraintsSnapshot(tree btree.TreeStore, table *Ta
 				if !live {
 					continue
 				}
-				if len(existingRow) > i && compareValues(rowValues[i], existingRow[i]) == 0 {
+				if len(existingRow) > i && compareCollated(rowValues[i], existingRow[i], columnCollation(&table.Columns[i])) == 0 {
 					if stmt.ConflictAction == query.ConflictIgnore {
 						return true, nil
 					}
-					return false, fmt.Errorf("UNIQUE constraint failed: %s", col.Name)
+					return false, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: %s", col.Name)
 				}
 			}
 			iter, err := tree.Scan(nil, nil)
@@ -190,7 +192,7 @@ func (c *Catalog) checkUniqueConstraintsSnapshot(tree btree.TreeStore, table *Ta
 				if !live {
 					continue
 				}
-				if len(existingRow) > i && compareValues(rowValues[i], existingRow[i]) == 0 {
+				if len(existingRow) > i && compareCollated(rowValues[i], existingRow[i], columnCollation(&table.Columns[i])) == 0 {
 					duplicateKey = k
 					break
 				}
@@ -234,7 +236,7 @@ func (c *Catalog) checkUniqueConstraintsSnapshot(tree btree.TreeStore, table *Ta
 				}
 				return false, nil
 			}
-			return false, fmt.Errorf("UNIQUE constraint failed: %s", col.Name)
+			return false, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: %s", col.Name)
 		}
 	}
 	return false, nil
@@ -297,7 +299,7 @@ func restoreDeletedIndexEntries(entries []deletedIndexEntry) error {
 func (c *Catalog) buildBufferedInsertIndexesSnapshot(table *TableDef, stmt *query.InsertStmt, key string, rowValues []interface{}, ts *catalogTxnState, idxSnap []indexSnapshot) ([]PendingIndexUpdate, bool, error) {
 	var idxUpdates []PendingIndexUpdate
 	for _, idx := range idxSnap {
-		if idx.def.TableName != stmt.Table || len(idx.def.Columns) == 0 {
+		if idx.def.TableName != stmt.Table || len(idx.def.Columns) == 0 || c.skipDeferredIndex(stmt.Table, idx.def) {
 			continue
 		}
 		indexKey, ok := buildCompositeIndexKey(table, idx.def, rowValues)
@@ -314,7 +316,7 @@ func (c *Catalog) buildBufferedInsertIndexesSnapshot(table *TableDef, stmt *quer
 				if stmt.ConflictAction == query.ConflictIgnore {
 					return nil, true, nil
 				}
-				return nil, false, fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, idx.name)
+				return nil, false, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, idx.name)
 			}
 		}
 		var idxStorageKey string
@@ -435,14 +437,14 @@ func referencedRowExistsSnapshot(refTable *TableDef, refTree btree.TreeStore, pe
 // checkForeignKeyConstraintsSnapshot is the lock-free variant that uses
 // pre-snapshot referenced tables instead of reading c.tables/c.tableTrees.
 func (c *Catalog) checkForeignKeyConstraintsSnapshot(table *TableDef, rowValues []interface{}, ts *catalogTxnState, fkRefs map[string]fkSnapshot) error {
-	for _, fk := range table.ForeignKeys {
+	for _, fk := range c.enforcedForeignKeys(table) {
 		fkValues, skip := foreignKeyValuesForRow(table, fk, rowValues)
 		if skip {
 			continue
 		}
 		refSnap, ok := fkRefs[fk.ReferencedTable]
 		if !ok || refSnap.tree == nil {
-			return fmt.Errorf("FOREIGN KEY constraint failed: referenced table not found")
+			return sqlerr.New(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: referenced table not found")
 		}
 		var pendingParents map[string]PendingWrite
 		if ts != nil {
@@ -451,10 +453,10 @@ func (c *Catalog) checkForeignKeyConstraintsSnapshot(table *TableDef, rowValues
 		refColumns := referencedColumnsForTable(refSnap.table, fk)
 		found, err := referencedRowExistsSnapshot(refSnap.table, refSnap.tree, pendingParents, refColumns, fkValues)
 		if err != nil {
-			return fmt.Errorf("FOREIGN KEY constraint failed: failed to scan referenced table %s: %w", fk.ReferencedTable, err)
+			return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: failed to scan referenced table %s: %w", fk.ReferencedTable, err)
 		}
 		if !found {
-			return fmt.Errorf("FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
+			return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
 		}
 	}
 	return nil
@@ -470,7 +472,7 @@ func (c *Catalog) resolvePKConflictSnapshot(tree btree.TreeStore, table *TableDe
 func (c *Catalog) validateInsertRowSnapshot(table *TableDef, tree btree.TreeStore, stmt *query.InsertStmt, rowValues []interface{}, args []interface{}, compositePK bool, key string, ts *catalogTxnState, idxSnap []indexSnapshot, fkRefs map[string]fkSnapshot) (string, bool, error) {
 	for i, col := range table.Columns {
 		if col.NotNull && !col.AutoIncrement && rowValues[i] == nil {
-			return key, false, fmt.Errorf("NOT NULL constraint failed: column '%s' cannot be null", col.Name)
+			return key, false, sqlerr.Errorf(sqlerr.CodeNotNullViolation, "NOT NULL constraint failed: column '%s' cannot be null", col.Name)
 		}
 	}
 	if compositePK {
@@ -521,7 +523,7 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 		}
 		for i, colName := range stmt.Columns {
 			if table.GetColumnIndex(colName) < 0 {
-				return 0, 0, fmt.Errorf("column '%s' does not exist in table '%s'", colName, stmt.Table)
+				return 0, 0, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' does not exist in table '%s'", colName, stmt.Table)
 			}
 			insertColIndices[i] = table.GetColumnIndex(colName)
 		}
@@ -529,10 +531,11 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 
 	rowsAffected := int64(0)
 	autoIncValue := int64(0)
+	lastRowID := int64(0)
 	valueRows := stmt.Values
 	if stmt.Select != nil {
 		var err error
-		valueRows, err = c.convertSelectToValueRows(stmt, numInsertCols, args)
+		valueRows, args, err = c.convertSelectToValueRows(stmt, numInsertCols, args)
 		if err != nil {
 			return 0, 0, err
 		}
@@ -595,6 +598,7 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 
 		var key string
 		hasPrimaryKey := false
+		pk := insertPKValue{idx: -1}
 		if !compositePK {
 			for _, pkColName := range table.PrimaryKey {
 				valueIdx := -1
@@ -625,7 +629,11 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 					}
 				} else {
 					val, err := evaluateExpression(c, nil, nil, valueRow[valueIdx], args)
+					if err == nil {
+						pk = insertPKValue{idx: valueIdx, val: val}
+					}
 					if err == nil && val != nil {
+						val = keyColumnAffinity(table, pkColName, val)
 						if strVal, ok := toString(val); ok {
 							key = "S:" + strVal
 						} else if fVal, ok := toFloat64(val); ok {
@@ -653,7 +661,7 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 		} else {
 			rowValues = make([]interface{}, n)
 		}
-		if buildErr := c.buildInsertRow(table, insertColIndices, insertColumns, valueRow, args, autoIncValue, rowValues); buildErr != nil {
+		if buildErr := c.buildInsertRow(table, insertColIndices, insertColumns, valueRow, args, autoIncValue, pk, rowValues); buildErr != nil {
 			insertErr = buildErr
 			break
 		}
@@ -701,6 +709,10 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 			}
 		}
 
+		if err := c.lockRow(ts, stmt.Table, key); err != nil {
+			insertErr = err
+			break
+		}
 		if skip, err := c.resolvePKConflictSnapshot(tree, table, stmt, key); err != nil {
 			insertErr = err
 			break
@@ -711,7 +723,7 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 			if stmt.ConflictAction == query.ConflictIgnore {
 				continue
 			}
-			insertErr = fmt.Errorf("UNIQUE constraint failed: duplicate primary key value")
+			insertErr = sqlerr.New(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate primary key value")
 			break
 		}
 
@@ -753,6 +765,7 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 			insertedRows = append(insertedRows, rowCopy)
 		}
 		rowsAffected++
+		lastRowID = insertedRowID(table, rowValues, autoIncValue)
 	}
 
 	if insertErr != nil {
@@ -780,6 +793,10 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 		}
 	}
 
+	if err := c.logSequenceAdvance(table, savedAutoIncSeq); err != nil {
+		return rollbackInsertErr(err)
+	}
+
 	c.invalidateQueryCache(stmt.Table)
 
 	c.setLastReturning(returningRows, returningCols)
@@ -789,9 +806,10 @@ func (c *Catalog) insertBufferedLocked(ctx context.Context, stmt *query.InsertSt
 		c.vacuumMu.Lock()
 		c.liveTuples[stmt.Table] += rowsAffected
 		c.vacuumMu.Unlock()
+		c.noteModifiedRows(stmt.Table, rowsAffected)
 	}
 
-	return autoIncValue, rowsAffected, nil
+	return lastRowID, rowsAffected, nil
 }
 
 // zeroPadding is a lookup table for common zero-padding lengths (0-20).
@@ -921,7 +939,20 @@ func buildCompositePK(table *TableDef, rowValues []interface{}) (string, bool) {
 	return strings.Join(parts, compositeKeySep), true
 }
 
-func (c *Catalog) Insert(ctx context.Context, stmt *query.InsertStmt, args []interface{}) (int64, int64, error) {
+func (c *Catalog) Insert(ctx context.Context, stmt *query.InsertStmt, args []interface{}) (_ int64, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.Insert")
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.String("db.sql.table", stmt.Table))
+	}
+	defer func() { tracing.End(span, err) }()
+	defer tracing.Bind(ctx)()
+
+	if stmt.Query != nil {
+		// WITH and set-operation queries cannot run under the catalog lock;
+		// the engine materializes them into VALUES first.
+		return 0, 0, fmt.Errorf("INSERT from a WITH or compound query is not supported in this context")
+	}
+
 	// Fast path: resolve table metadata from schema cache without lock.
 	table, ver, cacheHit := c.getCachedTable(stmt.Table)
 	if !cacheHit {
@@ -961,7 +992,7 @@ func (c *Catalog) Insert(ctx context.Context, stmt *query.InsertStmt, args []int
 	// Determine buffered mode.  We can check enableBufferedWrites without the
 	// lock because it is set once at engine open and never changed afterwards.
 	useBuffer := c.isBufferedMode() && table != nil && table.Partition == nil && stmt.ConflictAction != query.ConflictReplace
-	if useBuffer && c.hasVectorIndexForTableLocked(stmt.Table) {
+	if useBuffer && (c.hasVectorIndexForTableLocked(stmt.Table) || c.hasFTSIndexForTableLocked(stmt.Table)) {
 		useBuffer = false
 	}
 
@@ -1051,16 +1082,36 @@ func (c *Catalog) resolvePKConflict(tree btree.TreeStore, table *TableDef, stmt
 		}
 		return false, nil // Proceed with insert after cleanup
 	}
-	return false, fmt.Errorf("UNIQUE constraint failed: duplicate primary key value")
+	return false, sqlerr.New(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate primary key value")
+}
+
+// evalColumnDefault evaluates a column DEFAULT. Defaults calling functions
+// such as RANDOM() or NEXTVAL() need the catalog (for SET seed and the
+// sequences), so they go through evaluateExpression.
+func (c *Catalog) evalColumnDefault(expr query.Expression, args []interface{}) (interface{}, error) {
+	if query.HasNonDeterministicFunction(expr) {
+		return evaluateExpression(c, nil, nil, expr, args)
+	}
+	return EvalExpression(expr, args)
 }
 
-func (c *Catalog) buildInsertRow(table *TableDef, insertColIndices []int, insertColumns []string, valueRow []query.Expression, args []interface{}, autoIncValue int64, rowValues []interface{}) error {
-	// Set defaults for all columns first.
+// insertPKValue is a primary key value the caller already evaluated to derive
+// the row key, so expressions with side effects such as NEXTVAL run once.
+// idx is the position in the VALUES row, or -1.
+type insertPKValue struct {
+	idx int
+	val interface{}
+}
+
+func (c *Catalog) buildInsertRow(table *TableDef, insertColIndices []int, insertColumns []string, valueRow []query.Expression, args []interface{}, autoIncValue int64, pk insertPKValue, rowValues []interface{}) error {
+	// Set defaults for all columns first. rowValues may be reused from the
+	// previous VALUES row, so every column is reset.
 	for i, col := range table.Columns {
+		rowValues[i] = nil
 		if col.AutoIncrement {
 			rowValues[i] = float64(autoIncValue)
 		} else if col.defaultExpr != nil {
-			if defVal, err := EvalExpression(col.defaultExpr, args); err == nil {
+			if defVal, err := c.evalColumnDefault(col.defaultExpr, args); err == nil {
 				rowValues[i] = defVal
 			}
 		}
@@ -1074,6 +1125,10 @@ func (c *Catalog) buildInsertRow(table *TableDef, insertColIndices []int, insert
 				if _, isDefault := valueRow[colIdx].(*query.DefaultExpr); isDefault {
 					continue
 				}
+				if colIdx == pk.idx {
+					rowValues[tableColIdx] = pk.val
+					continue
+				}
 				val, err := evaluateExpression(c, nil, nil, valueRow[colIdx], args)
 				if err != nil {
 					colName := ""
@@ -1094,6 +1149,10 @@ func (c *Catalog) buildInsertRow(table *TableDef, insertColIndices []int, insert
 			if _, isDefault := valueRow[colIdx].(*query.DefaultExpr); isDefault {
 				continue
 			}
+			if colIdx == pk.idx {
+				rowValues[colIdx] = pk.val
+				continue
+			}
 			val, err := evaluateExpression(c, nil, nil, valueRow[colIdx], args)
 			if err != nil {
 				return fmt.Errorf("failed to evaluate value for column '%s': %w", table.Columns[colIdx].Name, err)
@@ -1109,7 +1168,7 @@ func (c *Catalog) buildInsertRow(table *TableDef, insertColIndices []int, insert
 		}
 	}
 
-	return nil
+	return c.applyColumnAffinity(table, rowValues)
 }
 
 // validateInsertRow checks NOT NULL, composite PK, UNIQUE, CHECK, and FK constraints.
@@ -1118,7 +1177,7 @@ func (c *Catalog) validateInsertRow(table *TableDef, tree btree.TreeStore, stmt
 	// Check NOT NULL constraints
 	for i, col := range table.Columns {
 		if col.NotNull && !col.AutoIncrement && rowValues[i] == nil {
-			return key, false, fmt.Errorf("NOT NULL constraint failed: column '%s' cannot be null", col.Name)
+			return key, false, sqlerr.Errorf(sqlerr.CodeNotNullViolation, "NOT NULL constraint failed: column '%s' cannot be null", col.Name)
 		}
 	}
 
@@ -1218,7 +1277,7 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 		}
 		for i, colName := range stmt.Columns {
 			if table.GetColumnIndex(colName) < 0 {
-				return 0, 0, fmt.Errorf("column '%s' does not exist in table '%s'", colName, stmt.Table)
+				return 0, 0, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' does not exist in table '%s'", colName, stmt.Table)
 			}
 			insertColIndices[i] = table.GetColumnIndex(colName)
 		}
@@ -1231,7 +1290,7 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 	valueRows := stmt.Values
 	if stmt.Select != nil {
 		var err error
-		valueRows, err = c.convertSelectToValueRows(stmt, numInsertCols, args)
+		valueRows, args, err = c.convertSelectToValueRows(stmt, numInsertCols, args)
 		if err != nil {
 			return 0, 0, err
 		}
@@ -1289,16 +1348,15 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 	// with secondary indexes as long as we are not doing REPLACE (which
 	// requires immediate mutation of committed data).
 	useBuffer := c.isBufferedMode() && table.Partition == nil && stmt.ConflictAction != query.ConflictReplace
-	if useBuffer && c.hasVectorIndexForTableLocked(stmt.Table) {
+	if useBuffer && (c.hasVectorIndexForTableLocked(stmt.Table) || c.hasFTSIndexForTableLocked(stmt.Table)) {
 		useBuffer = false
 	}
 
 	// Skip allocating row copies when no triggers or RETURNING clause need them.
 	needsInsertedRows := len(stmt.Returning) > 0 || len(c.getTriggersForTableLocked(stmt.Table, "INSERT")) > 0
 
-	// Track the last generated auto-increment value so we can return it.
-	// (Multi-row INSERTs return the last generated id, matching MySQL semantics.)
-	var lastAutoIncValue int64
+	// Multi-row INSERTs return the ROWID of the last row inserted.
+	var lastRowID int64
 
 	compositePK := len(table.PrimaryKey) > 1
 
@@ -1314,9 +1372,7 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 		if skipRow {
 			continue
 		}
-		if autoIncValue > 0 {
-			lastAutoIncValue = autoIncValue
-		}
+		rowID := insertedRowID(table, rowValues, autoIncValue)
 
 		// Encode row with temporal versioning.
 		// Reuse the per-transaction buffer to avoid a heap alloc per row.
@@ -1363,6 +1419,7 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 				insertedRows = append(insertedRows, bufferedRow)
 			}
 			rowsAffected++
+			lastRowID = rowID
 			continue
 		}
 
@@ -1382,6 +1439,7 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 			insertedRows = append(insertedRows, insertedRow)
 		}
 		rowsAffected++
+		lastRowID = rowID
 	}
 
 	// Statement-level atomicity: undo all inserts on error
@@ -1389,6 +1447,10 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 		return rollbackInsertErr(insertErr)
 	}
 
+	if err := c.logSequenceAdvance(table, savedAutoIncSeq); err != nil {
+		return rollbackInsertErr(err)
+	}
+
 	// Finalize the INSERT: RETURNING → AFTER triggers → cache invalidation →
 	// store RETURNING → vacuum bookkeeping. Any error here is reported
 	// through the same rollback path so a RETURNING/trigger failure does
@@ -1397,7 +1459,7 @@ func (c *Catalog) insertLocked(ctx context.Context, stmt *query.InsertStmt, args
 		return rollbackInsertErr(err)
 	}
 
-	return lastAutoIncValue, rowsAffected, nil
+	return lastRowID, rowsAffected, nil
 }
 
 // prepareInsertRow is the per-row pre-flight for insertLocked. It performs
@@ -1444,6 +1506,7 @@ func (c *Catalog) prepareInsertRow(
 	// rowValues have been evaluated (the composite key is built from
 	// all PK column values together).
 	hasPrimaryKey := false
+	pk := insertPKValue{idx: -1}
 	if !compositePK {
 		for _, pkColName := range table.PrimaryKey {
 			// Find which valueRow index corresponds to this PK column.
@@ -1477,7 +1540,11 @@ func (c *Catalog) prepareInsertRow(
 			} else {
 				// Non-numeric primary key (TEXT, etc.)
 				val, evErr := evaluateExpression(c, nil, nil, valueRow[valueIdx], args)
+				if evErr == nil {
+					pk = insertPKValue{idx: valueIdx, val: val}
+				}
 				if evErr == nil && val != nil {
+					val = keyColumnAffinity(table, pkColName, val)
 					if strVal, ok := toString(val); ok {
 						key = "S:" + strVal // Prefix to distinguish from numeric keys
 					} else if fVal, ok := toFloat64(val); ok {
@@ -1507,7 +1574,7 @@ func (c *Catalog) prepareInsertRow(
 	} else {
 		rowValues = make([]interface{}, n)
 	}
-	if buildErr := c.buildInsertRow(table, insertColIndices, insertColumns, valueRow, args, autoIncValue, rowValues); buildErr != nil {
+	if buildErr := c.buildInsertRow(table, insertColIndices, insertColumns, valueRow, args, autoIncValue, pk, rowValues); buildErr != nil {
 		return nil, "", 0, false, buildErr
 	}
 
@@ -1582,6 +1649,7 @@ func (c *Catalog) finalizeInsert(
 		c.vacuumMu.Lock()
 		c.liveTuples[stmt.Table] += rowsAffected
 		c.vacuumMu.Unlock()
+		c.noteModifiedRows(stmt.Table, rowsAffected)
 	}
 
 	return nil
@@ -1616,6 +1684,9 @@ func (c *Catalog) applyInsertRowBuffered(
 	valueData []byte,
 	needsInsertedRows bool,
 ) (insertedRow []interface{}, skipRow bool, err error) {
+	if err := c.lockRow(ts, stmt.Table, key); err != nil {
+		return nil, false, err
+	}
 	// Check PK conflict against committed data AND buffered writes.
 	if skip, err := c.resolvePKConflict(tree, table, stmt, key); err != nil {
 		return nil, false, err
@@ -1626,7 +1697,7 @@ func (c *Catalog) applyInsertRowBuffered(
 		if stmt.ConflictAction == query.ConflictIgnore {
 			return nil, true, nil
 		}
-		return nil, false, fmt.Errorf("UNIQUE constraint failed: duplicate primary key value")
+		return nil, false, sqlerr.New(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate primary key value")
 	}
 
 	// Record the value we read (nil if absent, soft-deleted row if
@@ -1722,7 +1793,7 @@ func (c *Catalog) applyInsertRowDirect(
 			Type:  storage.WALInsert,
 			Data:  walData,
 		}
-		if appendErr := c.wal.Append(record); appendErr != nil {
+		if appendErr := c.appendRowWAL(ts, record); appendErr != nil {
 			return nil, stmtInsertEntry{}, false, appendErr
 		}
 	}
@@ -1781,6 +1852,9 @@ func (c *Catalog) applyInsertRowDirect(
 	if vErr := c.updateVectorIndexesForInsert(stmt.Table, rowValues, key); vErr != nil {
 		return nil, stmtInsertEntry{}, false, vErr
 	}
+	c.updateFTSIndexesForWrite(table, nil, []byte(key), rowValues)
+	c.updateGINIndexesForWrite(table, nil, []byte(key), rowValues)
+	c.updateBloomIndexesForWrite(table, nil, []byte(key), rowValues)
 
 	// Record undo log entry for rollback (after applying change).
 	if txnActive {
@@ -1814,14 +1888,17 @@ func (c *Catalog) applyInsertRowDirect(
 
 // convertSelectToValueRows executes the SELECT part of INSERT...SELECT and
 // converts the result rows into expression rows that the insert loop can process.
-func (c *Catalog) convertSelectToValueRows(stmt *query.InsertStmt, numCols int, args []interface{}) ([][]query.Expression, error) {
+// BLOB values have no literal form, so they are bound as extra arguments; the
+// returned args must be used to evaluate the rows.
+func (c *Catalog) convertSelectToValueRows(stmt *query.InsertStmt, numCols int, args []interface{}) ([][]query.Expression, []interface{}, error) {
 	selectCols, selectRows, err := c.selectLocked(stmt.Select, args)
 	if err != nil {
-		return nil, fmt.Errorf("INSERT...SELECT failed: %w", err)
+		return nil, nil, fmt.Errorf("INSERT...SELECT failed: %w", err)
 	}
 	if len(selectCols) != numCols {
-		return nil, fmt.Errorf("INSERT...SELECT column count mismatch: INSERT has %d columns, SELECT returns %d columns", numCols, len(selectCols))
+		return nil, nil, fmt.Errorf("INSERT...SELECT column count mismatch: INSERT has %d columns, SELECT returns %d columns", numCols, len(selectCols))
 	}
+	args = args[:len(args):len(args)] // appending must not write into the caller's slice
 	valueRows := make([][]query.Expression, len(selectRows))
 	for i, row := range selectRows {
 		exprRow := make([]query.Expression, len(row))
@@ -1839,13 +1916,16 @@ func (c *Catalog) convertSelectToValueRows(stmt *query.InsertStmt, numCols int,
 				exprRow[j] = &query.NumberLiteral{Value: float64(v), Raw: strconv.Itoa(v)}
 			case bool:
 				exprRow[j] = &query.BooleanLiteral{Value: v}
+			case []byte:
+				exprRow[j] = &query.PlaceholderExpr{Index: len(args)}
+				args = append(args, v)
 			default:
 				exprRow[j] = &query.StringLiteral{Value: ValueToStringKey(v)}
 			}
 		}
 		valueRows[i] = exprRow
 	}
-	return valueRows, nil
+	return valueRows, args, nil
 }
 
 // checkUniqueConstraints verifies UNIQUE constraints for a single row.
@@ -1861,7 +1941,7 @@ func (c *Catalog) insertRowIndexes(tree btree.TreeStore, table *TableDef, stmt *
 	txnActive := ts != nil && ts.txnActive
 	for idxName, idxTree := range c.indexTrees {
 		idxDef := c.indexes[idxName]
-		if idxDef.TableName != stmt.Table || len(idxDef.Columns) == 0 {
+		if idxDef.TableName != stmt.Table || len(idxDef.Columns) == 0 || c.skipDeferredIndex(stmt.Table, idxDef) {
 			continue
 		}
 		indexKey, ok := buildCompositeIndexKey(table, idxDef, rowValues)
@@ -1933,7 +2013,7 @@ func (c *Catalog) insertRowIndexes(tree btree.TreeStore, table *TableDef, stmt *
 						}
 					}
 				} else {
-					return idxChanges, skipRow, fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, idxName)
+					return idxChanges, skipRow, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, idxName)
 				}
 			}
 		}
@@ -1965,7 +2045,7 @@ func (c *Catalog) buildBufferedInsertIndexes(table *TableDef, stmt *query.Insert
 	var idxUpdates []PendingIndexUpdate
 	for idxName, idxTree := range c.indexTrees {
 		idxDef := c.indexes[idxName]
-		if idxDef.TableName != stmt.Table || len(idxDef.Columns) == 0 {
+		if idxDef.TableName != stmt.Table || len(idxDef.Columns) == 0 || c.skipDeferredIndex(stmt.Table, idxDef) {
 			continue
 		}
 		indexKey, ok := buildCompositeIndexKey(table, idxDef, rowValues)
@@ -1983,7 +2063,7 @@ func (c *Catalog) buildBufferedInsertIndexes(table *TableDef, stmt *query.Insert
 				if stmt.ConflictAction == query.ConflictIgnore {
 					return nil, true, nil
 				}
-				return nil, false, fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, idxName)
+				return nil, false, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", indexKey, idxName)
 			}
 		}
 		var idxStorageKey string
@@ -2013,9 +2093,9 @@ func (c *Catalog) checkUniqueConstraints(tree btree.TreeStore, table *TableDef,
 		colLower := strings.ToLower(col.Name)
 		found := false
 		for idxName, idxDef := range c.indexes {
-			if idxDef.TableName == stmt.Table && idxDef.Unique && len(idxDef.Columns) == 1 && strings.ToLower(idxDef.Columns[0]) == colLower {
+			if idxDef.TableName == stmt.Table && idxDef.Unique && idxDef.JSONPath == "" && len(idxDef.Columns) == 1 && strings.ToLower(idxDef.Columns[0]) == colLower {
 				if idxTree, ok := c.indexTrees[idxName]; ok {
-					idxKey := typeTaggedKey(rowValues[i])
+					idxKey := typeTaggedKey(collatedIndexValue(&table.Columns[i], rowValues[i]))
 					if pkData, err := idxTree.Get([]byte(idxKey)); err == nil {
 						duplicateKey = append([]byte(nil), pkData...)
 					}
@@ -2038,11 +2118,11 @@ func (c *Catalog) checkUniqueConstraints(tree btree.TreeStore, table *TableDef,
 						if !live {
 							continue
 						}
-						if len(existingRow) > i && compareValues(rowValues[i], existingRow[i]) == 0 {
+						if len(existingRow) > i && compareCollated(rowValues[i], existingRow[i], columnCollation(&table.Columns[i])) == 0 {
 							if stmt.ConflictAction == query.ConflictIgnore {
 								return true, nil
 							}
-							return false, fmt.Errorf("UNIQUE constraint failed: %s", col.Name)
+							return false, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: %s", col.Name)
 						}
 					}
 				}
@@ -2065,7 +2145,7 @@ func (c *Catalog) checkUniqueConstraints(tree btree.TreeStore, table *TableDef,
 				if !live {
 					continue
 				}
-				if len(existingRow) > i && compareValues(rowValues[i], existingRow[i]) == 0 {
+				if len(existingRow) > i && compareCollated(rowValues[i], existingRow[i], columnCollation(&table.Columns[i])) == 0 {
 					duplicateKey = k
 					break
 				}
@@ -2106,7 +2186,7 @@ func (c *Catalog) checkUniqueConstraints(tree btree.TreeStore, table *TableDef,
 					return false, fmt.Errorf("failed to delete duplicate row: %w", delErr)
 				}
 			} else {
-				return false, fmt.Errorf("UNIQUE constraint failed: %s", col.Name)
+				return false, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: %s", col.Name)
 			}
 		}
 	}
@@ -2149,7 +2229,7 @@ func (c *Catalog) validateRowAgainstConstraints(table *TableDef, rowValues []int
 	// NOT NULL — column-level.
 	for i, col := range table.Columns {
 		if col.NotNull && i < len(rowValues) && rowValues[i] == nil {
-			return fmt.Errorf("NOT NULL constraint failed: column '%s' cannot be null", col.Name)
+			return sqlerr.Errorf(sqlerr.CodeNotNullViolation, "NOT NULL constraint failed: column '%s' cannot be null", col.Name)
 		}
 	}
 	// CHECK — column-level + table-level (NULL passes, false fails).
@@ -2171,7 +2251,7 @@ func (c *Catalog) validateRowNonFKConstraints(table *TableDef, rowValues []inter
 	// NOT NULL — column-level.
 	for i, col := range table.Columns {
 		if col.NotNull && i < len(rowValues) && rowValues[i] == nil {
-			return fmt.Errorf("NOT NULL constraint failed: column '%s' cannot be null", col.Name)
+			return sqlerr.Errorf(sqlerr.CodeNotNullViolation, "NOT NULL constraint failed: column '%s' cannot be null", col.Name)
 		}
 	}
 	// CHECK — column-level + table-level.
@@ -2185,11 +2265,11 @@ func (c *Catalog) checkRowConstraints(table *TableDef, rowValues []interface{},
 		}
 		result, err := evaluateExpression(c, rowValues, table.Columns, col.Check, args)
 		if err != nil {
-			return fmt.Errorf("CHECK constraint failed: %w", err)
+			return sqlerr.Errorf(sqlerr.CodeCheckViolation, "CHECK constraint failed: %w", err)
 		}
 		if result != nil {
 			if resultBool, ok := result.(bool); ok && !resultBool {
-				return fmt.Errorf("CHECK constraint failed for column: %s", col.Name)
+				return sqlerr.Errorf(sqlerr.CodeCheckViolation, "CHECK constraint failed for column: %s", col.Name)
 			}
 		}
 	}
@@ -2199,14 +2279,14 @@ func (c *Catalog) checkRowConstraints(table *TableDef, rowValues []interface{},
 		}
 		result, err := evaluateExpression(c, rowValues, table.Columns, check.Check, args)
 		if err != nil {
-			return fmt.Errorf("CHECK constraint failed: %w", err)
+			return sqlerr.Errorf(sqlerr.CodeCheckViolation, "CHECK constraint failed: %w", err)
 		}
 		if result != nil {
 			if resultBool, ok := result.(bool); ok && !resultBool {
 				if check.Name != "" {
-					return fmt.Errorf("CHECK constraint failed: %s", check.Name)
+					return sqlerr.Errorf(sqlerr.CodeCheckViolation, "CHECK constraint failed: %s", check.Name)
 				}
-				return fmt.Errorf("CHECK constraint failed")
+				return sqlerr.ErrConstraintCheck
 			}
 		}
 	}
@@ -2217,17 +2297,17 @@ func (c *Catalog) checkRowConstraints(table *TableDef, rowValues []interface{},
 // NULL values skip FK checking per SQL standard.
 func (c *Catalog) checkForeignKeyConstraints(table *TableDef, rowValues []interface{}, ts *catalogTxnState) error {
 	fke := NewForeignKeyEnforcer(c)
-	for _, fk := range table.ForeignKeys {
+	for _, fk := range c.enforcedForeignKeys(table) {
 		fkValues, skip := foreignKeyValuesForRow(table, fk, rowValues)
 		if skip {
 			continue
 		}
 		found, err := fke.referencedRowExists(fk.ReferencedTable, fk.ReferencedColumns, fkValues)
 		if err != nil {
-			return fmt.Errorf("FOREIGN KEY constraint failed: failed to decode referenced row in table %s: %w", fk.ReferencedTable, err)
+			return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: failed to decode referenced row in table %s: %w", fk.ReferencedTable, err)
 		}
 		if !found {
-			return fmt.Errorf("FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
+			return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
 		}
 	}
 	return nil
@@ -2316,7 +2396,7 @@ func (c *Catalog) getInsertTargetTree(table *TableDef, stmt *query.InsertStmt, a
 	if partitionVal == nil {
 		// Check if the partition column has a DEFAULT expression.
 		if partitionColIdx >= 0 && partitionColIdx < len(table.Columns) && table.Columns[partitionColIdx].defaultExpr != nil {
-			if defVal, err := EvalExpression(table.Columns[partitionColIdx].defaultExpr, args); err == nil {
+			if defVal, err := c.evalColumnDefault(table.Columns[partitionColIdx].defaultExpr, args); err == nil {
 				partitionVal = defVal
 			}
 		}
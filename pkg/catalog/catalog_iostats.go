@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// StatementStats collects the I/O a statement performs, per table. It is
+// filled in by the catalog while the statement runs under
+// StatementLimits.Stats and must not be read until the statement ends.
+//
+// Pages fetched by parallel scan workers run on other goroutines and are
+// not counted; rows and bytes are.
+type StatementStats struct {
+	Tables     []*TableIOStats  // In the order the statement first touched them
+	Operators  []*OperatorStats // In the order the steps first ran
+	PagesHit   uint64           // All pages found in the buffer pool
+	PagesRead  uint64           // All pages read from the storage backend
+	SpillFiles int64            // Temporary files sorts and grouping spilled rows to
+	SpillBytes int64            // Bytes written to them
+}
+
+// TableIOStats counts the work done reading one table.
+type TableIOStats struct {
+	Table        string
+	PagesHit     uint64 // Pages found in the buffer pool
+	PagesRead    uint64 // Pages read from the storage backend
+	RowsScanned  int64  // Stored rows fetched, before any filtering
+	BytesDecoded int64  // Encoded size of the rows fetched
+	IndexEntries int64  // Index entries visited to find rows
+}
+
+// Table returns the counters for the named table, or nil if the statement
+// did not read it.
+func (s *StatementStats) Table(name string) *TableIOStats {
+	if s == nil {
+		return nil
+	}
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Table, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *StatementStats) table(name string) *TableIOStats {
+	if t := s.Table(name); t != nil {
+		return t
+	}
+	t := &TableIOStats{Table: name}
+	s.Tables = append(s.Tables, t)
+	return t
+}
+
+// Steps of a SELECT that OperatorStats reports.
+const (
+	OperatorScan      = "Scan"      // Reading a table; Rows counts the rows kept
+	OperatorFilter    = "Filter"    // Applying WHERE
+	OperatorJoin      = "Join"      // Joining a table to the rows so far
+	OperatorAggregate = "Aggregate" // Grouping and computing aggregates
+	OperatorSort      = "Sort"      // ORDER BY
+	OperatorLimit     = "Limit"     // OFFSET and LIMIT
+)
+
+// OperatorStats counts what one step of a SELECT actually did. Steps of
+// subqueries are merged into the outer query's steps of the same kind and
+// table, so they show up as extra loops.
+type OperatorStats struct {
+	Operator string // One of the Operator constants
+	Table    string // Table scanned or joined ("" for other steps)
+	Loops    int64  // Times the step ran
+	Rows     int64  // Rows it produced over all loops
+	// Elapsed is the time spent in the step over all loops. A filter
+	// evaluated during a scan shares the scan's time.
+	Elapsed time.Duration
+}
+
+// Operator returns the counters for a step, or nil if it was never started.
+func (s *StatementStats) Operator(op, table string) *OperatorStats {
+	if s == nil {
+		return nil
+	}
+	for _, o := range s.Operators {
+		if o.Operator == op && strings.EqualFold(o.Table, table) {
+			return o
+		}
+	}
+	return nil
+}
+
+// beginOperator times one run of a step; end records the rows it
+// produced. Without stats collection it is a no-op.
+func (b *statementBudget) beginOperator(op, table string) (end func(rows int)) {
+	if b == nil || b.limits.Stats == nil {
+		return func(int) {}
+	}
+	s := b.limits.Stats
+	o := s.Operator(op, table)
+	if o == nil {
+		o = &OperatorStats{Operator: op, Table: table}
+		s.Operators = append(s.Operators, o)
+	}
+	start := time.Now()
+	return func(rows int) {
+		o.Loops++
+		o.Rows += int64(rows)
+		o.Elapsed += time.Since(start)
+	}
+}
+
+// beginScan times a scan of table that applies the WHERE clause as it
+// reads, recording the scan and, if filtered, the filter.
+func (b *statementBudget) beginScan(table string, filtered bool) (end func(rows int)) {
+	endScan := b.beginOperator(OperatorScan, table)
+	if !filtered {
+		return endScan
+	}
+	endFilter := b.beginOperator(OperatorFilter, "")
+	return func(rows int) {
+		endScan(rows)
+		endFilter(rows)
+	}
+}
+
+// beginLimit times the OFFSET/LIMIT step of stmt if it has a LIMIT.
+func (b *statementBudget) beginLimit(stmt *query.SelectStmt) (end func(rows int)) {
+	if stmt.Limit == nil {
+		return func(int) {}
+	}
+	return b.beginOperator(OperatorLimit, "")
+}
+
+// addRow counts one stored row of n encoded bytes. The nil counters ignore it.
+func (t *TableIOStats) addRow(n int) {
+	if t != nil {
+		t.RowsScanned++
+		t.BytesDecoded += int64(n)
+	}
+}
+
+// addIndexEntries counts n index entries visited.
+func (t *TableIOStats) addIndexEntries(n int) {
+	if t != nil {
+		t.IndexEntries += int64(n)
+	}
+}
+
+// beginTableIO returns the counters for table and attributes the pages the
+// goroutine fetches until end is called to it. Pages fetched inside a nested
+// beginTableIO go to the inner table only. Without stats collection both
+// results are no-ops.
+func (b *statementBudget) beginTableIO(table string) (io *TableIOStats, end func()) {
+	if b == nil || b.limits.Stats == nil {
+		return nil, func() {}
+	}
+	io = b.limits.Stats.table(table)
+	startPages, startClaimed := b.pages, b.claimed
+	return io, func() {
+		hits := (b.pages.Hits - startPages.Hits) - (b.claimed.Hits - startClaimed.Hits)
+		reads := (b.pages.Reads - startPages.Reads) - (b.claimed.Reads - startClaimed.Reads)
+		io.PagesHit += hits
+		io.PagesRead += reads
+		b.claimed.Hits += hits
+		b.claimed.Reads += reads
+	}
+}
@@ -0,0 +1,39 @@
+package catalog
+
+import "testing"
+
+func TestBeginTableIOAttribution(t *testing.T) {
+	var nilBudget *statementBudget
+	if io, end := nilBudget.beginTableIO("t"); io != nil {
+		t.Fatal("no stats should be collected without a budget")
+	} else {
+		io.addRow(10)
+		io.addIndexEntries(1)
+		end()
+	}
+
+	stats := &StatementStats{}
+	b := &statementBudget{limits: StatementLimits{Stats: stats}}
+	outer, endOuter := b.beginTableIO("Orders")
+	b.pages.Hits += 2
+	inner, endInner := b.beginTableIO("customers")
+	b.pages.Hits++
+	b.pages.Reads += 3
+	inner.addRow(40)
+	endInner()
+	b.pages.Reads++
+	outer.addRow(100)
+	outer.addIndexEntries(5)
+	endOuter()
+
+	if got := stats.Table("orders"); got != outer || got.PagesHit != 2 || got.PagesRead != 1 ||
+		got.RowsScanned != 1 || got.BytesDecoded != 100 || got.IndexEntries != 5 {
+		t.Errorf("orders = %+v", got)
+	}
+	if got := stats.Table("CUSTOMERS"); got != inner || got.PagesHit != 1 || got.PagesRead != 3 {
+		t.Errorf("customers = %+v", got)
+	}
+	if len(stats.Tables) != 2 || stats.Tables[0].Table != "Orders" || stats.Table("missing") != nil {
+		t.Errorf("tables = %+v", stats.Tables)
+	}
+}
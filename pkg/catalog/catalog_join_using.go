@@ -0,0 +1,234 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// usingOutputCol is one column of the rows a chain of joins produces, as
+// SELECT * lists it.
+type usingOutputCol struct {
+	name string
+	expr query.Expression
+}
+
+// resolveJoinUsing rewrites JOIN ... USING (cols) and NATURAL JOIN into
+// joins with an explicit ON condition, so every join path sees an ordinary
+// equality join. A USING column appears once in SELECT *, ahead of the other
+// columns, and an unqualified reference to it means the merged column: the
+// left side's value, the right side's for a RIGHT JOIN, or the first that is
+// not NULL for a FULL JOIN. NATURAL joins on every column name the two sides
+// share, and is a cross join when they share none.
+//
+// A USING column missing from either side is an error. Statements whose
+// tables' columns cannot be known before they run, such as a derived table
+// selecting *, are returned unchanged.
+func (cat *Catalog) resolveJoinUsing(stmt *query.SelectStmt) (*query.SelectStmt, error) {
+	needed := false
+	for _, join := range stmt.Joins {
+		if join.Natural || len(join.Using) > 0 {
+			needed = true
+			break
+		}
+	}
+	if !needed || stmt.From == nil {
+		return stmt, nil
+	}
+
+	fromCols, ok := cat.joinSourceColumnNames(stmt.From)
+	if !ok {
+		return stmt, nil
+	}
+	out := usingSourceColumns(joinRefName(stmt.From), fromCols)
+	merged := make(map[string]query.Expression)
+	joins := make([]*query.JoinClause, len(stmt.Joins))
+
+	for i, join := range stmt.Joins {
+		joins[i] = join
+		rightCols, ok := cat.joinSourceColumnNames(join.Table)
+		if !ok {
+			return stmt, nil
+		}
+		rightName := joinRefName(join.Table)
+		right := usingSourceColumns(rightName, rightCols)
+		if !join.Natural && len(join.Using) == 0 {
+			out = append(out, right...)
+			continue
+		}
+
+		using := join.Using
+		if join.Natural && len(using) == 0 {
+			for _, rc := range right {
+				if findOutputCol(out, rc.name) >= 0 {
+					using = append(using, rc.name)
+				}
+			}
+		}
+
+		var cond query.Expression
+		usingSet := make(map[string]bool, len(using))
+		var mergedCols []usingOutputCol
+		for _, name := range using {
+			key := toLowerFast(name)
+			if usingSet[key] {
+				return nil, fmt.Errorf("column %s appears more than once in USING clause", name)
+			}
+			usingSet[key] = true
+			li := findOutputCol(out, name)
+			if li < 0 {
+				return nil, fmt.Errorf("column %s specified in USING clause does not exist in left table", name)
+			}
+			if findOutputCol(out[li+1:], name) >= 0 {
+				return nil, fmt.Errorf("common column name %s appears more than once in left table", name)
+			}
+			ri := findOutputCol(right, name)
+			if ri < 0 {
+				return nil, fmt.Errorf("column %s specified in USING clause does not exist in right table %s", name, rightName)
+			}
+			left, rightExpr := out[li].expr, right[ri].expr
+			eq := &query.BinaryExpr{Left: left, Operator: query.TokenEq, Right: rightExpr}
+			if cond == nil {
+				cond = eq
+			} else {
+				cond = &query.BinaryExpr{Left: cond, Operator: query.TokenAnd, Right: eq}
+			}
+
+			var expr query.Expression
+			switch join.Type {
+			case query.TokenRight:
+				expr = rightExpr
+			case query.TokenFull:
+				expr = &query.FunctionCall{Name: "COALESCE", Args: []query.Expression{left, rightExpr}}
+			default:
+				expr = left
+			}
+			merged[key] = expr
+			mergedCols = append(mergedCols, usingOutputCol{name: out[li].name, expr: expr})
+		}
+
+		// The join's columns are its USING columns, then the rest of each
+		// side in order.
+		next := mergedCols
+		for _, col := range out {
+			if !usingSet[toLowerFast(col.name)] {
+				next = append(next, col)
+			}
+		}
+		for _, col := range right {
+			if !usingSet[toLowerFast(col.name)] {
+				next = append(next, col)
+			}
+		}
+		out = next
+
+		resolved := *join
+		resolved.Condition = cond
+		resolved.Using = nil
+		resolved.Natural = false
+		if cond == nil && (join.Type == query.TokenInner || join.Type == query.TokenJoin) {
+			resolved.Type = query.TokenCross
+		}
+		joins[i] = &resolved
+	}
+
+	result := *stmt
+	result.Joins = joins
+
+	// An unqualified * lists the merged columns once; other columns keep
+	// their table so a name the tables share still finds its own value.
+	var columns []query.Expression
+	for _, col := range stmt.Columns {
+		if star, ok := col.(*query.StarExpr); ok && star.Table == "" {
+			for _, oc := range out {
+				columns = append(columns, &query.AliasExpr{Expr: oc.expr, Alias: oc.name})
+			}
+			continue
+		}
+		if id, ok := col.(*query.Identifier); ok {
+			if expr, ok := merged[toLowerFast(id.Name)]; ok {
+				columns = append(columns, &query.AliasExpr{Expr: expr, Alias: id.Name})
+				continue
+			}
+		}
+		columns = append(columns, substituteSelectAliases(col, merged))
+	}
+	result.Columns = columns
+
+	if stmt.Where != nil {
+		result.Where = substituteSelectAliases(stmt.Where, merged)
+	}
+	if stmt.Having != nil {
+		result.Having = substituteSelectAliases(stmt.Having, merged)
+	}
+	if len(stmt.GroupBy) > 0 {
+		result.GroupBy = make([]query.Expression, len(stmt.GroupBy))
+		for i, gb := range stmt.GroupBy {
+			result.GroupBy[i] = substituteSelectAliases(gb, merged)
+		}
+	}
+	// ORDER BY names match the output columns, which now carry the merged
+	// values; only expressions over them need rewriting.
+	if len(stmt.OrderBy) > 0 {
+		result.OrderBy = make([]*query.OrderByExpr, len(stmt.OrderBy))
+		for i, ob := range stmt.OrderBy {
+			result.OrderBy[i] = ob
+			if _, ok := ob.Expr.(*query.Identifier); ok {
+				continue
+			}
+			if expr := substituteSelectAliases(ob.Expr, merged); expr != ob.Expr {
+				copied := *ob
+				copied.Expr = expr
+				result.OrderBy[i] = &copied
+			}
+		}
+	}
+	return &result, nil
+}
+
+// joinSourceColumnNames returns the column names of a table, view, CTE or
+// derived table in a FROM clause, or false if they are not known yet.
+func (cat *Catalog) joinSourceColumnNames(ref *query.TableRef) ([]string, bool) {
+	if ref.Subquery != nil || ref.SubqueryStmt != nil {
+		if res, ok := cat.cteResults[toLowerFast(ref.Alias)]; ok {
+			return res.columns, true
+		}
+		if ref.Subquery == nil {
+			return nil, false
+		}
+	}
+	def, ok := cat.resolveJoinTableDef(ref)
+	if !ok {
+		return nil, false
+	}
+	names := make([]string, def.storedColumnCount())
+	for i := range names {
+		names[i] = def.Columns[i].Name
+	}
+	return names, true
+}
+
+func joinRefName(ref *query.TableRef) string {
+	if ref.Alias != "" {
+		return ref.Alias
+	}
+	return ref.Name
+}
+
+func usingSourceColumns(table string, names []string) []usingOutputCol {
+	cols := make([]usingOutputCol, len(names))
+	for i, name := range names {
+		cols[i] = usingOutputCol{name: name, expr: &query.QualifiedIdentifier{Table: table, Column: name}}
+	}
+	return cols
+}
+
+func findOutputCol(cols []usingOutputCol, name string) int {
+	for i, col := range cols {
+		if strings.EqualFold(col.name, name) {
+			return i
+		}
+	}
+	return -1
+}
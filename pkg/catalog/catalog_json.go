@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
 )
 
 func (c *Catalog) CreateJSONIndex(name, tableName, column, path, dataType string) error {
@@ -241,6 +244,99 @@ func (c *Catalog) indexJSONValue(idx *JSONIndexDef, value interface{}, rowNum in
 	}
 }
 
+// jsonPathIndexKey returns the index key of the value at path in doc, or
+// false when the path selects nothing. Keys use jsonIndexText, so an index on
+// JSON_EXTRACT(col, path) serves ->, ->> and JSON_EXTRACT lookups alike.
+func jsonPathIndexKey(doc interface{}, path string) (string, bool) {
+	text, ok := toString(doc)
+	if !ok {
+		text = ValueToStringKey(doc)
+	}
+	v, err := JSONExtract(text, path)
+	if err != nil || v == nil {
+		return "", false
+	}
+	return typeTaggedKey(jsonIndexText(v)), true
+}
+
+// jsonIndexText folds a JSON scalar or a search value into the text form
+// used by JSON path index keys. Numbers and numeric strings share one form,
+// so the index may return extra candidates; callers re-check the WHERE clause.
+func jsonIndexText(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+			return float64Key(f)
+		}
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(val); err == nil {
+			return string(b)
+		}
+	}
+	if f, ok := toFloat64(v); ok {
+		return float64Key(f)
+	}
+	return ValueToStringKey(v)
+}
+
+// jsonPathOperand matches JSON_EXTRACT(col, 'path'), col->'path' and
+// col->>'path'.
+func jsonPathOperand(expr query.Expression) (string, string, bool) {
+	switch e := expr.(type) {
+	case *query.JSONPathExpr:
+		if col, ok := e.Column.(*query.Identifier); ok {
+			return col.Name, e.Path, true
+		}
+	case *query.FunctionCall:
+		if e.Name == "JSON_EXTRACT" && len(e.Args) == 2 {
+			col, colOK := e.Args[0].(*query.Identifier)
+			path, pathOK := e.Args[1].(*query.StringLiteral)
+			if colOK && pathOK {
+				return col.Name, path.Value, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// findJSONPathIndex looks for an active JSON path index serving
+// "target = value" where target extracts a path from a column.
+func (c *Catalog) findJSONPathIndex(tableName string, target, value query.Expression, args []interface{}) (string, string, interface{}) {
+	colName, path, ok := jsonPathOperand(target)
+	if !ok {
+		return "", "", nil
+	}
+	searchVal := c.extractLiteralValue(value, args)
+	if searchVal == nil {
+		return "", "", nil
+	}
+	for idxName, idxDef := range c.indexes {
+		if idxDef.Status != IndexActive || idxDef.TableName != tableName || idxDef.JSONPath == "" ||
+			len(idxDef.Columns) != 1 || !strings.EqualFold(idxDef.Columns[0], colName) {
+			continue
+		}
+		if sameJSONPath(idxDef.JSONPath, path) {
+			return idxName, colName, jsonIndexText(searchVal)
+		}
+	}
+	return "", "", nil
+}
+
+func sameJSONPath(a, b string) bool {
+	pa, err := getCachedJSONPath(a)
+	if err != nil {
+		return false
+	}
+	pb, err := getCachedJSONPath(b)
+	if err != nil {
+		return false
+	}
+	return slices.Equal(pa.Segments, pb.Segments)
+}
+
 func (c *Catalog) DropJSONIndex(name string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,361 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// ErrLimitExceeded is returned, wrapped in *LimitExceededError, when a
+// statement breaches one of its resource limits.
+var ErrLimitExceeded = errors.New("statement limit exceeded")
+
+// Statement limit names reported by LimitExceededError.
+const (
+	LimitMaxExecutionTime = "max_execution_time"
+	LimitMaxMemory        = "max_memory"
+)
+
+// LimitExceededError reports which limit a statement breached.
+type LimitExceededError struct {
+	Limit string // One of the Limit* names
+	Max   int64  // The limit: nanoseconds or bytes
+	Err   error  // Underlying cause such as context.DeadlineExceeded, or nil
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Limit == LimitMaxExecutionTime {
+		return fmt.Sprintf("%v: %s of %v", ErrLimitExceeded, e.Limit, time.Duration(e.Max))
+	}
+	return fmt.Sprintf("%v: %s of %d bytes", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+// Unwrap lets errors.Is match both ErrLimitExceeded and the cause.
+func (e *LimitExceededError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrLimitExceeded, e.Err}
+	}
+	return []error{ErrLimitExceeded}
+}
+
+// StatementLimits bounds the work a statement does inside the catalog.
+type StatementLimits struct {
+	Ctx       context.Context // Stops the statement once done (nil = never)
+	MaxMemory int64           // Bytes sorts, joins and grouping may hold (0 = unlimited)
+	// SpillMemory is the bytes of rows a sort or table GROUP BY holds before
+	// moving them to temporary files in SpillDir ("" = os.TempDir()). A
+	// spilling step is charged against MaxMemory only for what it holds.
+	// 0 never spills.
+	SpillMemory int64
+	SpillDir    string
+	Stats       *StatementStats // Receives per-table I/O counters (nil = not collected)
+	Rows        RowSink         // Receives the rows of a SELECT as they are scanned (nil = returned as a slice)
+	Session     *Session        // Connection state read by LAST_INSERT_ROWID() (nil = none)
+}
+
+// budgetCheckInterval is how many rows pass between context checks.
+const budgetCheckInterval = 256
+
+// statementBudget tracks one statement's use of its limits. It belongs to
+// the goroutine running the statement, so it needs no locking.
+type statementBudget struct {
+	limits StatementLimits
+	used   int64
+	ticks  int
+	err    error
+	prev   *statementBudget // budget of the enclosing statement, if nested
+
+	// Page accounting for limits.Stats; see beginTableIO.
+	pages   storage.PageTrace
+	claimed storage.PageTrace
+
+	docs   jsonDocCache                         // JSON documents parsed by the statement
+	inSets map[*query.SelectStmt]*inSubquerySet // uncorrelated IN subquery results
+
+	rowsTaken bool // limits.Rows was handed to the statement's SELECT
+
+	shared *sharedBudget // set on the budgets of pool workers; see sharedBudget
+}
+
+// BeginStatement applies limits to the catalog work the calling goroutine
+// does until end is called. end returns the error that stopped the statement
+// early, if any; the statement's result is incomplete and must be discarded.
+// State kept for the length of one statement, such as parsed JSON documents,
+// lives until end as well, so it is worth calling even without limits.
+func (c *Catalog) BeginStatement(limits StatementLimits) (end func() error) {
+	gid := goroutineID()
+	b := &statementBudget{limits: limits}
+	if prev, ok := c.stmtBudgets.Load(gid); ok {
+		b.prev = prev.(*statementBudget)
+	} else {
+		c.activeBudgets.Add(1)
+	}
+	c.stmtBudgets.Store(gid, b)
+	stopTrace := func() {}
+	if limits.Stats != nil && c.pool != nil {
+		stopTrace = c.pool.TracePages(&b.pages)
+	}
+	return func() error {
+		stopTrace()
+		if limits.Stats != nil {
+			limits.Stats.PagesHit += b.pages.Hits
+			limits.Stats.PagesRead += b.pages.Reads
+		}
+		if b.prev != nil {
+			c.stmtBudgets.Store(gid, b.prev)
+		} else {
+			c.stmtBudgets.Delete(gid)
+			c.activeBudgets.Add(-1)
+		}
+		return b.err
+	}
+}
+
+// budget returns the calling goroutine's statement budget, or nil. The nil
+// budget allows everything.
+func (c *Catalog) budget() *statementBudget {
+	if c == nil || c.activeBudgets.Load() == 0 {
+		return nil
+	}
+	if b, ok := c.stmtBudgets.Load(goroutineID()); ok {
+		return b.(*statementBudget)
+	}
+	return nil
+}
+
+// alive reports whether the statement may go on, checking its context every
+// budgetCheckInterval calls.
+func (b *statementBudget) alive() bool {
+	return b.aliveAfter(1)
+}
+
+// aliveAfter is alive for n rows of work at once. The context is checked
+// whenever the running count crosses a multiple of budgetCheckInterval, so
+// a caller reporting rows in blocks is checked as often as one reporting
+// them singly.
+func (b *statementBudget) aliveAfter(n int) bool {
+	if b == nil {
+		return true
+	}
+	if b.err != nil {
+		return false
+	}
+	if b.shared != nil && b.shared.stop.Load() {
+		b.err = b.shared.failure()
+		return false
+	}
+	if b.limits.Ctx == nil {
+		return true
+	}
+	before := b.ticks / budgetCheckInterval
+	b.ticks += max(n, 1)
+	if b.ticks/budgetCheckInterval != before {
+		if err := b.limits.Ctx.Err(); err != nil {
+			b.err = err
+			return false
+		}
+	}
+	return true
+}
+
+// chargeMemory adds n bytes to the statement's memory use and reports
+// whether it is still within MaxMemory.
+func (b *statementBudget) chargeMemory(n int64) bool {
+	if b.limits.MaxMemory <= 0 {
+		return true
+	}
+	b.used += n
+	used := b.used
+	if s := b.shared; s != nil {
+		used = s.parent.used + s.used.Add(n)
+	}
+	if used > b.limits.MaxMemory {
+		b.err = &LimitExceededError{Limit: LimitMaxMemory, Max: b.limits.MaxMemory}
+		return false
+	}
+	return true
+}
+
+// chargeRows accounts for rows a sort, join or grouping materializes and
+// reports whether the statement may go on. Rows that pass through several
+// such steps are charged by each, so the estimate errs high.
+func (b *statementBudget) chargeRows(rows [][]interface{}) bool {
+	if b == nil {
+		return true
+	}
+	if b.err != nil {
+		return false
+	}
+	if b.limits.MaxMemory > 0 {
+		var n int64
+		for _, row := range rows {
+			n += rowFootprint(row)
+		}
+		if !b.chargeMemory(n) {
+			return false
+		}
+	}
+	return b.aliveAfter(len(rows))
+}
+
+// chargeRow is chargeRows for a single row.
+func (b *statementBudget) chargeRow(row []interface{}) bool {
+	if b == nil {
+		return true
+	}
+	if b.err != nil {
+		return false
+	}
+	if b.limits.MaxMemory > 0 && !b.chargeMemory(rowFootprint(row)) {
+		return false
+	}
+	return b.alive()
+}
+
+// chargeBytes accounts for n bytes a spilling step holds and reports whether
+// the statement may go on.
+func (b *statementBudget) chargeBytes(n int64) bool {
+	if b == nil {
+		return true
+	}
+	if b.err != nil {
+		return false
+	}
+	if !b.chargeMemory(n) {
+		return false
+	}
+	return b.alive()
+}
+
+// fail stops the statement with err unless it already stopped.
+func (b *statementBudget) fail(err error) {
+	if b != nil && b.err == nil {
+		b.err = err
+	}
+}
+
+// sharedBudget lets the pool workers of a partitioned scan or join work
+// under the budget of the statement that started them. A statementBudget
+// belongs to one goroutine, so each worker gets one of its own with the
+// statement's limits; their memory charges add up here and the first error
+// any of them hits stops the rest.
+type sharedBudget struct {
+	c      *Catalog
+	parent *statementBudget
+	used   atomic.Int64 // bytes the workers charged on top of parent.used
+	stop   atomic.Bool
+
+	mu  sync.Mutex
+	err error
+}
+
+// share returns a sharedBudget for workers started by the statement b
+// belongs to. b may be nil; the workers then run without limits but still
+// stop on the first error.
+func (b *statementBudget) share(c *Catalog) *sharedBudget {
+	return &sharedBudget{c: c, parent: b}
+}
+
+// run calls fn on the calling goroutine with a worker budget, which is also
+// registered for the goroutine so catalog calls made by fn find it. An error
+// from fn or from the worker budget stops every worker.
+func (s *sharedBudget) run(fn func(b *statementBudget) error) {
+	if s.stop.Load() {
+		return
+	}
+	b := &statementBudget{shared: s}
+	if s.parent != nil {
+		b.limits = StatementLimits{
+			Ctx:         s.parent.limits.Ctx,
+			MaxMemory:   s.parent.limits.MaxMemory,
+			SpillMemory: s.parent.limits.SpillMemory,
+			SpillDir:    s.parent.limits.SpillDir,
+			Session:     s.parent.limits.Session,
+		}
+		// Tasks the pool has no free worker for run on the statement's own
+		// goroutine, so its budget is put back afterwards.
+		gid := goroutineID()
+		prev, hadPrev := s.c.stmtBudgets.Load(gid)
+		if !hadPrev {
+			s.c.activeBudgets.Add(1)
+		}
+		s.c.stmtBudgets.Store(gid, b)
+		defer func() {
+			if hadPrev {
+				s.c.stmtBudgets.Store(gid, prev)
+			} else {
+				s.c.stmtBudgets.Delete(gid)
+				s.c.activeBudgets.Add(-1)
+			}
+		}()
+	}
+	err := fn(b)
+	if err == nil {
+		err = b.err
+	}
+	if err != nil {
+		s.fail(err)
+	}
+}
+
+// fail stops every worker with err unless they already stopped.
+func (s *sharedBudget) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.stop.Store(true)
+}
+
+// failure returns the error that stopped the workers.
+func (s *sharedBudget) failure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// settle folds the workers' charges and first error into the statement's
+// budget once they have all returned. It returns that error, or nil.
+func (s *sharedBudget) settle() error {
+	if s.parent != nil {
+		s.parent.used += s.used.Load()
+		if s.err != nil {
+			s.parent.fail(s.err)
+		}
+	}
+	return s.err
+}
+
+// rowFootprint estimates the heap bytes a materialized row occupies: the
+// slice, one interface per value and the bytes of strings and blobs.
+func rowFootprint(row []interface{}) int64 {
+	size := int64(24 + 16*len(row))
+	for _, value := range row {
+		switch v := value.(type) {
+		case string:
+			size += int64(len(v))
+		case []byte:
+			size += int64(len(v))
+		}
+	}
+	return size
+}
+
+// capRows limits a preallocation of n rows to what the memory budget could
+// hold, so a large estimate cannot allocate past the limit up front.
+func (b *statementBudget) capRows(n int) int {
+	if b == nil || b.limits.MaxMemory <= 0 {
+		return n
+	}
+	if most := b.limits.MaxMemory / 24; int64(n) > most {
+		return int(most)
+	}
+	return n
+}
@@ -0,0 +1,116 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStatementBudget(t *testing.T) {
+	c := &Catalog{}
+	if c.budget() != nil {
+		t.Fatal("budget outside a statement should be nil")
+	}
+	var nilBudget *statementBudget
+	if !nilBudget.chargeRows([][]interface{}{{1, "x"}}) || !nilBudget.alive() {
+		t.Fatal("the nil budget should allow everything")
+	}
+
+	endOuter := c.BeginStatement(StatementLimits{MaxMemory: 1 << 20})
+	outer := c.budget()
+	endInner := c.BeginStatement(StatementLimits{MaxMemory: 100})
+	inner := c.budget()
+	if inner == outer || inner == nil {
+		t.Fatal("a nested statement should get its own budget")
+	}
+	row := []interface{}{int64(1), "0123456789"}
+	if !inner.chargeRow(row) { // 24 + 32 + 10 bytes
+		t.Fatal("first row should fit in 100 bytes")
+	}
+	if inner.chargeRow(row) {
+		t.Fatal("second row should exceed 100 bytes")
+	}
+	err := endInner()
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitMaxMemory || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("inner end() = %v, want a max_memory LimitExceededError", err)
+	}
+	if c.budget() != outer {
+		t.Fatal("ending the nested statement should restore the outer budget")
+	}
+	if err := endOuter(); err != nil {
+		t.Fatalf("outer end() = %v", err)
+	}
+	if c.budget() != nil || c.activeBudgets.Load() != 0 {
+		t.Fatal("budget should be cleared after the statement ends")
+	}
+	if got := (&statementBudget{limits: StatementLimits{MaxMemory: 240}}).capRows(1000); got != 10 {
+		t.Fatalf("capRows = %d, want 10", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	end := c.BeginStatement(StatementLimits{Ctx: ctx})
+	b := c.budget()
+	alive := true
+	for i := 0; i < budgetCheckInterval && alive; i++ {
+		alive = b.alive()
+	}
+	if alive {
+		t.Fatal("budget should notice the canceled context")
+	}
+	if err := end(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("end() = %v, want context.Canceled", err)
+	}
+}
+
+func TestSharedBudget(t *testing.T) {
+	c := &Catalog{}
+	end := c.BeginStatement(StatementLimits{MaxMemory: 1000})
+	parent := c.budget()
+	shared := parent.share(c)
+	shared.run(func(b *statementBudget) error {
+		if c.budget() != b || b == parent {
+			t.Fatal("a worker should run under its own registered budget")
+		}
+		if !b.chargeMemory(600) {
+			t.Fatal("600 bytes should fit in 1000")
+		}
+		return nil
+	})
+	if c.budget() != parent {
+		t.Fatal("run should restore the caller's budget")
+	}
+	ran := false
+	shared.run(func(b *statementBudget) error {
+		ran = true
+		if b.chargeMemory(600) {
+			t.Fatal("workers should share the statement's memory limit")
+		}
+		return nil
+	})
+	if !ran {
+		t.Fatal("the second worker should have run")
+	}
+	shared.run(func(*statementBudget) error {
+		t.Fatal("workers should not start once another has failed")
+		return nil
+	})
+	var limitErr *LimitExceededError
+	if err := shared.settle(); !errors.As(err, &limitErr) || limitErr.Limit != LimitMaxMemory {
+		t.Fatalf("settle() = %v, want a max_memory LimitExceededError", err)
+	}
+	if parent.used != 1200 {
+		t.Fatalf("parent used = %d, want 1200", parent.used)
+	}
+	if err := end(); !errors.As(err, &limitErr) {
+		t.Fatalf("end() = %v, want the workers' error", err)
+	}
+
+	// Without a statement, workers run unbudgeted and nothing fails.
+	shared = c.budget().share(c)
+	shared.run(func(b *statementBudget) error { return nil })
+	if err := shared.settle(); err != nil || c.budget() != nil {
+		t.Fatalf("settle() = %v outside a statement", err)
+	}
+}
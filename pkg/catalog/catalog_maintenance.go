@@ -8,6 +8,7 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/query"
 	"github.com/cobaltdb/cobaltdb/pkg/security"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -83,6 +84,10 @@ func (c *Catalog) Save() error {
 		}
 	}
 
+	if err := c.saveSequencesLocked(); err != nil {
+		return err
+	}
+
 	for materializedViewName, materializedView := range c.materializedViews {
 		sql := c.materializedViewSQL[materializedViewName]
 		if strings.TrimSpace(sql) == "" {
@@ -142,6 +147,10 @@ func (c *Catalog) Save() error {
 		return fmt.Errorf("failed to flush index trees: %w", err)
 	}
 
+	// The flushed catalog tree no longer references released trees, so the
+	// flush below also makes their pages reusable.
+	c.freeReleasedTreePages()
+
 	// Flush buffer pool to ensure all pages are written to disk
 	if c.pool != nil {
 		if err := c.pool.FlushAll(); err != nil {
@@ -152,6 +161,71 @@ func (c *Catalog) Save() error {
 	return nil
 }
 
+// releaseTreeLocked queues the pages of a dropped or rebuilt tree for the
+// freelist. Inside a transaction the tree is kept for rollback and released
+// by CommitTransaction instead.
+func (c *Catalog) releaseTreeLocked(tree btree.TreeStore) {
+	if tree == nil || c.isCurrentTxnActive() {
+		return
+	}
+	c.releaseTrees(tree)
+}
+
+func (c *Catalog) releaseTrees(trees ...btree.TreeStore) {
+	c.releaseMu.Lock()
+	defer c.releaseMu.Unlock()
+	for _, tree := range trees {
+		if tree != nil {
+			c.releasedTrees = append(c.releasedTrees, tree)
+		}
+	}
+}
+
+// releaseCommittedTrees releases the trees dropped by a committed
+// transaction, which its undo log still holds.
+func (c *Catalog) releaseCommittedTrees(undoLog []undoEntry) {
+	for _, entry := range undoLog {
+		switch entry.action {
+		case undoDropTable:
+			c.releaseTrees(entry.tableTree)
+			for _, tree := range entry.tableIdxTrees {
+				c.releaseTrees(tree)
+			}
+		case undoDropIndex:
+			c.releaseTrees(entry.indexTree)
+		case undoAlterDropColumn:
+			for _, tree := range entry.droppedIdxTrees {
+				c.releaseTrees(tree)
+			}
+		}
+	}
+}
+
+// freeReleasedTreePages hands the pages of released trees to the buffer
+// pool. It runs once the catalog tree that stopped referencing them has been
+// flushed, and the pool reuses them after its next full flush.
+func (c *Catalog) freeReleasedTreePages() {
+	c.releaseMu.Lock()
+	trees := c.releasedTrees
+	c.releasedTrees = nil
+	c.releaseMu.Unlock()
+	if c.pool == nil {
+		return
+	}
+	for _, tree := range trees {
+		if dt, ok := tree.(*dictTree); ok {
+			tree = dt.TreeStore
+		}
+		paged, ok := tree.(interface{ PageIDs() []uint32 })
+		if !ok {
+			continue
+		}
+		for _, pageID := range paged.PageIDs() {
+			c.pool.FreePage(pageID)
+		}
+	}
+}
+
 type persistedSQLDef struct {
 	Name string `json:"name"`
 	SQL  string `json:"sql"`
@@ -231,7 +305,9 @@ func (c *Catalog) storeVectorIndexDef(vid *VectorIndexDef) error {
 }
 
 func (c *Catalog) storeFTSIndexDef(fts *FTSIndexDef) error {
+	c.ftsMu.RLock()
 	data, err := json.Marshal(fts)
+	c.ftsMu.RUnlock()
 	if err != nil {
 		return err
 	}
@@ -338,76 +414,13 @@ func (c *Catalog) Load() error {
 			continue
 		}
 		tableName := strings.TrimPrefix(keyStr, "tbl:")
-
-		// Unmarshal table definition
-		var tableDef TableDef
-		if err := json.Unmarshal(value, &tableDef); err != nil {
-			return fmt.Errorf("load catalog: failed to parse table metadata %s: %w", tableName, err)
-		}
-
-		// Restore DEFAULT and CHECK expressions from persisted strings
-		for i := range tableDef.Columns {
-			if tableDef.Columns[i].Default != "" && tableDef.Columns[i].defaultExpr == nil {
-				parsed, err := query.ParseExpression(tableDef.Columns[i].Default)
-				if err != nil {
-					return fmt.Errorf(
-						"load catalog: failed to parse default expression for table %s column %s: %w",
-						tableName,
-						tableDef.Columns[i].Name,
-						err,
-					)
-				}
-				tableDef.Columns[i].defaultExpr = parsed
-			}
-			if tableDef.Columns[i].CheckStr != "" && tableDef.Columns[i].Check == nil {
-				parsed, err := query.ParseExpression(tableDef.Columns[i].CheckStr)
-				if err != nil {
-					return fmt.Errorf(
-						"load catalog: failed to parse check expression for table %s column %s: %w",
-						tableName,
-						tableDef.Columns[i].Name,
-						err,
-					)
-				}
-				tableDef.Columns[i].Check = parsed
-			}
-		}
-		for i := range tableDef.Checks {
-			if tableDef.Checks[i].CheckStr != "" && tableDef.Checks[i].Check == nil {
-				parsed, err := query.ParseExpression(tableDef.Checks[i].CheckStr)
-				if err != nil {
-					return fmt.Errorf(
-						"load catalog: failed to parse check constraint for table %s constraint %s: %w",
-						tableName,
-						tableDef.Checks[i].Name,
-						err,
-					)
-				}
-				tableDef.Checks[i].Check = parsed
-			}
+		tableDef, err := decodeTableDef(tableName, value)
+		if err != nil {
+			return err
 		}
-
-		// Create or open B+Tree for the table
-		var tableTree btree.TreeStore
-		if tableDef.RootPageID != 0 {
-			tree, err := btree.OpenBTreeStrict(c.pool, tableDef.RootPageID)
-			if err != nil {
-				return fmt.Errorf("load catalog: failed to open tree for table %s: %w", tableName, err)
-			}
-			tableTree = tree
-		} else {
-			tree, err := btree.NewBTree(c.pool)
-			if err != nil {
-				return fmt.Errorf("load catalog: failed to create tree for table %s: %w", tableName, err)
-			}
-			tableDef.RootPageID = tree.RootPageID()
-			tableTree = tree
+		if err := c.installTableDefLocked(tableDef); err != nil {
+			return err
 		}
-
-		// Build column index cache
-		tableDef.buildColumnIndexCache()
-		c.tables[tableName] = &tableDef
-		c.tableTrees[tableName] = tableTree
 	}
 
 	// Load regular B-tree index definitions after tables so orphaned/corrupt
@@ -426,40 +439,9 @@ func (c *Catalog) Load() error {
 			continue
 		}
 
-		indexName := strings.TrimPrefix(keyStr, "idx:")
-		var indexDef IndexDef
-		if err := json.Unmarshal(value, &indexDef); err != nil {
-			return fmt.Errorf("load catalog: failed to parse index metadata %s: %w", indexName, err)
-		}
-		if indexDef.Name == "" {
-			indexDef.Name = indexName
-		}
-		table, tableExists := c.tables[indexDef.TableName]
-		if !tableExists {
-			continue
-		}
-
-		var indexTree btree.TreeStore
-		if indexDef.RootPageID != 0 {
-			indexTree, err = btree.OpenBTreeStrict(c.pool, indexDef.RootPageID)
-			if err != nil {
-				return fmt.Errorf("load catalog: failed to open index %s: %w", indexDef.Name, err)
-			}
-		} else {
-			indexTree, err = btree.NewBTree(c.pool)
-			if err != nil {
-				return fmt.Errorf("load catalog: failed to create index %s: %w", indexDef.Name, err)
-			}
-			indexDef.RootPageID = indexTree.RootPageID()
-			if tableTree := c.tableTrees[indexDef.TableName]; tableTree != nil {
-				if err := c.populateIndexLocked(indexTree, &indexDef, table, tableTree); err != nil {
-					return fmt.Errorf("load catalog: failed to populate index %s: %w", indexDef.Name, err)
-				}
-			}
+		if _, err := c.loadIndexDefLocked(strings.TrimPrefix(keyStr, "idx:"), value); err != nil {
+			return err
 		}
-
-		c.indexes[indexDef.Name] = &indexDef
-		c.indexTrees[indexDef.Name] = indexTree
 	}
 
 	foreignTableIter, err := c.tree.Scan([]byte("ft:"), []byte("ft;"))
@@ -508,29 +490,9 @@ func (c *Catalog) Load() error {
 		if !strings.HasPrefix(keyStr, "view:") {
 			continue
 		}
-		viewName := strings.TrimPrefix(keyStr, "view:")
-		def, err := decodePersistedSQLDef(keyStr, "view:", value)
-		if err != nil {
-			return fmt.Errorf("load catalog: failed to parse view metadata %s: %w", viewName, err)
-		}
-		if def.SQL == "" {
-			return fmt.Errorf("load catalog: missing SQL for view metadata %s", viewName)
-		}
-		parsed, err := query.Parse(def.SQL)
-		if err != nil {
-			return fmt.Errorf("load catalog: failed to parse view SQL %s: %w", viewName, err)
-		}
-		viewStmt, ok := parsed.(*query.CreateViewStmt)
-		if !ok || viewStmt.Query == nil {
-			return fmt.Errorf("load catalog: invalid view metadata %s", viewName)
-		}
-		name := viewStmt.Name
-		if name == "" {
-			name = def.Name
+		if err := c.loadViewDefLocked(keyStr, value); err != nil {
+			return err
 		}
-		c.views[name] = viewStmt.Query
-		c.viewSQL[name] = strings.TrimSpace(def.SQL)
-		c.viewTemporary[name] = false
 	}
 
 	triggerIter, err := c.tree.Scan([]byte("trg:"), []byte("trg;"))
@@ -549,34 +511,9 @@ func (c *Catalog) Load() error {
 		if !strings.HasPrefix(keyStr, "trg:") {
 			continue
 		}
-		triggerName := strings.TrimPrefix(keyStr, "trg:")
-		def, err := decodePersistedSQLDef(keyStr, "trg:", value)
-		if err != nil {
-			return fmt.Errorf("load catalog: failed to parse trigger metadata %s: %w", triggerName, err)
-		}
-		if def.SQL == "" {
-			return fmt.Errorf("load catalog: missing SQL for trigger metadata %s", triggerName)
-		}
-		parsed, err := query.Parse(def.SQL)
-		if err != nil {
-			return fmt.Errorf("load catalog: failed to parse trigger SQL %s: %w", triggerName, err)
-		}
-		triggerStmt, ok := parsed.(*query.CreateTriggerStmt)
-		if !ok {
-			return fmt.Errorf("load catalog: invalid trigger metadata %s", triggerName)
-		}
-		if _, tableOK := c.tables[triggerStmt.Table]; !tableOK {
-			if _, viewOK := c.views[triggerStmt.Table]; !viewOK {
-				return fmt.Errorf("load catalog: trigger %s references missing table or view %s", triggerName, triggerStmt.Table)
-			}
-		}
-		name := triggerStmt.Name
-		if name == "" {
-			name = def.Name
+		if err := c.loadTriggerDefLocked(keyStr, value); err != nil {
+			return err
 		}
-		triggerStmt.RawSQL = strings.TrimSpace(def.SQL)
-		c.triggers[name] = triggerStmt
-		c.triggerSQL[name] = triggerStmt.RawSQL
 	}
 
 	procedureIter, err := c.tree.Scan([]byte("proc:"), []byte("proc;"))
@@ -620,6 +557,14 @@ func (c *Catalog) Load() error {
 		c.procedureSQL[name] = procedureStmt.RawSQL
 	}
 
+	if err := c.loadSequencesLocked(); err != nil {
+		return err
+	}
+
+	if err := c.loadSchemasLocked(); err != nil {
+		return err
+	}
+
 	materializedViewIter, err := c.tree.Scan([]byte("mv:"), []byte("mv;"))
 	if err != nil {
 		return fmt.Errorf("load catalog: failed to scan materialized view metadata: %w", err)
@@ -709,6 +654,7 @@ func (c *Catalog) Load() error {
 		return fmt.Errorf("load catalog: failed to scan full-text index metadata: %w", err)
 	}
 	defer ftsIter.Close()
+	var ftsToBuild []*FTSIndexDef
 	for ftsIter.HasNext() {
 		key, value, err := ftsIter.Next()
 		if err != nil {
@@ -729,8 +675,27 @@ func (c *Catalog) Load() error {
 		if fts.Index == nil {
 			fts.Index = make(map[string][]int64)
 		}
+		if fts.Docs == nil {
+			// Written before per-row documents were stored: rebuild from the
+			// table once it has been loaded.
+			ftsToBuild = append(ftsToBuild, &fts)
+		}
 		c.ftsIndexes[fts.Name] = &fts
 	}
+	for _, fts := range ftsToBuild {
+		if table, ok := c.tables[fts.TableName]; ok {
+			if err := c.buildFTSIndexLocked(fts, table); err != nil {
+				return fmt.Errorf("load catalog: %w", err)
+			}
+		}
+	}
+
+	if err := c.loadGINIndexesLocked(); err != nil {
+		return err
+	}
+	if err := c.loadBloomIndexesLocked(); err != nil {
+		return err
+	}
 
 	// Load JSON index definitions.
 	jsonIter, err := c.tree.Scan([]byte("json:"), []byte("json;"))
@@ -820,6 +785,206 @@ func (c *Catalog) Load() error {
 	return nil
 }
 
+// decodeTableDef parses a persisted table definition and restores the DEFAULT
+// and CHECK expressions kept as SQL strings.
+func decodeTableDef(tableName string, value []byte) (*TableDef, error) {
+	var tableDef TableDef
+	if err := json.Unmarshal(value, &tableDef); err != nil {
+		return nil, fmt.Errorf("load catalog: failed to parse table metadata %s: %w", tableName, err)
+	}
+
+	// Restore DEFAULT and CHECK expressions from persisted strings
+	for i := range tableDef.Columns {
+		if tableDef.Columns[i].Default != "" && tableDef.Columns[i].defaultExpr == nil {
+			parsed, err := query.ParseExpression(tableDef.Columns[i].Default)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"load catalog: failed to parse default expression for table %s column %s: %w",
+					tableName,
+					tableDef.Columns[i].Name,
+					err,
+				)
+			}
+			tableDef.Columns[i].defaultExpr = parsed
+		}
+		if tableDef.Columns[i].CheckStr != "" && tableDef.Columns[i].Check == nil {
+			parsed, err := query.ParseExpression(tableDef.Columns[i].CheckStr)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"load catalog: failed to parse check expression for table %s column %s: %w",
+					tableName,
+					tableDef.Columns[i].Name,
+					err,
+				)
+			}
+			tableDef.Columns[i].Check = parsed
+		}
+	}
+	for i := range tableDef.Checks {
+		if tableDef.Checks[i].CheckStr != "" && tableDef.Checks[i].Check == nil {
+			parsed, err := query.ParseExpression(tableDef.Checks[i].CheckStr)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"load catalog: failed to parse check constraint for table %s constraint %s: %w",
+					tableName,
+					tableDef.Checks[i].Name,
+					err,
+				)
+			}
+			tableDef.Checks[i].Check = parsed
+		}
+	}
+	return &tableDef, nil
+}
+
+// installTableDefLocked opens the B+Tree of a decoded table definition, or
+// creates one when the definition has no root page yet, and registers the
+// table. Must be called with c.mu held.
+func (c *Catalog) installTableDefLocked(tableDef *TableDef) error {
+	// Create or open B+Tree for the table
+	var tableTree btree.TreeStore
+	if tableDef.RootPageID != 0 {
+		tree, err := c.openTableTree(tableDef.Engine, tableDef.RootPageID)
+		if err != nil {
+			return fmt.Errorf("load catalog: failed to open tree for table %s: %w", tableDef.Name, err)
+		}
+		tableTree = tree
+	} else {
+		tree, err := c.newTableTree(tableDef.Engine)
+		if err != nil {
+			return fmt.Errorf("load catalog: failed to create tree for table %s: %w", tableDef.Name, err)
+		}
+		tableDef.RootPageID = tree.RootPageID()
+		tableTree = tree
+	}
+
+	tableTree, err := openDictTree(tableTree, tableDef.CompressionDict)
+	if err != nil {
+		return fmt.Errorf("load catalog: table %s: %w", tableDef.Name, err)
+	}
+
+	// Build column index cache
+	tableDef.buildColumnIndexCache()
+	c.tables[tableDef.Name] = tableDef
+	c.tableTrees[tableDef.Name] = tableTree
+	return nil
+}
+
+// loadIndexDefLocked registers a persisted index definition, building the
+// index from its table when the definition has no root page yet or was left
+// IndexBuilding by an interrupted build or bulk load. It returns nil when the
+// indexed table does not exist. Must be called with c.mu held.
+func (c *Catalog) loadIndexDefLocked(indexName string, value []byte) (*IndexDef, error) {
+	var indexDef IndexDef
+	if err := json.Unmarshal(value, &indexDef); err != nil {
+		return nil, fmt.Errorf("load catalog: failed to parse index metadata %s: %w", indexName, err)
+	}
+	if indexDef.Name == "" {
+		indexDef.Name = indexName
+	}
+	table, tableExists := c.tables[indexDef.TableName]
+	if !tableExists {
+		return nil, nil
+	}
+
+	var indexTree btree.TreeStore
+	var err error
+	if indexDef.RootPageID != 0 && indexDef.Status != IndexBuilding {
+		indexTree, err = btree.OpenBTreeStrict(c.pool, indexDef.RootPageID)
+		if err != nil {
+			return nil, fmt.Errorf("load catalog: failed to open index %s: %w", indexDef.Name, err)
+		}
+	} else {
+		indexTree, err = btree.NewBTree(c.pool)
+		if err != nil {
+			return nil, fmt.Errorf("load catalog: failed to create index %s: %w", indexDef.Name, err)
+		}
+		indexDef.RootPageID = indexTree.RootPageID()
+		if tableTree := c.tableTrees[indexDef.TableName]; tableTree != nil {
+			err = c.populateIndexLocked(indexTree, &indexDef, table, tableTree)
+		}
+		switch {
+		case err != nil && indexDef.Status == IndexBuilding:
+			// A build that cannot finish, such as a UNIQUE index over
+			// duplicate values, leaves the index out of use as before.
+		case err != nil:
+			return nil, fmt.Errorf("load catalog: failed to populate index %s: %w", indexDef.Name, err)
+		case indexDef.Status == IndexBuilding:
+			indexDef.Status = IndexActive
+			if err := c.storeIndexDef(&indexDef); err != nil {
+				return nil, fmt.Errorf("load catalog: failed to store index %s: %w", indexDef.Name, err)
+			}
+		}
+	}
+
+	c.indexes[indexDef.Name] = &indexDef
+	c.indexTrees[indexDef.Name] = indexTree
+	return &indexDef, nil
+}
+
+// loadViewDefLocked registers a persisted view definition. Must be called with
+// c.mu held.
+func (c *Catalog) loadViewDefLocked(keyStr string, value []byte) error {
+	viewName := strings.TrimPrefix(keyStr, "view:")
+	def, err := decodePersistedSQLDef(keyStr, "view:", value)
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to parse view metadata %s: %w", viewName, err)
+	}
+	if def.SQL == "" {
+		return fmt.Errorf("load catalog: missing SQL for view metadata %s", viewName)
+	}
+	parsed, err := query.Parse(def.SQL)
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to parse view SQL %s: %w", viewName, err)
+	}
+	viewStmt, ok := parsed.(*query.CreateViewStmt)
+	if !ok || viewStmt.Query == nil {
+		return fmt.Errorf("load catalog: invalid view metadata %s", viewName)
+	}
+	name := viewStmt.Name
+	if name == "" {
+		name = def.Name
+	}
+	c.views[name] = viewStmt.Query
+	c.viewSQL[name] = strings.TrimSpace(def.SQL)
+	c.viewTemporary[name] = false
+	return nil
+}
+
+// loadTriggerDefLocked registers a persisted trigger definition. Must be
+// called with c.mu held.
+func (c *Catalog) loadTriggerDefLocked(keyStr string, value []byte) error {
+	triggerName := strings.TrimPrefix(keyStr, "trg:")
+	def, err := decodePersistedSQLDef(keyStr, "trg:", value)
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to parse trigger metadata %s: %w", triggerName, err)
+	}
+	if def.SQL == "" {
+		return fmt.Errorf("load catalog: missing SQL for trigger metadata %s", triggerName)
+	}
+	parsed, err := query.Parse(def.SQL)
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to parse trigger SQL %s: %w", triggerName, err)
+	}
+	triggerStmt, ok := parsed.(*query.CreateTriggerStmt)
+	if !ok {
+		return fmt.Errorf("load catalog: invalid trigger metadata %s", triggerName)
+	}
+	if _, tableOK := c.tables[triggerStmt.Table]; !tableOK {
+		if _, viewOK := c.views[triggerStmt.Table]; !viewOK {
+			return fmt.Errorf("load catalog: trigger %s references missing table or view %s", triggerName, triggerStmt.Table)
+		}
+	}
+	name := triggerStmt.Name
+	if name == "" {
+		name = def.Name
+	}
+	triggerStmt.RawSQL = strings.TrimSpace(def.SQL)
+	c.triggers[name] = triggerStmt
+	c.triggerSQL[name] = triggerStmt.RawSQL
+	return nil
+}
+
 func (c *Catalog) hasTableLocked(tableName string) bool {
 	if _, exists := c.tables[tableName]; exists {
 		return true
@@ -983,27 +1148,25 @@ func (c *Catalog) LoadSchema(dir string) error {
 		// Create or open B+Tree for the table
 		var tableTree btree.TreeStore
 		if tableDef.RootPageID != 0 && c.pool != nil {
-			tree, err := btree.OpenBTreeStrict(c.pool, tableDef.RootPageID)
+			tree, err := c.openTableTree(tableDef.Engine, tableDef.RootPageID)
 			if err != nil {
-				tree, err = btree.NewBTree(c.pool)
+				tree, err = c.newTableTree(tableDef.Engine)
 				if err != nil {
 					return fmt.Errorf("load schema: failed to create replacement tree for %s: %w", name, err)
 				}
 				tableDef.RootPageID = tree.RootPageID()
 			}
 			tableTree = tree
-		} else {
-			var tree *btree.BTree
-			if c.pool != nil {
-				tree, err = btree.NewBTree(c.pool)
-				if err != nil {
-					return fmt.Errorf("load schema: failed to create tree for %s: %w", name, err)
-				}
-				tableDef.RootPageID = tree.RootPageID()
-			}
-			if tree != nil {
-				tableTree = tree
+		} else if c.pool != nil {
+			tree, err := c.newTableTree(tableDef.Engine)
+			if err != nil {
+				return fmt.Errorf("load schema: failed to create tree for %s: %w", name, err)
 			}
+			tableDef.RootPageID = tree.RootPageID()
+			tableTree = tree
+		}
+		if tableTree, err = openDictTree(tableTree, tableDef.CompressionDict); err != nil {
+			return fmt.Errorf("load schema: table %s: %w", name, err)
 		}
 
 		// Persist to catalog tree
@@ -1233,17 +1396,40 @@ func (c *Catalog) vacuumTreeLocked(name string, horizonNS int64) error {
 		return nil
 	}
 
-	newTree, err := btree.NewBTree(c.pool)
-	if err != nil {
-		return fmt.Errorf("vacuum: failed to create new tree for table %s: %w", name, err)
-	}
-	for _, e := range entries {
-		if err := newTree.Put(e.key, e.value); err != nil {
-			return fmt.Errorf("vacuum: failed to copy entry in table %s: %w", name, err)
+	var dst btree.TreeStore
+	if engine := c.tableEngineFor(name); engine == EngineAppend {
+		newTree, err := c.newTableTree(engine)
+		if err != nil {
+			return fmt.Errorf("vacuum: failed to create new tree for table %s: %w", name, err)
 		}
+		dst = rewrapTree(tree, newTree)
+		for _, e := range entries {
+			if err := dst.Put(e.key, e.value); err != nil {
+				return fmt.Errorf("vacuum: failed to copy entry in table %s: %w", name, err)
+			}
+		}
+	} else {
+		// The scan returns the rows in key order, ready to bulk-load.
+		dt, _ := tree.(*dictTree)
+		loader := btree.NewBulkLoader(c.pool)
+		for _, e := range entries {
+			value := e.value
+			if dt != nil {
+				value = dt.encode(value)
+			}
+			if err := loader.Add(e.key, value); err != nil {
+				return fmt.Errorf("vacuum: failed to copy entry in table %s: %w", name, err)
+			}
+		}
+		newTree, err := loader.Finish()
+		if err != nil {
+			return fmt.Errorf("vacuum: failed to build new tree for table %s: %w", name, err)
+		}
+		dst = rewrapTree(tree, newTree)
 	}
 
-	c.tableTrees[name] = newTree
+	c.tableTrees[name] = dst
+	c.releaseTrees(tree)
 
 	// Persist the new root page. Vacuum allocates a fresh tree (new root page),
 	// but Load() reopens a table from its persisted TableDef.RootPageID. Without
@@ -1252,7 +1438,7 @@ func (c *Catalog) vacuumTreeLocked(name string, horizonNS int64) error {
 	// vacuum (AutoVacuum is on by default, so this happens silently). Only the
 	// non-partitioned single-tree case is keyed in c.tables here.
 	if td, ok := c.tables[name]; ok {
-		td.RootPageID = newTree.RootPageID()
+		td.RootPageID = dst.RootPageID()
 		if err := c.storeTableDef(td); err != nil {
 			return fmt.Errorf("vacuum: failed to persist new root for table %s: %w", name, err)
 		}
@@ -1297,17 +1483,19 @@ func (c *Catalog) compactIndexTreeLocked(name string, tree btree.TreeStore) erro
 		return nil
 	}
 
-	newTree, err := btree.NewBTree(c.pool)
-	if err != nil {
-		return fmt.Errorf("vacuum: failed to create new tree for index %s: %w", name, err)
-	}
+	loader := btree.NewBulkLoader(c.pool)
 	for _, e := range entries {
-		if err := newTree.Put(e.key, e.value); err != nil {
+		if err := loader.Add(e.key, e.value); err != nil {
 			return fmt.Errorf("vacuum: failed to copy entry in index %s: %w", name, err)
 		}
 	}
+	newTree, err := loader.Finish()
+	if err != nil {
+		return fmt.Errorf("vacuum: failed to build new tree for index %s: %w", name, err)
+	}
 
 	c.indexTrees[name] = newTree
+	c.releaseTrees(tree)
 	// Persist the new index root page (same reopen hazard as table vacuum).
 	if idx, ok := c.indexes[name]; ok {
 		idx.RootPageID = newTree.RootPageID()
@@ -1376,6 +1564,56 @@ func (c *Catalog) ListTablesNeedingVacuum(threshold float64) []string {
 	return result
 }
 
+// noteModifiedRows counts n rows of table written since its last ANALYZE.
+func (c *Catalog) noteModifiedRows(table string, n int64) {
+	if n <= 0 {
+		return
+	}
+	c.vacuumMu.Lock()
+	if c.modTuples == nil {
+		c.modTuples = make(map[string]int64)
+	}
+	c.modTuples[table] += n
+	c.vacuumMu.Unlock()
+}
+
+// ModifiedRowsSinceAnalyze returns how many rows of a table were inserted,
+// updated or deleted since it was last analyzed.
+func (c *Catalog) ModifiedRowsSinceAnalyze(tableName string) int64 {
+	c.vacuumMu.RLock()
+	defer c.vacuumMu.RUnlock()
+	return c.modTuples[tableName]
+}
+
+// ListTablesNeedingAnalyze returns, sorted, the tables with rows written
+// since their last ANALYZE numbering at least fraction of the row count
+// that ANALYZE saw. A written table never analyzed always qualifies.
+func (c *Catalog) ListTablesNeedingAnalyze(fraction float64) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.vacuumMu.RLock()
+	defer c.vacuumMu.RUnlock()
+
+	var result []string
+	for name, modified := range c.modTuples {
+		if modified <= 0 {
+			continue
+		}
+		if _, ok := c.tableTrees[name]; !ok {
+			continue
+		}
+		var rows uint64
+		if stats := c.stats[name]; stats != nil {
+			rows = stats.RowCount
+		}
+		if float64(modified) >= fraction*float64(rows) {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
 // Analyze computes per-column statistics (row count, null count, distinct count,
 // min/max values) for a table. The scan phase runs WITHOUT holding c.mu so that
 // concurrent DML is not blocked. The catalog lock is held only briefly: once to
@@ -1397,6 +1635,10 @@ func (c *Catalog) Analyze(tableName string) error {
 		return fmt.Errorf("table %s has no data", tableName)
 	}
 	columns := table.Columns
+	// Rows written from here on count toward the next ANALYZE.
+	c.vacuumMu.Lock()
+	delete(c.modTuples, tableName)
+	c.vacuumMu.Unlock()
 	c.mu.Unlock()
 
 	// Phase 2: scan WITHOUT holding c.mu — allows concurrent DML.
@@ -1494,3 +1736,52 @@ func (c *Catalog) Analyze(tableName string) error {
 	c.stats[tableName] = stats
 	return nil
 }
+
+// TreePageStats is the page usage of one table or index tree.
+type TreePageStats struct {
+	Name  string `json:"name"`
+	Index bool   `json:"index,omitempty"`
+	btree.PageStats
+}
+
+// TreePageStats reports how full the pages of every B+Tree table and index
+// are, as of their last flush, sorted by name. Tables stored with another
+// engine are left out.
+func (c *Catalog) TreePageStats() ([]TreePageStats, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var out []TreePageStats
+	add := func(name string, tree btree.TreeStore, index bool) error {
+		if dt, ok := tree.(*dictTree); ok {
+			tree = dt.TreeStore
+		}
+		bt, ok := tree.(*btree.BTree)
+		if !ok {
+			return nil
+		}
+		stats, err := bt.PageStats()
+		if err != nil {
+			return fmt.Errorf("failed to read pages of %s: %w", name, err)
+		}
+		out = append(out, TreePageStats{Name: name, Index: index, PageStats: stats})
+		return nil
+	}
+	for name, tree := range c.tableTrees {
+		if err := add(name, tree, false); err != nil {
+			return nil, err
+		}
+	}
+	for name, tree := range c.indexTrees {
+		if err := add(name, tree, true); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return !out[i].Index && out[j].Index
+	})
+	return out, nil
+}
@@ -0,0 +1,239 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// A column declared MASKED reads through its mask for sessions the unmask
+// policy denies (see Session.SetUnmask): every query of such a session reads
+// the table, like a CTE, as its rows with the masked columns replaced. WHERE,
+// joins and aggregates therefore see masked values too. Masking changes
+// what reads return, not the stored values or what writes match.
+
+// ColumnMask is the mask of a MASKED column: one of the masking functions
+// MASK, MASK_FIRST, MASK_LAST or MASK_EMAIL, called with the column value
+// followed by Args.
+type ColumnMask struct {
+	Function string  `json:"function"`
+	Args     []int64 `json:"args,omitempty"`
+}
+
+// maskFunctionArgs is the number of arguments each masking function takes
+// after the value.
+var maskFunctionArgs = map[string]int{
+	"MASK":       0,
+	"MASK_FIRST": 1,
+	"MASK_LAST":  1,
+	"MASK_EMAIL": 0,
+}
+
+// columnMaskOf converts the mask of a parsed column definition, checking
+// its function and arguments.
+func columnMaskOf(col *query.ColumnDef) (*ColumnMask, error) {
+	if col.Mask == nil {
+		return nil, nil
+	}
+	n, ok := maskFunctionArgs[col.Mask.Function]
+	if !ok {
+		return nil, fmt.Errorf("column %s: unknown masking function %s", col.Name, col.Mask.Function)
+	}
+	if len(col.Mask.Args) != n {
+		return nil, fmt.Errorf("column %s: %s takes %d argument(s) after the value, got %d", col.Name, col.Mask.Function, n, len(col.Mask.Args))
+	}
+	for _, arg := range col.Mask.Args {
+		if arg < 0 {
+			return nil, fmt.Errorf("column %s: %s argument must not be negative", col.Name, col.Mask.Function)
+		}
+	}
+	return &ColumnMask{Function: col.Mask.Function, Args: col.Mask.Args}, nil
+}
+
+// apply returns v masked.
+func (m *ColumnMask) apply(v interface{}) (interface{}, error) {
+	args := make([]interface{}, 0, 1+len(m.Args))
+	args = append(args, v)
+	for _, arg := range m.Args {
+		args = append(args, arg)
+	}
+	return scalarFunctionHandlers[m.Function](args)
+}
+
+// valueText returns the text of a value, formatting non-strings.
+func valueText(v interface{}) string {
+	if s, ok := toString(v); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// maskKeep returns s with every character but the first (fromEnd false) or
+// last (fromEnd true) n replaced by X.
+func maskKeep(s string, n int, fromEnd bool) string {
+	count := utf8.RuneCountInString(s)
+	if n >= count {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	i := 0
+	for _, r := range s {
+		if (fromEnd && i >= count-n) || (!fromEnd && i < n) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('X')
+		}
+		i++
+	}
+	return b.String()
+}
+
+// maskCount reads the character count argument of MASK_FIRST or MASK_LAST.
+func maskCount(funcName string, args []interface{}) (int, error) {
+	if len(args) != 2 {
+		return 0, fmt.Errorf("%s requires 2 arguments", funcName)
+	}
+	n, ok := toFloat64(args[1])
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("%s: character count must be a non-negative number", funcName)
+	}
+	return int(n), nil
+}
+
+func evalMask(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("MASK requires 1 argument")
+	}
+	switch args[0].(type) {
+	case nil:
+		return nil, nil
+	case int64, int, float64:
+		return int64(0), nil
+	}
+	// A fixed mask hides the length too.
+	return "XXXX", nil
+}
+
+func evalMaskFirst(args []interface{}) (interface{}, error) {
+	n, err := maskCount("MASK_FIRST", args)
+	if err != nil || args[0] == nil {
+		return nil, err
+	}
+	return maskKeep(valueText(args[0]), n, false), nil
+}
+
+func evalMaskLast(args []interface{}) (interface{}, error) {
+	n, err := maskCount("MASK_LAST", args)
+	if err != nil || args[0] == nil {
+		return nil, err
+	}
+	return maskKeep(valueText(args[0]), n, true), nil
+}
+
+func evalMaskEmail(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("MASK_EMAIL requires 1 argument")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	s := valueText(args[0])
+	first, _ := utf8.DecodeRuneInString(s)
+	if first == utf8.RuneError {
+		return "XXX@XXXX.com", nil
+	}
+	return string(first) + "XXX@XXXX.com", nil
+}
+
+// SetUnmask sets the policy deciding which tables the session reads
+// unmasked: MASKED columns of a table for which unmask returns false read
+// masked. nil, the default, reads every table unmasked.
+func (s *Session) SetUnmask(unmask func(table string) bool) {
+	if unmask == nil {
+		s.unmask.Store(nil)
+		return
+	}
+	s.unmask.Store(&unmask)
+}
+
+// masksTablesLocked reports whether the calling statement's session reads
+// some tables masked. Must be called with c.mu held.
+func (cat *Catalog) masksTablesLocked() bool {
+	if !cat.tableFlagsLocked().masked {
+		return false
+	}
+	s := cat.session()
+	return s != nil && s.unmask.Load() != nil
+}
+
+// masksTableLocked reports whether the calling statement's session reads
+// table masked. Must be called with c.mu held.
+func (cat *Catalog) masksTableLocked(table *TableDef) bool {
+	if cat.unmasking[table.Name] || !hasMaskedColumns(table) {
+		return false
+	}
+	s := cat.session()
+	if s == nil {
+		return false
+	}
+	unmask := s.unmask.Load()
+	return unmask != nil && !(*unmask)(table.Name)
+}
+
+func hasMaskedColumns(table *TableDef) bool {
+	for i := range table.Columns {
+		if table.Columns[i].Mask != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// liveRowsLocked returns the rows table's SELECT * reads, unmasked. Must be
+// called with c.mu held exclusively.
+func (cat *Catalog) liveRowsLocked(table *TableDef) (*cteResultSet, error) {
+	if cat.unmasking == nil {
+		cat.unmasking = make(map[string]bool)
+	}
+	cat.unmasking[table.Name] = true
+	defer delete(cat.unmasking, table.Name)
+	cols, rows, err := cat.selectLocked(&query.SelectStmt{
+		Columns: []query.Expression{&query.StarExpr{}},
+		From:    &query.TableRef{Name: table.Name},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &cteResultSet{columns: cols, rows: rows}, nil
+}
+
+// maskRows returns res with table's MASKED columns masked.
+func maskRows(table *TableDef, res *cteResultSet) (*cteResultSet, error) {
+	masks := make([]*ColumnMask, len(res.columns))
+	for i, name := range res.columns {
+		for j := range table.Columns {
+			if strings.EqualFold(table.Columns[j].Name, name) {
+				masks[i] = table.Columns[j].Mask
+				break
+			}
+		}
+	}
+	masked := &cteResultSet{columns: res.columns, rows: make([][]interface{}, len(res.rows))}
+	for r, row := range res.rows {
+		out := make([]interface{}, len(row))
+		for i, v := range row {
+			if i < len(masks) && masks[i] != nil {
+				var err error
+				if v, err = masks[i].apply(v); err != nil {
+					return nil, err
+				}
+			}
+			out[i] = v
+		}
+		masked.rows[r] = out
+	}
+	return masked, nil
+}
@@ -0,0 +1,182 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+	"github.com/cobaltdb/cobaltdb/pkg/txn"
+)
+
+var (
+	// ErrRowPrepared is returned by a commit that writes a row a prepared
+	// transaction holds; see PrepareTransaction.
+	ErrRowPrepared = errors.New("row is held by a prepared transaction")
+	// ErrNotPreparable is returned by PrepareTransaction for a transaction
+	// whose changes are not all buffered row writes.
+	ErrNotPreparable = errors.New("transaction cannot be prepared")
+)
+
+// PrepareTransaction is the first phase of a two-phase commit of the calling
+// goroutine's transaction. It validates the transaction's reads as
+// CommitTransaction would, then ends the transaction without applying its
+// writes: they are returned as WAL records for the second phase to log and
+// apply, and the rows they write stay held under xid until
+// ReleasePreparedRows. A commit of another transaction that writes a held
+// row fails with ErrRowPrepared, so the records can be applied later without
+// losing an update.
+//
+// Only buffered row writes can be prepared; a transaction that changed the
+// schema fails with ErrNotPreparable. After any error the transaction is
+// still active, for the caller to roll back.
+func (c *Catalog) PrepareTransaction(xid string) ([]*storage.WALRecord, error) {
+	ts := c.getCurrentTxn()
+	if ts == nil || !ts.txnActive {
+		return nil, errors.New("no active transaction")
+	}
+	if ts.schemaRecordsWritten+len(ts.schemaRecords) > 0 {
+		return nil, fmt.Errorf("%w: it changes the schema", ErrNotPreparable)
+	}
+	for _, entry := range ts.undoLog {
+		// An AUTOINCREMENT counter advanced by the transaction stays
+		// advanced, as it does for a rolled back one.
+		if entry.action != undoAutoIncSeq {
+			return nil, fmt.Errorf("%w: it made changes that are not buffered for commit", ErrNotPreparable)
+		}
+	}
+
+	// The last write of a row wins, as at commit.
+	rows := make([]txn.WriteKey, 0, len(ts.pendingWrites))
+	values := make(map[txn.WriteKey][]byte, len(ts.pendingWrites))
+	shardSet := make(map[int]struct{}, len(ts.pendingWrites)+len(ts.readValues))
+	for _, pw := range ts.pendingWrites {
+		wk := txn.WriteKey{TreeName: pw.TreeName, Key: pw.Key}
+		if _, seen := values[wk]; !seen {
+			rows = append(rows, wk)
+		}
+		values[wk] = pw.Value
+		shardSet[c.commitLockIdx(pw.TreeName, pw.Key)] = struct{}{}
+	}
+	records := make([]*storage.WALRecord, 0, len(rows))
+	for _, wk := range rows {
+		data, err := encodeLogicalWALData(wk.TreeName, []byte(wk.Key), values[wk])
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &storage.WALRecord{TxnID: ts.txnID, Type: storage.WALUpdate, Data: data})
+	}
+	for wk := range ts.readValues {
+		shardSet[c.commitLockIdx(wk.TreeName, wk.Key)] = struct{}{}
+	}
+	if err := c.holdPreparedRows(ts, xid, rows, shardSet); err != nil {
+		return nil, err
+	}
+
+	if mt, ok := ts.managerTxn.(*txn.Transaction); ok && mt != nil {
+		_ = mt.Rollback() // releases its locks; the writes live on in records
+	}
+	ts.txnActive = false
+	c.unregisterGoroutineTxn()
+	c.putTxnState(ts)
+	return records, nil
+}
+
+// holdPreparedRows validates ts's reads and holds rows for xid, under the
+// commit locks of shardSet so no commit slips in between.
+func (c *Catalog) holdPreparedRows(ts *catalogTxnState, xid string, rows []txn.WriteKey, shardSet map[int]struct{}) error {
+	sortedShards := make([]int, 0, len(shardSet))
+	for s := range shardSet {
+		sortedShards = append(sortedShards, s)
+	}
+	sort.Ints(sortedShards)
+	for _, s := range sortedShards {
+		c.commitMu[s].Lock()
+	}
+	defer func() {
+		for i := len(sortedShards) - 1; i >= 0; i-- {
+			c.commitMu[sortedShards[i]].Unlock()
+		}
+	}()
+
+	for wk, originalValue := range ts.readValues {
+		tree, exists := ts.treeCache[wk.TreeName]
+		if !exists || tree == nil {
+			continue
+		}
+		currentValue, err := readCommitValidationValue(tree, wk.TreeName, wk.Key)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(originalValue, currentValue) {
+			return txn.ErrConflict
+		}
+	}
+	return c.holdRows(xid, rows)
+}
+
+// HoldPreparedRows holds the rows records write for xid, as
+// PrepareTransaction did before a restart.
+func (c *Catalog) HoldPreparedRows(xid string, records []*storage.WALRecord) error {
+	rows := make([]txn.WriteKey, 0, len(records))
+	c.mu.RLock()
+	for _, r := range records {
+		key, _, err := parseReplayWALKeyValue(r.Data)
+		if err != nil {
+			c.mu.RUnlock()
+			return fmt.Errorf("invalid prepared %v: %w", r.Type, err)
+		}
+		if treeName, rowKey, ok := c.splitTreeKeyLocked(key); ok {
+			rows = append(rows, txn.WriteKey{TreeName: treeName, Key: rowKey})
+		}
+	}
+	c.mu.RUnlock()
+	return c.holdRows(xid, rows)
+}
+
+func (c *Catalog) holdRows(xid string, rows []txn.WriteKey) error {
+	c.preparedMu.Lock()
+	defer c.preparedMu.Unlock()
+	for _, wk := range rows {
+		if holder, held := c.preparedRows[wk]; held {
+			return fmt.Errorf("%w: transaction %q holds a row of %s", ErrRowPrepared, holder, wk.TreeName)
+		}
+	}
+	if c.preparedRows == nil {
+		c.preparedRows = make(map[txn.WriteKey]string, len(rows))
+	}
+	for _, wk := range rows {
+		c.preparedRows[wk] = xid
+	}
+	c.preparedCount.Store(int32(len(c.preparedRows))) // #nosec G115 -- bounded by memory long before 2^31 rows
+	return nil
+}
+
+// ReleasePreparedRows releases the rows held for xid, once its records are
+// applied or discarded.
+func (c *Catalog) ReleasePreparedRows(xid string) {
+	c.preparedMu.Lock()
+	defer c.preparedMu.Unlock()
+	for wk, holder := range c.preparedRows {
+		if holder == xid {
+			delete(c.preparedRows, wk)
+		}
+	}
+	c.preparedCount.Store(int32(len(c.preparedRows))) // #nosec G115 -- see holdRows
+}
+
+// checkPreparedRow fails a commit that writes a row held by a prepared
+// transaction. The caller holds the row's commit lock.
+func (c *Catalog) checkPreparedRow(treeName, key string) error {
+	if c.preparedCount.Load() == 0 {
+		return nil
+	}
+	c.preparedMu.Lock()
+	holder, held := c.preparedRows[txn.WriteKey{TreeName: treeName, Key: key}]
+	c.preparedMu.Unlock()
+	if held {
+		return fmt.Errorf("%w: transaction %q holds a row of %s", ErrRowPrepared, holder, treeName)
+	}
+	return nil
+}
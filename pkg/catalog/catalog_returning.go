@@ -3,6 +3,7 @@ package catalog
 import (
 	"fmt"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 )
 
 // evaluateReturning evaluates RETURNING clause expressions against a row
@@ -45,21 +46,21 @@ func (c *Catalog) evaluateReturningExpr(expr query.Expression, row []interface{}
 		if colIdx >= 0 && colIdx < len(row) {
 			return []interface{}{row[colIdx]}, []string{e.Column}, nil
 		}
-		return nil, nil, fmt.Errorf("column '%s' not found", e.Column)
+		return nil, nil, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found", e.Column)
 
 	case *query.QualifiedIdentifier:
 		colIdx := table.GetColumnIndex(e.Column)
 		if colIdx >= 0 && colIdx < len(row) {
 			return []interface{}{row[colIdx]}, []string{e.Column}, nil
 		}
-		return nil, nil, fmt.Errorf("column '%s' not found", e.Column)
+		return nil, nil, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found", e.Column)
 
 	case *query.Identifier:
 		colIdx := table.GetColumnIndex(e.Name)
 		if colIdx >= 0 && colIdx < len(row) {
 			return []interface{}{row[colIdx]}, []string{e.Name}, nil
 		}
-		return nil, nil, fmt.Errorf("column '%s' not found", e.Name)
+		return nil, nil, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found", e.Name)
 
 	default:
 		// For complex expressions, use the existing evaluation
@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// rowIDColumn is the pseudo-column that exposes a row's storage key. It is
+// the row's insertion sequence number in tables without a primary key and the
+// key value in tables with a single INTEGER primary key. A real column named
+// rowid takes precedence.
+const rowIDColumn = "rowid"
+
+// SetUnorderedScans lets SELECTs without ORDER BY return rows in whatever
+// order is cheapest. By default they return rows in primary key order
+// (insertion order for tables without one), whichever access path is used.
+func (c *Catalog) SetUnorderedScans(unordered bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unorderedScans = unordered
+}
+
+// stableScanOrder reports whether a scan for stmt must return rows in key
+// order.
+func (cat *Catalog) stableScanOrder(stmt *query.SelectStmt) bool {
+	return !cat.unorderedScans && len(stmt.OrderBy) == 0
+}
+
+// sortedKeys returns keys in storage order without reordering the caller's
+// slice.
+func sortedKeys(keys []string) []string {
+	if slices.IsSorted(keys) {
+		return keys
+	}
+	sorted := slices.Clone(keys)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// selectReferencesRowID reports whether stmt mentions ROWID anywhere a
+// single-table scan evaluates it.
+func selectReferencesRowID(stmt *query.SelectStmt) bool {
+	exprs := make([]query.Expression, 0, len(stmt.Columns)+len(stmt.OrderBy)+1)
+	exprs = append(exprs, stmt.Columns...)
+	exprs = append(exprs, stmt.Where)
+	for _, ob := range stmt.OrderBy {
+		exprs = append(exprs, ob.Expr)
+	}
+	for _, expr := range exprs {
+		if expr != nil && checkExpressionReferencesColumn(expr, rowIDColumn) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRowIDColumn returns table with ROWID appended to its columns when stmt
+// needs it, or table itself when it does not. Scans of the returned
+// definition decode the stored columns and fill ROWID from each row's key.
+func withRowIDColumn(table *TableDef, stmt *query.SelectStmt) (*TableDef, error) {
+	if table.rowIDHidden || !selectReferencesRowID(stmt) {
+		return table, nil
+	}
+	if table.GetColumnIndex(rowIDColumn) >= 0 {
+		return table, nil
+	}
+	switch {
+	case len(table.PrimaryKey) == 0:
+	case len(table.PrimaryKey) == 1 && isIntegerPrimaryKey(table):
+	case hasSubqueries(stmt):
+		// The reference may belong to a subquery's table.
+		return table, nil
+	default:
+		return nil, fmt.Errorf("table %s has no ROWID: its primary key is not a single INTEGER column", table.Name)
+	}
+	shadow := *table
+	shadow.Columns = make([]ColumnDef, len(table.Columns), len(table.Columns)+1)
+	copy(shadow.Columns, table.Columns)
+	shadow.Columns = append(shadow.Columns, ColumnDef{Name: rowIDColumn, Type: "INTEGER", sourceTbl: table.Name})
+	shadow.columnIndices = nil
+	shadow.rowIDHidden = true
+	return &shadow, nil
+}
+
+func isIntegerPrimaryKey(table *TableDef) bool {
+	idx := table.GetColumnIndex(table.PrimaryKey[0])
+	return idx >= 0 && strings.EqualFold(table.Columns[idx].Type, "INTEGER")
+}
+
+// insertedRowID returns the ROWID of a row being inserted: its INTEGER
+// primary key value, or the generated key in a table without a primary key.
+// It returns 0 for tables that have no ROWID.
+func insertedRowID(table *TableDef, row []interface{}, autoIncValue int64) int64 {
+	switch {
+	case len(table.PrimaryKey) == 0:
+		return autoIncValue
+	case len(table.PrimaryKey) == 1 && isIntegerPrimaryKey(table):
+		if f, ok := toFloat64(row[table.GetColumnIndex(table.PrimaryKey[0])]); ok {
+			return int64(f)
+		}
+	}
+	return 0
+}
+
+// storedColumnCount is the number of columns a stored row of table holds.
+func (t *TableDef) storedColumnCount() int {
+	if t.rowIDHidden {
+		return len(t.Columns) - 1
+	}
+	return len(t.Columns)
+}
+
+// appendRowID returns row with the ROWID decoded from key appended.
+func appendRowID(row []interface{}, key string) []interface{} {
+	out := make([]interface{}, len(row), len(row)+1)
+	copy(out, row)
+	return append(out, rowIDFromKey(key))
+}
+
+// rowIDFromKey decodes an integer storage key built by formatKey.
+func rowIDFromKey(key string) interface{} {
+	digits := strings.TrimLeft(key, "0")
+	if digits == "" {
+		return int64(0)
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return n
+}
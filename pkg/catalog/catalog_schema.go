@@ -0,0 +1,230 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// MainSchema names the default schema: tables created without a schema
+// live in it. "public" is accepted as another name for it.
+const MainSchema = "main"
+
+// IsMainSchema reports whether name is the default schema.
+func IsMainSchema(name string) bool {
+	return strings.EqualFold(name, MainSchema) || strings.EqualFold(name, "public")
+}
+
+// CreateSchema creates a schema. Its tables are stored under their
+// qualified names, schema.table.
+func (c *Catalog) CreateSchema(stmt *query.CreateSchemaStmt) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.invalidateSchemaCache()
+
+	if err := validateTableName(stmt.Name); err != nil {
+		return fmt.Errorf("invalid schema name: %w", err)
+	}
+	if IsMainSchema(stmt.Name) || strings.EqualFold(stmt.Name, "temp") {
+		return fmt.Errorf("schema name %s is reserved", stmt.Name)
+	}
+	if _, exists := c.schemas[stmt.Name]; exists {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("schema %s already exists", stmt.Name)
+	}
+	if _, exists := c.attached[strings.ToLower(stmt.Name)]; exists {
+		return fmt.Errorf("database %s is already in use", stmt.Name)
+	}
+
+	if c.tree != nil {
+		if err := c.tree.Put([]byte("schema:"+stmt.Name), []byte(stmt.Name)); err != nil {
+			return err
+		}
+	}
+	if c.schemas == nil {
+		c.schemas = make(map[string]struct{})
+	}
+	c.schemas[stmt.Name] = struct{}{}
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{action: undoCreateSchema, schemaName: stmt.Name})
+	}
+	return nil
+}
+
+// DropSchema removes an empty schema. The caller drops the schema's tables
+// first for DROP SCHEMA ... CASCADE.
+func (c *Catalog) DropSchema(name string, ifExists bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.invalidateSchemaCache()
+
+	if _, exists := c.schemas[name]; !exists {
+		if ifExists {
+			return nil
+		}
+		return fmt.Errorf("schema %s does not exist", name)
+	}
+	if tables := c.schemaTablesLocked(name); len(tables) > 0 {
+		return fmt.Errorf("cannot drop schema %s: it contains tables %s (use CASCADE)", name, strings.Join(tables, ", "))
+	}
+
+	if c.tree != nil {
+		if err := c.tree.Delete([]byte("schema:" + name)); err != nil && !errors.Is(err, btree.ErrKeyNotFound) {
+			return err
+		}
+	}
+	delete(c.schemas, name)
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{action: undoDropSchema, schemaName: name})
+	}
+	return nil
+}
+
+// HasSchema reports whether a schema created with CreateSchema exists. The
+// main schema and attached databases are not counted.
+func (c *Catalog) HasSchema(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, exists := c.schemas[name]
+	return exists
+}
+
+// Schemas returns the names of the schemas created with CreateSchema.
+func (c *Catalog) Schemas() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.schemas))
+	for name := range c.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SchemaTables returns the qualified names of the tables in a schema.
+func (c *Catalog) SchemaTables(name string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.schemaTablesLocked(name)
+}
+
+func (c *Catalog) schemaTablesLocked(name string) []string {
+	var tables []string
+	for tableName := range c.tables {
+		if schema, _ := query.SplitTableName(tableName); schema == name {
+			tables = append(tables, tableName)
+		}
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// ResolveTableName finds an unqualified table, view or foreign table name
+// in the schemas of searchPath, in order, and returns the name it is stored
+// under: schema.name, or name itself for the main schema. Qualified names
+// and names found in none of the schemas are returned unchanged, so a name
+// the path misses still resolves in the main schema.
+func (c *Catalog) ResolveTableName(name string, searchPath []string) string {
+	if schema, _ := query.SplitTableName(name); schema != "" {
+		return name
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, schema := range searchPath {
+		if IsMainSchema(schema) {
+			if c.hasRelationLocked(name) {
+				return name
+			}
+			continue
+		}
+		qualified := schema + "." + name
+		if _, exists := c.schemas[schema]; exists {
+			if _, ok := c.tables[qualified]; ok {
+				return qualified
+			}
+			continue
+		}
+		if other, ok := c.attached[strings.ToLower(schema)]; ok {
+			if _, err := other.GetTable(name); err == nil {
+				return qualified
+			}
+		}
+	}
+	return name
+}
+
+// hasRelationLocked reports whether name is a table, view or foreign table
+// of the main schema.
+func (c *Catalog) hasRelationLocked(name string) bool {
+	if _, ok := c.tables[name]; ok {
+		return true
+	}
+	if _, ok := c.views[name]; ok {
+		return true
+	}
+	_, ok := c.foreignTables[name]
+	return ok
+}
+
+// checkTableSchemaLocked reports an error if a qualified table name names a
+// schema that does not exist.
+func (c *Catalog) checkTableSchemaLocked(name string) error {
+	schema, _ := query.SplitTableName(name)
+	if schema == "" {
+		return nil
+	}
+	if _, exists := c.schemas[schema]; !exists {
+		return fmt.Errorf("schema %s does not exist", schema)
+	}
+	return nil
+}
+
+// loadSchemasLocked reads the schemas stored in the catalog tree. Must be
+// called with c.mu held.
+func (c *Catalog) loadSchemasLocked() error {
+	if c.schemas == nil {
+		c.schemas = make(map[string]struct{})
+	}
+	iter, err := c.tree.Scan([]byte("schema:"), []byte("schema;"))
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to scan schema metadata: %w", err)
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		_, value, err := iter.NextString()
+		if err != nil {
+			return fmt.Errorf("load catalog: failed to read schema metadata: %w", err)
+		}
+		c.schemas[string(value)] = struct{}{}
+	}
+	return nil
+}
+
+func (c *Catalog) undoCreateSchemaEntry(entry undoEntry, errorPrefix string) error {
+	delete(c.schemas, entry.schemaName)
+	if c.tree != nil {
+		if err := c.tree.Delete([]byte("schema:" + entry.schemaName)); err != nil && !errors.Is(err, btree.ErrKeyNotFound) {
+			return fmt.Errorf("%s removing schema %s: %w", errorPrefix, entry.schemaName, err)
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) undoDropSchemaEntry(entry undoEntry, errorPrefix string) error {
+	if c.schemas == nil {
+		c.schemas = make(map[string]struct{})
+	}
+	c.schemas[entry.schemaName] = struct{}{}
+	if c.tree != nil {
+		if err := c.tree.Put([]byte("schema:"+entry.schemaName), []byte(entry.schemaName)); err != nil {
+			return fmt.Errorf("%s restoring schema %s: %w", errorPrefix, entry.schemaName, err)
+		}
+	}
+	return nil
+}
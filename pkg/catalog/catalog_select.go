@@ -9,7 +9,10 @@ import (
 	"strings"
 
 	"github.com/cobaltdb/cobaltdb/pkg/fdw"
+	"github.com/cobaltdb/cobaltdb/pkg/parallel"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
 )
 
 // tableOffset tracks column offsets for each table in a JOIN
@@ -20,12 +23,23 @@ type tableOffset struct {
 }
 
 // Select executes a SELECT statement and returns column names and matching rows.
-func (cat *Catalog) Select(stmt *query.SelectStmt, args []interface{}) ([]string, [][]interface{}, error) {
+func (cat *Catalog) Select(stmt *query.SelectStmt, args []interface{}) (_ []string, _ [][]interface{}, err error) {
+	span := tracing.StartBound("catalog.Select")
+	setSelectSpanAttributes(span, stmt)
+	defer func() { tracing.End(span, err) }()
+
 	cat.mu.RLock()
+	if cat.replacesTablesLocked(stmt) {
+		cat.mu.RUnlock()
+		return cat.selectExclusive(stmt, args)
+	}
 	defer cat.mu.RUnlock()
 
+	// Streamed results are never cached: the sink has consumed the rows.
+	sink := cat.budget().takeRowSink()
+
 	// Check if this query can be cached
-	if cat.queryCache != nil && query.IsCacheableQuery(stmt) {
+	if sink == nil && cat.queryCache != nil && query.IsCacheableQuery(stmt) && !query.ContainsFunctionCall(stmt, cat.isVolatileUserCall) && !cat.readsAttachedLocked(stmt) {
 		// Generate cache key from query and args using the same logic as cache.Cache
 		sql := query.QueryToSQL(stmt)
 
@@ -35,7 +49,7 @@ func (cat *Catalog) Select(stmt *query.SelectStmt, args []interface{}) ([]string
 		}
 
 		// Execute query (outermost path may release lock during scan)
-		columns, rows, err := cat.selectLockedInternal(stmt, args, true)
+		columns, rows, err := cat.selectLockedInternal(stmt, args, true, nil)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -47,7 +61,16 @@ func (cat *Catalog) Select(stmt *query.SelectStmt, args []interface{}) ([]string
 		return columns, rows, nil
 	}
 
-	return cat.selectLockedInternal(stmt, args, true)
+	return cat.selectLockedInternal(stmt, args, true, sink)
+}
+
+// selectExclusive runs a SELECT that replaces tables with rows of its own,
+// see replacesTablesLocked, under the catalog's exclusive lock: the rows
+// are installed where every query looks up CTE results.
+func (cat *Catalog) selectExclusive(stmt *query.SelectStmt, args []interface{}) ([]string, [][]interface{}, error) {
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+	return cat.selectLockedInternal(stmt, args, false, cat.budget().takeRowSink())
 }
 
 // SetRLSContext sets the context used for RLS user/role extraction in SELECT queries.
@@ -61,13 +84,28 @@ func (cat *Catalog) SetRLSContext(ctx context.Context) {
 // a different user — without this, two users sharing one catalog could see each
 // other's RLS identity (a cross-user data leak). Use only when RLS is enabled;
 // non-RLS reads should use the concurrent Select path.
-func (cat *Catalog) SelectWithContext(ctx context.Context, stmt *query.SelectStmt, args []interface{}) ([]string, [][]interface{}, error) {
+func (cat *Catalog) SelectWithContext(ctx context.Context, stmt *query.SelectStmt, args []interface{}) (_ []string, _ [][]interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.Select")
+	setSelectSpanAttributes(span, stmt)
+	defer func() { tracing.End(span, err) }()
+	defer tracing.Bind(ctx)()
+
 	cat.mu.Lock()
 	defer cat.mu.Unlock()
 	prev := cat.rlsCtx
 	cat.rlsCtx = ctx
 	defer func() { cat.rlsCtx = prev }()
-	return cat.selectLockedInternal(stmt, args, false)
+	// Row-level security filters results after the scan, so they are
+	// never streamed.
+	cat.budget().takeRowSink()
+	return cat.selectLockedInternal(stmt, args, false, nil)
+}
+
+// setSelectSpanAttributes names the table a SELECT reads, if it reads one.
+func setSelectSpanAttributes(span tracing.Span, stmt *query.SelectStmt) {
+	if tracing.Recording(span) && stmt.From != nil && stmt.From.Name != "" {
+		span.SetAttributes(tracing.String("db.sql.table", stmt.From.Name))
+	}
 }
 
 func (c *Catalog) executeScalarSelect(stmt *query.SelectStmt, args []interface{}) ([]string, [][]interface{}, error) {
@@ -266,7 +304,7 @@ func (c *Catalog) loadMainTableRowsWithFDWOptions(from *query.TableRef, scanOpti
 	// Get the main table
 	mainTable, err := c.getTableLocked(from.Name)
 	if err != nil {
-		return nil, nil, fmt.Errorf("table '%s' not found: %w", from.Name, err)
+		return nil, nil, sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table '%s' not found: %w", from.Name, err)
 	}
 
 	// Get all trees for scanning (handles partitioned tables)
@@ -277,6 +315,10 @@ func (c *Catalog) loadMainTableRowsWithFDWOptions(from *query.TableRef, scanOpti
 
 	var intermediateRows [][]interface{}
 	seen := make(map[string]int)
+	io, endIO := c.budget().beginTableIO(mainTable.Name)
+	defer endIO()
+	endScan := c.budget().beginOperator(OperatorScan, mainTable.Name)
+	defer func() { endScan(len(intermediateRows)) }()
 	for _, tree := range trees {
 		mainIter, err := tree.Scan(nil, nil)
 		if err != nil {
@@ -288,6 +330,7 @@ func (c *Catalog) loadMainTableRowsWithFDWOptions(from *query.TableRef, scanOpti
 				mainIter.Close()
 				return mainTable.Columns, nil, fmt.Errorf("select: failed to read table %s: %w", mainTable.Name, err)
 			}
+			io.addRow(len(data))
 			row, live, err := decodeLiveRow(data, len(mainTable.Columns))
 			if err != nil {
 				mainIter.Close()
@@ -373,7 +416,9 @@ func (c *Catalog) executeSelectWithJoin(stmt *query.SelectStmt, args []interface
 	}}
 
 	// Chain through each JOIN
+	budget := c.budget()
 	for _, join := range stmt.Joins {
+		endJoin := budget.beginOperator(OperatorJoin, join.Table.Name)
 		joinTableCols, joinRows, err := c.resolveJoinTable(join, args)
 		if err != nil {
 			return nil, nil, err
@@ -398,8 +443,11 @@ func (c *Catalog) executeSelectWithJoin(stmt *query.SelectStmt, args []interface
 
 		var newIntermediate [][]interface{}
 
-		// Convert USING clause to join condition if present
+		// Convert USING clause to join condition if present. resolveJoinUsing
+		// has already done so unless a table's columns were unknown before
+		// it ran.
 		joinCondition := join.Condition
+		using := join.Using
 
 		// Handle NATURAL JOIN - find common column names between tables
 		if join.Natural {
@@ -419,22 +467,23 @@ func (c *Catalog) executeSelectWithJoin(stmt *query.SelectStmt, args []interface
 					}
 				}
 			}
-			join.Using = commonCols
+			using = commonCols
 		}
 
-		if joinCondition == nil && len(join.Using) > 0 {
+		if joinCondition == nil && len(using) > 0 {
 			// Build condition from USING columns: left.col = right.col for each column
 			joinTableName := join.Table.Name
 			if join.Table.Alias != "" {
 				joinTableName = join.Table.Alias
 			}
-			joinCondition = c.buildUsingCondition(join.Using, tableOffsets, len(combinedColumns), joinTableCols, joinTableName)
+			joinCondition = c.buildUsingCondition(using, tableOffsets, len(combinedColumns), joinTableCols, joinTableName)
 		}
 
 		// joinRows is already populated above (from CTE result or B-tree scan)
 		rightRows := joinRows
 
 		newIntermediate = c.executeJoinPass(intermediateRows, rightRows, joinTableCols, combinedColumns, newCombinedColumns, joinCondition, args, isLeftJoin, isRightJoin, isCrossJoin, joinAlias)
+		endJoin(len(newIntermediate))
 
 		intermediateRows = newIntermediate
 		combinedColumns = newCombinedColumns
@@ -447,6 +496,7 @@ func (c *Catalog) executeSelectWithJoin(stmt *query.SelectStmt, args []interface
 
 	// Apply WHERE clause to joined rows
 	if stmt.Where != nil {
+		endFilter := budget.beginOperator(OperatorFilter, "")
 		var filteredRows [][]interface{}
 		for _, row := range intermediateRows {
 			matched, err := evaluateWhere(c, row, combinedColumns, stmt.Where, args)
@@ -456,8 +506,12 @@ func (c *Catalog) executeSelectWithJoin(stmt *query.SelectStmt, args []interface
 			filteredRows = append(filteredRows, row)
 		}
 		intermediateRows = filteredRows
+		endFilter(len(intermediateRows))
 	}
 
+	if err := checkOrderByPositions(stmt.OrderBy, len(selectCols)); err != nil {
+		return nil, nil, err
+	}
 	selectCols, hiddenOrderByCols := c.resolveHiddenJoinOrderByCols(stmt, selectCols, mainTableCols, mainAlias, combinedColumns, tableOffsets)
 
 	// Also resolve hidden columns for window function ORDER BY / PARTITION BY
@@ -501,6 +555,8 @@ func (c *Catalog) executeSelectWithJoin(stmt *query.SelectStmt, args []interface
 		resultRows = c.applyDistinct(resultRows)
 	}
 
+	endLimit := budget.beginLimit(stmt)
+
 	// Apply OFFSET
 	if stmt.Offset != nil {
 		offsetVal, err := evaluateExpression(c, nil, nil, stmt.Offset, args)
@@ -524,6 +580,7 @@ func (c *Catalog) executeSelectWithJoin(stmt *query.SelectStmt, args []interface
 			}
 		}
 	}
+	endLimit(len(resultRows))
 
 	return returnColumns, resultRows, nil
 }
@@ -591,7 +648,7 @@ func (c *Catalog) resolveHiddenJoinOrderByCols(stmt *query.SelectStmt, selectCol
 				} else {
 					for ci, cc := range mainTableCols {
 						if strings.EqualFold(cc.Name, colName) {
-							selectCols = append(selectCols, selectColInfo{name: colName, tableName: mainAlias, index: ci})
+							selectCols = append(selectCols, selectColInfo{name: colName, tableName: mainAlias, index: ci, collation: cc.Collation})
 							hiddenOrderByCols++
 							break
 						}
@@ -670,7 +727,7 @@ func (c *Catalog) resolveHiddenWindowCols(stmt *query.SelectStmt, selectCols *[]
 				// Unqualified — check combined columns.
 				for ci, col := range combinedColumns {
 					if toLowerFast(col.Name) == colLower {
-						*selectCols = append(*selectCols, selectColInfo{name: colName, tableName: mainAlias, index: ci})
+						*selectCols = append(*selectCols, selectColInfo{name: colName, tableName: mainAlias, index: ci, collation: col.Collation})
 						hiddenCols++
 						return
 					}
@@ -759,6 +816,7 @@ func (c *Catalog) executeSelectWithJoinAndGroupBy(stmt *query.SelectStmt, args [
 
 	// Apply WHERE clause to joined rows before GROUP BY
 	if stmt.Where != nil {
+		endFilter := c.budget().beginOperator(OperatorFilter, "")
 		var filteredRows [][]interface{}
 		for _, row := range intermediateRows {
 			matched, err := evaluateWhere(c, row, allColumns, stmt.Where, args)
@@ -768,7 +826,9 @@ func (c *Catalog) executeSelectWithJoinAndGroupBy(stmt *query.SelectStmt, args [
 			filteredRows = append(filteredRows, row)
 		}
 		intermediateRows = filteredRows
+		endFilter(len(intermediateRows))
 	}
+	endAggregate := c.budget().beginOperator(OperatorAggregate, "")
 
 	// joinedRows now contains properly filtered and chained results
 	joinedRows := intermediateRows
@@ -932,6 +992,7 @@ func (c *Catalog) executeSelectWithJoinAndGroupBy(stmt *query.SelectStmt, args [
 		}
 		resultRows = filtered
 	}
+	endAggregate(len(resultRows))
 
 	return returnColumns, resultRows, nil
 }
@@ -943,20 +1004,9 @@ func (c *Catalog) resolveJoinTable(join *query.JoinClause, args []interface{}) (
 	var joinTableCols []ColumnDef
 	var joinRows [][]interface{}
 
-	// Check if join table is a derived table (subquery or UNION)
-	if join.Table.Subquery != nil || join.Table.SubqueryStmt != nil {
-		subCols, subRows, err := c.executeDerivedTable(join.Table, args)
-		if err == nil {
-			joinTableCols = make([]ColumnDef, len(subCols))
-			for i, col := range subCols {
-				joinTableCols[i] = ColumnDef{Name: col, Type: "TEXT"}
-			}
-			joinRows = subRows
-		}
-	}
-
-	// Check if join table is a CTE result
-	if joinTableCols == nil && c.cteResults != nil {
+	// Check if join table is a CTE result or a derived table materialized
+	// under its alias
+	if c.cteResults != nil {
 		if cteRes, ok := c.cteResults[toLowerFast(join.Table.Name)]; ok {
 			joinTableCols = make([]ColumnDef, len(cteRes.columns))
 			for i, col := range cteRes.columns {
@@ -966,6 +1016,19 @@ func (c *Catalog) resolveJoinTable(join *query.JoinClause, args []interface{}) (
 		}
 	}
 
+	// Check if join table is a derived table (subquery or UNION)
+	if joinTableCols == nil && (join.Table.Subquery != nil || join.Table.SubqueryStmt != nil) {
+		subCols, subRows, err := c.executeDerivedTable(join.Table, args)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error in derived table '%s': %w", join.Table.Alias, err)
+		}
+		joinTableCols = make([]ColumnDef, len(subCols))
+		for i, col := range subCols {
+			joinTableCols[i] = ColumnDef{Name: col, Type: "TEXT"}
+		}
+		joinRows = subRows
+	}
+
 	if joinTableCols == nil {
 		// Check if it's a view (CTE registered as view)
 		if viewDef, viewErr := c.getViewLocked(join.Table.Name); viewErr == nil {
@@ -1066,17 +1129,19 @@ func materializedViewColumnNames(mv *MaterializedViewDef) []string {
 
 func (c *Catalog) executeJoinPass(intermediateRows [][]interface{}, rightRows [][]interface{}, joinTableCols []ColumnDef, combinedColumns []ColumnDef, newCombinedColumns []ColumnDef, joinCondition query.Expression, args []interface{}, isLeftJoin, isRightJoin, isCrossJoin bool, joinAlias string) [][]interface{} {
 	var newIntermediate [][]interface{}
+	budget := c.budget()
 
 	if isCrossJoin {
 		if len(rightRows) == 0 {
 			return nil
 		}
 		// Pre-calculate total capacity for cross join (leftRows * rightRows)
-		totalEst := len(intermediateRows) * len(rightRows)
+		totalEst := budget.capRows(len(intermediateRows) * len(rightRows))
 		if totalEst > 0 {
 			newIntermediate = make([][]interface{}, 0, totalEst)
 		}
 		for _, leftRow := range intermediateRows {
+			mark := len(newIntermediate)
 			leftLen := len(leftRow)
 			rightLen := len(rightRows[0]) // assuming uniform right rows
 			for _, joinRow := range rightRows {
@@ -1092,6 +1157,9 @@ func (c *Catalog) executeJoinPass(intermediateRows [][]interface{}, rightRows []
 				}
 				newIntermediate = append(newIntermediate, combined)
 			}
+			if !budget.chargeRows(newIntermediate[mark:]) {
+				return newIntermediate
+			}
 		}
 	} else {
 		// Pass the join alias so detectEqualityJoinQualified can handle qualified identifiers (t.col)
@@ -1101,7 +1169,7 @@ func (c *Catalog) executeJoinPass(intermediateRows [][]interface{}, rightRows []
 			leftColIdx, rightColIdx, canHashJoin = detectEqualityJoinUnique(joinCondition, combinedColumns, joinTableCols)
 		}
 
-		if canHashJoin && !isRightJoin {
+		if canHashJoin {
 			hashMap := make(map[string][]int)
 			for ri, joinRow := range rightRows {
 				if rightColIdx < len(joinRow) && joinRow[rightColIdx] != nil {
@@ -1114,59 +1182,33 @@ func (c *Catalog) executeJoinPass(intermediateRows [][]interface{}, rightRows []
 			// Handle empty rightRows - skip join entirely
 			if len(rightRows) == 0 {
 				if isLeftJoin {
-					return intermediateRows
+					return padJoinRows(intermediateRows, len(joinTableCols))
 				}
 				return nil
 			}
 
-			// Pre-allocate with estimated capacity for hash join
-			// Worst case: all left rows match all right rows
-			leftLen := len(intermediateRows)
-			rightLen := len(rightRows[0])
-			estimatedCombined := leftLen * rightLen
-			if estimatedCombined > 0 && estimatedCombined <= 10000000 { // cap at 10M
-				newIntermediate = make([][]interface{}, 0, estimatedCombined)
-			}
-
-			for _, leftRow := range intermediateRows {
-				matched := false
-				if leftColIdx < len(leftRow) && leftRow[leftColIdx] != nil {
-					key := hashJoinKey(leftRow[leftColIdx])
-					if indices, ok := hashMap[key]; ok {
-						combinedLen := len(leftRow) + rightLen
-						for _, ri := range indices {
-							combined := make([]interface{}, combinedLen)
-							copy(combined, leftRow)
-							copy(combined[len(leftRow):], rightRows[ri])
-							newIntermediate = append(newIntermediate, combined)
-							matched = true
-						}
-					}
-				}
-
-				if isLeftJoin && !matched {
-					combined := make([]interface{}, len(leftRow)+len(joinTableCols))
-					copy(combined, leftRow)
-					newIntermediate = append(newIntermediate, combined)
-				}
+			newIntermediate, _ = c.probeHashJoin(intermediateRows, rightRows, hashMap, leftColIdx, isLeftJoin, len(joinTableCols))
+			if isRightJoin {
+				newIntermediate = appendUnmatchedRightRows(newIntermediate, intermediateRows, rightRows, leftColIdx, rightColIdx, len(combinedColumns))
 			}
 		} else {
 			// Handle empty rightRows in nested loop join
 			if len(rightRows) == 0 {
 				if isLeftJoin {
-					return intermediateRows
+					return padJoinRows(intermediateRows, len(joinTableCols))
 				}
 				return nil
 			}
 
 			// Nested loop join - pre-allocate with capacity estimate
-			estimatedCombined := len(intermediateRows) * len(rightRows)
+			estimatedCombined := budget.capRows(len(intermediateRows) * len(rightRows))
 			if estimatedCombined > 0 && estimatedCombined <= 10000000 {
 				newIntermediate = make([][]interface{}, 0, estimatedCombined)
 			}
 			rightMatched := make([]bool, len(rightRows))
 
 			for _, leftRow := range intermediateRows {
+				mark := len(newIntermediate)
 				matched := false
 
 				for ri, joinRow := range rightRows {
@@ -1190,6 +1232,9 @@ func (c *Catalog) executeJoinPass(intermediateRows [][]interface{}, rightRows []
 					copy(combined, leftRow)
 					newIntermediate = append(newIntermediate, combined)
 				}
+				if !budget.chargeRows(newIntermediate[mark:]) {
+					return newIntermediate
+				}
 			}
 
 			if isRightJoin {
@@ -1207,24 +1252,112 @@ func (c *Catalog) executeJoinPass(intermediateRows [][]interface{}, rightRows []
 	return newIntermediate
 }
 
+// probeHashJoin joins each left row with the right rows hashMap lists under
+// its key, in left-row order. With isLeftJoin, unmatched left rows are kept
+// and padded with padCols NULLs. Large inputs are probed in partitions on the
+// worker pool and merged back in order, so the output matches a serial probe.
+// ok is false when the statement budget stopped the probe.
+func (c *Catalog) probeHashJoin(leftRows, rightRows [][]interface{}, hashMap map[string][]int, leftColIdx int, isLeftJoin bool, padCols int) (joined [][]interface{}, ok bool) {
+	budget := c.budget()
+	probeRow := func(out [][]interface{}, leftRow []interface{}) [][]interface{} {
+		matched := false
+		if leftColIdx < len(leftRow) && leftRow[leftColIdx] != nil {
+			for _, ri := range hashMap[hashJoinKey(leftRow[leftColIdx])] {
+				combined := make([]interface{}, len(leftRow)+len(rightRows[ri]))
+				copy(combined, leftRow)
+				copy(combined[len(leftRow):], rightRows[ri])
+				out = append(out, combined)
+				matched = true
+			}
+		}
+		if isLeftJoin && !matched {
+			combined := make([]interface{}, len(leftRow)+padCols)
+			copy(combined, leftRow)
+			out = append(out, combined)
+		}
+		return out
+	}
+
+	// Probe charges the budget, and checks the statement's context, whenever
+	// a block of left rows has been probed or a block of rows produced, so a
+	// key with many matches cannot outrun the check.
+	probe := func(b *statementBudget, start, end int) ([][]interface{}, bool) {
+		out := make([][]interface{}, 0, b.capRows(end-start))
+		mark, since := 0, 0
+		for _, leftRow := range leftRows[start:end] {
+			out = probeRow(out, leftRow)
+			if since++; since < budgetCheckInterval && len(out)-mark < budgetCheckInterval {
+				continue
+			}
+			if !b.chargeRows(out[mark:]) {
+				return out, false
+			}
+			mark, since = len(out), 0
+		}
+		return out, b.chargeRows(out[mark:])
+	}
+
+	if parts := c.parallelism(len(leftRows)); parts > 1 {
+		// Each partition charges what it produces against the shared
+		// budget, so a join that outgrows the statement stops early.
+		shared := budget.share(c)
+		joined = parallel.MapRanges(c.parallelPool, len(leftRows), parts, func(start, end int) [][]interface{} {
+			var out [][]interface{}
+			shared.run(func(b *statementBudget) error {
+				out, _ = probe(b, start, end)
+				return nil
+			})
+			return out
+		})
+		return joined, shared.settle() == nil
+	}
+	return probe(budget, 0, len(leftRows))
+}
+
+// appendUnmatchedRightRows appends to joined the right rows of an equality
+// hash join that no left row matched, padded on the left with leftWidth
+// NULLs, as RIGHT and FULL joins keep them. A right row matched if some left
+// row has its key; rows with a NULL key match nothing.
+func appendUnmatchedRightRows(joined, leftRows, rightRows [][]interface{}, leftColIdx, rightColIdx, leftWidth int) [][]interface{} {
+	leftKeys := make(map[string]struct{}, len(leftRows))
+	for _, leftRow := range leftRows {
+		if leftColIdx < len(leftRow) && leftRow[leftColIdx] != nil {
+			leftKeys[hashJoinKey(leftRow[leftColIdx])] = struct{}{}
+		}
+	}
+	for _, joinRow := range rightRows {
+		if rightColIdx < len(joinRow) && joinRow[rightColIdx] != nil {
+			if _, ok := leftKeys[hashJoinKey(joinRow[rightColIdx])]; ok {
+				continue
+			}
+		}
+		combined := make([]interface{}, leftWidth+len(joinRow))
+		copy(combined[leftWidth:], joinRow)
+		joined = append(joined, combined)
+	}
+	return joined
+}
+
+// padJoinRows returns rows each extended with n NULLs, the result of an
+// outer join whose other side is empty.
+func padJoinRows(rows [][]interface{}, n int) [][]interface{} {
+	padded := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		padded[i] = make([]interface{}, len(row)+n)
+		copy(padded[i], row)
+	}
+	return padded
+}
+
 // executeJoinChainForGroupBy chains through JOINs for GROUP BY queries.
 func (c *Catalog) executeJoinChainForGroupBy(stmt *query.SelectStmt, args []interface{}, intermediateRows [][]interface{}, allColumns []ColumnDef, mainTableCols []ColumnDef) ([][]interface{}, []ColumnDef, error) {
+	budget := c.budget()
 	for _, join := range stmt.Joins {
+		endJoin := budget.beginOperator(OperatorJoin, join.Table.Name)
 		var joinTableCols []ColumnDef
 		var rightRows [][]interface{}
 
-		if join.Table.Subquery != nil || join.Table.SubqueryStmt != nil {
-			subCols, subRows, err := c.executeDerivedTable(join.Table, args)
-			if err == nil {
-				joinTableCols = make([]ColumnDef, len(subCols))
-				for i, col := range subCols {
-					joinTableCols[i] = ColumnDef{Name: col, Type: "TEXT"}
-				}
-				rightRows = subRows
-			}
-		}
-
-		if joinTableCols == nil && c.cteResults != nil {
+		if c.cteResults != nil {
 			if cteRes, ok := c.cteResults[toLowerFast(join.Table.Name)]; ok {
 				joinTableCols = make([]ColumnDef, len(cteRes.columns))
 				for i, col := range cteRes.columns {
@@ -1234,6 +1367,18 @@ func (c *Catalog) executeJoinChainForGroupBy(stmt *query.SelectStmt, args []inte
 			}
 		}
 
+		if joinTableCols == nil && (join.Table.Subquery != nil || join.Table.SubqueryStmt != nil) {
+			subCols, subRows, err := c.executeDerivedTable(join.Table, args)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error in derived table '%s': %w", join.Table.Alias, err)
+			}
+			joinTableCols = make([]ColumnDef, len(subCols))
+			for i, col := range subCols {
+				joinTableCols[i] = ColumnDef{Name: col, Type: "TEXT"}
+			}
+			rightRows = subRows
+		}
+
 		if joinTableCols == nil {
 			if viewDef, viewErr := c.getViewLocked(join.Table.Name); viewErr == nil {
 				viewCols, viewRows, viewExecErr := c.selectLocked(viewDef, args)
@@ -1309,64 +1454,54 @@ func (c *Catalog) executeJoinChainForGroupBy(stmt *query.SelectStmt, args []inte
 
 		if isCrossJoin {
 			for _, leftRow := range intermediateRows {
+				mark := len(newIntermediate)
 				for _, joinRow := range rightRows {
 					combined := make([]interface{}, len(leftRow)+len(joinRow))
 					copy(combined, leftRow)
 					copy(combined[len(leftRow):], joinRow)
 					newIntermediate = append(newIntermediate, combined)
 				}
+				if !budget.chargeRows(newIntermediate[mark:]) {
+					return nil, nil, budget.err
+				}
 			}
 		} else {
-			// Try hash join first for INNER joins
-			if !isLeftJoin && !isRightJoin && len(rightRows) > 0 {
-				// Detect if we can use hash join
-				leftColIdx, rightColIdx, canHashJoin := detectEqualityJoinQualified(join.Condition, allColumns, joinTableCols, nil, joinAlias)
-				if !canHashJoin {
-					leftColIdx, rightColIdx, canHashJoin = detectEqualityJoinUnique(join.Condition, allColumns, joinTableCols)
-				}
-
-				if canHashJoin {
-					hashMap := make(map[string][]int)
-					for ri, joinRow := range rightRows {
-						if rightColIdx < len(joinRow) && joinRow[rightColIdx] != nil {
-							key := hashJoinKey(joinRow[rightColIdx])
-							existing := hashMap[key]
-							hashMap[key] = append(existing, ri)
-						}
-					}
-
-					if len(intermediateRows) > 0 && len(rightRows) > 0 {
-						rightLen := len(rightRows[0])
-						estimatedCombined := len(intermediateRows) * rightLen
-						if estimatedCombined > 0 && estimatedCombined <= 10000000 {
-							newIntermediate = make([][]interface{}, 0, estimatedCombined)
-						}
-					}
+			// Try hash join first
+			leftColIdx, rightColIdx, canHashJoin := detectEqualityJoinQualified(join.Condition, allColumns, joinTableCols, nil, joinAlias)
+			if !canHashJoin {
+				leftColIdx, rightColIdx, canHashJoin = detectEqualityJoinUnique(join.Condition, allColumns, joinTableCols)
+			}
 
-					for _, leftRow := range intermediateRows {
-						if leftColIdx < len(leftRow) && leftRow[leftColIdx] != nil {
-							key := hashJoinKey(leftRow[leftColIdx])
-							if indices, ok := hashMap[key]; ok {
-								for _, ri := range indices {
-									combined := make([]interface{}, len(leftRow)+len(rightRows[ri]))
-									copy(combined, leftRow)
-									copy(combined[len(leftRow):], rightRows[ri])
-									newIntermediate = append(newIntermediate, combined)
-								}
-							}
-						}
+			if canHashJoin {
+				hashMap := make(map[string][]int)
+				for ri, joinRow := range rightRows {
+					if rightColIdx < len(joinRow) && joinRow[rightColIdx] != nil {
+						key := hashJoinKey(joinRow[rightColIdx])
+						existing := hashMap[key]
+						hashMap[key] = append(existing, ri)
 					}
+				}
 
-					intermediateRows = newIntermediate
-					allColumns = newAllColumns
-					continue
+				var ok bool
+				newIntermediate, ok = c.probeHashJoin(intermediateRows, rightRows, hashMap, leftColIdx, isLeftJoin, len(joinTableCols))
+				if !ok {
+					return nil, nil, budget.err
+				}
+				if isRightJoin {
+					newIntermediate = appendUnmatchedRightRows(newIntermediate, intermediateRows, rightRows, leftColIdx, rightColIdx, len(allColumns))
 				}
+
+				endJoin(len(newIntermediate))
+				intermediateRows = newIntermediate
+				allColumns = newAllColumns
+				continue
 			}
 
-			// Fall back to nested loop join for OUTER joins or non-equality joins
+			// Fall back to nested loop join for non-equality joins
 			rightMatched := make([]bool, len(rightRows))
 
 			for _, leftRow := range intermediateRows {
+				mark := len(newIntermediate)
 				matched := false
 
 				for ri, joinRow := range rightRows {
@@ -1390,6 +1525,9 @@ func (c *Catalog) executeJoinChainForGroupBy(stmt *query.SelectStmt, args []inte
 					copy(combined, leftRow)
 					newIntermediate = append(newIntermediate, combined)
 				}
+				if !budget.chargeRows(newIntermediate[mark:]) {
+					return nil, nil, budget.err
+				}
 			}
 
 			if isRightJoin {
@@ -1403,6 +1541,7 @@ func (c *Catalog) executeJoinChainForGroupBy(stmt *query.SelectStmt, args []inte
 			}
 		}
 
+		endJoin(len(newIntermediate))
 		intermediateRows = newIntermediate
 		allColumns = newAllColumns
 	}
@@ -1410,16 +1549,32 @@ func (c *Catalog) executeJoinChainForGroupBy(stmt *query.SelectStmt, args []inte
 	return intermediateRows, allColumns, nil
 }
 
+// applyOrderBy sorts rows by orderBy. Rows that outgrow the statement's
+// spill memory are sorted externally; see sortRowsSpilling.
 func (c *Catalog) applyOrderBy(rows [][]interface{}, selectCols []selectColInfo, orderBy []*query.OrderByExpr) [][]interface{} {
 	if len(rows) == 0 || len(orderBy) == 0 {
 		return rows
 	}
+	endSort := c.budget().beginOperator(OperatorSort, "")
+	defer func() { endSort(len(rows)) }()
+	less := orderByLess(selectCols, orderBy)
+	if spilled, ok := c.sortRowsSpilling(rows, less); ok {
+		return spilled
+	}
+	if !c.budget().chargeRows(rows) {
+		return rows
+	}
 
-	// Build sort key function
 	sorted := make([][]interface{}, len(rows))
 	copy(sorted, rows)
+	sort.Slice(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+
+	return sorted
+}
 
-	sort.Slice(sorted, func(i, j int) bool {
+// orderByLess returns the comparison applyOrderBy sorts by.
+func orderByLess(selectCols []selectColInfo, orderBy []*query.OrderByExpr) func(a, b []interface{}) bool {
+	return func(a, b []interface{}) bool {
 		for obIdx, ob := range orderBy {
 			// Find column index by matching expression to selectCols
 			colIdx := -1
@@ -1487,11 +1642,11 @@ func (c *Catalog) applyOrderBy(rows [][]interface{}, selectCols []selectColInfo,
 					}
 				}
 			}
-			if colIdx < 0 || colIdx >= len(sorted[i]) || colIdx >= len(sorted[j]) {
+			if colIdx < 0 || colIdx >= len(a) || colIdx >= len(b) {
 				continue
 			}
 
-			ai, aj := sorted[i][colIdx], sorted[j][colIdx]
+			ai, aj := a[colIdx], b[colIdx]
 			if ai == nil || aj == nil {
 				if ai == nil && aj == nil {
 					continue // tie on this key; move to next
@@ -1508,7 +1663,7 @@ func (c *Catalog) applyOrderBy(rows [][]interface{}, selectCols []selectColInfo,
 				return !nullsFirst // j is NULL: i (non-NULL) first iff !nullsFirst
 			}
 
-			cmp := compareValues(ai, aj)
+			cmp := compareCollated(ai, aj, orderByCollation(ob, selectCols, colIdx))
 			if cmp != 0 {
 				if ob.Desc {
 					return cmp > 0
@@ -1517,9 +1672,7 @@ func (c *Catalog) applyOrderBy(rows [][]interface{}, selectCols []selectColInfo,
 			}
 		}
 		return false
-	})
-
-	return sorted
+	}
 }
 
 // buildUsingCondition creates a join condition from USING clause columns
@@ -1868,6 +2021,10 @@ func (c *Catalog) computeJoinGroupAggregates(stmt *query.SelectStmt, selectCols
 					}
 				}
 
+				if v, ok := c.userAggregateResult(ci.aggregateType, values, ci.isDistinct); ok {
+					resultRow[i] = v
+					continue
+				}
 				resultRow[i] = computeAggregateValue(c.selectColInfoWithGroupConcatSeparator(ci, aggregateRows, allColumns, args), values, aggregateRows)
 			} else {
 				colIdx := -1
@@ -2197,17 +2354,8 @@ func (cat *Catalog) applyOuterQueryAggregates(stmt *query.SelectStmt, filteredRo
 		}
 		if aliasName != "" {
 			returnCols[i] = aliasName
-		} else if fc, ok := actual.(*query.FunctionCall); ok {
-			fn := toUpperFast(fc.Name)
-			if len(fc.Args) > 0 {
-				returnCols[i] = fn + "()"
-			} else {
-				returnCols[i] = fn + "(*)"
-			}
-		} else if id, ok := actual.(*query.Identifier); ok {
-			returnCols[i] = id.Name
 		} else {
-			returnCols[i] = "col" + strconv.Itoa(i)
+			returnCols[i] = outerQueryColumnName(actual)
 		}
 	}
 
@@ -2292,11 +2440,7 @@ func (cat *Catalog) applyOuterQueryAggregates(stmt *query.SelectStmt, filteredRo
 			for _, ob := range stmt.OrderBy {
 				vi, _ := evaluateExpression(cat, resultRows[i], resultColumns, ob.Expr, args)
 				vj, _ := evaluateExpression(cat, resultRows[j], resultColumns, ob.Expr, args)
-				cmp := compareValues(vi, vj)
-				if ob.Desc {
-					cmp = -cmp
-				}
-				if cmp != 0 {
+				if cmp := compareOrderByValues(vi, vj, ob); cmp != 0 {
 					return cmp < 0
 				}
 			}
@@ -2360,10 +2504,11 @@ func (cat *Catalog) applyOuterQueryProjection(stmt *query.SelectStmt, filteredRo
 			for j, name := range viewCols {
 				mappings = append(mappings, colMapping{name: name, viewIdx: j, srcCol: srcIdx})
 			}
-		case *query.Identifier:
+		case *query.Identifier, *query.QualifiedIdentifier:
+			colName := outerQueryColumnName(c)
 			found := false
 			for j, name := range viewCols {
-				if strings.EqualFold(name, c.Name) {
+				if strings.EqualFold(name, colName) {
 					displayName := name
 					if aliasName != "" {
 						displayName = aliasName
@@ -2374,10 +2519,13 @@ func (cat *Catalog) applyOuterQueryProjection(stmt *query.SelectStmt, filteredRo
 				}
 			}
 			if !found {
-				mappings = append(mappings, colMapping{name: c.Name, viewIdx: -1, srcCol: srcIdx})
+				if aliasName != "" {
+					colName = aliasName
+				}
+				mappings = append(mappings, colMapping{name: colName, viewIdx: -1, srcCol: srcIdx})
 			}
 		default:
-			name := "expr"
+			name := outerQueryColumnName(c)
 			if aliasName != "" {
 				name = aliasName
 			}
@@ -2495,8 +2643,53 @@ func (cat *Catalog) applyOuterQueryProjection(stmt *query.SelectStmt, filteredRo
 	return returnCols, resultRows, nil
 }
 
+// outerQueryColumnName names an unaliased select-list column of a query over
+// a derived table the same way a query over a base table does: columns by their
+// name, aggregates as COUNT(*) or SUM(total), other functions as NAME().
+func outerQueryColumnName(expr query.Expression) string {
+	switch e := expr.(type) {
+	case *query.Identifier:
+		return e.Name
+	case *query.QualifiedIdentifier:
+		return e.Column
+	case *query.FunctionCall:
+		if !isAggregateCall(e) {
+			return e.Name + "()"
+		}
+		arg := "*"
+		if len(e.Args) > 0 {
+			switch a := e.Args[0].(type) {
+			case *query.Identifier:
+				arg = a.Name
+			case *query.QualifiedIdentifier:
+				arg = a.Column
+			case *query.StarExpr:
+			default:
+				arg = "expr"
+			}
+		}
+		if e.Distinct {
+			return e.Name + "(DISTINCT " + arg + ")"
+		}
+		return e.Name + "(" + arg + ")"
+	case *query.WindowExpr:
+		return e.Function + "()"
+	}
+	return "expr"
+}
+
 func (cat *Catalog) computeViewAggregate(fn string, fc *query.FunctionCall, rows [][]interface{}, columns []ColumnDef, args []interface{}) interface{} {
 	aggregateRows := cat.aggregateRowsForFunction(fc, rows, columns, args)
+	if cat.userAggregate(fn) != nil {
+		values := make([]interface{}, 0, len(aggregateRows))
+		for _, row := range aggregateRows {
+			if v, ok := cat.collectUserAggregateArgs(fc.Args, row, columns, args); ok {
+				values = append(values, v)
+			}
+		}
+		v, _ := cat.userAggregateResult(fn, values, fc.Distinct)
+		return v
+	}
 	// DISTINCT aggregates (e.g. over a derived table): materialize the argument
 	// values and reduce through the shared distinct-aware path so COUNT/SUM/AVG
 	// deduplicate. MIN/MAX are unaffected by dedup; GROUP_CONCAT dedups members.
@@ -86,6 +86,7 @@ func (cat *Catalog) applySelectPostProcess(p applySelectPostProcessParams) ([]st
 		rows = stripHiddenCols(rows, len(p.selectCols), p.hiddenOrderByCols)
 	}
 
+	endLimit := cat.budget().beginLimit(p.stmt)
 	if p.stmt.Offset != nil {
 		offsetVal, err := evaluateExpression(cat, nil, nil, p.stmt.Offset, p.args)
 		if err == nil {
@@ -107,6 +108,7 @@ func (cat *Catalog) applySelectPostProcess(p applySelectPostProcessParams) ([]st
 			}
 		}
 	}
+	endLimit(len(rows))
 
 	if !fullRowRLSApplied && cat.enableRLS && cat.rlsManager != nil && p.stmt.From != nil {
 		rlsCtx := cat.rlsCtx
@@ -259,14 +261,9 @@ func (cat *Catalog) executeCTEWindowQuery(stmt *query.SelectStmt, args []interfa
 						}
 					}
 				}
-				cmp := compareValues(va, vb)
-				if cmp == 0 {
-					continue
+				if cmp := compareOrderByValues(va, vb, ob); cmp != 0 {
+					return cmp < 0
 				}
-				if ob.Desc {
-					return cmp > 0
-				}
-				return cmp < 0
 			}
 			return false
 		})
@@ -368,7 +365,7 @@ func (cat *Catalog) resolveIdentifierColumn(
 				if aliasName != "" {
 					displayName = aliasName
 				}
-				return append(selectCols, selectColInfo{name: displayName, tableName: mainTableAlias, index: idx}), false
+				return append(selectCols, selectColInfo{name: displayName, tableName: mainTableAlias, index: idx, collation: table.Columns[idx].Collation}), false
 			}
 		} else {
 			for _, join := range stmt.Joins {
@@ -384,7 +381,7 @@ func (cat *Catalog) resolveIdentifierColumn(
 							if aliasName != "" {
 								displayName = aliasName
 							}
-							return append(selectCols, selectColInfo{name: displayName, tableName: joinAlias, index: idx}), false
+							return append(selectCols, selectColInfo{name: displayName, tableName: joinAlias, index: idx, collation: joinTable.Columns[idx].Collation}), false
 						}
 					}
 					break
@@ -399,7 +396,7 @@ func (cat *Catalog) resolveIdentifierColumn(
 		if aliasName != "" {
 			displayName = aliasName
 		}
-		return append(selectCols, selectColInfo{name: displayName, tableName: mainTableRef, index: idx}), false
+		return append(selectCols, selectColInfo{name: displayName, tableName: mainTableRef, index: idx, collation: table.Columns[idx].Collation}), false
 	}
 	// Not found in the main table: an unqualified column may belong to a joined
 	// table (e.g. SELECT id, x, y FROM a JOIN b ON ... where y is a column of b).
@@ -415,7 +412,7 @@ func (cat *Catalog) resolveIdentifierColumn(
 				if aliasName != "" {
 					displayName = aliasName
 				}
-				return append(selectCols, selectColInfo{name: displayName, tableName: joinAlias, index: idx}), false
+				return append(selectCols, selectColInfo{name: displayName, tableName: joinAlias, index: idx, collation: joinTable.Columns[idx].Collation}), false
 			}
 		}
 	}
@@ -427,10 +424,25 @@ func (cat *Catalog) resolveIdentifierColumn(
 // persistent catalog. Without this, JOINs whose table is a CTE silently drop
 // their columns from the projection.
 func (cat *Catalog) resolveJoinTableDef(ref *query.TableRef) (*TableDef, bool) {
-	// Derived table (subquery): infer output column names from its SELECT list
-	// so JOINs against a subquery don't drop the subquery's columns.
+	name := ref.Name
+	if cat.cteResults != nil {
+		if cteRes, ok := cat.cteResults[toLowerFast(name)]; ok {
+			cols := make([]ColumnDef, len(cteRes.columns))
+			for i, col := range cteRes.columns {
+				cols[i] = ColumnDef{Name: col, Type: "TEXT"}
+			}
+			return &TableDef{Name: name, Columns: cols}, true
+		}
+	}
+	// Derived table (subquery) not materialized yet: infer output column
+	// names from its SELECT list so JOINs against a subquery don't drop the
+	// subquery's columns.
 	if ref.Subquery != nil {
-		if names, ok := derivedSelectColumnNames(ref.Subquery); ok {
+		names, ok := derivedSelectColumnNames(ref.Subquery)
+		if len(ref.Columns) > 0 {
+			names, ok = ref.Columns, true
+		}
+		if ok {
 			cols := make([]ColumnDef, len(names))
 			for i, n := range names {
 				cols[i] = ColumnDef{Name: n, Type: "TEXT"}
@@ -438,12 +450,11 @@ func (cat *Catalog) resolveJoinTableDef(ref *query.TableRef) (*TableDef, bool) {
 			return &TableDef{Name: ref.Alias, Columns: cols}, true
 		}
 	}
-	name := ref.Name
-	if cat.cteResults != nil {
-		if cteRes, ok := cat.cteResults[toLowerFast(name)]; ok {
-			cols := make([]ColumnDef, len(cteRes.columns))
-			for i, col := range cteRes.columns {
-				cols[i] = ColumnDef{Name: col, Type: "TEXT"}
+	if view, err := cat.getViewLocked(name); err == nil {
+		if names, ok := derivedSelectColumnNames(view); ok {
+			cols := make([]ColumnDef, len(names))
+			for i, n := range names {
+				cols[i] = ColumnDef{Name: n, Type: "TEXT"}
 			}
 			return &TableDef{Name: name, Columns: cols}, true
 		}
@@ -495,7 +506,7 @@ func (cat *Catalog) resolveQualifiedColumn(
 
 	if targetTable == stmt.From.Name || targetTable == stmt.From.Alias {
 		if idx := table.GetColumnIndex(colName); idx >= 0 {
-			return append(selectCols, selectColInfo{name: colName, tableName: mainTableAlias, index: idx})
+			return append(selectCols, selectColInfo{name: colName, tableName: mainTableAlias, index: idx, collation: table.Columns[idx].Collation})
 		}
 	} else {
 		for _, join := range stmt.Joins {
@@ -507,7 +518,7 @@ func (cat *Catalog) resolveQualifiedColumn(
 				joinTable, ok := cat.resolveJoinTableDef(join.Table)
 				if ok {
 					if idx := joinTable.GetColumnIndex(colName); idx >= 0 {
-						return append(selectCols, selectColInfo{name: colName, tableName: joinAlias, index: idx})
+						return append(selectCols, selectColInfo{name: colName, tableName: joinAlias, index: idx, collation: joinTable.Columns[idx].Collation})
 					}
 				}
 				break
@@ -525,8 +536,8 @@ func (cat *Catalog) resolveStarColumns(
 	// star expands all tables.
 	wantMain := c.Table == "" || c.Table == stmt.From.Name || c.Table == stmt.From.Alias
 	if wantMain {
-		for i, tc := range table.Columns {
-			selectCols = append(selectCols, selectColInfo{name: tc.Name, tableName: mainTableRef, index: i})
+		for i, tc := range table.Columns[:table.storedColumnCount()] {
+			selectCols = append(selectCols, selectColInfo{name: tc.Name, tableName: mainTableRef, index: i, collation: tc.Collation})
 		}
 	}
 	for _, join := range stmt.Joins {
@@ -540,7 +551,7 @@ func (cat *Catalog) resolveStarColumns(
 		joinTable, ok := cat.resolveJoinTableDef(join.Table)
 		if ok {
 			for i, tc := range joinTable.Columns {
-				selectCols = append(selectCols, selectColInfo{name: tc.Name, tableName: joinAlias, index: i})
+				selectCols = append(selectCols, selectColInfo{name: tc.Name, tableName: joinAlias, index: i, collation: tc.Collation})
 			}
 		}
 	}
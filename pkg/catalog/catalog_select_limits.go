@@ -38,23 +38,17 @@ func evaluateSelectBound(c *Catalog, expr query.Expression, args []interface{},
 }
 
 func exactNonNegativeInt(value interface{}) (int, bool) {
-	switch v := value.(type) {
-	case int:
-		if v < 0 {
-			return 0, false
-		}
-		return v, true
-	case int64:
-		if v < 0 || v > int64(math.MaxInt) {
-			return 0, false
-		}
-		return int(v), true
-	case float64:
+	if v, ok := value.(float64); ok {
 		if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 || v > float64(math.MaxInt) || math.Trunc(v) != v {
 			return 0, false
 		}
 		return int(v), true
-	default:
+	}
+	// Any integer type: bound parameters arrive as whatever the caller or
+	// the wire decoder produced (int8 for small msgpack values).
+	v, ok := compareAsInt64(value)
+	if !ok || v < 0 || v > int64(math.MaxInt) {
 		return 0, false
 	}
+	return int(v), true
 }
@@ -0,0 +1,160 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// pkOrderLimit reports how many rows a SELECT needs when it can be answered
+// by reading its table in primary key order: a single-column key in ORDER BY
+// with a constant LIMIT (plus OFFSET). desc is true when the scan runs from
+// the largest key down. Everything else in the statement must be per-row, so
+// that the first rows that match are the ones the query returns; the usual
+// ORDER BY and LIMIT then run on just those.
+//
+// Non-negative integer keys sort as their values do, but the keys of negative
+// and fractional values do not: negative ones sort before every other key and
+// fractional ones after. scanPKOrder gives up when it meets one of them where
+// its value could rank among the rows it returns.
+func (cat *Catalog) pkOrderLimit(table *TableDef, stmt *query.SelectStmt, args []interface{}, collectFullRows bool) (need int, desc bool, ok bool) {
+	if len(stmt.OrderBy) != 1 || stmt.Limit == nil || stmt.Distinct || collectFullRows {
+		return 0, false, false
+	}
+	if len(table.PrimaryKey) != 1 || table.rowIDHidden || !cat.canApplySelectPostProcessUnlocked() {
+		return 0, false, false
+	}
+	var name string
+	switch e := stmt.OrderBy[0].Expr.(type) {
+	case *query.Identifier:
+		name = e.Name
+	case *query.QualifiedIdentifier:
+		name = e.Column
+	default:
+		return 0, false, false
+	}
+	if !strings.EqualFold(name, table.PrimaryKey[0]) {
+		return 0, false, false
+	}
+	// ORDER BY may name a select alias rather than the column.
+	for _, col := range stmt.Columns {
+		if alias, ok := col.(*query.AliasExpr); ok && strings.EqualFold(alias.Alias, name) {
+			if id, ok := alias.Expr.(*query.Identifier); !ok || !strings.EqualFold(id.Name, name) {
+				return 0, false, false
+			}
+		}
+	}
+
+	limitVal, err := evaluateExpression(cat, nil, nil, stmt.Limit, args)
+	if err != nil {
+		return 0, false, false
+	}
+	limit, ok := toInt(limitVal)
+	if !ok || limit <= 0 {
+		return 0, false, false
+	}
+	need = int(limit)
+	if stmt.Offset != nil {
+		offsetVal, err := evaluateExpression(cat, nil, nil, stmt.Offset, args)
+		if err != nil {
+			return 0, false, false
+		}
+		offset, ok := toInt(offsetVal)
+		if !ok || offset < 0 {
+			return 0, false, false
+		}
+		need += int(offset)
+	}
+	return need, stmt.OrderBy[0].Desc, true
+}
+
+// isIntegerKey reports whether key is formatKey's key for a non-negative
+// integer.
+func isIntegerKey(key string) bool {
+	if len(key) != 20 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '0' || key[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// scanPKOrder reads tree in key order, from its largest key down when desc
+// is set, until need rows are visible and match the WHERE clause, in pages of
+// ScanRange results that double in size. It returns ok=false, having
+// produced nothing, if it meets a key that is not a non-negative integer key,
+// as key order is not value order then. An ascending scan also gives up
+// unless the largest key is an integer one, since fractional keys sort after
+// every integer key whatever their value.
+func (cat *Catalog) scanPKOrder(table *TableDef, stmt *query.SelectStmt, args []interface{}, selectCols []selectColInfo, queryTime time.Time, tree btree.TreeStore, need int, desc bool, io *TableIOStats) ([][]interface{}, bool, error) {
+	if !desc {
+		last, err := tree.ScanRange(btree.ScanOptions{Reverse: true, Limit: 1})
+		if err != nil {
+			return nil, false, fmt.Errorf("select: failed to scan table %s: %w", table.Name, err)
+		}
+		integral := true
+		if last.HasNext() {
+			key, _, err := last.NextString()
+			integral = err == nil && isIntegerKey(key)
+		}
+		last.Close()
+		if !integral {
+			return nil, false, nil
+		}
+	}
+
+	budget := cat.budget()
+	rows := make([][]interface{}, 0, need)
+	opts := btree.ScanOptions{Reverse: desc, Limit: need}
+	for {
+		iter, err := tree.ScanRange(opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("select: failed to scan table %s: %w", table.Name, err)
+		}
+		read := 0
+		lastKey := ""
+		for iter.HasNext() && budget.alive() {
+			key, valueData, err := iter.NextString()
+			if err != nil {
+				iter.Close()
+				return nil, false, fmt.Errorf("select: failed to read table %s: %w", table.Name, err)
+			}
+			if !isIntegerKey(key) {
+				iter.Close()
+				return nil, false, nil
+			}
+			read++
+			lastKey = key
+			io.addRow(len(valueData))
+			selectedRow, _, ok, err := cat.filterAndProjectRow(key, valueData, table, stmt, selectCols, args, queryTime, false)
+			if err != nil {
+				iter.Close()
+				return nil, false, err
+			}
+			if !ok {
+				continue
+			}
+			rows = append(rows, selectedRow)
+			if len(rows) >= need {
+				iter.Close()
+				return rows, true, nil
+			}
+		}
+		iter.Close()
+		if read < opts.Limit || !budget.alive() {
+			return rows, true, nil
+		}
+		if desc {
+			opts.End = []byte(lastKey)
+		} else {
+			opts.Start = []byte(lastKey + "\x00")
+		}
+		opts.Limit *= 2
+	}
+}
@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// RowSink receives the result of a SELECT as the table is scanned, so a
+// caller exporting a large table never holds all of it in memory. Columns is
+// called once, before the first row. Rows are projected and filtered but
+// owned by the sink only until Row returns. An error from either method
+// stops the statement and is returned by it.
+type RowSink interface {
+	Columns(columns []string) error
+	Row(row []interface{}) error
+}
+
+// takeRowSink returns the statement's row sink the first time it is called
+// and nil after that, so only the outermost SELECT of a statement streams;
+// subqueries and view expansions collect their rows as usual.
+func (b *statementBudget) takeRowSink() RowSink {
+	if b == nil || b.rowsTaken {
+		return nil
+	}
+	b.rowsTaken = true
+	return b.limits.Rows
+}
+
+// canStreamSelect reports whether the rows of a plain single-table SELECT
+// scanned from trees are final as they are projected. Joins, grouping and
+// aggregates have been handled before it is asked; sorting, DISTINCT,
+// LIMIT/OFFSET, row-level security and a transaction's own pending writes
+// all need the full result first.
+func (cat *Catalog) canStreamSelect(stmt *query.SelectStmt, table *TableDef, trees []btree.TreeStore) bool {
+	if len(trees) != 1 || len(stmt.OrderBy) > 0 || stmt.Distinct || stmt.Limit != nil || stmt.Offset != nil {
+		return false
+	}
+	if !cat.canApplySelectPostProcessUnlocked() {
+		return false
+	}
+	if ts := cat.getCurrentTxn(); ts != nil {
+		if _, pending := ts.getPendingWriteMap()[table.Name]; pending {
+			return false
+		}
+	}
+	return true
+}
+
+// streamTableRows scans tree and hands each visible row that matches the
+// WHERE clause to snap.Rows.
+func (cat *Catalog) streamTableRows(snap TableSnapshot, stmt *query.SelectStmt, args []interface{}, queryTime time.Time, tree btree.TreeStore) error {
+	table := snap.Def
+	budget := cat.budget()
+	io, endIO := budget.beginTableIO(table.Name)
+	defer endIO()
+	streamed := 0
+	endScan := budget.beginScan(table.Name, stmt.Where != nil)
+	defer func() { endScan(streamed) }()
+
+	iter, err := tree.Scan(nil, nil)
+	if err != nil {
+		return fmt.Errorf("select: failed to scan table %s: %w", table.Name, err)
+	}
+	defer iter.Close()
+	numCols := len(table.Columns)
+	for iter.HasNext() && budget.alive() {
+		_, valueData, err := iter.NextString()
+		if err != nil {
+			return fmt.Errorf("select: failed to read table %s: %w", table.Name, err)
+		}
+		io.addRow(len(valueData))
+		vrow, err := decodeVersionedRow(valueData, numCols)
+		if err != nil {
+			return fmt.Errorf("select: failed to decode row in table %s: %w", table.Name, err)
+		}
+		if !vrow.Version.isVisibleAt(queryTime) {
+			continue
+		}
+		if stmt.Where != nil {
+			matched, err := evaluateWhere(cat, vrow.Data, table.Columns, stmt.Where, args)
+			if err != nil || !matched {
+				continue
+			}
+		}
+		if err := snap.Rows.Row(cat.projectSelectedRow(vrow.Data, snap.Columns, stmt, table, args, false)); err != nil {
+			return err
+		}
+		streamed++
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package catalog
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+type collectSink struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+func (s *collectSink) Columns(columns []string) error {
+	s.columns = columns
+	return nil
+}
+
+func (s *collectSink) Row(row []interface{}) error {
+	s.rows = append(s.rows, append([]interface{}(nil), row...))
+	return nil
+}
+
+func TestSelectRowSink(t *testing.T) {
+	c := newTestCatalog(t)
+	createTestTable(t, c, "t", []*query.ColumnDef{
+		{Name: "id", Type: query.TokenInteger, PrimaryKey: true},
+		{Name: "v", Type: query.TokenText},
+	})
+	for i := 1; i <= 50; i++ {
+		insertTestRow(t, c, "t", []query.Expression{
+			&query.NumberLiteral{Value: float64(i)},
+			&query.StringLiteral{Value: fmt.Sprintf("v%d", i)},
+		})
+	}
+	run := func(stmt *query.SelectStmt) (*collectSink, [][]interface{}) {
+		sink := &collectSink{}
+		end := c.BeginStatement(StatementLimits{Rows: sink})
+		_, rows, err := c.Select(stmt, nil)
+		if endErr := end(); err == nil {
+			err = endErr
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sink, rows
+	}
+	where := &query.BinaryExpr{Left: &query.Identifier{Name: "id"}, Operator: query.TokenGt, Right: &query.NumberLiteral{Value: 40}}
+
+	sink, rows := run(&query.SelectStmt{
+		Columns: []query.Expression{&query.Identifier{Name: "v"}},
+		From:    &query.TableRef{Name: "t"},
+		Where:   where,
+	})
+	if len(rows) != 0 || len(sink.rows) != 10 || fmt.Sprint(sink.columns) != "[v]" || sink.rows[0][0] != "v41" {
+		t.Errorf("streamed: rows = %v, sink = %+v", rows, sink)
+	}
+
+	// Sorted results are collected; the sink is not used.
+	sink, rows = run(&query.SelectStmt{
+		Columns: []query.Expression{&query.Identifier{Name: "v"}},
+		From:    &query.TableRef{Name: "t"},
+		Where:   where,
+		OrderBy: []*query.OrderByExpr{{Expr: &query.Identifier{Name: "id"}, Desc: true}},
+	})
+	if len(rows) != 10 || sink.columns != nil || len(sink.rows) != 0 {
+		t.Errorf("sorted: rows = %v, sink = %+v", rows, sink)
+	}
+}
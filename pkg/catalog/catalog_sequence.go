@@ -0,0 +1,520 @@
+package catalog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// User sequences (CREATE SEQUENCE / NEXTVAL).
+//
+// Sequence values are not transactional: a value NEXTVAL handed out is never
+// handed out again, even when the transaction that asked for it rolls back.
+// To keep that true across a crash without syncing the WAL on every call,
+// NEXTVAL logs a value sequenceLogAhead increments ahead and hands out values
+// up to it without logging. The catalog stores the same bound, so a crash can
+// skip up to sequenceLogAhead values but never repeats one.
+
+// sequenceLogAhead is the number of values one WAL record covers.
+const sequenceLogAhead = 32
+
+// SequenceDef is a sequence created with CREATE SEQUENCE.
+type SequenceDef struct {
+	Name      string `json:"name"`
+	Start     int64  `json:"start"`
+	Increment int64  `json:"increment"`
+	MinValue  int64  `json:"min_value"`
+	MaxValue  int64  `json:"max_value"`
+	Cycle     bool   `json:"cycle,omitempty"`
+	// Last is the last value handed out; meaningless until Called is set.
+	Last   int64 `json:"last"`
+	Called bool  `json:"called,omitempty"`
+
+	// reserved is the value durably recorded in the WAL or the catalog:
+	// NEXTVAL may hand out values up to it without logging.
+	reserved    int64
+	hasReserved bool
+}
+
+// newSequenceDef builds a sequence from CREATE SEQUENCE, filling in the
+// defaults of the options left out.
+func newSequenceDef(stmt *query.CreateSequenceStmt) (*SequenceDef, error) {
+	seq := &SequenceDef{Name: stmt.Name, Increment: 1, Cycle: stmt.Cycle}
+	if stmt.Increment != nil {
+		seq.Increment = *stmt.Increment
+	}
+	if seq.Increment == 0 {
+		return nil, fmt.Errorf("sequence %s: INCREMENT must not be zero", stmt.Name)
+	}
+	if seq.Increment > 0 {
+		seq.MinValue, seq.MaxValue = 1, math.MaxInt64
+	} else {
+		seq.MinValue, seq.MaxValue = math.MinInt64, -1
+	}
+	if stmt.MinValue != nil {
+		seq.MinValue = *stmt.MinValue
+	}
+	if stmt.MaxValue != nil {
+		seq.MaxValue = *stmt.MaxValue
+	}
+	if seq.MinValue >= seq.MaxValue {
+		return nil, fmt.Errorf("sequence %s: MINVALUE (%d) must be less than MAXVALUE (%d)", stmt.Name, seq.MinValue, seq.MaxValue)
+	}
+	seq.Start = seq.MinValue
+	if seq.Increment < 0 {
+		seq.Start = seq.MaxValue
+	}
+	if stmt.Start != nil {
+		seq.Start = *stmt.Start
+	}
+	if seq.Start < seq.MinValue || seq.Start > seq.MaxValue {
+		return nil, fmt.Errorf("sequence %s: START value %d is outside [%d, %d]", stmt.Name, seq.Start, seq.MinValue, seq.MaxValue)
+	}
+	seq.Last = seq.Start
+	return seq, nil
+}
+
+// next returns the value after Last without changing the sequence.
+func (s *SequenceDef) next() (int64, error) {
+	if !s.Called {
+		return s.Start, nil
+	}
+	if s.Increment > 0 && s.Last > s.MaxValue-s.Increment {
+		if !s.Cycle {
+			return 0, fmt.Errorf("sequence %s reached its maximum value %d", s.Name, s.MaxValue)
+		}
+		return s.MinValue, nil
+	}
+	if s.Increment < 0 && s.Last < s.MinValue-s.Increment {
+		if !s.Cycle {
+			return 0, fmt.Errorf("sequence %s reached its minimum value %d", s.Name, s.MinValue)
+		}
+		return s.MaxValue, nil
+	}
+	return s.Last + s.Increment, nil
+}
+
+// covered reports whether v lies within the durable reservation.
+func (s *SequenceDef) covered(v int64) bool {
+	if !s.hasReserved {
+		return false
+	}
+	if s.Increment > 0 {
+		return v <= s.reserved
+	}
+	return v >= s.reserved
+}
+
+// reservationFrom returns the bound sequenceLogAhead values from v, clamped
+// to the sequence's range.
+func (s *SequenceDef) reservationFrom(v int64) int64 {
+	const ahead = sequenceLogAhead - 1
+	if s.Increment > 0 {
+		if s.Increment > (s.MaxValue-v)/ahead {
+			return s.MaxValue
+		}
+		return v + ahead*s.Increment
+	}
+	if -s.Increment > (v-s.MinValue)/ahead {
+		return s.MinValue
+	}
+	return v + ahead*s.Increment
+}
+
+// durable returns the state to store: the reserved bound while values below
+// it may be in use, otherwise the sequence itself.
+func (s *SequenceDef) durable() (last int64, called bool) {
+	if s.hasReserved {
+		return s.reserved, true
+	}
+	return s.Last, s.Called
+}
+
+// CreateSequence creates a sequence from a CREATE SEQUENCE statement.
+func (c *Catalog) CreateSequence(stmt *query.CreateSequenceStmt) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.invalidateSchemaCache()
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+
+	if _, exists := c.sequences[stmt.Name]; exists {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("sequence %s already exists", stmt.Name)
+	}
+	seq, err := newSequenceDef(stmt)
+	if err != nil {
+		return err
+	}
+	// A WAL record of the initial state stops recovery from applying the
+	// records of an earlier sequence with the same name.
+	if err := c.logSequenceState(seq, seq.Last, false); err != nil {
+		return err
+	}
+	if err := c.storeSequenceDef(seq); err != nil {
+		return err
+	}
+	if c.sequences == nil {
+		c.sequences = make(map[string]*SequenceDef)
+	}
+	c.sequences[stmt.Name] = seq
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{action: undoCreateSequence, sequenceDef: seq})
+	}
+	return nil
+}
+
+// DropSequence removes a sequence.
+func (c *Catalog) DropSequence(name string, ifExists bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.invalidateSchemaCache()
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+
+	seq, exists := c.sequences[name]
+	if !exists {
+		if ifExists {
+			return nil
+		}
+		return fmt.Errorf("sequence %s not found", name)
+	}
+	if err := c.deleteCatalogDef("sequence:" + name); err != nil {
+		return fmt.Errorf("failed to delete sequence metadata %s: %w", name, err)
+	}
+	delete(c.sequences, name)
+	if c.isCurrentTxnActive() {
+		c.appendUndoEntry(undoEntry{action: undoDropSequence, sequenceDef: seq})
+	}
+	return nil
+}
+
+// GetSequence returns a copy of a sequence's definition and current state.
+func (c *Catalog) GetSequence(name string) (*SequenceDef, error) {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	seq, err := c.lookupSequenceLocked(name)
+	if err != nil {
+		return nil, err
+	}
+	out := *seq
+	return &out, nil
+}
+
+// ListSequences returns the names of all sequences.
+func (c *Catalog) ListSequences() []string {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	names := make([]string, 0, len(c.sequences))
+	for name := range c.sequences {
+		names = append(names, name)
+	}
+	return names
+}
+
+// NextVal advances a sequence and returns its new value.
+func (c *Catalog) NextVal(name string) (int64, error) {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	seq, err := c.lookupSequenceLocked(name)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := seq.next()
+	if err != nil {
+		return 0, err
+	}
+	if seq.Called && (seq.Increment > 0) != (v > seq.Last) {
+		// Wrapped around (CYCLE): the old reservation covers nothing ahead.
+		seq.hasReserved = false
+	}
+	if !seq.covered(v) {
+		bound := seq.reservationFrom(v)
+		if err := c.logSequenceState(seq, bound, true); err != nil {
+			return 0, err
+		}
+		if c.wal != nil {
+			seq.reserved, seq.hasReserved = bound, true
+		}
+	}
+	seq.Last, seq.Called = v, true
+	return v, nil
+}
+
+// CurrVal returns the value NEXTVAL last returned for a sequence.
+func (c *Catalog) CurrVal(name string) (int64, error) {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	seq, err := c.lookupSequenceLocked(name)
+	if err != nil {
+		return 0, err
+	}
+	if !seq.Called {
+		return 0, fmt.Errorf("CURRVAL of sequence %s is not yet defined", name)
+	}
+	return seq.Last, nil
+}
+
+// SetVal sets a sequence's current value. With called set the next NEXTVAL
+// returns the value after v, otherwise it returns v itself.
+func (c *Catalog) SetVal(name string, v int64, called bool) (int64, error) {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	seq, err := c.lookupSequenceLocked(name)
+	if err != nil {
+		return 0, err
+	}
+	if v < seq.MinValue || v > seq.MaxValue {
+		return 0, fmt.Errorf("SETVAL: value %d is outside the bounds of sequence %s [%d, %d]", v, name, seq.MinValue, seq.MaxValue)
+	}
+	if err := c.logSequenceState(seq, v, called); err != nil {
+		return 0, err
+	}
+	seq.Last, seq.Called = v, called
+	seq.hasReserved = false
+	if c.wal != nil && called {
+		seq.reserved, seq.hasReserved = v, true
+	}
+	return v, nil
+}
+
+// lookupSequenceLocked finds a sequence. It takes c.sequenceMu rather than
+// c.mu because NEXTVAL runs inside statements that already hold c.mu. Must be
+// called with c.sequenceMu held.
+func (c *Catalog) lookupSequenceLocked(name string) (*SequenceDef, error) {
+	seq, exists := c.sequences[name]
+	if !exists {
+		return nil, fmt.Errorf("sequence %s not found", name)
+	}
+	return seq, nil
+}
+
+// logSequenceState writes a sequence's state to the WAL and syncs it. It is
+// not part of any transaction: recovery applies it regardless.
+func (c *Catalog) logSequenceState(seq *SequenceDef, last int64, called bool) error {
+	if c.wal == nil {
+		return nil
+	}
+	var value [9]byte
+	binary.LittleEndian.PutUint64(value[:8], uint64(last)) // #nosec G115 -- round-tripped as int64.
+	if called {
+		value[8] = 1
+	}
+	data, err := encodeLogicalWALData("sequence", []byte(seq.Name), value[:])
+	if err != nil {
+		return err
+	}
+	return c.wal.Append(&storage.WALRecord{Type: storage.WALSequence, Data: data})
+}
+
+// replayUserSequenceLocked applies a logged sequence state. Records are
+// replayed in log order, so the last one wins. Must be called with c.mu held.
+func (c *Catalog) replayUserSequenceLocked(name string, value []byte) error {
+	if len(value) != 9 {
+		return fmt.Errorf("sequence state for %s has %d bytes", name, len(value))
+	}
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	seq, exists := c.sequences[name]
+	if !exists {
+		return nil
+	}
+	seq.Last = int64(binary.LittleEndian.Uint64(value[:8])) // #nosec G115 -- written from an int64.
+	seq.Called = value[8] == 1
+	seq.hasReserved = seq.Called
+	seq.reserved = seq.Last
+	return c.storeSequenceDef(seq)
+}
+
+// storeSequenceDef writes a sequence's definition and durable state to the
+// catalog tree. Must be called with c.mu and c.sequenceMu held.
+func (c *Catalog) storeSequenceDef(seq *SequenceDef) error {
+	if c.tree == nil {
+		return nil
+	}
+	out := *seq
+	out.Last, out.Called = seq.durable()
+	data, err := json.Marshal(&out)
+	if err != nil {
+		return err
+	}
+	return c.tree.Put([]byte("sequence:"+seq.Name), data)
+}
+
+// loadSequencesLocked reads the sequences stored in the catalog tree. A
+// stored sequence resumes after its stored value. Must be called with c.mu
+// held.
+func (c *Catalog) loadSequencesLocked() error {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	if c.sequences == nil {
+		c.sequences = make(map[string]*SequenceDef)
+	}
+	iter, err := c.tree.Scan([]byte("sequence:"), []byte("sequence;"))
+	if err != nil {
+		return fmt.Errorf("load catalog: failed to scan sequence metadata: %w", err)
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		keyStr, value, err := iter.NextString()
+		if err != nil {
+			return fmt.Errorf("load catalog: failed to read sequence metadata: %w", err)
+		}
+		if !strings.HasPrefix(keyStr, "sequence:") {
+			continue
+		}
+		var seq SequenceDef
+		if err := json.Unmarshal(value, &seq); err != nil {
+			return fmt.Errorf("load catalog: failed to parse sequence metadata %s: %w", keyStr, err)
+		}
+		if seq.Name == "" {
+			seq.Name = strings.TrimPrefix(keyStr, "sequence:")
+		}
+		seq.reserved, seq.hasReserved = seq.Last, seq.Called && c.wal != nil
+		c.sequences[seq.Name] = &seq
+	}
+	return nil
+}
+
+// ReleaseSequenceReservations drops the unused values reserved for every
+// sequence, so the next Save stores their exact state. Call it at a clean
+// shutdown, once no more NEXTVAL calls can run.
+func (c *Catalog) ReleaseSequenceReservations() {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	for _, seq := range c.sequences {
+		seq.hasReserved = false
+	}
+}
+
+// PersistSequences stores the table row sequences and user sequences that
+// moved since they were last stored, and flushes the catalog tree. A
+// checkpoint calls it before truncating the WAL, which would otherwise hold
+// the only record of those advances.
+func (c *Catalog) PersistSequences() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tree == nil {
+		return nil
+	}
+	changed := false
+	for _, table := range c.tables {
+		if table.Temporary || atomic.LoadInt64(&table.AutoIncSeq) == atomic.LoadInt64(&table.storedAutoIncSeq) {
+			continue
+		}
+		if err := c.storeTableDef(table); err != nil {
+			return fmt.Errorf("failed to save sequence of table %s: %w", table.Name, err)
+		}
+		changed = true
+	}
+	c.sequenceMu.Lock()
+	for _, seq := range c.sequences {
+		if err := c.storeSequenceDef(seq); err != nil {
+			c.sequenceMu.Unlock()
+			return fmt.Errorf("failed to save sequence %s: %w", seq.Name, err)
+		}
+		changed = true
+	}
+	c.sequenceMu.Unlock()
+	if !changed {
+		return nil
+	}
+	if err := c.tree.Flush(); err != nil {
+		return fmt.Errorf("failed to flush catalog tree: %w", err)
+	}
+	return nil
+}
+
+// saveSequencesLocked stores every sequence. Must be called with c.mu held.
+func (c *Catalog) saveSequencesLocked() error {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	for _, seq := range c.sequences {
+		if err := c.storeSequenceDef(seq); err != nil {
+			return fmt.Errorf("failed to save sequence %s: %w", seq.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) undoCreateSequenceEntry(entry undoEntry, errorPrefix string) error {
+	name := entry.sequenceDef.Name
+	c.sequenceMu.Lock()
+	delete(c.sequences, name)
+	c.sequenceMu.Unlock()
+	if c.tree != nil {
+		if err := c.tree.Delete([]byte("sequence:" + name)); err != nil && !errors.Is(err, btree.ErrKeyNotFound) {
+			return fmt.Errorf("%s removing sequence %s: %w", errorPrefix, name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) undoDropSequenceEntry(entry undoEntry, errorPrefix string) error {
+	c.sequenceMu.Lock()
+	defer c.sequenceMu.Unlock()
+	if c.sequences == nil {
+		c.sequences = make(map[string]*SequenceDef)
+	}
+	c.sequences[entry.sequenceDef.Name] = entry.sequenceDef
+	if err := c.storeSequenceDef(entry.sequenceDef); err != nil {
+		return fmt.Errorf("%s restoring sequence %s: %w", errorPrefix, entry.sequenceDef.Name, err)
+	}
+	return nil
+}
+
+// evaluateSequenceFunction handles NEXTVAL, CURRVAL and SETVAL.
+func (c *Catalog) evaluateSequenceFunction(funcName string, args []interface{}) (interface{}, bool, error) {
+	switch funcName {
+	case "NEXTVAL", "CURRVAL", "SETVAL":
+	default:
+		return nil, false, nil
+	}
+	if c == nil {
+		return nil, true, fmt.Errorf("%s requires a database", funcName)
+	}
+	minArgs, maxArgs := 1, 1
+	if funcName == "SETVAL" {
+		minArgs, maxArgs = 2, 3
+	}
+	if len(args) < minArgs || len(args) > maxArgs {
+		return nil, true, fmt.Errorf("%s takes %d to %d arguments, got %d", funcName, minArgs, maxArgs, len(args))
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, true, fmt.Errorf("%s requires a sequence name", funcName)
+	}
+	switch funcName {
+	case "NEXTVAL":
+		v, err := c.NextVal(name)
+		return v, true, err
+	case "CURRVAL":
+		v, err := c.CurrVal(name)
+		return v, true, err
+	}
+	f, ok := toFloat64(args[1])
+	if !ok || f != math.Trunc(f) {
+		return nil, true, fmt.Errorf("SETVAL requires an integer value")
+	}
+	called := true
+	if len(args) == 3 {
+		b, ok := args[2].(bool)
+		if !ok {
+			return nil, true, fmt.Errorf("SETVAL requires a boolean is_called argument")
+		}
+		called = b
+	}
+	v, err := c.SetVal(name, int64(f), called)
+	return v, true, err
+}
@@ -0,0 +1,69 @@
+package catalog
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Session is the state of one client connection that SQL functions can
+// read, such as the value LAST_INSERT_ROWID() returns. Statements see the
+// session passed in StatementLimits.Session. It is safe for concurrent use.
+type Session struct {
+	lastInsertRowID atomic.Int64
+	searchPath      atomic.Pointer[[]string]
+	unmask          atomic.Pointer[func(table string) bool]
+}
+
+// LastInsertRowID returns the ROWID of the row most recently inserted
+// through the session, or 0 if there is none.
+func (s *Session) LastInsertRowID() int64 {
+	return s.lastInsertRowID.Load()
+}
+
+// SetLastInsertRowID records id as the session's last inserted ROWID.
+func (s *Session) SetLastInsertRowID(id int64) {
+	s.lastInsertRowID.Store(id)
+}
+
+// SearchPath returns the schemas SET search_path chose for the session, or
+// nil if it has not set one.
+func (s *Session) SearchPath() []string {
+	if path := s.searchPath.Load(); path != nil {
+		return *path
+	}
+	return nil
+}
+
+// SetSearchPath records the session's search path; nil clears it.
+func (s *Session) SetSearchPath(path []string) {
+	if path == nil {
+		s.searchPath.Store(nil)
+		return
+	}
+	s.searchPath.Store(&path)
+}
+
+// session returns the session of the calling goroutine's statement, or nil
+// when it runs without one.
+func (c *Catalog) session() *Session {
+	for b := c.budget(); b != nil; b = b.prev {
+		if b.limits.Session != nil {
+			return b.limits.Session
+		}
+	}
+	return nil
+}
+
+// evaluateSessionFunction handles LAST_INSERT_ROWID.
+func (c *Catalog) evaluateSessionFunction(funcName string, args []interface{}) (interface{}, bool, error) {
+	if funcName != "LAST_INSERT_ROWID" {
+		return nil, false, nil
+	}
+	if len(args) != 0 {
+		return nil, true, fmt.Errorf("LAST_INSERT_ROWID takes no arguments, got %d", len(args))
+	}
+	if s := c.session(); s != nil {
+		return s.LastInsertRowID(), true, nil
+	}
+	return int64(0), true, nil
+}
@@ -0,0 +1,517 @@
+package catalog
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// Sorts and table GROUP BYs whose rows outgrow StatementLimits.SpillMemory
+// move them to temporary spill files instead of holding them all: ORDER BY
+// writes sorted runs and merges them, GROUP BY hashes its groups into
+// partitions and aggregates one partition at a time. Rows are written in a
+// small tagged binary form that keeps their Go types. A row holding a value
+// that form cannot carry keeps a step that has not spilled yet in memory.
+
+// Value tags of the spill encoding.
+const (
+	spillNil byte = iota
+	spillInt64
+	spillInt
+	spillFloat64
+	spillString
+	spillBytes
+	spillTrue
+	spillFalse
+	spillTime
+	spillList
+	spillMap
+	spillStringBox
+)
+
+// spillFile is a temporary file of rows, read back in the order they were
+// written. close removes it.
+type spillFile struct {
+	f      *os.File
+	w      *bufio.Writer
+	r      *bufio.Reader
+	buf    []byte
+	budget *statementBudget
+}
+
+// spillMemory returns the bytes a sort or grouping may hold before spilling,
+// or 0 if the statement never spills.
+func (b *statementBudget) spillMemory() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.limits.SpillMemory
+}
+
+// newSpillFile creates an empty spill file in the statement's spill
+// directory.
+func (b *statementBudget) newSpillFile() (*spillFile, error) {
+	dir := ""
+	if b != nil {
+		dir = b.limits.SpillDir
+	}
+	f, err := os.CreateTemp(dir, "cobaltdb-spill-*")
+	if err != nil {
+		return nil, fmt.Errorf("spill: %w", err)
+	}
+	if b != nil && b.limits.Stats != nil {
+		b.limits.Stats.SpillFiles++
+	}
+	return &spillFile{f: f, w: bufio.NewWriter(f), budget: b}, nil
+}
+
+// write appends row to the file.
+func (s *spillFile) write(row []interface{}) error {
+	s.buf = appendSpillRow(s.buf[:0], row)
+	if _, err := s.w.Write(s.buf); err != nil {
+		return fmt.Errorf("spill: %w", err)
+	}
+	if s.budget != nil && s.budget.limits.Stats != nil {
+		s.budget.limits.Stats.SpillBytes += int64(len(s.buf))
+	}
+	return nil
+}
+
+// rewind flushes the rows written so far and starts reading them from the
+// first.
+func (s *spillFile) rewind() error {
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("spill: %w", err)
+	}
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("spill: %w", err)
+	}
+	s.r = bufio.NewReader(s.f)
+	return nil
+}
+
+// read returns the next row, or io.EOF after the last.
+func (s *spillFile) read() ([]interface{}, error) {
+	n, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		return nil, err
+	}
+	row := make([]interface{}, n)
+	for i := range row {
+		if row[i], err = readSpillValue(s.r); err != nil {
+			return nil, fmt.Errorf("spill: %w", err)
+		}
+	}
+	return row, nil
+}
+
+func (s *spillFile) close() {
+	_ = s.f.Close()
+	_ = os.Remove(s.f.Name())
+}
+
+// sortRowsSpilling sorts rows that outgrow the statement's spill memory
+// externally: it cuts them into runs of about that size, sorts each run and
+// writes it to a spill file, then merges the runs. Rows written out are
+// cleared from rows. ok is false when the statement does not spill, the rows
+// fit or one of them cannot be spilled; the caller then sorts in memory.
+func (c *Catalog) sortRowsSpilling(rows [][]interface{}, less func(a, b []interface{}) bool) (sorted [][]interface{}, ok bool) {
+	b := c.budget()
+	limit := b.spillMemory()
+	if limit <= 0 {
+		return nil, false
+	}
+	var total int64
+	for _, row := range rows {
+		if !spillable(row) {
+			return nil, false
+		}
+		total += rowFootprint(row)
+	}
+	if total <= limit {
+		return nil, false
+	}
+	if !b.chargeBytes(limit) {
+		return rows, true
+	}
+
+	var runs []*spillFile
+	defer func() {
+		for _, run := range runs {
+			run.close()
+		}
+	}()
+	for start := 0; start < len(rows); {
+		end, held := start+1, rowFootprint(rows[start])
+		for end < len(rows) && held+rowFootprint(rows[end]) <= limit {
+			held += rowFootprint(rows[end])
+			end++
+		}
+		run := rows[start:end]
+		sort.SliceStable(run, func(i, j int) bool { return less(run[i], run[j]) })
+		f, err := b.newSpillFile()
+		if err != nil {
+			b.fail(err)
+			return nil, true
+		}
+		runs = append(runs, f)
+		for i, row := range run {
+			if err := f.write(row); err != nil {
+				b.fail(err)
+				return nil, true
+			}
+			run[i] = nil
+		}
+		if !b.alive() {
+			return nil, true
+		}
+		start = end
+	}
+
+	merge := &spillMerge{less: less}
+	for i, run := range runs {
+		if !merge.next(run, i) {
+			b.fail(merge.err)
+			return nil, true
+		}
+	}
+	heap.Init(merge)
+	sorted = make([][]interface{}, 0, len(rows))
+	for merge.Len() > 0 {
+		top := merge.items[0]
+		sorted = append(sorted, top.row)
+		heap.Pop(merge)
+		if !merge.next(runs[top.run], top.run) {
+			b.fail(merge.err)
+			return nil, true
+		}
+		if merge.Len() > 0 {
+			heap.Fix(merge, merge.Len()-1)
+		}
+	}
+	return sorted, true
+}
+
+// spillMerge is the heap merging the sorted runs of sortRowsSpilling. Rows
+// that compare equal come out in run order, so the merge keeps the order
+// the runs were cut in.
+type spillMerge struct {
+	items []spillMergeItem
+	less  func(a, b []interface{}) bool
+	err   error
+}
+
+type spillMergeItem struct {
+	row []interface{}
+	run int
+}
+
+// next reads the next row of run, numbered i, into the heap's last slot.
+// It reports false on a read error, kept in err.
+func (m *spillMerge) next(run *spillFile, i int) bool {
+	if run.r == nil {
+		if m.err = run.rewind(); m.err != nil {
+			return false
+		}
+	}
+	row, err := run.read()
+	if err == io.EOF {
+		return true
+	}
+	if err != nil {
+		m.err = err
+		return false
+	}
+	m.items = append(m.items, spillMergeItem{row: row, run: i})
+	return true
+}
+
+func (m *spillMerge) Len() int { return len(m.items) }
+
+func (m *spillMerge) Less(i, j int) bool {
+	a, b := m.items[i], m.items[j]
+	if m.less(a.row, b.row) {
+		return true
+	}
+	return !m.less(b.row, a.row) && a.run < b.run
+}
+
+func (m *spillMerge) Swap(i, j int) { m.items[i], m.items[j] = m.items[j], m.items[i] }
+
+func (m *spillMerge) Push(x interface{}) { m.items = append(m.items, x.(spillMergeItem)) }
+
+func (m *spillMerge) Pop() interface{} {
+	last := m.items[len(m.items)-1]
+	m.items = m.items[:len(m.items)-1]
+	return last
+}
+
+// groupSpillPartitions is how many partitions a spilling GROUP BY hashes
+// its groups into; each is aggregated in memory on its own.
+const groupSpillPartitions = 16
+
+// groupSpill holds the rows of a GROUP BY that outgrew the statement's spill
+// memory, hash-partitioned by group key so that every group lies in one
+// partition. Each record is the sequence number of the row's group, the
+// group key and the row.
+type groupSpill struct {
+	parts []*spillFile
+}
+
+// spillGroups moves groups to a new groupSpill. firstSeen holds the
+// sequence number of the first row of each group.
+func (b *statementBudget) spillGroups(groups map[string][][]interface{}, order []string, firstSeen map[string]int64) (*groupSpill, error) {
+	spill := &groupSpill{parts: make([]*spillFile, 0, groupSpillPartitions)}
+	for i := 0; i < groupSpillPartitions; i++ {
+		f, err := b.newSpillFile()
+		if err != nil {
+			spill.close()
+			return nil, err
+		}
+		spill.parts = append(spill.parts, f)
+	}
+	for _, key := range order {
+		for _, row := range groups[key] {
+			if err := spill.add(firstSeen[key], key, row); err != nil {
+				spill.close()
+				return nil, err
+			}
+		}
+	}
+	return spill, nil
+}
+
+// add writes row, numbered seq, to the partition of its group key.
+func (s *groupSpill) add(seq int64, key string, row []interface{}) error {
+	if !spillable(row) {
+		return fmt.Errorf("spill: row holds a value that cannot be spilled")
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	record := make([]interface{}, 0, 2+len(row))
+	record = append(record, seq, key)
+	return s.parts[h.Sum32()%uint32(len(s.parts))].write(append(record, row...))
+}
+
+func (s *groupSpill) close() {
+	for _, part := range s.parts {
+		part.close()
+	}
+}
+
+// computeSpilledGroupResultRows aggregates the groups of spill one partition
+// at a time, returning their result rows in the order the groups were first
+// seen, as computeGroupResultRows would.
+func (c *Catalog) computeSpilledGroupResultRows(spill *groupSpill, stmt *query.SelectStmt, selectCols []selectColInfo, table *TableDef, args []interface{}) ([][]interface{}, error) {
+	type seqRow struct {
+		seq int64
+		row []interface{}
+	}
+	var results []seqRow
+	for _, part := range spill.parts {
+		if err := part.rewind(); err != nil {
+			return nil, fmt.Errorf("group by: %w", err)
+		}
+		groups := make(map[string][][]interface{})
+		var order []string
+		firstSeen := make(map[string]int64)
+		for {
+			record, err := part.read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("group by: %w", err)
+			}
+			seq, _ := record[0].(int64)
+			key, _ := record[1].(string)
+			if _, exists := groups[key]; !exists {
+				order = append(order, key)
+				firstSeen[key] = seq
+			}
+			groups[key] = append(groups[key], record[2:])
+		}
+		for _, key := range order {
+			for _, row := range c.computeGroupResultRows(groups, []string{key}, stmt, selectCols, table, args) {
+				results = append(results, seqRow{seq: firstSeen[key], row: row})
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].seq < results[j].seq })
+	rows := make([][]interface{}, len(results))
+	for i, r := range results {
+		rows[i] = r.row
+	}
+	return rows, nil
+}
+
+// spillable reports whether every value of row can be written to a spill
+// file.
+func spillable(row []interface{}) bool {
+	for _, v := range row {
+		if !spillableValue(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func spillableValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, int64, int, float64, string, []byte, bool, time.Time:
+		return true
+	case StringBox:
+		return v.ptr != nil
+	case []interface{}:
+		return spillable(v)
+	case map[string]interface{}:
+		for _, e := range v {
+			if !spillableValue(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func appendSpillRow(buf []byte, row []interface{}) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(row)))
+	for _, v := range row {
+		buf = appendSpillValue(buf, v)
+	}
+	return buf
+}
+
+// appendSpillValue appends v, which must be spillable, to buf.
+func appendSpillValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, spillNil)
+	case int64:
+		return binary.AppendVarint(append(buf, spillInt64), v)
+	case int:
+		return binary.AppendVarint(append(buf, spillInt), int64(v))
+	case float64:
+		return binary.LittleEndian.AppendUint64(append(buf, spillFloat64), math.Float64bits(v))
+	case string:
+		buf = binary.AppendUvarint(append(buf, spillString), uint64(len(v)))
+		return append(buf, v...)
+	case StringBox:
+		buf = binary.AppendUvarint(append(buf, spillStringBox), uint64(len(*v.ptr)))
+		return append(buf, *v.ptr...)
+	case []byte:
+		buf = binary.AppendUvarint(append(buf, spillBytes), uint64(len(v)))
+		return append(buf, v...)
+	case bool:
+		if v {
+			return append(buf, spillTrue)
+		}
+		return append(buf, spillFalse)
+	case time.Time:
+		data, _ := v.MarshalBinary()
+		buf = binary.AppendUvarint(append(buf, spillTime), uint64(len(data)))
+		return append(buf, data...)
+	case []interface{}:
+		return appendSpillRow(append(buf, spillList), v)
+	case map[string]interface{}:
+		buf = binary.AppendUvarint(append(buf, spillMap), uint64(len(v)))
+		for k, e := range v {
+			buf = binary.AppendUvarint(buf, uint64(len(k)))
+			buf = appendSpillValue(append(buf, k...), e)
+		}
+		return buf
+	}
+	panic(fmt.Sprintf("spill: unspillable value of type %T", v))
+}
+
+func readSpillValue(r *bufio.Reader) (interface{}, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case spillNil:
+		return nil, nil
+	case spillInt64:
+		return binary.ReadVarint(r)
+	case spillInt:
+		n, err := binary.ReadVarint(r)
+		return int(n), err
+	case spillFloat64:
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[:])), nil
+	case spillString:
+		b, err := readSpillBytes(r)
+		return string(b), err
+	case spillStringBox:
+		b, err := readSpillBytes(r)
+		s := string(b)
+		return StringBox{ptr: &s}, err
+	case spillBytes:
+		return readSpillBytes(r)
+	case spillTrue:
+		return true, nil
+	case spillFalse:
+		return false, nil
+	case spillTime:
+		b, err := readSpillBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		var t time.Time
+		err = t.UnmarshalBinary(b)
+		return t, err
+	case spillList:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			if list[i], err = readSpillValue(r); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case spillMap:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{}, n)
+		for ; n > 0; n-- {
+			k, err := readSpillBytes(r)
+			if err != nil {
+				return nil, err
+			}
+			if m[string(k)], err = readSpillValue(r); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unknown value tag %d", tag)
+}
+
+func readSpillBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
@@ -0,0 +1,56 @@
+package catalog
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSpillFileRoundTrip(t *testing.T) {
+	stats := &StatementStats{}
+	b := &statementBudget{limits: StatementLimits{SpillDir: t.TempDir(), Stats: stats}}
+	f, err := b.newSpillFile()
+	if err != nil {
+		t.Fatalf("newSpillFile: %v", err)
+	}
+	defer f.close()
+
+	s := "boxed"
+	rows := [][]interface{}{
+		{nil, int64(-7), 3, 2.5, "text", []byte{0, 1}, true, false},
+		{time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC), StringBox{ptr: &s}},
+		{[]interface{}{int64(1), "a"}, map[string]interface{}{"k": 1.5, "n": nil}},
+		{},
+	}
+	for _, row := range rows {
+		if !spillable(row) {
+			t.Fatalf("row %v not spillable", row)
+		}
+		if err := f.write(row); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := f.rewind(); err != nil {
+		t.Fatalf("rewind: %v", err)
+	}
+	for i, want := range rows {
+		got, err := f.read()
+		if err != nil {
+			t.Fatalf("read row %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("row %d = %#v, want %#v", i, got, want)
+		}
+	}
+	if _, err := f.read(); err != io.EOF {
+		t.Fatalf("read past the end: err = %v, want io.EOF", err)
+	}
+	if stats.SpillFiles != 1 || stats.SpillBytes == 0 {
+		t.Fatalf("spill stats = %d files, %d bytes", stats.SpillFiles, stats.SpillBytes)
+	}
+
+	if spillable([]interface{}{struct{}{}}) || spillable([]interface{}{StringBox{}}) {
+		t.Fatal("unsupported values reported spillable")
+	}
+}
@@ -272,6 +272,9 @@ func tableRefToSQL(ref *query.TableRef) string {
 	if ref.Alias != "" {
 		sql += " AS " + ref.Alias
 	}
+	if len(ref.Columns) > 0 {
+		sql += " (" + strings.Join(ref.Columns, ", ") + ")"
+	}
 	return sql
 }
 
@@ -0,0 +1,55 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+)
+
+// Storage engines a table can be created with (CREATE TABLE ... USING).
+const (
+	// EngineBTree is the default B+Tree row store.
+	EngineBTree = ""
+	// EngineAppend is the append-optimized segment store for event and
+	// audit tables: writes append records, and compaction reclaims the
+	// space taken by updated and deleted rows.
+	EngineAppend = "append"
+)
+
+// tableEngine normalizes a USING clause into an engine name.
+func tableEngine(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", "btree":
+		return EngineBTree, nil
+	case EngineAppend:
+		return EngineAppend, nil
+	default:
+		return "", fmt.Errorf("unknown storage engine %q", name)
+	}
+}
+
+// newTableTree creates an empty data tree for a table stored with engine.
+func (c *Catalog) newTableTree(engine string) (btree.TreeStore, error) {
+	if engine == EngineAppend {
+		return btree.NewAppendStore(c.pool)
+	}
+	return btree.NewBTree(c.pool)
+}
+
+// openTableTree opens the data tree of a table stored with engine.
+func (c *Catalog) openTableTree(engine string, rootPageID uint32) (btree.TreeStore, error) {
+	if engine == EngineAppend {
+		return btree.OpenAppendStore(c.pool, rootPageID)
+	}
+	return btree.OpenBTreeStrict(c.pool, rootPageID)
+}
+
+// tableEngineFor returns the storage engine of the table whose data tree is
+// keyed name in c.tableTrees. Partition trees always use the B+Tree.
+func (c *Catalog) tableEngineFor(name string) string {
+	if td, ok := c.tables[name]; ok {
+		return td.Engine
+	}
+	return EngineBTree
+}
@@ -0,0 +1,30 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveTTLColumn checks that name, from CREATE TABLE ... WITH (ttl_column =
+// name), is a column of table that can hold an expiry time, and returns it
+// as declared. An INTEGER TTL column holds Unix seconds.
+func resolveTTLColumn(table *TableDef, name string) (string, error) {
+	idx := table.GetColumnIndex(name)
+	if idx < 0 {
+		return "", fmt.Errorf("table %s: TTL column %s does not exist", table.Name, name)
+	}
+	col := table.Columns[idx]
+	switch col.Type {
+	case "TIMESTAMP", "DATETIME", "DATE", "INTEGER":
+		return col.Name, nil
+	default:
+		return "", fmt.Errorf("table %s: TTL column %s must be TIMESTAMP, DATETIME, DATE or INTEGER, not %s", table.Name, col.Name, col.Type)
+	}
+}
+
+// renameTTLColumn follows a column rename in table's TTL column.
+func renameTTLColumn(table *TableDef, oldName, newName string) {
+	if table.TTLColumn != "" && strings.EqualFold(table.TTLColumn, oldName) {
+		table.TTLColumn = newName
+	}
+}
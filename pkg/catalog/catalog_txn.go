@@ -17,6 +17,7 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/cache"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
 	"github.com/cobaltdb/cobaltdb/pkg/security"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
 	"github.com/cobaltdb/cobaltdb/pkg/txn"
 )
@@ -104,6 +105,8 @@ func (c *Catalog) invalidateQueryCache(tableName string) {
 	if c.queryCache != nil {
 		c.queryCache.InvalidateTable(tableName)
 	}
+	// IN subqueries the statement already ran may have read the table.
+	c.budget().dropInSets()
 }
 
 func (c *Catalog) CreateRLSPolicy(policy *security.Policy) error {
@@ -174,7 +177,7 @@ func (c *Catalog) EnableRLSTable(tableName string) error {
 	defer c.invalidateSchemaCache()
 
 	if !c.hasTableLocked(tableName) {
-		return fmt.Errorf("table %s does not exist", tableName)
+		return sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table %s does not exist", tableName)
 	}
 	if c.rlsManager == nil {
 		c.rlsManager = security.NewManager()
@@ -261,6 +264,9 @@ func (c *Catalog) putTxnState(ts *catalogTxnState) {
 		ts.rowBuf[i] = nil
 	}
 	ts.valueDataBuf = ts.valueDataBuf[:0]
+	clear(ts.schemaRecords)
+	ts.schemaRecords = ts.schemaRecords[:0]
+	ts.schemaRecordsWritten = 0
 	for k := range ts.treeCache {
 		delete(ts.treeCache, k)
 	}
@@ -381,6 +387,12 @@ type pendingIdxOp struct {
 
 func (c *Catalog) CommitTransaction() error {
 	ts := c.getCurrentTxn()
+	ginWrites := c.ginPendingWrites(ts)
+	bloomWrites := c.bloomPendingWrites(ts)
+	loggedSchema := ts != nil && ts.schemaRecordsWritten+len(ts.schemaRecords) > 0
+	if err := c.writeSchemaRecords(ts); err != nil {
+		return err
+	}
 
 	// When a txn.Manager bridge is active, commit through the Manager first.
 	// This performs conflict detection and updates the version store.
@@ -392,6 +404,10 @@ func (c *Catalog) CommitTransaction() error {
 				pw := ts.pendingWrites[0]
 				shard := c.commitLockIdx(pw.TreeName, pw.Key)
 				c.commitMu[shard].Lock()
+				if err := c.checkPreparedRow(pw.TreeName, pw.Key); err != nil {
+					c.commitMu[shard].Unlock()
+					return err
+				}
 
 				// Validate reads using cached tree references (no c.mu needed).
 				if len(ts.readValues) > 0 {
@@ -422,6 +438,11 @@ func (c *Catalog) CommitTransaction() error {
 					c.commitMu[shard].Unlock()
 					return fmt.Errorf("partition tree %s not found", pw.TreeName)
 				}
+				capture := c.changeCaptureFunc()
+				var changes []RowChange
+				if capture != nil {
+					changes = collectRowChanges(ts.pendingWrites[:1], ts.treeCache)
+				}
 				var putErr error
 				if bt, ok := tree.(*btree.BTree); ok {
 					putErr = bt.PutString(pw.Key, pw.Value)
@@ -432,6 +453,9 @@ func (c *Catalog) CommitTransaction() error {
 					c.commitMu[shard].Unlock()
 					return fmt.Errorf("failed to apply buffered write to %s: %w", pw.TreeName, putErr)
 				}
+				if len(changes) > 0 {
+					capture(mt.CommitLSN, changes)
+				}
 				c.commitMu[shard].Unlock()
 				ts.pendingWrites = ts.pendingWrites[:0]
 				ts.pendingWriteMap = nil
@@ -504,6 +528,8 @@ func (c *Catalog) CommitTransaction() error {
 			}
 		}
 	}
+	c.applyGINWrites(ginWrites)
+	c.applyBloomWrites(bloomWrites)
 
 	// Legacy path: write WAL commit record when no Manager handled it.
 	managerHandledCommit := false
@@ -512,7 +538,9 @@ func (c *Catalog) CommitTransaction() error {
 			managerHandledCommit = true
 		}
 	}
-	if c.wal != nil && ts != nil && ts.txnActive && !managerHandledCommit {
+	// A manager commit writes nothing for a transaction without row writes,
+	// so DDL records get a commit record of their own.
+	if c.wal != nil && ts != nil && ts.txnActive && (!managerHandledCommit || loggedSchema) {
 		record := &storage.WALRecord{
 			TxnID: ts.txnID,
 			Type:  storage.WALCommit,
@@ -523,10 +551,12 @@ func (c *Catalog) CommitTransaction() error {
 	}
 
 	if ts == nil {
+		c.releaseCommittedTrees(c.undoLog)
 		c.undoLog = nil
 		c.savepoints = nil
 	}
 	if ts != nil {
+		c.releaseCommittedTrees(ts.undoLog)
 		ts.txnActive = false
 	}
 	c.unregisterGoroutineTxn()
@@ -583,6 +613,14 @@ func (c *Catalog) applyCommittedBatchWrites(
 		}
 	}
 
+	if c.preparedCount.Load() > 0 {
+		for _, pw := range ts.pendingWrites {
+			if err := c.checkPreparedRow(pw.TreeName, pw.Key); err != nil {
+				return err
+			}
+		}
+	}
+
 	for name := range tableKeys {
 		if _, exists := tableTrees[name]; !exists {
 			return fmt.Errorf("partition tree %s not found", name)
@@ -603,6 +641,12 @@ func (c *Catalog) applyCommittedBatchWrites(
 		return fmt.Errorf("txn manager commit: %w", err)
 	}
 
+	capture := c.changeCaptureFunc()
+	var changes []RowChange
+	if capture != nil {
+		changes = collectRowChanges(ts.pendingWrites, tableTrees)
+	}
+
 	// Apply writes while still holding locks.
 	for name, keys := range tableKeys {
 		tree := tableTrees[name]
@@ -622,6 +666,9 @@ func (c *Catalog) applyCommittedBatchWrites(
 			return fmt.Errorf("failed to apply buffered index deletes to %s: %w", name, err)
 		}
 	}
+	if len(changes) > 0 {
+		capture(mt.CommitLSN, changes)
+	}
 	ts.pendingWrites = ts.pendingWrites[:0]
 	ts.pendingWriteMap = nil
 	return nil
@@ -711,6 +758,7 @@ func (c *Catalog) RollbackTransaction() error {
 		}
 		// Discard buffered writes.
 		ts.pendingWrites = nil
+		ts.schemaRecords = nil
 	}
 
 	if c.wal != nil && ts != nil && ts.txnActive {
@@ -724,6 +772,9 @@ func (c *Catalog) RollbackTransaction() error {
 	}
 
 	undoLog := c.getCurrentTxnUndoLog()
+	defer c.rebuildFTSIndexes(c.ftsTablesInUndoLog(undoLog, 0))
+	defer c.rebuildGINIndexesAfterUndo(undoLog, 0)
+	defer c.rebuildBloomIndexesAfterUndo(undoLog, 0)
 	if len(undoLog) > 0 {
 		// Determine if the undo log contains any DDL entries.
 		hasDDL := false
@@ -864,6 +915,38 @@ func (c *Catalog) applyUndoEntry(entry undoEntry, errorPrefix string) error {
 		if entry.ftsIndexDef != nil {
 			c.ftsIndexes[entry.indexName] = cloneFTSIndexDef(entry.ftsIndexDef)
 		}
+	case undoCreateGINIndex:
+		delete(c.ginIndexes, entry.indexName)
+		c.ginCount.Store(int32(len(c.ginIndexes))) // #nosec G115 -- index count
+		if err := c.deleteCatalogDef("gin:" + entry.indexName); err != nil {
+			return fmt.Errorf("%s dropping GIN index %s: %w", errorPrefix, entry.indexName, err)
+		}
+	case undoDropGINIndex:
+		if entry.ginIndexDef != nil {
+			c.ginIndexes[entry.indexName] = entry.ginIndexDef
+			c.ginCount.Store(int32(len(c.ginIndexes))) // #nosec G115 -- index count
+			if table, ok := c.tables[entry.ginIndexDef.TableName]; ok && !table.Temporary {
+				if err := c.storeGINIndexDef(entry.ginIndexDef); err != nil {
+					return fmt.Errorf("%s restoring GIN index def %s: %w", errorPrefix, entry.indexName, err)
+				}
+			}
+		}
+	case undoCreateBloomIndex:
+		delete(c.bloomIndexes, entry.indexName)
+		c.bloomCount.Store(int32(len(c.bloomIndexes))) // #nosec G115 -- index count
+		if err := c.deleteCatalogDef("bloom:" + entry.indexName); err != nil {
+			return fmt.Errorf("%s dropping bloom index %s: %w", errorPrefix, entry.indexName, err)
+		}
+	case undoDropBloomIndex:
+		if entry.bloomIndexDef != nil {
+			c.bloomIndexes[entry.indexName] = entry.bloomIndexDef
+			c.bloomCount.Store(int32(len(c.bloomIndexes))) // #nosec G115 -- index count
+			if table, ok := c.tables[entry.bloomIndexDef.TableName]; ok && !table.Temporary {
+				if err := c.storeBloomIndexDef(entry.bloomIndexDef); err != nil {
+					return fmt.Errorf("%s restoring bloom index def %s: %w", errorPrefix, entry.indexName, err)
+				}
+			}
+		}
 	case undoCreateVectorIndex:
 		delete(c.vectorIndexes, entry.indexName)
 		if c.tree != nil {
@@ -923,6 +1006,14 @@ func (c *Catalog) applyUndoEntry(entry undoEntry, errorPrefix string) error {
 		return c.undoCreateProcedureEntry(entry, errorPrefix)
 	case undoDropProcedure:
 		return c.undoDropProcedureEntry(entry, errorPrefix)
+	case undoCreateSequence:
+		return c.undoCreateSequenceEntry(entry, errorPrefix)
+	case undoDropSequence:
+		return c.undoDropSequenceEntry(entry, errorPrefix)
+	case undoCreateSchema:
+		return c.undoCreateSchemaEntry(entry, errorPrefix)
+	case undoDropSchema:
+		return c.undoDropSchemaEntry(entry, errorPrefix)
 	case undoCreateMaterializedView:
 		return c.undoCreateMaterializedViewEntry(entry, errorPrefix)
 	case undoDropMaterializedView:
@@ -1173,6 +1264,7 @@ func (c *Catalog) undoAlterRenameColumnEntry(entry undoEntry, errorPrefix string
 	}
 	tbl.buildColumnIndexCache()
 	renameCheckColumnReferences(tbl, entry.newName, entry.oldName)
+	renameTTLColumn(tbl, entry.newName, entry.oldName)
 	for _, idxDef := range c.indexes {
 		if idxDef.TableName == entry.tableName {
 			for i, idxCol := range idxDef.Columns {
@@ -1405,9 +1497,11 @@ func isDDLUndo(a undoAction) bool {
 	switch a {
 	case undoCreateTable, undoDropTable, undoCreateIndex, undoDropIndex,
 		undoCreateFTSIndex, undoDropFTSIndex, undoCreateVectorIndex, undoDropVectorIndex,
+		undoCreateGINIndex, undoDropGINIndex, undoCreateBloomIndex, undoDropBloomIndex,
 		undoAlterAddColumn, undoAlterDropColumn, undoAlterRename, undoAlterRenameColumn, undoAlterForeignKeys, undoAlterChecks,
 		undoCreateView, undoDropView, undoCreateTrigger, undoDropTrigger,
-		undoCreateProcedure, undoDropProcedure,
+		undoCreateProcedure, undoDropProcedure, undoCreateSequence, undoDropSequence,
+		undoCreateSchema, undoDropSchema,
 		undoCreateMaterializedView, undoDropMaterializedView,
 		undoCreateForeignTable, undoDropForeignTable,
 		undoEnableRLSTable, undoCreateRLSPolicy, undoDropRLSPolicy:
@@ -1486,14 +1580,16 @@ func (c *Catalog) Savepoint(name string) error {
 	if !c.isCurrentTxnActive() {
 		return fmt.Errorf("SAVEPOINT can only be used within a transaction")
 	}
-	pwPos := 0
+	pwPos, schemaPos := 0, 0
 	if ts := c.getCurrentTxn(); ts != nil {
 		pwPos = len(ts.pendingWrites)
+		schemaPos = ts.schemaRecordsWritten + len(ts.schemaRecords)
 	}
 	sps := append(c.getCurrentTxnSavepoints(), savepointEntry{
 		name:            name,
 		undoPos:         len(c.getCurrentTxnUndoLog()),
 		pendingWritePos: pwPos,
+		schemaRecordPos: schemaPos,
 	})
 	c.setCurrentTxnSavepoints(sps)
 	return nil
@@ -1521,6 +1617,9 @@ func (c *Catalog) RollbackToSavepoint(name string) error {
 	pwPos := sps[spIdx].pendingWritePos
 
 	undoLog := c.getCurrentTxnUndoLog()
+	defer c.rebuildFTSIndexes(c.ftsTablesInUndoLog(undoLog, undoPos))
+	defer c.rebuildGINIndexesAfterUndo(undoLog, undoPos)
+	defer c.rebuildBloomIndexesAfterUndo(undoLog, undoPos)
 	if undoPos >= 0 && undoPos < len(undoLog) {
 		// Determine if affected undo entries contain DDL.
 		hasDDL := false
@@ -1576,6 +1675,7 @@ func (c *Catalog) RollbackToSavepoint(name string) error {
 				}
 				rebuildPendingWriteMap(ts)
 				reconcileManagerWriteSetAfterSavepoint(ts, tail)
+				truncateSchemaRecords(ts, sps[spIdx].schemaRecordPos)
 			}
 			c.setCurrentTxnSavepoints(sps[:spIdx+1])
 			return rollbackErr
@@ -1595,6 +1695,7 @@ func (c *Catalog) RollbackToSavepoint(name string) error {
 		}
 		rebuildPendingWriteMap(ts)
 		reconcileManagerWriteSetAfterSavepoint(ts, tail)
+		truncateSchemaRecords(ts, sps[spIdx].schemaRecordPos)
 	}
 	// Remove savepoints after this one (but keep the current savepoint)
 	c.setCurrentTxnSavepoints(sps[:spIdx+1])
@@ -1682,6 +1783,13 @@ func (c *Catalog) getCurrentManagerTxn() interface{} {
 	return nil
 }
 
+// CurrentManagerTxn returns the txn.Manager transaction of the calling
+// goroutine's catalog transaction, or nil if there is none.
+func (c *Catalog) CurrentManagerTxn() *txn.Transaction {
+	mt, _ := c.getCurrentManagerTxn().(*txn.Transaction)
+	return mt
+}
+
 // recordManagerRead records a read version and value for the given key in the
 // current txn.Manager transaction's ReadSet. This enables conflict detection
 // for read-modify-write cycles under SnapshotIsolation.
@@ -1770,11 +1878,53 @@ func (c *Catalog) EnableBufferedWrites() {
 	c.enableBufferedWrites = true
 }
 
+// SetRowLockTimeout makes buffered writes lock every row they write until
+// their transaction ends, so two transactions cannot both change a row: the
+// second waits up to d for the first to commit or roll back, and fails with
+// txn.ErrLockTimeout if it does not, or at once with txn.ErrDeadlockDetected
+// if its wait would close a cycle. A writer that waited and then finds the
+// row changed under it fails at commit with txn.ErrConflict. Zero, the
+// default, takes no row locks.
+func (c *Catalog) SetRowLockTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	c.rowLockTimeout.Store(int64(d))
+}
+
+// RowLockTimeout returns the timeout set by SetRowLockTimeout.
+func (c *Catalog) RowLockTimeout() time.Duration {
+	return time.Duration(c.rowLockTimeout.Load())
+}
+
+// lockRow takes the exclusive lock on the row stored under key in treeName
+// for ts's transaction, when row locks are enabled. Callers take it before
+// reading the row they are about to buffer a write for.
+func (c *Catalog) lockRow(ts *catalogTxnState, treeName, key string) error {
+	timeout := c.RowLockTimeout()
+	if timeout <= 0 || ts == nil {
+		return nil
+	}
+	mt, ok := ts.managerTxn.(*txn.Transaction)
+	if !ok || mt == nil {
+		return nil
+	}
+	mgr, ok := c.txnManager.(*txn.Manager)
+	if !ok || mgr == nil {
+		return nil
+	}
+	if err := mgr.AcquireLockMode(mt.ID, txn.RowLockKey(treeName, key), txn.LockExclusive, timeout); err != nil {
+		return fmt.Errorf("lock row of %s: %w", treeName, err)
+	}
+	return nil
+}
+
 // appendPendingWriteTs is the ts-cached variant of appendPendingWrite.
 func (c *Catalog) appendPendingWriteTs(ts *catalogTxnState, pw PendingWrite) {
 	if ts == nil {
 		return
 	}
+	history, versioned := c.historyWrite(ts, pw)
 	ts.pendingWrites = append(ts.pendingWrites, pw)
 	if len(ts.pendingWrites) > 1 {
 		if ts.pendingWriteMap == nil {
@@ -1796,6 +1946,9 @@ func (c *Catalog) appendPendingWriteTs(ts *catalogTxnState, pw PendingWrite) {
 			ts.treeCache[idx.IndexName] = c.indexTrees[idx.IndexName]
 		}
 	}
+	if versioned {
+		c.appendPendingWriteTs(ts, history)
+	}
 }
 
 // rebuildPendingWriteMap rebuilds the map index from the pendingWrites slice.
@@ -1891,15 +2044,33 @@ func (c *Catalog) indexKeyPendingState(indexName string, key string) int {
 // ReplayWALOps replays logical WAL operations (from txn.Manager commit) into
 // the primary B-trees.  This is called during database open after the catalog
 // has been loaded.  It restores committed data that may not have been flushed
-// to pages before a crash.  After replay, all indexes for affected tables are
-// rebuilt so that index trees stay consistent with the recovered table data.
+// to pages before a crash, along with the DDL and sequence advances logged
+// since the last checkpoint.  After replay, all indexes for affected tables
+// are rebuilt so that index trees stay consistent with the recovered table
+// data.
 func (c *Catalog) ReplayWALOps(ops []storage.WALReplayOp) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	affectedTables := make(map[string]struct{})
 
+	var sequences []storage.WALReplayOp
 	for _, op := range ops {
+		switch op.Type {
+		case storage.WALSequence:
+			// Applied last: a sequence record is recovered ahead of the
+			// uncommitted-until-then CREATE TABLE of its own transaction.
+			sequences = append(sequences, op)
+			continue
+		case storage.WALCreateTable, storage.WALDropTable, storage.WALAlterTable,
+			storage.WALCreateIndex, storage.WALDropIndex, storage.WALCreateView,
+			storage.WALDropView, storage.WALCreateTrigger, storage.WALDropTrigger:
+			if err := c.replaySchemaOpLocked(op, affectedTables); err != nil {
+				return fmt.Errorf("failed to replay WAL %v for txn %d: %w", op.Type, op.TxnID, err)
+			}
+			c.invalidateSchemaCache()
+			continue
+		}
 		switch op.Type {
 		case storage.WALInsert, storage.WALUpdate, storage.WALUpdateCommit:
 			key, value, err := parseReplayWALKeyValue(op.Data)
@@ -1942,6 +2113,12 @@ func (c *Catalog) ReplayWALOps(ops []storage.WALReplayOp) error {
 		}
 	}
 
+	for _, op := range sequences {
+		if err := c.replaySequenceLocked(op); err != nil {
+			return fmt.Errorf("failed to replay WAL sequence for txn %d: %w", op.TxnID, err)
+		}
+	}
+
 	for tableName := range affectedTables {
 		if err := c.rebuildTableIndexesLocked(tableName); err != nil {
 			return fmt.Errorf("failed to rebuild indexes for %s: %w", tableName, err)
@@ -8,11 +8,12 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/security"
 	"sort"
 	"sync/atomic"
-	"time"
 
 	"github.com/cobaltdb/cobaltdb/pkg/btree"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
 	"github.com/cobaltdb/cobaltdb/pkg/txn"
 )
 
@@ -37,7 +38,14 @@ type updateSnapshot struct {
 	triggers    []*query.CreateTriggerStmt
 }
 
-func (c *Catalog) Update(ctx context.Context, stmt *query.UpdateStmt, args []interface{}) (int64, int64, error) {
+func (c *Catalog) Update(ctx context.Context, stmt *query.UpdateStmt, args []interface{}) (_ int64, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "catalog.Update")
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.String("db.sql.table", stmt.Table))
+	}
+	defer func() { tracing.End(span, err) }()
+	defer tracing.Bind(ctx)()
+
 	// Fast path: resolve table metadata from schema cache without lock.
 	table, ver, cacheHit := c.getCachedTable(stmt.Table)
 	if !cacheHit {
@@ -91,18 +99,7 @@ func (c *Catalog) Update(ctx context.Context, stmt *query.UpdateStmt, args []int
 		return c.updateWithJoinLocked(ctx, stmt, args)
 	}
 
-	useBuffer := c.isBufferedMode() && table.Partition == nil
-	if useBuffer {
-		if c.hasVectorIndexForTableLocked(stmt.Table) {
-			useBuffer = false
-		}
-		for _, setClause := range stmt.Set {
-			if table.isPrimaryKeyColumn(setClause.Column) {
-				useBuffer = false
-				break
-			}
-		}
-	}
+	useBuffer := c.updateUsesBufferLocked(table, stmt)
 	ts := c.getCurrentTxn()
 
 	if !useBuffer {
@@ -178,6 +175,7 @@ func (c *Catalog) Update(ctx context.Context, stmt *query.UpdateStmt, args []int
 	}
 
 	c.invalidateQueryCache(stmt.Table)
+	c.noteModifiedRows(stmt.Table, rowsAffected)
 
 	c.setLastReturning(returningRows, returningCols)
 
@@ -265,6 +263,9 @@ func (c *Catalog) processUpdateRowDataSnapshot(ctx context.Context, table *Table
 			updatedRow[colIdx] = newVal
 		}
 	}
+	if err := c.applyColumnAffinity(table, updatedRow); err != nil {
+		return err
+	}
 	if allowed, rlsErr := c.checkRowCheckLocked(ctx, stmt.Table, table.Columns, updatedRow, security.PolicyUpdate); rlsErr != nil {
 		return fmt.Errorf("RLS WITH CHECK failed for UPDATE: %w", rlsErr)
 	} else if !allowed {
@@ -305,6 +306,7 @@ func (c *Catalog) hasUpdateUniqueConflict(tree btree.TreeStore, table *TableDef,
 	newVal interface{}, selfKey string, pendingKeys map[string]PendingWrite, collected []updateEntry) (bool, error) {
 
 	numCols := len(table.Columns)
+	coll := columnCollation(&table.Columns[colIdx])
 	overridden := make(map[string]struct{}, len(collected))
 	for i := range collected {
 		ek := string(collected[i].key)
@@ -313,7 +315,7 @@ func (c *Catalog) hasUpdateUniqueConflict(tree btree.TreeStore, table *TableDef,
 			continue
 		}
 		nr := collected[i].newRow
-		if colIdx < len(nr) && nr[colIdx] != nil && compareValues(newVal, nr[colIdx]) == 0 {
+		if colIdx < len(nr) && nr[colIdx] != nil && compareCollated(newVal, nr[colIdx], coll) == 0 {
 			return true, nil
 		}
 	}
@@ -331,7 +333,7 @@ func (c *Catalog) hasUpdateUniqueConflict(tree btree.TreeStore, table *TableDef,
 		if !live {
 			continue
 		}
-		if colIdx < len(vrow) && vrow[colIdx] != nil && compareValues(newVal, vrow[colIdx]) == 0 {
+		if colIdx < len(vrow) && vrow[colIdx] != nil && compareCollated(newVal, vrow[colIdx], coll) == 0 {
 			return true, nil
 		}
 	}
@@ -362,7 +364,7 @@ func (c *Catalog) hasUpdateUniqueConflict(tree btree.TreeStore, table *TableDef,
 		if !live {
 			continue
 		}
-		if colIdx < len(vrow) && vrow[colIdx] != nil && compareValues(newVal, vrow[colIdx]) == 0 {
+		if colIdx < len(vrow) && vrow[colIdx] != nil && compareCollated(newVal, vrow[colIdx], coll) == 0 {
 			return true, nil
 		}
 	}
@@ -374,7 +376,9 @@ func (c *Catalog) hasUpdateUniqueConflict(tree btree.TreeStore, table *TableDef,
 // collected holds the rows already staged by this UPDATE statement so that two rows
 // driven to the same unique value within one statement (or txn) are rejected.
 func (c *Catalog) checkConstraintsForUpdate(table *TableDef, tree btree.TreeStore, key []byte, oldRow, newRow []interface{}, snap *updateSnapshot, ts *catalogTxnState, args []interface{}, treeName string, collected []updateEntry) error {
-	applySelfReferentialUpdateCascades(table, oldRow, newRow)
+	if c.ForeignKeys() {
+		applySelfReferentialUpdateCascades(table, oldRow, newRow)
+	}
 
 	// Check UNIQUE constraints on table columns
 	var pendingKeys map[string]PendingWrite
@@ -388,7 +392,7 @@ func (c *Catalog) checkConstraintsForUpdate(table *TableDef, tree btree.TreeStor
 				return err
 			}
 			if conflict {
-				return fmt.Errorf("UNIQUE constraint failed: %s", col.Name)
+				return sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: %s", col.Name)
 			}
 		}
 	}
@@ -408,7 +412,7 @@ func (c *Catalog) checkConstraintsForUpdate(table *TableDef, tree btree.TreeStor
 		}
 		if idx.tree != nil {
 			if _, err := idx.tree.Get([]byte(newIdxKey)); err == nil {
-				return fmt.Errorf("UNIQUE constraint failed: duplicate value in index %s", idx.name)
+				return sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value in index %s", idx.name)
 			}
 		}
 	}
@@ -422,7 +426,7 @@ func (c *Catalog) checkConstraintsForUpdate(table *TableDef, tree btree.TreeStor
 	}
 
 	// Check FOREIGN KEY constraints using the snapshot FK references
-	for _, fk := range table.ForeignKeys {
+	for _, fk := range c.enforcedForeignKeys(table) {
 		if !foreignKeyColumnsChanged(table, fk, oldRow, newRow) {
 			continue
 		}
@@ -432,7 +436,7 @@ func (c *Catalog) checkConstraintsForUpdate(table *TableDef, tree btree.TreeStor
 		}
 		refSnap, ok := snap.fkRefs[fk.ReferencedTable]
 		if !ok || refSnap.tree == nil {
-			return fmt.Errorf("FOREIGN KEY constraint failed: referenced table not found")
+			return sqlerr.New(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: referenced table not found")
 		}
 		var pendingParents map[string]PendingWrite
 		if ts != nil {
@@ -444,11 +448,11 @@ func (c *Catalog) checkConstraintsForUpdate(table *TableDef, tree btree.TreeStor
 			var err error
 			found, err = referencedRowExistsSnapshot(refSnap.table, refSnap.tree, pendingParents, refColumns, fkValues)
 			if err != nil {
-				return fmt.Errorf("FOREIGN KEY constraint failed: failed to scan referenced table %s: %w", fk.ReferencedTable, err)
+				return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: failed to scan referenced table %s: %w", fk.ReferencedTable, err)
 			}
 		}
 		if !found {
-			return fmt.Errorf("FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
+			return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
 		}
 	}
 
@@ -490,7 +494,7 @@ func (c *Catalog) scanUpdateEntries(ctx context.Context, stmt *query.UpdateStmt,
 	for i, setClause := range stmt.Set {
 		setColumnIndices[i] = table.GetColumnIndex(setClause.Column)
 		if setColumnIndices[i] < 0 {
-			return nil, 0, fmt.Errorf("column '%s' not found in table '%s'", setClause.Column, stmt.Table)
+			return nil, 0, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found in table '%s'", setClause.Column, stmt.Table)
 		}
 	}
 
@@ -636,18 +640,7 @@ func (c *Catalog) updateLocked(ctx context.Context, stmt *query.UpdateStmt, args
 	}
 
 	// Determine if we can use buffered writes for this update.
-	useBuffer := c.isBufferedMode() && table.Partition == nil
-	if useBuffer {
-		if c.hasVectorIndexForTableLocked(stmt.Table) {
-			useBuffer = false
-		}
-		for _, setClause := range stmt.Set {
-			if table.isPrimaryKeyColumn(setClause.Column) {
-				useBuffer = false
-				break
-			}
-		}
-	}
+	useBuffer := c.updateUsesBufferLocked(table, stmt)
 
 	// Cache transaction state to avoid repeated goroutine-shard lookups.
 	ts := c.getCurrentTxn()
@@ -670,7 +663,7 @@ func (c *Catalog) updateLocked(ctx context.Context, stmt *query.UpdateStmt, args
 	for i, setClause := range stmt.Set {
 		setColumnIndices[i] = table.GetColumnIndex(setClause.Column)
 		if setColumnIndices[i] < 0 {
-			return 0, 0, fmt.Errorf("column '%s' not found in table '%s'", setClause.Column, stmt.Table)
+			return 0, 0, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found in table '%s'", setClause.Column, stmt.Table)
 		}
 	}
 
@@ -730,6 +723,24 @@ func (c *Catalog) updateLocked(ctx context.Context, stmt *query.UpdateStmt, args
 	return 0, rowsAffected, nil
 }
 
+// updateUsesBufferLocked reports whether an UPDATE of table can use
+// buffered writes: it cannot for partitioned tables, tables with vector or
+// full-text indexes, or when it changes the primary key.
+func (c *Catalog) updateUsesBufferLocked(table *TableDef, stmt *query.UpdateStmt) bool {
+	if !c.isBufferedMode() || table.Partition != nil {
+		return false
+	}
+	if c.hasVectorIndexForTableLocked(stmt.Table) || c.hasFTSIndexForTableLocked(stmt.Table) {
+		return false
+	}
+	for _, setClause := range stmt.Set {
+		if table.isPrimaryKeyColumn(setClause.Column) {
+			return false
+		}
+	}
+	return true
+}
+
 // resolveUpdateTargetRows is phase 1 of updateLocked: it walks every
 // partition tree, overlays pending writes (so buffered-mode updates see
 // their own prior mutations), evaluates the WHERE clause, and assembles
@@ -990,6 +1001,7 @@ func (c *Catalog) applyUpdateIndexes(
 
 	// Invalidate query cache for the affected table
 	c.invalidateQueryCache(stmt.Table)
+	c.noteModifiedRows(stmt.Table, int64(len(entries)))
 
 	// Store returning rows for retrieval
 	c.setLastReturning(returningRows, returningCols)
@@ -1036,15 +1048,15 @@ func (c *Catalog) updateWithJoinLocked(ctx context.Context, stmt *query.UpdateSt
 		}
 	}
 	for _, tref := range sourceRefs {
-		srcTable, srcErr := c.getTableLocked(tref.Name)
-		if srcErr != nil {
-			continue // Source may be a subquery/CTE we cannot introspect here
-		}
 		tableAlias := tref.Name
 		if tref.Alias != "" {
 			tableAlias = tref.Alias
 		}
-		for _, col := range srcTable.Columns {
+		srcCols, srcErr := c.joinSourceColumnsLocked(tref, args)
+		if srcErr != nil {
+			return 0, 0, srcErr
+		}
+		for _, col := range srcCols {
 			selectColumns = append(selectColumns, &query.QualifiedIdentifier{Table: tableAlias, Column: col.Name})
 			cd := col
 			cd.sourceTbl = tableAlias
@@ -1052,20 +1064,18 @@ func (c *Catalog) updateWithJoinLocked(ctx context.Context, stmt *query.UpdateSt
 		}
 	}
 
+	// The target table comes first: the FROM sources are cross joined to
+	// it and the JOINs follow, so the WHERE clause does the correlation.
 	selectStmt := &query.SelectStmt{
 		Columns: selectColumns,
 		From:    &query.TableRef{Name: stmt.Table, Alias: stmt.Alias},
 		Joins:   stmt.Joins,
 		Where:   stmt.Where,
 	}
-
-	// If FROM clause exists, use it as the main table and add target as first join
 	if stmt.From != nil {
-		selectStmt.From = stmt.From
-		// Add target table as first join with no condition
 		selectStmt.Joins = append([]*query.JoinClause{{
 			Type:  query.TokenJoin,
-			Table: &query.TableRef{Name: stmt.Table, Alias: stmt.Alias},
+			Table: stmt.From,
 		}}, stmt.Joins...)
 	}
 
@@ -1075,13 +1085,9 @@ func (c *Catalog) updateWithJoinLocked(ctx context.Context, stmt *query.UpdateSt
 		return 0, 0, fmt.Errorf("failed to execute UPDATE join: %w", err)
 	}
 
-	// Find primary key column index in the target table
-	pkColIdx := 0
-	if len(targetTable.PrimaryKey) > 0 {
-		pkColIdx = targetTable.GetColumnIndex(targetTable.PrimaryKey[0])
-		if pkColIdx < 0 {
-			pkColIdx = 0
-		}
+	targetKeys, err := c.joinTargetKeysLocked(targetTable, targetTree)
+	if err != nil {
+		return 0, 0, err
 	}
 
 	// Map each target row's key to the first joined row that matched it, so
@@ -1091,9 +1097,7 @@ func (c *Catalog) updateWithJoinLocked(ctx context.Context, stmt *query.UpdateSt
 	keyToJoinedRow := make(map[string][]interface{})
 	keyOrder := make([]string, 0)
 	for _, row := range resultRows {
-		if pkColIdx < len(row) && row[pkColIdx] != nil {
-			key := c.serializePK(row[pkColIdx], targetTree)
-			k := string(key)
+		for _, k := range targetKeys(row) {
 			if _, seen := keyToJoinedRow[k]; !seen {
 				keyToJoinedRow[k] = row
 				keyOrder = append(keyOrder, k)
@@ -1105,45 +1109,49 @@ func (c *Catalog) updateWithJoinLocked(ctx context.Context, stmt *query.UpdateSt
 		return 0, 0, nil // No rows to update
 	}
 
-	// Now update each row
-	rowsAffected := int64(0)
-
-	var entries []joinUpdateEntry
-
 	// Pre-calculate column indices for SET clauses
 	setColumnIndices := make([]int, len(stmt.Set))
 	for i, setClause := range stmt.Set {
 		setColumnIndices[i] = targetTable.GetColumnIndex(setClause.Column)
 		if setColumnIndices[i] < 0 {
-			return 0, 0, fmt.Errorf("column '%s' not found in table '%s'", setClause.Column, stmt.Table)
+			return 0, 0, sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column '%s' not found in table '%s'", setClause.Column, stmt.Table)
 		}
 	}
 
-	// Iterate over keys to update (deterministic: iteration order of keyOrder
-	// mirrors match order, which matches existing behavior).
+	useBuffer := c.updateUsesBufferLocked(targetTable, stmt)
+	ts := c.getCurrentTxn()
+	txnActive := ts != nil && ts.txnActive
+	var pendingKeys map[string]PendingWrite
+	if ts != nil {
+		pendingKeys = ts.getPendingWriteMap()[stmt.Table]
+	}
+
+	// Build the update entries in match order. The current row is read
+	// through this transaction's pending writes, and SET expressions are
+	// evaluated against the joined row so they can reference the sources.
+	var entries []updateEntry
+	rowsAffected := int64(0)
 	for _, keyStr := range keyOrder {
 		key := []byte(keyStr)
 		valueData, err := targetTree.Get(key)
-		if err != nil {
+		found := err == nil && valueData != nil
+		if pw, ok := pendingKeys[keyStr]; ok {
+			valueData = pw.Value
+			found = true
+		} else if found && useBuffer {
+			c.recordManagerRead(stmt.Table, keyStr, valueData)
+		}
+		if !found {
 			continue // Row may have been deleted
 		}
-
-		row, err := decodeRow(valueData, len(targetTable.Columns))
+		row, live, err := decodeLiveRow(valueData, len(targetTable.Columns))
 		if err != nil {
 			return 0, rowsAffected, fmt.Errorf("update join: failed to decode row in table %s: %w", targetTable.Name, err)
 		}
+		if !live {
+			continue
+		}
 
-		// Make a copy of the row to update
-		updatedRow := make([]interface{}, len(row))
-		copy(updatedRow, row)
-
-		// Use the joined row for SET evaluation so SET expressions can
-		// reference source-table columns. The target portion of the joined
-		// row may be stale (selectLocked may have projected through several
-		// layers), but it came from the same selectLocked snapshot we used
-		// to locate this key, so it's consistent with that snapshot. The
-		// authoritative target-column values for indexing/encoding come
-		// from `row` fetched above.
 		evalRow := keyToJoinedRow[keyStr]
 		evalCols := joinedColDefs
 		if len(evalRow) != len(evalCols) {
@@ -1152,193 +1160,127 @@ func (c *Catalog) updateWithJoinLocked(ctx context.Context, stmt *query.UpdateSt
 			evalRow = row
 			evalCols = targetTable.Columns
 		}
-
-		// Apply SET clauses
-		for i, setClause := range stmt.Set {
-			colIdx := setColumnIndices[i]
-			newVal, err := evaluateExpression(c, evalRow, evalCols, setClause.Value, args)
-			if err != nil {
-				return 0, rowsAffected, fmt.Errorf("failed to evaluate SET expression: %w", err)
-			}
-			updatedRow[colIdx] = newVal
-		}
-
-		// Check RLS policy for UPDATE on this specific row
-		if allowed, rlsErr := c.checkRowAccessLocked(ctx, stmt.Table, targetTable.Columns, row, security.PolicyUpdate); rlsErr != nil {
-			return 0, rowsAffected, fmt.Errorf("RLS check failed: %w", rlsErr)
-		} else if !allowed {
-			continue
-		}
-		if allowed, rlsErr := c.checkRowCheckLocked(ctx, stmt.Table, targetTable.Columns, updatedRow, security.PolicyUpdate); rlsErr != nil {
-			return 0, rowsAffected, fmt.Errorf("RLS WITH CHECK failed for UPDATE: %w", rlsErr)
-		} else if !allowed {
-			continue
-		}
-
-		// Check constraints (simplified - full checks in actual implementation)
-		for i, col := range targetTable.Columns {
-			if col.NotNull && i < len(updatedRow) && updatedRow[i] == nil {
-				return 0, rowsAffected, fmt.Errorf("NOT NULL constraint failed: %s", col.Name)
-			}
+		if err := c.processUpdateRowEval(ctx, targetTable, targetTree, stmt.Table, key, row, evalRow, evalCols,
+			stmt, args, setColumnIndices, &entries, &rowsAffected); err != nil {
+			return 0, rowsAffected, err
 		}
-
-		entries = append(entries, joinUpdateEntry{
-			key:    key,
-			oldRow: row,
-			newRow: updatedRow,
-		})
-		rowsAffected++
 	}
 
-	for _, entry := range entries {
-		if trigErr := c.executeTriggers(ctx, stmt.Table, "UPDATE", "BEFORE", entry.newRow, entry.oldRow, targetTable.Columns); trigErr != nil {
-			return 0, rowsAffected, fmt.Errorf("BEFORE UPDATE trigger failed: %w", trigErr)
-		}
+	pendingWriteStartPos := 0
+	if ts != nil {
+		pendingWriteStartPos = len(ts.pendingWrites)
 	}
-
-	var returningRows [][]interface{}
-	var returningCols []string
-	if len(stmt.Returning) > 0 && rowsAffected > 0 {
-		for _, entry := range entries {
-			returningRow, cols, err := c.evaluateReturning(stmt.Returning, entry.newRow, targetTable, args)
-			if err != nil {
-				return 0, rowsAffected, fmt.Errorf("RETURNING clause failed: %w", err)
-			}
-			returningRows = append(returningRows, returningRow)
-			if returningCols == nil {
-				returningCols = cols
-			}
-		}
+	returningRows, returningCols, err := c.validateUpdateConstraints(
+		ctx, stmt, targetTable, entries, rowsAffected, ts, pendingWriteStartPos, args,
+	)
+	if err != nil {
+		return 0, rowsAffected, err
 	}
-
-	// Apply all updates
-	if err := c.applyJoinUpdateEntries(stmt.Table, targetTable, targetTree, entries); err != nil {
-		if rbErr := c.rollbackAppliedJoinUpdateEntries(stmt.Table, targetTable, targetTree, entries); rbErr != nil {
-			return 0, rowsAffected, fmt.Errorf("%w; rollback failed: %v", err, rbErr)
-		}
+	if err := c.applyUpdateIndexes(
+		ctx, stmt, targetTable, entries, ts, txnActive, useBuffer,
+		pendingWriteStartPos, returningRows, returningCols,
+	); err != nil {
 		return 0, rowsAffected, err
 	}
+	return 0, rowsAffected, nil
+}
 
-	for _, entry := range entries {
-		if trigErr := c.executeTriggers(ctx, stmt.Table, "UPDATE", "AFTER", entry.newRow, entry.oldRow, targetTable.Columns); trigErr != nil {
-			if rbErr := c.rollbackAppliedJoinUpdateEntries(stmt.Table, targetTable, targetTree, entries); rbErr != nil {
-				return 0, rowsAffected, fmt.Errorf("AFTER UPDATE trigger failed: %w; rollback failed: %v", trigErr, rbErr)
-			}
-			return 0, rowsAffected, fmt.Errorf("AFTER UPDATE trigger failed: %w", trigErr)
+// joinSourceColumnsLocked returns the columns of a FROM source of UPDATE
+// ... FROM: a table's own columns, or the output columns of a view, derived
+// table or CTE.
+func (c *Catalog) joinSourceColumnsLocked(ref *query.TableRef, args []interface{}) ([]ColumnDef, error) {
+	if ref.Subquery == nil && ref.SubqueryStmt == nil {
+		if table, err := c.getTableLocked(ref.Name); err == nil {
+			return table.Columns, nil
 		}
 	}
-
-	// Store returning rows for retrieval
-	c.setLastReturning(returningRows, returningCols)
-
-	return int64(len(entries)), rowsAffected, nil
-}
-
-// joinUpdateEntry is a local entry type used by updateWithJoinLocked to track
-// pending updates discovered through a JOIN.
-type joinUpdateEntry struct {
-	key    []byte
-	oldRow []interface{}
-	newRow []interface{}
+	probe := &query.SelectStmt{
+		Columns: []query.Expression{&query.StarExpr{}},
+		From:    ref,
+		Limit:   &query.NumberLiteral{Value: 0, Raw: "0"},
+	}
+	names, _, err := c.selectLocked(probe, args)
+	if err != nil {
+		return nil, fmt.Errorf("UPDATE source %s: %w", ref.Name, err)
+	}
+	cols := make([]ColumnDef, len(names))
+	for i, name := range names {
+		cols[i] = ColumnDef{Name: name}
+	}
+	return cols, nil
 }
 
-// applyJoinUpdateEntries writes back updated rows, updates indexes, and records
-// undo log entries for a JOIN-based UPDATE.
-func (c *Catalog) applyJoinUpdateEntries(tableName string, table *TableDef, tree btree.TreeStore, entries []joinUpdateEntry) error {
-	ts := c.getCurrentTxn()
-	txnActive := ts != nil && ts.txnActive
-	for _, entry := range entries {
-		newValue, err := encodeRow(nil, entry.newRow)
-		if err != nil {
-			return err
-		}
-		if c.wal != nil && txnActive {
-			walData, err := encodeLogicalWALData(tableName, entry.key, newValue)
-			if err != nil {
-				return fmt.Errorf("failed to encode WAL update record: %w", err)
-			}
-			record := &storage.WALRecord{
-				TxnID: ts.txnID,
-				Type:  storage.WALUpdate,
-				Data:  walData,
-			}
-			if err := c.wal.Append(record); err != nil {
-				return fmt.Errorf("failed to append WAL record: %w", err)
-			}
-		}
-		if err := tree.Put(entry.key, newValue); err != nil {
-			return err
-		}
-
-		// Update indexes
-		var deletedIndexEntries []deletedIndexEntry
-		for idxName, idxDef := range c.indexes {
-			if idxDef.TableName != tableName || len(idxDef.Columns) == 0 {
-				continue
-			}
-			idxTree, exists := c.indexTrees[idxName]
-			if !exists {
-				continue
-			}
-			deletedEntry, err := deleteIndexEntryForRowTracked(idxName, table, idxDef, idxTree, entry.oldRow, entry.key)
-			if err != nil {
-				if restoreErr := restoreDeletedIndexEntries(deletedIndexEntries); restoreErr != nil {
-					return fmt.Errorf("failed to delete old index entry: %w; failed to restore deleted index entries: %v", err, restoreErr)
-				}
-				return fmt.Errorf("failed to delete old index entry: %w", err)
+// joinTargetKeysLocked returns a function that maps a target row found by
+// the join of UPDATE ... FROM or DELETE ... USING to the storage keys of the
+// rows it stands for. The row's leading columns are the target table's.
+// Rows are found by primary key; in a table without one they are found by
+// value, so identical rows, which no join condition can tell apart, are
+// matched together.
+func (c *Catalog) joinTargetKeysLocked(table *TableDef, tree btree.TreeStore) (func(row []interface{}) []string, error) {
+	switch {
+	case len(table.PrimaryKey) > 1:
+		return func(row []interface{}) []string {
+			if key, ok := buildCompositePK(table, row); ok {
+				return []string{key}
 			}
-			if deletedEntry != nil {
-				deletedIndexEntries = append(deletedIndexEntries, *deletedEntry)
+			return nil
+		}, nil
+	case len(table.PrimaryKey) == 1:
+		pkIdx := table.GetColumnIndex(table.PrimaryKey[0])
+		return func(row []interface{}) []string {
+			if pkIdx < 0 || pkIdx >= len(row) || row[pkIdx] == nil {
+				return nil
 			}
+			return []string{string(c.serializePK(row[pkIdx], tree))}
+		}, nil
+	}
 
-			newIdxKey, newOk := buildCompositeIndexKey(table, idxDef, entry.newRow)
-			if !newOk || newIdxKey == "" {
-				continue
-			}
-			var newStorageKey []byte
-			if idxDef.Unique {
-				newStorageKey = []byte(newIdxKey)
-			} else {
-				newStorageKey = []byte(newIdxKey + "\x00" + string(entry.key))
-			}
-			if err := idxTree.Put(newStorageKey, entry.key); err != nil {
-				if restoreErr := restoreDeletedIndexEntries(deletedIndexEntries); restoreErr != nil {
-					return fmt.Errorf("failed to insert new index entry: %w; failed to restore deleted index entries: %v", err, restoreErr)
-				}
-				return fmt.Errorf("failed to insert new index entry: %w", err)
-			}
+	// The transaction's pending writes are overlaid on the stored rows.
+	var pending map[string]PendingWrite
+	if ts := c.getCurrentTxn(); ts != nil {
+		pending = ts.getPendingWriteMap()[table.Name]
+	}
+	numCols := len(table.Columns)
+	byValue := make(map[string][]string)
+	addRow := func(key string, data []byte) error {
+		row, live, err := decodeLiveRow(data, numCols)
+		if err != nil {
+			return fmt.Errorf("failed to decode row in table %s: %w", table.Name, err)
 		}
-
-		// Record undo log for rollback
-		if txnActive {
-			oldValueData, marshalErr := json.Marshal(entry.oldRow)
-			if marshalErr == nil {
-				keyCopy := make([]byte, len(entry.key))
-				copy(keyCopy, entry.key)
-				c.appendUndoEntry(undoEntry{
-					action:    undoUpdate,
-					tableName: tableName,
-					key:       keyCopy,
-					oldValue:  oldValueData,
-				})
-			}
+		if live {
+			fp := rowKeyForDedup(row)
+			byValue[fp] = append(byValue[fp], key)
 		}
+		return nil
 	}
-	return nil
-}
-
-func (c *Catalog) rollbackAppliedJoinUpdateEntries(tableName string, table *TableDef, tree btree.TreeStore, entries []joinUpdateEntry) error {
-	for i := len(entries) - 1; i >= 0; i-- {
-		entry := entries[i]
-		oldValue, err := encodeRow(nil, entry.oldRow)
+	iter, err := tree.Scan(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan table %s: %w", table.Name, err)
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		key, data, err := iter.Next()
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to read table %s: %w", table.Name, err)
 		}
-		if err := tree.Put(entry.key, oldValue); err != nil {
-			return err
+		if _, ok := pending[string(key)]; ok {
+			continue
+		}
+		if err := addRow(string(key), data); err != nil {
+			return nil, err
 		}
 	}
-	return c.rebuildTableIndexesLocked(tableName)
+	for key, pw := range pending {
+		if err := addRow(key, pw.Value); err != nil {
+			return nil, err
+		}
+	}
+	return func(row []interface{}) []string {
+		if len(row) < numCols {
+			return nil
+		}
+		return byValue[rowKeyForDedup(row[:numCols])]
+	}, nil
 }
 
 func (c *Catalog) deleteWithUsingLocked(ctx context.Context, stmt *query.DeleteStmt, args []interface{}) (int64, int64, error) {
@@ -1383,21 +1325,20 @@ func (c *Catalog) deleteWithUsingLocked(ctx context.Context, stmt *query.DeleteS
 		return 0, 0, fmt.Errorf("failed to execute DELETE USING: %w", err)
 	}
 
-	// Find primary key column index
-	pkIdx := 0
-	if len(targetTable.PrimaryKey) > 0 {
-		pkIdx = targetTable.GetColumnIndex(targetTable.PrimaryKey[0])
-		if pkIdx < 0 {
-			pkIdx = 0
-		}
+	targetKeys, err := c.joinTargetKeysLocked(targetTable, targetTree)
+	if err != nil {
+		return 0, 0, err
 	}
 
-	// Collect keys of rows to delete by serializing the PK value
-	keysToDelete := make(map[string]struct{})
+	// Collect the storage keys of the rows to delete, in match order
+	var keysToDelete []string
+	seen := make(map[string]bool)
 	for _, row := range resultRows {
-		if pkIdx < len(row) && row[pkIdx] != nil {
-			key := c.serializePK(row[pkIdx], targetTree)
-			keysToDelete[string(key)] = struct{}{}
+		for _, key := range targetKeys(row) {
+			if !seen[key] {
+				seen[key] = true
+				keysToDelete = append(keysToDelete, key)
+			}
 		}
 	}
 
@@ -1405,60 +1346,41 @@ func (c *Catalog) deleteWithUsingLocked(ctx context.Context, stmt *query.DeleteS
 		return 0, 0, nil // No rows to delete
 	}
 
-	// Now delete each row
-	rowsAffected := int64(0)
-	var entries []joinDelEntry
-
-	// Foreign key enforcer for CASCADE/RESTRICT actions
-	fke := NewForeignKeyEnforcer(c)
+	useBuffer := c.isBufferedMode() && targetTable.Partition == nil
+	ts := c.getCurrentTxn()
+	txnActive := ts != nil && ts.txnActive
+	var pendingKeys map[string]PendingWrite
+	if ts != nil {
+		pendingKeys = ts.getPendingWriteMap()[stmt.Table]
+	}
 
-	// Collect entries to delete
-	for keyStr := range keysToDelete {
+	// The join already applied the WHERE clause, so the rows are read
+	// through the pending writes and deleted as they are.
+	rowStmt := *stmt
+	rowStmt.Where = nil
+	var entries []deleteEntry
+	rowsAffected := int64(0)
+	for _, keyStr := range keysToDelete {
 		key := []byte(keyStr)
 		valueData, err := targetTree.Get(key)
-		if err != nil {
-			continue // Row may have been deleted
+		found := err == nil && valueData != nil
+		if pw, ok := pendingKeys[keyStr]; ok {
+			valueData = pw.Value
+			found = true
+		} else if found && useBuffer {
+			c.recordManagerRead(stmt.Table, keyStr, valueData)
 		}
-
-		row, version, live, err := decodeLiveRowFull(valueData, len(targetTable.Columns))
-		if err != nil {
-			return 0, rowsAffected, fmt.Errorf("delete using: failed to decode row in table %s: %w", targetTable.Name, err)
-		}
-		// Skip already deleted rows
-		if !live {
-			continue
-		}
-
-		// Check RLS policy for DELETE on this row
-		if allowed, rlsErr := c.checkRowAccessLocked(ctx, stmt.Table, targetTable.Columns, row, security.PolicyDelete); rlsErr != nil {
-			return 0, 0, fmt.Errorf("RLS check failed: %w", rlsErr)
-		} else if !allowed {
-			continue
+		if !found {
+			continue // Row may have been deleted
 		}
-
-		// Enforce foreign key ON DELETE actions
-		if fkErr := fke.OnDeleteRow(ctx, stmt.Table, row); fkErr != nil {
-			return 0, 0, fmt.Errorf("foreign key constraint: %w", fkErr)
+		if err := c.processDeleteRow(ctx, targetTable, targetTree, stmt.Table, key, valueData, &rowStmt, args, &entries, &rowsAffected); err != nil {
+			return 0, rowsAffected, err
 		}
-
-		keyCopy := make([]byte, len(key))
-		copy(keyCopy, key)
-		valueCopy := make([]byte, len(valueData))
-		copy(valueCopy, valueData)
-
-		entries = append(entries, joinDelEntry{
-			key:     keyCopy,
-			value:   valueCopy,
-			row:     row,
-			version: version,
-		})
-		rowsAffected++
 	}
 
-	for _, entry := range entries {
-		if trigErr := c.executeTriggers(ctx, stmt.Table, "DELETE", "BEFORE", nil, entry.row, targetTable.Columns); trigErr != nil {
-			return 0, rowsAffected, fmt.Errorf("BEFORE DELETE trigger failed: %w", trigErr)
-		}
+	pendingWriteStartPos := 0
+	if ts != nil {
+		pendingWriteStartPos = len(ts.pendingWrites)
 	}
 
 	var returningRows [][]interface{}
@@ -1476,120 +1398,35 @@ func (c *Catalog) deleteWithUsingLocked(ctx context.Context, stmt *query.DeleteS
 		}
 	}
 
-	// Soft delete collected entries
-	if err := c.softDeleteJoinEntries(stmt.Table, targetTable, targetTree, entries); err != nil {
-		if rbErr := c.rollbackAppliedJoinDeleteEntries(stmt.Table, targetTree, entries); rbErr != nil {
-			return 0, rowsAffected, fmt.Errorf("%w; rollback failed: %v", err, rbErr)
+	if useBuffer {
+		if err := c.bufferDeleteEntries(ctx, targetTable, stmt, entries, ts); err != nil {
+			if ts != nil {
+				ts.pendingWrites = ts.pendingWrites[:pendingWriteStartPos]
+				rebuildPendingWriteMap(ts)
+			}
+			return 0, rowsAffected, err
 		}
-		return 0, rowsAffected, err
-	}
-
-	for _, entry := range entries {
-		if trigErr := c.executeTriggers(ctx, stmt.Table, "DELETE", "AFTER", nil, entry.row, targetTable.Columns); trigErr != nil {
-			if rbErr := c.rollbackAppliedJoinDeleteEntries(stmt.Table, targetTree, entries); rbErr != nil {
-				return 0, rowsAffected, fmt.Errorf("AFTER DELETE trigger failed: %w; rollback failed: %v", trigErr, rbErr)
+	} else {
+		if _, applyErr := c.applyDeleteEntries(ctx, targetTable, stmt, entries, ts, txnActive); applyErr != nil {
+			if rbErr := c.rollbackAppliedDeleteEntries(stmt.Table, entries); rbErr != nil {
+				return 0, rowsAffected, fmt.Errorf("%w; rollback failed: %v", applyErr, rbErr)
 			}
-			return 0, rowsAffected, fmt.Errorf("AFTER DELETE trigger failed: %w", trigErr)
+			return 0, rowsAffected, applyErr
 		}
 	}
 
+	c.invalidateQueryCache(stmt.Table)
+	c.noteModifiedRows(stmt.Table, rowsAffected)
+
 	// Store returning rows for retrieval
 	c.setLastReturning(returningRows, returningCols)
 
-	return int64(len(entries)), rowsAffected, nil
+	return 0, rowsAffected, nil
 }
 
 // processUpdateRow processes a single row update from index lookup (valueData is raw bytes)
 
-// joinDelEntry is a local entry type used by deleteWithUsingLocked to track
-// pending deletions discovered through a JOIN.
-type joinDelEntry struct {
-	key     []byte
-	value   []byte
-	row     []interface{}
-	version RowVersion // captured at scan time; avoids re-decode in softDeleteJoinEntries
-}
-
-func (c *Catalog) rollbackAppliedJoinDeleteEntries(tableName string, tree btree.TreeStore, entries []joinDelEntry) error {
-	for i := len(entries) - 1; i >= 0; i-- {
-		entry := entries[i]
-		if err := tree.Put(entry.key, entry.value); err != nil {
-			return err
-		}
-	}
-	return c.rebuildTableIndexesLocked(tableName)
-}
-
-// softDeleteJoinEntries performs soft deletion of collected entries from a
-// USING-based DELETE: updates indexes, writes WAL records, and marks rows deleted.
-func (c *Catalog) softDeleteJoinEntries(tableName string, table *TableDef, tree btree.TreeStore, entries []joinDelEntry) error {
-	ts := c.getCurrentTxn()
-	txnActive := ts != nil && ts.txnActive
-	for _, entry := range entries {
-		// Remove from indexes first (before soft deleting the row), tracking
-		// for rollback. Uses entry.row which was decoded and visibility-checked
-		// during scan in deleteWithUsingLocked.
-		var deletedIndexEntries []deletedIndexEntry
-		for idxName, idxTree := range c.indexTrees {
-			idxDef := c.indexes[idxName]
-			if idxDef.TableName == tableName && len(idxDef.Columns) > 0 {
-				deletedEntry, err := deleteIndexEntryForRowTracked(idxName, table, idxDef, idxTree, entry.row, entry.key)
-				if err != nil {
-					if restoreErr := restoreDeletedIndexEntries(deletedIndexEntries); restoreErr != nil {
-						return fmt.Errorf("failed to delete index entry: %w; failed to restore deleted index entries: %v", err, restoreErr)
-					}
-					return fmt.Errorf("failed to delete index entry: %w", err)
-				}
-				if deletedEntry != nil {
-					deletedIndexEntries = append(deletedIndexEntries, *deletedEntry)
-				}
-			}
-		}
-
-		// Soft delete: use entry.version (captured at scan time) for re-encode.
-		ver := entry.version
-		ver.markDeleted(time.Now())
-
-		// Re-encode and store (binary-safe).
-		deletedValueData, err := encodeVersionedRowFull(entry.row, ver)
-		if err != nil {
-			if restoreErr := restoreDeletedIndexEntries(deletedIndexEntries); restoreErr != nil {
-				return fmt.Errorf("failed to encode deleted row: %w; failed to restore deleted index entries: %v", err, restoreErr)
-			}
-			return fmt.Errorf("failed to encode deleted row: %w", err)
-		}
-
-		// Log to WAL before applying change
-		if c.wal != nil && txnActive {
-			walData, err := encodeLogicalWALData(tableName, entry.key, nil)
-			if err != nil {
-				if restoreErr := restoreDeletedIndexEntries(deletedIndexEntries); restoreErr != nil {
-					return fmt.Errorf("failed to encode WAL delete record: %w; failed to restore deleted index entries: %v", err, restoreErr)
-				}
-				return fmt.Errorf("failed to encode WAL delete record: %w", err)
-			}
-			record := &storage.WALRecord{
-				TxnID: ts.txnID,
-				Type:  storage.WALDelete,
-				Data:  walData,
-			}
-			if err := c.wal.Append(record); err != nil {
-				if restoreErr := restoreDeletedIndexEntries(deletedIndexEntries); restoreErr != nil {
-					return fmt.Errorf("failed to append WAL record: %w; failed to restore deleted index entries: %v", err, restoreErr)
-				}
-				return fmt.Errorf("failed to append WAL record: %w", err)
-			}
-		}
-
-		if err := tree.Put(entry.key, deletedValueData); err != nil {
-			if restoreErr := restoreDeletedIndexEntries(deletedIndexEntries); restoreErr != nil {
-				return fmt.Errorf("failed to soft delete row: %w; failed to restore deleted index entries: %v", err, restoreErr)
-			}
-			return fmt.Errorf("failed to soft delete row: %w", err)
-		}
-	}
-	return nil
-}
+// processUpdateRow processes a single row update from index lookup (valueData is raw bytes)
 func (c *Catalog) processUpdateRow(ctx context.Context, table *TableDef, tree btree.TreeStore, treeName string, key []byte, valueData []byte,
 	stmt *query.UpdateStmt, args []interface{}, setColumnIndices []int, entries *[]updateEntry, rowsAffected *int64) error {
 	row, live, err := decodeLiveRow(valueData, len(table.Columns))
@@ -1616,6 +1453,15 @@ func (c *Catalog) processUpdateRow(ctx context.Context, table *TableDef, tree bt
 // processUpdateRowData processes a single row update from scan path (row is already decoded)
 func (c *Catalog) processUpdateRowData(ctx context.Context, table *TableDef, tree btree.TreeStore, treeName string, key []byte, row []interface{},
 	stmt *query.UpdateStmt, args []interface{}, setColumnIndices []int, entries *[]updateEntry, rowsAffected *int64) error {
+	return c.processUpdateRowEval(ctx, table, tree, treeName, key, row, row, table.Columns, stmt, args, setColumnIndices, entries, rowsAffected)
+}
+
+// processUpdateRowEval is processUpdateRowData with the SET expressions
+// evaluated against evalRow and evalCols, which for UPDATE ... FROM are the
+// joined row and its columns.
+func (c *Catalog) processUpdateRowEval(ctx context.Context, table *TableDef, tree btree.TreeStore, treeName string, key []byte, row []interface{},
+	evalRow []interface{}, evalCols []ColumnDef,
+	stmt *query.UpdateStmt, args []interface{}, setColumnIndices []int, entries *[]updateEntry, rowsAffected *int64) error {
 
 	// Apply Row-Level Security check for UPDATE
 	if allowed, rlsErr := c.checkRowAccessLocked(ctx, stmt.Table, table.Columns, row, security.PolicyUpdate); rlsErr != nil || !allowed {
@@ -1630,13 +1476,16 @@ func (c *Catalog) processUpdateRowData(ctx context.Context, table *TableDef, tre
 	for i, setClause := range stmt.Set {
 		colIdx := setColumnIndices[i]
 		if colIdx >= 0 {
-			newVal, err := evaluateExpression(c, row, table.Columns, setClause.Value, args)
+			newVal, err := evaluateExpression(c, evalRow, evalCols, setClause.Value, args)
 			if err != nil {
 				return fmt.Errorf("failed to evaluate SET expression for column '%s': %w", setClause.Column, err)
 			}
 			updatedRow[colIdx] = newVal
 		}
 	}
+	if err := c.applyColumnAffinity(table, updatedRow); err != nil {
+		return err
+	}
 	if allowed, rlsErr := c.checkRowCheckLocked(ctx, stmt.Table, table.Columns, updatedRow, security.PolicyUpdate); rlsErr != nil {
 		return fmt.Errorf("RLS WITH CHECK failed for UPDATE: %w", rlsErr)
 	} else if !allowed {
@@ -1646,7 +1495,9 @@ func (c *Catalog) processUpdateRowData(ctx context.Context, table *TableDef, tre
 	// Check UNIQUE constraints before updating. *entries holds the rows already
 	// staged by this statement so two rows driven to the same unique value within
 	// one statement are rejected instead of silently committing a duplicate.
-	applySelfReferentialUpdateCascades(table, row, updatedRow)
+	if c.ForeignKeys() {
+		applySelfReferentialUpdateCascades(table, row, updatedRow)
+	}
 
 	for i, col := range table.Columns {
 		if col.Unique && updatedRow[i] != nil {
@@ -1655,7 +1506,7 @@ func (c *Catalog) processUpdateRowData(ctx context.Context, table *TableDef, tre
 				return err
 			}
 			if conflict {
-				return fmt.Errorf("UNIQUE constraint failed: %s", col.Name)
+				return sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: %s", col.Name)
 			}
 		}
 	}
@@ -1673,7 +1524,7 @@ func (c *Catalog) processUpdateRowData(ctx context.Context, table *TableDef, tre
 			}
 			if idxTree, exists := c.indexTrees[idxName]; exists {
 				if _, err := idxTree.Get([]byte(newIdxKey)); err == nil {
-					return fmt.Errorf("UNIQUE constraint failed: duplicate value in index %s", idxName)
+					return sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value in index %s", idxName)
 				}
 			}
 		}
@@ -1688,7 +1539,7 @@ func (c *Catalog) processUpdateRowData(ctx context.Context, table *TableDef, tre
 
 	// Check FOREIGN KEY constraints on updated columns
 	fke := NewForeignKeyEnforcer(c)
-	for _, fk := range table.ForeignKeys {
+	for _, fk := range c.enforcedForeignKeys(table) {
 		if !foreignKeyColumnsChanged(table, fk, row, updatedRow) {
 			continue
 		}
@@ -1701,11 +1552,11 @@ func (c *Catalog) processUpdateRowData(ctx context.Context, table *TableDef, tre
 			var err error
 			found, err = fke.referencedRowExists(fk.ReferencedTable, fk.ReferencedColumns, fkValues)
 			if err != nil {
-				return fmt.Errorf("FOREIGN KEY constraint failed: failed to decode referenced row in table %s: %w", fk.ReferencedTable, err)
+				return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: failed to decode referenced row in table %s: %w", fk.ReferencedTable, err)
 			}
 		}
 		if !found {
-			return fmt.Errorf("FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
+			return sqlerr.Errorf(sqlerr.CodeForeignKeyViolation, "FOREIGN KEY constraint failed: key %v not found in referenced table %s", fkValues, fk.ReferencedTable)
 		}
 	}
 
@@ -1916,7 +1767,7 @@ func (c *Catalog) applyUpdateEntries(ctx context.Context, table *TableDef, stmt
 					newKey = []byte(formatKey(int64(fVal)))
 				}
 				if existingData, err := updateTree.Get(newKey); err == nil && existingData != nil {
-					return rollbackApplied(fmt.Errorf("PRIMARY KEY constraint failed: duplicate key '%v'", pkVal), nil)
+					return rollbackApplied(sqlerr.Errorf(sqlerr.CodeUniqueViolation, "PRIMARY KEY constraint failed: duplicate key '%v'", pkVal), nil)
 				}
 			}
 		}
@@ -2008,7 +1859,7 @@ func (c *Catalog) applyUpdateEntryDirect(
 				Type:  storage.WALDelete,
 				Data:  deleteData,
 			}
-			if err := c.wal.Append(deleteRecord); err != nil {
+			if err := c.appendRowWAL(ts, deleteRecord); err != nil {
 				return nil, err
 			}
 			walData, err := encodeLogicalWALData(entry.treeName, newKey, newValueData)
@@ -2020,7 +1871,7 @@ func (c *Catalog) applyUpdateEntryDirect(
 				Type:  storage.WALInsert,
 				Data:  walData,
 			}
-			if err := c.wal.Append(insertRecord); err != nil {
+			if err := c.appendRowWAL(ts, insertRecord); err != nil {
 				return nil, err
 			}
 		} else {
@@ -2033,7 +1884,7 @@ func (c *Catalog) applyUpdateEntryDirect(
 				Type:  storage.WALUpdate,
 				Data:  walData,
 			}
-			if err := c.wal.Append(record); err != nil {
+			if err := c.appendRowWAL(ts, record); err != nil {
 				return nil, err
 			}
 		}
@@ -2103,7 +1954,7 @@ func (c *Catalog) applyUpdateEntryDirect(
 				idxStorageKey = []byte(newIndexKey)
 				if newIndexKey != oldIndexKey {
 					if _, err := idxTree.Get(idxStorageKey); err == nil {
-						return nil, fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", newIndexKey, idxName)
+						return nil, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", newIndexKey, idxName)
 					}
 				}
 			} else {
@@ -2127,6 +1978,9 @@ func (c *Catalog) applyUpdateEntryDirect(
 	if err := c.updateVectorIndexesForUpdate(stmt.Table, entry.newRow, string(entry.key)); err != nil {
 		return nil, err
 	}
+	c.updateFTSIndexesForWrite(table, oldKey, newKey, entry.newRow)
+	c.updateGINIndexesForWrite(table, oldKey, newKey, entry.newRow)
+	c.updateBloomIndexesForWrite(table, oldKey, newKey, entry.newRow)
 
 	return idxChanges, nil
 }
@@ -2197,10 +2051,10 @@ func (c *Catalog) bufferUpdateEntry(table *TableDef, stmt *query.UpdateStmt, ent
 			if idxDef.Unique && newIndexKey != oldIndexKey {
 				if idxTree, exists := c.indexTrees[idxName]; exists {
 					if _, err := idxTree.Get([]byte(newIndexKey)); err == nil {
-						return nil, nil, fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", newIndexKey, idxName)
+						return nil, nil, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", newIndexKey, idxName)
 					}
 					if c.indexKeyInPendingWrites(idxName, newIndexKey) {
-						return nil, nil, fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", newIndexKey, idxName)
+						return nil, nil, sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", newIndexKey, idxName)
 					}
 				}
 			}
@@ -2218,6 +2072,9 @@ func (c *Catalog) bufferUpdateEntry(table *TableDef, stmt *query.UpdateStmt, ent
 		}
 	}
 
+	if err := c.lockRow(ts, stmt.Table, string(entry.key)); err != nil {
+		return nil, nil, err
+	}
 	c.appendPendingWriteTs(ts, PendingWrite{
 		TreeName:     stmt.Table,
 		Key:          string(entry.key),
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 )
 
 // CreateVectorIndex creates a new HNSW vector index on a table column
@@ -25,7 +27,7 @@ func (c *Catalog) CreateVectorIndex(name, tableName, columnName string) error {
 	// Verify column exists and is VECTOR type
 	colIdx := table.GetColumnIndex(columnName)
 	if colIdx == -1 {
-		return fmt.Errorf("column %s not found in table %s", columnName, tableName)
+		return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column %s not found in table %s", columnName, tableName)
 	}
 	col := table.Columns[colIdx]
 	if col.Type != "VECTOR" {
@@ -301,7 +303,7 @@ func (c *Catalog) updateVectorIndexesForInsert(tableName string, rowSlice []inte
 		}
 		colIdx := table.GetColumnIndex(vectorIndex.ColumnName)
 		if colIdx == -1 {
-			return fmt.Errorf("column %s not found in table %s for vector index %s", vectorIndex.ColumnName, tableName, vectorIndex.Name)
+			return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column %s not found in table %s for vector index %s", vectorIndex.ColumnName, tableName, vectorIndex.Name)
 		}
 
 		if err := c.indexRowForVector(vectorIndex, rowSlice, key, colIdx); err != nil {
@@ -353,7 +355,7 @@ func (c *Catalog) updateVectorIndexesForUpdate(tableName string, rowSlice []inte
 		}
 		colIdx := table.GetColumnIndex(vectorIndex.ColumnName)
 		if colIdx == -1 {
-			return fmt.Errorf("column %s not found in table %s for vector index %s", vectorIndex.ColumnName, tableName, vectorIndex.Name)
+			return sqlerr.Errorf(sqlerr.CodeUndefinedColumn, "column %s not found in table %s for vector index %s", vectorIndex.ColumnName, tableName, vectorIndex.Name)
 		}
 
 		if err := c.indexRowForVector(vectorIndex, rowSlice, rowKey, colIdx); err != nil {
@@ -0,0 +1,344 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// A table created WITH SYSTEM VERSIONING keeps every row version an update
+// or delete replaces in its history table, <table>_history: an ordinary
+// table with the versioned table's columns followed by the version's
+// valid_from and valid_to times. FOR SYSTEM_TIME AS OF reads the versions
+// valid at a time from both.
+const (
+	historyTableSuffix = "_history"
+	validFromColumn    = "valid_from"
+	validToColumn      = "valid_to"
+	// historyKeyPrefix starts the keys of history rows written by
+	// versioning, which sort after the numeric keys of rows inserted into
+	// the history table directly.
+	historyKeyPrefix = "h"
+)
+
+// historyTableStmt returns the CREATE TABLE of the history table for stmt,
+// a CREATE TABLE ... WITH SYSTEM VERSIONING.
+func historyTableStmt(stmt *query.CreateTableStmt) (*query.CreateTableStmt, error) {
+	if stmt.Temporary {
+		return nil, fmt.Errorf("table %s: temporary tables cannot be system-versioned", stmt.Table)
+	}
+	if stmt.Partition != nil {
+		return nil, fmt.Errorf("table %s: partitioned tables cannot be system-versioned", stmt.Table)
+	}
+	hist := &query.CreateTableStmt{
+		Table:   stmt.Table + historyTableSuffix,
+		Columns: make([]*query.ColumnDef, 0, len(stmt.Columns)+2),
+	}
+	for _, col := range stmt.Columns {
+		if strings.EqualFold(col.Name, validFromColumn) || strings.EqualFold(col.Name, validToColumn) {
+			return nil, fmt.Errorf("table %s: column %s is reserved for the history table of a system-versioned table", stmt.Table, col.Name)
+		}
+		// The history keeps values, not constraints.
+		hist.Columns = append(hist.Columns, &query.ColumnDef{
+			Name:       col.Name,
+			Type:       col.Type,
+			Collation:  col.Collation,
+			Dimensions: col.Dimensions,
+			Mask:       col.Mask,
+		})
+	}
+	hist.Columns = append(hist.Columns,
+		&query.ColumnDef{Name: validFromColumn, Type: query.TokenTimestamp},
+		&query.ColumnDef{Name: validToColumn, Type: query.TokenTimestamp},
+	)
+	return hist, nil
+}
+
+// createVersionedTableLocked creates a table WITH SYSTEM VERSIONING and its
+// history table. Must be called with c.mu held.
+func (c *Catalog) createVersionedTableLocked(stmt *query.CreateTableStmt) error {
+	if _, exists := c.tables[stmt.Table]; exists && stmt.IfNotExists {
+		return nil
+	}
+	hist, err := historyTableStmt(stmt)
+	if err != nil {
+		return err
+	}
+	if _, exists := c.tables[hist.Table]; exists {
+		return fmt.Errorf("table %s: history table %s already exists", stmt.Table, hist.Table)
+	}
+	if _, exists := c.foreignTables[hist.Table]; exists {
+		return fmt.Errorf("table %s: history table %s already exists", stmt.Table, hist.Table)
+	}
+	if err := c.createTableLocked(stmt, ""); err != nil {
+		return err
+	}
+	return c.createTableLocked(hist, stmt.Table)
+}
+
+// versionedTableOf returns the system-versioned table whose history table
+// is table, or nil. The versioned table may have been dropped since.
+func (c *Catalog) versionedTableOf(table *TableDef) *TableDef {
+	if table.HistoryOf == "" {
+		return nil
+	}
+	if versioned, ok := c.tables[table.HistoryOf]; ok && versioned.HistoryTable == table.Name {
+		return versioned
+	}
+	return nil
+}
+
+// checkColumnsNotVersioned fails ALTER TABLE changes to the columns or name
+// of a system-versioned table or its history table, whose rows must line
+// up. Must be called with c.mu held.
+func (c *Catalog) checkColumnsNotVersioned(table *TableDef) error {
+	if table.HistoryTable != "" {
+		return fmt.Errorf("cannot alter system-versioned table '%s'", table.Name)
+	}
+	if versioned := c.versionedTableOf(table); versioned != nil {
+		return fmt.Errorf("cannot alter '%s', the history table of system-versioned table '%s'", table.Name, versioned.Name)
+	}
+	return nil
+}
+
+// historyWrite returns the history row for pw when it replaces a committed
+// version of a row of a system-versioned table: an update or a delete. A
+// row written earlier in the same transaction was never visible, so
+// replacing it keeps no history. Must be called with c.mu held, before pw
+// is buffered.
+func (c *Catalog) historyWrite(ts *catalogTxnState, pw PendingWrite) (PendingWrite, bool) {
+	table, ok := c.tables[pw.TreeName]
+	if !ok || table.HistoryTable == "" {
+		return PendingWrite{}, false
+	}
+	hist, ok := c.tables[table.HistoryTable]
+	if !ok {
+		return PendingWrite{}, false
+	}
+	if len(ts.pendingWrites) > 0 {
+		if _, pending := ts.getPendingWriteMap()[pw.TreeName][pw.Key]; pending {
+			return PendingWrite{}, false
+		}
+	}
+	tree, ok := c.tableTrees[pw.TreeName]
+	if !ok {
+		return PendingWrite{}, false
+	}
+	oldValue, err := tree.Get([]byte(pw.Key))
+	if err != nil || oldValue == nil {
+		return PendingWrite{}, false
+	}
+	oldRow, oldVersion, live, err := decodeLiveRowFull(oldValue, len(table.Columns))
+	if err != nil || !live {
+		return PendingWrite{}, false
+	}
+	newVersion, err := decodeVersionedRow(pw.Value, len(table.Columns))
+	if err != nil {
+		return PendingWrite{}, false
+	}
+	replacedAt := newVersion.Version.CreatedAt
+	if newVersion.Version.DeletedAt > 0 {
+		replacedAt = newVersion.Version.DeletedAt
+	}
+
+	row := make([]interface{}, 0, len(hist.Columns))
+	row = append(row, oldRow...)
+	row = append(row, formatVersionTime(oldVersion.CreatedAt), formatVersionTime(replacedAt))
+	value, err := encodeVersionedRow(row, nil)
+	if err != nil {
+		return PendingWrite{}, false
+	}
+	key := fmt.Sprintf("%s%020d\x00%s", historyKeyPrefix, time.Now().UnixNano(), pw.Key)
+
+	var idxUpdates []PendingIndexUpdate
+	for idxName, idxDef := range c.indexes {
+		if idxDef.TableName != hist.Name || len(idxDef.Columns) == 0 {
+			continue
+		}
+		idxKey, ok := buildCompositeIndexKey(hist, idxDef, row)
+		if !ok || idxKey == "" {
+			continue
+		}
+		if !idxDef.Unique {
+			idxKey += "\x00" + key
+		}
+		idxUpdates = append(idxUpdates, PendingIndexUpdate{IndexName: idxName, Key: idxKey, Value: []byte(key)})
+	}
+	return PendingWrite{TreeName: hist.Name, Key: key, Value: value, IndexUpdates: idxUpdates}, true
+}
+
+// formatVersionTime formats a row version time, in Unix seconds, as a
+// TIMESTAMP value.
+func formatVersionTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format("2006-01-02 15:04:05")
+}
+
+// installReplacedRows replaces, like CTEs, the tables of stmt's FROM clause
+// that read rows of their own. Read as of queryTime, for an AS OF query,
+// each system-versioned table reads its versions valid at that time and,
+// with a flashback source set, every other table its rows rebuilt for that
+// time. A table the session reads masked reads its rows masked. It returns
+// a function that removes them. Must be called with c.mu held.
+func (cat *Catalog) installReplacedRows(stmt *query.SelectStmt, queryTime time.Time) (func(), error) {
+	refs := make([]*query.TableRef, 0, 1+len(stmt.Joins))
+	refs = append(refs, stmt.From)
+	for _, join := range stmt.Joins {
+		refs = append(refs, join.Table)
+	}
+	var installed []string
+	cleanup := func() {
+		for _, name := range installed {
+			delete(cat.cteResults, name)
+		}
+	}
+	for _, ref := range refs {
+		if ref == nil || ref.Subquery != nil || ref.SubqueryStmt != nil {
+			continue
+		}
+		table, ok := cat.tables[ref.Name]
+		if !ok {
+			continue
+		}
+		flashback := cat.flashbackFunc()
+		asOf := stmt.AsOf != nil &&
+			(table.HistoryTable != "" || (flashback != nil && !table.Temporary && table.Partition == nil))
+		masked := cat.masksTableLocked(table)
+		if !asOf && !masked {
+			continue
+		}
+		name := toLowerFast(ref.Name)
+		if _, isCTE := cat.cteResults[name]; isCTE {
+			continue
+		}
+		var res *cteResultSet
+		var err error
+		switch {
+		case asOf && cat.selectNeedsFullRowsForRLS(table.Name):
+			err = fmt.Errorf("FOR SYSTEM_TIME is not supported on table %s, which has row-level security", table.Name)
+		case asOf && table.HistoryTable != "":
+			res, err = cat.systemTimeRows(table, queryTime)
+		case asOf:
+			res, err = cat.flashbackRows(table, queryTime, flashback)
+		default:
+			res, err = cat.liveRowsLocked(table)
+		}
+		if err == nil && masked {
+			res, err = maskRows(table, res)
+		}
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		if cat.cteResults == nil {
+			cat.cteResults = make(map[string]*cteResultSet)
+		}
+		cat.cteResults[name] = res
+		installed = append(installed, name)
+	}
+	return cleanup, nil
+}
+
+// systemTimeRows returns the versions of table's rows valid at queryTime:
+// its current rows created by then, and its history rows valid then.
+func (cat *Catalog) systemTimeRows(table *TableDef, queryTime time.Time) (*cteResultSet, error) {
+	res := &cteResultSet{columns: make([]string, len(table.Columns))}
+	for i, col := range table.Columns {
+		res.columns[i] = col.Name
+	}
+	queryUnix := queryTime.Unix()
+	err := cat.scanVersionRows(table.Name, len(table.Columns), func(_ string, row []interface{}, version RowVersion) {
+		// A deleted row's last version is in the history.
+		if version.DeletedAt == 0 && version.CreatedAt <= queryUnix {
+			res.rows = append(res.rows, row)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	hist, ok := cat.tables[table.HistoryTable]
+	if !ok {
+		return res, nil
+	}
+	at := formatVersionTime(queryUnix)
+	n := len(table.Columns)
+	err = cat.scanVersionRows(hist.Name, len(hist.Columns), func(_ string, row []interface{}, version RowVersion) {
+		if version.DeletedAt != 0 || len(row) < n+2 {
+			return
+		}
+		from, _ := row[n].(string)
+		to, _ := row[n+1].(string)
+		if from <= at && at < to {
+			res.rows = append(res.rows, row[:n:n])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// scanVersionRows calls fn with the key and stored row version of every row
+// of a table, in key order.
+func (cat *Catalog) scanVersionRows(tableName string, numCols int, fn func(string, []interface{}, RowVersion)) error {
+	tree, ok := cat.tableTrees[tableName]
+	if !ok {
+		return fmt.Errorf("table %s has no data tree", tableName)
+	}
+	iter, err := tree.Scan(nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan table %s: %w", tableName, err)
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		key, value, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("failed to read table %s: %w", tableName, err)
+		}
+		vrow, err := decodeVersionedRow(value, numCols)
+		if err != nil {
+			return fmt.Errorf("failed to decode row in table %s: %w", tableName, err)
+		}
+		fn(string(key), vrow.Data, vrow.Version)
+	}
+	return nil
+}
+
+// tableFlags records, for the schema version ver, whether any table is
+// system-versioned and whether any has MASKED columns.
+type tableFlags struct {
+	ver       uint64
+	versioned bool
+	masked    bool
+}
+
+// tableFlagsLocked returns the table flags of the current schema. They are
+// cached until the next schema change. Must be called with c.mu held.
+func (cat *Catalog) tableFlagsLocked() *tableFlags {
+	ver := cat.schemaVersion.Load()
+	if f := cat.flags.Load(); f != nil && f.ver == ver {
+		return f
+	}
+	f := &tableFlags{ver: ver}
+	for _, table := range cat.tables {
+		f.versioned = f.versioned || table.HistoryTable != ""
+		f.masked = f.masked || hasMaskedColumns(table)
+	}
+	cat.flags.Store(f)
+	return f
+}
+
+// replacesTablesLocked reports whether stmt may read tables that
+// installReplacedRows replaces with rows of their own: tables read AS OF a
+// time, or with the session's masks. Such a query must hold c.mu
+// exclusively. Must be called with c.mu held.
+func (cat *Catalog) replacesTablesLocked(stmt *query.SelectStmt) bool {
+	if cat.masksTablesLocked() {
+		return true
+	}
+	if cat.flashbackFunc() == nil && !cat.tableFlagsLocked().versioned {
+		return false
+	}
+	return stmt.AsOf != nil || query.ContainsAsOf(stmt)
+}
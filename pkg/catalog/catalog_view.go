@@ -91,14 +91,19 @@ func (c *Catalog) DropMaterializedView(name string, ifExists bool) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	defer c.invalidateSchemaCache()
-	mv, exists := c.materializedViews[name]
-	if !exists {
+	if _, exists := c.materializedViews[name]; !exists {
 		if ifExists {
 			return nil // Silently succeed
 		}
 		return fmt.Errorf("materialized view %s not found", name)
 	}
+	return c.dropMaterializedViewLocked(name)
+}
 
+// dropMaterializedViewLocked removes a materialized view. Must be called
+// with c.mu held.
+func (c *Catalog) dropMaterializedViewLocked(name string) error {
+	mv := c.materializedViews[name]
 	if err := c.deleteCatalogDef("mv:" + name); err != nil {
 		return fmt.Errorf("failed to delete materialized view metadata %s: %w", name, err)
 	}
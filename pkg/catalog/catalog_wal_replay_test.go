@@ -3,6 +3,7 @@ package catalog
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -78,13 +79,6 @@ func TestJoinUpdateWritesLogicalWALBeforeCommit(t *testing.T) {
 	c, pool := newMetadataIsolationCatalog(t)
 	defer pool.Close()
 
-	if _, err := c.ExecuteQuery("CREATE TABLE wal_join_update (id INTEGER PRIMARY KEY, status TEXT)"); err != nil {
-		t.Fatalf("create table: %v", err)
-	}
-	if _, err := c.ExecuteQuery("INSERT INTO wal_join_update (id, status) VALUES (1, 'old')"); err != nil {
-		t.Fatalf("insert row: %v", err)
-	}
-
 	walPath := filepath.Join(t.TempDir(), "join-update.wal")
 	wal, err := storage.OpenWAL(walPath)
 	if err != nil {
@@ -92,27 +86,24 @@ func TestJoinUpdateWritesLogicalWALBeforeCommit(t *testing.T) {
 	}
 	c.SetWAL(wal)
 
-	table := c.tables["wal_join_update"]
-	tree := c.tableTrees["wal_join_update"]
-	rowKey := []byte(formatKey(1))
-	valueData, err := tree.Get(rowKey)
-	if err != nil {
-		t.Fatalf("get existing row: %v", err)
+	c.BeginTransaction(100)
+	for _, sql := range []string{
+		"CREATE TABLE wal_join_update (id INTEGER PRIMARY KEY, status TEXT)",
+		"CREATE TABLE wal_join_src (id INTEGER PRIMARY KEY, status TEXT)",
+		"INSERT INTO wal_join_update (id, status) VALUES (1, 'old'), (2, 'old')",
+		"INSERT INTO wal_join_src (id, status) VALUES (1, 'new')",
+	} {
+		if _, err := c.ExecuteQuery(sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
 	}
-	oldVersioned, err := decodeVersionedRow(valueData, len(table.Columns))
-	if err != nil {
-		t.Fatalf("decode existing row: %v", err)
+	if err := c.CommitTransaction(); err != nil {
+		t.Fatalf("CommitTransaction: %v", err)
 	}
-	newRow := append([]interface{}(nil), oldVersioned.Data...)
-	newRow[1] = "new"
 
 	c.BeginTransaction(101)
-	if err := c.applyJoinUpdateEntries("wal_join_update", table, tree, []joinUpdateEntry{{
-		key:    rowKey,
-		oldRow: oldVersioned.Data,
-		newRow: newRow,
-	}}); err != nil {
-		t.Fatalf("applyJoinUpdateEntries: %v", err)
+	if _, err := c.ExecuteQuery("UPDATE wal_join_update SET status = s.status FROM wal_join_src s WHERE wal_join_update.id = s.id"); err != nil {
+		t.Fatalf("join update: %v", err)
 	}
 	if err := c.CommitTransaction(); err != nil {
 		t.Fatalf("CommitTransaction: %v", err)
@@ -122,23 +113,38 @@ func TestJoinUpdateWritesLogicalWALBeforeCommit(t *testing.T) {
 		t.Fatalf("Close WAL: %v", err)
 	}
 
-	value := requireReplayOp(t, recoverReplayOpsFromWAL(t, walPath, pool), storage.WALUpdate, "wal_join_update:"+formatKey(1))
+	dst, dstPool := newMetadataIsolationCatalog(t)
+	defer dstPool.Close()
+	ops := recoverReplayOpsFromWAL(t, walPath, dstPool)
+	value := requireReplayOp(t, ops, storage.WALUpdate, "wal_join_update:"+formatKey(1))
 	if len(value) == 0 {
 		t.Fatal("WAL update replay value is empty")
 	}
+	for _, op := range ops {
+		if op.Type != storage.WALUpdate {
+			continue
+		}
+		if key, _, _ := parseReplayWALKeyValue(op.Data); key == "wal_join_update:"+formatKey(2) {
+			t.Fatalf("unmatched row 2 was logged as updated")
+		}
+	}
+
+	if err := dst.ReplayWALOps(ops); err != nil {
+		t.Fatalf("ReplayWALOps: %v", err)
+	}
+	res, err := dst.ExecuteQuery("SELECT id, status FROM wal_join_update ORDER BY id")
+	if err != nil {
+		t.Fatalf("select after replay: %v", err)
+	}
+	if len(res.Rows) != 2 || fmt.Sprint(res.Rows[0][1]) != "new" || fmt.Sprint(res.Rows[1][1]) != "old" {
+		t.Fatalf("rows after replay = %v, want [[1 new] [2 old]]", res.Rows)
+	}
 }
 
 func TestDeleteUsingWritesLogicalWALBeforeSoftDelete(t *testing.T) {
 	c, pool := newMetadataIsolationCatalog(t)
 	defer pool.Close()
 
-	if _, err := c.ExecuteQuery("CREATE TABLE wal_delete_using (id INTEGER PRIMARY KEY, status TEXT)"); err != nil {
-		t.Fatalf("create table: %v", err)
-	}
-	if _, err := c.ExecuteQuery("INSERT INTO wal_delete_using (id, status) VALUES (1, 'old')"); err != nil {
-		t.Fatalf("insert row: %v", err)
-	}
-
 	walPath := filepath.Join(t.TempDir(), "delete-using.wal")
 	wal, err := storage.OpenWAL(walPath)
 	if err != nil {
@@ -146,25 +152,24 @@ func TestDeleteUsingWritesLogicalWALBeforeSoftDelete(t *testing.T) {
 	}
 	c.SetWAL(wal)
 
-	table := c.tables["wal_delete_using"]
-	tree := c.tableTrees["wal_delete_using"]
-	rowKey := []byte(formatKey(1))
-	valueData, err := tree.Get(rowKey)
-	if err != nil {
-		t.Fatalf("get existing row: %v", err)
+	c.BeginTransaction(100)
+	for _, sql := range []string{
+		"CREATE TABLE wal_delete_using (id INTEGER PRIMARY KEY, status TEXT)",
+		"CREATE TABLE wal_delete_src (id INTEGER PRIMARY KEY)",
+		"INSERT INTO wal_delete_using (id, status) VALUES (1, 'old'), (2, 'old')",
+		"INSERT INTO wal_delete_src (id) VALUES (1)",
+	} {
+		if _, err := c.ExecuteQuery(sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
 	}
-	versioned, err := decodeVersionedRow(valueData, len(table.Columns))
-	if err != nil {
-		t.Fatalf("decode existing row: %v", err)
+	if err := c.CommitTransaction(); err != nil {
+		t.Fatalf("CommitTransaction: %v", err)
 	}
 
 	c.BeginTransaction(102)
-	if err := c.softDeleteJoinEntries("wal_delete_using", table, tree, []joinDelEntry{{
-		key:   rowKey,
-		value: append([]byte(nil), valueData...),
-		row:   versioned.Data,
-	}}); err != nil {
-		t.Fatalf("softDeleteJoinEntries: %v", err)
+	if _, err := c.ExecuteQuery("DELETE FROM wal_delete_using USING wal_delete_src s WHERE wal_delete_using.id = s.id"); err != nil {
+		t.Fatalf("delete using: %v", err)
 	}
 	if err := c.CommitTransaction(); err != nil {
 		t.Fatalf("CommitTransaction: %v", err)
@@ -174,10 +179,24 @@ func TestDeleteUsingWritesLogicalWALBeforeSoftDelete(t *testing.T) {
 		t.Fatalf("Close WAL: %v", err)
 	}
 
-	value := requireReplayOp(t, recoverReplayOpsFromWAL(t, walPath, pool), storage.WALDelete, "wal_delete_using:"+formatKey(1))
+	dst, dstPool := newMetadataIsolationCatalog(t)
+	defer dstPool.Close()
+	ops := recoverReplayOpsFromWAL(t, walPath, dstPool)
+	value := requireReplayOp(t, ops, storage.WALDelete, "wal_delete_using:"+formatKey(1))
 	if len(value) != 0 {
 		t.Fatalf("WAL delete replay value should be empty, got %q", string(value))
 	}
+
+	if err := dst.ReplayWALOps(ops); err != nil {
+		t.Fatalf("ReplayWALOps: %v", err)
+	}
+	res, err := dst.ExecuteQuery("SELECT id, status FROM wal_delete_using ORDER BY id")
+	if err != nil {
+		t.Fatalf("select after replay: %v", err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != int64(2) {
+		t.Fatalf("rows after replay = %v, want only row 2", res.Rows)
+	}
 }
 
 func TestForeignKeyCascadeUpdateWritesLogicalWAL(t *testing.T) {
@@ -402,3 +421,114 @@ func TestReplayWALOpsRejectsUnknownType(t *testing.T) {
 		t.Fatalf("expected invalid WAL replay type error, got %v", err)
 	}
 }
+
+func TestReplayWALOpsRebuildsSchema(t *testing.T) {
+	src, srcPool := newMetadataIsolationCatalog(t)
+	defer srcPool.Close()
+	walPath := filepath.Join(t.TempDir(), "schema.wal")
+	wal, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatalf("OpenWAL: %v", err)
+	}
+	src.SetWAL(wal)
+
+	inTxn := func(txnID uint64, steps ...func() error) {
+		t.Helper()
+		src.BeginTransaction(txnID)
+		for _, step := range steps {
+			if err := step(); err != nil {
+				t.Fatalf("txn %d: %v", txnID, err)
+			}
+		}
+		if err := src.CommitTransaction(); err != nil {
+			t.Fatalf("commit txn %d: %v", txnID, err)
+		}
+	}
+	sql := func(q string) func() error {
+		return func() error { _, err := src.ExecuteQuery(q); return err }
+	}
+	view, err := query.Parse("CREATE VIEW big_orders AS SELECT id FROM orders WHERE qty > 4")
+	if err != nil {
+		t.Fatalf("parse view: %v", err)
+	}
+
+	inTxn(1,
+		sql("CREATE TABLE orders (id INTEGER PRIMARY KEY, item TEXT, qty INTEGER)"),
+		sql("CREATE INDEX idx_orders_item ON orders (item)"),
+		sql("INSERT INTO orders VALUES (1, 'apple', 3)"),
+		sql("INSERT INTO orders VALUES (2, 'pear', 5)"),
+	)
+	inTxn(2,
+		func() error { return src.CreateView("big_orders", view.(*query.CreateViewStmt).Query) },
+		sql("CREATE TABLE audit (item TEXT)"),
+		func() error {
+			return src.CreateTrigger(mustParseTrigger("CREATE TRIGGER trg_orders AFTER INSERT ON orders BEGIN INSERT INTO audit VALUES (NEW.item); END"))
+		},
+	)
+	inTxn(3,
+		func() error {
+			return src.AlterTableAddColumn(&query.AlterTableStmt{Table: "orders", Column: query.ColumnDef{Name: "note", Type: query.TokenText}})
+		},
+		func() error {
+			return src.AlterTableRename(&query.AlterTableStmt{Table: "orders", NewName: "purchases"})
+		},
+	)
+	inTxn(4,
+		sql("CREATE TABLE kept (id INTEGER PRIMARY KEY)"),
+		func() error { return src.Savepoint("sp") },
+		sql("CREATE TABLE undone (id INTEGER PRIMARY KEY)"),
+		func() error { return src.RollbackToSavepoint("sp") },
+	)
+	inTxn(5,
+		sql("CREATE TABLE notes (body TEXT)"),
+		sql("INSERT INTO notes VALUES ('a')"),
+		sql("INSERT INTO notes VALUES ('b')"),
+		sql("DROP TABLE audit"),
+	)
+	src.BeginTransaction(6)
+	if _, err := src.ExecuteQuery("CREATE TABLE scratch (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("create scratch: %v", err)
+	}
+	if err := src.RollbackTransaction(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	src.SetWAL(nil)
+	if err := wal.Close(); err != nil {
+		t.Fatalf("close WAL: %v", err)
+	}
+
+	dst, dstPool := newMetadataIsolationCatalog(t)
+	defer dstPool.Close()
+	if err := dst.ReplayWALOps(recoverReplayOpsFromWAL(t, walPath, dstPool)); err != nil {
+		t.Fatalf("ReplayWALOps: %v", err)
+	}
+
+	for name, want := range map[string]bool{"purchases": true, "kept": true, "notes": true, "orders": false, "audit": false, "undone": false, "scratch": false} {
+		if _, err := dst.GetTable(name); (err == nil) != want {
+			t.Errorf("table %s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+	if cols := dst.tables["purchases"].Columns; len(cols) != 4 || cols[3].Name != "note" {
+		t.Errorf("purchases columns = %+v, want id, item, qty, note", cols)
+	}
+	if idx := dst.indexes["idx_orders_item"]; idx == nil || idx.TableName != "purchases" {
+		t.Errorf("index after replay = %+v, want it on purchases", idx)
+	}
+	if _, err := dst.GetView("big_orders"); err != nil {
+		t.Errorf("view after replay: %v", err)
+	}
+	if _, err := dst.GetTrigger("trg_orders"); err != nil {
+		t.Errorf("trigger after replay: %v", err)
+	}
+
+	res, err := dst.ExecuteQuery("SELECT id, item FROM purchases WHERE item = 'pear'")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != int64(2) {
+		t.Errorf("index lookup after replay = %v, want row 2", res.Rows)
+	}
+	if got := dst.tables["notes"].AutoIncSeq; got != 2 {
+		t.Errorf("notes sequence after replay = %d, want 2", got)
+	}
+}
@@ -0,0 +1,371 @@
+package catalog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// logSchemaRecords writes DDL records to the WAL. Inside a transaction they
+// are held until commit, so ROLLBACK and ROLLBACK TO SAVEPOINT discard them
+// together with the change; otherwise they are written with their own commit.
+func (c *Catalog) logSchemaRecords(records ...*storage.WALRecord) error {
+	if c.wal == nil || len(records) == 0 {
+		return nil
+	}
+	if ts := c.getCurrentTxn(); ts != nil && ts.txnActive {
+		for _, r := range records {
+			r.TxnID = ts.txnID
+		}
+		ts.schemaRecords = append(ts.schemaRecords, records...)
+		return nil
+	}
+	return c.wal.AppendBatch(append(records, &storage.WALRecord{Type: storage.WALCommit}))
+}
+
+// schemaRecord builds a DDL record keyed by the catalog key of the object
+// ("tbl:orders") carrying def encoded as JSON, or no value when def is nil.
+func schemaRecord(recordType storage.WALRecordType, catalogKey string, def interface{}) (*storage.WALRecord, error) {
+	var value []byte
+	if def != nil {
+		var err error
+		if value, err = json.Marshal(def); err != nil {
+			return nil, err
+		}
+	}
+	prefix, name, _ := strings.Cut(catalogKey, ":")
+	data, err := encodeLogicalWALData(prefix, []byte(name), value)
+	if err != nil {
+		return nil, fmt.Errorf("schema WAL record for %s: %w", catalogKey, err)
+	}
+	return &storage.WALRecord{Type: recordType, Data: data}, nil
+}
+
+// logTableSchema records a CREATE or ALTER of table, keyed by name (the
+// table's name before an ALTER). The logged definition has no root page, so
+// replay builds a fresh tree, and no compression dictionary, which replay
+// keeps from the existing table.
+func (c *Catalog) logTableSchema(recordType storage.WALRecordType, name string, table *TableDef) error {
+	if c.wal == nil || table.Temporary {
+		return nil
+	}
+	def := *table
+	def.RootPageID = 0
+	def.CompressionDict = nil
+	def.AutoIncSeq = atomic.LoadInt64(&table.AutoIncSeq)
+	record, err := schemaRecord(recordType, "tbl:"+name, &def)
+	if err != nil {
+		return err
+	}
+	return c.logSchemaRecords(record)
+}
+
+// logIndexSchema records a CREATE INDEX, or a new definition of an existing
+// index. Replay builds a new index from its table, so the root page is not
+// logged.
+func (c *Catalog) logIndexSchema(index *IndexDef) error {
+	if c.wal == nil || index.Temporary {
+		return nil
+	}
+	def := *index
+	def.RootPageID = 0
+	record, err := schemaRecord(storage.WALCreateIndex, "idx:"+index.Name, &def)
+	if err != nil {
+		return err
+	}
+	return c.logSchemaRecords(record)
+}
+
+// logSQLSchema records a CREATE VIEW or CREATE TRIGGER as its SQL text, the
+// form Load reads views and triggers back from.
+func (c *Catalog) logSQLSchema(recordType storage.WALRecordType, catalogKey, name, sql string) error {
+	if c.wal == nil {
+		return nil
+	}
+	record, err := schemaRecord(recordType, catalogKey, persistedSQLDef{Name: name, SQL: strings.TrimSpace(sql)})
+	if err != nil {
+		return err
+	}
+	return c.logSchemaRecords(record)
+}
+
+// logSchemaDrop records the DROP of the object stored under catalogKey.
+func (c *Catalog) logSchemaDrop(recordType storage.WALRecordType, catalogKey string) error {
+	if c.wal == nil {
+		return nil
+	}
+	record, err := schemaRecord(recordType, catalogKey, nil)
+	if err != nil {
+		return err
+	}
+	return c.logSchemaRecords(record)
+}
+
+// logRowRewrites records rows an ALTER TABLE rewrote in place, so recovery
+// does not pair the new definition with rows in the old layout.
+func (c *Catalog) logRowRewrites(table *TableDef, rows []struct{ key, val []byte }) error {
+	if c.wal == nil || table.Temporary || len(rows) == 0 {
+		return nil
+	}
+	records := make([]*storage.WALRecord, 0, len(rows))
+	for _, row := range rows {
+		data, err := encodeLogicalWALData(table.Name, row.key, row.val)
+		if err != nil {
+			return err
+		}
+		records = append(records, &storage.WALRecord{Type: storage.WALUpdate, Data: data})
+	}
+	return c.logSchemaRecords(records...)
+}
+
+// logSequenceAdvance records table's row sequence after a statement moved it
+// past before. The record is not synced; the statement's commit syncs it.
+func (c *Catalog) logSequenceAdvance(table *TableDef, before int64) error {
+	if c.wal == nil || table.Temporary {
+		return nil
+	}
+	seq := atomic.LoadInt64(&table.AutoIncSeq)
+	if seq <= before {
+		return nil
+	}
+	var value [8]byte
+	binary.LittleEndian.PutUint64(value[:], uint64(seq)) // #nosec G115 -- seq > before >= 0 here.
+	data, err := encodeLogicalWALData("seq", []byte(table.Name), value[:])
+	if err != nil {
+		return err
+	}
+	record := &storage.WALRecord{Type: storage.WALSequence, Data: data}
+	if ts := c.getCurrentTxn(); ts != nil && ts.txnActive {
+		record.TxnID = ts.txnID
+	}
+	return c.wal.AppendWithoutSync(record)
+}
+
+// writeSchemaRecords writes the DDL records ts holds back. They go out at
+// commit, or earlier when a row write of the same transaction is logged, so
+// recovery replays DDL and row changes in the order they ran.
+func (c *Catalog) writeSchemaRecords(ts *catalogTxnState) error {
+	if c.wal == nil || ts == nil || len(ts.schemaRecords) == 0 {
+		return nil
+	}
+	if err := c.wal.AppendBatchWithoutSync(ts.schemaRecords); err != nil {
+		return err
+	}
+	ts.schemaRecordsWritten += len(ts.schemaRecords)
+	clear(ts.schemaRecords)
+	ts.schemaRecords = ts.schemaRecords[:0]
+	return nil
+}
+
+// appendRowWAL logs a row change made directly to a table tree, after the DDL
+// records the transaction logged before it.
+func (c *Catalog) appendRowWAL(ts *catalogTxnState, record *storage.WALRecord) error {
+	if err := c.writeSchemaRecords(ts); err != nil {
+		return err
+	}
+	return c.wal.Append(record)
+}
+
+// replaySchemaOpLocked applies one recovered DDL record. Records for objects
+// that already match the loaded catalog are skipped, so replaying DDL whose
+// catalog pages did reach disk is harmless. A rename carries the table's
+// entry in affectedTables over to the new name. Must be called with c.mu held.
+func (c *Catalog) replaySchemaOpLocked(op storage.WALReplayOp, affectedTables map[string]struct{}) error {
+	key, value, err := parseReplayWALKeyValue(op.Data)
+	if err != nil {
+		return err
+	}
+	_, name, ok := strings.Cut(key, ":")
+	if !ok || name == "" {
+		return fmt.Errorf("missing object name in key %q", key)
+	}
+
+	switch op.Type {
+	case storage.WALCreateTable:
+		if _, exists := c.tables[name]; exists {
+			return nil
+		}
+		tableDef, err := decodeTableDef(name, value)
+		if err != nil {
+			return err
+		}
+		tableDef.RootPageID = 0
+		if err := c.installTableDefLocked(tableDef); err != nil {
+			return err
+		}
+		return c.storeTableDef(tableDef)
+
+	case storage.WALAlterTable:
+		newName, err := c.replayAlterTableLocked(name, value)
+		if _, ok := affectedTables[name]; ok && newName != name {
+			delete(affectedTables, name)
+			affectedTables[newName] = struct{}{}
+		}
+		return err
+
+	case storage.WALDropTable:
+		if _, exists := c.tables[name]; !exists {
+			return nil
+		}
+		for idxName, idxDef := range c.indexes {
+			if idxDef.TableName == name {
+				if err := c.dropIndexForReplayLocked(idxName); err != nil {
+					return err
+				}
+			}
+		}
+		if err := c.deleteCatalogDef("tbl:" + name); err != nil {
+			return err
+		}
+		delete(c.tables, name)
+		delete(c.tableTrees, name)
+		delete(c.stats, name)
+		return nil
+
+	case storage.WALCreateIndex:
+		if existing, exists := c.indexes[name]; exists {
+			// A later definition of an existing index (after a column
+			// rename) keeps the index's tree.
+			var def IndexDef
+			if err := json.Unmarshal(value, &def); err != nil {
+				return err
+			}
+			def.RootPageID = existing.RootPageID
+			c.indexes[name] = &def
+			return c.storeIndexDef(&def)
+		}
+		idx, err := c.loadIndexDefLocked(name, value)
+		if err != nil || idx == nil {
+			return err
+		}
+		idx.Status = IndexActive
+		return c.storeIndexDef(idx)
+
+	case storage.WALDropIndex:
+		return c.dropIndexForReplayLocked(name)
+
+	case storage.WALCreateView:
+		if c.viewSQL == nil {
+			c.viewSQL = make(map[string]string)
+		}
+		if c.viewTemporary == nil {
+			c.viewTemporary = make(map[string]bool)
+		}
+		if err := c.loadViewDefLocked(key, value); err != nil {
+			return err
+		}
+		return c.storeViewDef(name, c.viewSQL[name])
+
+	case storage.WALDropView:
+		delete(c.views, name)
+		delete(c.viewSQL, name)
+		delete(c.viewTemporary, name)
+		return c.deleteCatalogDef(key)
+
+	case storage.WALCreateTrigger:
+		if _, exists := c.triggers[name]; exists {
+			return nil
+		}
+		if c.triggerSQL == nil {
+			c.triggerSQL = make(map[string]string)
+		}
+		if err := c.loadTriggerDefLocked(key, value); err != nil {
+			return err
+		}
+		return c.storeTriggerDef(name, c.triggerSQL[name])
+
+	case storage.WALDropTrigger:
+		delete(c.triggers, name)
+		delete(c.triggerSQL, name)
+		return c.deleteCatalogDef(key)
+	}
+	return fmt.Errorf("unexpected schema record type %v", op.Type)
+}
+
+// replayAlterTableLocked installs the definition an ALTER TABLE logged for
+// oldName, renaming the table first when the definition carries a new name,
+// and returns the table's name afterwards. The table keeps its tree and
+// compression dictionary.
+func (c *Catalog) replayAlterTableLocked(oldName string, value []byte) (string, error) {
+	def, err := decodeTableDef(oldName, value)
+	if err != nil {
+		return oldName, err
+	}
+	if def.Name == "" {
+		def.Name = oldName
+	}
+	if def.Name != oldName {
+		_, oldExists := c.tables[oldName]
+		_, newExists := c.tables[def.Name]
+		if oldExists && !newExists {
+			if err := c.renameTableLocked(oldName, def.Name); err != nil {
+				return oldName, err
+			}
+		}
+	}
+	table, exists := c.tables[def.Name]
+	if !exists {
+		return def.Name, nil
+	}
+	def.RootPageID = table.RootPageID
+	def.CompressionDict = table.CompressionDict
+	if seq := atomic.LoadInt64(&table.AutoIncSeq); seq > def.AutoIncSeq {
+		def.AutoIncSeq = seq
+	}
+	def.buildColumnIndexCache()
+	c.tables[def.Name] = def
+	return def.Name, c.storeTableDef(def)
+}
+
+// dropIndexForReplayLocked removes an index during WAL replay. Must be called
+// with c.mu held.
+func (c *Catalog) dropIndexForReplayLocked(name string) error {
+	if err := c.deleteCatalogDef("idx:" + name); err != nil {
+		return err
+	}
+	delete(c.indexes, name)
+	delete(c.indexTrees, name)
+	return nil
+}
+
+// replaySequenceLocked raises a table's row sequence to the value a
+// WALSequence record logged, or applies the logged state of a user sequence.
+// Must be called with c.mu held.
+func (c *Catalog) replaySequenceLocked(op storage.WALReplayOp) error {
+	key, value, err := parseReplayWALKeyValue(op.Data)
+	if err != nil {
+		return err
+	}
+	kind, name, _ := strings.Cut(key, ":")
+	if kind == "sequence" {
+		return c.replayUserSequenceLocked(name, value)
+	}
+	if len(value) != 8 {
+		return fmt.Errorf("sequence value for %s has %d bytes", name, len(value))
+	}
+	table, exists := c.tables[name]
+	if !exists {
+		return nil
+	}
+	seq := int64(binary.LittleEndian.Uint64(value)) // #nosec G115 -- written from a non-negative int64.
+	if seq <= atomic.LoadInt64(&table.AutoIncSeq) {
+		return nil
+	}
+	atomic.StoreInt64(&table.AutoIncSeq, seq)
+	return c.storeTableDef(table)
+}
+
+// truncateSchemaRecords drops the DDL records logged after savepoint position
+// pos, undoing them along with a ROLLBACK TO SAVEPOINT. Records a later row
+// write already pushed into the WAL stay there.
+func truncateSchemaRecords(ts *catalogTxnState, pos int) {
+	keep := max(pos-ts.schemaRecordsWritten, 0)
+	if keep < len(ts.schemaRecords) {
+		clear(ts.schemaRecords[keep:])
+		ts.schemaRecords = ts.schemaRecords[:keep]
+	}
+}
@@ -201,14 +201,9 @@ func (c *Catalog) evalWindowPartitions(rows [][]interface{}, colIdx int, we *que
 				for _, ob := range we.OrderBy {
 					va := c.evalWindowExprOnRow(ob.Expr, entries[a].row, selectCols, table, args, entries[a].fullRow)
 					vb := c.evalWindowExprOnRow(ob.Expr, entries[b].row, selectCols, table, args, entries[b].fullRow)
-					cmp := compareValues(va, vb)
-					if cmp == 0 {
-						continue
-					}
-					if ob.Desc {
-						return cmp > 0
+					if cmp := compareOrderByValues(va, vb, ob); cmp != 0 {
+						return cmp < 0
 					}
-					return cmp < 0
 				}
 				return false
 			})
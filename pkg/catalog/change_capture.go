@@ -0,0 +1,109 @@
+package catalog
+
+import (
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+)
+
+// RowChange is one row written by a committed transaction. Old is nil for
+// an inserted row and New is nil for a deleted one. Values are in column
+// order but, like stored rows, may be shorter than the table when columns
+// were added after the row was written.
+type RowChange struct {
+	Table string
+	Key   string // the row's storage key
+	Old   []interface{}
+	New   []interface{}
+}
+
+// ChangeCaptureFunc receives the rows a transaction changed, in the order
+// it first wrote them, with the LSN of its WAL commit record (0 without a
+// WAL). It runs while the rows' commit locks are held, so it must not block
+// or call back into the catalog.
+type ChangeCaptureFunc func(lsn uint64, changes []RowChange)
+
+// SetChangeCapture makes fn receive the row changes of every committed
+// transaction; nil stops capture. Capture covers writes buffered for commit,
+// which is every DML statement run through the engine.
+func (c *Catalog) SetChangeCapture(fn ChangeCaptureFunc) {
+	if fn == nil {
+		c.changeCapture.Store(nil)
+		return
+	}
+	c.changeCapture.Store(&fn)
+}
+
+// changeCaptureFunc returns the hook set with SetChangeCapture, or nil.
+func (c *Catalog) changeCaptureFunc() ChangeCaptureFunc {
+	if fn := c.changeCapture.Load(); fn != nil {
+		return *fn
+	}
+	return nil
+}
+
+// collectRowChanges pairs each row in writes with the value it replaces,
+// reading trees before the writes are applied. A row written several times
+// reports its first old and last new value; one inserted and deleted by the
+// same transaction reports nothing.
+func collectRowChanges(writes []PendingWrite, trees map[string]btree.TreeStore) []RowChange {
+	type rowKey struct{ tree, key string }
+	order := make([]rowKey, 0, len(writes))
+	latest := make(map[rowKey][]byte, len(writes))
+	for _, pw := range writes {
+		rk := rowKey{pw.TreeName, pw.Key}
+		if _, seen := latest[rk]; !seen {
+			order = append(order, rk)
+		}
+		latest[rk] = pw.Value
+	}
+
+	changes := make([]RowChange, 0, len(order))
+	for _, rk := range order {
+		var oldRow []interface{}
+		if tree := trees[rk.tree]; tree != nil {
+			if data, err := tree.Get([]byte(rk.key)); err == nil {
+				oldRow = liveRowData(data, 0)
+			}
+		}
+		newRow := liveRowData(latest[rk], 0)
+		if oldRow == nil && newRow == nil {
+			continue
+		}
+		table, _, _ := strings.Cut(rk.tree, ":") // partition trees are "table:partition"
+		changes = append(changes, RowChange{Table: table, Key: rk.key, Old: oldRow, New: newRow})
+	}
+	return changes
+}
+
+// liveRowData decodes a stored row, padded to numCols values, returning nil
+// for a deleted or unreadable one.
+func liveRowData(data []byte, numCols int) []interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	vrow, err := decodeVersionedRow(data, numCols)
+	if err != nil || vrow.Version.DeletedAt != 0 {
+		return nil
+	}
+	if vrow.Data == nil {
+		return []interface{}{}
+	}
+	return vrow.Data
+}
+
+// EncodeRowValues encodes row as a stored row is encoded, binary values
+// included, for callers that keep RowChange values outside the catalog.
+func EncodeRowValues(row []interface{}) ([]byte, error) {
+	return encodeVersionedRowFull(row, RowVersion{})
+}
+
+// DecodeRowValues decodes a row encoded by EncodeRowValues, giving its
+// values the types a read of the table gives them.
+func DecodeRowValues(data []byte) ([]interface{}, error) {
+	vrow, err := decodeVersionedRow(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	return vrow.Data, nil
+}
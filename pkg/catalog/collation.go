@@ -0,0 +1,309 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// Collation defines how strings compare under a COLLATE name.
+type Collation struct {
+	// Compare orders a and b like strings.Compare.
+	Compare func(a, b string) int
+	// Key maps a string to a form whose byte equality matches Compare
+	// returning 0. Indexes on a column with this collation store Key(value),
+	// so lookups and UNIQUE checks follow the collation. A collation without
+	// Key indexes the raw value.
+	Key func(s string) string
+}
+
+// Built-in collations.
+var (
+	// BINARY compares bytes; it is the default.
+	collationBinary = &Collation{Compare: strings.Compare, Key: func(s string) string { return s }}
+	// NOCASE folds ASCII letters only, like SQLite.
+	collationNoCase = &Collation{Compare: compareNoCase, Key: asciiLower}
+	// RTRIM ignores trailing spaces.
+	collationRTrim = &Collation{Compare: compareRTrim, Key: func(s string) string { return strings.TrimRight(s, " ") }}
+	// UNICODE folds case across all of Unicode (so 'ÄÖ' = 'äö').
+	collationUnicode = &Collation{Compare: compareUnicodeFold, Key: unicodeFoldKey}
+)
+
+var builtinCollations = map[string]*Collation{
+	"BINARY":  collationBinary,
+	"NOCASE":  collationNoCase,
+	"RTRIM":   collationRTrim,
+	"UNICODE": collationUnicode,
+}
+
+// customCollations holds collations added with RegisterCollation. Like Go's
+// database/sql drivers they are process-wide: a column's collation is part
+// of its stored schema and is resolved by name wherever the table is used.
+var customCollations sync.Map // upper-case name -> *Collation
+
+// RegisterCollation makes coll available as COLLATE name (case-insensitive)
+// in column definitions, expressions and ORDER BY. Register collations
+// before opening databases whose tables use them: a column whose collation
+// is unknown compares as BINARY. Built-in collations cannot be replaced.
+func RegisterCollation(name string, coll *Collation) error {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if !isValidFunctionName(upper) {
+		return fmt.Errorf("invalid collation name %q", name)
+	}
+	if coll == nil || coll.Compare == nil {
+		return fmt.Errorf("collation %s has no compare function", upper)
+	}
+	if _, ok := builtinCollations[upper]; ok {
+		return fmt.Errorf("cannot redefine built-in collation %s", upper)
+	}
+	customCollations.Store(upper, coll)
+	return nil
+}
+
+// lookupCollation returns the collation called name, or nil if none is
+// registered. The empty name is BINARY.
+func lookupCollation(name string) *Collation {
+	if name == "" {
+		return collationBinary
+	}
+	upper := toUpperFast(name)
+	if coll, ok := builtinCollations[upper]; ok {
+		return coll
+	}
+	if coll, ok := customCollations.Load(upper); ok {
+		return coll.(*Collation)
+	}
+	return nil
+}
+
+// columnCollation returns the collation declared for col, or nil for
+// BINARY (including unknown names).
+func columnCollation(col *ColumnDef) *Collation {
+	if col == nil || col.Collation == "" {
+		return nil
+	}
+	coll := lookupCollation(col.Collation)
+	if coll == collationBinary {
+		return nil
+	}
+	return coll
+}
+
+// collatedIndexValue returns the value an index stores for v in a column
+// with the given collation: the collation key for strings, v otherwise.
+func collatedIndexValue(col *ColumnDef, v interface{}) interface{} {
+	coll := columnCollation(col)
+	if coll == nil || coll.Key == nil {
+		return v
+	}
+	if s, ok := toString(v); ok {
+		return coll.Key(s)
+	}
+	return v
+}
+
+// hasCollatedColumn reports whether column name of table has a non-BINARY
+// collation. Primary keys are stored by their raw value, so lookups on such
+// a column must scan rather than seek.
+func hasCollatedColumn(table *TableDef, name string) bool {
+	idx := table.GetColumnIndex(name)
+	return idx >= 0 && columnCollation(&table.Columns[idx]) != nil
+}
+
+// compareCollated compares a and b under coll when both are strings and
+// falls back to compareValues otherwise.
+func compareCollated(a, b interface{}, coll *Collation) int {
+	if coll != nil {
+		if as, ok := toString(a); ok {
+			if bs, ok := toString(b); ok {
+				return coll.Compare(as, bs)
+			}
+		}
+	}
+	return compareValues(a, b)
+}
+
+// orderByCollation returns the collation for an ORDER BY term whose values
+// are in selectCols[colIdx]: its COLLATE clause, else the source column's
+// collation. nil means BINARY.
+func orderByCollation(ob *query.OrderByExpr, selectCols []selectColInfo, colIdx int) *Collation {
+	if ce, ok := ob.Expr.(*query.CollateExpr); ok {
+		return lookupCollation(ce.Collation)
+	}
+	if colIdx >= 0 && colIdx < len(selectCols) && selectCols[colIdx].collation != "" {
+		return lookupCollation(selectCols[colIdx].collation)
+	}
+	return nil
+}
+
+// exprCollation returns the collation an operand contributes to a
+// comparison and whether it was explicit (a COLLATE clause) rather than
+// inherited from a column.
+func (ctx *EvalContext) exprCollation(expr query.Expression) (*Collation, bool) {
+	switch e := expr.(type) {
+	case *query.CollateExpr:
+		return lookupCollation(e.Collation), true
+	case *query.AliasExpr:
+		return ctx.exprCollation(e.Expr)
+	case *query.Identifier:
+		return ctx.columnCollationByName("", e.Name), false
+	case *query.QualifiedIdentifier:
+		return ctx.columnCollationByName(e.Table, e.Column), false
+	case *query.ColumnRef:
+		return ctx.columnCollationByName(e.Table, e.Column), false
+	}
+	return nil, false
+}
+
+func (ctx *EvalContext) columnCollationByName(table, name string) *Collation {
+	if dot := strings.IndexByte(name, '.'); table == "" && dot > 0 {
+		table, name = name[:dot], name[dot+1:]
+	}
+	for i := range ctx.Columns {
+		col := &ctx.Columns[i]
+		if col.Collation == "" || !strings.EqualFold(col.Name, name) {
+			continue
+		}
+		if table != "" && col.sourceTbl != "" && !strings.EqualFold(col.sourceTbl, table) {
+			continue
+		}
+		return columnCollation(col)
+	}
+	return nil
+}
+
+// comparisonCollation picks the collation for left op right: an explicit
+// COLLATE clause wins (left before right), then a collated column operand.
+func (ctx *EvalContext) comparisonCollation(leftExpr, rightExpr query.Expression) *Collation {
+	lc, lExplicit := ctx.exprCollation(leftExpr)
+	rc, rExplicit := ctx.exprCollation(rightExpr)
+	switch {
+	case lExplicit:
+		return lc
+	case rExplicit:
+		return rc
+	case lc != nil:
+		return lc
+	}
+	return rc
+}
+
+func (ctx *EvalContext) EvalCollate(val interface{}, collation string) (interface{}, error) {
+	if lookupCollation(collation) == nil {
+		return nil, fmt.Errorf("no such collation sequence: %s", collation)
+	}
+	return val, nil
+}
+
+func (ctx *EvalContext) EvalComparison(left, right interface{}, op query.TokenType, leftExpr, rightExpr query.Expression) (interface{}, error) {
+	coll := ctx.comparisonCollation(leftExpr, rightExpr)
+	if coll == nil || coll == collationBinary || left == nil || right == nil {
+		return applyBinaryOp(left, right, op)
+	}
+	ls, lok := toString(left)
+	rs, rok := toString(right)
+	if !lok || !rok {
+		return applyBinaryOp(left, right, op)
+	}
+	cmp := coll.Compare(ls, rs)
+	switch op {
+	case query.TokenEq, query.TokenNullSafeEq:
+		return cmp == 0, nil
+	case query.TokenNeq:
+		return cmp != 0, nil
+	case query.TokenLt:
+		return cmp < 0, nil
+	case query.TokenGt:
+		return cmp > 0, nil
+	case query.TokenLte:
+		return cmp <= 0, nil
+	case query.TokenGte:
+		return cmp >= 0, nil
+	}
+	return applyBinaryOp(left, right, op)
+}
+
+func asciiLower(s string) string {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 'A' && c <= 'Z' {
+			b := []byte(s)
+			for j := i; j < len(b); j++ {
+				if b[j] >= 'A' && b[j] <= 'Z' {
+					b[j] += 'a' - 'A'
+				}
+			}
+			return string(b)
+		}
+	}
+	return s
+}
+
+func compareNoCase(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ca, cb := a[i], b[i]
+		if ca >= 'A' && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if cb >= 'A' && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+func compareRTrim(a, b string) int {
+	return strings.Compare(strings.TrimRight(a, " "), strings.TrimRight(b, " "))
+}
+
+// foldRune maps r to a canonical member of its Unicode case-folding orbit
+// (the smallest one), so runes that fold together map to the same rune.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+func compareUnicodeFold(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if fa, fb := foldRune(ra), foldRune(rb); fa != fb {
+			if fa < fb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[na:], b[nb:]
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+func unicodeFoldKey(s string) string {
+	return strings.Map(foldRune, s)
+}
@@ -0,0 +1,42 @@
+package catalog
+
+import "testing"
+
+func TestBuiltinCollations(t *testing.T) {
+	tests := []struct {
+		coll  string
+		a, b  string
+		want  int
+		equal bool // Key(a) == Key(b)
+	}{
+		{"BINARY", "a", "A", 1, false},
+		{"NOCASE", "Hello", "hELLO", 0, true},
+		{"NOCASE", "abc", "ABD", -1, false},
+		{"NOCASE", "ab", "AB ", -1, false},
+		{"NOCASE", "Ä", "ä", -1, false}, // ASCII only
+		{"RTRIM", "x  ", "x", 0, true},
+		{"RTRIM", " x", "x", -1, false},
+		{"UNICODE", "Ärger", "äRGER", 0, true},
+		{"UNICODE", "Straße", "STRASSE", 1, false}, // simple folding only
+		{"UNICODE", "a", "B", -1, false},
+	}
+	for _, tt := range tests {
+		coll := lookupCollation(tt.coll)
+		if coll == nil {
+			t.Fatalf("collation %s not found", tt.coll)
+		}
+		got := coll.Compare(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("%s.Compare(%q, %q) = %d, want sign of %d", tt.coll, tt.a, tt.b, got, tt.want)
+		}
+		if eq := coll.Key(tt.a) == coll.Key(tt.b); eq != tt.equal {
+			t.Errorf("%s keys of %q and %q equal = %v, want %v", tt.coll, tt.a, tt.b, eq, tt.equal)
+		}
+	}
+	if lookupCollation("nocase") != collationNoCase || lookupCollation("") != collationBinary {
+		t.Fatal("collation lookup is not case-insensitive")
+	}
+	if lookupCollation("missing") != nil {
+		t.Fatal("unknown collation resolved")
+	}
+}
@@ -1276,11 +1276,24 @@ func TestComprehensive_CollectAggregatesPaths(t *testing.T) {
 
 // ── catalog_core.go resolveAggregateInExpr paths ──
 func TestComprehensive_ResolveAggregatePaths(t *testing.T) {
-	// resolveAggregateInExpr with non-aggregate function
+	// resolveAggregateInExpr with non-aggregate function resolves its arguments
 	expr := &query.FunctionCall{Name: "UPPER", Args: []query.Expression{&query.Identifier{Name: "a"}}}
 	result := resolveAggregateInExpr(expr, []selectColInfo{{name: "a", index: 0}}, []interface{}{"hello"})
+	fc, ok := result.(*query.FunctionCall)
+	if !ok || fc == expr || fc.Name != "UPPER" {
+		t.Fatalf("Expected a resolved copy of UPPER(a), got %#v", result)
+	}
+	if lit, ok := fc.Args[0].(*query.StringLiteral); !ok || lit.Value != "hello" {
+		t.Errorf("Expected UPPER's argument resolved to 'hello', got %#v", fc.Args[0])
+	}
+	if expr.Args[0].(*query.Identifier).Name != "a" {
+		t.Error("resolveAggregateInExpr modified its input")
+	}
+
+	// Nothing to resolve leaves the function as it is
+	result = resolveAggregateInExpr(expr, []selectColInfo{{name: "b", index: 0}}, []interface{}{"hello"})
 	if result != expr {
-		t.Error("Expected resolveAggregateInExpr to return same expr for non-aggregate")
+		t.Error("Expected resolveAggregateInExpr to return same expr when no argument resolves")
 	}
 }
 
@@ -1297,8 +1310,8 @@ func TestComprehensive_ReplaceAggregatesPaths(t *testing.T) {
 // ── catalog_core.go resolvePositionalRefs paths ──
 func TestComprehensive_PositionalRefPaths(t *testing.T) {
 	// resolvePositionalRefs with nil stmt
-	result := resolvePositionalRefs(nil)
-	if result != nil {
+	result, err := resolvePositionalRefs(nil)
+	if result != nil || err != nil {
 		t.Error("Expected nil for nil stmt")
 	}
 
@@ -1307,10 +1320,8 @@ func TestComprehensive_PositionalRefPaths(t *testing.T) {
 		Columns: []query.Expression{&query.NumberLiteral{Value: 1}},
 		OrderBy: []*query.OrderByExpr{{Expr: &query.NumberLiteral{Value: 99}}},
 	}
-	result = resolvePositionalRefs(stmt)
-	if result.OrderBy[0].Expr != stmt.OrderBy[0].Expr {
-		// Should remain unchanged for invalid position
-		t.Log("Invalid positional ref handled")
+	if _, err := resolvePositionalRefs(stmt); err == nil {
+		t.Error("Expected an error for ORDER BY 99 over one column")
 	}
 }
 
@@ -0,0 +1,196 @@
+package catalog
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date and time values are stored as ISO-8601 text in UTC, which keeps them
+// human readable and makes lexical order match chronological order:
+//
+//	DATE                "2006-01-02"
+//	TIMESTAMP/DATETIME  "2006-01-02 15:04:05" (fractional seconds kept when present)
+const (
+	dateLayout     = "2006-01-02"
+	timeLayout     = "15:04:05"
+	datetimeLayout = "2006-01-02 15:04:05"
+	// datetimeFracLayout trims trailing zeros, so whole seconds render
+	// exactly like datetimeLayout.
+	datetimeFracLayout = "2006-01-02 15:04:05.999999999"
+
+	// unixEpochJulianDay is the Julian day number of 1970-01-01 00:00:00 UTC.
+	unixEpochJulianDay = 2440587.5
+)
+
+// currentTime is the clock used by NOW(), CURRENT_TIMESTAMP and 'now'.
+// Like SQLite, the current time is reported in UTC; use the 'localtime'
+// modifier to convert.
+func currentTime() time.Time {
+	return time.Now().UTC()
+}
+
+// dateTimeFunc builds DATE/TIME/DATETIME(value, modifier...) handlers that
+// render the modified value with layout. Unparseable values and unknown
+// modifiers yield NULL.
+func dateTimeFunc(layout string) functionHandler {
+	return func(args []interface{}) (interface{}, error) {
+		t, ok := evalTimeArgs(args)
+		if !ok {
+			return nil, nil
+		}
+		if layout == datetimeLayout {
+			return formatDatetime(t), nil
+		}
+		return t.Format(layout), nil
+	}
+}
+
+// evalTimeArgs parses args[0] as a time value and applies args[1:] as
+// modifiers. Without arguments the current time is used.
+func evalTimeArgs(args []interface{}) (time.Time, bool) {
+	if len(args) == 0 {
+		return currentTime(), true
+	}
+	if args[0] == nil {
+		return time.Time{}, false
+	}
+	t, ok := timeFromValue(args[0])
+	if !ok {
+		return time.Time{}, false
+	}
+	return applyDateModifiers(t, args[1:])
+}
+
+// timeFromValue converts a stored or bound value into a time.Time. Numbers
+// are unix epoch seconds.
+func timeFromValue(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val.UTC(), true
+	case float64:
+		return epochToTime(val), true
+	case int64:
+		return time.Unix(val, 0).UTC(), true
+	case int:
+		return time.Unix(int64(val), 0).UTC(), true
+	}
+	return parseFlexibleTime(ValueToStringKey(v))
+}
+
+func epochToTime(secs float64) time.Time {
+	whole, frac := math.Modf(secs)
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC()
+}
+
+func formatDatetime(t time.Time) string {
+	return t.Format(datetimeFracLayout)
+}
+
+// applyDateModifiers applies SQLite-style date modifiers in order:
+//
+//	'+N days' / '-N hours' / '+N minutes' / '+N seconds' / '+N months' / '+N years'
+//	'start of day' / 'start of month' / 'start of year'
+//	'weekday N'  (advance to the next day whose weekday is N, 0 = Sunday)
+//	'unixepoch'  (the value is epoch seconds; numbers already are)
+//	'localtime' / 'utc'
+func applyDateModifiers(t time.Time, mods []interface{}) (time.Time, bool) {
+	for _, m := range mods {
+		if m == nil {
+			return time.Time{}, false
+		}
+		mod := strings.ToLower(strings.Join(strings.Fields(ValueToStringKey(m)), " "))
+		switch {
+		case mod == "start of day":
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		case mod == "start of month":
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		case mod == "start of year":
+			t = time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+		case mod == "unixepoch" || mod == "utc":
+			t = t.UTC()
+		case mod == "localtime":
+			t = t.Local()
+		case strings.HasPrefix(mod, "weekday "):
+			n, err := strconv.Atoi(strings.TrimPrefix(mod, "weekday "))
+			if err != nil || n < 0 || n > 6 {
+				return time.Time{}, false
+			}
+			t = t.AddDate(0, 0, (n-int(t.Weekday())+7)%7)
+		default:
+			var ok bool
+			if t, ok = applyDateOffset(t, mod); !ok {
+				return time.Time{}, false
+			}
+		}
+	}
+	return t, true
+}
+
+// applyDateOffset applies a '+N unit' modifier. Days, hours, minutes and
+// seconds may be fractional; months and years must be whole numbers and
+// overflow like SQLite ('2024-01-31' + 1 month = '2024-03-02').
+func applyDateOffset(t time.Time, mod string) (time.Time, bool) {
+	fields := strings.Fields(mod)
+	if len(fields) != 2 {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return time.Time{}, false
+	}
+	unit := strings.TrimSuffix(fields[1], "s")
+	switch unit {
+	case "day":
+		return t.Add(time.Duration(n * float64(24*time.Hour))), true
+	case "hour":
+		return t.Add(time.Duration(n * float64(time.Hour))), true
+	case "minute":
+		return t.Add(time.Duration(n * float64(time.Minute))), true
+	case "second":
+		return t.Add(time.Duration(n * float64(time.Second))), true
+	case "month", "year":
+		if n != math.Trunc(n) || math.Abs(n) > 1e6 {
+			return time.Time{}, false
+		}
+		if unit == "month" {
+			return t.AddDate(0, int(n), 0), true
+		}
+		return t.AddDate(int(n), 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// julianDay returns the fractional Julian day number of t.
+func julianDay(t time.Time) float64 {
+	return unixEpochJulianDay + float64(t.UnixNano())/float64(24*time.Hour)
+}
+
+// isTemporalColumnType reports whether values of a column type are stored as
+// normalized date/time text.
+func isTemporalColumnType(colType string) bool {
+	switch strings.ToUpper(colType) {
+	case "DATE", "TIMESTAMP", "DATETIME":
+		return true
+	}
+	return false
+}
+
+// normalizeTemporalValue converts a value bound for a DATE, TIMESTAMP or
+// DATETIME column to its canonical stored form. Values that are not
+// recognizable dates are rejected.
+func normalizeTemporalValue(col *ColumnDef, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	t, ok := timeFromValue(v)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s value for column '%s': %q", strings.ToUpper(col.Type), col.Name, ValueToStringKey(v))
+	}
+	if strings.EqualFold(col.Type, "DATE") {
+		return t.Format(dateLayout), nil
+	}
+	return formatDatetime(t), nil
+}
@@ -9,13 +9,14 @@ import (
 	"time"
 
 	"github.com/cobaltdb/cobaltdb/pkg/btree"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
 	"github.com/cobaltdb/cobaltdb/pkg/txn"
 )
 
 var (
-	ErrForeignKeyViolation = errors.New("foreign key constraint violation")
-	ErrReferencedRowExists = errors.New("cannot delete or update: referenced row exists")
+	ErrForeignKeyViolation = sqlerr.ErrConstraintFK
+	ErrReferencedRowExists = sqlerr.New(sqlerr.CodeForeignKeyViolation, "cannot delete or update: referenced row exists")
 )
 
 // ForeignKeyEnforcer handles foreign key constraint enforcement
@@ -33,6 +34,27 @@ func NewForeignKeyEnforcer(catalog *Catalog) *ForeignKeyEnforcer {
 	return &ForeignKeyEnforcer{catalog: catalog}
 }
 
+// SetForeignKeys turns FOREIGN KEY enforcement on or off, like SQLite's
+// PRAGMA foreign_keys. While it is off, writes neither check references
+// nor run ON DELETE and ON UPDATE actions; rows written meanwhile are not
+// checked when it is turned back on. Enforcement is on by default.
+func (c *Catalog) SetForeignKeys(enabled bool) {
+	c.foreignKeysOff.Store(!enabled)
+}
+
+// ForeignKeys reports whether FOREIGN KEY constraints are enforced.
+func (c *Catalog) ForeignKeys() bool {
+	return !c.foreignKeysOff.Load()
+}
+
+// enforcedForeignKeys returns the foreign keys writes to table must check.
+func (c *Catalog) enforcedForeignKeys(table *TableDef) []ForeignKeyDef {
+	if c.foreignKeysOff.Load() {
+		return nil
+	}
+	return table.ForeignKeys
+}
+
 type referenceChange struct {
 	oldRow  []interface{}
 	newRow  []interface{}
@@ -42,13 +64,16 @@ type referenceChange struct {
 // OnDelete handles foreign key actions when a row is deleted from the referenced table.
 // pkValues accepts one value per primary key column (variadic for backward compatibility).
 func (fke *ForeignKeyEnforcer) OnDelete(ctx context.Context, tableName string, pkValues ...interface{}) error {
-	if len(pkValues) == 0 {
+	if len(pkValues) == 0 || !fke.catalog.ForeignKeys() {
 		return nil
 	}
 	return fke.applyDeleteActions(ctx, tableName, pkValues)
 }
 
 func (fke *ForeignKeyEnforcer) OnDeleteRow(ctx context.Context, tableName string, oldRow []interface{}) error {
+	if !fke.catalog.ForeignKeys() {
+		return nil
+	}
 	table, err := fke.catalog.getTableLocked(tableName)
 	if err != nil || table == nil {
 		return nil
@@ -148,13 +173,16 @@ func (fke *ForeignKeyEnforcer) applyDeleteAction(ctx context.Context, referencin
 // OnUpdate handles foreign key actions when a primary key is updated in the referenced table.
 // oldPkValues and newPkValues must contain one value per primary key column.
 func (fke *ForeignKeyEnforcer) OnUpdate(ctx context.Context, tableName string, oldPkValues []interface{}, newPkValues []interface{}) error {
-	if len(oldPkValues) == 0 || len(newPkValues) == 0 {
+	if len(oldPkValues) == 0 || len(newPkValues) == 0 || !fke.catalog.ForeignKeys() {
 		return nil
 	}
 	return fke.applyUpdateActions(ctx, tableName, oldPkValues, newPkValues)
 }
 
 func (fke *ForeignKeyEnforcer) OnUpdateRow(ctx context.Context, tableName string, oldRow, newRow []interface{}) error {
+	if !fke.catalog.ForeignKeys() {
+		return nil
+	}
 	table, err := fke.catalog.getTableLocked(tableName)
 	if err != nil || table == nil {
 		return nil
@@ -408,6 +436,9 @@ func (fke *ForeignKeyEnforcer) trackUpdatingReferenceRows(tableName string, entr
 // ValidateInsert validates that foreign key values reference existing rows
 // row is a map from column name to value
 func (fke *ForeignKeyEnforcer) ValidateInsert(ctx context.Context, tableName string, row map[string]interface{}) error {
+	if !fke.catalog.ForeignKeys() {
+		return nil
+	}
 	table, err := fke.catalog.getTableLocked(tableName)
 	if err != nil {
 		return err
@@ -452,6 +483,9 @@ func (fke *ForeignKeyEnforcer) ValidateInsert(ctx context.Context, tableName str
 
 // ValidateUpdate validates foreign key constraints on update
 func (fke *ForeignKeyEnforcer) ValidateUpdate(ctx context.Context, tableName string, oldRow, newRow map[string]interface{}) error {
+	if !fke.catalog.ForeignKeys() {
+		return nil
+	}
 	table, err := fke.catalog.getTableLocked(tableName)
 	if err != nil {
 		return err
@@ -676,8 +710,7 @@ func (fke *ForeignKeyEnforcer) pendingDeleteRow(ctx context.Context, tableName s
 		return err
 	}
 	idxUpdates := fke.pendingIndexDeletesForRow(table, tableName, match.key, match.row)
-	fke.appendPendingActionWrite(ts, tableName, match.key, valueData, idxUpdates)
-	return nil
+	return fke.appendPendingActionWrite(ts, tableName, match.key, valueData, idxUpdates)
 }
 
 func (fke *ForeignKeyEnforcer) pendingUpdateForeignKey(ctx context.Context, tableName string, match referencingRowMatch, columns []string, newValues []interface{}, validateLocalFK ...bool) error {
@@ -724,8 +757,7 @@ func (fke *ForeignKeyEnforcer) pendingUpdateForeignKey(ctx context.Context, tabl
 		return err
 	}
 	idxUpdates := fke.pendingIndexUpdatesForRowChange(table, tableName, match.key, match.row, newRow)
-	fke.appendPendingActionWrite(ts, tableName, match.key, valueData, idxUpdates)
-	return nil
+	return fke.appendPendingActionWrite(ts, tableName, match.key, valueData, idxUpdates)
 }
 
 func (fke *ForeignKeyEnforcer) pendingIndexDeletesForRow(table *TableDef, tableName, key string, oldRow []interface{}) []PendingIndexUpdate {
@@ -766,7 +798,10 @@ func (fke *ForeignKeyEnforcer) pendingIndexUpdatesForRowChange(table *TableDef,
 	return updates
 }
 
-func (fke *ForeignKeyEnforcer) appendPendingActionWrite(ts *catalogTxnState, tableName, key string, value []byte, idxUpdates []PendingIndexUpdate) {
+func (fke *ForeignKeyEnforcer) appendPendingActionWrite(ts *catalogTxnState, tableName, key string, value []byte, idxUpdates []PendingIndexUpdate) error {
+	if err := fke.catalog.lockRow(ts, tableName, key); err != nil {
+		return err
+	}
 	fke.catalog.appendPendingWriteTs(ts, PendingWrite{
 		TreeName:     tableName,
 		Key:          key,
@@ -776,6 +811,7 @@ func (fke *ForeignKeyEnforcer) appendPendingActionWrite(ts *catalogTxnState, tab
 	if mt, ok := ts.managerTxn.(*txn.Transaction); ok && mt != nil {
 		mt.SetWrite(tableName, key, value)
 	}
+	return nil
 }
 
 // referencedRowExists checks if a row exists in the referenced table
@@ -952,7 +988,7 @@ func (fke *ForeignKeyEnforcer) updateForeignKey(ctx context.Context, tableName s
 func (fke *ForeignKeyEnforcer) validateActionRow(tableName, selfKey string, table *TableDef, row []interface{}) error {
 	for i, col := range table.Columns {
 		if col.NotNull && i < len(row) && row[i] == nil {
-			return fmt.Errorf("NOT NULL constraint failed: column '%s' cannot be null", col.Name)
+			return sqlerr.Errorf(sqlerr.CodeNotNullViolation, "NOT NULL constraint failed: column '%s' cannot be null", col.Name)
 		}
 	}
 	if err := fke.catalog.checkRowConstraints(table, row, nil); err != nil {
@@ -975,13 +1011,13 @@ func (fke *ForeignKeyEnforcer) validateActionUniqueConstraints(tableName, selfKe
 			continue
 		}
 		conflict, err := fke.actionRowConflicts(tableName, selfKey, table, pending, func(existing []interface{}) bool {
-			return i < len(existing) && existing[i] != nil && compareValues(row[i], existing[i]) == 0
+			return i < len(existing) && existing[i] != nil && compareCollated(row[i], existing[i], columnCollation(&table.Columns[i])) == 0
 		})
 		if err != nil {
 			return err
 		}
 		if conflict {
-			return fmt.Errorf("UNIQUE constraint failed: %s", col.Name)
+			return sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: %s", col.Name)
 		}
 	}
 
@@ -1001,7 +1037,7 @@ func (fke *ForeignKeyEnforcer) validateActionUniqueConstraints(tableName, selfKe
 			return err
 		}
 		if conflict {
-			return fmt.Errorf("UNIQUE constraint failed: duplicate value '%v' in index %s", newIdxKey, idxName)
+			return sqlerr.Errorf(sqlerr.CodeUniqueViolation, "UNIQUE constraint failed: duplicate value '%v' in index %s", newIdxKey, idxName)
 		}
 	}
 
@@ -1024,7 +1060,7 @@ func (fke *ForeignKeyEnforcer) actionRowConflicts(tableName, selfKey string, tab
 
 	tree, exists := fke.catalog.tableTrees[tableName]
 	if !exists {
-		return false, fmt.Errorf("table %s not found", tableName)
+		return false, sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table %s not found", tableName)
 	}
 	iter, err := tree.Scan([]byte{}, []byte{0xFF})
 	if err != nil {
@@ -1070,7 +1106,7 @@ func decodeActionConstraintRow(data []byte, numCols int) ([]interface{}, bool, e
 func (fke *ForeignKeyEnforcer) getRowSlice(tableName string, rowKey interface{}) ([]interface{}, error) {
 	tree, exists := fke.catalog.tableTrees[tableName]
 	if !exists {
-		return nil, fmt.Errorf("table %s not found", tableName)
+		return nil, sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table %s not found", tableName)
 	}
 
 	key := fke.serializeValue(rowKey)
@@ -1096,7 +1132,7 @@ func (fke *ForeignKeyEnforcer) getRowSlice(tableName string, rowKey interface{})
 func (fke *ForeignKeyEnforcer) updateRowSlice(tableName string, rowKey interface{}, rowData []interface{}) error {
 	tree, exists := fke.catalog.tableTrees[tableName]
 	if !exists {
-		return fmt.Errorf("table %s not found", tableName)
+		return sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table %s not found", tableName)
 	}
 
 	ts := fke.catalog.getCurrentTxn()
@@ -1268,7 +1304,7 @@ func (fke *ForeignKeyEnforcer) deleteRow(ctx context.Context, tableName string,
 
 	table := fke.catalog.tables[tableName]
 	if table == nil {
-		return fmt.Errorf("table %s not found", tableName)
+		return sqlerr.Errorf(sqlerr.CodeUndefinedTable, "table %s not found", tableName)
 	}
 	vrow, err := decodeVersionedRow(oldData, len(table.Columns))
 	if err != nil {
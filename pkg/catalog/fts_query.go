@@ -0,0 +1,299 @@
+package catalog
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// BM25 tuning constants (the usual Robertson/Sparck Jones defaults).
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// ftsTokens splits text into lower-cased words: runs of letters and digits.
+func ftsTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ftsTerm is one word or quoted phrase of a full-text query.
+type ftsTerm struct {
+	words  []string
+	prefix bool // the last word matches any word it starts (word*)
+	negate bool // NOT term / -term
+}
+
+// ftsQuery is a parsed MATCH pattern: alternatives separated by OR, each a
+// list of terms that must all match (implicit AND).
+type ftsQuery [][]ftsTerm
+
+// parseFTSQuery parses a MATCH pattern. It supports bare words, "quoted
+// phrases", prefix terms (data*), NOT term or -term exclusions, AND (the
+// default) and OR.
+func parseFTSQuery(pattern string) ftsQuery {
+	q := ftsQuery{nil}
+	negate := false
+	add := func(words []string, prefix bool) {
+		if len(words) == 0 {
+			return
+		}
+		last := len(q) - 1
+		q[last] = append(q[last], ftsTerm{words: words, prefix: prefix, negate: negate})
+		negate = false
+	}
+	for i := 0; i < len(pattern); {
+		switch ch := pattern[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '"':
+			end := strings.IndexByte(pattern[i+1:], '"')
+			if end < 0 {
+				end = len(pattern) - i - 1
+			}
+			phrase := pattern[i+1 : i+1+end]
+			i += end + 2
+			prefix := i < len(pattern) && pattern[i] == '*'
+			if prefix {
+				i++
+			}
+			add(ftsTokens(phrase), prefix)
+		case ch == '-':
+			negate = true
+			i++
+		default:
+			start := i
+			for i < len(pattern) && !strings.ContainsRune(" \t\n\r\"", rune(pattern[i])) {
+				i++
+			}
+			word := pattern[start:i]
+			switch word {
+			case "OR":
+				q = append(q, nil)
+				continue
+			case "AND":
+				continue
+			case "NOT":
+				negate = true
+				continue
+			}
+			add(ftsTokens(word), strings.HasSuffix(word, "*"))
+		}
+	}
+	return q
+}
+
+// positiveTerms returns the non-negated terms of every alternative.
+func (q ftsQuery) positiveTerms() []ftsTerm {
+	var terms []ftsTerm
+	for _, clause := range q {
+		for _, t := range clause {
+			if !t.negate {
+				terms = append(terms, t)
+			}
+		}
+	}
+	return terms
+}
+
+// matches reports whether a document (its token lists, one per column)
+// satisfies the query.
+func (q ftsQuery) matches(doc [][]string) bool {
+	for _, clause := range q {
+		positive := false
+		ok := true
+		for _, t := range clause {
+			found := t.count(doc) > 0
+			if found == t.negate {
+				ok = false
+				break
+			}
+			positive = positive || !t.negate
+		}
+		if ok && positive {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns how often the term occurs in doc. Phrases must appear within
+// a single column.
+func (t ftsTerm) count(doc [][]string) int {
+	n := 0
+	for _, tokens := range doc {
+		for i := 0; i+len(t.words) <= len(tokens); i++ {
+			if t.matchesAt(tokens, i) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (t ftsTerm) matchesAt(tokens []string, i int) bool {
+	last := len(t.words) - 1
+	for j, w := range t.words {
+		if j == last && t.prefix {
+			if !strings.HasPrefix(tokens[i+j], w) {
+				return false
+			}
+		} else if tokens[i+j] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// docFreq estimates the number of indexed rows containing the term.
+// Phrases use their rarest word.
+func (t ftsTerm) docFreq(idx *FTSIndexDef) int {
+	df := -1
+	for j, w := range t.words {
+		n := len(idx.Index[w])
+		if j == len(t.words)-1 && t.prefix {
+			seen := make(map[int64]struct{})
+			for word, ids := range idx.Index {
+				if strings.HasPrefix(word, w) {
+					for _, id := range ids {
+						seen[id] = struct{}{}
+					}
+				}
+			}
+			n = len(seen)
+		}
+		if df < 0 || n < df {
+			df = n
+		}
+	}
+	return max(df, 0)
+}
+
+// bm25Score ranks doc against the query's terms using the corpus statistics
+// of idx. Without an index the row is scored as a corpus of one. Higher
+// scores are better matches; rows containing none of the terms score 0.
+func bm25Score(q ftsQuery, doc [][]string, idx *FTSIndexDef) float64 {
+	dl := 0
+	for _, tokens := range doc {
+		dl += len(tokens)
+	}
+	n, avgdl := 1.0, float64(dl)
+	if idx != nil && len(idx.Docs) > 0 {
+		n = float64(len(idx.Docs))
+		avgdl = float64(idx.TotalTerms) / n
+	}
+	if avgdl <= 0 {
+		avgdl = 1
+	}
+	score := 0.0
+	for _, t := range q.positiveTerms() {
+		tf := float64(t.count(doc))
+		if tf == 0 {
+			continue
+		}
+		df := 1.0
+		if idx != nil && len(idx.Docs) > 0 {
+			df = float64(max(t.docFreq(idx), 1))
+		}
+		idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(dl)/avgdl))
+	}
+	return score
+}
+
+// evaluateFTSMatchLocked evaluates `target MATCH pattern` and BM25(target,
+// pattern). The target is either a table with a full-text index, which
+// searches all of the index's columns, or a single column.
+// Caller must hold c.mu (read or write lock).
+func evaluateFTSMatchLocked(c *Catalog, row []interface{}, columns []ColumnDef, expr *query.MatchExpr, args []interface{}) (interface{}, error) {
+	if len(expr.Columns) != 1 {
+		return nil, fmt.Errorf("MATCH expects a single table or column")
+	}
+	doc, idx, err := ftsDocument(c, row, columns, expr.Columns[0])
+	if err != nil {
+		return nil, err
+	}
+	patternVal, err := evaluateExpression(c, row, columns, expr.Pattern, args)
+	if err != nil {
+		return nil, err
+	}
+	if patternVal == nil {
+		return nil, nil
+	}
+	q := parseFTSQuery(ValueToStringKey(patternVal))
+
+	if idx != nil {
+		c.ftsMu.RLock()
+		defer c.ftsMu.RUnlock()
+	}
+	if expr.Rank {
+		return bm25Score(q, doc, idx), nil
+	}
+	return q.matches(doc), nil
+}
+
+// ftsDocument tokenizes the text a MATCH target refers to in row and returns
+// the full-text index whose statistics rank it (nil if there is none).
+func ftsDocument(c *Catalog, row []interface{}, columns []ColumnDef, target query.Expression) ([][]string, *FTSIndexDef, error) {
+	var tableName, colName string
+	switch t := target.(type) {
+	case *query.Identifier:
+		colName = t.Name
+	case *query.QualifiedIdentifier:
+		tableName, colName = t.Table, t.Column
+	default:
+		return nil, nil, fmt.Errorf("MATCH expects a table or column name")
+	}
+
+	columnText := func(i int) []string {
+		if i < len(row) && row[i] != nil {
+			return ftsTokens(ValueToStringKey(row[i]))
+		}
+		return nil
+	}
+	findColumn := func(table, name string) int {
+		for i, col := range columns {
+			if strings.EqualFold(col.Name, name) && (table == "" || col.sourceTbl == "" || strings.EqualFold(col.sourceTbl, table)) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if i := findColumn(tableName, colName); i >= 0 {
+		table := columns[i].sourceTbl
+		for _, ftsIndex := range c.ftsIndexes {
+			if table != "" && !strings.EqualFold(ftsIndex.TableName, table) {
+				continue
+			}
+			for _, col := range ftsIndex.Columns {
+				if strings.EqualFold(col, colName) {
+					return [][]string{columnText(i)}, ftsIndex, nil
+				}
+			}
+		}
+		return [][]string{columnText(i)}, nil, nil
+	}
+
+	// Not a column: the name of a table with a full-text index.
+	if tableName == "" {
+		for _, ftsIndex := range c.ftsIndexes {
+			if !strings.EqualFold(ftsIndex.TableName, colName) {
+				continue
+			}
+			doc := make([][]string, 0, len(ftsIndex.Columns))
+			for _, col := range ftsIndex.Columns {
+				if i := findColumn(ftsIndex.TableName, col); i >= 0 {
+					doc = append(doc, columnText(i))
+				}
+			}
+			return doc, ftsIndex, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("MATCH target %s is neither a column nor a table with a full-text index", colName)
+}
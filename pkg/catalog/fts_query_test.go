@@ -0,0 +1,119 @@
+package catalog
+
+import "testing"
+
+func TestParseFTSQueryMatches(t *testing.T) {
+	doc := [][]string{ftsTokens("Full-Text search"), ftsTokens("An inverted index ranks documents with BM25")}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"search", true},
+		{"SEARCH index", true},
+		{"search missing", false},
+		{"missing OR bm25", true},
+		{"search -index", false},
+		{"search NOT missing", true},
+		{"-missing", false}, // no positive term
+		{`"inverted index"`, true},
+		{`"index inverted"`, false},
+		{`"search an"`, false}, // phrases do not span columns
+		{"doc*", true},
+		{`"inverted ind"*`, true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := parseFTSQuery(tt.query).matches(doc); got != tt.want {
+			t.Errorf("%q matches = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestBM25ScoreOrdering(t *testing.T) {
+	idx := &FTSIndexDef{}
+	short := [][]string{ftsTokens("rare word")}
+	long := [][]string{ftsTokens("rare and common words padding the document out")}
+	common := [][]string{ftsTokens("common word")}
+	for i, doc := range [][][]string{short, long, common, {ftsTokens("common text")}} {
+		addFTSDocForTest(idx, int64(i+1), doc)
+	}
+
+	q := parseFTSQuery("rare")
+	if s, l := bm25Score(q, short, idx), bm25Score(q, long, idx); s <= l {
+		t.Fatalf("shorter document should score higher: %v <= %v", s, l)
+	}
+	if r, c := bm25Score(parseFTSQuery("rare"), short, idx), bm25Score(parseFTSQuery("common"), common, idx); r <= c {
+		t.Fatalf("rarer term should score higher: %v <= %v", r, c)
+	}
+	if s := bm25Score(q, common, idx); s != 0 {
+		t.Fatalf("non-matching document scored %v", s)
+	}
+	if s := bm25Score(q, short, nil); s <= 0 {
+		t.Fatalf("score without an index = %v, want > 0", s)
+	}
+}
+
+func addFTSDocForTest(idx *FTSIndexDef, id int64, doc [][]string) {
+	if idx.Index == nil {
+		idx.Index = make(map[string][]int64)
+		idx.Docs = make(map[int64]map[string]int)
+	}
+	terms := make(map[string]int)
+	for _, tokens := range doc {
+		for _, tok := range tokens {
+			terms[tok]++
+			idx.TotalTerms++
+		}
+	}
+	for term := range terms {
+		idx.Index[term] = insertSortedID(idx.Index[term], id)
+	}
+	idx.Docs[id] = terms
+}
+
+func TestFTSIndexMaintenance(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+	jcExec(t, c, "INSERT INTO notes VALUES (1, 'apple banana'), (2, 'banana cherry')")
+	if err := c.CreateFTSIndex("notes_fts", "notes", []string{"body"}); err != nil {
+		t.Fatalf("CreateFTSIndex: %v", err)
+	}
+	idx := c.ftsIndexes["notes_fts"]
+	check := func(step string, docs int, term string, df int) {
+		t.Helper()
+		if len(idx.Docs) != docs || len(idx.Index[term]) != df {
+			t.Fatalf("%s: docs=%d df(%s)=%d, want %d and %d", step, len(idx.Docs), term, len(idx.Index[term]), docs, df)
+		}
+	}
+	check("create", 2, "banana", 2)
+
+	jcExec(t, c, "INSERT INTO notes VALUES (3, 'banana split')")
+	check("insert", 3, "banana", 3)
+	jcExec(t, c, "UPDATE notes SET body = 'durian' WHERE id = 1")
+	check("update", 3, "banana", 2)
+	if idx.TotalTerms != 5 {
+		t.Fatalf("TotalTerms = %d, want 5", idx.TotalTerms)
+	}
+	jcExec(t, c, "DELETE FROM notes WHERE id = 2")
+	check("delete", 2, "cherry", 0)
+
+	c.BeginTransaction(1)
+	jcExec(t, c, "INSERT INTO notes VALUES (4, 'cherry')")
+	jcExec(t, c, "DELETE FROM notes WHERE id = 3")
+	check("in transaction", 2, "cherry", 1)
+	if err := c.RollbackTransaction(); err != nil {
+		t.Fatalf("RollbackTransaction: %v", err)
+	}
+	idx = c.ftsIndexes["notes_fts"]
+	check("rollback", 2, "cherry", 0)
+	check("rollback", 2, "banana", 1)
+
+	if got := jcScalar(t, c, "SELECT id FROM notes WHERE body MATCH 'split'"); got != "3" {
+		t.Fatalf("MATCH after rollback = %s, want 3", got)
+	}
+
+	jcExec(t, c, "DROP TABLE notes")
+	if _, err := c.GetFTSIndex("notes_fts"); err == nil {
+		t.Fatal("FTS index survived DROP TABLE")
+	}
+}
@@ -0,0 +1,239 @@
+package catalog
+
+import "sort"
+
+// Function kinds reported by BuiltinFunctions.
+const (
+	FunctionScalar    = "scalar"
+	FunctionAggregate = "aggregate"
+	FunctionWindow    = "window"
+)
+
+// FunctionInfo describes a built-in SQL function for tooling such as editor
+// completion. Signature uses SQL-ish notation: optional arguments in [...],
+// repeated arguments as "...".
+type FunctionInfo struct {
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Signature string   `json:"signature"`
+	Returns   string   `json:"returns"`
+	Aliases   []string `json:"aliases,omitempty"`
+}
+
+// builtinFunctions lists every function the evaluator dispatches on. Keep it in
+// sync with scalarFunctionHandlers and the evaluate*Function switches; the
+// functions test checks the dispatch map against it.
+var builtinFunctions = []FunctionInfo{
+	// String
+	{Name: "LENGTH", Kind: FunctionScalar, Signature: "LENGTH(str)", Returns: "INTEGER", Aliases: []string{"LEN"}},
+	{Name: "UPPER", Kind: FunctionScalar, Signature: "UPPER(str)", Returns: "TEXT"},
+	{Name: "LOWER", Kind: FunctionScalar, Signature: "LOWER(str)", Returns: "TEXT"},
+	{Name: "TRIM", Kind: FunctionScalar, Signature: "TRIM(str [, chars])", Returns: "TEXT"},
+	{Name: "LTRIM", Kind: FunctionScalar, Signature: "LTRIM(str [, chars])", Returns: "TEXT"},
+	{Name: "RTRIM", Kind: FunctionScalar, Signature: "RTRIM(str [, chars])", Returns: "TEXT"},
+	{Name: "SUBSTR", Kind: FunctionScalar, Signature: "SUBSTR(str, start [, length])", Returns: "TEXT", Aliases: []string{"SUBSTRING"}},
+	{Name: "CONCAT", Kind: FunctionScalar, Signature: "CONCAT(value, ...)", Returns: "TEXT"},
+	{Name: "CONCAT_WS", Kind: FunctionScalar, Signature: "CONCAT_WS(separator, value, ...)", Returns: "TEXT"},
+	{Name: "REPLACE", Kind: FunctionScalar, Signature: "REPLACE(str, from, to)", Returns: "TEXT"},
+	{Name: "TRANSLATE", Kind: FunctionScalar, Signature: "TRANSLATE(str, from, to)", Returns: "TEXT"},
+	{Name: "INSTR", Kind: FunctionScalar, Signature: "INSTR(str, substr)", Returns: "INTEGER", Aliases: []string{"STRPOS"}},
+	{Name: "LOCATE", Kind: FunctionScalar, Signature: "LOCATE(substr, str [, start])", Returns: "INTEGER", Aliases: []string{"POSITION"}},
+	{Name: "SUBSTRING_INDEX", Kind: FunctionScalar, Signature: "SUBSTRING_INDEX(str, delim, count)", Returns: "TEXT"},
+	{Name: "SPLIT_PART", Kind: FunctionScalar, Signature: "SPLIT_PART(str, delim, n)", Returns: "TEXT"},
+	{Name: "ASCII", Kind: FunctionScalar, Signature: "ASCII(str)", Returns: "INTEGER"},
+	{Name: "PRINTF", Kind: FunctionScalar, Signature: "PRINTF(format, value, ...)", Returns: "TEXT"},
+	{Name: "REVERSE", Kind: FunctionScalar, Signature: "REVERSE(str)", Returns: "TEXT"},
+	{Name: "REPEAT", Kind: FunctionScalar, Signature: "REPEAT(str, count)", Returns: "TEXT"},
+	{Name: "LEFT", Kind: FunctionScalar, Signature: "LEFT(str, n)", Returns: "TEXT"},
+	{Name: "RIGHT", Kind: FunctionScalar, Signature: "RIGHT(str, n)", Returns: "TEXT"},
+	{Name: "LPAD", Kind: FunctionScalar, Signature: "LPAD(str, length [, pad])", Returns: "TEXT"},
+	{Name: "RPAD", Kind: FunctionScalar, Signature: "RPAD(str, length [, pad])", Returns: "TEXT"},
+	{Name: "HEX", Kind: FunctionScalar, Signature: "HEX(value)", Returns: "TEXT"},
+	{Name: "UNHEX", Kind: FunctionScalar, Signature: "UNHEX(hex)", Returns: "BLOB"},
+	{Name: "UNICODE", Kind: FunctionScalar, Signature: "UNICODE(str)", Returns: "INTEGER"},
+	{Name: "CHAR", Kind: FunctionScalar, Signature: "CHAR(code, ...)", Returns: "TEXT"},
+	{Name: "QUOTE", Kind: FunctionScalar, Signature: "QUOTE(value)", Returns: "TEXT"},
+	{Name: "GLOB", Kind: FunctionScalar, Signature: "GLOB(pattern, str)", Returns: "BOOLEAN"},
+	{Name: "ILIKE", Kind: FunctionScalar, Signature: "ILIKE(str, pattern [, escape])", Returns: "BOOLEAN"},
+	{Name: "ZEROBLOB", Kind: FunctionScalar, Signature: "ZEROBLOB(n)", Returns: "BLOB"},
+	{Name: "REGEXP_LIKE", Kind: FunctionScalar, Signature: "REGEXP_LIKE(str, pattern [, flags])", Returns: "BOOLEAN", Aliases: []string{"REGEXP_MATCH"}},
+	{Name: "REGEXP_REPLACE", Kind: FunctionScalar, Signature: "REGEXP_REPLACE(str, pattern, replacement [, flags])", Returns: "TEXT"},
+	{Name: "REGEXP_EXTRACT", Kind: FunctionScalar, Signature: "REGEXP_EXTRACT(str, pattern [, group])", Returns: "TEXT"},
+
+	// Hashing and encoding
+	{Name: "MD5", Kind: FunctionScalar, Signature: "MD5(value)", Returns: "TEXT"},
+	{Name: "SHA1", Kind: FunctionScalar, Signature: "SHA1(value)", Returns: "TEXT"},
+	{Name: "SHA256", Kind: FunctionScalar, Signature: "SHA256(value)", Returns: "TEXT"},
+	{Name: "SHA512", Kind: FunctionScalar, Signature: "SHA512(value)", Returns: "TEXT"},
+	{Name: "SHA2", Kind: FunctionScalar, Signature: "SHA2(value, bits)", Returns: "TEXT"},
+	{Name: "HMAC", Kind: FunctionScalar, Signature: "HMAC(key, message [, algorithm])", Returns: "TEXT"},
+	{Name: "CRC32", Kind: FunctionScalar, Signature: "CRC32(value)", Returns: "INTEGER"},
+	{Name: "TO_BASE64", Kind: FunctionScalar, Signature: "TO_BASE64(value)", Returns: "TEXT"},
+	{Name: "FROM_BASE64", Kind: FunctionScalar, Signature: "FROM_BASE64(text)", Returns: "BLOB"},
+
+	// Math
+	{Name: "ABS", Kind: FunctionScalar, Signature: "ABS(x)", Returns: "REAL"},
+	{Name: "ROUND", Kind: FunctionScalar, Signature: "ROUND(x [, digits])", Returns: "REAL"},
+	{Name: "FLOOR", Kind: FunctionScalar, Signature: "FLOOR(x)", Returns: "REAL"},
+	{Name: "CEIL", Kind: FunctionScalar, Signature: "CEIL(x)", Returns: "REAL", Aliases: []string{"CEILING"}},
+	{Name: "MOD", Kind: FunctionScalar, Signature: "MOD(x, y)", Returns: "REAL"},
+	{Name: "POWER", Kind: FunctionScalar, Signature: "POWER(x, y)", Returns: "REAL", Aliases: []string{"POW"}},
+	{Name: "SQRT", Kind: FunctionScalar, Signature: "SQRT(x)", Returns: "REAL"},
+	{Name: "SIGN", Kind: FunctionScalar, Signature: "SIGN(x)", Returns: "INTEGER"},
+	{Name: "TRUNCATE", Kind: FunctionScalar, Signature: "TRUNCATE(x [, digits])", Returns: "REAL", Aliases: []string{"TRUNC"}},
+	{Name: "EXP", Kind: FunctionScalar, Signature: "EXP(x)", Returns: "REAL"},
+	{Name: "LN", Kind: FunctionScalar, Signature: "LN(x)", Returns: "REAL"},
+	{Name: "LOG", Kind: FunctionScalar, Signature: "LOG([base,] x)", Returns: "REAL"},
+	{Name: "LOG10", Kind: FunctionScalar, Signature: "LOG10(x)", Returns: "REAL"},
+	{Name: "LOG2", Kind: FunctionScalar, Signature: "LOG2(x)", Returns: "REAL"},
+	{Name: "PI", Kind: FunctionScalar, Signature: "PI()", Returns: "REAL"},
+	{Name: "RADIANS", Kind: FunctionScalar, Signature: "RADIANS(degrees)", Returns: "REAL"},
+	{Name: "DEGREES", Kind: FunctionScalar, Signature: "DEGREES(radians)", Returns: "REAL"},
+	{Name: "GREATEST", Kind: FunctionScalar, Signature: "GREATEST(value, ...)", Returns: "ANY"},
+	{Name: "LEAST", Kind: FunctionScalar, Signature: "LEAST(value, ...)", Returns: "ANY"},
+	{Name: "SIN", Kind: FunctionScalar, Signature: "SIN(x)", Returns: "REAL"},
+	{Name: "COS", Kind: FunctionScalar, Signature: "COS(x)", Returns: "REAL"},
+	{Name: "TAN", Kind: FunctionScalar, Signature: "TAN(x)", Returns: "REAL"},
+	{Name: "ASIN", Kind: FunctionScalar, Signature: "ASIN(x)", Returns: "REAL"},
+	{Name: "ACOS", Kind: FunctionScalar, Signature: "ACOS(x)", Returns: "REAL"},
+	{Name: "ATAN", Kind: FunctionScalar, Signature: "ATAN(x)", Returns: "REAL"},
+	{Name: "ATAN2", Kind: FunctionScalar, Signature: "ATAN2(y, x)", Returns: "REAL"},
+	{Name: "COT", Kind: FunctionScalar, Signature: "COT(x)", Returns: "REAL"},
+	{Name: "SINH", Kind: FunctionScalar, Signature: "SINH(x)", Returns: "REAL"},
+	{Name: "COSH", Kind: FunctionScalar, Signature: "COSH(x)", Returns: "REAL"},
+	{Name: "TANH", Kind: FunctionScalar, Signature: "TANH(x)", Returns: "REAL"},
+	{Name: "RANDOM", Kind: FunctionScalar, Signature: "RANDOM()", Returns: "REAL"},
+	{Name: "RANDOMBLOB", Kind: FunctionScalar, Signature: "RANDOMBLOB(n)", Returns: "BLOB"},
+
+	// UUIDs
+	{Name: "UUID", Kind: FunctionScalar, Signature: "UUID([version])", Returns: "TEXT"},
+	{Name: "UUID_V7", Kind: FunctionScalar, Signature: "UUID_V7()", Returns: "TEXT"},
+	{Name: "UUID_TO_BIN", Kind: FunctionScalar, Signature: "UUID_TO_BIN(uuid)", Returns: "BLOB"},
+	{Name: "BIN_TO_UUID", Kind: FunctionScalar, Signature: "BIN_TO_UUID(blob)", Returns: "TEXT"},
+
+	// Sequences and row ids
+	{Name: "NEXTVAL", Kind: FunctionScalar, Signature: "NEXTVAL(sequence)", Returns: "INTEGER"},
+	{Name: "CURRVAL", Kind: FunctionScalar, Signature: "CURRVAL(sequence)", Returns: "INTEGER"},
+	{Name: "SETVAL", Kind: FunctionScalar, Signature: "SETVAL(sequence, value [, is_called])", Returns: "INTEGER"},
+	{Name: "LAST_INSERT_ROWID", Kind: FunctionScalar, Signature: "LAST_INSERT_ROWID()", Returns: "INTEGER"},
+
+	// Column encryption and masking
+	{Name: "ENCRYPT", Kind: FunctionScalar, Signature: "ENCRYPT(value, key_id)", Returns: "TEXT"},
+	{Name: "DECRYPT", Kind: FunctionScalar, Signature: "DECRYPT(value, key_id)", Returns: "TEXT"},
+	{Name: "MASK", Kind: FunctionScalar, Signature: "MASK(value)", Returns: "ANY"},
+	{Name: "MASK_FIRST", Kind: FunctionScalar, Signature: "MASK_FIRST(value, n)", Returns: "TEXT"},
+	{Name: "MASK_LAST", Kind: FunctionScalar, Signature: "MASK_LAST(value, n)", Returns: "TEXT"},
+	{Name: "MASK_EMAIL", Kind: FunctionScalar, Signature: "MASK_EMAIL(value)", Returns: "TEXT"},
+
+	// Date and time
+	{Name: "NOW", Kind: FunctionScalar, Signature: "NOW()", Returns: "TEXT"},
+	{Name: "CURRENT_TIMESTAMP", Kind: FunctionScalar, Signature: "CURRENT_TIMESTAMP", Returns: "TEXT"},
+	{Name: "CURRENT_DATE", Kind: FunctionScalar, Signature: "CURRENT_DATE", Returns: "TEXT"},
+	{Name: "CURRENT_TIME", Kind: FunctionScalar, Signature: "CURRENT_TIME", Returns: "TEXT"},
+	{Name: "DATE", Kind: FunctionScalar, Signature: "DATE(value, modifier...)", Returns: "TEXT"},
+	{Name: "TIME", Kind: FunctionScalar, Signature: "TIME(value, modifier...)", Returns: "TEXT"},
+	{Name: "DATETIME", Kind: FunctionScalar, Signature: "DATETIME(value, modifier...)", Returns: "TEXT"},
+	{Name: "STRFTIME", Kind: FunctionScalar, Signature: "STRFTIME(format, value, modifier...)", Returns: "TEXT"},
+	{Name: "JULIANDAY", Kind: FunctionScalar, Signature: "JULIANDAY(value, modifier...)", Returns: "REAL"},
+	{Name: "UNIXEPOCH", Kind: FunctionScalar, Signature: "UNIXEPOCH(value, modifier...)", Returns: "INTEGER"},
+	{Name: "YEAR", Kind: FunctionScalar, Signature: "YEAR(date)", Returns: "INTEGER"},
+	{Name: "MONTH", Kind: FunctionScalar, Signature: "MONTH(date)", Returns: "INTEGER"},
+	{Name: "DAY", Kind: FunctionScalar, Signature: "DAY(date)", Returns: "INTEGER", Aliases: []string{"DAYOFMONTH"}},
+	{Name: "HOUR", Kind: FunctionScalar, Signature: "HOUR(time)", Returns: "INTEGER"},
+	{Name: "MINUTE", Kind: FunctionScalar, Signature: "MINUTE(time)", Returns: "INTEGER"},
+	{Name: "SECOND", Kind: FunctionScalar, Signature: "SECOND(time)", Returns: "INTEGER"},
+	{Name: "DAYOFWEEK", Kind: FunctionScalar, Signature: "DAYOFWEEK(date)", Returns: "INTEGER"},
+	{Name: "DAYOFYEAR", Kind: FunctionScalar, Signature: "DAYOFYEAR(date)", Returns: "INTEGER"},
+	{Name: "WEEKDAY", Kind: FunctionScalar, Signature: "WEEKDAY(date)", Returns: "INTEGER"},
+	{Name: "DATE_ADD", Kind: FunctionScalar, Signature: "DATE_ADD(date, days)", Returns: "TEXT"},
+	{Name: "DATE_SUB", Kind: FunctionScalar, Signature: "DATE_SUB(date, days)", Returns: "TEXT"},
+	{Name: "DATEDIFF", Kind: FunctionScalar, Signature: "DATEDIFF(end, start)", Returns: "INTEGER"},
+
+	// JSON
+	{Name: "JSON_EXTRACT", Kind: FunctionScalar, Signature: "JSON_EXTRACT(json, path)", Returns: "ANY"},
+	{Name: "JSON_SET", Kind: FunctionScalar, Signature: "JSON_SET(json, path, value)", Returns: "JSON"},
+	{Name: "JSON_REMOVE", Kind: FunctionScalar, Signature: "JSON_REMOVE(json, path)", Returns: "JSON"},
+	{Name: "JSON_VALID", Kind: FunctionScalar, Signature: "JSON_VALID(value)", Returns: "BOOLEAN"},
+	{Name: "JSON_ARRAY_LENGTH", Kind: FunctionScalar, Signature: "JSON_ARRAY_LENGTH(json [, path])", Returns: "INTEGER"},
+	{Name: "JSON_TYPE", Kind: FunctionScalar, Signature: "JSON_TYPE(json [, path])", Returns: "TEXT"},
+	{Name: "JSON_KEYS", Kind: FunctionScalar, Signature: "JSON_KEYS(json)", Returns: "JSON"},
+	{Name: "JSON_PRETTY", Kind: FunctionScalar, Signature: "JSON_PRETTY(json)", Returns: "TEXT"},
+	{Name: "JSON_MINIFY", Kind: FunctionScalar, Signature: "JSON_MINIFY(json)", Returns: "TEXT"},
+	{Name: "JSON_MERGE", Kind: FunctionScalar, Signature: "JSON_MERGE(json, json)", Returns: "JSON"},
+	{Name: "JSON_QUOTE", Kind: FunctionScalar, Signature: "JSON_QUOTE(str)", Returns: "TEXT"},
+	{Name: "JSON_UNQUOTE", Kind: FunctionScalar, Signature: "JSON_UNQUOTE(json)", Returns: "TEXT"},
+	{Name: "JSON_OBJECT", Kind: FunctionScalar, Signature: "JSON_OBJECT(key, value, ...)", Returns: "JSON"},
+	{Name: "JSON_ARRAY", Kind: FunctionScalar, Signature: "JSON_ARRAY(value, ...)", Returns: "JSON"},
+	{Name: "JSON_CONTAINS", Kind: FunctionScalar, Signature: "JSON_CONTAINS(json, candidate [, path])", Returns: "BOOLEAN"},
+	{Name: "JSON_CONTAINS_PATH", Kind: FunctionScalar, Signature: "JSON_CONTAINS_PATH(json, 'one'|'all', path, ...)", Returns: "BOOLEAN"},
+
+	// Vector
+	{Name: "COSINE_SIMILARITY", Kind: FunctionScalar, Signature: "COSINE_SIMILARITY(a, b)", Returns: "REAL"},
+	{Name: "L2_DISTANCE", Kind: FunctionScalar, Signature: "L2_DISTANCE(a, b)", Returns: "REAL", Aliases: []string{"L2_DIST"}},
+	{Name: "INNER_PRODUCT", Kind: FunctionScalar, Signature: "INNER_PRODUCT(a, b)", Returns: "REAL", Aliases: []string{"DOT_PRODUCT", "DOT"}},
+
+	// Conditional and conversion
+	{Name: "COALESCE", Kind: FunctionScalar, Signature: "COALESCE(value, ...)", Returns: "ANY"},
+	{Name: "IFNULL", Kind: FunctionScalar, Signature: "IFNULL(value, fallback)", Returns: "ANY"},
+	{Name: "NULLIF", Kind: FunctionScalar, Signature: "NULLIF(a, b)", Returns: "ANY"},
+	{Name: "IIF", Kind: FunctionScalar, Signature: "IIF(condition, then, else)", Returns: "ANY"},
+	{Name: "TYPEOF", Kind: FunctionScalar, Signature: "TYPEOF(value)", Returns: "TEXT"},
+	{Name: "CAST", Kind: FunctionScalar, Signature: "CAST(value AS type)", Returns: "ANY"},
+	{Name: "INTEGER", Kind: FunctionScalar, Signature: "INTEGER(value)", Returns: "INTEGER", Aliases: []string{"INT"}},
+	{Name: "REAL", Kind: FunctionScalar, Signature: "REAL(value)", Returns: "REAL", Aliases: []string{"FLOAT"}},
+	{Name: "TEXT", Kind: FunctionScalar, Signature: "TEXT(value)", Returns: "TEXT", Aliases: []string{"STRING"}},
+	{Name: "BOOLEAN", Kind: FunctionScalar, Signature: "BOOLEAN(value)", Returns: "BOOLEAN", Aliases: []string{"BOOL"}},
+	{Name: "IS_TRUE", Kind: FunctionScalar, Signature: "IS_TRUE(value)", Returns: "BOOLEAN"},
+	{Name: "IS_FALSE", Kind: FunctionScalar, Signature: "IS_FALSE(value)", Returns: "BOOLEAN"},
+	{Name: "IS_UNKNOWN", Kind: FunctionScalar, Signature: "IS_UNKNOWN(value)", Returns: "BOOLEAN"},
+
+	// Session
+	{Name: "VERSION", Kind: FunctionScalar, Signature: "VERSION()", Returns: "TEXT"},
+	{Name: "DATABASE", Kind: FunctionScalar, Signature: "DATABASE()", Returns: "TEXT", Aliases: []string{"SCHEMA"}},
+	{Name: "USER", Kind: FunctionScalar, Signature: "USER()", Returns: "TEXT", Aliases: []string{"CURRENT_USER", "SESSION_USER", "SYSTEM_USER"}},
+	{Name: "CONNECTION_ID", Kind: FunctionScalar, Signature: "CONNECTION_ID()", Returns: "INTEGER"},
+
+	// Aggregates
+	{Name: "COUNT", Kind: FunctionAggregate, Signature: "COUNT(* | [DISTINCT] expr)", Returns: "INTEGER"},
+	{Name: "SUM", Kind: FunctionAggregate, Signature: "SUM([DISTINCT] expr)", Returns: "REAL"},
+	{Name: "AVG", Kind: FunctionAggregate, Signature: "AVG([DISTINCT] expr)", Returns: "REAL"},
+	{Name: "MIN", Kind: FunctionAggregate, Signature: "MIN(expr)", Returns: "ANY"},
+	{Name: "MAX", Kind: FunctionAggregate, Signature: "MAX(expr)", Returns: "ANY"},
+	{Name: "GROUP_CONCAT", Kind: FunctionAggregate, Signature: "GROUP_CONCAT(expr [, separator])", Returns: "TEXT"},
+	{Name: "JSON_ARRAYAGG", Kind: FunctionAggregate, Signature: "JSON_ARRAYAGG(expr)", Returns: "JSON"},
+	{Name: "JSON_OBJECTAGG", Kind: FunctionAggregate, Signature: "JSON_OBJECTAGG(key, value)", Returns: "JSON"},
+	{Name: "STDDEV", Kind: FunctionAggregate, Signature: "STDDEV(expr)", Returns: "REAL", Aliases: []string{"STD", "STDDEV_POP"}},
+	{Name: "STDDEV_SAMP", Kind: FunctionAggregate, Signature: "STDDEV_SAMP(expr)", Returns: "REAL"},
+	{Name: "VARIANCE", Kind: FunctionAggregate, Signature: "VARIANCE(expr)", Returns: "REAL", Aliases: []string{"VAR_POP"}},
+	{Name: "VAR_SAMP", Kind: FunctionAggregate, Signature: "VAR_SAMP(expr)", Returns: "REAL"},
+
+	// Window
+	{Name: "ROW_NUMBER", Kind: FunctionWindow, Signature: "ROW_NUMBER() OVER (...)", Returns: "INTEGER"},
+	{Name: "RANK", Kind: FunctionWindow, Signature: "RANK() OVER (...)", Returns: "INTEGER"},
+	{Name: "DENSE_RANK", Kind: FunctionWindow, Signature: "DENSE_RANK() OVER (...)", Returns: "INTEGER"},
+	{Name: "PERCENT_RANK", Kind: FunctionWindow, Signature: "PERCENT_RANK() OVER (...)", Returns: "REAL"},
+	{Name: "CUME_DIST", Kind: FunctionWindow, Signature: "CUME_DIST() OVER (...)", Returns: "REAL"},
+	{Name: "NTILE", Kind: FunctionWindow, Signature: "NTILE(n) OVER (...)", Returns: "INTEGER"},
+	{Name: "LAG", Kind: FunctionWindow, Signature: "LAG(expr [, offset [, default]]) OVER (...)", Returns: "ANY"},
+	{Name: "LEAD", Kind: FunctionWindow, Signature: "LEAD(expr [, offset [, default]]) OVER (...)", Returns: "ANY"},
+	{Name: "FIRST_VALUE", Kind: FunctionWindow, Signature: "FIRST_VALUE(expr) OVER (...)", Returns: "ANY"},
+	{Name: "LAST_VALUE", Kind: FunctionWindow, Signature: "LAST_VALUE(expr) OVER (...)", Returns: "ANY"},
+	{Name: "NTH_VALUE", Kind: FunctionWindow, Signature: "NTH_VALUE(expr, n) OVER (...)", Returns: "ANY"},
+}
+
+// BuiltinFunctions returns the built-in SQL functions sorted by name. The
+// result is a copy and may be modified by the caller.
+func BuiltinFunctions() []FunctionInfo {
+	out := make([]FunctionInfo, len(builtinFunctions))
+	for i, fn := range builtinFunctions {
+		fn.Aliases = append([]string(nil), fn.Aliases...)
+		out[i] = fn
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
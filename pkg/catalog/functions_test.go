@@ -0,0 +1,124 @@
+package catalog
+
+import "testing"
+
+func TestBuiltinFunctionsCoverDispatchMap(t *testing.T) {
+	listed := make(map[string]bool)
+	for _, fn := range BuiltinFunctions() {
+		if fn.Name == "" || fn.Signature == "" || fn.Returns == "" {
+			t.Errorf("incomplete function entry: %+v", fn)
+		}
+		switch fn.Kind {
+		case FunctionScalar, FunctionAggregate, FunctionWindow:
+		default:
+			t.Errorf("%s: unknown kind %q", fn.Name, fn.Kind)
+		}
+		listed[fn.Name] = true
+		for _, alias := range fn.Aliases {
+			listed[alias] = true
+		}
+	}
+	for name := range scalarFunctionHandlers {
+		if !listed[name] {
+			t.Errorf("scalar function %s is dispatched but missing from builtinFunctions", name)
+		}
+	}
+	for _, name := range []string{"COUNT", "SUM", "AVG", "GROUP_CONCAT", "STDDEV_SAMP", "VAR_SAMP"} {
+		if !isAggregateFuncName(name) || !listed[name] {
+			t.Errorf("aggregate %s not listed", name)
+		}
+	}
+}
+
+func TestBuiltinFunctionsReturnsCopy(t *testing.T) {
+	fns := BuiltinFunctions()
+	for i := range fns {
+		if len(fns[i].Aliases) > 0 {
+			fns[i].Aliases[0] = "MUTATED"
+			break
+		}
+	}
+	for _, fn := range BuiltinFunctions() {
+		for _, alias := range fn.Aliases {
+			if alias == "MUTATED" {
+				t.Fatal("BuiltinFunctions exposed its internal alias slice")
+			}
+		}
+	}
+}
+
+func TestStringFunctionLibrary(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	for sql, want := range map[string]interface{}{
+		"SELECT LPAD('7', 3, '0')":                "007",
+		"SELECT LPAD('7', 3)":                     "  7",
+		"SELECT RPAD('ab', 5, 'xy')":              "abxyx",
+		"SELECT RPAD('abcdef', 3)":                "abc",
+		"SELECT SPLIT_PART('a,b,,c', ',', 2)":     "b",
+		"SELECT SPLIT_PART('a,b,,c', ',', 3)":     "",
+		"SELECT SPLIT_PART('a,b,,c', ',', -1)":    "c",
+		"SELECT SPLIT_PART('a,b,,c', ',', 9)":     "",
+		"SELECT SPLIT_PART('a::b', '::', 2)":      "b",
+		"SELECT SPLIT_PART('abc', '', 1)":         "abc",
+		"SELECT SPLIT_PART(NULL, ',', 1)":         nil,
+		"SELECT TRANSLATE('12-34-56', '-', '')":   "123456",
+		"SELECT TRANSLATE('hello', 'elo', 'ipa')": "hippa",
+		"SELECT TRANSLATE('héllo', 'éh', 'eH')":   "Hello",
+		"SELECT TRANSLATE('aab', 'aa', 'xy')":     "xxb",
+		"SELECT REVERSE('abc')":                   "cba",
+		"SELECT REPEAT('ab', 3)":                  "ababab",
+		"SELECT HEX('ab')":                        "6162",
+		"SELECT LEFT('hello', 2)":                 "he",
+		"SELECT RIGHT('hello', 3)":                "llo",
+		"SELECT POSITION('l' IN 'hello')":         float64(3),
+		"SELECT STRPOS('hello', 'lo')":            float64(4),
+		"SELECT STRPOS('hello', 'z')":             float64(0),
+	} {
+		r, err := c.ExecuteQuery(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if got := r.Rows[0][0]; got != want {
+			t.Errorf("%s = %#v, want %#v", sql, got, want)
+		}
+	}
+	if _, err := c.ExecuteQuery("SELECT SPLIT_PART('a,b', ',', 0)"); err == nil {
+		t.Error("SPLIT_PART with field 0 succeeded")
+	}
+}
+
+func TestRegexpFunctions(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	for sql, want := range map[string]interface{}{
+		"SELECT REGEXP_LIKE('Hello', '^h')":                                 false,
+		"SELECT REGEXP_LIKE('Hello', '^h', 'i')":                            true,
+		"SELECT REGEXP_MATCH('', '^$')":                                     true,
+		"SELECT REGEXP_MATCH(12345, '^12')":                                 true,
+		"SELECT REGEXP_MATCH(NULL, 'a')":                                    nil,
+		"SELECT REGEXP_REPLACE('a1b22c333', '[0-9]+', '#')":                 "a#b#c#",
+		"SELECT REGEXP_REPLACE('john smith', '([a-z]+) ([a-z]+)', '$2 $1')": "smith john",
+		"SELECT REGEXP_REPLACE('ABC', 'b', 'x', 'i')":                       "AxC",
+		"SELECT REGEXP_REPLACE(NULL, 'a', 'b')":                             nil,
+		"SELECT REGEXP_EXTRACT('a1b22c333', '[0-9]+')":                      "1",
+		"SELECT REGEXP_EXTRACT('key=val', 'key=([a-z]+)', 1)":               "val",
+		"SELECT REGEXP_EXTRACT('abc', '[0-9]+')":                            nil,
+		"SELECT REGEXP_EXTRACT('abc', 'x|(b)', 1)":                          "b",
+	} {
+		r, err := c.ExecuteQuery(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if got := r.Rows[0][0]; got != want {
+			t.Errorf("%s = %#v, want %#v", sql, got, want)
+		}
+	}
+	for _, sql := range []string{
+		"SELECT REGEXP_LIKE('a', '(')",
+		"SELECT REGEXP_LIKE('a', 'a', 'q')",
+		"SELECT REGEXP_EXTRACT('abc', 'b', 1)",
+	} {
+		if _, err := c.ExecuteQuery(sql); err == nil {
+			t.Errorf("%s succeeded", sql)
+		}
+	}
+}
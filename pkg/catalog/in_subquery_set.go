@@ -0,0 +1,232 @@
+package catalog
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// Limit on the IN subqueries one statement keeps materialized. Past it the
+// cache starts over, so a statement running many distinct subqueries (a
+// trigger body fired per row, say) holds at most one batch of results.
+const inSetCacheEntries = 64
+
+// inSubquerySet is the first column of an IN subquery's result, hashed for
+// lookups. Values compareValues may call equal to a value of another type
+// without sharing its key (booleans, blobs, huge numbers) are not hashed
+// and are compared one by one.
+type inSubquerySet struct {
+	keys    map[string]struct{}
+	values  []interface{} // every non-NULL value, for probes with no key
+	unkeyed []interface{} // the values left out of keys
+	hasNull bool
+}
+
+// newInSubquerySet materializes rows, the result of an IN subquery.
+func newInSubquerySet(rows [][]interface{}) *inSubquerySet {
+	s := &inSubquerySet{keys: make(map[string]struct{}, len(rows))}
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		v := row[0]
+		if v == nil {
+			s.hasNull = true
+			continue
+		}
+		s.values = append(s.values, v)
+		if key, ok := inSetKey(v); ok {
+			s.keys[key] = struct{}{}
+		} else {
+			s.unkeyed = append(s.unkeyed, v)
+		}
+	}
+	return s
+}
+
+// contains reports `v IN (set)`, or its negation when not is set, with SQL's
+// three-valued logic: nothing is in an empty set, not even NULL, and a miss
+// against a set holding NULL is unknown.
+func (s *inSubquerySet) contains(v interface{}, not bool) interface{} {
+	if len(s.values) == 0 && !s.hasNull {
+		return not
+	}
+	if v == nil {
+		return nil
+	}
+	candidates := s.values
+	if key, ok := inSetKey(v); ok {
+		if _, found := s.keys[key]; found {
+			return !not
+		}
+		candidates = s.unkeyed
+	}
+	for _, item := range candidates {
+		if compareValues(v, item) == 0 {
+			return !not
+		}
+	}
+	if s.hasNull {
+		return nil
+	}
+	return not
+}
+
+// inSetKey returns the key v is hashed under in an inSubquerySet. Values
+// that compareValues calls equal share a key: text that reads as a number
+// is keyed as that number, so '1' matches 1 and 1.0. ok is false for values
+// only a full comparison can match.
+func inSetKey(v interface{}) (key string, ok bool) {
+	if s, isStr := toString(v); isStr {
+		if !looksLikeNumber(s) {
+			return "s:" + s, true
+		}
+	} else if _, isBool := v.(bool); isBool {
+		return "", false
+	}
+	f, isNum := toFloat64(v)
+	// Past 2^53 integers compare exactly but floats do not, so no single
+	// key agrees with both.
+	if !isNum || math.IsNaN(f) || math.Abs(f) >= 1<<53 {
+		return "", false
+	}
+	if f == 0 {
+		f = 0 // fold -0 into 0
+	}
+	return "n:" + strconv.FormatFloat(f, 'g', -1, 64), true
+}
+
+// inSubqueryResult evaluates the IN subquery q for the outer row. A
+// subquery that does not refer to the outer row is run once per statement
+// and its set reused for every row; a correlated one runs for each row.
+// Caller must hold c.mu (read or write lock).
+func (c *Catalog) inSubqueryResult(q *query.SelectStmt, row []interface{}, columns []ColumnDef, args []interface{}) (*inSubquerySet, error) {
+	b := c.budget()
+	if b != nil {
+		if s, ok := b.inSets[q]; ok && s != nil {
+			return s, nil
+		}
+	}
+	correlated := queryHasOuterRefs(q)
+	subq := q
+	if correlated {
+		subq = resolveOuterRefsInQuery(q, row, columns)
+	}
+	_, rows, err := c.selectLocked(subq, args)
+	if err != nil {
+		return nil, err
+	}
+	s := newInSubquerySet(rows)
+	if b != nil && !correlated {
+		if b.inSets == nil || len(b.inSets) >= inSetCacheEntries {
+			b.inSets = make(map[*query.SelectStmt]*inSubquerySet)
+		}
+		b.inSets[q] = s
+	}
+	return s, nil
+}
+
+// dropInSets forgets the IN subquery results of the statement and of the
+// statements enclosing it, once one of them has written to a table.
+func (b *statementBudget) dropInSets() {
+	for ; b != nil; b = b.prev {
+		b.inSets = nil
+	}
+}
+
+// queryHasOuterRefs reports whether q names a table it does not itself read,
+// which resolveOuterRefsInQuery would resolve against the outer row.
+func queryHasOuterRefs(q *query.SelectStmt) bool {
+	inner := make(map[string]bool)
+	if q.From != nil {
+		if q.From.Alias != "" {
+			inner[toLowerFast(q.From.Alias)] = true
+		} else {
+			inner[toLowerFast(q.From.Name)] = true
+		}
+	}
+	for _, join := range q.Joins {
+		if join.Table == nil {
+			continue
+		}
+		if join.Table.Alias != "" {
+			inner[toLowerFast(join.Table.Alias)] = true
+		} else {
+			inner[toLowerFast(join.Table.Name)] = true
+		}
+	}
+	for _, col := range q.Columns {
+		if exprHasOuterRefs(col, inner) {
+			return true
+		}
+	}
+	for _, gb := range q.GroupBy {
+		if exprHasOuterRefs(gb, inner) {
+			return true
+		}
+	}
+	for _, ob := range q.OrderBy {
+		if ob != nil && exprHasOuterRefs(ob.Expr, inner) {
+			return true
+		}
+	}
+	for _, join := range q.Joins {
+		if exprHasOuterRefs(join.Condition, inner) {
+			return true
+		}
+	}
+	return exprHasOuterRefs(q.Where, inner) || exprHasOuterRefs(q.Having, inner)
+}
+
+// exprHasOuterRefs walks expr the way resolveOuterRefsInExpr does.
+func exprHasOuterRefs(expr query.Expression, inner map[string]bool) bool {
+	switch e := expr.(type) {
+	case nil:
+		return false
+	case *query.QualifiedIdentifier:
+		return !inner[toLowerFast(e.Table)]
+	case *query.BinaryExpr:
+		return exprHasOuterRefs(e.Left, inner) || exprHasOuterRefs(e.Right, inner)
+	case *query.UnaryExpr:
+		return exprHasOuterRefs(e.Expr, inner)
+	case *query.FunctionCall:
+		for _, arg := range e.Args {
+			if exprHasOuterRefs(arg, inner) {
+				return true
+			}
+		}
+		for _, ob := range e.OrderBy {
+			if ob != nil && exprHasOuterRefs(ob.Expr, inner) {
+				return true
+			}
+		}
+		return exprHasOuterRefs(e.Filter, inner)
+	case *query.InExpr:
+		for _, v := range e.List {
+			if exprHasOuterRefs(v, inner) {
+				return true
+			}
+		}
+		return exprHasOuterRefs(e.Expr, inner)
+	case *query.BetweenExpr:
+		return exprHasOuterRefs(e.Expr, inner) || exprHasOuterRefs(e.Lower, inner) || exprHasOuterRefs(e.Upper, inner)
+	case *query.IsNullExpr:
+		return exprHasOuterRefs(e.Expr, inner)
+	case *query.LikeExpr:
+		return exprHasOuterRefs(e.Expr, inner) || exprHasOuterRefs(e.Pattern, inner) || exprHasOuterRefs(e.Escape, inner)
+	case *query.CaseExpr:
+		for _, w := range e.Whens {
+			if exprHasOuterRefs(w.Condition, inner) || exprHasOuterRefs(w.Result, inner) {
+				return true
+			}
+		}
+		return exprHasOuterRefs(e.Expr, inner) || exprHasOuterRefs(e.Else, inner)
+	case *query.AliasExpr:
+		return exprHasOuterRefs(e.Expr, inner)
+	case *query.CollateExpr:
+		return exprHasOuterRefs(e.Expr, inner)
+	default:
+		return false
+	}
+}
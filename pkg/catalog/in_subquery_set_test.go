@@ -0,0 +1,112 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+func TestInSubquerySetContains(t *testing.T) {
+	rows := func(vals ...interface{}) [][]interface{} {
+		out := make([][]interface{}, len(vals))
+		for i, v := range vals {
+			out[i] = []interface{}{v}
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		rows [][]interface{}
+		v    interface{}
+		not  bool
+		want interface{}
+	}{
+		{"hit", rows(int64(1), int64(2)), int64(2), false, true},
+		{"miss", rows(int64(1), int64(2)), int64(3), false, false},
+		{"numeric text", rows("1", "2.0"), int64(2), false, true},
+		{"float matches int", rows(int64(3)), 3.0, false, true},
+		{"text", rows("a", "b"), "b", false, true},
+		{"number is not text", rows("abc"), int64(1), false, false},
+		{"unkeyed value", rows(true), "true", false, true},
+		{"null in empty set", rows(), nil, false, false},
+		{"null not in empty set", rows(), nil, true, true},
+		{"null probe", rows(int64(1)), nil, false, nil},
+		{"miss with null", rows(int64(1), nil), int64(2), false, nil},
+		{"not in miss with null", rows(int64(1), nil), int64(2), true, nil},
+		{"not in hit with null", rows(int64(1), nil), int64(1), true, false},
+		{"not in miss", rows(int64(1)), int64(2), true, true},
+	}
+	for _, tt := range tests {
+		got := newInSubquerySet(tt.rows).contains(tt.v, tt.not)
+		if got != tt.want {
+			t.Errorf("%s: contains(%v, not=%v) = %v, want %v", tt.name, tt.v, tt.not, got, tt.want)
+		}
+	}
+}
+
+func TestQueryHasOuterRefs(t *testing.T) {
+	for sql, want := range map[string]bool{
+		"SELECT id FROM u":                               false,
+		"SELECT u.id FROM u WHERE u.x > 1":               false,
+		"SELECT v.id FROM u AS v":                        false,
+		"SELECT id FROM u WHERE u.x = t.x":               true,
+		"SELECT id FROM u AS v WHERE v.x = u.x":          true,
+		"SELECT id FROM u JOIN w ON w.id = u.id":         false,
+		"SELECT id FROM u WHERE u.x IN (1, t.y)":         true,
+		"SELECT CASE WHEN t.a THEN 1 END FROM u":         true,
+		"SELECT COUNT(*) FROM u GROUP BY u.k HAVING t.n": true,
+	} {
+		stmt, err := query.Parse(sql)
+		if err != nil {
+			t.Fatalf("Parse %s: %v", sql, err)
+		}
+		if got := queryHasOuterRefs(stmt.(*query.SelectStmt)); got != want {
+			t.Errorf("%s: queryHasOuterRefs = %v, want %v", sql, got, want)
+		}
+	}
+}
+
+func TestInSubqueryMaterializedPerStatement(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	for _, sql := range []string{
+		"CREATE TABLE ins_t (id INTEGER PRIMARY KEY, x INTEGER)",
+		"CREATE TABLE ins_u (id INTEGER PRIMARY KEY, x INTEGER)",
+		"INSERT INTO ins_t VALUES (1, 10), (2, 20), (3, 30)",
+		"INSERT INTO ins_u VALUES (1, 10), (2, 30)",
+	} {
+		if _, err := c.ExecuteQuery(sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+	}
+
+	end := c.BeginStatement(StatementLimits{})
+	defer end()
+	res, err := c.ExecuteQuery("SELECT id FROM ins_t WHERE x IN (SELECT x FROM ins_u)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 2 {
+		t.Errorf("IN returned %d rows, want 2", len(res.Rows))
+	}
+	if n := len(c.budget().inSets); n != 1 {
+		t.Errorf("statement holds %d IN sets, want 1", n)
+	}
+
+	if _, err := c.ExecuteQuery("INSERT INTO ins_u VALUES (3, 20)"); err != nil {
+		t.Fatal(err)
+	}
+	if c.budget().inSets != nil {
+		t.Error("a write should drop the statement's IN sets")
+	}
+
+	res, err = c.ExecuteQuery("SELECT id FROM ins_t WHERE x IN (SELECT x FROM ins_u WHERE ins_u.id = ins_t.id)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 1 {
+		t.Errorf("correlated IN returned %d rows, want 1", len(res.Rows))
+	}
+	if n := len(c.budget().inSets); n != 0 {
+		t.Errorf("correlated IN subquery was cached (%d sets)", n)
+	}
+}
@@ -39,3 +39,22 @@ func TestExecuteQueryInsertReturning(t *testing.T) {
 		t.Fatalf("plain INSERT must return no rows, got %v", r3.Rows)
 	}
 }
+
+// Each row of a multi-row INSERT that omits the INTEGER PRIMARY KEY gets its
+// own row id (the reused row buffer used to keep the first row's id).
+func TestMultiRowInsertAssignsRowIDs(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	if _, err := c.ExecuteQuery("CREATE TABLE mr (id INTEGER PRIMARY KEY, v TEXT)"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := c.ExecuteQuery("INSERT INTO mr (v) VALUES ('a'), ('b'), ('c')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	r, err := c.ExecuteQuery("SELECT id, v FROM mr ORDER BY v")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if got := fmt.Sprintf("%v", r.Rows); got != "[[1 a] [2 b] [3 c]]" {
+		t.Fatalf("rows = %s, want [[1 a] [2 b] [3 c]]", got)
+	}
+}
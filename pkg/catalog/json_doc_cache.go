@@ -0,0 +1,53 @@
+package catalog
+
+// Limits on the documents one statement keeps parsed. Past either the cache
+// starts over, so a scan over many distinct documents holds at most one
+// batch of them at a time.
+const (
+	jsonDocCacheEntries = 64
+	jsonDocCacheBytes   = 1 << 20
+)
+
+// jsonDocCache holds the parsed form of the JSON documents a statement has
+// read, so that several JSON functions, -> operators and WHERE terms over
+// the same column value parse it once. It belongs to the statement's
+// goroutine and needs no locking.
+//
+// Parsed values are shared between callers and must not be modified;
+// JSON_SET and the other functions that edit a document parse their own copy.
+type jsonDocCache struct {
+	docs  map[string]interface{}
+	bytes int
+}
+
+// parse returns the parsed form of text. The nil cache parses every time.
+func (jc *jsonDocCache) parse(text string) (interface{}, error) {
+	if jc != nil {
+		if v, ok := jc.docs[text]; ok {
+			return v, nil
+		}
+	}
+	var data interface{}
+	if err := unmarshalJSONInput(text, &data); err != nil {
+		return nil, err
+	}
+	if jc == nil || len(text) > jsonDocCacheBytes {
+		return data, nil
+	}
+	if jc.docs == nil || len(jc.docs) >= jsonDocCacheEntries || jc.bytes+len(text) > jsonDocCacheBytes {
+		jc.docs = make(map[string]interface{}, jsonDocCacheEntries)
+		jc.bytes = 0
+	}
+	jc.docs[text] = data
+	jc.bytes += len(text)
+	return data, nil
+}
+
+// jsonDocs returns the statement's JSON document cache, or nil outside a
+// statement.
+func (b *statementBudget) jsonDocs() *jsonDocCache {
+	if b == nil {
+		return nil
+	}
+	return &b.docs
+}
@@ -0,0 +1,40 @@
+package catalog
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestJSONDocCache(t *testing.T) {
+	var none *jsonDocCache
+	if v, err := none.parse(`{"a":1}`); err != nil || v.(map[string]interface{})["a"] != float64(1) {
+		t.Fatalf("nil cache parse = %v, %v", v, err)
+	}
+
+	c := &Catalog{}
+	end := c.BeginStatement(StatementLimits{})
+	docs := c.budget().jsonDocs()
+	if docs == nil {
+		t.Fatal("a statement should have a JSON document cache")
+	}
+	first, _ := docs.parse(`{"a":[1,2]}`)
+	again, _ := docs.parse(`{"a":[1,2]}`)
+	if fmt.Sprintf("%p", first) != fmt.Sprintf("%p", again) {
+		t.Error("a repeated document should be parsed once")
+	}
+	if _, err := docs.parse(`{broken`); err == nil {
+		t.Error("invalid JSON should fail to parse")
+	}
+	for i := 0; i < jsonDocCacheEntries; i++ {
+		docs.parse(fmt.Sprintf(`[%d]`, i))
+	}
+	if len(docs.docs) > jsonDocCacheEntries {
+		t.Errorf("cache holds %d documents, want at most %d", len(docs.docs), jsonDocCacheEntries)
+	}
+	if err := end(); err != nil {
+		t.Fatalf("end() = %v", err)
+	}
+	if c.budget().jsonDocs() != nil {
+		t.Error("the cache should end with the statement")
+	}
+}
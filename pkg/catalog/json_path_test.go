@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+func TestJSONPathFunctions(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE docs (id INTEGER PRIMARY KEY, data JSON)")
+	jcExec(t, c, `INSERT INTO docs VALUES (1, '{"a":{"b":[10,20,{"c":"x"}]},"n":5,"k":"v"}')`)
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{`SELECT JSON_EXTRACT(data, '$.a.b[2].c') FROM docs`, "x"},
+		{`SELECT JSON_EXTRACT(data, '$.a.b[#-3]') FROM docs`, "10"},
+		{`SELECT JSON_EXTRACT(data, '$.a.b[last]') FROM docs`, "map[c:x]"},
+		{`SELECT JSON_EXTRACT('{"a b":1}', '$."a b"') FROM docs`, "1"},
+		{`SELECT JSON_EXTRACT(data, '$.n', '$.k', '$.a.b[9]') FROM docs`, `[5,"v",null]`},
+		{`SELECT JSON_SET(data, '$.n', 6, '$.a.b[#]', 30) FROM docs`, `{"a":{"b":[10,20,{"c":"x"},30]},"k":"v","n":6}`},
+		{`SELECT JSON_REMOVE(data, '$.a', '$.missing', '$.k') FROM docs`, `{"n":5}`},
+		{`SELECT JSON_ARRAY(1, 'two', NULL, JSON_OBJECT('k', JSON_ARRAY(3, 4))) FROM docs`, `[1,"two",null,{"k":[3,4]}]`},
+		{`SELECT JSON_OBJECT('doc', JSON_SET('{}', '$.x', JSON_ARRAY(1))) FROM docs`, `{"doc":{"x":[1]}}`},
+		{`SELECT JSON_ARRAY('[1]') FROM docs`, `["[1]"]`},
+	}
+	for _, tt := range tests {
+		if got := jcScalar(t, c, tt.sql); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPathIndex(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	jcExec(t, c, "CREATE TABLE docs (id INTEGER PRIMARY KEY, data JSON)")
+	jcExec(t, c, `INSERT INTO docs VALUES (1, '{"user":{"name":"ann","age":30}}')`)
+	jcExec(t, c, `INSERT INTO docs VALUES (2, '{"user":{"name":"bob","age":"30"}}')`)
+	jcExec(t, c, `INSERT INTO docs VALUES (3, '{"other":true}')`)
+	jcExec(t, c, "CREATE INDEX idx_name ON docs (JSON_EXTRACT(data, '$.user.name'))")
+	jcExec(t, c, "CREATE INDEX idx_age ON docs ((data->'$.user.age'))")
+
+	idx, err := c.GetIndex("idx_name")
+	if err != nil {
+		t.Fatalf("GetIndex: %v", err)
+	}
+	if idx.JSONPath != "$.user.name" || len(idx.Columns) != 1 || idx.Columns[0] != "data" {
+		t.Fatalf("index def = %+v", idx)
+	}
+
+	for _, where := range []string{
+		"JSON_EXTRACT(data, '$.user.name') = 'bob'",
+		"data->>'$.user.name' = 'bob'",
+		"'bob' = data->'user.name'",
+	} {
+		stmt, err := query.Parse("SELECT id FROM docs WHERE " + where)
+		if err != nil {
+			t.Fatalf("parse %q: %v", where, err)
+		}
+		name, _, _ := c.findUsableIndexWithArgs("docs", stmt.(*query.SelectStmt).Where, nil)
+		if name != "idx_name" {
+			t.Errorf("WHERE %s uses index %q, want idx_name", where, name)
+		}
+		if got := jcScalar(t, c, "SELECT id FROM docs WHERE "+where); got != "2" {
+			t.Errorf("WHERE %s = %s, want 2", where, got)
+		}
+	}
+
+	// The number 30 and the string "30" share an index entry; the WHERE clause
+	// still decides which rows match.
+	r, err := c.ExecuteQuery("SELECT id FROM docs WHERE JSON_EXTRACT(data, '$.user.age') = 30 ORDER BY id")
+	if err != nil {
+		t.Fatalf("age query: %v", err)
+	}
+	if len(r.Rows) == 0 || r.Rows[0][0] != int64(1) && r.Rows[0][0] != float64(1) {
+		t.Fatalf("age = 30 returned %v", r.Rows)
+	}
+
+	// Writes keep the index current.
+	jcExec(t, c, `UPDATE docs SET data = JSON_SET(data, '$.user.name', 'cat') WHERE id = 2`)
+	if r, err := c.ExecuteQuery("SELECT id FROM docs WHERE data->>'$.user.name' = 'bob'"); err != nil || len(r.Rows) != 0 {
+		t.Fatalf("stale entry after update: %v, %v", r, err)
+	}
+	if got := jcScalar(t, c, "SELECT id FROM docs WHERE data->>'$.user.name' = 'cat'"); got != "2" {
+		t.Fatalf("updated row = %s, want 2", got)
+	}
+	jcExec(t, c, "DELETE FROM docs WHERE id = 2")
+	if r, err := c.ExecuteQuery("SELECT id FROM docs WHERE data->>'$.user.name' = 'cat'"); err != nil || len(r.Rows) != 0 {
+		t.Fatalf("stale entry after delete: %v, %v", r, err)
+	}
+
+	// A JSON path index is not an index on the column itself.
+	stmt, _ := query.Parse("SELECT id FROM docs WHERE data = 'x'")
+	if name, _, _ := c.findUsableIndexWithArgs("docs", stmt.(*query.SelectStmt).Where, nil); name != "" {
+		t.Fatalf("plain column lookup used JSON path index %q", name)
+	}
+}
+
+func TestJSONPathIndexRejectsOtherExpressions(t *testing.T) {
+	for _, sql := range []string{
+		"CREATE INDEX i ON docs (UPPER(data))",
+		"CREATE INDEX i ON docs (JSON_EXTRACT(data, other))",
+	} {
+		if _, err := query.Parse(sql); err == nil {
+			t.Errorf("%s: expected parse error", sql)
+		}
+	}
+}
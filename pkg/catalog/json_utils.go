@@ -19,7 +19,11 @@ const (
 	maxCachedJSONPaths      = 1024
 )
 
-var errJSONInputTooLarge = errors.New("JSON input too large")
+var (
+	errJSONInputTooLarge = errors.New("JSON input too large")
+	// errJSONPathNotFound reports a path that does not exist in the document.
+	errJSONPathNotFound = errors.New("JSON path not found")
+)
 
 // regexpCache caches compiled regexps for GLOB and similar per-row operations.
 var regexpCache = newBoundedRegexpCache(maxCachedRegexps)
@@ -131,6 +135,18 @@ func ParseJSONPath(path string) (*JSONPath, error) {
 			if len(remaining) == 0 {
 				break
 			}
+			// Quoted key: ."a b"
+			if remaining[0] == '"' {
+				end := strings.IndexByte(remaining[1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("unclosed string in JSON path")
+				}
+				if err := appendJSONPathSegment(&segments, remaining[1:end+1]); err != nil {
+					return nil, err
+				}
+				remaining = remaining[end+2:]
+				continue
+			}
 			// Find the end of the key
 			end := 0
 			for end < len(remaining) {
@@ -188,18 +204,18 @@ func ParseJSONPath(path string) (*JSONPath, error) {
 				if end >= len(remaining) {
 					return nil, fmt.Errorf("unclosed bracket in JSON path")
 				}
-				indexStr := remaining[:end]
+				indexStr := strings.TrimSpace(remaining[:end])
 				// Check if it's a wildcard *
 				if indexStr == "*" {
 					if err := appendJSONPathSegment(&segments, "*"); err != nil {
 						return nil, err
 					}
 				} else {
-					idx, err := strconv.Atoi(indexStr)
+					segment, err := parseJSONArrayIndex(indexStr)
 					if err != nil {
-						return nil, fmt.Errorf("invalid array index: %s", indexStr)
+						return nil, err
 					}
-					if err := appendJSONPathSegment(&segments, fmt.Sprintf("[%d]", idx)); err != nil {
+					if err := appendJSONPathSegment(&segments, segment); err != nil {
 						return nil, err
 					}
 				}
@@ -213,6 +229,51 @@ func ParseJSONPath(path string) (*JSONPath, error) {
 	return &JSONPath{Segments: segments}, nil
 }
 
+// parseJSONArrayIndex parses the inside of an array subscript into a path
+// segment. Besides plain indexes it accepts SQLite's end-relative forms: [#]
+// (one past the last element, for appending), [#-N], and MySQL's [last].
+func parseJSONArrayIndex(indexStr string) (string, error) {
+	if indexStr == "last" {
+		return "[#-1]", nil
+	}
+	if indexStr == "#" {
+		return "[#]", nil
+	}
+	if rest, ok := strings.CutPrefix(indexStr, "#-"); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(rest))
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("invalid array index: %s", indexStr)
+		}
+		return fmt.Sprintf("[#-%d]", n), nil
+	}
+	idx, err := strconv.Atoi(indexStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid array index: %s", indexStr)
+	}
+	return fmt.Sprintf("[%d]", idx), nil
+}
+
+// jsonArrayIndex resolves an array segment against an array of length n. The
+// result may be out of range; [#] resolves to n.
+func jsonArrayIndex(segment string, n int) (int, error) {
+	idxStr := segment[1 : len(segment)-1]
+	if idxStr == "#" {
+		return n, nil
+	}
+	if rest, ok := strings.CutPrefix(idxStr, "#-"); ok {
+		back, err := strconv.Atoi(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid array index: %s", idxStr)
+		}
+		return n - back, nil
+	}
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index: %s", idxStr)
+	}
+	return idx, nil
+}
+
 func appendJSONPathSegment(segments *[]string, segment string) error {
 	if len(segment) > maxJSONPathSegmentBytes {
 		return fmt.Errorf("JSON path segment too large: maximum allowed size is %d bytes", maxJSONPathSegmentBytes)
@@ -267,16 +328,14 @@ func (jp *JSONPath) Get(data interface{}) (interface{}, error) {
 
 		// Check if it's an array index
 		if strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]") {
-			idxStr := segment[1 : len(segment)-1]
-			idx, err := strconv.Atoi(idxStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid array index: %s", idxStr)
-			}
-
 			arr, ok := current.([]interface{})
 			if !ok {
 				return nil, nil
 			}
+			idx, err := jsonArrayIndex(segment, len(arr))
+			if err != nil {
+				return nil, err
+			}
 			if idx < 0 || idx >= len(arr) {
 				return nil, nil
 			}
@@ -369,6 +428,10 @@ func (c *boundedJSONPathCache) len() int {
 
 // JSONExtract extracts a value from JSON using a path
 func JSONExtract(jsonData, path string) (interface{}, error) {
+	return jsonExtract(nil, jsonData, path)
+}
+
+func jsonExtract(docs *jsonDocCache, jsonData, path string) (interface{}, error) {
 	if jsonData == "" {
 		return nil, nil
 	}
@@ -378,8 +441,8 @@ func JSONExtract(jsonData, path string) (interface{}, error) {
 		return nil, fmt.Errorf("invalid JSON path: %w", err)
 	}
 
-	var data interface{}
-	if err := unmarshalJSONInput(jsonData, &data); err != nil {
+	data, err := docs.parse(jsonData)
+	if err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
 
@@ -424,16 +487,20 @@ func JSONSet(jsonData, path, value string) (string, error) {
 	return string(result), nil
 }
 
-// Set sets a value at the JSON path
+// Set sets a value at the JSON path. A final [#] segment appends to the
+// array; that requires data to be a *interface{} when the array is the root.
 func (jp *JSONPath) Set(data interface{}, value interface{}) error {
 	if len(jp.Segments) == 0 {
 		return fmt.Errorf("empty JSON path")
 	}
 
-	// Unwrap *interface{} pointer if present
+	// Unwrap *interface{} pointer if present. assign replaces current in its
+	// container, which appending to an array needs.
 	current := data
+	assign := func(interface{}) {}
 	if ptr, ok := current.(*interface{}); ok {
 		current = *ptr
+		assign = func(v interface{}) { *ptr = v }
 	}
 
 	path := jp.Segments[:len(jp.Segments)-1]
@@ -445,42 +512,45 @@ func (jp *JSONPath) Set(data interface{}, value interface{}) error {
 		}
 
 		if strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]") {
-			idxStr := segment[1 : len(segment)-1]
-			idx, err := strconv.Atoi(idxStr)
-			if err != nil {
-				return fmt.Errorf("invalid array index: %s", idxStr)
-			}
-
 			arr, ok := current.([]interface{})
 			if !ok {
 				return fmt.Errorf("not an array at segment %s", segment)
 			}
+			idx, err := jsonArrayIndex(segment, len(arr))
+			if err != nil {
+				return err
+			}
 			if idx < 0 || idx >= len(arr) {
 				return fmt.Errorf("array index out of bounds: %d", idx)
 			}
 			current = arr[idx]
+			assign = func(v interface{}) { arr[idx] = v }
 		} else {
 			obj, ok := current.(map[string]interface{})
 			if !ok {
 				return fmt.Errorf("not an object at segment %s", segment)
 			}
 			current = obj[segment]
+			key := segment
+			assign = func(v interface{}) { obj[key] = v }
 		}
 	}
 
 	// Set the final segment
 	lastSegment := jp.Segments[len(jp.Segments)-1]
 	if strings.HasPrefix(lastSegment, "[") && strings.HasSuffix(lastSegment, "]") {
-		idxStr := lastSegment[1 : len(lastSegment)-1]
-		idx, err := strconv.Atoi(idxStr)
-		if err != nil {
-			return fmt.Errorf("invalid array index: %s", idxStr)
-		}
-
 		arr, ok := current.([]interface{})
 		if !ok {
 			return fmt.Errorf("not an array at segment %s", lastSegment)
 		}
+		idx, err := jsonArrayIndex(lastSegment, len(arr))
+		if err != nil {
+			return err
+		}
+		if idx == len(arr) {
+			assign(append(arr, value))
+			return nil
+		}
 		if idx < 0 || idx >= len(arr) {
 			return fmt.Errorf("array index out of bounds: %d", idx)
 		}
@@ -512,7 +582,9 @@ func JSONRemove(jsonData, path string) (string, error) {
 		return "", fmt.Errorf("invalid JSON path: %w", err)
 	}
 
-	if err := jp.Remove(&data); err != nil {
+	// Like SQLite, removing a path that does not exist leaves the document
+	// unchanged.
+	if err := jp.Remove(&data); err != nil && !errors.Is(err, errJSONPathNotFound) {
 		return "", err
 	}
 
@@ -556,22 +628,20 @@ func (jp *JSONPath) Remove(dataPtr *interface{}) error {
 		}
 
 		if current == nil {
-			return fmt.Errorf("path not found at segment %s", segment)
+			return fmt.Errorf("%w at segment %s", errJSONPathNotFound, segment)
 		}
 
 		if strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]") {
-			idxStr := segment[1 : len(segment)-1]
-			idx, err := strconv.Atoi(idxStr)
-			if err != nil {
-				return fmt.Errorf("invalid array index: %s", idxStr)
-			}
-
 			arr, ok := current.([]interface{})
 			if !ok {
-				return fmt.Errorf("not an array at segment %s", segment)
+				return fmt.Errorf("%w: not an array at segment %s", errJSONPathNotFound, segment)
+			}
+			idx, err := jsonArrayIndex(segment, len(arr))
+			if err != nil {
+				return err
 			}
 			if idx < 0 || idx >= len(arr) {
-				return fmt.Errorf("array index out of bounds: %d", idx)
+				return fmt.Errorf("%w: array index out of bounds: %d", errJSONPathNotFound, idx)
 			}
 
 			parents = append(parents, parentInfo{arr: arr, index: idx, isArr: true})
@@ -579,12 +649,12 @@ func (jp *JSONPath) Remove(dataPtr *interface{}) error {
 		} else {
 			obj, ok := current.(map[string]interface{})
 			if !ok {
-				return fmt.Errorf("not an object at segment %s", segment)
+				return fmt.Errorf("%w: not an object at segment %s", errJSONPathNotFound, segment)
 			}
 
 			// Check if key exists
 			if _, exists := obj[segment]; !exists {
-				return fmt.Errorf("key not found: %s", segment)
+				return fmt.Errorf("%w: key %s", errJSONPathNotFound, segment)
 			}
 
 			parents = append(parents, parentInfo{obj: obj, key: segment, isArr: false})
@@ -630,12 +700,16 @@ func (jp *JSONPath) Remove(dataPtr *interface{}) error {
 
 // JSONArrayLength returns the length of a JSON array
 func JSONArrayLength(jsonData string) (int, error) {
+	return jsonArrayLength(nil, jsonData)
+}
+
+func jsonArrayLength(docs *jsonDocCache, jsonData string) (int, error) {
 	if jsonData == "" {
 		return 0, nil
 	}
 
-	var data interface{}
-	if err := unmarshalJSONInput(jsonData, &data); err != nil {
+	data, err := docs.parse(jsonData)
+	if err != nil {
 		return 0, fmt.Errorf("invalid JSON: %w", err)
 	}
 
@@ -649,12 +723,16 @@ func JSONArrayLength(jsonData string) (int, error) {
 
 // JSONKeys returns the keys of a JSON object
 func JSONKeys(jsonData string) ([]string, error) {
+	return jsonKeys(nil, jsonData)
+}
+
+func jsonKeys(docs *jsonDocCache, jsonData string) ([]string, error) {
 	if jsonData == "" {
 		return nil, nil
 	}
 
-	var data interface{}
-	if err := unmarshalJSONInput(jsonData, &data); err != nil {
+	data, err := docs.parse(jsonData)
+	if err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
 
@@ -787,13 +865,16 @@ func JSONEach(jsonData string) (map[string]interface{}, error) {
 
 // JSONType returns the type of a JSON value
 func JSONType(jsonData, path string) (string, error) {
-	var data interface{}
+	return jsonType(nil, jsonData, path)
+}
 
+func jsonType(docs *jsonDocCache, jsonData, path string) (string, error) {
 	if jsonData == "" {
 		return "null", nil
 	}
 
-	if err := unmarshalJSONInput(jsonData, &data); err != nil {
+	data, err := docs.parse(jsonData)
+	if err != nil {
 		return "", fmt.Errorf("invalid JSON: %w", err)
 	}
 
@@ -855,11 +936,15 @@ func JSONUnquote(value string) (string, error) {
 
 // IsValidJSON checks if a string is valid JSON
 func IsValidJSON(jsonData string) bool {
+	return isValidJSON(nil, jsonData)
+}
+
+func isValidJSON(docs *jsonDocCache, jsonData string) bool {
 	if jsonData == "" {
 		return false
 	}
-	var data interface{}
-	return unmarshalJSONInput(jsonData, &data) == nil
+	_, err := docs.parse(jsonData)
+	return err == nil
 }
 
 // RegexMatch checks if a string matches a regex pattern (uses package-level cache)
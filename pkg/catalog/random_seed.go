@@ -0,0 +1,76 @@
+package catalog
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// seededRandom is the deterministic source RANDOM() uses once a seed is set.
+type seededRandom struct {
+	mu   sync.Mutex
+	seed int64
+	src  *rand.Rand
+}
+
+// SetRandomSeed makes RANDOM() return a reproducible sequence starting from
+// seed. Setting the same seed again restarts the sequence.
+func (c *Catalog) SetRandomSeed(seed int64) {
+	c.random.Store(&seededRandom{seed: seed, src: rand.New(rand.NewSource(seed))}) // #nosec G404 - a seeded PRNG is the point: SET seed asks for reproducible values.
+}
+
+// ClearRandomSeed returns RANDOM() to cryptographically random values.
+func (c *Catalog) ClearRandomSeed() {
+	c.random.Store(nil)
+}
+
+// RandomSeed returns the seed set with SetRandomSeed, if any.
+func (c *Catalog) RandomSeed() (int64, bool) {
+	r := c.random.Load()
+	if r == nil {
+		return 0, false
+	}
+	return r.seed, true
+}
+
+// seededRandomFunction evaluates RANDOM() and RANDOMBLOB(n) from the seeded
+// source. handled is false when no seed is set or funcName is neither.
+func (c *Catalog) seededRandomFunction(funcName string, args []interface{}) (interface{}, bool, error) {
+	if c == nil || (funcName != "RANDOM" && funcName != "RANDOMBLOB") {
+		return nil, false, nil
+	}
+	r := c.random.Load()
+	if r == nil {
+		return nil, false, nil
+	}
+	if funcName == "RANDOM" {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return float64(r.src.Int63()), true, nil
+	}
+	size, err := randomBlobSize(args)
+	if err != nil {
+		return nil, true, err
+	}
+	b := make([]byte, size)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.src.Read(b) // #nosec G404 - seeded on purpose, see SetRandomSeed.
+	return b, true, nil
+}
+
+// randomBlobSize returns the length RANDOMBLOB(n) produces: n bytes, at least
+// one, as in SQLite.
+func randomBlobSize(args []interface{}) (int, error) {
+	if len(args) < 1 {
+		return 0, fmt.Errorf("RANDOMBLOB requires 1 argument")
+	}
+	size, err := boundedStringSizeArg(args[0], "RANDOMBLOB", maxStringResultLen)
+	if err != nil {
+		return 0, err
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size, nil
+}
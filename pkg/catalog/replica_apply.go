@@ -0,0 +1,194 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/btree"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// ApplyReplicatedWALOps applies the logical WAL operations of one
+// transaction committed on a replication primary. It is ReplayWALOps for a
+// live replica: the index entries of each written row are updated in place
+// instead of rebuilding every index of the table, so an apply costs the rows
+// it touches. Tables whose schema the transaction changed have their indexes
+// rebuilt.
+func (c *Catalog) ApplyReplicatedWALOps(ops []storage.WALReplayOp) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rebuild := make(map[string]struct{})
+	written := make(map[string]struct{})
+	var sequences []storage.WALReplayOp
+	for _, op := range ops {
+		switch op.Type {
+		case storage.WALSequence:
+			sequences = append(sequences, op)
+		case storage.WALCreateTable, storage.WALDropTable, storage.WALAlterTable,
+			storage.WALCreateIndex, storage.WALDropIndex, storage.WALCreateView,
+			storage.WALDropView, storage.WALCreateTrigger, storage.WALDropTrigger:
+			if err := c.replaySchemaOpLocked(op, rebuild); err != nil {
+				return fmt.Errorf("failed to apply replicated %v: %w", op.Type, err)
+			}
+			if table := c.schemaOpTableLocked(op); table != "" {
+				rebuild[table] = struct{}{}
+			}
+			c.invalidateSchemaCache()
+		case storage.WALInsert, storage.WALUpdate, storage.WALUpdateCommit, storage.WALDelete:
+			key, value, err := parseReplayWALKeyValue(op.Data)
+			if err != nil {
+				return fmt.Errorf("invalid replicated %v: %w", op.Type, err)
+			}
+			treeName, rowKey, ok := c.splitTreeKeyLocked(key)
+			if !ok {
+				continue // table dropped since, or not created yet on this node
+			}
+			if op.Type == storage.WALDelete {
+				value = nil
+			}
+			if err := c.applyReplicatedRowLocked(treeName, rowKey, value); err != nil {
+				return err
+			}
+			table, _, _ := strings.Cut(treeName, ":")
+			written[table] = struct{}{}
+		case storage.WALCommit, storage.WALRollback, storage.WALCheckpoint, storage.WALPageImage:
+		default:
+			return fmt.Errorf("invalid replicated WAL record type %v", op.Type)
+		}
+	}
+
+	for _, op := range sequences {
+		if err := c.replaySequenceLocked(op); err != nil {
+			return fmt.Errorf("failed to apply replicated sequence: %w", err)
+		}
+	}
+	for table := range rebuild {
+		if err := c.rebuildTableIndexesLocked(table); err != nil {
+			return fmt.Errorf("failed to rebuild indexes for %s: %w", table, err)
+		}
+		written[table] = struct{}{}
+	}
+	for table := range written {
+		c.invalidateQueryCache(table)
+	}
+	return nil
+}
+
+// splitTreeKeyLocked splits a logical WAL key, "tree:rowKey", at the colon
+// that ends the name of an existing table tree. Partition trees are named
+// "table:partition", so the first colon is not always the one.
+func (c *Catalog) splitTreeKeyLocked(key string) (treeName, rowKey string, ok bool) {
+	for i := 0; i < len(key); i++ {
+		if key[i] != ':' {
+			continue
+		}
+		if _, exists := c.tableTrees[key[:i]]; exists && i+1 < len(key) {
+			return key[:i], key[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// schemaOpTableLocked returns the table a replayed DDL record changed, or ""
+// for views, triggers and dropped objects.
+func (c *Catalog) schemaOpTableLocked(op storage.WALReplayOp) string {
+	key, _, err := parseReplayWALKeyValue(op.Data)
+	if err != nil {
+		return ""
+	}
+	_, name, _ := strings.Cut(key, ":")
+	switch op.Type {
+	case storage.WALCreateTable:
+		return name
+	case storage.WALAlterTable:
+		if _, exists := c.tables[name]; exists {
+			return name
+		}
+	case storage.WALCreateIndex:
+		if idx, exists := c.indexes[name]; exists {
+			return idx.TableName
+		}
+	}
+	return ""
+}
+
+// applyReplicatedRowLocked stores value under rowKey in treeName, or deletes
+// the row when value is nil, and moves the row's entries in the table's
+// indexes from its old values to its new ones.
+func (c *Catalog) applyReplicatedRowLocked(treeName, rowKey string, value []byte) error {
+	tree := c.tableTrees[treeName]
+	tableName, _, _ := strings.Cut(treeName, ":")
+	table := c.tables[tableName]
+	if table == nil {
+		return nil
+	}
+	key := []byte(rowKey)
+
+	var oldRow []interface{}
+	if old, err := tree.Get(key); err == nil {
+		oldRow = liveRowData(old, len(table.Columns))
+	} else if !errors.Is(err, btree.ErrKeyNotFound) {
+		return fmt.Errorf("failed to read %s row for replication: %w", treeName, err)
+	}
+	newRow := liveRowData(value, len(table.Columns))
+
+	if value == nil {
+		if err := tree.Delete(key); err != nil && !errors.Is(err, btree.ErrKeyNotFound) {
+			return fmt.Errorf("failed to apply replicated delete to %s: %w", treeName, err)
+		}
+	} else if err := tree.Put(key, value); err != nil {
+		return fmt.Errorf("failed to apply replicated write to %s: %w", treeName, err)
+	}
+
+	for idxName, idxDef := range c.indexes {
+		if idxDef.TableName != tableName {
+			continue
+		}
+		idxTree := c.indexTrees[idxName]
+		if idxTree == nil {
+			continue
+		}
+		oldKey := indexStorageKey(table, idxDef, oldRow, rowKey)
+		newKey := indexStorageKey(table, idxDef, newRow, rowKey)
+		if oldKey == newKey {
+			continue
+		}
+		if oldKey != "" {
+			if err := idxTree.Delete([]byte(oldKey)); err != nil && !errors.Is(err, btree.ErrKeyNotFound) {
+				return fmt.Errorf("failed to apply replicated write to index %s: %w", idxName, err)
+			}
+		}
+		if newKey != "" {
+			if err := idxTree.Put([]byte(newKey), key); err != nil {
+				return fmt.Errorf("failed to apply replicated write to index %s: %w", idxName, err)
+			}
+		}
+	}
+
+	var oldKey []byte
+	if oldRow != nil {
+		oldKey = key
+	}
+	c.updateFTSIndexesForWrite(table, oldKey, key, newRow)
+	c.updateGINIndexesForWrite(table, oldKey, key, newRow)
+	c.updateBloomIndexesForWrite(table, oldKey, key, newRow)
+	return nil
+}
+
+// indexStorageKey returns the key row has in the index, as
+// rebuildTableIndexesLocked writes it, or "" when row is nil or has no key.
+func indexStorageKey(table *TableDef, idxDef *IndexDef, row []interface{}, rowKey string) string {
+	if row == nil {
+		return ""
+	}
+	indexKey, ok := buildCompositeIndexKey(table, idxDef, row)
+	if !ok {
+		return ""
+	}
+	if idxDef.Unique {
+		return indexKey
+	}
+	return indexKey + "\x00" + rowKey
+}
@@ -128,6 +128,42 @@ func TestSQLSemantics_WindowFunctions(t *testing.T) {
 	}
 }
 
+// MIN and MAX return the column's own values: integers stay int64 (big ones
+// exactly), and text and timestamps compare as text, on every aggregate path.
+func TestSQLSemantics_MinMaxKeepTypes(t *testing.T) {
+	c, _ := createCatalogWithTxnManager(t)
+	ssExec(t, c, "CREATE TABLE m (id INTEGER PRIMARY KEY, g INTEGER, name TEXT, n INTEGER, ts TIMESTAMP)")
+	ssExec(t, c, "INSERT INTO m VALUES (1,1,'pear',9007199254740993,'2024-03-01 10:00:00'),(2,1,'apple',-4,'2023-12-31 09:00:00'),"+
+		"(3,1,'fig',12,'2025-01-01 00:00:00'),(4,1,NULL,NULL,NULL)")
+	want := "[string(apple) string(pear) int64(-4) int64(9007199254740993) string(2023-12-31 09:00:00) string(2025-01-01 00:00:00)]"
+	const aggs = "MIN(name), MAX(name), MIN(n), MAX(n), MIN(ts), MAX(ts)"
+	for _, sql := range []string{
+		"SELECT " + aggs + " FROM m",
+		"SELECT " + aggs + " FROM m WHERE id > 0",
+		"SELECT " + aggs + " FROM m GROUP BY g",
+		"SELECT " + aggs + ", COUNT(DISTINCT name) FROM m GROUP BY g",
+		"SELECT " + aggs + " FROM m a JOIN m b ON a.id = b.id",
+		"WITH c AS (SELECT * FROM m) SELECT " + aggs + " FROM c GROUP BY g",
+		"SELECT MIN(name) OVER (), MAX(name) OVER (), MIN(n) OVER (), MAX(n) OVER (), MIN(ts) OVER (), MAX(ts) OVER () FROM m",
+	} {
+		rows := ssExec(t, c, sql)
+		if len(rows) == 0 {
+			t.Fatalf("%s: no rows", sql)
+		}
+		got := make([]string, 6)
+		for i := range got {
+			v := rows[0][i]
+			if str, ok := toString(v); ok {
+				v = str // StringBox is how decoded text travels
+			}
+			got[i] = fmt.Sprintf("%T(%v)", v, v)
+		}
+		if s := fmt.Sprint(got); s != want {
+			t.Fatalf("%s:\n got %s\nwant %s", sql, s, want)
+		}
+	}
+}
+
 // Correlated subqueries (scalar and EXISTS) resolve the outer reference per row.
 func TestSQLSemantics_CorrelatedSubquery(t *testing.T) {
 	c, _ := createCatalogWithTxnManager(t)
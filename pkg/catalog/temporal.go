@@ -105,6 +105,12 @@ func encodeVersionedRow(rowValues []interface{}, asOfTime *time.Time) ([]byte, e
 	return encodeVersionedRowFull(rowValues, RowVersion{CreatedAt: createdAt, DeletedAt: 0})
 }
 
+// materializedRowTime is the creation time of rows copied into a temporary
+// tree for one statement, such as a foreign or attached table's. Stamping
+// them with the time of the copy would hide them from the statement when the
+// copy ends in a later second than the statement's query time.
+var materializedRowTime = time.Unix(0, 0)
+
 // encodeVersionedRowFull encodes row data with an explicit version, choosing the
 // binary-safe path when any value is binary. Used by re-encode paths (soft
 // delete, ALTER TABLE) so a []byte / non-UTF-8 value is not corrupted by a bare
@@ -203,7 +209,8 @@ func decodeBinaryVersionedRow(data []byte, numCols int) (VersionedRow, error) {
 	for len(vrow.Data) < numCols {
 		vrow.Data = append(vrow.Data, nil)
 	}
-	if len(vrow.Data) > numCols {
+	// numCols 0 keeps every value, as the other decoders do.
+	if numCols > 0 && len(vrow.Data) > numCols {
 		vrow.Data = vrow.Data[:numCols]
 	}
 	return vrow, nil
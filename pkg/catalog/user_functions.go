@@ -0,0 +1,304 @@
+package catalog
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// UserFunction is a scalar SQL function implemented in Go.
+type UserFunction struct {
+	// Fn receives the evaluated arguments (int64, float64, string, []byte,
+	// bool, nil, ...) and returns the result. Go integer and float types are
+	// widened to int64 and float64.
+	Fn func(args []interface{}) (interface{}, error)
+	// NumArgs is the required argument count; negative accepts any count.
+	NumArgs int
+	// Deterministic marks functions whose result depends only on their
+	// arguments. Queries calling a function that is not deterministic are
+	// never served from the query cache.
+	Deterministic bool
+}
+
+// Aggregator accumulates one group of an aggregate function.
+type Aggregator interface {
+	// Step adds one row's arguments.
+	Step(args []interface{}) error
+	// Result returns the aggregate of the rows stepped so far.
+	Result() (interface{}, error)
+}
+
+// UserAggregate is an aggregate SQL function implemented in Go. New is
+// called once per group.
+type UserAggregate struct {
+	New     func() Aggregator
+	NumArgs int // required argument count; negative accepts any count
+}
+
+// userFunctions is a catalog's registry, replaced wholesale on every change
+// so evaluation reads it without locking.
+type userFunctions struct {
+	scalars    map[string]*UserFunction
+	aggregates map[string]*UserAggregate
+}
+
+// userAggregateNames holds every name registered as a user aggregate by any
+// catalog. Aggregate detection runs on the AST before a catalog is at hand,
+// so it is name-based; a catalog without the aggregate reports it as unknown
+// when the query runs.
+var userAggregateNames sync.Map
+
+// RegisterFunction makes fn callable from SQL as name (case-insensitive).
+// Registering a name again replaces the previous function; built-in
+// functions cannot be replaced.
+func (c *Catalog) RegisterFunction(name string, fn *UserFunction) error {
+	if fn == nil || fn.Fn == nil {
+		return fmt.Errorf("function %s has no implementation", name)
+	}
+	return c.registerUserFunction(name, func(set *userFunctions, upper string) {
+		delete(set.aggregates, upper)
+		set.scalars[upper] = fn
+	})
+}
+
+// RegisterAggregate makes agg callable from SQL as the aggregate name
+// (case-insensitive), including with DISTINCT and in GROUP BY queries.
+func (c *Catalog) RegisterAggregate(name string, agg *UserAggregate) error {
+	if agg == nil || agg.New == nil {
+		return fmt.Errorf("aggregate %s has no implementation", name)
+	}
+	return c.registerUserFunction(name, func(set *userFunctions, upper string) {
+		delete(set.scalars, upper)
+		set.aggregates[upper] = agg
+		userAggregateNames.Store(upper, struct{}{})
+	})
+}
+
+func (c *Catalog) registerUserFunction(name string, add func(set *userFunctions, upper string)) error {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if !isValidFunctionName(upper) {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+	if isBuiltinFunctionName(upper) {
+		return fmt.Errorf("cannot redefine built-in function %s", upper)
+	}
+
+	c.userFuncMu.Lock()
+	defer c.userFuncMu.Unlock()
+	next := &userFunctions{scalars: make(map[string]*UserFunction), aggregates: make(map[string]*UserAggregate)}
+	if cur := c.userFuncs.Load(); cur != nil {
+		for k, v := range cur.scalars {
+			next.scalars[k] = v
+		}
+		for k, v := range cur.aggregates {
+			next.aggregates[k] = v
+		}
+	}
+	add(next, upper)
+	c.userFuncs.Store(next)
+
+	// Cached results may have been computed by the previous definition.
+	if c.queryCache != nil {
+		c.queryCache.InvalidateAll()
+	}
+	return nil
+}
+
+func isValidFunctionName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if ch != '_' && (ch < 'A' || ch > 'Z') && (ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isBuiltinFunctionName(name string) bool {
+	if isAggregateFuncName(name) {
+		return true
+	}
+	for _, fn := range builtinFunctions {
+		if fn.Name == name {
+			return true
+		}
+		for _, alias := range fn.Aliases {
+			if alias == name {
+				return true
+			}
+		}
+	}
+	_, ok := scalarFunctionHandlers[name]
+	return ok
+}
+
+func (c *Catalog) userFunction(name string) *UserFunction {
+	if c == nil {
+		return nil
+	}
+	if set := c.userFuncs.Load(); set != nil {
+		return set.scalars[name]
+	}
+	return nil
+}
+
+func (c *Catalog) userAggregate(name string) *UserAggregate {
+	if c == nil {
+		return nil
+	}
+	if set := c.userFuncs.Load(); set != nil {
+		return set.aggregates[name]
+	}
+	return nil
+}
+
+// isVolatileUserCall reports whether fc calls a user function that is not
+// marked deterministic. User aggregates are always treated as volatile.
+func (c *Catalog) isVolatileUserCall(fc *query.FunctionCall) bool {
+	name := toUpperFast(fc.Name)
+	if c.userAggregate(name) != nil {
+		return true
+	}
+	fn := c.userFunction(name)
+	return fn != nil && !fn.Deterministic
+}
+
+func isUserAggregateName(name string) bool {
+	_, ok := userAggregateNames.Load(name)
+	return ok
+}
+
+// callUserFunction evaluates a user scalar function.
+func callUserFunction(name string, fn *UserFunction, args []interface{}) (interface{}, error) {
+	if fn.NumArgs >= 0 && len(args) != fn.NumArgs {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, fn.NumArgs, len(args))
+	}
+	v, err := fn.Fn(userArgs(args))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return normalizeUserValue(v), nil
+}
+
+// userAggregateArgs is one row's arguments to a user aggregate, as collected
+// by collectAggregateInput.
+type userAggregateArgs []interface{}
+
+// reduceUserAggregate runs a user aggregate over the collected argument
+// lists of one group.
+func reduceUserAggregate(name string, agg *UserAggregate, values []interface{}, distinct bool) (interface{}, error) {
+	a := agg.New()
+	seen := make(map[string]bool)
+	for _, v := range values {
+		args, ok := v.(userAggregateArgs)
+		if !ok {
+			args = userAggregateArgs{v}
+		}
+		if agg.NumArgs >= 0 && len(args) != agg.NumArgs {
+			return nil, fmt.Errorf("%s expects %d arguments, got %d", name, agg.NumArgs, len(args))
+		}
+		if distinct {
+			var key strings.Builder
+			for _, arg := range args {
+				key.WriteString(typeTaggedKey(arg))
+				key.WriteByte(0)
+			}
+			if seen[key.String()] {
+				continue
+			}
+			seen[key.String()] = true
+		}
+		if err := a.Step(userArgs(args)); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	v, err := a.Result()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return normalizeUserValue(v), nil
+}
+
+// userAggregateResult reduces a user aggregate for the query paths that
+// cannot return errors; a failing aggregate yields NULL.
+func (c *Catalog) userAggregateResult(name string, values []interface{}, distinct bool) (interface{}, bool) {
+	agg := c.userAggregate(name)
+	if agg == nil {
+		return nil, false
+	}
+	v, err := reduceUserAggregate(name, agg, values, distinct)
+	if err != nil {
+		return nil, true
+	}
+	return v, true
+}
+
+// collectUserAggregateArgs evaluates every argument of a user aggregate call
+// for one row.
+func (c *Catalog) collectUserAggregateArgs(argExprs []query.Expression, row []interface{}, columns []ColumnDef, args []interface{}) (interface{}, bool) {
+	vals := make(userAggregateArgs, 0, len(argExprs))
+	for _, e := range argExprs {
+		if _, star := e.(*query.StarExpr); star {
+			continue
+		}
+		v, err := evaluateExpression(c, row, columns, e, args)
+		if err != nil {
+			return nil, false
+		}
+		vals = append(vals, v)
+	}
+	return vals, true
+}
+
+// userArgs replaces the engine's internal string representations with plain
+// strings before arguments are handed to user code.
+func userArgs(args []interface{}) []interface{} {
+	var out []interface{}
+	for i, v := range args {
+		if _, ok := v.(string); ok {
+			continue
+		}
+		if s, ok := toString(v); ok {
+			if out == nil {
+				out = append([]interface{}(nil), args...)
+			}
+			out[i] = s
+		}
+	}
+	if out == nil {
+		return args
+	}
+	return out
+}
+
+// normalizeUserValue widens Go numeric types returned by user functions to
+// the int64 and float64 values the engine stores.
+func normalizeUserValue(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, int64, float64, string, []byte, bool:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= 1<<63-1 {
+			return int64(u)
+		}
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+	return v
+}
@@ -0,0 +1,189 @@
+package catalog
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// UUID columns hold the canonical lowercase text form
+// (xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx). Writes accept that form in any
+// case, with or without hyphens or braces, and 16-byte blobs; anything else
+// is rejected. UUID_TO_BIN and BIN_TO_UUID convert to and from the 16-byte
+// form for BLOB columns.
+
+// uuidV7State keeps UUID_V7() values strictly increasing: within one
+// millisecond the 12-bit rand_a field counts up, so keys generated in order
+// sort in order and inserts land at the right edge of the B+Tree.
+var uuidV7State struct {
+	mu     sync.Mutex
+	lastMs int64
+	seq    uint16
+}
+
+// newUUIDv4 returns a random (version 4) UUID.
+func newUUIDv4() ([16]byte, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return u, fmt.Errorf("UUID: %w", err)
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// newUUIDv7 returns a time-ordered (version 7) UUID.
+func newUUIDv7() ([16]byte, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return u, fmt.Errorf("UUID_V7: %w", err)
+	}
+	ms := currentTime().UnixMilli()
+	s := &uuidV7State
+	s.mu.Lock()
+	if ms > s.lastMs {
+		s.lastMs = ms
+		s.seq = binary.BigEndian.Uint16(u[6:8]) & 0x07ff // leave room to count up
+	} else {
+		s.seq++
+		if s.seq > 0x0fff {
+			s.lastMs++
+			s.seq = 0
+		}
+	}
+	ms, seq := s.lastMs, s.seq
+	s.mu.Unlock()
+
+	// #nosec G115 -- a 48-bit millisecond timestamp, as RFC 9562 lays out.
+	binary.BigEndian.PutUint64(u[:8], uint64(ms)<<16|uint64(0x7000|seq))
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// formatUUID renders u in the canonical lowercase text form.
+func formatUUID(u [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// parseUUID accepts a UUID as text (hyphens and braces optional, any case)
+// or as 16 bytes.
+func parseUUID(v interface{}) ([16]byte, bool) {
+	var u [16]byte
+	var s string
+	switch val := v.(type) {
+	case []byte:
+		if len(val) == 16 {
+			copy(u[:], val)
+			return u, true
+		}
+		s = string(val)
+	case string, StringBox:
+		s = ValueToStringKey(val)
+	default:
+		return u, false
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, false
+		}
+		s = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return u, false
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, false
+	}
+	return u, true
+}
+
+// normalizeUUIDValue converts a value bound for a UUID column to its
+// canonical text form. Values that are not UUIDs are rejected.
+func normalizeUUIDValue(col *ColumnDef, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	u, ok := parseUUID(v)
+	if !ok {
+		return nil, fmt.Errorf("invalid UUID value for column '%s': %q", col.Name, ValueToStringKey(v))
+	}
+	return formatUUID(u), nil
+}
+
+// uuidFunc implements UUID([version]): a version 4 UUID, or version 7 when
+// asked for one.
+func uuidFunc(args []interface{}) (interface{}, error) {
+	version := 4.0
+	if len(args) > 0 && args[0] != nil {
+		var ok bool
+		if version, ok = toFloat64(args[0]); !ok {
+			return nil, fmt.Errorf("UUID version must be 4 or 7")
+		}
+	}
+	var u [16]byte
+	var err error
+	switch version {
+	case 4:
+		u, err = newUUIDv4()
+	case 7:
+		u, err = newUUIDv7()
+	default:
+		return nil, fmt.Errorf("UUID version must be 4 or 7, got %v", version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return formatUUID(u), nil
+}
+
+// uuidV7Func implements UUID_V7().
+func uuidV7Func(args []interface{}) (interface{}, error) {
+	return uuidFunc([]interface{}{int64(7)})
+}
+
+// uuidToBinFunc implements UUID_TO_BIN(uuid): the 16-byte form.
+func uuidToBinFunc(args []interface{}) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("UUID_TO_BIN requires 1 argument")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	u, ok := parseUUID(args[0])
+	if !ok {
+		return nil, fmt.Errorf("UUID_TO_BIN: invalid UUID %q", ValueToStringKey(args[0]))
+	}
+	return u[:], nil
+}
+
+// binToUUIDFunc implements BIN_TO_UUID(blob): the canonical text form.
+func binToUUIDFunc(args []interface{}) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("BIN_TO_UUID requires 1 argument")
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	u, ok := parseUUID(args[0])
+	if !ok {
+		return nil, fmt.Errorf("BIN_TO_UUID: invalid UUID %q", ValueToStringKey(args[0]))
+	}
+	return formatUUID(u), nil
+}
@@ -1236,7 +1236,7 @@ func TestApplyGroupByOrderBy(t *testing.T) {
 		{"C", float64(200.0)},
 	}
 
-	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy)
+	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy, nil)
 
 	if len(result) != 3 {
 		t.Fatalf("expected 3 rows, got %d", len(result))
@@ -1266,7 +1266,7 @@ func TestApplyGroupByOrderBy_Desc(t *testing.T) {
 		{"C", float64(200.0)},
 	}
 
-	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy)
+	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy, nil)
 
 	if len(result) != 3 {
 		t.Fatalf("expected 3 rows, got %d", len(result))
@@ -1296,7 +1296,7 @@ func TestApplyGroupByOrderBy_Positional(t *testing.T) {
 		{"C", float64(200.0)},
 	}
 
-	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy)
+	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy, nil)
 
 	if len(result) != 3 {
 		t.Fatalf("expected 3 rows, got %d", len(result))
@@ -1322,7 +1322,7 @@ func TestApplyGroupByOrderBy_Empty(t *testing.T) {
 		{"B", float64(50.0)},
 	}
 
-	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy)
+	result := catalog.applyGroupByOrderBy(rows, selectCols, orderBy, nil)
 
 	if len(result) != 2 {
 		t.Errorf("expected 2 rows, got %d", len(result))
@@ -1341,7 +1341,7 @@ func TestApplyGroupByOrderBy_NilRows(t *testing.T) {
 		{Expr: &query.Identifier{Name: "category"}},
 	}
 
-	result := catalog.applyGroupByOrderBy(nil, selectCols, orderBy)
+	result := catalog.applyGroupByOrderBy(nil, selectCols, orderBy, nil)
 
 	if result != nil {
 		t.Errorf("expected nil result for nil input, got %v", result)
@@ -2833,7 +2833,10 @@ func TestResolvePositionalRefs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := resolvePositionalRefs(tt.stmt)
+			result, err := resolvePositionalRefs(tt.stmt)
+			if err != nil {
+				t.Fatalf("resolvePositionalRefs: %v", err)
+			}
 
 			if tt.stmt == nil {
 				if result != nil {
@@ -13200,7 +13203,9 @@ func TestEvaluateJSONFunction(t *testing.T) {
 			return got == ""
 		}},
 
-		// REGEXP_MATCH tests
+		// REGEXP_* tests. These are served by scalarFunctionHandlers rather than
+		// evaluateJSONFunction: an empty pattern matches every string and
+		// REGEXP_EXTRACT returns the first match as text, or NULL.
 		{"regexp_match_true", "REGEXP_MATCH", []interface{}{"hello world", "world"}, false, func(got interface{}) bool {
 			return got == true
 		}},
@@ -13211,7 +13216,7 @@ func TestEvaluateJSONFunction(t *testing.T) {
 			return got == false
 		}},
 		{"regexp_match_empty_pattern", "REGEXP_MATCH", []interface{}{"hello", ""}, false, func(got interface{}) bool {
-			return got == false
+			return got == true
 		}},
 
 		// REGEXP_REPLACE tests
@@ -13224,12 +13229,10 @@ func TestEvaluateJSONFunction(t *testing.T) {
 
 		// REGEXP_EXTRACT tests
 		{"regexp_extract_basic", "REGEXP_EXTRACT", []interface{}{"hello world", "\\w+"}, false, func(got interface{}) bool {
-			extracted, ok := got.([]string)
-			return ok && len(extracted) > 0
+			return got == "hello"
 		}},
 		{"regexp_extract_empty", "REGEXP_EXTRACT", []interface{}{"", "world"}, false, func(got interface{}) bool {
-			extracted, ok := got.([]string)
-			return ok && len(extracted) == 0
+			return got == nil
 		}},
 
 		// Error cases
@@ -13285,7 +13288,13 @@ func TestEvaluateJSONFunction(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := evaluateJSONFunction(tt.funcName, tt.args)
+			var result interface{}
+			var err error
+			if handler, ok := scalarFunctionHandlers[tt.funcName]; ok {
+				result, err = handler(tt.args)
+			} else {
+				result, err = evaluateJSONFunction(nil, tt.funcName, tt.args)
+			}
 			if (err != nil) != tt.wantErr {
 				t.Errorf("evaluateJSONFunction(%s) error = %v, wantErr %v", tt.name, err, tt.wantErr)
 				return
@@ -56,6 +56,8 @@ func createCatalogWithTxnManager(t *testing.T) (*Catalog, *txn.Manager) {
 		procedures:        make(map[string]*query.CreateProcedureStmt),
 		materializedViews: make(map[string]*MaterializedViewDef),
 		ftsIndexes:        make(map[string]*FTSIndexDef),
+		ginIndexes:        make(map[string]*GINIndexDef),
+		bloomIndexes:      make(map[string]*BloomIndexDef),
 		jsonIndexes:       make(map[string]*JSONIndexDef),
 		vectorIndexes:     make(map[string]*VectorIndexDef),
 		stats:             make(map[string]*StatsTableStats),
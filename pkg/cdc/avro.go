@@ -0,0 +1,251 @@
+package cdc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AvroEncoder encodes a Message in Avro's single-object encoding: the
+// marker bytes C3 01, the 8-byte little-endian CRC-64-AVRO fingerprint of
+// the writer schema, then the binary record. Readers look the schema up by
+// its fingerprint; AvroSchema returns it for a table's messages.
+//
+// Each table gets a record schema named cobaltdb.<table>.Change with fields
+// op, table, lsn, before and after. Before and after are nullable records of
+// the message's columns, each nullable, typed from the declared column
+// type: long for integers, double for reals, boolean, bytes for BLOB and
+// string for everything else.
+type AvroEncoder struct {
+	schemas sync.Map // schema key -> *avroSchema
+}
+
+type avroSchema struct {
+	json        string
+	fingerprint uint64
+}
+
+// Encode implements Encoder.
+func (e *AvroEncoder) Encode(msg *Message) ([]byte, error) {
+	schema := e.schema(msg)
+	buf := make([]byte, 0, 64)
+	buf = append(buf, 0xC3, 0x01)
+	buf = binary.LittleEndian.AppendUint64(buf, schema.fingerprint)
+	buf = appendAvroString(buf, string(msg.Op))
+	buf = appendAvroString(buf, msg.Table)
+	buf = appendAvroLong(buf, int64(msg.LSN)) // #nosec G115 -- LSNs stay far below 2^63
+	var err error
+	for _, row := range []map[string]interface{}{msg.Before, msg.After} {
+		if row == nil {
+			buf = appendAvroLong(buf, 0) // null branch
+			continue
+		}
+		buf = appendAvroLong(buf, 1)
+		for _, col := range msg.Columns {
+			if buf, err = appendAvroValue(buf, avroType(col.Type), row[col.Name]); err != nil {
+				return nil, fmt.Errorf("column %s: %w", col.Name, err)
+			}
+		}
+	}
+	return buf, nil
+}
+
+// ContentType implements Encoder.
+func (*AvroEncoder) ContentType() string { return "application/vnd.apache.avro+binary" }
+
+// AvroSchema returns the schema, in Avro's parsing canonical form, that
+// Encode writes msg with.
+func (e *AvroEncoder) AvroSchema(msg *Message) string {
+	return e.schema(msg).json
+}
+
+func (e *AvroEncoder) schema(msg *Message) *avroSchema {
+	var key strings.Builder
+	key.WriteString(msg.Table)
+	for _, col := range msg.Columns {
+		key.WriteByte(0)
+		key.WriteString(col.Name)
+		key.WriteByte(0)
+		key.WriteString(avroType(col.Type))
+	}
+	if s, ok := e.schemas.Load(key.String()); ok {
+		return s.(*avroSchema)
+	}
+	text := canonicalAvroSchema(msg)
+	s, _ := e.schemas.LoadOrStore(key.String(), &avroSchema{json: text, fingerprint: avroFingerprint([]byte(text))})
+	return s.(*avroSchema)
+}
+
+// canonicalAvroSchema writes msg's schema directly in parsing canonical
+// form (full names, no whitespace, only name, type and fields), so its
+// fingerprint matches the one any Avro library computes.
+func canonicalAvroSchema(msg *Message) string {
+	ns := "cobaltdb." + avroName(msg.Table)
+	var b strings.Builder
+	b.WriteString(`{"name":"` + ns + `.Change","type":"record","fields":[`)
+	b.WriteString(`{"name":"op","type":"string"},`)
+	b.WriteString(`{"name":"table","type":"string"},`)
+	b.WriteString(`{"name":"lsn","type":"long"},`)
+	b.WriteString(`{"name":"before","type":["null",{"name":"` + ns + `.Row","type":"record","fields":[`)
+	for i, col := range msg.Columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"name":"` + avroName(col.Name) + `","type":["null","` + avroType(col.Type) + `"]}`)
+	}
+	b.WriteString(`]}]},`)
+	b.WriteString(`{"name":"after","type":["null","` + ns + `.Row"]}`)
+	b.WriteString(`]}`)
+	return b.String()
+}
+
+// avroName makes name a valid Avro name: letters, digits and underscores,
+// not starting with a digit.
+func avroName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z'):
+			b.WriteRune(r)
+		case '0' <= r && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// avroType maps a declared SQL type to the Avro type its values take.
+func avroType(sqlType string) string {
+	t := strings.ToUpper(sqlType)
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = t[:i]
+	}
+	switch strings.TrimSpace(t) {
+	case "INTEGER", "INT", "BIGINT", "SMALLINT", "TINYINT":
+		return "long"
+	case "REAL", "FLOAT", "DOUBLE", "NUMERIC", "DECIMAL":
+		return "double"
+	case "BOOLEAN", "BOOL":
+		return "boolean"
+	case "BLOB":
+		return "bytes"
+	default:
+		return "string"
+	}
+}
+
+// appendAvroValue appends v as the union ["null", typ].
+func appendAvroValue(buf []byte, typ string, v interface{}) ([]byte, error) {
+	if v == nil {
+		return appendAvroLong(buf, 0), nil
+	}
+	buf = appendAvroLong(buf, 1)
+	switch typ {
+	case "long":
+		switch n := v.(type) {
+		case int64:
+			return appendAvroLong(buf, n), nil
+		case int:
+			return appendAvroLong(buf, int64(n)), nil
+		case float64:
+			if n == math.Trunc(n) {
+				return appendAvroLong(buf, int64(n)), nil
+			}
+		case bool:
+			if n {
+				return appendAvroLong(buf, 1), nil
+			}
+			return appendAvroLong(buf, 0), nil
+		}
+	case "double":
+		switch n := v.(type) {
+		case float64:
+			return binary.LittleEndian.AppendUint64(buf, math.Float64bits(n)), nil
+		case int64:
+			return binary.LittleEndian.AppendUint64(buf, math.Float64bits(float64(n))), nil
+		}
+	case "boolean":
+		switch n := v.(type) {
+		case bool:
+			if n {
+				return append(buf, 1), nil
+			}
+			return append(buf, 0), nil
+		case int64:
+			if n != 0 {
+				return append(buf, 1), nil
+			}
+			return append(buf, 0), nil
+		}
+	case "bytes":
+		switch n := v.(type) {
+		case []byte:
+			return appendAvroBytes(buf, n), nil
+		case string:
+			return appendAvroString(buf, n), nil
+		}
+	default:
+		switch n := v.(type) {
+		case string:
+			return appendAvroString(buf, n), nil
+		case []byte:
+			return appendAvroBytes(buf, n), nil
+		case int64:
+			return appendAvroString(buf, strconv.FormatInt(n, 10)), nil
+		case float64:
+			return appendAvroString(buf, strconv.FormatFloat(n, 'g', -1, 64)), nil
+		default:
+			return appendAvroString(buf, fmt.Sprint(n)), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot encode %T as Avro %s", v, typ)
+}
+
+// appendAvroLong appends n zig-zag encoded as a variable-length integer.
+func appendAvroLong(buf []byte, n int64) []byte {
+	return binary.AppendUvarint(buf, uint64((n<<1)^(n>>63))) // #nosec G115 -- zig-zag encoding
+}
+
+func appendAvroBytes(buf, b []byte) []byte {
+	buf = appendAvroLong(buf, int64(len(b)))
+	return append(buf, b...)
+}
+
+func appendAvroString(buf []byte, s string) []byte {
+	buf = appendAvroLong(buf, int64(len(s)))
+	return append(buf, s...)
+}
+
+// avroFingerprint is the CRC-64-AVRO (Rabin) fingerprint of a canonical
+// schema.
+func avroFingerprint(schema []byte) uint64 {
+	fp := avroEmpty
+	for _, b := range schema {
+		fp = (fp >> 8) ^ avroFPTable[byte(fp)^b]
+	}
+	return fp
+}
+
+const avroEmpty uint64 = 0xc15d213aa4d7a795
+
+var avroFPTable = func() (t [256]uint64) {
+	for i := range t {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (avroEmpty & -(fp & 1))
+		}
+		t[i] = fp
+	}
+	return t
+}()
@@ -0,0 +1,321 @@
+// Package cdc turns a database's committed row changes into change messages
+// and delivers them to external systems, for event-driven pipelines built
+// on CobaltDB.
+//
+// A Pipeline follows DB.Subscribe, decodes each ChangeEvent into a Message
+// (operation, table, commit LSN, primary key and the row before and after),
+// encodes it as JSON or Avro and sends it to a Sink: a file, a webhook or a
+// Kafka producer. Each table is configured on its own with TableConfig, so
+// tables can go to different sinks, in different formats, with different
+// columns.
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+)
+
+// AllTables is the TableConfig.Table that routes every table without a
+// configuration of its own.
+const AllTables = "*"
+
+// Column describes one column of a Message's rows.
+type Column struct {
+	Name string
+	Type string // declared SQL type, e.g. INTEGER
+}
+
+// Message is one row change, decoded for delivery.
+type Message struct {
+	Op    engine.ChangeOp `json:"op"`
+	Table string          `json:"table"`
+	// LSN is the WAL position of the commit. The messages of a transaction
+	// share it, and it grows with commit order.
+	LSN uint64 `json:"lsn"`
+	// Key holds the primary key columns of the row.
+	Key map[string]interface{} `json:"key"`
+	// Before is the row before an update or delete and After the row after
+	// an insert or update; the other is nil.
+	Before map[string]interface{} `json:"before"`
+	After  map[string]interface{} `json:"after"`
+	// Columns lists the columns of Before and After in table order.
+	Columns []Column `json:"-"`
+}
+
+// Decode converts ev into a Message, keeping only columns when any are
+// named. Key columns are always reported in Key.
+func Decode(ev engine.ChangeEvent, columns []string) *Message {
+	msg := &Message{Op: ev.Op, Table: ev.Table, LSN: ev.LSN, Key: make(map[string]interface{}, len(ev.PrimaryKey))}
+	keep := make([]bool, len(ev.Columns))
+	for i, name := range ev.Columns {
+		keep[i] = len(columns) == 0 || containsFold(columns, name)
+		if keep[i] {
+			col := Column{Name: name}
+			if i < len(ev.Types) {
+				col.Type = ev.Types[i]
+			}
+			msg.Columns = append(msg.Columns, col)
+		}
+	}
+	row := func(values []interface{}) map[string]interface{} {
+		if values == nil {
+			return nil
+		}
+		m := make(map[string]interface{}, len(msg.Columns))
+		for i, name := range ev.Columns {
+			if keep[i] && i < len(values) {
+				m[name] = values[i]
+			}
+		}
+		return m
+	}
+	msg.Before = row(ev.Old)
+	msg.After = row(ev.New)
+
+	values := ev.New
+	if values == nil {
+		values = ev.Old
+	}
+	for _, key := range ev.PrimaryKey {
+		for i, name := range ev.Columns {
+			if name == key && i < len(values) {
+				msg.Key[name] = values[i]
+			}
+		}
+	}
+	return msg
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// TableConfig says where the changes of one table go.
+type TableConfig struct {
+	// Table is the table's name, or AllTables.
+	Table string
+	// Columns limits messages to these columns; nil sends them all.
+	Columns []string
+	// Encoder formats the messages; nil means JSON.
+	Encoder Encoder
+	// Sink receives the messages. Several tables may share one.
+	Sink Sink
+}
+
+// Stats counts a Pipeline's deliveries.
+type Stats struct {
+	Delivered uint64 // messages accepted by their sink
+	Failures  uint64 // sink errors; the batch is retried
+	LastLSN   uint64 // LSN of the last delivered message
+	LastError error  // most recent sink error, nil once a retry succeeds
+}
+
+// maxBatch bounds the messages a Pipeline sends to a sink in one call.
+const maxBatch = 256
+
+// Retry backoff after a sink error.
+const (
+	minRetryDelay = 50 * time.Millisecond
+	maxRetryDelay = 5 * time.Second
+)
+
+// Pipeline delivers a database's changes to sinks. Delivery is at least
+// once: a batch a sink rejects is retried, with backoff, until it is
+// accepted or the pipeline stops, and later changes wait behind it. A
+// pipeline sees the changes committed after Start; it has no position to
+// resume from, so changes made while none runs are not sent.
+type Pipeline struct {
+	routes map[string]*TableConfig
+	all    *TableConfig
+	sinks  []Sink
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Start begins delivering the changes of the configured tables. The
+// pipeline runs until ctx is done, Close is called or db is closed.
+func Start(ctx context.Context, db *engine.DB, tables ...TableConfig) (*Pipeline, error) {
+	if len(tables) == 0 {
+		return nil, errors.New("cdc: no tables configured")
+	}
+	p := &Pipeline{routes: make(map[string]*TableConfig), done: make(chan struct{})}
+	var names []string
+	seen := make(map[Sink]bool)
+	for i := range tables {
+		tc := tables[i]
+		if tc.Sink == nil {
+			return nil, fmt.Errorf("cdc: table %q has no sink", tc.Table)
+		}
+		if tc.Encoder == nil {
+			tc.Encoder = JSONEncoder{}
+		}
+		if !seen[tc.Sink] {
+			seen[tc.Sink] = true
+			p.sinks = append(p.sinks, tc.Sink)
+		}
+		if tc.Table == AllTables {
+			p.all = &tc
+			continue
+		}
+		if _, dup := p.routes[tc.Table]; dup {
+			return nil, fmt.Errorf("cdc: table %q configured twice", tc.Table)
+		}
+		p.routes[tc.Table] = &tc
+		names = append(names, tc.Table)
+	}
+	if p.all != nil {
+		names = nil // subscribe to every table
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	events, err := db.Subscribe(ctx, names...)
+	if err != nil {
+		p.cancel()
+		return nil, err
+	}
+	go p.run(ctx, events)
+	return p, nil
+}
+
+// Close stops the pipeline, waits for the batch in flight, and closes the
+// sinks.
+func (p *Pipeline) Close() error {
+	p.cancel()
+	<-p.done
+	var errs []error
+	for _, sink := range p.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats returns the pipeline's delivery counts.
+func (p *Pipeline) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func (p *Pipeline) run(ctx context.Context, events <-chan engine.ChangeEvent) {
+	defer close(p.done)
+	for {
+		ev, ok := <-events
+		if !ok {
+			return
+		}
+		batch := []engine.ChangeEvent{ev}
+	fill:
+		for len(batch) < maxBatch {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, ev)
+			default:
+				break fill
+			}
+		}
+		if !p.deliver(ctx, batch) {
+			return
+		}
+	}
+}
+
+// deliver sends batch, one run of consecutive events per sink so that each
+// sink sees its messages in commit order. It reports false if ctx ended
+// first.
+func (p *Pipeline) deliver(ctx context.Context, batch []engine.ChangeEvent) bool {
+	var (
+		sink    Sink
+		records []Record
+	)
+	flush := func() bool {
+		if len(records) == 0 {
+			return true
+		}
+		ok := p.send(ctx, sink, records)
+		records = nil
+		return ok
+	}
+	for _, ev := range batch {
+		tc := p.routes[ev.Table]
+		if tc == nil {
+			tc = p.all
+		}
+		if tc == nil {
+			continue
+		}
+		rec, err := newRecord(ev, tc)
+		if err != nil {
+			p.noteFailure(err)
+			continue // a message that cannot be encoded never will be
+		}
+		if tc.Sink != sink && !flush() {
+			return false
+		}
+		sink = tc.Sink
+		records = append(records, rec)
+	}
+	return flush()
+}
+
+func newRecord(ev engine.ChangeEvent, tc *TableConfig) (Record, error) {
+	msg := Decode(ev, tc.Columns)
+	value, err := tc.Encoder.Encode(msg)
+	if err != nil {
+		return Record{}, fmt.Errorf("cdc: encode %s change at LSN %d: %w", msg.Table, msg.LSN, err)
+	}
+	key, err := encodeKey(msg)
+	if err != nil {
+		return Record{}, fmt.Errorf("cdc: encode %s key at LSN %d: %w", msg.Table, msg.LSN, err)
+	}
+	return Record{Table: msg.Table, Key: key, Value: value, ContentType: tc.Encoder.ContentType(), Message: msg}, nil
+}
+
+// send retries records on sink until it accepts them or ctx ends.
+func (p *Pipeline) send(ctx context.Context, sink Sink, records []Record) bool {
+	delay := minRetryDelay
+	for {
+		err := sink.Send(ctx, records)
+		if err == nil {
+			p.mu.Lock()
+			p.stats.Delivered += uint64(len(records))
+			p.stats.LastLSN = records[len(records)-1].Message.LSN
+			p.stats.LastError = nil
+			p.mu.Unlock()
+			return true
+		}
+		p.noteFailure(err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+func (p *Pipeline) noteFailure(err error) {
+	p.mu.Lock()
+	p.stats.Failures++
+	p.stats.LastError = err
+	p.mu.Unlock()
+}
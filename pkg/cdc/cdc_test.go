@@ -0,0 +1,277 @@
+package cdc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+)
+
+func openDB(t *testing.T) *engine.DB {
+	t.Helper()
+	db, err := engine.Open(filepath.Join(t.TempDir(), "cdc.db"), &engine.Options{
+		CoreStorage: engine.CoreStorage{CacheSize: 128, WALEnabled: engine.BoolPtr(true)},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func exec(t *testing.T, db *engine.DB, sql string) {
+	t.Helper()
+	if _, err := db.Exec(context.Background(), sql); err != nil {
+		t.Fatalf("exec %q: %v", sql, err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type producedMessage struct {
+	topic      string
+	key, value []byte
+}
+
+type fakeProducer struct {
+	mu       sync.Mutex
+	messages []producedMessage
+	closed   bool
+}
+
+func (p *fakeProducer) Produce(_ context.Context, topic string, key, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, producedMessage{topic, key, value})
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *fakeProducer) produced() []producedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]producedMessage(nil), p.messages...)
+}
+
+func TestPipelineRoutesTablesToSinks(t *testing.T) {
+	db := openDB(t)
+	exec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, secret TEXT)")
+	exec(t, db, "CREATE TABLE orders (id INTEGER PRIMARY KEY, total REAL)")
+	exec(t, db, "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+
+	path := filepath.Join(t.TempDir(), "users.ndjson")
+	file, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	producer := &fakeProducer{}
+	var (
+		hookMu sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		hookMu.Lock()
+		bodies = append(bodies, r.Header.Get("Content-Type")+" "+string(body))
+		hookMu.Unlock()
+	}))
+	defer srv.Close()
+
+	p, err := Start(context.Background(), db,
+		TableConfig{Table: "users", Columns: []string{"id", "name"}, Sink: file},
+		TableConfig{Table: "orders", Encoder: &AvroEncoder{}, Sink: &KafkaSink{Producer: producer}},
+		TableConfig{Table: AllTables, Sink: &WebhookSink{URL: srv.URL}},
+	)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	exec(t, db, "INSERT INTO users VALUES (1, 'ann', 'x')")
+	exec(t, db, "UPDATE users SET name = 'anna' WHERE id = 1")
+	exec(t, db, "INSERT INTO orders VALUES (7, 12.5)")
+	exec(t, db, "INSERT INTO notes VALUES (1, 'hi')")
+	exec(t, db, "DELETE FROM users WHERE id = 1")
+	waitFor(t, "delivery", func() bool { return p.Stats().Delivered == 5 })
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The file holds the users changes as JSON lines, without the secret.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	want := []string{
+		`{"op":"insert","table":"users","lsn":0,"key":{"id":1},"before":null,"after":{"id":1,"name":"ann"}}`,
+		`{"op":"update","table":"users","lsn":0,"key":{"id":1},"before":{"id":1,"name":"ann"},"after":{"id":1,"name":"anna"}}`,
+		`{"op":"delete","table":"users","lsn":0,"key":{"id":1},"before":{"id":1,"name":"anna"},"after":null}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("file sink lines:\n%s", data)
+	}
+	var lastLSN uint64
+	for i, line := range lines {
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		lsn := uint64(msg["lsn"].(float64))
+		if lsn <= lastLSN {
+			t.Fatalf("line %d: LSN %d after %d", i, lsn, lastLSN)
+		}
+		lastLSN = lsn
+		msg["lsn"] = 0
+		normalized, _ := json.Marshal(msg)
+		var wantMsg map[string]interface{}
+		_ = json.Unmarshal([]byte(want[i]), &wantMsg)
+		wantNormalized, _ := json.Marshal(wantMsg)
+		if string(normalized) != string(wantNormalized) {
+			t.Fatalf("line %d = %s, want %s", i, normalized, wantNormalized)
+		}
+	}
+
+	// Orders went to Kafka as Avro, keyed by primary key.
+	msgs := producer.produced()
+	if len(msgs) != 1 || msgs[0].topic != "cobaltdb.orders" || string(msgs[0].key) != `{"id":7}` {
+		t.Fatalf("kafka messages = %+v", msgs)
+	}
+	if v := msgs[0].value; len(v) < 10 || v[0] != 0xC3 || v[1] != 0x01 {
+		t.Fatalf("kafka value is not single-object Avro: %x", v)
+	}
+	if !producer.closed {
+		t.Fatal("Close did not close the Kafka producer")
+	}
+
+	// Everything else went to the webhook.
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	if len(bodies) != 1 || !strings.HasPrefix(bodies[0], "application/x-ndjson ") || !strings.Contains(bodies[0], `"table":"notes"`) {
+		t.Fatalf("webhook bodies = %q", bodies)
+	}
+}
+
+type flakySink struct {
+	mu       sync.Mutex
+	failures int
+	got      []Record
+}
+
+func (s *flakySink) Send(_ context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.got = append(s.got, records...)
+	return nil
+}
+
+func (s *flakySink) Close() error { return nil }
+
+func TestPipelineRetriesFailedBatches(t *testing.T) {
+	db := openDB(t)
+	exec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	sink := &flakySink{failures: 2}
+	p, err := Start(context.Background(), db, TableConfig{Table: "t", Sink: sink})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Close()
+
+	exec(t, db, "INSERT INTO t VALUES (1)")
+	waitFor(t, "delivery", func() bool { return p.Stats().Delivered == 1 })
+	stats := p.Stats()
+	if stats.Failures != 2 || stats.LastError != nil || stats.LastLSN == 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.got) != 1 || sink.got[0].Message.After["id"] != int64(1) {
+		t.Fatalf("delivered %+v", sink.got)
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	db := openDB(t)
+	if _, err := Start(context.Background(), db); err == nil {
+		t.Fatal("Start without tables succeeded")
+	}
+	if _, err := Start(context.Background(), db, TableConfig{Table: "t"}); err == nil {
+		t.Fatal("Start without a sink succeeded")
+	}
+	if _, err := Start(context.Background(), db, TableConfig{Table: "missing", Sink: &flakySink{}}); err == nil {
+		t.Fatal("Start for a missing table succeeded")
+	}
+}
+
+func TestAvroEncoder(t *testing.T) {
+	enc := &AvroEncoder{}
+	msg := &Message{
+		Op: engine.ChangeUpdate, Table: "users", LSN: 3,
+		Columns: []Column{{"id", "INTEGER"}, {"name", "TEXT"}},
+		Before:  map[string]interface{}{"id": int64(1), "name": nil},
+		After:   map[string]interface{}{"id": int64(1), "name": "ann"},
+	}
+	data, err := enc.Encode(msg)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	schema := enc.AvroSchema(msg)
+	wantSchema := `{"name":"cobaltdb.users.Change","type":"record","fields":[{"name":"op","type":"string"},{"name":"table","type":"string"},{"name":"lsn","type":"long"},{"name":"before","type":["null",{"name":"cobaltdb.users.Row","type":"record","fields":[{"name":"id","type":["null","long"]},{"name":"name","type":["null","string"]}]}]},{"name":"after","type":["null","cobaltdb.users.Row"]}]}`
+	if schema != wantSchema {
+		t.Fatalf("schema = %s", schema)
+	}
+	if got := binary.LittleEndian.Uint64(data[2:10]); got != avroFingerprint([]byte(schema)) {
+		t.Fatalf("fingerprint = %x", got)
+	}
+	// op "update", table "users", lsn 3, before {1, null}, after {1, "ann"}.
+	want := []byte{0x0c, 'u', 'p', 'd', 'a', 't', 'e', 0x0a, 'u', 's', 'e', 'r', 's', 0x06,
+		0x02, 0x02, 0x02, 0x00,
+		0x02, 0x02, 0x02, 0x02, 0x06, 'a', 'n', 'n'}
+	if !bytes.Equal(data[10:], want) {
+		t.Fatalf("body = %x, want %x", data[10:], want)
+	}
+
+	// The empty-schema fingerprint is the CRC-64-AVRO initial value.
+	if avroFingerprint(nil) != 0xc15d213aa4d7a795 {
+		t.Fatal("bad fingerprint of empty input")
+	}
+
+	if _, err := enc.Encode(&Message{Table: "t", Columns: []Column{{"n", "INTEGER"}}, After: map[string]interface{}{"n": "x"}}); err == nil {
+		t.Fatal("encoding a string as long succeeded")
+	}
+}
@@ -0,0 +1,46 @@
+package cdc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Encoder turns a Message into the bytes a sink sends. Encoded messages are
+// self-delimiting, so a sink can write several back to back.
+type Encoder interface {
+	Encode(msg *Message) ([]byte, error)
+	// ContentType is the MIME type of the encoded messages.
+	ContentType() string
+}
+
+// JSONEncoder encodes a Message as one line of JSON:
+//
+//	{"op":"update","table":"users","lsn":42,"key":{"id":1},"before":{...},"after":{...}}
+//
+// Byte slices are base64 strings, as encoding/json writes them.
+type JSONEncoder struct{}
+
+// Encode implements Encoder.
+func (JSONEncoder) Encode(msg *Message) ([]byte, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ContentType implements Encoder: newline-delimited JSON.
+func (JSONEncoder) ContentType() string { return "application/x-ndjson" }
+
+// encodeKey encodes a message's primary key as a JSON object, whatever the
+// message format, for sinks that partition by key.
+func encodeKey(msg *Message) ([]byte, error) {
+	if len(msg.Key) == 0 {
+		return nil, nil
+	}
+	key, err := json.Marshal(msg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,150 @@
+package cdc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Record is one encoded message on its way to a sink.
+type Record struct {
+	Table string
+	// Key is the row's primary key as a JSON object, or nil for a table
+	// without one; sinks that partition use it.
+	Key         []byte
+	Value       []byte
+	ContentType string
+	Message     *Message
+}
+
+// Sink delivers records to an external system. Send is called from one
+// goroutine at a time, with records in commit order; if it returns an error
+// the same records are sent again. Sinks are compared by identity, so a
+// sink shared by several tables must be a pointer or other comparable
+// value.
+type Sink interface {
+	Send(ctx context.Context, records []Record) error
+	Close() error
+}
+
+// FileSink appends records to a file, one after another, and syncs the
+// file after each batch.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens path for appending, creating it if needed.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600) // #nosec G304 - the path is the caller's sink configuration.
+	if err != nil {
+		return nil, fmt.Errorf("cdc: open file sink: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Send implements Sink.
+func (s *FileSink) Send(_ context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var buf bytes.Buffer
+	for _, rec := range records {
+		buf.Write(rec.Value)
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("cdc: write file sink: %w", err)
+	}
+	return s.file.Sync()
+}
+
+// Close implements Sink.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+const webhookTimeout = 10 * time.Second
+
+// WebhookSink POSTs each batch of records to a URL, the records' values
+// concatenated in the body. Any status other than 2xx is an error, and the
+// batch is sent again.
+type WebhookSink struct {
+	URL string
+	// Header is added to every request, e.g. for an Authorization token.
+	Header http.Header
+	// Client sends the requests; nil uses one with a 10s timeout.
+	Client *http.Client
+}
+
+// Send implements Sink.
+func (s *WebhookSink) Send(ctx context.Context, records []Record) error {
+	var body bytes.Buffer
+	for _, rec := range records {
+		body.Write(rec.Value)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, &body)
+	if err != nil {
+		return fmt.Errorf("cdc: webhook request: %w", err)
+	}
+	for name, values := range s.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", records[0].ContentType)
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cdc: webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("cdc: webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close implements Sink.
+func (s *WebhookSink) Close() error { return nil }
+
+// KafkaProducer publishes messages to Kafka. Wrap the client library of
+// your choice in it; Produce should return once the broker has
+// acknowledged the message.
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+	Close() error
+}
+
+// KafkaSink publishes each record to a topic per table, keyed by the row's
+// primary key so that changes to one row stay in one partition, in order.
+type KafkaSink struct {
+	Producer KafkaProducer
+	// Topic names a table's topic; nil uses "cobaltdb.<table>".
+	Topic func(table string) string
+}
+
+// Send implements Sink.
+func (s *KafkaSink) Send(ctx context.Context, records []Record) error {
+	for _, rec := range records {
+		topic := "cobaltdb." + rec.Table
+		if s.Topic != nil {
+			topic = s.Topic(rec.Table)
+		}
+		if err := s.Producer.Produce(ctx, topic, rec.Key, rec.Value); err != nil {
+			return fmt.Errorf("cdc: kafka topic %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// Close implements Sink and closes the producer.
+func (s *KafkaSink) Close() error { return s.Producer.Close() }
@@ -0,0 +1,394 @@
+// Package client implements a connection to a cobaltdb-server over the native
+// wire protocol (length-prefixed MessagePack frames, default port 4200).
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+	"github.com/cobaltdb/cobaltdb/pkg/wire"
+)
+
+const (
+	// DefaultPort is the cobaltdb-server wire protocol port.
+	DefaultPort = "4200"
+
+	maxResponseBytes   = 16 * 1024 * 1024
+	defaultDialTimeout = 10 * time.Second
+)
+
+var (
+	ErrClosed             = errors.New("client: connection is closed")
+	ErrUnexpectedResponse = errors.New("client: unexpected response")
+)
+
+// Error is a server-side error returned over the wire protocol. SQLState is
+// the error's SQLSTATE code when the server classified it, so
+// errors.Is(err, sqlerr.ErrConstraintUnique) works across the network as it
+// does against the engine. Line and Column locate a syntax error.
+type Error struct {
+	Code     int
+	Message  string
+	SQLState string
+	Line     int
+	Column   int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server error %d: %s", e.Code, e.Message)
+}
+
+// SQLCode returns the SQLSTATE code, implementing sqlerr.Coder.
+func (e *Error) SQLCode() sqlerr.Code {
+	return sqlerr.Code(e.SQLState)
+}
+
+// Is reports whether target is a sqlerr error with e's SQLSTATE.
+func (e *Error) Is(target error) bool {
+	return sqlerr.Match(e.SQLCode(), target)
+}
+
+// Options configures Dial.
+type Options struct {
+	Username    string        // Empty skips authentication (server with auth disabled)
+	Password    string        // Password for Username
+	Database    string        // Informational database name shown to users
+	TLSConfig   *tls.Config   // nil = plaintext TCP
+	DialTimeout time.Duration // Connect + auth timeout (default: 10s)
+}
+
+// Result holds the response to a statement. Queries fill Columns/Rows;
+// other statements fill RowsAffected/LastInsertID.
+//
+// When a result is larger than the server's page size, Rows holds the first
+// page, HasMore is set, and the remaining pages are read with Fetch(CursorID).
+type Result struct {
+	Columns      []string
+	Types        []string
+	Rows         [][]interface{}
+	RowsAffected int64
+	LastInsertID int64
+	CursorID     uint32
+	HasMore      bool
+	// InTransaction reports whether the connection had a transaction open
+	// after the statement ran.
+	InTransaction bool
+}
+
+// Conn is a single authenticated wire protocol connection. It is safe for
+// concurrent use, but requests are serialized because the protocol is strictly
+// request/response.
+type Conn struct {
+	mu       sync.Mutex
+	conn     net.Conn
+	reader   *bufio.Reader
+	addr     string
+	username string
+	database string
+	isAdmin  bool
+	closed   bool
+}
+
+// ParseAddress parses a connection target of the form
+// tcp://host[:port][/database] (the tcp:// scheme is optional) into a dialable
+// host:port and a database name.
+func ParseAddress(target string) (addr, database string, err error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", "", fmt.Errorf("client: empty address")
+	}
+	if !strings.Contains(target, "://") {
+		target = "tcp://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", "", fmt.Errorf("client: invalid address %q: %w", target, err)
+	}
+	if u.Scheme != "tcp" {
+		return "", "", fmt.Errorf("client: unsupported scheme %q (want tcp)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", "", fmt.Errorf("client: missing host in %q", target)
+	}
+	port := u.Port()
+	if port == "" {
+		port = DefaultPort
+	}
+	return net.JoinHostPort(u.Hostname(), port), strings.Trim(u.Path, "/"), nil
+}
+
+// Dial connects to addr (host:port or tcp://host:port/db) and authenticates
+// when opts.Username is set.
+func Dial(ctx context.Context, addr string, opts *Options) (*Conn, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	hostPort, database, err := ParseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Database != "" {
+		database = opts.Database
+	}
+	timeout := opts.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	netConn, err := d.DialContext(ctx, "tcp", hostPort)
+	if err != nil {
+		return nil, fmt.Errorf("client: dial %s: %w", hostPort, err)
+	}
+	if opts.TLSConfig != nil {
+		tlsConn := tls.Client(netConn, opts.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = netConn.Close()
+			return nil, fmt.Errorf("client: TLS handshake: %w", err)
+		}
+		netConn = tlsConn
+	}
+
+	c := &Conn{
+		conn:     netConn,
+		reader:   bufio.NewReader(netConn),
+		addr:     hostPort,
+		database: database,
+	}
+	if opts.Username != "" {
+		if err := c.authenticate(ctx, opts.Username, opts.Password); err != nil {
+			_ = netConn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *Conn) authenticate(ctx context.Context, username, password string) error {
+	msgType, payload, err := c.roundTrip(ctx, wire.MsgAuth, &wire.AuthMessage{Username: username, Password: password})
+	if err != nil {
+		return err
+	}
+	switch msgType {
+	case wire.MsgAuthSuccess:
+		var ok wire.AuthSuccessMessage
+		if err := wire.Decode(payload, &ok); err != nil {
+			return fmt.Errorf("client: malformed auth response: %w", err)
+		}
+		c.username = username
+		c.isAdmin = ok.IsAdmin
+		return nil
+	case wire.MsgError:
+		return decodeError(payload)
+	default:
+		return fmt.Errorf("%w: message type 0x%02x to auth", ErrUnexpectedResponse, byte(msgType))
+	}
+}
+
+// Execute runs a single statement with optional parameters. sql.NamedArg
+// values bind :name and @name placeholders; the others bind ? in order.
+func (c *Conn) Execute(ctx context.Context, sql string, args ...interface{}) (*Result, error) {
+	msgType, payload, err := c.roundTrip(ctx, wire.MsgQuery, wire.NewQueryMessage(sql, args...))
+	if err != nil {
+		return nil, err
+	}
+	switch msgType {
+	case wire.MsgResult:
+		var rm wire.ResultMessage
+		if err := wire.Decode(payload, &rm); err != nil {
+			return nil, fmt.Errorf("client: malformed result: %w", err)
+		}
+		return resultFromMessage(&rm), nil
+	case wire.MsgOK:
+		var ok wire.OKMessage
+		if err := wire.Decode(payload, &ok); err != nil {
+			return nil, fmt.Errorf("client: malformed OK response: %w", err)
+		}
+		return &Result{RowsAffected: ok.RowsAffected, LastInsertID: ok.LastInsertID, InTransaction: ok.InTxn}, nil
+	case wire.MsgError:
+		return nil, decodeError(payload)
+	default:
+		return nil, fmt.Errorf("%w: message type 0x%02x to query", ErrUnexpectedResponse, byte(msgType))
+	}
+}
+
+// Fetch returns the next page of a result cursor opened by Execute. The cursor
+// is released by the server once a page with HasMore == false is returned.
+func (c *Conn) Fetch(ctx context.Context, cursorID uint32) (*Result, error) {
+	msgType, payload, err := c.roundTrip(ctx, wire.MsgFetch, &wire.FetchMessage{CursorID: cursorID})
+	if err != nil {
+		return nil, err
+	}
+	switch msgType {
+	case wire.MsgResult:
+		var rm wire.ResultMessage
+		if err := wire.Decode(payload, &rm); err != nil {
+			return nil, fmt.Errorf("client: malformed result: %w", err)
+		}
+		return resultFromMessage(&rm), nil
+	case wire.MsgError:
+		return nil, decodeError(payload)
+	default:
+		return nil, fmt.Errorf("%w: message type 0x%02x to fetch", ErrUnexpectedResponse, byte(msgType))
+	}
+}
+
+// CloseCursor releases a result cursor that will not be read to the end.
+func (c *Conn) CloseCursor(ctx context.Context, cursorID uint32) error {
+	msgType, payload, err := c.roundTrip(ctx, wire.MsgCloseCursor, &wire.CloseCursorMessage{CursorID: cursorID})
+	if err != nil {
+		return err
+	}
+	switch msgType {
+	case wire.MsgOK:
+		return nil
+	case wire.MsgError:
+		return decodeError(payload)
+	default:
+		return fmt.Errorf("%w: message type 0x%02x to close cursor", ErrUnexpectedResponse, byte(msgType))
+	}
+}
+
+// Ping checks that the server is responsive.
+func (c *Conn) Ping(ctx context.Context) error {
+	msgType, payload, err := c.roundTrip(ctx, wire.MsgPing, nil)
+	if err != nil {
+		return err
+	}
+	switch msgType {
+	case wire.MsgPong:
+		return nil
+	case wire.MsgError:
+		return decodeError(payload)
+	default:
+		return fmt.Errorf("%w: message type 0x%02x to ping", ErrUnexpectedResponse, byte(msgType))
+	}
+}
+
+// Addr returns the host:port the connection was dialed to.
+func (c *Conn) Addr() string { return c.addr }
+
+// Username returns the authenticated user, or "" when auth was skipped.
+func (c *Conn) Username() string { return c.username }
+
+// Database returns the database name given at Dial time.
+func (c *Conn) Database() string { return c.database }
+
+// IsAdmin reports whether the server granted the user the admin role.
+func (c *Conn) IsAdmin() bool { return c.isAdmin }
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
+
+func (c *Conn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *Conn) roundTrip(ctx context.Context, msgType wire.MsgType, payload interface{}) (wire.MsgType, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, nil, ErrClosed
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return 0, nil, err
+	}
+	if done := ctx.Done(); done != nil {
+		stop := context.AfterFunc(ctx, func() {
+			_ = c.conn.SetDeadline(time.Now())
+		})
+		defer stop()
+	}
+
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = wire.Encode(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	packet := make([]byte, 5+len(body))
+	binary.LittleEndian.PutUint32(packet[:4], uint32(len(body)+1)) // #nosec G115 - wire.Encode caps payload size.
+	packet[4] = byte(msgType)
+	copy(packet[5:], body)
+	if _, err := c.conn.Write(packet); err != nil {
+		return 0, nil, c.failLocked(ctx, err)
+	}
+
+	var length uint32
+	if err := binary.Read(c.reader, binary.LittleEndian, &length); err != nil {
+		return 0, nil, c.failLocked(ctx, err)
+	}
+	if length < 1 || length > maxResponseBytes {
+		_ = c.conn.Close()
+		c.closed = true
+		return 0, nil, fmt.Errorf("%w: frame length %d", ErrUnexpectedResponse, length)
+	}
+	respType, err := c.reader.ReadByte()
+	if err != nil {
+		return 0, nil, c.failLocked(ctx, err)
+	}
+	resp := make([]byte, length-1)
+	if _, err := io.ReadFull(c.reader, resp); err != nil {
+		return 0, nil, c.failLocked(ctx, err)
+	}
+	return wire.MsgType(respType), resp, nil
+}
+
+// failLocked closes a connection whose framing can no longer be trusted after
+// a partial read or write.
+func (c *Conn) failLocked(ctx context.Context, err error) error {
+	_ = c.conn.Close()
+	c.closed = true
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func resultFromMessage(rm *wire.ResultMessage) *Result {
+	return &Result{Columns: rm.Columns, Types: rm.Types, Rows: rm.Rows, CursorID: rm.CursorID, HasMore: rm.HasMore, InTransaction: rm.InTxn}
+}
+
+func decodeError(payload []byte) error {
+	var em wire.ErrorMessage
+	if err := wire.Decode(payload, &em); err != nil {
+		return fmt.Errorf("client: malformed error response: %w", err)
+	}
+	return &Error{Code: em.Code, Message: em.Message, SQLState: em.SQLState, Line: em.Line, Column: em.Column}
+}
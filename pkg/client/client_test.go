@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/server"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+)
+
+const testAdminPass = "Str0ng!Pass#2026"
+
+func startTestServer(t *testing.T, authEnabled bool) string {
+	t.Helper()
+	return startTestServerWithOptions(t, authEnabled, &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true, CacheSize: 1024}})
+}
+
+func startTestServerWithOptions(t *testing.T, authEnabled bool, opts *engine.Options) string {
+	t.Helper()
+	db, err := engine.Open(":memory:", opts)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	srv, err := server.New(server.NewProductionServer(db, server.DefaultProductionConfig()), &server.Config{
+		AuthEnabled:      authEnabled,
+		DefaultAdminUser: "admin",
+		DefaultAdminPass: testAdminPass,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() { _ = srv.ListenOnListener(ln) }()
+	t.Cleanup(func() {
+		_ = srv.Close()
+		_ = db.Close()
+	})
+	return ln.Addr().String()
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in       string
+		addr     string
+		database string
+		wantErr  bool
+	}{
+		{in: "tcp://db.example.com:4300/sales", addr: "db.example.com:4300", database: "sales"},
+		{in: "tcp://localhost", addr: "localhost:4200"},
+		{in: "127.0.0.1:5000", addr: "127.0.0.1:5000"},
+		{in: "http://localhost:4200", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		addr, database, err := ParseAddress(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseAddress(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if err == nil && (addr != tt.addr || database != tt.database) {
+			t.Fatalf("ParseAddress(%q) = %q, %q; want %q, %q", tt.in, addr, database, tt.addr, tt.database)
+		}
+	}
+}
+
+func TestDialExecuteAndQuery(t *testing.T) {
+	addr := startTestServer(t, true)
+	ctx := context.Background()
+
+	conn, err := Dial(ctx, "tcp://"+addr+"/main", &Options{Username: "admin", Password: testAdminPass})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if conn.Username() != "admin" || !conn.IsAdmin() || conn.Database() != "main" {
+		t.Fatalf("unexpected session: user=%q admin=%v db=%q", conn.Username(), conn.IsAdmin(), conn.Database())
+	}
+	if err := conn.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if _, err := conn.Execute(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	res, err := conn.Execute(ctx, "INSERT INTO t VALUES (?, ?)", 1, "alice")
+	if err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	if res.RowsAffected != 1 {
+		t.Fatalf("RowsAffected = %d, want 1", res.RowsAffected)
+	}
+	res, err = conn.Execute(ctx, "SELECT name FROM t WHERE id = 1")
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if len(res.Columns) != 1 || len(res.Rows) != 1 || res.Rows[0][0] != "alice" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	var srvErr *Error
+	if _, err := conn.Execute(ctx, "SELECT * FROM missing"); !errors.As(err, &srvErr) {
+		t.Fatalf("expected *Error for missing table, got %v", err)
+	}
+}
+
+func TestExecuteNamedAndListParams(t *testing.T) {
+	addr := startTestServer(t, false)
+	ctx := context.Background()
+	conn, err := Dial(ctx, addr, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Execute(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	if _, err := conn.Execute(ctx, "INSERT INTO t VALUES (:id, :name), (?, 'bob'), (3, 'carol')", sql.Named("id", 1), sql.Named("name", "alice"), 2); err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	res, err := conn.Execute(ctx, "SELECT name FROM t WHERE id IN (?) ORDER BY id LIMIT ? OFFSET ?", []int64{1, 2, 3}, 1, 1)
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != "bob" {
+		t.Fatalf("unexpected result: %+v", res.Rows)
+	}
+}
+
+func TestDialRejectsBadCredentials(t *testing.T) {
+	addr := startTestServer(t, true)
+	_, err := Dial(context.Background(), addr, &Options{Username: "admin", Password: "wrong-password"})
+	var srvErr *Error
+	if !errors.As(err, &srvErr) {
+		t.Fatalf("expected *Error for bad credentials, got %v", err)
+	}
+}
+
+func TestDialWithoutAuth(t *testing.T) {
+	addr := startTestServer(t, false)
+	conn, err := Dial(context.Background(), addr, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	if _, err := conn.Execute(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := conn.Execute(context.Background(), "SELECT 1"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Execute after Close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestExecutePagesLargeResultsThroughCursor(t *testing.T) {
+	addr := startTestServerWithOptions(t, false, &engine.Options{
+		CoreStorage:  engine.CoreStorage{InMemory: true, CacheSize: 1024},
+		ResultLimits: engine.ResultLimits{MaxResultRows: 4, ResultOverflow: engine.ResultOverflowCursor},
+		// An open cursor holds the only slot until it is exhausted or closed.
+		ConnectionPool: engine.ConnectionPool{MaxConnections: 1},
+	})
+	ctx := context.Background()
+	conn, err := Dial(ctx, addr, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Execute(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	for i := 1; i <= 10; i++ {
+		if _, err := conn.Execute(ctx, "INSERT INTO t VALUES (?)", i); err != nil {
+			t.Fatalf("INSERT failed: %v", err)
+		}
+	}
+
+	res, err := conn.Execute(ctx, "SELECT id FROM t ORDER BY id")
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if !res.HasMore || res.CursorID == 0 || len(res.Rows) != 4 {
+		t.Fatalf("first page: HasMore=%v CursorID=%d rows=%d", res.HasMore, res.CursorID, len(res.Rows))
+	}
+	total := len(res.Rows)
+	pages := 1
+	for res.HasMore {
+		res, err = conn.Fetch(ctx, res.CursorID)
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		total += len(res.Rows)
+		pages++
+	}
+	if total != 10 || pages != 3 {
+		t.Fatalf("got %d rows in %d pages, want 10 rows in 3 pages", total, pages)
+	}
+
+	var srvErr *Error
+	if _, err := conn.Fetch(ctx, res.CursorID); !errors.As(err, &srvErr) {
+		t.Fatalf("Fetch on exhausted cursor = %v, want server error", err)
+	}
+
+	res, err = conn.Execute(ctx, "SELECT id FROM t")
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if err := conn.CloseCursor(ctx, res.CursorID); err != nil {
+		t.Fatalf("CloseCursor failed: %v", err)
+	}
+	if _, err := conn.Fetch(ctx, res.CursorID); !errors.As(err, &srvErr) {
+		t.Fatalf("Fetch on closed cursor = %v, want server error", err)
+	}
+	if res, err = conn.Execute(ctx, "SELECT COUNT(*) FROM t"); err != nil || len(res.Rows) != 1 || res.HasMore {
+		t.Fatalf("SELECT after CloseCursor = %+v, %v", res, err)
+	}
+}
+
+func TestExecuteReportsResultTooLarge(t *testing.T) {
+	addr := startTestServerWithOptions(t, false, &engine.Options{
+		CoreStorage:  engine.CoreStorage{InMemory: true, CacheSize: 1024},
+		ResultLimits: engine.ResultLimits{MaxResultRows: 2},
+	})
+	ctx := context.Background()
+	conn, err := Dial(ctx, addr, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	for _, sql := range []string{"CREATE TABLE t (id INTEGER PRIMARY KEY)", "INSERT INTO t VALUES (1)", "INSERT INTO t VALUES (2)", "INSERT INTO t VALUES (3)"} {
+		if _, err := conn.Execute(ctx, sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+	}
+	var srvErr *Error
+	if _, err := conn.Execute(ctx, "SELECT id FROM t"); !errors.As(err, &srvErr) || !strings.Contains(srvErr.Message, "result set too large") {
+		t.Fatalf("Execute = %v, want result set too large", err)
+	}
+}
+
+func TestExecuteReportsSQLState(t *testing.T) {
+	addr := startTestServer(t, false)
+	ctx := context.Background()
+	conn, err := Dial(ctx, addr, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Execute(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Execute(ctx, "INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Execute(ctx, "INSERT INTO t VALUES (1)")
+	var srvErr *Error
+	if !errors.As(err, &srvErr) || srvErr.SQLState != string(sqlerr.CodeUniqueViolation) {
+		t.Fatalf("Execute = %#v, want SQLSTATE %s", err, sqlerr.CodeUniqueViolation)
+	}
+	if !errors.Is(err, sqlerr.ErrConstraintUnique) {
+		t.Fatal("errors.Is(err, sqlerr.ErrConstraintUnique) = false over the wire")
+	}
+
+	_, err = conn.Execute(ctx, "SELECT id\nFROM t WHERE (id = 1")
+	if !errors.As(err, &srvErr) || !errors.Is(err, sqlerr.ErrSyntax) || srvErr.Line != 2 || srvErr.Column == 0 {
+		t.Fatalf("Execute = %#v, want a syntax error on line 2", err)
+	}
+}
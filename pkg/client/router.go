@@ -0,0 +1,424 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultMaxIdlePerNode = 4
+
+var (
+	// ErrRouterClosed is returned by a Router after Close.
+	ErrRouterClosed = errors.New("client: router is closed")
+	// ErrTxnStatement is returned when BEGIN, COMMIT or ROLLBACK is sent through
+	// Router.Execute: pooled connections cannot hold a transaction open between
+	// calls, so transactions go through Router.Begin.
+	ErrTxnStatement = errors.New("client: transaction statements must use Router.Begin")
+	// ErrTxDone is returned by a Tx after Commit or Rollback.
+	ErrTxDone = errors.New("client: transaction has already been committed or rolled back")
+)
+
+// Route selects the node a statement is sent to.
+type Route int
+
+const (
+	// RouteWrite sends the statement to the leader.
+	RouteWrite Route = iota
+	// RouteRead sends the statement to a follower (or the leader when the
+	// router has no followers).
+	RouteRead
+)
+
+func (r Route) String() string {
+	if r == RouteRead {
+		return "read"
+	}
+	return "write"
+}
+
+// RouterOptions configures NewRouter.
+type RouterOptions struct {
+	Leader    string   // Address of the writable node (see ParseAddress)
+	Followers []string // Addresses of read replicas; empty sends reads to the leader
+	Options   *Options // Dial options used for every node
+	MaxIdle   int      // Idle connections kept per node (default: 4)
+}
+
+// Router splits statements between a leader and its followers. Statements are
+// classified with Classify, or by a leading /*+ READ */ or /*+ WRITE */ hint,
+// or by WithRoute on the context; reads are spread round-robin over the
+// followers and everything else goes to the leader. Transactions opened with
+// Begin stay on one leader connection until they end.
+//
+// A Router is safe for concurrent use. Connections are dialed on demand and
+// pooled per node.
+type Router struct {
+	leader    *nodePool
+	followers []*nodePool
+	next      atomic.Uint64
+	closed    atomic.Bool
+}
+
+// NewRouter validates the node addresses and returns a router. No connection
+// is made until the first statement.
+func NewRouter(opts RouterOptions) (*Router, error) {
+	if opts.Leader == "" {
+		return nil, fmt.Errorf("client: router needs a leader address")
+	}
+	maxIdle := opts.MaxIdle
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdlePerNode
+	}
+	newPool := func(addr string) (*nodePool, error) {
+		if _, _, err := ParseAddress(addr); err != nil {
+			return nil, err
+		}
+		return &nodePool{addr: addr, opts: opts.Options, maxIdle: maxIdle}, nil
+	}
+	leader, err := newPool(opts.Leader)
+	if err != nil {
+		return nil, err
+	}
+	r := &Router{leader: leader}
+	for _, addr := range opts.Followers {
+		p, err := newPool(addr)
+		if err != nil {
+			return nil, err
+		}
+		r.followers = append(r.followers, p)
+	}
+	return r, nil
+}
+
+type routeKey struct{}
+
+// WithRoute returns a context that forces statements run with it onto route,
+// overriding classification and hints.
+func WithRoute(ctx context.Context, route Route) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// Execute runs a statement on the node its route selects. A read whose
+// follower cannot be reached is retried on the leader. Paged results are read
+// to the end before the connection goes back to the pool, so the returned
+// Result never has HasMore set.
+func (r *Router) Execute(ctx context.Context, sql string, args ...interface{}) (*Result, error) {
+	if r.closed.Load() {
+		return nil, ErrRouterClosed
+	}
+	if isTxnStatement(sql) {
+		return nil, ErrTxnStatement
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	route, ok := ctx.Value(routeKey{}).(Route)
+	if !ok {
+		route = Classify(sql)
+	}
+	// The server expects statements to start with their keyword, so the hint
+	// is only read here and not sent.
+	_, sql = splitRouteHint(sql)
+
+	p := r.pick(route)
+	conn, err := p.get(ctx)
+	if err != nil && p != r.leader {
+		p = r.leader
+		conn, err = p.get(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := conn.Execute(ctx, sql, args...)
+	if err == nil {
+		err = readAllPages(ctx, conn, res)
+	}
+	p.put(conn)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Begin starts a transaction on a leader connection. Every statement of the
+// transaction, reads included, runs on that connection.
+func (r *Router) Begin(ctx context.Context) (*Tx, error) {
+	if r.closed.Load() {
+		return nil, ErrRouterClosed
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	conn, err := r.leader.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Execute(ctx, "BEGIN"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &Tx{pool: r.leader, conn: conn}, nil
+}
+
+// Close closes every pooled connection. Connections held by open
+// transactions are closed when the transaction ends.
+func (r *Router) Close() error {
+	if !r.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	err := r.leader.close()
+	for _, p := range r.followers {
+		if ferr := p.close(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+func (r *Router) pick(route Route) *nodePool {
+	if route != RouteRead || len(r.followers) == 0 {
+		return r.leader
+	}
+	n := r.next.Add(1) - 1
+	return r.followers[n%uint64(len(r.followers))]
+}
+
+// Tx is a transaction pinned to one leader connection. It is not safe for
+// concurrent use.
+type Tx struct {
+	pool *nodePool
+	conn *Conn
+	done bool
+}
+
+// Execute runs a statement inside the transaction. Results are returned as
+// the server sends them; use Fetch for further pages.
+func (tx *Tx) Execute(ctx context.Context, sql string, args ...interface{}) (*Result, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.Execute(ctx, sql, args...)
+}
+
+// Fetch returns the next page of a result cursor opened by Execute.
+func (tx *Tx) Fetch(ctx context.Context, cursorID uint32) (*Result, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.Fetch(ctx, cursorID)
+}
+
+// Commit commits the transaction and releases its connection.
+func (tx *Tx) Commit(ctx context.Context) error {
+	return tx.end(ctx, "COMMIT")
+}
+
+// Rollback aborts the transaction and releases its connection.
+func (tx *Tx) Rollback(ctx context.Context) error {
+	return tx.end(ctx, "ROLLBACK")
+}
+
+func (tx *Tx) end(ctx context.Context, stmt string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	if _, err := tx.conn.Execute(ctx, stmt); err != nil {
+		// The server rolls back whatever is still open when the connection
+		// goes away, so a connection in an unknown state is dropped.
+		_ = tx.conn.Close()
+		return err
+	}
+	tx.pool.put(tx.conn)
+	return nil
+}
+
+// nodePool keeps idle connections to one node.
+type nodePool struct {
+	addr    string
+	opts    *Options
+	maxIdle int
+
+	mu     sync.Mutex
+	idle   []*Conn
+	closed bool
+}
+
+func (p *nodePool) get(ctx context.Context) (*Conn, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrRouterClosed
+	}
+	for len(p.idle) > 0 {
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !c.isClosed() {
+			p.mu.Unlock()
+			return c, nil
+		}
+	}
+	p.mu.Unlock()
+	return Dial(ctx, p.addr, p.opts)
+}
+
+// put returns c to the pool, closing it if the pool is full or closed or if
+// the connection broke while in use.
+func (p *nodePool) put(c *Conn) {
+	if c.isClosed() {
+		return
+	}
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.maxIdle {
+		p.idle = append(p.idle, c)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	_ = c.Close()
+}
+
+func (p *nodePool) close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+	var err error
+	for _, c := range idle {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// readAllPages appends the remaining pages of a cursor result to res.
+func readAllPages(ctx context.Context, conn *Conn, res *Result) error {
+	for res.HasMore {
+		page, err := conn.Fetch(ctx, res.CursorID)
+		if err != nil {
+			return err
+		}
+		res.Rows = append(res.Rows, page.Rows...)
+		res.HasMore = page.HasMore
+	}
+	res.CursorID = 0
+	return nil
+}
+
+// Classify reports whether sql only reads data. SELECT, WITH, VALUES, SHOW,
+// DESCRIBE and EXPLAIN are reads unless they also write (SELECT ... INTO,
+// SELECT ... FOR UPDATE, a WITH wrapping INSERT/UPDATE/DELETE); everything
+// else is a write. A leading /*+ READ */ or /*+ WRITE */ comment overrides
+// the classification.
+func Classify(sql string) Route {
+	switch hint, _ := splitRouteHint(sql); hint {
+	case "READ":
+		return RouteRead
+	case "WRITE":
+		return RouteWrite
+	}
+	words := sqlKeywords(sql)
+	if len(words) == 0 {
+		return RouteWrite
+	}
+	switch words[0] {
+	case "SELECT", "WITH", "VALUES", "SHOW", "DESCRIBE", "DESC", "EXPLAIN":
+	default:
+		return RouteWrite
+	}
+	for _, w := range words[1:] {
+		switch w {
+		case "INSERT", "UPDATE", "DELETE", "MERGE", "INTO":
+			return RouteWrite
+		}
+	}
+	return RouteRead
+}
+
+func isTxnStatement(sql string) bool {
+	_, body := splitRouteHint(sql)
+	words := sqlKeywords(body)
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "BEGIN", "START", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		return true
+	}
+	return false
+}
+
+// splitRouteHint returns the READ or WRITE hint of a leading /*+ ... */
+// comment and the statement that follows it. Without a hint, it returns ""
+// and sql unchanged.
+func splitRouteHint(sql string) (hint, rest string) {
+	trimmed := strings.TrimLeft(sql, " \t\r\n")
+	if !strings.HasPrefix(trimmed, "/*+") {
+		return "", sql
+	}
+	end := strings.Index(trimmed, "*/")
+	if end < 0 {
+		return "", sql
+	}
+	switch h := strings.ToUpper(strings.TrimSpace(trimmed[3:end])); h {
+	case "READ", "WRITE":
+		return h, strings.TrimLeft(trimmed[end+2:], " \t\r\n")
+	}
+	return "", sql
+}
+
+// sqlKeywords returns the upper-cased bare words of sql, skipping string
+// literals, quoted identifiers and comments.
+func sqlKeywords(sql string) []string {
+	var words []string
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return words
+			}
+			i += end + 4
+		case ch == '\'' || ch == '"' || ch == '`':
+			i++
+			for i < len(sql) {
+				if sql[i] == ch {
+					if i+1 < len(sql) && sql[i+1] == ch {
+						i += 2
+						continue
+					}
+					break
+				}
+				if sql[i] == '\\' && ch == '\'' {
+					i++
+				}
+				i++
+			}
+			i++
+		case isWordByte(ch):
+			start := i
+			for i < len(sql) && (isWordByte(sql[i]) || (sql[i] >= '0' && sql[i] <= '9')) {
+				i++
+			}
+			words = append(words, strings.ToUpper(sql[start:i]))
+		default:
+			i++
+		}
+	}
+	return words
+}
+
+func isWordByte(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want Route
+	}{
+		{"SELECT * FROM t", RouteRead},
+		{"  -- comment\n(SELECT 1)", RouteRead},
+		{"with x AS (SELECT 1) SELECT * FROM x", RouteRead},
+		{"SELECT REPLACE(name, 'a', 'b') FROM t WHERE note = 'INSERT INTO'", RouteRead},
+		{"EXPLAIN SELECT 1", RouteRead},
+		{"SHOW TABLES", RouteRead},
+		{"SELECT * FROM t FOR UPDATE", RouteWrite},
+		{"SELECT * INTO t2 FROM t", RouteWrite},
+		{"WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", RouteWrite},
+		{"INSERT INTO t VALUES (1)", RouteWrite},
+		{"CREATE TABLE t (id INTEGER)", RouteWrite},
+		{"", RouteWrite},
+		{"/*+ WRITE */ SELECT * FROM t", RouteWrite},
+		{"/*+ read */ CALL report()", RouteRead},
+		{"SELECT 1 /*+ WRITE */", RouteRead},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.sql); got != tt.want {
+			t.Errorf("Classify(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+// startRouterNodes starts a leader and a follower. They are independent
+// databases, each holding a row naming itself, so a read shows which node
+// served it.
+func startRouterNodes(t *testing.T) *Router {
+	t.Helper()
+	ctx := context.Background()
+	var addrs []string
+	for _, name := range []string{"leader", "follower"} {
+		addr := startTestServer(t, false)
+		conn, err := Dial(ctx, addr, nil)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		for _, sql := range []string{"CREATE TABLE node (name TEXT)", "INSERT INTO node VALUES ('" + name + "')"} {
+			if _, err := conn.Execute(ctx, sql); err != nil {
+				t.Fatalf("%s: %v", sql, err)
+			}
+		}
+		_ = conn.Close()
+		addrs = append(addrs, addr)
+	}
+	r, err := NewRouter(RouterOptions{Leader: addrs[0], Followers: addrs[1:]})
+	if err != nil {
+		t.Fatalf("NewRouter failed: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func servedBy(t *testing.T, ctx context.Context, exec func(context.Context, string, ...interface{}) (*Result, error), sql string) string {
+	t.Helper()
+	res, err := exec(ctx, sql)
+	if err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	if len(res.Rows) == 0 {
+		return ""
+	}
+	return res.Rows[0][0].(string)
+}
+
+func TestRouterSplitsReadsAndWrites(t *testing.T) {
+	r := startRouterNodes(t)
+	ctx := context.Background()
+
+	if got := servedBy(t, ctx, r.Execute, "SELECT name FROM node"); got != "follower" {
+		t.Fatalf("read served by %q, want follower", got)
+	}
+	if got := servedBy(t, ctx, r.Execute, "/*+ WRITE */ SELECT name FROM node"); got != "leader" {
+		t.Fatalf("hinted read served by %q, want leader", got)
+	}
+	if got := servedBy(t, WithRoute(ctx, RouteWrite), r.Execute, "SELECT name FROM node"); got != "leader" {
+		t.Fatalf("WithRoute read served by %q, want leader", got)
+	}
+
+	if _, err := r.Execute(ctx, "INSERT INTO node VALUES ('written')"); err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	if got := servedBy(t, WithRoute(ctx, RouteWrite), r.Execute, "SELECT name FROM node WHERE name = 'written'"); got != "written" {
+		t.Fatalf("write did not reach the leader")
+	}
+	if got := servedBy(t, ctx, r.Execute, "SELECT name FROM node WHERE name = 'written'"); got != "" {
+		t.Fatalf("write reached the follower")
+	}
+
+	if _, err := r.Execute(ctx, "BEGIN"); !errors.Is(err, ErrTxnStatement) {
+		t.Fatalf("Execute(BEGIN) = %v, want ErrTxnStatement", err)
+	}
+}
+
+func TestRouterTransactionsStickToLeader(t *testing.T) {
+	r := startRouterNodes(t)
+	ctx := context.Background()
+
+	tx, err := r.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if _, err := tx.Execute(ctx, "INSERT INTO node VALUES ('in-tx')"); err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	if got := servedBy(t, ctx, tx.Execute, "SELECT name FROM node WHERE name = 'in-tx'"); got != "in-tx" {
+		t.Fatalf("read inside transaction did not see its own write")
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if _, err := tx.Execute(ctx, "SELECT 1"); !errors.Is(err, ErrTxDone) {
+		t.Fatalf("Execute after Rollback = %v, want ErrTxDone", err)
+	}
+	if got := servedBy(t, WithRoute(ctx, RouteWrite), r.Execute, "SELECT name FROM node WHERE name = 'in-tx'"); got != "" {
+		t.Fatalf("rolled back row is visible")
+	}
+}
+
+func TestRouterFallsBackToLeader(t *testing.T) {
+	addr := startTestServer(t, false)
+	r, err := NewRouter(RouterOptions{Leader: addr, Followers: []string{"127.0.0.1:1"}})
+	if err != nil {
+		t.Fatalf("NewRouter failed: %v", err)
+	}
+	defer r.Close()
+	if _, err := r.Execute(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("read with unreachable follower: %v", err)
+	}
+	_ = r.Close()
+	if _, err := r.Execute(context.Background(), "SELECT 1"); !errors.Is(err, ErrRouterClosed) {
+		t.Fatalf("Execute after Close = %v, want ErrRouterClosed", err)
+	}
+}
@@ -0,0 +1,503 @@
+// Package cluster runs several CobaltDB databases as one highly available
+// database: a Raft group whose members elect a single leader to take
+// writes, and elect another when it fails.
+//
+// The replicated log carries the database's committed transactions, in the
+// form streaming replication ships them (see engine.ConsensusLog). The
+// leader runs a write, proposes its commit to the log, and returns only
+// once a majority of members has the entry in their logs; followers apply
+// committed entries in log order and refuse writes with engine.ErrNotLeader.
+// An acknowledged write survives the loss of any minority of members, and
+// every later leader holds it. A write whose leader fails before the entry
+// commits returns an error, and may or may not survive. Reads are served by
+// whichever member receives them; on a follower they can trail the leader
+// by the entries it has not yet applied.
+//
+// The cluster is not linearizable. A leader applies a write before the
+// group commits it, so reads on the leader can see a write that has not
+// been acknowledged and that a failover may yet discard. For the same
+// reason a member can end up holding changes the log does not: a leader
+// that loses an election with entries nobody else received, or a member
+// that crashed between applying an entry and recording it. Such a member,
+// and every member on its first start, resynchronises from a snapshot of
+// the leader's database.
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/logger"
+)
+
+// Config configures one member of a cluster.
+type Config struct {
+	// ID names the member; each member of a cluster needs its own.
+	ID string
+	// Addr is the address the member listens on for the other members.
+	Addr string
+	// Peers maps the other members' IDs to their Addr.
+	Peers map[string]string
+	// Dir holds the member's term, vote and log. It must survive restarts
+	// along with the database.
+	Dir string
+	// ClientAddr is where clients reach this member's server. Followers
+	// report the leader's in the error a write gets.
+	ClientAddr string
+	// Token is the shared secret members prove they hold before any
+	// request is read. It is never sent; an empty token admits anyone.
+	Token string
+	// TLS, when set, encrypts traffic between members: the listener serves
+	// it and connections to peers are dialled with it, so it needs both a
+	// certificate and the roots that verify the peers'. Without it member
+	// traffic, including the database snapshots sent to lagging
+	// followers, is plaintext and the cluster network must be trusted.
+	TLS *tls.Config
+	// HeartbeatInterval is how often the leader contacts each follower.
+	// Default 100ms.
+	HeartbeatInterval time.Duration
+	// ElectionTimeout is how long a follower waits to hear from a leader
+	// before standing for election, randomised up to twice as long, and
+	// how long a leader keeps leading without hearing from a majority.
+	// Default 1s.
+	ElectionTimeout time.Duration
+	// MaxLogEntries bounds the applied entries kept for followers that fall
+	// behind; one further behind gets a snapshot. Default 10000.
+	MaxLogEntries int
+	// Logger receives elections and errors; nil discards them.
+	Logger *logger.Logger
+}
+
+// Defaults for Config.
+const (
+	DefaultHeartbeatInterval = 100 * time.Millisecond
+	DefaultElectionTimeout   = time.Second
+	DefaultMaxLogEntries     = 10000
+)
+
+// ErrLeadershipLost is returned for a write whose leader stepped down before
+// its entry committed. The write may still survive, if the new leader
+// received it.
+var ErrLeadershipLost = errors.New("cluster: leadership lost before the write committed")
+
+// ErrClosed is returned once a Node is closed.
+var ErrClosed = errors.New("cluster: node closed")
+
+// ParsePeers parses a comma-separated list of id=address pairs, as the
+// -cluster-peers flag takes them.
+func ParsePeers(s string) (map[string]string, error) {
+	peers := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, addr, ok := strings.Cut(item, "=")
+		id, addr = strings.TrimSpace(id), strings.TrimSpace(addr)
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("cluster: peer %q is not id=address", item)
+		}
+		if _, dup := peers[id]; dup {
+			return nil, fmt.Errorf("cluster: peer %q listed twice", id)
+		}
+		peers[id] = addr
+	}
+	return peers, nil
+}
+
+type role int
+
+const (
+	follower role = iota
+	candidate
+	leader
+)
+
+func (r role) String() string {
+	switch r {
+	case candidate:
+		return "candidate"
+	case leader:
+		return "leader"
+	default:
+		return "follower"
+	}
+}
+
+// Status describes a member's view of its cluster.
+type Status struct {
+	ID   string
+	Role string // "leader", "follower" or "candidate"
+	Term uint64
+	// Leader is the ID of the member this one follows, or its own ID when
+	// it leads; empty while there is none. LeaderAddr is the leader's
+	// client address.
+	Leader     string
+	LeaderAddr string
+	// LastIndex is the last entry in the member's log, CommitIndex the
+	// last it knows to be committed and AppliedIndex the last its database
+	// holds.
+	LastIndex    uint64
+	CommitIndex  uint64
+	AppliedIndex uint64
+	// Members is the size of the cluster, this member included.
+	Members int
+}
+
+// Node is one running member of a cluster. It implements
+// engine.ConsensusLog for its database.
+type Node struct {
+	cfg   Config
+	db    *engine.DB
+	store *storage
+	log   *logger.Logger
+
+	mu          sync.Mutex
+	role        role
+	term        uint64
+	votedFor    string
+	leader      string
+	leaderAddr  string
+	entries     []entry // the log after snapIndex
+	snapIndex   uint64
+	snapTerm    uint64
+	commitIndex uint64
+	persisted   uint64 // last entry in the log file
+	ready       bool   // a leader that has applied its log takes writes
+	diverged    bool   // the database holds changes the log does not
+	deadline    time.Time
+	peers       map[string]*peer
+	changed     chan struct{} // closed when role, term or commitIndex changes
+	closed      bool
+
+	// applyMu serialises changes to the database that come from the log:
+	// applying entries and installing snapshots.
+	applyMu     sync.Mutex
+	applyKick   chan struct{}
+	persistKick chan struct{}
+
+	listener net.Listener
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type peer struct {
+	id     string
+	client *client
+	next   uint64 // next entry to send
+	match  uint64 // last entry known to be in its log
+	// lastAck is when it last answered the leader in the current term.
+	lastAck      time.Time
+	needSnapshot bool
+	kick         chan struct{}
+}
+
+// Start makes db a member of the cluster cfg describes. The node listens on
+// cfg.Addr, rejoins with the term, vote and log it saved in cfg.Dir, and
+// takes part in elections until Close. db must have its WAL enabled and
+// must not use streaming replication; every write it takes from then on
+// goes through the cluster.
+func Start(db *engine.DB, cfg Config) (*Node, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("cluster: member ID is required")
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("cluster: state directory is required")
+	}
+	if _, ok := cfg.Peers[cfg.ID]; ok {
+		return nil, fmt.Errorf("cluster: member %q is listed as its own peer", cfg.ID)
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if cfg.ElectionTimeout <= 0 {
+		cfg.ElectionTimeout = DefaultElectionTimeout
+	}
+	if cfg.ElectionTimeout < 2*cfg.HeartbeatInterval {
+		return nil, errors.New("cluster: election timeout must be at least two heartbeat intervals")
+	}
+	if cfg.MaxLogEntries <= 0 {
+		cfg.MaxLogEntries = DefaultMaxLogEntries
+	}
+
+	store, state, entries, found, err := openStorage(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{
+		cfg:         cfg,
+		db:          db,
+		store:       store,
+		log:         cfg.Logger,
+		term:        state.Term,
+		votedFor:    state.VotedFor,
+		entries:     entries,
+		snapIndex:   state.SnapIndex,
+		snapTerm:    state.SnapTerm,
+		commitIndex: state.Applied,
+		diverged:    !found || !state.Clean,
+		peers:       make(map[string]*peer, len(cfg.Peers)),
+		changed:     make(chan struct{}),
+		applyKick:   make(chan struct{}, 1),
+		persistKick: make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+	}
+	n.persisted = n.lastIndexLocked()
+	db.SetConsensusIndex(state.Applied)
+	for id, addr := range cfg.Peers {
+		n.peers[id] = &peer{id: id, client: newClient(addr, cfg.Token, cfg.TLS, cfg.ElectionTimeout), kick: make(chan struct{}, 1)}
+	}
+	// Until Close marks it clean again, a crash leaves the state unclean.
+	if err := n.saveStateLocked(); err != nil {
+		_ = store.close()
+		return nil, err
+	}
+
+	if err := db.AttachConsensus(n); err != nil {
+		_ = store.close()
+		return nil, fmt.Errorf("cluster: %w", err)
+	}
+	n.listener, err = net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		_ = store.close()
+		return nil, fmt.Errorf("cluster: listen on %s: %w", cfg.Addr, err)
+	}
+	if cfg.TLS != nil {
+		n.listener = tls.NewListener(n.listener, cfg.TLS)
+	}
+	n.resetDeadlineLocked()
+
+	n.wg.Add(4 + len(n.peers))
+	go n.serve()
+	go n.tick()
+	go n.applyLoop()
+	go n.persistLoop()
+	for _, p := range n.peers {
+		go n.replicateLoop(p)
+	}
+	return n, nil
+}
+
+// Addr returns the address the node listens on for other members.
+func (n *Node) Addr() string {
+	return n.listener.Addr().String()
+}
+
+// Close leaves the cluster: the node stops answering other members, and the
+// database takes no more writes. Close the node before the database.
+func (n *Node) Close() error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	n.ready = false
+	n.role = follower
+	n.notifyLocked()
+	n.mu.Unlock()
+
+	close(n.stop)
+	_ = n.listener.Close()
+	for _, p := range n.peers {
+		p.client.close()
+	}
+	n.wg.Wait()
+
+	// Wait out an apply in progress, then record a clean shutdown: the
+	// database holds exactly the entries up to Applied.
+	n.applyMu.Lock()
+	defer n.applyMu.Unlock()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	err := n.store.append(n.entries[n.persisted-n.snapIndex:])
+	if err == nil {
+		err = n.saveStateClean(!n.diverged)
+	}
+	return errors.Join(err, n.store.close())
+}
+
+// Propose implements engine.ConsensusLog.
+func (n *Node) Propose(data []byte) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.role != leader || !n.ready {
+		n.diverged = true
+		return 0, engine.ErrNotLeader
+	}
+	return n.appendLocked(data), nil
+}
+
+// WaitCommitted implements engine.ConsensusLog.
+func (n *Node) WaitCommitted(ctx context.Context, index uint64) error {
+	n.mu.Lock()
+	term := n.termAtLocked(index)
+	for {
+		if n.closed {
+			n.mu.Unlock()
+			return ErrClosed
+		}
+		if n.commitIndex >= index {
+			// An entry is ours if the log still has it at our term; one
+			// compacted away was committed before it was dropped.
+			ok := index <= n.snapIndex || n.termAtLocked(index) == term
+			n.mu.Unlock()
+			if !ok {
+				return ErrLeadershipLost
+			}
+			return nil
+		}
+		if n.role != leader || n.termAtLocked(index) != term {
+			n.mu.Unlock()
+			return ErrLeadershipLost
+		}
+		changed := n.changed
+		n.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		n.mu.Lock()
+	}
+}
+
+// IsLeader implements engine.ConsensusLog.
+func (n *Node) IsLeader() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role == leader && n.ready
+}
+
+// Leader implements engine.ConsensusLog: the client address of the
+// leader, if known.
+func (n *Node) Leader() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.leaderAddr
+}
+
+// Status returns the node's view of the cluster.
+func (n *Node) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return Status{
+		ID:           n.cfg.ID,
+		Role:         n.role.String(),
+		Term:         n.term,
+		Leader:       n.leader,
+		LeaderAddr:   n.leaderAddr,
+		LastIndex:    n.lastIndexLocked(),
+		CommitIndex:  n.commitIndex,
+		AppliedIndex: n.db.ConsensusIndex(),
+		Members:      len(n.peers) + 1,
+	}
+}
+
+// WaitForLeader blocks until the cluster has a leader, and returns its ID.
+func (n *Node) WaitForLeader(ctx context.Context) (string, error) {
+	for {
+		n.mu.Lock()
+		id, closed, changed := n.leader, n.closed, n.changed
+		if n.role == leader && !n.ready {
+			id = "" // still applying its log
+		}
+		n.mu.Unlock()
+		switch {
+		case closed:
+			return "", ErrClosed
+		case id != "":
+			return id, nil
+		}
+		select {
+		case <-changed:
+		case <-time.After(n.cfg.HeartbeatInterval):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+func (n *Node) lastIndexLocked() uint64 {
+	return n.snapIndex + uint64(len(n.entries))
+}
+
+// termAtLocked returns the term of the entry at index, or 0 when the log no
+// longer, or not yet, has it.
+func (n *Node) termAtLocked(index uint64) uint64 {
+	switch {
+	case index == n.snapIndex:
+		return n.snapTerm
+	case index < n.snapIndex || index > n.lastIndexLocked():
+		return 0
+	default:
+		return n.entries[index-n.snapIndex-1].Term
+	}
+}
+
+// appendLocked adds an entry of the current term to a leader's log. The
+// persist loop writes it to disk, and the replicate loops send it out.
+func (n *Node) appendLocked(data []byte) uint64 {
+	index := n.lastIndexLocked() + 1
+	n.entries = append(n.entries, entry{Index: index, Term: n.term, Data: data})
+	kick(n.persistKick)
+	for _, p := range n.peers {
+		kick(p.kick)
+	}
+	return index
+}
+
+// notifyLocked wakes everything waiting on n.changed.
+func (n *Node) notifyLocked() {
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+func kick(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Node) saveStateLocked() error {
+	return n.saveStateClean(false)
+}
+
+func (n *Node) saveStateClean(clean bool) error {
+	return n.store.saveState(persistentState{
+		Term:      n.term,
+		VotedFor:  n.votedFor,
+		SnapIndex: n.snapIndex,
+		SnapTerm:  n.snapTerm,
+		Applied:   n.db.ConsensusIndex(),
+		Clean:     clean,
+	})
+}
+
+func (n *Node) resetDeadlineLocked() {
+	timeout := n.cfg.ElectionTimeout
+	n.deadline = time.Now().Add(timeout + rand.N(timeout)) // #nosec G404 -- election jitter needs no cryptographic randomness
+}
+
+func (n *Node) logf(level logger.Level, format string, args ...interface{}) {
+	if n.log == nil {
+		return
+	}
+	switch level {
+	case logger.ErrorLevel:
+		n.log.Errorf(format, args...)
+	case logger.WarnLevel:
+		n.log.Warnf(format, args...)
+	default:
+		n.log.Infof(format, args...)
+	}
+}
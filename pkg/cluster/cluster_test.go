@@ -0,0 +1,505 @@
+package cluster
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+)
+
+type member struct {
+	id, addr, dir string
+	tls           *tls.Config
+	db            *engine.DB
+	node          *Node
+}
+
+// newMembers reserves an address and a directory for each of n members.
+func newMembers(t *testing.T, n int) []*member {
+	t.Helper()
+	members := make([]*member, n)
+	for i := range members {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		members[i] = &member{id: fmt.Sprintf("n%d", i+1), addr: l.Addr().String(), dir: t.TempDir()}
+		_ = l.Close()
+	}
+	return members
+}
+
+func (m *member) start(t *testing.T, members []*member) {
+	t.Helper()
+	db, err := engine.Open(filepath.Join(m.dir, "cobalt.cb"), &engine.Options{
+		CoreStorage: engine.CoreStorage{CacheSize: 128, WALEnabled: engine.BoolPtr(true)},
+	})
+	if err != nil {
+		t.Fatalf("%s: Open: %v", m.id, err)
+	}
+	peers := make(map[string]string)
+	for _, other := range members {
+		if other != m {
+			peers[other.id] = other.addr
+		}
+	}
+	node, err := Start(db, Config{
+		ID:                m.id,
+		Addr:              m.addr,
+		Peers:             peers,
+		Dir:               filepath.Join(m.dir, "cluster"),
+		ClientAddr:        m.id + ".example:4200",
+		Token:             "secret",
+		TLS:               m.tls,
+		HeartbeatInterval: 20 * time.Millisecond,
+		ElectionTimeout:   200 * time.Millisecond,
+	})
+	if err != nil {
+		db.Close()
+		t.Fatalf("%s: Start: %v", m.id, err)
+	}
+	m.db, m.node = db, node
+	t.Cleanup(func() { m.stop(t) })
+}
+
+func (m *member) stop(t *testing.T) {
+	t.Helper()
+	if m.node == nil {
+		return
+	}
+	if err := m.node.Close(); err != nil {
+		t.Errorf("%s: Close node: %v", m.id, err)
+	}
+	if err := m.db.Close(); err != nil {
+		t.Errorf("%s: Close database: %v", m.id, err)
+	}
+	m.node, m.db = nil, nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitForLeader waits until every running member agrees on one leader that
+// takes writes, and returns it.
+func waitForLeader(t *testing.T, members []*member) *member {
+	t.Helper()
+	var lead *member
+	waitFor(t, "a leader", func() bool {
+		lead = nil
+		for _, m := range members {
+			if m.node != nil && m.node.IsLeader() {
+				lead = m
+			}
+		}
+		if lead == nil {
+			return false
+		}
+		for _, m := range members {
+			if m.node != nil && m.node.Status().Leader != lead.id {
+				return false
+			}
+		}
+		return true
+	})
+	return lead
+}
+
+func count(db *engine.DB, table string) int {
+	rows, err := db.Query(context.Background(), "SELECT COUNT(*) FROM "+table)
+	if err != nil {
+		return -1
+	}
+	defer rows.Close()
+	var n int
+	if !rows.Next() || rows.Scan(&n) != nil {
+		return -1
+	}
+	return n
+}
+
+func exec(t *testing.T, db *engine.DB, sql string) {
+	t.Helper()
+	if _, err := db.Exec(context.Background(), sql); err != nil {
+		t.Fatalf("exec %q: %v", sql, err)
+	}
+}
+
+func TestClusterReplicatesLeaderWrites(t *testing.T) {
+	members := newMembers(t, 3)
+	for _, m := range members {
+		m.start(t, members)
+	}
+	lead := waitForLeader(t, members)
+
+	exec(t, lead.db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	exec(t, lead.db, "CREATE INDEX t_v ON t (v)")
+	tx, err := lead.db.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := tx.Exec(context.Background(), fmt.Sprintf("INSERT INTO t VALUES (%d, 'v%d')", i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	exec(t, lead.db, "UPDATE t SET v = 'x' WHERE id = 2")
+
+	// The write returned, so a majority has it; every member gets it.
+	status := lead.node.Status()
+	if status.CommitIndex < status.AppliedIndex {
+		t.Fatalf("leader acknowledged uncommitted writes: %+v", status)
+	}
+	for _, m := range members {
+		waitFor(t, m.id+" to apply the writes", func() bool {
+			rows, err := m.db.Query(context.Background(), "SELECT id FROM t WHERE v = 'x'")
+			if err != nil {
+				return false
+			}
+			defer rows.Close()
+			return count(m.db, "t") == 3 && rows.Next()
+		})
+	}
+
+	// Followers refuse writes and name the leader.
+	for _, m := range members {
+		if m == lead {
+			continue
+		}
+		_, err := m.db.Exec(context.Background(), "INSERT INTO t VALUES (9, 'no')")
+		if !errors.Is(err, engine.ErrNotLeader) || !errors.Is(err, engine.ErrReadOnly) {
+			t.Fatalf("%s: write on a follower: %v", m.id, err)
+		}
+		if !strings.Contains(err.Error(), lead.id+".example:4200") {
+			t.Fatalf("%s: error does not name the leader: %v", m.id, err)
+		}
+	}
+}
+
+// testTLSConfig returns a config with a fresh self-signed certificate for
+// 127.0.0.1 that also trusts it, so members can both serve and dial.
+func testTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cobaltdb cluster test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		RootCAs:      roots,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func TestClusterOverTLS(t *testing.T) {
+	members := newMembers(t, 3)
+	cfg := testTLSConfig(t)
+	for _, m := range members {
+		m.tls = cfg
+		m.start(t, members)
+	}
+	lead := waitForLeader(t, members)
+	exec(t, lead.db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	exec(t, lead.db, "INSERT INTO t VALUES (1)")
+	for _, m := range members {
+		waitFor(t, m.id+" to apply the write", func() bool { return count(m.db, "t") == 1 })
+	}
+
+	// A member without TLS cannot get anything from the others.
+	plain := newClient(lead.addr, "secret", nil, time.Second)
+	defer plain.close()
+	if err := plain.call(&rpcRequest{Vote: &voteRequest{}}, &rpcResponse{}); err == nil {
+		t.Fatal("plaintext call to a TLS member succeeded")
+	}
+}
+
+func TestClusterFailover(t *testing.T) {
+	members := newMembers(t, 3)
+	for _, m := range members {
+		m.start(t, members)
+	}
+	lead := waitForLeader(t, members)
+	exec(t, lead.db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	exec(t, lead.db, "INSERT INTO t VALUES (1)")
+	oldTerm := lead.node.Status().Term
+
+	lead.stop(t)
+	next := waitForLeader(t, members)
+	if next == lead {
+		t.Fatal("stopped member still leads")
+	}
+	if term := next.node.Status().Term; term <= oldTerm {
+		t.Fatalf("new leader's term %d is not after %d", term, oldTerm)
+	}
+	// The acknowledged write survived, and the new leader takes writes.
+	if n := count(next.db, "t"); n != 1 {
+		t.Fatalf("new leader has %d rows", n)
+	}
+	exec(t, next.db, "INSERT INTO t VALUES (2)")
+
+	// The old leader rejoins as a follower and catches up from the log.
+	lead.start(t, members)
+	waitFor(t, "the old leader to catch up", func() bool { return count(lead.db, "t") == 2 })
+	if lead.node.IsLeader() {
+		t.Fatal("rejoined member leads")
+	}
+	if got := waitForLeader(t, members); got != next {
+		t.Fatalf("leader changed to %s after a member rejoined", got.id)
+	}
+}
+
+func TestLeaderWithoutQuorumStopsTakingWrites(t *testing.T) {
+	members := newMembers(t, 3)
+	for _, m := range members {
+		m.start(t, members)
+	}
+	lead := waitForLeader(t, members)
+	exec(t, lead.db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	for _, m := range members {
+		if m != lead {
+			m.stop(t)
+		}
+	}
+
+	// A write now cannot commit: it fails rather than being acknowledged,
+	// and the leader soon steps down.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := lead.db.Exec(ctx, "INSERT INTO t VALUES (1)"); err == nil {
+		t.Fatal("write without a quorum succeeded")
+	}
+	waitFor(t, "the leader to step down", func() bool { return !lead.node.IsLeader() })
+	if _, err := lead.db.Exec(ctx, "INSERT INTO t VALUES (2)"); !errors.Is(err, engine.ErrNotLeader) {
+		t.Fatalf("write after stepping down: %v", err)
+	}
+
+	// The unconfirmed write may or may not survive the members' return,
+	// but all of them end up agreeing on it.
+	for _, m := range members {
+		if m != lead {
+			m.start(t, members)
+		}
+	}
+	next := waitForLeader(t, members)
+	exec(t, next.db, "INSERT INTO t VALUES (3)")
+	want := count(next.db, "t")
+	if want != 1 && want != 2 {
+		t.Fatalf("leader has %d rows", want)
+	}
+	for _, m := range members {
+		waitFor(t, m.id+" to agree", func() bool { return count(m.db, "t") == want })
+	}
+}
+
+func TestSingleMemberCluster(t *testing.T) {
+	members := newMembers(t, 1)
+	m := members[0]
+	m.start(t, members)
+	waitForLeader(t, members)
+	exec(t, m.db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	exec(t, m.db, "INSERT INTO t VALUES (1)")
+	index := m.node.Status().CommitIndex
+
+	// A restart keeps the term and log, and the database's place in it.
+	m.stop(t)
+	m.start(t, members)
+	waitForLeader(t, members)
+	status := m.node.Status()
+	if status.CommitIndex < index || status.Term < 2 {
+		t.Fatalf("after restart: %+v, committed index was %d", status, index)
+	}
+	exec(t, m.db, "INSERT INTO t VALUES (2)")
+	if n := count(m.db, "t"); n != 2 {
+		t.Fatalf("%d rows", n)
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	db, err := engine.Open(":memory:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dir := t.TempDir()
+	for _, cfg := range []Config{
+		{Dir: dir, Addr: "127.0.0.1:0"},
+		{ID: "a", Addr: "127.0.0.1:0"},
+		{ID: "a", Dir: dir, Addr: "127.0.0.1:0", Peers: map[string]string{"a": "x:1"}},
+		{ID: "a", Dir: dir, Addr: "127.0.0.1:0", HeartbeatInterval: time.Second, ElectionTimeout: time.Second},
+		{ID: "a", Dir: dir, Addr: "127.0.0.1:0"}, // an in-memory database has no WAL
+	} {
+		if node, err := Start(db, cfg); err == nil {
+			node.Close()
+			t.Fatalf("Start(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	run := func(serverToken, clientToken string, client func(net.Conn) error) (serverErr, clientErr error) {
+		srv, cli := net.Pipe()
+		defer srv.Close()
+		defer cli.Close()
+		done := make(chan error, 1)
+		go func() { done <- acceptHandshake(srv, serverToken) }()
+		clientErr = client(cli)
+		if clientErr != nil {
+			cli.Close()
+		}
+		return <-done, clientErr
+	}
+	dial := func(token string) func(net.Conn) error {
+		return func(conn net.Conn) error { return dialHandshake(conn, token) }
+	}
+
+	if serverErr, clientErr := run("secret", "secret", dial("secret")); serverErr != nil || clientErr != nil {
+		t.Fatalf("matching tokens: server %v, client %v", serverErr, clientErr)
+	}
+	if serverErr, clientErr := run("secret", "wrong", dial("wrong")); !errors.Is(serverErr, errBadToken) || !errors.Is(clientErr, errBadToken) {
+		t.Fatalf("wrong token: server %v, client %v", serverErr, clientErr)
+	}
+
+	// A peer that skips the handshake and sends a request straight away is
+	// refused after the fixed-size handshake message; the request is never
+	// decoded.
+	serverErr, _ := run("secret", "", func(conn net.Conn) error {
+		nonce := make([]byte, handshakeNonceSize)
+		if _, err := io.ReadFull(conn, nonce); err != nil {
+			return err
+		}
+		go func() { _ = gob.NewEncoder(conn).Encode(&rpcRequest{Vote: &voteRequest{}}) }()
+		reply := make([]byte, 1+sha256.Size)
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[0] != handshakeBadToken {
+			return fmt.Errorf("status %d, want bad token", reply[0])
+		}
+		return nil
+	})
+	if !errors.Is(serverErr, errBadToken) {
+		t.Fatalf("request without a handshake: server %v", serverErr)
+	}
+}
+
+func TestParsePeers(t *testing.T) {
+	peers, err := ParsePeers(" a=10.0.0.1:4300, b=10.0.0.2:4300 ,")
+	if err != nil || len(peers) != 2 || peers["a"] != "10.0.0.1:4300" || peers["b"] != "10.0.0.2:4300" {
+		t.Fatalf("ParsePeers = %v, %v", peers, err)
+	}
+	for _, bad := range []string{"a", "=x:1", "a=", "a=x:1,a=y:1"} {
+		if _, err := ParsePeers(bad); err == nil {
+			t.Fatalf("ParsePeers(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestStorageRecoversLog(t *testing.T) {
+	dir := t.TempDir()
+	s, state, log, found, err := openStorage(dir)
+	if err != nil || found || len(log) != 0 || state.Term != 0 {
+		t.Fatalf("fresh storage: %v %v %v %v", state, log, found, err)
+	}
+	var entries []entry
+	for i := uint64(1); i <= 5; i++ {
+		entries = append(entries, entry{Index: i, Term: 1 + i/4, Data: []byte(fmt.Sprint(i))})
+	}
+	if err := s.append(entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.truncate(4); err != nil { // drop entry 5
+		t.Fatal(err)
+	}
+	if err := s.saveState(persistentState{Term: 2, VotedFor: "b", SnapIndex: 1, SnapTerm: 1, Applied: 2}); err != nil {
+		t.Fatal(err)
+	}
+	s.close()
+
+	// A torn record at the end is dropped; entry 1 is compacted.
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write(appendRecord(nil, entry{Index: 5, Term: 2, Data: []byte("torn")})[:26])
+	f.Close()
+
+	s, state, log, found, err = openStorage(dir)
+	if err != nil || !found {
+		t.Fatalf("reopen: %v %v", found, err)
+	}
+	if state.Term != 2 || state.VotedFor != "b" || state.Applied != 2 || state.Clean {
+		t.Fatalf("state = %+v", state)
+	}
+	if len(log) != 3 || log[0].Index != 2 || log[2].Index != 4 || string(log[2].Data) != "4" || log[2].Term != 2 {
+		t.Fatalf("log = %+v", log)
+	}
+
+	// Compacting entry 2 away and appending after the rewrite.
+	state.SnapIndex, state.SnapTerm = 2, 1
+	if err := s.saveState(state); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.rewrite(log[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append([]entry{{Index: 5, Term: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	s.close()
+	s, _, log, _, err = openStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+	if len(log) != 3 || log[0].Index != 3 || log[2].Index != 5 || log[2].Term != 3 {
+		t.Fatalf("log after compaction = %+v", log)
+	}
+}
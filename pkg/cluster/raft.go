@@ -0,0 +1,617 @@
+package cluster
+
+import (
+	"slices"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/logger"
+)
+
+// maxAppendEntries bounds the entries sent, or applied, in one batch.
+const maxAppendEntries = 256
+
+type voteRequest struct {
+	Term      uint64
+	Candidate string
+	LastIndex uint64
+	LastTerm  uint64
+}
+
+type voteResponse struct {
+	Term    uint64
+	Granted bool
+}
+
+type appendRequest struct {
+	Term       uint64
+	Leader     string
+	LeaderAddr string // client address
+	PrevIndex  uint64
+	PrevTerm   uint64
+	Entries    []entry
+	Commit     uint64
+}
+
+type appendResponse struct {
+	Term    uint64
+	Success bool
+	// LastIndex is where the follower's log ends, after a failed append,
+	// so the leader can skip back past a gap in one step.
+	LastIndex uint64
+	// NeedSnapshot asks for the leader's database: the follower holds
+	// changes the log does not.
+	NeedSnapshot bool
+}
+
+type snapshotRequest struct {
+	Term       uint64
+	Leader     string
+	LeaderAddr string
+	Index      uint64 // last entry the database holds
+	IndexTerm  uint64
+	Data       []byte
+}
+
+type snapshotResponse struct {
+	Term uint64
+}
+
+// tick drives elections: a follower or candidate that has not heard from a
+// leader by its deadline stands for election, and a leader that has not
+// heard from a majority within an election timeout steps down, so that a
+// partitioned leader stops taking writes it cannot commit.
+func (n *Node) tick() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.cfg.HeartbeatInterval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+		}
+		n.mu.Lock()
+		switch {
+		case n.role == leader:
+			n.checkQuorumLocked()
+		case time.Now().After(n.deadline):
+			n.startElectionLocked()
+		}
+		n.mu.Unlock()
+	}
+}
+
+func (n *Node) quorum() int {
+	return (len(n.peers)+1)/2 + 1
+}
+
+func (n *Node) checkQuorumLocked() {
+	heard := 1
+	cutoff := time.Now().Add(-n.cfg.ElectionTimeout)
+	for _, p := range n.peers {
+		if p.lastAck.After(cutoff) {
+			heard++
+		}
+	}
+	if heard < n.quorum() {
+		n.logf(logger.WarnLevel, "cluster: %s lost contact with a majority in term %d, stepping down", n.cfg.ID, n.term)
+		n.becomeFollowerLocked(n.term)
+	}
+}
+
+func (n *Node) startElectionLocked() {
+	n.role = candidate
+	n.term++
+	n.votedFor = n.cfg.ID
+	n.leader, n.leaderAddr = "", ""
+	n.resetDeadlineLocked()
+	if err := n.saveStateLocked(); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: %v", err)
+		n.role = follower
+		return
+	}
+	n.notifyLocked()
+	n.logf(logger.InfoLevel, "cluster: %s standing for election in term %d", n.cfg.ID, n.term)
+
+	if len(n.peers) == 0 {
+		n.becomeLeaderLocked()
+		return
+	}
+	req := &voteRequest{Term: n.term, Candidate: n.cfg.ID, LastIndex: n.lastIndexLocked(), LastTerm: n.termAtLocked(n.lastIndexLocked())}
+	votes := 1
+	for _, p := range n.peers {
+		go func(p *peer) {
+			var resp voteResponse
+			if err := p.client.call(&rpcRequest{Vote: req}, &rpcResponse{Vote: &resp}); err != nil {
+				return
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if n.closed {
+				return
+			}
+			if resp.Term > n.term {
+				n.becomeFollowerLocked(resp.Term)
+				return
+			}
+			if n.role != candidate || n.term != req.Term || !resp.Granted {
+				return
+			}
+			if votes++; votes == n.quorum() {
+				n.becomeLeaderLocked()
+			}
+		}(p)
+	}
+}
+
+// becomeFollowerLocked moves to term as a follower, forgetting the vote of
+// an earlier term.
+func (n *Node) becomeFollowerLocked(term uint64) {
+	if term > n.term {
+		n.term = term
+		n.votedFor = ""
+		if err := n.saveStateLocked(); err != nil {
+			n.logf(logger.ErrorLevel, "cluster: %v", err)
+		}
+	}
+	if n.role == leader {
+		n.leader, n.leaderAddr = "", ""
+	}
+	n.role = follower
+	n.ready = false
+	n.resetDeadlineLocked()
+	n.notifyLocked()
+}
+
+// becomeLeaderLocked takes over after winning an election. The new leader
+// brings its database up to the end of its log, then appends a no-op so
+// that the entries of earlier terms commit with it, and only then takes
+// writes.
+func (n *Node) becomeLeaderLocked() {
+	n.role = leader
+	n.ready = false
+	n.leader, n.leaderAddr = n.cfg.ID, n.cfg.ClientAddr
+	last := n.lastIndexLocked()
+	now := time.Now()
+	for _, p := range n.peers {
+		p.next, p.match = last+1, 0
+		p.lastAck = now // a fresh leader gets an election timeout to reach them
+		// A leader whose database went astray makes it everyone's.
+		p.needSnapshot = n.diverged
+	}
+	n.notifyLocked()
+	n.logf(logger.InfoLevel, "cluster: %s is leader for term %d", n.cfg.ID, n.term)
+
+	term, diverged := n.term, n.diverged
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.applyMu.Lock()
+		defer n.applyMu.Unlock()
+		n.applyCommitted(term, diverged)
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.role != leader || n.term != term || n.closed {
+			return
+		}
+		if n.diverged {
+			n.diverged = false
+			n.logf(logger.WarnLevel, "cluster: %s leads with a database that may differ from the log; followers will take a snapshot of it", n.cfg.ID)
+		}
+		n.db.SetConsensusIndex(n.appendLocked(nil))
+		n.ready = true
+		n.notifyLocked()
+	}()
+}
+
+// replicateLoop sends a leader's log to one follower: whenever the log
+// grows, and every heartbeat interval regardless.
+func (n *Node) replicateLoop(p *peer) {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+		case <-p.kick:
+		}
+		n.replicateTo(p)
+	}
+}
+
+func (n *Node) replicateTo(p *peer) {
+	n.mu.Lock()
+	if n.role != leader {
+		n.mu.Unlock()
+		return
+	}
+	if p.needSnapshot || p.next <= n.snapIndex {
+		n.mu.Unlock()
+		n.sendSnapshot(p)
+		return
+	}
+	term := n.term
+	req := &appendRequest{
+		Term:       term,
+		Leader:     n.cfg.ID,
+		LeaderAddr: n.cfg.ClientAddr,
+		PrevIndex:  p.next - 1,
+		PrevTerm:   n.termAtLocked(p.next - 1),
+		Commit:     n.commitIndex,
+	}
+	if last := n.lastIndexLocked(); p.next <= last {
+		from := p.next - n.snapIndex - 1
+		to := min(from+maxAppendEntries, uint64(len(n.entries)))
+		req.Entries = slices.Clone(n.entries[from:to])
+	}
+	n.mu.Unlock()
+
+	var resp appendResponse
+	if err := p.client.call(&rpcRequest{Append: req}, &rpcResponse{Append: &resp}); err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if resp.Term > n.term {
+		n.becomeFollowerLocked(resp.Term)
+		return
+	}
+	if n.role != leader || n.term != term {
+		return
+	}
+	p.lastAck = time.Now()
+	switch {
+	case resp.NeedSnapshot:
+		p.needSnapshot = true
+		kick(p.kick)
+	case resp.Success:
+		p.match = max(p.match, req.PrevIndex+uint64(len(req.Entries)))
+		p.next = p.match + 1
+		n.advanceCommitLocked()
+		if p.next <= n.lastIndexLocked() {
+			kick(p.kick)
+		}
+	default:
+		p.next = max(1, min(p.next-1, resp.LastIndex+1))
+		kick(p.kick)
+	}
+}
+
+// advanceCommitLocked commits the last entry a majority holds, if the
+// current term wrote it; earlier terms' entries commit along with it.
+func (n *Node) advanceCommitLocked() {
+	matches := []uint64{n.persisted}
+	for _, p := range n.peers {
+		matches = append(matches, p.match)
+	}
+	slices.Sort(matches)
+	index := matches[len(matches)-n.quorum()]
+	if index > n.commitIndex && n.termAtLocked(index) == n.term {
+		n.commitIndex = index
+		n.notifyLocked()
+		n.compactLocked()
+	}
+}
+
+// sendSnapshot sends the leader's database to a follower that is too far
+// behind for the log, or holds changes the log does not.
+func (n *Node) sendSnapshot(p *peer) {
+	data, index, err := n.db.ConsensusSnapshot()
+	if err != nil {
+		n.logf(logger.ErrorLevel, "cluster: snapshot for %s: %v", p.id, err)
+		return
+	}
+	n.mu.Lock()
+	if n.role != leader {
+		n.mu.Unlock()
+		return
+	}
+	term := n.term
+	req := &snapshotRequest{
+		Term:       term,
+		Leader:     n.cfg.ID,
+		LeaderAddr: n.cfg.ClientAddr,
+		Index:      index,
+		IndexTerm:  n.termAtLocked(index),
+		Data:       data,
+	}
+	n.mu.Unlock()
+
+	var resp snapshotResponse
+	if err := p.client.call(&rpcRequest{Snapshot: req}, &rpcResponse{Snapshot: &resp}); err != nil {
+		n.logf(logger.WarnLevel, "cluster: snapshot for %s: %v", p.id, err)
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if resp.Term > n.term {
+		n.becomeFollowerLocked(resp.Term)
+		return
+	}
+	if n.role != leader || n.term != term {
+		return
+	}
+	n.logf(logger.InfoLevel, "cluster: %s installed a snapshot at entry %d", p.id, index)
+	p.lastAck = time.Now()
+	p.needSnapshot = false
+	p.match = max(p.match, index)
+	p.next = p.match + 1
+	n.advanceCommitLocked()
+	kick(p.kick)
+}
+
+func (n *Node) handleVote(req *voteRequest) *voteResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if req.Term > n.term {
+		n.becomeFollowerLocked(req.Term)
+	}
+	resp := &voteResponse{Term: n.term}
+	if req.Term < n.term || (n.votedFor != "" && n.votedFor != req.Candidate) {
+		return resp
+	}
+	// Only a candidate whose log is at least as complete as ours can hold
+	// every committed entry.
+	last := n.lastIndexLocked()
+	lastTerm := n.termAtLocked(last)
+	if req.LastTerm < lastTerm || (req.LastTerm == lastTerm && req.LastIndex < last) {
+		return resp
+	}
+	n.votedFor = req.Candidate
+	if err := n.saveStateLocked(); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: %v", err)
+		n.votedFor = ""
+		return resp
+	}
+	n.resetDeadlineLocked()
+	resp.Granted = true
+	return resp
+}
+
+// followLocked accepts the sender of a current request as leader. It
+// reports false for a request from an earlier term.
+func (n *Node) followLocked(term uint64, id, addr string) bool {
+	if term < n.term {
+		return false
+	}
+	if term > n.term || n.role != follower {
+		n.becomeFollowerLocked(term)
+	}
+	if n.leader != id {
+		n.leader, n.leaderAddr = id, addr
+		n.notifyLocked()
+	}
+	n.resetDeadlineLocked()
+	return true
+}
+
+func (n *Node) handleAppend(req *appendRequest) *appendResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	resp := &appendResponse{Term: n.term}
+	if !n.followLocked(req.Term, req.Leader, req.LeaderAddr) {
+		return resp
+	}
+	resp.Term = n.term
+	if n.diverged {
+		resp.NeedSnapshot = true
+		return resp
+	}
+
+	last := n.lastIndexLocked()
+	entries := req.Entries
+	switch {
+	case req.PrevIndex > last:
+		resp.LastIndex = last
+		return resp
+	case req.PrevIndex < n.snapIndex:
+		// What we compacted was committed, and so matches the leader.
+		for len(entries) > 0 && entries[0].Index <= n.snapIndex {
+			entries = entries[1:]
+		}
+	case n.termAtLocked(req.PrevIndex) != req.PrevTerm:
+		resp.LastIndex = req.PrevIndex - 1
+		return resp
+	}
+
+	for i, e := range entries {
+		if e.Index <= last {
+			if n.termAtLocked(e.Index) == e.Term {
+				continue
+			}
+			// A conflicting entry is one the leader never committed. If the
+			// database already holds it, only a snapshot can undo it.
+			if e.Index <= n.db.ConsensusIndex() {
+				n.diverged = true
+				resp.NeedSnapshot = true
+				return resp
+			}
+			pos := int(e.Index - n.snapIndex - 1)
+			n.entries = n.entries[:pos]
+			if err := n.store.truncate(pos); err != nil {
+				n.logf(logger.ErrorLevel, "cluster: %v", err)
+				return resp
+			}
+			n.persisted = min(n.persisted, e.Index-1)
+		}
+		n.entries = append(n.entries, entries[i:]...)
+		break
+	}
+	if err := n.persistLocked(); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: %v", err)
+		return resp
+	}
+
+	if commit := min(req.Commit, req.PrevIndex+uint64(len(req.Entries))); commit > n.commitIndex {
+		n.commitIndex = commit
+		n.notifyLocked()
+		kick(n.applyKick)
+	}
+	resp.Success = true
+	return resp
+}
+
+func (n *Node) handleSnapshot(req *snapshotRequest) *snapshotResponse {
+	n.applyMu.Lock()
+	defer n.applyMu.Unlock()
+	n.mu.Lock()
+	resp := &snapshotResponse{Term: n.term}
+	if !n.followLocked(req.Term, req.Leader, req.LeaderAddr) {
+		n.mu.Unlock()
+		return resp
+	}
+	resp.Term = n.term
+	n.mu.Unlock()
+
+	if err := n.db.RestoreConsensusSnapshot(req.Data, req.Index); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: install snapshot at entry %d: %v", req.Index, err)
+		return resp
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// Keep the log after the snapshot if it agrees with the leader there.
+	var keep []entry
+	if n.termAtLocked(req.Index) == req.IndexTerm && req.Index < n.lastIndexLocked() && req.Index >= n.snapIndex {
+		keep = slices.Clone(n.entries[req.Index-n.snapIndex:])
+	}
+	persisted := n.persisted
+	n.entries, n.snapIndex, n.snapTerm = keep, req.Index, req.IndexTerm
+	n.persisted = min(max(persisted, req.Index), n.lastIndexLocked())
+	n.commitIndex = max(n.commitIndex, req.Index)
+	n.diverged = false
+	if err := n.saveStateLocked(); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: %v", err)
+	}
+	if err := n.store.rewrite(n.entries[:n.persisted-n.snapIndex]); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: %v", err)
+	}
+	n.logf(logger.InfoLevel, "cluster: %s installed %s's snapshot at entry %d", n.cfg.ID, req.Leader, req.Index)
+	kick(n.applyKick)
+	return resp
+}
+
+// applyLoop applies committed entries to a follower's database.
+func (n *Node) applyLoop() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-n.applyKick:
+		}
+		n.applyMu.Lock()
+		n.mu.Lock()
+		term, isLeader := n.term, n.role == leader
+		n.mu.Unlock()
+		if !isLeader {
+			n.applyCommitted(term, false)
+		}
+		n.applyMu.Unlock()
+	}
+}
+
+// applyCommitted applies the log to the database, up to the commit index
+// or, for the leader of term, to the end of the log. The caller holds
+// applyMu. An entry that fails to apply leaves the database astray, and
+// applying stops; with bestEffort, for a leader whose database is astray
+// already, the error is logged and applying goes on.
+func (n *Node) applyCommitted(term uint64, bestEffort bool) {
+	for {
+		n.mu.Lock()
+		applied := n.db.ConsensusIndex()
+		upTo := n.commitIndex
+		if n.role == leader && n.term == term {
+			upTo = n.lastIndexLocked()
+		}
+		if (n.diverged && !bestEffort) || n.closed || applied >= upTo || applied < n.snapIndex {
+			n.mu.Unlock()
+			return
+		}
+		from := applied - n.snapIndex
+		to := min(upTo-n.snapIndex, from+maxAppendEntries)
+		batch := n.entries[from:to]
+		n.mu.Unlock()
+
+		for _, e := range batch {
+			var err error
+			if len(e.Data) == 0 {
+				n.db.SetConsensusIndex(e.Index)
+			} else {
+				err = n.db.ApplyConsensusEntry(e.Index, e.Data)
+			}
+			if err != nil {
+				n.logf(logger.ErrorLevel, "cluster: apply entry %d: %v", e.Index, err)
+				if bestEffort {
+					n.db.SetConsensusIndex(e.Index)
+					continue
+				}
+				n.mu.Lock()
+				n.diverged = true
+				n.mu.Unlock()
+				return
+			}
+		}
+
+		n.mu.Lock()
+		if err := n.saveStateLocked(); err != nil {
+			n.logf(logger.ErrorLevel, "cluster: %v", err)
+		}
+		n.compactLocked()
+		n.mu.Unlock()
+	}
+}
+
+// persistLoop writes the entries a leader proposes to its log file,
+// batching those that arrive while a write is in progress.
+func (n *Node) persistLoop() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-n.persistKick:
+		}
+		n.mu.Lock()
+		if err := n.persistLocked(); err != nil {
+			n.logf(logger.ErrorLevel, "cluster: %v", err)
+		} else if n.role == leader {
+			n.advanceCommitLocked()
+		}
+		n.mu.Unlock()
+	}
+}
+
+func (n *Node) persistLocked() error {
+	if err := n.store.append(n.entries[n.persisted-n.snapIndex:]); err != nil {
+		return err
+	}
+	n.persisted = n.lastIndexLocked()
+	return nil
+}
+
+// compactLocked drops entries from the front of the log once it holds more
+// than MaxLogEntries, keeping any that are not both committed and applied.
+func (n *Node) compactLocked() {
+	if len(n.entries) <= 2*n.cfg.MaxLogEntries {
+		return
+	}
+	upTo := min(n.commitIndex, n.db.ConsensusIndex(), n.persisted, n.lastIndexLocked()-uint64(n.cfg.MaxLogEntries))
+	if upTo <= n.snapIndex {
+		return
+	}
+	drop := upTo - n.snapIndex
+	n.snapTerm = n.entries[drop-1].Term
+	n.snapIndex = upTo
+	n.entries = slices.Clone(n.entries[drop:])
+	if err := n.saveStateLocked(); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: %v", err)
+		return
+	}
+	if err := n.store.rewrite(n.entries[:n.persisted-n.snapIndex]); err != nil {
+		n.logf(logger.ErrorLevel, "cluster: %v", err)
+	}
+}
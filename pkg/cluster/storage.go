@@ -0,0 +1,238 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// entry is one log entry. An entry without data is the no-op a new leader
+// appends to commit the entries of earlier terms.
+type entry struct {
+	Index uint64
+	Term  uint64
+	Data  []byte
+}
+
+// persistentState is what a member must remember across restarts besides
+// its log.
+type persistentState struct {
+	Term     uint64 `json:"term"`
+	VotedFor string `json:"voted_for,omitempty"`
+	// SnapIndex and SnapTerm identify the last entry dropped from the log;
+	// the database holds it and everything before.
+	SnapIndex uint64 `json:"snap_index"`
+	SnapTerm  uint64 `json:"snap_term"`
+	// Applied is the last entry applied to the database.
+	Applied uint64 `json:"applied"`
+	// Clean is set by Close. A member that finds it unset may have applied
+	// entries after Applied, or, as leader, written transactions the log
+	// never received, and resynchronises from the leader.
+	Clean bool `json:"clean"`
+}
+
+const (
+	stateFileName = "raft-state.json"
+	logFileName   = "raft.log"
+	filePerm      = 0o600
+
+	// Log records are [Index:8][Term:8][Length:4][CRC32:4][Data].
+	logRecordHeaderSize = 24
+)
+
+// storage keeps a member's state and log in its directory. The log file
+// holds the entries after the state's SnapIndex, in order; a torn record at
+// its end, from a crash mid-append, is dropped on open.
+type storage struct {
+	dir     string
+	file    *os.File
+	offsets []int64 // file offset of each entry in the file
+	size    int64
+}
+
+// openStorage opens dir, creating it on a member's first start, and returns
+// the saved state and log. found reports whether a state had been saved.
+func openStorage(dir string) (s *storage, state persistentState, log []entry, found bool, err error) {
+	dir = filepath.Clean(dir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, state, nil, false, fmt.Errorf("cluster: create %s: %w", dir, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, stateFileName)) // #nosec G304 - the directory is the member's configuration.
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, state, nil, false, fmt.Errorf("cluster: read state: %w", err)
+		}
+		found = true
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, state, nil, false, fmt.Errorf("cluster: read state: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, filePerm) // #nosec G304 - the directory is the member's configuration.
+	if err != nil {
+		return nil, state, nil, false, fmt.Errorf("cluster: open log: %w", err)
+	}
+	s = &storage{dir: dir, file: file}
+	log, err = s.load(state.SnapIndex)
+	if err != nil {
+		_ = file.Close()
+		return nil, state, nil, false, err
+	}
+	return s, state, log, found, nil
+}
+
+func (s *storage) load(snapIndex uint64) ([]entry, error) {
+	var log []entry
+	r := bufio.NewReader(s.file)
+	var header [logRecordHeaderSize]byte
+	var offset int64
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			break // end of log, or a torn header
+		}
+		n := binary.LittleEndian.Uint32(header[16:20])
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(header[20:24]) {
+			break
+		}
+		e := entry{
+			Index: binary.LittleEndian.Uint64(header[0:8]),
+			Term:  binary.LittleEndian.Uint64(header[8:16]),
+			Data:  data,
+		}
+		if e.Index > snapIndex {
+			if e.Index != snapIndex+uint64(len(log))+1 {
+				break
+			}
+			log = append(log, e)
+			s.offsets = append(s.offsets, offset)
+		} // else compacted, but the file was not rewritten before a crash
+		offset += logRecordHeaderSize + int64(n)
+	}
+	if err := s.file.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("cluster: truncate log: %w", err)
+	}
+	s.size = offset
+	return log, nil
+}
+
+// saveState replaces the state file atomically.
+func (s *storage) saveState(state persistentState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, "."+stateFileName+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("cluster: save state: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(s.dir, stateFileName))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("cluster: save state: %w", err)
+	}
+	return syncDir(s.dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304 - the directory is the member's configuration.
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// append writes entries at the end of the log and syncs it.
+func (s *storage) append(entries []entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var buf []byte
+	for _, e := range entries {
+		s.offsets = append(s.offsets, s.size+int64(len(buf)))
+		buf = appendRecord(buf, e)
+	}
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		return fmt.Errorf("cluster: append log: %w", err)
+	}
+	s.size += int64(len(buf))
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("cluster: sync log: %w", err)
+	}
+	return nil
+}
+
+func appendRecord(buf []byte, e entry) []byte {
+	buf = binary.LittleEndian.AppendUint64(buf, e.Index)
+	buf = binary.LittleEndian.AppendUint64(buf, e.Term)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(e.Data))) // #nosec G115 -- a transaction's records fit in 4 GiB
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(e.Data))
+	return append(buf, e.Data...)
+}
+
+// truncate drops the entries from position i of the file on.
+func (s *storage) truncate(i int) error {
+	if i >= len(s.offsets) {
+		return nil
+	}
+	s.size = s.offsets[i]
+	s.offsets = s.offsets[:i]
+	if err := s.file.Truncate(s.size); err != nil {
+		return fmt.Errorf("cluster: truncate log: %w", err)
+	}
+	return s.file.Sync()
+}
+
+// rewrite replaces the log file with entries, after the state has moved
+// SnapIndex past the entries it drops.
+func (s *storage) rewrite(entries []entry) error {
+	path := filepath.Join(s.dir, logFileName)
+	tmp, err := os.CreateTemp(s.dir, "."+logFileName+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("cluster: compact log: %w", err)
+	}
+	var buf []byte
+	offsets := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		offsets = append(offsets, int64(len(buf)))
+		buf = appendRecord(buf, e)
+	}
+	_, err = tmp.Write(buf)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("cluster: compact log: %w", err)
+	}
+	_ = s.file.Close()
+	s.file, s.offsets, s.size = tmp, offsets, int64(len(buf))
+	return syncDir(s.dir)
+}
+
+func (s *storage) close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,314 @@
+package cluster
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/logger"
+)
+
+// Members talk over TCP, or TLS when Config.TLS is set, one gob-encoded
+// request and response at a time per connection. Before any gob data the
+// two ends prove they hold the cluster token with a fixed-size handshake:
+//
+//	server -> client: nonce (32 bytes)
+//	client -> server: nonce (32 bytes), HMAC-SHA256(token, 'c' | both nonces)
+//	server -> client: status (1 byte), HMAC-SHA256(token, 's' | both nonces)
+//
+// so nothing a peer without the token sends is ever decoded. The token is
+// not sent, but without TLS the requests themselves travel in the clear.
+type rpcRequest struct {
+	Vote     *voteRequest
+	Append   *appendRequest
+	Snapshot *snapshotRequest
+}
+
+type rpcResponse struct {
+	Error    string
+	Vote     *voteResponse
+	Append   *appendResponse
+	Snapshot *snapshotResponse
+}
+
+var errBadToken = errors.New("cluster: bad token")
+
+const handshakeNonceSize = 32
+
+// Handshake status bytes the server sends with its MAC.
+const (
+	handshakeOK       byte = 0
+	handshakeBadToken byte = 1
+)
+
+// handshakeMAC is the proof one side sends: role is 'c' for the client
+// and 's' for the server, so neither proof can be replayed as the other.
+func handshakeMAC(token string, role byte, serverNonce, clientNonce []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte{role})
+	mac.Write(serverNonce)
+	mac.Write(clientNonce)
+	return mac.Sum(nil)
+}
+
+// acceptHandshake runs the server side of the handshake on conn.
+func acceptHandshake(conn net.Conn, token string) error {
+	serverNonce := make([]byte, handshakeNonceSize)
+	if _, err := rand.Read(serverNonce); err != nil {
+		return err
+	}
+	if _, err := conn.Write(serverNonce); err != nil {
+		return err
+	}
+	msg := make([]byte, handshakeNonceSize+sha256.Size)
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return err
+	}
+	clientNonce := msg[:handshakeNonceSize]
+	reply := make([]byte, 1, 1+sha256.Size)
+	ok := hmac.Equal(msg[handshakeNonceSize:], handshakeMAC(token, 'c', serverNonce, clientNonce))
+	if !ok {
+		// The client learns why it was refused; the MAC is zeros.
+		reply[0] = handshakeBadToken
+		reply = append(reply, make([]byte, sha256.Size)...)
+	} else {
+		reply[0] = handshakeOK
+		reply = append(reply, handshakeMAC(token, 's', serverNonce, clientNonce)...)
+	}
+	if _, err := conn.Write(reply); err != nil {
+		return err
+	}
+	if !ok {
+		return errBadToken
+	}
+	return nil
+}
+
+// dialHandshake runs the client side of the handshake on conn.
+func dialHandshake(conn net.Conn, token string) error {
+	serverNonce := make([]byte, handshakeNonceSize)
+	if _, err := io.ReadFull(conn, serverNonce); err != nil {
+		return err
+	}
+	clientNonce := make([]byte, handshakeNonceSize)
+	if _, err := rand.Read(clientNonce); err != nil {
+		return err
+	}
+	msg := append(append([]byte(nil), clientNonce...), handshakeMAC(token, 'c', serverNonce, clientNonce)...)
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+	reply := make([]byte, 1+sha256.Size)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != handshakeOK {
+		return errBadToken
+	}
+	if !hmac.Equal(reply[1:], handshakeMAC(token, 's', serverNonce, clientNonce)) {
+		return errors.New("cluster: peer does not hold the cluster token")
+	}
+	return nil
+}
+
+// serve accepts connections from other members until Close.
+func (n *Node) serve() {
+	defer n.wg.Done()
+	var (
+		connMu sync.Mutex
+		conns  = make(map[net.Conn]struct{})
+	)
+	defer func() {
+		connMu.Lock()
+		for conn := range conns {
+			_ = conn.Close()
+		}
+		connMu.Unlock()
+	}()
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			select {
+			case <-n.stop:
+				return
+			default:
+			}
+			n.logf(logger.WarnLevel, "cluster: accept: %v", err)
+			time.Sleep(n.cfg.HeartbeatInterval)
+			continue
+		}
+		connMu.Lock()
+		conns[conn] = struct{}{}
+		connMu.Unlock()
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.serveConn(conn)
+			connMu.Lock()
+			delete(conns, conn)
+			connMu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+func (n *Node) serveConn(conn net.Conn) {
+	_ = conn.SetDeadline(time.Now().Add(n.cfg.ElectionTimeout))
+	if err := acceptHandshake(conn, n.cfg.Token); err != nil {
+		n.logf(logger.WarnLevel, "cluster: refused %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+	dec := gob.NewDecoder(bufio.NewReader(conn))
+	w := bufio.NewWriter(conn)
+	enc := gob.NewEncoder(w)
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		var resp rpcResponse
+		switch {
+		case req.Vote != nil:
+			resp.Vote = n.handleVote(req.Vote)
+		case req.Append != nil:
+			resp.Append = n.handleAppend(req.Append)
+		case req.Snapshot != nil:
+			resp.Snapshot = n.handleSnapshot(req.Snapshot)
+		default:
+			resp.Error = "cluster: empty request"
+		}
+		if err := enc.Encode(&resp); err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+		if resp.Error != "" {
+			return
+		}
+	}
+}
+
+// client is the connection to one peer, dialled on first use and again
+// after any error. Calls take turns.
+type client struct {
+	addr    string
+	token   string
+	tls     *tls.Config
+	timeout time.Duration
+
+	callMu sync.Mutex
+	w      *bufio.Writer
+	enc    *gob.Encoder
+	dec    *gob.Decoder
+
+	mu     sync.Mutex // guards conn and closed, so close need not wait for a call
+	conn   net.Conn
+	closed bool
+}
+
+func newClient(addr, token string, tlsConfig *tls.Config, timeout time.Duration) *client {
+	return &client{addr: addr, token: token, tls: tlsConfig, timeout: timeout}
+}
+
+// snapshotTimeoutPerMiB extends a call's deadline for the data it carries.
+const snapshotTimeoutPerMiB = 100 * time.Millisecond
+
+// call sends req and decodes the answer into resp.
+func (c *client) call(req *rpcRequest, resp *rpcResponse) error {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+	conn, err := c.connect()
+	if err != nil {
+		return err
+	}
+	timeout := c.timeout
+	if req.Snapshot != nil {
+		timeout += time.Duration(len(req.Snapshot.Data)>>20) * snapshotTimeoutPerMiB
+	}
+	err = conn.SetDeadline(time.Now().Add(timeout))
+	if err == nil {
+		err = c.enc.Encode(req)
+	}
+	if err == nil {
+		err = c.w.Flush()
+	}
+	if err == nil {
+		err = c.dec.Decode(resp)
+	}
+	if err == nil && resp.Error != "" {
+		err = fmt.Errorf("%s: %s", c.addr, resp.Error)
+	}
+	if err != nil {
+		c.mu.Lock()
+		if c.conn == conn {
+			c.conn = nil
+		}
+		c.mu.Unlock()
+		_ = conn.Close()
+	}
+	return err
+}
+
+// connect returns the open connection, dialling one if needed. The caller
+// holds callMu.
+func (c *client) connect() (net.Conn, error) {
+	c.mu.Lock()
+	conn, closed := c.conn, c.closed
+	c.mu.Unlock()
+	switch {
+	case closed:
+		return nil, ErrClosed
+	case conn != nil:
+		return conn, nil
+	}
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var err error
+	if c.tls != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.addr, c.tls)
+	} else {
+		conn, err = dialer.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = conn.SetDeadline(time.Now().Add(c.timeout))
+	if err == nil {
+		err = dialHandshake(conn, c.token)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%s: %w", c.addr, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		_ = conn.Close()
+		return nil, ErrClosed
+	}
+	c.conn = conn
+	c.w = bufio.NewWriter(conn)
+	c.enc = gob.NewEncoder(c.w)
+	c.dec = gob.NewDecoder(bufio.NewReader(conn))
+	return conn, nil
+}
+
+func (c *client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+}
@@ -0,0 +1,300 @@
+// Package cobalttest creates throwaway CobaltDB databases for Go tests.
+//
+//	func TestOrders(t *testing.T) {
+//		db := cobalttest.New(t, cobalttest.WithFixture(`
+//			CREATE TABLE orders (id INTEGER PRIMARY KEY, total REAL);
+//			INSERT INTO orders VALUES (1, 9.5), (2, 20);
+//		`))
+//		db.MustExec("DELETE FROM orders WHERE total < 10")
+//		db.AssertRows("SELECT id FROM orders", [][]interface{}{{2}})
+//	}
+//
+// Every database is private to its test and is closed (and, for file-backed
+// databases, removed) by t.Cleanup.
+package cobalttest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/logger"
+)
+
+// DB is a test database. It embeds *engine.DB, so the full engine API is
+// available alongside the Must/Assert helpers, which fail the test instead of
+// returning errors.
+type DB struct {
+	*engine.DB
+	t    testing.TB
+	path string
+}
+
+// Option configures New.
+type Option func(*config)
+
+type config struct {
+	onDisk   bool
+	opts     *engine.Options
+	fixtures []fixture
+}
+
+type fixture struct {
+	sql  string
+	path string
+}
+
+// OnDisk stores the database in a file under t.TempDir() instead of memory,
+// for tests that exercise persistence or reopen the database.
+func OnDisk() Option {
+	return func(c *config) { c.onDisk = true }
+}
+
+// WithOptions opens the database with opts. The storage location is still
+// chosen by New (in-memory unless OnDisk is given).
+func WithOptions(opts *engine.Options) Option {
+	return func(c *config) { c.opts = opts }
+}
+
+// WithFixture runs sql, one or more ';'-separated statements, after the
+// database is opened. Fixtures run in the order they are given.
+func WithFixture(sql string) Option {
+	return func(c *config) { c.fixtures = append(c.fixtures, fixture{sql: sql}) }
+}
+
+// WithFixtureFile runs the statements in the SQL file at path, like
+// WithFixture.
+func WithFixtureFile(path string) Option {
+	return func(c *config) { c.fixtures = append(c.fixtures, fixture{path: path}) }
+}
+
+// New opens an isolated database for t, loads its fixtures and registers a
+// cleanup that closes it. Any failure stops the test.
+func New(t testing.TB, opts ...Option) *DB {
+	t.Helper()
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var engineOpts engine.Options
+	if cfg.opts != nil {
+		engineOpts = *cfg.opts
+	} else {
+		engineOpts = *engine.DefaultOptions()
+		engineOpts.CoreStorage.Logger = nil
+	}
+	if engineOpts.CoreStorage.Logger == nil {
+		// Keep test output quiet: only engine errors are printed.
+		engineOpts.CoreStorage.Logger = logger.New(logger.ErrorLevel, os.Stderr)
+	}
+	path := ":memory:"
+	engineOpts.CoreStorage.InMemory = !cfg.onDisk
+	if cfg.onDisk {
+		path = filepath.Join(t.TempDir(), "test.db")
+	}
+
+	edb, err := engine.Open(path, &engineOpts)
+	if err != nil {
+		t.Fatalf("cobalttest: open database: %v", err)
+	}
+	db := &DB{DB: edb, t: t, path: path}
+	t.Cleanup(func() {
+		if err := edb.Close(); err != nil {
+			t.Errorf("cobalttest: close database: %v", err)
+		}
+	})
+
+	for _, f := range cfg.fixtures {
+		sql := f.sql
+		if f.path != "" {
+			data, err := os.ReadFile(f.path)
+			if err != nil {
+				t.Fatalf("cobalttest: read fixture: %v", err)
+			}
+			sql = string(data)
+		}
+		for _, stmt := range splitStatements(sql) {
+			if _, err := edb.Exec(context.Background(), stmt); err != nil {
+				t.Fatalf("cobalttest: fixture statement %q: %v", stmt, err)
+			}
+		}
+	}
+	return db
+}
+
+// Path returns the database file, or ":memory:" for an in-memory database.
+func (db *DB) Path() string { return db.path }
+
+// MustExec runs a statement and fails the test if it returns an error.
+func (db *DB) MustExec(sql string, args ...interface{}) engine.Result {
+	db.t.Helper()
+	res, err := db.Exec(context.Background(), sql, args...)
+	if err != nil {
+		db.t.Fatalf("cobalttest: %s: %v", sql, err)
+	}
+	return res
+}
+
+// MustQuery runs a query and returns its rows, failing the test on error.
+func (db *DB) MustQuery(sql string, args ...interface{}) [][]interface{} {
+	db.t.Helper()
+	rows, err := db.Query(context.Background(), sql, args...)
+	if err != nil {
+		db.t.Fatalf("cobalttest: %s: %v", sql, err)
+	}
+	defer rows.Close()
+	n := len(rows.Columns())
+	var out [][]interface{}
+	for rows.Next() {
+		vals := make([]interface{}, n)
+		ptrs := make([]interface{}, n)
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			db.t.Fatalf("cobalttest: %s: scan: %v", sql, err)
+		}
+		out = append(out, vals)
+	}
+	return out
+}
+
+// AssertRows fails the test unless the query returns exactly want, in order.
+// Integers and floats compare by value regardless of their Go type, so
+// {{1, "a"}} matches an INTEGER column read back as int64.
+func (db *DB) AssertRows(sql string, want [][]interface{}, args ...interface{}) {
+	db.t.Helper()
+	got := db.MustQuery(sql, args...)
+	if !rowsEqual(got, want) {
+		db.t.Errorf("cobalttest: %s\n got: %s\nwant: %s", sql, formatRows(got), formatRows(want))
+	}
+}
+
+// AssertValue fails the test unless the query returns a single row with a
+// single column equal to want.
+func (db *DB) AssertValue(sql string, want interface{}, args ...interface{}) {
+	db.t.Helper()
+	db.AssertRows(sql, [][]interface{}{{want}}, args...)
+}
+
+// AssertCount fails the test unless table holds n rows.
+func (db *DB) AssertCount(table string, n int64) {
+	db.t.Helper()
+	db.AssertValue("SELECT COUNT(*) FROM "+table, n)
+}
+
+// AssertError fails the test unless the statement fails with an error whose
+// message contains substr (any error when substr is empty).
+func (db *DB) AssertError(substr, sql string, args ...interface{}) {
+	db.t.Helper()
+	_, err := db.Exec(context.Background(), sql, args...)
+	switch {
+	case err == nil:
+		db.t.Errorf("cobalttest: %s: expected an error, got none", sql)
+	case !strings.Contains(err.Error(), substr):
+		db.t.Errorf("cobalttest: %s: error %q does not contain %q", sql, err, substr)
+	}
+}
+
+func rowsEqual(got, want [][]interface{}) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if len(got[i]) != len(want[i]) {
+			return false
+		}
+		for j := range got[i] {
+			if !reflect.DeepEqual(normalize(got[i][j]), normalize(want[i][j])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// normalize maps numbers to int64 or float64 (integral floats to int64) so
+// rows compare by value.
+func normalize(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()) // #nosec G115 - test values
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f == float64(int64(f)) {
+			return int64(f)
+		}
+		return f
+	}
+	return v
+}
+
+func formatRows(rows [][]interface{}) string {
+	if len(rows) == 0 {
+		return "(no rows)"
+	}
+	parts := make([]string, len(rows))
+	for i, row := range rows {
+		vals := make([]string, len(row))
+		for j, v := range row {
+			if s, ok := v.(string); ok {
+				vals[j] = fmt.Sprintf("%q", s)
+			} else {
+				vals[j] = fmt.Sprintf("%v", v)
+			}
+		}
+		parts[i] = "(" + strings.Join(vals, ", ") + ")"
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitStatements splits a fixture script on ';' outside string literals,
+// quoted identifiers and comments.
+func splitStatements(sql string) []string {
+	var stmts []string
+	start := 0
+	flush := func(end int) {
+		if s := strings.TrimSpace(sql[start:end]); s != "" {
+			stmts = append(stmts, s)
+		}
+	}
+	for i := 0; i < len(sql); i++ {
+		switch ch := sql[i]; {
+		case ch == '\'' || ch == '"' || ch == '`':
+			for i++; i < len(sql); i++ {
+				if sql[i] == ch {
+					if i+1 < len(sql) && sql[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+		case ch == ';':
+			flush(i)
+			start = i + 1
+		}
+	}
+	flush(len(sql))
+	return stmts
+}
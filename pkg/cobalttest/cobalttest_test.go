@@ -0,0 +1,90 @@
+package cobalttest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+)
+
+const fixtureSQL = `
+	-- users; orders
+	CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, score REAL);
+	INSERT INTO users VALUES (1, 'ann; the first', 1.5), (2, 'bob', 2);
+	/* a ; in a comment */
+	INSERT INTO users VALUES (3, 'it''s carl', NULL)
+`
+
+func TestNewLoadsFixtures(t *testing.T) {
+	db := New(t, WithFixture(fixtureSQL))
+	if db.Path() != ":memory:" {
+		t.Fatalf("Path() = %q, want :memory:", db.Path())
+	}
+	db.AssertCount("users", 3)
+	db.AssertRows("SELECT id, name, score FROM users ORDER BY id", [][]interface{}{
+		{1, "ann; the first", 1.5},
+		{2, "bob", 2},
+		{3, "it's carl", nil},
+	})
+	db.AssertValue("SELECT name FROM users WHERE id = ?", "bob", 2)
+	db.AssertError("", "INSERT INTO users VALUES (1, 'dup', 0)")
+
+	db.MustExec("DELETE FROM users WHERE id > ?", 1)
+	db.AssertCount("users", 1)
+}
+
+func TestNewIsolatesDatabases(t *testing.T) {
+	a := New(t, WithFixture("CREATE TABLE t (id INTEGER)"))
+	b := New(t)
+	a.MustExec("INSERT INTO t VALUES (1)")
+	if _, err := b.Query(context.Background(), "SELECT * FROM t"); err == nil {
+		t.Fatal("table from one test database is visible in another")
+	}
+}
+
+func TestNewOnDiskWithFixtureFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fixture.sql")
+	if err := os.WriteFile(file, []byte(fixtureSQL), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := engine.DefaultOptions()
+	opts.CoreStorage.CacheSize = 64
+	opts.CoreStorage.Logger = nil
+	db := New(t, OnDisk(), WithOptions(opts), WithFixtureFile(file))
+	if _, err := os.Stat(db.Path()); err != nil {
+		t.Fatalf("database file: %v", err)
+	}
+	db.AssertCount("users", 3)
+}
+
+func TestAssertionsReportMismatches(t *testing.T) {
+	db := New(t, WithFixture(fixtureSQL))
+	ft := &fakeT{TB: t}
+	db.t = ft
+	db.AssertRows("SELECT id FROM users WHERE id = 1", [][]interface{}{{2}})
+	db.AssertCount("users", 4)
+	db.AssertError("", "CREATE TABLE other (id INTEGER)")
+	if ft.errors != 3 {
+		t.Fatalf("got %d reported failures, want 3", ft.errors)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	got := splitStatements("a; 'b;c' ; -- d;\n e /* f; */;; \"g;\"")
+	want := []string{"a", "'b;c'", "-- d;\n e /* f; */", "\"g;\""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitStatements = %q, want %q", got, want)
+	}
+}
+
+// fakeT records Errorf calls instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors int
+}
+
+func (f *fakeT) Helper()                                   {}
+func (f *fakeT) Errorf(format string, args ...interface{}) { f.errors++ }
@@ -0,0 +1,361 @@
+package sqlfuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Column types the generator uses.
+const (
+	typeInteger = "INTEGER"
+	typeReal    = "REAL"
+	typeText    = "TEXT"
+)
+
+// words are the TEXT values. They are lower case so that case-insensitive
+// LIKE and binary collation agree, and they share prefixes so LIKE patterns
+// match some rows but not all.
+var words = []string{"", "a", "ab", "abc", "b", "ba", "bab", "c", "cab", "x"}
+
+// Generator produces a deterministic schema, data set and query stream from
+// a seed.
+type Generator struct {
+	cfg    Config
+	rng    *rand.Rand
+	tables []table
+	setup  []string
+}
+
+type table struct {
+	name string
+	cols []column
+}
+
+type column struct {
+	name, typ string
+}
+
+// colRef is a column as it is written in a query.
+type colRef struct {
+	expr, typ string
+}
+
+// NewGenerator creates the schema and data for cfg. Calls to Query continue
+// from the same random stream, so two generators with the same Config
+// produce the same statements.
+func NewGenerator(cfg Config) *Generator {
+	cfg = cfg.withDefaults()
+	g := &Generator{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))} // #nosec G404 - test data, not security sensitive.
+	for i := 0; i < cfg.Tables; i++ {
+		// c0 is always an INTEGER, so every pair of tables can be joined.
+		t := table{name: fmt.Sprintf("t%d", i), cols: []column{{"id", typeInteger}, {"c0", typeInteger}}}
+		for j, n := 1, 1+g.rng.Intn(3); j <= n; j++ {
+			t.cols = append(t.cols, column{fmt.Sprintf("c%d", j), g.pickType()})
+		}
+		g.tables = append(g.tables, t)
+	}
+	for _, t := range g.tables {
+		defs := make([]string, len(t.cols))
+		for i, c := range t.cols {
+			defs[i] = c.name + " " + c.typ
+		}
+		defs[0] += " PRIMARY KEY"
+		g.setup = append(g.setup, fmt.Sprintf("CREATE TABLE %s (%s)", t.name, strings.Join(defs, ", ")))
+	}
+	for _, t := range g.tables {
+		for id := 1; id <= cfg.Rows; id++ {
+			vals := []string{strconv.Itoa(id)}
+			for _, c := range t.cols[1:] {
+				vals = append(vals, g.value(c.typ, true))
+			}
+			g.setup = append(g.setup, fmt.Sprintf("INSERT INTO %s VALUES (%s)", t.name, strings.Join(vals, ", ")))
+		}
+	}
+	return g
+}
+
+// Setup returns the CREATE TABLE and INSERT statements of the data set.
+func (g *Generator) Setup() []string {
+	return append([]string(nil), g.setup...)
+}
+
+// Indexes returns a CREATE INDEX statement for every non-key column.
+func (g *Generator) Indexes() []string {
+	var out []string
+	for _, t := range g.tables {
+		for _, c := range t.cols[1:] {
+			out = append(out, fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s)", t.name, c.name, t.name, c.name))
+		}
+	}
+	return out
+}
+
+// Query returns the next query of the stream.
+func (g *Generator) Query() string {
+	switch g.rng.Intn(6) {
+	case 0:
+		return g.aggregateQuery()
+	case 1:
+		return g.groupQuery()
+	case 2:
+		return g.joinQuery()
+	case 3:
+		return g.subqueryQuery()
+	case 4:
+		return g.unionQuery()
+	default:
+		return g.simpleQuery()
+	}
+}
+
+func (g *Generator) simpleQuery() string {
+	t := g.table()
+	scope := g.scope(t, "")
+	sel := "SELECT "
+	if g.rng.Intn(4) == 0 {
+		sel += "DISTINCT "
+	}
+	n := 1 + g.rng.Intn(3)
+	exprs := make([]string, n)
+	for i := range exprs {
+		exprs[i] = g.scalar(scope)
+	}
+	return sel + strings.Join(exprs, ", ") + " FROM " + t.name + g.where(scope)
+}
+
+func (g *Generator) aggregateQuery() string {
+	t := g.table()
+	scope := g.scope(t, "")
+	n := 1 + g.rng.Intn(3)
+	aggs := make([]string, n)
+	for i := range aggs {
+		aggs[i] = g.aggregate(scope)
+	}
+	return "SELECT " + strings.Join(aggs, ", ") + " FROM " + t.name + g.where(scope)
+}
+
+func (g *Generator) groupQuery() string {
+	t := g.table()
+	scope := g.scope(t, "")
+	key := g.pick(scope).expr
+	sql := "SELECT " + key + ", COUNT(*), " + g.aggregate(scope) + " FROM " + t.name + g.where(scope) + " GROUP BY " + key
+	if g.rng.Intn(3) == 0 {
+		sql += fmt.Sprintf(" HAVING COUNT(*) > %d", g.rng.Intn(3))
+	}
+	return sql
+}
+
+func (g *Generator) joinQuery() string {
+	ta, tb := g.table(), g.table()
+	scope := append(g.scope(ta, "a"), g.scope(tb, "b")...)
+	join := " JOIN "
+	if g.rng.Intn(2) == 0 {
+		join = " LEFT JOIN "
+	}
+	exprs := []string{g.pick(scope).expr, g.pick(scope).expr}
+	return "SELECT " + strings.Join(exprs, ", ") + " FROM " + ta.name + " a" + join + tb.name + " b ON a.c0 = b.c0" + g.where(scope)
+}
+
+func (g *Generator) subqueryQuery() string {
+	ta, tb := g.table(), g.table()
+	outer := g.scope(ta, "a")
+	inner := g.scope(tb, "b")
+	var cond string
+	if g.rng.Intn(2) == 0 {
+		cond = "a.c0 IN (SELECT b.c0 FROM " + tb.name + " b" + g.where(inner) + ")"
+	} else {
+		cond = "EXISTS (SELECT 1 FROM " + tb.name + " b WHERE b.c0 = a.c0"
+		if g.rng.Intn(2) == 0 {
+			cond += " AND " + g.predicate(inner, 1)
+		}
+		cond += ")"
+	}
+	if g.rng.Intn(3) == 0 {
+		cond = "NOT " + cond
+	}
+	return "SELECT " + g.scalar(outer) + " FROM " + ta.name + " a WHERE " + cond
+}
+
+func (g *Generator) unionQuery() string {
+	part := func() string {
+		t := g.table()
+		scope := g.scope(t, "")
+		return "SELECT c0 FROM " + t.name + g.where(scope)
+	}
+	op := " UNION "
+	if g.rng.Intn(2) == 0 {
+		op = " UNION ALL "
+	}
+	return part() + op + part()
+}
+
+// where returns a WHERE clause over scope, or nothing.
+func (g *Generator) where(scope []colRef) string {
+	if g.rng.Intn(4) == 0 {
+		return ""
+	}
+	return " WHERE " + g.predicate(scope, 2)
+}
+
+// predicate returns a boolean expression over scope nested up to depth
+// levels of AND, OR and NOT.
+func (g *Generator) predicate(scope []colRef, depth int) string {
+	if depth > 0 && g.rng.Intn(3) == 0 {
+		switch g.rng.Intn(3) {
+		case 0:
+			return "(" + g.predicate(scope, depth-1) + " AND " + g.predicate(scope, depth-1) + ")"
+		case 1:
+			return "(" + g.predicate(scope, depth-1) + " OR " + g.predicate(scope, depth-1) + ")"
+		default:
+			return "NOT (" + g.predicate(scope, depth-1) + ")"
+		}
+	}
+	c := g.pick(scope)
+	switch g.rng.Intn(6) {
+	case 0:
+		if g.rng.Intn(2) == 0 {
+			return c.expr + " IS NULL"
+		}
+		return c.expr + " IS NOT NULL"
+	case 1:
+		lo, hi := g.value(c.typ, false), g.value(c.typ, false)
+		return c.expr + " BETWEEN " + lo + " AND " + hi
+	case 2:
+		vals := make([]string, 1+g.rng.Intn(3))
+		for i := range vals {
+			vals[i] = g.value(c.typ, false)
+		}
+		return c.expr + " IN (" + strings.Join(vals, ", ") + ")"
+	case 3:
+		if c.typ == typeText {
+			return c.expr + " LIKE " + g.pattern()
+		}
+	case 4:
+		var same []colRef
+		for _, o := range scope {
+			if o.typ == c.typ && o.expr != c.expr {
+				same = append(same, o)
+			}
+		}
+		if len(same) > 0 {
+			return c.expr + " " + g.comparison() + " " + same[g.rng.Intn(len(same))].expr
+		}
+	}
+	return c.expr + " " + g.comparison() + " " + g.value(c.typ, false)
+}
+
+// scalar returns a select-list expression over scope.
+func (g *Generator) scalar(scope []colRef) string {
+	c := g.pick(scope)
+	if g.rng.Intn(2) == 0 {
+		return c.expr
+	}
+	switch c.typ {
+	case typeInteger:
+		switch g.rng.Intn(4) {
+		case 0:
+			return c.expr + " + " + g.value(typeInteger, false)
+		case 1:
+			return c.expr + " * " + g.value(typeInteger, false)
+		case 2:
+			return "ABS(" + c.expr + ")"
+		default:
+			return "CASE WHEN " + g.predicate(scope, 0) + " THEN 1 ELSE 0 END"
+		}
+	case typeText:
+		if g.rng.Intn(2) == 0 {
+			return "LENGTH(" + c.expr + ")"
+		}
+	}
+	return "COALESCE(" + c.expr + ", " + g.value(c.typ, false) + ")"
+}
+
+// aggregate returns an aggregate call over scope.
+func (g *Generator) aggregate(scope []colRef) string {
+	c := g.pick(scope)
+	numeric := c.typ != typeText
+	switch g.rng.Intn(6) {
+	case 0:
+		return "COUNT(*)"
+	case 1:
+		return "COUNT(" + c.expr + ")"
+	case 2:
+		return "MIN(" + c.expr + ")"
+	case 3:
+		return "MAX(" + c.expr + ")"
+	case 4:
+		if numeric {
+			return "SUM(" + c.expr + ")"
+		}
+	case 5:
+		if numeric {
+			return "AVG(" + c.expr + ")"
+		}
+	}
+	return "COUNT(" + c.expr + ")"
+}
+
+func (g *Generator) comparison() string {
+	return []string{"=", "<>", "<", "<=", ">", ">="}[g.rng.Intn(6)]
+}
+
+// pattern returns a LIKE pattern built from the TEXT vocabulary.
+func (g *Generator) pattern() string {
+	w := words[g.rng.Intn(len(words))]
+	switch g.rng.Intn(4) {
+	case 0:
+		w += "%"
+	case 1:
+		w = "%" + w
+	case 2:
+		w = "%" + w + "%"
+	default:
+		if w != "" {
+			w = "_" + w[1:]
+		}
+	}
+	return "'" + w + "'"
+}
+
+// value returns a literal of type typ, or sometimes NULL when nullable.
+func (g *Generator) value(typ string, nullable bool) string {
+	if nullable && g.rng.Intn(7) == 0 {
+		return "NULL"
+	}
+	switch typ {
+	case typeReal:
+		// Quarters are exact in binary, so sums do not depend on order.
+		return strconv.FormatFloat(float64(g.rng.Intn(41)-10)/4, 'f', 2, 64)
+	case typeText:
+		return "'" + words[g.rng.Intn(len(words))] + "'"
+	default:
+		return strconv.Itoa(g.rng.Intn(21) - 5)
+	}
+}
+
+func (g *Generator) pickType() string {
+	return []string{typeInteger, typeReal, typeText}[g.rng.Intn(3)]
+}
+
+func (g *Generator) table() table {
+	return g.tables[g.rng.Intn(len(g.tables))]
+}
+
+func (g *Generator) pick(scope []colRef) colRef {
+	return scope[g.rng.Intn(len(scope))]
+}
+
+// scope returns the columns of t, qualified by alias when it is set.
+func (g *Generator) scope(t table, alias string) []colRef {
+	refs := make([]colRef, len(t.cols))
+	for i, c := range t.cols {
+		expr := c.name
+		if alias != "" {
+			expr = alias + "." + c.name
+		}
+		refs[i] = colRef{expr, c.typ}
+	}
+	return refs
+}
@@ -0,0 +1,209 @@
+// Package sqlfuzz is a deterministic differential tester for CobaltDB. A
+// Generator builds a random schema, data set and stream of SELECT queries
+// from a seed; Run loads the schema and data into two Targets, runs every
+// query on both and reports each query whose results diverge.
+//
+//	divs, err := sqlfuzz.Run(ctx, sqlfuzz.Config{Seed: 42}, reference, sqlfuzz.EngineTarget(db))
+//
+// Results are compared as multisets of rows, so row order never matters and
+// the generator never emits LIMIT. Integral floats compare equal to integers
+// and other floats are compared to 9 significant digits, which hides the
+// differences in numeric typing between engines (SUM of integers, for one).
+//
+// The queries stay inside the SQL subset whose semantics CobaltDB and SQLite
+// share: no division, comparisons only between values of the same type and
+// only lower-case text, so LIKE and collation agree. The SQLite reference
+// target is built with the sqlfuzz_sqlite build tag; see sqlite.go.
+package sqlfuzz
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Target is a database the generated statements run against.
+type Target interface {
+	// Name identifies the target in divergence reports.
+	Name() string
+	// Exec runs a statement that returns no rows.
+	Exec(ctx context.Context, sql string) error
+	// Query runs a query and returns all of its rows.
+	Query(ctx context.Context, sql string) ([][]interface{}, error)
+}
+
+// Config configures a Generator and Run. Zero fields take the defaults.
+type Config struct {
+	Seed    int64 // Seed for every random choice; the same seed gives the same run
+	Tables  int   // Tables in the schema (default 3)
+	Rows    int   // Rows per table (default 30)
+	Queries int   // Queries to compare (default 200)
+	// Indexes creates secondary indexes on the second target only, so its
+	// index-backed plans are checked against the first target's scans.
+	Indexes bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.Tables <= 0 {
+		c.Tables = 3
+	}
+	if c.Rows <= 0 {
+		c.Rows = 30
+	}
+	if c.Queries <= 0 {
+		c.Queries = 200
+	}
+	return c
+}
+
+// Divergence is a query whose results differ between the two targets.
+type Divergence struct {
+	Seed  int64
+	Index int // 0-based position of the query in the seed's query stream
+	Query string
+	A, B  Outcome
+}
+
+// Outcome is what one target returned for a query.
+type Outcome struct {
+	Target string
+	Rows   []string // Canonical rows, sorted
+	Err    error
+}
+
+func (o Outcome) String() string {
+	if o.Err != nil {
+		return fmt.Sprintf("%s: error: %v", o.Target, o.Err)
+	}
+	return fmt.Sprintf("%s: %d rows %v", o.Target, len(o.Rows), o.Rows)
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("seed %d query %d: %s\n  %s\n  %s", d.Seed, d.Index, d.Query, d.A, d.B)
+}
+
+// Run generates cfg's schema and data, loads them into a and b, and compares
+// cfg.Queries generated queries between them. A query that fails on both
+// targets is not a divergence. The error reports a setup statement that
+// failed on either target.
+func Run(ctx context.Context, cfg Config, a, b Target) ([]Divergence, error) {
+	cfg = cfg.withDefaults()
+	g := NewGenerator(cfg)
+
+	setup := g.Setup()
+	for _, t := range []Target{a, b} {
+		for _, sql := range setup {
+			if err := t.Exec(ctx, sql); err != nil {
+				return nil, fmt.Errorf("%s: setup %q: %w", t.Name(), sql, err)
+			}
+		}
+	}
+	if cfg.Indexes {
+		for _, sql := range g.Indexes() {
+			if err := b.Exec(ctx, sql); err != nil {
+				return nil, fmt.Errorf("%s: setup %q: %w", b.Name(), sql, err)
+			}
+		}
+	}
+
+	var divs []Divergence
+	for i := 0; i < cfg.Queries; i++ {
+		if err := ctx.Err(); err != nil {
+			return divs, err
+		}
+		sql := g.Query()
+		if d, ok := Compare(ctx, sql, a, b); !ok {
+			d.Seed, d.Index = cfg.Seed, i
+			divs = append(divs, d)
+		}
+	}
+	return divs, nil
+}
+
+// Compare runs sql on a and b and reports whether their results agree. When
+// they do not, the Divergence holds both outcomes.
+func Compare(ctx context.Context, sql string, a, b Target) (Divergence, bool) {
+	oa, ob := run(ctx, sql, a), run(ctx, sql, b)
+	d := Divergence{Query: sql, A: oa, B: ob}
+	if oa.Err != nil || ob.Err != nil {
+		return d, oa.Err != nil && ob.Err != nil
+	}
+	if len(oa.Rows) != len(ob.Rows) {
+		return d, false
+	}
+	for i := range oa.Rows {
+		if oa.Rows[i] != ob.Rows[i] {
+			return d, false
+		}
+	}
+	return d, true
+}
+
+func run(ctx context.Context, sql string, t Target) Outcome {
+	rows, err := t.Query(ctx, sql)
+	o := Outcome{Target: t.Name(), Err: err}
+	if err != nil {
+		return o
+	}
+	o.Rows = make([]string, len(rows))
+	for i, row := range rows {
+		o.Rows[i] = canonicalRow(row)
+	}
+	sort.Strings(o.Rows)
+	return o
+}
+
+// canonicalRow renders a row so that rows equal across engines render the
+// same.
+func canonicalRow(row []interface{}) string {
+	parts := make([]string, len(row))
+	for i, v := range row {
+		parts[i] = canonicalValue(v)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func canonicalValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case int:
+		return strconv.Itoa(x)
+	case int32:
+		return strconv.FormatInt(int64(x), 10)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float32:
+		return canonicalFloat(float64(x))
+	case float64:
+		return canonicalFloat(x)
+	case []byte:
+		return strconv.Quote(string(x))
+	case string:
+		return strconv.Quote(x)
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+func canonicalFloat(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	s := strconv.FormatFloat(f, 'g', 9, 64)
+	// Rounding to 9 digits can make a float integral, e.g. 2.9999999999.
+	if r, err := strconv.ParseFloat(s, 64); err == nil && r == math.Trunc(r) {
+		return strconv.FormatInt(int64(r), 10)
+	}
+	return s
+}
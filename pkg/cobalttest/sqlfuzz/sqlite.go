@@ -0,0 +1,32 @@
+//go:build sqlfuzz_sqlite
+
+// The SQLite reference target needs the pure-Go driver, which is not a
+// dependency of the module. To run the differential test against SQLite:
+//
+//	go get modernc.org/sqlite
+//	go test -tags sqlfuzz_sqlite ./pkg/cobalttest/sqlfuzz
+
+package sqlfuzz
+
+import (
+	"database/sql"
+	"io"
+
+	_ "modernc.org/sqlite"
+)
+
+// OpenSQLite opens an in-memory SQLite database as a reference Target. The
+// Closer closes the database.
+func OpenSQLite() (Target, io.Closer, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, nil, err
+	}
+	// Every connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return SQLTarget("sqlite", db), db, nil
+}
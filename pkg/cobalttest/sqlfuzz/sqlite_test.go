@@ -0,0 +1,35 @@
+//go:build sqlfuzz_sqlite
+
+package sqlfuzz
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/cobalttest"
+)
+
+var (
+	flagSeeds   = flag.Int("sqlfuzz.seeds", 20, "number of seeds to compare against SQLite")
+	flagQueries = flag.Int("sqlfuzz.queries", 200, "queries per seed")
+)
+
+func TestDifferentialSQLite(t *testing.T) {
+	ctx := context.Background()
+	for seed := int64(1); seed <= int64(*flagSeeds); seed++ {
+		ref, closer, err := OpenSQLite()
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := EngineTarget(cobalttest.New(t).DB)
+		divs, err := Run(ctx, Config{Seed: seed, Queries: *flagQueries, Indexes: seed%2 == 0}, ref, db)
+		closer.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range divs {
+			t.Error(d)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package sqlfuzz
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+)
+
+// EngineTarget runs statements on an embedded CobaltDB database.
+func EngineTarget(db *engine.DB) Target {
+	return engineTarget{db}
+}
+
+type engineTarget struct {
+	db *engine.DB
+}
+
+func (engineTarget) Name() string { return "cobaltdb" }
+
+func (t engineTarget) Exec(ctx context.Context, sql string) error {
+	_, err := t.db.Exec(ctx, sql)
+	return err
+}
+
+func (t engineTarget) Query(ctx context.Context, sql string) ([][]interface{}, error) {
+	rows, err := t.db.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out [][]interface{}
+	n := len(rows.Columns())
+	for rows.Next() {
+		row := make([]interface{}, n)
+		dest := make([]interface{}, n)
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// SQLTarget runs statements through a database/sql handle, such as a
+// reference database or CobaltDB behind its driver. name labels the target
+// in divergence reports.
+func SQLTarget(name string, db *sql.DB) Target {
+	return sqlTarget{name, db}
+}
+
+type sqlTarget struct {
+	name string
+	db   *sql.DB
+}
+
+func (t sqlTarget) Name() string { return t.name }
+
+func (t sqlTarget) Exec(ctx context.Context, sql string) error {
+	_, err := t.db.ExecContext(ctx, sql)
+	return err
+}
+
+func (t sqlTarget) Query(ctx context.Context, sql string) ([][]interface{}, error) {
+	rows, err := t.db.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var out [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+const typedTable = "CREATE TABLE typed (id INTEGER PRIMARY KEY, n INTEGER, r REAL, s TEXT, b BOOLEAN)"
+
+func TestTypeAffinityConvertsCompatibleValues(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		db := openTestDB(t, &Options{Security: Security{StrictTypes: strict}}, typedTable)
+		ctx := context.Background()
+		mustExec(t, db, "INSERT INTO typed VALUES ('1', ' 42 ', '2.5', 7, 'yes')")
+
+		var n int64
+		var r float64
+		var s string
+		var b bool
+		row := db.QueryRow(ctx, "SELECT n, r, s, b FROM typed WHERE id = 1")
+		if err := row.Scan(&n, &r, &s, &b); err != nil {
+			t.Fatalf("strict=%v: scan: %v", strict, err)
+		}
+		if n != 42 || r != 2.5 || s != "7" || !b {
+			t.Fatalf("strict=%v: got (%d, %v, %q, %v), want (42, 2.5, \"7\", true)", strict, n, r, s, b)
+		}
+
+		mustExec(t, db, "UPDATE typed SET n = '8', b = 0 WHERE id = 1")
+		if err := db.QueryRow(ctx, "SELECT n, b FROM typed WHERE id = 1").Scan(&n, &b); err != nil {
+			t.Fatalf("strict=%v: scan after update: %v", strict, err)
+		}
+		if n != 8 || b {
+			t.Fatalf("strict=%v: after update got (%d, %v), want (8, false)", strict, n, b)
+		}
+	}
+}
+
+func TestTypeAffinityStrictRejectsMismatches(t *testing.T) {
+	db := openTestDB(t, &Options{Security: Security{StrictTypes: true}}, typedTable)
+	ctx := context.Background()
+	mustExec(t, db, "INSERT INTO typed VALUES (1, 1, 1.0, 'x', true)")
+
+	for _, sql := range []string{
+		"INSERT INTO typed (id, n) VALUES (2, 'abc')",
+		"INSERT INTO typed (id, n) VALUES (2, 1.5)",
+		"INSERT INTO typed (id, r) VALUES (2, 'ten')",
+		"INSERT INTO typed (id, b) VALUES (2, 5)",
+		"UPDATE typed SET n = 'abc' WHERE id = 1",
+	} {
+		if _, err := db.Exec(ctx, sql); !errors.Is(err, catalog.ErrTypeMismatch) {
+			t.Errorf("%s: err = %v, want ErrTypeMismatch", sql, err)
+		}
+	}
+
+	var n int64
+	if err := db.QueryRow(ctx, "SELECT n FROM typed WHERE id = 1").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("row changed by rejected update: n = %d, %v", n, err)
+	}
+}
+
+func TestTypeAffinityLooseStoresMismatchesUnchanged(t *testing.T) {
+	db := openTestDB(t, &Options{Security: Security{StrictTypes: false}}, typedTable)
+	ctx := context.Background()
+	mustExec(t, db, "INSERT INTO typed (id, n) VALUES (1, 'abc')")
+
+	var s string
+	if err := db.QueryRow(ctx, "SELECT n FROM typed WHERE id = 1").Scan(&s); err != nil || s != "abc" {
+		t.Fatalf("loose mismatch = %q, %v; want \"abc\"", s, err)
+	}
+}
+
+func TestTypeAffinityJSON(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		db := openTestDB(t, &Options{Security: Security{StrictTypes: strict}}, typedTable)
+		ctx := context.Background()
+		mustExec(t, db, "CREATE TABLE docs (id INTEGER PRIMARY KEY, doc JSON)")
+		mustExec(t, db, `INSERT INTO docs VALUES (1, '{ "a": [1, 2],  "b": {"c": "x y"} }'), (2, 7)`)
+
+		// Documents are stored minified; strings inside keep their spaces.
+		got := queryStrings(t, db, "SELECT doc, JSON_EXTRACT(doc, '$.b.c'), JSON_TYPE(doc, '$.a') FROM docs WHERE id = 1")
+		if len(got) != 1 || got[0] != `{"a":[1,2],"b":{"c":"x y"}}|x y|array` {
+			t.Fatalf("strict=%v: got %v", strict, got)
+		}
+		if got := queryStrings(t, db, `SELECT id FROM docs WHERE doc = '{"a":[1,2],"b":{"c":"x y"}}'`); len(got) != 1 {
+			t.Fatalf("strict=%v: minified lookup = %v", strict, got)
+		}
+
+		_, err := db.Exec(ctx, "UPDATE docs SET doc = '{broken' WHERE id = 2")
+		if strict {
+			if !errors.Is(err, catalog.ErrTypeMismatch) {
+				t.Fatalf("invalid JSON in strict mode: err = %v, want ErrTypeMismatch", err)
+			}
+			continue
+		}
+		// Loose mode keeps text that is not JSON, and JSON functions still
+		// report it as invalid.
+		if err != nil {
+			t.Fatalf("invalid JSON in loose mode: %v", err)
+		}
+		if got := queryStrings(t, db, "SELECT doc, JSON_VALID(doc) FROM docs WHERE id = 2"); got[0] != "{broken|false" {
+			t.Fatalf("loose invalid JSON = %v", got)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendTableSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "append.db")
+	db, err := Open(path, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT, payload TEXT) USING append")
+	mustExec(t, db, "CREATE INDEX idx_events_kind ON events (kind)")
+	for i := 0; i < 200; i++ {
+		if _, err := db.Exec(ctx, fmt.Sprintf("INSERT INTO events VALUES (%d, 'k%d', 'p%d')", i, i%4, i)); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	mustExec(t, db, "UPDATE events SET payload = 'changed' WHERE id < 10")
+	mustExec(t, db, "DELETE FROM events WHERE id >= 150")
+	mustExec(t, db, "VACUUM")
+
+	schema, err := db.TableSchema("events")
+	if err != nil {
+		t.Fatalf("TableSchema: %v", err)
+	}
+	if !strings.HasSuffix(schema, ") USING append;") {
+		t.Fatalf("schema does not keep the storage engine:\n%s", schema)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := Open(path, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	assertScalar(t, reopened, "SELECT COUNT(*) FROM events", int64(150))
+	assertScalar(t, reopened, "SELECT COUNT(*) FROM events WHERE payload = 'changed'", int64(10))
+	assertScalar(t, reopened, "SELECT COUNT(*) FROM events WHERE kind = 'k1'", int64(38))
+	assertScalar(t, reopened, "SELECT MAX(id) FROM events", int64(149))
+
+	// The rebuilt schema must create an append table again.
+	mustExec(t, reopened, strings.Replace(schema, "events", "events_copy", 1))
+	mustExec(t, reopened, "INSERT INTO events_copy SELECT * FROM events")
+	assertScalar(t, reopened, "SELECT COUNT(*) FROM events_copy", int64(150))
+}
+
+func TestCreateTableRejectsUnknownEngine(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(context.Background(), "CREATE TABLE t (id INTEGER PRIMARY KEY) USING columnar"); err == nil ||
+		!strings.Contains(err.Error(), "unknown storage engine") {
+		t.Fatalf("CREATE TABLE USING columnar = %v, want unknown storage engine error", err)
+	}
+	mustExec(t, db, "CREATE TABLE b (id INTEGER PRIMARY KEY) USING btree")
+	schema, err := db.TableSchema("b")
+	if err != nil {
+		t.Fatalf("TableSchema: %v", err)
+	}
+	if strings.Contains(schema, "USING") {
+		t.Fatalf("B+Tree table schema names an engine:\n%s", schema)
+	}
+}
@@ -0,0 +1,304 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// attachedDB is a database attached with ATTACH DATABASE.
+type attachedDB struct {
+	name string // schema name as given
+	path string // absolute file path, or ":memory:"
+	db   *DB
+}
+
+// Attach opens the database file at path, creating it if needed, and makes
+// its tables available as name.table, as ATTACH DATABASE does. The path
+// ":memory:" attaches a new in-memory database.
+//
+// Queries read attached tables like any other and can join them with the
+// main database's. INSERT, UPDATE, DELETE and DDL naming name.table run on
+// the attached database; their own subqueries see only its tables. Inside a
+// transaction an attached database joins it on its first write and commits
+// or rolls back with it. Each database commits atomically on its own, the
+// attached ones first, so a crash during COMMIT can leave an attached
+// database committed and the main one not.
+//
+// The attached database uses this one's storage and encryption settings.
+func (db *DB) Attach(path, name string) error {
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+	if db.options.Security.DisableAttach {
+		return errors.New("ATTACH is disabled")
+	}
+	if db.catalog.IsTransactionActive() {
+		return errors.New("cannot ATTACH database within transaction")
+	}
+	if path != ":memory:" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("attach %s: %w", name, err)
+		}
+		path = abs
+	}
+
+	db.attachMu.Lock()
+	defer db.attachMu.Unlock()
+	key := strings.ToLower(name)
+	if _, exists := db.attached[key]; exists {
+		return fmt.Errorf("database %s is already in use", name)
+	}
+	if path != ":memory:" {
+		if mainPath, err := filepath.Abs(db.path); err == nil && mainPath == path {
+			return fmt.Errorf("database %s is already open", path)
+		}
+		for _, a := range db.attached {
+			if a.path == path {
+				return fmt.Errorf("database %s is already attached as %s", path, a.name)
+			}
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.CoreStorage = db.options.CoreStorage
+	opts.CoreStorage.InMemory = path == ":memory:"
+	opts.Security.EncryptionKey = db.options.Security.EncryptionKey
+	opts.Security.EncryptionConfig = db.options.Security.EncryptionConfig
+	opts.Security.DisableAttach = true
+	other, err := Open(path, opts)
+	if err != nil {
+		return fmt.Errorf("attach %s: %w", name, err)
+	}
+	if err := db.catalog.Attach(name, other.catalog); err != nil {
+		_ = other.Close()
+		return err
+	}
+	if db.attached == nil {
+		db.attached = make(map[string]*attachedDB)
+	}
+	db.attached[key] = &attachedDB{name: name, path: path, db: other}
+	return nil
+}
+
+// Detach closes a database added with Attach.
+func (db *DB) Detach(name string) error {
+	db.attachMu.Lock()
+	defer db.attachMu.Unlock()
+	key := strings.ToLower(name)
+	a, ok := db.attached[key]
+	if !ok {
+		return fmt.Errorf("no such database: %s", name)
+	}
+	if a.db.catalog.IsTransactionActive() {
+		return fmt.Errorf("database %s is locked by an open transaction", name)
+	}
+	if err := db.catalog.Detach(name); err != nil {
+		return err
+	}
+	delete(db.attached, key)
+	return a.db.Close()
+}
+
+// AttachedDatabases returns the schema names of the attached databases.
+func (db *DB) AttachedDatabases() []string {
+	db.attachMu.RLock()
+	defer db.attachMu.RUnlock()
+	names := make([]string, 0, len(db.attached))
+	for _, a := range db.attached {
+		names = append(names, a.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// attachedDBs returns the attached databases.
+func (db *DB) attachedDBs() []*DB {
+	db.attachMu.RLock()
+	defer db.attachMu.RUnlock()
+	dbs := make([]*DB, 0, len(db.attached))
+	for _, a := range db.attached {
+		dbs = append(dbs, a.db)
+	}
+	return dbs
+}
+
+// detachAll closes every attached database, for Close.
+func (db *DB) detachAll() error {
+	db.attachMu.Lock()
+	defer db.attachMu.Unlock()
+	var errs []error
+	for key, a := range db.attached {
+		_ = db.catalog.Detach(a.name)
+		if err := a.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close attached database %s: %w", a.name, err))
+		}
+		delete(db.attached, key)
+	}
+	return errors.Join(errs...)
+}
+
+// executeAttachment runs ATTACH and DETACH, and writes that name a table as
+// schema.table where schema is an attached database. It reports false for
+// any other statement, which the caller runs as usual.
+func (db *DB) executeAttachment(ctx context.Context, stmt query.Statement, args []interface{}) (Result, bool, error) {
+	switch s := stmt.(type) {
+	case *query.AttachStmt:
+		return Result{}, true, db.Attach(s.Path, s.Name)
+	case *query.DetachStmt:
+		return Result{}, true, db.Detach(s.Name)
+	}
+
+	schema, local := unqualifyWrite(stmt)
+	if schema == "" || db.catalog.HasSchema(schema) {
+		return Result{}, false, nil
+	}
+	db.attachMu.RLock()
+	a, ok := db.attached[strings.ToLower(schema)]
+	db.attachMu.RUnlock()
+	if !ok {
+		return Result{}, true, fmt.Errorf("no such schema or database: %s", schema)
+	}
+
+	// INSERT ... SELECT reads through this database, so it can copy rows
+	// from the main database or another attached one.
+	if ins, ok := local.(*query.InsertStmt); ok && insertQuery(ins) != nil {
+		rows, err := db.query(ctx, insertQuery(ins), args)
+		if err != nil {
+			return Result{}, true, err
+		}
+		defer rows.Close()
+		if len(rows.rows) == 0 {
+			return Result{}, true, nil
+		}
+		local, args = insertValues(ins, rows.rows, nil)
+	}
+
+	if db.catalog.IsTransactionActive() && !a.db.catalog.IsTransactionActive() {
+		if _, err := a.db.execute(ctx, &query.BeginStmt{}, nil); err != nil {
+			return Result{}, true, fmt.Errorf("begin transaction on %s: %w", a.name, err)
+		}
+	}
+	result, err := a.db.execute(ctx, local, args)
+	return result, true, err
+}
+
+// unqualifyWrite returns the schema of the table a write names and, if it
+// has one, the statement with the schema removed.
+func unqualifyWrite(stmt query.Statement) (string, query.Statement) {
+	var name string
+	switch s := stmt.(type) {
+	case *query.InsertStmt:
+		name = s.Table
+	case *query.UpdateStmt:
+		name = s.Table
+	case *query.DeleteStmt:
+		name = s.Table
+	case *query.CreateTableStmt:
+		name = s.Table
+	case *query.DropTableStmt:
+		name = s.Table
+	case *query.CreateIndexStmt:
+		name = s.Table
+	}
+	schema, table := query.SplitTableName(name)
+	if schema == "" {
+		return "", stmt
+	}
+
+	switch s := stmt.(type) {
+	case *query.InsertStmt:
+		local := *s
+		local.Table = table
+		return schema, &local
+	case *query.UpdateStmt:
+		local := *s
+		local.Table = table
+		if local.Alias == table {
+			local.Alias = ""
+		}
+		return schema, &local
+	case *query.DeleteStmt:
+		local := *s
+		local.Table = table
+		return schema, &local
+	case *query.CreateTableStmt:
+		local := *s
+		local.Table = table
+		return schema, &local
+	case *query.DropTableStmt:
+		local := *s
+		local.Table = table
+		return schema, &local
+	default: // *query.CreateIndexStmt
+		local := *stmt.(*query.CreateIndexStmt)
+		local.Table = table
+		return schema, &local
+	}
+}
+
+// insertQuery returns the query an INSERT ... SELECT reads its rows from,
+// or nil for INSERT ... VALUES.
+func insertQuery(ins *query.InsertStmt) query.Statement {
+	if ins.Query != nil {
+		return ins.Query
+	}
+	if ins.Select != nil {
+		return ins.Select
+	}
+	return nil
+}
+
+// insertValues turns INSERT ... SELECT into INSERT ... VALUES with the
+// selected rows bound as arguments, numbered after args.
+func insertValues(ins *query.InsertStmt, rows [][]interface{}, args []interface{}) (*query.InsertStmt, []interface{}) {
+	local := *ins
+	local.Select = nil
+	local.Query = nil
+	local.Values = make([][]query.Expression, 0, len(rows))
+	args = args[:len(args):len(args)]
+	for _, row := range rows {
+		values := make([]query.Expression, len(row))
+		for i, v := range row {
+			values[i] = &query.PlaceholderExpr{Index: len(args)}
+			args = append(args, cloneScannedValue(v))
+		}
+		local.Values = append(local.Values, values)
+	}
+	return &local, args
+}
+
+// commitAttached commits the transactions attached databases joined on this
+// goroutine. If one fails, the rest are rolled back.
+func (db *DB) commitAttached(ctx context.Context) error {
+	var err error
+	for _, other := range db.attachedDBs() {
+		if !other.catalog.IsTransactionActive() {
+			continue
+		}
+		if err != nil {
+			_, _ = other.execute(ctx, &query.RollbackStmt{}, nil)
+			continue
+		}
+		if _, cerr := other.execute(ctx, &query.CommitStmt{}, nil); cerr != nil {
+			err = fmt.Errorf("commit attached database: %w", cerr)
+		}
+	}
+	return err
+}
+
+// rollbackAttached rolls back the transactions attached databases joined on
+// this goroutine.
+func (db *DB) rollbackAttached(ctx context.Context) {
+	for _, other := range db.attachedDBs() {
+		if other.catalog.IsTransactionActive() {
+			_, _ = other.execute(ctx, &query.RollbackStmt{}, nil)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttachDatabase(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	db, err := Open(filepath.Join(dir, "main.cb"), nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "INSERT INTO users VALUES (1, 'ada'), (2, 'bob')")
+
+	otherPath := filepath.Join(dir, "other.cb")
+	mustExec(t, db, fmt.Sprintf("ATTACH DATABASE '%s' AS aux", otherPath))
+	if got := db.AttachedDatabases(); len(got) != 1 || got[0] != "aux" {
+		t.Fatalf("AttachedDatabases = %v", got)
+	}
+	mustExec(t, db, "CREATE TABLE aux.orders (id INTEGER PRIMARY KEY, user_id INTEGER, total INTEGER)")
+	mustExec(t, db, "INSERT INTO aux.orders VALUES (10, 1, 5), (11, 1, 7), (12, 2, 3)")
+	mustExec(t, db, "UPDATE aux.orders SET total = total + 1 WHERE id = 12")
+	mustExec(t, db, "DELETE FROM aux.orders WHERE id = 11")
+
+	// The table lives in the attached file, not the main one.
+	if _, err := db.Query(ctx, "SELECT * FROM orders"); err == nil {
+		t.Error("aux.orders is visible unqualified in the main database")
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM aux.orders", int64(2))
+
+	rows := queryRows(t, db, "SELECT u.name, o.total FROM users u JOIN aux.orders o ON o.user_id = u.id ORDER BY o.id")
+	if len(rows) != 2 || rows[0][0] != "ada" || rows[0][1] != int64(5) || rows[1][0] != "bob" || rows[1][1] != int64(4) {
+		t.Fatalf("join rows = %v", rows)
+	}
+	assertScalar(t, db, "SELECT orders.total FROM aux.orders WHERE aux.orders.id = 10", int64(5))
+	assertScalar(t, db, "SELECT COUNT(*) FROM main.users", int64(2))
+
+	// INSERT ... SELECT copies between databases in both directions.
+	mustExec(t, db, "CREATE TABLE aux.names (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "INSERT INTO aux.names SELECT id, name FROM users")
+	assertScalar(t, db, "SELECT COUNT(*) FROM aux.names", int64(2))
+	mustExec(t, db, "CREATE TABLE totals (id INTEGER PRIMARY KEY, total INTEGER)")
+	mustExec(t, db, "INSERT INTO totals SELECT id, total FROM aux.orders")
+	assertScalar(t, db, "SELECT SUM(total) FROM totals", float64(9))
+
+	// A transaction spans both databases.
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "INSERT INTO users VALUES (3, 'cy')")
+	mustExec(t, db, "INSERT INTO aux.orders VALUES (13, 3, 9)")
+	assertScalar(t, db, "SELECT COUNT(*) FROM aux.orders", int64(3))
+	mustExec(t, db, "ROLLBACK")
+	assertScalar(t, db, "SELECT COUNT(*) FROM aux.orders", int64(2))
+	assertScalar(t, db, "SELECT COUNT(*) FROM users", int64(2))
+
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "INSERT INTO users VALUES (3, 'cy')")
+	mustExec(t, db, "INSERT INTO aux.orders VALUES (13, 3, 9)")
+	if _, err := db.Exec(ctx, "DETACH aux"); err == nil {
+		t.Error("DETACH succeeded inside a transaction that wrote to it")
+	}
+	mustExec(t, db, "COMMIT")
+	assertScalar(t, db, "SELECT COUNT(*) FROM aux.orders", int64(3))
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM aux.orders WHERE id = 13"); err != nil {
+		t.Fatalf("tx delete: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("tx commit: %v", err)
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM aux.orders", int64(2))
+
+	// Errors: unknown schema, reattaching, attaching the main file.
+	if _, err := db.Exec(ctx, "INSERT INTO nope.t VALUES (1)"); err == nil {
+		t.Error("insert into an unknown schema succeeded")
+	}
+	if _, err := db.Exec(ctx, fmt.Sprintf("ATTACH '%s' AS aux2", otherPath)); err == nil {
+		t.Error("attached the same file twice")
+	}
+	if _, err := db.Exec(ctx, fmt.Sprintf("ATTACH '%s' AS self", filepath.Join(dir, "main.cb"))); err == nil {
+		t.Error("attached the main database to itself")
+	}
+	if _, err := db.Exec(ctx, "ATTACH ':memory:' AS main"); err == nil {
+		t.Error("attached a database as main")
+	}
+
+	// The attached file keeps its data after DETACH.
+	mustExec(t, db, "DETACH DATABASE aux")
+	if _, err := db.Query(ctx, "SELECT * FROM aux.orders"); err == nil {
+		t.Error("aux.orders readable after DETACH")
+	}
+	other, err := Open(otherPath, nil)
+	if err != nil {
+		t.Fatalf("open attached file: %v", err)
+	}
+	defer other.Close()
+	assertScalar(t, other, "SELECT SUM(total) FROM orders", float64(9))
+}
+
+func TestAttachDisabled(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true, Security: Security{DisableAttach: true}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(context.Background(), "ATTACH ':memory:' AS aux"); err == nil {
+		t.Fatal("ATTACH succeeded with DisableAttach")
+	}
+}
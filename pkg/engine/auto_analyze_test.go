@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestThresholdAnalyzeJobAnalyzesWrittenTables(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE busy (id INTEGER PRIMARY KEY, v INTEGER)")
+	mustExec(t, db, "CREATE TABLE idle (id INTEGER PRIMARY KEY, v INTEGER)")
+	for i := 1; i <= 20; i++ {
+		mustExec(t, db, fmt.Sprintf("INSERT INTO busy VALUES (%d, %d)", i, i))
+	}
+
+	// A written table never analyzed qualifies at once; an unwritten one never.
+	if err := db.runThresholdAnalyzeJob(0.5); err != nil {
+		t.Fatalf("runThresholdAnalyzeJob: %v", err)
+	}
+	stats, err := db.catalog.GetTableStats("busy")
+	if err != nil || stats.RowCount != 20 {
+		t.Fatalf("busy stats = %+v, %v; want 20 rows", stats, err)
+	}
+	if _, err := db.catalog.GetTableStats("idle"); err == nil {
+		t.Fatal("idle table was analyzed")
+	}
+	if n := db.catalog.ModifiedRowsSinceAnalyze("busy"); n != 0 {
+		t.Fatalf("modified rows after ANALYZE = %d, want 0", n)
+	}
+
+	// 9 of 20 rows is under half; the tenth reaches it.
+	mustExec(t, db, "UPDATE busy SET v = v + 1 WHERE id <= 5")
+	mustExec(t, db, "DELETE FROM busy WHERE id > 16")
+	if got := db.catalog.ListTablesNeedingAnalyze(0.5); len(got) != 0 {
+		t.Fatalf("tables needing analyze = %v, want none", got)
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO busy VALUES (100, 0)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if got := db.catalog.ListTablesNeedingAnalyze(0.5); len(got) != 1 || got[0] != "busy" {
+		t.Fatalf("tables needing analyze = %v, want [busy]", got)
+	}
+	if err := db.runThresholdAnalyzeJob(0.5); err != nil {
+		t.Fatalf("runThresholdAnalyzeJob: %v", err)
+	}
+	again, err := db.catalog.GetTableStats("busy")
+	if err != nil || !again.LastAnalyzed.After(stats.LastAnalyzed) {
+		t.Fatalf("busy stats = %+v, %v; want analyzed again", again, err)
+	}
+}
+
+func TestSchedulerAnalyzeThresholdValidation(t *testing.T) {
+	if _, err := Open(":memory:", &Options{Scheduler: SchedulerConfig{AnalyzeThreshold: -0.1}}); err == nil {
+		t.Fatal("negative analyze threshold: expected error")
+	}
+	if _, err := Open(":memory:", &Options{Scheduler: SchedulerConfig{AnalyzeCheckInterval: -1}}); err == nil {
+		t.Fatal("negative analyze check interval: expected error")
+	}
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenBlobStreamsReadsAndWrites(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "blob_stream.db")
+	ctx := context.Background()
+	db, err := Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE files (id INTEGER PRIMARY KEY, name TEXT, data BLOB)")
+	mustExec(t, db, "INSERT INTO files (id, name) VALUES (1, 'empty')")
+
+	// 32 KiB spans several pages, so the value lives in overflow pages.
+	payload := make([]byte, 32*1024)
+	for i := range payload {
+		payload[i] = byte(i * 31)
+	}
+
+	blob, err := db.OpenBlob(ctx, "files", "data", 1)
+	if err != nil {
+		t.Fatalf("OpenBlob: %v", err)
+	}
+	if blob.Size() != 0 {
+		t.Fatalf("NULL blob size = %d, want 0", blob.Size())
+	}
+	if _, err := io.Copy(blob, bytes.NewReader(payload)); err != nil {
+		t.Fatalf("io.Copy into blob: %v", err)
+	}
+	if _, err := blob.WriteAt([]byte("HEAD"), 0); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	if err := blob.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	copy(payload, "HEAD")
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	db, err = Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	blob, err = db.OpenBlob(ctx, "files", "DATA", 1)
+	if err != nil {
+		t.Fatalf("OpenBlob after reopen: %v", err)
+	}
+	defer blob.Close()
+	if blob.Size() != int64(len(payload)) {
+		t.Fatalf("size = %d, want %d", blob.Size(), len(payload))
+	}
+	if _, err := blob.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	tail := make([]byte, 32)
+	n, err := blob.Read(tail)
+	if n != 10 || err != io.EOF || !bytes.Equal(tail[:n], payload[len(payload)-10:]) {
+		t.Fatalf("tail read = %d, %v", n, err)
+	}
+	got := make([]byte, len(payload))
+	if _, err := blob.ReadAt(got, 0); err != nil {
+		t.Fatalf("ReadAt: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatal("blob content mismatch after reopen")
+	}
+
+	if err := blob.Truncate(4); err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+	if err := blob.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	var v interface{}
+	if err := db.QueryRow(ctx, "SELECT data FROM files WHERE id = 1").Scan(&v); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if b, _ := v.([]byte); string(b) != "HEAD" {
+		t.Fatalf("after truncate value = %#v, want HEAD", v)
+	}
+}
+
+func TestOpenBlobErrors(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE files (id INTEGER PRIMARY KEY, name TEXT, data BLOB)")
+	mustExec(t, db, "CREATE TABLE pairs (a INTEGER, b INTEGER, data BLOB, PRIMARY KEY (a, b))")
+
+	if _, err := db.OpenBlob(ctx, "files", "data", 99); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("missing row: %v, want ErrBlobNotFound", err)
+	}
+	if _, err := db.OpenBlob(ctx, "files", "name", 1); !errors.Is(err, ErrNotBlobColumn) {
+		t.Errorf("TEXT column: %v, want ErrNotBlobColumn", err)
+	}
+	if _, err := db.OpenBlob(ctx, "files", "nope", 1); err == nil {
+		t.Error("unknown column should fail")
+	}
+	if _, err := db.OpenBlob(ctx, "pairs", "data", 1); !errors.Is(err, ErrBlobNeedsPK) {
+		t.Errorf("composite key: %v, want ErrBlobNeedsPK", err)
+	}
+
+	mustExec(t, db, "INSERT INTO files (id, data) VALUES (1, NULL)")
+	blob, err := db.OpenBlob(ctx, "files", "data", 1)
+	if err != nil {
+		t.Fatalf("OpenBlob: %v", err)
+	}
+	mustExec(t, db, "DELETE FROM files WHERE id = 1")
+	blob.Write([]byte("x"))
+	if err := blob.Close(); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("flush after delete: %v, want ErrBlobNotFound", err)
+	}
+	if _, err := blob.Read(make([]byte, 1)); !errors.Is(err, ErrBlobClosed) {
+		t.Errorf("read after close: %v, want ErrBlobClosed", err)
+	}
+}
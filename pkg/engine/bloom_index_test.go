@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBloomIndexLookups(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "bloom.db")
+	db, err := Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)")
+	for i := 1; i <= 300; i++ {
+		mustExec(t, db, fmt.Sprintf("INSERT INTO users VALUES (%d, 'user%d@example.com')", i, i))
+	}
+	mustExec(t, db, "CREATE INDEX idx_users_email ON users USING BLOOM (email)")
+
+	check := func(d *DB, where string, want ...string) {
+		t.Helper()
+		got := queryStrings(t, d, "SELECT id FROM users WHERE "+where+" ORDER BY id")
+		if !slices.Equal(got, want) {
+			t.Errorf("WHERE %s = %v, want %v", where, got, want)
+		}
+	}
+
+	check(db, "email = 'user42@example.com'", "42")
+	check(db, "email = 'nobody@example.com'")
+	check(db, "email IN ('user7@example.com', 'user250@example.com')", "7", "250")
+	check(db, "email = 'user42@example.com' AND id > 100")
+	if got := queryStrings(t, db, "SELECT id FROM users WHERE email = ?", "user9@example.com"); !slices.Equal(got, []string{"9"}) {
+		t.Errorf("email = ? = %v, want [9]", got)
+	}
+
+	plan := strings.Join(queryStrings(t, db, "EXPLAIN SELECT id FROM users WHERE email = 'user42@example.com'"), "\n")
+	if !strings.Contains(plan, "idx_users_email") {
+		t.Errorf("EXPLAIN does not use the bloom index:\n%s", plan)
+	}
+
+	mustExec(t, db, "UPDATE users SET email = 'renamed@example.com' WHERE id = 42")
+	mustExec(t, db, "DELETE FROM users WHERE id = 7")
+	check(db, "email = 'user42@example.com'")
+	check(db, "email = 'renamed@example.com'", "42")
+	check(db, "email = 'user7@example.com'")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO users VALUES (301, 'new@example.com')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM users WHERE id = 9"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	check(db, "email = 'new@example.com'")
+	check(db, "email = 'user9@example.com'", "9")
+
+	// The definition persists and the blocks are rebuilt on open.
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	db, err = Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	check(db, "email = 'renamed@example.com'", "42")
+	check(db, "email = 'user300@example.com'", "300")
+
+	mustExec(t, db, "DROP INDEX idx_users_email")
+	check(db, "email = 'user300@example.com'", "300")
+}
@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+const (
+	// defaultLoadBatchSize is the most rows LoadRecords inserts per batch.
+	defaultLoadBatchSize = 1000
+	// loadBatchPages bounds a batch by the size of its values, so wide rows
+	// commit in batches of about this many pages rather than of a row count.
+	loadBatchPages = 64
+)
+
+// LoadOptions configures LoadCSV and LoadRecords.
+type LoadOptions struct {
+	Columns   []string           // Target columns in field order (nil = the CSV header, or every table column)
+	Header    bool               // CSV: the first record is a header naming the columns
+	Comma     rune               // CSV: field delimiter (0 = ',')
+	BatchSize int                // Most rows per batch (0 = 1000)
+	Progress  func(LoadProgress) // Called after each batch (nil = no reports)
+}
+
+// LoadProgress reports how far a bulk load has got.
+type LoadProgress struct {
+	Rows    int64         // Rows loaded so far
+	Bytes   int64         // Input bytes read so far (LoadCSV only)
+	Elapsed time.Duration // Time since the load started
+}
+
+// RecordReader supplies the rows of a bulk load. Read returns the next row,
+// with one value per target column, or io.EOF after the last one. The
+// engine parses CSV itself; it has no Parquet reader yet, so Parquet files
+// are loaded by decoding them with a reader library behind RecordReader.
+type RecordReader interface {
+	Read() ([]interface{}, error)
+}
+
+// LoadCSV streams CSV records from r into table. Fields are loaded as text
+// and converted by the columns' type affinity; empty fields load as NULL.
+// See LoadRecords for how rows are written.
+func (db *DB) LoadCSV(ctx context.Context, table string, r io.Reader, opts LoadOptions) (int64, error) {
+	counter := &countingReader{r: r}
+	reader := csv.NewReader(counter)
+	reader.ReuseRecord = true
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	if opts.Header {
+		header, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("load %s: read csv header: %w", table, err)
+		}
+		if opts.Columns == nil {
+			for _, name := range header {
+				opts.Columns = append(opts.Columns, strings.TrimSpace(name))
+			}
+		}
+	}
+	if progress := opts.Progress; progress != nil {
+		opts.Progress = func(p LoadProgress) {
+			p.Bytes = counter.n
+			progress(p)
+		}
+	}
+	return db.LoadRecords(ctx, table, &csvRecordReader{reader: reader}, opts)
+}
+
+// LoadRecords inserts the rows src supplies into table, for initial loads
+// of large datasets. Rows go to the catalog in batches of at most
+// opts.BatchSize rows and about loadBatchPages pages of values, bound as
+// arguments rather than written out as SQL. Each batch commits on its own,
+// so a load that fails part way keeps the batches before the failure. The
+// table's non-unique indexes are rebuilt once at the end instead of being
+// updated row by row; until then queries on the table do not use them, and
+// an index left unbuilt by a crash is rebuilt when the database is opened.
+// It returns the number of rows loaded.
+func (db *DB) LoadRecords(ctx context.Context, table string, src RecordReader, opts LoadOptions) (loaded int64, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	columns := opts.Columns
+	if columns == nil {
+		def, err := db.catalog.GetTable(table)
+		if err != nil {
+			return 0, fmt.Errorf("load %s: %w", table, err)
+		}
+		for _, col := range def.Columns {
+			columns = append(columns, col.Name)
+		}
+	}
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("load %s: no columns", table)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultLoadBatchSize
+	}
+
+	finishIndexes, err := db.catalog.DeferIndexes(table)
+	if err != nil {
+		return 0, fmt.Errorf("load %s: %w", table, err)
+	}
+	defer func() {
+		if indexErr := finishIndexes(); indexErr != nil && err == nil {
+			err = fmt.Errorf("load %s: %w", table, indexErr)
+		}
+	}()
+
+	insert := &query.InsertStmt{Table: table, Columns: columns}
+	start := time.Now()
+	rows := make([][]interface{}, 0, batchSize)
+	batchBytes := 0
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		stmt, args := insertValues(insert, rows, nil)
+		finish := db.beginStatement(ctx)
+		result, err := db.execute(ctx, stmt, args)
+		if err == nil {
+			err = db.awaitConsensus(ctx)
+		}
+		if err = finish(err); err != nil {
+			return fmt.Errorf("load %s: rows %d-%d: %w", table, loaded+1, loaded+int64(len(rows)), err)
+		}
+		loaded += result.RowsAffected
+		rows = rows[:0]
+		batchBytes = 0
+		if opts.Progress != nil {
+			opts.Progress(LoadProgress{Rows: loaded, Elapsed: time.Since(start)})
+		}
+		return nil
+	}
+
+	for record := int64(1); ; record++ {
+		row, err := src.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return loaded, fmt.Errorf("load %s: record %d: %w", table, record, err)
+		}
+		if len(row) != len(columns) {
+			return loaded, fmt.Errorf("load %s: record %d has %d fields, want %d", table, record, len(row), len(columns))
+		}
+		rows = append(rows, row)
+		for _, v := range row {
+			batchBytes += loadValueSize(v)
+		}
+		if len(rows) == batchSize || batchBytes >= loadBatchPages*storage.PageSize {
+			if err := ctx.Err(); err != nil {
+				return loaded, err
+			}
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+	return loaded, flush()
+}
+
+// loadValueSize estimates the bytes v takes in a stored row.
+func loadValueSize(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case nil:
+		return 1
+	default:
+		return 8
+	}
+}
+
+// csvRecordReader adapts a csv.Reader to RecordReader.
+type csvRecordReader struct {
+	reader *csv.Reader
+}
+
+func (r *csvRecordReader) Read() ([]interface{}, error) {
+	fields, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if field != "" {
+			row[i] = field
+		}
+	}
+	return row, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
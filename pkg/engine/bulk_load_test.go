@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+func TestLoadCSV(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE items (id INTEGER PRIMARY KEY, code INTEGER, name TEXT)")
+	mustExec(t, db, "CREATE INDEX idx_items_code ON items (code)")
+
+	var csvText strings.Builder
+	csvText.WriteString("name,id,code\n")
+	for i := 1; i <= 2500; i++ {
+		name := fmt.Sprintf("item %d", i)
+		if i%500 == 0 {
+			name = ""
+		}
+		fmt.Fprintf(&csvText, "%s,%d,%d\n", name, i, i%10)
+	}
+
+	var reports []LoadProgress
+	n, err := db.LoadCSV(ctx, "items", strings.NewReader(csvText.String()), LoadOptions{
+		Header:    true,
+		BatchSize: 1000,
+		Progress: func(p LoadProgress) {
+			reports = append(reports, p)
+			// The deferred index is not used mid-load, so lookups still
+			// see every row loaded so far.
+			got := queryStrings(t, db, "SELECT COUNT(*) FROM items WHERE code = 3")
+			if want := fmt.Sprint(p.Rows / 10); got[0] != want {
+				t.Errorf("after %d rows: code = 3 matches %s rows, want %s", p.Rows, got[0], want)
+			}
+		},
+	})
+	if err != nil || n != 2500 {
+		t.Fatalf("LoadCSV = %d, %v; want 2500 rows", n, err)
+	}
+	if len(reports) != 3 || reports[2].Rows != 2500 || reports[2].Bytes != int64(csvText.Len()) {
+		t.Fatalf("progress reports = %+v", reports)
+	}
+
+	// The index is rebuilt and in use again.
+	if got := queryStrings(t, db, "SELECT COUNT(*) FROM items WHERE code = 7"); got[0] != "250" {
+		t.Fatalf("code = 7 matches %v rows, want 250", got)
+	}
+	if got := queryStrings(t, db, "SELECT id, code, name FROM items WHERE id IN (1, 500) ORDER BY id"); strings.Join(got, ",") != "1|1|item 1,500|0|NULL" {
+		t.Fatalf("rows = %v", got)
+	}
+
+	// Duplicate keys stop the load; the earlier batches stay.
+	dup := "id,code,name\n3000,1,a\n3001,2,b\n1,3,c\n"
+	n, err = db.LoadCSV(ctx, "items", strings.NewReader(dup), LoadOptions{Header: true, BatchSize: 2})
+	if err == nil || n != 2 {
+		t.Fatalf("duplicate load = %d, %v; want 2 rows and an error", n, err)
+	}
+	if got := queryStrings(t, db, "SELECT COUNT(*) FROM items WHERE code = 2"); got[0] != "251" {
+		t.Fatalf("code = 2 matches %v rows after the failed load, want 251", got)
+	}
+
+	if _, err := db.LoadCSV(ctx, "items", strings.NewReader("1;2\n"), LoadOptions{Comma: ';'}); err == nil {
+		t.Error("a record with too few fields should fail")
+	}
+	if _, err := db.LoadCSV(ctx, "missing", strings.NewReader("1\n"), LoadOptions{}); err == nil {
+		t.Error("loading into a missing table should fail")
+	}
+}
+
+func TestLoadDeferredIndexRebuiltOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "load.db")
+	db, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE items (id INTEGER PRIMARY KEY, code INTEGER)")
+	mustExec(t, db, "CREATE INDEX idx_items_code ON items (code)")
+	mustExec(t, db, "INSERT INTO items VALUES (1, 5)")
+
+	// A load that never finishes, as after a crash: the index is left
+	// IndexBuilding and misses the rows written since.
+	if _, err := db.catalog.DeferIndexes("items"); err != nil {
+		t.Fatalf("DeferIndexes: %v", err)
+	}
+	mustExec(t, db, "INSERT INTO items VALUES (2, 5), (3, 6)")
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	db, err = Open(path, nil)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	idx, err := db.catalog.GetIndex("idx_items_code")
+	if err != nil || idx.Status != catalog.IndexActive {
+		t.Fatalf("index after reopen = %+v, %v; want it rebuilt and active", idx, err)
+	}
+	if got := queryStrings(t, db, "SELECT id FROM items WHERE code = 5 ORDER BY id"); strings.Join(got, ",") != "1,2" {
+		t.Fatalf("code = 5 matches %v, want 1,2", got)
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/txn"
+)
+
+// ErrBusy is returned when a write waited the busy timeout for a table that
+// another transaction is writing.
+var ErrBusy = errors.New("database is busy")
+
+// SetBusyTimeout sets how long INSERT, UPDATE and DELETE wait for a table
+// that another open transaction has written to, like SQLite's busy_timeout.
+// Writers queue on a per-table lock held until their transaction commits or
+// rolls back; a writer that waits longer than d fails with ErrBusy, and one
+// whose wait would close a cycle fails at once with txn.ErrDeadlockDetected.
+// Zero disables waiting: concurrent writers proceed and the later commit of
+// two conflicting transactions fails with txn.ErrConflict.
+func (db *DB) SetBusyTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	db.busyTimeout.Store(int64(d))
+	db.syncRowLockTimeout()
+}
+
+// BusyTimeout returns the timeout set by SetBusyTimeout or
+// Options.ConnectionPool.BusyTimeout.
+func (db *DB) BusyTimeout() time.Duration {
+	return time.Duration(db.busyTimeout.Load())
+}
+
+// SetRowLocks sets the granularity of the locks the busy timeout enables.
+// With row locks on, a writer takes an intent lock on the table and an
+// exclusive lock on each row it inserts, updates or deletes, so transactions
+// writing different rows of a table no longer wait for each other, while a
+// second writer of the same row still waits up to the busy timeout. A writer
+// that waited for a row and then finds it changed fails at commit with
+// txn.ErrConflict instead of overwriting the change. Off, the default, locks
+// whole tables.
+func (db *DB) SetRowLocks(on bool) {
+	db.rowLocks.Store(on)
+	db.syncRowLockTimeout()
+}
+
+// RowLocks reports whether writers lock rows rather than tables; see
+// SetRowLocks.
+func (db *DB) RowLocks() bool {
+	return db.rowLocks.Load()
+}
+
+// syncRowLockTimeout passes the row lock wait on to the catalog.
+func (db *DB) syncRowLockTimeout() {
+	if db.catalog == nil {
+		return
+	}
+	var d time.Duration
+	if db.rowLocks.Load() {
+		d = db.BusyTimeout()
+	}
+	db.catalog.SetRowLockTimeout(d)
+}
+
+// writeTarget returns the table a DML statement writes, or "".
+func writeTarget(stmt query.Statement) string {
+	switch s := stmt.(type) {
+	case *query.InsertStmt:
+		return s.Table
+	case *query.UpdateStmt:
+		return s.Table
+	case *query.DeleteStmt:
+		return s.Table
+	}
+	return ""
+}
+
+// lockForWrite takes the write lock on the table stmt writes, waiting up to
+// the busy timeout: an exclusive lock, or with row locks an intent lock that
+// only table-wide locks conflict with. Inside a transaction the lock is held
+// until it ends and release is a no-op; outside one the statement locks
+// through a short-lived transaction that release ends.
+func (db *DB) lockForWrite(stmt query.Statement) (release func(), err error) {
+	release = func() {}
+	timeout := db.BusyTimeout()
+	table := writeTarget(stmt)
+	if timeout <= 0 || table == "" || db.txnMgr == nil {
+		return release, nil
+	}
+	mt := db.catalog.CurrentManagerTxn()
+	if mt == nil {
+		mt = db.txnMgr.Begin(nil)
+		release = func() { _ = mt.Rollback() }
+	}
+	mode := txn.LockExclusive
+	if db.RowLocks() {
+		mode = txn.LockIntentExclusive
+	}
+	start := time.Now()
+	err = db.txnMgr.AcquireLockMode(mt.ID, txn.TableLockKey(table), mode, timeout)
+	db.commitLockWait.Observe(time.Since(start))
+	switch {
+	case err == nil:
+		return release, nil
+	case errors.Is(err, txn.ErrLockTimeout):
+		err = fmt.Errorf("%w: table %s is locked by another transaction", ErrBusy, table)
+	}
+	release()
+	return func() {}, err
+}
+
+// rowBusyError reports a row lock wait of stmt that timed out as ErrBusy.
+func rowBusyError(stmt query.Statement, err error) error {
+	if !errors.Is(err, txn.ErrLockTimeout) {
+		return err
+	}
+	return fmt.Errorf("%w: a row of table %s is locked by another transaction", ErrBusy, writeTarget(stmt))
+}
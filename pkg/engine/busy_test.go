@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/txn"
+)
+
+func openBusyDB(t *testing.T, timeout time.Duration) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "busy.db"), &Options{
+		ConnectionPool: ConnectionPool{BusyTimeout: timeout},
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mustExec(t, db, "CREATE TABLE a (id INTEGER PRIMARY KEY)")
+	mustExec(t, db, "CREATE TABLE b (id INTEGER PRIMARY KEY)")
+	return db
+}
+
+func TestBusyTimeoutQueuesWriters(t *testing.T) {
+	ctx := context.Background()
+	db := openBusyDB(t, 5*time.Second)
+	if got := db.BusyTimeout(); got != 5*time.Second {
+		t.Fatalf("BusyTimeout() = %v, want 5s", got)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO a VALUES (1)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Exec(ctx, "UPDATE a SET id = id + 10")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("second writer did not wait for the open transaction (err = %v)", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("queued writer failed: %v", err)
+	}
+	// The queued UPDATE ran after the commit, so it saw the inserted row.
+	if got := queryStrings(t, db, "SELECT id FROM a"); !slices.Equal(got, []string{"11"}) {
+		t.Fatalf("rows = %v, want [11]", got)
+	}
+
+	// Readers and writers of other tables do not wait.
+	tx, err = db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(ctx, "INSERT INTO a VALUES (2)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	go func() {
+		if _, err := db.Exec(ctx, "INSERT INTO b VALUES (1)"); err != nil {
+			done <- err
+			return
+		}
+		_, err := db.Query(ctx, "SELECT id FROM a")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unrelated statement failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("unrelated statement waited for the open transaction")
+	}
+}
+
+func TestBusyTimeoutExpires(t *testing.T) {
+	ctx := context.Background()
+	db := openBusyDB(t, 5*time.Second)
+	db.SetBusyTimeout(50 * time.Millisecond)
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(ctx, "INSERT INTO a VALUES (1)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Exec(ctx, "INSERT INTO a VALUES (2)")
+		done <- err
+	}()
+	if err := <-done; !errors.Is(err, ErrBusy) {
+		t.Fatalf("err = %v, want ErrBusy", err)
+	}
+}
+
+func TestBusyTimeoutDetectsDeadlock(t *testing.T) {
+	ctx := context.Background()
+	db := openBusyDB(t, 5*time.Second)
+
+	// tx1 writes a then b; tx2 writes b then a.
+	tx1Holds, tx2Holds := make(chan struct{}), make(chan struct{})
+	tx1Result, tx2Result := make(chan error, 1), make(chan error, 1)
+	go func() {
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			tx1Result <- err
+			return
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO a VALUES (1)"); err != nil {
+			tx1Result <- err
+			return
+		}
+		close(tx1Holds)
+		<-tx2Holds
+		if _, err := tx.Exec(ctx, "INSERT INTO b VALUES (1)"); err != nil {
+			_ = tx.Rollback()
+			tx1Result <- err
+			return
+		}
+		tx1Result <- tx.Commit()
+	}()
+	go func() {
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			tx2Result <- err
+			return
+		}
+		<-tx1Holds
+		if _, err := tx.Exec(ctx, "INSERT INTO b VALUES (2)"); err != nil {
+			tx2Result <- err
+			return
+		}
+		close(tx2Holds)
+		time.Sleep(100 * time.Millisecond) // let tx1 start waiting for b
+		_, err = tx.Exec(ctx, "INSERT INTO a VALUES (2)")
+		_ = tx.Rollback()
+		tx2Result <- err
+	}()
+
+	if err := <-tx2Result; !errors.Is(err, txn.ErrDeadlockDetected) {
+		t.Fatalf("tx2 err = %v, want ErrDeadlockDetected", err)
+	}
+	if err := <-tx1Result; err != nil {
+		t.Fatalf("tx1 should proceed once tx2 rolls back: %v", err)
+	}
+	if got := queryStrings(t, db, "SELECT id FROM b"); !slices.Equal(got, []string{"1"}) {
+		t.Fatalf("b = %v, want [1]", got)
+	}
+}
+
+func TestRowLocks(t *testing.T) {
+	ctx := context.Background()
+	db := openBusyDB(t, 5*time.Second)
+	db.SetRowLocks(true)
+	mustExec(t, db, "CREATE TABLE c (id INTEGER PRIMARY KEY, n INTEGER)")
+	mustExec(t, db, "INSERT INTO c VALUES (1, 0), (2, 0)")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE c SET n = n + 1 WHERE id = 1"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// Writers of other rows of the table do not wait.
+	done := make(chan error, 1)
+	go func() {
+		if _, err := db.Exec(ctx, "UPDATE c SET n = 5 WHERE id = 2"); err != nil {
+			done <- err
+			return
+		}
+		_, err := db.Exec(ctx, "INSERT INTO c VALUES (3, 0)")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("writer of another row failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("writer of another row waited for the open transaction")
+	}
+
+	// A writer of the same row waits, and its update is not lost.
+	go func() {
+		_, err := db.Exec(ctx, "UPDATE c SET n = n + 10 WHERE id = 1")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("writer of the same row did not wait (err = %v)", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	err = <-done
+	got := queryStrings(t, db, "SELECT n FROM c WHERE id = 1")
+	switch {
+	case err == nil && slices.Equal(got, []string{"11"}):
+	case errors.Is(err, txn.ErrConflict) && slices.Equal(got, []string{"1"}):
+	default:
+		t.Fatalf("queued writer: err = %v, n = %v", err, got)
+	}
+
+	// A writer that waits out the busy timeout fails with ErrBusy.
+	tx, err = db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(ctx, "DELETE FROM c WHERE id = 2"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	db.SetBusyTimeout(50 * time.Millisecond)
+	go func() {
+		_, err := db.Exec(ctx, "UPDATE c SET n = 0 WHERE id = 2")
+		done <- err
+	}()
+	if err := <-done; !errors.Is(err, ErrBusy) {
+		t.Fatalf("err = %v, want ErrBusy", err)
+	}
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCaseExpressions(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, g TEXT, v INTEGER)")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 'a', 10), (2, 'b', 20), (3, 'a', 30), (4, NULL, 0)")
+
+	// Simple CASE compares the operand with each value; NULL matches none.
+	assertScalar(t, db, "SELECT CASE g WHEN 'a' THEN 'A' WHEN 'b' THEN 'B' ELSE 'other' END FROM t WHERE id = 2", "B")
+	assertScalar(t, db, "SELECT CASE g WHEN NULL THEN 'null' ELSE 'other' END FROM t WHERE id = 4", "other")
+	assertScalar(t, db, "SELECT CASE WHEN v THEN 'yes' ELSE 'no' END FROM t WHERE id = 1", "yes")
+
+	// Only the chosen branch is evaluated.
+	assertScalar(t, db, "SELECT CASE WHEN v = 0 THEN -1 ELSE 100 / v END FROM t WHERE id = 4", int64(-1))
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE CASE WHEN v = 0 THEN 0 ELSE 100 / v END = 0", int64(1))
+
+	// WHERE, SET, ORDER BY and aggregates.
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE CASE g WHEN 'a' THEN v > 20 ELSE 0 END", int64(1))
+	assertScalar(t, db, "SELECT SUM(CASE WHEN g = 'a' THEN v ELSE 0 END) FROM t", float64(40))
+	assertScalar(t, db, "SELECT COUNT(CASE g WHEN 'b' THEN 1 END) FROM t", int64(1))
+	assertScalar(t, db, "SELECT CASE WHEN SUM(v) > 30 THEN 'big' ELSE 'small' END FROM t WHERE g = 'a' GROUP BY g", "big")
+	assertScalar(t, db, "SELECT id FROM t ORDER BY CASE g WHEN 'b' THEN 0 ELSE 1 END, id LIMIT 1", int64(2))
+	if _, err := db.Exec(ctx, "UPDATE t SET v = CASE g WHEN 'a' THEN v + 1 ELSE v - 1 END"); err != nil {
+		t.Fatalf("UPDATE with CASE: %v", err)
+	}
+	assertScalar(t, db, "SELECT v FROM t WHERE id = 3", int64(31))
+	assertScalar(t, db, "SELECT v FROM t WHERE id = 2", int64(19))
+}
@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+// ErrNoWAL is returned by Subscribe for a database without a WAL, such as an
+// in-memory one: the change feed follows WAL commits.
+var ErrNoWAL = errors.New("change feed requires the WAL")
+
+// ChangeOp is the kind of row change a ChangeEvent reports.
+type ChangeOp string
+
+const (
+	ChangeInsert ChangeOp = "insert"
+	ChangeUpdate ChangeOp = "update"
+	ChangeDelete ChangeOp = "delete"
+)
+
+// ChangeEvent is one row changed by a committed transaction.
+type ChangeEvent struct {
+	Op    ChangeOp
+	Table string
+	// Columns names the values of Old and New, as the table is defined when
+	// the event is delivered. Types holds each column's declared type, such
+	// as INTEGER or TEXT, and PrimaryKey the names of the key columns.
+	Columns    []string
+	Types      []string
+	PrimaryKey []string
+	// Old is the row before an update or delete and New the row after an
+	// insert or update; the other is nil.
+	Old []interface{}
+	New []interface{}
+	// LSN is the WAL position of the transaction's commit record. Every
+	// row of a transaction has the same LSN, and LSNs grow with commit
+	// order.
+	LSN uint64
+}
+
+// committedChanges is what the catalog reports for one commit.
+type committedChanges struct {
+	lsn  uint64
+	rows []catalog.RowChange
+}
+
+// Subscribe returns a channel that receives a ChangeEvent for every row
+// inserted, updated or deleted by a transaction committed after the call,
+// limited to tables when any are named. Events for one row arrive in commit
+// order. Nothing is dropped: events queue in memory while the receiver is
+// behind, so a subscriber must keep reading. The channel is closed when ctx
+// is done or the database is closed.
+func (db *DB) Subscribe(ctx context.Context, tables ...string) (<-chan ChangeEvent, error) {
+	if db.closed.Load() {
+		return nil, ErrDatabaseClosed
+	}
+	if db.wal == nil {
+		return nil, ErrNoWAL
+	}
+	var filter map[string]bool
+	if len(tables) > 0 {
+		filter = make(map[string]bool, len(tables))
+		for _, name := range tables {
+			table, err := db.catalog.GetTable(name)
+			if err != nil {
+				return nil, err
+			}
+			filter[table.Name] = true
+		}
+	}
+
+	sub := &changeSubscriber{filter: filter, wake: make(chan struct{}, 1)}
+	db.changeMu.Lock()
+	unregister := db.changeHooks.add(sub.enqueue)
+	db.catalog.SetChangeCapture(db.publishChanges)
+	db.changeMu.Unlock()
+
+	out := make(chan ChangeEvent)
+	go func() {
+		defer close(out)
+		defer func() {
+			db.changeMu.Lock()
+			unregister()
+			if len(db.changeHooks.snapshot()) == 0 {
+				db.catalog.SetChangeCapture(nil)
+			}
+			db.changeMu.Unlock()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-db.shutdownCh:
+				return
+			case <-sub.wake:
+			}
+			for _, ev := range sub.drain() {
+				db.describeChange(&ev)
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				case <-db.shutdownCh:
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// publishChanges is the catalog's change capture hook while anyone is
+// subscribed. It runs under the catalog's commit locks, so subscribers only
+// queue the changes.
+func (db *DB) publishChanges(lsn uint64, rows []catalog.RowChange) {
+	changes := committedChanges{lsn: lsn, rows: rows}
+	for _, fn := range db.changeHooks.snapshot() {
+		fn(changes)
+	}
+}
+
+// describeChange fills in ev's columns and key from the table definition
+// and pads rows written before columns were added.
+func (db *DB) describeChange(ev *ChangeEvent) {
+	table, err := db.catalog.GetTable(ev.Table)
+	if err != nil {
+		return // dropped since the commit
+	}
+	ev.Columns = make([]string, len(table.Columns))
+	ev.Types = make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		ev.Columns[i] = col.Name
+		ev.Types[i] = col.Type
+	}
+	ev.PrimaryKey = table.PrimaryKey
+	ev.Old = padRow(ev.Old, len(ev.Columns))
+	ev.New = padRow(ev.New, len(ev.Columns))
+}
+
+func padRow(row []interface{}, n int) []interface{} {
+	if row == nil || len(row) >= n {
+		return row
+	}
+	padded := make([]interface{}, n)
+	copy(padded, row)
+	return padded
+}
+
+// changeSubscriber queues the events of one Subscribe call until its
+// goroutine delivers them.
+type changeSubscriber struct {
+	filter map[string]bool // table names; nil for every table
+	wake   chan struct{}
+
+	mu    sync.Mutex
+	queue []ChangeEvent
+}
+
+func (s *changeSubscriber) enqueue(c committedChanges) {
+	s.mu.Lock()
+	added := false
+	for _, row := range c.rows {
+		if s.filter != nil && !s.filter[row.Table] {
+			continue
+		}
+		ev := ChangeEvent{Table: row.Table, Old: row.Old, New: row.New, LSN: c.lsn}
+		switch {
+		case row.Old == nil:
+			ev.Op = ChangeInsert
+		case row.New == nil:
+			ev.Op = ChangeDelete
+		default:
+			ev.Op = ChangeUpdate
+		}
+		s.queue = append(s.queue, ev)
+		added = true
+	}
+	s.mu.Unlock()
+	if added {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *changeSubscriber) drain() []ChangeEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.queue
+	s.queue = nil
+	return events
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func nextChange(t *testing.T, ch <-chan ChangeEvent) ChangeEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("change feed closed")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change event")
+	}
+	return ChangeEvent{}
+}
+
+func TestSubscribeReportsCommittedChanges(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "cdc.db"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "CREATE TABLE audit (id INTEGER PRIMARY KEY, note TEXT)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := db.Subscribe(ctx, "users")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	mustExec(t, db, "INSERT INTO users VALUES (1, 'ann')")
+	mustExec(t, db, "INSERT INTO audit VALUES (1, 'ignored')")
+	mustExec(t, db, "UPDATE users SET name = 'anna' WHERE id = 1")
+	mustExec(t, db, "DELETE FROM users WHERE id = 1")
+
+	ins := nextChange(t, ch)
+	if ins.Op != ChangeInsert || ins.Table != "users" || ins.Old != nil || fmt.Sprint(ins.New) != "[1 ann]" {
+		t.Fatalf("insert event = %+v", ins)
+	}
+	if fmt.Sprint(ins.Columns, ins.Types, ins.PrimaryKey) != "[id name] [INTEGER TEXT] [id]" {
+		t.Fatalf("columns = %v, types = %v, key = %v", ins.Columns, ins.Types, ins.PrimaryKey)
+	}
+	upd := nextChange(t, ch)
+	if upd.Op != ChangeUpdate || fmt.Sprint(upd.Old) != "[1 ann]" || fmt.Sprint(upd.New) != "[1 anna]" {
+		t.Fatalf("update event = %+v", upd)
+	}
+	del := nextChange(t, ch)
+	if del.Op != ChangeDelete || fmt.Sprint(del.Old) != "[1 anna]" || del.New != nil {
+		t.Fatalf("delete event = %+v", del)
+	}
+	if ins.LSN == 0 || !(ins.LSN < upd.LSN && upd.LSN < del.LSN) {
+		t.Fatalf("LSNs %d, %d, %d are not increasing", ins.LSN, upd.LSN, del.LSN)
+	}
+
+	// A transaction's rows share its commit LSN; rolled back ones never show.
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "INSERT INTO users VALUES (2, 'bob')")
+	mustExec(t, db, "INSERT INTO users VALUES (3, 'cy')")
+	mustExec(t, db, "COMMIT")
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "INSERT INTO users VALUES (4, 'dee')")
+	mustExec(t, db, "ROLLBACK")
+	mustExec(t, db, "INSERT INTO users VALUES (5, 'eve')")
+	a, b, c := nextChange(t, ch), nextChange(t, ch), nextChange(t, ch)
+	if a.LSN != b.LSN || c.LSN <= b.LSN {
+		t.Fatalf("LSNs %d, %d, %d", a.LSN, b.LSN, c.LSN)
+	}
+	if fmt.Sprint(a.New, b.New, c.New) != "[2 bob] [3 cy] [5 eve]" {
+		t.Fatalf("rows = %v %v %v", a.New, b.New, c.New)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("unexpected event after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("feed not closed after cancel")
+	}
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	mem, err := Open(":memory:", nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer mem.Close()
+	if _, err := mem.Subscribe(context.Background()); !errors.Is(err, ErrNoWAL) {
+		t.Fatalf("Subscribe without a WAL: err = %v, want ErrNoWAL", err)
+	}
+
+	db, err := Open(filepath.Join(t.TempDir(), "cdc.db"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := db.Subscribe(context.Background(), "missing"); err == nil {
+		t.Fatal("Subscribe to a missing table succeeded")
+	}
+
+	// Close ends the feed.
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	ch, err := db.Subscribe(context.Background())
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	mustExec(t, db, "INSERT INTO t VALUES (1)")
+	if ev := nextChange(t, ch); ev.Op != ChangeInsert || ev.Table != "t" {
+		t.Fatalf("event = %+v", ev)
+	}
+	db.Close()
+	for range ch {
+	}
+}
@@ -0,0 +1,16 @@
+package engine
+
+import "github.com/cobaltdb/cobaltdb/pkg/catalog"
+
+// Collation defines a custom string ordering for COLLATE clauses; see
+// catalog.Collation.
+type Collation = catalog.Collation
+
+// RegisterCollation adds a custom collation usable as COLLATE name in
+// column definitions, comparisons and ORDER BY. Collations are process-wide,
+// like database/sql drivers, and should be registered before opening a
+// database whose tables use them. The built-in collations are BINARY (the
+// default), NOCASE, RTRIM and UNICODE.
+func RegisterCollation(name string, coll *Collation) error {
+	return catalog.RegisterCollation(name, coll)
+}
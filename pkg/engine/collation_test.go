@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCollationColumnsAndOrderBy(t *testing.T) {
+	db := openTestDB(t, nil)
+	mustExec(t, db, "CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT COLLATE NOCASE, code TEXT COLLATE RTRIM, title TEXT, city TEXT COLLATE UNICODE)")
+	mustExec(t, db, "INSERT INTO people VALUES (1, 'alice', 'A1  ', 'b', 'Łódź'), (2, 'Bob', 'B2', 'B', 'łÓDŹ'), (3, 'ALICE', 'A1', 'a', 'Kraków')")
+
+	tests := []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT id FROM people WHERE name = 'Alice' ORDER BY id", []string{"1", "3"}},
+		{"SELECT id FROM people WHERE 'BOB' = name", []string{"2"}},
+		{"SELECT id FROM people WHERE code = 'A1' ORDER BY id", []string{"1", "3"}},
+		{"SELECT id FROM people WHERE city = 'ŁÓDŹ' ORDER BY id", []string{"1", "2"}},
+		{"SELECT id FROM people WHERE title = 'B'", []string{"2"}},
+		{"SELECT id FROM people WHERE title = 'B' COLLATE NOCASE ORDER BY id", []string{"1", "2"}},
+		{"SELECT id FROM people WHERE name COLLATE BINARY = 'alice'", []string{"1"}},
+		{"SELECT id FROM people WHERE name > 'ALICE' ORDER BY id", []string{"2"}},
+		{"SELECT title FROM people ORDER BY title", []string{"B", "a", "b"}},
+		{"SELECT title FROM people ORDER BY title COLLATE NOCASE, id", []string{"a", "b", "B"}},
+		{"SELECT name FROM people ORDER BY name, id", []string{"alice", "ALICE", "Bob"}},
+		{"SELECT id FROM people ORDER BY name DESC, id", []string{"2", "1", "3"}},
+	}
+	for _, tt := range tests {
+		if got := queryStrings(t, db, tt.sql); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+
+	if _, err := db.Query(context.Background(), "SELECT 'a' = 'A' COLLATE bogus"); err == nil || !strings.Contains(err.Error(), "no such collation") {
+		t.Fatalf("unknown collation: err = %v", err)
+	}
+}
+
+func TestCollationIndexKeys(t *testing.T) {
+	db := openTestDB(t, nil)
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT COLLATE NOCASE UNIQUE, nick TEXT COLLATE NOCASE)")
+	mustExec(t, db, "CREATE INDEX idx_users_nick ON users (nick)")
+	mustExec(t, db, "INSERT INTO users VALUES (1, 'Ann@Example.com', 'Annie'), (2, 'bob@example.com', 'BOBBY')")
+
+	if _, err := db.Exec(ctx, "INSERT INTO users VALUES (3, 'ann@example.COM', 'x')"); err == nil {
+		t.Fatal("UNIQUE NOCASE column accepted a value differing only in case")
+	}
+	if got := queryStrings(t, db, "SELECT id FROM users WHERE nick = ?", "bobby"); strings.Join(got, ",") != "2" {
+		t.Fatalf("index lookup under NOCASE = %v, want [2]", got)
+	}
+	mustExec(t, db, "UPDATE users SET nick = 'Robert' WHERE id = 2")
+	if got := queryStrings(t, db, "SELECT id FROM users WHERE nick = 'ROBERT'"); strings.Join(got, ",") != "2" {
+		t.Fatalf("index lookup after update = %v, want [2]", got)
+	}
+	if got := queryStrings(t, db, "SELECT id FROM users WHERE nick = 'bobby'"); len(got) != 0 {
+		t.Fatalf("stale index entry after update: %v", got)
+	}
+}
+
+func TestRegisterCollation(t *testing.T) {
+	// Orders strings by length, then bytewise.
+	err := RegisterCollation("BY_LENGTH", &Collation{
+		Compare: func(a, b string) int {
+			if len(a) != len(b) {
+				return len(a) - len(b)
+			}
+			return strings.Compare(a, b)
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterCollation: %v", err)
+	}
+	if err := RegisterCollation("nocase", &Collation{Compare: strings.Compare}); err == nil {
+		t.Fatal("expected error redefining a built-in collation")
+	}
+
+	db := openTestDB(t, nil)
+	mustExec(t, db, "CREATE TABLE words (w TEXT COLLATE by_length)")
+	mustExec(t, db, "INSERT INTO words VALUES ('ccc'), ('a'), ('bb'), ('aa')")
+	if got := queryStrings(t, db, "SELECT w FROM words ORDER BY w"); strings.Join(got, ",") != "a,aa,bb,ccc" {
+		t.Fatalf("ORDER BY custom collation = %v", got)
+	}
+	if got := queryStrings(t, db, "SELECT w FROM words WHERE w < 'zz' ORDER BY w"); strings.Join(got, ",") != "a,aa,bb" {
+		t.Fatalf("comparison under custom collation = %v", got)
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+func TestColumnEncryptionFunctions(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	db, err := Open(":memory:", &Options{Security: Security{ColumnKeys: map[string][]byte{"k1": key}}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.SetColumnKey("k2", key[:16]); err != nil {
+		t.Fatalf("SetColumnKey: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE secrets (id INTEGER PRIMARY KEY, ssn TEXT)")
+	mustExec(t, db, "INSERT INTO secrets VALUES (1, ENCRYPT('123-45-6789', 'k1')), (2, ENCRYPT(NULL, 'k1'))")
+
+	var stored, plain string
+	if err := db.QueryRow(ctx, "SELECT ssn, DECRYPT(ssn, 'k1') FROM secrets WHERE id = 1").Scan(&stored, &plain); err != nil {
+		t.Fatalf("DECRYPT: %v", err)
+	}
+	if plain != "123-45-6789" || strings.Contains(stored, "6789") {
+		t.Fatalf("stored %q decrypts to %q", stored, plain)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM secrets WHERE DECRYPT(ssn, 'k1') IS NULL"); got != 1 {
+		t.Fatalf("NULL rows = %d, want 1", got)
+	}
+
+	if _, err := db.Query(ctx, "SELECT DECRYPT(?, 'k2')", stored); err == nil {
+		t.Fatal("DECRYPT with another key: expected error")
+	}
+	if _, err := db.Query(ctx, "SELECT ENCRYPT('x', 'nope')"); !errors.Is(err, catalog.ErrUnknownColumnKey) {
+		t.Fatalf("unknown key: err = %v, want ErrUnknownColumnKey", err)
+	}
+	if err := db.SetColumnKey("k1", nil); err != nil {
+		t.Fatalf("SetColumnKey(nil): %v", err)
+	}
+	if _, err := db.Query(ctx, "SELECT DECRYPT(?, 'k1')", stored); !errors.Is(err, catalog.ErrUnknownColumnKey) {
+		t.Fatalf("removed key: err = %v, want ErrUnknownColumnKey", err)
+	}
+	if err := db.SetColumnKey("k3", []byte("short")); err == nil {
+		t.Fatal("SetColumnKey with a 5-byte key: expected error")
+	}
+	if _, err := Open(":memory:", &Options{Security: Security{ColumnKeys: map[string][]byte{"k": []byte("short")}}}); err == nil {
+		t.Fatal("Open with a 5-byte column key: expected error")
+	}
+}
+
+func TestMaskedColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "masked.db")
+	db := openPreparedTestDB(t, path)
+	mustExec(t, db, `CREATE TABLE customers (
+		id INTEGER PRIMARY KEY,
+		card TEXT MASKED WITH MASK_LAST(4),
+		email TEXT MASKED WITH MASK_EMAIL(),
+		name TEXT MASKED,
+		balance INTEGER MASKED,
+		city TEXT)`)
+	mustExec(t, db, "INSERT INTO customers VALUES (1, '4111111111111111', 'ann@example.com', 'Ann', 250, 'Oslo'), (2, NULL, 'bob@example.com', 'Bob', 75, 'Rome')")
+	if _, err := db.Exec(context.Background(), "CREATE TABLE bad (a TEXT MASKED WITH MASK_LAST())"); err == nil {
+		t.Fatal("MASK_LAST without a count: expected error")
+	}
+
+	masked := &Session{}
+	masked.SetUnmask(func(string) bool { return false })
+	unmasked := &Session{}
+	unmasked.SetUnmask(func(table string) bool { return table == "customers" })
+	row := func(s *Session, sql string) string {
+		t.Helper()
+		ctx := context.Background()
+		if s != nil {
+			ctx = WithSession(ctx, s)
+		}
+		rows, err := db.Query(ctx, sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		defer rows.Close()
+		var out []string
+		for rows.Next() {
+			var a, b, c, d, e interface{}
+			dest := []interface{}{&a, &b, &c, &d, &e}[:len(rows.Columns())]
+			if err := rows.Scan(dest...); err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			for _, v := range dest {
+				out = append(out, fmt.Sprint(*(v.(*interface{}))))
+			}
+		}
+		return strings.Join(out, ",")
+	}
+
+	const all = "SELECT card, email, name, balance, city FROM customers ORDER BY id"
+	if got, want := row(masked, all), "XXXXXXXXXXXX1111,aXXX@XXXX.com,XXXX,0,Oslo,<nil>,bXXX@XXXX.com,XXXX,0,Rome"; got != want {
+		t.Fatalf("masked read = %s, want %s", got, want)
+	}
+	for _, s := range []*Session{nil, unmasked} {
+		if got, want := row(s, all), "4111111111111111,ann@example.com,Ann,250,Oslo,<nil>,bob@example.com,Bob,75,Rome"; got != want {
+			t.Fatalf("unmasked read = %s, want %s", got, want)
+		}
+	}
+	// Filters, aggregates and subqueries see the masked values too.
+	if got := row(masked, "SELECT id FROM customers WHERE name = 'Ann'"); got != "" {
+		t.Fatalf("masked filter matched %s", got)
+	}
+	if got := row(masked, "SELECT MAX(balance) FROM customers"); got != "0" {
+		t.Fatalf("masked MAX = %s, want 0", got)
+	}
+	if got := row(masked, "SELECT city FROM customers WHERE id IN (SELECT id FROM customers WHERE email LIKE 'ann%')"); got != "" {
+		t.Fatalf("masked subquery matched %s", got)
+	}
+	if got := row(unmasked, "SELECT city FROM customers WHERE id IN (SELECT id FROM customers WHERE email LIKE 'ann%')"); got != "Oslo" {
+		t.Fatalf("unmasked subquery = %s, want Oslo", got)
+	}
+	if got := row(masked, "SELECT MASK_LAST('555-1234', 4), MASK_FIRST('secret', 2)"); got != "XXXX1234,seXXXX" {
+		t.Fatalf("mask functions = %s", got)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Masks survive a restart and appear in the schema SQL.
+	db = openPreparedTestDB(t, path)
+	defer db.Close()
+	ddl, err := db.TableSchema("customers")
+	if err != nil {
+		t.Fatalf("TableSchema: %v", err)
+	}
+	for _, want := range []string{"MASKED WITH MASK_LAST(4)", "MASKED WITH MASK_EMAIL()", "name TEXT MASKED"} {
+		if !strings.Contains(ddl, want) {
+			t.Fatalf("schema SQL %q lacks %q", ddl, want)
+		}
+	}
+	if got := row(masked, "SELECT card FROM customers WHERE id = 1"); got != "XXXXXXXXXXXX1111" {
+		t.Fatalf("masked read after restart = %s", got)
+	}
+}
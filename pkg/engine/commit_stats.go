@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/metrics"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// CommitStats breaks down where commits spend their time.
+type CommitStats struct {
+	// Latency is the whole commit as its caller saw it.
+	Latency metrics.LatencySnapshot `json:"latency"`
+	// LockWait is the time writers waited for locks: the commit gates and,
+	// with a busy timeout, the table locks of INSERT, UPDATE and DELETE.
+	LockWait metrics.LatencySnapshot `json:"lock_wait"`
+	// WAL append and fsync wait, the group commit window and how many
+	// commits each fsync covered. Zero without a WAL.
+	storage.WALStats
+}
+
+func (db *DB) observeCommit(start time.Time) {
+	db.commitLatency.Observe(time.Since(start))
+}
+
+func (db *DB) commitStats() *CommitStats {
+	stats := &CommitStats{
+		Latency:  db.commitLatency.Snapshot(),
+		LockWait: db.commitLockWait.Snapshot(),
+	}
+	if db.wal != nil {
+		stats.WALStats = db.wal.Stats()
+	}
+	return stats
+}
+
+// configureGroupCommit sets up WAL fsync batching for the sync mode in
+// effect. SyncNormal coalesces concurrent commits into one fsync: led by
+// the waiting commits themselves, or with CoreStorage.GroupCommitWindow
+// set, by a flusher with an adaptive window of at most that long. SyncOff
+// never waits for an fsync; SyncFull syncs each commit on its own.
+func (db *DB) configureGroupCommit(wal *storage.WAL) {
+	switch db.SyncMode() {
+	case SyncNormal:
+		if window := db.options.CoreStorage.GroupCommitWindow; window > 0 {
+			wal.EnableAdaptiveGroupCommit(0, window)
+		} else {
+			wal.EnableLeaderGroupCommit()
+		}
+		wal.SetGroupCommitBatch(db.options.CoreStorage.GroupCommitBatch)
+	case SyncOff:
+		wal.EnableGroupCommit(0, 0)
+	case SyncFull:
+		wal.DisableGroupCommit()
+	}
+}
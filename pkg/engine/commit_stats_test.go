@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsCommitLatency(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "commit.db"), &Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+
+	const writers, perWriter = 4, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				tx, err := db.Begin(ctx)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO t VALUES (%d, 'x')", w*perWriter+i)); err != nil {
+					_ = tx.Rollback()
+					errs <- err
+					return
+				}
+				if err := tx.Commit(); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("writer: %v", err)
+	}
+	mustExec(t, db, "INSERT INTO t VALUES (1000, 'autocommit')")
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	c := stats.Commit
+	if c.Latency.Count < writers*perWriter+1 {
+		t.Errorf("commit latency count = %d, want at least %d", c.Latency.Count, writers*perWriter+1)
+	}
+	if c.LockWait.Count < writers*perWriter {
+		t.Errorf("lock wait count = %d, want at least %d", c.LockWait.Count, writers*perWriter)
+	}
+	if c.FsyncWait.Count == 0 || c.AppendLatency.Count == 0 || c.Fsyncs == 0 {
+		t.Errorf("WAL stats not recorded: %+v", c.WALStats)
+	}
+	if !c.LeaderFollower {
+		t.Errorf("default sync mode should use leader/follower group commit")
+	}
+	if c.Latency.Quantile(0.99) < c.Latency.Quantile(0.5) {
+		t.Errorf("p99 %v below p50 %v", c.Latency.Quantile(0.99), c.Latency.Quantile(0.5))
+	}
+}
+
+func TestSyncModes(t *testing.T) {
+	const commits = 10
+	fsyncs := func(opts *Options) (SyncMode, uint64) {
+		t.Helper()
+		db, err := Open(filepath.Join(t.TempDir(), "sync.db"), opts)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		defer db.Close()
+		mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+		before := db.wal.Stats().Fsyncs
+		for i := 0; i < commits; i++ {
+			mustExec(t, db, fmt.Sprintf("INSERT INTO t VALUES (%d)", i))
+		}
+		return db.SyncMode(), db.wal.Stats().Fsyncs - before
+	}
+
+	// The zero value is SyncNormal, so SyncOff can be chosen explicitly.
+	if mode, n := fsyncs(&Options{CoreStorage: CoreStorage{SyncMode: SyncOff}}); mode != SyncOff || n != 0 {
+		t.Errorf("SyncOff: mode %v, %d fsyncs; want off and none", mode, n)
+	}
+	if mode, n := fsyncs(&Options{CoreStorage: CoreStorage{SyncMode: SyncFull}}); mode != SyncFull || n < commits {
+		t.Errorf("SyncFull: mode %v, %d fsyncs; want full and at least %d", mode, n, commits)
+	}
+	if mode, n := fsyncs(&Options{}); mode != SyncNormal || n == 0 {
+		t.Errorf("default: mode %v, %d fsyncs; want normal and some", mode, n)
+	}
+	// A batch of one fsyncs every commit without waiting out the window.
+	opts := &Options{CoreStorage: CoreStorage{GroupCommitWindow: time.Second, GroupCommitBatch: 1}}
+	start := time.Now()
+	if _, n := fsyncs(opts); n < commits {
+		t.Errorf("GroupCommitBatch 1: %d fsyncs, want at least %d", n, commits)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GroupCommitBatch 1 took %v; commits waited for the window", elapsed)
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "bad.db"), &Options{CoreStorage: CoreStorage{SyncMode: 7}}); err == nil {
+		t.Error("Open accepted an invalid sync mode")
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompressionDictionarySQL(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "dict.db")
+	db, err := Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT, body TEXT)")
+	for i := 1; i <= 200; i++ {
+		mustExec(t, db, fmt.Sprintf(
+			"INSERT INTO events VALUES (%d, 'kind%d', 'order %d shipped to warehouse %d via express courier, tracking pending')", i, i%4, i, i%5))
+	}
+	const q = "SELECT id, kind, body FROM events WHERE id IN (3, 150, 201) ORDER BY id"
+	want := queryStrings(t, db, q)
+
+	mustExec(t, db, "ALTER TABLE events SET COMPRESSION DICTIONARY")
+	if got := queryStrings(t, db, q); !slices.Equal(got, want) {
+		t.Errorf("after compression = %v, want %v", got, want)
+	}
+
+	// Writes after training are compressed and read back as written.
+	mustExec(t, db, "INSERT INTO events VALUES (201, 'kind1', 'order 201 shipped to warehouse 1 via express courier, tracking pending')")
+	mustExec(t, db, "UPDATE events SET kind = 'late' WHERE id = 150")
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE events SET kind = 'rolled back' WHERE id = 3"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	want = queryStrings(t, db, q)
+	if len(want) != 3 || want[1] != "150|late|order 150 shipped to warehouse 0 via express courier, tracking pending" {
+		t.Fatalf("rows = %v", want)
+	}
+	mustExec(t, db, "VACUUM events")
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	db, err = Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	if got := queryStrings(t, db, q); !slices.Equal(got, want) {
+		t.Errorf("after reopen = %v, want %v", got, want)
+	}
+	if got := queryStrings(t, db, "SELECT COUNT(*) FROM events WHERE body LIKE '%express%'"); !slices.Equal(got, []string{"201"}) {
+		t.Errorf("count = %v", got)
+	}
+	mustExec(t, db, "ALTER TABLE events SET COMPRESSION NONE")
+	if got := queryStrings(t, db, q); !slices.Equal(got, want) {
+		t.Errorf("after decompression = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,397 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// configEnvPrefix prefixes the environment variables that override Options.
+const configEnvPrefix = "COBALTDB_"
+
+// OpenWithConfig opens the database at path with options read by LoadConfig
+// from configPath, so deployments can tune the engine without flag handling
+// of their own.
+func OpenWithConfig(path, configPath string) (*DB, error) {
+	opts, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return Open(path, opts)
+}
+
+// LoadConfig returns DefaultOptions overridden first by the TOML file at
+// configPath (skipped when configPath is empty) and then by the environment.
+//
+// Every table of the file is an Options group and every key one of its
+// settings, both in snake_case:
+//
+//	[core_storage]
+//	cache_size = 4096
+//	sync_mode = "full"     # off, normal or full
+//
+//	[connection_pool]
+//	busy_timeout = "5s"
+//
+//	[result_limits]
+//	max_result_rows = 100000
+//	result_overflow = "cursor"
+//
+// The environment variable COBALTDB_<GROUP>_<SETTING>, upper-cased, overrides
+// the same setting, e.g. COBALTDB_CORE_STORAGE_CACHE_SIZE=4096. Durations
+// use time.ParseDuration syntax. Settings that hold Go values, such as
+// CoreStorage.Logger or Security.AuditConfig, can only be set in code.
+func LoadConfig(configPath string) (*Options, error) {
+	opts := DefaultOptions()
+	settings := configSettings(opts)
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+		if err := applyConfigFile(settings, data, configPath); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range settings {
+		v, ok := os.LookupEnv(s.env)
+		if !ok {
+			continue
+		}
+		if err := setConfigValue(s.field, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+	return opts, nil
+}
+
+// configSetting is one Options field that a config file can set.
+type configSetting struct {
+	name   string // group.setting
+	env    string
+	secret bool
+	field  reflect.Value
+}
+
+// configNameOverrides spells the field names configName would run together.
+var configNameOverrides = map[string]string{"SSLCA": "ssl_ca"}
+
+// configSecrets are the settings SHOW CONFIG masks.
+var configSecrets = map[string]bool{
+	"security.encryption_key": true,
+	"replication.auth_token":  true,
+	"backup.encryption_key":   true,
+}
+
+// configSettings lists the settable fields of opts' groups in declaration
+// order. Deprecated flat fields are not groups and are left out.
+func configSettings(opts *Options) []configSetting {
+	var settings []configSetting
+	ov := reflect.ValueOf(opts).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		gf := ov.Type().Field(i)
+		if !gf.IsExported() || gf.Type.Kind() != reflect.Struct {
+			continue
+		}
+		group := configName(gf.Name)
+		gv := ov.Field(i)
+		for j := 0; j < gv.NumField(); j++ {
+			f := gv.Type().Field(j)
+			if !f.IsExported() || !configSettable(f.Type) {
+				continue
+			}
+			name := group + "." + configName(f.Name)
+			settings = append(settings, configSetting{
+				name:   name,
+				env:    configEnvPrefix + strings.ToUpper(strings.ReplaceAll(name, ".", "_")),
+				secret: configSecrets[name],
+				field:  gv.Field(j),
+			})
+		}
+	}
+	return settings
+}
+
+// configName converts a Go field name to snake_case, keeping initialisms
+// together: WALEnabled becomes wal_enabled.
+func configName(s string) string {
+	if name, ok := configNameOverrides[s]; ok {
+		return name
+	}
+	r := []rune(s)
+	var b strings.Builder
+	for i, c := range r {
+		if unicode.IsUpper(c) && i > 0 {
+			prev := r[i-1]
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+	boolPtrType  = reflect.TypeOf((*bool)(nil))
+)
+
+func configSettable(t reflect.Type) bool {
+	switch t {
+	case bytesType, boolPtrType:
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// setConfigValue parses s as the type of f and stores it.
+func setConfigValue(f reflect.Value, s string) error {
+	switch f.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	case reflect.TypeOf(SyncMode(0)):
+		m, err := parseSyncMode(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(m))
+		return nil
+	case reflect.TypeOf(ResultOverflow(0)):
+		switch strings.ToLower(s) {
+		case "error":
+			f.SetInt(int64(ResultOverflowError))
+		case "cursor":
+			f.SetInt(int64(ResultOverflowCursor))
+		default:
+			return fmt.Errorf("invalid result overflow %q (want error or cursor)", s)
+		}
+		return nil
+	case bytesType:
+		f.SetBytes([]byte(s))
+		return nil
+	case boolPtrType:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(&b))
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.Type())
+	}
+	return nil
+}
+
+// formatConfigValue renders f the way setConfigValue reads it.
+func formatConfigValue(f reflect.Value) string {
+	switch v := f.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case SyncMode:
+		return v.String()
+	case ResultOverflow:
+		return v.String()
+	case []byte:
+		return string(v)
+	case *bool:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatBool(*v)
+	}
+	return fmt.Sprint(f.Interface())
+}
+
+func parseSyncMode(s string) (SyncMode, error) {
+	switch strings.ToLower(s) {
+	case "off":
+		return SyncOff, nil
+	case "normal":
+		return SyncNormal, nil
+	case "full":
+		return SyncFull, nil
+	}
+	return 0, fmt.Errorf("invalid sync mode %q (want off, normal or full)", s)
+}
+
+func (m SyncMode) valid() bool {
+	return m == SyncOff || m == SyncNormal || m == SyncFull
+}
+
+// String returns the sync mode name.
+func (m SyncMode) String() string {
+	switch m {
+	case SyncOff:
+		return "off"
+	case SyncNormal:
+		return "normal"
+	case SyncFull:
+		return "full"
+	default:
+		return fmt.Sprintf("SyncMode(%d)", int(m))
+	}
+}
+
+// applyConfigFile applies the TOML subset LoadConfig reads: [group] tables,
+// key = value pairs (dotted group.key at the top level), # comments, and
+// basic or literal strings, booleans, integers and floats as values.
+func applyConfigFile(settings []configSetting, data []byte, path string) error {
+	byName := make(map[string]configSetting, len(settings))
+	for _, s := range settings {
+		byName[s.name] = s
+	}
+	group := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%s:%d: %s", path, i+1, fmt.Sprintf(format, args...))
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 || strings.HasPrefix(line, "[[") || !isConfigComment(line[end+1:]) {
+				return fail("invalid table header %q", line)
+			}
+			group = strings.TrimSpace(line[1:end])
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return fail("expected key = value")
+		}
+		key := strings.TrimSpace(line[:eq])
+		if group != "" {
+			key = group + "." + key
+		}
+		s, ok := byName[key]
+		if !ok {
+			return fail("unknown setting %s", key)
+		}
+		value, err := parseConfigValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return fail("%s: %v", key, err)
+		}
+		if err := setConfigValue(s.field, value); err != nil {
+			return fail("%s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// parseConfigValue returns the text of a TOML value with quotes and escapes
+// removed, dropping any trailing comment.
+func parseConfigValue(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	switch s[0] {
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c == '"':
+				if !isConfigComment(s[i+1:]) {
+					return "", fmt.Errorf("unexpected text after string")
+				}
+				return b.String(), nil
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case '"', '\\':
+					b.WriteByte(s[i])
+				default:
+					return "", fmt.Errorf("unsupported escape \\%c", s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated string")
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if !isConfigComment(s[end+2:]) {
+			return "", fmt.Errorf("unexpected text after string")
+		}
+		return s[1 : end+1], nil
+	case '[', '{':
+		return "", fmt.Errorf("arrays and inline tables are not supported")
+	}
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+func isConfigComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
+
+func (db *DB) executeShowConfigQuery() (*Rows, error) {
+	opts := *db.options
+	// The busy timeout and lock granularity can change after Open.
+	opts.ConnectionPool.BusyTimeout = db.BusyTimeout()
+	opts.ConnectionPool.RowLocks = db.RowLocks()
+	settings := configSettings(&opts)
+	rows := make([][]interface{}, 0, len(settings))
+	for _, s := range settings {
+		value := formatConfigValue(s.field)
+		if s.secret && value != "" {
+			value = "********"
+		}
+		rows = append(rows, []interface{}{s.name, value})
+	}
+	return &Rows{
+		columns: []string{"Variable_name", "Value"},
+		rows:    rows,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cobalt.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+# engine settings
+replication.ssl_ca = "/etc/ca.pem"
+
+[core_storage]
+cache_size = 4_096
+wal_enabled = false
+sync_mode = "full"   # off, normal or full
+
+[connection_pool]
+busy_timeout = "250ms"
+
+[security]
+encryption_key = 'k3y # not a comment'
+
+[result_limits]
+max_result_rows = 100
+result_overflow = "cursor"
+
+[maintenance]
+auto_vacuum_threshold = 0.5
+`)
+	t.Setenv("COBALTDB_CORE_STORAGE_CACHE_SIZE", "2048")
+	t.Setenv("COBALTDB_RESULT_LIMITS_MAX_RESULT_BYTES", "1048576")
+
+	opts, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if opts.CoreStorage.CacheSize != 2048 {
+		t.Errorf("cache_size = %d, want the environment's 2048", opts.CoreStorage.CacheSize)
+	}
+	if opts.CoreStorage.WALEnabled == nil || *opts.CoreStorage.WALEnabled {
+		t.Errorf("wal_enabled = %v, want false", opts.CoreStorage.WALEnabled)
+	}
+	if opts.CoreStorage.SyncMode != SyncFull {
+		t.Errorf("sync_mode = %v, want full", opts.CoreStorage.SyncMode)
+	}
+	if opts.ConnectionPool.BusyTimeout != 250*time.Millisecond {
+		t.Errorf("busy_timeout = %v", opts.ConnectionPool.BusyTimeout)
+	}
+	if string(opts.Security.EncryptionKey) != "k3y # not a comment" {
+		t.Errorf("encryption_key = %q", opts.Security.EncryptionKey)
+	}
+	if opts.ResultLimits.MaxResultRows != 100 || opts.ResultLimits.MaxResultBytes != 1<<20 ||
+		opts.ResultLimits.ResultOverflow != ResultOverflowCursor {
+		t.Errorf("result limits = %+v", opts.ResultLimits)
+	}
+	if opts.Maintenance.AutoVacuumThreshold != 0.5 {
+		t.Errorf("auto_vacuum_threshold = %v", opts.Maintenance.AutoVacuumThreshold)
+	}
+	if opts.Replication.SSLCA != "/etc/ca.pem" {
+		t.Errorf("ssl_ca = %q", opts.Replication.SSLCA)
+	}
+	// Unset settings keep their defaults.
+	if opts.ConnectionPool.MaxConnections != DefaultOptions().ConnectionPool.MaxConnections {
+		t.Errorf("max_connections = %d, want the default", opts.ConnectionPool.MaxConnections)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, tt := range []struct {
+		config, want string
+	}{
+		{"[core_storage]\ncache_sise = 1", "cobalt.toml:2: unknown setting core_storage.cache_sise"},
+		{"cache_size = 1", "unknown setting cache_size"},
+		{"[core_storage]\nlogger = 'x'", "unknown setting core_storage.logger"},
+		{"[core_storage]\nsync_mode = 'sometimes'", "invalid sync mode"},
+		{"[connection_pool]\nquery_timeout = 30", "missing unit"},
+		{"[core_storage]\ncache_size = \"12", "unterminated string"},
+		{"[core_storage\n", "invalid table header"},
+		{"[parallel_query]\nworkers = [1, 2]", "arrays and inline tables"},
+	} {
+		_, err := LoadConfig(writeConfig(t, tt.config))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.config, err, tt.want)
+		}
+	}
+
+	t.Setenv("COBALTDB_CORE_STORAGE_IN_MEMORY", "maybe")
+	if _, err := LoadConfig(""); err == nil || !strings.Contains(err.Error(), "COBALTDB_CORE_STORAGE_IN_MEMORY") {
+		t.Errorf("err = %v, want an error naming the variable", err)
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
+
+func TestConfigName(t *testing.T) {
+	for name, want := range map[string]string{
+		"WALEnabled":       "wal_enabled",
+		"QueryCacheTTL":    "query_cache_ttl",
+		"StrictSQLParsing": "strict_sql_parsing",
+		"SSLCert":          "ssl_cert",
+		"SSLCA":            "ssl_ca",
+		"CoreStorage":      "core_storage",
+	} {
+		if got := configName(name); got != want {
+			t.Errorf("configName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestOpenWithConfigShowConfig(t *testing.T) {
+	path := writeConfig(t, `
+[core_storage]
+in_memory = true
+cache_size = 512
+sync_mode = "full"
+
+[replication]
+auth_token = "secret"
+`)
+	t.Setenv("COBALTDB_CONNECTION_POOL_BUSY_TIMEOUT", "2s")
+	db, err := OpenWithConfig(":memory:", path)
+	if err != nil {
+		t.Fatalf("OpenWithConfig: %v", err)
+	}
+	defer db.Close()
+	if db.BusyTimeout() != 2*time.Second {
+		t.Errorf("BusyTimeout() = %v, want 2s", db.BusyTimeout())
+	}
+	db.SetBusyTimeout(time.Second)
+
+	settings := map[string]string{}
+	for _, row := range queryStrings(t, db, "SHOW CONFIG") {
+		name, value, _ := strings.Cut(row, "|")
+		settings[name] = value
+	}
+	for name, want := range map[string]string{
+		"core_storage.cache_size":       "512",
+		"core_storage.sync_mode":        "full",
+		"core_storage.in_memory":        "true",
+		"connection_pool.busy_timeout":  "1s",
+		"replication.auth_token":        "********",
+		"security.encryption_key":       "",
+		"result_limits.result_overflow": "error",
+	} {
+		if got, ok := settings[name]; !ok || got != want {
+			t.Errorf("SHOW CONFIG %s = %q (present %v), want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := settings["core_storage.logger"]; ok {
+		t.Error("SHOW CONFIG lists core_storage.logger, which cannot be configured")
+	}
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotLeader is returned for a write to a cluster member that is not the
+// elected leader: only the leader takes writes.
+var ErrNotLeader = fmt.Errorf("%w: not the cluster leader", ErrReadOnly)
+
+// ConsensusLog is the replicated log of a consensus group, such as the Raft
+// group of package cluster. Attached to a database with AttachConsensus, it
+// takes the place of streaming replication: the leader proposes every
+// committed transaction to the log, and a write returns once the group has
+// committed it.
+type ConsensusLog interface {
+	// Propose appends a committed transaction's records to the log and
+	// returns its index. It is called in commit order with the WAL locked,
+	// so it must not wait for the network. An error means the database now
+	// holds a transaction the log does not, and the log must bring the
+	// database back in line, e.g. from a leader's snapshot.
+	Propose(data []byte) (index uint64, err error)
+	// WaitCommitted blocks until the entry at index is committed, or
+	// returns an error once it cannot tell whether it will be.
+	WaitCommitted(ctx context.Context, index uint64) error
+	// IsLeader reports whether this member may take writes.
+	IsLeader() bool
+	// Leader returns the address clients should send writes to, or "" if
+	// no leader is known.
+	Leader() string
+}
+
+// AttachConsensus makes log replicate the database. It needs the WAL, and
+// cannot be combined with streaming replication. Entries the log commits
+// elsewhere are applied with ApplyConsensusEntry and
+// RestoreConsensusSnapshot.
+func (db *DB) AttachConsensus(log ConsensusLog) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+	if db.wal == nil {
+		return errors.New("consensus replication requires the WAL")
+	}
+	if db.replicationMgr != nil {
+		return errors.New("consensus replication cannot be combined with streaming replication")
+	}
+	if db.consensus != nil {
+		return errors.New("a consensus log is already attached")
+	}
+	db.consensus = log
+	db.replicationCtx, db.cancelReplication = context.WithCancel(context.Background())
+	db.startWALShipping()
+	return nil
+}
+
+// proposeCommitted is the WAL shipper's send with a consensus log attached.
+func (db *DB) proposeCommitted(_ uint64, data []byte) {
+	index, err := db.consensus.Propose(data)
+	if err != nil {
+		db.noteBackgroundError("consensus", "", err)
+		return
+	}
+	db.consensusIndex.Store(index)
+}
+
+// awaitConsensus waits until the group has committed every transaction this
+// database has proposed so far, which includes the caller's own write.
+func (db *DB) awaitConsensus(ctx context.Context) error {
+	if db.consensus == nil {
+		return nil
+	}
+	index := db.consensusIndex.Load()
+	if index == 0 {
+		return nil
+	}
+	if err := db.consensus.WaitCommitted(ctx, index); err != nil {
+		return fmt.Errorf("write not confirmed by the cluster: %w", err)
+	}
+	return nil
+}
+
+// ApplyConsensusEntry applies a transaction the consensus log committed on
+// another member, as proposed there, and records index as applied.
+func (db *DB) ApplyConsensusEntry(index uint64, data []byte) error {
+	if err := db.applyReplicatedData(index, data); err != nil {
+		return err
+	}
+	db.consensusIndex.Store(index)
+	return nil
+}
+
+// ConsensusSnapshot returns the database file together with the index of
+// the last log entry it holds, for a member too far behind, or too far
+// astray, to catch up from the log.
+func (db *DB) ConsensusSnapshot() (data []byte, index uint64, err error) {
+	return db.takeSnapshot(db.consensusIndex.Load)
+}
+
+// RestoreConsensusSnapshot replaces the database with a ConsensusSnapshot
+// taken on another member.
+func (db *DB) RestoreConsensusSnapshot(data []byte, index uint64) error {
+	if err := db.applyReplicationSnapshot(data, 0); err != nil {
+		return err
+	}
+	db.consensusIndex.Store(index)
+	return nil
+}
+
+// ConsensusIndex returns the index of the last consensus log entry the
+// database holds.
+func (db *DB) ConsensusIndex() uint64 {
+	return db.consensusIndex.Load()
+}
+
+// SetConsensusIndex records the log index the database holds when a member
+// restarts; the log keeps it, the database does not.
+func (db *DB) SetConsensusIndex(index uint64) {
+	db.consensusIndex.Store(index)
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCreateTableAsSelect(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE src (id INTEGER PRIMARY KEY, name TEXT, data BLOB, score REAL)")
+	mustExec(t, db, "INSERT INTO src VALUES (1, 'a', X'00FF10', 1.5), (2, 'b', NULL, 2), (3, 'c', X'41', 3.25)")
+
+	// Column types come from the source columns, so BLOBs stay BLOBs.
+	mustExec(t, db, "CREATE TABLE copy AS SELECT * FROM src")
+	table, err := db.catalog.GetTable("copy")
+	if err != nil {
+		t.Fatalf("GetTable: %v", err)
+	}
+	var types []string
+	for _, col := range table.Columns {
+		types = append(types, col.Name+" "+col.Type)
+	}
+	if got := strings.Join(types, ", "); got != "id INTEGER, name TEXT, data BLOB, score REAL" {
+		t.Errorf("copy columns = %s", got)
+	}
+	var data []byte
+	if err := db.QueryRow(ctx, "SELECT data FROM copy WHERE id = 1").Scan(&data); err != nil || !bytes.Equal(data, []byte{0x00, 0xFF, 0x10}) {
+		t.Errorf("copied blob = %x, err = %v", data, err)
+	}
+	assertScalar(t, db, "SELECT name FROM copy WHERE id = 2", "b")
+
+	// Column lists, compound queries, WITH and temporary tables.
+	mustExec(t, db, "CREATE TABLE named (k, v) AS SELECT id, name FROM src WHERE id > 1")
+	assertScalar(t, db, "SELECT v FROM named WHERE k = 3", "c")
+	mustExec(t, db, "CREATE TABLE ids AS SELECT id FROM src UNION SELECT id + 10 FROM src")
+	assertScalar(t, db, "SELECT COUNT(*) FROM ids", int64(6))
+	mustExec(t, db, "CREATE TABLE cte AS WITH big AS (SELECT id FROM src WHERE score > 1.5) SELECT id FROM big")
+	assertScalar(t, db, "SELECT COUNT(*) FROM cte", int64(2))
+	mustExec(t, db, "CREATE TABLE cte_union AS WITH big AS (SELECT id FROM src WHERE score > 1.5) SELECT id FROM big UNION ALL SELECT id + 10 FROM big ORDER BY id DESC LIMIT 3")
+	assertScalar(t, db, "SELECT COUNT(*) FROM cte_union", int64(3))
+	assertScalar(t, db, "SELECT MIN(id) FROM cte_union", int64(3))
+	mustExec(t, db, "CREATE TEMP TABLE scratch AS SELECT id FROM src")
+	if table, err := db.catalog.GetTable("scratch"); err != nil || !table.Temporary {
+		t.Errorf("scratch: table = %+v, err = %v; want a temporary table", table, err)
+	}
+
+	if _, err := db.Exec(ctx, "CREATE TABLE dup AS SELECT s.id, c.id FROM src s JOIN copy c ON c.id = s.id"); err == nil || !strings.Contains(err.Error(), "duplicate column name") {
+		t.Errorf("duplicate output columns: err = %v", err)
+	}
+	if _, err := db.Exec(ctx, "CREATE TABLE short (a) AS SELECT id, name FROM src"); err == nil {
+		t.Error("created a table with fewer column names than the query returns")
+	}
+	for _, name := range []string{"dup", "short"} {
+		if _, err := db.catalog.GetTable(name); err == nil {
+			t.Errorf("failed CREATE TABLE %s AS left the table behind", name)
+		}
+	}
+
+	// Rows are inserted in batches.
+	mustExec(t, db, "CREATE TABLE many (id INTEGER PRIMARY KEY)")
+	for i := 0; i < ctasBatchSize*2+5; i += 500 {
+		var values []string
+		for j := i; j < i+500 && j < ctasBatchSize*2+5; j++ {
+			values = append(values, fmt.Sprintf("(%d)", j))
+		}
+		mustExec(t, db, "INSERT INTO many VALUES "+strings.Join(values, ", "))
+	}
+	mustExec(t, db, "CREATE TABLE many_copy AS SELECT id FROM many")
+	assertScalar(t, db, "SELECT COUNT(*) FROM many_copy", int64(ctasBatchSize*2+5))
+}
+
+func TestInsertSelectQueries(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE src (id INTEGER PRIMARY KEY, name TEXT, data BLOB)")
+	mustExec(t, db, "INSERT INTO src VALUES (1, 'a', X'0102'), (2, 'b', NULL)")
+	mustExec(t, db, "CREATE TABLE dst (id INTEGER PRIMARY KEY, name TEXT, data BLOB)")
+
+	mustExec(t, db, "INSERT INTO dst SELECT * FROM src")
+	var data []byte
+	if err := db.QueryRow(ctx, "SELECT data FROM dst WHERE id = 1").Scan(&data); err != nil || !bytes.Equal(data, []byte{0x01, 0x02}) {
+		t.Errorf("INSERT ... SELECT blob = %x, err = %v", data, err)
+	}
+
+	// Every arm of a set operation is inserted.
+	mustExec(t, db, "INSERT INTO dst (id, name) SELECT id + 10, name FROM src UNION ALL SELECT id + 20, name FROM src")
+	assertScalar(t, db, "SELECT COUNT(*) FROM dst", int64(6))
+	assertScalar(t, db, "SELECT name FROM dst WHERE id = 22", "b")
+
+	mustExec(t, db, "INSERT INTO dst (id, name) WITH s AS (SELECT id, name FROM src) SELECT id + 30, name FROM s")
+	assertScalar(t, db, "SELECT name FROM dst WHERE id = 31", "a")
+	mustExec(t, db, "INSERT INTO dst (id, name) WITH s AS (SELECT id, name FROM src) SELECT id + 60, name FROM s UNION ALL SELECT id + 70, name FROM s")
+	assertScalar(t, db, "SELECT COUNT(*) FROM dst WHERE id > 60", int64(4))
+	assertScalar(t, db, "SELECT name FROM dst WHERE id = 72", "b")
+
+	rows := queryRows(t, db, "INSERT INTO dst (id, name) SELECT id + 40, name FROM src UNION SELECT id + 50, name FROM src RETURNING id")
+	if len(rows) != 4 {
+		t.Errorf("RETURNING rows = %v", rows)
+	}
+	mustExec(t, db, "INSERT INTO dst (id, name) SELECT id, name FROM src UNION SELECT 3, 'c' ON CONFLICT (id) DO UPDATE SET name = 'z'")
+	assertScalar(t, db, "SELECT name FROM dst WHERE id = 1", "z")
+	assertScalar(t, db, "SELECT name FROM dst WHERE id = 3", "c")
+}
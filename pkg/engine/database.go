@@ -25,7 +25,9 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/replication"
 	"github.com/cobaltdb/cobaltdb/pkg/scheduler"
 	"github.com/cobaltdb/cobaltdb/pkg/security"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
 	"github.com/cobaltdb/cobaltdb/pkg/txn"
 )
 
@@ -48,6 +50,8 @@ func toUpperFast(s string) string {
 var (
 	ErrDatabaseClosed = errors.New("database is closed")
 	ErrInvalidPath    = errors.New("invalid database path")
+	ErrNotEncrypted   = errors.New("database is not encrypted")
+	ErrReadOnly       = errors.New("database is read-only")
 )
 
 // PanicRecovery records the most recent panic recovered from a public query API.
@@ -67,8 +71,13 @@ type DB struct {
 	catalog  *catalog.Catalog
 	txnMgr   *txn.Manager
 	rootTree *btree.BTree
-	mu       sync.RWMutex
-	closed   atomic.Bool
+	// freeListID is the freelist chain Close writes for the meta page.
+	freeListID uint32
+	mu         sync.RWMutex
+	closed     atomic.Bool
+	// readOnly is set for a backend that accepts no writes, such as one
+	// serving the file over HTTP; statements that write are refused.
+	readOnly bool
 	options  *Options
 	// Security components
 	auditLogger *audit.Logger     // Audit logger
@@ -76,8 +85,11 @@ type DB struct {
 	// Prepared statement cache for performance (LRU via doubly-linked list)
 	stmtCache map[string]*cachedStmt
 	stmtMu    sync.RWMutex
-	stmtLRU   *stmtLRUList  // O(1) eviction
-	nextTxnID atomic.Uint64 // Auto-increment transaction ID counter
+	stmtLRU   *stmtLRUList // O(1) eviction
+	// stmtSchemaVer is the catalog schema version the cached statements
+	// were parsed under; see dropStaleStatements.
+	stmtSchemaVer atomic.Uint64
+	nextTxnID     atomic.Uint64 // Auto-increment transaction ID counter
 	// backupMu serializes hot backup against concurrent checkpoints. Acquiring
 	// backupMu in BeginHotBackup blocks both DB.Checkpoint and WAL auto-checkpoint.
 	backupMu sync.Mutex
@@ -98,6 +110,26 @@ type DB struct {
 
 	// Replication Manager
 	replicationMgr *replication.Manager
+	// replicationCtx is canceled when Close stops replication, so an entry
+	// waiting out a Freeze gives up.
+	replicationCtx    context.Context
+	cancelReplication context.CancelFunc
+	// On a replica, the primary's LSN of the last applied entry and how
+	// long after its commit it was applied, in nanoseconds.
+	replicaAppliedLSN atomic.Uint64
+	replicaLag        atomic.Int64
+	// consensus replicates the database in place of replicationMgr; see
+	// AttachConsensus. consensusIndex is the last log entry the database
+	// holds.
+	consensus      ConsensusLog
+	consensusIndex atomic.Uint64
+	// prepared holds the records of each prepared transaction by ID; see
+	// Tx.Prepare.
+	prepared   map[string][]*storage.WALRecord
+	preparedMu sync.Mutex
+	// stopTTLSweeper stops the TTL sweeper and waits for it; nil when it
+	// does not run.
+	stopTTLSweeper func()
 
 	// Backup Manager
 	backupMgr *backup.Manager
@@ -106,6 +138,10 @@ type DB struct {
 	slowQueryLog           *metrics.SlowQueryLog
 	unregisterSlowQueryLog func()
 	unregisterStorageStats func()
+	unregisterPrometheus   func()
+
+	// queryCounters feed DBStats.Queries and WritePrometheus.
+	queryCounters queryCounters
 
 	// Query Plan Cache - caches parsed query statements
 	planCache *QueryPlanCache
@@ -121,6 +157,45 @@ type DB struct {
 
 	// IndexAdvisor analyzes queries and recommends missing indexes
 	indexAdvisor *advisor.IndexAdvisor
+
+	// writes blocks writing statements between Freeze and Thaw.
+	writes writeGate
+
+	// busyTimeout is the write lock wait, in nanoseconds; see SetBusyTimeout.
+	busyTimeout atomic.Int64
+
+	// rowLocks makes writers lock rows instead of tables; see SetRowLocks.
+	rowLocks atomic.Bool
+
+	// syncMode is the SyncMode in effect; see SetSyncMode.
+	syncMode atomic.Int32
+
+	// attached holds the databases added with ATTACH, by lower-cased name.
+	attachMu sync.RWMutex
+	attached map[string]*attachedDB
+
+	// searchPath is the default search path; nil for the main schema alone.
+	searchPath atomic.Pointer[[]string]
+
+	// session is the connection state of statements run without WithSession.
+	session Session
+
+	// ddlHooks are the OnDDL callbacks; eventHooks the OnEvent callbacks.
+	ddlHooks   hookList[DDLEvent]
+	eventHooks hookList[Event]
+
+	// changeHooks are the Subscribe queues; changeMu pairs changes to the
+	// list with installing or removing the catalog's capture hook.
+	changeHooks hookList[committedChanges]
+	changeMu    sync.Mutex
+
+	// flashback is the log AS OF queries rebuild rows from, when
+	// Maintenance.FlashbackRetention is set.
+	flashback *flashbackLog
+
+	// Commit latency, reported by Stats.
+	commitLatency  metrics.LatencyHistogram
+	commitLockWait metrics.LatencyHistogram
 }
 
 // LastPanicRecovery returns the latest panic recovered from Exec or Query.
@@ -146,6 +221,7 @@ func (db *DB) recordRecoveredPanic(operation string, recovered interface{}, stac
 	if db.options != nil && db.options.CoreStorage.Logger != nil {
 		db.options.CoreStorage.Logger.Errorf("PANIC in %s: %v\n%s", operation, recovered, stack)
 	}
+	db.emitEvent(Event{Kind: EventError, Operation: operation, Err: fmt.Errorf("recovered panic: %v", recovered), Time: info.At})
 }
 
 // CoreStorage contains the fundamental storage engine parameters.
@@ -154,8 +230,22 @@ type CoreStorage struct {
 	CacheSize  int            // Number of cached pages
 	InMemory   bool           // Run fully in-memory without persisting
 	WALEnabled *bool          // Enable write-ahead logging (nil = default: true for disk)
-	SyncMode   SyncMode       // Durability vs performance trade-off
+	SyncMode   SyncMode       // When commits wait for the WAL to reach disk (default SyncNormal)
 	Logger     *logger.Logger // Optional custom logger (nil = default)
+	// MMap serves page reads from a memory mapping of the database file
+	// rather than a read system call each; writes still go through the
+	// buffer pool and WAL. Ignored in memory and where mmap is unavailable.
+	MMap bool
+
+	// Under SyncNormal, concurrent commits share an fsync. By default the
+	// first waiting commit leads: it fsyncs at once for every commit
+	// queued, and commits arriving meanwhile form the next group. A
+	// positive GroupCommitWindow instead has a flusher wait up to that
+	// long for others to share the fsync (the window adapts below it to
+	// the commit rate), and GroupCommitBatch fsyncs as soon as that many
+	// commits are waiting (0 = only when the window closes).
+	GroupCommitWindow time.Duration
+	GroupCommitBatch  int
 }
 
 // ConnectionPool governs how concurrent database connections are managed.
@@ -163,16 +253,22 @@ type ConnectionPool struct {
 	MaxConnections    int           // Maximum concurrent connections (0 = unlimited)
 	ConnectionTimeout time.Duration // Timeout for acquiring a connection
 	QueryTimeout      time.Duration // Default query timeout (0 = no timeout)
+	BusyTimeout       time.Duration // How long a write waits for a table another transaction is writing (0 = no waiting); see DB.SetBusyTimeout
+	RowLocks          bool          // Lock the rows a write changes instead of its whole table; see DB.SetRowLocks
 }
 
 // Security governs encryption, auditing, and access control settings.
 type Security struct {
-	EncryptionKey    []byte                    // Encryption key for data at rest (nil = no encryption)
-	EncryptionConfig *storage.EncryptionConfig // Detailed encryption configuration
-	AuditConfig      *audit.Config             // Audit logging configuration (nil = disabled)
-	EnableRLS        bool                      // Enable Row-Level Security by default
-	MaxStmtCacheSize int                       // Maximum cached prepared statements (default: 1000)
-	StrictSQLParsing bool                      // Reject trailing tokens after a parsed statement
+	EncryptionKey       []byte                    // Encryption key for data at rest (nil = no encryption)
+	EncryptionConfig    *storage.EncryptionConfig // Detailed encryption configuration
+	AuditConfig         *audit.Config             // Audit logging configuration (nil = disabled)
+	EnableRLS           bool                      // Enable Row-Level Security by default
+	MaxStmtCacheSize    int                       // Maximum cached prepared statements (default: 1000)
+	StrictSQLParsing    bool                      // Reject trailing tokens after a parsed statement
+	StrictTypes         bool                      // Reject values that do not fit their column type (default: SQLite-style loose affinity)
+	CaseInsensitiveLike bool                      // LIKE ignores case, as after PRAGMA case_sensitive_like = off
+	DisableAttach       bool                      // Reject ATTACH DATABASE, which opens files by path
+	ColumnKeys          map[string][]byte         // AES keys ENCRYPT and DECRYPT use, by key ID; see DB.SetColumnKey
 }
 
 // QueryCacheConfig governs the query result cache.
@@ -201,6 +297,9 @@ type BackupConfig struct {
 	Retention        time.Duration // Backup retention period
 	MaxBackups       int           // Maximum number of backups to retain
 	CompressionLevel int           // Compression level (0-9, 0=disabled)
+	Encrypt          bool          // Encrypt backups (requires EncryptionKey or KeyFile)
+	EncryptionKey    []byte        // Backup key, separate from the database key; backups are HMAC-signed when set
+	KeyFile          string        // File holding the backup key when EncryptionKey is empty
 }
 
 // SlowQueryLogConfig governs slow query logging.
@@ -218,7 +317,8 @@ type PlanCacheConfig struct {
 	MaxEntries      int   // Max number of cached plans (default: 1000)
 }
 
-// MaintenanceConfig governs auto-vacuum and checkpoint settings.
+// MaintenanceConfig governs auto-vacuum, checkpoint, TTL sweeper and
+// flashback settings.
 type MaintenanceConfig struct {
 	EnableAutoVacuum    bool          // Enable automatic VACUUM (default: true for disk)
 	AutoVacuumInterval  time.Duration // Interval between auto-vacuum checks (default: 1m)
@@ -231,14 +331,27 @@ type MaintenanceConfig struct {
 	AutoVacuumRetention  time.Duration
 	EnableAutoCheckpoint bool          // Enable automatic WAL checkpoint (default: true for disk)
 	CheckpointInterval   time.Duration // Interval between checkpoints (default: 5m)
+	EnableTTLSweeper     bool          // Delete expired rows of TTL tables in the background (default: true)
+	TTLSweepInterval     time.Duration // Interval between TTL sweeps (default: 1m)
+	TTLBatchSize         int           // Rows deleted per sweep transaction (default: 1000)
+	// FlashbackRetention is how long the row changes of committed
+	// transactions are kept so AS OF queries can rebuild tables as they
+	// were, undoing updates and deletes. Changes are kept for this long in
+	// <path>.flashback, and survive a clean Close. Default: 0 (disabled).
+	FlashbackRetention time.Duration
 }
 
 // SchedulerConfig governs the background job scheduler.
 type SchedulerConfig struct {
 	EnableScheduler bool          // Enable job scheduler (default: true for disk)
 	AnalyzeInterval time.Duration // Interval for automatic ANALYZE (default: 1h)
-	Workers         int           // Number of scheduler workers (default: 2)
-	TickInterval    time.Duration // Dispatcher resolution (default: 1s)
+	// AnalyzeThreshold is the fraction of a table's rows that, once
+	// inserted, updated or deleted since its last ANALYZE, has it analyzed
+	// again at the next threshold check (default: 0.1 = 10%).
+	AnalyzeThreshold     float64
+	AnalyzeCheckInterval time.Duration // Interval between auto-analyze threshold checks (default: 1m)
+	Workers              int           // Number of scheduler workers (default: 2)
+	TickInterval         time.Duration // Dispatcher resolution (default: 1s)
 }
 
 // PageCompressionConfig holds page-level compression settings.
@@ -248,8 +361,13 @@ type PageCompressionConfig struct {
 
 // ParallelQueryConfig governs parallel query execution.
 type ParallelQueryConfig struct {
-	Workers   int // Number of parallel query workers (0 = disabled, default: NumCPU)
-	Threshold int // Min rows to trigger parallel execution (default: 1000)
+	Workers        int // Number of parallel query workers (0 = disabled, default: NumCPU)
+	Threshold      int // Min rows to trigger parallel execution (default: 1000)
+	MaxParallelism int // Max workers one table scan or hash-join probe uses (0 = Workers)
+	// UnorderedScans lets SELECTs without ORDER BY return rows in any order.
+	// By default they return rows in primary key order (insertion order for
+	// tables without a primary key).
+	UnorderedScans bool
 }
 
 // Options contains database configuration options
@@ -257,6 +375,7 @@ type Options struct {
 	CoreStorage
 	ConnectionPool
 	Security
+	ResultLimits
 
 	// Deprecated: use CoreStorage.InMemory.
 	InMemory bool
@@ -350,9 +469,27 @@ type Options struct {
 	Scheduler       SchedulerConfig
 	PageCompression PageCompressionConfig
 	ParallelQuery   ParallelQueryConfig
+
+	// SearchPath is the default search path: the schemas searched, in
+	// order, for table names given without a schema (nil = the main schema
+	// alone). See DB.SetSearchPath.
+	SearchPath []string
+
+	// QueryLogger, when set, receives every statement with its fingerprint,
+	// duration, row counts and error.
+	QueryLogger QueryLogger
+	// QueryLogThreshold passes only statements that ran at least this long
+	// to QueryLogger, plus every failed one (0 = all statements).
+	QueryLogThreshold time.Duration
+
+	// Tracer, when set, traces statements as spans under the span in the
+	// caller's context. A tracer attached to the context with
+	// tracing.WithTracer takes precedence.
+	Tracer tracing.Tracer
 }
 
-// SyncMode controls when data is synced to disk
+// SyncMode controls when commits wait for the WAL to reach disk, trading
+// durability for commit throughput.
 type SyncMode int
 
 // cachedStmt represents a cached prepared statement with metadata
@@ -426,8 +563,14 @@ func (l *stmtLRUList) removeTail() *stmtLRUEntry {
 }
 
 const (
-	SyncOff SyncMode = iota
-	SyncNormal
+	// SyncNormal, the default, makes every commit durable before it
+	// returns but lets concurrent commits share one fsync (group commit).
+	SyncNormal SyncMode = iota
+	// SyncOff never waits for an fsync, so a crash can lose recently
+	// committed transactions. The WAL is synced at checkpoints and on
+	// Close.
+	SyncOff
+	// SyncFull fsyncs each commit on its own.
 	SyncFull
 )
 
@@ -449,32 +592,23 @@ func (db *DB) RegisterFDW(name string, factory func() fdw.ForeignDataWrapper) {
 	db.catalog.GetFDWRegistry().Register(name, factory)
 }
 
-// getPreparedStatement returns a cached prepared statement or parses and caches it
+// getPreparedStatement returns a cached prepared statement or parses and caches it.
+// Statements are cached under their normalized SQL (see query.NormalizeSQL),
+// so one sent spaced or commented differently is parsed only once.
 
 func (db *DB) getPreparedStatement(sql string, args ...interface{}) (query.Statement, error) {
-	// First check plan cache if enabled (more sophisticated caching with size limits)
-	if db.planCache != nil {
-		if entry, found := db.planCache.getShared(sql, args); found {
-			return entry.ParsedStmt, nil
-		}
-	}
-
-	db.stmtMu.RLock()
-	cached, exists := db.stmtCache[sql]
-	db.stmtMu.RUnlock()
+	db.dropStaleStatements()
 
-	if exists {
-		// Best-effort LRU update: if the lock is uncontended bump the stats,
-		// otherwise skip rather than serialise every goroutine on stmtMu.
-		if db.stmtMu.TryLock() {
-			if c, ok := db.stmtCache[sql]; ok {
-				c.lastUsed = time.Now().Unix()
-				c.useCount++
-				db.stmtLRU.moveToFront(c.elem)
-			}
-			db.stmtMu.Unlock()
+	// Most repeated statements are sent with the same text, so look up the
+	// SQL as sent before normalizing it.
+	if stmt, ok := db.cachedStatement(sql, args); ok {
+		return stmt, nil
+	}
+	key := query.NormalizeSQL(sql)
+	if key != sql {
+		if stmt, ok := db.cachedStatement(key, args); ok {
+			return stmt, nil
 		}
-		return cached.stmt, nil
 	}
 
 	// Parse and cache
@@ -486,18 +620,22 @@ func (db *DB) getPreparedStatement(sql string, args ...interface{}) (query.State
 	if err != nil {
 		return nil, err
 	}
-	annotateDDLRawSQL(parsedStmt, sql)
+	if annotateDDLRawSQL(parsedStmt, sql) {
+		// The statement keeps its own text, which statements with the
+		// same normalized SQL do not share.
+		return parsedStmt, nil
+	}
 
 	// Cache in plan cache if enabled
 	if db.planCache != nil {
-		if err := db.planCache.Put(sql, args, parsedStmt); err != nil {
+		if err := db.planCache.Put(key, args, parsedStmt); err != nil {
 			return nil, err
 		}
 	}
 
 	// Cache the statement with O(1) LRU eviction
 	db.stmtMu.Lock()
-	if cached, exists := db.stmtCache[sql]; exists {
+	if cached, exists := db.stmtCache[key]; exists {
 		cached.lastUsed = time.Now().Unix()
 		cached.useCount++
 		db.stmtLRU.moveToFront(cached.elem)
@@ -511,22 +649,73 @@ func (db *DB) getPreparedStatement(sql string, args ...interface{}) (query.State
 	if len(db.stmtCache) >= maxCacheSize {
 		db.evictLRUEntry()
 	}
-	entry := &stmtLRUEntry{sql: sql}
+	entry := &stmtLRUEntry{sql: key}
 	cs := &cachedStmt{
 		stmt:     parsedStmt,
 		lastUsed: time.Now().Unix(),
 		useCount: 1,
-		sql:      sql,
+		sql:      key,
 		elem:     entry,
 	}
-	db.stmtCache[sql] = cs
+	db.stmtCache[key] = cs
 	db.stmtLRU.pushFront(entry)
 	db.stmtMu.Unlock()
 
 	return parsedStmt, nil
 }
 
-func annotateDDLRawSQL(stmt query.Statement, sql string) {
+// cachedStatement looks key up in the plan cache, if enabled, and the
+// statement cache.
+func (db *DB) cachedStatement(key string, args []interface{}) (query.Statement, bool) {
+	// First check plan cache if enabled (more sophisticated caching with size limits)
+	if db.planCache != nil {
+		if entry, found := db.planCache.getShared(key, args); found {
+			return entry.ParsedStmt, true
+		}
+	}
+
+	db.stmtMu.RLock()
+	cached, exists := db.stmtCache[key]
+	db.stmtMu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+	// Best-effort LRU update: if the lock is uncontended bump the stats,
+	// otherwise skip rather than serialise every goroutine on stmtMu.
+	if db.stmtMu.TryLock() {
+		if c, ok := db.stmtCache[key]; ok {
+			c.lastUsed = time.Now().Unix()
+			c.useCount++
+			db.stmtLRU.moveToFront(c.elem)
+		}
+		db.stmtMu.Unlock()
+	}
+	return cached.stmt, true
+}
+
+// dropStaleStatements empties the statement and plan caches after a schema
+// change, which DDL, replication or a restored snapshot make.
+func (db *DB) dropStaleStatements() {
+	ver := db.catalog.SchemaVersion()
+	if db.stmtSchemaVer.Load() == ver {
+		return
+	}
+	db.stmtMu.Lock()
+	defer db.stmtMu.Unlock()
+	if db.stmtSchemaVer.Load() == ver {
+		return
+	}
+	clear(db.stmtCache)
+	db.stmtLRU = newStmtLRUList()
+	if db.planCache != nil {
+		db.planCache.Clear()
+	}
+	db.stmtSchemaVer.Store(ver)
+}
+
+// annotateDDLRawSQL records sql in the DDL statements that keep their text,
+// reporting whether stmt is one.
+func annotateDDLRawSQL(stmt query.Statement, sql string) bool {
 	normalized := strings.TrimSpace(sql)
 	switch s := stmt.(type) {
 	case *query.CreateViewStmt:
@@ -537,7 +726,10 @@ func annotateDDLRawSQL(stmt query.Statement, sql string) {
 		s.RawSQL = normalized
 	case *query.CreateProcedureStmt:
 		s.RawSQL = normalized
+	default:
+		return false
 	}
+	return true
 }
 
 // evictLRUEntry removes the least recently used entry from the cache
@@ -694,24 +886,15 @@ func (db *DB) runStatement(ctx context.Context, methodName, sql string, args ...
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	// Apply default query timeout only if the caller did not already set one.
-	if db.options.ConnectionPool.QueryTimeout > 0 {
-		if _, hasDeadline := ctx.Deadline(); !hasDeadline {
-			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeout(ctx, db.options.ConnectionPool.QueryTimeout)
-			release = func() {
-				cancel()
-				db.releaseConnection()
-			}
-		} else {
-			release = db.releaseConnection
-		}
-	} else {
-		release = db.releaseConnection
+	ctx, cancel := db.statementContext(ctx)
+	release = func() {
+		cancel()
+		db.releaseConnection()
 	}
 
 	// Acquire connection
 	if acquireErr := db.acquireConnection(ctx); acquireErr != nil {
+		cancel()
 		return ctx, nil, time.Time{}, func() {}, acquireErr
 	}
 
@@ -761,9 +944,23 @@ func (db *DB) Exec(ctx context.Context, sql string, args ...interface{}) (result
 		if db.metrics != nil {
 			db.metrics.RecordError()
 		}
-		return Result{}, execErr
+		db.queryCounters.errors.Add(1)
+		err = statementLimitError(runCtx, execErr)
+		db.logQuery(runCtx, sql, start, 0, 0, err)
+		return Result{}, err
 	}
 	defer release()
+	defer func() { db.observeStatement(stmt, time.Since(start), result.RowsAffected, err) }()
+	defer func() { db.logQuery(runCtx, sql, start, result.RowsAffected, 0, err) }()
+
+	runCtx, span := db.startStatementSpan(runCtx, "cobaltdb.Exec", sql, stmt)
+	defer func() {
+		if tracing.Recording(span) {
+			span.SetAttributes(tracing.Int64("db.rows_affected", result.RowsAffected))
+		}
+		tracing.End(span, err)
+	}()
+	defer tracing.Bind(runCtx)()
 
 	// Metrics
 	if db.metrics != nil {
@@ -779,13 +976,30 @@ func (db *DB) Exec(ctx context.Context, sql string, args ...interface{}) (result
 			db.slowQueryLog.Log(sql, time.Since(start), result.RowsAffected, 0)
 		}()
 	}
+	defer func() { db.noteSlowQuery(sql, time.Since(start), result.RowsAffected) }()
 
-	return db.execute(runCtx, stmt, args)
+	finish := db.beginStatement(runCtx)
+	result, err = db.execute(runCtx, stmt, args)
+	if err == nil && !isReadOnlyStatement(stmt) {
+		err = db.awaitConsensus(runCtx)
+	}
+	if err = finish(err); err == nil {
+		db.fireDDLHooks(stmt, sql)
+		if vacuum, ok := stmt.(*query.VacuumStmt); ok {
+			db.emitEvent(Event{Kind: EventCompaction, Table: vacuum.Table, Duration: time.Since(start)})
+		}
+	}
+	return result, err
 }
 
 // Query executes a SQL query and returns rows
 
-func (db *DB) Query(ctx context.Context, sql string, args ...interface{}) (rows *Rows, err error) {
+func (db *DB) Query(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	return db.runQuery(ctx, sql, args, nil)
+}
+
+// runQuery runs a query for Query, or for QueryCursor when stream is set.
+func (db *DB) runQuery(ctx context.Context, sql string, args []interface{}, stream *rowStream) (rows *Rows, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			stack := debug.Stack()
@@ -802,9 +1016,23 @@ func (db *DB) Query(ctx context.Context, sql string, args ...interface{}) (rows
 		if db.metrics != nil {
 			db.metrics.RecordError()
 		}
-		return nil, execErr
+		db.queryCounters.errors.Add(1)
+		err = statementLimitError(runCtx, execErr)
+		db.logQuery(runCtx, sql, start, 0, 0, err)
+		return nil, err
 	}
 	defer release()
+	defer func() { db.observeStatement(stmt, time.Since(start), rows.rowCount(), err) }()
+	defer func() { db.logQuery(runCtx, sql, start, 0, rows.rowCount(), err) }()
+
+	runCtx, span := db.startStatementSpan(runCtx, "cobaltdb.Query", sql, stmt)
+	defer func() {
+		if tracing.Recording(span) {
+			span.SetAttributes(tracing.Int64("db.rows_returned", rows.rowCount()))
+		}
+		tracing.End(span, err)
+	}()
+	defer tracing.Bind(runCtx)()
 
 	// Metrics
 	if db.metrics != nil {
@@ -820,8 +1048,14 @@ func (db *DB) Query(ctx context.Context, sql string, args ...interface{}) (rows
 			db.slowQueryLog.Log(sql, time.Since(start), 0, 0)
 		}()
 	}
+	defer func() { db.noteSlowQuery(sql, time.Since(start), 0) }()
 
-	return db.query(runCtx, stmt, args)
+	sink, finish := db.beginQuery(runCtx, stmt, stream)
+	rows, err = db.query(runCtx, stmt, args)
+	if err == nil && !isReadOnlyStatement(stmt) {
+		err = db.awaitConsensus(runCtx)
+	}
+	return db.finishQuery(sink, rows, finish(err))
 }
 
 // QueryRow executes a SQL query and returns a single row
@@ -902,6 +1136,9 @@ func (db *DB) tableSchema(name string, includeForeignKeys, quoteIdentifiers bool
 			}
 			line += fmt.Sprintf("CHECK (%s)", schemaCheckExpr(col.CheckStr))
 		}
+		if col.Mask != nil {
+			line += " " + schemaColumnMask(col.Mask)
+		}
 		clauses = append(clauses, line)
 	}
 	if compositePK {
@@ -938,9 +1175,24 @@ func (db *DB) tableSchema(name string, includeForeignKeys, quoteIdentifiers bool
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", schemaIdentifier(table.Name, quoteIdentifiers)))
+	if table.HistoryOf != "" {
+		// CREATE TABLE ... WITH SYSTEM VERSIONING creates it too.
+		sb.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", schemaIdentifier(table.Name, quoteIdentifiers)))
+	} else {
+		sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", schemaIdentifier(table.Name, quoteIdentifiers)))
+	}
 	sb.WriteString(strings.Join(clauses, ",\n"))
-	sb.WriteString("\n);")
+	sb.WriteString("\n)")
+	if table.Engine != catalog.EngineBTree {
+		sb.WriteString(" USING " + table.Engine)
+	}
+	if table.TTLColumn != "" {
+		sb.WriteString(" WITH (ttl_column = " + schemaIdentifier(table.TTLColumn, quoteIdentifiers) + ")")
+	}
+	if table.HistoryTable != "" {
+		sb.WriteString(" WITH SYSTEM VERSIONING")
+	}
+	sb.WriteString(";")
 	return sb.String(), nil
 }
 
@@ -974,6 +1226,18 @@ func schemaCheckExpr(expr string) string {
 	return expr
 }
 
+// schemaColumnMask renders a column mask as its MASKED clause.
+func schemaColumnMask(mask *catalog.ColumnMask) string {
+	if mask.Function == "MASK" {
+		return "MASKED"
+	}
+	args := make([]string, len(mask.Args))
+	for i, arg := range mask.Args {
+		args[i] = strconv.FormatInt(arg, 10)
+	}
+	return fmt.Sprintf("MASKED WITH %s(%s)", mask.Function, strings.Join(args, ", "))
+}
+
 // TableForeignKeyRefs returns the distinct names of tables referenced by a
 // table's foreign keys, used to order a dump so referenced tables come first.
 func (db *DB) TableForeignKeyRefs(name string) []string {
@@ -1056,11 +1320,15 @@ func (db *DB) TableIndexDDL(name string) []string {
 		if idx.Unique {
 			unique = "UNIQUE "
 		}
+		cols := strings.Join(schemaIdentifierList(idx.Columns, true), ", ")
+		if idx.JSONPath != "" {
+			cols = fmt.Sprintf("JSON_EXTRACT(%s, '%s')", cols, strings.ReplaceAll(idx.JSONPath, "'", "''"))
+		}
 		ddl = append(ddl, fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);",
 			unique,
 			schemaIdentifier(idx.Name, true),
 			schemaIdentifier(name, true),
-			strings.Join(schemaIdentifierList(idx.Columns, true), ", ")))
+			cols))
 	}
 	return ddl
 }
@@ -1305,6 +1573,33 @@ func (db *DB) ProcedureDDL() []string {
 	return ddl
 }
 
+// SequenceDDL returns CREATE SEQUENCE statements for SQL dumps, each followed
+// by a SETVAL that restores the sequence's current value once it has been
+// used.
+func (db *DB) SequenceDDL() []string {
+	names := db.catalog.ListSequences()
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	ddl := make([]string, 0, len(names))
+	for _, name := range names {
+		seq, err := db.catalog.GetSequence(name)
+		if err != nil {
+			continue
+		}
+		stmt := fmt.Sprintf("CREATE SEQUENCE %s START WITH %d INCREMENT BY %d MINVALUE %d MAXVALUE %d",
+			schemaIdentifier(seq.Name, true), seq.Start, seq.Increment, seq.MinValue, seq.MaxValue)
+		if seq.Cycle {
+			stmt += " CYCLE"
+		}
+		ddl = append(ddl, stmt+";")
+		if seq.Called {
+			ddl = append(ddl, fmt.Sprintf("SELECT SETVAL(%s, %d);", schemaStringLiteral(seq.Name), seq.Last))
+		}
+	}
+	return ddl
+}
+
 // Begin starts a new transaction
 
 func (db *DB) Begin(ctx context.Context) (*Tx, error) {
@@ -1344,11 +1639,22 @@ func (db *DB) AbortConnTransaction() {
 	if db.closed.Load() || db.catalog == nil {
 		return
 	}
+	db.rollbackAttached(context.Background())
 	if db.catalog.IsTransactionActive() {
 		_ = db.catalog.RollbackTransaction()
 	}
 }
 
+// InConnTransaction reports whether the calling goroutine has a transaction
+// open from a BEGIN statement. Like AbortConnTransaction, it must run on the
+// connection's own handler goroutine.
+func (db *DB) InConnTransaction() bool {
+	if db.closed.Load() || db.catalog == nil {
+		return false
+	}
+	return db.catalog.IsTransactionActive()
+}
+
 // auditUser extracts the username from context for audit logging.
 
 func auditUser(ctx context.Context) string {
@@ -1369,9 +1675,10 @@ func auditUser(ctx context.Context) string {
 // should trigger a schema flush to disk for crash durability.
 func isSchemaDDL(stmt query.Statement) bool {
 	switch stmt.(type) {
-	case *query.CreateTableStmt, *query.CreateForeignTableStmt, *query.DropTableStmt,
+	case *query.CreateTableStmt, *query.CreateVirtualTableStmt, *query.CreateForeignTableStmt, *query.DropTableStmt,
 		*query.CreateIndexStmt, *query.DropIndexStmt, *query.AlterTableStmt,
-		*query.CreateViewStmt, *query.DropViewStmt:
+		*query.CreateViewStmt, *query.DropViewStmt, *query.CreateSequenceStmt, *query.DropSequenceStmt,
+		*query.CreateSchemaStmt, *query.DropSchemaStmt:
 		return true
 	}
 	return false
@@ -1397,11 +1704,6 @@ func (db *DB) dispatchDDL(ctx context.Context, action, table string, handler fun
 	if db.auditLogger != nil {
 		db.auditLogger.Log(audit.EventDDL, auditUser(ctx), action, opts...)
 	}
-	if err == nil {
-		if replErr := db.replicateWrite(action, table, nil); replErr != nil {
-			return result, replErr
-		}
-	}
 	return result, err
 }
 
@@ -1410,6 +1712,33 @@ func (db *DB) dispatchDDL(ctx context.Context, action, table string, handler fun
 func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interface{}) (result Result, err error) {
 	start := time.Now()
 
+	// Writes wait out a Freeze. Entered first so the schema flush and
+	// autocommit below also finish before a freeze proceeds.
+	if !isReadOnlyStatement(stmt) {
+		var exitGate func()
+		if ctx, exitGate, err = db.writes.enter(ctx); err != nil {
+			return Result{}, err
+		}
+		defer exitGate()
+		if db.closed.Load() {
+			return Result{}, ErrDatabaseClosed
+		}
+		if err := db.checkWritable(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	stmt = db.resolveSearchPath(ctx, stmt)
+	stmt, args, err = query.BindParams(stmt, args)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// ATTACH, DETACH and writes to attached databases run on their own.
+	if result, handled, err := db.executeAttachment(ctx, stmt, args); handled {
+		return result, err
+	}
+
 	// Flush the catalog schema to disk after a successful DDL so it survives an
 	// unclean shutdown before the first checkpoint. Registered before the
 	// autocommit defer below so it runs *after* the commit (defers are LIFO).
@@ -1451,16 +1780,29 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 					err = fmt.Errorf("%w; rollback failed: %v", err, rbErr)
 				}
 			} else {
+				defer db.observeCommit(time.Now())
 				if cmtErr := db.catalog.CommitTransaction(); cmtErr != nil {
+					// As in Tx.Commit, end the transaction so it does not keep
+					// its locks.
+					_ = db.catalog.RollbackTransaction()
 					err = fmt.Errorf("commit failed: %w", cmtErr)
 				}
 			}
 		}()
 	}
 
+	release, err := db.lockForWrite(stmt)
+	if err != nil {
+		return Result{}, err
+	}
+	defer release()
+	defer func() { err = rowBusyError(stmt, err) }()
+
 	switch s := stmt.(type) {
 	case *query.CreateTableStmt:
 		return db.dispatchDDL(ctx, "CREATE_TABLE", s.Table, func() (Result, error) { return db.executeCreateTable(ctx, s) }, audit.WithTable(s.Table))
+	case *query.CreateVirtualTableStmt:
+		return db.dispatchDDL(ctx, "CREATE_VIRTUAL_TABLE", s.Table, func() (Result, error) { return db.executeCreateVirtualTable(ctx, s) }, audit.WithTable(s.Table))
 	case *query.CreateForeignTableStmt:
 		result, err := db.executeCreateForeignTable(ctx, s)
 		if db.auditLogger != nil {
@@ -1480,33 +1822,18 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 		if db.auditLogger != nil {
 			db.auditLogger.LogQuery(auditUser(ctx), "INSERT", time.Since(start), result.RowsAffected, err)
 		}
-		if err == nil {
-			if replErr := db.replicateWrite("INSERT", s.Table, args); replErr != nil {
-				return result, replErr
-			}
-		}
 		return result, err
 	case *query.UpdateStmt:
 		result, err := db.executeUpdate(ctx, s, args)
 		if db.auditLogger != nil {
 			db.auditLogger.LogQuery(auditUser(ctx), "UPDATE", time.Since(start), result.RowsAffected, err)
 		}
-		if err == nil {
-			if replErr := db.replicateWrite("UPDATE", s.Table, args); replErr != nil {
-				return result, replErr
-			}
-		}
 		return result, err
 	case *query.DeleteStmt:
 		result, err := db.executeDelete(ctx, s, args)
 		if db.auditLogger != nil {
 			db.auditLogger.LogQuery(auditUser(ctx), "DELETE", time.Since(start), result.RowsAffected, err)
 		}
-		if err == nil {
-			if replErr := db.replicateWrite("DELETE", s.Table, args); replErr != nil {
-				return result, replErr
-			}
-		}
 		return result, err
 	case *query.DropTableStmt:
 		return db.dispatchDDL(ctx, "DROP_TABLE", s.Table, func() (Result, error) { return db.executeDropTable(ctx, s) }, audit.WithTable(s.Table))
@@ -1530,6 +1857,14 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 		return db.dispatchDDL(ctx, "CREATE_PROCEDURE", "", func() (Result, error) { return db.executeCreateProcedure(ctx, s) })
 	case *query.DropProcedureStmt:
 		return db.dispatchDDL(ctx, "DROP_PROCEDURE", "", func() (Result, error) { return db.executeDropProcedure(ctx, s) })
+	case *query.CreateSequenceStmt:
+		return db.dispatchDDL(ctx, "CREATE_SEQUENCE", "", func() (Result, error) { return Result{}, db.catalog.CreateSequence(s) })
+	case *query.DropSequenceStmt:
+		return db.dispatchDDL(ctx, "DROP_SEQUENCE", "", func() (Result, error) { return Result{}, db.catalog.DropSequence(s.Name, s.IfExists) })
+	case *query.CreateSchemaStmt:
+		return db.dispatchDDL(ctx, "CREATE_SCHEMA", "", func() (Result, error) { return Result{}, db.catalog.CreateSchema(s) })
+	case *query.DropSchemaStmt:
+		return db.dispatchDDL(ctx, "DROP_SCHEMA", "", func() (Result, error) { return db.executeDropSchema(ctx, s) })
 	case *query.CreatePolicyStmt:
 		return db.dispatchDDL(ctx, "CREATE_POLICY", s.Table, func() (Result, error) { return db.executeCreatePolicy(ctx, s) }, audit.WithTable(s.Table))
 	case *query.DropPolicyStmt:
@@ -1555,9 +1890,14 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 		if !db.catalog.IsTransactionActive() {
 			return Result{}, errors.New("no transaction in progress")
 		}
+		defer db.observeCommit(time.Now())
 		if err := db.catalog.FlushTableTrees(); err != nil {
 			return Result{}, fmt.Errorf("failed to flush tables: %w", err)
 		}
+		if err := db.commitAttached(ctx); err != nil {
+			_ = db.catalog.RollbackTransaction()
+			return Result{}, err
+		}
 		if err := db.catalog.CommitTransaction(); err != nil {
 			return Result{}, err
 		}
@@ -1573,6 +1913,7 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 			}
 			return Result{}, nil
 		}
+		db.rollbackAttached(ctx)
 		if err := db.catalog.RollbackTransaction(); err != nil {
 			return Result{}, err
 		}
@@ -1642,13 +1983,22 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 		}
 		return result, err
 	case *query.SetVarStmt:
-		// MySQL compatibility - accept SET commands silently
+		if strings.EqualFold(strings.TrimSpace(s.Variable), "seed") {
+			return Result{}, db.setRandomSeed(s.Value)
+		}
+		if value, ok := searchPathValue(s); ok {
+			return Result{}, db.setSearchPathVar(ctx, value)
+		}
+		// MySQL compatibility - accept other SET commands silently
 		return Result{}, nil
+	case *query.PragmaStmt:
+		_, err := db.executePragma(s)
+		return Result{}, err
 	case *query.UseStmt:
 		// MySQL compatibility - accept USE commands silently (single-database)
 		return Result{}, nil
 	case *query.ShowTablesStmt, *query.ShowCreateTableStmt, *query.ShowColumnsStmt,
-		*query.ShowDatabasesStmt, *query.DescribeStmt:
+		*query.ShowDatabasesStmt, *query.ShowConfigStmt, *query.DescribeStmt:
 		// These are query-like statements that return rows — use Query() instead
 		return Result{}, errors.New("use Query() instead of Exec() for SELECT/SHOW statements")
 	case *query.DropIndexStmt:
@@ -1673,6 +2023,9 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 		}
 		// Try regular index
 		if err := db.catalog.DropIndex(s.Index); err != nil {
+			if s.IfExists && errors.Is(err, catalog.ErrIndexNotFound) {
+				return Result{RowsAffected: 0}, nil
+			}
 			return Result{}, err
 		}
 		if db.auditLogger != nil {
@@ -1688,6 +2041,27 @@ func (db *DB) execute(ctx context.Context, stmt query.Statement, args []interfac
 
 func (db *DB) query(ctx context.Context, stmt query.Statement, args []interface{}) (*Rows, error) {
 	start := time.Now()
+	stmt = db.resolveSearchPath(ctx, stmt)
+	stmt, args, err := query.BindParams(stmt, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch stmt.(type) {
+	case *query.InsertStmt, *query.UpdateStmt, *query.DeleteStmt, *query.CallProcedureStmt:
+		gateCtx, exitGate, err := db.writes.enter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer exitGate()
+		if db.closed.Load() {
+			return nil, ErrDatabaseClosed
+		}
+		if err := db.checkWritable(); err != nil {
+			return nil, err
+		}
+		ctx = gateCtx
+	}
 
 	// Check for context cancellation
 	if ctx != nil {
@@ -1698,6 +2072,12 @@ func (db *DB) query(ctx context.Context, stmt query.Statement, args []interface{
 		}
 	}
 
+	release, err := db.lockForWrite(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	switch s := stmt.(type) {
 	case *query.SelectStmt:
 		rows, err := db.executeSelect(ctx, s, args)
@@ -1739,23 +2119,30 @@ func (db *DB) query(ctx context.Context, stmt query.Statement, args []interface{
 		return db.executeShowIndexQuery(ctx, s)
 	case *query.ShowDatabasesStmt:
 		return db.executeShowDatabasesQuery(ctx)
+	case *query.ShowConfigStmt:
+		return db.executeShowConfigQuery()
+	case *query.PragmaStmt:
+		return db.queryPragma(s)
 	case *query.DescribeStmt:
 		return db.executeDescribeQuery(ctx, s)
 	case *query.ExplainStmt:
-		return db.executeExplainQuery(ctx, s)
+		return db.executeExplainQuery(ctx, s, args)
 	case *query.InsertStmt:
 		if len(s.Returning) > 0 {
-			return db.executeInsertReturning(ctx, s, args)
+			rows, err := db.executeInsertReturning(ctx, s, args)
+			return rows, rowBusyError(s, err)
 		}
 		return nil, fmt.Errorf("not a query statement: %T", stmt)
 	case *query.UpdateStmt:
 		if len(s.Returning) > 0 {
-			return db.executeUpdateReturning(ctx, s, args)
+			rows, err := db.executeUpdateReturning(ctx, s, args)
+			return rows, rowBusyError(s, err)
 		}
 		return nil, fmt.Errorf("not a query statement: %T", stmt)
 	case *query.DeleteStmt:
 		if len(s.Returning) > 0 {
-			return db.executeDeleteReturning(ctx, s, args)
+			rows, err := db.executeDeleteReturning(ctx, s, args)
+			return rows, rowBusyError(s, err)
 		}
 		return nil, fmt.Errorf("not a query statement: %T", stmt)
 	case *query.CallProcedureStmt:
@@ -1768,14 +2155,16 @@ func (db *DB) query(ctx context.Context, stmt query.Statement, args []interface{
 // executeCreateTable executes CREATE TABLE
 
 func (db *DB) executeCreateTable(ctx context.Context, stmt *query.CreateTableStmt) (Result, error) {
-	if stmt.AsSelect != nil {
-		return db.executeCreateTableAsSelect(ctx, stmt)
-	}
+	// Checked before CTAS too: an existing table must not receive the
+	// SELECT's rows.
 	if stmt.IfNotExists {
 		if _, err := db.catalog.GetTable(stmt.Table); err == nil {
 			return Result{RowsAffected: 0}, nil
 		}
 	}
+	if stmt.AsSelect != nil {
+		return db.executeCreateTableAsSelect(ctx, stmt)
+	}
 	cleanupOnError := func(primary error) error {
 		if cleanupErr := db.catalog.CleanupFailedCreateTable(stmt.Table); cleanupErr != nil {
 			return fmt.Errorf("%w; cleanup failed: %v", primary, cleanupErr)
@@ -1820,59 +2209,170 @@ func (db *DB) executeCreateCollection(ctx context.Context, stmt *query.CreateCol
 	return Result{RowsAffected: 0}, nil
 }
 
+// ctasBatchSize is how many rows CREATE TABLE ... AS inserts per batch.
+const ctasBatchSize = 1000
+
 // executeCreateTableAsSelect implements CREATE TABLE ... AS SELECT (CTAS):
-// materialize the query, infer column types, create the table, insert the rows.
+// run the query, create a table with its columns, and insert its rows in
+// batches, releasing each batch once it is stored.
 func (db *DB) executeCreateTableAsSelect(ctx context.Context, stmt *query.CreateTableStmt) (Result, error) {
 	rows, err := db.query(ctx, stmt.AsSelect, nil)
 	if err != nil {
 		return Result{}, err
 	}
+	defer rows.Close()
+
 	cols := rows.Columns()
-	var data [][]interface{}
-	for rows.Next() {
-		vals := make([]interface{}, len(cols))
-		ptrs := make([]interface{}, len(cols))
-		for i := range vals {
-			ptrs[i] = &vals[i]
-		}
-		if err := rows.Scan(ptrs...); err != nil {
-			_ = rows.Close()
-			return Result{}, err
+	if len(stmt.AsColumns) > 0 {
+		if len(stmt.AsColumns) != len(cols) {
+			return Result{}, fmt.Errorf("CREATE TABLE %s: %d column names given but the query returns %d columns", stmt.Table, len(stmt.AsColumns), len(cols))
 		}
-		data = append(data, vals)
+		cols = stmt.AsColumns
 	}
-	if err := rows.Close(); err != nil {
-		return Result{}, err
+	seen := make(map[string]bool, len(cols))
+	for _, name := range cols {
+		if seen[strings.ToLower(name)] {
+			return Result{}, fmt.Errorf("CREATE TABLE %s: duplicate column name %s (alias the query's columns or give a column list)", stmt.Table, name)
+		}
+		seen[strings.ToLower(name)] = true
 	}
 
+	types := db.ctasColumnTypes(stmt.AsSelect, len(cols))
 	colDefs := make([]*query.ColumnDef, len(cols))
 	for i, name := range cols {
-		colDefs[i] = &query.ColumnDef{Name: name, Type: inferCTASColumnType(data, i)}
+		colType := types[i]
+		if colType == 0 {
+			colType = inferCTASColumnType(rows.rows, i)
+		}
+		colDefs[i] = &query.ColumnDef{Name: name, Type: colType}
 	}
-	createStmt := &query.CreateTableStmt{Table: stmt.Table, IfNotExists: stmt.IfNotExists, Columns: colDefs}
+	createStmt := &query.CreateTableStmt{Table: stmt.Table, IfNotExists: stmt.IfNotExists, Temporary: stmt.Temporary, Columns: colDefs, Engine: stmt.Engine, TTLColumn: stmt.TTLColumn}
 	if err := db.catalog.CreateTable(createStmt); err != nil {
 		return Result{}, err
 	}
 
 	inserted := int64(0)
-	for _, row := range data {
-		valExprs := make([]query.Expression, len(row))
-		for j, v := range row {
-			valExprs[j] = valueToLiteralExpr(v)
-		}
-		ins := &query.InsertStmt{Table: stmt.Table, Values: [][]query.Expression{valExprs}}
-		if _, n, err := db.catalog.Insert(ctx, ins, nil); err != nil {
-			return Result{}, fmt.Errorf("CTAS insert: %w", err)
-		} else {
-			inserted += n
+	ins := &query.InsertStmt{Table: stmt.Table, Columns: cols}
+	for start := 0; start < len(rows.rows); start += ctasBatchSize {
+		end := start + ctasBatchSize
+		if end > len(rows.rows) {
+			end = len(rows.rows)
+		}
+		batch, args := insertValues(ins, rows.rows[start:end], nil)
+		_, n, err := db.catalog.Insert(ctx, batch, args)
+		if err != nil {
+			err = fmt.Errorf("CTAS insert: %w", err)
+			if cleanupErr := db.catalog.CleanupFailedCreateTable(stmt.Table); cleanupErr != nil {
+				err = fmt.Errorf("%w; cleanup failed: %v", err, cleanupErr)
+			}
+			return Result{}, err
 		}
+		inserted += n
+		clear(rows.rows[start:end])
 	}
 	return Result{RowsAffected: inserted}, nil
 }
 
+// ctasColumnTypes returns the declared types of the query's columns that
+// are plain references to a table column, and 0 for the others, whose type
+// is inferred from their values.
+func (db *DB) ctasColumnTypes(stmt query.Statement, n int) []query.TokenType {
+	types := make([]query.TokenType, n)
+	sel, ok := stmt.(*query.SelectStmt)
+	if !ok || sel.From == nil {
+		return types
+	}
+	// Tables by the name the query refers to them by.
+	tables := make(map[string]*catalog.TableDef)
+	refs := []*query.TableRef{sel.From}
+	for _, join := range sel.Joins {
+		refs = append(refs, join.Table)
+	}
+	for _, ref := range refs {
+		if ref == nil || ref.Subquery != nil || ref.SubqueryStmt != nil {
+			continue
+		}
+		table, err := db.catalog.GetTable(ref.Name)
+		if err != nil {
+			continue
+		}
+		tables[strings.ToLower(ref.Name)] = table
+		if ref.Alias != "" {
+			tables[strings.ToLower(ref.Alias)] = table
+		}
+	}
+
+	if len(sel.Columns) == 1 && len(refs) == 1 {
+		if _, ok := sel.Columns[0].(*query.StarExpr); ok {
+			if table := tables[strings.ToLower(sel.From.Name)]; table != nil && len(table.Columns) == n {
+				for i, col := range table.Columns {
+					types[i] = columnTypeToken(col.Type)
+				}
+			}
+			return types
+		}
+	}
+	if len(sel.Columns) != n {
+		return types
+	}
+	for i, expr := range sel.Columns {
+		if alias, ok := expr.(*query.AliasExpr); ok {
+			expr = alias.Expr
+		}
+		var tableName, column string
+		switch e := expr.(type) {
+		case *query.QualifiedIdentifier:
+			tableName, column = e.Table, e.Column
+		case *query.Identifier:
+			column = e.Name
+			if len(refs) == 1 {
+				tableName = sel.From.Name
+			}
+		default:
+			continue
+		}
+		table := tables[strings.ToLower(tableName)]
+		if table == nil {
+			continue
+		}
+		if idx := table.GetColumnIndex(column); idx >= 0 {
+			types[i] = columnTypeToken(table.Columns[idx].Type)
+		}
+	}
+	return types
+}
+
+// columnTypeToken maps a stored column type back to its type keyword.
+func columnTypeToken(colType string) query.TokenType {
+	switch strings.ToUpper(colType) {
+	case "INTEGER":
+		return query.TokenInteger
+	case "TEXT":
+		return query.TokenText
+	case "REAL":
+		return query.TokenReal
+	case "BLOB":
+		return query.TokenBlob
+	case "BOOLEAN":
+		return query.TokenBoolean
+	case "JSON":
+		return query.TokenJSON
+	case "DATE":
+		return query.TokenDate
+	case "TIMESTAMP":
+		return query.TokenTimestamp
+	case "DATETIME":
+		return query.TokenDatetime
+	case "UUID":
+		return query.TokenUUID
+	default:
+		return 0
+	}
+}
+
 // inferCTASColumnType picks a column type for CTAS from the materialized values.
 func inferCTASColumnType(data [][]interface{}, col int) query.TokenType {
-	allInt, allNum, sawVal := true, true, false
+	allInt, allNum, allBlob, allBool, sawVal := true, true, true, true, false
 	for _, row := range data {
 		if col >= len(row) || row[col] == nil {
 			continue
@@ -1880,11 +2380,15 @@ func inferCTASColumnType(data [][]interface{}, col int) query.TokenType {
 		sawVal = true
 		switch row[col].(type) {
 		case int, int64:
+			allBlob, allBool = false, false
 		case float64:
-			allInt = false
+			allInt, allBlob, allBool = false, false, false
+		case []byte:
+			allInt, allNum, allBool = false, false, false
+		case bool:
+			allInt, allNum, allBlob = false, false, false
 		default:
-			allInt = false
-			allNum = false
+			allInt, allNum, allBlob, allBool = false, false, false, false
 		}
 	}
 	switch {
@@ -1894,31 +2398,15 @@ func inferCTASColumnType(data [][]interface{}, col int) query.TokenType {
 		return query.TokenInteger
 	case allNum:
 		return query.TokenReal
+	case allBlob:
+		return query.TokenBlob
+	case allBool:
+		return query.TokenBoolean
 	default:
 		return query.TokenText
 	}
 }
 
-// valueToLiteralExpr wraps a Go value as the equivalent literal AST node.
-func valueToLiteralExpr(v interface{}) query.Expression {
-	switch val := v.(type) {
-	case nil:
-		return &query.NullLiteral{}
-	case int:
-		return &query.NumberLiteral{Value: float64(val)}
-	case int64:
-		return &query.NumberLiteral{Value: float64(val)}
-	case float64:
-		return &query.NumberLiteral{Value: val}
-	case bool:
-		return &query.BooleanLiteral{Value: val}
-	case string:
-		return &query.StringLiteral{Value: val}
-	default:
-		return &query.StringLiteral{Value: fmt.Sprintf("%v", val)}
-	}
-}
-
 func (db *DB) executeCreateForeignTable(ctx context.Context, stmt *query.CreateForeignTableStmt) (Result, error) {
 	if err := db.catalog.CreateForeignTable(stmt); err != nil {
 		return Result{}, err
@@ -1930,15 +2418,40 @@ func (db *DB) executeCreateForeignTable(ctx context.Context, stmt *query.CreateF
 
 func (db *DB) executeInsert(ctx context.Context, stmt *query.InsertStmt, args []interface{}) (Result, error) {
 	if stmt.OnConflict != nil && stmt.OnConflict.DoUpdate != nil {
-		return db.executeUpsert(ctx, stmt, args)
+		result, err := db.executeUpsert(ctx, stmt, args)
+		if err == nil {
+			db.recordLastInsertID(ctx, result.LastInsertID)
+		}
+		return result, err
+	}
+	if stmt.Query != nil {
+		var err error
+		if stmt, args, err = db.materializeInsertQuery(ctx, stmt, args); err != nil {
+			return Result{}, err
+		}
 	}
 	lastInsertID, rowsAffected, err := db.catalog.Insert(ctx, stmt, args)
 	if err != nil {
 		return Result{}, err
 	}
+	db.recordLastInsertID(ctx, lastInsertID)
 	return Result{LastInsertID: lastInsertID, RowsAffected: rowsAffected}, nil
 }
 
+// materializeInsertQuery runs the query of INSERT ... SELECT and returns the
+// INSERT with the query's rows bound as VALUES arguments. The catalog runs a
+// plain SELECT itself; WITH and set-operation queries, and the rows an upsert
+// tries one by one, are read here.
+func (db *DB) materializeInsertQuery(ctx context.Context, stmt *query.InsertStmt, args []interface{}) (*query.InsertStmt, []interface{}, error) {
+	rows, err := db.query(ctx, insertQuery(stmt), args)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	local, args := insertValues(stmt, rows.rows, args)
+	return local, args, nil
+}
+
 // executeUpsert implements INSERT ... ON CONFLICT (...) DO UPDATE SET ... by
 // attempting a per-row insert and, on a unique/primary-key conflict, applying
 // the UPDATE assignments to the conflicting row. Safe under the catalog's
@@ -1950,33 +2463,12 @@ func (db *DB) executeUpsert(ctx context.Context, stmt *query.InsertStmt, args []
 	}
 
 	// Source rows come from VALUES, or from a materialized INSERT ... SELECT.
-	valueRows := stmt.Values
-	if stmt.Select != nil {
-		rows, qerr := db.query(ctx, stmt.Select, args)
-		if qerr != nil {
-			return Result{}, qerr
-		}
-		cols := rows.Columns()
-		for rows.Next() {
-			vals := make([]interface{}, len(cols))
-			ptrs := make([]interface{}, len(cols))
-			for i := range vals {
-				ptrs[i] = &vals[i]
-			}
-			if scanErr := rows.Scan(ptrs...); scanErr != nil {
-				_ = rows.Close()
-				return Result{}, scanErr
-			}
-			exprs := make([]query.Expression, len(vals))
-			for j, v := range vals {
-				exprs[j] = valueToLiteralExpr(v)
-			}
-			valueRows = append(valueRows, exprs)
-		}
-		if cerr := rows.Close(); cerr != nil {
-			return Result{}, cerr
+	if insertQuery(stmt) != nil {
+		if stmt, args, err = db.materializeInsertQuery(ctx, stmt, args); err != nil {
+			return Result{}, err
 		}
 	}
+	valueRows := stmt.Values
 
 	// Resolve conflict target columns. Explicit ON CONFLICT targets use that
 	// one target; MySQL-style ON DUPLICATE KEY UPDATE has no target, so try the
@@ -2088,7 +2580,7 @@ func (db *DB) upsertConflictTargets(tableName string, table *catalog.TableDef) [
 		}
 	}
 	for _, idx := range db.catalog.GetTableIndexes(tableName) {
-		if idx.Unique {
+		if idx.Unique && idx.JSONPath == "" {
 			add(idx.Columns)
 		}
 	}
@@ -2251,6 +2743,12 @@ func substituteUpsertValuesExpr(expr query.Expression, colPos map[string]int, ro
 			return nil, err
 		}
 		return &query.AliasExpr{Expr: ex, Alias: e.Alias}, nil
+	case *query.CollateExpr:
+		ex, err := substituteUpsertValuesExpr(e.Expr, colPos, row)
+		if err != nil {
+			return nil, err
+		}
+		return &query.CollateExpr{Expr: ex, Collation: e.Collation}, nil
 	case *query.JSONPathExpr:
 		col, err := substituteUpsertValuesExpr(e.Column, colPos, row)
 		if err != nil {
@@ -2308,11 +2806,7 @@ func upsertValuesColumnName(expr query.Expression) (string, bool) {
 // isUniqueConflictError reports whether err is a primary-key/unique violation
 // from the insert path (used to trigger ON CONFLICT DO UPDATE).
 func isUniqueConflictError(err error) bool {
-	if err == nil {
-		return false
-	}
-	s := err.Error()
-	return strings.Contains(s, "UNIQUE constraint failed") || strings.Contains(s, "duplicate primary key")
+	return errors.Is(err, sqlerr.ErrConstraintUnique)
 }
 
 // executeUpdate executes UPDATE
@@ -2338,8 +2832,13 @@ func (db *DB) executeDelete(ctx context.Context, stmt *query.DeleteStmt, args []
 // executeInsertReturning executes INSERT with RETURNING clause
 
 func (db *DB) executeInsertReturning(ctx context.Context, stmt *query.InsertStmt, args []interface{}) (*Rows, error) {
-	_, _, err := db.catalog.Insert(ctx, stmt, args)
-	if err != nil {
+	var err error
+	if stmt.Query != nil {
+		if stmt, args, err = db.materializeInsertQuery(ctx, stmt, args); err != nil {
+			return nil, err
+		}
+	}
+	if _, _, err = db.catalog.Insert(ctx, stmt, args); err != nil {
 		return nil, err
 	}
 
@@ -2395,6 +2894,21 @@ func (db *DB) executeDeleteReturning(ctx context.Context, stmt *query.DeleteStmt
 // executeAlterTable executes ALTER TABLE
 
 func (db *DB) executeAlterTable(ctx context.Context, stmt *query.AlterTableStmt) (Result, error) {
+	if stmt.IfExists || stmt.TargetIfNotExists {
+		table, err := db.catalog.GetTable(stmt.Table)
+		if err != nil {
+			if stmt.IfExists && errors.Is(err, catalog.ErrTableNotFound) {
+				return Result{RowsAffected: 0}, nil
+			}
+			return Result{}, err
+		}
+		// Skip before the catalog call so the column's UNIQUE index is not
+		// recreated either.
+		if stmt.Action == "ADD" && stmt.TargetIfNotExists && table.GetColumnIndex(stmt.Column.Name) >= 0 {
+			return Result{RowsAffected: 0}, nil
+		}
+	}
+
 	switch stmt.Action {
 	case "ADD":
 		if err := db.catalog.AlterTableAddColumn(stmt); err != nil {
@@ -2450,12 +2964,23 @@ func (db *DB) executeAlterTable(ctx context.Context, stmt *query.AlterTableStmt)
 		}
 	case "DROP_CONSTRAINT":
 		if err := db.catalog.DropTableConstraint(stmt.Table, stmt.ConstraintName); err != nil {
+			if stmt.TargetIfExists && errors.Is(err, catalog.ErrIndexNotFound) {
+				break
+			}
 			return Result{}, err
 		}
 	case "ENABLE_RLS":
 		if err := db.catalog.EnableRLSTable(stmt.Table); err != nil {
 			return Result{}, err
 		}
+	case "SET_COMPRESSION_DICTIONARY":
+		if err := db.catalog.SetCompressionDictionary(stmt.Table); err != nil {
+			return Result{}, err
+		}
+	case "SET_COMPRESSION_NONE":
+		if err := db.catalog.DropCompressionDictionary(stmt.Table); err != nil {
+			return Result{}, err
+		}
 	default:
 		return Result{}, fmt.Errorf("unsupported ALTER TABLE action: %s", stmt.Action)
 	}
@@ -2506,15 +3031,15 @@ func (db *DB) executeCreateView(ctx context.Context, stmt *query.CreateViewStmt)
 		}
 		return Result{RowsAffected: 0}, nil
 	}
+	if stmt.IfNotExists && db.catalog.HasTableOrView(stmt.Name) {
+		return Result{RowsAffected: 0}, nil
+	}
 	if stmt.Temporary {
 		err = db.catalog.CreateTemporaryViewSQL(stmt.Name, viewQuery, stmt.RawSQL)
 	} else {
 		err = db.catalog.CreateViewSQL(stmt.Name, viewQuery, stmt.RawSQL)
 	}
 	if err != nil {
-		if stmt.IfNotExists {
-			return Result{RowsAffected: 0}, nil
-		}
 		return Result{}, err
 	}
 	return Result{RowsAffected: 0}, nil
@@ -2548,10 +3073,16 @@ func applyCreateViewColumnList(stmt *query.CreateViewStmt) (*query.SelectStmt, e
 // executeDropView executes DROP VIEW
 
 func (db *DB) executeDropView(ctx context.Context, stmt *query.DropViewStmt) (Result, error) {
-	if err := db.catalog.DropView(stmt.Name); err != nil {
-		if stmt.IfExists {
+	if stmt.IfExists {
+		if _, err := db.catalog.GetView(stmt.Name); err != nil {
 			return Result{RowsAffected: 0}, nil
 		}
+	}
+	drop := db.catalog.DropView
+	if stmt.Cascade {
+		drop = db.catalog.DropViewCascade
+	}
+	if err := drop(stmt.Name); err != nil {
 		return Result{}, err
 	}
 	return Result{RowsAffected: 0}, nil
@@ -2560,6 +3091,11 @@ func (db *DB) executeDropView(ctx context.Context, stmt *query.DropViewStmt) (Re
 // executeCreateTrigger executes CREATE TRIGGER
 
 func (db *DB) executeCreateTrigger(ctx context.Context, stmt *query.CreateTriggerStmt) (Result, error) {
+	if stmt.IfNotExists {
+		if _, err := db.catalog.GetTrigger(stmt.Name); err == nil {
+			return Result{RowsAffected: 0}, nil
+		}
+	}
 	if err := db.catalog.CreateTriggerSQL(stmt, stmt.RawSQL); err != nil {
 		return Result{}, err
 	}
@@ -2569,6 +3105,11 @@ func (db *DB) executeCreateTrigger(ctx context.Context, stmt *query.CreateTrigge
 // executeDropTrigger executes DROP TRIGGER
 
 func (db *DB) executeDropTrigger(ctx context.Context, stmt *query.DropTriggerStmt) (Result, error) {
+	if stmt.IfExists {
+		if _, err := db.catalog.GetTrigger(stmt.Name); err != nil {
+			return Result{RowsAffected: 0}, nil
+		}
+	}
 	if err := db.catalog.DropTrigger(stmt.Name); err != nil {
 		return Result{}, err
 	}
@@ -2578,10 +3119,12 @@ func (db *DB) executeDropTrigger(ctx context.Context, stmt *query.DropTriggerStm
 // executeCreateProcedure executes CREATE PROCEDURE
 
 func (db *DB) executeCreateProcedure(ctx context.Context, stmt *query.CreateProcedureStmt) (Result, error) {
-	if err := db.catalog.CreateProcedureSQL(stmt, stmt.RawSQL); err != nil {
-		if stmt.IfNotExists {
+	if stmt.IfNotExists {
+		if _, err := db.catalog.GetProcedure(stmt.Name); err == nil {
 			return Result{}, nil // Silently succeed
 		}
+	}
+	if err := db.catalog.CreateProcedureSQL(stmt, stmt.RawSQL); err != nil {
 		return Result{}, err
 	}
 	return Result{RowsAffected: 0}, nil
@@ -2590,10 +3133,12 @@ func (db *DB) executeCreateProcedure(ctx context.Context, stmt *query.CreateProc
 // executeDropProcedure executes DROP PROCEDURE
 
 func (db *DB) executeDropProcedure(ctx context.Context, stmt *query.DropProcedureStmt) (Result, error) {
-	if err := db.catalog.DropProcedure(stmt.Name); err != nil {
-		if stmt.IfExists {
+	if stmt.IfExists {
+		if _, err := db.catalog.GetProcedure(stmt.Name); err != nil {
 			return Result{}, nil // Silently succeed
 		}
+	}
+	if err := db.catalog.DropProcedure(stmt.Name); err != nil {
 		return Result{}, err
 	}
 	return Result{RowsAffected: 0}, nil
@@ -2654,6 +3199,9 @@ func (db *DB) executeCreatePolicy(ctx context.Context, stmt *query.CreatePolicyS
 	}
 
 	if err := db.catalog.CreateRLSPolicy(policy); err != nil {
+		if stmt.IfNotExists && errors.Is(err, security.ErrPolicyAlreadyExists) {
+			return Result{RowsAffected: 0}, nil
+		}
 		return Result{}, err
 	}
 
@@ -2758,6 +3306,10 @@ func expressionToString(expr query.Expression) string {
 			sb.WriteString(" LIKE ")
 		}
 		sb.WriteString(patternStr)
+		if e.Escape != nil {
+			sb.WriteString(" ESCAPE ")
+			sb.WriteString(expressionToString(e.Escape))
+		}
 		return sb.String()
 	case *query.IsNullExpr:
 		exprStr := expressionToString(e.Expr)
@@ -2817,6 +3369,10 @@ func tokenTypeToString(tok query.TokenType) string {
 func (db *DB) executeDropPolicy(ctx context.Context, stmt *query.DropPolicyStmt) (Result, error) {
 	// Check if RLS is enabled
 	if !db.catalog.IsRLSEnabled() {
+		if stmt.IfExists {
+			// No policy can exist without row-level security.
+			return Result{RowsAffected: 0}, nil
+		}
 		return Result{}, errors.New("row-level security is not enabled for this database")
 	}
 
@@ -2828,7 +3384,7 @@ func (db *DB) executeDropPolicy(ctx context.Context, stmt *query.DropPolicyStmt)
 	}
 
 	if err := db.catalog.DropRLSPolicy(tableName, stmt.Name); err != nil {
-		if stmt.IfExists && err.Error() == "security policy not found" {
+		if stmt.IfExists && errors.Is(err, security.ErrPolicyNotFound) {
 			return Result{RowsAffected: 0}, nil
 		}
 		return Result{}, err
@@ -2980,7 +3536,7 @@ func isProcedureResultStatement(stmt query.Statement) bool {
 	switch stmt.(type) {
 	case *query.SelectStmt, *query.UnionStmt, *query.SelectStmtWithCTE,
 		*query.ShowTablesStmt, *query.ShowCreateTableStmt, *query.ShowColumnsStmt,
-		*query.ShowDatabasesStmt, *query.DescribeStmt, *query.ExplainStmt:
+		*query.ShowDatabasesStmt, *query.ShowConfigStmt, *query.DescribeStmt, *query.ExplainStmt:
 		return true
 	default:
 		return false
@@ -3427,7 +3983,9 @@ func (db *DB) executeUnion(ctx context.Context, stmt *query.UnionStmt, args []in
 
 	// Apply ORDER BY if present
 	if len(stmt.OrderBy) > 0 {
-		db.applyUnionOrderBy(combined, columns, stmt.OrderBy)
+		if err := db.applyUnionOrderBy(combined, columns, stmt.OrderBy); err != nil {
+			return nil, err
+		}
 	}
 
 	// Apply OFFSET
@@ -3512,31 +4070,53 @@ func normalizeRowKey(row []interface{}) string {
 	return sb.String()
 }
 
-// applyUnionOrderBy sorts union result rows
-
-func (db *DB) applyUnionOrderBy(rows [][]interface{}, columns []string, orderBy []*query.OrderByExpr) {
+// applyUnionOrderBy sorts union result rows. Each ORDER BY term must name
+// a result column, by name or position, since the combined rows carry no
+// tables to evaluate expressions against.
+func (db *DB) applyUnionOrderBy(rows [][]interface{}, columns []string, orderBy []*query.OrderByExpr) error {
+	colIdxs := make([]int, len(orderBy))
+	for k, ob := range orderBy {
+		colIdx := -1
+		switch expr := ob.Expr.(type) {
+		case *query.Identifier:
+			colIdx = unionOrderByColumn(columns, expr.Name)
+		case *query.QualifiedIdentifier:
+			colIdx = unionOrderByColumn(columns, expr.Column)
+		case *query.NumberLiteral:
+			if expr.Value != float64(int(expr.Value)) || expr.Value < 1 || int(expr.Value) > len(columns) {
+				return fmt.Errorf("ORDER BY position %v is not in select list", expr.Value)
+			}
+			colIdx = int(expr.Value) - 1
+		}
+		if colIdx < 0 {
+			return fmt.Errorf("ORDER BY term %d does not match a result column of the set operation", k+1)
+		}
+		colIdxs[k] = colIdx
+	}
 	if len(rows) == 0 {
-		return
+		return nil
 	}
 
-	sort.Slice(rows, func(i, j int) bool {
-		for _, ob := range orderBy {
-			colIdx := -1
-			switch expr := ob.Expr.(type) {
-			case *query.Identifier:
-				for k, col := range columns {
-					if strings.EqualFold(col, expr.Name) {
-						colIdx = k
-						break
-					}
-				}
-			case *query.NumberLiteral:
-				colIdx = int(expr.Value) - 1
-			}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k, ob := range orderBy {
+			colIdx := colIdxs[k]
 			if colIdx < 0 || colIdx >= len(rows[i]) || colIdx >= len(rows[j]) {
 				continue
 			}
-			cmp := db.compareUnionValues(rows[i][colIdx], rows[j][colIdx])
+			a, b := rows[i][colIdx], rows[j][colIdx]
+			if a == nil || b == nil {
+				if a == nil && b == nil {
+					continue
+				}
+				// NULLs sort last for ASC and first for DESC, as in a
+				// plain SELECT, unless NULLS FIRST/LAST is given.
+				nullsFirst := ob.Desc
+				if ob.NullsSpecified {
+					nullsFirst = ob.NullsFirst
+				}
+				return (a == nil) == nullsFirst
+			}
+			cmp := db.compareUnionValues(a, b)
 			if cmp != 0 {
 				if ob.Desc {
 					return cmp > 0
@@ -3546,6 +4126,18 @@ func (db *DB) applyUnionOrderBy(rows [][]interface{}, columns []string, orderBy
 		}
 		return false
 	})
+	return nil
+}
+
+// unionOrderByColumn returns the index of the result column named name, or
+// -1 if there is none.
+func unionOrderByColumn(columns []string, name string) int {
+	for k, col := range columns {
+		if strings.EqualFold(col, name) {
+			return k
+		}
+	}
+	return -1
 }
 
 // compareUnionValues compares two values for sorting
@@ -3771,6 +4363,11 @@ func (db *DB) executeVacuum(ctx context.Context, stmt *query.VacuumStmt) (Result
 	if err := db.catalog.Vacuum(db.options.Maintenance.AutoVacuumRetention); err != nil {
 		return Result{}, err
 	}
+	// An explicit VACUUM also retrains compression dictionaries; automatic
+	// vacuums leave them alone.
+	if err := db.catalog.RefreshCompressionDictionaries(stmt.Table); err != nil {
+		return Result{}, err
+	}
 	return Result{RowsAffected: 0}, nil
 }
 
@@ -3823,15 +4420,58 @@ func (db *DB) executeRefreshMaterializedView(ctx context.Context, stmt *query.Re
 // executeCreateFTSIndex executes CREATE FULLTEXT INDEX
 
 func (db *DB) executeCreateFTSIndex(ctx context.Context, stmt *query.CreateFTSIndexStmt) (Result, error) {
+	if stmt.IfNotExists {
+		if _, err := db.catalog.GetFTSIndex(stmt.Index); err == nil {
+			return Result{RowsAffected: 0}, nil
+		}
+	}
 	if err := db.catalog.CreateFTSIndex(stmt.Index, stmt.Table, stmt.Columns); err != nil {
 		return Result{}, err
 	}
 	return Result{RowsAffected: 0}, nil
 }
 
+// executeCreateVirtualTable executes CREATE VIRTUAL TABLE ... USING fts(...).
+// The table is an ordinary table of TEXT columns with a full-text index of the
+// same name over all of them, so it can be queried with `table MATCH '...'`.
+func (db *DB) executeCreateVirtualTable(ctx context.Context, stmt *query.CreateVirtualTableStmt) (Result, error) {
+	switch strings.ToLower(stmt.Module) {
+	case "fts", "fts4", "fts5":
+	default:
+		return Result{}, fmt.Errorf("no such module: %s", stmt.Module)
+	}
+	if len(stmt.Args) == 0 {
+		return Result{}, fmt.Errorf("virtual table %s needs at least one column", stmt.Table)
+	}
+	if stmt.IfNotExists {
+		if _, err := db.catalog.GetTable(stmt.Table); err == nil {
+			return Result{RowsAffected: 0}, nil
+		}
+	}
+	cols := make([]*query.ColumnDef, len(stmt.Args))
+	for i, name := range stmt.Args {
+		cols[i] = &query.ColumnDef{Name: name, Type: query.TokenText}
+	}
+	if _, err := db.executeCreateTable(ctx, &query.CreateTableStmt{Table: stmt.Table, Columns: cols}); err != nil {
+		return Result{}, err
+	}
+	if err := db.catalog.CreateFTSIndex(stmt.Table, stmt.Table, stmt.Args); err != nil {
+		if cleanupErr := db.catalog.CleanupFailedCreateTable(stmt.Table); cleanupErr != nil {
+			return Result{}, fmt.Errorf("%w; cleanup failed: %v", err, cleanupErr)
+		}
+		return Result{}, err
+	}
+	return Result{RowsAffected: 0}, nil
+}
+
 // executeCreateVectorIndex executes CREATE VECTOR INDEX
 
 func (db *DB) executeCreateVectorIndex(ctx context.Context, stmt *query.CreateVectorIndexStmt) (Result, error) {
+	if stmt.IfNotExists {
+		if _, err := db.catalog.GetVectorIndex(stmt.Index); err == nil {
+			return Result{RowsAffected: 0}, nil
+		}
+	}
 	if err := db.catalog.CreateVectorIndex(stmt.Index, stmt.Table, stmt.Column); err != nil {
 		return Result{}, err
 	}
@@ -3952,8 +4592,11 @@ func (db *DB) executeDescribeQuery(ctx context.Context, stmt *query.DescribeStmt
 }
 
 // executeExplainQuery executes EXPLAIN and returns the query plan
-func (db *DB) executeExplainQuery(ctx context.Context, stmt *query.ExplainStmt) (*Rows, error) {
+func (db *DB) executeExplainQuery(ctx context.Context, stmt *query.ExplainStmt, args []interface{}) (*Rows, error) {
 	innerStmt := stmt.Statement
+	if stmt.Analyze {
+		return db.executeExplainAnalyze(ctx, innerStmt, args)
+	}
 
 	var plan *QueryPlan
 	switch s := innerStmt.(type) {
@@ -3975,6 +4618,10 @@ func (db *DB) executeExplainQuery(ctx context.Context, stmt *query.ExplainStmt)
 		}, nil
 	}
 
+	if seed, ok := db.catalog.RandomSeed(); ok {
+		plan.Nodes = append(plan.Nodes, PlanNode{ID: len(plan.Nodes) + 1, Operation: "Random Seed", Detail: fmt.Sprintf("seed=%d", seed)})
+	}
+
 	columns, rows := formatQueryPlan(plan)
 	return &Rows{
 		columns: columns,
@@ -3995,6 +4642,20 @@ type Rows struct {
 	rows    [][]interface{}
 	pos     int
 	closed  bool
+
+	// A cursor's rows (see QueryCursor) hold only the current row, read
+	// from stream; err is the error that ended them.
+	stream   *rowStream
+	err      error
+	streamed int64 // rows a cursor's statement sent, for rowCount
+}
+
+// rowCount returns the number of rows in the result, 0 for nil rows.
+func (r *Rows) rowCount() int64 {
+	if r == nil {
+		return 0
+	}
+	return int64(len(r.rows)) + r.streamed
 }
 
 // Next advances to the next row
@@ -4003,10 +4664,29 @@ func (r *Rows) Next() bool {
 	if r == nil || r.closed {
 		return false
 	}
+	if r.stream != nil {
+		row, ok := <-r.stream.rows
+		if !ok {
+			r.err = r.stream.err
+			r.rows, r.pos = nil, 0
+			return false
+		}
+		r.rows, r.pos = append(r.rows[:0], row), 1
+		return true
+	}
 	r.pos++
 	return r.pos <= len(r.rows)
 }
 
+// Err returns the error that ended a cursor's rows early, or nil. Rows
+// returned by Query hold their whole result and never fail.
+func (r *Rows) Err() error {
+	if r == nil {
+		return nil
+	}
+	return r.err
+}
+
 // Scan copies column values into dest
 
 func (r *Rows) Scan(dest ...interface{}) error {
@@ -4096,6 +4776,9 @@ func (r *Rows) Close() error {
 		return nil
 	}
 	r.closed = true
+	if r.stream != nil {
+		r.stream.close()
+	}
 	r.columns = nil
 	r.rows = nil
 	r.pos = 0
@@ -4277,6 +4960,10 @@ func scanValue(src interface{}, dest interface{}) error {
 
 func cloneScannedValue(value interface{}) interface{} {
 	switch typed := value.(type) {
+	case catalog.StringBox:
+		// Interned strings are an internal representation; callers scanning
+		// into interface{} (the wire server, database/sql) expect a string.
+		return typed.String()
 	case []byte:
 		if typed == nil {
 			return []byte(nil)
@@ -4340,6 +5027,7 @@ func releaseTx(tx *Tx) {
 	}
 	tx.db = nil
 	tx.txn = nil
+	tx.xid = ""
 }
 
 // Tx represents a database transaction
@@ -4347,6 +5035,7 @@ type Tx struct {
 	db   *DB
 	txn  *txn.Transaction
 	done atomic.Bool // prevents double commit/rollback and double connection release
+	xid  string      // set by Prepare
 }
 
 // Exec executes a statement within the transaction
@@ -4363,11 +5052,30 @@ func (tx *Tx) Exec(ctx context.Context, sql string, args ...interface{}) (Result
 	// Parse the statement
 	stmt, err := tx.db.getPreparedStatement(sql, args...)
 	if err != nil {
-		return Result{}, fmt.Errorf("parse error: %w", err)
+		tx.db.queryCounters.errors.Add(1)
+		err = fmt.Errorf("parse error: %w", err)
+		tx.db.logQuery(ctx, sql, time.Time{}, 0, 0, err)
+		return Result{}, err
 	}
 
 	// Execute within transaction context
-	return tx.db.execute(ctx, stmt, args)
+	start := time.Now()
+	ctx, cancel := tx.db.statementContext(ctx)
+	defer cancel()
+	ctx, span := tx.db.startStatementSpan(ctx, "cobaltdb.Tx.Exec", sql, stmt)
+	defer tracing.Bind(ctx)()
+	finish := tx.db.beginStatement(ctx)
+	result, err := tx.db.execute(ctx, stmt, args)
+	if err = finish(err); err == nil {
+		tx.db.fireDDLHooks(stmt, sql)
+	}
+	tx.db.observeStatement(stmt, time.Since(start), result.RowsAffected, err)
+	tx.db.logQuery(ctx, sql, start, result.RowsAffected, 0, err)
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.Int64("db.rows_affected", result.RowsAffected))
+	}
+	tracing.End(span, err)
+	return result, err
 }
 
 // Query executes a query within the transaction.
@@ -4385,18 +5093,43 @@ func (tx *Tx) Query(ctx context.Context, sql string, args ...interface{}) (*Rows
 
 	stmt, err := tx.db.getPreparedStatement(sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("parse error: %w", err)
+		tx.db.queryCounters.errors.Add(1)
+		err = fmt.Errorf("parse error: %w", err)
+		tx.db.logQuery(ctx, sql, time.Time{}, 0, 0, err)
+		return nil, err
 	}
 
-	return tx.db.query(ctx, stmt, args)
+	start := time.Now()
+	ctx, cancel := tx.db.statementContext(ctx)
+	defer cancel()
+	ctx, span := tx.db.startStatementSpan(ctx, "cobaltdb.Tx.Query", sql, stmt)
+	defer tracing.Bind(ctx)()
+	sink, finish := tx.db.beginQuery(ctx, stmt, nil)
+	rows, err := tx.db.query(ctx, stmt, args)
+	rows, err = tx.db.finishQuery(sink, rows, finish(err))
+	tx.db.observeStatement(stmt, time.Since(start), rows.rowCount(), err)
+	tx.db.logQuery(ctx, sql, start, 0, rows.rowCount(), err)
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.Int64("db.rows_returned", rows.rowCount()))
+	}
+	tracing.End(span, err)
+	return rows, err
 }
 
 // Commit commits the transaction
 
-func (tx *Tx) Commit() error {
+func (tx *Tx) Commit() (err error) {
 	if !tx.done.CompareAndSwap(false, true) {
 		return errors.New("transaction already completed")
 	}
+	// Wait for the cluster last, after the write gate and locks below are
+	// released.
+	db := tx.db
+	defer func() {
+		if err == nil {
+			err = db.awaitConsensus(context.Background())
+		}
+	}()
 	defer func() {
 		releaseTx(tx)
 	}()
@@ -4406,6 +5139,14 @@ func (tx *Tx) Commit() error {
 			tx.txn.Recycle()
 		}
 	}()
+	if tx.xid != "" {
+		return db.commitPrepared(tx.xid)
+	}
+
+	start := time.Now()
+	if _, exitGate, err := tx.db.writes.enter(context.Background()); err == nil {
+		defer exitGate()
+	}
 
 	// Concurrent explicit transactions apply buffered writes inside
 	// CommitTransaction, which serializes on per-tree mutexes.
@@ -4413,6 +5154,13 @@ func (tx *Tx) Commit() error {
 	// self-flushes before eviction when memory pressure requires it.
 	tx.db.flushMu.RLock()
 	defer tx.db.flushMu.RUnlock()
+	tx.db.commitLockWait.Observe(time.Since(start))
+	defer tx.db.observeCommit(start)
+
+	if err := tx.db.commitAttached(context.Background()); err != nil {
+		_ = tx.db.catalog.RollbackTransaction()
+		return fmt.Errorf("commit transaction failed: %w", err)
+	}
 
 	// Commit in catalog (conflict detection, WAL write, apply buffered writes)
 	if err := tx.db.catalog.CommitTransaction(); err != nil {
@@ -4447,6 +5195,15 @@ func (tx *Tx) Rollback() error {
 			tx.txn.Recycle()
 		}
 	}()
+	if tx.xid != "" {
+		return tx.db.RollbackPrepared(tx.xid)
+	}
+
+	if _, exitGate, err := tx.db.writes.enter(context.Background()); err == nil {
+		defer exitGate()
+	}
+
+	tx.db.rollbackAttached(context.Background())
 
 	// Rollback in catalog first (writes rollback record to WAL)
 	if err := tx.db.catalog.RollbackTransaction(); err != nil {
@@ -3,7 +3,6 @@ package engine
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/cobaltdb/cobaltdb/pkg/advisor"
@@ -13,6 +12,7 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/metrics"
 	"github.com/cobaltdb/cobaltdb/pkg/optimizer"
 	"github.com/cobaltdb/cobaltdb/pkg/replication"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
 )
 
 func (db *DB) GetMetricsCollector() *metrics.Collector {
@@ -27,18 +27,25 @@ func (db *DB) GetCatalog() *catalog.Catalog {
 
 // DBStats holds database statistics
 type DBStats struct {
-	Path              string        `json:"path"`
-	InMemory          bool          `json:"in_memory"`
-	PageSize          int           `json:"page_size"`
-	CacheSize         int           `json:"cache_size"`
-	ActiveConnections int64         `json:"active_connections"`
-	MaxConnections    int           `json:"max_connections"`
-	Tables            int           `json:"tables"`
-	Indexes           int           `json:"indexes"`
-	DatabaseSize      int64         `json:"database_size_bytes"`
-	Uptime            time.Duration `json:"uptime"`
-	IsHealthy         bool          `json:"is_healthy"`
-	LastCheckTime     time.Time     `json:"last_check_time"`
+	Path              string            `json:"path"`
+	InMemory          bool              `json:"in_memory"`
+	PageSize          int               `json:"page_size"`
+	CacheSize         int               `json:"cache_size"`
+	ActiveConnections int64             `json:"active_connections"`
+	MaxConnections    int               `json:"max_connections"`
+	Tables            int               `json:"tables"`
+	Indexes           int               `json:"indexes"`
+	DatabaseSize      int64             `json:"database_size_bytes"`
+	Uptime            time.Duration     `json:"uptime"`
+	IsHealthy         bool              `json:"is_healthy"`
+	LastCheckTime     time.Time         `json:"last_check_time"`
+	Commit            *CommitStats      `json:"commit"`
+	Pages             storage.PageUsage `json:"pages"`
+	// Queries counts statements, rows read and written, and latency per
+	// statement type.
+	Queries            *QueryStats             `json:"queries"`
+	ActiveTransactions int                     `json:"active_transactions"`
+	BufferPool         storage.BufferPoolStats `json:"buffer_pool"`
 }
 
 // Stats returns detailed database statistics
@@ -60,6 +67,11 @@ func (db *DB) Stats() (*DBStats, error) {
 		MaxConnections:    db.options.ConnectionPool.MaxConnections,
 		LastCheckTime:     time.Now(),
 		IsHealthy:         true,
+		Commit:            db.commitStats(),
+		Queries:           db.queryStats(),
+	}
+	if db.txnMgr != nil {
+		stats.ActiveTransactions = db.txnMgr.ActiveCount()
 	}
 
 	// Get catalog stats
@@ -73,10 +85,26 @@ func (db *DB) Stats() (*DBStats, error) {
 	if db.backend != nil {
 		stats.DatabaseSize = db.backend.Size()
 	}
+	if db.pool != nil {
+		stats.Pages = db.pool.PageUsage()
+		stats.BufferPool = db.pool.Stats()
+	}
 
 	return stats, nil
 }
 
+// TreePageStats reports the depth, page count and fill factor of every
+// table and index B+Tree, as of the last checkpoint or flush.
+func (db *DB) TreePageStats() ([]catalog.TreePageStats, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed.Load() {
+		return nil, ErrDatabaseClosed
+	}
+	return db.catalog.TreePageStats()
+}
+
 // HealthCheck performs a health check on the database
 
 func (db *DB) HealthCheck() error {
@@ -127,7 +155,7 @@ func (db *DB) GetWALPath() string {
 // WAL.Checkpoint.  The no-WAL path keeps flushMu.Lock because there is no
 // recovery log to replay changes that arrive after FlushTableTrees.
 
-func (db *DB) Checkpoint() error {
+func (db *DB) Checkpoint() (err error) {
 	if db.closed.Load() {
 		return ErrDatabaseClosed
 	}
@@ -137,6 +165,13 @@ func (db *DB) Checkpoint() error {
 	if !db.backupMu.TryLock() {
 		return nil // backup in progress, skip this checkpoint
 	}
+	// Registered before the unlocks below so hooks run with no locks held.
+	start := time.Now()
+	defer func() {
+		if err == nil {
+			db.emitEvent(Event{Kind: EventCheckpoint, Duration: time.Since(start)})
+		}
+	}()
 	defer db.backupMu.Unlock()
 
 	if db.wal != nil {
@@ -148,6 +183,11 @@ func (db *DB) Checkpoint() error {
 	}
 
 	if db.catalog != nil {
+		// The WAL is truncated below, so sequence advances logged since the
+		// last save must reach the catalog first.
+		if err := db.catalog.PersistSequences(); err != nil {
+			return fmt.Errorf("failed to persist sequences: %w", err)
+		}
 		if err := db.catalog.FlushTableTrees(); err != nil {
 			return fmt.Errorf("failed to flush table trees: %w", err)
 		}
@@ -196,6 +236,100 @@ func (db *DB) EndHotBackup() error {
 	return nil
 }
 
+// Rekey rotates the encryption-at-rest key. Pending changes are checkpointed
+// first, every page is re-encrypted under newKey with a fresh salt, the salt
+// sidecar is rewritten, and the WAL switches to the new cipher. Statements and
+// checkpoints are blocked for the duration of the rotation. A rekey journal
+// kept beside the database until the salt is rewritten lets Open finish a
+// rotation cut short by a crash when given newKey, or undo it when given the
+// old key.
+func (db *DB) Rekey(ctx context.Context, newKey []byte) error {
+	if len(newKey) == 0 {
+		return storage.ErrInvalidKey
+	}
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+	encBackend, ok := db.backend.(*storage.EncryptedBackend)
+	if !ok || encBackend.GetCipher() == nil {
+		return ErrNotEncrypted
+	}
+
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+	db.flushMu.Lock()
+	defer db.flushMu.Unlock()
+
+	checkpoint := func() error {
+		if err := db.catalog.FlushTableTrees(); err != nil {
+			return fmt.Errorf("failed to flush table trees: %w", err)
+		}
+		if err := db.catalog.Save(); err != nil {
+			return fmt.Errorf("failed to save catalog: %w", err)
+		}
+		if err := db.saveMetaPage(); err != nil {
+			return fmt.Errorf("failed to save meta page: %w", err)
+		}
+		if db.wal != nil {
+			return db.wal.Checkpoint(db.pool)
+		}
+		return db.pool.FlushDirty()
+	}
+
+	if err := checkpoint(); err != nil {
+		return fmt.Errorf("rekey: %w", err)
+	}
+	var journalPath string
+	if db.path != ":memory:" {
+		var err error
+		if journalPath, err = storage.RekeyJournalPath(db.path); err != nil {
+			return fmt.Errorf("rekey: %w", err)
+		}
+	}
+	if err := encBackend.Rekey(newKey, journalPath); err != nil {
+		return err
+	}
+	if journalPath != "" {
+		if err := storage.PersistSalt(db.path, encBackend.GetSalt()); err != nil {
+			return fmt.Errorf("rekey: failed to persist salt: %w", err)
+		}
+		if err := storage.RemoveFileDurable(journalPath); err != nil {
+			return fmt.Errorf("rekey: failed to remove journal: %w", err)
+		}
+	}
+	if db.wal != nil {
+		db.wal.SetEncryptionCipher(encBackend.GetCipher())
+	}
+	db.options.Security.EncryptionKey = append([]byte(nil), newKey...)
+	if db.options.Security.EncryptionConfig != nil {
+		db.options.Security.EncryptionConfig.Key = append([]byte(nil), newKey...)
+		db.options.Security.EncryptionConfig.Salt = encBackend.GetSalt()
+	}
+
+	// Records appended between the first checkpoint and the cipher switch were
+	// sealed with the old key; truncate them now that their pages are rewritten.
+	if err := checkpoint(); err != nil {
+		return fmt.Errorf("rekey: %w", err)
+	}
+	return nil
+}
+
+// SetColumnKey registers the AES key, of 16, 24 or 32 bytes, that the SQL
+// functions ENCRYPT(value, id) and DECRYPT(value, id) use for key ID id; a
+// nil key removes it. Keys are not stored in the database, so columns
+// encrypted under a key can only be decrypted while it is registered.
+func (db *DB) SetColumnKey(id string, key []byte) error {
+	return db.catalog.SetColumnKey(id, key)
+}
+
 // GetCurrentLSN returns the current log sequence number (implements backup.Database)
 
 func (db *DB) GetCurrentLSN() uint64 {
@@ -382,36 +516,6 @@ func (db *DB) UpdateTableStatistics(tableName string, stats *optimizer.TableStat
 	}
 }
 
-// replicateWrite sends write operations to the replication manager
-// This is called automatically after successful write operations (INSERT, UPDATE, DELETE, DDL)
-
-func (db *DB) replicateWrite(operation string, table string, args []interface{}) error {
-	if db.replicationMgr == nil {
-		return nil // Replication not enabled
-	}
-
-	// Serialize the write operation
-	// Format: operation|table|args...
-	var sb strings.Builder
-	sb.WriteString(operation)
-	sb.WriteByte('|')
-	sb.WriteString(table)
-	sb.WriteByte('|')
-	for i, arg := range args {
-		if i > 0 {
-			sb.WriteByte(',')
-		}
-		fmt.Fprintf(&sb, "%v", arg)
-	}
-
-	// Send to replication manager (async, non-blocking)
-	// The replication manager handles buffering and sending to slaves
-	if err := db.replicationMgr.ReplicateWALEntry([]byte(sb.String())); err != nil {
-		return fmt.Errorf("failed to replicate %s on %s: %w", operation, table, err)
-	}
-	return nil
-}
-
 // Replication methods
 
 // GetReplicationManager returns the replication manager
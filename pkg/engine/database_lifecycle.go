@@ -51,11 +51,16 @@ func DefaultOptions() *Options {
 			AutoVacuumThreshold:  0.2,
 			EnableAutoCheckpoint: true,
 			CheckpointInterval:   5 * time.Minute,
+			EnableTTLSweeper:     true,
+			TTLSweepInterval:     1 * time.Minute,
+			TTLBatchSize:         1000,
 		},
 		Scheduler: SchedulerConfig{
-			EnableScheduler: true,
-			AnalyzeInterval: 1 * time.Hour,
-			Workers:         2,
+			EnableScheduler:      true,
+			AnalyzeInterval:      1 * time.Hour,
+			AnalyzeThreshold:     0.1,
+			AnalyzeCheckInterval: 1 * time.Minute,
+			Workers:              2,
 		},
 		ParallelQuery: ParallelQueryConfig{
 			Workers:   runtime.NumCPU(),
@@ -205,9 +210,6 @@ func normalizeOptions(opts *Options) *Options {
 	} else {
 		normalized.CoreStorage.WALEnabled = cloneBoolPtr(normalized.CoreStorage.WALEnabled)
 	}
-	if normalized.CoreStorage.SyncMode == 0 {
-		normalized.CoreStorage.SyncMode = defaults.CoreStorage.SyncMode
-	}
 	if normalized.CoreStorage.Logger == nil {
 		normalized.CoreStorage.Logger = defaults.CoreStorage.Logger
 	}
@@ -232,9 +234,21 @@ func normalizeOptions(opts *Options) *Options {
 	if normalized.Maintenance.CheckpointInterval == 0 {
 		normalized.Maintenance.CheckpointInterval = defaults.Maintenance.CheckpointInterval
 	}
+	if normalized.Maintenance.TTLSweepInterval == 0 {
+		normalized.Maintenance.TTLSweepInterval = defaults.Maintenance.TTLSweepInterval
+	}
+	if normalized.Maintenance.TTLBatchSize == 0 {
+		normalized.Maintenance.TTLBatchSize = defaults.Maintenance.TTLBatchSize
+	}
 	if normalized.Scheduler.AnalyzeInterval == 0 {
 		normalized.Scheduler.AnalyzeInterval = defaults.Scheduler.AnalyzeInterval
 	}
+	if normalized.Scheduler.AnalyzeThreshold == 0 {
+		normalized.Scheduler.AnalyzeThreshold = defaults.Scheduler.AnalyzeThreshold
+	}
+	if normalized.Scheduler.AnalyzeCheckInterval == 0 {
+		normalized.Scheduler.AnalyzeCheckInterval = defaults.Scheduler.AnalyzeCheckInterval
+	}
 	if normalized.Scheduler.Workers == 0 {
 		normalized.Scheduler.Workers = defaults.Scheduler.Workers
 	}
@@ -306,15 +320,29 @@ func Open(path string, opts *Options) (*DB, error) {
 	var backend storage.Backend
 	var err error
 
+	registered, isRegistered, err := storage.OpenBackend(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
 	if opts.CoreStorage.InMemory || path == ":memory:" {
 		log.Infof("Opening in-memory database")
 		backend = storage.NewMemory()
+	} else if isRegistered {
+		// A registered backend holds the whole database; there is no local
+		// file beside it for a WAL.
+		log.Infof("Opening database at %s", path)
+		backend = registered
+		opts.CoreStorage.WALEnabled = BoolPtr(false)
 	} else {
 		log.Infof("Opening database at %s", path)
 		if err := prepareDatabaseParentDir(path); err != nil {
 			return nil, err
 		}
-		backend, err = storage.OpenDisk(path)
+		if opts.CoreStorage.MMap {
+			backend, err = storage.OpenDiskMMap(path)
+		} else {
+			backend, err = storage.OpenDisk(path)
+		}
 		if err != nil {
 			log.Errorf("Failed to open database: %v", err)
 			return nil, fmt.Errorf("failed to open database: %w", err)
@@ -341,6 +369,9 @@ func Open(path string, opts *Options) (*DB, error) {
 			log.Infof("Enabling encryption at rest")
 			// Try to load existing salt for key derivation consistency
 			if len(opts.Security.EncryptionConfig.Salt) == 0 && path != ":memory:" {
+				if rerr := storage.RecoverRekey(path, backend, opts.Security.EncryptionConfig); rerr != nil {
+					return nil, errors.Join(fmt.Errorf("failed to recover interrupted rekey: %w", rerr), backend.Close())
+				}
 				if salt, loadErr := storage.LoadSalt(path); loadErr == nil && salt != nil {
 					opts.Security.EncryptionConfig.Salt = salt
 				}
@@ -368,6 +399,9 @@ func Open(path string, opts *Options) (*DB, error) {
 			}
 			// Try to load existing salt for key derivation consistency
 			if path != ":memory:" {
+				if rerr := storage.RecoverRekey(path, backend, encConfig); rerr != nil {
+					return nil, errors.Join(fmt.Errorf("failed to recover interrupted rekey: %w", rerr), backend.Close())
+				}
 				if salt, loadErr := storage.LoadSalt(path); loadErr == nil && salt != nil {
 					encConfig.Salt = salt
 				}
@@ -398,6 +432,15 @@ func Open(path string, opts *Options) (*DB, error) {
 		}
 	}
 
+	return openBackend(path, opts, backend, log)
+}
+
+// openBackend builds the DB for Open and Deserialize once its storage
+// backend is ready, creating the database in an empty backend and loading
+// it otherwise. The backend is closed if opening fails.
+func openBackend(path string, opts *Options, backend storage.Backend, log *logger.Logger) (*DB, error) {
+	var err error
+
 	// Initialize metrics collector
 	collector := metrics.NewCollector(0) // Use default interval
 
@@ -411,6 +454,13 @@ func Open(path string, opts *Options) (*DB, error) {
 		shutdownCh:   make(chan struct{}),
 		indexAdvisor: advisor.NewIndexAdvisor(),
 	}
+	db.readOnly = storage.IsReadOnly(backend)
+	db.SetBusyTimeout(opts.ConnectionPool.BusyTimeout)
+	db.rowLocks.Store(opts.ConnectionPool.RowLocks)
+	if err := db.SetSearchPath(opts.SearchPath...); err != nil {
+		return nil, errors.Join(err, backend.Close())
+	}
+	db.syncMode.Store(int32(opts.CoreStorage.SyncMode))
 
 	// Initialize audit logger if configured
 	if opts.Security.AuditConfig != nil && opts.Security.AuditConfig.Enabled {
@@ -465,7 +515,7 @@ func Open(path string, opts *Options) (*DB, error) {
 	})
 
 	// Start background dirty page flusher (5s interval) for disk-backed databases
-	if db.path != ":memory:" {
+	if db.path != ":memory:" && !db.readOnly {
 		db.pool.StartBackgroundFlusher(5 * time.Second)
 	}
 
@@ -481,14 +531,26 @@ func Open(path string, opts *Options) (*DB, error) {
 		err = errors.Join(err, backend.Close())
 		return nil, err
 	}
+	if err := db.loadPrepared(); err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	db.unregisterPrometheus = metrics.RegisterPrometheusSource(db)
 
 	// Start scheduler for maintenance jobs if enabled.
 	// Auto-vacuum implies the scheduler must be active.
-	if !db.options.CoreStorage.InMemory && db.path != ":memory:" {
+	if !db.options.CoreStorage.InMemory && db.path != ":memory:" && !db.readOnly {
 		if db.options.Scheduler.EnableScheduler || db.options.Maintenance.EnableAutoVacuum {
 			db.startScheduler()
 		}
 	}
+	if db.options.Maintenance.EnableTTLSweeper && !db.readOnly {
+		db.startTTLSweeper()
+	}
+	if db.options.Maintenance.FlashbackRetention > 0 {
+		if err := db.startFlashback(); err != nil {
+			return nil, errors.Join(err, db.Close())
+		}
+	}
 
 	return db, nil
 }
@@ -502,6 +564,18 @@ func validateOptions(opts *Options) error {
 	if opts.CoreStorage.PageSize != storage.PageSize {
 		return fmt.Errorf("page size %d is unsupported; expected %d", opts.CoreStorage.PageSize, storage.PageSize)
 	}
+	if !opts.CoreStorage.SyncMode.valid() {
+		return fmt.Errorf("invalid sync mode: %v", opts.CoreStorage.SyncMode)
+	}
+	if opts.CoreStorage.GroupCommitWindow < 0 {
+		return fmt.Errorf("group commit window must be non-negative: %s", opts.CoreStorage.GroupCommitWindow)
+	}
+	if opts.CoreStorage.GroupCommitBatch < 0 {
+		return fmt.Errorf("group commit batch must be non-negative: %d", opts.CoreStorage.GroupCommitBatch)
+	}
+	if _, err := normalizeSearchPath(opts.SearchPath); err != nil {
+		return err
+	}
 	if opts.ConnectionPool.MaxConnections < 0 {
 		return fmt.Errorf("max connections must be non-negative: %d", opts.ConnectionPool.MaxConnections)
 	}
@@ -553,9 +627,32 @@ func validateOptions(opts *Options) error {
 	if opts.Maintenance.CheckpointInterval < 0 {
 		return fmt.Errorf("checkpoint interval must be non-negative: %s", opts.Maintenance.CheckpointInterval)
 	}
+	if opts.Maintenance.TTLSweepInterval < 0 {
+		return fmt.Errorf("TTL sweep interval must be non-negative: %s", opts.Maintenance.TTLSweepInterval)
+	}
+	if opts.Maintenance.TTLBatchSize < 0 {
+		return fmt.Errorf("TTL batch size must be non-negative: %d", opts.Maintenance.TTLBatchSize)
+	}
+	for id, key := range opts.Security.ColumnKeys {
+		if id == "" {
+			return fmt.Errorf("column key ID must not be empty")
+		}
+		if n := len(key); n != 16 && n != 24 && n != 32 {
+			return fmt.Errorf("column key %s must be 16, 24 or 32 bytes, got %d", id, n)
+		}
+	}
+	if opts.Maintenance.FlashbackRetention < 0 {
+		return fmt.Errorf("flashback retention must be non-negative: %s", opts.Maintenance.FlashbackRetention)
+	}
 	if opts.Scheduler.AnalyzeInterval < 0 {
 		return fmt.Errorf("scheduler analyze interval must be non-negative: %s", opts.Scheduler.AnalyzeInterval)
 	}
+	if opts.Scheduler.AnalyzeThreshold < 0 {
+		return fmt.Errorf("scheduler analyze threshold must be non-negative: %v", opts.Scheduler.AnalyzeThreshold)
+	}
+	if opts.Scheduler.AnalyzeCheckInterval < 0 {
+		return fmt.Errorf("scheduler analyze check interval must be non-negative: %s", opts.Scheduler.AnalyzeCheckInterval)
+	}
 	if opts.Scheduler.Workers < 0 {
 		return fmt.Errorf("scheduler workers must be non-negative: %d", opts.Scheduler.Workers)
 	}
@@ -568,6 +665,9 @@ func validateOptions(opts *Options) error {
 	if opts.ParallelQuery.Threshold < 0 {
 		return fmt.Errorf("parallel query threshold must be non-negative: %d", opts.ParallelQuery.Threshold)
 	}
+	if opts.ParallelQuery.MaxParallelism < 0 {
+		return fmt.Errorf("max parallelism must be non-negative: %d", opts.ParallelQuery.MaxParallelism)
+	}
 	return nil
 }
 
@@ -702,19 +802,16 @@ func (db *DB) createNew() error {
 
 		db.pool.SetWAL(wal)
 
-		switch db.options.CoreStorage.SyncMode {
-		case SyncNormal:
-			wal.EnableGroupCommit(0, 5*time.Millisecond)
-		case SyncOff:
-			wal.EnableGroupCommit(0, 0)
-		}
+		db.configureGroupCommit(wal)
 	}
 
 	// Initialize catalog (shared init happens after this)
 	db.catalog = catalog.New(db.rootTree, db.pool, db.wal)
 	db.catalog.SetParallelOptions(db.options.ParallelQuery.Workers, db.options.ParallelQuery.Threshold)
+	db.catalog.SetUnorderedScans(db.options.ParallelQuery.UnorderedScans)
+	db.catalog.SetMaxParallelism(db.options.ParallelQuery.MaxParallelism)
 
-	// Initialize common subsystems: FDW, RLS, txnMgr, query cache,
+	// Initialize common subsystems: FDW, RLS, txnMgr, query and plan caches,
 	// optimizer, replication, backup, and slow-query log.
 	db.initializeCommonComponents()
 
@@ -723,7 +820,8 @@ func (db *DB) createNew() error {
 
 // initializeCommonComponents sets up the subsystems shared by both createNew
 // and loadExisting: catalog with FDW registry, transaction manager, query
-// cache, optimizer, replication manager, backup manager, and slow-query log.
+// and plan caches, optimizer, replication manager, backup manager, and
+// slow-query log.
 // The catalog must already be assigned to db.catalog before calling this.
 func (db *DB) initializeCommonComponents() {
 	// Initialize FDW registry and register built-in wrappers
@@ -735,11 +833,35 @@ func (db *DB) initializeCommonComponents() {
 	if db.options.Security.EnableRLS {
 		db.catalog.EnableRLS()
 	}
+	if db.options.Security.StrictTypes {
+		db.catalog.SetTypeAffinity(catalog.TypeAffinityStrict)
+	}
+	if db.options.Security.CaseInsensitiveLike {
+		db.catalog.SetCaseSensitiveLike(false)
+	}
+	for id, key := range db.options.Security.ColumnKeys {
+		// Keys were validated with the options.
+		_ = db.catalog.SetColumnKey(id, key)
+	}
+
+	// Initialize query plan cache
+	if db.options.PlanCache.EnablePlanCache {
+		planCacheSize := db.options.PlanCache.Size
+		if planCacheSize <= 0 {
+			planCacheSize = 32 * 1024 * 1024 // 32MB default
+		}
+		planCacheEntries := db.options.PlanCache.MaxEntries
+		if planCacheEntries <= 0 {
+			planCacheEntries = 1000
+		}
+		db.planCache = NewQueryPlanCache(planCacheSize, planCacheEntries)
+	}
 
 	// Initialize transaction manager
 	db.txnMgr = txn.NewManager(db.wal)
 	db.catalog.SetTxnManager(db.txnMgr)
 	db.catalog.EnableBufferedWrites()
+	db.syncRowLockTimeout()
 
 	// Initialize query cache if enabled
 	if db.options.QueryCache.EnableQueryCache {
@@ -818,6 +940,7 @@ func (db *DB) saveMetaPage() error {
 	}
 	// Update root page ID
 	meta.RootPageID = db.rootTree.RootPageID()
+	meta.FreeListID = db.freeListID
 	db.expandMetaPageCount(&meta)
 	meta.Serialize(metaPage.Data)
 	if _, err := storage.WriteFullAt(db.backend, metaPage.Data, 0); err != nil {
@@ -877,14 +1000,7 @@ func (db *DB) loadExisting() error {
 		db.pool.SetWAL(wal)
 
 		// Enable group commit based on SyncMode
-		switch db.options.CoreStorage.SyncMode {
-		case SyncNormal:
-			wal.EnableGroupCommit(0, 5*time.Millisecond)
-		case SyncOff:
-			wal.EnableGroupCommit(0, 0)
-		default: // SyncFull
-			// immediate sync (default behavior)
-		}
+		db.configureGroupCommit(wal)
 
 		// Recover from WAL if needed
 		if wal.LSN() > wal.CheckpointLSN() {
@@ -894,6 +1010,22 @@ func (db *DB) loadExisting() error {
 		}
 	}
 
+	// Take over the freelist a clean close left. The chain stops being valid
+	// at the first allocation, so clear it on disk before anything allocates.
+	if meta.FreeListID != 0 && !db.readOnly {
+		if err := db.pool.LoadFreeList(meta.FreeListID); err != nil {
+			return fmt.Errorf("failed to load freelist: %w", err)
+		}
+		meta.FreeListID = 0
+		meta.Serialize(metaPage.Data)
+		if _, err := storage.WriteFullAt(db.backend, metaPage.Data, 0); err != nil {
+			return fmt.Errorf("failed to update meta page: %w", err)
+		}
+		if err := db.backend.Sync(); err != nil {
+			return fmt.Errorf("failed to sync meta page: %w", err)
+		}
+	}
+
 	// Open root B+Tree
 	rootTree, err := btree.OpenBTreeStrict(db.pool, meta.RootPageID)
 	if err != nil {
@@ -904,6 +1036,8 @@ func (db *DB) loadExisting() error {
 	// Load catalog - schema and data are now stored in the B+Tree pages
 	db.catalog = catalog.New(db.rootTree, db.pool, db.wal)
 	db.catalog.SetParallelOptions(db.options.ParallelQuery.Workers, db.options.ParallelQuery.Threshold)
+	db.catalog.SetUnorderedScans(db.options.ParallelQuery.UnorderedScans)
+	db.catalog.SetMaxParallelism(db.options.ParallelQuery.MaxParallelism)
 
 	// Load catalog metadata from the B+Tree
 	if err := db.catalog.Load(); err != nil {
@@ -921,23 +1055,10 @@ func (db *DB) loadExisting() error {
 		}
 	}
 
-	// Initialize common subsystems: FDW, RLS, txnMgr, query cache, optimizer,
-	// replication, backup, and slow-query log.
+	// Initialize common subsystems: FDW, RLS, txnMgr, query and plan caches,
+	// optimizer, replication, backup, and slow-query log.
 	db.initializeCommonComponents()
 
-	// Initialize query plan cache
-	if db.options.PlanCache.EnablePlanCache {
-		planCacheSize := db.options.PlanCache.Size
-		if planCacheSize <= 0 {
-			planCacheSize = 32 * 1024 * 1024 // 32MB default
-		}
-		planCacheEntries := db.options.PlanCache.MaxEntries
-		if planCacheEntries <= 0 {
-			planCacheEntries = 1000
-		}
-		db.planCache = NewQueryPlanCache(planCacheSize, planCacheEntries)
-	}
-
 	return nil
 }
 
@@ -952,6 +1073,9 @@ func (db *DB) initializeBackupManager() {
 		RetentionPeriod:  db.options.Backup.Retention,
 		MaxBackups:       db.options.Backup.MaxBackups,
 		CompressionLevel: db.options.Backup.CompressionLevel,
+		Encrypt:          db.options.Backup.Encrypt,
+		EncryptionKey:    db.options.Backup.EncryptionKey,
+		KeyFile:          db.options.Backup.KeyFile,
 	}
 	if backupConfig.BackupDir == "" {
 		backupConfig.BackupDir = defaultBackupDirForDatabase(db.path)
@@ -995,6 +1119,20 @@ func (db *DB) Shutdown(ctx context.Context) error {
 // Close closes the database immediately
 
 func (db *DB) Close() error {
+	// Stop replication before taking db.mu: its goroutines take the lock to
+	// apply entries and snapshots, and Stop waits for them.
+	var replErr error
+	if db.cancelReplication != nil && !db.closed.Load() {
+		db.cancelReplication()
+	}
+	if db.replicationMgr != nil && !db.closed.Load() {
+		replErr = db.replicationMgr.Stop()
+	}
+	// Likewise the TTL sweeper, whose deletes take the lock.
+	if db.stopTTLSweeper != nil {
+		db.stopTTLSweeper()
+	}
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -1003,6 +1141,8 @@ func (db *DB) Close() error {
 	}
 
 	db.closed.Store(true)
+	// Wake writers blocked by Freeze; they observe the closed flag and fail.
+	db.writes.open()
 
 	// Signal shutdown
 	select {
@@ -1022,6 +1162,9 @@ func (db *DB) Close() error {
 	if db.unregisterStorageStats != nil {
 		db.unregisterStorageStats()
 	}
+	if db.unregisterPrometheus != nil {
+		db.unregisterPrometheus()
+	}
 
 	// Stop scheduler (vacuum, analyze, and other maintenance jobs)
 	if db.scheduler != nil {
@@ -1029,17 +1172,29 @@ func (db *DB) Close() error {
 	}
 
 	var errs []error
+	if err := db.detachAll(); err != nil {
+		errs = append(errs, err)
+	}
 
 	// Serialize all page-flush operations during close so that no concurrent
 	// btree flush can race with BufferPool.FlushAll.
 	db.flushMu.Lock()
 
 	// Save catalog metadata to B+Tree (if not in-memory)
-	if !db.options.CoreStorage.InMemory && db.path != ":memory:" {
+	if !db.options.CoreStorage.InMemory && db.path != ":memory:" && !db.readOnly {
+		db.catalog.ReleaseSequenceReservations()
 		if err := db.catalog.Save(); err != nil {
 			errs = append(errs, fmt.Errorf("save catalog: %w", err))
 		}
 
+		// Store the freelist last: its chain lives in free pages, so it
+		// holds only while nothing else is allocated.
+		if head, err := db.pool.WriteFreeList(); err != nil {
+			errs = append(errs, fmt.Errorf("save freelist: %w", err))
+		} else {
+			db.freeListID = head
+		}
+
 		// Update meta page with current root page ID
 		if err := db.saveMetaPage(); err != nil {
 			errs = append(errs, fmt.Errorf("save meta page: %w", err))
@@ -1053,6 +1208,18 @@ func (db *DB) Close() error {
 		}
 	}
 
+	// The flashback log ends with the LSN the WAL is left at, so Open
+	// knows no commit is missing from it.
+	if db.flashback != nil {
+		var lsn uint64
+		if db.wal != nil {
+			lsn = db.wal.LSN()
+		}
+		if err := db.flashback.close(lsn); err != nil {
+			errs = append(errs, fmt.Errorf("close flashback log: %w", err))
+		}
+	}
+
 	// Flush buffer pool (after checkpoint)
 	if err := db.pool.Close(); err != nil {
 		errs = append(errs, fmt.Errorf("close buffer pool: %w", err))
@@ -1067,11 +1234,8 @@ func (db *DB) Close() error {
 		}
 	}
 
-	// Close replication manager
-	if db.replicationMgr != nil {
-		if err := db.replicationMgr.Stop(); err != nil {
-			errs = append(errs, fmt.Errorf("close replication manager: %w", err))
-		}
+	if replErr != nil {
+		errs = append(errs, fmt.Errorf("close replication manager: %w", replErr))
 	}
 
 	// Close WAL
@@ -1146,6 +1310,29 @@ func (db *DB) startScheduler() {
 		db.options.CoreStorage.Logger.Warnf("Failed to register auto-analyze job: %v", err)
 	}
 
+	// Register the job analyzing tables written past the threshold
+	checkInterval := db.options.Scheduler.AnalyzeCheckInterval
+	if checkInterval <= 0 {
+		checkInterval = 1 * time.Minute
+	}
+	analyzeThreshold := db.options.Scheduler.AnalyzeThreshold
+	if analyzeThreshold <= 0 {
+		analyzeThreshold = 0.1
+	}
+	thresholdJob := &scheduler.Job{
+		ID:       "auto-analyze-threshold",
+		Name:     "Auto Analyze (threshold)",
+		Type:     scheduler.JobTypeAnalyze,
+		Interval: checkInterval,
+		Enabled:  true,
+		Fn: func(ctx context.Context) error {
+			return db.runThresholdAnalyzeJob(analyzeThreshold)
+		},
+	}
+	if err := db.scheduler.Register(thresholdJob); err != nil {
+		db.options.CoreStorage.Logger.Warnf("Failed to register auto-analyze-threshold job: %v", err)
+	}
+
 	// Register checkpoint job
 	if db.options.Maintenance.EnableAutoCheckpoint {
 		checkpointInterval := db.options.Maintenance.CheckpointInterval
@@ -1173,13 +1360,19 @@ func (db *DB) startScheduler() {
 // runAutoVacuumJob checks all tables and vacuums those exceeding the dead-tuple threshold.
 
 func (db *DB) runAutoVacuumJob(threshold float64) error {
+	if db.IsFrozen() {
+		return nil
+	}
 	tables := db.catalog.ListTablesNeedingVacuum(threshold)
 	for _, tableName := range tables {
+		start := time.Now()
 		if err := db.catalog.VacuumTable(tableName, db.options.Maintenance.AutoVacuumRetention); err != nil {
 			if db.options.CoreStorage.Logger != nil {
 				db.options.CoreStorage.Logger.Warnf("AutoVacuum failed for table %s: %v", tableName, err)
 			}
+			db.noteBackgroundError("auto-vacuum", tableName, err)
 		} else {
+			db.emitEvent(Event{Kind: EventCompaction, Table: tableName, Duration: time.Since(start)})
 			if db.options.CoreStorage.Logger != nil {
 				db.options.CoreStorage.Logger.Infof("AutoVacuum completed for table %s", tableName)
 			}
@@ -1191,12 +1384,23 @@ func (db *DB) runAutoVacuumJob(threshold float64) error {
 // runAnalyzeJob runs ANALYZE on all tables to update query planner statistics.
 
 func (db *DB) runAnalyzeJob() error {
-	tables := db.catalog.ListTables()
+	return db.analyzeTables(db.catalog.ListTables())
+}
+
+// runThresholdAnalyzeJob runs ANALYZE on the tables whose rows written since
+// their last ANALYZE reach threshold, a fraction of their row count, so the
+// planner statistics of busy tables do not wait for the hourly job.
+func (db *DB) runThresholdAnalyzeJob(threshold float64) error {
+	return db.analyzeTables(db.catalog.ListTablesNeedingAnalyze(threshold))
+}
+
+func (db *DB) analyzeTables(tables []string) error {
 	for _, tableName := range tables {
 		if err := db.catalog.Analyze(tableName); err != nil {
 			if db.options.CoreStorage.Logger != nil {
 				db.options.CoreStorage.Logger.Warnf("AutoAnalyze failed for table %s: %v", tableName, err)
 			}
+			db.noteBackgroundError("auto-analyze", tableName, err)
 		} else {
 			if db.options.CoreStorage.Logger != nil {
 				db.options.CoreStorage.Logger.Infof("AutoAnalyze completed for table %s", tableName)
@@ -1215,6 +1419,7 @@ func (db *DB) runCheckpointJob() error {
 		if db.options.CoreStorage.Logger != nil {
 			db.options.CoreStorage.Logger.Warnf("AutoCheckpoint failed: %v", err)
 		}
+		db.noteBackgroundError("auto-checkpoint", "", err)
 		return err
 	}
 	if db.options.CoreStorage.Logger != nil {
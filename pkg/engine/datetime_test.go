@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func queryString(t *testing.T, db *DB, sql string) interface{} {
+	t.Helper()
+	rows, err := db.Query(context.Background(), sql)
+	if err != nil {
+		t.Fatalf("Query %q failed: %v", sql, err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatalf("Query %q returned no rows", sql)
+	}
+	var v interface{}
+	if err := rows.Scan(&v); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	return v
+}
+
+func TestDateTimeFunctions(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	cases := []struct {
+		sql  string
+		want interface{}
+	}{
+		{"SELECT DATE('2024-01-31', '+1 month')", "2024-03-02"},
+		{"SELECT DATE('2024-03-15 10:30:00', 'start of month', '-1 day')", "2024-02-29"},
+		{"SELECT DATETIME('2024-01-01 00:00:00', '+90 minutes', '+30 seconds')", "2024-01-01 01:30:30"},
+		{"SELECT DATE('2024-01-01', 'weekday 5')", "2024-01-05"},
+		{"SELECT DATETIME(1700000000, 'unixepoch')", "2023-11-14 22:13:20"},
+		{"SELECT TIME('2024-01-01 13:14:15', '+1 hour')", "14:14:15"},
+		{"SELECT STRFTIME('%Y/%m/%d', '2024-02-28', '+1 day')", "2024/02/29"},
+		{"SELECT JULIANDAY('2000-01-01 12:00:00')", 2451545.0},
+		{"SELECT UNIXEPOCH('1970-01-02')", int64(86400)},
+		{"SELECT DATE '2024-06-01'", "2024-06-01"},
+		{"SELECT TIMESTAMP '2024-06-01 08:00:00+02:00'", "2024-06-01 06:00:00"},
+		{"SELECT DATE('not a date')", nil},
+		{"SELECT DATE('2024-01-01', '+1 fortnight')", nil},
+	}
+	for _, tc := range cases {
+		if got := queryString(t, db, tc.sql); got != tc.want {
+			t.Errorf("%s = %#v, want %#v", tc.sql, got, tc.want)
+		}
+	}
+
+	before := time.Now().UTC().Format("2006-01-02")
+	got := queryString(t, db, "SELECT CURRENT_DATE")
+	if after := time.Now().UTC().Format("2006-01-02"); got != before && got != after {
+		t.Errorf("CURRENT_DATE = %v, want %s (UTC)", got, before)
+	}
+}
+
+func TestTemporalColumnsAreNormalized(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE ev (id INTEGER PRIMARY KEY, d DATE, ts TIMESTAMP, created DATETIME DEFAULT CURRENT_TIMESTAMP)")
+	if _, err := db.Exec(ctx, "INSERT INTO ev (id, d, ts) VALUES (1, ?, ?)",
+		"2024-01-05T10:00:00Z", "2024-01-05T10:00:00+02:00"); err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	if got := queryString(t, db, "SELECT d FROM ev WHERE id = 1"); got != "2024-01-05" {
+		t.Errorf("stored DATE = %#v", got)
+	}
+	if got := queryString(t, db, "SELECT ts FROM ev WHERE id = 1"); got != "2024-01-05 08:00:00" {
+		t.Errorf("stored TIMESTAMP = %#v", got)
+	}
+	created, _ := queryString(t, db, "SELECT created FROM ev WHERE id = 1").(string)
+	if _, err := time.Parse("2006-01-02 15:04:05", created); err != nil {
+		t.Errorf("DEFAULT CURRENT_TIMESTAMP stored %q", created)
+	}
+
+	mustExec(t, db, "UPDATE ev SET d = DATE(d, '+1 year') WHERE id = 1")
+	if got := queryString(t, db, "SELECT d FROM ev WHERE id = 1"); got != "2025-01-05" {
+		t.Errorf("updated DATE = %#v", got)
+	}
+
+	_, err = db.Exec(ctx, "INSERT INTO ev (id, d) VALUES (2, 'garbage')")
+	if err == nil || !strings.Contains(err.Error(), "invalid DATE value") {
+		t.Fatalf("INSERT of invalid date = %v, want invalid DATE value error", err)
+	}
+	if _, err := db.Exec(ctx, "UPDATE ev SET ts = 'yesterday-ish' WHERE id = 1"); err == nil {
+		t.Fatal("UPDATE with invalid timestamp should fail")
+	}
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// DDLEvent describes a schema change that has just been applied.
+type DDLEvent struct {
+	// Action names the statement, e.g. "CREATE_TABLE", "DROP_INDEX" or
+	// "ALTER_TABLE" (the same names the audit log uses).
+	Action string
+	// ObjectType is the kind of object created, changed or dropped: TABLE,
+	// INDEX, VIEW, MATERIALIZED_VIEW, TRIGGER, PROCEDURE, POLICY or
+	// COLLECTION.
+	ObjectType string
+	// Object is the affected object's name.
+	Object string
+	// Table is the table the object belongs to (the table itself for table
+	// DDL), or "" when the statement does not name one.
+	Table string
+	// SQL is the statement text as submitted.
+	SQL  string
+	Time time.Time
+}
+
+// OnDDL registers fn to be called after every successful DDL statement
+// (CREATE, ALTER or DROP of a table, index, view, trigger, procedure, policy
+// or collection). fn runs synchronously on the goroutine that executed the
+// statement, after the statement has been applied; inside an explicit
+// transaction that is before COMMIT. The returned function unregisters fn.
+func (db *DB) OnDDL(fn func(DDLEvent)) (unregister func()) {
+	return db.ddlHooks.add(fn)
+}
+
+// SubscribeDDL returns a channel that receives a DDLEvent for every
+// successful DDL statement, for consumers that prefer notifications to
+// callbacks. Events are dropped rather than blocking the statement when the
+// channel's buffer is full. cancel unsubscribes and closes the channel.
+func (db *DB) SubscribeDDL(buffer int) (events <-chan DDLEvent, cancel func()) {
+	return db.ddlHooks.subscribe(buffer)
+}
+
+// fireDDLHooks reports stmt to the registered hooks if it is DDL.
+func (db *DB) fireDDLHooks(stmt query.Statement, sql string) {
+	hooks := db.ddlHooks.snapshot()
+	if len(hooks) == 0 {
+		return
+	}
+	ev, ok := ddlEventFor(stmt)
+	if !ok {
+		return
+	}
+	ev.SQL = strings.TrimSpace(sql)
+	ev.Time = time.Now()
+	for _, fn := range hooks {
+		fn(ev)
+	}
+}
+
+// ddlEventFor describes stmt as a DDLEvent, or reports false if stmt does
+// not change the schema.
+func ddlEventFor(stmt query.Statement) (DDLEvent, bool) {
+	switch s := stmt.(type) {
+	case *query.CreateTableStmt:
+		return DDLEvent{Action: "CREATE_TABLE", ObjectType: "TABLE", Object: s.Table, Table: s.Table}, true
+	case *query.CreateVirtualTableStmt:
+		return DDLEvent{Action: "CREATE_VIRTUAL_TABLE", ObjectType: "TABLE", Object: s.Table, Table: s.Table}, true
+	case *query.CreateForeignTableStmt:
+		return DDLEvent{Action: "CREATE_FOREIGN_TABLE", ObjectType: "TABLE", Object: s.Table, Table: s.Table}, true
+	case *query.AlterTableStmt:
+		return DDLEvent{Action: "ALTER_TABLE", ObjectType: "TABLE", Object: s.Table, Table: s.Table}, true
+	case *query.DropTableStmt:
+		return DDLEvent{Action: "DROP_TABLE", ObjectType: "TABLE", Object: s.Table, Table: s.Table}, true
+	case *query.CreateCollectionStmt:
+		return DDLEvent{Action: "CREATE_COLLECTION", ObjectType: "COLLECTION", Object: s.Name, Table: s.Name}, true
+	case *query.DropCollectionStmt:
+		return DDLEvent{Action: "DROP_COLLECTION", ObjectType: "COLLECTION", Object: s.Name, Table: s.Name}, true
+	case *query.CreateIndexStmt:
+		return DDLEvent{Action: "CREATE_INDEX", ObjectType: "INDEX", Object: s.Index, Table: s.Table}, true
+	case *query.CreateFTSIndexStmt:
+		return DDLEvent{Action: "CREATE_FTS_INDEX", ObjectType: "INDEX", Object: s.Index, Table: s.Table}, true
+	case *query.CreateVectorIndexStmt:
+		return DDLEvent{Action: "CREATE_VECTOR_INDEX", ObjectType: "INDEX", Object: s.Index, Table: s.Table}, true
+	case *query.DropIndexStmt:
+		return DDLEvent{Action: "DROP_INDEX", ObjectType: "INDEX", Object: s.Index}, true
+	case *query.CreateViewStmt:
+		return DDLEvent{Action: "CREATE_VIEW", ObjectType: "VIEW", Object: s.Name}, true
+	case *query.DropViewStmt:
+		return DDLEvent{Action: "DROP_VIEW", ObjectType: "VIEW", Object: s.Name}, true
+	case *query.CreateMaterializedViewStmt:
+		return DDLEvent{Action: "CREATE_MATERIALIZED_VIEW", ObjectType: "MATERIALIZED_VIEW", Object: s.Name}, true
+	case *query.DropMaterializedViewStmt:
+		return DDLEvent{Action: "DROP_MATERIALIZED_VIEW", ObjectType: "MATERIALIZED_VIEW", Object: s.Name}, true
+	case *query.CreateTriggerStmt:
+		return DDLEvent{Action: "CREATE_TRIGGER", ObjectType: "TRIGGER", Object: s.Name, Table: s.Table}, true
+	case *query.DropTriggerStmt:
+		return DDLEvent{Action: "DROP_TRIGGER", ObjectType: "TRIGGER", Object: s.Name}, true
+	case *query.CreateProcedureStmt:
+		return DDLEvent{Action: "CREATE_PROCEDURE", ObjectType: "PROCEDURE", Object: s.Name}, true
+	case *query.DropProcedureStmt:
+		return DDLEvent{Action: "DROP_PROCEDURE", ObjectType: "PROCEDURE", Object: s.Name}, true
+	case *query.CreateSequenceStmt:
+		return DDLEvent{Action: "CREATE_SEQUENCE", ObjectType: "SEQUENCE", Object: s.Name}, true
+	case *query.DropSequenceStmt:
+		return DDLEvent{Action: "DROP_SEQUENCE", ObjectType: "SEQUENCE", Object: s.Name}, true
+	case *query.CreateSchemaStmt:
+		return DDLEvent{Action: "CREATE_SCHEMA", ObjectType: "SCHEMA", Object: s.Name}, true
+	case *query.DropSchemaStmt:
+		return DDLEvent{Action: "DROP_SCHEMA", ObjectType: "SCHEMA", Object: s.Name}, true
+	case *query.CreatePolicyStmt:
+		return DDLEvent{Action: "CREATE_POLICY", ObjectType: "POLICY", Object: s.Name, Table: s.Table}, true
+	case *query.DropPolicyStmt:
+		return DDLEvent{Action: "DROP_POLICY", ObjectType: "POLICY", Object: s.Name, Table: s.Table}, true
+	}
+	return DDLEvent{}, false
+}
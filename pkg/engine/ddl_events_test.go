@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOnDDLHooks(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	var events []DDLEvent
+	unregister := db.OnDDL(func(ev DDLEvent) { events = append(events, ev) })
+	notes, cancel := db.SubscribeDDL(8)
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 'a')") // not DDL
+	mustExec(t, db, "CREATE INDEX idx_t_v ON t (v)")
+	if _, err := db.Exec(ctx, "CREATE TABLE t (id INTEGER)"); err == nil {
+		t.Fatal("expected error creating a duplicate table")
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "ALTER TABLE t ADD COLUMN w INTEGER"); err != nil {
+		t.Fatalf("ALTER in tx: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	mustExec(t, db, "  DROP INDEX idx_t_v ")
+
+	want := []DDLEvent{
+		{Action: "CREATE_TABLE", ObjectType: "TABLE", Object: "t", Table: "t", SQL: "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)"},
+		{Action: "CREATE_INDEX", ObjectType: "INDEX", Object: "idx_t_v", Table: "t", SQL: "CREATE INDEX idx_t_v ON t (v)"},
+		{Action: "ALTER_TABLE", ObjectType: "TABLE", Object: "t", Table: "t", SQL: "ALTER TABLE t ADD COLUMN w INTEGER"},
+		{Action: "DROP_INDEX", ObjectType: "INDEX", Object: "idx_t_v", SQL: "DROP INDEX idx_t_v"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, ev := range events {
+		if ev.Time.IsZero() {
+			t.Errorf("event %d has no time", i)
+		}
+		ev.Time = want[i].Time
+		if ev != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, ev, want[i])
+		}
+	}
+	for i := range want {
+		if ev := <-notes; ev.Action != want[i].Action {
+			t.Errorf("notification %d = %s, want %s", i, ev.Action, want[i].Action)
+		}
+	}
+
+	unregister()
+	cancel()
+	mustExec(t, db, "DROP TABLE t")
+	if len(events) != len(want) {
+		t.Fatalf("hook fired after unregister: %+v", events[len(want):])
+	}
+	if _, open := <-notes; open {
+		t.Fatal("subscription channel still open after cancel")
+	}
+}
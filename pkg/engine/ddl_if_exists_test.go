@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+// provisioningScript exercises IF [NOT] EXISTS on every DDL statement; it
+// must succeed when run repeatedly against the same database.
+var provisioningScript = []string{
+	"CREATE TABLE IF NOT EXISTS items (id INTEGER PRIMARY KEY, name TEXT, qty INTEGER)",
+	"CREATE TABLE IF NOT EXISTS items_copy AS SELECT * FROM items",
+	"ALTER TABLE items ADD COLUMN IF NOT EXISTS sku TEXT UNIQUE",
+	"ALTER TABLE items DROP COLUMN IF EXISTS legacy",
+	"ALTER TABLE IF EXISTS retired ADD COLUMN x INTEGER",
+	"ALTER TABLE items DROP CONSTRAINT IF EXISTS items_old_check",
+	"CREATE INDEX IF NOT EXISTS idx_items_name ON items (name)",
+	"CREATE UNIQUE INDEX IF NOT EXISTS idx_items_qty ON items (qty)",
+	"CREATE FULLTEXT INDEX IF NOT EXISTS fts_items ON items (name)",
+	"CREATE VIEW IF NOT EXISTS item_names AS SELECT name FROM items",
+	"CREATE MATERIALIZED VIEW IF NOT EXISTS item_totals AS SELECT COUNT(*) AS n FROM items",
+	"CREATE TRIGGER IF NOT EXISTS items_audit AFTER INSERT ON items BEGIN DELETE FROM items_copy WHERE id < 0; END",
+	"CREATE PROCEDURE IF NOT EXISTS restock() BEGIN UPDATE items SET qty = qty + 1; END",
+	"CREATE POLICY IF NOT EXISTS items_visible ON items USING (qty >= 0)",
+	"DROP POLICY IF EXISTS items_hidden ON items",
+	"DROP TRIGGER IF EXISTS items_old_trigger",
+	"DROP PROCEDURE IF EXISTS old_proc",
+	"DROP VIEW IF EXISTS old_view",
+	"DROP MATERIALIZED VIEW IF EXISTS old_totals",
+	"DROP INDEX IF EXISTS idx_old",
+	"DROP TABLE IF EXISTS retired",
+}
+
+func TestDDLIfExistsIsIdempotent(t *testing.T) {
+	db, err := Open(":memory:", &Options{
+		CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024},
+		Security:    Security{EnableRLS: true},
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	for run := 1; run <= 3; run++ {
+		for _, sql := range provisioningScript {
+			if _, err := db.Exec(ctx, sql); err != nil {
+				t.Fatalf("run %d: %s: %v", run, sql, err)
+			}
+		}
+		if run == 1 {
+			mustExec(t, db, "INSERT INTO items (id, name, qty, sku) VALUES (1, 'bolt', 5, 'B-1')")
+		}
+	}
+
+	// CTAS IF NOT EXISTS must not copy rows into the existing table.
+	var n int
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM items_copy").Scan(&n); err != nil || n != 0 {
+		t.Fatalf("items_copy has %d rows, %v; want 0", n, err)
+	}
+	// The existing index was kept, not rebuilt with a new definition.
+	if _, err := db.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_items_name ON items (qty)"); err != nil {
+		t.Fatalf("CREATE INDEX IF NOT EXISTS with a different definition: %v", err)
+	}
+	idx, err := db.catalog.GetIndex("idx_items_name")
+	if err != nil || len(idx.Columns) != 1 || idx.Columns[0] != "name" {
+		t.Fatalf("idx_items_name = %+v, %v; want the original (name) index", idx, err)
+	}
+}
+
+func TestDDLWithoutIfExistsStillFails(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "CREATE TRIGGER items_audit AFTER INSERT ON items BEGIN SELECT 1; END")
+
+	for _, sql := range []string{
+		"CREATE TABLE items (id INTEGER PRIMARY KEY)",
+		"CREATE TRIGGER items_audit AFTER INSERT ON items BEGIN SELECT 1; END",
+		"ALTER TABLE items ADD COLUMN name TEXT",
+		"ALTER TABLE items DROP COLUMN missing",
+		"ALTER TABLE retired ADD COLUMN x INTEGER",
+		"DROP INDEX idx_missing",
+		"DROP TRIGGER missing_trigger",
+		// IF NOT EXISTS only skips existing objects; other errors still surface.
+		"CREATE TRIGGER IF NOT EXISTS t2 AFTER INSERT ON missing BEGIN SELECT 1; END",
+		"ALTER TABLE IF EXISTS items DROP COLUMN missing",
+	} {
+		if _, err := db.Exec(ctx, sql); err == nil {
+			t.Errorf("%s: expected an error", sql)
+		}
+	}
+}
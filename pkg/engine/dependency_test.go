@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDropTableRestrictAndCascade(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, active INTEGER)")
+	mustExec(t, db, "CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id))")
+	mustExec(t, db, "CREATE VIEW active_users AS SELECT id, name FROM users WHERE active = 1")
+	mustExec(t, db, "CREATE VIEW active_names AS SELECT name FROM active_users")
+	mustExec(t, db, "CREATE VIEW order_users AS SELECT o.id FROM orders o JOIN users u ON u.id = o.user_id")
+	mustExec(t, db, "INSERT INTO users VALUES (1, 'ada', 1)")
+	mustExec(t, db, "INSERT INTO orders VALUES (10, 1)")
+
+	got := db.catalog.Dependents("users")
+	var names []string
+	for _, dep := range got {
+		names = append(names, dep.String())
+	}
+	want := "foreign key fk_user on table orders, view active_users, view order_users"
+	if strings.Join(names, ", ") != want {
+		t.Fatalf("Dependents(users) = %v, want %s", names, want)
+	}
+
+	// RESTRICT, the default, names every dependent.
+	for _, sql := range []string{"DROP TABLE users", "DROP TABLE users RESTRICT"} {
+		_, err := db.Exec(ctx, sql)
+		if err == nil {
+			t.Fatalf("%s: dropped a table with dependents", sql)
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %q does not list the dependents", sql, err)
+		}
+	}
+	if _, err := db.Exec(ctx, "DROP VIEW active_users"); err == nil || !strings.Contains(err.Error(), "view active_names") {
+		t.Errorf("DROP VIEW active_users: err = %v, want it to name active_names", err)
+	}
+
+	// A rolled-back CASCADE restores everything it dropped.
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "DROP TABLE users CASCADE")
+	mustExec(t, db, "ROLLBACK")
+	assertScalar(t, db, "SELECT COUNT(*) FROM active_names", int64(1))
+	if deps := db.catalog.Dependents("users"); len(deps) != 3 {
+		t.Fatalf("dependents after rollback = %v", deps)
+	}
+
+	mustExec(t, db, "DROP TABLE users CASCADE")
+	for _, view := range []string{"active_users", "active_names", "order_users"} {
+		if _, err := db.Query(ctx, "SELECT * FROM "+view); err == nil {
+			t.Errorf("view %s survived DROP TABLE CASCADE", view)
+		}
+	}
+	// The referencing table stays; only its foreign key goes.
+	mustExec(t, db, "INSERT INTO orders VALUES (11, 99)")
+	assertScalar(t, db, "SELECT COUNT(*) FROM orders", int64(2))
+
+	mustExec(t, db, "CREATE VIEW v1 AS SELECT id FROM orders")
+	mustExec(t, db, "CREATE VIEW v2 AS SELECT id FROM v1")
+	mustExec(t, db, "DROP VIEW v1 CASCADE")
+	if _, err := db.Query(ctx, "SELECT * FROM v2"); err == nil {
+		t.Error("v2 survived DROP VIEW v1 CASCADE")
+	}
+}
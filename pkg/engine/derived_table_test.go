@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDerivedTables(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE users (user_id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, amt INTEGER)")
+	mustExec(t, db, "INSERT INTO users VALUES (1, 'ann'), (2, 'bob'), (3, 'cy')")
+	mustExec(t, db, "INSERT INTO orders VALUES (10, 1, 5), (11, 2, 7), (12, 2, 1), (13, 4, 9)")
+
+	const totals = "(SELECT user_id, SUM(amt) AS total FROM orders GROUP BY user_id)"
+	tests := []struct {
+		sql  string
+		cols string
+		want string
+	}{
+		{"SELECT * FROM " + totals + " AS t ORDER BY total DESC",
+			"user_id total", "[4 9] [2 8] [1 5]"},
+		{"SELECT t.user_id, t.total FROM " + totals + " t WHERE t.total > 5 ORDER BY 1",
+			"user_id total", "[2 8] [4 9]"},
+		{"SELECT COUNT(*), SUM(total), MAX(t.user_id) FROM " + totals + " t",
+			"COUNT(*) SUM(total) MAX(user_id)", "[3 22 4]"},
+		{"SELECT x.n FROM (SELECT y.n FROM (SELECT COUNT(*) AS n FROM orders) y) x",
+			"n", "[4]"},
+		{"SELECT * FROM (SELECT id FROM orders ORDER BY id DESC LIMIT 2) t",
+			"id", "[13] [12]"},
+		{"SELECT * FROM (SELECT user_id FROM users UNION SELECT user_id FROM orders) u ORDER BY 1",
+			"user_id", "[1] [2] [3] [4]"},
+		// A column list renames the derived table's columns.
+		{"SELECT t.a, b FROM (SELECT id, amt FROM orders) AS t (a, b) WHERE b > 5 ORDER BY 1",
+			"a b", "[11 7] [13 9]"},
+		{"SELECT u.name, t.total FROM users u JOIN " + totals + " t ON t.user_id = u.user_id ORDER BY u.name",
+			"name total", "[ann 5] [bob 8]"},
+		{"SELECT u.name, t.total FROM " + totals + " t JOIN users u ON t.user_id = u.user_id ORDER BY u.name",
+			"name total", "[ann 5] [bob 8]"},
+		{"SELECT u.name, t.total FROM users u LEFT JOIN " + totals + " t ON t.user_id = u.user_id ORDER BY u.name",
+			"name total", "[ann 5] [bob 8] [cy <nil>]"},
+		{"SELECT a.id, b.id FROM (SELECT id FROM orders) a JOIN (SELECT id FROM orders) b ON b.id = a.id + 1 ORDER BY 1",
+			"id id", "[10 11] [11 12] [12 13]"},
+		{"SELECT u.name, t.n FROM users u JOIN (SELECT user_id, COUNT(*) FROM orders GROUP BY user_id) AS t (uid, n) ON t.uid = u.user_id ORDER BY 1",
+			"name n", "[ann 1] [bob 2]"},
+		// A joined SELECT * derived table keeps all of its columns.
+		{"SELECT * FROM users u JOIN (SELECT * FROM orders) t USING (user_id) ORDER BY id",
+			"user_id name id amt", "[1 ann 10 5] [2 bob 11 7] [2 bob 12 1]"},
+		{"SELECT t.user_id, SUM(t.amt) FROM (SELECT * FROM orders) t JOIN users u ON u.user_id = t.user_id GROUP BY t.user_id ORDER BY 1",
+			"user_id SUM(amt)", "[1 5] [2 8]"},
+		// A derived table's alias shadows a CTE of the same name only inside it.
+		{"WITH t AS (SELECT 1 AS x) SELECT t.x, d.id FROM t JOIN (SELECT id FROM (SELECT id FROM orders) t) d ON d.id > 12",
+			"x id", "[1 13]"},
+	}
+	for _, tt := range tests {
+		rows, err := db.Query(ctx, tt.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sql, err)
+		}
+		cols := strings.Join(rows.Columns(), " ")
+		rows.Close()
+		if cols != tt.cols {
+			t.Errorf("%s: columns %s, want %s", tt.sql, cols, tt.cols)
+		}
+		var got []string
+		for _, row := range queryAll(t, db, ctx, tt.sql) {
+			got = append(got, fmt.Sprint(row))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%s = %s, want %s", tt.sql, s, tt.want)
+		}
+	}
+
+	for sql, want := range map[string]string{
+		"SELECT * FROM (SELECT id, amt FROM orders) AS t (a)":                    "has 2 columns but its column list names 1",
+		"SELECT * FROM users u JOIN (SELECT id, amt FROM orders) t (a) ON 1 = 1": "has 2 columns but its column list names 1",
+	} {
+		if _, err := db.Query(ctx, sql); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", sql, err, want)
+		}
+	}
+}
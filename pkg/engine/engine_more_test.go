@@ -511,6 +511,31 @@ func TestRowsScanCopiesMutableValues(t *testing.T) {
 	}
 }
 
+func TestRowsScanUnboxesInternedStrings(t *testing.T) {
+	db, _ := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	defer db.Close()
+
+	ctx := context.Background()
+	db.Exec(ctx, `CREATE TABLE test (id INTEGER PRIMARY KEY, name TEXT)`)
+	db.Exec(ctx, `INSERT INTO test VALUES (1, 'alice')`)
+
+	rows, err := db.Query(ctx, `SELECT name FROM test`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("expected row")
+	}
+	var name interface{}
+	if err := rows.Scan(&name); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if got, ok := name.(string); !ok || got != "alice" {
+		t.Fatalf("Scan into interface{} = %#v, want string \"alice\"", name)
+	}
+}
+
 func TestRowsScanMismatch(t *testing.T) {
 	db, _ := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
 	defer db.Close()
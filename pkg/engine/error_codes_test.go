@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
+)
+
+func TestErrorCodes(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE parent (id INTEGER PRIMARY KEY)")
+	mustExec(t, db, `CREATE TABLE child (
+		id INTEGER PRIMARY KEY,
+		email TEXT UNIQUE,
+		name TEXT NOT NULL,
+		qty INTEGER CHECK (qty > 0),
+		parent_id INTEGER REFERENCES parent(id))`)
+	mustExec(t, db, "INSERT INTO parent VALUES (1)")
+	mustExec(t, db, "INSERT INTO child VALUES (1, 'a@x', 'a', 1, 1)")
+
+	tests := []struct {
+		sql  string
+		want *sqlerr.Error
+	}{
+		{"INSERT INTO child VALUES (1, 'b@x', 'b', 1, 1)", sqlerr.ErrConstraintUnique},
+		{"INSERT INTO child VALUES (2, 'a@x', 'b', 1, 1)", sqlerr.ErrConstraintUnique},
+		{"INSERT INTO child VALUES (2, 'b@x', NULL, 1, 1)", sqlerr.ErrConstraintNotNull},
+		{"INSERT INTO child VALUES (2, 'b@x', 'b', 0, 1)", sqlerr.ErrConstraintCheck},
+		{"INSERT INTO child VALUES (2, 'b@x', 'b', 1, 9)", sqlerr.ErrConstraintFK},
+		{"DELETE FROM parent WHERE id = 1", sqlerr.ErrConstraintFK},
+		{"INSERT INTO missing VALUES (1)", sqlerr.ErrTableNotFound},
+		{"UPDATE child SET missing = 1", sqlerr.ErrColumnNotFound},
+		{"CREATE TABLE parent (id INTEGER)", sqlerr.ErrTableExists},
+		{"DROP INDEX missing", sqlerr.ErrIndexNotFound},
+		{"SELEC 1", sqlerr.ErrSyntax},
+	}
+	for _, tt := range tests {
+		_, err := db.Exec(ctx, tt.sql)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v (code %q), want %s", tt.sql, err, sqlerr.CodeOf(err), tt.want.Code)
+		}
+	}
+
+	_, err = db.Query(ctx, "SELECT id\nFROM child WHERE (id = 1")
+	var se *sqlerr.SyntaxError
+	if !errors.As(err, &se) {
+		t.Fatalf("Query = %v, want a *sqlerr.SyntaxError", err)
+	}
+	if se.Line != 2 || se.Column == 0 {
+		t.Fatalf("syntax error at line %d column %d, want line 2", se.Line, se.Column)
+	}
+}
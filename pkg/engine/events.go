@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventKind identifies what an Event reports.
+type EventKind string
+
+const (
+	// EventCheckpoint is emitted after a checkpoint, explicit or automatic,
+	// has flushed the table trees and truncated the WAL.
+	EventCheckpoint EventKind = "checkpoint"
+	// EventCompaction is emitted after VACUUM or auto-vacuum compacted a
+	// table.
+	EventCompaction EventKind = "compaction"
+	// EventSlowQuery is emitted for a statement that ran at least the slow
+	// query threshold (SlowQueryLog.Threshold, default 1s), whether or not
+	// the slow query log is enabled.
+	EventSlowQuery EventKind = "slow_query"
+	// EventReplicationLag is emitted when the replication manager reports a
+	// replica falling behind.
+	EventReplicationLag EventKind = "replication_lag"
+	// EventError is emitted when a background job fails or a panic is
+	// recovered. Errors returned to a caller are not reported again.
+	EventError EventKind = "error"
+)
+
+// Event is a structured notification about the engine's health, for
+// embedders that show a status view without parsing logs. Only the fields
+// relevant to Kind are set.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	// Duration is how long the checkpoint, compaction or query took.
+	Duration time.Duration
+	// Table is the compacted table ("" when VACUUM covered every table) or
+	// the table a failed background job was working on.
+	Table string
+	// SQL and RowsAffected describe a slow query.
+	SQL          string
+	RowsAffected int64
+	// Replica and Lag describe replication lag.
+	Replica string
+	Lag     time.Duration
+	// Operation names what failed, e.g. "auto-checkpoint" or "Exec", and
+	// Err is the failure.
+	Operation string
+	Err       error
+}
+
+// OnEvent registers fn to be called for every engine event. fn runs
+// synchronously on the goroutine that produced the event (a statement,
+// Checkpoint caller or background job), so it should return quickly. The
+// returned function unregisters fn.
+func (db *DB) OnEvent(fn func(Event)) (unregister func()) {
+	return db.eventHooks.add(fn)
+}
+
+// SubscribeEvents returns a channel that receives every engine event.
+// Events are dropped rather than blocking the engine when the channel's
+// buffer is full. cancel unsubscribes and closes the channel.
+func (db *DB) SubscribeEvents(buffer int) (events <-chan Event, cancel func()) {
+	return db.eventHooks.subscribe(buffer)
+}
+
+// emitEvent delivers ev to the registered hooks.
+func (db *DB) emitEvent(ev Event) {
+	hooks := db.eventHooks.snapshot()
+	if len(hooks) == 0 {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	for _, fn := range hooks {
+		fn(ev)
+	}
+}
+
+// noteSlowQuery emits EventSlowQuery if sql ran past the slow query
+// threshold.
+func (db *DB) noteSlowQuery(sql string, elapsed time.Duration, rowsAffected int64) {
+	if len(db.eventHooks.snapshot()) == 0 {
+		return
+	}
+	threshold := db.options.SlowQueryLog.Threshold
+	if threshold == 0 {
+		threshold = time.Second
+	}
+	if elapsed < threshold {
+		return
+	}
+	db.emitEvent(Event{Kind: EventSlowQuery, Duration: elapsed, SQL: sql, RowsAffected: rowsAffected})
+}
+
+// noteBackgroundError emits EventError for a failed background job.
+func (db *DB) noteBackgroundError(operation string, table string, err error) {
+	db.emitEvent(Event{Kind: EventError, Operation: operation, Table: table, Err: err})
+}
+
+// hookList holds registered callbacks for one event type. The list is
+// replaced copy-on-write so firing reads it without taking the lock.
+type hookList[T any] struct {
+	mu     sync.Mutex
+	nextID int
+	hooks  map[int]func(T)
+	list   atomic.Pointer[[]func(T)]
+}
+
+func (h *hookList[T]) add(fn func(T)) (unregister func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.hooks == nil {
+		h.hooks = make(map[int]func(T))
+	}
+	id := h.nextID
+	h.nextID++
+	h.hooks[id] = fn
+	h.rebuildLocked()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.hooks, id)
+			h.rebuildLocked()
+		})
+	}
+}
+
+// subscribe adapts the hook list to a buffered channel that drops events
+// when full.
+func (h *hookList[T]) subscribe(buffer int) (<-chan T, func()) {
+	ch := make(chan T, buffer)
+	var mu sync.Mutex
+	closed := false
+	unregister := h.add(func(ev T) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	})
+	return ch, func() {
+		unregister()
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			closed = true
+			close(ch)
+		}
+	}
+}
+
+func (h *hookList[T]) rebuildLocked() {
+	ids := make([]int, 0, len(h.hooks))
+	for id := range h.hooks {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids) // fire in registration order
+	list := make([]func(T), len(ids))
+	for i, id := range ids {
+		list[i] = h.hooks[id]
+	}
+	h.list.Store(&list)
+}
+
+func (h *hookList[T]) snapshot() []func(T) {
+	if list := h.list.Load(); list != nil {
+		return *list
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEngineEvents(t *testing.T) {
+	opts := durabilityTestOptions()
+	opts.SlowQueryLog.Threshold = time.Nanosecond
+	db, err := Open(filepath.Join(t.TempDir(), "events.db"), opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	var events []Event
+	unregister := db.OnEvent(func(ev Event) { events = append(events, ev) })
+	notes, cancel := db.SubscribeEvents(1)
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	mustExec(t, db, "VACUUM t")
+
+	kinds := map[EventKind]int{}
+	for _, ev := range events {
+		if ev.Time.IsZero() {
+			t.Errorf("%s event has no time", ev.Kind)
+		}
+		kinds[ev.Kind]++
+		switch ev.Kind {
+		case EventCompaction:
+			if ev.Table != "t" {
+				t.Errorf("compaction event table = %q, want t", ev.Table)
+			}
+		case EventSlowQuery:
+			if ev.SQL == "" || ev.Duration <= 0 {
+				t.Errorf("slow query event = %+v, want SQL and duration", ev)
+			}
+		}
+	}
+	if kinds[EventCheckpoint] != 1 || kinds[EventCompaction] != 1 || kinds[EventSlowQuery] != 2 {
+		t.Fatalf("event counts = %v, want 1 checkpoint, 1 compaction, 2 slow queries", kinds)
+	}
+	// The one-slot channel kept the first event and dropped the rest.
+	if ev := <-notes; ev.Kind != EventSlowQuery {
+		t.Fatalf("first notification = %s, want %s", ev.Kind, EventSlowQuery)
+	}
+	cancel()
+	if _, open := <-notes; open {
+		t.Fatal("subscription channel still open after cancel")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	events = nil
+	if err := db.runCheckpointJob(); err == nil {
+		t.Fatal("checkpoint job on a closed database succeeded")
+	}
+	if len(events) != 1 || events[0].Kind != EventError || events[0].Operation != "auto-checkpoint" ||
+		!errors.Is(events[0].Err, ErrDatabaseClosed) {
+		t.Fatalf("events after failed checkpoint job = %+v, want one auto-checkpoint error", events)
+	}
+
+	unregister()
+	db.emitEvent(Event{Kind: EventCheckpoint})
+	if len(events) != 1 {
+		t.Fatalf("hook fired after unregister: %+v", events[1:])
+	}
+}
@@ -16,6 +16,7 @@ type PlanNode struct {
 	Detail    string
 	Cost      float64
 	Rows      int64
+	Table     string // Table a scan node reads or a join node joins in
 }
 
 // QueryPlan represents a structured query execution plan
@@ -179,6 +180,12 @@ func (db *DB) buildTableScanPlan(tableRef *query.TableRef, where query.Expressio
 	if where != nil && db.optimizer != nil {
 		bestIndex = db.optimizer.SelectBestIndex(tableRef.Name, where)
 	}
+	if bestIndex == "" && where != nil {
+		bestIndex = db.catalog.GINIndexForWhere(tableRef.Name, where)
+	}
+	if bestIndex == "" && where != nil {
+		bestIndex = db.catalog.BloomIndexForWhere(tableRef.Name, where)
+	}
 
 	if bestIndex != "" || indexHint != "" {
 		detail := tableName
@@ -188,7 +195,7 @@ func (db *DB) buildTableScanPlan(tableRef *query.TableRef, where query.Expressio
 			detail += " (hint: " + indexHint + ")"
 		}
 		selectivity := 0.1
-		if bestIndex != "" {
+		if bestIndex != "" && db.optimizer != nil {
 			stats := db.optimizer.GetTableStatistics(tableRef.Name)
 			if stats != nil && stats.IndexStats[bestIndex] != nil {
 				selectivity = float64(stats.IndexStats[bestIndex].Selectivity)
@@ -202,11 +209,15 @@ func (db *DB) buildTableScanPlan(tableRef *query.TableRef, where query.Expressio
 			indexRows = 1
 		}
 		cost := float64(indexRows) * 0.5
-		return pb.addNode(parentID, "Index Scan", detail, cost, indexRows)
+		id := pb.addNode(parentID, "Index Scan", detail, cost, indexRows)
+		pb.getNode(id).Table = tableRef.Name
+		return id
 	}
 
 	cost := float64(rows) * 1.0
-	return pb.addNode(parentID, "Seq Scan", tableName, cost, rows)
+	id := pb.addNode(parentID, "Seq Scan", tableName, cost, rows)
+	pb.getNode(id).Table = tableRef.Name
+	return id
 }
 
 // buildJoinPlan builds a plan node for a JOIN
@@ -232,7 +243,9 @@ func (db *DB) buildJoinPlan(join *query.JoinClause, leftID int, pb *planBuilder,
 	outputRows := db.estimateJoinRows(join, leftRows, rightRows)
 	cost := pb.getNodeCost(leftID) + pb.getNodeCost(rightID) + float64(outputRows)*2.0
 
-	return pb.addNode(parentID, joinTypeStr+" Join", joinDetail, cost, outputRows)
+	id := pb.addNode(parentID, joinTypeStr+" Join", joinDetail, cost, outputRows)
+	pb.getNode(id).Table = join.Table.Name
+	return id
 }
 
 // estimateTableRows estimates the row count for a table
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+type statementStatsKey struct{}
+
+// withStatementStats returns a context whose statements record their I/O
+// into stats.
+func withStatementStats(ctx context.Context, stats *catalog.StatementStats) context.Context {
+	return context.WithValue(ctx, statementStatsKey{}, stats)
+}
+
+func statementStatsFrom(ctx context.Context) *catalog.StatementStats {
+	stats, _ := ctx.Value(statementStatsKey{}).(*catalog.StatementStats)
+	return stats
+}
+
+// executeExplainAnalyze runs a query and returns its plan annotated with the
+// I/O each table scan actually did: buffer pool hits and backend page reads,
+// stored rows fetched, their encoded size and the index entries visited to
+// find them. A scan whose rows_scanned dwarfs the rows it returns is the
+// usual sign of a missing index. Each node also reports the rows it actually
+// produced, how many times it ran and the time spent in it, like
+// PostgreSQL's EXPLAIN ANALYZE.
+func (db *DB) executeExplainAnalyze(ctx context.Context, stmt query.Statement, args []interface{}) (*Rows, error) {
+	var plan *QueryPlan
+	switch s := stmt.(type) {
+	case *query.SelectStmt:
+		plan = db.buildQueryPlan(s)
+	case *query.SelectStmtWithCTE:
+		plan = &QueryPlan{}
+		if s.Select != nil {
+			plan = db.buildQueryPlan(s.Select)
+		}
+	default:
+		return nil, fmt.Errorf("EXPLAIN ANALYZE supports only SELECT, not %T", stmt)
+	}
+
+	stats := &catalog.StatementStats{}
+	runCtx := withStatementStats(ctx, stats)
+	finish := db.beginStatement(runCtx)
+	start := time.Now()
+	result, err := db.query(runCtx, stmt, args)
+	elapsed := time.Since(start)
+	if err = finish(err); err != nil {
+		if result != nil {
+			result.Close()
+		}
+		return nil, err
+	}
+	actualRows := int64(len(result.rows))
+	result.Close()
+
+	columns, rows := formatAnalyzedPlan(plan, stats, actualRows, elapsed)
+	return &Rows{
+		columns: columns,
+		rows:    rows,
+		pos:     0,
+	}, nil
+}
+
+// formatAnalyzedPlan adds the I/O and actuals columns to formatQueryPlan's.
+// Each table's counters go on its first scan node; tables read without one,
+// such as CTE and subquery sources, get a "Table Access" node of their own.
+// Nodes the executor did not run, or ran inside another operator, report
+// NULL actuals. A final "Execution" node carries the statement totals and
+// its actual row count.
+func formatAnalyzedPlan(plan *QueryPlan, stats *catalog.StatementStats, actualRows int64, elapsed time.Duration) ([]string, [][]interface{}) {
+	columns, rows := formatQueryPlan(plan)
+	columns = append(columns, "pages_hit", "pages_read", "rows_scanned", "bytes_decoded", "index_entries",
+		"actual_rows", "loops", "actual_time")
+
+	shown := make(map[*catalog.OperatorStats]bool)
+	actualColumns := func(op *catalog.OperatorStats) []interface{} {
+		if op == nil || op.Loops == 0 || shown[op] {
+			return []interface{}{nil, nil, nil}
+		}
+		shown[op] = true
+		return []interface{}{op.Rows, op.Loops, op.Elapsed.Round(time.Microsecond).String()}
+	}
+
+	reported := make(map[*catalog.TableIOStats]bool)
+	ioColumns := func(io *catalog.TableIOStats) []interface{} {
+		if io == nil || reported[io] {
+			return []interface{}{nil, nil, nil, nil, nil}
+		}
+		reported[io] = true
+		return []interface{}{int64(io.PagesHit), int64(io.PagesRead), io.RowsScanned, io.BytesDecoded, io.IndexEntries}
+	}
+	for i, node := range plan.Nodes {
+		var io *catalog.TableIOStats
+		op := node.Operation
+		switch {
+		case strings.HasSuffix(op, " Join"):
+			op = catalog.OperatorJoin
+		case node.Table != "":
+			io = stats.Table(node.Table)
+			op = catalog.OperatorScan
+		}
+		rows[i] = append(rows[i], ioColumns(io)...)
+		rows[i] = append(rows[i], actualColumns(stats.Operator(op, node.Table))...)
+	}
+
+	nextID := int64(len(plan.Nodes) + 1)
+	var totalRows, totalBytes, totalEntries int64
+	for _, io := range stats.Tables {
+		totalRows += io.RowsScanned
+		totalBytes += io.BytesDecoded
+		totalEntries += io.IndexEntries
+		if reported[io] {
+			continue
+		}
+		row := []interface{}{nextID, int64(0), "Table Access", io.Table, "", int64(0)}
+		row = append(row, ioColumns(io)...)
+		rows = append(rows, append(row, actualColumns(stats.Operator(catalog.OperatorScan, io.Table))...))
+		nextID++
+	}
+	rows = append(rows, []interface{}{
+		nextID, int64(0), "Execution", fmt.Sprintf("time=%s", elapsed.Round(time.Microsecond)), "", actualRows,
+		int64(stats.PagesHit), int64(stats.PagesRead), totalRows, totalBytes, totalEntries,
+		actualRows, int64(1), elapsed.Round(time.Microsecond).String(),
+	})
+	return columns, rows
+}
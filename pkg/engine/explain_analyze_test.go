@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// analyzeRows runs EXPLAIN ANALYZE and returns each row's integer columns,
+// keyed by table for scan nodes and by operation otherwise.
+func analyzeRows(t *testing.T, db *DB, sql string) map[string]map[string]int64 {
+	t.Helper()
+	rows, err := db.Query(context.Background(), "EXPLAIN ANALYZE "+sql)
+	if err != nil {
+		t.Fatalf("EXPLAIN ANALYZE %s: %v", sql, err)
+	}
+	defer rows.Close()
+	cols := rows.Columns()
+	out := make(map[string]map[string]int64)
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(vals))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		node := make(map[string]int64)
+		for i, col := range cols {
+			if v, ok := vals[i].(int64); ok {
+				node[col] = v
+			}
+		}
+		key := fmt.Sprint(vals[2])
+		if strings.HasSuffix(key, "Scan") || key == "Table Access" {
+			key = strings.Fields(fmt.Sprint(vals[3]))[0]
+		}
+		out[key] = node
+	}
+	return out
+}
+
+func TestExplainAnalyzeIO(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE items (id INTEGER PRIMARY KEY, code INTEGER, name TEXT)")
+	mustExec(t, db, "CREATE TABLE tags (id INTEGER PRIMARY KEY, code INTEGER)")
+	mustExec(t, db, "CREATE INDEX idx_items_code ON items (code)")
+	var values []string
+	for i := 1; i <= 1000; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, 'item %d')", i, i%100, i))
+	}
+	mustExec(t, db, "INSERT INTO items VALUES "+strings.Join(values, ", "))
+	mustExec(t, db, "INSERT INTO tags VALUES (1, 7), (2, 8)")
+
+	// Without an index every row is fetched and decoded to find one.
+	seq := analyzeRows(t, db, "SELECT id FROM items WHERE name = 'item 7'")
+	cold := seq["items"]
+	if cold["rows_scanned"] != 1000 || cold["bytes_decoded"] == 0 || cold["index_entries"] != 0 {
+		t.Fatalf("seq scan = %v, want 1000 rows and no index entries", cold)
+	}
+	if _, ok := cold["pages_hit"]; !ok {
+		t.Fatalf("seq scan = %v, want a pages_hit column", cold)
+	}
+	if exec := seq["Execution"]; exec["rows"] != 1 || exec["rows_scanned"] != 1000 {
+		t.Fatalf("execution = %v, want 1 row of 1000 scanned", exec)
+	}
+
+	// The index visits only the matching entries and decodes their rows.
+	indexed := analyzeRows(t, db, "SELECT id FROM items WHERE code = 7")
+	scan := indexed["items"]
+	if scan["index_entries"] != 10 || scan["rows_scanned"] != 10 || scan["bytes_decoded"]*20 > cold["bytes_decoded"] {
+		t.Fatalf("index scan = %v, want 10 entries and rows", scan)
+	}
+	if exec := indexed["Execution"]; exec["rows"] != 10 || exec["rows_scanned"] != 10 {
+		t.Fatalf("execution = %v, want 10 rows", exec)
+	}
+
+	// Each side of a join reports its own I/O.
+	joined := analyzeRows(t, db, "SELECT items.id FROM items JOIN tags ON items.code = tags.code")
+	if got := joined["items"]["rows_scanned"]; got != 1000 {
+		t.Errorf("join items rows_scanned = %d, want 1000", got)
+	}
+	if got := joined["tags"]["rows_scanned"]; got != 2 {
+		t.Errorf("join tags rows_scanned = %d, want 2", got)
+	}
+	if got := joined["Execution"]["rows"]; got != 20 {
+		t.Errorf("join rows = %d, want 20", got)
+	}
+
+	if _, err := db.Query(context.Background(), "EXPLAIN ANALYZE DELETE FROM items"); err == nil {
+		t.Error("EXPLAIN ANALYZE DELETE should be rejected")
+	}
+}
+
+func TestExplainAnalyzeActuals(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE orders (id INTEGER PRIMARY KEY, customer INTEGER, total INTEGER)")
+	mustExec(t, db, "CREATE TABLE customers (id INTEGER PRIMARY KEY, region TEXT)")
+	var values []string
+	for i := 1; i <= 100; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", i, i%10, i))
+	}
+	mustExec(t, db, "INSERT INTO orders VALUES "+strings.Join(values, ", "))
+	mustExec(t, db, "INSERT INTO customers VALUES (1, 'eu'), (2, 'us'), (3, 'eu')")
+
+	plan := analyzeRows(t, db, "SELECT orders.id FROM orders JOIN customers ON orders.customer = customers.id "+
+		"WHERE customers.region = 'eu' ORDER BY orders.total DESC LIMIT 5")
+	for key, want := range map[string]int64{
+		"orders":     100,
+		"customers":  3,
+		"Inner Join": 30,
+		"Filter":     20,
+		"Sort":       20,
+		"Limit":      5,
+		"Execution":  5,
+	} {
+		node := plan[key]
+		if node["actual_rows"] != want || node["loops"] != 1 {
+			t.Errorf("%s = %v, want %d actual rows in 1 loop", key, node, want)
+		}
+	}
+
+	grouped := analyzeRows(t, db, "SELECT customer, COUNT(*) FROM orders WHERE total > 50 GROUP BY customer")
+	if got := grouped["orders"]["actual_rows"]; got != 100 {
+		t.Errorf("grouped scan actual_rows = %d, want 100", got)
+	}
+	if got := grouped["Aggregate"]["actual_rows"]; got != 10 {
+		t.Errorf("aggregate actual_rows = %d, want 10", got)
+	}
+}
@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// ExportFormat selects how QueryToWriter encodes rows.
+type ExportFormat int
+
+const (
+	FormatCSV       ExportFormat = iota // CSV with a header row; NULL is an empty field
+	FormatJSONLines                     // One JSON object per line, keyed by column name
+)
+
+type rowSinkKey struct{}
+
+// withRowSink returns a context whose statement streams its SELECT rows to
+// sink.
+func withRowSink(ctx context.Context, sink catalog.RowSink) context.Context {
+	return context.WithValue(ctx, rowSinkKey{}, sink)
+}
+
+func rowSinkFrom(ctx context.Context) catalog.RowSink {
+	sink, _ := ctx.Value(rowSinkKey{}).(catalog.RowSink)
+	return sink
+}
+
+// QueryToWriter runs a query and writes its result to w in format, for ETL
+// jobs that read whole tables out of the database. A plain single-table
+// SELECT (no joins, grouping, ORDER BY, DISTINCT or LIMIT) is written as the
+// table is scanned, so memory use does not grow with the table; other
+// queries are run as by Query and then written out. Result size limits do
+// not apply. It returns the number of rows written; on error, w may hold a
+// partial result.
+func (db *DB) QueryToWriter(ctx context.Context, sql string, w io.Writer, format ExportFormat, args ...interface{}) (written int64, err error) {
+	enc, err := newRowEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			err = fmt.Errorf("internal error in QueryToWriter: %v", r)
+			db.recordRecoveredPanic("QueryToWriter", r, stack)
+		}
+	}()
+
+	runCtx, stmt, start, release, execErr := db.runStatement(ctx, "QueryToWriter", sql, args...)
+	if execErr != nil {
+		if errors.Is(execErr, ErrDatabaseClosed) {
+			return 0, execErr
+		}
+		if db.metrics != nil {
+			db.metrics.RecordError()
+		}
+		return 0, statementLimitError(runCtx, execErr)
+	}
+	defer release()
+
+	if db.metrics != nil {
+		defer func() {
+			duration := time.Since(start)
+			db.metrics.RecordQuery(duration, duration > 100*time.Millisecond)
+		}()
+	}
+	if db.slowQueryLog != nil {
+		defer func() {
+			db.slowQueryLog.Log(sql, time.Since(start), 0, 0)
+		}()
+	}
+
+	// Only a plain SELECT streams: the sides of a set operation are
+	// Selects of their own, and the first would take the sink.
+	stmtCtx := runCtx
+	if _, ok := stmt.(*query.SelectStmt); ok {
+		stmtCtx = withRowSink(runCtx, enc)
+	}
+	finish := db.beginStatement(stmtCtx)
+	rows, err := db.query(runCtx, stmt, args)
+	if err = finish(err); err == nil && !enc.started && rows != nil {
+		// Not streamed: write the collected result.
+		err = enc.Columns(rows.columns)
+		for i := 0; err == nil && i < len(rows.rows); i++ {
+			err = enc.Row(rows.rows[i])
+		}
+	}
+	if rows != nil {
+		rows.Close()
+	}
+	if err == nil {
+		err = enc.flush()
+	}
+	return enc.rows, err
+}
+
+// rowEncoder writes rows to an export as a catalog.RowSink.
+type rowEncoder struct {
+	format  ExportFormat
+	csv     *csv.Writer
+	buf     *bufio.Writer
+	record  []string // CSV: reused field buffer
+	keys    [][]byte // JSON Lines: encoded `"column":` prefixes
+	started bool     // Columns has been called
+	rows    int64
+}
+
+func newRowEncoder(w io.Writer, format ExportFormat) (*rowEncoder, error) {
+	enc := &rowEncoder{format: format}
+	switch format {
+	case FormatCSV:
+		enc.csv = csv.NewWriter(w)
+	case FormatJSONLines:
+		enc.buf = bufio.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown export format %d", format)
+	}
+	return enc, nil
+}
+
+func (e *rowEncoder) Columns(columns []string) error {
+	e.started = true
+	if e.format == FormatCSV {
+		e.record = make([]string, len(columns))
+		return e.csv.Write(columns)
+	}
+	e.keys = make([][]byte, len(columns))
+	for i, col := range columns {
+		key, err := json.Marshal(col)
+		if err != nil {
+			return err
+		}
+		e.keys[i] = append(key, ':')
+	}
+	return nil
+}
+
+func (e *rowEncoder) Row(row []interface{}) error {
+	e.rows++
+	if e.format == FormatCSV {
+		for i := range e.record {
+			e.record[i] = ""
+			if i < len(row) {
+				e.record[i] = exportText(row[i])
+			}
+		}
+		return e.csv.Write(e.record)
+	}
+	e.buf.WriteByte('{')
+	for i, key := range e.keys {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.buf.Write(key)
+		var v interface{}
+		if i < len(row) {
+			v = exportValue(row[i])
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("export column %s: %w", key[:len(key)-1], err)
+		}
+		e.buf.Write(value)
+	}
+	e.buf.WriteByte('}')
+	return e.buf.WriteByte('\n')
+}
+
+func (e *rowEncoder) flush() error {
+	if e.format == FormatCSV {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return e.buf.Flush()
+}
+
+// exportValue unboxes the catalog's internal string representations.
+func exportValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case catalog.StringBox:
+		return val.String()
+	case *string:
+		if val == nil {
+			return nil
+		}
+		return *val
+	}
+	return v
+}
+
+// exportText formats a value as a CSV field.
+func exportText(v interface{}) string {
+	switch val := exportValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(val)
+	}
+}
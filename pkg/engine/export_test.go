@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n += len(p); w.n > 1024 {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestQueryToWriter(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, price REAL)")
+	var values []string
+	for i := 1; i <= 2000; i++ {
+		values = append(values, fmt.Sprintf("(%d, 'item, \"%d\"', %d.5)", i, i, i))
+	}
+	mustExec(t, db, "INSERT INTO items VALUES "+strings.Join(values, ", "))
+	mustExec(t, db, "UPDATE items SET name = NULL WHERE id = 7")
+
+	var buf bytes.Buffer
+	n, err := db.QueryToWriter(ctx, "SELECT id, name, price FROM items WHERE id > ?", &buf, FormatCSV, 5)
+	if err != nil || n != 1995 {
+		t.Fatalf("csv export = %d, %v", n, err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 1996 || strings.Join(records[0], ",") != "id,name,price" {
+		t.Fatalf("csv header/rows = %v, %d", records[0], len(records))
+	}
+	if got := strings.Join(records[1], "|"); got != "6|item, \"6\"|6.5" {
+		t.Errorf("first row = %s", got)
+	}
+	if got := strings.Join(records[2], "|"); got != "7||7.5" {
+		t.Errorf("NULL row = %s", got)
+	}
+
+	// Sorted queries are collected first and written the same way.
+	buf.Reset()
+	n, err = db.QueryToWriter(ctx, "SELECT id, name FROM items WHERE id IN (7, 2) ORDER BY id DESC", &buf, FormatJSONLines)
+	if err != nil || n != 2 {
+		t.Fatalf("json export = %d, %v", n, err)
+	}
+	if want := "{\"id\":7,\"name\":null}\n{\"id\":2,\"name\":\"item, \\\"2\\\"\"}\n"; buf.String() != want {
+		t.Errorf("json lines = %q, want %q", buf.String(), want)
+	}
+
+	// Both sides of a set operation are written.
+	buf.Reset()
+	n, err = db.QueryToWriter(ctx, "SELECT id FROM items WHERE id < 3 UNION ALL SELECT id FROM items WHERE id > 1999", &buf, FormatCSV)
+	if err != nil || n != 3 || buf.String() != "id\n1\n2\n2000\n" {
+		t.Fatalf("union export = %d, %v, %q", n, err, buf.String())
+	}
+
+	if _, err := db.QueryToWriter(ctx, "SELECT * FROM items", &failingWriter{}, FormatCSV); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("writer error = %v", err)
+	}
+	if _, err := db.QueryToWriter(ctx, "SELECT * FROM items", &buf, ExportFormat(9)); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	// The streamed query was not cached empty.
+	if got := queryStrings(t, db, "SELECT COUNT(*) FROM items WHERE id > 1990"); got[0] != "10" {
+		t.Errorf("count = %v", got)
+	}
+}
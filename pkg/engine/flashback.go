@@ -0,0 +1,494 @@
+package engine
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// ErrFlashbackTooOld is returned for an AS OF query whose time is before
+// the changes the flashback log still holds.
+var ErrFlashbackTooOld = errors.New("AS OF time is outside the flashback retention window")
+
+// Flashback log records are a kind byte, the payload's length and CRC-32 as
+// little-endian uint32s, then the payload, sealed with the database's cipher
+// when it is encrypted.
+const (
+	flashbackRecStart  = 's' // payload: the time rows can be rebuilt from, in Unix nanoseconds
+	flashbackRecCommit = 'c' // payload: commit time, row count, then table, key and old row per row
+	flashbackRecClose  = 'e' // payload: the WAL's LSN when the database closed
+
+	flashbackHeaderSize = 9
+
+	// flashbackCompactSize is how many bytes of pruned commits the log file
+	// may hold before it is rewritten without them.
+	flashbackCompactSize = 1 << 20
+)
+
+// errFlashbackCorrupt reports a commit record that does not decode.
+var errFlashbackCorrupt = errors.New("corrupt flashback log record")
+
+// With Maintenance.FlashbackRetention set, the flashback log keeps the row
+// changes of every transaction committed in the last FlashbackRetention,
+// each with the values the rows held before it. An AS OF query rebuilds a
+// table as it was at a time in that window by giving each row written since
+// the value it had before the first of those writes; rows not written since
+// read as they are now. It undoes updates and deletes, so an accidental
+// DELETE can be recovered with INSERT ... SELECT ... AS OF. Schema changes
+// are not undone: rebuilt rows have the table's current columns.
+//
+// The changes are appended to the file <path>.flashback as transactions
+// commit; memory holds only each commit's time, tables and place in the
+// file. Close ends the file with the WAL's LSN, and Open keeps the history
+// only while that is still the WAL's LSN, so no commit can be missing from
+// it. After a crash, or once the database was written with flashback off,
+// the history starts again at Open. Databases without a WAL keep the log in
+// a temporary file removed on Close. An encrypted database's log is
+// encrypted with its key.
+type flashbackLog struct {
+	retention time.Duration
+	path      string
+	temporary bool
+	aead      cipher.AEAD // nil unless the database is encrypted
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64             // bytes of records in file
+	head    int64             // bytes of the start record
+	since   time.Time         // the earliest time rows can be rebuilt for
+	commits []flashbackCommit // in commit order
+	broken  bool              // a commit failed to be written; see appendCommitLocked
+	buf     []byte            // record being encoded or read
+}
+
+// flashbackCommit locates the record of one commit in the log file.
+type flashbackCommit struct {
+	at     time.Time
+	off    int64 // offset of the record
+	size   int   // length of the record, header included
+	tables []string
+}
+
+// startFlashback opens the flashback log, starts appending committed
+// changes to it and makes the catalog rebuild AS OF reads from it.
+func (db *DB) startFlashback() error {
+	l := &flashbackLog{retention: db.options.Maintenance.FlashbackRetention}
+	if encrypted, ok := db.backend.(*storage.EncryptedBackend); ok {
+		l.aead = encrypted.GetCipher()
+	}
+	var err error
+	if db.wal != nil && !db.readOnly {
+		err = l.open(db.path+".flashback", db.wal.LSN())
+	} else {
+		err = l.openTemp(db.options.ResultLimits.SpillDir)
+	}
+	if err != nil {
+		return fmt.Errorf("open flashback log: %w", err)
+	}
+	db.flashback = l
+	db.changeMu.Lock()
+	db.changeHooks.add(l.record)
+	db.catalog.SetChangeCapture(db.publishChanges)
+	db.changeMu.Unlock()
+	db.catalog.SetFlashbackSource(l.rowsAt)
+	return nil
+}
+
+// open opens the log file at path, keeping its history when it was closed
+// at WAL LSN lsn.
+func (l *flashbackLog) open(path string, lsn uint64) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) // #nosec G304 - path is derived from the database path.
+	if err != nil {
+		return err
+	}
+	l.path, l.file = path, f
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kept, err := l.loadLocked(lsn)
+	if err == nil && !kept {
+		err = l.resetLocked(time.Now())
+	}
+	if err != nil {
+		return errors.Join(err, f.Close())
+	}
+	l.pruneLocked(time.Now())
+	return nil
+}
+
+// openTemp starts a log in a new temporary file in dir.
+func (l *flashbackLog) openTemp(dir string) error {
+	f, err := os.CreateTemp(dir, "cobaltdb-flashback-*")
+	if err != nil {
+		return err
+	}
+	l.path, l.file, l.temporary = f.Name(), f, true
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.resetLocked(time.Now()); err != nil {
+		return errors.Join(err, f.Close(), os.Remove(l.path))
+	}
+	return nil
+}
+
+// loadLocked reads the log file into l. It reports false, leaving the file
+// to be reset, unless the file ends with a close record for lsn.
+func (l *flashbackLog) loadLocked(lsn uint64) (bool, error) {
+	stat, err := l.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	r := bufio.NewReader(io.NewSectionReader(l.file, 0, stat.Size()))
+	names := make(map[string]string)
+	var header [flashbackHeaderSize]byte
+	var off int64
+	closeOff := int64(-1)
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			break // the end of the file, or a record torn by a crash
+		}
+		n := int64(binary.LittleEndian.Uint32(header[1:]))
+		if n > stat.Size()-off-flashbackHeaderSize {
+			break
+		}
+		l.buf = slices.Grow(l.buf[:0], int(n))[:n]
+		if _, err := io.ReadFull(r, l.buf); err != nil ||
+			crc32.ChecksumIEEE(l.buf) != binary.LittleEndian.Uint32(header[5:]) {
+			break
+		}
+		if closeOff >= 0 {
+			return false, nil // written after the close record
+		}
+		payload, err := l.openRecord(header[0], l.buf)
+		if err != nil {
+			return false, nil // written under another key
+		}
+		switch {
+		case header[0] == flashbackRecStart && off == 0 && len(payload) == 8:
+			l.since = time.Unix(0, int64(binary.LittleEndian.Uint64(payload)))
+			l.head = flashbackHeaderSize + n
+		case header[0] == flashbackRecCommit && off > 0:
+			c := flashbackCommit{off: off, size: int(flashbackHeaderSize + n)}
+			at, err := eachFlashbackRow(payload, func(table, _, _ []byte) {
+				if !slices.Contains(c.tables, string(table)) {
+					name, ok := names[string(table)]
+					if !ok {
+						name = string(table)
+						names[name] = name
+					}
+					c.tables = append(c.tables, name)
+				}
+			})
+			if err != nil {
+				return false, nil
+			}
+			c.at = at
+			l.commits = append(l.commits, c)
+		case header[0] == flashbackRecClose && off > 0 && len(payload) == 8:
+			if binary.LittleEndian.Uint64(payload) != lsn {
+				return false, nil
+			}
+			closeOff = off
+		default:
+			return false, nil
+		}
+		off += flashbackHeaderSize + n
+	}
+	if closeOff < 0 {
+		return false, nil
+	}
+	// Drop the close record, so a crash from now on does not look like a
+	// clean close.
+	l.size = closeOff
+	return true, l.file.Truncate(closeOff)
+}
+
+// resetLocked empties the log, which then rebuilds rows from since on.
+func (l *flashbackLog) resetLocked(since time.Time) error {
+	l.commits = nil
+	l.since = since
+	l.size = 0
+	if err := l.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := l.appendLocked(flashbackRecStart, binary.LittleEndian.AppendUint64(l.buf[:0], uint64(since.UnixNano()))); err != nil {
+		return err
+	}
+	l.head = l.size
+	return nil
+}
+
+// appendLocked writes a record of kind with payload at the end of the file.
+// payload may alias l.buf.
+func (l *flashbackLog) appendLocked(kind byte, payload []byte) error {
+	if l.aead != nil {
+		nonce := make([]byte, l.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		payload = l.aead.Seal(nonce, nonce, payload, []byte{kind})
+	}
+	var header [flashbackHeaderSize]byte
+	header[0] = kind
+	binary.LittleEndian.PutUint32(header[1:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[5:], crc32.ChecksumIEEE(payload))
+	if _, err := l.file.WriteAt(header[:], l.size); err != nil {
+		return err
+	}
+	if _, err := l.file.WriteAt(payload, l.size+flashbackHeaderSize); err != nil {
+		return err
+	}
+	l.size += flashbackHeaderSize + int64(len(payload))
+	return nil
+}
+
+// openRecord returns the payload of a record of kind as written, which may
+// alias it.
+func (l *flashbackLog) openRecord(kind byte, written []byte) ([]byte, error) {
+	if l.aead == nil {
+		return written, nil
+	}
+	if len(written) < l.aead.NonceSize() {
+		return nil, errFlashbackCorrupt
+	}
+	nonce, sealed := written[:l.aead.NonceSize()], written[l.aead.NonceSize():]
+	return l.aead.Open(nil, nonce, sealed, []byte{kind})
+}
+
+// record is the change hook of the flashback log. It runs under the
+// catalog's commit locks, so it only appends and prunes.
+func (l *flashbackLog) record(c committedChanges) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.appendCommitLocked(now, c.rows)
+}
+
+// appendCommitLocked appends the changes of a commit at time at.
+func (l *flashbackLog) appendCommitLocked(at time.Time, rows []catalog.RowChange) {
+	if l.file == nil {
+		return
+	}
+	c := flashbackCommit{at: at, off: l.size}
+	payload, err := encodeFlashbackCommit(l.buf[:0], at, rows, &c.tables)
+	if err == nil {
+		err = l.appendLocked(flashbackRecCommit, payload)
+		l.buf = payload[:0]
+	}
+	if err != nil {
+		// Times before this commit can no longer be rebuilt. The file may
+		// hold them still, so Close leaves it to be reset by Open.
+		l.broken = true
+		l.commits = nil
+		l.since = at
+		return
+	}
+	c.size = int(l.size - c.off)
+	l.commits = append(l.commits, c)
+	l.pruneLocked(at)
+}
+
+// pruneLocked drops the commits older than the retention window. Rows can
+// then only be rebuilt for times after the last commit dropped. Once the
+// dropped commits outweigh the rest of the file, it is rewritten without
+// them.
+func (l *flashbackLog) pruneLocked(now time.Time) {
+	cutoff := now.Add(-l.retention)
+	n := 0
+	for n < len(l.commits) && l.commits[n].at.Before(cutoff) {
+		n++
+	}
+	if n > 0 {
+		l.since = l.commits[n-1].at
+		clear(l.commits[:n])
+		l.commits = l.commits[n:]
+	}
+	first := l.size
+	if len(l.commits) > 0 {
+		first = l.commits[0].off
+	}
+	if dead := first - l.head; dead >= flashbackCompactSize && dead >= l.size-first && !l.broken {
+		// On failure the old file stays in use and is retried next time.
+		_ = l.compactLocked(first)
+	}
+}
+
+// compactLocked replaces the log file with one holding the records from
+// offset first on.
+func (l *flashbackLog) compactLocked(first int64) error {
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600) // #nosec G304 - path is derived from the log path.
+	if err != nil {
+		return err
+	}
+	old, oldSize, oldHead := l.file, l.size, l.head
+	l.file, l.size = tmp, 0
+	err = l.appendLocked(flashbackRecStart, binary.LittleEndian.AppendUint64(l.buf[:0], uint64(l.since.UnixNano())))
+	l.head = l.size
+	if err == nil {
+		var copied int64
+		copied, err = io.Copy(io.NewOffsetWriter(tmp, l.size), io.NewSectionReader(old, first, oldSize-first))
+		l.size += copied
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, l.path)
+	}
+	if err != nil {
+		l.file, l.size, l.head = old, oldSize, oldHead
+		return errors.Join(err, tmp.Close(), os.Remove(tmpPath))
+	}
+	shift := first - l.head
+	for i := range l.commits {
+		l.commits[i].off -= shift
+	}
+	return old.Close()
+}
+
+// rowsAt is the catalog's flashback source: the value at time at of every
+// row of table written since. Only the records of commits since at that
+// wrote table are read back from the file.
+func (l *flashbackLog) rowsAt(table string, at time.Time) (map[string][]interface{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil, ErrDatabaseClosed
+	}
+	l.pruneLocked(time.Now())
+	if at.Before(l.since) {
+		return nil, fmt.Errorf("%w: %s is before %s", ErrFlashbackTooOld,
+			at.UTC().Format(time.RFC3339), l.since.UTC().Format(time.RFC3339))
+	}
+	past := make(map[string][]interface{})
+	first := sort.Search(len(l.commits), func(i int) bool { return l.commits[i].at.After(at) })
+	// Oldest first, so a row's earliest change since at sets its value.
+	for _, c := range l.commits[first:] {
+		if !slices.Contains(c.tables, table) {
+			continue
+		}
+		l.buf = slices.Grow(l.buf[:0], c.size)[:c.size]
+		if _, err := l.file.ReadAt(l.buf, c.off); err != nil {
+			return nil, fmt.Errorf("read flashback log: %w", err)
+		}
+		payload, err := l.openRecord(l.buf[0], l.buf[flashbackHeaderSize:])
+		if err != nil {
+			return nil, fmt.Errorf("read flashback log: %w", err)
+		}
+		var decodeErr error
+		_, err = eachFlashbackRow(payload, func(rowTable, key, old []byte) {
+			if decodeErr != nil || string(rowTable) != table {
+				return
+			}
+			if _, seen := past[string(key)]; seen {
+				return
+			}
+			var row []interface{}
+			if len(old) > 0 {
+				row, decodeErr = catalog.DecodeRowValues(old)
+			}
+			past[string(key)] = row
+		})
+		if err = errors.Join(err, decodeErr); err != nil {
+			return nil, fmt.Errorf("read flashback log: %w", err)
+		}
+	}
+	return past, nil
+}
+
+// close ends the log. A log file is kept for the next Open, ending with a
+// close record for lsn; a temporary one is removed.
+func (l *flashbackLog) close(lsn uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	var err error
+	if l.temporary {
+		err = errors.Join(l.file.Close(), os.Remove(l.path))
+	} else {
+		if !l.broken {
+			if err = l.appendLocked(flashbackRecClose, binary.LittleEndian.AppendUint64(l.buf[:0], lsn)); err == nil {
+				err = l.file.Sync()
+			}
+		}
+		err = errors.Join(err, l.file.Close())
+	}
+	l.file = nil
+	l.commits = nil
+	return err
+}
+
+// encodeFlashbackCommit appends the payload of a commit record for rows to
+// dst, adding the tables they belong to to tables.
+func encodeFlashbackCommit(dst []byte, at time.Time, rows []catalog.RowChange, tables *[]string) ([]byte, error) {
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(at.UnixNano()))
+	dst = binary.AppendUvarint(dst, uint64(len(rows)))
+	for _, row := range rows {
+		if !slices.Contains(*tables, row.Table) {
+			*tables = append(*tables, row.Table)
+		}
+		var old []byte
+		if row.Old != nil {
+			var err error
+			if old, err = catalog.EncodeRowValues(row.Old); err != nil {
+				return nil, err
+			}
+		}
+		dst = appendFlashbackBytes(dst, []byte(row.Table))
+		dst = appendFlashbackBytes(dst, []byte(row.Key))
+		dst = appendFlashbackBytes(dst, old)
+	}
+	return dst, nil
+}
+
+func appendFlashbackBytes(dst, b []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(b)))
+	return append(dst, b...)
+}
+
+// eachFlashbackRow calls fn with the table, key and encoded old row of each
+// row of a commit record's payload, and returns the commit's time. old is
+// empty for an inserted row. The slices alias payload.
+func eachFlashbackRow(payload []byte, fn func(table, key, old []byte)) (time.Time, error) {
+	if len(payload) < 8 {
+		return time.Time{}, errFlashbackCorrupt
+	}
+	at := time.Unix(0, int64(binary.LittleEndian.Uint64(payload)))
+	payload = payload[8:]
+	next := func() ([]byte, bool) {
+		n, k := binary.Uvarint(payload)
+		if k <= 0 || n > uint64(len(payload)-k) {
+			return nil, false
+		}
+		b := payload[k : k+int(n)]
+		payload = payload[k+int(n):]
+		return b, true
+	}
+	count, k := binary.Uvarint(payload)
+	if k <= 0 {
+		return time.Time{}, errFlashbackCorrupt
+	}
+	payload = payload[k:]
+	for ; count > 0; count-- {
+		table, ok1 := next()
+		key, ok2 := next()
+		old, ok3 := next()
+		if !ok1 || !ok2 || !ok3 {
+			return time.Time{}, errFlashbackCorrupt
+		}
+		fn(table, key, old)
+	}
+	return at, nil
+}
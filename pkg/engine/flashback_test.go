@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+func TestFlashbackQueryRecoversDeletedRows(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "flashback.db"), &Options{
+		Maintenance: MaintenanceConfig{FlashbackRetention: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "INSERT INTO items VALUES (1, 'a'), (2, 'b'), (3, 'c')")
+	// AS OF times have second granularity.
+	time.Sleep(1100 * time.Millisecond)
+	mark := time.Now().UTC().Format("2006-01-02 15:04:05")
+
+	mustExec(t, db, "DELETE FROM items WHERE id = 2")
+	mustExec(t, db, "UPDATE items SET name = 'z' WHERE id = 1")
+	mustExec(t, db, "UPDATE items SET name = 'zz' WHERE id = 1")
+	mustExec(t, db, "INSERT INTO items VALUES (4, 'd')")
+
+	names := func(sql string) string {
+		t.Helper()
+		rows, err := db.Query(ctx, sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		defer rows.Close()
+		var got string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			got += name
+		}
+		return got
+	}
+	if got := names("SELECT name FROM items AS OF '" + mark + "' ORDER BY id"); got != "abc" {
+		t.Fatalf("AS OF rows = %q, want abc", got)
+	}
+	if got := names("SELECT name FROM items ORDER BY id"); got != "zzcd" {
+		t.Fatalf("current rows = %q, want zzcd", got)
+	}
+
+	mustExec(t, db, "INSERT INTO items SELECT id, name FROM items AS OF '"+mark+"' WHERE id = 2")
+	if got := names("SELECT name FROM items ORDER BY id"); got != "zzbcd" {
+		t.Fatalf("rows after recovery = %q, want zzbcd", got)
+	}
+
+	_, err = db.Query(ctx, "SELECT name FROM items AS OF '1 hour ago'")
+	if !errors.Is(err, ErrFlashbackTooOld) {
+		t.Fatalf("AS OF before Open: err = %v, want ErrFlashbackTooOld", err)
+	}
+}
+
+func TestFlashbackQuerySurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flashback.db")
+	// The database is encrypted, so the log must be too.
+	key := Security{EncryptionKey: []byte("0123456789abcdef0123456789abcdef")}
+	opts := &Options{Security: key, Maintenance: MaintenanceConfig{FlashbackRetention: time.Hour}}
+	db, err := Open(path, opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, data BLOB)")
+	mustExec(t, db, "INSERT INTO items VALUES (1, 'a', X'00ff'), (2, 'secret', NULL)")
+	time.Sleep(1100 * time.Millisecond)
+	mark := time.Now().UTC().Format("2006-01-02 15:04:05")
+	mustExec(t, db, "DELETE FROM items WHERE id = 1")
+	mustExec(t, db, "UPDATE items SET name = 'z' WHERE id = 2")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if data, err := os.ReadFile(path + ".flashback"); err != nil || bytes.Contains(data, []byte("secret")) {
+		t.Fatalf("flashback log holds plaintext rows (err %v)", err)
+	}
+
+	db, err = Open(path, opts)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	asOf := "SELECT id, name, HEX(data) FROM items AS OF '" + mark + "' ORDER BY id"
+	if got := strings.Join(queryStrings(t, db, asOf), ","); got != "1|a|00FF,2|secret|NULL" {
+		t.Fatalf("AS OF rows after reopen = %q", got)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Writes made with flashback off are missing from the log, so the
+	// history starts again.
+	db, err = Open(path, &Options{Security: key})
+	if err != nil {
+		t.Fatalf("reopen without flashback: %v", err)
+	}
+	mustExec(t, db, "DELETE FROM items")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	db, err = Open(path, opts)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Query(context.Background(), asOf); !errors.Is(err, ErrFlashbackTooOld) {
+		t.Fatalf("AS OF across unlogged writes: err = %v, want ErrFlashbackTooOld", err)
+	}
+}
+
+func TestFlashbackLogPrunesOldCommits(t *testing.T) {
+	now := time.Now()
+	l := &flashbackLog{retention: time.Minute}
+	if err := l.openTemp(t.TempDir()); err != nil {
+		t.Fatalf("openTemp: %v", err)
+	}
+	defer l.close(0)
+	l.since = now.Add(-time.Hour)
+	l.appendCommitLocked(now.Add(-10*time.Minute), []catalog.RowChange{{Table: "t", Key: "k", Old: []interface{}{int64(1)}}})
+	l.appendCommitLocked(now.Add(-time.Second), []catalog.RowChange{
+		{Table: "u", Key: "k", Old: []interface{}{int64(3)}},
+		{Table: "t", Key: "k", Old: []interface{}{int64(2)}},
+		{Table: "t", Key: "new"},
+	})
+
+	past, err := l.rowsAt("t", now.Add(-30*time.Second))
+	if err != nil {
+		t.Fatalf("rowsAt: %v", err)
+	}
+	if len(l.commits) != 1 || len(past) != 2 || past["k"][0] != int64(2) || past["new"] != nil {
+		t.Fatalf("commits = %d, past = %v; want 1 commit, k = 2 and new absent", len(l.commits), past)
+	}
+	if _, err := l.rowsAt("t", now.Add(-20*time.Minute)); !errors.Is(err, ErrFlashbackTooOld) {
+		t.Fatalf("rowsAt before the pruned commit: err = %v, want ErrFlashbackTooOld", err)
+	}
+}
+
+func TestFlashbackLogCompacts(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	l := &flashbackLog{retention: time.Minute}
+	if err := l.openTemp(t.TempDir()); err != nil {
+		t.Fatalf("openTemp: %v", err)
+	}
+	defer l.close(0)
+	big := []interface{}{strings.Repeat("x", 64<<10)}
+	for i := 0; i < 40; i++ {
+		l.appendCommitLocked(start.Add(time.Duration(i)*time.Second), []catalog.RowChange{{Table: "t", Key: fmt.Sprint(i), Old: big}})
+	}
+	// Every commit above is out of the window once this one is appended.
+	l.appendCommitLocked(time.Now(), []catalog.RowChange{{Table: "t", Key: "last", Old: []interface{}{"v"}}})
+	if len(l.commits) != 1 || l.size > 1<<10 {
+		t.Fatalf("after pruning: %d commits, %d bytes; want 1 commit in a compacted file", len(l.commits), l.size)
+	}
+	past, err := l.rowsAt("t", time.Now().Add(-time.Second))
+	if err != nil || past["last"][0] != "v" {
+		t.Fatalf("rowsAt after compaction = %v, %v", past, err)
+	}
+}
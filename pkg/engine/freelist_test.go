@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fillFreeListTable(t *testing.T, db *DB, table string, rows int) {
+	t.Helper()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE "+table+" (id INTEGER PRIMARY KEY, body TEXT)")
+	body := strings.Repeat("x", 200)
+	for i := 0; i < rows; i++ {
+		if _, err := db.Exec(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%d, '%s')", table, i, body)); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+}
+
+func pageUsage(t *testing.T, db *DB) (total, free uint32) {
+	t.Helper()
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Pages.UsedPages+stats.Pages.FreePages != stats.Pages.TotalPages {
+		t.Fatalf("page usage does not add up: %+v", stats.Pages)
+	}
+	return stats.Pages.TotalPages, stats.Pages.FreePages
+}
+
+func TestDropTableFreesPagesForReuse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "freelist.db")
+	db, err := Open(path, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	fillFreeListTable(t, db, "big", 300)
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	mustExec(t, db, "DROP TABLE big")
+	totalAfterDrop, freed := pageUsage(t, db)
+	if freed < 10 {
+		t.Fatalf("DROP TABLE freed %d pages, want the table's pages", freed)
+	}
+
+	fillFreeListTable(t, db, "again", 100)
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	total, free := pageUsage(t, db)
+	if total != totalAfterDrop {
+		t.Fatalf("file grew from %d to %d pages despite %d free pages", totalAfterDrop, total, freed)
+	}
+	if free >= freed {
+		t.Fatalf("free pages = %d, want fewer than %d after reuse", free, freed)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := Open(path, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if _, reloaded := pageUsage(t, reopened); reloaded < free {
+		t.Fatalf("free pages after reopen = %d, want at least %d", reloaded, free)
+	}
+	assertScalar(t, reopened, "SELECT COUNT(*) FROM again", int64(100))
+
+	// Reused pages must not corrupt the surviving table.
+	fillFreeListTable(t, reopened, "third", 100)
+	assertScalar(t, reopened, "SELECT COUNT(*) FROM again", int64(100))
+	assertScalar(t, reopened, "SELECT COUNT(*) FROM third", int64(100))
+}
+
+func TestRolledBackDropKeepsPages(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "rollback.db"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	fillFreeListTable(t, db, "kept", 100)
+	_, before := pageUsage(t, db)
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "DROP TABLE kept")
+	mustExec(t, db, "ROLLBACK")
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if _, after := pageUsage(t, db); after != before {
+		t.Fatalf("free pages = %d after rolled-back DROP, want %d", after, before)
+	}
+	fillFreeListTable(t, db, "other", 100)
+	assertScalar(t, db, "SELECT COUNT(*) FROM kept", int64(100))
+}
+
+func TestTreePageStats(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "stats.db"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	fillFreeListTable(t, db, "docs", 300)
+	mustExec(t, db, "CREATE INDEX idx_docs_body ON docs (body)")
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	stats, err := db.TreePageStats()
+	if err != nil {
+		t.Fatalf("TreePageStats: %v", err)
+	}
+	var table, index bool
+	for _, s := range stats {
+		switch {
+		case s.Name == "docs" && !s.Index:
+			table = true
+			if s.Entries != 300 || s.Pages < 2 || s.Depth != 2 {
+				t.Errorf("docs: %+v", s)
+			}
+		case s.Name == "idx_docs_body" && s.Index:
+			index = true
+			// Every key starts with the same 200-byte body.
+			if s.Entries != 300 || s.StoredKeyBytes >= s.KeyBytes/10 {
+				t.Errorf("idx_docs_body: %+v", s)
+			}
+		}
+		if s.FillFactor <= 0 || s.FillFactor > 1 {
+			t.Errorf("%s fill factor = %v", s.Name, s.FillFactor)
+		}
+	}
+	if !table || !index {
+		t.Fatalf("missing table or index in %+v", stats)
+	}
+}
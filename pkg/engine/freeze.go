@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+var (
+	ErrAlreadyFrozen = errors.New("database is already frozen")
+	ErrNotFrozen     = errors.New("database is not frozen")
+)
+
+// writeGateKey marks a context whose statement already holds the write gate,
+// so statements nested inside it (procedure bodies, triggers) do not wait on a
+// freeze that is itself waiting for the outer statement.
+type writeGateKey struct{}
+
+// writeGate lets Freeze stop new writes and wait for in-flight ones to drain.
+type writeGate struct {
+	mu     sync.Mutex
+	active int           // writes currently executing
+	idle   chan struct{} // closed when active drops to zero during a freeze
+	thawed chan struct{} // non-nil while frozen; closed by Thaw
+}
+
+// enter blocks while the database is frozen and registers a write. The
+// returned context must be passed to nested statements and exit called when
+// the write finishes.
+func (g *writeGate) enter(ctx context.Context) (context.Context, func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Value(writeGateKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+	for {
+		g.mu.Lock()
+		if g.thawed == nil {
+			g.active++
+			g.mu.Unlock()
+			return context.WithValue(ctx, writeGateKey{}, true), g.exit, nil
+		}
+		thawed := g.thawed
+		g.mu.Unlock()
+
+		select {
+		case <-thawed:
+		case <-ctx.Done():
+			return ctx, func() {}, fmt.Errorf("waiting for frozen database: %w", ctx.Err())
+		}
+	}
+}
+
+func (g *writeGate) exit() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active--
+	if g.active == 0 && g.idle != nil {
+		close(g.idle)
+		g.idle = nil
+	}
+}
+
+// close stops new writes and waits until in-flight writes have finished.
+func (g *writeGate) close(ctx context.Context) error {
+	g.mu.Lock()
+	if g.thawed != nil {
+		g.mu.Unlock()
+		return ErrAlreadyFrozen
+	}
+	g.thawed = make(chan struct{})
+	if g.active == 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	g.idle = make(chan struct{})
+	idle := g.idle
+	g.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		g.open()
+		return ctx.Err()
+	}
+}
+
+// open releases writers blocked by close. It reports whether the gate was
+// closed.
+func (g *writeGate) open() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.thawed == nil {
+		return false
+	}
+	close(g.thawed)
+	g.thawed = nil
+	g.idle = nil
+	return true
+}
+
+func (g *writeGate) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.thawed != nil
+}
+
+// Freeze quiesces the database for an external filesystem or volume snapshot
+// (LVM, ZFS, EBS). New writes block, in-flight writes are allowed to finish,
+// and everything committed so far is checkpointed and fsynced to the database
+// file, so a snapshot taken before Thaw captures a consistent image that opens
+// without WAL replay. Reads continue while frozen; writes (and checkpoints)
+// wait until Thaw. If ctx ends before in-flight writes drain the freeze is
+// abandoned and ctx's error returned.
+//
+// Keep the frozen window short: writers queue for its whole duration, and a
+// writer whose context expires fails with a context error.
+func (db *DB) Freeze(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+	if err := db.writes.close(ctx); err != nil {
+		return err
+	}
+
+	db.backupMu.Lock()
+	if err := db.flushForFreeze(); err != nil {
+		db.backupMu.Unlock()
+		db.writes.open()
+		return fmt.Errorf("freeze: %w", err)
+	}
+	// backupMu stays held until Thaw so background checkpoints cannot rewrite
+	// the file under the snapshot.
+	return nil
+}
+
+// Thaw releases a Freeze and lets blocked writers proceed.
+func (db *DB) Thaw() error {
+	if !db.writes.open() {
+		return ErrNotFrozen
+	}
+	db.backupMu.Unlock()
+	return nil
+}
+
+// IsFrozen reports whether the database is between Freeze and Thaw.
+func (db *DB) IsFrozen() bool {
+	return db.writes.isClosed()
+}
+
+func (db *DB) flushForFreeze() error {
+	db.flushMu.Lock()
+	defer db.flushMu.Unlock()
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+
+	if err := db.catalog.FlushTableTrees(); err != nil {
+		return fmt.Errorf("failed to flush table trees: %w", err)
+	}
+	if !db.options.CoreStorage.InMemory && db.path != ":memory:" {
+		if err := db.catalog.Save(); err != nil {
+			return fmt.Errorf("failed to save catalog: %w", err)
+		}
+		if err := db.saveMetaPage(); err != nil {
+			return fmt.Errorf("failed to save meta page: %w", err)
+		}
+	}
+	if db.wal != nil {
+		if err := db.wal.Checkpoint(db.pool); err != nil {
+			return err
+		}
+	} else if err := db.pool.FlushDirty(); err != nil {
+		return err
+	}
+	return db.backend.Sync()
+}
+
+// isReadOnlyStatement reports whether stmt cannot modify the database, so it
+// may run while the database is frozen.
+func isReadOnlyStatement(stmt query.Statement) bool {
+	switch stmt.(type) {
+	case *query.SelectStmt, *query.UnionStmt, *query.SelectStmtWithCTE,
+		*query.ExplainStmt, *query.DescribeStmt, *query.ShowTablesStmt,
+		*query.ShowCreateTableStmt, *query.ShowColumnsStmt, *query.ShowDatabasesStmt,
+		*query.ShowConfigStmt, *query.SetVarStmt, *query.PragmaStmt:
+		return true
+	}
+	return false
+}
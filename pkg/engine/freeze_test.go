@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFreezeBlocksWritesAndFlushesForSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "frozen.db")
+	db, err := Open(dbPath, &Options{CoreStorage: CoreStorage{WALEnabled: BoolPtr(true)}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 'before')")
+
+	if err := db.Freeze(ctx); err != nil {
+		t.Fatalf("Freeze failed: %v", err)
+	}
+	if !db.IsFrozen() {
+		t.Fatal("IsFrozen = false after Freeze")
+	}
+	if err := db.Freeze(ctx); !errors.Is(err, ErrAlreadyFrozen) {
+		t.Fatalf("second Freeze = %v, want ErrAlreadyFrozen", err)
+	}
+
+	// The file alone (no WAL) must hold everything committed before Freeze.
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("read db file: %v", err)
+	}
+	snapPath := filepath.Join(dir, "snapshot.db")
+	if err := os.WriteFile(snapPath, data, 0600); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+
+	// Reads are not blocked.
+	var count int
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM t").Scan(&count); err != nil || count != 1 {
+		t.Fatalf("read while frozen = %d, %v", count, err)
+	}
+
+	// Writers time out on their own context...
+	shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	_, err = db.Exec(shortCtx, "INSERT INTO t VALUES (2, 'timeout')")
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("write while frozen = %v, want context deadline", err)
+	}
+
+	// ...and otherwise wait for Thaw.
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Exec(ctx, "INSERT INTO t VALUES (3, 'after')")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("write completed while frozen: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := db.Thaw(); err != nil {
+		t.Fatalf("Thaw failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("blocked write failed after Thaw: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked write did not resume after Thaw")
+	}
+	if err := db.Thaw(); !errors.Is(err, ErrNotFrozen) {
+		t.Fatalf("second Thaw = %v, want ErrNotFrozen", err)
+	}
+
+	snap, err := Open(snapPath, &Options{CoreStorage: CoreStorage{WALEnabled: BoolPtr(true)}})
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	defer snap.Close()
+	var v string
+	if err := snap.QueryRow(ctx, "SELECT v FROM t WHERE id = 1").Scan(&v); err != nil || v != "before" {
+		t.Fatalf("snapshot row = %q, %v", v, err)
+	}
+	if err := snap.QueryRow(ctx, "SELECT COUNT(*) FROM t").Scan(&count); err != nil || count != 1 {
+		t.Fatalf("snapshot has %d rows, %v; want 1", count, err)
+	}
+}
+
+func TestFreezeWaitsForOpenWrites(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	_, exit, err := db.writes.enter(context.Background())
+	if err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := db.Freeze(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Freeze with a write in flight = %v, want context deadline", err)
+	}
+	if db.IsFrozen() {
+		t.Fatal("abandoned Freeze left the database frozen")
+	}
+
+	exit()
+	if err := db.Freeze(context.Background()); err != nil {
+		t.Fatalf("Freeze after the write finished: %v", err)
+	}
+	if err := db.Thaw(); err != nil {
+		t.Fatalf("Thaw failed: %v", err)
+	}
+}
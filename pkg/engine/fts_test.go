@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// ftsDocs is a small full-text table the MATCH tests search.
+var ftsDocs = []string{
+	"CREATE VIRTUAL TABLE docs USING fts(title, body)",
+	`INSERT INTO docs VALUES
+		('Go databases', 'An embedded database written in Go'),
+		('Cooking', 'Slow cooked beans and rice'),
+		('Database internals', 'B+Tree pages, the write-ahead log and database recovery'),
+		('Gardening', 'Growing beans on a balcony')`,
+}
+
+func matchTitles(t *testing.T, db *DB, sql string, args ...interface{}) []string {
+	t.Helper()
+	rows, err := db.Query(context.Background(), sql, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	defer rows.Close()
+	var titles []string
+	for rows.Next() {
+		var title string
+		if err := rows.Scan(&title); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+func TestFTSVirtualTableMatch(t *testing.T) {
+	db := openTestDB(t, nil, ftsDocs...)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"database", []string{"Go databases", "Database internals"}},
+		{"beans", []string{"Cooking", "Gardening"}},
+		{"beans -rice", []string{"Gardening"}},
+		{"beans NOT rice", []string{"Gardening"}},
+		{"cooking OR gardening", []string{"Cooking", "Gardening"}},
+		{`"write ahead log"`, []string{"Database internals"}},
+		{`"log database"`, nil},
+		{"data*", []string{"Go databases", "Database internals"}},
+		{"internals recovery", []string{"Database internals"}},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		got := matchTitles(t, db, "SELECT title FROM docs WHERE docs MATCH ? ORDER BY title", tt.query)
+		if !slices.Equal(got, slices.Sorted(slices.Values(tt.want))) {
+			t.Errorf("MATCH %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// A single column can be searched too.
+	if got := matchTitles(t, db, "SELECT title FROM docs WHERE title MATCH 'database'"); !slices.Equal(got, []string{"Database internals"}) {
+		t.Errorf("column MATCH = %v", got)
+	}
+}
+
+func TestFTSVirtualTableBM25Ranking(t *testing.T) {
+	db := openTestDB(t, nil, ftsDocs...)
+	got := matchTitles(t, db, "SELECT title FROM docs WHERE docs MATCH 'database' ORDER BY BM25(docs, 'database') DESC")
+	// "Database internals" mentions the term three times.
+	if !slices.Equal(got, []string{"Database internals", "Go databases"}) {
+		t.Fatalf("ranked titles = %v", got)
+	}
+}
+
+func TestFTSVirtualTableMaintenance(t *testing.T) {
+	db := openTestDB(t, nil, ftsDocs...)
+	ctx := context.Background()
+	query := "SELECT title FROM docs WHERE docs MATCH 'balcony'"
+
+	mustExec(t, db, "UPDATE docs SET body = 'Growing tomatoes indoors' WHERE title = 'Gardening'")
+	if got := matchTitles(t, db, query); len(got) != 0 {
+		t.Fatalf("updated row still matches old text: %v", got)
+	}
+	mustExec(t, db, "INSERT INTO docs VALUES ('Balconies', 'A balcony garden')")
+	if got := matchTitles(t, db, query); !slices.Equal(got, []string{"Balconies"}) {
+		t.Fatalf("after insert got %v", got)
+	}
+	mustExec(t, db, "DELETE FROM docs WHERE title = 'Balconies'")
+	if got := matchTitles(t, db, query); len(got) != 0 {
+		t.Fatalf("deleted row still matches: %v", got)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO docs VALUES ('Rolled back', 'balcony')"); err != nil {
+		t.Fatalf("insert in tx: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if got := matchTitles(t, db, query); len(got) != 0 {
+		t.Fatalf("rolled back row matches: %v", got)
+	}
+
+	mustExec(t, db, "DROP TABLE docs")
+	mustExec(t, db, "CREATE VIRTUAL TABLE IF NOT EXISTS docs USING fts5(body)")
+	mustExec(t, db, "CREATE VIRTUAL TABLE IF NOT EXISTS docs USING fts5(body)")
+	if _, err := db.Exec(ctx, "CREATE VIRTUAL TABLE other USING rtree(x)"); err == nil {
+		t.Fatal("expected error for unknown module")
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGINIndexContainment(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "gin.db")
+	db, err := Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE events (id INTEGER PRIMARY KEY, data JSON)")
+	mustExec(t, db, `INSERT INTO events VALUES
+		(1, '{"status":"active","tags":["a","b"]}'),
+		(2, '{"status":"closed","owner":{"name":"ann"}}'),
+		(3, '{"status":"active","owner":{"name":"bob"}}')`)
+	mustExec(t, db, "CREATE INDEX idx_events_data ON events USING GIN (data)")
+
+	ids := func(d *DB, where string, args ...interface{}) []string {
+		t.Helper()
+		return queryStrings(t, d, "SELECT id FROM events WHERE "+where+" ORDER BY id", args...)
+	}
+	check := func(d *DB, where string, want ...string) {
+		t.Helper()
+		if got := ids(d, where); !slices.Equal(got, want) {
+			t.Errorf("WHERE %s = %v, want %v", where, got, want)
+		}
+	}
+
+	check(db, `data @> '{"status":"active"}'`, "1", "3")
+	check(db, `data @> '{"tags":["b"]}'`, "1")
+	check(db, `data @> '{"owner":{"name":"ann"}}'`, "2")
+	check(db, `JSON_CONTAINS_PATH(data, 'one', '$.owner.name')`, "2", "3")
+	check(db, `data @> '{"status":"active"}' AND JSON_CONTAINS_PATH(data, 'one', '$.owner')`, "3")
+	if got := ids(db, "data @> ?", `{"status":"closed"}`); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("data @> ? = %v, want [2]", got)
+	}
+
+	plan := strings.Join(queryStrings(t, db, `EXPLAIN SELECT id FROM events WHERE data @> '{"status":"active"}'`), "\n")
+	if !strings.Contains(plan, "idx_events_data") {
+		t.Errorf("EXPLAIN does not use the GIN index:\n%s", plan)
+	}
+
+	// Writes keep the index current, both in autocommit and in transactions.
+	mustExec(t, db, `UPDATE events SET data = '{"status":"closed"}' WHERE id = 1`)
+	mustExec(t, db, `DELETE FROM events WHERE id = 3`)
+	check(db, `data @> '{"status":"active"}'`)
+	check(db, `data @> '{"status":"closed"}'`, "1", "2")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO events VALUES (4, '{"status":"active"}')`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	check(db, `data @> '{"status":"active"}'`)
+
+	tx, err = db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO events VALUES (5, '{"status":"active"}')`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	check(db, `data @> '{"status":"active"}'`, "5")
+
+	// The definition persists and the postings are rebuilt on open.
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	db, err = Open(dbPath, &Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	check(db, `data @> '{"status":"active"}'`, "5")
+	check(db, `data @> '{"status":"closed"}'`, "1", "2")
+
+	mustExec(t, db, "DROP INDEX idx_events_data")
+	check(db, `data @> '{"status":"closed"}'`, "1", "2")
+	if _, err := db.Exec(ctx, "CREATE INDEX idx_bad ON events USING GIN (id)"); err == nil {
+		t.Error("expected an error for a GIN index on a non-JSON column")
+	}
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGroupByAccumulatorsMatchCollectedGroups(t *testing.T) {
+	db, err := Open(":memory:", &Options{
+		CoreStorage:  CoreStorage{InMemory: true},
+		ResultLimits: ResultLimits{MaxQueryMemory: 64 << 10},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE sales (id INTEGER PRIMARY KEY, region TEXT, qty INTEGER, note TEXT)")
+	// Over the parallel threshold, so both the serial and the parallel scan
+	// are covered by the two row counts.
+	for _, n := range []int{300, 2400} {
+		mustExec(t, db, "DELETE FROM sales")
+		for start := 0; start < n; start += 200 {
+			values := make([]string, 0, 200)
+			for i := start; i < start+200; i++ {
+				qty := fmt.Sprint(i % 17)
+				if i%11 == 0 {
+					qty = "NULL"
+				}
+				values = append(values, fmt.Sprintf("(%d, 'r%d', %s, '%s')", i, i%7, qty, strings.Repeat("n", 30)))
+			}
+			mustExec(t, db, "INSERT INTO sales VALUES "+strings.Join(values, ", "))
+		}
+
+		const cols = "region, COUNT(*), COUNT(qty), SUM(qty), AVG(qty), MIN(qty), MAX(id), " +
+			"COUNT(*) FILTER (WHERE qty > 8), UPPER(region)"
+		accumulated := queryAll(t, db, ctx, "SELECT "+cols+" FROM sales GROUP BY region HAVING COUNT(qty) > 0 ORDER BY region")
+		collected := queryAll(t, db, WithQueryLimits(ctx, QueryLimits{MaxMemory: 1 << 30}),
+			"SELECT "+cols+", GROUP_CONCAT(note) FROM sales GROUP BY region HAVING COUNT(qty) > 0 ORDER BY region")
+		if len(accumulated) != 7 || len(collected) != 7 {
+			t.Fatalf("%d rows: got %d and %d groups, want 7", n, len(accumulated), len(collected))
+		}
+		for i := range accumulated {
+			if want := collected[i][:len(accumulated[i])]; !reflect.DeepEqual(accumulated[i], want) {
+				t.Fatalf("%d rows, group %d = %v, want %v", n, i, accumulated[i], want)
+			}
+		}
+	}
+
+	// The collected rows alone outgrow the memory limit; the accumulators
+	// hold one row per group.
+	if _, err := db.Query(ctx, "SELECT region, GROUP_CONCAT(note) FROM sales GROUP BY region"); err == nil {
+		t.Fatal("collecting GROUP BY stayed under the memory limit")
+	}
+}
+
+func queryAll(t *testing.T, db *DB, ctx context.Context, sql string) [][]interface{} {
+	t.Helper()
+	rows, err := db.Query(ctx, sql)
+	if err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	defer rows.Close()
+	var out [][]interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(rows.Columns()))
+		ptrs := make([]interface{}, len(vals))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		out = append(out, vals)
+	}
+	return out
+}
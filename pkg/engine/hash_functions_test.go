@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestHashAndEncodingFunctions(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"MD5('abc')", "900150983cd24fb0d6963f7d28e17f72"},
+		{"SHA1('abc')", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"SHA256('abc')", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"SHA2('abc', 0)", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"SHA2('abc', 224)", "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7"},
+		{"SHA2('abc', 7)", nil},
+		{"HMAC('key', 'The quick brown fox jumps over the lazy dog')", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"HMAC('key', 'The quick brown fox jumps over the lazy dog', 'md5')", "80070713463e7749b90c2dc24911e275"},
+		{"CRC32('abc')", int64(891568578)},
+		{"TO_BASE64('hello')", "aGVsbG8="},
+		{"FROM_BASE64('not base64!')", nil},
+		{"MD5(NULL)", nil},
+		{"MD5(123)", "202cb962ac59075b964b07152d234b70"},
+	}
+	for _, tt := range tests {
+		var got interface{}
+		if err := db.QueryRow(ctx, "SELECT "+tt.expr).Scan(&got); err != nil {
+			t.Fatalf("SELECT %s: %v", tt.expr, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+	if _, err := db.Query(ctx, "SELECT HMAC('k', 'm', 'whirlpool')"); err == nil {
+		t.Error("HMAC with an unknown algorithm succeeded")
+	}
+
+	// Blobs hash and encode as their bytes, not their text form, and hashes
+	// work as column defaults.
+	mustExec(t, db, "CREATE TABLE blobs (id INTEGER PRIMARY KEY, data BLOB, tag TEXT DEFAULT (SHA1('x')))")
+	mustExec(t, db, "INSERT INTO blobs (id, data) VALUES (1, FROM_BASE64('AAH/'))")
+	var data []byte
+	var encoded, digest, tag string
+	if err := db.QueryRow(ctx, "SELECT data, TO_BASE64(data), MD5(data), tag FROM blobs").Scan(&data, &encoded, &digest, &tag); err != nil {
+		t.Fatalf("SELECT blob: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0x00, 0x01, 0xff}) || encoded != "AAH/" {
+		t.Fatalf("blob round trip = (%v, %q), want ([0 1 255], \"AAH/\")", data, encoded)
+	}
+	if digest != "ffbb8cd5a232b7d906904533e9609f48" {
+		t.Fatalf("MD5(blob) = %q", digest)
+	}
+	if tag != "11f6ad8ec52a2984abaafd7c3b516503785c2072" {
+		t.Fatalf("hash default = %q", tag)
+	}
+}
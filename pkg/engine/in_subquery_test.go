@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestInSubquerySemantics(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE orders (id INTEGER PRIMARY KEY, customer INTEGER)")
+	mustExec(t, db, "CREATE TABLE vip (customer INTEGER)")
+	mustExec(t, db, "CREATE TABLE banned (customer INTEGER)")
+	mustExec(t, db, "INSERT INTO orders VALUES (1, 10), (2, 20), (3, 30), (4, NULL)")
+	mustExec(t, db, "INSERT INTO vip VALUES (10), (30)")
+	mustExec(t, db, "INSERT INTO banned VALUES (20), (NULL)")
+
+	tests := []struct {
+		where string
+		want  []int64
+	}{
+		{"customer IN (SELECT customer FROM vip)", []int64{1, 3}},
+		{"customer NOT IN (SELECT customer FROM vip)", []int64{2}},
+		{"customer IN (SELECT customer FROM banned)", []int64{2}},
+		// A NULL in the subquery makes every miss unknown.
+		{"customer NOT IN (SELECT customer FROM banned)", nil},
+		// Nothing is in an empty set, so NOT IN holds even for NULL.
+		{"customer NOT IN (SELECT customer FROM vip WHERE customer > 100)", []int64{1, 2, 3, 4}},
+		{"customer IN (SELECT customer FROM vip WHERE customer > 100)", nil},
+		{"customer IN (SELECT CAST(customer AS TEXT) FROM vip)", []int64{1, 3}},
+		{"customer IN (SELECT v.customer FROM vip v WHERE v.customer = orders.customer)", []int64{1, 3}},
+	}
+	for _, tt := range tests {
+		sql := "SELECT id FROM orders WHERE " + tt.where + " ORDER BY id"
+		var got []int64
+		for _, row := range queryAll(t, db, ctx, sql) {
+			got = append(got, row[0].(int64))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", sql, got, tt.want)
+		}
+	}
+
+	// The subquery's result is taken before the statement changes its table.
+	mustExec(t, db, "DELETE FROM vip WHERE customer IN (SELECT customer FROM vip WHERE customer < 20)")
+	assertScalar(t, db, "SELECT COUNT(*) FROM vip", int64(1))
+	mustExec(t, db, "UPDATE orders SET customer = customer + 1 WHERE customer IN (SELECT customer FROM orders)")
+	assertScalar(t, db, "SELECT COUNT(*) FROM orders WHERE customer IN (11, 21, 31)", int64(3))
+}
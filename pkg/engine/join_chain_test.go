@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Each join folds over the rows of the joins before it, so a condition can
+// name any table to its left.
+func TestJoinChain(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, manager_id INTEGER)")
+	mustExec(t, db, "CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, product_id INTEGER)")
+	mustExec(t, db, "CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, cat_id INTEGER)")
+	mustExec(t, db, "CREATE TABLE cats (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "INSERT INTO users VALUES (1, 'ann', NULL), (2, 'bob', 1), (3, 'cy', 1)")
+	mustExec(t, db, "INSERT INTO orders VALUES (10, 1, 100), (11, 2, 101), (12, 2, 100), (13, 3, 102)")
+	mustExec(t, db, "INSERT INTO products VALUES (100, 'pen', 1), (101, 'ink', 1), (102, 'cup', 2)")
+	mustExec(t, db, "INSERT INTO cats VALUES (1, 'office'), (2, 'kitchen')")
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT u.name, p.name, c.name FROM users u JOIN orders o ON o.user_id = u.id JOIN products p ON p.id = o.product_id JOIN cats c ON c.id = p.cat_id ORDER BY o.id",
+			"[ann pen office] [bob ink office] [bob pen office] [cy cup kitchen]"},
+		// The third join refers back to the first table, past the second.
+		{"SELECT u.name, o.id, c.name FROM users u JOIN orders o ON o.user_id = u.id JOIN cats c ON c.id = u.id ORDER BY o.id",
+			"[ann 10 office] [bob 11 kitchen] [bob 12 kitchen]"},
+		{"SELECT u.name, o.id, c.name FROM users u JOIN orders o ON o.user_id = u.id JOIN cats c ON c.id = u.id AND o.id <> 12 ORDER BY o.id",
+			"[ann 10 office] [bob 11 kitchen]"},
+		{"SELECT o.id, o2.id FROM orders o JOIN products p ON p.id = o.product_id JOIN orders o2 ON o2.product_id = p.id AND o2.id <> o.id ORDER BY 1",
+			"[10 12] [12 10]"},
+		{"SELECT u.name, m.name, mm.name FROM users u JOIN users m ON m.id = u.manager_id LEFT JOIN users mm ON mm.id = m.manager_id ORDER BY u.id",
+			"[bob ann <nil>] [cy ann <nil>]"},
+		{"SELECT u.name, o.id, p.name FROM users u LEFT JOIN orders o ON o.user_id = u.id AND o.id > 11 LEFT JOIN products p ON p.id = o.product_id ORDER BY u.id",
+			"[ann <nil> <nil>] [bob 12 pen] [cy 13 cup]"},
+		{"SELECT u.name, c.name FROM users u JOIN orders o ON o.user_id = u.id JOIN products p ON p.id = o.product_id RIGHT JOIN cats c ON c.id = p.cat_id AND u.id = 3 ORDER BY c.id",
+			"[<nil> office] [cy kitchen]"},
+		{"SELECT u.name, p.name FROM users u, orders o, products p WHERE o.user_id = u.id AND p.id = o.product_id ORDER BY o.id",
+			"[ann pen] [bob ink] [bob pen] [cy cup]"},
+		{"SELECT c.name, COUNT(*) FROM users u JOIN orders o ON o.user_id = u.id JOIN cats c ON c.id = u.id GROUP BY c.name ORDER BY 1",
+			"[kitchen 2] [office 1]"},
+		{"SELECT c.name, COUNT(o.id) FROM users u LEFT JOIN orders o ON o.user_id = u.id AND o.id > 11 RIGHT JOIN cats c ON c.id = u.id GROUP BY c.name ORDER BY 1",
+			"[kitchen 1] [office 0]"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, row := range queryAll(t, db, ctx, tt.sql) {
+			got = append(got, fmt.Sprint(row))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%s\n got %s\nwant %s", tt.sql, s, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJoinUsingAndNatural(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE users (user_id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, amt INTEGER)")
+	mustExec(t, db, "CREATE TABLE items (id INTEGER, sku TEXT)")
+	mustExec(t, db, "INSERT INTO users VALUES (1, 'ann'), (2, 'bob'), (3, 'cy')")
+	mustExec(t, db, "INSERT INTO orders VALUES (10, 1, 5), (11, 2, 7), (12, 2, 1), (13, 4, 9)")
+	mustExec(t, db, "INSERT INTO items VALUES (10, 'a'), (11, 'b'), (99, 'z')")
+
+	tests := []struct {
+		sql  string
+		cols string
+		want string
+	}{
+		{"SELECT * FROM users JOIN orders USING (user_id) ORDER BY id",
+			"user_id name id amt", "[1 ann 10 5] [2 bob 11 7] [2 bob 12 1]"},
+		{"SELECT * FROM users NATURAL JOIN orders ORDER BY id",
+			"user_id name id amt", "[1 ann 10 5] [2 bob 11 7] [2 bob 12 1]"},
+		{"SELECT * FROM users LEFT JOIN orders USING (user_id) ORDER BY 1, id",
+			"user_id name id amt", "[1 ann 10 5] [2 bob 11 7] [2 bob 12 1] [3 cy <nil> <nil>]"},
+		// The merged column takes whichever side has a row.
+		{"SELECT user_id, name, id FROM users FULL JOIN orders USING (user_id) ORDER BY user_id, id",
+			"user_id name id", "[1 ann 10] [2 bob 11] [2 bob 12] [3 cy <nil>] [4 <nil> 13]"},
+		{"SELECT user_id, id FROM users RIGHT JOIN orders USING (user_id) WHERE user_id > 1 ORDER BY id",
+			"user_id id", "[2 11] [2 12] [4 13]"},
+		{"SELECT users.user_id, orders.user_id FROM users FULL JOIN orders USING (user_id) WHERE id = 13",
+			"user_id user_id", "[<nil> 4]"},
+		{"SELECT name, COUNT(*) FROM users JOIN orders USING (user_id) GROUP BY name ORDER BY 1",
+			"name COUNT(*)", "[ann 1] [bob 2]"},
+		{"SELECT * FROM users JOIN orders USING (user_id) JOIN items USING (id) ORDER BY id",
+			"id user_id name amt sku", "[10 1 ann 5 a] [11 2 bob 7 b]"},
+		{"SELECT * FROM users NATURAL JOIN orders NATURAL JOIN items ORDER BY id",
+			"id user_id name amt sku", "[10 1 ann 5 a] [11 2 bob 7 b]"},
+		// No shared columns: a cross join.
+		{"SELECT COUNT(*) FROM users NATURAL JOIN items", "COUNT(*)", "[9]"},
+		{"SELECT u.name FROM users u JOIN orders o USING (user_id) WHERE user_id = 2 ORDER BY o.id",
+			"name", "[bob] [bob]"},
+	}
+	for _, tt := range tests {
+		// Run twice: resolving USING must not change the cached statement.
+		for run := 0; run < 2; run++ {
+			rows, err := db.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.sql, err)
+			}
+			cols := strings.Join(rows.Columns(), " ")
+			rows.Close()
+			if cols != tt.cols {
+				t.Errorf("%s: columns %s, want %s", tt.sql, cols, tt.cols)
+			}
+			var got []string
+			for _, row := range queryAll(t, db, ctx, tt.sql) {
+				got = append(got, fmt.Sprint(row))
+			}
+			if s := strings.Join(got, " "); s != tt.want {
+				t.Errorf("%s = %s, want %s", tt.sql, s, tt.want)
+			}
+		}
+	}
+
+	for sql, want := range map[string]string{
+		"SELECT * FROM users JOIN orders USING (nope)":                                     "does not exist in left table",
+		"SELECT * FROM users JOIN items USING (user_id)":                                   "does not exist in right table items",
+		"SELECT * FROM users JOIN orders USING (user_id, user_id)":                         "more than once in USING",
+		"SELECT * FROM orders JOIN items ON items.id = orders.id JOIN items i2 USING (id)": "more than once in left table",
+	} {
+		if _, err := db.Query(ctx, sql); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", sql, err, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLastInsertID(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "CREATE TABLE notes (body TEXT)")
+	mustExec(t, db, "CREATE TABLE tags (name TEXT PRIMARY KEY)")
+
+	insert := func(ctx context.Context, sql string, want int64) {
+		t.Helper()
+		res, err := db.Exec(ctx, sql)
+		if err != nil {
+			t.Fatalf("exec %q: %v", sql, err)
+		}
+		if res.LastInsertID != want {
+			t.Fatalf("%q: LastInsertID = %d, want %d", sql, res.LastInsertID, want)
+		}
+	}
+	insert(ctx, "INSERT INTO users VALUES (41, 'ann')", 41)
+	insert(ctx, "INSERT INTO users (name) VALUES ('bob'), ('cy')", 43)
+	insert(ctx, "INSERT INTO notes VALUES ('a'), ('b'), ('c')", 3)
+	assertScalar(t, db, "SELECT last_insert_rowid()", int64(3))
+
+	// A table without a ROWID leaves the previous value in place.
+	insert(ctx, "INSERT INTO tags VALUES ('go')", 0)
+	assertScalar(t, db, "SELECT last_insert_rowid()", int64(3))
+
+	// The function sees the value of the statement before it.
+	mustExec(t, db, "INSERT INTO users (id, name) VALUES (100, 'dee')")
+	mustExec(t, db, "INSERT INTO notes VALUES (CAST(last_insert_rowid() AS TEXT))")
+	assertScalar(t, db, "SELECT body FROM notes WHERE rowid = 4", "100")
+
+	// Each session keeps its own value.
+	s1, s2 := &Session{}, &Session{}
+	insert(WithSession(ctx, s1), "INSERT INTO users VALUES (200, 'eve')", 200)
+	insert(WithSession(ctx, s2), "INSERT INTO users VALUES (300, 'fay')", 300)
+	for _, tc := range []struct {
+		s    *Session
+		want int64
+	}{{s1, 200}, {s2, 300}} {
+		var got int64
+		if err := db.QueryRow(WithSession(ctx, tc.s), "SELECT last_insert_rowid()").Scan(&got); err != nil {
+			t.Fatalf("last_insert_rowid: %v", err)
+		}
+		if got != tc.want || tc.s.LastInsertRowID() != tc.want {
+			t.Fatalf("session last_insert_rowid = %d, want %d", got, tc.want)
+		}
+	}
+	assertScalar(t, db, "SELECT last_insert_rowid()", int64(4))
+}
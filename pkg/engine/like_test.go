@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestLikeEscapeAndPrefixScans(t *testing.T) {
+	db, err := Open(":memory:", &Options{
+		CoreStorage: CoreStorage{InMemory: true},
+		QueryCache:  QueryCacheConfig{EnableQueryCache: true},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	// The same rows with an index, with a TEXT primary key, and with neither:
+	// prefix scans must not change any result.
+	const rows = "('abc', 1), ('abd', 2), ('ABc', 3), ('a%x', 4), ('a%%', 5), ('b', 6), ('ab', 7), ('héllo', 8), ('hello', 9), ('a_c', 10)"
+	mustExec(t, db, "CREATE TABLE indexed (name TEXT, id INTEGER PRIMARY KEY)")
+	mustExec(t, db, "CREATE INDEX indexed_name ON indexed (name)")
+	mustExec(t, db, "CREATE TABLE keyed (name TEXT PRIMARY KEY, id INTEGER)")
+	mustExec(t, db, "CREATE TABLE plain (name TEXT, id INTEGER)")
+	for _, table := range []string{"indexed", "keyed", "plain"} {
+		mustExec(t, db, "INSERT INTO "+table+" VALUES "+rows)
+	}
+
+	tests := []struct {
+		where string
+		want  []int64
+	}{
+		{"name LIKE 'ab%'", []int64{1, 2, 7}},
+		{"name LIKE 'ab_'", []int64{1, 2}},
+		{"name LIKE 'h_llo'", []int64{8, 9}},
+		{"name LIKE 'a!%%' ESCAPE '!'", []int64{4, 5}},
+		{"name LIKE 'a\\\\%%' ESCAPE '\\\\'", []int64{4, 5}},
+		{`name LIKE 'a\%%' ESCAPE '\'`, []int64{4, 5}},
+		{"name LIKE 'a!_c' ESCAPE '!'", []int64{10}},
+		{"name LIKE 'a%%' ESCAPE ''", []int64{1, 2, 4, 5, 7, 10}},
+		{"name LIKE 'ab%' AND id > 1", []int64{2, 7}},
+		{"name NOT LIKE 'a%'", []int64{3, 6, 8, 9}},
+	}
+	for _, tt := range tests {
+		for _, table := range []string{"indexed", "keyed", "plain"} {
+			sql := "SELECT id FROM " + table + " WHERE " + tt.where + " ORDER BY id"
+			var got []int64
+			for _, row := range queryAll(t, db, ctx, sql) {
+				got = append(got, row[0].(int64))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %v, want %v", sql, got, tt.want)
+			}
+		}
+	}
+
+	if _, err := db.Query(ctx, "SELECT id FROM plain WHERE name LIKE 'a%' ESCAPE 'xy'"); err == nil {
+		t.Error("a two-character ESCAPE was accepted")
+	}
+	if _, err := db.Query(ctx, "SELECT 'a' LIKE 'a%' ESCAPE ?", "xy"); err == nil {
+		t.Error("a two-character ESCAPE argument was accepted")
+	}
+
+	// Turning case sensitivity off drops cached results and the prefix scan.
+	assertScalar(t, db, "SELECT COUNT(*) FROM indexed WHERE name LIKE 'ab%'", int64(3))
+	mustExec(t, db, "PRAGMA case_sensitive_like = off")
+	assertScalar(t, db, "SELECT COUNT(*) FROM indexed WHERE name LIKE 'ab%'", int64(4))
+}
+
+func TestCaseInsensitiveLikeOption(t *testing.T) {
+	db, err := Open(":memory:", &Options{
+		CoreStorage: CoreStorage{InMemory: true},
+		Security:    Security{CaseInsensitiveLike: true},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	assertScalar(t, db, "PRAGMA case_sensitive_like", false)
+	mustExec(t, db, "CREATE TABLE names (name TEXT PRIMARY KEY)")
+	mustExec(t, db, "INSERT INTO names VALUES ('Alice'), ('ÉMILE'), ('bob')")
+	assertScalar(t, db, "SELECT COUNT(*) FROM names WHERE name LIKE 'al%'", int64(1))
+	assertScalar(t, db, "SELECT COUNT(*) FROM names WHERE name LIKE 'émile'", int64(1))
+}
+
+func TestGlobAndILike(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE files (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "CREATE INDEX files_name ON files (name)")
+	mustExec(t, db, "INSERT INTO files VALUES (1, 'Report.TXT'), (2, 'report.txt'), (3, 'data1.csv'), (4, 'data9.csv'), (5, 'Émile'), (6, NULL), (7, 'a]b'), (8, 'a_b')")
+
+	tests := []struct {
+		where string
+		want  []int64
+	}{
+		{"name GLOB '*.txt'", []int64{2}},
+		{"name GLOB 'data[0-5].csv'", []int64{3}},
+		{"name GLOB 'data[^0-5].csv'", []int64{4}},
+		{"name GLOB '?mile'", []int64{5}},
+		{"name GLOB 'a[]_]b'", []int64{7, 8}},
+		{"name GLOB 'a[_'", nil},
+		{"name NOT GLOB '*.csv'", []int64{1, 2, 5, 7, 8}},
+		{"name ILIKE 'report%'", []int64{1, 2}},
+		{"name NOT ILIKE 'report%'", []int64{3, 4, 5, 7, 8}},
+		{"name ILIKE 'émile'", []int64{5}},
+		{"name ILIKE 'A!_%' ESCAPE '!'", []int64{8}},
+		{"name ILIKE 'DATA_%' AND id > 3", []int64{4}},
+	}
+	for _, tt := range tests {
+		sql := "SELECT id FROM files WHERE " + tt.where + " ORDER BY id"
+		var got []int64
+		for _, row := range queryAll(t, db, ctx, sql) {
+			got = append(got, row[0].(int64))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", sql, got, tt.want)
+		}
+	}
+	assertScalar(t, db, "SELECT 'abc' ILIKE NULL", nil)
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// SQLMetadata is a machine-readable description of the SQL dialect and the
+// current schema, intended for language servers and editor plugins that
+// provide completion and diagnostics. It is safe to serialize as JSON.
+type SQLMetadata struct {
+	Keywords  []string               `json:"keywords"`
+	Types     []string               `json:"types"`
+	Functions []catalog.FunctionInfo `json:"functions"`
+	Tables    []TableMetadata        `json:"tables"`
+	Views     []string               `json:"views"`
+}
+
+// TableMetadata describes a table's columns and secondary indexes.
+type TableMetadata struct {
+	Name       string           `json:"name"`
+	Columns    []ColumnMetadata `json:"columns"`
+	PrimaryKey []string         `json:"primary_key,omitempty"`
+	Indexes    []IndexMetadata  `json:"indexes,omitempty"`
+}
+
+// ColumnMetadata describes a single column.
+type ColumnMetadata struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	NotNull    bool   `json:"not_null,omitempty"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+	Unique     bool   `json:"unique,omitempty"`
+	Default    string `json:"default,omitempty"`
+}
+
+// IndexMetadata describes a secondary index.
+type IndexMetadata struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// Metadata returns the supported grammar keywords, column types, built-in
+// functions with signatures, and a snapshot of the current schema.
+func (db *DB) Metadata() (*SQLMetadata, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed.Load() {
+		return nil, ErrDatabaseClosed
+	}
+
+	md := &SQLMetadata{
+		Keywords:  query.Keywords(),
+		Types:     query.DataTypes(),
+		Functions: catalog.BuiltinFunctions(),
+		Tables:    []TableMetadata{},
+		Views:     []string{},
+	}
+
+	tables := db.catalog.ListTables()
+	sort.Strings(tables)
+	for _, name := range tables {
+		table, err := db.catalog.GetTable(name)
+		if err != nil {
+			continue // dropped concurrently
+		}
+		tm := TableMetadata{
+			Name:       table.Name,
+			Columns:    make([]ColumnMetadata, 0, len(table.Columns)),
+			PrimaryKey: table.PrimaryKey,
+		}
+		for _, col := range table.Columns {
+			tm.Columns = append(tm.Columns, ColumnMetadata{
+				Name:       col.Name,
+				Type:       schemaColumnType(col),
+				NotNull:    col.NotNull,
+				PrimaryKey: col.PrimaryKey,
+				Unique:     col.Unique,
+				Default:    col.Default,
+			})
+		}
+		for _, idx := range db.catalog.GetTableIndexes(name) {
+			tm.Indexes = append(tm.Indexes, IndexMetadata{Name: idx.Name, Columns: idx.Columns, Unique: idx.Unique})
+		}
+		sort.Slice(tm.Indexes, func(i, j int) bool { return tm.Indexes[i].Name < tm.Indexes[j].Name })
+		md.Tables = append(md.Tables, tm)
+	}
+
+	for name := range db.catalog.ListViewSQL() {
+		md.Views = append(md.Views, name)
+	}
+	sort.Strings(md.Views)
+
+	return md, nil
+}
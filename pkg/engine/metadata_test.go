@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestMetadataDescribesGrammarAndSchema(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, sql := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE, score REAL DEFAULT 0)`,
+		`CREATE INDEX idx_users_score ON users (score)`,
+		`CREATE VIEW top_users AS SELECT id FROM users WHERE score > 10`,
+	} {
+		if _, err := db.Exec(ctx, sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+	}
+
+	md, err := db.Metadata()
+	if err != nil {
+		t.Fatalf("Metadata failed: %v", err)
+	}
+	if !containsString(md.Keywords, "SELECT") || !containsString(md.Types, "INTEGER") {
+		t.Errorf("missing grammar entries: keywords=%d types=%v", len(md.Keywords), md.Types)
+	}
+	var sawUpper bool
+	for _, fn := range md.Functions {
+		if fn.Name == "UPPER" {
+			sawUpper = fn.Signature == "UPPER(str)" && fn.Returns == "TEXT"
+		}
+	}
+	if !sawUpper {
+		t.Error("UPPER missing or has the wrong signature")
+	}
+
+	if len(md.Tables) != 1 || md.Tables[0].Name != "users" {
+		t.Fatalf("tables = %+v", md.Tables)
+	}
+	users := md.Tables[0]
+	if len(users.Columns) != 3 || users.Columns[1].Name != "email" || !users.Columns[1].NotNull || users.Columns[2].Default == "" {
+		t.Errorf("columns = %+v", users.Columns)
+	}
+	if len(users.Indexes) != 1 || users.Indexes[0].Name != "idx_users_score" {
+		t.Errorf("indexes = %+v", users.Indexes)
+	}
+	if len(md.Views) != 1 || md.Views[0] != "top_users" {
+		t.Errorf("views = %v", md.Views)
+	}
+	if _, err := json.Marshal(md); err != nil {
+		t.Errorf("metadata is not JSON-serializable: %v", err)
+	}
+
+	db.Close()
+	if _, err := db.Metadata(); err != ErrDatabaseClosed {
+		t.Errorf("Metadata on closed DB = %v, want ErrDatabaseClosed", err)
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
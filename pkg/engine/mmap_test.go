@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMMapReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mmap.db")
+	opts := durabilityTestOptions()
+	opts.MMap = true
+	// A small cache makes reads reach the mapped file.
+	opts.CoreStorage.CacheSize = 16
+
+	db, err := Open(path, opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+	var values []string
+	for i := 1; i <= 500; i++ {
+		values = append(values, fmt.Sprintf("(%d, '%s')", i, strings.Repeat("n", 100)))
+	}
+	mustExec(t, db, "INSERT INTO notes VALUES "+strings.Join(values, ", "))
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	mustExec(t, db, "UPDATE notes SET body = 'updated' WHERE id = 7")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	db, err = Open(path, opts)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	rows := queryRows(t, db, "SELECT COUNT(*), MAX(id) FROM notes")
+	if fmt.Sprint(rows) != "[[500 500]]" {
+		t.Fatalf("count, max = %v", rows)
+	}
+	if rows := queryRows(t, db, "SELECT body FROM notes WHERE id = 7"); fmt.Sprint(rows) != "[[updated]]" {
+		t.Fatalf("updated row = %v", rows)
+	}
+}
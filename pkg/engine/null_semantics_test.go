@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNullSemantics(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, x INTEGER, y INTEGER)")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 1, 1), (2, NULL, 1), (3, 7, NULL), (4, NULL, NULL), (5, 7, 2)")
+	mustExec(t, db, "CREATE TABLE u (id INTEGER PRIMARY KEY, x INTEGER)")
+	mustExec(t, db, "INSERT INTO u VALUES (1, 1), (2, NULL)")
+
+	// IS NULL and three-valued logic in WHERE: an UNKNOWN condition filters
+	// the row out, and NOT UNKNOWN is still UNKNOWN.
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE x IS NULL", int64(2))
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE x IS NOT NULL", int64(3))
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE NOT (x = 7)", int64(1))
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE x = 7 OR y = 1", int64(4))
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE NOT (x = 7 AND y = 1)", int64(2))
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE x NOT IN (SELECT x FROM u)", int64(0))
+	assertScalar(t, db, "SELECT COUNT(*) FROM t WHERE x IN (1, NULL)", int64(1))
+
+	// NULLs sort last ascending and first descending everywhere, and
+	// NULLS FIRST / NULLS LAST override that.
+	for _, tc := range []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT x FROM t ORDER BY x", "[[1] [7] [7] [<nil>] [<nil>]]"},
+		{"SELECT x FROM t ORDER BY x DESC", "[[<nil>] [<nil>] [7] [7] [1]]"},
+		{"SELECT x, COUNT(*) FROM t GROUP BY x ORDER BY x", "[[1 1] [7 2] [<nil> 2]]"},
+		{"SELECT x, COUNT(*) FROM t GROUP BY x ORDER BY 1 DESC", "[[<nil> 2] [7 2] [1 1]]"},
+		{"SELECT x, COUNT(*) FROM t GROUP BY x ORDER BY x NULLS FIRST", "[[<nil> 2] [1 1] [7 2]]"},
+		{"SELECT x FROM t UNION SELECT x FROM u ORDER BY 1", "[[1] [7] [<nil>]]"},
+		{"SELECT x FROM t UNION SELECT x FROM u ORDER BY x DESC NULLS LAST", "[[7] [1] [<nil>]]"},
+	} {
+		got := queryRows(t, db, tc.sql)
+		if s := fmt.Sprint(got); s != tc.want {
+			t.Errorf("%s = %s, want %s", tc.sql, s, tc.want)
+		}
+	}
+}
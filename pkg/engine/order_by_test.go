@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOrderByExpressionsAndPositions(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE p (id INTEGER PRIMARY KEY, a INTEGER, b TEXT)")
+	mustExec(t, db, "INSERT INTO p VALUES (1, 3, 'x'), (2, NULL, 'y'), (3, 1, 'x'), (4, 2, NULL), (5, 1, 'zz')")
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT id FROM p ORDER BY a * -1, id", "[1] [4] [3] [5] [2]"},
+		{"SELECT id, a * 10 AS t FROM p ORDER BY t + id DESC", "[2 <nil>] [1 30] [4 20] [5 10] [3 10]"},
+		{"SELECT * FROM p ORDER BY 2 DESC, 1", "[2 <nil> y] [1 3 x] [4 2 <nil>] [3 1 x] [5 1 zz]"},
+		{"SELECT id, a FROM p ORDER BY 2 NULLS FIRST, 1 DESC", "[2 <nil>] [5 1] [3 1] [4 2] [1 3]"},
+		{"SELECT id, a FROM p ORDER BY a DESC NULLS LAST, id", "[1 3] [4 2] [3 1] [5 1] [2 <nil>]"},
+		{"SELECT b FROM p GROUP BY b ORDER BY LENGTH(b) DESC NULLS LAST, b", "[zz] [x] [y] [<nil>]"},
+		{"SELECT b, COUNT(*) AS c FROM p GROUP BY b ORDER BY c * 10 + LENGTH(b), 1", "[y 1] [zz 1] [x 2] [<nil> 1]"},
+		{"SELECT id FROM p UNION SELECT a FROM p ORDER BY 1 DESC NULLS LAST", "[5] [4] [3] [2] [1] [<nil>]"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, row := range queryAll(t, db, ctx, tt.sql) {
+			got = append(got, fmt.Sprint(row))
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%s = %s, want %s", tt.sql, s, tt.want)
+		}
+	}
+
+	for _, sql := range []string{
+		"SELECT id, a FROM p ORDER BY 3",
+		"SELECT * FROM p ORDER BY 4",
+		"SELECT id FROM p UNION SELECT a FROM p ORDER BY 2",
+		"SELECT id FROM p UNION SELECT a FROM p ORDER BY id * -1",
+	} {
+		if _, err := db.Query(ctx, sql); err == nil {
+			t.Errorf("%s: expected an error", sql)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOrderByPKLimit(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT)")
+	var values []string
+	for i := 1; i <= 1000; i++ {
+		values = append(values, fmt.Sprintf("(%d, '%s')", i, []string{"a", "b"}[i%2]))
+	}
+	mustExec(t, db, "INSERT INTO events VALUES "+strings.Join(values, ", "))
+
+	ids := func(sql string) string {
+		t.Helper()
+		var out []string
+		for _, row := range queryRows(t, db, sql) {
+			out = append(out, fmt.Sprint(row[0]))
+		}
+		return strings.Join(out, " ")
+	}
+
+	// Only the rows returned are read.
+	const top = "SELECT id FROM events ORDER BY id DESC LIMIT 3"
+	if got := ids(top); got != "1000 999 998" {
+		t.Fatalf("%s = %s", top, got)
+	}
+	if scan := analyzeRows(t, db, top)["events"]; scan["rows_scanned"] != 3 {
+		t.Fatalf("rows scanned = %v, want 3", scan)
+	}
+	if got := ids("SELECT id FROM events WHERE kind = 'a' ORDER BY id DESC LIMIT 2 OFFSET 1"); got != "998 996" {
+		t.Fatalf("filtered = %s", got)
+	}
+	if got := ids("SELECT kind FROM events ORDER BY id DESC LIMIT 1"); got != "a" {
+		t.Fatalf("hidden order column = %s", got)
+	}
+	mustExec(t, db, "DELETE FROM events WHERE id > 990")
+	if got := ids(top); got != "990 989 988" {
+		t.Fatalf("after delete = %s", got)
+	}
+
+	// Ascending order reads from the smallest key up.
+	const bottom = "SELECT id FROM events ORDER BY id LIMIT 3 OFFSET 2"
+	if got := ids(bottom); got != "3 4 5" {
+		t.Fatalf("%s = %s", bottom, got)
+	}
+	if scan := analyzeRows(t, db, bottom)["events"]; scan["rows_scanned"] != 5 {
+		t.Fatalf("rows scanned = %v, want 5", scan)
+	}
+	if got := ids("SELECT id FROM events WHERE kind = 'b' ORDER BY id ASC LIMIT 3"); got != "1 3 5" {
+		t.Fatalf("filtered ascending = %s", got)
+	}
+
+	// Negative and fractional keys do not sort as their values; the scan
+	// falls back to reading everything.
+	mustExec(t, db, "INSERT INTO events VALUES (-5, 'a'), (-12, 'b')")
+	if got := ids("SELECT id FROM events WHERE id < 3 ORDER BY id DESC LIMIT 4"); got != "2 1 -5 -12" {
+		t.Fatalf("negative keys = %s", got)
+	}
+	if got := ids("SELECT id FROM events ORDER BY id LIMIT 3"); got != "-12 -5 1" {
+		t.Fatalf("negative keys ascending = %s", got)
+	}
+	mustExec(t, db, "CREATE TABLE prices (p REAL PRIMARY KEY)")
+	mustExec(t, db, "INSERT INTO prices VALUES (1), (2), (1.5), (3)")
+	if got := ids("SELECT p FROM prices ORDER BY p DESC LIMIT 3"); got != "3 2 1.5" {
+		t.Fatalf("fractional keys = %s", got)
+	}
+	mustExec(t, db, "INSERT INTO prices VALUES (0.5)")
+	if got := ids("SELECT p FROM prices ORDER BY p LIMIT 2"); got != "0.5 1" {
+		t.Fatalf("fractional keys ascending = %s", got)
+	}
+}
@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPlaceholderBinding(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, v INTEGER)")
+	if _, err := db.Exec(ctx, "INSERT INTO t VALUES (1, ?, 10), (2, ?, 20), (3, 'c', ?), (?, 'd', 40)", "a", "b", 30, 4); err != nil {
+		t.Fatalf("INSERT: %v", err)
+	}
+
+	// Placeholders are numbered in statement order across every clause.
+	for _, tc := range []struct {
+		sql  string
+		args []interface{}
+		want string
+	}{
+		{"SELECT id FROM t ORDER BY id LIMIT ? OFFSET ?", []interface{}{2, 1}, "[[2] [3]]"},
+		{"SELECT id FROM t WHERE v > ? ORDER BY id LIMIT ? OFFSET ?", []interface{}{10, 2, 1}, "[[3] [4]]"},
+		{"SELECT id + ? FROM t WHERE v > ? ORDER BY id", []interface{}{100, 20}, "[[103] [104]]"},
+		{"SELECT id FROM t WHERE id = ? UNION SELECT id FROM t WHERE id = ? ORDER BY 1", []interface{}{1, 2}, "[[1] [2]]"},
+		{"SELECT a.id FROM t a JOIN t b ON b.id = a.id + ? WHERE a.v > ? ORDER BY a.id", []interface{}{1, 10}, "[[2] [3]]"},
+		{"SELECT id FROM t GROUP BY id HAVING id > ? ORDER BY id LIMIT ?", []interface{}{1, 1}, "[[2]]"},
+
+		// Named parameters, mixed with positional ones.
+		{"SELECT id FROM t WHERE v > @lo AND v < @hi", []interface{}{sql.Named("hi", 40), sql.Named("lo", 10)}, "[[2] [3]]"},
+		{"SELECT id FROM t WHERE v >= :lo OR id = :lo ORDER BY id LIMIT ?", []interface{}{sql.Named("lo", 30), 1}, "[[3]]"},
+		{"SELECT name FROM t WHERE id = :id", []interface{}{4}, "[[d]]"},
+
+		// Slices expand into IN lists.
+		{"SELECT id FROM t WHERE name IN (?) ORDER BY id", []interface{}{[]string{"a", "d"}}, "[[1] [4]]"},
+		{"SELECT id FROM t WHERE id NOT IN (?, ?) AND v < ? ORDER BY id", []interface{}{[]int{1}, 4, 40}, "[[2] [3]]"},
+		{"SELECT id FROM t WHERE id IN (:ids) AND v > ? ORDER BY id", []interface{}{sql.Named("ids", []int64{1, 2, 3}), 10}, "[[2] [3]]"},
+	} {
+		rows, err := db.Query(ctx, tc.sql, tc.args...)
+		if err != nil {
+			t.Errorf("%s: %v", tc.sql, err)
+			continue
+		}
+		var got [][]interface{}
+		for rows.Next() {
+			var v interface{}
+			if err := rows.Scan(&v); err != nil {
+				t.Fatalf("%s: scan: %v", tc.sql, err)
+			}
+			got = append(got, []interface{}{v})
+		}
+		rows.Close()
+		if s := fmt.Sprint(got); s != tc.want {
+			t.Errorf("%s = %s, want %s", tc.sql, s, tc.want)
+		}
+	}
+
+	if _, err := db.Query(ctx, "SELECT id FROM t WHERE id = :missing", sql.Named("id", 1)); err == nil || !strings.Contains(err.Error(), ":missing") {
+		t.Errorf("unbound named parameter: err = %v", err)
+	}
+	if _, err := db.Query(ctx, "SELECT id FROM t WHERE id IN (?)", []int{}); err == nil {
+		t.Error("empty IN list bound without error")
+	}
+
+	// Writes and transactions bind the same way.
+	mustExec(t, db, "INSERT INTO t VALUES (5, 'e', 0) ON CONFLICT (id) DO NOTHING")
+	if _, err := db.Exec(ctx, "INSERT INTO t VALUES (?, 'x', 0) ON CONFLICT (id) DO UPDATE SET v = ?", 5, 50); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	assertScalar(t, db, "SELECT v FROM t WHERE id = 5", int64(50))
+	if _, err := db.Exec(ctx, "UPDATE t SET v = :v WHERE id IN (:ids)", sql.Named("v", 7), sql.Named("ids", []int{4, 5})); err != nil {
+		t.Fatalf("UPDATE: %v", err)
+	}
+	assertScalar(t, db, "SELECT SUM(v) FROM t WHERE id >= 4", float64(14))
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM t WHERE id IN (?)", []int64{4, 5}); err != nil {
+		t.Fatalf("DELETE: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM t", int64(3))
+}
+
+func TestPrepareParamNames(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, v INTEGER)")
+
+	ins, err := db.Prepare(ctx, "INSERT INTO t VALUES (:id, @name, :id * ?)")
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if got := fmt.Sprint(ins.ParamNames()); got != "[id name ]" {
+		t.Errorf("ParamNames = %q, want [id name ]", got)
+	}
+	// Positional arguments bind the parameters in ParamNames order.
+	if _, err := ins.Exec(ctx, 1, "a", 10); err != nil {
+		t.Fatalf("Exec positional: %v", err)
+	}
+	// A map binds named parameters; the rest bind ? in order.
+	if _, err := ins.Exec(ctx, map[string]interface{}{"id": 2, "name": "b"}, 10); err != nil {
+		t.Fatalf("Exec map: %v", err)
+	}
+	assertScalar(t, db, "SELECT v FROM t WHERE name = 'b'", int64(20))
+
+	rows := queryRows(t, db, "SELECT id FROM t WHERE v >= 10 ORDER BY id")
+	if fmt.Sprint(rows) != "[[1] [2]]" {
+		t.Errorf("rows = %v", rows)
+	}
+	sel, err := db.Prepare(ctx, "SELECT name FROM t WHERE id = :id")
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	var name string
+	r, err := sel.Query(ctx, map[string]interface{}{"id": 2})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if !r.Next() || r.Scan(&name) != nil || name != "b" {
+		t.Errorf("Query by map: name = %q", name)
+	}
+	r.Close()
+
+	// Without named parameters a map is an ordinary (JSON) value.
+	mustExec(t, db, "CREATE TABLE docs (id INTEGER PRIMARY KEY, body JSON)")
+	if _, err := db.Exec(ctx, "INSERT INTO docs VALUES (1, ?)", map[string]interface{}{"k": "v"}); err != nil {
+		t.Fatalf("insert JSON map: %v", err)
+	}
+	assertScalar(t, db, "SELECT JSON_EXTRACT(body, '$.k') FROM docs", "v")
+
+	if _, err := db.Prepare(ctx, "SELEC 1"); err == nil {
+		t.Error("Prepare accepted invalid SQL")
+	}
+}
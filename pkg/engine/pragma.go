@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// pragma reads and changes one runtime setting.
+type pragma struct {
+	get func(db *DB) interface{}
+	set func(db *DB, value string) error
+}
+
+// pragmas are the settings PRAGMA name [= value] reads and changes, named
+// as in SQLite:
+//
+//	cache_size           buffer pool size in pages; negative values are KiB
+//	wal_sync_mode        off, normal or full; synchronous is an alias
+//	foreign_keys         whether FOREIGN KEY constraints are enforced
+//	busy_timeout         how long writers wait for a locked table, in ms
+//	case_sensitive_like  whether LIKE tells upper and lower case apart
+//
+// Changes last until the database is closed.
+var pragmas = map[string]pragma{
+	"cache_size": {
+		get: func(db *DB) interface{} { return int64(db.CacheSize()) },
+		set: func(db *DB, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid cache_size %q", value)
+			}
+			if n < 0 {
+				n = -n * 1024 / storage.PageSize
+			}
+			return db.SetCacheSize(n)
+		},
+	},
+	"wal_sync_mode": {
+		get: func(db *DB) interface{} { return db.SyncMode().String() },
+		set: func(db *DB, value string) error {
+			mode, err := parsePragmaSyncMode(value)
+			if err != nil {
+				return err
+			}
+			return db.SetSyncMode(mode)
+		},
+	},
+	"foreign_keys": {
+		get: func(db *DB) interface{} { return db.catalog.ForeignKeys() },
+		set: func(db *DB, value string) error {
+			on, err := parsePragmaBool("foreign_keys", value)
+			if err == nil {
+				db.catalog.SetForeignKeys(on)
+			}
+			return err
+		},
+	},
+	"busy_timeout": {
+		get: func(db *DB) interface{} { return db.BusyTimeout().Milliseconds() },
+		set: func(db *DB, value string) error {
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid busy_timeout %q", value)
+			}
+			db.SetBusyTimeout(time.Duration(ms) * time.Millisecond)
+			return nil
+		},
+	},
+	"row_locks": {
+		get: func(db *DB) interface{} { return db.RowLocks() },
+		set: func(db *DB, value string) error {
+			on, err := parsePragmaBool("row_locks", value)
+			if err == nil {
+				db.SetRowLocks(on)
+			}
+			return err
+		},
+	},
+	"case_sensitive_like": {
+		get: func(db *DB) interface{} { return db.catalog.CaseSensitiveLike() },
+		set: func(db *DB, value string) error {
+			on, err := parsePragmaBool("case_sensitive_like", value)
+			if err == nil {
+				db.catalog.SetCaseSensitiveLike(on)
+			}
+			return err
+		},
+	},
+}
+
+func init() {
+	pragmas["synchronous"] = pragmas["wal_sync_mode"]
+}
+
+// executePragma applies stmt's value, if it has one, and returns the
+// setting's current value.
+func (db *DB) executePragma(stmt *query.PragmaStmt) (interface{}, error) {
+	p, ok := pragmas[stmt.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pragma %q", stmt.Name)
+	}
+	if stmt.Value != "" {
+		if err := p.set(db, stmt.Value); err != nil {
+			return nil, err
+		}
+	}
+	return p.get(db), nil
+}
+
+// queryPragma runs a PRAGMA and returns its value as a one-row result.
+func (db *DB) queryPragma(stmt *query.PragmaStmt) (*Rows, error) {
+	value, err := db.executePragma(stmt)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{
+		columns: []string{stmt.Name},
+		rows:    [][]interface{}{{value}},
+	}, nil
+}
+
+// CacheSize returns how many pages the buffer pool caches.
+func (db *DB) CacheSize() int {
+	return db.pool.Stats().Capacity
+}
+
+// SetCacheSize resizes the buffer pool to pages pages, evicting least
+// recently used pages when it shrinks.
+func (db *DB) SetCacheSize(pages int) error {
+	return db.pool.SetCapacity(pages)
+}
+
+// SyncMode returns the WAL sync mode in effect.
+func (db *DB) SyncMode() SyncMode {
+	return SyncMode(db.syncMode.Load())
+}
+
+// SetSyncMode changes when commits wait for the WAL to reach disk; see
+// CoreStorage.SyncMode. Commits already waiting finish under the old mode.
+func (db *DB) SetSyncMode(mode SyncMode) error {
+	if !mode.valid() {
+		return fmt.Errorf("invalid sync mode: %v", mode)
+	}
+	db.syncMode.Store(int32(mode))
+	if db.wal != nil {
+		db.configureGroupCommit(db.wal)
+	}
+	return nil
+}
+
+// parsePragmaSyncMode reads a sync mode by name or by SQLite's numbers for
+// synchronous: 0 off, 1 normal, 2 full.
+func parsePragmaSyncMode(value string) (SyncMode, error) {
+	switch value {
+	case "0":
+		return SyncOff, nil
+	case "1":
+		return SyncNormal, nil
+	case "2":
+		return SyncFull, nil
+	}
+	return parseSyncMode(value)
+}
+
+func parsePragmaBool(name, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "on", "true", "yes":
+		return true, nil
+	case "0", "off", "false", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid %s %q (want on or off)", name, value)
+}
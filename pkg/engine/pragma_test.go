@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPragmaSettings(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 256}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	assertScalar(t, db, "PRAGMA cache_size", int64(256))
+	assertScalar(t, db, "PRAGMA cache_size = 64", int64(64))
+	mustExec(t, db, "PRAGMA cache_size = -1024")
+	if got := db.CacheSize(); got != 256 {
+		t.Errorf("cache_size -1024 KiB = %d pages, want 256", got)
+	}
+
+	mustExec(t, db, "PRAGMA busy_timeout = 250")
+	if got := db.BusyTimeout(); got != 250*time.Millisecond {
+		t.Errorf("BusyTimeout = %v, want 250ms", got)
+	}
+	assertScalar(t, db, "PRAGMA busy_timeout", int64(250))
+	mustExec(t, db, "PRAGMA row_locks = on")
+	if !db.RowLocks() || db.catalog.RowLockTimeout() != 250*time.Millisecond {
+		t.Errorf("row_locks on: RowLocks = %v, row lock timeout = %v", db.RowLocks(), db.catalog.RowLockTimeout())
+	}
+	assertScalar(t, db, "PRAGMA row_locks", true)
+
+	mustExec(t, db, "CREATE TABLE parent (id INTEGER PRIMARY KEY)")
+	mustExec(t, db, "CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id))")
+	if _, err := db.Exec(ctx, "INSERT INTO child VALUES (1, 99)"); err == nil {
+		t.Fatal("orphan insert succeeded with foreign keys on")
+	}
+	mustExec(t, db, "PRAGMA foreign_keys = OFF")
+	assertScalar(t, db, "PRAGMA foreign_keys", false)
+	mustExec(t, db, "INSERT INTO child VALUES (1, 99)")
+	mustExec(t, db, "PRAGMA foreign_keys(1)")
+	if _, err := db.Exec(ctx, "INSERT INTO child VALUES (2, 98)"); err == nil {
+		t.Fatal("orphan insert succeeded after foreign keys were turned back on")
+	}
+
+	mustExec(t, db, "CREATE TABLE names (name TEXT)")
+	mustExec(t, db, "INSERT INTO names VALUES ('Alice'), ('bob')")
+	assertScalar(t, db, "SELECT COUNT(*) FROM names WHERE name LIKE 'a%'", int64(0))
+	mustExec(t, db, "PRAGMA case_sensitive_like = off")
+	assertScalar(t, db, "SELECT COUNT(*) FROM names WHERE name LIKE 'a%'", int64(1))
+	assertScalar(t, db, "SELECT COUNT(*) FROM names WHERE name LIKE 'B_B'", int64(1))
+
+	for _, sql := range []string{"PRAGMA no_such_pragma", "PRAGMA foreign_keys = maybe", "PRAGMA cache_size = 0"} {
+		if _, err := db.Exec(ctx, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestPragmaSyncMode(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sync.db"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	assertScalar(t, db, "PRAGMA wal_sync_mode", db.options.CoreStorage.SyncMode.String())
+	for _, mode := range []string{"full", "off", "normal"} {
+		assertScalar(t, db, "PRAGMA wal_sync_mode = "+mode, mode)
+		mustExec(t, db, "CREATE TABLE IF NOT EXISTS t (id INTEGER PRIMARY KEY)")
+		mustExec(t, db, "INSERT INTO t DEFAULT VALUES")
+	}
+	assertScalar(t, db, "PRAGMA synchronous = 2", "full")
+	if got := db.SyncMode(); got != SyncFull {
+		t.Errorf("SyncMode = %v, want full", got)
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM t", int64(3))
+}
@@ -0,0 +1,276 @@
+package engine
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// ErrPreparedTxnNotFound is returned by CommitPrepared and RollbackPrepared
+// for an ID no prepared transaction has.
+var ErrPreparedTxnNotFound = errors.New("prepared transaction not found")
+
+// A prepared transaction is kept as the WAL records its commit will log,
+// and, with a WAL, in a file of its own under the database's .prepared
+// directory until it is committed or rolled back. The file holds the
+// records in the replication encoding followed by their CRC32; its name is
+// the hex-encoded transaction ID.
+const (
+	preparedDirSuffix  = ".prepared"
+	preparedFileSuffix = ".txn"
+)
+
+// Prepare is the first phase of a two-phase commit driven by an external
+// coordinator. It checks that the transaction can commit and saves its
+// writes under xid, the coordinator's ID for it, without applying them;
+// Commit or Rollback then finishes it. A prepared transaction survives a
+// restart, after which PreparedTransactions lists it and CommitPrepared or
+// RollbackPrepared finishes it.
+//
+// Until then the rows it writes are held: another transaction that writes
+// one fails at commit with catalog.ErrRowPrepared. Only row changes can be
+// prepared, not schema changes or writes to attached databases. If Prepare
+// fails, the transaction is rolled back.
+func (tx *Tx) Prepare(xid string) (err error) {
+	if tx.done.Load() {
+		return errors.New("transaction already completed")
+	}
+	if tx.xid != "" {
+		return errors.New("transaction already prepared")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if xid == "" {
+		return errors.New("prepared transaction ID cannot be empty")
+	}
+	if err := tx.db.prepare(xid); err != nil {
+		return fmt.Errorf("prepare transaction failed: %w", err)
+	}
+	tx.xid = xid
+	return nil
+}
+
+// prepare prepares the calling goroutine's transaction under xid.
+func (db *DB) prepare(xid string) error {
+	if err := db.checkWritable(); err != nil {
+		return err
+	}
+	if _, exitGate, err := db.writes.enter(context.Background()); err == nil {
+		defer exitGate()
+	}
+	db.flushMu.RLock()
+	defer db.flushMu.RUnlock()
+
+	for _, other := range db.attachedDBs() {
+		if other.catalog.IsTransactionActive() {
+			return errors.New("a transaction that writes attached databases cannot be prepared")
+		}
+	}
+
+	db.preparedMu.Lock()
+	defer db.preparedMu.Unlock()
+	if _, exists := db.prepared[xid]; exists {
+		return fmt.Errorf("prepared transaction %q already exists", xid)
+	}
+	records, err := db.catalog.PrepareTransaction(xid)
+	if err != nil {
+		return err
+	}
+	if err := db.savePrepared(xid, records); err != nil {
+		db.catalog.ReleasePreparedRows(xid)
+		return err
+	}
+	if db.prepared == nil {
+		db.prepared = make(map[string][]*storage.WALRecord)
+	}
+	db.prepared[xid] = records
+	return nil
+}
+
+// CommitPrepared commits the transaction prepared under xid, the second
+// phase of a two-phase commit. Committing applies the writes Prepare saved
+// and logs them like any other commit.
+func (db *DB) CommitPrepared(xid string) error {
+	if err := db.commitPrepared(xid); err != nil {
+		return err
+	}
+	return db.awaitConsensus(context.Background())
+}
+
+func (db *DB) commitPrepared(xid string) error {
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+	if err := db.checkWritable(); err != nil {
+		return err
+	}
+	// The gate comes before preparedMu, as in prepare.
+	ctx, exitGate, err := db.writes.enter(context.Background())
+	if err != nil {
+		return err
+	}
+	defer exitGate()
+	db.preparedMu.Lock()
+	defer db.preparedMu.Unlock()
+	records, ok := db.prepared[xid]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrPreparedTxnNotFound, xid)
+	}
+
+	// The records are logged under a new transaction ID: the one they were
+	// prepared under may have been reused since a restart.
+	mt := db.txnMgr.Begin(nil)
+	id := mt.ID
+	_ = mt.Rollback()
+	mt.Recycle()
+	batch := make([]*storage.WALRecord, 0, len(records)+1)
+	for _, r := range records {
+		batch = append(batch, &storage.WALRecord{TxnID: id, Type: r.Type, Data: r.Data})
+	}
+	batch = append(batch, &storage.WALRecord{TxnID: id, Type: storage.WALCommit})
+	if err := db.logAndApply(ctx, batch); err != nil {
+		return fmt.Errorf("commit prepared transaction %q: %w", xid, err)
+	}
+	// The commit must be durable before the prepared file goes.
+	if db.wal != nil {
+		if err := db.wal.Sync(); err != nil {
+			return fmt.Errorf("commit prepared transaction %q: %w", xid, err)
+		}
+	}
+	return db.forgetPrepared(xid)
+}
+
+// RollbackPrepared rolls back the transaction prepared under xid, discarding
+// its writes.
+func (db *DB) RollbackPrepared(xid string) error {
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+	db.preparedMu.Lock()
+	defer db.preparedMu.Unlock()
+	if _, ok := db.prepared[xid]; !ok {
+		return fmt.Errorf("%w: %q", ErrPreparedTxnNotFound, xid)
+	}
+	return db.forgetPrepared(xid)
+}
+
+// PreparedTransactions returns the IDs of the prepared transactions waiting
+// for their coordinator's decision, sorted.
+func (db *DB) PreparedTransactions() []string {
+	db.preparedMu.Lock()
+	defer db.preparedMu.Unlock()
+	xids := make([]string, 0, len(db.prepared))
+	for xid := range db.prepared {
+		xids = append(xids, xid)
+	}
+	sort.Strings(xids)
+	return xids
+}
+
+// forgetPrepared drops a committed or rolled back transaction. The caller
+// holds preparedMu.
+func (db *DB) forgetPrepared(xid string) error {
+	delete(db.prepared, xid)
+	db.catalog.ReleasePreparedRows(xid)
+	if dir := db.preparedDir(); dir != "" {
+		if err := storage.RemoveFileDurable(filepath.Join(dir, preparedFileName(xid))); err != nil {
+			return fmt.Errorf("remove prepared transaction %q: %w", xid, err)
+		}
+	}
+	return nil
+}
+
+// preparedDir returns the directory prepared transactions are saved in, or
+// "" when they are not saved: without a WAL a commit is not durable either.
+func (db *DB) preparedDir() string {
+	if db.wal == nil || db.path == ":memory:" {
+		return ""
+	}
+	return db.path + preparedDirSuffix
+}
+
+func preparedFileName(xid string) string {
+	return hex.EncodeToString([]byte(xid)) + preparedFileSuffix
+}
+
+// savePrepared writes xid's records to its file.
+func (db *DB) savePrepared(xid string, records []*storage.WALRecord) error {
+	dir := db.preparedDir()
+	if dir == "" {
+		return nil
+	}
+	var data []byte
+	for _, r := range records {
+		data = appendReplicatedRecord(data, r)
+	}
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+	if err := storage.WriteFileAtomic(filepath.Join(dir, preparedFileName(xid)), data, 0o600); err != nil {
+		return fmt.Errorf("save prepared transaction: %w", err)
+	}
+	return nil
+}
+
+// loadPrepared restores the prepared transactions saved before a restart,
+// holding their rows again.
+func (db *DB) loadPrepared() error {
+	dir := db.preparedDir()
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read prepared transactions: %w", err)
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), preparedFileSuffix)
+		if !ok || entry.IsDir() {
+			continue // e.g. a temporary file left by a crash while saving
+		}
+		xid, err := hex.DecodeString(name)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name())) // #nosec G304 - a file of the database's own .prepared directory.
+		if err != nil {
+			return fmt.Errorf("failed to read prepared transaction %q: %w", xid, err)
+		}
+		records, err := decodePreparedFile(data)
+		if err != nil {
+			return fmt.Errorf("prepared transaction %q: %w", xid, err)
+		}
+		if err := db.catalog.HoldPreparedRows(string(xid), records); err != nil {
+			return fmt.Errorf("prepared transaction %q: %w", xid, err)
+		}
+		if db.prepared == nil {
+			db.prepared = make(map[string][]*storage.WALRecord)
+		}
+		db.prepared[string(xid)] = records
+	}
+	return nil
+}
+
+func decodePreparedFile(data []byte) ([]*storage.WALRecord, error) {
+	if len(data) < 4 {
+		return nil, errors.New("truncated file")
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, errors.New("checksum mismatch")
+	}
+	return decodeReplicatedRecords(body)
+}
@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+func openPreparedTestDB(t *testing.T, path string) *DB {
+	t.Helper()
+	db, err := Open(path, &Options{CoreStorage: CoreStorage{WALEnabled: BoolPtr(true)}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return db
+}
+
+func countRows(t *testing.T, db *DB, sql string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(context.Background(), sql).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	return n
+}
+
+func TestPreparedTransactionCommitsAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xa.db")
+	ctx := context.Background()
+	db := openPreparedTestDB(t, path)
+	mustExec(t, db, "CREATE TABLE acct (id INTEGER PRIMARY KEY, balance INTEGER)")
+	mustExec(t, db, "INSERT INTO acct VALUES (1, 100), (2, 100)")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE acct SET balance = 50 WHERE id = 1"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO acct VALUES (3, 50)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := tx.Prepare("gtrid-1"); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	// Prepared writes are not visible, and their rows are held.
+	if n := countRows(t, db, "SELECT COUNT(*) FROM acct"); n != 2 {
+		t.Fatalf("rows after prepare = %d, want 2", n)
+	}
+	if _, err := db.Exec(ctx, "UPDATE acct SET balance = 0 WHERE id = 1"); !errors.Is(err, catalog.ErrRowPrepared) {
+		t.Fatalf("write to a held row = %v, want ErrRowPrepared", err)
+	}
+	mustExec(t, db, "UPDATE acct SET balance = 90 WHERE id = 2")
+	if got := db.PreparedTransactions(); !reflect.DeepEqual(got, []string{"gtrid-1"}) {
+		t.Fatalf("PreparedTransactions = %v", got)
+	}
+
+	// The coordinator's decision arrives after a restart.
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Commit through a closed database succeeded")
+	}
+	db = openPreparedTestDB(t, path)
+	defer db.Close()
+	if got := db.PreparedTransactions(); !reflect.DeepEqual(got, []string{"gtrid-1"}) {
+		t.Fatalf("PreparedTransactions after restart = %v", got)
+	}
+	if _, err := db.Exec(ctx, "DELETE FROM acct WHERE id = 3"); err != nil {
+		t.Fatalf("delete of a row the prepared transaction inserts: %v", err)
+	}
+	if _, err := db.Exec(ctx, "UPDATE acct SET balance = 0 WHERE id = 1"); !errors.Is(err, catalog.ErrRowPrepared) {
+		t.Fatalf("write to a held row after restart = %v, want ErrRowPrepared", err)
+	}
+	if err := db.CommitPrepared("gtrid-1"); err != nil {
+		t.Fatalf("CommitPrepared: %v", err)
+	}
+	if n := countRows(t, db, "SELECT SUM(balance) FROM acct"); n != 190 {
+		t.Fatalf("balance after commit = %d, want 190", n)
+	}
+	if got := db.PreparedTransactions(); len(got) != 0 {
+		t.Fatalf("PreparedTransactions after commit = %v", got)
+	}
+	mustExec(t, db, "UPDATE acct SET balance = 40 WHERE id = 1")
+	if err := db.CommitPrepared("gtrid-1"); !errors.Is(err, ErrPreparedTxnNotFound) {
+		t.Fatalf("second CommitPrepared = %v, want ErrPreparedTxnNotFound", err)
+	}
+
+	// The commit itself is durable.
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	db = openPreparedTestDB(t, path)
+	defer db.Close()
+	if n := countRows(t, db, "SELECT SUM(balance) FROM acct"); n != 180 {
+		t.Fatalf("balance after reopen = %d, want 180", n)
+	}
+	if got := db.PreparedTransactions(); len(got) != 0 {
+		t.Fatalf("PreparedTransactions after reopen = %v", got)
+	}
+}
+
+func TestPreparedTransactionRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xa.db")
+	ctx := context.Background()
+	db := openPreparedTestDB(t, path)
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 'a')")
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE t SET v = 'b' WHERE id = 1"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := tx.Prepare("x"); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if err := tx.Prepare("y"); err == nil {
+		t.Fatal("second Prepare succeeded")
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Commit after Rollback succeeded")
+	}
+	var v string
+	if err := db.QueryRow(ctx, "SELECT v FROM t WHERE id = 1").Scan(&v); err != nil || v != "a" {
+		t.Fatalf("v = %q, %v; want a", v, err)
+	}
+	mustExec(t, db, "UPDATE t SET v = 'c' WHERE id = 1")
+	if err := db.RollbackPrepared("x"); !errors.Is(err, ErrPreparedTxnNotFound) {
+		t.Fatalf("RollbackPrepared = %v, want ErrPreparedTxnNotFound", err)
+	}
+}
+
+func TestPrepareFailureRollsBack(t *testing.T) {
+	db := openPreparedTestDB(t, filepath.Join(t.TempDir(), "xa.db"))
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v INTEGER)")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 1)")
+
+	// A schema change cannot be prepared.
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "CREATE TABLE u (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := tx.Prepare("ddl"); !errors.Is(err, catalog.ErrNotPreparable) {
+		t.Fatalf("Prepare with DDL = %v, want ErrNotPreparable", err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Commit after a failed Prepare succeeded")
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO u VALUES (1)"); err == nil {
+		t.Fatal("table created by the rolled back transaction exists")
+	}
+
+	// Two prepared transactions cannot hold the same row, and an ID is
+	// used once.
+	tx1, _ := db.Begin(ctx)
+	if _, err := tx1.Exec(ctx, "UPDATE t SET v = 2 WHERE id = 1"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := tx1.Prepare("a"); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	tx2, _ := db.Begin(ctx)
+	if _, err := tx2.Exec(ctx, "UPDATE t SET v = 3 WHERE id = 1"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := tx2.Prepare("b"); !errors.Is(err, catalog.ErrRowPrepared) {
+		t.Fatalf("Prepare of a held row = %v, want ErrRowPrepared", err)
+	}
+	tx3, _ := db.Begin(ctx)
+	if err := tx3.Prepare("a"); err == nil {
+		t.Fatal("Prepare reused an ID")
+	}
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if n := countRows(t, db, "SELECT v FROM t WHERE id = 1"); n != 2 {
+		t.Fatalf("v = %d, want 2", n)
+	}
+}
+
+func TestPreparedTransactionInMemory(t *testing.T) {
+	db, err := Open(":memory:", nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+
+	tx, _ := db.Begin(ctx)
+	if _, err := tx.Exec(ctx, "INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := tx.Prepare("m"); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if err := db.CommitPrepared("m"); err != nil {
+		t.Fatalf("CommitPrepared: %v", err)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM t"); n != 1 {
+		t.Fatalf("rows = %d, want 1", n)
+	}
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// QueryLogger receives a record of each statement run through DB.Exec,
+// DB.Query, Tx.Exec and Tx.Query. Set it in Options.QueryLogger.
+//
+// LogQuery runs synchronously on the statement's goroutine after the
+// statement finished, so slow loggers slow every statement down; hand
+// entries to a channel or buffered writer when logging is expensive.
+type QueryLogger interface {
+	LogQuery(ctx context.Context, entry QueryLogEntry)
+}
+
+// QueryLoggerFunc adapts a function to the QueryLogger interface.
+type QueryLoggerFunc func(ctx context.Context, entry QueryLogEntry)
+
+// LogQuery calls f(ctx, entry).
+func (f QueryLoggerFunc) LogQuery(ctx context.Context, entry QueryLogEntry) {
+	f(ctx, entry)
+}
+
+// QueryLogEntry describes one finished statement.
+type QueryLogEntry struct {
+	Start time.Time
+	SQL   string
+	// Fingerprint is query.Fingerprint(SQL): statements that differ only
+	// in their values share it.
+	Fingerprint  string
+	Duration     time.Duration
+	RowsAffected int64 // Rows inserted, updated or deleted
+	RowsReturned int64 // Rows returned by a query
+	Err          error // Parse, planning or execution error
+}
+
+// logQuery passes a finished statement to Options.QueryLogger. Statements
+// under Options.QueryLogThreshold are skipped unless they failed.
+func (db *DB) logQuery(ctx context.Context, sql string, start time.Time, rowsAffected, rowsReturned int64, err error) {
+	logger := db.options.QueryLogger
+	if logger == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+	if err == nil && elapsed < db.options.QueryLogThreshold {
+		return
+	}
+	logger.LogQuery(ctx, QueryLogEntry{
+		Start:        start,
+		SQL:          sql,
+		Fingerprint:  query.Fingerprint(sql),
+		Duration:     elapsed,
+		RowsAffected: rowsAffected,
+		RowsReturned: rowsReturned,
+		Err:          err,
+	})
+}
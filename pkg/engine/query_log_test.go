@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryLogger(t *testing.T) {
+	var entries []QueryLogEntry
+	opts := durabilityTestOptions()
+	opts.QueryLogger = QueryLoggerFunc(func(_ context.Context, e QueryLogEntry) {
+		entries = append(entries, e)
+	})
+	db, err := Open(filepath.Join(t.TempDir(), "querylog.db"), opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "insert into t values (1, 'a'), (2, 'b')")
+	mustQuery(t, db, "SELECT name FROM t WHERE id IN (1, 2)")
+	if _, err := db.Exec(ctx, "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Fatal("insert into a missing table succeeded")
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO"); err == nil {
+		t.Fatal("malformed insert succeeded")
+	}
+
+	if len(entries) != 5 {
+		t.Fatalf("logged %d statements, want 5: %+v", len(entries), entries)
+	}
+	insert, sel := entries[1], entries[2]
+	if insert.SQL != "insert into t values (1, 'a'), (2, 'b')" || insert.RowsAffected != 2 ||
+		insert.Fingerprint != "INSERT INTO t VALUES (?), (?)" || insert.Duration <= 0 || insert.Start.IsZero() {
+		t.Errorf("insert entry = %+v", insert)
+	}
+	if sel.RowsReturned != 2 || sel.Fingerprint != "SELECT name FROM t WHERE id IN (?)" {
+		t.Errorf("select entry = %+v", sel)
+	}
+	if entries[3].Err == nil || entries[4].Err == nil {
+		t.Errorf("failed statements logged without errors: %+v, %+v", entries[3], entries[4])
+	}
+
+	// Above the threshold only failures get through.
+	db.options.QueryLogThreshold = time.Hour
+	entries = nil
+	mustQuery(t, db, "SELECT * FROM t")
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE missing SET x = 1"); err == nil {
+		t.Fatal("update of a missing table succeeded")
+	}
+	_ = tx.Rollback()
+	if len(entries) != 1 || entries[0].SQL != "UPDATE missing SET x = 1" {
+		t.Fatalf("entries over threshold = %+v, want only the failed UPDATE", entries)
+	}
+}
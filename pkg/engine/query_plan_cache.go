@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,6 +21,7 @@ const maxQueryPlanTopQueries = 100
 // QueryPlanCacheEntry represents a cached query plan
 type QueryPlanCacheEntry struct {
 	SQL          string
+	Fingerprint  string // query.Fingerprint(SQL)
 	ParsedStmt   query.Statement
 	HashKey      string // cached hash key for O(1) eviction lookup
 	CreatedAt    time.Time
@@ -248,6 +250,49 @@ func (c *QueryPlanCache) GetTopQueries(n int) []QueryPlanCacheEntry {
 	return topEntries
 }
 
+// QueryPlanFingerprintStats sums the cached plans of the statements sharing
+// a fingerprint.
+type QueryPlanFingerprintStats struct {
+	Fingerprint string
+	Entries     int    // Cached plans with the fingerprint
+	AccessCount uint64 // Accesses summed over those plans
+}
+
+// GetTopFingerprints groups the cached plans by fingerprint and returns the
+// n groups with the most accesses. A group with many entries is a statement
+// sent with inlined values instead of parameters, each variant taking its
+// own cache slot.
+func (c *QueryPlanCache) GetTopFingerprints(n int) []QueryPlanFingerprintStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if n <= 0 {
+		n = 10
+	}
+	if n > maxQueryPlanTopQueries {
+		n = maxQueryPlanTopQueries
+	}
+
+	index := make(map[string]int)
+	var groups []QueryPlanFingerprintStats
+	for elem := c.lruList.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*QueryPlanCacheEntry)
+		i, ok := index[entry.Fingerprint]
+		if !ok {
+			i = len(groups)
+			index[entry.Fingerprint] = i
+			groups = append(groups, QueryPlanFingerprintStats{Fingerprint: entry.Fingerprint})
+		}
+		groups[i].Entries++
+		groups[i].AccessCount += entry.AccessCount
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].AccessCount > groups[j].AccessCount })
+	if len(groups) > n {
+		groups = groups[:n]
+	}
+	return groups
+}
+
 // WarmCache pre-populates the cache with common queries
 func (c *QueryPlanCache) WarmCache(queries []string) error {
 	for _, sql := range queries {
@@ -285,6 +330,7 @@ func (c *QueryPlanCache) createEntry(sql string, stmt query.Statement) *QueryPla
 	now := time.Now()
 	return &QueryPlanCacheEntry{
 		SQL:          sql,
+		Fingerprint:  query.Fingerprint(sql),
 		ParsedStmt:   query.CloneStatement(stmt),
 		CreatedAt:    now,
 		LastAccessed: now,
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"context"
 	"testing"
 
 	"github.com/cobaltdb/cobaltdb/pkg/query"
@@ -366,3 +367,68 @@ func TestQueryPlanCache_AccessCount(t *testing.T) {
 		t.Errorf("Expected AccessCount 7, got %d", entry.AccessCount)
 	}
 }
+
+func TestQueryPlanCache_GetTopFingerprints(t *testing.T) {
+	cache := NewQueryPlanCache(1024*1024, 100)
+	for _, sql := range []string{
+		"SELECT * FROM test WHERE id = 1",
+		"SELECT * FROM test WHERE id = 2",
+		"SELECT * FROM test WHERE id = ?",
+		"DELETE FROM test",
+	} {
+		stmt, err := query.Parse(sql)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", sql, err)
+		}
+		if err := cache.Put(sql, nil, stmt); err != nil {
+			t.Fatalf("Put(%q): %v", sql, err)
+		}
+	}
+	cache.Get("DELETE FROM test", nil)
+
+	top := cache.GetTopFingerprints(10)
+	if len(top) != 2 {
+		t.Fatalf("GetTopFingerprints = %+v, want 2 groups", top)
+	}
+	if top[0].Fingerprint != "SELECT * FROM test WHERE id = ?" || top[0].Entries != 3 || top[0].AccessCount != 3 {
+		t.Errorf("top group = %+v", top[0])
+	}
+	if top[1].Fingerprint != "DELETE FROM test" || top[1].Entries != 1 || top[1].AccessCount != 2 {
+		t.Errorf("second group = %+v", top[1])
+	}
+}
+
+func TestPlanCacheSharesNormalizedSQLAndDropsOnDDL(t *testing.T) {
+	db, err := Open(":memory:", &Options{PlanCache: PlanCacheConfig{EnablePlanCache: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	mustExec(t, db, "INSERT INTO t VALUES (1, 'a  b')")
+	db.planCache.ResetStats()
+
+	for _, sql := range []string{
+		"SELECT v FROM t WHERE id = 1",
+		"SELECT  v\n  FROM t -- comment\n WHERE id = 1;",
+		"SELECT v /* x */ FROM t WHERE id = 1",
+	} {
+		var v string
+		if err := db.QueryRow(context.Background(), sql).Scan(&v); err != nil || v != "a  b" {
+			t.Fatalf("%q = %q, %v", sql, v, err)
+		}
+	}
+	stats := db.GetPlanCacheStats()
+	if stats.Hits < 2 {
+		t.Fatalf("plan cache hits = %d, want the respaced statements to hit", stats.Hits)
+	}
+
+	size := stats.Size
+	mustExec(t, db, "ALTER TABLE t ADD COLUMN w INTEGER")
+	if _, err := db.Query(context.Background(), "SELECT w FROM t"); err != nil {
+		t.Fatalf("query after ALTER: %v", err)
+	}
+	if stats = db.GetPlanCacheStats(); stats.Size >= size || stats.Invalidations == 0 {
+		t.Fatalf("after DDL: %d cached plans, %d invalidations; want the cache emptied", stats.Size, stats.Invalidations)
+	}
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/metrics"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// statementKind groups statements for QueryStats.
+type statementKind int
+
+const (
+	kindSelect statementKind = iota
+	kindInsert
+	kindUpdate
+	kindDelete
+	kindDDL
+	kindOther
+	numStatementKinds
+)
+
+// statementKindNames are the QueryStats.Latency keys and the type label of
+// the Prometheus statement metrics.
+var statementKindNames = [numStatementKinds]string{"select", "insert", "update", "delete", "ddl", "other"}
+
+func statementKindOf(stmt query.Statement) statementKind {
+	switch stmt.(type) {
+	case *query.SelectStmt, *query.SelectStmtWithCTE, *query.UnionStmt:
+		return kindSelect
+	case *query.InsertStmt:
+		return kindInsert
+	case *query.UpdateStmt:
+		return kindUpdate
+	case *query.DeleteStmt:
+		return kindDelete
+	}
+	if _, ok := ddlEventFor(stmt); ok {
+		return kindDDL
+	}
+	return kindOther
+}
+
+// queryCounters accumulates QueryStats without locking.
+type queryCounters struct {
+	errors      atomic.Uint64
+	rowsRead    atomic.Uint64
+	rowsWritten atomic.Uint64
+	latency     [numStatementKinds]metrics.LatencyHistogram
+}
+
+// QueryStats counts the statements run through Exec, Query and Tx since the
+// database was opened.
+type QueryStats struct {
+	Executed    uint64 `json:"executed"`     // Statements run, failed ones included
+	Errors      uint64 `json:"errors"`       // Statements that failed, parse errors included
+	RowsRead    uint64 `json:"rows_read"`    // Rows returned by queries
+	RowsWritten uint64 `json:"rows_written"` // Rows inserted, updated or deleted
+	// Latency per statement type: select, insert, update, delete, ddl and
+	// other.
+	Latency map[string]metrics.LatencySnapshot `json:"latency"`
+}
+
+// observeStatement records a statement that ran for elapsed.
+func (db *DB) observeStatement(stmt query.Statement, elapsed time.Duration, rows int64, err error) {
+	q := &db.queryCounters
+	kind := statementKindOf(stmt)
+	q.latency[kind].Observe(elapsed)
+	if err != nil {
+		q.errors.Add(1)
+		return
+	}
+	if rows <= 0 {
+		return
+	}
+	switch kind {
+	case kindSelect:
+		q.rowsRead.Add(uint64(rows))
+	case kindInsert, kindUpdate, kindDelete:
+		q.rowsWritten.Add(uint64(rows))
+	}
+}
+
+func (db *DB) queryStats() *QueryStats {
+	q := &db.queryCounters
+	stats := &QueryStats{
+		Errors:      q.errors.Load(),
+		RowsRead:    q.rowsRead.Load(),
+		RowsWritten: q.rowsWritten.Load(),
+		Latency:     make(map[string]metrics.LatencySnapshot, numStatementKinds),
+	}
+	for kind, name := range statementKindNames {
+		snap := q.latency[kind].Snapshot()
+		stats.Executed += snap.Count
+		stats.Latency[name] = snap
+	}
+	return stats
+}
+
+// WritePrometheus writes the statement, row, WAL and replication metrics in the
+// Prometheus text format. Buffer pool, transaction and runtime metrics come
+// from the process-wide exporter in pkg/metrics, which also calls this
+// method for the most recently opened DB.
+func (db *DB) WritePrometheus(w io.Writer) {
+	stats := db.queryStats()
+
+	fmt.Fprintf(w, "# HELP cobaltdb_statements_total Statements executed, by type\n")
+	fmt.Fprintf(w, "# TYPE cobaltdb_statements_total counter\n")
+	for _, name := range statementKindNames {
+		fmt.Fprintf(w, "cobaltdb_statements_total{type=%q} %d\n", name, stats.Latency[name].Count)
+	}
+
+	fmt.Fprintf(w, "# HELP cobaltdb_statement_errors_total Statements that failed\n")
+	fmt.Fprintf(w, "# TYPE cobaltdb_statement_errors_total counter\n")
+	fmt.Fprintf(w, "cobaltdb_statement_errors_total %d\n", stats.Errors)
+
+	fmt.Fprintf(w, "# HELP cobaltdb_rows_read_total Rows returned by queries\n")
+	fmt.Fprintf(w, "# TYPE cobaltdb_rows_read_total counter\n")
+	fmt.Fprintf(w, "cobaltdb_rows_read_total %d\n", stats.RowsRead)
+
+	fmt.Fprintf(w, "# HELP cobaltdb_rows_written_total Rows inserted, updated or deleted\n")
+	fmt.Fprintf(w, "# TYPE cobaltdb_rows_written_total counter\n")
+	fmt.Fprintf(w, "cobaltdb_rows_written_total %d\n", stats.RowsWritten)
+
+	fmt.Fprintf(w, "# HELP cobaltdb_statement_duration_seconds Statement latency, by type\n")
+	fmt.Fprintf(w, "# TYPE cobaltdb_statement_duration_seconds histogram\n")
+	for _, name := range statementKindNames {
+		metrics.WritePrometheusHistogram(w, "cobaltdb_statement_duration_seconds", fmt.Sprintf("type=%q", name), stats.Latency[name])
+	}
+
+	if db.wal != nil {
+		fmt.Fprintf(w, "# HELP cobaltdb_wal_bytes_written_total Bytes written to the WAL\n")
+		fmt.Fprintf(w, "# TYPE cobaltdb_wal_bytes_written_total counter\n")
+		fmt.Fprintf(w, "cobaltdb_wal_bytes_written_total %d\n", db.wal.Stats().BytesWritten)
+	}
+
+	if repl := db.ReplicationStats(); repl != nil {
+		fmt.Fprintf(w, "# HELP cobaltdb_replication_lag_seconds Time between a commit on the primary and its apply on this replica\n")
+		fmt.Fprintf(w, "# TYPE cobaltdb_replication_lag_seconds gauge\n")
+		fmt.Fprintf(w, "cobaltdb_replication_lag_seconds %g\n", repl.Lag.Seconds())
+
+		fmt.Fprintf(w, "# HELP cobaltdb_replication_applied_lsn Primary LSN of the last entry this replica applied\n")
+		fmt.Fprintf(w, "# TYPE cobaltdb_replication_applied_lsn gauge\n")
+		fmt.Fprintf(w, "cobaltdb_replication_applied_lsn %d\n", repl.LastAppliedLSN)
+
+		fmt.Fprintf(w, "# HELP cobaltdb_replication_replicas Replicas connected to this primary\n")
+		fmt.Fprintf(w, "# TYPE cobaltdb_replication_replicas gauge\n")
+		fmt.Fprintf(w, "cobaltdb_replication_replicas %d\n", repl.Replicas)
+
+		fmt.Fprintf(w, "# HELP cobaltdb_replication_shipped_bytes_total Bytes this primary sent to its replicas\n")
+		fmt.Fprintf(w, "# TYPE cobaltdb_replication_shipped_bytes_total counter\n")
+		fmt.Fprintf(w, "cobaltdb_replication_shipped_bytes_total %d\n", repl.ShippedBytes)
+	}
+}
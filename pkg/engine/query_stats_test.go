@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueryStats(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "stats.db"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	mustExec(t, db, "INSERT INTO t (v) VALUES ('a'), ('b'), ('c')")
+	mustExec(t, db, "UPDATE t SET v = 'z' WHERE id > 1")
+	mustQuery(t, db, "SELECT * FROM t")
+	if _, err := db.Exec(ctx, "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Fatal("insert into a missing table succeeded")
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM t WHERE id = 1"); err != nil {
+		t.Fatalf("tx delete: %v", err)
+	}
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.ActiveTransactions != 1 {
+		t.Errorf("ActiveTransactions = %d, want 1", stats.ActiveTransactions)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	stats, err = db.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	q := stats.Queries
+	if q.Executed != 6 || q.Errors != 1 || q.RowsRead != 3 || q.RowsWritten != 6 {
+		t.Fatalf("query stats = %+v, want 6 executed, 1 error, 3 rows read, 6 written", q)
+	}
+	for kind, want := range map[string]uint64{"ddl": 1, "insert": 2, "update": 1, "delete": 1, "select": 1} {
+		if got := q.Latency[kind].Count; got != want {
+			t.Errorf("%s count = %d, want %d", kind, got, want)
+		}
+	}
+	if stats.ActiveTransactions != 0 {
+		t.Errorf("ActiveTransactions after commit = %d, want 0", stats.ActiveTransactions)
+	}
+
+	var b strings.Builder
+	db.WritePrometheus(&b)
+	out := b.String()
+	for _, want := range []string{
+		`cobaltdb_statements_total{type="insert"} 2`,
+		`cobaltdb_statement_errors_total 1`,
+		`cobaltdb_rows_read_total 3`,
+		`cobaltdb_rows_written_total 6`,
+		`cobaltdb_statement_duration_seconds_count{type="select"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("Prometheus output lacks %q", want)
+		}
+	}
+	if !strings.Contains(out, "cobaltdb_wal_bytes_written_total ") {
+		t.Error("Prometheus output lacks WAL bytes")
+	}
+	if stats.Commit == nil || stats.Commit.BytesWritten == 0 {
+		t.Errorf("WAL stats = %+v, want bytes written", stats.Commit)
+	}
+}
@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// setRandomSeed applies SET seed = <value>. An integer seed makes RANDOM()
+// return the same sequence on every run; DEFAULT or NULL restores
+// cryptographically random values.
+func (db *DB) setRandomSeed(value string) error {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	switch strings.ToUpper(value) {
+	case "DEFAULT", "NULL":
+		db.catalog.ClearRandomSeed()
+		return nil
+	}
+	seed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid seed %q: must be an integer, DEFAULT, or NULL", value)
+	}
+	db.catalog.SetRandomSeed(seed)
+	return nil
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func randomSequence(t *testing.T, db *DB) []float64 {
+	t.Helper()
+	rows, err := db.Query(context.Background(), "SELECT id, RANDOM() FROM nums ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+	var out []float64
+	for rows.Next() {
+		var id int64
+		var v float64
+		if err := rows.Scan(&id, &v); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestSetSeedMakesRandomReproducible(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE nums (id INTEGER PRIMARY KEY)")
+	for i := 1; i <= 5; i++ {
+		if _, err := db.Exec(ctx, "INSERT INTO nums VALUES (?)", i); err != nil {
+			t.Fatalf("INSERT failed: %v", err)
+		}
+	}
+
+	mustExec(t, db, "SET seed = 42")
+	first := randomSequence(t, db)
+	mustExec(t, db, "SET seed = 42")
+	second := randomSequence(t, db)
+	if len(first) != 5 || len(second) != 5 {
+		t.Fatalf("got %d and %d values, want 5", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("value %d differs after reseeding: %v vs %v", i, first[i], second[i])
+		}
+	}
+	if first[0] == first[1] {
+		t.Fatalf("seeded RANDOM() repeated a value: %v", first)
+	}
+	if third := randomSequence(t, db); third[0] == second[0] {
+		t.Fatal("RANDOM() sequence did not advance without reseeding")
+	}
+
+	rows, err := db.Query(ctx, "EXPLAIN SELECT RANDOM() FROM nums")
+	if err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	found := false
+	for rows.Next() {
+		var id, parent, rowCount interface{}
+		var op, detail, cost string
+		if err := rows.Scan(&id, &parent, &op, &detail, &cost, &rowCount); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if op == "Random Seed" && detail == "seed=42" {
+			found = true
+		}
+	}
+	rows.Close()
+	if !found {
+		t.Fatal("EXPLAIN output does not record the seed")
+	}
+
+	mustExec(t, db, "SET seed = DEFAULT")
+	if _, ok := db.catalog.RandomSeed(); ok {
+		t.Fatal("SET seed = DEFAULT did not clear the seed")
+	}
+	if _, err := db.Exec(ctx, "SET seed = abc"); err == nil {
+		t.Fatal("SET seed = abc should fail")
+	}
+}
+
+func TestSeededRandomBlobAndDefaults(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE tokens (id INTEGER PRIMARY KEY, tok BLOB DEFAULT (RANDOMBLOB(8)), r REAL DEFAULT (ABS(RANDOM()) % 100), p REAL DEFAULT (POWER(2, 10)))")
+
+	insert := func() [][]interface{} {
+		mustExec(t, db, "DELETE FROM tokens")
+		mustExec(t, db, "INSERT INTO tokens (id) VALUES (1), (2)")
+		rows, err := db.Query(ctx, "SELECT tok, r, p, HEX(RANDOMBLOB(0)) FROM tokens ORDER BY id")
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		defer rows.Close()
+		var out [][]interface{}
+		for rows.Next() {
+			var tok []byte
+			var rv, pv interface{}
+			var h string
+			if err := rows.Scan(&tok, &rv, &pv, &h); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			r, p := asFloat(rv), asFloat(pv)
+			if len(tok) != 8 || r < 0 || r >= 100 || p != 1024 || len(h) != 2 {
+				t.Fatalf("row = (%x, %v, %v, %q)", tok, r, p, h)
+			}
+			out = append(out, []interface{}{string(tok), r, h})
+		}
+		return out
+	}
+
+	mustExec(t, db, "SET seed = 7")
+	first := insert()
+	mustExec(t, db, "SET seed = 7")
+	second := insert()
+	if len(first) != 2 || fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("seeded defaults differ: %v vs %v", first, second)
+	}
+	if first[0][0] == first[1][0] {
+		t.Fatal("RANDOMBLOB default repeated a value")
+	}
+
+	mustExec(t, db, "SET seed = DEFAULT")
+	if got := len(insert()); got != 2 {
+		t.Fatalf("unseeded insert returned %d rows", got)
+	}
+	var n int64
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM tokens WHERE LENGTH(HEX(RANDOMBLOB(4))) = 8 AND SQRT(id) >= 1 AND SIGN(id) = 1").Scan(&n); err != nil || n != 2 {
+		t.Fatalf("RANDOMBLOB in WHERE matched %d rows, err %v", n, err)
+	}
+}
+
+func asFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRegexpOperatorInWhere(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT, code INTEGER)")
+	mustExec(t, db, "INSERT INTO people VALUES (1, 'alice', 123), (2, 'bob', 456), (3, NULL, 789), (4, '', 10)")
+
+	tests := []struct {
+		sql  string
+		args []interface{}
+		want []int64
+	}{
+		{"name REGEXP '^a'", nil, []int64{1}},
+		{"name NOT REGEXP '^a'", nil, []int64{2, 4}},
+		{"name RLIKE 'b+'", nil, []int64{2}},
+		{"name REGEXP '^$'", nil, []int64{4}},
+		{"code REGEXP '^[47]'", nil, []int64{2, 3}},
+		{"name REGEXP ?", []interface{}{"^(alice|bob)$"}, []int64{1, 2}},
+		{"REGEXP_LIKE(name, 'ALICE|BOB', 'i')", nil, []int64{1, 2}},
+	}
+	for _, tt := range tests {
+		sql := "SELECT id FROM people WHERE " + tt.sql + " ORDER BY id"
+		rows, err := db.Query(ctx, sql, tt.args...)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		var got []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			got = append(got, id)
+		}
+		rows.Close()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", sql, got, tt.want)
+		}
+	}
+
+	if _, err := db.Query(ctx, "SELECT id FROM people WHERE name REGEXP '(a'"); err == nil {
+		t.Fatal("an invalid REGEXP pattern matched silently")
+	}
+}
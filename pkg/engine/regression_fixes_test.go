@@ -227,6 +227,29 @@ func openRegressionDB(t *testing.T) *DB {
 	return db
 }
 
+// openTestDB opens an in-memory database with opts layered over the test
+// defaults, runs the setup statements and closes the database when the test
+// ends. A nil opts uses the defaults alone.
+func openTestDB(t *testing.T, opts *Options, setup ...string) *DB {
+	t.Helper()
+	if opts == nil {
+		opts = &Options{}
+	}
+	opts.CoreStorage.InMemory = true
+	if opts.CoreStorage.CacheSize == 0 {
+		opts.CoreStorage.CacheSize = 1024
+	}
+	db, err := Open(":memory:", opts)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, sql := range setup {
+		mustExec(t, db, sql)
+	}
+	return db
+}
+
 func mustExec(t *testing.T, db *DB, sql string) {
 	t.Helper()
 	if _, err := db.Exec(context.Background(), sql); err != nil {
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRekeyRotatesEncryptionKey(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "rekey.db")
+	oldKey := []byte("0123456789abcdef0123456789abcdef")
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+
+	db, err := Open(dbPath, &Options{Security: Security{EncryptionKey: oldKey}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := db.Exec(ctx, "CREATE TABLE secrets (id INTEGER PRIMARY KEY, val TEXT)"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO secrets VALUES (1, 'before')"); err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	if err := db.Rekey(ctx, newKey); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	if _, err := os.Stat(dbPath + ".rekey"); !os.IsNotExist(err) {
+		t.Fatalf("rekey journal should be removed after rekey, stat error = %v", err)
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO secrets VALUES (2, 'after')"); err != nil {
+		t.Fatalf("INSERT after rekey failed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if db, err := Open(dbPath, &Options{Security: Security{EncryptionKey: oldKey}}); err == nil {
+		db.Close()
+		t.Fatal("expected open with the old key to fail after rekey")
+	}
+
+	db, err = Open(dbPath, &Options{Security: Security{EncryptionKey: newKey}})
+	if err != nil {
+		t.Fatalf("reopen with new key failed: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM secrets").Scan(&count); err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("row count = %d, want 2", count)
+	}
+}
+
+func TestRekeyRequiresEncryptedDatabase(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Rekey(context.Background(), []byte("key")); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("Rekey error = %v, want ErrNotEncrypted", err)
+	}
+}
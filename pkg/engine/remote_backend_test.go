@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenOverHTTP(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(filepath.Join(dir, "app.db"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	mustExec(t, db, "CREATE TABLE cities (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "CREATE INDEX idx_cities_name ON cities (name)")
+	mustExec(t, db, "INSERT INTO cities VALUES (1, 'Oslo'), (2, 'Lima'), (3, 'Pune')")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	remote, err := Open(srv.URL+"/app.db", durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open over HTTP: %v", err)
+	}
+	rows := queryRows(t, remote, "SELECT id FROM cities WHERE name = 'Lima'")
+	if fmt.Sprint(rows) != "[[2]]" {
+		t.Fatalf("rows = %v", rows)
+	}
+	if _, err := remote.Exec(context.Background(), "INSERT INTO cities VALUES (4, 'Baku')"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("INSERT err = %v, want ErrReadOnly", err)
+	}
+	if _, err := remote.Query(context.Background(), "DELETE FROM cities RETURNING id"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("DELETE RETURNING err = %v, want ErrReadOnly", err)
+	}
+	if err := remote.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
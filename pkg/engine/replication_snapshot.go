@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -19,11 +21,33 @@ func (db *DB) configureReplicationCallbacks() {
 		return
 	}
 
+	db.replicationCtx, db.cancelReplication = context.WithCancel(context.Background())
+	db.replicationMgr.OnApply = db.applyReplicatedEntry
 	db.replicationMgr.OnSnapshot = db.createReplicationSnapshot
 	db.replicationMgr.OnApplySnapshot = db.applyReplicationSnapshot
+	db.replicationMgr.OnLag = func(replica string, lag time.Duration) {
+		db.emitEvent(Event{Kind: EventReplicationLag, Replica: replica, Lag: lag})
+	}
+	db.startWALShipping()
 }
 
 func (db *DB) createReplicationSnapshot() (data []byte, lsn uint64, err error) {
+	return db.takeSnapshot(nil)
+}
+
+// takeSnapshot returns the database file and the WAL LSN it reflects, or,
+// when mark is set, what mark returns with writes held off.
+func (db *DB) takeSnapshot(mark func() uint64) (data []byte, lsn uint64, err error) {
+	// Writes log and ship their commit before applying it, so hold them off
+	// while the snapshot is taken: every commit at or below the returned LSN
+	// must be in the data.
+	switch err := db.writes.close(context.Background()); {
+	case err == nil:
+		defer db.writes.open()
+	case !errors.Is(err, ErrAlreadyFrozen):
+		return nil, 0, err
+	}
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -58,6 +82,10 @@ func (db *DB) createReplicationSnapshot() (data []byte, lsn uint64, err error) {
 		return nil, 0, fmt.Errorf("failed to sync snapshot: %w", err)
 	}
 
+	if mark != nil {
+		lsn = mark()
+	}
+
 	size := db.backend.Size()
 	if err := validateReplicationSnapshotSize(size); err != nil {
 		return nil, 0, err
@@ -157,6 +185,7 @@ func (db *DB) applyReplicationSnapshot(data []byte, lsn uint64) error {
 	if err := db.appendSnapshotCheckpointLocked(lsn); err != nil {
 		return err
 	}
+	db.replicaAppliedLSN.Store(lsn)
 
 	return nil
 }
@@ -201,13 +230,9 @@ func (db *DB) resetWALForSnapshotLocked() error {
 	if encBackend, ok := db.backend.(*storage.EncryptedBackend); ok {
 		wal.SetEncryptionCipher(encBackend.GetCipher())
 	}
-	switch db.options.CoreStorage.SyncMode {
-	case SyncNormal:
-		wal.EnableGroupCommit(0, 5*time.Millisecond)
-	case SyncOff:
-		wal.EnableGroupCommit(0, 0)
-	}
+	db.configureGroupCommit(wal)
 	db.wal = wal
+	db.startWALShipping()
 	return nil
 }
 
@@ -239,6 +264,7 @@ func (db *DB) reloadSnapshotStateLocked() error {
 	db.rootTree = rootTree
 	db.catalog = catalog.New(db.rootTree, db.pool, db.wal)
 	db.catalog.SetParallelOptions(db.options.ParallelQuery.Workers, db.options.ParallelQuery.Threshold)
+	db.catalog.SetUnorderedScans(db.options.ParallelQuery.UnorderedScans)
 
 	fdwRegistry := fdw.NewRegistry()
 	fdwRegistry.Register("csv", func() fdw.ForeignDataWrapper { return &fdw.CSVWrapper{} })
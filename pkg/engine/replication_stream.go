@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/replication"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// ErrReplicaReadOnly is returned for a write to a replica: replicas only
+// apply what their primary ships them.
+var ErrReplicaReadOnly = fmt.Errorf("%w: replica", ErrReadOnly)
+
+// A primary streams its WAL to replicas one committed transaction at a
+// time. walShipper sees every record the WAL appends, holds a transaction's
+// records until its commit record and then ships them as one replication
+// entry, with the commit's LSN. Rolled-back transactions are never shipped.
+// Sequence records ship at once, as recovery applies them whether or not
+// their transaction commits. Page images and checkpoints are physical and
+// stay local.
+//
+// Shipping happens when the commit record is appended, before it is synced,
+// so a replica can briefly be ahead of a primary that then crashes; this is
+// asynchronous replication.
+//
+// With a consensus log attached the shipper proposes the same entries to
+// the log instead, while this member leads.
+type walShipper struct {
+	active  func() bool                   // whether this node ships
+	send    func(lsn uint64, data []byte) // ships one entry
+	pending map[uint64][]byte             // encoded records by transaction
+}
+
+// startWALShipping makes the WAL feed the replication manager or the
+// consensus log. It is called whenever a new WAL is opened; a shipper is
+// only needed with a WAL, so an in-memory primary has nothing to stream.
+func (db *DB) startWALShipping() {
+	if db.wal == nil {
+		return
+	}
+	s := &walShipper{pending: make(map[uint64][]byte)}
+	switch {
+	case db.consensus != nil:
+		s.active = db.consensus.IsLeader
+		s.send = db.proposeCommitted
+	case db.replicationMgr != nil:
+		mgr := db.replicationMgr
+		s.active = func() bool { return mgr.Role() == replication.RoleMaster }
+		s.send = func(lsn uint64, data []byte) {
+			if err := mgr.ReplicateWALEntryAt(lsn, data); err != nil {
+				db.noteBackgroundError("replication", "", err)
+			}
+		}
+	default:
+		return
+	}
+	db.wal.SetAppendHook(s.ship)
+}
+
+// ship is the WAL append hook. The WAL serialises calls, so pending needs
+// no lock.
+func (s *walShipper) ship(records []*storage.WALRecord) {
+	if !s.active() {
+		clear(s.pending)
+		return
+	}
+	for _, r := range records {
+		switch r.Type {
+		case storage.WALCheckpoint, storage.WALPageImage:
+		case storage.WALRollback:
+			delete(s.pending, r.TxnID)
+		case storage.WALSequence:
+			s.send(r.LSN, appendReplicatedRecord(nil, r))
+		case storage.WALCommit, storage.WALUpdateCommit:
+			data, ok := s.pending[r.TxnID]
+			delete(s.pending, r.TxnID)
+			if !ok && r.Type == storage.WALCommit {
+				continue // nothing logical to ship, e.g. a page image group
+			}
+			s.send(r.LSN, appendReplicatedRecord(data, r))
+		default:
+			s.pending[r.TxnID] = appendReplicatedRecord(s.pending[r.TxnID], r)
+		}
+	}
+}
+
+// Replication entries carry WAL records as
+// [TxnID:8][Type:1][Length:4][Data:N], repeated.
+const replicatedRecordHeaderSize = 13
+
+func appendReplicatedRecord(buf []byte, r *storage.WALRecord) []byte {
+	buf = binary.LittleEndian.AppendUint64(buf, r.TxnID)
+	buf = append(buf, byte(r.Type))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(r.Data))) // #nosec G115 -- WAL record data is limited to 64 KiB
+	return append(buf, r.Data...)
+}
+
+func decodeReplicatedRecords(data []byte) ([]*storage.WALRecord, error) {
+	var records []*storage.WALRecord
+	for len(data) > 0 {
+		if len(data) < replicatedRecordHeaderSize {
+			return nil, errors.New("truncated replicated WAL record")
+		}
+		n := binary.LittleEndian.Uint32(data[9:13])
+		if uint64(len(data)-replicatedRecordHeaderSize) < uint64(n) {
+			return nil, errors.New("truncated replicated WAL record")
+		}
+		end := replicatedRecordHeaderSize + int(n)
+		records = append(records, &storage.WALRecord{
+			TxnID: binary.LittleEndian.Uint64(data[0:8]),
+			Type:  storage.WALRecordType(data[8]),
+			Data:  data[replicatedRecordHeaderSize:end:end],
+		})
+		data = data[end:]
+	}
+	return records, nil
+}
+
+// applyReplicatedEntry is the replication manager's OnApply on a replica. It
+// logs the primary's records to the replica's own WAL, so they survive a
+// restart, and applies them to the catalog.
+func (db *DB) applyReplicatedEntry(entry *replication.WALEntry) error {
+	if err := db.applyReplicatedData(entry.LSN, entry.Data); err != nil {
+		return err
+	}
+	db.replicaAppliedLSN.Store(entry.LSN)
+	db.replicaLag.Store(max(int64(time.Since(entry.Timestamp)), 0))
+	return nil
+}
+
+// applyReplicatedData applies one shipped entry, lsn naming it in errors.
+func (db *DB) applyReplicatedData(lsn uint64, data []byte) error {
+	records, err := decodeReplicatedRecords(data)
+	if err != nil {
+		return fmt.Errorf("replication entry %d: %w", lsn, err)
+	}
+	if err := db.logAndApply(db.replicationCtx, records); err != nil {
+		return fmt.Errorf("replication entry %d: %w", lsn, err)
+	}
+	return nil
+}
+
+// logAndApply logs records, a whole transaction, to the WAL and applies them
+// to the catalog, as recovery would replay them.
+func (db *DB) logAndApply(ctx context.Context, records []*storage.WALRecord) error {
+	_, exitGate, err := db.writes.enter(ctx)
+	if err != nil {
+		return err
+	}
+	defer exitGate()
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.closed.Load() {
+		return ErrDatabaseClosed
+	}
+
+	if db.wal != nil {
+		if err := db.wal.AppendBatch(records); err != nil {
+			return fmt.Errorf("failed to log: %w", err)
+		}
+	}
+	ops := make([]storage.WALReplayOp, len(records))
+	schema := false
+	for i, r := range records {
+		ops[i] = storage.WALReplayOp{TxnID: r.TxnID, Type: r.Type, Data: r.Data}
+		schema = schema || storage.IsSchemaWALRecordType(r.Type)
+	}
+	if err := db.catalog.ApplyReplicatedWALOps(ops); err != nil {
+		return err
+	}
+	if schema {
+		if db.planCache != nil {
+			db.planCache.Clear()
+		}
+		if err := db.persistSchema(); err != nil {
+			return fmt.Errorf("failed to persist schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkWritable returns the error a write gets on a database that refuses
+// writes: one opened read-only, a replica, a fenced primary, or a cluster
+// member that does not lead.
+func (db *DB) checkWritable() error {
+	if db.readOnly {
+		return ErrReadOnly
+	}
+	if db.consensus != nil && !db.consensus.IsLeader() {
+		if addr := db.consensus.Leader(); addr != "" {
+			return fmt.Errorf("%w; the leader is at %s", ErrNotLeader, addr)
+		}
+		return ErrNotLeader
+	}
+	if mgr := db.replicationMgr; mgr != nil {
+		if mgr.Role() == replication.RoleSlave {
+			return ErrReplicaReadOnly
+		}
+		if mgr.IsFenced() {
+			return replication.ErrPrimaryFenced
+		}
+	}
+	return nil
+}
+
+// ReplicationStats describes this node's part in streaming replication.
+type ReplicationStats struct {
+	// Role is "master", "slave" or "standalone".
+	Role string
+	// LastAppliedLSN is the primary's LSN of the last entry a replica
+	// applied.
+	LastAppliedLSN uint64
+	// Lag is how long before a replica applied its last entry the primary
+	// committed it.
+	Lag time.Duration
+	// Replicas is the number of replicas connected to a primary.
+	Replicas int
+	// ShippedBytes counts the bytes a primary sent to its replicas.
+	ShippedBytes uint64
+}
+
+// ReplicationStats returns replication figures, or nil when replication is
+// not configured.
+func (db *DB) ReplicationStats() *ReplicationStats {
+	mgr := db.replicationMgr
+	if mgr == nil {
+		return nil
+	}
+	metrics := mgr.GetMetrics()
+	stats := &ReplicationStats{
+		Role:           "standalone",
+		LastAppliedLSN: db.replicaAppliedLSN.Load(),
+		Lag:            time.Duration(db.replicaLag.Load()),
+		Replicas:       int(metrics.ActiveSlaves),
+		ShippedBytes:   metrics.ReplicatedBytes,
+	}
+	switch mgr.Role() {
+	case replication.RoleMaster:
+		stats.Role = "master"
+	case replication.RoleSlave:
+		stats.Role = "slave"
+	}
+	return stats
+}
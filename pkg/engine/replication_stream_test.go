@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// waitForRows polls db until sql returns want, as fmt.Sprint formats it.
+func waitForRows(t *testing.T, db *DB, sql, want string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var got string
+		rows, err := db.Query(context.Background(), sql)
+		if err == nil {
+			var out [][]interface{}
+			for rows.Next() {
+				vals := make([]interface{}, len(rows.Columns()))
+				ptrs := make([]interface{}, len(vals))
+				for i := range vals {
+					ptrs[i] = &vals[i]
+				}
+				if err = rows.Scan(ptrs...); err != nil {
+					break
+				}
+				out = append(out, vals)
+			}
+			rows.Close()
+			got = fmt.Sprint(out)
+		}
+		if err == nil && got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s = %s (err %v), want %s", sql, got, err, want)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestStreamingReplicationToReadReplica(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	dir := t.TempDir()
+	primaryOpts := durabilityTestOptions()
+	primaryOpts.Replication = ReplicationConfig{Role: "master", ListenAddr: addr}
+	primary, err := Open(filepath.Join(dir, "primary.db"), primaryOpts)
+	if err != nil {
+		t.Fatalf("open primary: %v", err)
+	}
+	defer primary.Close()
+	mustExec(t, primary, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, primary, "CREATE INDEX idx_users_name ON users (name)")
+	mustExec(t, primary, "INSERT INTO users VALUES (1, 'ann')")
+
+	// The replica starts from a snapshot, then follows the stream.
+	replicaOpts := durabilityTestOptions()
+	replicaOpts.Replication = ReplicationConfig{Role: "slave", MasterAddr: addr}
+	replica, err := Open(filepath.Join(dir, "replica.db"), replicaOpts)
+	if err != nil {
+		t.Fatalf("open replica: %v", err)
+	}
+	defer replica.Close()
+	waitForRows(t, replica, "SELECT id, name FROM users", "[[1 ann]]")
+
+	mustExec(t, primary, "INSERT INTO users VALUES (2, 'bob')")
+	mustExec(t, primary, "UPDATE users SET name = 'anna' WHERE id = 1")
+	mustExec(t, primary, "BEGIN")
+	mustExec(t, primary, "INSERT INTO users VALUES (3, 'cy')")
+	mustExec(t, primary, "ROLLBACK")
+	mustExec(t, primary, "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+	mustExec(t, primary, "INSERT INTO notes VALUES (1, 'hi')")
+	mustExec(t, primary, "DELETE FROM users WHERE id = 2")
+
+	waitForRows(t, replica, "SELECT id, name FROM users", "[[1 anna]]")
+	waitForRows(t, replica, "SELECT body FROM notes", "[[hi]]")
+	// Index entries follow the rows.
+	waitForRows(t, replica, "SELECT id FROM users WHERE name = 'anna'", "[[1]]")
+	waitForRows(t, replica, "SELECT id FROM users WHERE name = 'ann'", "[]")
+
+	if _, err := replica.Exec(context.Background(), "INSERT INTO users VALUES (9, 'zed')"); !errors.Is(err, ErrReplicaReadOnly) || !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("write to replica: err = %v, want ErrReplicaReadOnly", err)
+	}
+
+	stats := replica.ReplicationStats()
+	if stats == nil || stats.Role != "slave" || stats.LastAppliedLSN == 0 {
+		t.Fatalf("replica stats = %+v", stats)
+	}
+	var out bytes.Buffer
+	replica.WritePrometheus(&out)
+	for _, metric := range []string{"cobaltdb_replication_lag_seconds ", "cobaltdb_replication_applied_lsn "} {
+		if !strings.Contains(out.String(), metric) {
+			t.Fatalf("metrics lack %q:\n%s", metric, out.String())
+		}
+	}
+	if s := primary.ReplicationStats(); s == nil || s.Role != "master" || s.Replicas != 1 {
+		t.Fatalf("primary stats = %+v", s)
+	}
+}
+
+func TestReplicatedRecordEncoding(t *testing.T) {
+	records := []*storage.WALRecord{
+		{TxnID: 7, Type: storage.WALInsert, Data: []byte("users:1\x00row")},
+		{TxnID: 7, Type: storage.WALCommit},
+	}
+	var buf []byte
+	for _, r := range records {
+		buf = appendReplicatedRecord(buf, r)
+	}
+	got, err := decodeReplicatedRecords(buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 2 || got[0].TxnID != 7 || got[0].Type != storage.WALInsert ||
+		string(got[0].Data) != "users:1\x00row" || got[1].Type != storage.WALCommit || len(got[1].Data) != 0 {
+		t.Fatalf("decoded %+v %+v", got[0], got[1])
+	}
+	if _, err := decodeReplicatedRecords(buf[:len(buf)-1]); err == nil {
+		t.Fatal("decoding a truncated entry succeeded")
+	}
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// rowStreamBuffer is how many rows a cursor's statement runs ahead of the
+// reader before it waits.
+const rowStreamBuffer = 256
+
+// errRowsClosed stops the statement of a cursor whose rows were closed
+// before the last one was read.
+var errRowsClosed = errors.New("rows closed")
+
+// QueryCursor runs a query whose rows are read as the statement produces
+// them, for callers such as a network server that page a large result out to
+// a client. A plain SELECT hands each row over as the table is scanned, so
+// the result is never held in memory as a whole; other queries are run as by
+// Query and their rows handed over once collected. The statement runs on its
+// own goroutine until its last row is read or the rows are closed, holding a
+// connection slot and bounded by the statement timeout meanwhile, so the rows
+// must be closed. An error the statement hits after its first row is
+// reported by Rows.Err. Within a transaction opened by BEGIN on the calling
+// goroutine, the query runs as by Query: the transaction belongs to that
+// goroutine.
+func (db *DB) QueryCursor(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	if db.InConnTransaction() {
+		return db.Query(ctx, sql, args...)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	stream := &rowStream{
+		ready: make(chan struct{}),
+		rows:  make(chan []interface{}, rowStreamBuffer),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		_, err := db.runQuery(ctx, sql, args, stream)
+		stream.finish(err)
+	}()
+	<-stream.ready
+	if !stream.started {
+		// The statement ended before it had rows to hand over.
+		if stream.err != nil {
+			return nil, stream.err
+		}
+		return &Rows{}, nil
+	}
+	return &Rows{columns: stream.columns, stream: stream}, nil
+}
+
+// rowStream carries the rows of a QueryCursor statement from the goroutine
+// running it to the Rows reading them.
+type rowStream struct {
+	ready     chan struct{} // closed once columns is set or the statement ends
+	readyOnce sync.Once
+	started   bool // columns was set; read after ready
+	columns   []string
+	rows      chan []interface{} // closed when the statement ends
+	err       error              // the statement's error; read after rows is closed
+	done      chan struct{}      // closed when the reader closes the rows
+	closeOnce sync.Once
+}
+
+// setColumns hands the reader the columns, which arrive before any row.
+func (s *rowStream) setColumns(columns []string) {
+	s.readyOnce.Do(func() {
+		s.started, s.columns = true, columns
+		close(s.ready)
+	})
+}
+
+// send hands row to the reader, waiting while rowStreamBuffer rows are
+// unread.
+func (s *rowStream) send(ctx context.Context, row []interface{}) error {
+	select {
+	case s.rows <- row:
+		return nil
+	case <-s.done:
+		return errRowsClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finish ends the stream with the statement's error.
+func (s *rowStream) finish(err error) {
+	s.err = err
+	close(s.rows)
+	s.readyOnce.Do(func() { close(s.ready) })
+}
+
+// close stops the statement and waits for it to end, so its connection slot
+// is released when Rows.Close returns.
+func (s *rowStream) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	for range s.rows {
+	}
+}
@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// ErrResultTooLarge is returned (wrapped in *ResultTooLargeError) when a query
+// produces more rows or bytes than Options.MaxResultRows/MaxResultBytes allow
+// and ResultOverflow is ResultOverflowError, or more rows than its
+// QueryLimits.MaxRows.
+var ErrResultTooLarge = errors.New("result set too large")
+
+// ResultOverflow selects what happens when a result exceeds the configured
+// limits.
+type ResultOverflow int
+
+const (
+	// ResultOverflowError fails the query with ErrResultTooLarge.
+	ResultOverflowError ResultOverflow = iota
+	// ResultOverflowCursor lets the query succeed; network servers return
+	// the first page and hand the client a server-side cursor to page
+	// through the rest, with MaxResultRows/MaxResultBytes as the page size.
+	ResultOverflowCursor
+)
+
+// String returns the overflow mode name.
+func (m ResultOverflow) String() string {
+	switch m {
+	case ResultOverflowError:
+		return "error"
+	case ResultOverflowCursor:
+		return "cursor"
+	default:
+		return fmt.Sprintf("ResultOverflow(%d)", int(m))
+	}
+}
+
+// ResultLimits caps how much a single query may return and hold while it
+// runs. WithQueryLimits overrides them per query.
+type ResultLimits struct {
+	MaxResultRows  int            // Max rows per result set (0 = unlimited)
+	MaxResultBytes int64          // Approximate max bytes per result set (0 = unlimited)
+	ResultOverflow ResultOverflow // Behavior when a limit is exceeded (default: error)
+	MaxQueryMemory int64          // Approximate max bytes of rows held by sorts, joins and grouping (0 = unlimited)
+	// SpillMemory is the approximate bytes of rows an ORDER BY or a GROUP
+	// BY over a table holds before spilling them to temporary files in
+	// SpillDir (default: os.TempDir()). A spilling sort or grouping counts
+	// against MaxQueryMemory only for what it holds, so set it lower.
+	// 0 never spills.
+	SpillMemory int64
+	SpillDir    string
+}
+
+// ResultTooLargeError reports which limit a result exceeded.
+type ResultTooLargeError struct {
+	Rows     int   // Rows counted when the limit tripped
+	Bytes    int64 // Estimated bytes counted when the limit tripped
+	MaxRows  int
+	MaxBytes int64
+}
+
+func (e *ResultTooLargeError) Error() string {
+	if e.MaxRows > 0 && e.Rows > e.MaxRows {
+		return fmt.Sprintf("%v: more than %d rows", ErrResultTooLarge, e.MaxRows)
+	}
+	return fmt.Sprintf("%v: more than %d bytes", ErrResultTooLarge, e.MaxBytes)
+}
+
+// Unwrap lets errors.Is(err, ErrResultTooLarge) match.
+func (e *ResultTooLargeError) Unwrap() error { return ErrResultTooLarge }
+
+// Is lets errors.Is(err, ErrLimitExceeded) match too.
+func (e *ResultTooLargeError) Is(target error) bool { return target == ErrLimitExceeded }
+
+// ResultLimits returns the configured result size limits.
+func (db *DB) ResultLimits() ResultLimits {
+	return db.options.ResultLimits
+}
+
+// beginQuery begins the statement of a row-returning query. When a result
+// limit applies, or the rows go to a cursor's stream, a plain SELECT streams
+// its rows into the returned sink, so its scan stops at the first row over a
+// limit instead of collecting the whole result first. The sink is nil when
+// there is nothing to enforce.
+func (db *DB) beginQuery(ctx context.Context, stmt query.Statement, stream *rowStream) (*resultSink, func(error) error) {
+	sink := db.newResultSink(ctx, stream)
+	if sink != nil {
+		// The sides of a set operation are Selects of their own, and the
+		// first would take the sink.
+		if _, ok := stmt.(*query.SelectStmt); ok {
+			ctx = withRowSink(ctx, sink)
+		}
+	}
+	return sink, db.beginStatement(ctx)
+}
+
+// finishQuery applies the result limits to the rows of a query that
+// succeeded, closing them if it did not.
+func (db *DB) finishQuery(sink *resultSink, rows *Rows, err error) (*Rows, error) {
+	if err == nil && sink != nil {
+		rows, err = sink.result(rows)
+	}
+	if err != nil {
+		if rows != nil {
+			_ = rows.Close()
+		}
+		if sink != nil && sink.stream != nil && errors.Is(err, errRowsClosed) {
+			// The cursor was closed before its last row: not a failure.
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rows, nil
+}
+
+// resultSink is the catalog.RowSink a query's rows stream into. It counts
+// them against the query's QueryLimits.MaxRows and, in ResultOverflowError
+// mode, the configured MaxResultRows and MaxResultBytes, failing the
+// statement at the first row over a limit. It collects the rows, or hands
+// them to a cursor's stream.
+type resultSink struct {
+	ctx      context.Context
+	maxRows  int          // QueryLimits.MaxRows
+	limits   ResultLimits // MaxResultRows and MaxResultBytes, in error mode
+	stream   *rowStream   // receives the rows instead of collecting them
+	columns  []string
+	rows     [][]interface{}
+	count    int
+	size     int64
+	streamed bool // Columns was called: the statement's rows came through the sink
+}
+
+// newResultSink returns the sink for a query run with ctx, or nil when
+// there is no limit to enforce and no stream to feed.
+func (db *DB) newResultSink(ctx context.Context, stream *rowStream) *resultSink {
+	s := &resultSink{ctx: ctx, maxRows: queryLimitsFrom(ctx).MaxRows, stream: stream}
+	if limits := db.options.ResultLimits; limits.ResultOverflow == ResultOverflowError {
+		s.limits.MaxResultRows = limits.MaxResultRows
+		s.limits.MaxResultBytes = limits.MaxResultBytes
+	}
+	if s.maxRows <= 0 && s.limits.MaxResultRows <= 0 && s.limits.MaxResultBytes <= 0 && stream == nil {
+		return nil
+	}
+	return s
+}
+
+func (s *resultSink) Columns(columns []string) error {
+	s.streamed = true
+	s.columns = columns
+	if s.stream != nil {
+		s.stream.setColumns(columns)
+	}
+	return nil
+}
+
+func (s *resultSink) Row(row []interface{}) error {
+	if err := s.admit(row); err != nil {
+		return err
+	}
+	// The sink owns row only until Row returns.
+	row = append([]interface{}(nil), row...)
+	if s.stream != nil {
+		return s.stream.send(s.ctx, row)
+	}
+	s.rows = append(s.rows, row)
+	return nil
+}
+
+// admit counts row against the limits.
+func (s *resultSink) admit(row []interface{}) error {
+	s.count++
+	if s.maxRows > 0 && s.count > s.maxRows {
+		return &ResultTooLargeError{Rows: s.count, MaxRows: s.maxRows}
+	}
+	maxRows, maxBytes := s.limits.MaxResultRows, s.limits.MaxResultBytes
+	if maxRows > 0 && s.count > maxRows {
+		return &ResultTooLargeError{Rows: s.count, MaxRows: maxRows, MaxBytes: maxBytes}
+	}
+	if maxBytes > 0 {
+		if s.size += ResultRowSize(row); s.size > maxBytes {
+			return &ResultTooLargeError{Rows: s.count, Bytes: s.size, MaxRows: maxRows, MaxBytes: maxBytes}
+		}
+	}
+	return nil
+}
+
+// result returns the rows of a statement run under s: those it streamed in,
+// or its own collected rows if it did not stream, checked against the limits
+// and handed to the stream. For a stream, the rows returned only count what
+// was sent.
+func (s *resultSink) result(rows *Rows) (*Rows, error) {
+	if s.streamed {
+		if s.stream != nil {
+			return &Rows{columns: s.columns, streamed: int64(s.count)}, nil
+		}
+		return &Rows{columns: s.columns, rows: s.rows}, nil
+	}
+	if rows == nil {
+		return nil, nil
+	}
+	for _, row := range rows.rows {
+		if err := s.admit(row); err != nil {
+			return rows, err
+		}
+	}
+	if s.stream == nil {
+		return rows, nil
+	}
+	s.stream.setColumns(rows.columns)
+	for _, row := range rows.rows {
+		if err := s.stream.send(s.ctx, row); err != nil {
+			return rows, err
+		}
+	}
+	return &Rows{columns: rows.columns, streamed: int64(len(rows.rows))}, nil
+}
+
+// ResultRowSize estimates the encoded size of a result row in bytes. It is
+// the measure MaxResultBytes is enforced against.
+func ResultRowSize(row []interface{}) int64 {
+	var size int64
+	for _, v := range row {
+		size += resultValueSize(v)
+	}
+	return size
+}
+
+func resultValueSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 1
+	case bool:
+		return 1
+	case string:
+		return int64(len(v))
+	case catalog.StringBox:
+		return int64(len(v.String()))
+	case []byte:
+		return int64(len(v))
+	case []interface{}:
+		var size int64
+		for _, nested := range v {
+			size += resultValueSize(nested)
+		}
+		return size
+	case map[string]interface{}:
+		var size int64
+		for key, nested := range v {
+			size += int64(len(key)) + resultValueSize(nested)
+		}
+		return size
+	default:
+		return 8
+	}
+}
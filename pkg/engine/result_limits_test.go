@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// resultItems is ten rows with a 100-byte body each.
+var resultItems = []string{
+	"CREATE TABLE items (id INTEGER PRIMARY KEY, body TEXT)",
+	`INSERT INTO items VALUES (1, REPEAT('x', 100)), (2, REPEAT('x', 100)), (3, REPEAT('x', 100)),
+		(4, REPEAT('x', 100)), (5, REPEAT('x', 100)), (6, REPEAT('x', 100)), (7, REPEAT('x', 100)),
+		(8, REPEAT('x', 100)), (9, REPEAT('x', 100)), (10, REPEAT('x', 100))`,
+}
+
+func TestResultLimitsRejectTooManyRows(t *testing.T) {
+	db := openTestDB(t, &Options{ResultLimits: ResultLimits{MaxResultRows: 5}}, resultItems...)
+	ctx := context.Background()
+
+	_, err := db.Query(ctx, "SELECT id FROM items")
+	if !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("Query = %v, want ErrResultTooLarge", err)
+	}
+	var tooLarge *ResultTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.MaxRows != 5 || tooLarge.Rows != 6 {
+		t.Fatalf("unexpected error details: %#v", err)
+	}
+
+	rows, err := db.Query(ctx, "SELECT id FROM items WHERE id <= 5")
+	if err != nil {
+		t.Fatalf("query at the limit failed: %v", err)
+	}
+	rows.Close()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Query(ctx, "SELECT id FROM items"); !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("Tx.Query = %v, want ErrResultTooLarge", err)
+	}
+}
+
+func TestResultLimitsRejectTooManyBytes(t *testing.T) {
+	db := openTestDB(t, &Options{ResultLimits: ResultLimits{MaxResultBytes: 550}}, resultItems...)
+	ctx := context.Background()
+
+	_, err := db.Query(ctx, "SELECT body FROM items")
+	if !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("Query = %v, want ErrResultTooLarge", err)
+	}
+	if !strings.Contains(err.Error(), "550 bytes") {
+		t.Errorf("error should name the byte limit: %v", err)
+	}
+	rows, err := db.Query(ctx, "SELECT id FROM items")
+	if err != nil {
+		t.Fatalf("narrow query failed: %v", err)
+	}
+	rows.Close()
+}
+
+func TestResultLimitsCursorModeDoesNotFail(t *testing.T) {
+	db := openTestDB(t, &Options{ResultLimits: ResultLimits{MaxResultRows: 3, ResultOverflow: ResultOverflowCursor}}, resultItems...)
+
+	rows, err := db.Query(context.Background(), "SELECT id FROM items")
+	if err != nil {
+		t.Fatalf("Query failed in cursor mode: %v", err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if n != 10 {
+		t.Fatalf("got %d rows, want 10", n)
+	}
+	if got := db.ResultLimits(); got.MaxResultRows != 3 || got.ResultOverflow != ResultOverflowCursor {
+		t.Errorf("ResultLimits() = %+v", got)
+	}
+	if s := fmt.Sprint(ResultOverflowCursor); s != "cursor" {
+		t.Errorf("ResultOverflowCursor.String() = %q", s)
+	}
+}
+
+func TestQueryCursor(t *testing.T) {
+	db := openTestDB(t, &Options{
+		ResultLimits:   ResultLimits{MaxResultRows: 3, ResultOverflow: ResultOverflowCursor},
+		ConnectionPool: ConnectionPool{MaxConnections: 1},
+	}, resultItems...)
+	ctx := context.Background()
+	readIDs := func(rows *Rows, n int) []int {
+		t.Helper()
+		var ids []int
+		for len(ids) < n && rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	for _, sql := range []string{"SELECT id FROM items", "SELECT id FROM items ORDER BY id"} {
+		rows, err := db.QueryCursor(ctx, sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if cols := rows.Columns(); len(cols) != 1 || cols[0] != "id" {
+			t.Fatalf("%s: columns = %v", sql, cols)
+		}
+		if ids := readIDs(rows, 100); len(ids) != 10 || ids[0] != 1 || ids[9] != 10 || rows.Err() != nil {
+			t.Fatalf("%s: ids = %v, err = %v", sql, ids, rows.Err())
+		}
+		rows.Close()
+	}
+
+	// Closing the rows early ends the statement and releases its
+	// connection slot.
+	rows, err := db.QueryCursor(ctx, "SELECT id FROM items")
+	if err != nil {
+		t.Fatalf("QueryCursor: %v", err)
+	}
+	if ids := readIDs(rows, 2); len(ids) != 2 {
+		t.Fatalf("ids = %v", ids)
+	}
+	rows.Close()
+	if got := queryStrings(t, db, "SELECT COUNT(*) FROM items"); got[0] != "10" {
+		t.Fatalf("count = %v", got)
+	}
+
+	if _, err := db.QueryCursor(ctx, "SELECT id FROM missing"); err == nil {
+		t.Fatal("expected an error for a missing table")
+	}
+	// A limit tripped after the first rows ends them with Err.
+	rows, err = db.QueryCursor(WithQueryLimits(ctx, QueryLimits{MaxRows: 4}), "SELECT id FROM items")
+	if err != nil {
+		t.Fatalf("QueryCursor: %v", err)
+	}
+	defer rows.Close()
+	if ids := readIDs(rows, 100); len(ids) != 4 || !errors.Is(rows.Err(), ErrResultTooLarge) {
+		t.Fatalf("ids = %v, err = %v", ids, rows.Err())
+	}
+}
@@ -45,6 +45,8 @@ func DefaultRetryConfig() *RetryConfig {
 		MaxDelay:     30 * time.Second,
 		Multiplier:   2.0,
 		Jitter:       0.1,
+		// Re-running a query cannot make its result smaller.
+		NonRetryableErrors: []error{ErrResultTooLarge},
 	}
 }
 
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanOrderFollowsPrimaryKey(t *testing.T) {
+	db := openTestDB(t, nil)
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	mustExec(t, db, "CREATE INDEX idx_t_v ON t (v)")
+	for _, sql := range []string{
+		"INSERT INTO t VALUES (50, 'x')",
+		"INSERT INTO t VALUES (7, 'y')",
+		"INSERT INTO t VALUES (300, 'x')",
+		"INSERT INTO t VALUES (12, 'y')",
+		"INSERT INTO t VALUES (1, 'x')",
+	} {
+		mustExec(t, db, sql)
+	}
+
+	if got, want := queryStrings(t, db, "SELECT id FROM t"), []string{"1", "7", "12", "50", "300"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("full scan = %v, want %v", got, want)
+	}
+	if got, want := queryStrings(t, db, "SELECT id FROM t WHERE v = 'x'"), []string{"1", "50", "300"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("index scan = %v, want %v", got, want)
+	}
+
+	// Rows buffered by an open transaction merge into key order.
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(ctx, "INSERT INTO t VALUES (9, 'x')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO t VALUES (2, 'y')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	rows, err := tx.Query(ctx, "SELECT id FROM t")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var got []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		got = append(got, id)
+	}
+	rows.Close()
+	if want := []string{"1", "2", "7", "9", "12", "50", "300"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("scan in transaction = %v, want %v", got, want)
+	}
+}
+
+func TestRowIDPseudoColumn(t *testing.T) {
+	db := openTestDB(t, nil)
+	mustExec(t, db, "CREATE TABLE log (msg TEXT)")
+	for _, msg := range []string{"c", "a", "d", "b"} {
+		mustExec(t, db, "INSERT INTO log VALUES ('"+msg+"')")
+	}
+	mustExec(t, db, "DELETE FROM log WHERE msg = 'a'")
+
+	tests := []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT rowid, msg FROM log", []string{"1|c", "3|d", "4|b"}},
+		{"SELECT msg FROM log ORDER BY rowid DESC", []string{"b", "d", "c"}},
+		{"SELECT msg FROM log WHERE rowid > 1 LIMIT 1", []string{"d"}},
+		{"SELECT log.rowid FROM log WHERE msg = 'b'", []string{"4"}},
+		{"SELECT * FROM log", []string{"c", "d", "b"}},
+	}
+	for _, tt := range tests {
+		if got := queryStrings(t, db, tt.sql); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, db, "INSERT INTO users VALUES (-4, 'neg'), (10, 'ten')")
+	if got, want := queryStrings(t, db, "SELECT rowid, name FROM users ORDER BY rowid"), []string{"-4|neg", "10|ten"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("integer primary key rowid = %v, want %v", got, want)
+	}
+
+	mustExec(t, db, "CREATE TABLE tags (name TEXT PRIMARY KEY)")
+	if _, err := db.Query(context.Background(), "SELECT rowid FROM tags"); err == nil || !strings.Contains(err.Error(), "no ROWID") {
+		t.Errorf("rowid on a TEXT primary key: err = %v, want a no ROWID error", err)
+	}
+
+	// A real column named rowid wins over the pseudo-column.
+	mustExec(t, db, "CREATE TABLE shadow (rowid TEXT)")
+	mustExec(t, db, "INSERT INTO shadow VALUES ('mine')")
+	if got, want := queryStrings(t, db, "SELECT rowid FROM shadow"), []string{"mine"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("real rowid column = %v, want %v", got, want)
+	}
+}
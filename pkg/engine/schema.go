@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// executeDropSchema runs DROP SCHEMA. With CASCADE the schema's tables are
+// dropped first; without it a schema that still has tables is an error.
+func (db *DB) executeDropSchema(ctx context.Context, stmt *query.DropSchemaStmt) (Result, error) {
+	if stmt.Cascade {
+		for _, table := range db.catalog.SchemaTables(stmt.Name) {
+			if _, err := db.executeDropTable(ctx, &query.DropTableStmt{Table: table, Cascade: true}); err != nil {
+				return Result{}, err
+			}
+		}
+	}
+	return Result{}, db.catalog.DropSchema(stmt.Name, stmt.IfExists)
+}
+
+// Schemas returns the names of the schemas created with CREATE SCHEMA.
+func (db *DB) Schemas() []string {
+	return db.catalog.Schemas()
+}
+
+// SearchPath returns the default search path: the schemas searched, in
+// order, for table names given without a schema.
+func (db *DB) SearchPath() []string {
+	if path := db.searchPath.Load(); path != nil {
+		return append([]string(nil), (*path)...)
+	}
+	return []string{catalog.MainSchema}
+}
+
+// SetSearchPath sets the default search path, which sessions use until
+// they run SET search_path. A name resolves to the first schema on the path
+// with a table or view of that name, and to the main schema ("main" or
+// "public") if none has one. CREATE TABLE without a schema creates the
+// table in the first schema on the path that exists. Attached databases may
+// be named too. No schemas restores the main schema alone.
+func (db *DB) SetSearchPath(schemas ...string) error {
+	path, err := normalizeSearchPath(schemas)
+	if err != nil {
+		return err
+	}
+	if path == nil {
+		db.searchPath.Store(nil)
+		return nil
+	}
+	db.searchPath.Store(&path)
+	return nil
+}
+
+// searchPathFor returns the search path of the statement's session, or
+// nil for the main schema alone.
+func (db *DB) searchPathFor(ctx context.Context) []string {
+	if path := db.sessionFrom(ctx).SearchPath(); path != nil {
+		return path
+	}
+	if path := db.searchPath.Load(); path != nil {
+		return *path
+	}
+	return nil
+}
+
+// normalizeSearchPath checks a search path, returning nil for the default.
+func normalizeSearchPath(schemas []string) ([]string, error) {
+	path := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		schema = strings.TrimSpace(schema)
+		if schema == "" {
+			return nil, fmt.Errorf("invalid search path: empty schema name")
+		}
+		path = append(path, schema)
+	}
+	if len(path) == 0 {
+		return nil, nil
+	}
+	return path, nil
+}
+
+// setSearchPathVar applies SET search_path = a, b to the statement's
+// session. DEFAULT returns it to the database's search path.
+func (db *DB) setSearchPathVar(ctx context.Context, value string) error {
+	value = strings.TrimSpace(value)
+	session := db.sessionFrom(ctx)
+	if strings.EqualFold(value, "DEFAULT") {
+		session.SetSearchPath(nil)
+		return nil
+	}
+	var schemas []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.Trim(strings.TrimSpace(part), `"'`); part != "" {
+			schemas = append(schemas, part)
+		}
+	}
+	path, err := normalizeSearchPath(schemas)
+	if err != nil {
+		return err
+	}
+	if path == nil {
+		return fmt.Errorf("invalid search path %q", value)
+	}
+	session.SetSearchPath(path)
+	return nil
+}
+
+// searchPathValue reports whether a SET statement sets the search path,
+// returning its value. SET search_path TO x parses as a variable named
+// "search_path TO x" with no value.
+func searchPathValue(s *query.SetVarStmt) (string, bool) {
+	fields := strings.Fields(s.Variable)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "search_path") {
+		return "", false
+	}
+	if len(fields) > 1 && strings.EqualFold(fields[1], "TO") {
+		return strings.Join(fields[2:], " ") + s.Value, true
+	}
+	return s.Value, true
+}
+
+// resolveSearchPath qualifies the table names in stmt that the session's
+// search path finds outside the main schema. With the default path stmt is
+// returned as is.
+func (db *DB) resolveSearchPath(ctx context.Context, stmt query.Statement) query.Statement {
+	path := db.searchPathFor(ctx)
+	if path == nil || (len(path) == 1 && catalog.IsMainSchema(path[0])) {
+		return stmt
+	}
+
+	if create, ok := stmt.(*query.CreateTableStmt); ok && !create.Temporary {
+		if schema, _ := query.SplitTableName(create.Table); schema == "" {
+			if schema := db.creationSchema(path); schema != "" {
+				local := *create
+				local.Table = schema + "." + create.Table
+				return &local
+			}
+		}
+		return stmt
+	}
+	return query.RenameTables(stmt, func(name string) string {
+		return db.catalog.ResolveTableName(name, path)
+	})
+}
+
+// creationSchema returns the first schema on path that exists, or "" if
+// that is the main schema.
+func (db *DB) creationSchema(path []string) string {
+	attached := db.AttachedDatabases()
+	for _, schema := range path {
+		if catalog.IsMainSchema(schema) {
+			return ""
+		}
+		if db.catalog.HasSchema(schema) {
+			return schema
+		}
+		for _, name := range attached {
+			if strings.EqualFold(name, schema) {
+				return schema
+			}
+		}
+	}
+	return ""
+}
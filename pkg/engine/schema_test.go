@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchemas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schemas.cb")
+	ctx := context.Background()
+	db, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	mustExec(t, db, "CREATE SCHEMA analytics")
+	mustExec(t, db, "CREATE SCHEMA IF NOT EXISTS analytics")
+	if _, err := db.Exec(ctx, "CREATE SCHEMA analytics"); err == nil {
+		t.Error("created a schema twice")
+	}
+	if _, err := db.Exec(ctx, "CREATE TABLE nope.t (id INTEGER)"); err == nil {
+		t.Error("created a table in a missing schema")
+	}
+
+	// The same name in two schemas names two tables.
+	mustExec(t, db, "CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT)")
+	mustExec(t, db, "CREATE TABLE analytics.events (id INTEGER PRIMARY KEY, kind TEXT)")
+	mustExec(t, db, "INSERT INTO events VALUES (1, 'main')")
+	mustExec(t, db, "INSERT INTO analytics.events VALUES (1, 'a'), (2, 'b')")
+	mustExec(t, db, "UPDATE analytics.events SET kind = 'c' WHERE id = 2")
+	mustExec(t, db, "CREATE INDEX idx_kind ON analytics.events (kind)")
+	assertScalar(t, db, "SELECT COUNT(*) FROM events", int64(1))
+	assertScalar(t, db, "SELECT kind FROM analytics.events WHERE id = 2", "c")
+	rows := queryRows(t, db, "SELECT e.kind, a.kind FROM events e JOIN analytics.events a ON a.id = e.id")
+	if len(rows) != 1 || rows[0][0] != "main" || rows[0][1] != "a" {
+		t.Fatalf("join rows = %v", rows)
+	}
+
+	// The search path resolves unqualified names; the main schema is
+	// searched when no schema on the path has the table.
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY)")
+	mustExec(t, db, "SET search_path = analytics, public")
+	assertScalar(t, db, "SELECT COUNT(*) FROM events", int64(2))
+	assertScalar(t, db, "SELECT COUNT(*) FROM events WHERE events.kind = 'c'", int64(1))
+	assertScalar(t, db, "SELECT COUNT(*) FROM users", int64(0))
+	mustExec(t, db, "CREATE TABLE sessions (id INTEGER PRIMARY KEY)")
+	mustExec(t, db, "INSERT INTO sessions VALUES (7)")
+	assertScalar(t, db, "SELECT COUNT(*) FROM analytics.sessions", int64(1))
+	mustExec(t, db, "DELETE FROM events WHERE id = 1")
+	assertScalar(t, db, "SELECT COUNT(*) FROM analytics.events", int64(1))
+
+	// Sessions keep their own search path.
+	other := WithSession(ctx, &Session{})
+	row := db.QueryRow(other, "SELECT COUNT(*) FROM events")
+	var n int64
+	if err := row.Scan(&n); err != nil || n != 1 {
+		t.Errorf("other session: count = %d, err = %v; want the main table's 1 row", n, err)
+	}
+	mustExec(t, db, "SET search_path TO DEFAULT")
+	assertScalar(t, db, "SELECT COUNT(*) FROM events", int64(1))
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Schemas and their tables survive a reopen, and Options.SearchPath
+	// sets the default path.
+	db, err = Open(path, &Options{SearchPath: []string{"analytics", "main"}})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	if got := db.Schemas(); len(got) != 1 || got[0] != "analytics" {
+		t.Fatalf("Schemas = %v", got)
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM events", int64(1))
+	assertScalar(t, db, "SELECT kind FROM events", "c")
+
+	if _, err := db.Exec(ctx, "DROP SCHEMA analytics"); err == nil {
+		t.Error("dropped a schema that has tables without CASCADE")
+	}
+	mustExec(t, db, "DROP SCHEMA analytics CASCADE")
+	if _, err := db.Query(ctx, "SELECT * FROM analytics.sessions"); err == nil {
+		t.Error("analytics.sessions readable after DROP SCHEMA CASCADE")
+	}
+	assertScalar(t, db, "SELECT kind FROM events", "main")
+	mustExec(t, db, "DROP SCHEMA IF EXISTS analytics")
+}
+
+func TestSchemaRollback(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "CREATE SCHEMA staging")
+	mustExec(t, db, "ROLLBACK")
+	if got := db.Schemas(); len(got) != 0 {
+		t.Fatalf("Schemas after rollback = %v", got)
+	}
+}
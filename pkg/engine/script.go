@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// ScriptOptions configures ExecScriptWith.
+type ScriptOptions struct {
+	// Transaction runs the whole script in one transaction: it is committed
+	// when every statement succeeds and rolled back when one fails. The
+	// script must not contain its own transaction control statements.
+	Transaction bool
+}
+
+// ScriptError reports the statement of a script that failed.
+type ScriptError struct {
+	Index int    // 1-based position of the statement in the script
+	Line  int    // Line of the script the statement starts on
+	SQL   string // Statement text
+	Err   error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("statement %d (line %d): %v", e.Index, e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// ExecScript executes a script of ';'-separated statements in order, like a
+// series of Exec calls on the calling goroutine. Statements that return rows
+// are run with Query and their rows are discarded. It stops at the first
+// statement that fails and returns a *ScriptError for it; statements that
+// ran before it are not undone unless the script started a transaction that
+// is still open. The result sums RowsAffected over the statements and keeps
+// the last non-zero LastInsertID.
+func (db *DB) ExecScript(ctx context.Context, sql string) (Result, error) {
+	return db.ExecScriptWith(ctx, sql, ScriptOptions{})
+}
+
+// ExecScriptWith executes a script like ExecScript with options.
+func (db *DB) ExecScriptWith(ctx context.Context, sql string, opts ScriptOptions) (Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	statements := query.SplitScript(sql)
+
+	if opts.Transaction && len(statements) > 0 {
+		if _, err := db.Exec(ctx, "BEGIN"); err != nil {
+			return Result{}, fmt.Errorf("begin script transaction: %w", err)
+		}
+	}
+	var total Result
+	for i, stmt := range statements {
+		err := ctx.Err()
+		var res Result
+		if err == nil {
+			res, err = db.execScriptStatement(ctx, stmt.SQL)
+		}
+		if err != nil {
+			if opts.Transaction {
+				db.AbortConnTransaction()
+			}
+			return total, &ScriptError{Index: i + 1, Line: stmt.Line, SQL: stmt.SQL, Err: err}
+		}
+		total.RowsAffected += res.RowsAffected
+		if res.LastInsertID != 0 {
+			total.LastInsertID = res.LastInsertID
+		}
+	}
+	if opts.Transaction && len(statements) > 0 {
+		if _, err := db.Exec(ctx, "COMMIT"); err != nil {
+			db.AbortConnTransaction()
+			return total, fmt.Errorf("commit script transaction: %w", err)
+		}
+	}
+	return total, nil
+}
+
+// execScriptStatement runs one statement of a script with Exec, or with Query
+// when it only returns rows.
+func (db *DB) execScriptStatement(ctx context.Context, sql string) (Result, error) {
+	db.mu.RLock()
+	stmt, err := db.getPreparedStatement(sql)
+	db.mu.RUnlock()
+	if err != nil || !isQueryOnlyStatement(stmt) {
+		// Exec reports the parse error with its usual bookkeeping.
+		return db.Exec(ctx, sql)
+	}
+	rows, err := db.Query(ctx, sql)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{}, rows.Close()
+}
+
+// isQueryOnlyStatement reports whether stmt is rejected by Exec because it
+// only returns rows.
+func isQueryOnlyStatement(stmt query.Statement) bool {
+	switch stmt.(type) {
+	case *query.SelectStmt, *query.UnionStmt, *query.SelectStmtWithCTE, *query.ExplainStmt,
+		*query.ShowTablesStmt, *query.ShowCreateTableStmt, *query.ShowColumnsStmt, *query.ShowIndexStmt,
+		*query.ShowDatabasesStmt, *query.ShowConfigStmt, *query.DescribeStmt:
+		return true
+	}
+	return false
+}
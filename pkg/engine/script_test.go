@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExecScript(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	res, err := db.ExecScript(ctx, `
+		-- schema
+		CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);
+		CREATE TABLE log (note_id INTEGER, msg TEXT);
+		CREATE TRIGGER notes_ai AFTER INSERT ON notes
+		BEGIN
+			INSERT INTO log VALUES (NEW.id, CASE WHEN NEW.body = '' THEN 'empty' ELSE 'ok' END);
+		END;
+		INSERT INTO notes VALUES (1, 'a; b'), (2, '');;
+		/* a comment; with a semicolon */
+		INSERT INTO notes VALUES (3, 'it''s; fine');
+		SELECT COUNT(*) FROM notes
+	`)
+	if err != nil {
+		t.Fatalf("ExecScript: %v", err)
+	}
+	if res.RowsAffected != 3 {
+		t.Fatalf("RowsAffected = %d, want 3", res.RowsAffected)
+	}
+	assertScalar(t, db, "SELECT body FROM notes WHERE id = 1", "a; b")
+	assertScalar(t, db, "SELECT body FROM notes WHERE id = 3", "it's; fine")
+	assertScalar(t, db, "SELECT msg FROM log WHERE note_id = 2", "empty")
+
+	// A failing statement stops the script and is identified.
+	_, err = db.ExecScript(ctx, "INSERT INTO notes VALUES (4, 'x');\nINSERT INTO notes VALUES (4, 'dup');\nINSERT INTO notes VALUES (5, 'y')")
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Index != 2 || scriptErr.Line != 2 {
+		t.Fatalf("err = %v, want a ScriptError for statement 2 on line 2", err)
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM notes", int64(4))
+
+	// Transactional scripts are all or nothing.
+	_, err = db.ExecScriptWith(ctx, "INSERT INTO notes VALUES (5, 'y'); INSERT INTO missing VALUES (1)", ScriptOptions{Transaction: true})
+	if !errors.As(err, &scriptErr) || scriptErr.Index != 2 {
+		t.Fatalf("err = %v, want a ScriptError for statement 2", err)
+	}
+	if db.InConnTransaction() {
+		t.Fatal("transaction left open after a failed script")
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM notes", int64(4))
+	if _, err := db.ExecScriptWith(ctx, "INSERT INTO notes VALUES (5, 'y'); UPDATE notes SET body = 'z' WHERE id = 5", ScriptOptions{Transaction: true}); err != nil {
+		t.Fatalf("transactional script: %v", err)
+	}
+	assertScalar(t, db, "SELECT body FROM notes WHERE id = 5", "z")
+
+	// Scripts may manage their own transactions.
+	if _, err := db.ExecScript(ctx, "BEGIN; DELETE FROM notes; ROLLBACK;"); err != nil {
+		t.Fatalf("script with BEGIN/ROLLBACK: %v", err)
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM notes", int64(5))
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSequencesSurviveCheckpointAndProcessExit(t *testing.T) {
+	if os.Getenv("COBALTDB_SEQUENCE_HELPER") == "1" {
+		runSequenceWriter(t)
+		os.Exit(0)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "sequences.db")
+	cmd := exec.Command(os.Args[0], "-test.run=TestSequencesSurviveCheckpointAndProcessExit")
+	cmd.Env = append(os.Environ(),
+		"COBALTDB_SEQUENCE_HELPER=1",
+		"COBALTDB_WAL_CRASH_DB="+dbPath,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sequence helper failed: %v\n%s", err, out)
+	}
+
+	recovered, err := Open(dbPath, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("open recovered db: %v", err)
+	}
+	defer recovered.Close()
+
+	// Row 3 was deleted before the checkpoint; its key must not come back.
+	mustExec(t, recovered, "INSERT INTO items (name) VALUES ('d')")
+	assertScalar(t, recovered, "SELECT MAX(id) FROM items", int64(4))
+	assertScalar(t, recovered, "SELECT COUNT(*) FROM items", int64(3))
+
+	// The writer got 1 through 4; a crash may skip values but never repeat one.
+	var next int64
+	if err := recovered.QueryRow(context.Background(), "SELECT NEXTVAL('ticket')").Scan(&next); err != nil {
+		t.Fatalf("NEXTVAL after recovery: %v", err)
+	}
+	if next <= 4 {
+		t.Fatalf("NEXTVAL after recovery = %d, want more than 4", next)
+	}
+}
+
+func runSequenceWriter(t *testing.T) {
+	t.Helper()
+
+	db, err := Open(os.Getenv("COBALTDB_WAL_CRASH_DB"), durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("open sequence writer db: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO items (name) VALUES ('a')",
+		"INSERT INTO items (name) VALUES ('b')",
+		"INSERT INTO items (name) VALUES ('c')",
+		"DELETE FROM items WHERE id = 3",
+		"CREATE SEQUENCE ticket",
+	} {
+		mustExec(t, db, stmt)
+	}
+	for i := 0; i < 3; i++ {
+		mustQuery(t, db, "SELECT NEXTVAL('ticket')")
+	}
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	mustQuery(t, db, "SELECT NEXTVAL('ticket')")
+
+	// Intentionally do not call db.Close.
+}
+
+// mustQuery runs a SELECT for its side effects and discards the rows.
+func mustQuery(t *testing.T, db *DB, sql string) {
+	t.Helper()
+	rows, err := db.Query(context.Background(), sql)
+	if err != nil {
+		t.Fatalf("query %q: %v", sql, err)
+	}
+	rows.Close()
+}
+
+func TestSequenceFunctions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seq.db")
+	db, err := Open(path, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	mustExec(t, db, "CREATE SEQUENCE countdown START WITH 3 INCREMENT BY -1 MINVALUE 1 MAXVALUE 3 CYCLE")
+	for _, want := range []int64{3, 2, 1, 3} {
+		assertScalar(t, db, "SELECT NEXTVAL('countdown')", want)
+	}
+	assertScalar(t, db, "SELECT CURRVAL('countdown')", int64(3))
+
+	mustExec(t, db, "CREATE SEQUENCE order_no START 100 INCREMENT 10 MAXVALUE 120")
+	if _, err := db.Query(context.Background(), "SELECT CURRVAL('order_no')"); err == nil {
+		t.Fatal("CURRVAL before NEXTVAL succeeded")
+	}
+	mustExec(t, db, "CREATE TABLE orders (no INTEGER PRIMARY KEY, item TEXT)")
+	mustExec(t, db, "INSERT INTO orders VALUES (NEXTVAL('order_no'), 'a')")
+	mustExec(t, db, "INSERT INTO orders VALUES (NEXTVAL('order_no'), 'b')")
+	assertScalar(t, db, "SELECT no FROM orders WHERE item = 'b'", int64(110))
+	assertScalar(t, db, "SELECT NEXTVAL('order_no')", int64(120))
+	if _, err := db.Query(context.Background(), "SELECT NEXTVAL('order_no')"); err == nil ||
+		!strings.Contains(err.Error(), "maximum value") {
+		t.Fatalf("NEXTVAL past MAXVALUE = %v, want maximum value error", err)
+	}
+	mustQuery(t, db, "SELECT SETVAL('order_no', 100, false)")
+	assertScalar(t, db, "SELECT NEXTVAL('order_no')", int64(100))
+
+	// Sequence values are not transactional, but the sequence itself is.
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "CREATE SEQUENCE scratch")
+	mustQuery(t, db, "SELECT NEXTVAL('order_no')")
+	mustExec(t, db, "ROLLBACK")
+	if _, err := db.Query(context.Background(), "SELECT NEXTVAL('scratch')"); err == nil {
+		t.Fatal("sequence created in a rolled-back transaction still exists")
+	}
+
+	ddl := strings.Join(db.SequenceDDL(), "\n")
+	if !strings.Contains(ddl, `CREATE SEQUENCE "order_no" START WITH 100 INCREMENT BY 10 MINVALUE 1 MAXVALUE 120;`) ||
+		!strings.Contains(ddl, `SELECT SETVAL('order_no', 110);`) {
+		t.Fatalf("unexpected sequence DDL:\n%s", ddl)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A clean close keeps the exact values.
+	reopened, err := Open(path, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	assertScalar(t, reopened, "SELECT NEXTVAL('order_no')", int64(120))
+	assertScalar(t, reopened, "SELECT NEXTVAL('countdown')", int64(2))
+	mustExec(t, reopened, "DROP SEQUENCE countdown")
+	mustExec(t, reopened, "DROP SEQUENCE IF EXISTS countdown")
+	if _, err := reopened.Exec(context.Background(), "CREATE SEQUENCE bad INCREMENT BY 0"); err == nil {
+		t.Fatal("CREATE SEQUENCE with a zero increment succeeded")
+	}
+}
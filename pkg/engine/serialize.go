@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cobaltdb/cobaltdb/pkg/logger"
+	"github.com/cobaltdb/cobaltdb/pkg/storage"
+)
+
+// ErrNotInMemory is returned by Serialize for a database kept on disk; use a
+// backup to copy one of those.
+var ErrNotInMemory = errors.New("database is not in memory")
+
+// Serialize returns the whole of an in-memory database as one byte slice,
+// in the same format as a database file. Committed changes are included;
+// those of open transactions are not. Statements wait while it runs.
+//
+// Deserialize turns the slice back into a database, so a snapshot can be
+// cached, kept as a test fixture or sent over the network.
+func (db *DB) Serialize() ([]byte, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed.Load() {
+		return nil, ErrDatabaseClosed
+	}
+	mem, ok := db.backend.(*storage.MemoryBackend)
+	if !ok {
+		return nil, ErrNotInMemory
+	}
+
+	db.backupMu.Lock()
+	defer db.backupMu.Unlock()
+	db.flushMu.Lock()
+	defer db.flushMu.Unlock()
+
+	if err := db.catalog.PersistSequences(); err != nil {
+		return nil, fmt.Errorf("serialize: failed to persist sequences: %w", err)
+	}
+	if err := db.catalog.FlushTableTrees(); err != nil {
+		return nil, fmt.Errorf("serialize: failed to flush table trees: %w", err)
+	}
+	if err := db.catalog.Save(); err != nil {
+		return nil, fmt.Errorf("serialize: failed to save catalog: %w", err)
+	}
+	if err := db.saveMetaPage(); err != nil {
+		return nil, fmt.Errorf("serialize: %w", err)
+	}
+	if err := db.pool.FlushDirty(); err != nil {
+		return nil, fmt.Errorf("serialize: failed to flush pages: %w", err)
+	}
+	return mem.Data(), nil
+}
+
+// Deserialize opens an in-memory database holding a copy of data, as
+// returned by Serialize or read from a database file, with default options.
+func Deserialize(data []byte) (*DB, error) {
+	return DeserializeWithOptions(data, nil)
+}
+
+// DeserializeWithOptions is Deserialize with options, as for Open. The
+// database is always in memory; changes to it do not touch data.
+func DeserializeWithOptions(data []byte, opts *Options) (*DB, error) {
+	if len(data) == 0 {
+		return nil, errors.New("deserialize: no data")
+	}
+	opts = normalizeOptions(opts)
+	opts.CoreStorage.InMemory = true
+	if err := validateOptions(opts); err != nil {
+		return nil, err
+	}
+	log := opts.CoreStorage.Logger
+	if log == nil {
+		log = logger.Default()
+	}
+	log = log.WithComponent("engine")
+
+	backend := storage.NewMemory()
+	backend.LoadFromData(data)
+	db, err := openBackend(":memory:", opts, backend, log)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize: %w", err)
+	}
+	return db, nil
+}
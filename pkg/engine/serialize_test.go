@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestSerializeDeserialize(t *testing.T) {
+	db, err := Open(":memory:", nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY AUTO_INCREMENT, name TEXT)")
+	mustExec(t, db, "CREATE INDEX idx_users_name ON users (name)")
+	mustExec(t, db, "CREATE VIEW named AS SELECT name FROM users WHERE name IS NOT NULL")
+	for i := 0; i < 200; i++ {
+		mustExec(t, db, fmt.Sprintf("INSERT INTO users (name) VALUES ('user%d')", i))
+	}
+	mustExec(t, db, "DELETE FROM users WHERE id > 150")
+
+	data, err := db.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	// The source keeps working and later changes stay out of the snapshot.
+	mustExec(t, db, "INSERT INTO users (name) VALUES ('late')")
+
+	copied, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	defer copied.Close()
+	if rows := queryRows(t, copied, "SELECT COUNT(*), MAX(id) FROM users"); fmt.Sprint(rows) != "[[150 150]]" {
+		t.Fatalf("count, max = %v", rows)
+	}
+	if rows := queryRows(t, copied, "SELECT id FROM users WHERE name = 'user41'"); fmt.Sprint(rows) != "[[42]]" {
+		t.Fatalf("index lookup = %v", rows)
+	}
+	if rows := queryRows(t, copied, "SELECT COUNT(*) FROM named"); fmt.Sprint(rows) != "[[150]]" {
+		t.Fatalf("view = %v", rows)
+	}
+	// The copy is a database of its own, and its counter carries on past
+	// the deleted rows.
+	mustExec(t, copied, "INSERT INTO users (name) VALUES ('copy')")
+	if rows := queryRows(t, copied, "SELECT id FROM users WHERE name = 'copy'"); fmt.Sprint(rows) != "[[201]]" {
+		t.Fatalf("insert into copy = %v", rows)
+	}
+	if rows := queryRows(t, db, "SELECT COUNT(*) FROM users WHERE name = 'copy'"); fmt.Sprint(rows) != "[[0]]" {
+		t.Fatalf("copy wrote to the source: %v", rows)
+	}
+
+	again, err := copied.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize copy: %v", err)
+	}
+	third, err := Deserialize(again)
+	if err != nil {
+		t.Fatalf("Deserialize again: %v", err)
+	}
+	defer third.Close()
+	if rows := queryRows(t, third, "SELECT COUNT(*) FROM users"); fmt.Sprint(rows) != "[[151]]" {
+		t.Fatalf("second generation count = %v", rows)
+	}
+
+	if _, err := Deserialize([]byte("not a database")); err == nil {
+		t.Fatal("Deserialize accepted garbage")
+	}
+	disk, err := Open(filepath.Join(t.TempDir(), "disk.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+	if _, err := disk.Serialize(); !errors.Is(err, ErrNotInMemory) {
+		t.Fatalf("Serialize on disk err = %v, want ErrNotInMemory", err)
+	}
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+// Session holds the state of one client connection, such as the ROWID that
+// last_insert_rowid() returns. Servers create one per connection and run its
+// statements with WithSession; statements without a session share the DB's.
+type Session = catalog.Session
+
+type sessionKey struct{}
+
+// WithSession returns a copy of ctx whose statements read and update s.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// sessionFrom returns the session of ctx, or the DB's shared session.
+func (db *DB) sessionFrom(ctx context.Context) *Session {
+	if s, _ := ctx.Value(sessionKey{}).(*Session); s != nil {
+		return s
+	}
+	return &db.session
+}
+
+// recordLastInsertID makes id the session's last_insert_rowid(). INSERTs
+// into tables without a ROWID, and those that inserted nothing, leave it
+// unchanged.
+func (db *DB) recordLastInsertID(ctx context.Context, id int64) {
+	if id != 0 {
+		db.sessionFrom(ctx).SetLastInsertRowID(id)
+	}
+}
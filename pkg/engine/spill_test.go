@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSortAndGroupBySpillToDisk(t *testing.T) {
+	spillDir := t.TempDir()
+	db, err := Open(":memory:", &Options{
+		CoreStorage:  CoreStorage{InMemory: true},
+		ResultLimits: ResultLimits{MaxQueryMemory: 64 << 10, SpillDir: spillDir},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE events (id INTEGER PRIMARY KEY, grp INTEGER, name TEXT)")
+	const n = 2000
+	for start := 0; start < n; start += 200 {
+		values := make([]string, 0, 200)
+		for i := start; i < start+200; i++ {
+			values = append(values, fmt.Sprintf("(%d, %d, '%s-%05d')", i, i%50, strings.Repeat("x", 40), (i*7919)%n))
+		}
+		mustExec(t, db, "INSERT INTO events VALUES "+strings.Join(values, ", "))
+	}
+
+	const sortSQL = "SELECT id, name FROM events ORDER BY name DESC"
+	// COUNT(DISTINCT) needs every row of a group, so the grouping holds them.
+	const groupSQL = "SELECT grp, COUNT(DISTINCT name), SUM(id) FROM events GROUP BY grp"
+	for _, sql := range []string{sortSQL, groupSQL} {
+		if _, err := db.Query(ctx, sql); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("%s without spilling: err = %v, want ErrLimitExceeded", sql, err)
+		}
+	}
+
+	spillCtx := WithQueryLimits(ctx, QueryLimits{SpillMemory: 4 << 10})
+	rows, err := db.Query(spillCtx, sortSQL)
+	if err != nil {
+		t.Fatalf("spilling sort: %v", err)
+	}
+	var prev string
+	count := 0
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		if count > 0 && name > prev {
+			t.Fatalf("row %d: %q sorts after %q", count, name, prev)
+		}
+		prev = name
+		count++
+	}
+	rows.Close()
+	if count != n {
+		t.Fatalf("spilling sort returned %d rows, want %d", count, n)
+	}
+
+	rows, err = db.Query(spillCtx, groupSQL)
+	if err != nil {
+		t.Fatalf("spilling GROUP BY: %v", err)
+	}
+	g := int64(0)
+	for rows.Next() {
+		var grp, cnt, sum int64
+		if err := rows.Scan(&grp, &cnt, &sum); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		// 40 rows per group, ids grp, grp+50, ..., grp+1950.
+		if grp != g || cnt != 40 || sum != 40*grp+50*780 {
+			t.Fatalf("group %d = (%d, %d, %d), want (%d, 40, %d)", g, grp, cnt, sum, g, 40*g+50*780)
+		}
+		g++
+	}
+	rows.Close()
+	if g != 50 {
+		t.Fatalf("spilling GROUP BY returned %d groups, want 50", g)
+	}
+
+	if left, _ := os.ReadDir(spillDir); len(left) != 0 {
+		t.Fatalf("spill files left behind: %d", len(left))
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/catalog"
+)
+
+// ErrLimitExceeded is returned, wrapped in *LimitExceededError, when a
+// statement runs longer than its execution time limit or holds more rows in
+// sorts, joins and grouping than its memory limit allows. Errors for results
+// over MaxResultRows/MaxResultBytes (ErrResultTooLarge) match it as well.
+var ErrLimitExceeded = catalog.ErrLimitExceeded
+
+// LimitExceededError reports which statement limit was breached.
+type LimitExceededError = catalog.LimitExceededError
+
+// Statement limit names reported by LimitExceededError.
+const (
+	LimitMaxExecutionTime = catalog.LimitMaxExecutionTime
+	LimitMaxMemory        = catalog.LimitMaxMemory
+)
+
+// QueryLimits overrides the engine-wide statement limits for the statements
+// run with a context from WithQueryLimits. Zero fields keep the engine's
+// setting.
+type QueryLimits struct {
+	Timeout     time.Duration // Max execution time; replaces ConnectionPool.QueryTimeout
+	MaxRows     int           // Max rows returned; fails the query even in cursor mode
+	MaxMemory   int64         // Max bytes held by sorts, joins and grouping; replaces ResultLimits.MaxQueryMemory
+	SpillMemory int64         // Bytes a sort or grouping holds before spilling; replaces ResultLimits.SpillMemory
+}
+
+type queryLimitsKey struct{}
+
+// WithQueryLimits returns a copy of ctx that applies limits to the statements
+// executed with it, so one query can get tighter bounds than the rest.
+func WithQueryLimits(ctx context.Context, limits QueryLimits) context.Context {
+	return context.WithValue(ctx, queryLimitsKey{}, limits)
+}
+
+func queryLimitsFrom(ctx context.Context) QueryLimits {
+	limits, _ := ctx.Value(queryLimitsKey{}).(QueryLimits)
+	return limits
+}
+
+// statementContext applies the statement's execution time limit to ctx. The
+// per-query Timeout always applies; the engine's QueryTimeout only when the
+// caller set no deadline of its own.
+func (db *DB) statementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := queryLimitsFrom(ctx).Timeout
+	if timeout <= 0 {
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			return ctx, func() {}
+		}
+		timeout = db.options.ConnectionPool.QueryTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, timeout, &LimitExceededError{
+		Limit: LimitMaxExecutionTime,
+		Max:   int64(timeout),
+		Err:   context.DeadlineExceeded,
+	})
+}
+
+// beginStatement applies ctx and the memory limits to the catalog work of one
+// statement. finish maps err, the statement's own error, to the error the
+// caller should return: a breached limit wins over a result built from
+// partial work.
+func (db *DB) beginStatement(ctx context.Context) (finish func(err error) error) {
+	maxMemory := queryLimitsFrom(ctx).MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = db.options.ResultLimits.MaxQueryMemory
+	}
+	spillMemory := queryLimitsFrom(ctx).SpillMemory
+	if spillMemory <= 0 {
+		spillMemory = db.options.ResultLimits.SpillMemory
+	}
+	limits := catalog.StatementLimits{
+		MaxMemory:   maxMemory,
+		SpillMemory: spillMemory,
+		SpillDir:    db.options.ResultLimits.SpillDir,
+		Stats:       statementStatsFrom(ctx),
+		Rows:        rowSinkFrom(ctx),
+		Session:     db.sessionFrom(ctx),
+	}
+	if ctx.Done() != nil {
+		limits.Ctx = ctx
+	}
+	end := db.catalog.BeginStatement(limits)
+	return func(err error) error {
+		if limitErr := end(); limitErr != nil {
+			err = limitErr
+		}
+		return statementLimitError(ctx, err)
+	}
+}
+
+// statementLimitError replaces the context error of a statement that ran out
+// of time with the *LimitExceededError that set its deadline.
+func statementLimitError(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var limitErr *LimitExceededError
+	if errors.As(context.Cause(ctx), &limitErr) {
+		return limitErr
+	}
+	return err
+}
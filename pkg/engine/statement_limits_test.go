@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func wantLimitExceeded(t *testing.T, err error, limit string) {
+	t.Helper()
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("err = %v, want ErrLimitExceeded", err)
+	}
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != limit {
+		t.Fatalf("err = %#v, want a %s LimitExceededError", err, limit)
+	}
+}
+
+func TestStatementMemoryLimit(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &Options{ResultLimits: ResultLimits{MaxQueryMemory: 16 << 10}},
+		"CREATE TABLE a (id INTEGER PRIMARY KEY, v INTEGER, s TEXT)",
+		"INSERT INTO a WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM n WHERE i < 399) SELECT i, i % 7, 'row ' || i FROM n",
+		"CREATE TABLE b (id INTEGER PRIMARY KEY, v INTEGER, s TEXT)",
+		"INSERT INTO b SELECT id, v, s FROM a")
+
+	// Small statements fit.
+	if got := queryStrings(t, db, "SELECT COUNT(*) FROM a WHERE v = 3"); len(got) != 1 || got[0] != "57" {
+		t.Fatalf("count = %v, want [57]", got)
+	}
+
+	for _, sql := range []string{
+		"SELECT a.id, b.id FROM a JOIN b ON a.v = b.v",
+		"SELECT id, s FROM a ORDER BY s DESC",
+		"SELECT s, COUNT(*) FROM a GROUP BY s",
+	} {
+		_, err := db.Query(ctx, sql)
+		wantLimitExceeded(t, err, LimitMaxMemory)
+	}
+	_, err := db.Exec(ctx, "INSERT INTO b SELECT a.id + 1000, a.v, a.s FROM a JOIN b ON a.v = b.v")
+	wantLimitExceeded(t, err, LimitMaxMemory)
+	if got := queryStrings(t, db, "SELECT COUNT(*) FROM b"); got[0] != "400" {
+		t.Fatalf("b has %v rows after the failed INSERT, want 400", got)
+	}
+
+	// A per-query limit raises the engine's.
+	rows, err := db.Query(WithQueryLimits(ctx, QueryLimits{MaxMemory: 64 << 20}), "SELECT id, s FROM a ORDER BY s DESC")
+	if err != nil {
+		t.Fatalf("query with a raised limit: %v", err)
+	}
+	rows.Close()
+}
+
+func TestStatementTimeout(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, nil,
+		"CREATE TABLE a (id INTEGER PRIMARY KEY, v INTEGER, s TEXT)",
+		"INSERT INTO a WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM n WHERE i < 999) SELECT i, i % 7, 'row ' || i FROM n",
+		"INSERT INTO a SELECT id + 1000, (id + 1000) % 7, 'row ' || (id + 1000) FROM a",
+		"INSERT INTO a SELECT id + 2000, (id + 2000) % 7, 'row ' || (id + 2000) FROM a WHERE id < 1000",
+		"CREATE TABLE b (id INTEGER PRIMARY KEY, v INTEGER, s TEXT)",
+		"INSERT INTO b SELECT id, v, s FROM a")
+
+	// 9M join-condition evaluations take far longer than the limit.
+	start := time.Now()
+	_, err := db.Query(WithQueryLimits(ctx, QueryLimits{Timeout: 20 * time.Millisecond}),
+		"SELECT COUNT(*) FROM a JOIN b ON a.v + b.v < 0")
+	wantLimitExceeded(t, err, LimitMaxExecutionTime)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want it to match context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("query ran %v past its 20ms limit", elapsed)
+	}
+
+	// A caller's own deadline is not reported as a limit.
+	tctx, cancel := context.WithTimeout(ctx, time.Nanosecond)
+	defer cancel()
+	<-tctx.Done()
+	if _, err := db.Query(tctx, "SELECT COUNT(*) FROM a"); err == nil || errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("err = %v, want the caller's deadline error", err)
+	}
+}
+
+func TestStatementLimitsInHashJoin(t *testing.T) {
+	// Each left row matches half the right rows, so the join builds 8M rows.
+	setup := []string{
+		"CREATE TABLE a (id INTEGER PRIMARY KEY, k INTEGER)",
+		"INSERT INTO a WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM n WHERE i < 999) SELECT i, i % 2 FROM n",
+		"INSERT INTO a SELECT id + 1000, k FROM a",
+		"INSERT INTO a SELECT id + 2000, k FROM a",
+		"CREATE TABLE b (id INTEGER PRIMARY KEY, k INTEGER)",
+		"INSERT INTO b SELECT id, k FROM a",
+	}
+	const join = "SELECT COUNT(*) FROM a JOIN b ON a.k = b.k"
+	for _, tc := range []struct {
+		name     string
+		parallel ParallelQueryConfig
+	}{
+		{"serial", ParallelQueryConfig{MaxParallelism: 1}},
+		{"parallel", ParallelQueryConfig{Workers: 4, MaxParallelism: 4}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := openTestDB(t, &Options{ParallelQuery: tc.parallel}, setup...)
+
+			start := time.Now()
+			_, err := db.Query(WithQueryLimits(ctx, QueryLimits{Timeout: 20 * time.Millisecond}), join)
+			wantLimitExceeded(t, err, LimitMaxExecutionTime)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("join ran %v past its 20ms limit", elapsed)
+			}
+
+			_, err = db.Query(WithQueryLimits(ctx, QueryLimits{MaxMemory: 1 << 20}), join)
+			wantLimitExceeded(t, err, LimitMaxMemory)
+		})
+	}
+}
+
+func TestQueryLimitsMaxRows(t *testing.T) {
+	ctx := WithQueryLimits(context.Background(), QueryLimits{MaxRows: 10})
+	db := openTestDB(t, &Options{ResultLimits: ResultLimits{ResultOverflow: ResultOverflowCursor}},
+		"CREATE TABLE a (id INTEGER PRIMARY KEY, v INTEGER, s TEXT)",
+		"INSERT INTO a WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM n WHERE i < 49) SELECT i, i % 7, 'row ' || i FROM n",
+		"CREATE TABLE b (id INTEGER PRIMARY KEY, v INTEGER, s TEXT)",
+		"INSERT INTO b SELECT id, v, s FROM a")
+
+	_, err := db.Query(ctx, "SELECT id FROM a")
+	if !errors.Is(err, ErrLimitExceeded) || !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("err = %v, want ErrResultTooLarge matching ErrLimitExceeded", err)
+	}
+	// A plain scan stops at the first row over the limit; a sorted result
+	// is collected first, but counted the same way.
+	var tooLarge *ResultTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Rows != 11 || tooLarge.MaxRows != 10 {
+		t.Fatalf("err = %#v, want the scan stopped at row 11", err)
+	}
+	_, err = db.Query(ctx, "SELECT id FROM a ORDER BY id DESC")
+	if !errors.As(err, &tooLarge) || tooLarge.Rows != 11 {
+		t.Fatalf("sorted err = %#v, want 11 rows counted", err)
+	}
+	rows, err := db.Query(ctx, "SELECT id, s FROM a WHERE id >= 40")
+	if err != nil {
+		t.Fatalf("streamed query within the limit: %v", err)
+	}
+	if got := rows.Columns(); len(got) != 2 || got[1] != "s" {
+		t.Fatalf("columns = %v", got)
+	}
+	var n int
+	for rows.Next() {
+		var id int
+		var text string
+		if err := rows.Scan(&id, &text); err != nil || text != fmt.Sprintf("row %d", id) {
+			t.Fatalf("scan = %d, %q, %v", id, text, err)
+		}
+		n++
+	}
+	rows.Close()
+	if n != 10 {
+		t.Fatalf("got %d rows, want 10", n)
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Query(ctx, "SELECT id FROM b"); !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("tx query err = %v, want ErrResultTooLarge", err)
+	}
+	rows, err = tx.Query(ctx, "SELECT id FROM b WHERE id < 10")
+	if err != nil {
+		t.Fatalf("query within the limit: %v", err)
+	}
+	rows.Close()
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+)
+
+// Stmt is a statement parsed once by Prepare and run any number of times.
+// It is safe for concurrent use.
+type Stmt struct {
+	db     *DB
+	sql    string
+	params []string
+}
+
+// Prepare parses sql and returns it as a Stmt. The parsed statement is kept
+// in the statement cache, so running the Stmt does not parse it again while
+// it stays cached.
+func (db *DB) Prepare(ctx context.Context, sql string) (*Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.closed.Load() {
+		return nil, ErrDatabaseClosed
+	}
+	stmt, err := db.getPreparedStatement(sql)
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %w", err)
+	}
+	return &Stmt{db: db, sql: sql, params: query.ParamNames(stmt)}, nil
+}
+
+// SQL returns the statement text.
+func (s *Stmt) SQL() string {
+	return s.sql
+}
+
+// ParamNames returns one entry per parameter, in the order the parameters
+// first appear: the name of a :name or @name parameter (without the prefix),
+// or "" for a ? placeholder. A name used more than once is one parameter.
+// Positional arguments bind the parameters in this order.
+func (s *Stmt) ParamNames() []string {
+	return append([]string(nil), s.params...)
+}
+
+// Exec runs the statement like DB.Exec.
+func (s *Stmt) Exec(ctx context.Context, args ...interface{}) (Result, error) {
+	return s.db.Exec(ctx, s.sql, args...)
+}
+
+// Query runs the statement like DB.Query.
+func (s *Stmt) Query(ctx context.Context, args ...interface{}) (*Rows, error) {
+	return s.db.Query(ctx, s.sql, args...)
+}
@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
+)
+
+// startStatementSpan begins the span of a statement, traced by the tracer
+// in ctx or else Options.Tracer. The catalog, B+Tree and WAL spans of the
+// statement nest under it.
+func (db *DB) startStatementSpan(ctx context.Context, name, sql string, stmt query.Statement) (context.Context, tracing.Span) {
+	if tracing.TracerFrom(ctx) == nil {
+		if db.options.Tracer == nil {
+			return tracing.Start(ctx, name)
+		}
+		ctx = tracing.WithTracer(ctx, db.options.Tracer)
+	}
+	return tracing.Start(ctx, name,
+		tracing.String("db.system", "cobaltdb"),
+		tracing.String("db.statement", sql),
+		tracing.String("db.operation", statementKindNames[statementKindOf(stmt)]),
+	)
+}
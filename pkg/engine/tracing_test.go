@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
+)
+
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]interface{}
+	err    error
+}
+
+func (s *testSpan) SetAttributes(attrs ...tracing.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  {}
+
+type testSpanKey struct{}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+	parent, _ := ctx.Value(testSpanKey{}).(*testSpan)
+	span := &testSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	tr.mu.Lock()
+	tr.spans = append(tr.spans, span)
+	tr.mu.Unlock()
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+// path names a span and its ancestors, outermost first.
+func (s *testSpan) path() string {
+	if s.parent == nil {
+		return s.name
+	}
+	return s.parent.path() + " > " + s.name
+}
+
+func TestStatementTracing(t *testing.T) {
+	tracer := &testTracer{}
+	opts := durabilityTestOptions()
+	opts.Tracer = tracer
+	db, err := Open(filepath.Join(t.TempDir(), "trace.db"), opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	mustExec(t, db, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	tracer.spans = nil
+
+	// The caller's span is the parent of the statement span.
+	root, _ := tracer.Start(context.Background(), "request")
+	if _, err := db.Exec(root, "INSERT INTO t VALUES (1, 'a')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	rows, err := db.Query(root, "SELECT v FROM t")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	rows.Close()
+	if _, err := db.Exec(root, "DELETE FROM missing"); err == nil {
+		t.Fatal("delete from a missing table succeeded")
+	}
+
+	paths := map[string]*testSpan{}
+	for _, s := range tracer.spans {
+		paths[s.path()] = s
+	}
+	for _, want := range []string{
+		"request > cobaltdb.Exec > catalog.Insert",
+		"request > cobaltdb.Query > catalog.Select",
+		"request > cobaltdb.Exec > catalog.Delete",
+	} {
+		if paths[want] == nil {
+			t.Errorf("missing span %q", want)
+		}
+	}
+	var walSpans int
+	for path := range paths {
+		if strings.Contains(path, "cobaltdb.Exec >") && strings.Contains(path, "wal.") {
+			walSpans++
+		}
+	}
+	if walSpans == 0 {
+		t.Errorf("no WAL spans under the INSERT: %v", paths)
+	}
+
+	insert := tracer.spans[1]
+	if insert.name != "cobaltdb.Exec" || insert.attrs["db.statement"] != "INSERT INTO t VALUES (1, 'a')" ||
+		insert.attrs["db.operation"] != "insert" || insert.attrs["db.rows_affected"] != int64(1) {
+		t.Errorf("insert span = %+v", insert)
+	}
+	if del := paths["request > cobaltdb.Exec > catalog.Delete"]; del == nil || del.err == nil || del.parent.err == nil {
+		t.Errorf("failed DELETE spans recorded no error: %+v", del)
+	}
+	if sel := paths["request > cobaltdb.Query > catalog.Select"]; sel == nil || sel.attrs["db.sql.table"] != "t" ||
+		sel.parent.attrs["db.rows_returned"] != int64(1) {
+		t.Errorf("select span = %+v", sel)
+	}
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SweepExpired deletes the expired rows of every table created WITH
+// (ttl_column = ...): those whose TTL column holds a time at or before now.
+// A NULL TTL column never expires. Rows are deleted oldest first, in
+// transactions of about Maintenance.TTLBatchSize rows each, so a large
+// backlog does not hold up other writers; rows sharing the batch's last
+// expiry time go in the same transaction. Deletes fire triggers and reach
+// change data capture like any other DELETE.
+//
+// The TTL sweeper calls SweepExpired every Maintenance.TTLSweepInterval. It
+// returns the number of rows deleted.
+func (db *DB) SweepExpired(ctx context.Context) (int64, error) {
+	if db.closed.Load() {
+		return 0, ErrDatabaseClosed
+	}
+	if err := db.checkWritable(); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	tables := db.catalog.ListTables()
+	sort.Strings(tables)
+	var total int64
+	for _, name := range tables {
+		table, err := db.catalog.GetTable(name)
+		if err != nil || table.TTLColumn == "" || table.Temporary {
+			continue
+		}
+		idx := table.GetColumnIndex(table.TTLColumn)
+		if idx < 0 {
+			continue
+		}
+		n, err := db.sweepTable(ctx, name, table.TTLColumn, ttlCutoff(table.Columns[idx].Type, now))
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("sweep expired rows of %s: %w", name, err)
+		}
+	}
+	return total, nil
+}
+
+// ttlCutoff returns now as a value of a TTL column of colType: the UTC
+// "YYYY-MM-DD HH:MM:SS" strings temporal columns hold, or Unix seconds.
+func ttlCutoff(colType string, now time.Time) interface{} {
+	switch colType {
+	case "INTEGER":
+		return now.Unix()
+	case "DATE":
+		return now.UTC().Format("2006-01-02")
+	default:
+		return now.UTC().Format("2006-01-02 15:04:05")
+	}
+}
+
+// sweepTable deletes the rows of table whose column is at or before cutoff,
+// a batch per transaction.
+func (db *DB) sweepTable(ctx context.Context, table, column string, cutoff interface{}) (int64, error) {
+	batch := db.options.Maintenance.TTLBatchSize
+	if batch <= 0 {
+		batch = DefaultOptions().Maintenance.TTLBatchSize
+	}
+	t, col := schemaIdentifier(table, true), schemaIdentifier(column, true)
+	// The expiry time of the batch's last row bounds the batch; without one
+	// fewer than a batch of rows has expired.
+	boundSQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s <= ? ORDER BY %s LIMIT 1 OFFSET %d", col, t, col, col, batch-1)
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE %s <= ?", t, col)
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, last, err := db.sweepBatch(ctx, boundSQL, deleteSQL, cutoff)
+		total += n
+		if err != nil || last || n == 0 {
+			return total, err
+		}
+	}
+}
+
+// sweepBatch deletes one batch in its own transaction. last reports whether
+// the batch held every remaining expired row.
+func (db *DB) sweepBatch(ctx context.Context, boundSQL, deleteSQL string, cutoff interface{}) (n int64, last bool, err error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	rows, err := tx.Query(ctx, boundSQL, cutoff)
+	if err != nil {
+		return 0, false, err
+	}
+	bound := cutoff
+	last = true
+	if rows.Next() {
+		if err := rows.Scan(&bound); err != nil {
+			rows.Close()
+			return 0, false, err
+		}
+		last = false
+	}
+	rows.Close()
+	res, err := tx.Exec(ctx, deleteSQL, bound)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return res.RowsAffected, last, nil
+}
+
+// startTTLSweeper runs SweepExpired every TTLSweepInterval until Close.
+func (db *DB) startTTLSweeper() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	var once sync.Once
+	db.stopTTLSweeper = func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+	interval := db.options.Maintenance.TTLSweepInterval
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// A replica or follower sweeps nothing: the primary's deletes
+			// reach it through replication.
+			if db.checkWritable() != nil {
+				continue
+			}
+			if n, err := db.SweepExpired(ctx); err != nil && ctx.Err() == nil {
+				db.options.CoreStorage.Logger.Warnf("TTL sweep failed: %v", err)
+			} else if n > 0 {
+				db.options.CoreStorage.Logger.Debugf("TTL sweep deleted %d expired rows", n)
+			}
+		}
+	}()
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSweepExpiredDeletesInBatches(t *testing.T) {
+	db, err := Open(":memory:", &Options{Maintenance: MaintenanceConfig{TTLBatchSize: 2}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	mustExec(t, db, "CREATE TABLE sessions (id INTEGER PRIMARY KEY, expires_at TIMESTAMP) WITH (ttl_column = expires_at)")
+	mustExec(t, db, "CREATE TABLE tokens (id TEXT, expires INTEGER) WITH (ttl_column = expires)")
+	past := time.Now().Add(-time.Hour).UTC().Format("2006-01-02 15:04:05")
+	future := time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+	for i := 1; i <= 5; i++ {
+		if _, err := db.Exec(ctx, "INSERT INTO sessions VALUES (?, ?)", i, past); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO sessions VALUES (6, ?), (7, NULL)", future); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	now := time.Now().Unix()
+	if _, err := db.Exec(ctx, "INSERT INTO tokens VALUES ('old', ?), ('new', ?)", now-10, now+3600); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	n, err := db.SweepExpired(ctx)
+	if err != nil {
+		t.Fatalf("SweepExpired: %v", err)
+	}
+	if n != 6 {
+		t.Fatalf("SweepExpired deleted %d rows, want 6", n)
+	}
+	if got := countRows(t, db, "SELECT COUNT(*) FROM sessions"); got != 2 {
+		t.Fatalf("sessions left = %d, want 2", got)
+	}
+	var id string
+	if err := db.QueryRow(ctx, "SELECT id FROM tokens").Scan(&id); err != nil || id != "new" {
+		t.Fatalf("tokens left = %q, %v; want new", id, err)
+	}
+	if n, err := db.SweepExpired(ctx); err != nil || n != 0 {
+		t.Fatalf("second SweepExpired = %d, %v; want 0", n, err)
+	}
+}
+
+func TestTTLSweeperRunsInBackground(t *testing.T) {
+	db, err := Open(":memory:", &Options{Maintenance: MaintenanceConfig{
+		EnableTTLSweeper: true,
+		TTLSweepInterval: 10 * time.Millisecond,
+	}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	mustExec(t, db, "CREATE TABLE cache (k TEXT PRIMARY KEY, expires_at DATETIME) WITH (ttl_column = expires_at)")
+	mustExec(t, db, "INSERT INTO cache VALUES ('a', '2000-01-01 00:00:00'), ('b', '2999-01-01 00:00:00')")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for countRows(t, db, "SELECT COUNT(*) FROM cache") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expired row was not swept")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTTLColumnSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttl.db")
+	ctx := context.Background()
+	db := openPreparedTestDB(t, path)
+	for _, sql := range []string{
+		"CREATE TABLE bad (id INTEGER) WITH (ttl_column = missing)",
+		"CREATE TABLE bad (id INTEGER, note TEXT) WITH (ttl_column = note)",
+	} {
+		if _, err := db.Exec(ctx, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+	mustExec(t, db, "CREATE TABLE s (id INTEGER PRIMARY KEY, exp TIMESTAMP) WITH (ttl_column = exp)")
+	if _, err := db.Exec(ctx, "ALTER TABLE s DROP COLUMN exp"); err == nil {
+		t.Fatal("dropping the TTL column succeeded")
+	}
+	mustExec(t, db, "ALTER TABLE s RENAME COLUMN exp TO expires_at")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The TTL column survives a restart and follows the rename.
+	db = openPreparedTestDB(t, path)
+	defer db.Close()
+	table, err := db.catalog.GetTable("s")
+	if err != nil {
+		t.Fatalf("GetTable: %v", err)
+	}
+	if table.TTLColumn != "expires_at" {
+		t.Fatalf("TTLColumn = %q, want expires_at", table.TTLColumn)
+	}
+	ddl, err := db.TableSchema("s")
+	if err != nil {
+		t.Fatalf("TableSchema: %v", err)
+	}
+	if !strings.Contains(ddl, "WITH (ttl_column = expires_at)") {
+		t.Fatalf("schema SQL %q lacks the TTL column", ddl)
+	}
+	mustExec(t, db, "INSERT INTO s VALUES (1, '2000-01-01 00:00:00')")
+	if n, err := db.SweepExpired(ctx); err != nil || n != 1 {
+		t.Fatalf("SweepExpired = %d, %v; want 1", n, err)
+	}
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUpdateFromAndDeleteUsing(t *testing.T) {
+	db, err := Open(":memory:", &Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	mustExec(t, db, "CREATE TABLE accounts (id INTEGER PRIMARY KEY, balance INTEGER, note TEXT)")
+	mustExec(t, db, "INSERT INTO accounts VALUES (1, 0, 'one'), (2, 0, 'two'), (3, 0, 'three')")
+	mustExec(t, db, "CREATE TABLE deposits (account_id INTEGER, amount INTEGER)")
+	mustExec(t, db, "INSERT INTO deposits VALUES (1, 5), (1, 7), (2, 9)")
+
+	mustExec(t, db, "UPDATE accounts SET balance = d.amount FROM deposits d WHERE d.account_id = accounts.id AND d.amount > 6")
+	assertScalar(t, db, "SELECT balance FROM accounts WHERE id = 1", int64(7))
+	assertScalar(t, db, "SELECT balance FROM accounts WHERE id = 3", int64(0))
+
+	// Derived tables and views can be sources.
+	mustExec(t, db, "UPDATE accounts SET balance = s.total FROM (SELECT account_id, SUM(amount) AS total FROM deposits GROUP BY account_id) AS s WHERE s.account_id = accounts.id")
+	assertScalar(t, db, "SELECT balance FROM accounts WHERE id = 1", int64(12))
+	mustExec(t, db, "CREATE VIEW big_deposits AS SELECT account_id, amount * 100 AS cents FROM deposits")
+	mustExec(t, db, "UPDATE accounts SET balance = v.cents FROM big_deposits v WHERE v.account_id = accounts.id AND v.account_id = 2")
+	assertScalar(t, db, "SELECT balance FROM accounts WHERE id = 2", int64(900))
+
+	// A self-join reads the source rows, not the row being updated.
+	mustExec(t, db, "UPDATE accounts SET note = nxt.note FROM accounts AS nxt WHERE nxt.id = accounts.id + 1")
+	assertScalar(t, db, "SELECT note FROM accounts WHERE id = 1", "two")
+	assertScalar(t, db, "SELECT note FROM accounts WHERE id = 3", "three")
+
+	// Tables without a primary key update every matching row, duplicates included.
+	mustExec(t, db, "CREATE TABLE tags (k INTEGER, v TEXT)")
+	mustExec(t, db, "INSERT INTO tags VALUES (1, 'a'), (2, 'b'), (2, 'b'), (3, 'c')")
+	mustExec(t, db, "CREATE TABLE renames (k INTEGER, v TEXT)")
+	mustExec(t, db, "INSERT INTO renames VALUES (2, 'B'), (3, 'C')")
+	res, err := db.Exec(ctx, "UPDATE tags SET v = r.v FROM renames r WHERE r.k = tags.k")
+	if err != nil || res.RowsAffected != 3 {
+		t.Fatalf("UPDATE tags: affected %d, err %v; want 3", res.RowsAffected, err)
+	}
+	res, err = db.Exec(ctx, "DELETE FROM tags USING renames r WHERE r.k = tags.k AND r.v = 'B'")
+	if err != nil || res.RowsAffected != 2 {
+		t.Fatalf("DELETE tags: affected %d, err %v; want 2", res.RowsAffected, err)
+	}
+	assertScalar(t, db, "SELECT COUNT(*) FROM tags", int64(2))
+
+	// Composite primary keys.
+	mustExec(t, db, "CREATE TABLE stock (store INTEGER, sku TEXT, qty INTEGER, PRIMARY KEY (store, sku))")
+	mustExec(t, db, "INSERT INTO stock VALUES (1, 'x', 0), (1, 'y', 0), (2, 'x', 0)")
+	mustExec(t, db, "UPDATE stock SET qty = r.k FROM renames r WHERE r.k = stock.store + 1 AND stock.sku = 'x'")
+	assertScalar(t, db, "SELECT qty FROM stock WHERE store = 2 AND sku = 'x'", int64(3))
+	assertScalar(t, db, "SELECT qty FROM stock WHERE store = 1 AND sku = 'y'", int64(0))
+
+	// Rows written earlier in the transaction are seen, and ROLLBACK undoes
+	// both statements.
+	mustExec(t, db, "BEGIN")
+	mustExec(t, db, "INSERT INTO accounts VALUES (4, 0, 'four')")
+	mustExec(t, db, "INSERT INTO deposits VALUES (4, 40)")
+	mustExec(t, db, "UPDATE accounts SET balance = d.amount FROM deposits d WHERE d.account_id = accounts.id AND accounts.id = 4")
+	assertScalar(t, db, "SELECT balance FROM accounts WHERE id = 4", int64(40))
+	mustExec(t, db, "DELETE FROM accounts USING deposits d WHERE d.account_id = accounts.id AND d.amount = 9")
+	assertScalar(t, db, "SELECT COUNT(*) FROM accounts", int64(3))
+	mustExec(t, db, "ROLLBACK")
+	assertScalar(t, db, "SELECT COUNT(*) FROM accounts", int64(3))
+	assertScalar(t, db, "SELECT balance FROM accounts WHERE id = 2", int64(900))
+}
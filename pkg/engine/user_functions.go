@@ -0,0 +1,61 @@
+package engine
+
+import "github.com/cobaltdb/cobaltdb/pkg/catalog"
+
+// Aggregator accumulates one group of a user-defined aggregate: Step is
+// called once per row with that row's arguments, then Result once.
+type Aggregator = catalog.Aggregator
+
+// FuncOption configures RegisterFunc and RegisterAggregate.
+type FuncOption func(*funcConfig)
+
+type funcConfig struct {
+	numArgs       int
+	deterministic bool
+}
+
+// Deterministic declares that the function's result depends only on its
+// arguments, so queries calling it may be served from the query cache.
+// Functions are volatile unless marked deterministic.
+func Deterministic() FuncOption {
+	return func(c *funcConfig) { c.deterministic = true }
+}
+
+// NumArgs makes calls with any other number of arguments fail. By default
+// any count is accepted.
+func NumArgs(n int) FuncOption {
+	return func(c *funcConfig) { c.numArgs = n }
+}
+
+func newFuncConfig(opts []FuncOption) funcConfig {
+	cfg := funcConfig{numArgs: -1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// RegisterFunc makes fn callable from SQL as the scalar function name
+// (case-insensitive) in any expression: select lists, WHERE, ORDER BY,
+// defaults and so on. Arguments arrive as int64, float64, string, []byte,
+// bool or nil; Go integer and float results are widened to int64 and
+// float64. Built-in functions cannot be redefined.
+func (db *DB) RegisterFunc(name string, fn func(args []interface{}) (interface{}, error), opts ...FuncOption) error {
+	cfg := newFuncConfig(opts)
+	return db.catalog.RegisterFunction(name, &catalog.UserFunction{
+		Fn:            fn,
+		NumArgs:       cfg.numArgs,
+		Deterministic: cfg.deterministic,
+	})
+}
+
+// RegisterAggregate makes name an aggregate function usable with GROUP BY,
+// DISTINCT and HAVING. factory is called once per group. Queries calling a
+// user aggregate are never cached, so Deterministic has no effect here.
+func (db *DB) RegisterAggregate(name string, factory func() Aggregator, opts ...FuncOption) error {
+	cfg := newFuncConfig(opts)
+	return db.catalog.RegisterAggregate(name, &catalog.UserAggregate{
+		New:     factory,
+		NumArgs: cfg.numArgs,
+	})
+}
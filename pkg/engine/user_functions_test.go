@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// productAgg multiplies its integer argument over a group.
+type productAgg struct {
+	product int64
+	rows    int
+}
+
+func (a *productAgg) Step(args []interface{}) error {
+	n, ok := args[0].(int64)
+	if !ok {
+		return errors.New("integer argument required")
+	}
+	if a.rows == 0 {
+		a.product = 1
+	}
+	a.product *= n
+	a.rows++
+	return nil
+}
+
+func (a *productAgg) Result() (interface{}, error) {
+	if a.rows == 0 {
+		return nil, nil
+	}
+	return a.product, nil
+}
+
+// udfItems is the table the user-defined function tests aggregate over.
+var udfItems = []string{
+	"CREATE TABLE items (id INTEGER PRIMARY KEY, grp TEXT, qty INTEGER, name TEXT)",
+	"INSERT INTO items VALUES (1, 'a', 2, 'bolt'), (2, 'a', 3, 'nut'), (3, 'b', 4, 'gear'), (4, 'b', 4, 'cog')",
+}
+
+func queryStrings(t *testing.T, db *DB, sql string, args ...interface{}) []string {
+	t.Helper()
+	rows, err := db.Query(context.Background(), sql, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		vals := make([]interface{}, len(rows.Columns()))
+		ptrs := make([]interface{}, len(vals))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		parts := make([]string, len(vals))
+		for i, v := range vals {
+			if v == nil {
+				parts[i] = "NULL"
+			} else {
+				parts[i] = fmt.Sprint(v)
+			}
+		}
+		out = append(out, strings.Join(parts, "|"))
+	}
+	return out
+}
+
+func TestRegisterFuncScalar(t *testing.T) {
+	db := openTestDB(t, nil, udfItems...)
+	err := db.RegisterFunc("reverse_text", func(args []interface{}) (interface{}, error) {
+		s, _ := args[0].(string)
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r), nil
+	}, NumArgs(1), Deterministic())
+	if err != nil {
+		t.Fatalf("RegisterFunc: %v", err)
+	}
+	if err := db.RegisterFunc("scale", func(args []interface{}) (interface{}, error) {
+		a, _ := args[0].(int64)
+		b, _ := args[1].(int64)
+		return int32(a * b), nil // widened to int64
+	}, NumArgs(2)); err != nil {
+		t.Fatalf("RegisterFunc: %v", err)
+	}
+
+	got := queryStrings(t, db, "SELECT Reverse_Text(name), scale(qty, 10) FROM items WHERE reverse_text(name) = 'tun' OR scale(qty, id) = 16 ORDER BY id")
+	want := []string{"tun|30", "goc|40"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	ctx := context.Background()
+	if _, err := db.Query(ctx, "SELECT scale(1)"); err == nil || !strings.Contains(err.Error(), "expects 2 arguments") {
+		t.Fatalf("wrong argument count: err = %v", err)
+	}
+	if err := db.RegisterFunc("fail", func([]interface{}) (interface{}, error) {
+		return nil, errors.New("boom")
+	}); err != nil {
+		t.Fatalf("RegisterFunc: %v", err)
+	}
+	if _, err := db.Query(ctx, "SELECT fail()"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("failing function: err = %v", err)
+	}
+	if err := db.RegisterFunc("upper", func([]interface{}) (interface{}, error) { return nil, nil }); err == nil {
+		t.Fatal("expected error redefining a built-in function")
+	}
+	if err := db.RegisterFunc("bad name", func([]interface{}) (interface{}, error) { return nil, nil }); err == nil {
+		t.Fatal("expected error for an invalid name")
+	}
+}
+
+func TestRegisterFuncVolatileNotCached(t *testing.T) {
+	db := openTestDB(t, nil, udfItems...)
+	var calls int64
+	counter := func([]interface{}) (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+	if err := db.RegisterFunc("next_tick", counter, NumArgs(0)); err != nil {
+		t.Fatalf("RegisterFunc: %v", err)
+	}
+	first := queryStrings(t, db, "SELECT next_tick() FROM items WHERE id = 1")
+	second := queryStrings(t, db, "SELECT next_tick() FROM items WHERE id = 1")
+	if len(first) != 1 || len(second) != 1 || first[0] == second[0] {
+		t.Fatalf("volatile function result was reused: %v then %v", first, second)
+	}
+}
+
+func TestRegisterAggregate(t *testing.T) {
+	db := openTestDB(t, nil, udfItems...)
+	if err := db.RegisterAggregate("product", func() Aggregator { return &productAgg{} }, NumArgs(1)); err != nil {
+		t.Fatalf("RegisterAggregate: %v", err)
+	}
+
+	tests := []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT product(qty) FROM items", []string{"96"}},
+		{"SELECT grp, PRODUCT(qty) FROM items GROUP BY grp ORDER BY grp", []string{"a|6", "b|16"}},
+		{"SELECT grp, product(DISTINCT qty) FROM items GROUP BY grp ORDER BY grp", []string{"a|6", "b|4"}},
+		{"SELECT grp FROM items GROUP BY grp HAVING product(qty) > 10", []string{"b"}},
+		{"SELECT product(qty) FROM items WHERE id > 10", []string{"NULL"}},
+	}
+	for _, tt := range tests {
+		if got := queryStrings(t, db, tt.sql); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+
+	if err := db.RegisterAggregate("count", func() Aggregator { return &productAgg{} }); err == nil {
+		t.Fatal("expected error redefining a built-in aggregate")
+	}
+}
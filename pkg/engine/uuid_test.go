@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+var canonicalUUID = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-([47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDColumnsAndFunctions(t *testing.T) {
+	db, err := Open(":memory:", &Options{CoreStorage: CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	var v4, v7 string
+	if err := db.QueryRow(ctx, "SELECT UUID(), UUID(7)").Scan(&v4, &v7); err != nil {
+		t.Fatalf("SELECT UUID(): %v", err)
+	}
+	if m := canonicalUUID.FindStringSubmatch(v4); m == nil || m[1] != "4" {
+		t.Fatalf("UUID() = %q, want a version 4 UUID", v4)
+	}
+	if m := canonicalUUID.FindStringSubmatch(v7); m == nil || m[1] != "7" {
+		t.Fatalf("UUID(7) = %q, want a version 7 UUID", v7)
+	}
+	if _, err := db.Query(ctx, "SELECT UUID(5)"); err == nil {
+		t.Fatal("UUID(5) succeeded")
+	}
+
+	// A column may still be named uuid.
+	mustExec(t, db, "CREATE TABLE items (id UUID PRIMARY KEY DEFAULT (UUID_V7()), seq INTEGER, uuid TEXT)")
+	const n = 300
+	for i := 0; i < n; i++ {
+		mustExec(t, db, fmt.Sprintf("INSERT INTO items (seq, uuid) VALUES (%d, 'x')", i))
+	}
+	rows, err := db.Query(ctx, "SELECT id, seq FROM items ORDER BY id")
+	if err != nil {
+		t.Fatalf("SELECT: %v", err)
+	}
+	want := int64(0)
+	for rows.Next() {
+		var id string
+		var seq int64
+		if err := rows.Scan(&id, &seq); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		if m := canonicalUUID.FindStringSubmatch(id); m == nil || m[1] != "7" {
+			t.Fatalf("default id %q is not a version 7 UUID", id)
+		}
+		if seq != want {
+			t.Fatalf("row %d in key order has seq %d: UUID_V7 keys are not in generation order", want, seq)
+		}
+		want++
+	}
+	rows.Close()
+	if want != n {
+		t.Fatalf("got %d rows, want %d", want, n)
+	}
+
+	// Any spelling of a UUID, and its 16 bytes, store the canonical text.
+	const id = "0190a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b"
+	mustExec(t, db, "INSERT INTO items (id, seq) VALUES ('{0190A1B2-C3D4-7E5F-8A9B-0C1D2E3F4A5B}', -1)")
+	if _, err := db.Exec(ctx, "INSERT INTO items (id, seq) VALUES (UUID_TO_BIN('0190a1b2c3d47e5f8a9b0c1d2e3f4a5b'), -2)"); err == nil {
+		t.Fatal("the same UUID as bytes did not collide with its text form")
+	}
+	var got string
+	var seq int64
+	if err := db.QueryRow(ctx, "SELECT id, seq FROM items WHERE id = BIN_TO_UUID(?)", "0190A1B2-C3D4-7E5F-8A9B-0C1D2E3F4A5B").Scan(&got, &seq); err != nil {
+		t.Fatalf("lookup by canonical UUID: %v", err)
+	}
+	if got != id || seq != -1 {
+		t.Fatalf("lookup = (%q, %d), want (%q, -1)", got, seq, id)
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO items (id, seq) VALUES ('not-a-uuid', -3)"); err == nil {
+		t.Fatal("invalid UUID accepted")
+	}
+
+	var size int64
+	var back string
+	if err := db.QueryRow(ctx, "SELECT LENGTH(UUID_TO_BIN(?)), BIN_TO_UUID(UUID_TO_BIN(?))", id, id).Scan(&size, &back); err != nil {
+		t.Fatalf("UUID_TO_BIN round trip: %v", err)
+	}
+	if size != 16 || back != id {
+		t.Fatalf("UUID_TO_BIN round trip = (%d, %q), want (16, %q)", size, back, id)
+	}
+}
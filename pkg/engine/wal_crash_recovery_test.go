@@ -121,6 +121,83 @@ func TestWALCrashRecoveryIgnoresOpenTransaction(t *testing.T) {
 	assertScalar(t, recovered, "SELECT COUNT(*) FROM accounts WHERE id = 4", int64(0))
 }
 
+func TestWALRecoversSchemaAfterProcessExit(t *testing.T) {
+	if os.Getenv("COBALTDB_WAL_SCHEMA_HELPER") == "1" {
+		runWALSchemaWriter(t)
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "schema.db")
+
+	db, err := Open(dbPath, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("open setup db: %v", err)
+	}
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("checkpoint setup db: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("close setup db: %v", err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestWALRecoversSchemaAfterProcessExit")
+	cmd.Env = append(os.Environ(),
+		"COBALTDB_WAL_SCHEMA_HELPER=1",
+		"COBALTDB_WAL_CRASH_DB="+dbPath,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("schema helper failed: %v\n%s", err, out)
+	}
+
+	recovered, err := Open(dbPath, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("open recovered db: %v", err)
+	}
+	defer recovered.Close()
+
+	assertScalar(t, recovered, "SELECT COUNT(*) FROM notes", int64(2))
+	assertScalar(t, recovered, "SELECT COUNT(*) FROM long_notes", int64(1))
+	assertScalar(t, recovered, "SELECT body FROM notes WHERE tag = 'x'", "second")
+	// The recovered row sequence must not hand out the keys of the
+	// recovered rows again.
+	if _, err := recovered.Exec(context.Background(), "INSERT INTO notes VALUES ('third', 'y')"); err != nil {
+		t.Fatalf("insert after recovery: %v", err)
+	}
+	assertScalar(t, recovered, "SELECT COUNT(*) FROM notes", int64(3))
+}
+
+func runWALSchemaWriter(t *testing.T) {
+	t.Helper()
+
+	dbPath := os.Getenv("COBALTDB_WAL_CRASH_DB")
+	if dbPath == "" {
+		t.Fatal("COBALTDB_WAL_CRASH_DB is required")
+	}
+
+	db, err := Open(dbPath, durabilityTestOptions())
+	if err != nil {
+		t.Fatalf("open schema writer db: %v", err)
+	}
+
+	ctx := context.Background()
+	statements := []string{
+		"CREATE TABLE notes (body TEXT, tag TEXT)",
+		"CREATE INDEX idx_notes_tag ON notes (tag)",
+		"INSERT INTO notes VALUES ('first', 'w')",
+		"INSERT INTO notes VALUES ('second', 'x')",
+		"CREATE VIEW long_notes AS SELECT body FROM notes WHERE body = 'second'",
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(ctx, stmt); err != nil {
+			t.Fatalf("exec %q: %v", stmt, err)
+		}
+	}
+
+	// Intentionally do not call db.Close or Checkpoint.
+}
+
 func runWALCrashWriter(t *testing.T) {
 	t.Helper()
 
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets covers 1µs to about 17s in powers of two.
+const latencyBuckets = 25
+
+// LatencyHistogram counts durations in power-of-two microsecond buckets.
+// Unlike HistogramMetric it keeps no samples and takes no locks, so hot
+// paths such as WAL commits can observe every call. The zero value is ready
+// to use.
+type LatencyHistogram struct {
+	counts [latencyBuckets]atomic.Uint64
+	sum    atomic.Int64
+}
+
+// Observe records one duration.
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := bits.Len64(uint64(d / time.Microsecond))
+	if i >= latencyBuckets {
+		i = latencyBuckets - 1
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Snapshot returns the counts recorded so far.
+func (h *LatencyHistogram) Snapshot() LatencySnapshot {
+	var s LatencySnapshot
+	for i := range h.counts {
+		n := h.counts[i].Load()
+		if n == 0 {
+			continue
+		}
+		s.Count += n
+		s.Buckets = append(s.Buckets, LatencyBucket{
+			UpperBound: time.Duration(1<<i) * time.Microsecond,
+			Count:      n,
+		})
+	}
+	s.Sum = time.Duration(h.sum.Load())
+	return s
+}
+
+// LatencySnapshot is a point-in-time copy of a LatencyHistogram.
+type LatencySnapshot struct {
+	Count   uint64          `json:"count"`
+	Sum     time.Duration   `json:"sum"`
+	Buckets []LatencyBucket `json:"buckets,omitempty"` // non-empty buckets, ascending
+}
+
+// LatencyBucket counts the durations below UpperBound and at or above the
+// previous bucket's bound. The last bucket also holds everything longer.
+type LatencyBucket struct {
+	UpperBound time.Duration `json:"le"`
+	Count      uint64        `json:"count"`
+}
+
+// Mean returns the average duration, or 0 with no observations.
+func (s LatencySnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Quantile returns the upper bound of the bucket holding the q-th quantile
+// (0 < q <= 1), an overestimate of at most a factor of two.
+func (s LatencySnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(s.Count))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for _, b := range s.Buckets {
+		seen += b.Count
+		if seen >= rank {
+			return b.UpperBound
+		}
+	}
+	return s.Buckets[len(s.Buckets)-1].UpperBound
+}
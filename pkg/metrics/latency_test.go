@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	var h LatencyHistogram
+	if s := h.Snapshot(); s.Count != 0 || s.Mean() != 0 || s.Quantile(0.99) != 0 {
+		t.Fatalf("empty snapshot = %+v", s)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 225; j++ {
+				h.Observe(100 * time.Microsecond)
+			}
+			for j := 0; j < 25; j++ {
+				h.Observe(10 * time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	h.Observe(time.Hour) // lands in the last bucket
+
+	s := h.Snapshot()
+	if s.Count != 1001 {
+		t.Fatalf("Count = %d, want 1001", s.Count)
+	}
+	if want := 900*100*time.Microsecond + 100*10*time.Millisecond + time.Hour; s.Sum != want {
+		t.Fatalf("Sum = %v, want %v", s.Sum, want)
+	}
+	if len(s.Buckets) != 3 {
+		t.Fatalf("buckets = %+v, want 3", s.Buckets)
+	}
+	// 100µs falls in [64µs, 128µs), 10ms in [8.192ms, 16.384ms).
+	if got := s.Quantile(0.5); got != 128*time.Microsecond {
+		t.Errorf("p50 = %v, want 128µs", got)
+	}
+	if got := s.Quantile(0.95); got != 16384*time.Microsecond {
+		t.Errorf("p95 = %v, want 16.384ms", got)
+	}
+	if got := s.Quantile(1); got != s.Buckets[2].UpperBound {
+		t.Errorf("p100 = %v, want the last bucket", got)
+	}
+}
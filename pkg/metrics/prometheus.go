@@ -2,8 +2,10 @@ package metrics
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -50,7 +52,49 @@ func (p *PrometheusMetrics) Handler() http.HandlerFunc {
 		p.writeSystemMetrics(w)
 		p.writeQueryMetrics(w)
 		p.writeStorageMetrics(w)
+
+		globalPrometheusSource.RLock()
+		source := globalPrometheusSource.source
+		globalPrometheusSource.RUnlock()
+		if source != nil {
+			source.WritePrometheus(w)
+		}
+	}
+}
+
+// PrometheusSource writes its own metric families in the Prometheus text
+// format. The database engine registers one so scrapes include its
+// statement and WAL counters.
+type PrometheusSource interface {
+	WritePrometheus(w io.Writer)
+}
+
+// WritePrometheusHistogram writes the bucket, sum and count samples of s as
+// a histogram in seconds. labels, such as `type="select"`, are added to each
+// sample; the caller writes the HELP and TYPE lines. Every bucket bound is
+// written, empty or not, so the series stay the same between scrapes.
+func WritePrometheusHistogram(w io.Writer, name, labels string, s LatencySnapshot) {
+	prefix := ""
+	if labels != "" {
+		prefix = labels + ","
+	}
+	var cumulative uint64
+	next := 0
+	// The last bucket also holds longer durations, so it is left to +Inf.
+	for i := 0; i < latencyBuckets-1; i++ {
+		bound := time.Duration(1<<i) * time.Microsecond
+		for next < len(s.Buckets) && s.Buckets[next].UpperBound <= bound {
+			cumulative += s.Buckets[next].Count
+			next++
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%g\"} %d\n", name, prefix, bound.Seconds(), cumulative)
 	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, s.Count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %g\n", name, labels, s.Sum.Seconds())
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, s.Count)
 }
 
 // writeTransactionMetrics writes transaction-related metrics
@@ -144,9 +188,30 @@ func (p *PrometheusMetrics) writeQueryMetrics(w http.ResponseWriter) {
 		fmt.Fprintf(w, "# HELP cobaltdb_slow_queries_total Total number of slow queries\n")
 		fmt.Fprintf(w, "# TYPE cobaltdb_slow_queries_total counter\n")
 		fmt.Fprintf(w, "cobaltdb_slow_queries_total %d\n", total)
+
+		groups := slowLog.GroupByFingerprint(maxSlowQueryFingerprintSeries)
+		if len(groups) > 0 {
+			fmt.Fprintf(w, "# HELP cobaltdb_slow_query_fingerprint_seconds Time spent in logged slow queries, by statement fingerprint\n")
+			fmt.Fprintf(w, "# TYPE cobaltdb_slow_query_fingerprint_seconds summary\n")
+			for _, g := range groups {
+				label := "fingerprint=\"" + escapePrometheusLabel(g.Fingerprint) + "\""
+				fmt.Fprintf(w, "cobaltdb_slow_query_fingerprint_seconds_sum{%s} %g\n", label, g.TotalDuration.Seconds())
+				fmt.Fprintf(w, "cobaltdb_slow_query_fingerprint_seconds_count{%s} %d\n", label, g.Count)
+			}
+		}
 	}
 }
 
+// maxSlowQueryFingerprintSeries bounds the per-fingerprint slow query series
+// to the fingerprints with the most slow query time.
+const maxSlowQueryFingerprintSeries = 20
+
+// escapePrometheusLabel escapes a label value for the text exposition
+// format.
+func escapePrometheusLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
 // writeStorageMetrics writes storage-related metrics
 func (p *PrometheusMetrics) writeStorageMetrics(w http.ResponseWriter) {
 	stats := StorageMetrics{}
@@ -213,6 +278,12 @@ var globalStorageMetrics struct {
 	generation uint64
 }
 
+var globalPrometheusSource struct {
+	sync.RWMutex
+	source     PrometheusSource
+	generation uint64
+}
+
 // GetPrometheusHandler returns the global Prometheus metrics HTTP handler
 func GetPrometheusHandler() http.HandlerFunc {
 	return globalPrometheusMetrics.Handler()
@@ -264,3 +335,21 @@ func RegisterStorageMetricsProvider(provider func() StorageMetrics) func() {
 		}
 	}
 }
+
+// RegisterPrometheusSource adds source's metrics to the global Prometheus
+// handler, replacing any source registered before. Passing nil clears the
+// current registration.
+func RegisterPrometheusSource(source PrometheusSource) func() {
+	globalPrometheusSource.Lock()
+	defer globalPrometheusSource.Unlock()
+	globalPrometheusSource.generation++
+	generation := globalPrometheusSource.generation
+	globalPrometheusSource.source = source
+	return func() {
+		globalPrometheusSource.Lock()
+		defer globalPrometheusSource.Unlock()
+		if globalPrometheusSource.generation == generation {
+			globalPrometheusSource.source = nil
+		}
+	}
+}
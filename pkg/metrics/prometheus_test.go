@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -130,6 +131,9 @@ func TestWriteQueryMetrics(t *testing.T) {
 	if !strings.Contains(w.Body.String(), "cobaltdb_slow_queries_total 1") {
 		t.Error("Output should contain registered slow query count")
 	}
+	if !strings.Contains(w.Body.String(), `cobaltdb_slow_query_fingerprint_seconds_count{fingerprint="SELECT ?"} 1`) {
+		t.Errorf("Output should contain the slow query fingerprint series:\n%s", w.Body.String())
+	}
 }
 
 // TestWriteStorageMetrics tests writing storage metrics
@@ -175,3 +179,51 @@ func TestWriteStorageMetrics(t *testing.T) {
 		t.Error("Output should contain registered hit ratio")
 	}
 }
+
+type testPrometheusSource string
+
+func (s testPrometheusSource) WritePrometheus(w io.Writer) {
+	io.WriteString(w, string(s))
+}
+
+func TestPrometheusHandlerWritesRegisteredSource(t *testing.T) {
+	unregister := RegisterPrometheusSource(testPrometheusSource("cobaltdb_test_metric 42\n"))
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		GetPrometheusHandler()(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w.Body.String()
+	}
+	if !strings.Contains(scrape(), "cobaltdb_test_metric 42\n") {
+		t.Fatal("scrape does not include the registered source")
+	}
+	unregister()
+	if strings.Contains(scrape(), "cobaltdb_test_metric") {
+		t.Fatal("scrape includes an unregistered source")
+	}
+}
+
+func TestWritePrometheusHistogram(t *testing.T) {
+	var h LatencyHistogram
+	h.Observe(3 * time.Microsecond)
+	h.Observe(3 * time.Microsecond)
+	h.Observe(time.Minute) // beyond the last finite bucket
+
+	var b strings.Builder
+	WritePrometheusHistogram(&b, "op_seconds", `type="read"`, h.Snapshot())
+	out := b.String()
+	for _, want := range []string{
+		`op_seconds_bucket{type="read",le="1e-06"} 0`,
+		`op_seconds_bucket{type="read",le="4e-06"} 2`,
+		`op_seconds_bucket{type="read",le="8.388608"} 2`,
+		`op_seconds_bucket{type="read",le="+Inf"} 3`,
+		`op_seconds_sum{type="read"} 60.000006`,
+		`op_seconds_count{type="read"} 3`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("histogram output lacks %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "_bucket{"); n != latencyBuckets {
+		t.Errorf("wrote %d buckets, want %d", n, latencyBuckets)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/query"
 )
 
 const (
@@ -21,6 +24,7 @@ const (
 type SlowQueryEntry struct {
 	Timestamp    time.Time     `json:"timestamp"`
 	SQL          string        `json:"sql"`
+	Fingerprint  string        `json:"fingerprint,omitempty"` // query.Fingerprint(SQL)
 	Duration     time.Duration `json:"duration_ms"`
 	RowsAffected int64         `json:"rows_affected,omitempty"`
 	RowsReturned int64         `json:"rows_returned,omitempty"`
@@ -29,6 +33,7 @@ type SlowQueryEntry struct {
 type slowQueryEntryJSON struct {
 	Timestamp    time.Time `json:"timestamp"`
 	SQL          string    `json:"sql"`
+	Fingerprint  string    `json:"fingerprint,omitempty"`
 	DurationMS   int64     `json:"duration_ms"`
 	RowsAffected int64     `json:"rows_affected,omitempty"`
 	RowsReturned int64     `json:"rows_returned,omitempty"`
@@ -38,6 +43,7 @@ func (e SlowQueryEntry) MarshalJSON() ([]byte, error) {
 	return json.Marshal(slowQueryEntryJSON{
 		Timestamp:    e.Timestamp,
 		SQL:          e.SQL,
+		Fingerprint:  e.Fingerprint,
 		DurationMS:   e.Duration.Milliseconds(),
 		RowsAffected: e.RowsAffected,
 		RowsReturned: e.RowsReturned,
@@ -51,6 +57,7 @@ func (e *SlowQueryEntry) UnmarshalJSON(data []byte) error {
 	}
 	e.Timestamp = entry.Timestamp
 	e.SQL = entry.SQL
+	e.Fingerprint = entry.Fingerprint
 	e.Duration = time.Duration(entry.DurationMS) * time.Millisecond
 	e.RowsAffected = entry.RowsAffected
 	e.RowsReturned = entry.RowsReturned
@@ -101,6 +108,7 @@ func (s *SlowQueryLog) Log(sql string, duration time.Duration, rowsAffected, row
 	entry := SlowQueryEntry{
 		Timestamp:    time.Now().UTC(),
 		SQL:          truncateSlowQuerySQL(sql),
+		Fingerprint:  truncateSlowQuerySQL(query.Fingerprint(sql)),
 		Duration:     duration,
 		RowsAffected: rowsAffected,
 		RowsReturned: rowsReturned,
@@ -309,6 +317,44 @@ func (s *SlowQueryLog) GetStats() (total int, avgDuration time.Duration) {
 	return total, totalDuration / time.Duration(total)
 }
 
+// SlowQueryGroup summarizes the slow query entries sharing a fingerprint.
+type SlowQueryGroup struct {
+	Fingerprint   string        `json:"fingerprint"`
+	Count         int           `json:"count"`
+	TotalDuration time.Duration `json:"total_duration"`
+	MaxDuration   time.Duration `json:"max_duration"`
+	LastSQL       string        `json:"last_sql"` // Most recent statement of the group
+}
+
+// GroupByFingerprint groups the in-memory entries by fingerprint and returns
+// the limit groups with the largest total duration (all of them when limit
+// <= 0), largest first.
+func (s *SlowQueryLog) GroupByFingerprint(limit int) []SlowQueryGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index := make(map[string]int)
+	var groups []SlowQueryGroup
+	for _, e := range s.entries {
+		i, ok := index[e.Fingerprint]
+		if !ok {
+			i = len(groups)
+			index[e.Fingerprint] = i
+			groups = append(groups, SlowQueryGroup{Fingerprint: e.Fingerprint})
+		}
+		g := &groups[i]
+		g.Count++
+		g.TotalDuration += e.Duration
+		g.MaxDuration = max(g.MaxDuration, e.Duration)
+		g.LastSQL = e.SQL
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].TotalDuration > groups[j].TotalDuration })
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+	return groups
+}
+
 // Clear clears all entries
 func (s *SlowQueryLog) Clear() {
 	s.mu.Lock()
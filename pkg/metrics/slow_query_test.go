@@ -392,3 +392,29 @@ func TestSlowQueryLogRows(t *testing.T) {
 		t.Errorf("Expected RowsReturned 50, got %d", entries[1].RowsReturned)
 	}
 }
+
+func TestSlowQueryLogGroupByFingerprint(t *testing.T) {
+	log := NewSlowQueryLog(true, time.Millisecond, 100, "")
+	log.Log("SELECT * FROM users WHERE id = 1", 10*time.Millisecond, 0, 1)
+	log.Log("select * from users where id = 2", 30*time.Millisecond, 0, 1)
+	log.Log("DELETE FROM sessions WHERE expires < 100", 20*time.Millisecond, 5, 0)
+
+	entries := log.GetEntries(0)
+	if entries[0].Fingerprint != "SELECT * FROM users WHERE id = ?" {
+		t.Fatalf("Fingerprint = %q", entries[0].Fingerprint)
+	}
+
+	groups := log.GroupByFingerprint(0)
+	if len(groups) != 2 {
+		t.Fatalf("groups = %+v, want 2", groups)
+	}
+	g := groups[0]
+	if g.Fingerprint != "SELECT * FROM users WHERE id = ?" || g.Count != 2 ||
+		g.TotalDuration != 40*time.Millisecond || g.MaxDuration != 30*time.Millisecond ||
+		g.LastSQL != "select * from users where id = 2" {
+		t.Fatalf("top group = %+v", g)
+	}
+	if top := log.GroupByFingerprint(1); len(top) != 1 || top[0].Fingerprint != g.Fingerprint {
+		t.Fatalf("GroupByFingerprint(1) = %+v", top)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestParallelSelectRows(t *testing.T) {
@@ -186,3 +187,66 @@ func TestParallelSelectRowsCapsExcessiveWorkers(t *testing.T) {
 		t.Fatalf("expected at most %d worker calls, got %d", maxParallelWorkers, calls.Load())
 	}
 }
+
+func TestPoolRun(t *testing.T) {
+	p := NewPool(3)
+	var running, peak, done atomic.Int32
+	p.Run(20, func(int) {
+		n := running.Add(1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		done.Add(1)
+	})
+	if done.Load() != 20 {
+		t.Fatalf("ran %d tasks, want 20", done.Load())
+	}
+	// Three workers plus the caller running overflow tasks inline.
+	if peak.Load() > 4 {
+		t.Fatalf("peak concurrency %d, want at most 4", peak.Load())
+	}
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("recovered %v, want the task's panic", r)
+		}
+	}()
+	p.Run(4, func(i int) {
+		if i == 2 {
+			panic("boom")
+		}
+	})
+}
+
+func TestMapRanges(t *testing.T) {
+	if got := Partition(10, 3); len(got) != 3 || got[0] != [2]int{0, 4} || got[2] != [2]int{8, 10} {
+		t.Fatalf("Partition(10, 3) = %v", got)
+	}
+	if got := Partition(0, 3); got != nil {
+		t.Fatalf("Partition(0, 3) = %v, want nil", got)
+	}
+
+	double := func(start, end int) []int {
+		out := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			out = append(out, i*2)
+		}
+		return out
+	}
+	for _, p := range []*Pool{nil, NewPool(4)} {
+		got := MapRanges(p, 1000, 7, double)
+		if len(got) != 1000 {
+			t.Fatalf("len = %d, want 1000", len(got))
+		}
+		for i, v := range got {
+			if v != i*2 {
+				t.Fatalf("got[%d] = %d, want %d; partitions must merge in order", i, v, i*2)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package parallel
+
+import "sync"
+
+// Pool bounds the worker goroutines that parallel operators use across all
+// queries. When every worker is busy, Run executes tasks on the calling
+// goroutine instead of waiting, so an operator never stalls behind another
+// query and nested use cannot deadlock. A nil Pool runs everything serially.
+type Pool struct {
+	slots chan struct{}
+}
+
+// NewPool returns a pool of up to workers goroutines (0 = runtime.NumCPU()).
+func NewPool(workers int) *Pool {
+	return &Pool{slots: make(chan struct{}, defaultWorkers(workers))}
+}
+
+// Size returns the number of workers.
+func (p *Pool) Size() int {
+	if p == nil {
+		return 1
+	}
+	return cap(p.slots)
+}
+
+// Run calls fn(0) ... fn(tasks-1) and returns once all have finished. Tasks
+// run on free workers or, failing that, on the caller. A panic in a task is
+// re-raised on the caller after the others finish.
+func (p *Pool) Run(tasks int, fn func(task int)) {
+	if p == nil || tasks <= 1 {
+		for i := 0; i < tasks; i++ {
+			fn(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	var pc panicCapture
+	for i := 0; i < tasks; i++ {
+		select {
+		case p.slots <- struct{}{}:
+			wg.Add(1)
+			go func(task int) {
+				defer func() { <-p.slots }()
+				defer wg.Done()
+				defer pc.recoverWorker()
+				fn(task)
+			}(i)
+		default:
+			func() {
+				defer pc.recoverWorker()
+				fn(i)
+			}()
+		}
+	}
+	wg.Wait()
+	pc.repanic()
+}
+
+// Partition splits n items into at most parts contiguous [start, end) ranges
+// of near-equal size, in order.
+func Partition(n, parts int) [][2]int {
+	if n <= 0 {
+		return nil
+	}
+	if parts < 1 {
+		parts = 1
+	}
+	sz := chunkSize(n, parts)
+	ranges := make([][2]int, 0, (n+sz-1)/sz)
+	for start := 0; start < n; start += sz {
+		end := start + sz
+		if end > n {
+			end = n
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// MapRanges runs fn over the partitions of n items on p and concatenates
+// the results in partition order, so the output matches a serial run over
+// the same input.
+func MapRanges[T any](p *Pool, n, parts int, fn func(start, end int) []T) []T {
+	ranges := Partition(n, parts)
+	if len(ranges) <= 1 {
+		if n <= 0 {
+			return nil
+		}
+		return fn(0, n)
+	}
+	results := make([][]T, len(ranges))
+	p.Run(len(ranges), func(i int) {
+		results[i] = fn(ranges[i][0], ranges[i][1])
+	})
+	total := 0
+	for _, r := range results {
+		total += len(r)
+	}
+	merged := make([]T, 0, total)
+	for _, r := range results {
+		merged = append(merged, r...)
+	}
+	return merged
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/cobaltdb/cobaltdb/pkg/engine"
 	"github.com/cobaltdb/cobaltdb/pkg/logger"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 )
 
 const (
@@ -512,7 +513,8 @@ func (s *MySQLServer) handleConnection(conn net.Conn) {
 		connID:      connID,
 		connectTime: time.Now(),
 	}
-	client.ctx, client.cancel = context.WithCancel(context.Background())
+	session := &engine.Session{}
+	client.ctx, client.cancel = context.WithCancel(engine.WithSession(context.Background(), session))
 
 	// Send handshake
 	if err := client.sendHandshake(); err != nil {
@@ -544,6 +546,11 @@ func (s *MySQLServer) handleConnection(conn net.Conn) {
 			}
 			return
 		}
+		// MASKED columns read unmasked only with the UNMASK permission.
+		username := client.username
+		session.SetUnmask(func(table string) bool {
+			return authenticator.HasPermission(username, "", table, auth.ActionUnmask)
+		})
 	}
 
 	// Send OK packet
@@ -951,13 +958,13 @@ func (c *MySQLClient) handleQuery(sql string) error {
 	// (e.g. "table not found") instead of falling through to the Exec path,
 	// which would mask it with "unsupported statement type".
 	if isReadStatement(sql) {
-		return c.sendErrorPacket(1, sanitizeMySQLError(err))
+		return c.sendQueryError(1, err)
 	}
 
 	// Try to execute as exec (INSERT, UPDATE, DELETE, SET, USE, CREATE, etc.)
 	result, err := c.server.db.Exec(ctx, sql)
 	if err != nil {
-		return c.sendErrorPacket(1, sanitizeMySQLError(err))
+		return c.sendQueryError(1, err)
 	}
 
 	rowsAffected := uint64(0)
@@ -1429,8 +1436,47 @@ func (c *MySQLClient) buildOKPacket(affectedRows, lastInsertID uint64) []byte {
 	return pkt
 }
 
+// mysqlErrors maps sqlerr codes to the MySQL error number and SQLSTATE that
+// MySQL reports for the same condition.
+var mysqlErrors = map[sqlerr.Code]struct {
+	code  uint16
+	state string
+}{
+	sqlerr.CodeSyntax:               {1064, "42000"},
+	sqlerr.CodeTypeMismatch:         {1366, "HY000"},
+	sqlerr.CodeUndefinedTable:       {1146, "42S02"},
+	sqlerr.CodeUndefinedColumn:      {1054, "42S22"},
+	sqlerr.CodeUndefinedObject:      {1091, "42000"},
+	sqlerr.CodeDuplicateTable:       {1050, "42S01"},
+	sqlerr.CodeDuplicateObject:      {1061, "42000"},
+	sqlerr.CodeNotNullViolation:     {1048, "23000"},
+	sqlerr.CodeForeignKeyViolation:  {1452, "23000"},
+	sqlerr.CodeUniqueViolation:      {1062, "23000"},
+	sqlerr.CodeCheckViolation:       {3819, "HY000"},
+	sqlerr.CodeReadOnlyTransaction:  {1792, "25006"},
+	sqlerr.CodeSerializationFailure: {1213, "40001"},
+	sqlerr.CodeDeadlock:             {1213, "40001"},
+	sqlerr.CodeLockNotAvailable:     {1205, "HY000"},
+}
+
+// sendQueryError sends the error packet for a failed statement, with the
+// MySQL error number and SQLSTATE of err's sqlerr code, or code and 42000
+// for an unclassified error.
+func (c *MySQLClient) sendQueryError(code uint16, err error) error {
+	state := "42000"
+	if m, ok := mysqlErrors[sqlerr.CodeOf(err)]; ok {
+		code, state = m.code, m.state
+	}
+	return c.sendErrorPacketState(code, state, sanitizeMySQLError(err))
+}
+
 // sendErrorPacket sends an error packet
 func (c *MySQLClient) sendErrorPacket(code uint16, message string) error {
+	return c.sendErrorPacketState(code, "42000", message)
+}
+
+// sendErrorPacketState sends an error packet with a SQLSTATE.
+func (c *MySQLClient) sendErrorPacketState(code uint16, state, message string) error {
 	pkt := make([]byte, 0, 128)
 
 	// Header 0xff
@@ -1443,7 +1489,7 @@ func (c *MySQLClient) sendErrorPacket(code uint16, message string) error {
 	pkt = append(pkt, '#')
 
 	// SQL state (5 bytes)
-	pkt = append(pkt, []byte("42000")...)
+	pkt = append(pkt, state...)
 
 	// Error message
 	pkt = append(pkt, []byte(message)...)
@@ -1647,7 +1693,7 @@ func (c *MySQLClient) handleStmtPrepare(sql string) error {
 	}
 	sql = strings.TrimSpace(sql)
 	if _, err := query.ParseStrict(sql); err != nil {
-		return c.sendErrorPacket(0, sanitizeMySQLError(err))
+		return c.sendQueryError(0, err)
 	}
 	numParams := countPreparedParams(sql)
 	if numParams > maxMySQLPreparedParams {
@@ -1675,7 +1721,7 @@ func (c *MySQLClient) handleStmtPrepare(sql string) error {
 			return err
 		}
 	} else if isReadStatement(sql) {
-		return c.sendErrorPacket(1, sanitizeMySQLError(err))
+		return c.sendQueryError(1, err)
 	}
 
 	c.nextStmtID++
@@ -2026,7 +2072,7 @@ func (c *MySQLClient) handleStmtExecute(data []byte) error {
 
 	result, err := c.server.db.Exec(ctx, stmt.sql, args...)
 	if err != nil {
-		return c.sendErrorPacket(1, sanitizeMySQLError(err))
+		return c.sendQueryError(1, err)
 	}
 
 	rowsAffected := uint64(0)
@@ -2096,7 +2142,7 @@ func (c *MySQLClient) sendBinaryResultSetFromRows(rows *engine.Rows) error {
 			// Surface the scan failure instead of silently dropping the row
 			// (which would return a successful result set with fewer rows than
 			// exist). Matches the cursor/FETCH path's error handling.
-			return c.sendErrorPacket(1, sanitizeMySQLError(err))
+			return c.sendQueryError(1, err)
 		}
 		if mysqlRowValueTooLarge(row) {
 			return c.sendErrorPacket(0, "result value too large")
@@ -2344,7 +2390,7 @@ func (c *MySQLClient) handleStmtFetch(data []byte) error {
 		row, ok, err := stmt.cursor.nextRow()
 		if err != nil {
 			stmt.closeCursor()
-			return c.sendErrorPacket(1, sanitizeMySQLError(err))
+			return c.sendQueryError(1, err)
 		}
 		if !ok {
 			exhausted = true
@@ -2368,7 +2414,7 @@ func (c *MySQLClient) handleStmtFetch(data []byte) error {
 		row, ok, err := stmt.cursor.nextRow()
 		if err != nil {
 			stmt.closeCursor()
-			return c.sendErrorPacket(1, sanitizeMySQLError(err))
+			return c.sendQueryError(1, err)
 		}
 		if ok {
 			stmt.cursor.pending = row
@@ -2467,7 +2513,7 @@ func (c *MySQLClient) handleFieldList(data []byte) error {
 	// Use DESCRIBE to get column info
 	rows, err := c.server.db.Query(ctx, "DESCRIBE "+quotedTableName)
 	if err != nil {
-		return c.sendErrorPacket(1, sanitizeMySQLError(err))
+		return c.sendQueryError(1, err)
 	}
 	defer rows.Close()
 
@@ -2479,7 +2525,7 @@ func (c *MySQLClient) handleFieldList(data []byte) error {
 			dest[i] = &row[i]
 		}
 		if err := rows.Scan(dest...); err != nil {
-			return c.sendErrorPacket(1, sanitizeMySQLError(err))
+			return c.sendQueryError(1, err)
 		}
 		colPkt := c.buildColumnDefPacketWithDefinition(mysqlColumnDefinitionFromDescribe(tableName, row))
 		if err := c.writePacket(colPkt, seq); err != nil {
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -56,6 +57,7 @@ type ExpressionVisitor interface {
 	VisitJSONPathExpr(expr *JSONPathExpr, ctx interface{}) interface{}
 	VisitJSONContainsExpr(expr *JSONContainsExpr, ctx interface{}) interface{}
 	VisitAliasExpr(expr *AliasExpr, ctx interface{}) interface{}
+	VisitCollateExpr(expr *CollateExpr, ctx interface{}) interface{}
 	VisitMatchExpr(expr *MatchExpr, ctx interface{}) interface{}
 	VisitWindowExpr(expr *WindowExpr, ctx interface{}) interface{}
 	VisitWindowSpec(expr *WindowSpec, ctx interface{}) interface{}
@@ -150,6 +152,8 @@ func walkChildren(expr interface{}, v ExpressionVisitor, ctx interface{}) {
 		}
 	case *AliasExpr:
 		Walk(e.Expr, v, ctx)
+	case *CollateExpr:
+		Walk(e.Expr, v, ctx)
 	case *JSONPathExpr:
 		Walk(e.Column, v, ctx)
 	case *JSONContainsExpr:
@@ -267,6 +271,9 @@ func (e *JSONContainsExpr) AcceptVisitor(v ExpressionVisitor, ctx interface{}) i
 func (e *AliasExpr) AcceptVisitor(v ExpressionVisitor, ctx interface{}) interface{} {
 	return v.VisitAliasExpr(e, ctx)
 }
+func (e *CollateExpr) AcceptVisitor(v ExpressionVisitor, ctx interface{}) interface{} {
+	return v.VisitCollateExpr(e, ctx)
+}
 func (e *MatchExpr) AcceptVisitor(v ExpressionVisitor, ctx interface{}) interface{} {
 	return v.VisitMatchExpr(e, ctx)
 }
@@ -302,6 +309,13 @@ type Evaluator interface {
 	EvalStar(table string) (interface{}, error)
 	EvalColumnRef(table, column string) (interface{}, error)
 	EvalWindow(w *WindowExpr) (interface{}, error)
+	// EvalCollate validates the collation named by a COLLATE clause and
+	// returns val unchanged; the collation itself is applied by comparisons.
+	EvalCollate(val interface{}, collation string) (interface{}, error)
+	// EvalComparison applies a comparison operator. The operand expressions
+	// are passed so explicit COLLATE clauses and column collations can be
+	// resolved.
+	EvalComparison(left, right interface{}, op TokenType, leftExpr, rightExpr Expression) (interface{}, error)
 }
 
 // TemporalExpr represents AS OF expression for temporal queries
@@ -376,6 +390,7 @@ type InsertStmt struct {
 	Columns        []string
 	Values         [][]Expression
 	Select         *SelectStmt       // For INSERT INTO ... SELECT ...
+	Query          Statement         // For INSERT INTO ... WITH ... SELECT and UNION/INTERSECT/EXCEPT queries
 	ConflictAction ConflictAction    // OR REPLACE / OR IGNORE / OR ROLLBACK
 	OnConflict     *OnConflictClause // ON CONFLICT (...) DO NOTHING|UPDATE
 	Returning      []Expression      // RETURNING clause expressions
@@ -459,6 +474,12 @@ type CreateTableStmt struct {
 	ForeignKeys []*ForeignKeyDef
 	Partition   *PartitionDef // Table partitioning definition
 	AsSelect    Statement     // CREATE TABLE ... AS SELECT ... (CTAS); nil otherwise
+	AsColumns   []string      // CREATE TABLE t (a, b) AS SELECT ...: names for the query's columns
+	Engine      string        // storage engine from USING, lower-case (e.g. "append"); "" for the B+Tree
+	TTLColumn   string        // column from WITH (ttl_column = ...) whose time expires the row; "" for none
+	// SystemVersioning is set by WITH SYSTEM VERSIONING: updates and
+	// deletes keep the previous row versions in a history table.
+	SystemVersioning bool
 	// UniqueConstraints holds table-level UNIQUE (col, ...) constraint column sets.
 	UniqueConstraints      [][]string
 	NamedUniqueConstraints []UniqueConstraintDef
@@ -494,6 +515,7 @@ func (s *CreateForeignTableStmt) statementNode()   {}
 type DropTableStmt struct {
 	IfExists bool
 	Table    string
+	Cascade  bool // CASCADE: drop dependent views and foreign keys too
 }
 
 func (s *DropTableStmt) nodeType() string { return "DropTableStmt" }
@@ -506,6 +528,8 @@ type CreateIndexStmt struct {
 	Table       string
 	Columns     []string
 	Unique      bool
+	JSONPath    string // set for an index on JSON_EXTRACT(Columns[0], JSONPath)
+	Method      string // access method from USING, upper-case (e.g. "GIN"); "" for a B+Tree
 }
 
 func (s *CreateIndexStmt) nodeType() string { return "CreateIndexStmt" }
@@ -567,6 +591,7 @@ func (s *CreateViewStmt) statementNode()   {}
 type DropViewStmt struct {
 	IfExists bool
 	Name     string
+	Cascade  bool // CASCADE: drop dependent views too
 }
 
 func (s *DropViewStmt) nodeType() string { return "DropViewStmt" }
@@ -617,15 +642,60 @@ type DropProcedureStmt struct {
 func (s *DropProcedureStmt) nodeType() string { return "DropProcedureStmt" }
 func (s *DropProcedureStmt) statementNode()   {}
 
+// CreateSequenceStmt represents a CREATE SEQUENCE statement. Options left out
+// are nil and take their defaults when the sequence is created.
+type CreateSequenceStmt struct {
+	IfNotExists bool
+	Name        string
+	Start       *int64 // START [WITH] n
+	Increment   *int64 // INCREMENT [BY] n
+	MinValue    *int64 // MINVALUE n; nil for NO MINVALUE
+	MaxValue    *int64 // MAXVALUE n; nil for NO MAXVALUE
+	Cycle       bool   // CYCLE: wrap around instead of failing at the limit
+}
+
+func (s *CreateSequenceStmt) nodeType() string { return "CreateSequenceStmt" }
+func (s *CreateSequenceStmt) statementNode()   {}
+
+// DropSequenceStmt represents a DROP SEQUENCE statement
+type DropSequenceStmt struct {
+	IfExists bool
+	Name     string
+}
+
+func (s *DropSequenceStmt) nodeType() string { return "DropSequenceStmt" }
+func (s *DropSequenceStmt) statementNode()   {}
+
+// CreateSchemaStmt represents a CREATE SCHEMA statement. A schema is a
+// namespace for tables, named as schema.table.
+type CreateSchemaStmt struct {
+	IfNotExists bool
+	Name        string
+}
+
+func (s *CreateSchemaStmt) nodeType() string { return "CreateSchemaStmt" }
+func (s *CreateSchemaStmt) statementNode()   {}
+
+// DropSchemaStmt represents DROP SCHEMA [IF EXISTS] name [CASCADE | RESTRICT]
+type DropSchemaStmt struct {
+	IfExists bool
+	Name     string
+	Cascade  bool // CASCADE: drop the schema's tables too
+}
+
+func (s *DropSchemaStmt) nodeType() string { return "DropSchemaStmt" }
+func (s *DropSchemaStmt) statementNode()   {}
+
 // CreatePolicyStmt represents a CREATE POLICY statement for row-level security
 type CreatePolicyStmt struct {
-	Name       string     // Policy name
-	Table      string     // Table name
-	Permissive bool       // true = PERMISSIVE (default), false = RESTRICTIVE
-	Event      string     // ALL, SELECT, INSERT, UPDATE, DELETE
-	Using      Expression // USING expression (for SELECT/UPDATE/DELETE)
-	WithCheck  Expression // WITH CHECK expression (for INSERT/UPDATE)
-	ForRoles   []string   // Roles this policy applies to (empty = all)
+	IfNotExists bool
+	Name        string     // Policy name
+	Table       string     // Table name
+	Permissive  bool       // true = PERMISSIVE (default), false = RESTRICTIVE
+	Event       string     // ALL, SELECT, INSERT, UPDATE, DELETE
+	Using       Expression // USING expression (for SELECT/UPDATE/DELETE)
+	WithCheck   Expression // WITH CHECK expression (for INSERT/UPDATE)
+	ForRoles    []string   // Roles this policy applies to (empty = all)
 }
 
 func (s *CreatePolicyStmt) nodeType() string { return "CreatePolicyStmt" }
@@ -717,6 +787,14 @@ type ColumnDef struct {
 	Collation     string         // Optional COLLATE name
 	Dimensions    int            // For VECTOR type: number of dimensions
 	ForeignKey    *ForeignKeyDef // inline column-level REFERENCES constraint
+	Mask          *ColumnMask    // MASKED [WITH mask]
+}
+
+// ColumnMask is the mask of a MASKED column: a masking function such as
+// MASK_LAST and its arguments after the column value.
+type ColumnMask struct {
+	Function string
+	Args     []int64
 }
 
 // ForeignKeyDef represents a foreign key constraint
@@ -735,6 +813,7 @@ type TableRef struct {
 	Alias        string
 	Subquery     *SelectStmt // non-nil for derived tables: FROM (SELECT ...) AS alias
 	SubqueryStmt Statement   // non-nil for derived tables with UNION: FROM (SELECT ... UNION ...) AS alias
+	Columns      []string    // optional derived table column names: FROM (SELECT ...) AS alias (a, b)
 	IndexHint    string      // hint for index usage (e.g., "auto", "primary", "idx_name")
 	NotIndexed   bool        // SQLite-style NOT INDEXED table hint
 }
@@ -886,9 +965,22 @@ func (e *BinaryExpr) Evaluate(ev Evaluator) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if IsComparisonOperator(e.Operator) {
+		return ev.EvalComparison(left, right, e.Operator, e.Left, e.Right)
+	}
 	return ev.EvalBinaryExpr(left, right, e.Operator)
 }
 
+// IsComparisonOperator reports whether op compares its operands (=, <>, <,
+// >, <=, >=, <=>), which is when collations apply.
+func IsComparisonOperator(op TokenType) bool {
+	switch op {
+	case TokenEq, TokenNeq, TokenLt, TokenGt, TokenLte, TokenGte, TokenNullSafeEq:
+		return true
+	}
+	return false
+}
+
 // UnaryExpr represents a unary expression
 type UnaryExpr struct {
 	Operator TokenType
@@ -923,11 +1015,44 @@ func (e *FunctionCall) Evaluate(ev Evaluator) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
+		if s, ok := v.(string); ok && isJSONValueArg(e.Name, i) && producesJSON(arg) {
+			v = json.RawMessage(s)
+		}
 		args[i] = v
 	}
 	return ev.EvalFunctionCall(e.Name, args, e.Distinct)
 }
 
+// isJSONValueArg reports whether argument i of a JSON constructor is a value
+// that is embedded in the result document.
+func isJSONValueArg(name string, i int) bool {
+	switch name {
+	case "JSON_ARRAY":
+		return true
+	case "JSON_OBJECT":
+		return i%2 == 1
+	case "JSON_SET":
+		return i >= 2 && i%2 == 0
+	}
+	return false
+}
+
+// producesJSON reports whether expr yields JSON text. Such values are passed
+// to JSON constructors as json.RawMessage so that JSON_ARRAY(JSON_OBJECT(...))
+// nests the object instead of embedding it as a string.
+func producesJSON(expr Expression) bool {
+	fc, ok := expr.(*FunctionCall)
+	if !ok {
+		return false
+	}
+	switch fc.Name {
+	case "JSON_ARRAY", "JSON_OBJECT", "JSON_SET", "JSON_REMOVE", "JSON_MERGE",
+		"JSON_MINIFY", "JSON_PRETTY", "JSON_QUOTE":
+		return true
+	}
+	return false
+}
+
 // StarExpr represents * in SELECT *
 type StarExpr struct {
 	Table string // optional table prefix
@@ -977,9 +1102,11 @@ func (e *JSONContainsExpr) Evaluate(ev Evaluator) (interface{}, error) {
 	return ok, err
 }
 
-// PlaceholderExpr represents a ? placeholder
+// PlaceholderExpr represents a ? placeholder or a named :name / @name one.
+// Index numbers the statement's placeholders in the order they appear.
 type PlaceholderExpr struct {
 	Index int
+	Name  string // set for named placeholders
 }
 
 func (e *PlaceholderExpr) nodeType() string { return "PlaceholderExpr" }
@@ -1105,7 +1232,9 @@ func (e *CastExpr) Evaluate(ev Evaluator) (interface{}, error) {
 	return ev.EvalCast(val, e.DataType)
 }
 
-// CaseExpr represents a CASE expression
+// CaseExpr represents a CASE expression. In a simple CASE (CASE x WHEN 1
+// THEN ...) Expr is x and each WHEN condition is a value compared with it;
+// in a searched CASE Expr is nil and the conditions are predicates.
 type CaseExpr struct {
 	Expr  Expression
 	Whens []*WhenClause
@@ -1120,35 +1249,44 @@ type WhenClause struct {
 
 func (e *CaseExpr) nodeType() string { return "CaseExpr" }
 func (e *CaseExpr) expressionNode()  {}
+
+// Evaluate evaluates the WHEN conditions in order and only the result of
+// the first that holds, so an untaken branch cannot fail the expression. A
+// NULL operand matches no WHEN value.
 func (e *CaseExpr) Evaluate(ev Evaluator) (interface{}, error) {
-	var exprVal interface{}
+	var operand interface{}
 	var err error
 	if e.Expr != nil {
-		exprVal, err = e.Expr.Evaluate(ev)
+		operand, err = e.Expr.Evaluate(ev)
 		if err != nil {
 			return nil, err
 		}
 	}
-	whens := make([][2]interface{}, len(e.Whens))
-	for i, w := range e.Whens {
+	for _, w := range e.Whens {
 		cond, err := w.Condition.Evaluate(ev)
 		if err != nil {
 			return nil, err
 		}
-		result, err := w.Result.Evaluate(ev)
+		if e.Expr != nil {
+			cond, err = ev.EvalComparison(operand, cond, TokenEq, e.Expr, w.Condition)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// A single WHEN whose result is true asks the evaluator whether
+		// the condition holds.
+		held, err := ev.EvalCase(nil, [][2]interface{}{{cond, true}}, false)
 		if err != nil {
 			return nil, err
 		}
-		whens[i] = [2]interface{}{cond, result}
+		if held == true {
+			return w.Result.Evaluate(ev)
+		}
 	}
-	var elseVal interface{}
 	if e.Else != nil {
-		elseVal, err = e.Else.Evaluate(ev)
-		if err != nil {
-			return nil, err
-		}
+		return e.Else.Evaluate(ev)
 	}
-	return ev.EvalCase(exprVal, whens, elseVal)
+	return nil, nil
 }
 
 // SubqueryExpr represents a subquery expression
@@ -1287,6 +1425,7 @@ type SelectStmtWithCTE struct {
 	CTEs        []*CTEDef
 	IsRecursive bool
 	Select      *SelectStmt
+	Union       *UnionStmt // main query when it is a UNION/INTERSECT/EXCEPT chain; Select is then its first SELECT
 }
 
 func (s *SelectStmtWithCTE) nodeType() string { return "SelectStmtWithCTE" }
@@ -1319,11 +1458,26 @@ type CreateFTSIndexStmt struct {
 func (s *CreateFTSIndexStmt) nodeType() string { return "CreateFTSIndexStmt" }
 func (s *CreateFTSIndexStmt) statementNode()   {}
 
+// CreateVirtualTableStmt represents CREATE VIRTUAL TABLE name USING module(args)
+type CreateVirtualTableStmt struct {
+	IfNotExists bool
+	Table       string
+	Module      string   // e.g. fts
+	Args        []string // module arguments (column names for fts)
+}
+
+func (s *CreateVirtualTableStmt) nodeType() string { return "CreateVirtualTableStmt" }
+func (s *CreateVirtualTableStmt) statementNode()   {}
+
 // MatchExpr represents a MATCH ... AGAINST expression for FTS
 type MatchExpr struct {
 	Columns []Expression
 	Pattern Expression
 	Mode    string // BOOLEAN MODE, NATURAL LANGUAGE MODE
+	// Operator is set for the `target MATCH pattern` form, which queries a
+	// full-text index; Rank makes it return the BM25 score (BM25(target, pattern)).
+	Operator bool
+	Rank     bool
 }
 
 func (e *MatchExpr) nodeType() string { return "MatchExpr" }
@@ -1368,6 +1522,23 @@ func (e *AliasExpr) Evaluate(ev Evaluator) (interface{}, error) {
 	return ev.EvalAlias(inner)
 }
 
+// CollateExpr is expr COLLATE name. It evaluates to expr; comparisons and
+// ORDER BY involving it use the named collation.
+type CollateExpr struct {
+	Expr      Expression
+	Collation string
+}
+
+func (e *CollateExpr) nodeType() string { return "CollateExpr" }
+func (e *CollateExpr) expressionNode()  {}
+func (e *CollateExpr) Evaluate(ev Evaluator) (interface{}, error) {
+	val, err := e.Expr.Evaluate(ev)
+	if err != nil {
+		return nil, err
+	}
+	return ev.EvalCollate(val, e.Collation)
+}
+
 // RefreshMaterializedViewStmt represents a REFRESH MATERIALIZED VIEW statement
 type RefreshMaterializedViewStmt struct {
 	Name         string
@@ -1380,7 +1551,7 @@ func (s *RefreshMaterializedViewStmt) statementNode()   {}
 // AlterTableStmt represents ALTER TABLE ADD/DROP/RENAME
 type AlterTableStmt struct {
 	Table             string
-	Action            string // "ADD", "DROP", "RENAME_TABLE", "RENAME_COLUMN", "ADD_CONSTRAINT", "DROP_CONSTRAINT"
+	Action            string // "ADD", "DROP", "RENAME_TABLE", "RENAME_COLUMN", "ADD_CONSTRAINT", "DROP_CONSTRAINT", "SET_COMPRESSION_DICTIONARY", "SET_COMPRESSION_NONE"
 	Column            ColumnDef
 	OldName           string // For RENAME COLUMN: old column name
 	NewName           string // For RENAME TABLE/COLUMN or DROP COLUMN: new name / dropped column name
@@ -1389,6 +1560,9 @@ type AlterTableStmt struct {
 	ConstraintColumns []string
 	ConstraintCheck   Expression
 	ForeignKey        *ForeignKeyDef
+	IfExists          bool // ALTER TABLE IF EXISTS: no-op when the table is missing
+	TargetIfNotExists bool // ADD COLUMN IF NOT EXISTS: no-op when the column exists
+	TargetIfExists    bool // DROP COLUMN/CONSTRAINT IF EXISTS: no-op when it is missing
 }
 
 func (s *AlterTableStmt) nodeType() string { return "AlterTableStmt" }
@@ -1441,12 +1615,45 @@ type SetVarStmt struct {
 func (s *SetVarStmt) nodeType() string { return "SetVarStmt" }
 func (s *SetVarStmt) statementNode()   {}
 
+// PragmaStmt represents PRAGMA <name> [= <value>] or PRAGMA <name>(<value>).
+// Name is lower-cased; Value is empty when the pragma is only read.
+type PragmaStmt struct {
+	Name  string
+	Value string
+}
+
+func (s *PragmaStmt) nodeType() string { return "PragmaStmt" }
+func (s *PragmaStmt) statementNode()   {}
+
+// AttachStmt represents ATTACH [DATABASE] '<path>' AS <name>.
+type AttachStmt struct {
+	Path string
+	Name string
+}
+
+func (s *AttachStmt) nodeType() string { return "AttachStmt" }
+func (s *AttachStmt) statementNode()   {}
+
+// DetachStmt represents DETACH [DATABASE] <name>.
+type DetachStmt struct {
+	Name string
+}
+
+func (s *DetachStmt) nodeType() string { return "DetachStmt" }
+func (s *DetachStmt) statementNode()   {}
+
 // ShowDatabasesStmt represents SHOW DATABASES
 type ShowDatabasesStmt struct{}
 
 func (s *ShowDatabasesStmt) nodeType() string { return "ShowDatabasesStmt" }
 func (s *ShowDatabasesStmt) statementNode()   {}
 
+// ShowConfigStmt represents SHOW CONFIG
+type ShowConfigStmt struct{}
+
+func (s *ShowConfigStmt) nodeType() string { return "ShowConfigStmt" }
+func (s *ShowConfigStmt) statementNode()   {}
+
 // DescribeStmt represents DESCRIBE <table>
 type DescribeStmt struct {
 	Table string
@@ -1455,9 +1662,10 @@ type DescribeStmt struct {
 func (s *DescribeStmt) nodeType() string { return "DescribeStmt" }
 func (s *DescribeStmt) statementNode()   {}
 
-// ExplainStmt represents EXPLAIN <query>
+// ExplainStmt represents EXPLAIN [ANALYZE] <query>
 type ExplainStmt struct {
 	Statement Statement // The statement to explain
+	Analyze   bool      // Run the statement and report what it did
 }
 
 func (s *ExplainStmt) nodeType() string { return "ExplainStmt" }
@@ -45,6 +45,7 @@ func TestASTNodeTypes(t *testing.T) {
 		&UseStmt{},
 		&SetVarStmt{},
 		&ShowDatabasesStmt{},
+		&ShowConfigStmt{},
 		&DescribeStmt{},
 		&ExplainStmt{},
 	}
@@ -148,6 +149,9 @@ func TestParserEdgeCasesForCoverage(t *testing.T) {
 		{"alter drop column", "ALTER TABLE t DROP COLUMN c", false},
 		{"alter rename column", "ALTER TABLE t RENAME COLUMN old TO new_col", false},
 		{"alter add constraint", "ALTER TABLE t ADD CONSTRAINT fk FOREIGN KEY (c) REFERENCES t2(id)", false},
+		{"alter set compression dictionary", "ALTER TABLE t SET COMPRESSION DICTIONARY", false},
+		{"alter set compression none", "ALTER TABLE t SET COMPRESSION NONE", false},
+		{"alter set compression unknown", "ALTER TABLE t SET COMPRESSION lz4", true},
 
 		// SAVEPOINT / RELEASE / ROLLBACK TO
 		{"savepoint", "SAVEPOINT sp1", false},
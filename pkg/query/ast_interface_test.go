@@ -44,6 +44,7 @@ func TestStatementNodeInterfaces(t *testing.T) {
 		&UseStmt{},
 		&SetVarStmt{},
 		&ShowDatabasesStmt{},
+		&ShowConfigStmt{},
 		&DescribeStmt{},
 		&ExplainStmt{},
 	}
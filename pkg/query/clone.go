@@ -76,3 +76,145 @@ func cloneASTValue(v reflect.Value) reflect.Value {
 		return v
 	}
 }
+
+// tableTargetTypes are the statements whose Table field names an existing
+// table. CREATE TABLE and friends are left out: they name a new one.
+var tableTargetTypes = map[reflect.Type]bool{
+	reflect.TypeOf(InsertStmt{}):            true,
+	reflect.TypeOf(UpdateStmt{}):            true,
+	reflect.TypeOf(DeleteStmt{}):            true,
+	reflect.TypeOf(DropTableStmt{}):         true,
+	reflect.TypeOf(AlterTableStmt{}):        true,
+	reflect.TypeOf(CreateIndexStmt{}):       true,
+	reflect.TypeOf(CreateFTSIndexStmt{}):    true,
+	reflect.TypeOf(CreateVectorIndexStmt{}): true,
+	reflect.TypeOf(CreateTriggerStmt{}):     true,
+	reflect.TypeOf(CreatePolicyStmt{}):      true,
+	reflect.TypeOf(DropPolicyStmt{}):        true,
+	reflect.TypeOf(VacuumStmt{}):            true,
+	reflect.TypeOf(AnalyzeStmt{}):           true,
+	reflect.TypeOf(ShowCreateTableStmt{}):   true,
+	reflect.TypeOf(ShowColumnsStmt{}):       true,
+	reflect.TypeOf(ShowIndexStmt{}):         true,
+	reflect.TypeOf(DescribeStmt{}):          true,
+}
+
+var (
+	tableRefType   = reflect.TypeOf(TableRef{})
+	cteDefType     = reflect.TypeOf(CTEDef{})
+	selectStmtType = reflect.TypeOf(SelectStmt{})
+)
+
+// RenameTables returns stmt with every table it reads or writes renamed by
+// rename: FROM and JOIN tables, subqueries, and DML and DDL targets. A
+// renamed table reference without an alias keeps its old name as the alias,
+// so column qualifiers still match. Names of the statement's CTEs are not
+// passed to rename. stmt itself is returned if no name changes; otherwise
+// the result is a copy and stmt is left as it was.
+func RenameTables(stmt Statement, rename func(name string) string) Statement {
+	if stmt == nil {
+		return nil
+	}
+	ctes := make(map[string]bool)
+	walkTableNames(reflect.ValueOf(stmt), func(v reflect.Value) {
+		if v.Type() == cteDefType {
+			ctes[v.FieldByName("Name").String()] = true
+		}
+	})
+	renamed := func(name string) (string, bool) {
+		if name == "" || ctes[name] {
+			return name, false
+		}
+		newName := rename(name)
+		return newName, newName != name
+	}
+
+	changed := false
+	walkTableNames(reflect.ValueOf(stmt), func(v reflect.Value) {
+		if name := tableNameField(v); name.IsValid() {
+			if _, ok := renamed(name.String()); ok {
+				changed = true
+			}
+		}
+	})
+	if !changed {
+		return stmt
+	}
+
+	out := CloneStatement(stmt)
+	walkTableNames(reflect.ValueOf(out), func(v reflect.Value) {
+		name := tableNameField(v)
+		if !name.IsValid() {
+			return
+		}
+		newName, ok := renamed(name.String())
+		if !ok {
+			return
+		}
+		if alias := v.FieldByName("Alias"); alias.IsValid() && alias.String() == "" {
+			alias.SetString(name.String())
+		}
+		name.SetString(newName)
+	})
+	return out
+}
+
+// tableNameField returns the field of struct v that names a table, or the
+// zero Value if v does not name one.
+func tableNameField(v reflect.Value) reflect.Value {
+	switch {
+	case v.Type() == tableRefType:
+		if v.FieldByName("Subquery").IsNil() && v.FieldByName("SubqueryStmt").IsNil() {
+			return v.FieldByName("Name")
+		}
+	case tableTargetTypes[v.Type()]:
+		return v.FieldByName("Table")
+	}
+	return reflect.Value{}
+}
+
+// walkTableNames calls visit for every struct in the AST under v.
+func walkTableNames(v reflect.Value, visit func(reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			walkTableNames(v.Elem(), visit)
+		}
+	case reflect.Struct:
+		visit(v)
+		for i := 0; i < v.NumField(); i++ {
+			walkTableNames(v.Field(i), visit)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkTableNames(v.Index(i), visit)
+		}
+	}
+}
+
+// ContainsAsOf reports whether stmt, or a query nested in it, reads AS OF a
+// time.
+func ContainsAsOf(stmt Statement) bool {
+	found := false
+	walkTableNames(reflect.ValueOf(stmt), func(v reflect.Value) {
+		if !found && v.Type() == selectStmtType && !v.FieldByName("AsOf").IsNil() {
+			found = true
+		}
+	})
+	return found
+}
+
+// ReferencedTables returns the names of the tables and views stmt reads or
+// writes, in the order RenameTables visits them, without its CTEs.
+func ReferencedTables(stmt Statement) []string {
+	seen := make(map[string]bool)
+	var names []string
+	RenameTables(stmt, func(name string) string {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return name
+	})
+	return names
+}
@@ -0,0 +1,111 @@
+package query
+
+import "strings"
+
+// Fingerprint normalizes sql so that statements differing only in their
+// values, spacing, comments or keyword case map to the same string, for
+// grouping statements in logs, metrics and caches:
+//
+//	Fingerprint("select * from Users where id = 42;")    // SELECT * FROM users WHERE id = ?
+//	Fingerprint("SELECT * FROM users WHERE id IN (1, -2)") // SELECT * FROM users WHERE id IN (?)
+//
+// Literals and placeholders (?, :name, @name) become ?, a negative number is
+// one literal, and lists of them collapse to a single ?, so IN lists of any
+// length match. Keywords are upper-cased, identifiers lower-cased, comments
+// dropped and a trailing ';' removed. SQL the lexer rejects comes back with
+// only its whitespace collapsed.
+func Fingerprint(sql string) string {
+	lexer := NewLexer(sql)
+	var parts []string
+	for {
+		tok := lexer.NextToken()
+		var part string
+		switch tok.Type {
+		case TokenEOF:
+			return joinFingerprint(parts)
+		case TokenIllegal:
+			return strings.Join(strings.Fields(sql), " ")
+		case TokenString, TokenNumber, TokenHexString, TokenTrue, TokenFalse,
+			TokenQuestion, TokenNamedParam:
+			n := len(parts)
+			// A '-' that cannot be a binary minus is the literal's sign.
+			if tok.Type == TokenNumber && n >= 1 && parts[n-1] == "-" && (n == 1 || !endsOperand(parts[n-2])) {
+				parts = parts[:n-1]
+				n--
+			}
+			// "?, ?" becomes "?".
+			if n >= 2 && parts[n-1] == "," && parts[n-2] == "?" {
+				parts = parts[:n-1]
+				continue
+			}
+			part = "?"
+		case TokenIdentifier:
+			part = strings.ToLower(tok.Literal)
+		default:
+			part = strings.ToUpper(tok.Literal)
+		}
+		parts = append(parts, part)
+	}
+}
+
+// endsOperand reports whether a fingerprint token can end an operand, so a
+// '-' after it is a binary minus.
+func endsOperand(part string) bool {
+	if part == "?" || part == ")" {
+		return true
+	}
+	// Identifiers are lower-cased; keywords and operators have no lower-case
+	// letters.
+	return strings.ToUpper(part) != part
+}
+
+// joinFingerprint joins tokens with single spaces, except around
+// parentheses, dots and commas, and drops a trailing semicolon.
+func joinFingerprint(parts []string) string {
+	if n := len(parts); n > 0 && parts[n-1] == ";" {
+		parts = parts[:n-1]
+	}
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			prev := parts[i-1]
+			if prev != "(" && prev != "." && part != ")" && part != "," && part != "." {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// NormalizeSQL collapses the spacing and comments of sql to single spaces
+// between tokens and drops a trailing ';', keeping every token as written:
+//
+//	NormalizeSQL("SELECT  *\n  FROM t -- all\n WHERE id = 42;") // SELECT * FROM t WHERE id = 42
+//
+// Statements with the same normalized SQL lex to the same tokens and so
+// parse to the same statement, which makes it a key for caching parsed
+// statements. Unlike Fingerprint it keeps values and case, both of which
+// can change what a statement does. SQL the lexer rejects comes back
+// unchanged.
+func NormalizeSQL(sql string) string {
+	lexer := NewLexer(sql)
+	var parts []string
+	for {
+		if !lexer.skipWhitespaceAndComments() {
+			return sql
+		}
+		start := lexer.pos
+		tok := lexer.NextToken()
+		switch tok.Type {
+		case TokenEOF:
+			if n := len(parts); n > 0 && parts[n-1] == ";" {
+				parts = parts[:n-1]
+			}
+			return strings.Join(parts, " ")
+		case TokenIllegal:
+			return sql
+		}
+		parts = append(parts, sql[start:min(lexer.pos, len(sql))])
+	}
+}
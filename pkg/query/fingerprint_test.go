@@ -0,0 +1,40 @@
+package query
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	for sql, want := range map[string]string{
+		"select * from Users where id = 42;":          "SELECT * FROM users WHERE id = ?",
+		"SELECT  a.x\n FROM a -- note\nWHERE y = 'z'": "SELECT a.x FROM a WHERE y = ?",
+		"UPDATE t SET v = ? WHERE k IN (1,2,3)":       "UPDATE t SET v = ? WHERE k IN (?)",
+		"DELETE FROM t WHERE b = X'ff' OR c = TRUE":   "DELETE FROM t WHERE b = ? OR c = ?",
+		"SELECT 'unterminated":                        "SELECT 'unterminated",
+		"SELECT * FROM t WHERE k IN (-1, 2, -3)":      "SELECT * FROM t WHERE k IN (?)",
+		"SELECT a - 1, (a) - 2 FROM t WHERE b > -3":   "SELECT a - ?, (a) - ? FROM t WHERE b > ?",
+		"SELECT * FROM t WHERE a = :a AND b = @b":     "SELECT * FROM t WHERE a = ? AND b = ?",
+		"INSERT INTO t VALUES (1, 'x'), (2, 'y')":     "INSERT INTO t VALUES (?), (?)",
+		"select x from t where y is null":             "SELECT x FROM t WHERE y IS NULL",
+	} {
+		if got := Fingerprint(sql); got != want {
+			t.Errorf("Fingerprint(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	for sql, want := range map[string]string{
+		"SELECT  *\n  FROM t -- all\n WHERE id = 42;": "SELECT * FROM t WHERE id = 42",
+		"select a.x from T /* c */ where y='a  b'":    "select a . x from T where y = 'a  b'",
+		"SELECT 1;;":           "SELECT 1 ;",
+		"  SELECT ';' ":        "SELECT ';'",
+		"SELECT 'unterminated": "SELECT 'unterminated",
+	} {
+		if got := NormalizeSQL(sql); got != want {
+			t.Errorf("NormalizeSQL(%q) = %q, want %q", sql, got, want)
+		}
+	}
+	// Spelling that can change the statement is kept.
+	if NormalizeSQL("SELECT date FROM t") == NormalizeSQL("SELECT DATE FROM t") {
+		t.Error("statements differing in case normalized alike")
+	}
+}
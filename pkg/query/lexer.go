@@ -3,6 +3,8 @@ package query
 import (
 	"fmt"
 	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 )
 
 const (
@@ -18,6 +20,9 @@ type Lexer struct {
 	ch      byte
 	line    int
 	column  int
+	// prev is the type of the last token returned, for the few literals
+	// whose lexing depends on what precedes them.
+	prev TokenType
 }
 
 // NewLexer creates a new lexer for the given input
@@ -55,9 +60,11 @@ func (l *Lexer) peekChar() byte {
 	return l.input[l.readPos]
 }
 
-func (l *Lexer) skipWhitespaceAndComments() {
+// skipWhitespaceAndComments skips whitespace, -- line comments and /* */
+// block comments. It reports false if a block comment is never closed.
+func (l *Lexer) skipWhitespaceAndComments() bool {
 	for {
-		for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' || l.ch == '\f' || l.ch == '\v' {
 			l.readChar()
 		}
 		if l.ch == '-' && l.peekChar() == '-' {
@@ -71,7 +78,7 @@ func (l *Lexer) skipWhitespaceAndComments() {
 			l.readChar() // skip *
 			for {
 				if l.ch == 0 {
-					return
+					return false
 				}
 				if l.ch == '*' && l.peekChar() == '/' {
 					l.readChar() // skip *
@@ -82,14 +89,22 @@ func (l *Lexer) skipWhitespaceAndComments() {
 			}
 			continue
 		}
-		return
+		return true
 	}
 }
 
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() Token {
+	tok := l.nextToken()
+	l.prev = tok.Type
+	return tok
+}
+
+func (l *Lexer) nextToken() Token {
 	var tok Token
-	l.skipWhitespaceAndComments()
+	if !l.skipWhitespaceAndComments() {
+		return Token{Type: TokenIllegal, Literal: "unterminated block comment", Line: l.line, Column: l.column}
+	}
 
 	tok.Line = l.line
 	tok.Column = l.column
@@ -194,19 +209,19 @@ func (l *Lexer) NextToken() Token {
 			literal := string(ch) + string(l.ch)
 			tok = Token{Type: TokenContains, Literal: literal, Line: l.line, Column: l.column - 1}
 			l.readChar()
-		} else if pk := l.peekChar(); pk == '@' || isLetter(pk) || pk == '_' {
-			// System (@@name) or user (@name) variable, kept as an identifier
-			// whose literal includes the @ prefix; resolved during evaluation.
+		} else if l.peekChar() == '@' {
+			// System variable (@@name), kept as an identifier whose literal
+			// includes the @@ prefix; resolved during evaluation.
 			startCol := l.column
 			pos := l.pos
 			l.readChar() // consume first '@'
-			if l.ch == '@' {
-				l.readChar() // consume second '@'
-			}
+			l.readChar() // consume second '@'
 			for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' {
 				l.readChar()
 			}
 			tok = Token{Type: TokenIdentifier, Literal: l.input[pos:l.pos], Line: l.line, Column: startCol}
+		} else if pk := l.peekChar(); isLetter(pk) || pk == '_' {
+			tok = l.readNamedParam()
 		} else {
 			tok = newToken(TokenIllegal, l.ch, l.line, l.column)
 			l.readChar()
@@ -262,13 +277,36 @@ func (l *Lexer) NextToken() Token {
 		tok = newToken(TokenSemicolon, l.ch, l.line, l.column)
 		l.readChar()
 	case '.':
+		if isDigit(l.peekChar()) {
+			// Number with a leading decimal point: .5
+			tok.Type = TokenNumber
+			tok.Literal = l.readNumber()
+			tok.Line = l.line
+			tok.Column = l.column - len(tok.Literal) + 1
+			return tok
+		}
 		tok = newToken(TokenDot, l.ch, l.line, l.column)
 		l.readChar()
+	case ':':
+		if pk := l.peekChar(); isLetter(pk) || pk == '_' {
+			tok = l.readNamedParam()
+		} else {
+			tok = newToken(TokenIllegal, l.ch, l.line, l.column)
+			l.readChar()
+		}
 	case '?':
 		tok = newToken(TokenQuestion, l.ch, l.line, l.column)
 		l.readChar()
 	case '\'':
 		startLine, startCol := l.line, l.column
+		if l.prev == TokenEscape && l.atLoneBackslashString() {
+			// ESCAPE '\' names the backslash itself, as in standard SQL,
+			// rather than escaping the closing quote.
+			l.readChar()
+			l.readChar()
+			l.readChar()
+			return Token{Type: TokenString, Literal: `\`, Line: startLine, Column: startCol}
+		}
 		lit, ok := l.readString('\'')
 		if !ok {
 			return Token{Type: TokenIllegal, Literal: "unterminated string literal", Line: startLine, Column: startCol}
@@ -287,6 +325,13 @@ func (l *Lexer) NextToken() Token {
 		tok.Literal = lit
 		tok.Line = l.line
 		tok.Column = l.column
+	case '$':
+		startLine, startCol := l.line, l.column
+		lit, ok := l.readDollarString()
+		if !ok {
+			return Token{Type: TokenIllegal, Literal: "unterminated dollar-quoted string", Line: startLine, Column: startCol}
+		}
+		tok = Token{Type: TokenString, Literal: lit, Line: startLine, Column: startCol}
 	case '`':
 		tok.Type = TokenIdentifier
 		tok.Literal = l.readBacktickString()
@@ -298,7 +343,18 @@ func (l *Lexer) NextToken() Token {
 		tok.Line = l.line
 		tok.Column = l.column
 	default:
-		if isLetter(l.ch) {
+		if (l.ch == 'x' || l.ch == 'X') && l.peekChar() == '\'' {
+			startLine, startCol := l.line, l.column
+			l.readChar() // consume x
+			lit, ok := l.readString('\'')
+			if !ok {
+				return Token{Type: TokenIllegal, Literal: "unterminated blob literal", Line: startLine, Column: startCol}
+			}
+			if !isHexString(lit) {
+				return Token{Type: TokenIllegal, Literal: "malformed blob literal X'" + lit + "'", Line: startLine, Column: startCol}
+			}
+			return Token{Type: TokenHexString, Literal: lit, Line: startLine, Column: startCol}
+		} else if isLetter(l.ch) {
 			literal := l.readIdentifier()
 			tok.Type = LookupKeyword(literal)
 			tok.Literal = literal
@@ -329,7 +385,9 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[pos:l.pos]
 }
 
-// readNumber reads a number (integer or float)
+// readNumber reads a number: digits with an optional fraction (1.5, .5)
+// and an optional exponent (1e10, 2.5E-3). An 'e' that is not followed by
+// exponent digits is left for the next token.
 func (l *Lexer) readNumber() string {
 	pos := l.pos
 	for isDigit(l.ch) {
@@ -343,12 +401,18 @@ func (l *Lexer) readNumber() string {
 	}
 	// Scientific notation
 	if l.ch == 'e' || l.ch == 'E' {
-		l.readChar()
-		if l.ch == '+' || l.ch == '-' {
-			l.readChar()
+		next := l.peekChar()
+		if (next == '+' || next == '-') && l.readPos+1 < len(l.input) {
+			next = l.input[l.readPos+1]
 		}
-		for isDigit(l.ch) {
-			l.readChar()
+		if isDigit(next) {
+			l.readChar() // consume e
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
 		}
 	}
 	return l.input[pos:l.pos]
@@ -382,6 +446,11 @@ func (l *Lexer) readString(quote byte) (string, bool) {
 				result.WriteByte('\r')
 			case '0':
 				result.WriteByte(0)
+			case '%', '_':
+				// As in MySQL, \% and \_ keep their backslash so a LIKE
+				// pattern can escape its wildcards with ESCAPE '\'.
+				result.WriteByte('\\')
+				result.WriteByte(l.ch)
 			default:
 				result.WriteByte(l.ch)
 			}
@@ -398,6 +467,53 @@ func (l *Lexer) readString(quote byte) (string, bool) {
 	return result.String(), true
 }
 
+// atLoneBackslashString reports whether the input at the current quote is
+// exactly '\' and is not followed by another quote, which would make it the
+// start of a longer backslash-escaped string.
+func (l *Lexer) atLoneBackslashString() bool {
+	rest := l.input[l.pos:]
+	return strings.HasPrefix(rest, `'\'`) && (len(rest) == 3 || rest[3] != '\'')
+}
+
+// readNamedParam reads a :name or @name parameter placeholder. The token
+// literal is the name without its prefix.
+func (l *Lexer) readNamedParam() Token {
+	line, col := l.line, l.column
+	l.readChar() // consume ':' or '@'
+	pos := l.pos
+	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+	return Token{Type: TokenNamedParam, Literal: l.input[pos:l.pos], Line: line, Column: col}
+}
+
+// readDollarString reads a PostgreSQL dollar-quoted string, $$...$$ or
+// $tag$...$tag$. The body is taken verbatim: no escapes are processed.
+func (l *Lexer) readDollarString() (string, bool) {
+	start := l.pos
+	l.readChar() // consume opening $
+	for isLetter(l.ch) || (l.pos > start+1 && isDigit(l.ch)) {
+		l.readChar()
+	}
+	if l.ch != '$' {
+		return "", false
+	}
+	delim := l.input[start : l.pos+1]
+	l.readChar() // consume closing $ of the opening delimiter
+	bodyStart := l.pos
+	end := strings.Index(l.input[bodyStart:], delim)
+	if end < 0 {
+		for l.ch != 0 {
+			l.readChar()
+		}
+		return "", false
+	}
+	for l.pos < bodyStart+end+len(delim) {
+		l.readChar()
+	}
+	return l.input[bodyStart : bodyStart+end], true
+}
+
 // readBacktickString reads a backtick-quoted identifier
 func (l *Lexer) readBacktickString() string {
 	l.readChar() // consume opening backtick
@@ -415,6 +531,20 @@ func isLetter(ch byte) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
 }
 
+// isHexString reports whether s is an even-length run of hex digits.
+func isHexString(s string) bool {
+	if len(s)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isDigit(c) && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
 // isDigit checks if a character is a digit (ASCII fast path)
 func isDigit(ch byte) bool {
 	return ch >= '0' && ch <= '9'
@@ -457,7 +587,8 @@ func Tokenize(input string) ([]Token, error) {
 			break
 		}
 		if tok.Type == TokenIllegal {
-			return nil, fmt.Errorf("illegal token at line %d, column %d: %s", tok.Line, tok.Column, tok.Literal)
+			return nil, &sqlerr.SyntaxError{Line: tok.Line, Column: tok.Column,
+				Err: fmt.Errorf("illegal token at line %d, column %d: %s", tok.Line, tok.Column, tok.Literal)}
 		}
 	}
 
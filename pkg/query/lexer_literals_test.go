@@ -0,0 +1,93 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexerLiterals(t *testing.T) {
+	tests := []struct {
+		input string
+		typ   TokenType
+		lit   string
+	}{
+		{"x'DEADbeef'", TokenHexString, "DEADbeef"},
+		{"X''", TokenHexString, ""},
+		{"$$it's \\n raw$$", TokenString, "it's \\n raw"},
+		{"$body$ a $$ b $body$", TokenString, " a $$ b "},
+		{"1e10", TokenNumber, "1e10"},
+		{"2.5E-3", TokenNumber, "2.5E-3"},
+		{"6.02e+23", TokenNumber, "6.02e+23"},
+		{".5", TokenNumber, ".5"},
+		{"/* lead */ -- line\n 42 /* trail */", TokenNumber, "42"},
+	}
+	for _, tt := range tests {
+		tokens, err := Tokenize(tt.input)
+		if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+		if len(tokens) != 2 || tokens[0].Type != tt.typ || tokens[0].Literal != tt.lit {
+			t.Errorf("%q: got %v, want %v %q", tt.input, tokens, tt.typ, tt.lit)
+		}
+	}
+}
+
+func TestLexerEscapeBackslash(t *testing.T) {
+	tokens, err := Tokenize(`name LIKE '100\%' ESCAPE '\'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 6 || tokens[2].Literal != `100\%` || tokens[4].Type != TokenString || tokens[4].Literal != `\` {
+		t.Fatalf("tokens = %v", tokens)
+	}
+
+	// Outside an ESCAPE clause a backslash still escapes the next quote.
+	tokens, err = Tokenize(`'\'' ESCAPE '\''`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 4 || tokens[0].Literal != "'" || tokens[2].Literal != "'" {
+		t.Fatalf("tokens = %v", tokens)
+	}
+}
+
+func TestLexerExponentNeedsDigits(t *testing.T) {
+	tokens, err := Tokenize("1e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 3 || tokens[0].Literal != "1" || tokens[1].Type != TokenIdentifier || tokens[1].Literal != "e" {
+		t.Fatalf("tokens = %v", tokens)
+	}
+}
+
+func TestLexerLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"SELECT 1 /* open", "unterminated block comment"},
+		{"SELECT $$open", "unterminated dollar-quoted string"},
+		{"SELECT x'ABC'", "malformed blob literal"},
+		{"SELECT x'zz'", "malformed blob literal"},
+		{"SELECT x'AB", "unterminated blob literal"},
+	}
+	for _, tt := range tests {
+		_, err := Tokenize(tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestParseHexLiteral(t *testing.T) {
+	stmt, err := Parse("INSERT INTO t VALUES (X'CAFE')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc, ok := stmt.(*InsertStmt).Values[0][0].(*FunctionCall)
+	if !ok || fc.Name != "UNHEX" || fc.Args[0].(*StringLiteral).Value != "CAFE" {
+		t.Fatalf("X'CAFE' parsed as %#v", stmt.(*InsertStmt).Values[0][0])
+	}
+}
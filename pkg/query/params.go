@@ -0,0 +1,224 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// BindParams resolves the arguments for stmt's placeholders into the
+// positional list that execution reads by PlaceholderExpr.Index.
+//
+// Arguments passed as sql.NamedArg, or as the entries of a
+// map[string]interface{} argument when stmt has named parameters, bind the
+// :name and @name placeholders with that name, and the remaining arguments
+// bind the other placeholders in order. Without named arguments every
+// parameter takes the argument at its position in ParamNames. A slice (other
+// than []byte) bound to a placeholder that is an item of an IN list expands
+// into one list item per element.
+//
+// stmt is returned unchanged when there is nothing to resolve; otherwise
+// the result is a copy, so a cached statement is never modified.
+func BindParams(stmt Statement, args []interface{}) (Statement, []interface{}, error) {
+	named, expand, maps := false, false, false
+	for _, arg := range args {
+		switch arg.(type) {
+		case sql.NamedArg:
+			named = true
+		case map[string]interface{}:
+			maps = true
+		default:
+			expand = expand || isExpandableParam(arg)
+		}
+	}
+	// A map is a JSON value unless the statement has names for it to bind.
+	if maps && hasNamedParams(stmt) {
+		args = namedMapArgs(args)
+		named = true
+	}
+	if !named && !expand {
+		return stmt, args, nil
+	}
+
+	out := CloneStatement(stmt)
+	placeholders := collectStmtPlaceholders(out)
+	sort.SliceStable(placeholders, func(i, j int) bool {
+		return placeholders[i].Index < placeholders[j].Index
+	})
+
+	// A placeholder's value is looked up by its original index; a node
+	// reached twice in the tree is copied by the clone but keeps its index.
+	byIndex := make(map[int]interface{}, len(placeholders))
+	if named {
+		byName := make(map[string]interface{})
+		var positional []interface{}
+		for _, arg := range args {
+			if na, ok := arg.(sql.NamedArg); ok {
+				byName[na.Name] = na.Value
+			} else {
+				positional = append(positional, arg)
+			}
+		}
+		next := 0
+		for _, ph := range placeholders {
+			if _, done := byIndex[ph.Index]; done {
+				continue
+			}
+			if ph.Name != "" {
+				v, ok := byName[ph.Name]
+				if !ok {
+					return nil, nil, fmt.Errorf("no value for parameter :%s", ph.Name)
+				}
+				byIndex[ph.Index] = v
+			} else if next < len(positional) {
+				byIndex[ph.Index] = positional[next]
+				next++
+			}
+		}
+	} else {
+		for _, ph := range placeholders {
+			if ph.Index >= 0 && ph.Index < len(args) {
+				byIndex[ph.Index] = args[ph.Index]
+			}
+		}
+	}
+	values := make(map[*PlaceholderExpr]interface{}, len(placeholders))
+	for _, ph := range placeholders {
+		values[ph] = byIndex[ph.Index]
+	}
+
+	// The new placeholders an IN list item expands into, in element order.
+	expanded := make(map[*PlaceholderExpr][]*PlaceholderExpr)
+	var expandErr error
+	walkTableNames(reflect.ValueOf(out), func(v reflect.Value) {
+		if v.Type() != inExprType || !v.CanAddr() || expandErr != nil {
+			return
+		}
+		in := v.Addr().Interface().(*InExpr)
+		var list []Expression
+		changed := false
+		for _, item := range in.List {
+			ph, ok := item.(*PlaceholderExpr)
+			if !ok || !isExpandableParam(values[ph]) {
+				list = append(list, item)
+				continue
+			}
+			rv := reflect.ValueOf(values[ph])
+			if rv.Len() == 0 {
+				expandErr = fmt.Errorf("empty list bound to IN placeholder")
+				return
+			}
+			changed = true
+			for i := 0; i < rv.Len(); i++ {
+				elem := &PlaceholderExpr{Index: ph.Index, Name: ph.Name}
+				values[elem] = rv.Index(i).Interface()
+				expanded[ph] = append(expanded[ph], elem)
+				list = append(list, elem)
+			}
+		}
+		if changed {
+			in.List = list
+		}
+	})
+	if expandErr != nil {
+		return nil, nil, expandErr
+	}
+
+	// Renumber in statement order. Uses of the same parameter share a slot;
+	// each expanded list item gets its own.
+	bound := make([]interface{}, 0, len(values))
+	slots := make(map[int]int)
+	for _, ph := range placeholders {
+		if elems, ok := expanded[ph]; ok {
+			for _, elem := range elems {
+				elem.Index = len(bound)
+				bound = append(bound, values[elem])
+			}
+			continue
+		}
+		slot, ok := slots[ph.Index]
+		if !ok {
+			slot = len(bound)
+			slots[ph.Index] = slot
+			bound = append(bound, values[ph])
+		}
+		ph.Index = slot
+	}
+	return out, bound, nil
+}
+
+// ParamNames returns one entry per parameter of stmt, in the order the
+// parameters first appear: the name of a :name or @name parameter, or ""
+// for a ? placeholder. A name used more than once is a single parameter.
+func ParamNames(stmt Statement) []string {
+	placeholders := collectStmtPlaceholders(stmt)
+	n := 0
+	for _, ph := range placeholders {
+		if ph.Index >= n {
+			n = ph.Index + 1
+		}
+	}
+	names := make([]string, n)
+	for _, ph := range placeholders {
+		if ph.Index >= 0 {
+			names[ph.Index] = ph.Name
+		}
+	}
+	return names
+}
+
+func hasNamedParams(stmt Statement) bool {
+	for _, ph := range collectStmtPlaceholders(stmt) {
+		if ph.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// namedMapArgs replaces each map[string]interface{} argument with one
+// sql.NamedArg per entry.
+func namedMapArgs(args []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		m, ok := arg.(map[string]interface{})
+		if !ok {
+			out = append(out, arg)
+			continue
+		}
+		for name, v := range m {
+			out = append(out, sql.Named(name, v))
+		}
+	}
+	return out
+}
+
+var (
+	placeholderExprType = reflect.TypeOf(PlaceholderExpr{})
+	inExprType          = reflect.TypeOf(InExpr{})
+)
+
+// collectStmtPlaceholders returns every placeholder in stmt.
+func collectStmtPlaceholders(stmt Statement) []*PlaceholderExpr {
+	var placeholders []*PlaceholderExpr
+	walkTableNames(reflect.ValueOf(stmt), func(v reflect.Value) {
+		if v.Type() == placeholderExprType && v.CanAddr() {
+			placeholders = append(placeholders, v.Addr().Interface().(*PlaceholderExpr))
+		}
+	})
+	return placeholders
+}
+
+// isExpandableParam reports whether v is a list argument for an IN list.
+// []byte is a BLOB value, not a list.
+func isExpandableParam(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if _, ok := v.([]byte); ok {
+		return false
+	}
+	k := reflect.TypeOf(v).Kind()
+	return k == reflect.Slice || k == reflect.Array
+}
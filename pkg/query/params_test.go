@@ -0,0 +1,82 @@
+package query
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholderIndexesFollowStatementOrder(t *testing.T) {
+	for _, sqlText := range []string{
+		"SELECT ? FROM t WHERE a = ? GROUP BY b HAVING COUNT(*) > ? ORDER BY c LIMIT ? OFFSET ?",
+		"UPDATE t SET a = ?, b = ? FROM u WHERE u.id = t.id AND u.x = ?",
+		"DELETE FROM t USING u JOIN v ON v.id = ? WHERE t.a = ? AND u.b IN (?, ?)",
+		"INSERT INTO t VALUES (1, ?), (?, 2) ON CONFLICT (id) DO UPDATE SET b = ?",
+		"SELECT a FROM t WHERE a = :a UNION SELECT a FROM u WHERE a = @b LIMIT ?",
+	} {
+		stmt, err := Parse(sqlText)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", sqlText, err)
+		}
+		seen := make(map[int]bool)
+		for _, ph := range collectStmtPlaceholders(stmt) {
+			seen[ph.Index] = true
+		}
+		for i := 0; i < len(seen); i++ {
+			if !seen[i] {
+				t.Errorf("%q: placeholder indexes %v are not 0..n-1", sqlText, seen)
+				break
+			}
+		}
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	stmt, err := Parse("SELECT a FROM t WHERE b IN (?) AND c = :c AND d IN (:d, ?) LIMIT ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound, args, err := BindParams(stmt, []interface{}{
+		[]int64{1, 2}, sql.Named("d", []string{"x", "y"}), "z", 10, sql.Named("c", true),
+	})
+	if err != nil {
+		t.Fatalf("BindParams: %v", err)
+	}
+	want := []interface{}{int64(1), int64(2), true, "x", "y", "z", 10}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %#v, want %#v", args, want)
+	}
+	for _, ph := range collectStmtPlaceholders(bound) {
+		if ph.Index < 0 || ph.Index >= len(args) {
+			t.Errorf("placeholder index %d out of range", ph.Index)
+		}
+	}
+	if in := stmt.(*SelectStmt).Where.(*BinaryExpr).Left.(*BinaryExpr).Left.(*InExpr); len(in.List) != 1 {
+		t.Errorf("BindParams modified the parsed statement: %d IN items", len(in.List))
+	}
+
+	// Nothing to resolve: the statement and arguments come back unchanged.
+	if out, outArgs, err := BindParams(stmt, []interface{}{1, []byte("blob")}); err != nil || out != stmt || len(outArgs) != 2 {
+		t.Errorf("BindParams without named or list args = %v, %v, %v", out, outArgs, err)
+	}
+	if _, _, err := BindParams(stmt, []interface{}{sql.Named("d", 1)}); err == nil {
+		t.Error("BindParams without a value for :c succeeded")
+	}
+}
+
+func TestParamNames(t *testing.T) {
+	stmt, err := Parse("SELECT a FROM t WHERE b = :b AND c = ? AND d = @d AND e = :b LIMIT ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ParamNames(stmt); !reflect.DeepEqual(got, []string{"b", "", "d", ""}) {
+		t.Errorf("ParamNames = %q", got)
+	}
+	_, args, err := BindParams(stmt, []interface{}{map[string]interface{}{"b": 1, "d": 2}, 3, 4})
+	if err != nil {
+		t.Fatalf("BindParams: %v", err)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, 3, 2, 4}) {
+		t.Errorf("args = %v", args)
+	}
+}
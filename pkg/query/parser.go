@@ -1,17 +1,21 @@
 package query
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 )
 
 // Parser parses SQL tokens into an AST
 type Parser struct {
 	tokens                 []Token
 	pos                    int
-	placeholderCount       int // Counter for auto-assigning placeholder indices
-	derivedAliasCount      int // Counter for generated aliases on anonymous derived tables
+	placeholderCount       int            // Counter for auto-assigning placeholder indices
+	namedParamIndex        map[string]int // Index of each named parameter seen so far
+	derivedAliasCount      int            // Counter for generated aliases on anonymous derived tables
 	depth                  int
 	strict                 bool // When true, expect() errors instead of silently advancing on known-token mismatches
 	stopAtMatchAgainstMode bool
@@ -67,6 +71,7 @@ func (p *Parser) leaveDepth() {
 func (p *Parser) Parse() (Statement, error) {
 	// Reset placeholder counter for each parse
 	p.placeholderCount = 0
+	p.namedParamIndex = nil
 	p.derivedAliasCount = 0
 
 	if p.current().Type == TokenEOF {
@@ -81,6 +86,21 @@ func (p *Parser) Parse() (Statement, error) {
 		return p.parseBegin()
 	}
 
+	// PRAGMA is not a reserved word, so it lexes as an identifier too.
+	if p.current().Type == TokenIdentifier && toUpperFast(p.current().Literal) == "PRAGMA" {
+		return p.parsePragma()
+	}
+
+	// Neither are ATTACH and DETACH.
+	if p.current().Type == TokenIdentifier {
+		switch toUpperFast(p.current().Literal) {
+		case "ATTACH":
+			return p.parseAttach()
+		case "DETACH":
+			return p.parseDetach()
+		}
+	}
+
 	switch p.current().Type {
 	case TokenWith:
 		return p.parseWithCTE()
@@ -234,6 +254,17 @@ func (p *Parser) parseTemporalExpr() (*TemporalExpr, error) {
 	return temporal, nil
 }
 
+// parseIfExists consumes an optional IF EXISTS clause.
+func (p *Parser) parseIfExists() (bool, error) {
+	if !p.match(TokenIf) {
+		return false, nil
+	}
+	if _, err := p.expect(TokenExists); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (p *Parser) parseIfNotExists() bool {
 	if p.match(TokenIf) {
 		if p.match(TokenNot) {
@@ -492,120 +523,6 @@ func (p *Parser) parseIdentifierList() ([]string, error) {
 	return ids, nil
 }
 
-func reindexPlaceholders(expr Expression, offset int) {
-	phs := collectPlaceholders(expr)
-	for i, ph := range phs {
-		ph.Index = offset + i
-	}
-}
-
-func reindexPlaceholdersFromExpr(expr Expression, offset int) {
-	phs := collectPlaceholders(expr)
-	for i, ph := range phs {
-		ph.Index = offset + i
-	}
-}
-
-// collectPlaceholders collects all PlaceholderExpr nodes from an expression
-func collectPlaceholders(expr Expression) []*PlaceholderExpr {
-	var placeholders []*PlaceholderExpr
-	collectPlaceholdersRecursive(expr, &placeholders)
-	return placeholders
-}
-
-func collectPlaceholdersRecursive(expr Expression, placeholders *[]*PlaceholderExpr) {
-	if expr == nil {
-		return
-	}
-
-	switch e := expr.(type) {
-	case *PlaceholderExpr:
-		*placeholders = append(*placeholders, e)
-	case *BinaryExpr:
-		collectPlaceholdersRecursive(e.Left, placeholders)
-		collectPlaceholdersRecursive(e.Right, placeholders)
-	case *UnaryExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-	case *FunctionCall:
-		for _, arg := range e.Args {
-			collectPlaceholdersRecursive(arg, placeholders)
-		}
-		collectPlaceholdersRecursive(e.Filter, placeholders)
-		for _, ob := range e.OrderBy {
-			if ob != nil {
-				collectPlaceholdersRecursive(ob.Expr, placeholders)
-			}
-		}
-	case *WindowExpr:
-		for _, arg := range e.Args {
-			collectPlaceholdersRecursive(arg, placeholders)
-		}
-		collectPlaceholdersRecursive(e.Filter, placeholders)
-		for _, partitionExpr := range e.PartitionBy {
-			collectPlaceholdersRecursive(partitionExpr, placeholders)
-		}
-		for _, ob := range e.OrderBy {
-			if ob != nil {
-				collectPlaceholdersRecursive(ob.Expr, placeholders)
-			}
-		}
-	case *InExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-		for _, item := range e.List {
-			collectPlaceholdersRecursive(item, placeholders)
-		}
-		if e.Subquery != nil {
-			collectPlaceholdersRecursive(e.Subquery.Where, placeholders)
-			collectPlaceholdersRecursive(e.Subquery.Having, placeholders)
-			for _, col := range e.Subquery.Columns {
-				collectPlaceholdersRecursive(col, placeholders)
-			}
-		}
-	case *BetweenExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-		collectPlaceholdersRecursive(e.Lower, placeholders)
-		collectPlaceholdersRecursive(e.Upper, placeholders)
-	case *LikeExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-		collectPlaceholdersRecursive(e.Pattern, placeholders)
-	case *IsNullExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-	case *SubqueryExpr:
-		if e.Query != nil {
-			collectPlaceholdersRecursive(e.Query.Where, placeholders)
-			collectPlaceholdersRecursive(e.Query.Having, placeholders)
-			for _, col := range e.Query.Columns {
-				collectPlaceholdersRecursive(col, placeholders)
-			}
-			for _, join := range e.Query.Joins {
-				collectPlaceholdersRecursive(join.Condition, placeholders)
-			}
-		}
-	case *ExistsExpr:
-		if e.Subquery != nil {
-			collectPlaceholdersRecursive(e.Subquery.Where, placeholders)
-			collectPlaceholdersRecursive(e.Subquery.Having, placeholders)
-			for _, col := range e.Subquery.Columns {
-				collectPlaceholdersRecursive(col, placeholders)
-			}
-			for _, join := range e.Subquery.Joins {
-				collectPlaceholdersRecursive(join.Condition, placeholders)
-			}
-		}
-	case *CaseExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-		for _, w := range e.Whens {
-			collectPlaceholdersRecursive(w.Condition, placeholders)
-			collectPlaceholdersRecursive(w.Result, placeholders)
-		}
-		collectPlaceholdersRecursive(e.Else, placeholders)
-	case *AliasExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-	case *CastExpr:
-		collectPlaceholdersRecursive(e.Expr, placeholders)
-	}
-}
-
 // parseProcedureBody parses BEGIN ... END block
 func (p *Parser) parseProcedureBody() ([]Statement, error) {
 	if _, err := p.expect(TokenBegin); err != nil {
@@ -722,7 +639,11 @@ func Parse(sql string) (Statement, error) {
 	}
 
 	parser := NewParser(tokens)
-	return parser.Parse()
+	stmt, err := parser.Parse()
+	if err != nil {
+		return nil, parser.syntaxError(err)
+	}
+	return stmt, nil
 }
 
 // ParseStrict parses a SQL string and rejects any non-semicolon tokens left
@@ -737,14 +658,25 @@ func ParseStrict(sql string) (Statement, error) {
 	parser := NewParserStrict(tokens)
 	stmt, err := parser.Parse()
 	if err != nil {
-		return nil, err
+		return nil, parser.syntaxError(err)
 	}
 	if err := parser.expectStatementEnd(); err != nil {
-		return nil, err
+		return nil, parser.syntaxError(err)
 	}
 	return stmt, nil
 }
 
+// syntaxError wraps a parse failure in a *sqlerr.SyntaxError positioned at
+// the token the parser stopped at.
+func (p *Parser) syntaxError(err error) error {
+	var se *sqlerr.SyntaxError
+	if errors.As(err, &se) {
+		return err
+	}
+	tok := p.current()
+	return &sqlerr.SyntaxError{Line: tok.Line, Column: tok.Column, Err: err}
+}
+
 func (p *Parser) expectStatementEnd() error {
 	for p.current().Type == TokenSemicolon {
 		p.advance()
@@ -0,0 +1,145 @@
+package query
+
+import "testing"
+
+func TestParseAttachDetachStmts(t *testing.T) {
+	stmt, err := Parse("ATTACH DATABASE 'other.cb' AS aux")
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if a, ok := stmt.(*AttachStmt); !ok || a.Path != "other.cb" || a.Name != "aux" {
+		t.Fatalf("attach: got %#v", stmt)
+	}
+	stmt, err = Parse("attach 'other.cb' as aux;")
+	if err != nil {
+		t.Fatalf("attach without DATABASE: %v", err)
+	}
+	if a, ok := stmt.(*AttachStmt); !ok || a.Name != "aux" {
+		t.Fatalf("attach without DATABASE: got %#v", stmt)
+	}
+	for _, sql := range []string{"DETACH DATABASE aux", "DETACH aux"} {
+		stmt, err := Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		if d, ok := stmt.(*DetachStmt); !ok || d.Name != "aux" {
+			t.Fatalf("%s: got %#v", sql, stmt)
+		}
+	}
+	for _, sql := range []string{"ATTACH other AS aux", "ATTACH 'other.cb'", "ATTACH 'other.cb' AS", "DETACH"} {
+		if _, err := Parse(sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestParseSchemaQualifiedTables(t *testing.T) {
+	stmt, err := Parse("SELECT t.a, aux.u.b FROM aux.t JOIN aux.u AS x ON t.id = x.id")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	if sel.From.Name != "aux.t" || sel.From.Alias != "t" {
+		t.Errorf("FROM: name %q alias %q", sel.From.Name, sel.From.Alias)
+	}
+	if j := sel.Joins[0].Table; j.Name != "aux.u" || j.Alias != "x" {
+		t.Errorf("JOIN: name %q alias %q", j.Name, j.Alias)
+	}
+	if q, ok := sel.Columns[1].(*QualifiedIdentifier); !ok || q.Table != "u" || q.Column != "b" {
+		t.Errorf("schema.table.column: got %#v", sel.Columns[1])
+	}
+
+	tables := map[string]string{
+		"INSERT INTO aux.t VALUES (1)":           "aux.t",
+		"UPDATE aux.t SET a = 1":                 "aux.t",
+		"DELETE FROM aux.t WHERE a = 1":          "aux.t",
+		"CREATE TABLE aux.t (a INTEGER)":         "aux.t",
+		"DROP TABLE IF EXISTS aux.t":             "aux.t",
+		"CREATE INDEX idx_a ON aux.t (a)":        "aux.t",
+		"INSERT INTO main.t SELECT * FROM aux.t": "t",
+	}
+	for sql, want := range tables {
+		stmt, err := Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		var got string
+		switch s := stmt.(type) {
+		case *InsertStmt:
+			got = s.Table
+		case *UpdateStmt:
+			got = s.Table
+		case *DeleteStmt:
+			got = s.Table
+		case *CreateTableStmt:
+			got = s.Table
+		case *DropTableStmt:
+			got = s.Table
+		case *CreateIndexStmt:
+			got = s.Table
+		}
+		if got != want {
+			t.Errorf("%s: table %q, want %q", sql, got, want)
+		}
+	}
+
+	if schema, table := SplitTableName("aux.t"); schema != "aux" || table != "t" {
+		t.Errorf("SplitTableName(aux.t) = %q, %q", schema, table)
+	}
+	if schema, table := SplitTableName("t"); schema != "" || table != "t" {
+		t.Errorf("SplitTableName(t) = %q, %q", schema, table)
+	}
+}
+
+func TestParseSchemaStmts(t *testing.T) {
+	stmt, err := Parse("CREATE SCHEMA IF NOT EXISTS analytics")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if s, ok := stmt.(*CreateSchemaStmt); !ok || s.Name != "analytics" || !s.IfNotExists {
+		t.Fatalf("create: got %#v", stmt)
+	}
+	stmt, err = Parse("DROP SCHEMA IF EXISTS analytics CASCADE")
+	if err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	if s, ok := stmt.(*DropSchemaStmt); !ok || s.Name != "analytics" || !s.IfExists || !s.Cascade {
+		t.Fatalf("drop: got %#v", stmt)
+	}
+	stmt, err = Parse("DROP SCHEMA analytics RESTRICT")
+	if err != nil {
+		t.Fatalf("drop restrict: %v", err)
+	}
+	if s, ok := stmt.(*DropSchemaStmt); !ok || s.Cascade {
+		t.Fatalf("drop restrict: got %#v", stmt)
+	}
+}
+
+func TestRenameTables(t *testing.T) {
+	stmt, err := Parse("WITH recent AS (SELECT id FROM events) SELECT e.id FROM events e JOIN recent ON recent.id = e.id WHERE e.id IN (SELECT id FROM users)")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	renamed := RenameTables(stmt, func(name string) string { return "app." + name })
+	if renamed == stmt {
+		t.Fatal("RenameTables returned the original statement")
+	}
+	sel := renamed.(*SelectStmtWithCTE)
+	if from := sel.Select.From; from.Name != "app.events" || from.Alias != "e" {
+		t.Errorf("FROM: name %q alias %q", from.Name, from.Alias)
+	}
+	if j := sel.Select.Joins[0].Table; j.Name != "recent" {
+		t.Errorf("CTE reference renamed to %q", j.Name)
+	}
+	if cte := sel.CTEs[0].Query.(*SelectStmt).From; cte.Name != "app.events" || cte.Alias != "events" {
+		t.Errorf("CTE body FROM: name %q alias %q", cte.Name, cte.Alias)
+	}
+	if orig := stmt.(*SelectStmtWithCTE).Select.From.Name; orig != "events" {
+		t.Errorf("original statement changed: FROM %q", orig)
+	}
+
+	ins, _ := Parse("INSERT INTO t VALUES (1)")
+	if same := RenameTables(ins, func(name string) string { return name }); same != ins {
+		t.Error("RenameTables copied a statement it did not change")
+	}
+}
@@ -27,6 +27,16 @@ func TestParseShowDatabases(t *testing.T) {
 	}
 }
 
+func TestParseShowConfig(t *testing.T) {
+	stmt, err := Parse("SHOW CONFIG")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, ok := stmt.(*ShowConfigStmt); !ok {
+		t.Errorf("Expected *ShowConfigStmt, got %T", stmt)
+	}
+}
+
 func TestParseShowCreateTable(t *testing.T) {
 	stmt, err := Parse("SHOW CREATE TABLE users")
 	if err != nil {
@@ -776,6 +786,20 @@ func TestParseDerivedTable(t *testing.T) {
 	}
 }
 
+func TestParseDerivedTableColumnList(t *testing.T) {
+	stmt, err := Parse("SELECT a FROM (SELECT 1, 2) AS sub (a, b) ORDER BY a")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	if got := strings.Join(sel.From.Columns, ","); got != "a,b" {
+		t.Errorf("Columns = %q, want a,b", got)
+	}
+	if len(sel.OrderBy) != 1 {
+		t.Errorf("ORDER BY after the column list was not parsed")
+	}
+}
+
 // ---- Lexer edge cases ----
 
 func TestLexerNeqOperator(t *testing.T) {
@@ -105,10 +105,53 @@ func (p *Parser) parseCreate() (Statement, error) {
 		}
 		return p.parseCreatePolicy()
 	default:
+		if isKeywordIdentifier(p.current(), "VIRTUAL") {
+			if temporary {
+				return nil, fmt.Errorf("TEMPORARY is only supported for CREATE TABLE")
+			}
+			return p.parseCreateVirtualTable()
+		}
+		if isKeywordIdentifier(p.current(), "SEQUENCE") {
+			if temporary {
+				return nil, fmt.Errorf("TEMPORARY is only supported for CREATE TABLE")
+			}
+			return p.parseCreateSequence()
+		}
+		if isKeywordIdentifier(p.current(), "SCHEMA") {
+			if temporary {
+				return nil, fmt.Errorf("TEMPORARY is only supported for CREATE TABLE")
+			}
+			return p.parseCreateSchema()
+		}
 		return nil, fmt.Errorf("unexpected token after CREATE: %s", p.current().Literal)
 	}
 }
 
+// isCTASColumnList reports whether the parser is at the (col, ...) list of
+// CREATE TABLE t (col, ...) AS query: bare names, with no types, followed by
+// the query.
+func (p *Parser) isCTASColumnList() bool {
+	if p.current().Type != TokenLParen {
+		return false
+	}
+	i := p.pos + 1
+	for i+1 < len(p.tokens) && p.tokens[i].Type == TokenIdentifier {
+		switch p.tokens[i+1].Type {
+		case TokenComma:
+			i += 2
+		case TokenRParen:
+			if i+2 >= len(p.tokens) {
+				return false
+			}
+			next := p.tokens[i+2].Type
+			return next == TokenAs || next == TokenSelect || next == TokenWith
+		default:
+			return false
+		}
+	}
+	return false
+}
+
 // parseCreateTable parses CREATE TABLE
 func (p *Parser) parseCreateTable() (*CreateTableStmt, error) {
 	stmt := &CreateTableStmt{}
@@ -116,20 +159,32 @@ func (p *Parser) parseCreateTable() (*CreateTableStmt, error) {
 
 	stmt.IfNotExists = p.parseIfNotExists()
 
-	table, err := p.expect(TokenIdentifier)
+	table, err := p.parseTableName()
 	if err != nil {
 		return nil, err
 	}
-	stmt.Table = table.Literal
+	stmt.Table = table
 
-	// CREATE TABLE name AS SELECT ... (CTAS). The AS keyword is optional.
+	// CREATE TABLE name [(col, ...)] AS query (CTAS). The AS keyword is
+	// optional.
+	if p.isCTASColumnList() {
+		p.advance() // consume (
+		columns, err := p.parseIdentifierList()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(TokenRParen); err != nil {
+			return nil, err
+		}
+		stmt.AsColumns = columns
+	}
 	if p.current().Type == TokenAs || p.current().Type == TokenSelect || p.current().Type == TokenWith {
 		p.match(TokenAs)
-		sel, err := p.parseSelect()
+		query, err := p.parseQuery()
 		if err != nil {
 			return nil, err
 		}
-		stmt.AsSelect = sel
+		stmt.AsSelect = query
 		return stmt, nil
 	}
 
@@ -272,9 +327,70 @@ func (p *Parser) parseCreateTable() (*CreateTableStmt, error) {
 		stmt.Partition = partitionDef
 	}
 
+	// Storage engine: CREATE TABLE ... USING append
+	if p.match(TokenUsing) {
+		engine := p.current()
+		if engine.Type == TokenEOF || engine.Type == TokenSemicolon {
+			return nil, fmt.Errorf("expected storage engine after USING")
+		}
+		p.advance()
+		stmt.Engine = strings.ToLower(engine.Literal)
+	}
+
+	// Table options: CREATE TABLE ... WITH (ttl_column = expires_at) and
+	// WITH SYSTEM VERSIONING, in either order.
+	for p.match(TokenWith) {
+		if p.match(TokenSystem) {
+			if !isKeywordIdentifier(p.current(), "VERSIONING") {
+				return nil, fmt.Errorf("expected VERSIONING after WITH SYSTEM, got %s", p.current().Literal)
+			}
+			p.advance()
+			stmt.SystemVersioning = true
+			continue
+		}
+		if err := p.parseTableOptions(stmt); err != nil {
+			return nil, err
+		}
+	}
+
 	return stmt, nil
 }
 
+// parseTableOptions parses the parenthesized name = value list of a CREATE
+// TABLE's WITH clause into stmt.
+func (p *Parser) parseTableOptions(stmt *CreateTableStmt) error {
+	if _, err := p.expect(TokenLParen); err != nil {
+		return err
+	}
+	for {
+		name := p.current()
+		if name.Type != TokenIdentifier {
+			return fmt.Errorf("expected table option name, got %s", name.Literal)
+		}
+		p.advance()
+		if _, err := p.expect(TokenEq); err != nil {
+			return err
+		}
+		// Allow keywords as column names, as in column lists.
+		value := p.current()
+		if value.Literal == "" || value.Type == TokenEOF || value.Type == TokenRParen || value.Type == TokenComma {
+			return fmt.Errorf("expected value for table option %s", name.Literal)
+		}
+		p.advance()
+		switch strings.ToLower(name.Literal) {
+		case "ttl_column":
+			stmt.TTLColumn = value.Literal
+		default:
+			return fmt.Errorf("unknown table option %s", name.Literal)
+		}
+		if !p.match(TokenComma) {
+			break
+		}
+	}
+	_, err := p.expect(TokenRParen)
+	return err
+}
+
 // parseCreateForeignTable parses CREATE FOREIGN TABLE
 func (p *Parser) parseCreateForeignTable() (*CreateForeignTableStmt, error) {
 	stmt := &CreateForeignTableStmt{Options: make(map[string]string)}
@@ -594,6 +710,13 @@ func (p *Parser) parseColumnDef() (*ColumnDef, error) {
 	case TokenInteger, TokenText, TokenReal, TokenBlob, TokenBoolean, TokenJSON, TokenDate, TokenTimestamp, TokenDatetime, TokenVector:
 		col.Type = p.current().Type
 		p.advance()
+	case TokenIdentifier:
+		// UUID is not a keyword, so columns named uuid keep working.
+		if !strings.EqualFold(p.current().Literal, "UUID") {
+			return nil, fmt.Errorf("expected data type, got %s", p.current().Literal)
+		}
+		col.Type = TokenUUID
+		p.advance()
 	default:
 		return nil, fmt.Errorf("expected data type, got %s", p.current().Literal)
 	}
@@ -688,6 +811,15 @@ func (p *Parser) parseColumnDef() (*ColumnDef, error) {
 			col.ForeignKey = fk
 			pendingConstraintName = ""
 		case TokenIdentifier:
+			if strings.EqualFold(p.current().Literal, "MASKED") {
+				p.advance()
+				mask, err := p.parseColumnMask()
+				if err != nil {
+					return nil, err
+				}
+				col.Mask = mask
+				continue
+			}
 			if !strings.EqualFold(p.current().Literal, "COLLATE") {
 				return col, nil
 			}
@@ -703,6 +835,42 @@ func (p *Parser) parseColumnDef() (*ColumnDef, error) {
 	}
 }
 
+// parseColumnMask parses what follows MASKED: nothing, for the full mask,
+// or WITH and a masking function called with integer arguments, such as
+// MASKED WITH MASK_LAST(4).
+func (p *Parser) parseColumnMask() (*ColumnMask, error) {
+	mask := &ColumnMask{Function: "MASK"}
+	if !p.match(TokenWith) {
+		return mask, nil
+	}
+	if p.current().Type != TokenIdentifier {
+		return nil, fmt.Errorf("expected masking function after MASKED WITH, got %s", p.current().Literal)
+	}
+	mask.Function = strings.ToUpper(p.current().Literal)
+	p.advance()
+	if _, err := p.expect(TokenLParen); err != nil {
+		return nil, err
+	}
+	for p.current().Type != TokenRParen {
+		if len(mask.Args) > 0 {
+			if _, err := p.expect(TokenComma); err != nil {
+				return nil, err
+			}
+		}
+		tok, err := p.expect(TokenNumber)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseInt(tok.Literal, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s argument %s", mask.Function, tok.Literal)
+		}
+		mask.Args = append(mask.Args, n)
+	}
+	p.advance() // consume )
+	return mask, nil
+}
+
 // parseCreateIndex parses CREATE INDEX
 func (p *Parser) parseCreateIndex() (*CreateIndexStmt, error) {
 	stmt := &CreateIndexStmt{}
@@ -724,21 +892,43 @@ func (p *Parser) parseCreateIndex() (*CreateIndexStmt, error) {
 		return nil, err
 	}
 
-	table, err := p.expect(TokenIdentifier)
+	table, err := p.parseTableName()
 	if err != nil {
 		return nil, err
 	}
-	stmt.Table = table.Literal
+	stmt.Table = table
+
+	// PostgreSQL-style access method: CREATE INDEX ... ON t USING GIN (col)
+	if p.match(TokenUsing) {
+		method := p.current()
+		if method.Type == TokenEOF || method.Type == TokenLParen {
+			return nil, fmt.Errorf("expected index method after USING")
+		}
+		p.advance()
+		stmt.Method = strings.ToUpper(method.Literal)
+		if stmt.Method == "BTREE" {
+			stmt.Method = ""
+		}
+	}
 
 	if _, err := p.expect(TokenLParen); err != nil {
 		return nil, err
 	}
 
-	columns, err := p.parseIndexColumnList()
-	if err != nil {
-		return nil, err
+	if p.atJSONIndexExpression() {
+		column, path, err := p.parseJSONIndexExpression()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Columns = []string{column}
+		stmt.JSONPath = path
+	} else {
+		columns, err := p.parseIndexColumnList()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Columns = columns
 	}
-	stmt.Columns = columns
 
 	if _, err := p.expect(TokenRParen); err != nil {
 		return nil, err
@@ -747,6 +937,44 @@ func (p *Parser) parseCreateIndex() (*CreateIndexStmt, error) {
 	return stmt, nil
 }
 
+// atJSONIndexExpression reports whether an index column list starts with a
+// JSON path expression: JSON_EXTRACT(col, 'path'), col->'path', col->>'path',
+// optionally wrapped in parentheses.
+func (p *Parser) atJSONIndexExpression() bool {
+	switch p.current().Type {
+	case TokenJsonExtract, TokenLParen:
+		return true
+	case TokenIdentifier:
+		next := p.peek().Type
+		return next == TokenArrow || next == TokenArrow2
+	}
+	return false
+}
+
+// parseJSONIndexExpression parses the single expression of a JSON path index
+// and returns the indexed column and path.
+func (p *Parser) parseJSONIndexExpression() (string, string, error) {
+	expr, err := p.parseExpression()
+	if err != nil {
+		return "", "", err
+	}
+	switch e := expr.(type) {
+	case *JSONPathExpr:
+		if col, ok := e.Column.(*Identifier); ok {
+			return col.Name, e.Path, nil
+		}
+	case *FunctionCall:
+		if e.Name == "JSON_EXTRACT" && len(e.Args) == 2 {
+			col, colOK := e.Args[0].(*Identifier)
+			path, pathOK := e.Args[1].(*StringLiteral)
+			if colOK && pathOK {
+				return col.Name, path.Value, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("index expression must be JSON_EXTRACT(column, 'path') or column->'path'")
+}
+
 func (p *Parser) parseIndexColumnList() ([]string, error) {
 	var columns []string
 	for {
@@ -874,6 +1102,7 @@ func (p *Parser) parseDropView() (*DropViewStmt, error) {
 		return nil, err
 	}
 	stmt.Name = name.Literal
+	stmt.Cascade = p.parseDropBehavior()
 
 	return stmt, nil
 }
@@ -1147,6 +1376,12 @@ func (p *Parser) parseDrop() (Statement, error) {
 	case TokenPolicy:
 		return p.parseDropPolicy()
 	default:
+		if isKeywordIdentifier(p.current(), "SEQUENCE") {
+			return p.parseDropSequence()
+		}
+		if isKeywordIdentifier(p.current(), "SCHEMA") {
+			return p.parseDropSchema()
+		}
 		return nil, fmt.Errorf("unexpected token after DROP: %s", p.current().Literal)
 	}
 }
@@ -1183,15 +1418,26 @@ func (p *Parser) parseDropTable() (*DropTableStmt, error) {
 		stmt.IfExists = true
 	}
 
-	table, err := p.expect(TokenIdentifier)
+	table, err := p.parseTableName()
 	if err != nil {
 		return nil, err
 	}
-	stmt.Table = table.Literal
+	stmt.Table = table
+	stmt.Cascade = p.parseDropBehavior()
 
 	return stmt, nil
 }
 
+// parseDropBehavior parses an optional CASCADE or RESTRICT after a DROP,
+// reporting whether it was CASCADE.
+func (p *Parser) parseDropBehavior() bool {
+	if p.match(TokenCascade) {
+		return true
+	}
+	p.match(TokenRestrict)
+	return false
+}
+
 // parseDropIndex parses DROP INDEX
 func (p *Parser) parseDropIndex() (*DropIndexStmt, error) {
 	stmt := &DropIndexStmt{}
@@ -1220,6 +1466,10 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 	if _, err := p.expect(TokenTable); err != nil {
 		return nil, err
 	}
+	ifExists, err := p.parseIfExists()
+	if err != nil {
+		return nil, err
+	}
 
 	// Table name (allow keywords as table names)
 	tableName := p.current()
@@ -1229,7 +1479,7 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 		return nil, fmt.Errorf("expected table name, got %s", tableName.Literal)
 	}
 
-	stmt := &AlterTableStmt{Table: tableName.Literal}
+	stmt := &AlterTableStmt{Table: tableName.Literal, IfExists: ifExists}
 
 	switch p.current().Type {
 	case TokenAdd:
@@ -1243,6 +1493,7 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 		}
 		p.match(TokenColumn) // COLUMN keyword is optional
 		stmt.Action = "ADD"
+		stmt.TargetIfNotExists = p.parseIfNotExists()
 		col, err := p.parseColumnDef()
 		if err != nil {
 			return nil, err
@@ -1254,6 +1505,9 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 		p.advance()
 		if isKeywordIdentifier(p.current(), "CONSTRAINT") {
 			p.advance()
+			if stmt.TargetIfExists, err = p.parseIfExists(); err != nil {
+				return nil, err
+			}
 			constraintName := p.current()
 			if constraintName.Type == TokenIdentifier || (constraintName.Literal != "" && constraintName.Type != TokenEOF) {
 				stmt.Action = "DROP_CONSTRAINT"
@@ -1265,6 +1519,9 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 		}
 		p.match(TokenColumn) // COLUMN keyword is optional
 		stmt.Action = "DROP"
+		if stmt.TargetIfExists, err = p.parseIfExists(); err != nil {
+			return nil, err
+		}
 		colName := p.current()
 		if colName.Type == TokenIdentifier || (colName.Literal != "" && colName.Type != TokenEOF) {
 			stmt.NewName = colName.Literal // Store column name to drop in NewName
@@ -1313,9 +1570,26 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 			return nil, fmt.Errorf("expected TO or COLUMN after RENAME, got %s", p.current().Literal)
 		}
 
+	case TokenSet:
+		// SET COMPRESSION DICTIONARY | NONE
+		p.advance()
+		if !isKeywordIdentifier(p.current(), "COMPRESSION") {
+			return nil, fmt.Errorf("expected COMPRESSION after SET, got %s", p.current().Literal)
+		}
+		p.advance()
+		switch strings.ToUpper(p.current().Literal) {
+		case "DICTIONARY":
+			stmt.Action = "SET_COMPRESSION_DICTIONARY"
+		case "NONE":
+			stmt.Action = "SET_COMPRESSION_NONE"
+		default:
+			return nil, fmt.Errorf("expected DICTIONARY or NONE after SET COMPRESSION, got %s", p.current().Literal)
+		}
+		p.advance()
+
 	case TokenIdentifier:
 		if !strings.EqualFold(p.current().Literal, "ENABLE") {
-			return nil, fmt.Errorf("expected ADD, DROP, RENAME, or ENABLE, got %s", p.current().Literal)
+			return nil, fmt.Errorf("expected ADD, DROP, RENAME, SET, or ENABLE, got %s", p.current().Literal)
 		}
 		p.advance()
 		if !strings.EqualFold(p.current().Literal, "ROW") {
@@ -1333,7 +1607,7 @@ func (p *Parser) parseAlterTable() (*AlterTableStmt, error) {
 		stmt.Action = "ENABLE_RLS"
 
 	default:
-		return nil, fmt.Errorf("expected ADD, DROP, RENAME, or ENABLE, got %s", p.current().Literal)
+		return nil, fmt.Errorf("expected ADD, DROP, RENAME, SET, or ENABLE, got %s", p.current().Literal)
 	}
 
 	return stmt, nil
@@ -1442,15 +1716,6 @@ func (p *Parser) parseCall() (*CallProcedureStmt, error) {
 		}
 	}
 
-	placeholderOffset := 0
-	for _, arg := range stmt.Args {
-		placeholders := collectPlaceholders(arg.Expr)
-		for i, ph := range placeholders {
-			ph.Index = placeholderOffset + i
-		}
-		placeholderOffset += len(placeholders)
-	}
-
 	return stmt, nil
 }
 
@@ -1568,6 +1833,51 @@ func (p *Parser) parseCreateFTSIndex() (*CreateFTSIndexStmt, error) {
 	return stmt, nil
 }
 
+// parseCreateVirtualTable parses CREATE VIRTUAL TABLE
+// CREATE VIRTUAL TABLE [IF NOT EXISTS] name USING module (arg, ...)
+func (p *Parser) parseCreateVirtualTable() (*CreateVirtualTableStmt, error) {
+	stmt := &CreateVirtualTableStmt{}
+	p.advance() // consume VIRTUAL
+
+	if _, err := p.expect(TokenTable); err != nil {
+		return nil, err
+	}
+
+	stmt.IfNotExists = p.parseIfNotExists()
+
+	table, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Table = table.Literal
+
+	if _, err := p.expect(TokenUsing); err != nil {
+		return nil, err
+	}
+
+	module, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Module = module.Literal
+
+	if _, err := p.expect(TokenLParen); err != nil {
+		return nil, err
+	}
+
+	args, err := p.parseIdentifierList()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Args = args
+
+	if _, err := p.expect(TokenRParen); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
 // parseCreateVectorIndex parses CREATE VECTOR INDEX
 // CREATE VECTOR INDEX [IF NOT EXISTS] idx_name ON table_name (column_name)
 func (p *Parser) parseCreateVectorIndex() (*CreateVectorIndexStmt, error) {
@@ -1624,6 +1934,7 @@ func (p *Parser) parseCreatePolicy() (*CreatePolicyStmt, error) {
 		Event:      "ALL", // default
 	}
 	p.advance() // consume POLICY
+	stmt.IfNotExists = p.parseIfNotExists()
 
 	// Policy name
 	name, err := p.expect(TokenIdentifier)
@@ -1728,3 +2039,140 @@ func (p *Parser) parseCreatePolicy() (*CreatePolicyStmt, error) {
 
 	return stmt, nil
 }
+
+// parseCreateSequence parses CREATE SEQUENCE [IF NOT EXISTS] name
+// [START [WITH] n] [INCREMENT [BY] n] [MINVALUE n | NO MINVALUE]
+// [MAXVALUE n | NO MAXVALUE] [CYCLE | NO CYCLE]. Options may come in any order.
+func (p *Parser) parseCreateSequence() (*CreateSequenceStmt, error) {
+	stmt := &CreateSequenceStmt{}
+	p.advance() // consume SEQUENCE
+
+	stmt.IfNotExists = p.parseIfNotExists()
+
+	name, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name.Literal
+
+	for {
+		tok := p.current()
+		switch {
+		case isKeywordIdentifier(tok, "START"):
+			p.advance()
+			p.match(TokenWith)
+			if stmt.Start, err = p.parseSequenceValue("START"); err != nil {
+				return nil, err
+			}
+		case isKeywordIdentifier(tok, "INCREMENT"):
+			p.advance()
+			p.match(TokenBy)
+			if stmt.Increment, err = p.parseSequenceValue("INCREMENT"); err != nil {
+				return nil, err
+			}
+		case isKeywordIdentifier(tok, "MINVALUE"):
+			p.advance()
+			if stmt.MinValue, err = p.parseSequenceValue("MINVALUE"); err != nil {
+				return nil, err
+			}
+		case isKeywordIdentifier(tok, "MAXVALUE"):
+			p.advance()
+			if stmt.MaxValue, err = p.parseSequenceValue("MAXVALUE"); err != nil {
+				return nil, err
+			}
+		case isKeywordIdentifier(tok, "CYCLE"):
+			p.advance()
+			stmt.Cycle = true
+		case tok.Type == TokenNo:
+			p.advance()
+			switch {
+			case isKeywordIdentifier(p.current(), "MINVALUE"):
+				stmt.MinValue = nil
+			case isKeywordIdentifier(p.current(), "MAXVALUE"):
+				stmt.MaxValue = nil
+			case isKeywordIdentifier(p.current(), "CYCLE"):
+				stmt.Cycle = false
+			default:
+				return nil, fmt.Errorf("expected MINVALUE, MAXVALUE or CYCLE after NO, got %s", p.current().Literal)
+			}
+			p.advance()
+		default:
+			return stmt, nil
+		}
+	}
+}
+
+// parseSequenceValue parses the optionally signed integer of a sequence option.
+func (p *Parser) parseSequenceValue(option string) (*int64, error) {
+	negative := p.match(TokenMinus)
+	tok := p.current()
+	if tok.Type != TokenNumber {
+		return nil, fmt.Errorf("expected integer after %s, got %s", option, tok.Literal)
+	}
+	literal := tok.Literal
+	if negative {
+		literal = "-" + literal
+	}
+	n, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %s", option, literal)
+	}
+	p.advance()
+	return &n, nil
+}
+
+// parseDropSequence parses DROP SEQUENCE [IF EXISTS] name
+func (p *Parser) parseDropSequence() (*DropSequenceStmt, error) {
+	stmt := &DropSequenceStmt{}
+	p.advance() // consume SEQUENCE
+
+	if p.match(TokenIf) {
+		if _, err := p.expect(TokenExists); err != nil {
+			return nil, err
+		}
+		stmt.IfExists = true
+	}
+
+	name, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name.Literal
+	return stmt, nil
+}
+
+// parseCreateSchema parses CREATE SCHEMA [IF NOT EXISTS] name
+func (p *Parser) parseCreateSchema() (*CreateSchemaStmt, error) {
+	stmt := &CreateSchemaStmt{}
+	p.advance() // consume SCHEMA
+
+	stmt.IfNotExists = p.parseIfNotExists()
+
+	name, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name.Literal
+	return stmt, nil
+}
+
+// parseDropSchema parses DROP SCHEMA [IF EXISTS] name [CASCADE | RESTRICT]
+func (p *Parser) parseDropSchema() (*DropSchemaStmt, error) {
+	stmt := &DropSchemaStmt{}
+	p.advance() // consume SCHEMA
+
+	if p.match(TokenIf) {
+		if _, err := p.expect(TokenExists); err != nil {
+			return nil, err
+		}
+		stmt.IfExists = true
+	}
+
+	name, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name.Literal
+	stmt.Cascade = p.parseDropBehavior()
+	return stmt, nil
+}
@@ -42,6 +42,21 @@ func (p *Parser) parseSelect() (*SelectStmt, error) {
 			}
 			stmt.AsOf = temporal
 		}
+	} else if p.current().Type == TokenFor && isKeywordIdentifier(p.peek(), "SYSTEM_TIME") {
+		// FOR SYSTEM_TIME AS OF <timestamp> (SQL:2011 time travel)
+		p.advance() // consume FOR
+		p.advance() // consume SYSTEM_TIME
+		if _, err := p.expect(TokenAs); err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(TokenOf); err != nil {
+			return nil, err
+		}
+		ts, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		stmt.AsOf = &TemporalExpr{Timestamp: ts}
 	}
 
 	// WHERE
@@ -51,7 +66,6 @@ func (p *Parser) parseSelect() (*SelectStmt, error) {
 			return nil, err
 		}
 		stmt.Where = where
-		reindexPlaceholders(where, 0)
 	}
 
 	// GROUP BY
@@ -304,6 +318,19 @@ func (p *Parser) parseTableRef() (*TableRef, error) {
 			} else {
 				ref.Alias = p.nextDerivedTableAlias()
 				ref.Name = ref.Alias
+				return ref, nil
+			}
+			// Optional column names: (SELECT ...) AS t (a, b)
+			if p.current().Type == TokenLParen {
+				p.advance()
+				columns, err := p.parseIdentifierList()
+				if err != nil {
+					return nil, err
+				}
+				if _, err := p.expect(TokenRParen); err != nil {
+					return nil, fmt.Errorf("expected ')' after derived table column list")
+				}
+				ref.Columns = columns
 			}
 			return ref, nil
 		}
@@ -311,12 +338,12 @@ func (p *Parser) parseTableRef() (*TableRef, error) {
 		return nil, fmt.Errorf("expected SELECT after '(' in FROM clause")
 	}
 
-	tok, err := p.expect(TokenIdentifier)
+	name, err := p.parseTableName()
 	if err != nil {
 		return nil, err
 	}
 
-	ref := &TableRef{Name: tok.Literal}
+	ref := &TableRef{Name: name}
 
 	// Alias?
 	if p.current().Type == TokenIdentifier && !isTableIndexHintStart(p.current()) {
@@ -337,6 +364,10 @@ func (p *Parser) parseTableRef() (*TableRef, error) {
 		}
 		ref.Alias = alias.Literal
 	}
+	// As in SQLite, columns of schema.name can be qualified by name alone.
+	if _, table := SplitTableName(ref.Name); ref.Alias == "" && table != ref.Name {
+		ref.Alias = table
+	}
 
 	if err := p.parseTableIndexHint(ref); err != nil {
 		return nil, err
@@ -460,6 +491,22 @@ func (p *Parser) parseNaturalJoinType(join *JoinClause) {
 	}
 }
 
+// joinTypeName names a join type in parse errors.
+func joinTypeName(t TokenType) string {
+	switch t {
+	case TokenLeft:
+		return "LEFT JOIN"
+	case TokenRight:
+		return "RIGHT JOIN"
+	case TokenFull:
+		return "FULL JOIN"
+	case TokenInner:
+		return "INNER JOIN"
+	default:
+		return "JOIN"
+	}
+}
+
 func (p *Parser) parseJoinCondition(join *JoinClause) error {
 	switch {
 	case join.Type == TokenCross:
@@ -508,8 +555,8 @@ func (p *Parser) parseJoinCondition(join *JoinClause) error {
 			join.Using = columns
 		}
 	default:
-		if _, err := p.expect(TokenOn); err != nil {
-			return err
+		if !p.match(TokenOn) {
+			return fmt.Errorf("%s requires an ON or USING clause, got %q", joinTypeName(join.Type), p.current().Literal)
 		}
 		cond, err := p.parseExpression()
 		if err != nil {
@@ -588,23 +635,11 @@ func (p *Parser) parseWindowExpr(funcName string, args []Expression, filter Expr
 		if p.current().Type == TokenBy {
 			p.advance() // consume BY
 		}
-		for {
-			expr, err := p.parseExpression()
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse window ORDER BY expression: %w", err)
-			}
-			orderBy := &OrderByExpr{Expr: expr, Desc: false}
-			if p.current().Type == TokenDesc {
-				orderBy.Desc = true
-				p.advance()
-			} else if p.current().Type == TokenAsc {
-				p.advance()
-			}
-			windowExpr.OrderBy = append(windowExpr.OrderBy, orderBy)
-			if !p.match(TokenComma) {
-				break
-			}
+		orderBy, err := p.parseOrderByList()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse window ORDER BY expression: %w", err)
 		}
+		windowExpr.OrderBy = orderBy
 	}
 
 	// Parse optional window frame clause (ROWS/RANGE ...)
@@ -804,11 +839,11 @@ func (p *Parser) parseInsertTargetAndSource(stmt *InsertStmt, requireInto bool)
 		p.match(TokenInto)
 	}
 
-	table, err := p.expect(TokenIdentifier)
+	table, err := p.parseTableName()
 	if err != nil {
 		return nil, err
 	}
-	stmt.Table = table.Literal
+	stmt.Table = table
 
 	// Optional column list
 	if p.current().Type == TokenLParen {
@@ -826,13 +861,17 @@ func (p *Parser) parseInsertTargetAndSource(stmt *InsertStmt, requireInto bool)
 		}
 	}
 
-	// INSERT INTO ... SELECT ...
-	if p.current().Type == TokenSelect {
-		selectStmt, err := p.parseSelect()
+	// INSERT INTO ... [WITH ...] SELECT ...
+	if p.current().Type == TokenSelect || p.current().Type == TokenWith {
+		source, err := p.parseQuery()
 		if err != nil {
 			return nil, err
 		}
-		stmt.Select = selectStmt
+		if sel, ok := source.(*SelectStmt); ok {
+			stmt.Select = sel
+		} else {
+			stmt.Query = source
+		}
 		if err := p.parseInsertTail(stmt); err != nil {
 			return nil, err
 		}
@@ -853,7 +892,7 @@ func (p *Parser) parseInsertTargetAndSource(stmt *InsertStmt, requireInto bool)
 	// MySQL: INSERT INTO table SET col = expr [, col = expr] ...
 	if p.current().Type == TokenSet {
 		p.advance()
-		clauses, _, err := p.parseSetClauses()
+		clauses, err := p.parseSetClauses()
 		if err != nil {
 			return nil, err
 		}
@@ -875,25 +914,16 @@ func (p *Parser) parseInsertTargetAndSource(stmt *InsertStmt, requireInto bool)
 	}
 
 	// Value lists
-	rowCount := 0
-	// Calculate placeholder count - if columns specified, use that; otherwise detect from first row
-	placeholderCount := len(stmt.Columns)
 	for {
 		if _, err := p.expect(TokenLParen); err != nil {
 			return nil, err
 		}
 
-		offset := rowCount * placeholderCount
-		values, err := p.parseExpressionListWithOffset(offset)
+		values, err := p.parseExpressionList()
 		if err != nil {
 			return nil, err
 		}
 
-		// Detect placeholder count from first row when no columns specified
-		if rowCount == 0 && placeholderCount == 0 {
-			placeholderCount = len(values)
-		}
-
 		stmt.Values = append(stmt.Values, values)
 		if _, err := p.expect(TokenRParen); err != nil {
 			return nil, err
@@ -902,7 +932,6 @@ func (p *Parser) parseInsertTargetAndSource(stmt *InsertStmt, requireInto bool)
 		if !p.match(TokenComma) {
 			break
 		}
-		rowCount++
 	}
 
 	if err := p.parseInsertTail(stmt); err != nil {
@@ -958,7 +987,7 @@ func (p *Parser) parseOnDuplicateKeyUpdate() (*OnConflictClause, error) {
 	if _, err := p.expect(TokenUpdate); err != nil {
 		return nil, err
 	}
-	clauses, _, err := p.parseSetClauses()
+	clauses, err := p.parseSetClauses()
 	if err != nil {
 		return nil, err
 	}
@@ -1004,7 +1033,7 @@ func (p *Parser) parseOnConflict() (*OnConflictClause, error) {
 	if _, err := p.expect(TokenSet); err != nil {
 		return nil, err
 	}
-	clauses, _, err := p.parseSetClauses()
+	clauses, err := p.parseSetClauses()
 	if err != nil {
 		return nil, err
 	}
@@ -1053,11 +1082,8 @@ func (p *Parser) parseUpdate() (*UpdateStmt, error) {
 	stmt.Table = tableRef.Name
 	stmt.Alias = tableRef.Alias
 
-	whereOffset := 0
 	if p.isJoin() {
-		var err error
-		whereOffset, err = p.parseUpdateJoins(stmt, whereOffset)
-		if err != nil {
+		if err := p.parseUpdateJoins(stmt); err != nil {
 			return nil, err
 		}
 	}
@@ -1071,20 +1097,14 @@ func (p *Parser) parseUpdate() (*UpdateStmt, error) {
 		return nil, err
 	}
 
-	setClauses, setPlaceholders, err := p.parseSetClauses()
+	setClauses, err := p.parseSetClauses()
 	if err != nil {
 		return nil, err
 	}
 	stmt.Set = setClauses
 
-	for i, ph := range setPlaceholders {
-		ph.Index = whereOffset + i
-	}
-	whereOffset += len(setPlaceholders)
-
 	if p.match(TokenFrom) {
-		whereOffset, err = p.parseUpdateFromJoin(stmt, whereOffset)
-		if err != nil {
+		if err := p.parseUpdateFromJoin(stmt); err != nil {
 			return nil, err
 		}
 	}
@@ -1095,7 +1115,6 @@ func (p *Parser) parseUpdate() (*UpdateStmt, error) {
 			return nil, err
 		}
 		stmt.Where = where
-		reindexPlaceholders(where, whereOffset)
 	}
 
 	if p.current().Type == TokenReturning {
@@ -1110,18 +1129,16 @@ func (p *Parser) parseUpdate() (*UpdateStmt, error) {
 	return stmt, nil
 }
 
-func (p *Parser) parseSetClauses() ([]*SetClause, []*PlaceholderExpr, error) {
+func (p *Parser) parseSetClauses() ([]*SetClause, error) {
 	var clauses []*SetClause
-	var placeholders []*PlaceholderExpr
 
 	for {
 		if p.current().Type == TokenLParen {
-			tupleClauses, tuplePlaceholders, err := p.parseTupleSetClause()
+			tupleClauses, err := p.parseTupleSetClause()
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
 			clauses = append(clauses, tupleClauses...)
-			placeholders = append(placeholders, tuplePlaceholders...)
 			if !p.match(TokenComma) {
 				break
 			}
@@ -1133,49 +1150,45 @@ func (p *Parser) parseSetClauses() ([]*SetClause, []*PlaceholderExpr, error) {
 		if col.Type == TokenIdentifier || (col.Literal != "" && col.Type != TokenEOF && col.Type != TokenEq) {
 			p.advance()
 		} else {
-			return nil, nil, fmt.Errorf("expected column name, got %s", col.Literal)
+			return nil, fmt.Errorf("expected column name, got %s", col.Literal)
 		}
 		if p.match(TokenDot) {
 			qualifiedCol := p.current()
 			if qualifiedCol.Literal == "" || qualifiedCol.Type == TokenEOF {
-				return nil, nil, fmt.Errorf("expected column name after '.'")
+				return nil, fmt.Errorf("expected column name after '.'")
 			}
 			colName = qualifiedCol.Literal
 			p.advance()
 		}
 
 		if _, err := p.expect(TokenEq); err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		val, err := p.parseExpression()
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		clauses = append(clauses, &SetClause{Column: colName, Value: val})
-		placeholders = append(placeholders, collectPlaceholders(val)...)
 
 		if !p.match(TokenComma) {
 			break
 		}
 	}
 
-	for i, ph := range placeholders {
-		ph.Index = i
-	}
-	return clauses, placeholders, nil
+	return clauses, nil
 }
 
-func (p *Parser) parseTupleSetClause() ([]*SetClause, []*PlaceholderExpr, error) {
+func (p *Parser) parseTupleSetClause() ([]*SetClause, error) {
 	if _, err := p.expect(TokenLParen); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	var columns []string
 	for {
 		colName, err := p.parseSetTargetColumn()
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		columns = append(columns, colName)
 		if !p.match(TokenComma) {
@@ -1183,31 +1196,29 @@ func (p *Parser) parseTupleSetClause() ([]*SetClause, []*PlaceholderExpr, error)
 		}
 	}
 	if _, err := p.expect(TokenRParen); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if _, err := p.expect(TokenEq); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if _, err := p.expect(TokenLParen); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	values, err := p.parseExpressionList()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if _, err := p.expect(TokenRParen); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if len(columns) != len(values) {
-		return nil, nil, fmt.Errorf("SET column list has %d columns but value list has %d expressions", len(columns), len(values))
+		return nil, fmt.Errorf("SET column list has %d columns but value list has %d expressions", len(columns), len(values))
 	}
 	clauses := make([]*SetClause, len(columns))
-	var placeholders []*PlaceholderExpr
 	for i, col := range columns {
 		clauses[i] = &SetClause{Column: col, Value: values[i]}
-		placeholders = append(placeholders, collectPlaceholders(values[i])...)
 	}
-	return clauses, placeholders, nil
+	return clauses, nil
 }
 
 func (p *Parser) parseSetTargetColumn() (string, error) {
@@ -1228,21 +1239,15 @@ func (p *Parser) parseSetTargetColumn() (string, error) {
 	return colName, nil
 }
 
-func (p *Parser) parseUpdateJoins(stmt *UpdateStmt, whereOffset int) (int, error) {
-	offset := whereOffset
+func (p *Parser) parseUpdateJoins(stmt *UpdateStmt) error {
 	for p.isJoin() {
 		join, err := p.parseJoin()
 		if err != nil {
-			return offset, err
+			return err
 		}
 		stmt.Joins = append(stmt.Joins, join)
-		if join.Condition != nil {
-			joinPHs := collectPlaceholders(join.Condition)
-			reindexPlaceholdersFromExpr(join.Condition, offset)
-			offset += len(joinPHs)
-		}
 	}
-	return offset, nil
+	return nil
 }
 
 func (p *Parser) parseUpdateCommaJoins(stmt *UpdateStmt) error {
@@ -1259,17 +1264,17 @@ func (p *Parser) parseUpdateCommaJoins(stmt *UpdateStmt) error {
 	return nil
 }
 
-func (p *Parser) parseUpdateFromJoin(stmt *UpdateStmt, whereOffset int) (int, error) {
+func (p *Parser) parseUpdateFromJoin(stmt *UpdateStmt) error {
 	table, err := p.parseTableRef()
 	if err != nil {
-		return whereOffset, err
+		return err
 	}
 	stmt.From = table
 
 	for p.match(TokenComma) {
 		crossTable, err := p.parseTableRef()
 		if err != nil {
-			return whereOffset, err
+			return err
 		}
 		stmt.Joins = append(stmt.Joins, &JoinClause{
 			Type:  TokenCross,
@@ -1280,20 +1285,11 @@ func (p *Parser) parseUpdateFromJoin(stmt *UpdateStmt, whereOffset int) (int, er
 	for p.isJoin() {
 		join, err := p.parseJoin()
 		if err != nil {
-			return whereOffset, err
+			return err
 		}
 		stmt.Joins = append(stmt.Joins, join)
 	}
-
-	offset := whereOffset
-	for _, join := range stmt.Joins {
-		if join.Condition != nil {
-			joinPHs := collectPlaceholders(join.Condition)
-			reindexPlaceholdersFromExpr(join.Condition, offset)
-			offset += len(joinPHs)
-		}
-	}
-	return offset, nil
+	return nil
 }
 
 // parseDelete parses a DELETE statement
@@ -1322,11 +1318,11 @@ func (p *Parser) parseDelete() (*DeleteStmt, error) {
 		return nil, err
 	}
 
-	table, err := p.expect(TokenIdentifier)
+	table, err := p.parseTableName()
 	if err != nil {
 		return nil, err
 	}
-	stmt.Table = table.Literal
+	stmt.Table = table
 
 	// Optional table alias
 	if p.current().Type == TokenIdentifier || p.current().Type == TokenAs {
@@ -1343,7 +1339,6 @@ func (p *Parser) parseDelete() (*DeleteStmt, error) {
 	}
 
 	// USING - for DELETE with JOIN
-	placeholderOffset := 0
 	var joinWhere Expression
 	if p.match(TokenUsing) {
 		for {
@@ -1367,28 +1362,17 @@ func (p *Parser) parseDelete() (*DeleteStmt, error) {
 			// Add JOIN as additional table with condition in WHERE
 			stmt.Using = append(stmt.Using, join.Table)
 			if join.Condition != nil {
-				// Collect placeholders from join condition
-				joinPlaceholders := collectPlaceholders(join.Condition)
-				for i, ph := range joinPlaceholders {
-					ph.Index = placeholderOffset + i
-				}
-				placeholderOffset += len(joinPlaceholders)
 				joinWhere = combineDeleteWhere(joinWhere, join.Condition)
 			}
 		}
 	}
 
-	// WHERE - placeholders start at offset 0 (or after USING/JOIN placeholders)
+	// WHERE
 	if p.match(TokenWhere) {
 		where, err := p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
-		// Fix WHERE clause placeholder indices
-		wherePlaceholders := collectPlaceholders(where)
-		for i, ph := range wherePlaceholders {
-			ph.Index = placeholderOffset + i
-		}
 		stmt.Where = combineDeleteWhere(joinWhere, where)
 	} else {
 		stmt.Where = joinWhere
@@ -1435,7 +1419,6 @@ func (p *Parser) parseMySQLTargetedDelete(stmt *DeleteStmt) (*DeleteStmt, error)
 	stmt.Alias = fromTable.Alias
 
 	var joinWhere Expression
-	placeholderOffset := 0
 	for p.match(TokenComma) {
 		usingTable, err := p.parseTableRef()
 		if err != nil {
@@ -1450,8 +1433,6 @@ func (p *Parser) parseMySQLTargetedDelete(stmt *DeleteStmt) (*DeleteStmt, error)
 		}
 		stmt.Using = append(stmt.Using, join.Table)
 		if join.Condition != nil {
-			reindexPlaceholdersFromExpr(join.Condition, placeholderOffset)
-			placeholderOffset += len(collectPlaceholders(join.Condition))
 			joinWhere = combineDeleteWhere(joinWhere, join.Condition)
 		}
 	}
@@ -1461,7 +1442,6 @@ func (p *Parser) parseMySQLTargetedDelete(stmt *DeleteStmt) (*DeleteStmt, error)
 		if err != nil {
 			return nil, err
 		}
-		reindexPlaceholdersFromExpr(where, placeholderOffset)
 		stmt.Where = combineDeleteWhere(joinWhere, where)
 	} else {
 		stmt.Where = joinWhere
@@ -1564,6 +1544,15 @@ func (p *Parser) parseWithCTE() (*SelectStmtWithCTE, error) {
 	}
 	stmt.Select = selectStmt
 
+	// The main query may be a UNION/INTERSECT/EXCEPT chain
+	if p.current().Type == TokenUnion || p.current().Type == TokenIntersect || p.current().Type == TokenExcept {
+		setOp, err := p.parseSetOp(selectStmt)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Union = setOp.(*UnionStmt)
+	}
+
 	return stmt, nil
 }
 
@@ -1618,6 +1607,9 @@ func (p *Parser) parseShow() (Statement, error) {
 		varName := p.current().Literal
 		p.advance()
 		upperVar := toUpperFast(varName)
+		if upperVar == "CONFIG" {
+			return &ShowConfigStmt{}, nil
+		}
 		if upperVar == "INDEXES" || upperVar == "KEYS" {
 			if err := p.expectShowFromOrIn("SHOW " + varName); err != nil {
 				return nil, err
@@ -1670,17 +1662,28 @@ func (p *Parser) parseDescribe() (Statement, error) {
 	return &DescribeStmt{Table: tok.Literal}, nil
 }
 
-// parseExplain parses EXPLAIN <query>
+// parseExplain parses EXPLAIN [ANALYZE] <query>
 func (p *Parser) parseExplain() (Statement, error) {
 	p.advance() // consume EXPLAIN
 
+	// ANALYZE is also a statement of its own, so it only counts as the
+	// EXPLAIN option when a statement follows it.
+	analyze := false
+	if p.current().Type == TokenAnalyze {
+		switch p.peek().Type {
+		case TokenSelect, TokenWith, TokenInsert, TokenUpdate, TokenDelete:
+			analyze = true
+			p.advance()
+		}
+	}
+
 	// Parse the inner statement (SELECT, INSERT, UPDATE, DELETE)
 	innerStmt, err := p.Parse()
 	if err != nil {
 		return nil, fmt.Errorf("error parsing EXPLAIN statement: %w", err)
 	}
 
-	return &ExplainStmt{Statement: innerStmt}, nil
+	return &ExplainStmt{Statement: innerStmt, Analyze: analyze}, nil
 }
 
 // parseSetVar parses SET <variable> = <value> (for MySQL compatibility)
@@ -1710,6 +1713,108 @@ func (p *Parser) parseSetVar() (Statement, error) {
 	return &SetVarStmt{Variable: varName, Value: strings.Join(valueParts, " ")}, nil
 }
 
+// parsePragma parses PRAGMA <name> [= <value>] and PRAGMA <name>(<value>).
+// The value is a single word, number or string, as in SQLite.
+func (p *Parser) parsePragma() (Statement, error) {
+	p.advance() // consume PRAGMA
+
+	nameTok := p.current()
+	if nameTok.Type == TokenEOF || nameTok.Type == TokenSemicolon || nameTok.Literal == "" {
+		return nil, fmt.Errorf("expected pragma name after PRAGMA")
+	}
+	p.advance()
+	stmt := &PragmaStmt{Name: strings.ToLower(nameTok.Literal)}
+
+	closeParen := false
+	switch p.current().Type {
+	case TokenEq:
+		p.advance()
+	case TokenLParen:
+		p.advance()
+		closeParen = true
+	default:
+		return stmt, nil
+	}
+
+	sign := ""
+	if p.current().Type == TokenMinus {
+		sign = "-"
+		p.advance()
+	}
+	valueTok := p.current()
+	if valueTok.Type == TokenEOF || valueTok.Type == TokenSemicolon || valueTok.Type == TokenRParen {
+		return nil, fmt.Errorf("expected value for PRAGMA %s", nameTok.Literal)
+	}
+	if sign != "" && valueTok.Type != TokenNumber {
+		return nil, fmt.Errorf("expected number after - in PRAGMA %s", nameTok.Literal)
+	}
+	p.advance()
+	stmt.Value = sign + valueTok.Literal
+
+	if closeParen {
+		if _, err := p.expect(TokenRParen); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+// parseAttach parses ATTACH [DATABASE] '<path>' AS <name>.
+func (p *Parser) parseAttach() (Statement, error) {
+	p.advance() // consume ATTACH
+	if isKeywordIdentifier(p.current(), "DATABASE") {
+		p.advance()
+	}
+	path, err := p.expect(TokenString)
+	if err != nil {
+		return nil, fmt.Errorf("expected database file name after ATTACH")
+	}
+	if _, err := p.expect(TokenAs); err != nil {
+		return nil, err
+	}
+	name, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("expected schema name after AS")
+	}
+	return &AttachStmt{Path: path.Literal, Name: name.Literal}, nil
+}
+
+// parseDetach parses DETACH [DATABASE] <name>.
+func (p *Parser) parseDetach() (Statement, error) {
+	p.advance() // consume DETACH
+	if isKeywordIdentifier(p.current(), "DATABASE") && p.peek().Type == TokenIdentifier {
+		p.advance()
+	}
+	name, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("expected schema name after DETACH")
+	}
+	return &DetachStmt{Name: name.Literal}, nil
+}
+
+// parseTableName parses a table name, optionally qualified by a schema:
+// name or schema.name. The result keeps the qualifier (see SplitTableName)
+// except main, which always names the database itself.
+func (p *Parser) parseTableName() (string, error) {
+	tok, err := p.expect(TokenIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if !p.match(TokenDot) {
+		return tok.Literal, nil
+	}
+	// After the dot even a keyword is a name, as in schema.table.
+	table := p.current()
+	if table.Type != TokenIdentifier && (table.Literal == "" || !isLetter(table.Literal[0])) {
+		return "", fmt.Errorf("expected table name after %s.", tok.Literal)
+	}
+	p.advance()
+	if strings.EqualFold(tok.Literal, "main") {
+		return table.Literal, nil
+	}
+	return tok.Literal + "." + table.Literal, nil
+}
+
 // parseUnion parses UNION [ALL] SELECT ... chains (backward compat wrapper).
 //
 //nolint:unused // retained for parser compatibility tests.
@@ -1717,6 +1822,23 @@ func (p *Parser) parseUnion(left Statement) (Statement, error) {
 	return p.parseSetOp(left)
 }
 
+// parseQuery parses a query used as the source of rows for another
+// statement: [WITH ...] SELECT ... [UNION | INTERSECT | EXCEPT SELECT ...].
+func (p *Parser) parseQuery() (Statement, error) {
+	switch p.current().Type {
+	case TokenWith:
+		return p.parseWithCTE()
+	case TokenSelect:
+		sel, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		return p.parseSetOp(sel)
+	default:
+		return nil, fmt.Errorf("expected SELECT, got %s", p.current().Literal)
+	}
+}
+
 // parseSetOp parses UNION/INTERSECT/EXCEPT [ALL] SELECT ... chains
 func (p *Parser) parseSetOp(left Statement) (Statement, error) {
 	for p.current().Type == TokenUnion || p.current().Type == TokenIntersect || p.current().Type == TokenExcept {
@@ -2,7 +2,9 @@ package query
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // parseOrderByList parses ORDER BY expressions
@@ -56,13 +58,7 @@ func (p *Parser) parseOrderByList() ([]*OrderByExpr, error) {
 
 // parseExpressionList parses a comma-separated list of expressions
 func (p *Parser) parseExpressionList() ([]Expression, error) {
-	return p.parseExpressionListWithOffset(0)
-}
-
-// parseExpressionListWithOffset parses a comma-separated list of expressions with placeholder offset
-func (p *Parser) parseExpressionListWithOffset(placeholderOffset int) ([]Expression, error) {
 	var exprs []Expression
-	phCount := 0
 
 	for {
 		expr, err := p.parseExpression()
@@ -70,13 +66,6 @@ func (p *Parser) parseExpressionListWithOffset(placeholderOffset int) ([]Express
 			return nil, err
 		}
 
-		// Update placeholder indices sequentially (including nested ones in function calls)
-		placeholders := collectPlaceholders(expr)
-		for _, ph := range placeholders {
-			ph.Index = placeholderOffset + phCount
-			phCount++
-		}
-
 		exprs = append(exprs, expr)
 		if len(exprs) > maxParserListItems {
 			return nil, fmt.Errorf("expression list count exceeds maximum (%d)", maxParserListItems)
@@ -205,6 +194,9 @@ func (p *Parser) parseComparison() (Expression, error) {
 			case isKeywordIdentifier(p.peek(), "GLOB"):
 				p.advance()
 				next, err = p.parseGlobExpr(left, true)
+			case isKeywordIdentifier(p.peek(), "ILIKE"):
+				p.advance()
+				next, err = p.parseLikeExpr(left, true)
 			case p.peek().Type == TokenBetween:
 				p.advance()
 				next, err = p.parseBetweenExpr(left, true)
@@ -242,6 +234,24 @@ func (p *Parser) parseComparison() (Expression, error) {
 				return nil, err
 			}
 			continue
+		case TokenContains:
+			// doc @> candidate: JSON containment
+			p.advance()
+			right, rerr := p.parseBitOr()
+			if rerr != nil {
+				return nil, rerr
+			}
+			left = &JSONContainsExpr{Column: left, Value: right}
+			continue
+		case TokenMatch:
+			// target MATCH 'full-text query'
+			p.advance()
+			right, rerr := p.parseBitOr()
+			if rerr != nil {
+				return nil, rerr
+			}
+			left = &MatchExpr{Columns: []Expression{left}, Pattern: right, Operator: true}
+			continue
 		default:
 			if isKeywordIdentifier(p.current(), "GLOB") {
 				if left, err = p.parseGlobExpr(left, false); err != nil {
@@ -249,6 +259,12 @@ func (p *Parser) parseComparison() (Expression, error) {
 				}
 				continue
 			}
+			if isKeywordIdentifier(p.current(), "ILIKE") {
+				if left, err = p.parseLikeExpr(left, false); err != nil {
+					return nil, err
+				}
+				continue
+			}
 			return left, nil
 		}
 	}
@@ -328,8 +344,12 @@ func (p *Parser) parseInExpr(left Expression, not bool) (Expression, error) {
 	return &InExpr{Expr: left, List: list, Not: not, Subquery: subquery}, nil
 }
 
+// parseLikeExpr parses the tail of `x [NOT] LIKE pattern [ESCAPE e]` and of
+// ILIKE, which becomes a call to the case-insensitive ILIKE function as GLOB
+// and REGEXP do.
 func (p *Parser) parseLikeExpr(left Expression, not bool) (Expression, error) {
-	p.advance() // consume LIKE
+	ilike := isKeywordIdentifier(p.current(), "ILIKE")
+	p.advance() // consume LIKE/ILIKE
 	if !not {
 		not = p.match(TokenNot)
 	}
@@ -344,6 +364,20 @@ func (p *Parser) parseLikeExpr(left Expression, not bool) (Expression, error) {
 		if err != nil {
 			return nil, err
 		}
+		if lit, ok := escape.(*StringLiteral); ok && utf8.RuneCountInString(lit.Value) > 1 {
+			return nil, fmt.Errorf("ESCAPE expression must be a single character, got %q", lit.Value)
+		}
+	}
+	if ilike {
+		args := []Expression{left, pattern}
+		if escape != nil {
+			args = append(args, escape)
+		}
+		var expr Expression = &FunctionCall{Name: "ILIKE", Args: args}
+		if not {
+			expr = &UnaryExpr{Operator: TokenNot, Expr: expr}
+		}
+		return expr, nil
 	}
 	return &LikeExpr{Expr: left, Pattern: pattern, Not: not, Escape: escape}, nil
 }
@@ -354,6 +388,13 @@ func (p *Parser) parseRegexpExpr(left Expression, not bool) (Expression, error)
 	if err != nil {
 		return nil, err
 	}
+	// A bad literal pattern would otherwise fail per row, and WHERE treats
+	// a failing row as a non-match.
+	if lit, ok := pattern.(*StringLiteral); ok {
+		if _, err := regexp.Compile(lit.Value); err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+	}
 	expr := &FunctionCall{
 		Name: "REGEXP_LIKE",
 		Args: []Expression{left, pattern},
@@ -470,7 +511,22 @@ func (p *Parser) parseUnary() (Expression, error) {
 		return &UnaryExpr{Operator: op, Expr: expr}, nil
 	}
 
-	return p.parsePrimary()
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	// COLLATE binds tighter than any binary operator: a = b COLLATE NOCASE
+	// collates b.
+	for p.current().Type == TokenIdentifier && strings.EqualFold(p.current().Literal, "COLLATE") {
+		p.advance()
+		name := p.current()
+		if name.Type != TokenIdentifier && name.Type != TokenString {
+			return nil, fmt.Errorf("expected collation name after COLLATE")
+		}
+		p.advance()
+		expr = &CollateExpr{Expr: expr, Collation: strings.ToUpper(name.Literal)}
+	}
+	return expr, nil
 }
 
 // parsePrimary parses primary expressions
@@ -480,6 +536,11 @@ func (p *Parser) parsePrimary() (Expression, error) {
 		return p.parseNumber()
 	case TokenString:
 		return p.parseString()
+	case TokenHexString:
+		// X'CAFE' is the blob produced by UNHEX('CAFE').
+		tok := p.current()
+		p.advance()
+		return &FunctionCall{Name: "UNHEX", Args: []Expression{&StringLiteral{Value: tok.Literal}}}, nil
 	case TokenDefault:
 		// `DEFAULT` as a value (INSERT ... VALUES (..., DEFAULT)).
 		p.advance()
@@ -530,8 +591,26 @@ func (p *Parser) parsePrimary() (Expression, error) {
 		p.advance()
 		return &BooleanLiteral{Value: false}, nil
 	case TokenQuestion:
+		// Placeholders are numbered in the order they appear in the
+		// statement, whichever clause they are in.
+		ph := &PlaceholderExpr{Index: p.placeholderCount}
+		p.placeholderCount++
+		p.advance()
+		return ph, nil
+	case TokenNamedParam:
+		// Every use of a name is the same parameter.
+		name := p.current().Literal
 		p.advance()
-		return &PlaceholderExpr{}, nil
+		if idx, ok := p.namedParamIndex[name]; ok {
+			return &PlaceholderExpr{Index: idx, Name: name}, nil
+		}
+		if p.namedParamIndex == nil {
+			p.namedParamIndex = make(map[string]int)
+		}
+		p.namedParamIndex[name] = p.placeholderCount
+		ph := &PlaceholderExpr{Index: p.placeholderCount, Name: name}
+		p.placeholderCount++
+		return ph, nil
 	case TokenCase:
 		return p.parseCaseExpr()
 	case TokenExists:
@@ -569,6 +648,14 @@ func (p *Parser) parsePrimary() (Expression, error) {
 			!isStructuralKeyword(p.current().Type) {
 			tok := p.current()
 			p.advance()
+			// Typed literals: DATE '2024-01-31', TIMESTAMP '2024-01-31 12:00:00'
+			if fn := typedLiteralFunction(tok.Type); fn != "" && p.current().Type == TokenString {
+				lit, err := p.parseString()
+				if err != nil {
+					return nil, err
+				}
+				return &FunctionCall{Name: fn, Args: []Expression{lit}}, nil
+			}
 			// Check for qualified identifier (table.column)
 			if p.match(TokenDot) {
 				// After dot, accept any token as column name (keywords can be column names)
@@ -619,7 +706,6 @@ func (p *Parser) parseCaseExpr() (Expression, error) {
 	p.advance() // consume CASE
 
 	caseExpr := &CaseExpr{}
-	hasSimpleCase := false
 
 	// Check for simple CASE: CASE expr WHEN ...
 	if p.current().Type != TokenWhen {
@@ -628,7 +714,6 @@ func (p *Parser) parseCaseExpr() (Expression, error) {
 			return nil, err
 		}
 		caseExpr.Expr = expr
-		hasSimpleCase = true
 	}
 
 	// Parse WHEN clauses
@@ -640,15 +725,6 @@ func (p *Parser) parseCaseExpr() (Expression, error) {
 			return nil, err
 		}
 
-		// For simple CASE, wrap condition as: expr = cond
-		if hasSimpleCase && caseExpr.Expr != nil {
-			cond = &BinaryExpr{
-				Left:     caseExpr.Expr,
-				Operator: TokenEq,
-				Right:    cond,
-			}
-		}
-
 		if p.current().Type != TokenThen {
 			return nil, fmt.Errorf("expected THEN, got %s", p.current().Literal)
 		}
@@ -747,6 +823,14 @@ func (p *Parser) parseIdentifierOrFunction() (Expression, error) {
 		if err != nil {
 			return nil, err
 		}
+		// schema.table.column: tables are qualified by name alone.
+		if p.match(TokenDot) {
+			table := col
+			if col, err = p.expect(TokenIdentifier); err != nil {
+				return nil, err
+			}
+			return &QualifiedIdentifier{Table: table.Literal, Column: col.Literal}, nil
+		}
 		return &QualifiedIdentifier{Table: tok.Literal, Column: col.Literal}, nil
 	}
 
@@ -770,9 +854,41 @@ func (p *Parser) parseIdentifierOrFunction() (Expression, error) {
 		}, nil
 	}
 
+	// Typed literals: TIME '12:30:00', DATETIME '2024-01-31 12:00:00'
+	if fn := typedLiteralFunction(tok.Type); fn != "" && p.current().Type == TokenString {
+		lit, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &FunctionCall{Name: fn, Args: []Expression{lit}}, nil
+	}
+
+	// CURRENT_TIMESTAMP, CURRENT_DATE and CURRENT_TIME are written without
+	// parentheses in standard SQL.
+	if tok.Type == TokenIdentifier {
+		switch name := strings.ToUpper(tok.Literal); name {
+		case "CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME":
+			return &FunctionCall{Name: name}, nil
+		}
+	}
+
 	return &Identifier{Name: tok.Literal}, nil
 }
 
+// typedLiteralFunction returns the conversion function for a typed literal
+// keyword (DATE '...'), or "" if tok does not introduce one.
+func typedLiteralFunction(tok TokenType) string {
+	switch tok {
+	case TokenDate:
+		return "DATE"
+	case TokenTimestamp, TokenDatetime:
+		return "DATETIME"
+	case TokenTime:
+		return "TIME"
+	}
+	return ""
+}
+
 // extractFieldToFunc maps an EXTRACT() field name to the equivalent scalar
 // date function name.
 func extractFieldToFunc(field string) string {
@@ -897,6 +1013,11 @@ func (p *Parser) parseFunctionCall(name string) (Expression, error) {
 		return p.parseWindowExpr(toUpperFast(name), args, filter)
 	}
 
+	// BM25(target, 'query') ranks a full-text match.
+	if upperName == "BM25" && len(args) == 2 && filter == nil {
+		return &MatchExpr{Columns: args[:1], Pattern: args[1], Operator: true, Rank: true}, nil
+	}
+
 	return &FunctionCall{Name: toUpperFast(name), Args: args, Distinct: distinct, Filter: filter}, nil
 }
 
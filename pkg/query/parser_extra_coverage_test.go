@@ -1310,38 +1310,19 @@ func TestParseSelectCountDistinct(t *testing.T) {
 	}
 }
 
-// --- collectPlaceholders coverage ---
+// --- collectStmtPlaceholders coverage ---
 
 func TestCollectPlaceholders(t *testing.T) {
 	stmt, err := Parse("SELECT * FROM t WHERE id = ? AND name = ?")
 	if err != nil {
 		t.Fatal(err)
 	}
-	sel := stmt.(*SelectStmt)
-	placeholders := collectPlaceholders(sel.Where)
+	placeholders := collectStmtPlaceholders(stmt)
 	if len(placeholders) != 2 {
 		t.Errorf("expected 2 placeholders, got %d", len(placeholders))
 	}
 }
 
-// --- parseExpressionListWithOffset ---
-
-func TestParseExpressionListWithOffset(t *testing.T) {
-	tokens, err := Tokenize("SELECT ?, ?, ?")
-	if err != nil {
-		t.Fatal(err)
-	}
-	p := NewParser(tokens)
-	p.advance() // skip SELECT
-	exprs, err := p.parseExpressionListWithOffset(100)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(exprs) != 3 {
-		t.Fatalf("expected 3 expressions, got %d", len(exprs))
-	}
-}
-
 // --- Window function: empty OVER() ---
 
 func TestParseWindowFunction_EmptyOver(t *testing.T) {
@@ -1528,6 +1509,27 @@ func TestParseExplain_Insert(t *testing.T) {
 	}
 }
 
+func TestParseExplain_Analyze(t *testing.T) {
+	for sql, want := range map[string]bool{
+		"EXPLAIN ANALYZE SELECT * FROM t WHERE id = 1":         true,
+		"EXPLAIN ANALYZE WITH c AS (SELECT 1) SELECT * FROM c": true,
+		"EXPLAIN SELECT * FROM t":                              false,
+		"EXPLAIN ANALYZE t":                                    false,
+	} {
+		stmt, err := Parse(sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		explain, ok := stmt.(*ExplainStmt)
+		if !ok {
+			t.Fatalf("%s: got %T, want *ExplainStmt", sql, stmt)
+		}
+		if explain.Analyze != want {
+			t.Errorf("%s: Analyze = %v, want %v", sql, explain.Analyze, want)
+		}
+	}
+}
+
 // --- CREATE TABLE with multiple foreign keys ---
 
 func TestParseCreateTable_MultipleFKs(t *testing.T) {
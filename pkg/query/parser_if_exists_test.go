@@ -0,0 +1,46 @@
+package query
+
+import "testing"
+
+func TestParseAlterTableIfExistsModifiers(t *testing.T) {
+	tests := []struct {
+		sql                                         string
+		ifExists, targetIfNotExists, targetIfExists bool
+	}{
+		{"ALTER TABLE IF EXISTS t ADD COLUMN c INTEGER", true, false, false},
+		{"ALTER TABLE t ADD COLUMN IF NOT EXISTS c INTEGER", false, true, false},
+		{"ALTER TABLE t ADD IF NOT EXISTS c INTEGER", false, true, false},
+		{"ALTER TABLE t DROP COLUMN IF EXISTS c", false, false, true},
+		{"ALTER TABLE IF EXISTS t DROP CONSTRAINT IF EXISTS uq", true, false, true},
+	}
+	for _, tt := range tests {
+		stmt, err := Parse(tt.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sql, err)
+		}
+		alter, ok := stmt.(*AlterTableStmt)
+		if !ok {
+			t.Fatalf("%s: got %T", tt.sql, stmt)
+		}
+		if alter.Table != "t" || alter.IfExists != tt.ifExists ||
+			alter.TargetIfNotExists != tt.targetIfNotExists || alter.TargetIfExists != tt.targetIfExists {
+			t.Errorf("%s: got table=%q IfExists=%v TargetIfNotExists=%v TargetIfExists=%v",
+				tt.sql, alter.Table, alter.IfExists, alter.TargetIfNotExists, alter.TargetIfExists)
+		}
+	}
+
+	if _, err := Parse("ALTER TABLE IF t ADD COLUMN c INTEGER"); err == nil {
+		t.Error("expected error for IF without EXISTS")
+	}
+}
+
+func TestParseCreatePolicyIfNotExists(t *testing.T) {
+	stmt, err := Parse("CREATE POLICY IF NOT EXISTS p ON t USING (id > 0)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	policy, ok := stmt.(*CreatePolicyStmt)
+	if !ok || !policy.IfNotExists || policy.Name != "p" || policy.Table != "t" {
+		t.Fatalf("got %#v", stmt)
+	}
+}
@@ -0,0 +1,36 @@
+package query
+
+import "testing"
+
+func TestParsePragmaStmt(t *testing.T) {
+	tests := []struct {
+		sql         string
+		name, value string
+	}{
+		{"PRAGMA foreign_keys", "foreign_keys", ""},
+		{"pragma Foreign_Keys = ON", "foreign_keys", "ON"},
+		{"PRAGMA foreign_keys = off;", "foreign_keys", "off"},
+		{"PRAGMA cache_size = -2000", "cache_size", "-2000"},
+		{"PRAGMA busy_timeout(500)", "busy_timeout", "500"},
+		{"PRAGMA wal_sync_mode = 'full'", "wal_sync_mode", "full"},
+	}
+	for _, tt := range tests {
+		stmt, err := Parse(tt.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sql, err)
+		}
+		pragma, ok := stmt.(*PragmaStmt)
+		if !ok {
+			t.Fatalf("%s: got %T", tt.sql, stmt)
+		}
+		if pragma.Name != tt.name || pragma.Value != tt.value {
+			t.Errorf("%s: got name=%q value=%q", tt.sql, pragma.Name, pragma.Value)
+		}
+	}
+
+	for _, sql := range []string{"PRAGMA", "PRAGMA cache_size =", "PRAGMA busy_timeout(500", "PRAGMA cache_size = -full"} {
+		if _, err := Parse(sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
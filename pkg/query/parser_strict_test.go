@@ -85,3 +85,131 @@ func TestParsePermissiveAllowsMalformedJoins(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCreateTableUsingEngine(t *testing.T) {
+	stmt, err := ParseStrict("CREATE TABLE events (id INTEGER PRIMARY KEY, body TEXT) USING APPEND;")
+	if err != nil {
+		t.Fatalf("ParseStrict: %v", err)
+	}
+	create, ok := stmt.(*CreateTableStmt)
+	if !ok {
+		t.Fatalf("got %T, want *CreateTableStmt", stmt)
+	}
+	if create.Engine != "append" {
+		t.Fatalf("Engine = %q, want append", create.Engine)
+	}
+	if _, err := ParseStrict("CREATE TABLE events (id INTEGER) USING"); err == nil {
+		t.Fatal("expected error for USING without an engine")
+	}
+}
+
+func TestParseCreateTableWithTTLColumn(t *testing.T) {
+	stmt, err := ParseStrict("CREATE TABLE sessions (id TEXT PRIMARY KEY, expires_at TIMESTAMP) WITH (ttl_column = expires_at)")
+	if err != nil {
+		t.Fatalf("ParseStrict: %v", err)
+	}
+	create, ok := stmt.(*CreateTableStmt)
+	if !ok {
+		t.Fatalf("got %T, want *CreateTableStmt", stmt)
+	}
+	if create.TTLColumn != "expires_at" {
+		t.Fatalf("TTLColumn = %q, want expires_at", create.TTLColumn)
+	}
+	for _, sql := range []string{
+		"CREATE TABLE t (a INTEGER) WITH (fillfactor = 70)",
+		"CREATE TABLE t (a INTEGER) WITH (ttl_column)",
+		"CREATE TABLE t (a INTEGER) WITH ttl_column = a",
+	} {
+		if _, err := ParseStrict(sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestParseSystemVersioning(t *testing.T) {
+	stmt, err := ParseStrict("CREATE TABLE prices (id INTEGER PRIMARY KEY, price REAL) WITH (ttl_column = id) WITH SYSTEM VERSIONING")
+	if err != nil {
+		t.Fatalf("ParseStrict: %v", err)
+	}
+	create, ok := stmt.(*CreateTableStmt)
+	if !ok {
+		t.Fatalf("got %T, want *CreateTableStmt", stmt)
+	}
+	if !create.SystemVersioning || create.TTLColumn != "id" {
+		t.Fatalf("SystemVersioning = %v, TTLColumn = %q", create.SystemVersioning, create.TTLColumn)
+	}
+
+	stmt, err = ParseStrict("SELECT price FROM prices FOR SYSTEM_TIME AS OF '2026-01-01 00:00:00' WHERE id = 1")
+	if err != nil {
+		t.Fatalf("ParseStrict: %v", err)
+	}
+	sel, ok := stmt.(*SelectStmt)
+	if !ok {
+		t.Fatalf("got %T, want *SelectStmt", stmt)
+	}
+	if sel.AsOf == nil || sel.AsOf.IsSystem || sel.Where == nil {
+		t.Fatalf("AsOf = %+v, Where = %v", sel.AsOf, sel.Where)
+	}
+	for _, sql := range []string{
+		"CREATE TABLE t (a INTEGER) WITH SYSTEM",
+		"SELECT a FROM t FOR SYSTEM_TIME '2026-01-01'",
+	} {
+		if _, err := ParseStrict(sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestParseMaskedColumns(t *testing.T) {
+	stmt, err := ParseStrict("CREATE TABLE cards (id INTEGER PRIMARY KEY, num TEXT NOT NULL MASKED WITH mask_last(4), holder TEXT MASKED, note TEXT)")
+	if err != nil {
+		t.Fatalf("ParseStrict: %v", err)
+	}
+	create := stmt.(*CreateTableStmt)
+	if m := create.Columns[1].Mask; m == nil || m.Function != "MASK_LAST" || len(m.Args) != 1 || m.Args[0] != 4 || !create.Columns[1].NotNull {
+		t.Fatalf("num mask = %+v", m)
+	}
+	if m := create.Columns[2].Mask; m == nil || m.Function != "MASK" || len(m.Args) != 0 {
+		t.Fatalf("holder mask = %+v", m)
+	}
+	if create.Columns[3].Mask != nil {
+		t.Fatalf("note mask = %+v", create.Columns[3].Mask)
+	}
+	for _, sql := range []string{
+		"CREATE TABLE t (a TEXT MASKED WITH)",
+		"CREATE TABLE t (a TEXT MASKED WITH MASK_LAST('4'))",
+		"CREATE TABLE t (a TEXT MASKED WITH MASK_LAST(4)",
+	} {
+		if _, err := ParseStrict(sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestParseCreateSequence(t *testing.T) {
+	stmt, err := ParseStrict("CREATE SEQUENCE IF NOT EXISTS countdown START WITH 3 INCREMENT BY -1 MINVALUE 1 NO MAXVALUE CYCLE")
+	if err != nil {
+		t.Fatalf("ParseStrict: %v", err)
+	}
+	create, ok := stmt.(*CreateSequenceStmt)
+	if !ok {
+		t.Fatalf("got %T, want *CreateSequenceStmt", stmt)
+	}
+	if !create.IfNotExists || create.Name != "countdown" || !create.Cycle {
+		t.Fatalf("unexpected statement: %+v", create)
+	}
+	if create.Start == nil || *create.Start != 3 || create.Increment == nil || *create.Increment != -1 {
+		t.Fatalf("unexpected START/INCREMENT: %+v", create)
+	}
+	if create.MinValue == nil || *create.MinValue != 1 || create.MaxValue != nil {
+		t.Fatalf("unexpected bounds: %+v", create)
+	}
+
+	stmt, err = ParseStrict("DROP SEQUENCE IF EXISTS countdown")
+	if err != nil {
+		t.Fatalf("ParseStrict: %v", err)
+	}
+	if drop, ok := stmt.(*DropSequenceStmt); !ok || !drop.IfExists || drop.Name != "countdown" {
+		t.Fatalf("got %#v, want DROP SEQUENCE IF EXISTS countdown", stmt)
+	}
+}
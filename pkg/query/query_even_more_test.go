@@ -1492,6 +1492,39 @@ func TestParseRegexpOperator(t *testing.T) {
 			}
 		})
 	}
+
+	if _, err := Parse("SELECT * FROM t WHERE name REGEXP '(a'"); err == nil {
+		t.Fatal("expected an invalid literal pattern to fail to parse")
+	}
+}
+
+func TestParseILikeOperator(t *testing.T) {
+	for sql, wantArgs := range map[string]int{
+		"SELECT * FROM t WHERE name ILIKE 'a%'":                2,
+		"SELECT * FROM t WHERE name NOT ILIKE 'a%'":            2,
+		"SELECT * FROM t WHERE name ILIKE 'a!%%' ESCAPE '!'":   3,
+		"SELECT * FROM t WHERE name ilike 'a%' AND id ILIKE ?": 2,
+	} {
+		stmt, err := Parse(sql)
+		if err != nil {
+			t.Fatalf("Parse %s: %v", sql, err)
+		}
+		expr := stmt.(*SelectStmt).Where
+		if bin, ok := expr.(*BinaryExpr); ok {
+			expr = bin.Left
+		}
+		if unary, ok := expr.(*UnaryExpr); ok && unary.Operator == TokenNot {
+			expr = unary.Expr
+		}
+		fn, ok := expr.(*FunctionCall)
+		if !ok || fn.Name != "ILIKE" || len(fn.Args) != wantArgs {
+			t.Fatalf("%s: got %#v, want ILIKE with %d args", sql, expr, wantArgs)
+		}
+	}
+	// ILIKE is not reserved.
+	if _, err := Parse("SELECT ilike FROM t WHERE ilike = 1"); err != nil {
+		t.Fatalf("column named ilike: %v", err)
+	}
 }
 
 func TestParseAllAggregateQuantifier(t *testing.T) {
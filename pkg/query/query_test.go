@@ -196,3 +196,118 @@ func TestParseCreateTable(t *testing.T) {
 		t.Error("Expected id to be primary key")
 	}
 }
+
+func TestParseCollateExpr(t *testing.T) {
+	stmt, err := Parse("SELECT name FROM users WHERE name = 'bob' COLLATE nocase ORDER BY name COLLATE RTRIM DESC")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	sel := stmt.(*SelectStmt)
+	where, ok := sel.Where.(*BinaryExpr)
+	if !ok {
+		t.Fatalf("Expected BinaryExpr, got %T", sel.Where)
+	}
+	if ce, ok := where.Right.(*CollateExpr); !ok || ce.Collation != "NOCASE" {
+		t.Fatalf("Expected right operand COLLATE NOCASE, got %#v", where.Right)
+	}
+	if ce, ok := sel.OrderBy[0].Expr.(*CollateExpr); !ok || ce.Collation != "RTRIM" || !sel.OrderBy[0].Desc {
+		t.Fatalf("Expected ORDER BY name COLLATE RTRIM DESC, got %#v", sel.OrderBy[0])
+	}
+	if _, err := Parse("SELECT name COLLATE FROM users"); err == nil {
+		t.Fatal("Expected error for COLLATE without a name")
+	}
+}
+
+func TestParseGINIndexAndContainment(t *testing.T) {
+	stmt, err := Parse("CREATE INDEX idx_data ON events USING gin (data)")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if ci := stmt.(*CreateIndexStmt); ci.Method != "GIN" || len(ci.Columns) != 1 || ci.Columns[0] != "data" {
+		t.Fatalf("Expected GIN index on data, got %#v", ci)
+	}
+	stmt, err = Parse("CREATE INDEX idx_name ON users USING BTREE (name)")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if ci := stmt.(*CreateIndexStmt); ci.Method != "" {
+		t.Fatalf("Expected BTREE to map to the default method, got %q", ci.Method)
+	}
+
+	stmt, err = Parse(`SELECT id FROM events WHERE data @> '{"status":"active"}'`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if _, ok := stmt.(*SelectStmt).Where.(*JSONContainsExpr); !ok {
+		t.Fatalf("Expected JSONContainsExpr, got %T", stmt.(*SelectStmt).Where)
+	}
+}
+
+func TestParseQuerySources(t *testing.T) {
+	stmt, err := Parse("CREATE TABLE t (a, b) AS SELECT id, name FROM users UNION SELECT id, name FROM admins")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	ct := stmt.(*CreateTableStmt)
+	if len(ct.AsColumns) != 2 || ct.AsColumns[1] != "b" {
+		t.Fatalf("Expected column list [a b], got %v", ct.AsColumns)
+	}
+	if _, ok := ct.AsSelect.(*UnionStmt); !ok {
+		t.Fatalf("Expected a UNION query, got %T", ct.AsSelect)
+	}
+	stmt, err = Parse("CREATE TABLE t AS WITH x AS (SELECT id FROM users) SELECT id FROM x")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if _, ok := stmt.(*CreateTableStmt).AsSelect.(*SelectStmtWithCTE); !ok {
+		t.Fatalf("Expected a WITH query, got %T", stmt.(*CreateTableStmt).AsSelect)
+	}
+	stmt, err = Parse("CREATE TABLE t AS WITH x AS (SELECT id FROM users) SELECT id FROM x UNION ALL SELECT id FROM x ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if cte := stmt.(*CreateTableStmt).AsSelect.(*SelectStmtWithCTE); cte.Union == nil || !cte.Union.All || len(cte.Union.OrderBy) != 1 {
+		t.Fatalf("Expected the WITH query's UNION ALL chain, got %#v", cte.Union)
+	}
+	// A column definition list is not a CTAS column list.
+	stmt, err = Parse("CREATE TABLE t (a INTEGER, b TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if ct := stmt.(*CreateTableStmt); ct.AsColumns != nil || len(ct.Columns) != 2 {
+		t.Fatalf("Expected two column definitions, got %#v", ct)
+	}
+
+	stmt, err = Parse("INSERT INTO t (id) SELECT id FROM a EXCEPT SELECT id FROM b")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if ins := stmt.(*InsertStmt); ins.Select != nil || ins.Query == nil {
+		t.Fatalf("Expected the EXCEPT query in Query, got %#v", ins)
+	}
+	stmt, err = Parse("INSERT INTO t WITH x AS (SELECT 1) SELECT * FROM x")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if _, ok := stmt.(*InsertStmt).Query.(*SelectStmtWithCTE); !ok {
+		t.Fatalf("Expected a WITH query, got %T", stmt.(*InsertStmt).Query)
+	}
+}
+
+func TestParseSimpleCaseOperand(t *testing.T) {
+	stmt, err := Parse("SELECT CASE status WHEN 'a' THEN 1 WHEN 'b' THEN 2 ELSE 0 END FROM t")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	ce, ok := stmt.(*SelectStmt).Columns[0].(*CaseExpr)
+	if !ok {
+		t.Fatalf("Expected CaseExpr, got %T", stmt.(*SelectStmt).Columns[0])
+	}
+	if id, ok := ce.Expr.(*Identifier); !ok || id.Name != "status" {
+		t.Fatalf("Expected the operand status, got %#v", ce.Expr)
+	}
+	// The WHEN values are kept as written, not folded into comparisons.
+	if lit, ok := ce.Whens[1].Condition.(*StringLiteral); !ok || lit.Value != "b" {
+		t.Fatalf("Expected WHEN 'b', got %#v", ce.Whens[1].Condition)
+	}
+}
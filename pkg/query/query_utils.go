@@ -17,6 +17,15 @@ func GenerateQueryKey(sql string, args []interface{}) string {
 	return builder.String()
 }
 
+// SplitTableName splits a table name parsed as schema.name into its schema
+// and table. An unqualified name has an empty schema.
+func SplitTableName(name string) (schema, table string) {
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
 // IsCacheableQuery returns true if the SELECT statement is safe to cache.
 // Queries without a FROM clause, with subqueries in SELECT, or with
 // non-deterministic functions are not cached.
@@ -93,16 +102,22 @@ func ContainsSubquery(expr Expression) bool {
 // ContainsNonDeterministicFunctions reports whether stmt contains non-deterministic functions.
 // Exported for backward compatibility with pkg/catalog tests.
 func ContainsNonDeterministicFunctions(stmt *SelectStmt) bool {
+	return ContainsFunctionCall(stmt, isNonDeterministicCall)
+}
+
+// ContainsFunctionCall reports whether the select list, WHERE clause or ORDER
+// BY of stmt calls a function for which match returns true.
+func ContainsFunctionCall(stmt *SelectStmt, match func(*FunctionCall) bool) bool {
 	for _, col := range stmt.Columns {
-		if HasNonDeterministicFunction(col) {
+		if exprCallsFunction(col, match) {
 			return true
 		}
 	}
-	if HasNonDeterministicFunction(stmt.Where) {
+	if exprCallsFunction(stmt.Where, match) {
 		return true
 	}
 	for _, ob := range stmt.OrderBy {
-		if HasNonDeterministicFunction(ob.Expr) {
+		if exprCallsFunction(ob.Expr, match) {
 			return true
 		}
 	}
@@ -112,55 +127,76 @@ func ContainsNonDeterministicFunctions(stmt *SelectStmt) bool {
 // HasNonDeterministicFunction reports whether expr contains a non-deterministic function.
 // Exported for backward compatibility with pkg/catalog tests.
 func HasNonDeterministicFunction(expr Expression) bool {
+	return exprCallsFunction(expr, isNonDeterministicCall)
+}
+
+func isNonDeterministicCall(e *FunctionCall) bool {
+	nonDetFuncs := []string{"RANDOM", "RANDOMBLOB", "RAND", "NOW", "CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME", "UUID", "UUID_V7", "NEWID", "NEXTVAL", "CURRVAL", "SETVAL", "LAST_INSERT_ROWID", "ENCRYPT", "DECRYPT"}
+	for _, ndf := range nonDetFuncs {
+		if strings.EqualFold(e.Name, ndf) {
+			return true
+		}
+	}
+	// DATE('now'), STRFTIME('%s', 'now'), ... read the clock too.
+	for _, arg := range e.Args {
+		if lit, ok := arg.(*StringLiteral); ok && strings.EqualFold(strings.TrimSpace(lit.Value), "now") {
+			return true
+		}
+	}
+	return false
+}
+
+// exprCallsFunction reports whether expr contains a function call for which
+// match returns true.
+func exprCallsFunction(expr Expression, match func(*FunctionCall) bool) bool {
 	if expr == nil {
 		return false
 	}
 	switch e := expr.(type) {
 	case *FunctionCall:
-		nonDetFuncs := []string{"RANDOM", "RAND", "NOW", "CURRENT_TIMESTAMP", "UUID", "NEWID"}
-		for _, ndf := range nonDetFuncs {
-			if strings.EqualFold(e.Name, ndf) {
-				return true
-			}
+		if match(e) {
+			return true
 		}
 		for _, arg := range e.Args {
-			if HasNonDeterministicFunction(arg) {
+			if exprCallsFunction(arg, match) {
 				return true
 			}
 		}
-		if HasNonDeterministicFunction(e.Filter) {
+		if exprCallsFunction(e.Filter, match) {
 			return true
 		}
 		for _, ob := range e.OrderBy {
-			if ob != nil && HasNonDeterministicFunction(ob.Expr) {
+			if ob != nil && exprCallsFunction(ob.Expr, match) {
 				return true
 			}
 		}
 	case *WindowExpr:
 		for _, arg := range e.Args {
-			if HasNonDeterministicFunction(arg) {
+			if exprCallsFunction(arg, match) {
 				return true
 			}
 		}
-		if HasNonDeterministicFunction(e.Filter) {
+		if exprCallsFunction(e.Filter, match) {
 			return true
 		}
 		for _, partitionExpr := range e.PartitionBy {
-			if HasNonDeterministicFunction(partitionExpr) {
+			if exprCallsFunction(partitionExpr, match) {
 				return true
 			}
 		}
 		for _, ob := range e.OrderBy {
-			if ob != nil && HasNonDeterministicFunction(ob.Expr) {
+			if ob != nil && exprCallsFunction(ob.Expr, match) {
 				return true
 			}
 		}
 	case *AliasExpr:
-		return HasNonDeterministicFunction(e.Expr)
+		return exprCallsFunction(e.Expr, match)
+	case *CollateExpr:
+		return exprCallsFunction(e.Expr, match)
 	case *BinaryExpr:
-		return HasNonDeterministicFunction(e.Left) || HasNonDeterministicFunction(e.Right)
+		return exprCallsFunction(e.Left, match) || exprCallsFunction(e.Right, match)
 	case *UnaryExpr:
-		return HasNonDeterministicFunction(e.Expr)
+		return exprCallsFunction(e.Expr, match)
 	}
 	return false
 }
@@ -319,6 +355,8 @@ func exprToStringImpl(expr Expression, exported bool) string {
 		return fmt.Sprintf("%v", e.Value)
 	case *AliasExpr:
 		return exprToStringImpl(e.Expr, exported) + " AS " + e.Alias
+	case *CollateExpr:
+		return exprToStringImpl(e.Expr, exported) + " COLLATE " + e.Collation
 	case *ColumnRef:
 		if e.Table != "" {
 			return e.Table + "." + e.Column
@@ -0,0 +1,95 @@
+package query
+
+import "strings"
+
+// ScriptStatement is one statement of a script split by SplitScript.
+type ScriptStatement struct {
+	SQL  string // Statement text, without the terminating ';'
+	Line int    // Line of the script the statement starts on
+}
+
+// SplitScript splits a script into its ';'-separated statements using the
+// SQL lexer, so a ';' inside a string literal, quoted identifier or comment
+// does not end a statement. The body of a CREATE TRIGGER or CREATE PROCEDURE
+// (BEGIN ... END, with nested BEGIN and CASE ... END blocks) stays part of
+// its statement; a transaction BEGIN is an ordinary statement. Empty
+// statements and comments between statements are dropped.
+//
+// The statements are not parsed, so a malformed statement is returned as
+// text and fails when it is run.
+func SplitScript(script string) []ScriptStatement {
+	statements, _ := splitScript(script)
+	return statements
+}
+
+// ScriptComplete reports whether every statement in script is terminated by
+// a ';' outside any string literal, comment or trigger/procedure body, so an
+// interactive client can tell a finished script from one that continues on
+// the next line. A script of only whitespace and comments is complete.
+func ScriptComplete(script string) bool {
+	_, complete := splitScript(script)
+	return complete
+}
+
+// splitScript implements SplitScript and reports whether the script ends
+// without a pending statement, string literal or block comment.
+func splitScript(script string) ([]ScriptStatement, bool) {
+	var out []ScriptStatement
+	l := NewLexer(script)
+	open := false
+
+	start, line := -1, 0
+	create, compound := false, false
+	depth := 0
+	flush := func(end int) {
+		if start >= 0 {
+			if sql := strings.TrimSpace(script[start:end]); sql != "" {
+				out = append(out, ScriptStatement{SQL: sql, Line: line})
+			}
+		}
+		start, create, compound, depth = -1, false, false, 0
+	}
+
+	for {
+		if !l.skipWhitespaceAndComments() {
+			// An unterminated block comment runs to the end of the script.
+			open = true
+			break
+		}
+		pos := l.pos
+		tok := l.NextToken()
+		if tok.Type == TokenEOF {
+			break
+		}
+		if tok.Type == TokenSemicolon && depth == 0 {
+			flush(pos)
+			continue
+		}
+		if start < 0 {
+			start, line = pos, tok.Line
+			create = tok.Type == TokenCreate
+		}
+		switch tok.Type {
+		case TokenTrigger, TokenProcedure:
+			compound = compound || create
+		case TokenIdentifier:
+			compound = compound || (create && strings.EqualFold(tok.Literal, "FUNCTION"))
+		case TokenBegin:
+			if compound {
+				depth++
+			}
+		case TokenCase:
+			if depth > 0 {
+				depth++
+			}
+		case TokenEnd:
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	// An unterminated string literal is part of the pending statement.
+	open = open || start >= 0
+	flush(len(script))
+	return out, !open
+}
@@ -0,0 +1,58 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []ScriptStatement
+	}{
+		{"empty", " ;; -- nothing\n", nil},
+		{"no trailing semicolon", "SELECT 1; SELECT 2", []ScriptStatement{{"SELECT 1", 1}, {"SELECT 2", 1}}},
+		{"literals and comments", "INSERT INTO t VALUES ('a;b', \"c;d\"); -- x;y\n/* p;q */ SELECT 1;", []ScriptStatement{
+			{"INSERT INTO t VALUES ('a;b', \"c;d\")", 1}, {"SELECT 1", 2},
+		}},
+		{"transaction", "BEGIN;\nUPDATE t SET a = 1;\nCOMMIT;", []ScriptStatement{{"BEGIN", 1}, {"UPDATE t SET a = 1", 2}, {"COMMIT", 3}}},
+		{"trigger body", "CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE u SET n = CASE WHEN n > 0 THEN 1 ELSE 0 END; DELETE FROM v; END;\nSELECT 1",
+			[]ScriptStatement{
+				{"CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE u SET n = CASE WHEN n > 0 THEN 1 ELSE 0 END; DELETE FROM v; END", 1},
+				{"SELECT 1", 2},
+			}},
+		{"unterminated comment", "SELECT 1; SELECT 2 /* open", []ScriptStatement{{"SELECT 1", 1}, {"SELECT 2 /* open", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitScript(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitScript(%q) = %#v, want %#v", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptComplete(t *testing.T) {
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{"", true},
+		{"-- just a comment", true},
+		{"SELECT 1;", true},
+		{"SELECT 1; -- done", true},
+		{"SELECT 1", false},
+		{"SELECT 1; SELECT", false},
+		{"SELECT 'a;\nb", false},
+		{"SELECT 'a;\nb';", true},
+		{"SELECT 1; /* open", false},
+		{"CREATE TRIGGER tr AFTER INSERT ON t BEGIN DELETE FROM u;", false},
+		{"CREATE TRIGGER tr AFTER INSERT ON t BEGIN DELETE FROM u; END;", true},
+	}
+	for _, tt := range tests {
+		if got := ScriptComplete(tt.script); got != tt.want {
+			t.Errorf("ScriptComplete(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package query
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 // TokenType represents the type of a token
 type TokenType int
@@ -26,6 +29,7 @@ const (
 	TokenIdentifier
 	TokenString
 	TokenNumber
+	TokenHexString // X'..' blob literal; Literal holds the hex digits
 
 	// Keywords
 	TokenSelect
@@ -162,7 +166,8 @@ const (
 	TokenComma
 	TokenSemicolon
 	TokenDot
-	TokenQuestion // ? placeholder for prepared statements
+	TokenQuestion   // ? placeholder for prepared statements
+	TokenNamedParam // :name or @name placeholder; the literal is the name
 
 	// Functions
 	TokenCount
@@ -301,6 +306,9 @@ const (
 
 	// TRUNCATE TABLE
 	TokenTruncate
+
+	// UUID column type; lexed as an identifier, see parseColumnDef
+	TokenUUID
 )
 
 // Token represents a lexical token
@@ -578,6 +586,31 @@ func LookupKeyword(ident string) TokenType {
 	return TokenIdentifier
 }
 
+// Keywords returns every reserved word recognized by the lexer, sorted.
+func Keywords() []string {
+	out := make([]string, 0, len(keywords))
+	for kw := range keywords {
+		out = append(out, kw)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DataTypes returns the type names accepted in column definitions, sorted.
+func DataTypes() []string {
+	var out []string
+	for kw, tok := range keywords {
+		switch tok {
+		case TokenInteger, TokenText, TokenReal, TokenBlob, TokenBoolean,
+			TokenJSON, TokenDate, TokenTimestamp, TokenDatetime, TokenVector:
+			out = append(out, kw)
+		}
+	}
+	out = append(out, "UUID")
+	sort.Strings(out)
+	return out
+}
+
 // TokenTypeString returns a string representation of a token type
 func TokenTypeString(t TokenType) string {
 	switch t {
@@ -591,6 +624,8 @@ func TokenTypeString(t TokenType) string {
 		return "STRING"
 	case TokenNumber:
 		return "NUMBER"
+	case TokenHexString:
+		return "HEXSTRING"
 	case TokenSelect:
 		return "SELECT"
 	case TokenInsert:
@@ -659,6 +694,8 @@ func TokenTypeString(t TokenType) string {
 		return "DATETIME"
 	case TokenVector:
 		return "VECTOR"
+	case TokenUUID:
+		return "UUID"
 	case TokenUnknown:
 		return "UNKNOWN"
 	case TokenDuplicate:
@@ -120,6 +120,18 @@ type WALEntry struct {
 	Timestamp time.Time
 	Data      []byte // Serialized operation
 	Checksum  uint32 // CRC32 checksum
+
+	// skipped counts the LSNs before LSN that no entry carries, as entries
+	// carrying the master's WAL LSNs need not be numbered densely. It is
+	// only kept in the master's buffer.
+	skipped uint64
+}
+
+// follows reports whether e is the entry the master appended next after
+// the one with lsn, or an earlier one: that is, whether a slave that has
+// applied lsn misses no entry before e.
+func (e *WALEntry) follows(lsn uint64) bool {
+	return e.LSN-e.skipped <= lsn+1
 }
 
 // Encode serializes the WAL entry
@@ -726,9 +738,8 @@ func (m *Manager) canResumeFromLocked(requestedLSN, currentLSN uint64) bool {
 		return false
 	}
 
-	firstRetained := m.walBuffer[0].LSN
 	lastRetained := m.walBuffer[len(m.walBuffer)-1].LSN
-	return requestedLSN+1 >= firstRetained && currentLSN <= lastRetained
+	return m.walBuffer[0].follows(requestedLSN) && currentLSN <= lastRetained
 }
 
 func (m *Manager) sendResyncRequired(slave *SlaveConnection, currentLSN uint64) error {
@@ -966,14 +977,14 @@ func (m *Manager) replicateWAL() {
 			continue
 		}
 
-		// Gap guard: if the earliest pending entry is past the slave's
-		// next-needed LSN, intermediate entries were evicted from the WAL buffer
+		// Gap guard: if the earliest pending entry does not follow the slave's
+		// LSN, intermediate entries were evicted from the WAL buffer
 		// by retention while the slave was still connected (lagging). Streaming
 		// this suffix would silently skip the pruned entries and diverge the
 		// slave from the master. Force a snapshot resync instead — same recovery
 		// the connect-time `canResumeFromLocked` path uses for an out-of-window
 		// request.
-		if pending[0].LSN > lastLSN+1 {
+		if !pending[0].follows(lastLSN) {
 			slave.mu.Lock()
 			slave.NeedsSnapshot = true
 			slave.LastLSN = 0
@@ -1594,6 +1605,15 @@ func setReplicationReadDeadline(conn net.Conn) (func(), error) {
 
 // ReplicateWALEntry adds a WAL entry for replication (called by master)
 func (m *Manager) ReplicateWALEntry(data []byte) error {
+	return m.ReplicateWALEntryAt(0, data)
+}
+
+// ReplicateWALEntryAt adds a WAL entry for replication with the given LSN,
+// normally the LSN of the master's own WAL record, so that the LSNs slaves
+// resume from match the ones OnSnapshot reports. An LSN that does not
+// follow the last entry's, such as 0 or one from before a promotion, is
+// replaced by the next LSN in sequence.
+func (m *Manager) ReplicateWALEntryAt(lsn uint64, data []byte) error {
 	if m.config.Role != RoleMaster {
 		return nil // Not a master, ignore
 	}
@@ -1607,11 +1627,17 @@ func (m *Manager) ReplicateWALEntry(data []byte) error {
 		return ErrPrimaryFenced
 	}
 
+	current := atomic.LoadUint64(&m.currentLSN)
+	if lsn <= current {
+		lsn = current + 1
+	}
+	atomic.StoreUint64(&m.currentLSN, lsn)
 	entry := &WALEntry{
-		LSN:       atomic.AddUint64(&m.currentLSN, 1),
+		LSN:       lsn,
 		Timestamp: time.Now(),
 		Data:      append([]byte(nil), data...),
 		Checksum:  calculateCRC32(data),
+		skipped:   lsn - current - 1,
 	}
 
 	m.walBuffer = append(m.walBuffer, entry)
@@ -1965,6 +1991,19 @@ type FailoverReadiness struct {
 	Blockers          []string `json:"blockers"`
 }
 
+// Role returns the node's current role, which PromoteToMasterWithFencing
+// and RejoinAsReplica change.
+func (m *Manager) Role() Role {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.role
+}
+
+// IsFenced reports whether FencePrimary has fenced this master.
+func (m *Manager) IsFenced() bool {
+	return atomic.LoadUint64(&m.fencedEpoch) > 0
+}
+
 // GetStatus returns current replication status
 func (m *Manager) GetStatus() *ReplicationStatus {
 	status := &ReplicationStatus{
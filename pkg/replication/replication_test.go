@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
@@ -638,6 +639,48 @@ func TestReplicateWALContiguousNoResync(t *testing.T) {
 	}
 }
 
+// TestReplicateWALSparseLSNsNoResync verifies the gap guard tells LSNs the
+// master never used, as with entries carrying its WAL LSNs, from entries
+// retention evicted.
+func TestReplicateWALSparseLSNsNoResync(t *testing.T) {
+	config := DefaultConfig()
+	config.Role = RoleMaster
+	m := NewManager(config)
+	for _, lsn := range []uint64{4, 9, 15} {
+		if err := m.ReplicateWALEntryAt(lsn, []byte(fmt.Sprintf("e%d", lsn))); err != nil {
+			t.Fatalf("ReplicateWALEntryAt(%d): %v", lsn, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	slave := &SlaveConnection{
+		ID:       "sparse",
+		Writer:   bufio.NewWriter(&buf),
+		LastLSN:  6, // needs entry 9, which follows 4 -> no gap
+		LastPing: time.Now(),
+	}
+	m.slaves = map[string]*SlaveConnection{slave.ID: slave}
+	if !m.canResumeFromLocked(6, 15) {
+		t.Fatal("expected a resume from LSN 6 to be possible")
+	}
+	m.replicateWAL()
+	if slave.NeedsSnapshot || strings.Contains(buf.String(), "RESYNC") || !strings.Contains(buf.String(), "e9") {
+		t.Fatalf("sparse LSNs: needs snapshot = %v, sent %q", slave.NeedsSnapshot, buf.String())
+	}
+
+	// Once entry 9 is evicted, a slave at LSN 4 misses it.
+	m.dropWALPrefixLocked(2)
+	buf.Reset()
+	slave.LastLSN, slave.NeedsSnapshot = 4, false
+	if m.canResumeFromLocked(4, 15) {
+		t.Fatal("expected a resume from LSN 4 to need a snapshot")
+	}
+	m.replicateWAL()
+	if !slave.NeedsSnapshot || !strings.Contains(buf.String(), "RESYNC") {
+		t.Fatalf("evicted entry: needs snapshot = %v, sent %q", slave.NeedsSnapshot, buf.String())
+	}
+}
+
 // TestSendHeartbeatError covers sendHeartbeat with a writer that
 // fails immediately. Ported from coverage_boost_replication_test.go.
 func TestSendHeartbeatError(t *testing.T) {
@@ -1473,3 +1516,34 @@ func TestSendSnapshotLockedErrors(t *testing.T) {
 		t.Fatal("expected error on Flush")
 	}
 }
+
+func TestReplicateWALEntryAt(t *testing.T) {
+	config := DefaultConfig()
+	config.Role = RoleMaster
+	mgr := NewManager(config)
+
+	for _, lsn := range []uint64{5, 9, 9, 0} {
+		if err := mgr.ReplicateWALEntryAt(lsn, []byte("x")); err != nil {
+			t.Fatalf("ReplicateWALEntryAt(%d): %v", lsn, err)
+		}
+	}
+	var got []uint64
+	for _, e := range mgr.walBuffer {
+		got = append(got, e.LSN)
+	}
+	if want := []uint64{5, 9, 10, 11}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("entry LSNs = %v, want %v", got, want)
+	}
+	if mgr.Role() != RoleMaster || mgr.IsFenced() {
+		t.Fatalf("role = %v, fenced = %v", mgr.Role(), mgr.IsFenced())
+	}
+	if err := mgr.FencePrimary(PrimaryFenceRequest{FencingToken: "t", Epoch: 1}); err != nil {
+		t.Fatalf("FencePrimary: %v", err)
+	}
+	if !mgr.IsFenced() {
+		t.Fatal("IsFenced = false after FencePrimary")
+	}
+	if err := mgr.ReplicateWALEntryAt(20, []byte("x")); !errors.Is(err, ErrPrimaryFenced) {
+		t.Fatalf("fenced ReplicateWALEntryAt: err = %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/wire"
+)
+
+// maxWireCursors bounds how many open result cursors one connection may hold.
+const maxWireCursors = 16
+
+// resultCursor pages a query result out to the client across several
+// MsgFetch round trips.
+type resultCursor struct {
+	columns    []string
+	rows       *engine.Rows
+	pending    []interface{} // row read ahead that did not fit the last page
+	hasPending bool
+}
+
+// nextPage reads up to maxRows rows (and, when maxBytes > 0, roughly maxBytes
+// bytes; a single oversized row is still returned on its own page). more
+// reports whether rows remain after the page.
+func (rc *resultCursor) nextPage(maxRows int, maxBytes int64) (page [][]interface{}, more bool, errMsg *wire.ErrorMessage) {
+	var size int64
+	for {
+		var row []interface{}
+		if rc.hasPending {
+			row, rc.pending, rc.hasPending = rc.pending, nil, false
+		} else {
+			if !rc.rows.Next() {
+				if err := rc.rows.Err(); err != nil {
+					return nil, false, queryFailedMessage(err)
+				}
+				return page, false, nil
+			}
+			row = make([]interface{}, len(rc.columns))
+			dest := make([]interface{}, len(rc.columns))
+			for i := range dest {
+				dest[i] = &row[i]
+			}
+			if err := rc.rows.Scan(dest...); err != nil {
+				return nil, false, queryErrorMessage(5, err)
+			}
+			if wireResultRowValueTooLarge(row) {
+				return nil, false, wire.NewErrorMessage(9, "result value too large")
+			}
+		}
+
+		rowSize := engine.ResultRowSize(row)
+		if len(page) > 0 && (len(page) >= maxRows || (maxBytes > 0 && size+rowSize > maxBytes)) {
+			rc.pending, rc.hasPending = row, true
+			return page, true, nil
+		}
+		page = append(page, row)
+		size += rowSize
+	}
+}
+
+// queryFailedMessage reports the error of a query, whether it failed before
+// returning rows or while a cursor paged them out.
+func queryFailedMessage(err error) *wire.ErrorMessage {
+	if errors.Is(err, engine.ErrResultTooLarge) {
+		return wire.NewErrorMessage(9, err.Error())
+	}
+	return queryErrorMessage(4, err)
+}
+
+// resultPageLimits returns the page size for wire results: the engine's
+// MaxResultRows/MaxResultBytes in cursor mode, capped by the protocol's own
+// row limit.
+func (c *ClientConn) resultPageLimits() (maxRows int, maxBytes int64, cursors bool) {
+	maxRows = maxWireResultRows
+	limits := c.Server.prodServer.DB().ResultLimits()
+	if limits.ResultOverflow != engine.ResultOverflowCursor {
+		return maxRows, 0, false
+	}
+	if limits.MaxResultRows > 0 && limits.MaxResultRows < maxRows {
+		maxRows = limits.MaxResultRows
+	}
+	return maxRows, limits.MaxResultBytes, true
+}
+
+// sendQueryResult builds the response for a row-returning statement, opening a
+// cursor when the result spans more than one page.
+func (c *ClientConn) sendQueryResult(rows *engine.Rows) interface{} {
+	rc := &resultCursor{columns: rows.Columns(), rows: rows}
+	maxRows, maxBytes, cursors := c.resultPageLimits()
+	page, more, errMsg := rc.nextPage(maxRows, maxBytes)
+	if errMsg != nil || !more {
+		_ = rows.Close()
+		if errMsg != nil {
+			return errMsg
+		}
+		return wire.NewResultMessage(rc.columns, page)
+	}
+	if !cursors {
+		_ = rows.Close()
+		return wire.NewErrorMessage(9, "result set too large")
+	}
+
+	c.cursorMu.Lock()
+	defer c.cursorMu.Unlock()
+	if c.cursors == nil {
+		c.cursors = make(map[uint32]*resultCursor)
+	}
+	if len(c.cursors) >= maxWireCursors {
+		_ = rows.Close()
+		return wire.NewErrorMessage(9, "too many open cursors")
+	}
+	c.nextCursorID++
+	id := c.nextCursorID
+	c.cursors[id] = rc
+
+	msg := wire.NewResultMessage(rc.columns, page)
+	msg.CursorID = id
+	msg.HasMore = true
+	return msg
+}
+
+// handleFetch returns the next page of an open cursor, releasing it once the
+// last page has been sent.
+func (c *ClientConn) handleFetch(fetch *wire.FetchMessage) interface{} {
+	c.cursorMu.Lock()
+	defer c.cursorMu.Unlock()
+	rc, ok := c.cursors[fetch.CursorID]
+	if !ok {
+		return wire.NewErrorMessage(4, "cursor not found")
+	}
+	maxRows, maxBytes, _ := c.resultPageLimits()
+	page, more, errMsg := rc.nextPage(maxRows, maxBytes)
+	if errMsg != nil || !more {
+		delete(c.cursors, fetch.CursorID)
+		_ = rc.rows.Close()
+		if errMsg != nil {
+			return errMsg
+		}
+	}
+	msg := wire.NewResultMessage(rc.columns, page)
+	msg.CursorID = fetch.CursorID
+	msg.HasMore = more
+	return msg
+}
+
+// handleCloseCursor releases a cursor the client no longer needs.
+func (c *ClientConn) handleCloseCursor(closeMsg *wire.CloseCursorMessage) interface{} {
+	c.cursorMu.Lock()
+	defer c.cursorMu.Unlock()
+	if rc, ok := c.cursors[closeMsg.CursorID]; ok {
+		delete(c.cursors, closeMsg.CursorID)
+		_ = rc.rows.Close()
+	}
+	return wire.NewOKMessage(0, 0)
+}
+
+// closeCursors releases every open cursor on connection teardown.
+func (c *ClientConn) closeCursors() {
+	c.cursorMu.Lock()
+	defer c.cursorMu.Unlock()
+	for id, rc := range c.cursors {
+		_ = rc.rows.Close()
+		delete(c.cursors, id)
+	}
+}
@@ -295,6 +295,8 @@ func (ps *ProductionServer) healthMux() http.Handler {
 	mux.HandleFunc("/circuit-breakers", ps.authRequiredHandler(ps.circuitBreakerHandler()))
 	mux.HandleFunc("/rate-limits", ps.authRequiredHandler(ps.rateLimitsHandler()))
 	mux.HandleFunc("/transaction-metrics", ps.authRequiredHandler(ps.transactionMetricsHandler()))
+	mux.HandleFunc("/metadata", ps.authRequiredHandler(ps.metadataHandler()))
+	mux.HandleFunc("/metrics", ps.prometheusMetricsHandler())
 	mux.HandleFunc("/metrics/prometheus", ps.prometheusMetricsHandler())
 
 	return ps.rateLimitHandler(mux)
@@ -471,6 +473,23 @@ func (ps *ProductionServer) Query(ctx context.Context, sql string, args ...inter
 	return rows, err
 }
 
+// QueryCursor executes a SQL query like DB.QueryCursor, with circuit breaker
+// and retry protection up to its first row.
+func (ps *ProductionServer) QueryCursor(ctx context.Context, sql string, args ...interface{}) (*engine.Rows, error) {
+	key := ps.circuitBreakerKey(sql)
+	var rows *engine.Rows
+	err := ps.ExecuteWithCircuitBreaker(key, func() error {
+		return ps.ExecuteWithRetry(ctx, func() error {
+			r, err := ps.db.QueryCursor(ctx, sql, args...)
+			if err == nil {
+				rows = r
+			}
+			return err
+		})
+	})
+	return rows, err
+}
+
 // QueryRow executes a single-row SQL query with circuit breaker and retry protection.
 func (ps *ProductionServer) QueryRow(ctx context.Context, sql string, args ...interface{}) (*engine.Row, error) {
 	key := ps.circuitBreakerKey(sql)
@@ -607,6 +626,31 @@ func (ps *ProductionServer) transactionMetricsHandler() http.HandlerFunc {
 	}
 }
 
+// metadataHandler serves engine.SQLMetadata (keywords, types, function
+// signatures, and schema) for editor and language server integrations.
+func (ps *ProductionServer) metadataHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if ps.db == nil {
+			http.Error(w, "database not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		md, err := ps.db.Metadata()
+		if err != nil {
+			http.Error(w, "metadata unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(md); err != nil {
+			ps.logErrorf("failed to encode metadata: %v", err)
+		}
+	}
+}
+
 func (ps *ProductionServer) prometheusMetricsHandler() http.HandlerFunc {
 	handler := metrics.GetPrometheusHandler()
 	if ps.Config != nil && ps.Config.AllowRemoteMetrics {
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -898,6 +899,41 @@ func TestProductionTransactionMetricsHandler(t *testing.T) {
 	}
 }
 
+// TestProductionMetadataHandler checks that /metadata serves the engine's
+// grammar and schema description as JSON.
+func TestProductionMetadataHandler(t *testing.T) {
+	db, err := engine.Open(":memory:", &engine.Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(context.Background(), "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+
+	ps := NewProductionServer(db, DefaultProductionConfig())
+	handler := ps.metadataHandler()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/metadata", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: expected status 200, got %d", w.Code)
+	}
+	var md engine.SQLMetadata
+	if err := json.NewDecoder(w.Body).Decode(&md); err != nil {
+		t.Fatalf("failed to decode metadata: %v", err)
+	}
+	if len(md.Tables) != 1 || md.Tables[0].Name != "items" || len(md.Functions) == 0 || len(md.Keywords) == 0 {
+		t.Errorf("unexpected metadata: tables=%+v functions=%d keywords=%d", md.Tables, len(md.Functions), len(md.Keywords))
+	}
+
+	w2 := httptest.NewRecorder()
+	handler(w2, httptest.NewRequest(http.MethodPost, "/metadata", nil))
+	if w2.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected status 405, got %d", w2.Code)
+	}
+}
+
 // TestProductionServerWait covers ProductionServer.Wait: it must
 // return after Stop() is called. Ported from the
 // coverage_boost_server3_test.go padding file.
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -11,13 +12,16 @@ import (
 	"net"
 	"reflect"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cobaltdb/cobaltdb/pkg/auth"
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
 	"github.com/cobaltdb/cobaltdb/pkg/logger"
 	"github.com/cobaltdb/cobaltdb/pkg/query"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/wire"
 )
 
@@ -192,29 +196,81 @@ func validateWireSQL(sql string) *wire.ErrorMessage {
 	return nil
 }
 
-func validateWireParams(params []interface{}) *wire.ErrorMessage {
-	if len(params) > maxWireParams {
+func validateWireParams(params []interface{}, named map[string]interface{}) *wire.ErrorMessage {
+	count := len(params) + len(named)
+	for _, param := range params {
+		if list, ok := param.([]interface{}); ok {
+			count += len(list)
+		}
+	}
+	for _, param := range named {
+		if list, ok := param.([]interface{}); ok {
+			count += len(list)
+		}
+	}
+	if count > maxWireParams {
 		return wire.NewErrorMessage(9, "too many parameters")
 	}
 	for _, param := range params {
-		switch v := param.(type) {
-		case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-			continue
-		case string:
-			if len(v) > maxWireParamBytes {
-				return wire.NewErrorMessage(9, "parameter too large")
-			}
-		case []byte:
-			if len(v) > maxWireParamBytes {
-				return wire.NewErrorMessage(9, "parameter too large")
-			}
-		default:
+		if errMsg := validateWireParam(param, true); errMsg != nil {
+			return errMsg
+		}
+	}
+	for _, param := range named {
+		if errMsg := validateWireParam(param, true); errMsg != nil {
+			return errMsg
+		}
+	}
+	return nil
+}
+
+// validateWireParam checks one parameter value. A list, bound to an IN (?)
+// list, may only hold scalar values.
+func validateWireParam(param interface{}, allowList bool) *wire.ErrorMessage {
+	switch v := param.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return nil
+	case string:
+		if len(v) > maxWireParamBytes {
+			return wire.NewErrorMessage(9, "parameter too large")
+		}
+	case []byte:
+		if len(v) > maxWireParamBytes {
+			return wire.NewErrorMessage(9, "parameter too large")
+		}
+	case []interface{}:
+		if !allowList {
 			return wire.NewErrorMessage(9, "unsupported parameter type")
 		}
+		for _, elem := range v {
+			if errMsg := validateWireParam(elem, false); errMsg != nil {
+				return errMsg
+			}
+		}
+	default:
+		return wire.NewErrorMessage(9, "unsupported parameter type")
 	}
 	return nil
 }
 
+// wireArgs returns the engine arguments for a query's positional and named
+// parameters.
+func wireArgs(params []interface{}, named map[string]interface{}) []interface{} {
+	if len(named) == 0 {
+		return params
+	}
+	args := append([]interface{}(nil), params...)
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, sql.Named(name, named[name]))
+	}
+	return args
+}
+
 func maxWireInboundPayloadFor(msgType wire.MsgType) int {
 	switch msgType {
 	case wire.MsgPing:
@@ -223,6 +279,8 @@ func maxWireInboundPayloadFor(msgType wire.MsgType) int {
 		return maxWireAuthPayloadBytes
 	case wire.MsgQuery, wire.MsgPrepare, wire.MsgExecute:
 		return maxWireInboundPayloadBytes
+	case wire.MsgFetch, wire.MsgCloseCursor:
+		return maxWireAuthPayloadBytes
 	default:
 		return 0
 	}
@@ -510,25 +568,30 @@ type ClientConn struct {
 	reader        *bufio.Reader
 	username      string
 	authed        bool
+	session       *engine.Session
 	ctx           context.Context
 	cancel        context.CancelFunc
 	preparedStmts map[uint32]*preparedStmt
 	nextStmtID    uint32
 	stmtMu        sync.Mutex
+	cursors       map[uint32]*resultCursor
+	nextCursorID  uint32
+	cursorMu      sync.Mutex
+	inTxn         bool // a BEGIN is open on this connection; see session.go
 }
 
 // Handle handles client requests
 func (c *ClientConn) Handle() {
-	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.session = &engine.Session{}
+	c.ctx, c.cancel = context.WithCancel(engine.WithSession(context.Background(), c.session))
 	defer func() {
 		// Roll back any transaction left open by the client before teardown.
 		// Must run on this connection's goroutine (txn state is goroutine-local);
 		// otherwise a client that BEGINs and disconnects leaks locks and pins
 		// MVCC version pruning.
-		if c.Server.prodServer != nil {
-			c.Server.prodServer.DB().AbortConnTransaction()
-		}
+		c.abortTransaction()
 		c.cancel() // cancel any in-flight queries on disconnect
+		c.closeCursors()
 		_ = c.Conn.Close()
 		c.Server.removeClient(c.ID)
 	}()
@@ -638,6 +701,30 @@ func (c *ClientConn) handleMessage(msgType wire.MsgType, payload []byte) interfa
 
 		return c.handleExecute(ctx, &execMsg)
 
+	case wire.MsgFetch:
+		if !c.authed {
+			return wire.NewErrorMessage(6, "authentication required")
+		}
+
+		var fetchMsg wire.FetchMessage
+		if err := wire.Decode(payload, &fetchMsg); err != nil {
+			return wire.NewErrorMessage(2, "malformed message")
+		}
+
+		return c.handleFetch(&fetchMsg)
+
+	case wire.MsgCloseCursor:
+		if !c.authed {
+			return wire.NewErrorMessage(6, "authentication required")
+		}
+
+		var closeMsg wire.CloseCursorMessage
+		if err := wire.Decode(payload, &closeMsg); err != nil {
+			return wire.NewErrorMessage(2, "malformed message")
+		}
+
+		return c.handleCloseCursor(&closeMsg)
+
 	default:
 		return wire.NewErrorMessage(3, fmt.Sprintf("unknown message type: %d", msgType))
 	}
@@ -652,8 +739,21 @@ func (c *ClientConn) handleAuth(authMsg *wire.AuthMessage) interface{} {
 
 	c.username = authMsg.Username
 	c.authed = true
+	if c.session != nil {
+		// MASKED columns read unmasked only with the UNMASK permission on
+		// their table, looked up on each read so grants apply at once.
+		username := authMsg.Username
+		c.session.SetUnmask(func(table string) bool {
+			return c.Server.auth.HasPermission(username, "", table, auth.ActionUnmask)
+		})
+	}
 
-	return wire.NewAuthSuccessMessage(token)
+	msg := wire.NewAuthSuccessMessage(token)
+	msg.Username = authMsg.Username
+	if user, err := c.Server.auth.GetUser(authMsg.Username); err == nil {
+		msg.IsAdmin = user.IsAdmin
+	}
+	return msg
 }
 
 // checkPermission checks if the authenticated user has permission for the operation
@@ -693,6 +793,10 @@ func (c *ClientConn) checkPermission(sql string) bool {
 	}
 
 	switch action {
+	case "BEGIN", "COMMIT", "ROLLBACK":
+		// Transaction control touches no table; the statements inside the
+		// transaction are checked on their own.
+		return true
 	case "SELECT", "INSERT", "UPDATE", "DELETE", "CREATE", "DROP", "ALTER":
 		// valid action
 	default:
@@ -712,9 +816,10 @@ func (c *ClientConn) handleQuery(ctx context.Context, query *wire.QueryMessage)
 	if errMsg := validateWireSQL(query.SQL); errMsg != nil {
 		return errMsg
 	}
-	if errMsg := validateWireParams(query.Params); errMsg != nil {
+	if errMsg := validateWireParams(query.Params, query.Named); errMsg != nil {
 		return errMsg
 	}
+	args := wireArgs(query.Params, query.Named)
 
 	// Check permissions
 	if !c.checkPermission(query.SQL) {
@@ -738,52 +843,42 @@ func (c *ClientConn) handleQuery(ctx context.Context, query *wire.QueryMessage)
 		return wire.NewErrorMessage(9, "multi-statement queries are not allowed")
 	}
 
+	if control := txnControlOf(sqlTrimmed); control != txnNone {
+		return c.handleTxnControl(ctx, control, query.SQL)
+	}
+
 	isQuery := len(sqlTrimmed) >= 4 && ((len(sqlTrimmed) >= 6 && strings.EqualFold(sqlTrimmed[:6], "SELECT")) ||
 		strings.EqualFold(sqlTrimmed[:4], "WITH") ||
 		strings.EqualFold(sqlTrimmed[:4], "SHOW") ||
 		(len(sqlTrimmed) >= 7 && strings.EqualFold(sqlTrimmed[:7], "EXPLAIN")) ||
-		(len(sqlTrimmed) >= 8 && strings.EqualFold(sqlTrimmed[:8], "DESCRIBE")))
+		(len(sqlTrimmed) >= 8 && strings.EqualFold(sqlTrimmed[:8], "DESCRIBE")) ||
+		(len(sqlTrimmed) >= 6 && strings.EqualFold(sqlTrimmed[:6], "PRAGMA")))
 
 	if isQuery {
-		rows, err := c.Server.prodServer.Query(ctx, query.SQL, query.Params...)
+		// With cursors, rows are paged out as the statement produces them.
+		run := c.Server.prodServer.Query
+		if _, _, cursors := c.resultPageLimits(); cursors {
+			run = c.Server.prodServer.QueryCursor
+		}
+		rows, err := run(ctx, query.SQL, args...)
 		if err != nil {
-			return wire.NewErrorMessage(4, sanitizeError(err))
+			return queryFailedMessage(err)
 		}
-		defer rows.Close()
-
-		columns := rows.Columns()
-		var resultRows [][]interface{}
-
-		for rows.Next() {
-			if len(resultRows) >= maxWireResultRows {
-				return wire.NewErrorMessage(9, "result set too large")
-			}
-			row := make([]interface{}, len(columns))
-			dest := make([]interface{}, len(columns))
-			for i := range dest {
-				dest[i] = &row[i]
-			}
 
-			if err := rows.Scan(dest...); err != nil {
-				return wire.NewErrorMessage(5, sanitizeError(err))
-			}
-			if wireResultRowValueTooLarge(row) {
-				return wire.NewErrorMessage(9, "result value too large")
-			}
-
-			resultRows = append(resultRows, row)
+		result := c.sendQueryResult(rows)
+		if rm, ok := result.(*wire.ResultMessage); ok {
+			rm.InTxn = c.inTxn
 		}
-
-		return wire.NewResultMessage(columns, resultRows)
+		return result
 	}
 
 	// Non-query statement (INSERT, UPDATE, DELETE, CREATE, etc.)
-	result, err := c.Server.prodServer.Exec(ctx, query.SQL, query.Params...)
+	result, err := c.Server.prodServer.Exec(ctx, query.SQL, args...)
 	if err != nil {
-		return wire.NewErrorMessage(4, sanitizeError(err))
+		return queryErrorMessage(4, err)
 	}
 
-	return wire.NewOKMessage(result.LastInsertID, result.RowsAffected)
+	return c.okMessage(result.LastInsertID, result.RowsAffected)
 }
 
 // handlePrepare parses and caches a prepared statement, returning a statement ID.
@@ -799,7 +894,7 @@ func (c *ClientConn) handlePrepare(ctx context.Context, prep *wire.PrepareMessag
 	}
 
 	if _, err := query.ParseStrict(prep.SQL); err != nil {
-		return wire.NewErrorMessage(4, sanitizeError(err))
+		return queryErrorMessage(4, err)
 	}
 
 	c.stmtMu.Lock()
@@ -829,7 +924,7 @@ func (c *ClientConn) handleExecute(ctx context.Context, exec *wire.ExecuteMessag
 	if !exists {
 		return wire.NewErrorMessage(4, fmt.Sprintf("prepared statement %d not found", exec.StmtID))
 	}
-	if errMsg := validateWireParams(exec.Params); errMsg != nil {
+	if errMsg := validateWireParams(exec.Params, exec.Named); errMsg != nil {
 		return errMsg
 	}
 
@@ -838,7 +933,7 @@ func (c *ClientConn) handleExecute(ctx context.Context, exec *wire.ExecuteMessag
 	}
 
 	// Reuse handleQuery logic by constructing a QueryMessage
-	qm := &wire.QueryMessage{SQL: ps.sql, Params: exec.Params}
+	qm := &wire.QueryMessage{SQL: ps.sql, Params: exec.Params, Named: exec.Named}
 	return c.handleQuery(ctx, qm)
 }
 
@@ -972,6 +1067,18 @@ func (c *ClientConn) sendError(code int, message string) {
 	}
 }
 
+// queryErrorMessage reports a failed statement with the error's SQLSTATE and,
+// for a syntax error, its position.
+func queryErrorMessage(code int, err error) *wire.ErrorMessage {
+	msg := wire.NewErrorMessage(code, sanitizeError(err))
+	msg.SQLState = string(sqlerr.CodeOf(err))
+	var se *sqlerr.SyntaxError
+	if errors.As(err, &se) {
+		msg.Line, msg.Column = se.Line, se.Column
+	}
+	return msg
+}
+
 // sanitizeError strips internal details from errors before sending to clients.
 // It preserves SQL-level errors (syntax, constraint, etc.) but removes
 // file paths, stack traces, and internal component names.
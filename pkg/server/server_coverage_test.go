@@ -1105,7 +1105,7 @@ func TestWireQueryRejectsInvalidParams(t *testing.T) {
 		},
 		{
 			name:    "nested",
-			params:  []interface{}{[]interface{}{1}},
+			params:  []interface{}{[]interface{}{[]interface{}{1}}},
 			message: "unsupported parameter type",
 		},
 	}
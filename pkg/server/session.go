@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cobaltdb/cobaltdb/pkg/wire"
+)
+
+// txnControl classifies statements that open or close a connection's
+// transaction.
+type txnControl int
+
+const (
+	txnNone     txnControl = iota // Any other statement, SAVEPOINT and ROLLBACK TO included
+	txnBegin                      // BEGIN [TRANSACTION]
+	txnCommit                     // COMMIT [TRANSACTION]
+	txnRollback                   // ROLLBACK [TRANSACTION]
+)
+
+// txnControlOf returns the kind of transaction control statement sql is.
+func txnControlOf(sql string) txnControl {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return txnNone
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "BEGIN":
+		return txnBegin
+	case "COMMIT":
+		return txnCommit
+	case "ROLLBACK":
+		if len(fields) > 1 && strings.EqualFold(fields[1], "TO") {
+			return txnNone
+		}
+		return txnRollback
+	}
+	return txnNone
+}
+
+// handleTxnControl runs BEGIN, COMMIT and ROLLBACK for the connection. A
+// transaction belongs to the connection that began it: BEGIN inside an open
+// transaction and COMMIT or ROLLBACK outside one are rejected with code 11
+// before reaching the engine, and the statements bypass the production
+// server's retries, which must not replay them.
+func (c *ClientConn) handleTxnControl(ctx context.Context, control txnControl, sql string) interface{} {
+	switch {
+	case control == txnBegin && c.inTxn:
+		return wire.NewErrorMessage(11, "a transaction is already open on this connection; COMMIT or ROLLBACK it first")
+	case control != txnBegin && !c.inTxn:
+		return wire.NewErrorMessage(11, "no transaction is open on this connection")
+	}
+	db := c.Server.prodServer.DB()
+	_, err := db.Exec(ctx, sql)
+	// A failed COMMIT may or may not have ended the transaction; the engine
+	// knows.
+	c.inTxn = db.InConnTransaction()
+	if err != nil {
+		return queryErrorMessage(4, err)
+	}
+	return c.okMessage(0, 0)
+}
+
+// abortTransaction rolls back the transaction a client left open when it
+// disconnected.
+func (c *ClientConn) abortTransaction() {
+	if c.Server.prodServer == nil {
+		return
+	}
+	db := c.Server.prodServer.DB()
+	if c.inTxn || db.InConnTransaction() {
+		db.AbortConnTransaction()
+		c.Server.logWarnf("server: rolled back the open transaction of disconnected client %d", c.ID)
+	}
+	c.inTxn = false
+}
+
+func (c *ClientConn) okMessage(lastInsertID, rowsAffected int64) *wire.OKMessage {
+	msg := wire.NewOKMessage(lastInsertID, rowsAffected)
+	msg.InTxn = c.inTxn
+	return msg
+}
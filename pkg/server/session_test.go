@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cobaltdb/cobaltdb/pkg/auth"
+	"github.com/cobaltdb/cobaltdb/pkg/engine"
+	"github.com/cobaltdb/cobaltdb/pkg/wire"
+)
+
+func TestSessionTransactionState(t *testing.T) {
+	db, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	srv, _ := New(NewProductionServer(db, DefaultProductionConfig()), nil)
+	ctx := context.Background()
+	// The handler goroutine owns the connection's transaction; here that is
+	// the test goroutine.
+	c := &ClientConn{ID: 1, Server: srv, authed: true}
+	run := func(sql string) interface{} {
+		t.Helper()
+		return c.handleQuery(ctx, &wire.QueryMessage{SQL: sql})
+	}
+	wantCode := func(sql string, code int) {
+		t.Helper()
+		if msg, ok := run(sql).(*wire.ErrorMessage); !ok || msg.Code != code {
+			t.Errorf("%s: got %+v, want error %d", sql, msg, code)
+		}
+	}
+	wantOK := func(sql string, inTxn bool) {
+		t.Helper()
+		msg, ok := run(sql).(*wire.OKMessage)
+		if !ok || msg.InTxn != inTxn {
+			t.Errorf("%s: got %+v, want OK with InTxn=%v", sql, msg, inTxn)
+		}
+	}
+
+	wantOK("CREATE TABLE t (id INTEGER PRIMARY KEY)", false)
+	wantCode("COMMIT", 11)
+	wantCode("ROLLBACK", 11)
+	wantOK("BEGIN", true)
+	wantCode("BEGIN", 11)
+	wantOK("INSERT INTO t VALUES (1)", true)
+	if rm, ok := run("SELECT id FROM t").(*wire.ResultMessage); !ok || !rm.InTxn || len(rm.Rows) != 1 {
+		t.Errorf("SELECT in transaction = %+v", rm)
+	}
+	wantOK("rollback", false)
+	wantOK("BEGIN TRANSACTION", true)
+	wantOK("INSERT INTO t VALUES (2)", true)
+	wantOK("COMMIT", false)
+
+	wantOK("BEGIN", true)
+	wantOK("INSERT INTO t VALUES (3)", true)
+	c.abortTransaction()
+	if c.inTxn || db.InConnTransaction() {
+		t.Fatal("disconnect left the transaction open")
+	}
+	if rm, ok := run("SELECT id FROM t").(*wire.ResultMessage); !ok || len(rm.Rows) != 1 {
+		t.Errorf("after disconnect rollback = %+v", rm)
+	}
+}
+
+func TestSessionMaskedColumnsFollowUnmaskPermission(t *testing.T) {
+	db, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true, CacheSize: 1024}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if _, err := db.Exec(ctx, "CREATE TABLE cards (id INTEGER PRIMARY KEY, num TEXT MASKED WITH MASK_LAST(4))"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, "INSERT INTO cards VALUES (1, '4111111111111111')"); err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := New(NewProductionServer(db, DefaultProductionConfig()), nil)
+	srv.auth.Enable()
+	if err := srv.auth.CreateUser("clerk", "Str0ng!Pass#2024", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.auth.GrantPermission("clerk", "", "", []string{"SELECT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &ClientConn{ID: 1, Server: srv, session: &engine.Session{}}
+	if _, ok := c.handleAuth(&wire.AuthMessage{Username: "clerk", Password: "Str0ng!Pass#2024"}).(*wire.AuthSuccessMessage); !ok {
+		t.Fatal("authentication failed")
+	}
+	num := func() interface{} {
+		t.Helper()
+		rm, ok := c.handleQuery(engine.WithSession(ctx, c.session), &wire.QueryMessage{SQL: "SELECT num FROM cards"}).(*wire.ResultMessage)
+		if !ok || len(rm.Rows) != 1 {
+			t.Fatalf("SELECT = %+v", rm)
+		}
+		return rm.Rows[0][0]
+	}
+	if got := num(); got != "XXXXXXXXXXXX1111" {
+		t.Fatalf("num without UNMASK = %v", got)
+	}
+	if err := srv.auth.GrantPermission("clerk", "", "cards", []string{auth.ActionUnmask}); err != nil {
+		t.Fatal(err)
+	}
+	if got := num(); got != "4111111111111111" {
+		t.Fatalf("num with UNMASK = %v", got)
+	}
+}
@@ -0,0 +1,147 @@
+// Package sqlerr is the error taxonomy of CobaltDB. Errors that a client may
+// want to handle - constraint violations, syntax errors, missing or duplicate
+// objects, transaction conflicts - carry a SQLSTATE-style Code, which the
+// server protocols send to clients alongside the message.
+//
+//	if errors.Is(err, sqlerr.ErrConstraintUnique) { ... }
+//	var syn *sqlerr.SyntaxError
+//	if errors.As(err, &syn) { fmt.Println(syn.Line, syn.Column) }
+//	code := sqlerr.CodeOf(err) // "23505", or "" for an unclassified error
+//
+// Two taxonomy errors with the same Code match under errors.Is, so a
+// specific error such as a UNIQUE failure naming its column still matches
+// the ErrConstraintUnique sentinel.
+package sqlerr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is a five-character SQLSTATE error code. The classes follow the SQL
+// standard; codes within a class follow PostgreSQL.
+type Code string
+
+// Error codes.
+const (
+	CodeSyntax               Code = "42601"
+	CodeTypeMismatch         Code = "42804"
+	CodeUndefinedTable       Code = "42P01"
+	CodeUndefinedColumn      Code = "42703"
+	CodeUndefinedObject      Code = "42704"
+	CodeDuplicateTable       Code = "42P07"
+	CodeDuplicateObject      Code = "42710"
+	CodeNotNullViolation     Code = "23502"
+	CodeForeignKeyViolation  Code = "23503"
+	CodeUniqueViolation      Code = "23505"
+	CodeCheckViolation       Code = "23514"
+	CodeReadOnlyTransaction  Code = "25006"
+	CodeSerializationFailure Code = "40001"
+	CodeDeadlock             Code = "40P01"
+	CodeLockNotAvailable     Code = "55P03"
+)
+
+// Sentinel errors, one per code. Packages that already exported an error for
+// one of these conditions (catalog.ErrTableNotFound, txn.ErrConflict, ...)
+// now export the sentinel itself.
+var (
+	ErrSyntax            = New(CodeSyntax, "syntax error")
+	ErrTypeMismatch      = New(CodeTypeMismatch, "type mismatch")
+	ErrTableNotFound     = New(CodeUndefinedTable, "table not found")
+	ErrColumnNotFound    = New(CodeUndefinedColumn, "column not found")
+	ErrIndexNotFound     = New(CodeUndefinedObject, "index not found")
+	ErrTableExists       = New(CodeDuplicateTable, "table already exists")
+	ErrIndexExists       = New(CodeDuplicateObject, "index already exists")
+	ErrConstraintNotNull = New(CodeNotNullViolation, "NOT NULL constraint failed")
+	ErrConstraintFK      = New(CodeForeignKeyViolation, "foreign key constraint violation")
+	ErrConstraintUnique  = New(CodeUniqueViolation, "UNIQUE constraint failed")
+	ErrConstraintCheck   = New(CodeCheckViolation, "CHECK constraint failed")
+	ErrReadOnlyTxn       = New(CodeReadOnlyTransaction, "read-only transaction cannot write")
+	ErrConflict          = New(CodeSerializationFailure, "transaction conflict")
+	ErrDeadlock          = New(CodeDeadlock, "deadlock detected")
+	ErrLockTimeout       = New(CodeLockNotAvailable, "lock acquisition timeout")
+)
+
+// Error is an error with a Code.
+type Error struct {
+	Code    Code
+	Message string
+	err     error // Wrapped error, for Errorf with %w
+}
+
+// New returns an error with code and message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Errorf formats an error with code. Like fmt.Errorf, a %w verb wraps its
+// operand.
+func Errorf(code Code, format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Message: err.Error(), err: err}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// SQLCode returns e.Code.
+func (e *Error) SQLCode() Code { return e.Code }
+
+// Unwrap returns the errors wrapped by Errorf's %w verbs.
+func (e *Error) Unwrap() []error {
+	switch u := e.err.(type) {
+	case interface{ Unwrap() []error }:
+		return u.Unwrap()
+	case interface{ Unwrap() error }:
+		return []error{u.Unwrap()}
+	}
+	return nil
+}
+
+// Is reports whether target is a taxonomy error with e's code.
+func (e *Error) Is(target error) bool {
+	return Match(e.Code, target)
+}
+
+// SyntaxError is a statement that does not parse. Line and Column locate the
+// token the parser stopped at (1-based, 0 when unknown). It matches ErrSyntax
+// under errors.Is and its message is the parser's.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *SyntaxError) Error() string { return e.Err.Error() }
+
+// SQLCode returns CodeSyntax.
+func (e *SyntaxError) SQLCode() Code { return CodeSyntax }
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrSyntax.
+func (e *SyntaxError) Is(target error) bool {
+	return Match(CodeSyntax, target)
+}
+
+// Coder is implemented by errors that carry a Code, including errors decoded
+// by protocol clients.
+type Coder interface {
+	SQLCode() Code
+}
+
+// CodeOf returns the code of the first error in err's chain that has one, or
+// "" if none does.
+func CodeOf(err error) Code {
+	var c Coder
+	if errors.As(err, &c) {
+		return c.SQLCode()
+	}
+	return ""
+}
+
+// Match reports whether target is a taxonomy error with code, for Is
+// methods of error types outside this package.
+func Match(code Code, target error) bool {
+	t, ok := target.(Coder)
+	return ok && code != "" && t.SQLCode() == code
+}
@@ -0,0 +1,52 @@
+package sqlerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestErrorfMatchesSentinelByCode(t *testing.T) {
+	err := Errorf(CodeUniqueViolation, "UNIQUE constraint failed: %s", "email")
+	if err.Error() != "UNIQUE constraint failed: email" {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, ErrConstraintUnique) {
+		t.Fatal("errors.Is(err, ErrConstraintUnique) = false")
+	}
+	if errors.Is(err, ErrConstraintNotNull) {
+		t.Fatal("a UNIQUE failure matched ErrConstraintNotNull")
+	}
+	wrapped := fmt.Errorf("insert: %w", err)
+	if got := CodeOf(wrapped); got != CodeUniqueViolation {
+		t.Fatalf("CodeOf = %q, want %q", got, CodeUniqueViolation)
+	}
+	if got := CodeOf(io.EOF); got != "" {
+		t.Fatalf("CodeOf(io.EOF) = %q, want none", got)
+	}
+}
+
+func TestErrorfWraps(t *testing.T) {
+	err := Errorf(CodeCheckViolation, "CHECK constraint failed: %w", io.ErrUnexpectedEOF)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatal("Errorf did not wrap its %w operand")
+	}
+	if !errors.Is(err, ErrConstraintCheck) {
+		t.Fatal("errors.Is(err, ErrConstraintCheck) = false")
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	var err error = &SyntaxError{Line: 2, Column: 7, Err: errors.New("unexpected token: FORM")}
+	if err.Error() != "unexpected token: FORM" {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, ErrSyntax) || CodeOf(err) != CodeSyntax {
+		t.Fatal("SyntaxError does not match ErrSyntax")
+	}
+	var se *SyntaxError
+	if !errors.As(fmt.Errorf("parse error: %w", err), &se) || se.Line != 2 || se.Column != 7 {
+		t.Fatalf("errors.As = %+v", se)
+	}
+}
@@ -124,16 +124,23 @@ func (p *CachedPage) IsPinned() bool {
 
 // BufferPool manages cached pages in memory
 type BufferPool struct {
-	capacity   int                    // max pages in cache
-	pages      map[uint32]*CachedPage // pageID -> cached page
-	lru        *list.List             // LRU eviction list
-	mu         sync.RWMutex
-	backend    Backend
-	wal        *WAL
-	nextPageID uint32 // next available page ID for allocation
-	stats      *bufferPoolStatsCollector
-	initErr    error
-	closed     bool
+	capacity    int                    // max pages in cache
+	pages       map[uint32]*CachedPage // pageID -> cached page
+	lru         *list.List             // LRU eviction list
+	mu          sync.RWMutex
+	backend     Backend
+	wal         *WAL
+	nextPageID  uint32              // next available page ID for allocation
+	freePages   []uint32            // freed page IDs NewPage reuses before growing the file
+	pendingFree []uint32            // freed page IDs waiting for the next full flush
+	freed       map[uint32]struct{} // IDs in freePages or pendingFree
+	stats       *bufferPoolStatsCollector
+	initErr     error
+	closed      bool
+
+	// Per-goroutine page traces; see TracePages.
+	traces  sync.Map // goroutine ID -> *PageTrace
+	tracing atomic.Int32
 
 	// Background flusher
 	flushInterval time.Duration
@@ -229,6 +236,7 @@ func (bp *BufferPool) GetPage(pageID uint32) (*CachedPage, error) {
 		bp.touchLRU(p)
 		p.Pin()
 		bp.stats.recordHit()
+		bp.tracePage(true)
 		return p, nil
 	}
 	bp.mu.RUnlock()
@@ -247,6 +255,7 @@ func (bp *BufferPool) GetPage(pageID uint32) (*CachedPage, error) {
 		p.Pin()
 		bp.mu.Unlock()
 		bp.stats.recordHit()
+		bp.tracePage(true)
 		return p, nil
 	}
 
@@ -287,6 +296,7 @@ func (bp *BufferPool) GetPage(pageID uint32) (*CachedPage, error) {
 	bp.pages[pageID] = page
 	page.lruElem = bp.lru.PushFront(page)
 	bp.mu.Unlock()
+	bp.tracePage(false)
 	return page, nil
 }
 
@@ -302,18 +312,30 @@ func (bp *BufferPool) NewPage(pageType PageType) (*CachedPage, error) {
 		return nil, ErrBufferPoolClosed
 	}
 
-	// Use next available page ID
-	if bp.nextPageID == 0 {
-		return nil, ErrPageIDExhausted
+	// Reuse a free page before growing the file
+	pageID, reused := bp.popFreePageLocked()
+	if !reused {
+		if bp.nextPageID == 0 {
+			return nil, ErrPageIDExhausted
+		}
+		pageID = bp.nextPageID
+		bp.nextPageID++
+	}
+	if stale, ok := bp.pages[pageID]; ok {
+		// A freed page that was still pinned when it was released.
+		bp.lru.Remove(stale.lruElem)
+		delete(bp.pages, pageID)
 	}
-	pageID := bp.nextPageID
-	bp.nextPageID++
 
 	// Evict if at capacity
 	if len(bp.pages) >= bp.capacity {
 		if err := bp.evict(); err != nil {
-			// Rollback the nextPageID increment on failure
-			bp.nextPageID--
+			// Rollback the allocation on failure
+			if reused {
+				bp.pushFreePageLocked(pageID)
+			} else {
+				bp.nextPageID--
+			}
 			return nil, err
 		}
 	}
@@ -374,7 +396,11 @@ func (bp *BufferPool) FlushAll() error {
 		}
 	}
 
-	return bp.backend.Sync()
+	if err := bp.backend.Sync(); err != nil {
+		return err
+	}
+	bp.releasePendingLocked(len(bp.pendingFree))
+	return nil
 }
 
 // FlushDirty writes only dirty pages to disk and syncs the backend.
@@ -395,6 +421,9 @@ func (bp *BufferPool) FlushDirty() error {
 			dirty = append(dirty, page)
 		}
 	}
+	// Only pages freed before the dirty set was taken are covered by this
+	// flush.
+	pending := len(bp.pendingFree)
 	bp.mu.RUnlock()
 
 	for _, page := range dirty {
@@ -402,7 +431,13 @@ func (bp *BufferPool) FlushDirty() error {
 			return err
 		}
 	}
-	return bp.backend.Sync()
+	if err := bp.backend.Sync(); err != nil {
+		return err
+	}
+	bp.mu.Lock()
+	bp.releasePendingLocked(pending)
+	bp.mu.Unlock()
+	return nil
 }
 
 // Unpin decrements the pin count of a page
@@ -622,6 +657,27 @@ func (bp *BufferPool) flushDirtyPages() {
 	bp.flushMu.Unlock()
 }
 
+// SetCapacity changes how many pages the pool caches, evicting least
+// recently used pages until the cache fits. Pinned pages are not evicted,
+// so the cache may stay above the new capacity until they are unpinned.
+func (bp *BufferPool) SetCapacity(capacity int) error {
+	if capacity <= 0 {
+		return fmt.Errorf("buffer pool capacity must be positive: %d", capacity)
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.capacity = capacity
+	for len(bp.pages) > bp.capacity {
+		if err := bp.evict(); err != nil {
+			if errors.Is(err, ErrBufferFull) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
 // PageCount returns the number of pages in the cache
 func (bp *BufferPool) PageCount() int {
 	bp.mu.RLock()
@@ -4,6 +4,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/petermattis/goid"
 )
 
 // BufferPoolStats holds buffer pool statistics
@@ -215,3 +217,45 @@ func (bp *BufferPool) EvictionCount() uint64 {
 func (bp *BufferPool) FlushErrorCount() uint64 {
 	return atomic.LoadUint64(&bp.stats.flushErrorCount)
 }
+
+// PageTrace counts the pages one goroutine fetches through a BufferPool.
+type PageTrace struct {
+	Hits  uint64 // Pages found in the cache
+	Reads uint64 // Pages read from the backend
+}
+
+// TracePages counts the calling goroutine's page fetches into t until stop
+// is called. Traces are per goroutine, so concurrent statements do not see
+// each other's reads; a nested trace replaces the outer one until it stops.
+func (bp *BufferPool) TracePages(t *PageTrace) (stop func()) {
+	gid := goid.Get()
+	prev, nested := bp.traces.Load(gid)
+	if !nested {
+		bp.tracing.Add(1)
+	}
+	bp.traces.Store(gid, t)
+	return func() {
+		if nested {
+			bp.traces.Store(gid, prev)
+			return
+		}
+		bp.traces.Delete(gid)
+		bp.tracing.Add(-1)
+	}
+}
+
+// tracePage records a fetch in the calling goroutine's trace, if any.
+func (bp *BufferPool) tracePage(hit bool) {
+	if bp.tracing.Load() == 0 {
+		return
+	}
+	v, ok := bp.traces.Load(goid.Get())
+	if !ok {
+		return
+	}
+	if t := v.(*PageTrace); hit {
+		t.Hits++
+	} else {
+		t.Reads++
+	}
+}
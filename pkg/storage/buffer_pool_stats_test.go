@@ -198,3 +198,83 @@ func TestBufferPoolStatsConcurrent(t *testing.T) {
 		t.Errorf("Expected 100 pages, got %d", stats.PageCount)
 	}
 }
+
+func TestBufferPoolTracePages(t *testing.T) {
+	bp := NewBufferPool(5, NewMemory())
+	var ids []uint32
+	for i := 0; i < 10; i++ {
+		page, err := bp.NewPage(PageTypeLeaf)
+		if err != nil {
+			t.Fatalf("Failed to create page: %v", err)
+		}
+		page.SetDirty(true)
+		ids = append(ids, page.ID())
+		bp.Unpin(page)
+	}
+
+	fetch := func(id uint32) {
+		page, err := bp.GetPage(id)
+		if err != nil {
+			t.Fatalf("GetPage(%d): %v", id, err)
+		}
+		bp.Unpin(page)
+	}
+	var outer, inner PageTrace
+	stopOuter := bp.TracePages(&outer)
+	fetch(ids[9]) // cached
+	stopInner := bp.TracePages(&inner)
+	fetch(ids[0]) // evicted, read from the backend
+	fetch(ids[0])
+	stopInner()
+	fetch(ids[9])
+	stopOuter()
+	fetch(ids[9]) // not traced
+
+	if inner != (PageTrace{Hits: 1, Reads: 1}) {
+		t.Errorf("inner trace = %+v, want 1 hit and 1 read", inner)
+	}
+	if outer != (PageTrace{Hits: 2}) {
+		t.Errorf("outer trace = %+v, want 2 hits", outer)
+	}
+
+	// Other goroutines are not traced.
+	var own PageTrace
+	stop := bp.TracePages(&own)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if page, err := bp.GetPage(ids[9]); err == nil {
+			bp.Unpin(page)
+		}
+	}()
+	<-done
+	stop()
+	if own != (PageTrace{}) || bp.tracing.Load() != 0 {
+		t.Errorf("trace = %+v, tracing = %d; want both empty", own, bp.tracing.Load())
+	}
+}
+
+func TestBufferPoolSetCapacity(t *testing.T) {
+	bp := NewBufferPool(10, NewMemory())
+	for i := 0; i < 8; i++ {
+		page, err := bp.NewPage(PageTypeLeaf)
+		if err != nil {
+			t.Fatalf("NewPage: %v", err)
+		}
+		bp.Unpin(page)
+	}
+
+	if err := bp.SetCapacity(3); err != nil {
+		t.Fatalf("SetCapacity: %v", err)
+	}
+	if stats := bp.Stats(); stats.Capacity != 3 || stats.PageCount != 3 {
+		t.Fatalf("after shrink: capacity %d, %d pages; want 3 and 3", stats.Capacity, stats.PageCount)
+	}
+	// Evicted dirty pages were flushed and read back intact.
+	if _, err := bp.GetPage(1); err != nil {
+		t.Fatalf("GetPage after shrink: %v", err)
+	}
+	if err := bp.SetCapacity(0); err == nil {
+		t.Fatal("SetCapacity(0) should fail")
+	}
+}
@@ -15,6 +15,10 @@ type DiskBackend struct {
 	filePath string
 	fileSize int64
 	mu       sync.RWMutex
+	// mmap serves reads from mapped, a read-only shared mapping of the
+	// file, when OpenDiskMMap enabled it; see disk_mmap.go.
+	mmap   bool
+	mapped []byte
 }
 
 var diskOpenFile = os.OpenFile
@@ -101,11 +105,25 @@ func (d *DiskBackend) ReadAt(buf []byte, offset int64) (int, error) {
 	}
 
 	d.mu.RLock()
+	if d.mmap && offset+int64(len(buf)) > int64(len(d.mapped)) && d.fileSize > int64(len(d.mapped)) {
+		// The file grew past the mapping since it was made.
+		d.mu.RUnlock()
+		d.mu.Lock()
+		err := d.remapLocked()
+		d.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+		d.mu.RLock()
+	}
 	defer d.mu.RUnlock()
 
 	if d.file == nil {
 		return 0, ErrBackendClosed
 	}
+	if end := offset + int64(len(buf)); end <= int64(len(d.mapped)) {
+		return copy(buf, d.mapped[offset:end]), nil
+	}
 
 	return d.file.ReadAt(buf, offset)
 }
@@ -184,12 +202,18 @@ func (d *DiskBackend) Truncate(size int64) error {
 		return ErrBackendClosed
 	}
 
+	// Pages past the new end must not stay mapped: touching them faults.
+	if size < int64(len(d.mapped)) {
+		if err := d.unmapLocked(); err != nil {
+			return err
+		}
+	}
 	if err := d.file.Truncate(size); err != nil {
 		return err
 	}
 
 	d.fileSize = size
-	return nil
+	return d.remapLocked()
 }
 
 // Close closes the file
@@ -201,8 +225,9 @@ func (d *DiskBackend) Close() error {
 		return nil
 	}
 
+	unmapErr := d.unmapLocked()
 	syncErr := d.file.Sync()
 	closeErr := d.file.Close()
 	d.file = nil
-	return errors.Join(syncErr, closeErr)
+	return errors.Join(unmapErr, syncErr, closeErr)
 }
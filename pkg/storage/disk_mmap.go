@@ -0,0 +1,66 @@
+package storage
+
+import "fmt"
+
+// OpenDiskMMap opens path like OpenDisk and serves reads from a read-only
+// shared mapping of the file instead of a pread per page. Writes still go
+// through WriteAt, so the buffer pool and WAL see no difference; the shared
+// mapping reflects them without remapping. The mapping follows the file as
+// it grows, on the first read past its end, and as it is truncated.
+//
+// Platforms without mmap read through the file as OpenDisk does.
+func OpenDiskMMap(path string) (*DiskBackend, error) {
+	d, err := OpenDisk(path)
+	if err != nil {
+		return nil, err
+	}
+	d.mmap = mmapSupported
+	d.mu.Lock()
+	err = d.remapLocked()
+	d.mu.Unlock()
+	if err != nil {
+		_ = d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// MappedSize returns the number of bytes of the file currently mapped for
+// reads (0 without mmap).
+func (d *DiskBackend) MappedSize() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return int64(len(d.mapped))
+}
+
+// remapLocked maps the whole file, replacing any earlier mapping. The caller
+// holds d.mu for writing.
+func (d *DiskBackend) remapLocked() error {
+	if !d.mmap || d.file == nil || d.fileSize == int64(len(d.mapped)) {
+		return nil
+	}
+	if err := d.unmapLocked(); err != nil {
+		return err
+	}
+	if d.fileSize == 0 || d.fileSize != int64(int(d.fileSize)) {
+		return nil
+	}
+	mapped, err := mmapFile(d.file, int(d.fileSize))
+	if err != nil {
+		return fmt.Errorf("failed to map database file: %w", err)
+	}
+	d.mapped = mapped
+	return nil
+}
+
+func (d *DiskBackend) unmapLocked() error {
+	if d.mapped == nil {
+		return nil
+	}
+	mapped := d.mapped
+	d.mapped = nil
+	if err := munmapFile(mapped); err != nil {
+		return fmt.Errorf("failed to unmap database file: %w", err)
+	}
+	return nil
+}
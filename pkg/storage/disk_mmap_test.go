@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskMMapReads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mmap.dat")
+	disk, err := OpenDisk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := bytes.Repeat([]byte{0xAB}, PageSize)
+	if _, err := disk.WriteAt(page, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := disk.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	disk, err = OpenDiskMMap(path)
+	if err != nil {
+		t.Fatalf("OpenDiskMMap: %v", err)
+	}
+	defer disk.Close()
+	if !mmapSupported {
+		t.Skip("mmap is not available on this platform")
+	}
+	if disk.MappedSize() != PageSize {
+		t.Fatalf("mapped %d bytes, want %d", disk.MappedSize(), PageSize)
+	}
+
+	read := func(offset int64) []byte {
+		t.Helper()
+		buf := make([]byte, PageSize)
+		if _, err := ReadFullAt(disk, buf, offset); err != nil {
+			t.Fatalf("ReadAt %d: %v", offset, err)
+		}
+		return buf
+	}
+	if !bytes.Equal(read(0), page) {
+		t.Fatal("mapped page differs from the written one")
+	}
+
+	// A write inside the mapping is seen by the next read.
+	if _, err := disk.WriteAt([]byte("changed"), 100); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(0)[100:107]; string(got) != "changed" {
+		t.Fatalf("read after write = %q", got)
+	}
+
+	// Reading past the mapping maps the grown file.
+	second := bytes.Repeat([]byte{0xCD}, PageSize)
+	if _, err := disk.WriteAt(second, PageSize); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read(PageSize), second) {
+		t.Fatal("page written past the mapping differs")
+	}
+	if disk.MappedSize() != 2*PageSize {
+		t.Fatalf("mapped %d bytes after growth, want %d", disk.MappedSize(), 2*PageSize)
+	}
+
+	// Truncation shrinks the mapping; reads past the end fail as for a file.
+	if err := disk.Truncate(PageSize); err != nil {
+		t.Fatal(err)
+	}
+	if disk.MappedSize() != PageSize {
+		t.Fatalf("mapped %d bytes after truncate, want %d", disk.MappedSize(), PageSize)
+	}
+	if _, err := disk.ReadAt(make([]byte, PageSize), PageSize); err != io.EOF {
+		t.Fatalf("read past end err = %v, want io.EOF", err)
+	}
+
+	if err := disk.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if disk.MappedSize() != 0 {
+		t.Fatal("mapping survived Close")
+	}
+	if _, err := disk.ReadAt(make([]byte, 8), 0); err != ErrBackendClosed {
+		t.Fatalf("read after close err = %v", err)
+	}
+}
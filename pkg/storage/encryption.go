@@ -390,6 +390,259 @@ func (eb *EncryptedBackend) GetSalt() []byte {
 	return salt
 }
 
+// Rekey re-encrypts every page with a session key derived from newKey and a
+// freshly generated salt, then switches the backend to the new key. Before any
+// page is rewritten a rekey journal is written to journalPath (see
+// RekeyJournalPath), so a rotation cut short by a crash is finished or undone
+// by RecoverRekey when the database is next opened; an empty journalPath skips
+// it for backends with nothing on disk. The caller must then persist the new
+// salt (GetSalt/PersistSalt), remove the journal, and hand the new cipher to
+// any WAL sharing the old one. Pages are rewritten in place while the backend
+// lock is held, so concurrent I/O waits for the rotation to finish.
+func (eb *EncryptedBackend) Rekey(newKey []byte, journalPath string) error {
+	if len(newKey) == 0 {
+		return ErrInvalidKey
+	}
+
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if eb.closed {
+		return ErrBackendClosed
+	}
+	if !eb.config.Enabled || eb.cipher == nil {
+		return fmt.Errorf("%w: backend is not encrypted", ErrInvalidKey)
+	}
+
+	next := &EncryptedBackend{config: cloneEncryptionConfig(eb.config)}
+	next.config.Key = append([]byte(nil), newKey...)
+	next.config.Salt = nil
+	if err := next.deriveKey(); err != nil {
+		return err
+	}
+	aead, err := newPageCipher(next.sessionKey)
+	if err != nil {
+		return err
+	}
+
+	if journalPath != "" {
+		journal, err := encodeRekeyJournal(eb.config.Salt, eb.sessionKey, eb.cipher, next.config.Salt, next.sessionKey, aead)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(journalPath, journal, 0600); err != nil {
+			return fmt.Errorf("rekey: failed to write journal: %w", err)
+		}
+	}
+	if err := resealPages(eb.backend, eb.cipher, aead); err != nil {
+		return err
+	}
+
+	for i := range eb.sessionKey {
+		eb.sessionKey[i] = 0
+	}
+	for i := range eb.config.Key {
+		eb.config.Key[i] = 0
+	}
+	eb.config = next.config
+	eb.sessionKey = next.sessionKey
+	eb.cipher = aead
+	return nil
+}
+
+// newPageCipher returns the AES-GCM cipher for a derived session key.
+func newPageCipher(sessionKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryptionFailed, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryptionFailed, err)
+	}
+	return aead, nil
+}
+
+// resealPages rewrites every page of backend sealed by from so that it is
+// sealed by to, then syncs. Pages already sealed by to, left by an earlier
+// pass that was cut short, are kept as they are.
+func resealPages(backend Backend, from, to cipher.AEAD) error {
+	encryptedSize := int64(PageSize + from.NonceSize() + from.Overhead())
+	numPages := backend.Size() / encryptedSize
+	encryptedBuf := make([]byte, encryptedSize)
+	aad := make([]byte, 8)
+	for pageID := int64(0); pageID < numPages; pageID++ {
+		physical := pageID * encryptedSize
+		if _, err := ReadFullAt(backend, encryptedBuf, physical); err != nil {
+			return fmt.Errorf("rekey: failed to read page %d: %w", pageID, err)
+		}
+		if isZeroBlock(encryptedBuf) {
+			// Sparse hole left by an allocated but never-written page.
+			continue
+		}
+		binary.LittleEndian.PutUint64(aad, uint64(pageID)*uint64(PageSize)) // #nosec G115 - pageID is non-negative.
+		nonceSize := from.NonceSize()
+		plaintext, err := from.Open(nil, encryptedBuf[:nonceSize], encryptedBuf[nonceSize:], aad)
+		if err != nil {
+			if _, resealed := to.Open(nil, encryptedBuf[:nonceSize], encryptedBuf[nonceSize:], aad); resealed == nil {
+				continue
+			}
+			return fmt.Errorf("rekey: %w: page %d: %w", ErrDecryptionFailed, pageID, err)
+		}
+
+		nonce := make([]byte, to.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return fmt.Errorf("%w: %w", ErrEncryptionFailed, err)
+		}
+		ciphertext := to.Seal(nonce, nonce, plaintext, aad)
+		if _, err := WriteFullAt(backend, ciphertext, physical); err != nil {
+			return fmt.Errorf("rekey: failed to write page %d: %w", pageID, err)
+		}
+	}
+	if err := backend.Sync(); err != nil {
+		return fmt.Errorf("rekey: failed to sync: %w", err)
+	}
+	return nil
+}
+
+// A rekey journal records a key rotation in progress: the old and new salts,
+// and each session key sealed under the other. Whichever of the two keys the
+// database is opened with can so recover the other session key, and with it
+// read every page whatever key it was last written under. The journal holds no
+// key in the clear; it is removed once the rotation is complete.
+const rekeyJournalMarker = "CBLT_REKEY_V1"
+
+// RekeyJournalPath returns the path of the rekey journal of the database at
+// dbPath (<dbpath>.rekey).
+func RekeyJournalPath(dbPath string) (string, error) {
+	if strings.TrimSpace(dbPath) == "" {
+		return "", fmt.Errorf("database path cannot be empty")
+	}
+	return filepath.Clean(dbPath) + ".rekey", nil
+}
+
+func encodeRekeyJournal(oldSalt, oldSessionKey []byte, oldCipher cipher.AEAD, newSalt, newSessionKey []byte, newCipher cipher.AEAD) ([]byte, error) {
+	sealedOld, err := sealJournalKey(newCipher, oldSessionKey)
+	if err != nil {
+		return nil, err
+	}
+	sealedNew, err := sealJournalKey(oldCipher, newSessionKey)
+	if err != nil {
+		return nil, err
+	}
+	data := append([]byte(rekeyJournalMarker), '\n')
+	for _, field := range [][]byte{oldSalt, newSalt, sealedOld, sealedNew} {
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(field))) // #nosec G115 - salts are capped at maxEncryptionSaltBytes.
+		data = append(data, field...)
+	}
+	return data, nil
+}
+
+func decodeRekeyJournal(data []byte) (oldSalt, newSalt, sealedOld, sealedNew []byte, err error) {
+	markerLen := len(rekeyJournalMarker) + 1
+	if len(data) < markerLen || string(data[:len(rekeyJournalMarker)]) != rekeyJournalMarker {
+		return nil, nil, nil, nil, errors.New("rekey journal: bad marker")
+	}
+	data = data[markerLen:]
+	fields := make([][]byte, 4)
+	for i := range fields {
+		if len(data) < 2 {
+			return nil, nil, nil, nil, errors.New("rekey journal: truncated")
+		}
+		n := int(binary.LittleEndian.Uint16(data))
+		if len(data)-2 < n || n == 0 {
+			return nil, nil, nil, nil, errors.New("rekey journal: truncated")
+		}
+		fields[i], data = data[2:2+n], data[2+n:]
+	}
+	return fields[0], fields[1], fields[2], fields[3], nil
+}
+
+func sealJournalKey(aead cipher.AEAD, key []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryptionFailed, err)
+	}
+	return aead.Seal(nonce, nonce, key, []byte(rekeyJournalMarker)), nil
+}
+
+func openJournalKey(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(rekeyJournalMarker))
+}
+
+// RecoverRekey completes a key rotation that a crash cut short, and must run
+// before the database at dbPath is opened through an EncryptedBackend. If no
+// rekey journal exists it does nothing. Otherwise config's key decides the
+// outcome: opened with the new key, the rotation is finished; opened with the
+// old key, it is undone. Every page is resealed under that key, its salt is
+// persisted, and the journal is removed. A key that is neither fails with
+// ErrInvalidKey.
+func RecoverRekey(dbPath string, backend Backend, config *EncryptionConfig) error {
+	journalPath, err := RekeyJournalPath(dbPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(journalPath) // #nosec G304 - journal path is derived from a cleaned database path.
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read rekey journal: %w", err)
+	}
+	oldSalt, newSalt, sealedOld, sealedNew, err := decodeRekeyJournal(data)
+	if err != nil {
+		return err
+	}
+
+	// Try config's key as the new key, then as the old one; the session key it
+	// derives must unseal the other side's.
+	var salt []byte
+	var keep, other cipher.AEAD
+	for _, side := range []struct{ salt, sealedOther []byte }{{newSalt, sealedOld}, {oldSalt, sealedNew}} {
+		eb := &EncryptedBackend{config: cloneEncryptionConfig(config)}
+		eb.config.Salt = side.salt
+		if err := eb.deriveKey(); err != nil {
+			return err
+		}
+		aead, err := newPageCipher(eb.sessionKey)
+		if err != nil {
+			return err
+		}
+		otherKey, err := openJournalKey(aead, side.sealedOther)
+		if err != nil {
+			continue
+		}
+		if other, err = newPageCipher(otherKey); err != nil {
+			return err
+		}
+		salt, keep = side.salt, aead
+		break
+	}
+	if keep == nil {
+		return fmt.Errorf("%w: key matches neither side of the interrupted rekey", ErrInvalidKey)
+	}
+
+	if err := resealPages(backend, other, keep); err != nil {
+		return err
+	}
+	if err := PersistSalt(dbPath, salt); err != nil {
+		return fmt.Errorf("rekey: failed to persist salt: %w", err)
+	}
+	return RemoveFileDurable(journalPath)
+}
+
+func isZeroBlock(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 const (
 	saltFileMarker         = "CBLT_SALT_V1"
 	maxEncryptionSaltBytes = 4096
@@ -502,6 +755,28 @@ func saltSidecarPath(dbPath string) (string, error) {
 	return filepath.Clean(dbPath) + ".salt", nil
 }
 
+// WriteFileAtomic replaces the file at path with data, syncing the file and
+// its directory, so a crash leaves either the old contents or the new.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return writeFileAtomic(path, data, perm)
+}
+
+// RemoveFileDurable removes the file at path and syncs its directory, so the
+// removal survives a crash. A missing file is not an error.
+func RemoveFileDurable(path string) error {
+	path = filepath.Clean(path)
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
+
 func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
 	path = filepath.Clean(path)
 	dir := filepath.Dir(path)
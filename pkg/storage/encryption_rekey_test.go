@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestEncryptedBackend(t *testing.T, backend Backend, key, salt []byte) *EncryptedBackend {
+	t.Helper()
+	eb, err := NewEncryptedBackend(backend, &EncryptionConfig{
+		Enabled:     true,
+		Key:         key,
+		Salt:        salt,
+		PBKDF2Iters: 1000,
+	})
+	if err != nil {
+		t.Fatalf("NewEncryptedBackend failed: %v", err)
+	}
+	return eb
+}
+
+func TestEncryptedBackendRekey(t *testing.T) {
+	mem := NewMemory()
+	eb := newTestEncryptedBackend(t, mem, []byte("old-key"), nil)
+
+	pages := make([][]byte, 3)
+	for i := range pages {
+		pages[i] = bytes.Repeat([]byte{byte('a' + i)}, PageSize)
+		if _, err := eb.WriteAt(pages[i], int64(i*PageSize)); err != nil {
+			t.Fatalf("WriteAt page %d failed: %v", i, err)
+		}
+	}
+	oldSalt := eb.GetSalt()
+
+	if err := eb.Rekey([]byte("new-key"), ""); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	newSalt := eb.GetSalt()
+	if bytes.Equal(oldSalt, newSalt) {
+		t.Fatal("Rekey should generate a fresh salt")
+	}
+
+	buf := make([]byte, PageSize)
+	for i, want := range pages {
+		if _, err := eb.ReadAt(buf, int64(i*PageSize)); err != nil {
+			t.Fatalf("ReadAt page %d after rekey failed: %v", i, err)
+		}
+		if !bytes.Equal(buf, want) {
+			t.Fatalf("page %d content changed after rekey", i)
+		}
+	}
+
+	// The old key must no longer decrypt the rewritten pages.
+	stale := newTestEncryptedBackend(t, mem, []byte("old-key"), oldSalt)
+	if _, err := stale.ReadAt(buf, 0); !errors.Is(err, ErrDecryptionFailed) {
+		t.Fatalf("old key read error = %v, want ErrDecryptionFailed", err)
+	}
+	fresh := newTestEncryptedBackend(t, mem, []byte("new-key"), newSalt)
+	if _, err := fresh.ReadAt(buf, PageSize); err != nil || !bytes.Equal(buf, pages[1]) {
+		t.Fatalf("new key read failed: %v", err)
+	}
+}
+
+func TestEncryptedBackendRekeyRejectsInvalidInput(t *testing.T) {
+	eb := newTestEncryptedBackend(t, NewMemory(), []byte("key"), nil)
+	if err := eb.Rekey(nil, ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Rekey(nil) error = %v, want ErrInvalidKey", err)
+	}
+
+	plain, err := NewEncryptedBackend(NewMemory(), &EncryptionConfig{Enabled: false})
+	if err != nil {
+		t.Fatalf("NewEncryptedBackend failed: %v", err)
+	}
+	if err := plain.Rekey([]byte("key"), ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Rekey on disabled backend error = %v, want ErrInvalidKey", err)
+	}
+
+	if err := eb.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := eb.Rekey([]byte("other"), ""); !errors.Is(err, ErrBackendClosed) {
+		t.Fatalf("Rekey after close error = %v, want ErrBackendClosed", err)
+	}
+}
+
+// crashingBackend fails every write after the first writes, leaving the pages
+// as a crash in the middle of a rekey would.
+type crashingBackend struct {
+	Backend
+	writes int
+}
+
+func (b *crashingBackend) WriteAt(p []byte, off int64) (int, error) {
+	if b.writes <= 0 {
+		return 0, errors.New("simulated crash")
+	}
+	b.writes--
+	return b.Backend.WriteAt(p, off)
+}
+
+func TestRecoverRekeyAfterCrash(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		openKey string
+	}{
+		{"finish with new key", "new-key"},
+		{"undo with old key", "old-key"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "crash.db")
+			mem := NewMemory()
+			eb := newTestEncryptedBackend(t, mem, []byte("old-key"), nil)
+			pages := make([][]byte, 4)
+			for i := range pages {
+				pages[i] = bytes.Repeat([]byte{byte('a' + i)}, PageSize)
+				if _, err := eb.WriteAt(pages[i], int64(i*PageSize)); err != nil {
+					t.Fatalf("WriteAt page %d failed: %v", i, err)
+				}
+			}
+			if err := PersistSalt(dbPath, eb.GetSalt()); err != nil {
+				t.Fatalf("PersistSalt failed: %v", err)
+			}
+
+			// Crash after two of the four pages are rewritten.
+			eb.backend = &crashingBackend{Backend: mem, writes: 2}
+			journalPath, err := RekeyJournalPath(dbPath)
+			if err != nil {
+				t.Fatalf("RekeyJournalPath failed: %v", err)
+			}
+			if err := eb.Rekey([]byte("new-key"), journalPath); err == nil {
+				t.Fatal("Rekey should fail on the simulated crash")
+			}
+
+			config := &EncryptionConfig{Enabled: true, Key: []byte(tc.openKey), PBKDF2Iters: 1000}
+			if err := RecoverRekey(dbPath, mem, &EncryptionConfig{Enabled: true, Key: []byte("wrong-key"), PBKDF2Iters: 1000}); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("RecoverRekey with the wrong key error = %v, want ErrInvalidKey", err)
+			}
+			if err := RecoverRekey(dbPath, mem, config); err != nil {
+				t.Fatalf("RecoverRekey failed: %v", err)
+			}
+			if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+				t.Fatalf("rekey journal should be removed, stat error = %v", err)
+			}
+
+			salt, err := LoadSalt(dbPath)
+			if err != nil {
+				t.Fatalf("LoadSalt failed: %v", err)
+			}
+			reopened := newTestEncryptedBackend(t, mem, []byte(tc.openKey), salt)
+			buf := make([]byte, PageSize)
+			for i, want := range pages {
+				if _, err := reopened.ReadAt(buf, int64(i*PageSize)); err != nil {
+					t.Fatalf("ReadAt page %d after recovery failed: %v", i, err)
+				}
+				if !bytes.Equal(buf, want) {
+					t.Fatalf("page %d content changed after recovery", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Free page management.
+//
+// A page released with FreePage stays pending until the next FlushDirty or
+// FlushAll. Until then the pages that referenced it may not be rewritten on
+// disk yet, and reusing it early could leave a tree pointing at another
+// tree's data after a crash. Once flushed, NewPage hands it out before the
+// file grows.
+//
+// WriteFreeList stores the list in a chain of PageTypeFreeList pages at a
+// clean shutdown, and LoadFreeList reads it back on open. The chain pages are
+// free pages themselves, so the list takes no extra space. The chain is
+// only valid until the next allocation, so the engine clears the meta page's
+// FreeListID once it has been read. A crash can then leak pages, but it can
+// never hand out the same page twice.
+
+// PageUsage reports how the pages of a database file are used.
+type PageUsage struct {
+	TotalPages uint32 `json:"total_pages"` // allocated pages, including the meta page
+	FreePages  uint32 `json:"free_pages"`  // pages on the freelist, reusable or pending
+	UsedPages  uint32 `json:"used_pages"`
+}
+
+// freeListPageCapacity is the number of page IDs one freelist page holds.
+const freeListPageCapacity = (PageSize - PageHeaderSize) / 4
+
+// FreePage releases a page that is no longer referenced. Its contents are
+// discarded and its ID is reused once the next full flush completes. The
+// meta page, unallocated IDs and pages that are already free are ignored.
+func (bp *BufferPool) FreePage(pageID uint32) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if pageID == 0 || pageID >= bp.nextPageID {
+		return
+	}
+	if _, ok := bp.freed[pageID]; ok {
+		return
+	}
+	if page, ok := bp.pages[pageID]; ok && !page.IsPinned() {
+		page.SetDirty(false)
+		bp.lru.Remove(page.lruElem)
+		delete(bp.pages, pageID)
+	}
+	if bp.freed == nil {
+		bp.freed = make(map[uint32]struct{})
+	}
+	bp.freed[pageID] = struct{}{}
+	bp.pendingFree = append(bp.pendingFree, pageID)
+}
+
+// PageUsage returns the total, free and used page counts.
+func (bp *BufferPool) PageUsage() PageUsage {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+	free := uint32(len(bp.freePages) + len(bp.pendingFree))
+	return PageUsage{
+		TotalPages: bp.nextPageID,
+		FreePages:  free,
+		UsedPages:  bp.nextPageID - free,
+	}
+}
+
+// WriteFreeList stores the free and pending page IDs in a chain of freelist
+// pages and returns the ID of the first one, or 0 when no page is free. The
+// chain is written straight to the backend and is only valid until the next
+// NewPage, so it is meant for a clean shutdown.
+func (bp *BufferPool) WriteFreeList() (uint32, error) {
+	if bp.initErr != nil {
+		return 0, bp.initErr
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if bp.closed {
+		return 0, ErrBufferPoolClosed
+	}
+
+	ids := make([]uint32, 0, len(bp.freePages)+len(bp.pendingFree))
+	ids = append(ids, bp.freePages...)
+	ids = append(ids, bp.pendingFree...)
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// The first chainLen IDs hold the rest.
+	chainLen := (len(ids) + freeListPageCapacity) / (freeListPageCapacity + 1)
+	chain, listed := ids[:chainLen], ids[chainLen:]
+	for i, pageID := range chain {
+		if stale, ok := bp.pages[pageID]; ok {
+			bp.lru.Remove(stale.lruElem)
+			delete(bp.pages, pageID)
+		}
+		n := min(len(listed), freeListPageCapacity)
+		page := NewPage(pageID, PageTypeFreeList)
+		page.Header.CellCount = uint16(n)
+		if i+1 < len(chain) {
+			page.Header.RightPtr = chain[i+1]
+		}
+		page.SerializeHeader()
+		for j, id := range listed[:n] {
+			off := PageHeaderSize + 4*j
+			binary.LittleEndian.PutUint32(page.Data[off:off+4], id)
+		}
+		listed = listed[n:]
+		_, err := WriteFullAt(bp.backend, page.Data, int64(pageID)*int64(PageSize))
+		putPageData(page.Data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write freelist page %d: %w", pageID, err)
+		}
+	}
+	return chain[0], nil
+}
+
+// LoadFreeList reads a chain written by WriteFreeList, starting at headID,
+// and makes its pages reusable, including the chain pages themselves.
+func (bp *BufferPool) LoadFreeList(headID uint32) error {
+	if bp.initErr != nil {
+		return bp.initErr
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if bp.closed {
+		return ErrBufferPoolClosed
+	}
+
+	freed := make(map[uint32]struct{})
+	var ids []uint32
+	add := func(id uint32) error {
+		if id == 0 || id >= bp.nextPageID {
+			return fmt.Errorf("%w: freelist page ID %d out of range", ErrPageCorrupted, id)
+		}
+		if _, dup := freed[id]; dup {
+			return fmt.Errorf("%w: page %d listed twice in freelist", ErrPageCorrupted, id)
+		}
+		freed[id] = struct{}{}
+		ids = append(ids, id)
+		return nil
+	}
+
+	data := getPageData()
+	defer putPageData(data)
+	for pageID := headID; pageID != 0; {
+		if err := add(pageID); err != nil {
+			return err
+		}
+		if _, err := ReadFullAt(bp.backend, data, int64(pageID)*int64(PageSize)); err != nil {
+			return fmt.Errorf("failed to read freelist page %d: %w", pageID, err)
+		}
+		if err := validatePageHeader(data, pageID); err != nil {
+			return err
+		}
+		page := &Page{Data: data}
+		page.DeserializeHeader()
+		if page.Header.PageType != PageTypeFreeList || int(page.Header.CellCount) > freeListPageCapacity {
+			return fmt.Errorf("%w: page %d is not a freelist page", ErrPageCorrupted, pageID)
+		}
+		for j := 0; j < int(page.Header.CellCount); j++ {
+			off := PageHeaderSize + 4*j
+			if err := add(binary.LittleEndian.Uint32(data[off : off+4])); err != nil {
+				return err
+			}
+		}
+		pageID = page.Header.RightPtr
+	}
+
+	// Hand out low page IDs first.
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	bp.freePages = ids
+	bp.pendingFree = nil
+	bp.freed = freed
+	return nil
+}
+
+// popFreePageLocked takes a reusable page ID off the freelist.
+func (bp *BufferPool) popFreePageLocked() (uint32, bool) {
+	n := len(bp.freePages)
+	if n == 0 {
+		return 0, false
+	}
+	pageID := bp.freePages[n-1]
+	bp.freePages = bp.freePages[:n-1]
+	delete(bp.freed, pageID)
+	return pageID, true
+}
+
+// pushFreePageLocked returns a page ID taken by popFreePageLocked.
+func (bp *BufferPool) pushFreePageLocked(pageID uint32) {
+	bp.freePages = append(bp.freePages, pageID)
+	bp.freed[pageID] = struct{}{}
+}
+
+// releasePendingLocked makes the first n pending pages reusable. Callers
+// have flushed and synced every page dirtied before those pages were freed.
+func (bp *BufferPool) releasePendingLocked(n int) {
+	if n == 0 {
+		return
+	}
+	bp.freePages = append(bp.freePages, bp.pendingFree[:n]...)
+	bp.pendingFree = append(bp.pendingFree[:0], bp.pendingFree[n:]...)
+}
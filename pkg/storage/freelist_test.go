@@ -0,0 +1,119 @@
+package storage
+
+import "testing"
+
+func newFreeListTestPool(t *testing.T, pages int) (*BufferPool, []uint32) {
+	t.Helper()
+	bp, err := NewBufferPoolWithError(64, NewMemory())
+	if err != nil {
+		t.Fatalf("NewBufferPoolWithError: %v", err)
+	}
+	ids := make([]uint32, 0, pages)
+	for i := 0; i < pages; i++ {
+		page, err := bp.NewPage(PageTypeLeaf)
+		if err != nil {
+			t.Fatalf("NewPage: %v", err)
+		}
+		ids = append(ids, page.ID())
+		bp.Unpin(page)
+	}
+	if err := bp.FlushDirty(); err != nil {
+		t.Fatalf("FlushDirty: %v", err)
+	}
+	return bp, ids
+}
+
+func TestFreePageReusedAfterFlush(t *testing.T) {
+	bp, ids := newFreeListTestPool(t, 4)
+
+	bp.FreePage(ids[1])
+	bp.FreePage(ids[1]) // double free is ignored
+	bp.FreePage(0)      // meta page is never freed
+	bp.FreePage(999)    // never allocated
+
+	usage := bp.PageUsage()
+	if usage.TotalPages != 5 || usage.FreePages != 1 || usage.UsedPages != 4 {
+		t.Fatalf("usage = %+v, want 5 total, 1 free, 4 used", usage)
+	}
+
+	// Still pending: a new page must come from the end of the file.
+	page, err := bp.NewPage(PageTypeLeaf)
+	if err != nil {
+		t.Fatalf("NewPage: %v", err)
+	}
+	if page.ID() != 5 {
+		t.Fatalf("NewPage before flush = %d, want 5", page.ID())
+	}
+	bp.Unpin(page)
+
+	if err := bp.FlushDirty(); err != nil {
+		t.Fatalf("FlushDirty: %v", err)
+	}
+	page, err = bp.NewPage(PageTypeLeaf)
+	if err != nil {
+		t.Fatalf("NewPage: %v", err)
+	}
+	if page.ID() != ids[1] {
+		t.Fatalf("NewPage after flush = %d, want reused page %d", page.ID(), ids[1])
+	}
+	bp.Unpin(page)
+
+	usage = bp.PageUsage()
+	if usage.TotalPages != 6 || usage.FreePages != 0 {
+		t.Fatalf("usage = %+v, want 6 total, 0 free", usage)
+	}
+}
+
+func TestFreeListRoundTrip(t *testing.T) {
+	// More IDs than one freelist page holds, so the chain has several pages.
+	const pages = 2500
+	bp, ids := newFreeListTestPool(t, pages)
+	freed := make(map[uint32]bool)
+	for i, id := range ids {
+		if i%3 != 0 {
+			bp.FreePage(id)
+			freed[id] = true
+		}
+	}
+
+	head, err := bp.WriteFreeList()
+	if err != nil {
+		t.Fatalf("WriteFreeList: %v", err)
+	}
+	if head == 0 {
+		t.Fatal("WriteFreeList returned no chain")
+	}
+
+	reopened, err := NewBufferPoolWithError(64, bp.backend)
+	if err != nil {
+		t.Fatalf("NewBufferPoolWithError: %v", err)
+	}
+	if err := reopened.LoadFreeList(head); err != nil {
+		t.Fatalf("LoadFreeList: %v", err)
+	}
+	if got := reopened.PageUsage().FreePages; got != uint32(len(freed)) {
+		t.Fatalf("free pages after reload = %d, want %d", got, len(freed))
+	}
+
+	for range freed {
+		page, err := reopened.NewPage(PageTypeLeaf)
+		if err != nil {
+			t.Fatalf("NewPage: %v", err)
+		}
+		if !freed[page.ID()] {
+			t.Fatalf("NewPage returned page %d, which was not free", page.ID())
+		}
+		delete(freed, page.ID())
+		reopened.Unpin(page)
+	}
+	if got := reopened.AllocatedPageCount(); got != pages+1 {
+		t.Fatalf("file grew to %d pages, want %d", got, pages+1)
+	}
+}
+
+func TestLoadFreeListRejectsNonFreeListPage(t *testing.T) {
+	bp, ids := newFreeListTestPool(t, 2)
+	if err := bp.LoadFreeList(ids[0]); err == nil {
+		t.Fatal("LoadFreeList accepted a leaf page")
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpBackendTimeout bounds each request of the default HTTP client.
+const httpBackendTimeout = 30 * time.Second
+
+// HTTPBackend is a read-only PageBackend over a database file served by an
+// HTTP server, such as an object store, that answers range requests. Each
+// page the buffer pool misses is one ranged GET, so queries read only the
+// pages they touch. It is registered for "http" and "https" locations:
+//
+//	db, err := engine.Open("https://bucket.example.com/app.cdb", nil)
+type HTTPBackend struct {
+	client *http.Client
+	url    string
+	size   int64
+}
+
+func init() {
+	open := func(location string) (Backend, error) {
+		b, err := OpenHTTP(location, nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewPageBackend(b), nil
+	}
+	RegisterBackend("http", open)
+	RegisterBackend("https", open)
+}
+
+// OpenHTTP opens the database file at url, learning its size from a HEAD
+// request. A nil client uses one with a 30 second timeout.
+func OpenHTTP(url string, client *http.Client) (*HTTPBackend, error) {
+	if client == nil {
+		client = &http.Client{Timeout: httpBackendTimeout}
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HEAD %s: %s", url, resp.Status)
+	}
+	if resp.ContentLength < 0 {
+		return nil, fmt.Errorf("HEAD %s: no content length", url)
+	}
+	if resp.ContentLength%PageSize != 0 {
+		return nil, fmt.Errorf("HEAD %s: size %d is not a multiple of the page size %d", url, resp.ContentLength, PageSize)
+	}
+	return &HTTPBackend{client: client, url: url, size: resp.ContentLength}, nil
+}
+
+// ReadPage fetches the page with a range request.
+func (h *HTTPBackend) ReadPage(pageID uint32, buf []byte) error {
+	start := int64(pageID) * PageSize
+	if start >= h.size {
+		return io.EOF
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, h.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, start+PageSize-1))
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("read page %d: %w", pageID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("read page %d: %s (the server must support range requests)", pageID, resp.Status)
+	}
+	if _, err := io.ReadFull(resp.Body, buf[:PageSize]); err != nil {
+		return fmt.Errorf("read page %d: %w", pageID, err)
+	}
+	return nil
+}
+
+// WritePage returns ErrReadOnly.
+func (h *HTTPBackend) WritePage(uint32, []byte) error {
+	return ErrReadOnly
+}
+
+// Sync has nothing to do.
+func (h *HTTPBackend) Sync() error {
+	return nil
+}
+
+// Size returns the size of the file when it was opened.
+func (h *HTTPBackend) Size() int64 {
+	return h.size
+}
+
+// ReadOnly reports true.
+func (h *HTTPBackend) ReadOnly() bool {
+	return true
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrReadOnly is returned for writes to a read-only backend.
+var ErrReadOnly = errors.New("storage backend is read-only")
+
+// PageBackend is the page-granular form of a storage backend, for
+// implementations that address the database by page rather than by byte:
+// remote and object stores, read-only images, test doubles. Pages are
+// PageSize bytes and page N starts at byte N*PageSize. NewPageBackend adapts
+// one into a Backend.
+//
+// A PageBackend may also implement io.Closer, Truncate(size int64) error and
+// ReadOnly() bool; the adapter forwards to them when present.
+type PageBackend interface {
+	// ReadPage fills buf, PageSize bytes, with the page. A page past Size
+	// returns io.EOF.
+	ReadPage(pageID uint32, buf []byte) error
+	// WritePage stores data, PageSize bytes, as the page, growing Size as
+	// needed. Read-only backends return ErrReadOnly.
+	WritePage(pageID uint32, data []byte) error
+	// Sync makes the pages written so far durable.
+	Sync() error
+	// Size returns the size of the database in bytes.
+	Size() int64
+}
+
+// readOnlyBackend is implemented by backends that accept no writes.
+type readOnlyBackend interface {
+	ReadOnly() bool
+}
+
+// IsReadOnly reports whether b accepts no writes.
+func IsReadOnly(b Backend) bool {
+	ro, ok := b.(readOnlyBackend)
+	return ok && ro.ReadOnly()
+}
+
+// NewPageBackend adapts pb into a Backend. Reads and writes that do not
+// cover whole pages read the pages they touch first.
+func NewPageBackend(pb PageBackend) Backend {
+	return &pageBackendAdapter{pb: pb}
+}
+
+type pageBackendAdapter struct {
+	mu sync.Mutex // orders partial-page read-modify-write cycles
+	pb PageBackend
+}
+
+func (a *pageBackendAdapter) ReadAt(buf []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+	size := a.pb.Size()
+	if offset >= size {
+		return 0, io.EOF
+	}
+	want := buf
+	if end := offset + int64(len(buf)); end > size {
+		want = buf[:size-offset]
+	}
+	page := make([]byte, PageSize)
+	n := 0
+	for n < len(want) {
+		off := offset + int64(n)
+		if err := a.pb.ReadPage(uint32(off/PageSize), page); err != nil {
+			return n, err
+		}
+		n += copy(want[n:], page[off%PageSize:])
+	}
+	if n < len(buf) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (a *pageBackendAdapter) WriteAt(buf []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+	if a.ReadOnly() {
+		return 0, ErrReadOnly
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	page := make([]byte, PageSize)
+	n := 0
+	for n < len(buf) {
+		off := offset + int64(n)
+		pageID := uint32(off / PageSize)
+		start := int(off % PageSize)
+		chunk := min(len(buf)-n, PageSize-start)
+		if chunk < PageSize {
+			clear(page)
+			if int64(pageID)*PageSize < a.pb.Size() {
+				if err := a.pb.ReadPage(pageID, page); err != nil && !errors.Is(err, io.EOF) {
+					return n, err
+				}
+			}
+		}
+		copy(page[start:], buf[n:n+chunk])
+		if err := a.pb.WritePage(pageID, page); err != nil {
+			return n, err
+		}
+		n += chunk
+	}
+	return n, nil
+}
+
+func (a *pageBackendAdapter) Sync() error {
+	return a.pb.Sync()
+}
+
+func (a *pageBackendAdapter) Size() int64 {
+	return a.pb.Size()
+}
+
+func (a *pageBackendAdapter) Truncate(size int64) error {
+	if size < 0 {
+		return ErrInvalidSize
+	}
+	if t, ok := a.pb.(interface{ Truncate(int64) error }); ok {
+		return t.Truncate(size)
+	}
+	if a.ReadOnly() {
+		return ErrReadOnly
+	}
+	return fmt.Errorf("storage backend %T does not support truncation", a.pb)
+}
+
+func (a *pageBackendAdapter) Close() error {
+	if c, ok := a.pb.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (a *pageBackendAdapter) ReadOnly() bool {
+	ro, ok := a.pb.(readOnlyBackend)
+	return ok && ro.ReadOnly()
+}
+
+// BackendOpener opens the backend for a location such as
+// "https://example.com/db.cdb".
+type BackendOpener func(location string) (Backend, error)
+
+var (
+	backendOpenersMu sync.RWMutex
+	backendOpeners   = map[string]BackendOpener{}
+)
+
+// RegisterBackend makes open the backend for locations of the form
+// "scheme://...", which engine.Open then accepts as a database path.
+// Registering a scheme twice replaces the earlier opener.
+func RegisterBackend(scheme string, open BackendOpener) {
+	if scheme == "" || open == nil {
+		panic("storage: RegisterBackend needs a scheme and an opener")
+	}
+	backendOpenersMu.Lock()
+	defer backendOpenersMu.Unlock()
+	backendOpeners[strings.ToLower(scheme)] = open
+}
+
+// OpenBackend opens location with the backend registered for its scheme.
+// ok is false when location has no scheme or none is registered for it, and
+// it names a local file instead.
+func OpenBackend(location string) (b Backend, ok bool, err error) {
+	scheme, _, found := strings.Cut(location, "://")
+	if !found || scheme == "" {
+		return nil, false, nil
+	}
+	backendOpenersMu.RLock()
+	open := backendOpeners[strings.ToLower(scheme)]
+	backendOpenersMu.RUnlock()
+	if open == nil {
+		return nil, false, nil
+	}
+	b, err = open(location)
+	if err != nil {
+		return nil, true, fmt.Errorf("open %s backend: %w", scheme, err)
+	}
+	return b, true, nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mapPages is a PageBackend keeping pages in a map.
+type mapPages struct {
+	pages map[uint32][]byte
+	size  int64
+}
+
+func (m *mapPages) ReadPage(id uint32, buf []byte) error {
+	if int64(id)*PageSize >= m.size {
+		return io.EOF
+	}
+	clear(buf)
+	copy(buf, m.pages[id])
+	return nil
+}
+
+func (m *mapPages) WritePage(id uint32, data []byte) error {
+	m.pages[id] = bytes.Clone(data)
+	m.size = max(m.size, int64(id+1)*PageSize)
+	return nil
+}
+
+func (m *mapPages) Sync() error  { return nil }
+func (m *mapPages) Size() int64  { return m.size }
+func (m *mapPages) Close() error { m.pages = nil; return nil }
+
+func TestPageBackendAdapter(t *testing.T) {
+	pages := &mapPages{pages: map[uint32][]byte{}}
+	b := NewPageBackend(pages)
+	if IsReadOnly(b) {
+		t.Fatal("writable page backend reported read-only")
+	}
+
+	// A write spanning a page boundary touches both pages.
+	if _, err := WriteFullAt(b, []byte("hello, world"), PageSize-5); err != nil {
+		t.Fatal(err)
+	}
+	if b.Size() != 2*PageSize {
+		t.Fatalf("size = %d, want %d", b.Size(), 2*PageSize)
+	}
+	got := make([]byte, 12)
+	if _, err := ReadFullAt(b, got, PageSize-5); err != nil || string(got) != "hello, world" {
+		t.Fatalf("read = %q, %v", got, err)
+	}
+	if n, err := b.ReadAt(make([]byte, 10), 2*PageSize-4); n != 4 || err != io.EOF {
+		t.Fatalf("read past end = %d, %v; want 4, io.EOF", n, err)
+	}
+
+	// A pool over it works as over any backend.
+	pool := NewBufferPool(4, b)
+	page, err := pool.NewPage(PageTypeLeaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(page.Data()[PageHeaderSize:], "payload")
+	page.SetDirty(true)
+	id := page.ID()
+	pool.Unpin(page)
+	if err := pool.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(pages.pages[id], []byte("payload")) {
+		t.Fatalf("page %d was not written through", id)
+	}
+	if err := b.Truncate(0); err == nil {
+		t.Fatal("Truncate succeeded on a backend without it")
+	}
+	if err := b.Close(); err != nil || pages.pages != nil {
+		t.Fatalf("Close = %v, pages not released", err)
+	}
+}
+
+func TestOpenBackendRegistry(t *testing.T) {
+	var opened string
+	RegisterBackend("memtest", func(location string) (Backend, error) {
+		opened = location
+		return NewMemory(), nil
+	})
+	b, ok, err := OpenBackend("MemTest://db/one")
+	if err != nil || !ok || opened != "MemTest://db/one" {
+		t.Fatalf("OpenBackend = %v, %v, %v (opened %q)", b, ok, err, opened)
+	}
+	b.Close()
+
+	for _, local := range []string{"data/app.db", "unknown://x", `C:\data\app.db`, "://x"} {
+		if _, ok, err := OpenBackend(local); ok || err != nil {
+			t.Errorf("OpenBackend(%q) = %v, %v; want a local path", local, ok, err)
+		}
+	}
+
+	RegisterBackend("failtest", func(string) (Backend, error) { return nil, errors.New("boom") })
+	if _, ok, err := OpenBackend("failtest://x"); !ok || err == nil {
+		t.Fatalf("failing opener = %v, %v", ok, err)
+	}
+}
+
+func TestHTTPBackend(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 3*PageSize)
+	for i := range data {
+		data[i] = byte(i / PageSize)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.db"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	b, ok, err := OpenBackend(srv.URL + "/app.db")
+	if err != nil || !ok {
+		t.Fatalf("OpenBackend: %v, %v", ok, err)
+	}
+	defer b.Close()
+	if !IsReadOnly(b) || b.Size() != int64(len(data)) {
+		t.Fatalf("read-only %v, size %d", IsReadOnly(b), b.Size())
+	}
+	page := make([]byte, PageSize)
+	if _, err := ReadFullAt(b, page, 2*PageSize); err != nil || page[0] != 2 || page[PageSize-1] != 2 {
+		t.Fatalf("page 2 = %v..., %v", page[:4], err)
+	}
+	if _, err := b.WriteAt(page, 0); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("write err = %v, want ErrReadOnly", err)
+	}
+
+	if _, _, err := OpenBackend(srv.URL + "/missing.db"); err == nil {
+		t.Fatal("opened a missing file")
+	}
+}
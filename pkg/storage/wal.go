@@ -12,7 +12,11 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/cobaltdb/cobaltdb/pkg/metrics"
+	"github.com/cobaltdb/cobaltdb/pkg/tracing"
 )
 
 var (
@@ -42,8 +46,36 @@ const (
 	WALRollback     WALRecordType = 0x05
 	WALCheckpoint   WALRecordType = 0x06
 	WALUpdateCommit WALRecordType = 0x07
+
+	// Schema records carry a catalog definition so recovery and replicas can
+	// rebuild DDL that ran after the last checkpoint. Their payload uses the
+	// logical layout with the catalog key ("tbl:orders", "idx:idx_a", ...) as
+	// the key and the JSON definition as the value; drops carry no value.
+	// WALAlterTable is keyed by the table's name before the change, so a
+	// rename carries the old name in the key and the new one in the value.
+	WALCreateTable   WALRecordType = 0x08
+	WALDropTable     WALRecordType = 0x09
+	WALAlterTable    WALRecordType = 0x0A
+	WALCreateIndex   WALRecordType = 0x0B
+	WALDropIndex     WALRecordType = 0x0C
+	WALCreateView    WALRecordType = 0x0D
+	WALDropView      WALRecordType = 0x0E
+	WALCreateTrigger WALRecordType = 0x0F
+	WALDropTrigger   WALRecordType = 0x10
+	// WALSequence records the high-water mark of a table's row sequence.
+	// Recovery applies it even when the transaction that advanced the
+	// sequence never committed.
+	WALSequence WALRecordType = 0x11
+	// WALPageImage carries the full content of one page, written as part of
+	// a group by LogPageImages.
+	WALPageImage WALRecordType = 0x12
 )
 
+// IsSchemaWALRecordType reports whether t records a DDL operation.
+func IsSchemaWALRecordType(t WALRecordType) bool {
+	return t >= WALCreateTable && t <= WALDropTrigger
+}
+
 // WAL on-disk format constants.
 //
 //	[LSN:8][TxnID:8][Type:1][PageID:4][Offset:2][Length:2][Data:N][CRC:4]
@@ -138,10 +170,44 @@ type WAL struct {
 	batchSize          int
 	syncInterval       time.Duration
 	stopGC             chan struct{}
+	gcKick             chan struct{} // wakes groupCommitLoop when a first commit starts waiting
+
+	// Leader/follower group commit, guarded by groupCommitMu: leading is
+	// set while a waiting commit is fsyncing on behalf of the others.
+	leaderFollower bool
+	leading        bool
+
+	// Adaptive group commit, guarded by groupCommitMu.
+	adaptive    bool
+	minWindow   time.Duration
+	window      time.Duration
+	lastArrival time.Time
+	arrivalGap  time.Duration // moving average of the gap between waiting commits
+	fsyncTime   time.Duration // moving average of an fsync
+
+	appendLatency metrics.LatencyHistogram
+	fsyncWait     metrics.LatencyHistogram
+	fsyncs        atomic.Uint64
+	syncedAppends atomic.Uint64
+	bytesWritten  atomic.Uint64
+
+	lastPageGroup uint64 // sequence of the last page image group, guarded by mu
+
+	appendHook func(records []*WALRecord) // see SetAppendHook, guarded by mu
 }
 
 var walOpenFile = os.OpenFile
 
+// writeLocked appends data to the log buffer and counts it in
+// WALStats.BytesWritten. Callers hold w.mu.
+func (w *WAL) writeLocked(data []byte) error {
+	if err := writeWALFull(w.bufWriter, data); err != nil {
+		return err
+	}
+	w.bytesWritten.Add(uint64(len(data)))
+	return nil
+}
+
 func writeWALFull(writer io.Writer, data []byte) error {
 	n, err := writer.Write(data)
 	if err != nil {
@@ -153,6 +219,17 @@ func writeWALFull(writer io.Writer, data []byte) error {
 	return nil
 }
 
+// SetAppendHook makes fn see every record appended to the log, in LSN order,
+// with its LSN set and its data unencrypted; nil removes the hook. fn runs
+// under the WAL lock, before the records are synced, so it must be quick and
+// must not keep the records or their data after it returns. Checkpoint
+// markers are not reported.
+func (w *WAL) SetAppendHook(fn func(records []*WALRecord)) {
+	w.mu.Lock()
+	w.appendHook = fn
+	w.mu.Unlock()
+}
+
 // SetEncryptionCipher sets an AEAD cipher for encrypting WAL record data.
 // When set, WAL record Data fields are encrypted before writing and decrypted on read.
 // The cipher must use the same key as the main storage encryption.
@@ -410,19 +487,43 @@ func (w *WAL) readRecord(reader *bufio.Reader, header []byte) (*WALRecord, int64
 // Append adds a record to the WAL.
 // When group commit is enabled, the record is written without an immediate sync
 // and the caller blocks until the next batch sync.
-func (w *WAL) Append(record *WALRecord) error {
+func (w *WAL) Append(record *WALRecord) (err error) {
+	span := tracing.StartBound("wal.Append")
+	defer func() { tracing.End(span, err) }()
+
 	if err := validateRecordSize(record); err != nil {
 		return err
 	}
+	start := time.Now()
 	w.groupCommitMu.Lock()
 	groupCommitEnabled := w.groupCommitEnabled
 	w.groupCommitMu.Unlock()
 	if groupCommitEnabled {
-		return w.groupCommitAppend(record)
+		return w.groupCommitAppend(record, start)
 	}
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.appendInternal(record, true)
+	if err := w.appendInternal(record, false); err != nil {
+		return err
+	}
+	appended := time.Now()
+	if err := w.bufWriter.Flush(); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.fsyncs.Add(1)
+	w.syncedAppends.Add(1)
+	w.observeAppend(start, appended)
+	return nil
+}
+
+// observeAppend records the latency of a durable append that started at
+// start and had its records in the log buffer at appended.
+func (w *WAL) observeAppend(start, appended time.Time) {
+	w.appendLatency.Observe(appended.Sub(start))
+	w.fsyncWait.Observe(time.Since(appended))
 }
 
 // AppendWithoutSync adds a record without syncing (for group commit)
@@ -439,7 +540,13 @@ func (w *WAL) AppendWithoutSync(record *WALRecord) error {
 // AppendBatchWithoutSync appends multiple records under a single lock
 // acquisition without syncing. This dramatically reduces mutex contention
 // when a transaction produces many WAL records.
-func (w *WAL) AppendBatchWithoutSync(records []*WALRecord) error {
+func (w *WAL) AppendBatchWithoutSync(records []*WALRecord) (err error) {
+	span := tracing.StartBound("wal.AppendBatch")
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.Int64("cobaltdb.records", int64(len(records))))
+	}
+	defer func() { tracing.End(span, err) }()
+
 	for _, r := range records {
 		if err := validateRecordSize(r); err != nil {
 			return err
@@ -465,8 +572,16 @@ func (w *WAL) AppendBatchWithoutSync(records []*WALRecord) error {
 // single byte slice outside the lock so the critical section is reduced to
 // one bufio.Writer.Write call plus an LSN bump.  This cuts lock hold time
 // by ~2-3x for the common two-record transaction compared with calling
-// appendInternal repeatedly inside the lock.
-func (w *WAL) AppendBatch(records []*WALRecord) error {
+// appendInternal repeatedly inside the lock. Each record's LSN field is set
+// to the LSN it was written with.
+func (w *WAL) AppendBatch(records []*WALRecord) (err error) {
+	span := tracing.StartBound("wal.AppendBatch")
+	if tracing.Recording(span) {
+		span.SetAttributes(tracing.Int64("cobaltdb.records", int64(len(records))))
+	}
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
 	for _, r := range records {
 		if err := validateRecordSize(r); err != nil {
 			return err
@@ -516,6 +631,7 @@ func (w *WAL) AppendBatch(records []*WALRecord) error {
 			lsn := w.lsn
 			for i := range records {
 				lsn++
+				records[i].LSN = lsn
 				binary.LittleEndian.PutUint64(batchBuf[lsnOffs[i]:], lsn)
 				crcOff := lsnOffs[i] + walHeaderSize + dataLens[i]
 				crcHash := crc32.ChecksumIEEE(batchBuf[lsnOffs[i] : lsnOffs[i]+walHeaderSize])
@@ -524,15 +640,18 @@ func (w *WAL) AppendBatch(records []*WALRecord) error {
 				}
 				binary.LittleEndian.PutUint32(batchBuf[crcOff:], crcHash)
 			}
-			if err := writeWALFull(w.bufWriter, batchBuf[:totalSize]); err != nil {
+			if err := w.writeLocked(batchBuf[:totalSize]); err != nil {
 				w.mu.Unlock()
 				walBatchBufPool.Put(bp)
 				return err
 			}
 			w.lsn = lsn
+			if w.appendHook != nil {
+				w.appendHook(records)
+			}
 			w.mu.Unlock()
 			walBatchBufPool.Put(bp)
-			return w.finishBatchSync()
+			return w.finishBatchSync(start)
 		}
 		walBatchBufPool.Put(bp)
 	}
@@ -548,8 +667,9 @@ func (w *WAL) AppendBatch(records []*WALRecord) error {
 		return ErrWALClosed
 	}
 	lsn := w.lsn
-	for _, off := range lsnOffsets {
+	for i, off := range lsnOffsets {
 		lsn++
+		records[i].LSN = lsn
 		binary.LittleEndian.PutUint64(formatted[off:], lsn)
 	}
 	for off := 0; off < len(formatted); {
@@ -562,13 +682,16 @@ func (w *WAL) AppendBatch(records []*WALRecord) error {
 		binary.LittleEndian.PutUint32(formatted[crcOff:], crcHash)
 		off = crcOff + 4
 	}
-	if err := writeWALFull(w.bufWriter, formatted); err != nil {
+	if err := w.writeLocked(formatted); err != nil {
 		w.mu.Unlock()
 		return err
 	}
 	w.lsn = lsn
+	if w.appendHook != nil {
+		w.appendHook(records)
+	}
 	w.mu.Unlock()
-	return w.finishBatchSync()
+	return w.finishBatchSync(start)
 }
 
 // finishBatchSync applies AppendBatch's post-write durability semantics. It
@@ -578,31 +701,25 @@ func (w *WAL) AppendBatch(records []*WALRecord) error {
 // for the batch fsync and blocks until that fsync completes, instead of
 // returning before the commit record is durable. SyncOff (no interval, no batch
 // size) returns immediately. Must be called AFTER w.mu is released and the
-// records are already in w.bufWriter.
-func (w *WAL) finishBatchSync() error {
+// records are already in w.bufWriter. start is when AppendBatch was called.
+func (w *WAL) finishBatchSync(start time.Time) error {
+	appended := time.Now()
+	defer w.observeAppend(start, appended)
 	w.groupCommitMu.Lock()
 	if !w.groupCommitEnabled {
 		w.groupCommitMu.Unlock()
-		return w.Sync()
+		if err := w.Sync(); err != nil {
+			return err
+		}
+		w.syncedAppends.Add(1)
+		return nil
 	}
-	if w.syncInterval <= 0 && w.batchSize <= 0 {
+	if w.syncInterval <= 0 && w.batchSize <= 0 && !w.leaderFollower {
 		w.groupCommitMu.Unlock()
 		return nil // SyncOff: do not wait for a background sync
 	}
 
-	done := make(chan error, 1)
-	w.pendingSyncs = append(w.pendingSyncs, done)
-	if w.batchSize > 0 && len(w.pendingSyncs) >= w.batchSize {
-		w.groupCommitMu.Unlock()
-		_ = w.flushPendingLocked()
-	} else {
-		w.groupCommitMu.Unlock()
-	}
-
-	if err := <-done; err != nil {
-		return fmt.Errorf("group commit failed: %w", err)
-	}
-	return nil
+	return w.awaitGroupSync(w.addPendingSyncLocked())
 }
 
 // formatBatch serialises records into a contiguous byte slice.  LSN fields
@@ -717,10 +834,10 @@ func validateEncryptedRecordSize(record *WALRecord, c cipher.AEAD) error {
 
 func isKnownWALRecordType(recordType WALRecordType) bool {
 	switch recordType {
-	case WALInsert, WALUpdate, WALDelete, WALCommit, WALRollback, WALCheckpoint, WALUpdateCommit:
+	case WALInsert, WALUpdate, WALDelete, WALCommit, WALRollback, WALCheckpoint, WALUpdateCommit, WALSequence, WALPageImage:
 		return true
 	default:
-		return false
+		return IsSchemaWALRecordType(recordType)
 	}
 }
 
@@ -784,26 +901,30 @@ func (w *WAL) appendInternal(record *WALRecord, sync bool) error {
 		return err
 	}
 	crcHash := crc32.ChecksumIEEE(buf[:walHeaderSize])
-	if err := writeWALFull(w.bufWriter, buf[:walHeaderSize]); err != nil {
+	if err := w.writeLocked(buf[:walHeaderSize]); err != nil {
 		return err
 	}
 
 	if dataLen > 0 {
 		crcHash = crc32.Update(crcHash, crc32.IEEETable, record.Data)
-		if err := writeWALFull(w.bufWriter, record.Data); err != nil {
+		if err := w.writeLocked(record.Data); err != nil {
 			return err
 		}
 	}
 
 	// Write CRC (direct encoding avoids binary.Write reflection)
 	binary.LittleEndian.PutUint32(buf[walHeaderSize:walHeaderSize+4], crcHash)
-	if err := writeWALFull(w.bufWriter, buf[walHeaderSize:walHeaderSize+4]); err != nil {
+	if err := w.writeLocked(buf[walHeaderSize : walHeaderSize+4]); err != nil {
 		return err
 	}
 
 	// Only update LSN after successful write
 	w.lsn = newLSN
 	assignedLSN = true
+	if w.appendHook != nil {
+		record.Data = originalData
+		w.appendHook([]*WALRecord{record})
+	}
 
 	// Sync if requested (for commit records or explicit sync)
 	if sync {
@@ -818,6 +939,7 @@ func (w *WAL) appendInternal(record *WALRecord, sync bool) error {
 
 // Sync flushes the buffer and syncs to disk
 func (w *WAL) Sync() error {
+	span := tracing.StartBound("wal.Sync")
 	pending := w.popPendingSyncs()
 
 	w.mu.Lock()
@@ -828,10 +950,14 @@ func (w *WAL) Sync() error {
 		syncErr = err
 	} else if err := w.file.Sync(); err != nil {
 		syncErr = err
+	} else {
+		w.fsyncs.Add(1)
+		w.syncedAppends.Add(uint64(len(pending)))
 	}
 	w.mu.Unlock()
 
 	signalPendingSyncs(pending, syncErr)
+	tracing.End(span, syncErr)
 	return syncErr
 }
 
@@ -856,11 +982,170 @@ func (w *WAL) EnableGroupCommit(batchSize int, interval time.Duration) {
 	w.groupCommitEnabled = true
 	w.batchSize = batchSize
 	w.syncInterval = interval
+	w.adaptive = false
+	w.leaderFollower = false
+	w.gcKick = nil
 	if interval > 0 {
-		stop := make(chan struct{})
-		w.stopGC = stop
-		go w.groupCommitLoop(interval, stop)
+		stop, kick := make(chan struct{}), make(chan struct{}, 1)
+		w.stopGC, w.gcKick = stop, kick
+		if len(w.pendingSyncs) > 0 {
+			kick <- struct{}{}
+		}
+		go w.groupCommitLoop(kick, stop)
+	}
+}
+
+// EnableAdaptiveGroupCommit turns on group commit with a window that tunes
+// itself between minWindow and maxWindow. While commits arrive faster than
+// an fsync completes, the flusher waits about one fsync time so the next
+// commits share it; when they arrive slower, waiting would only add latency
+// and it waits minWindow. maxWindow bounds how long any commit waits for
+// its fsync to start.
+func (w *WAL) EnableAdaptiveGroupCommit(minWindow, maxWindow time.Duration) {
+	if maxWindow <= 0 {
+		maxWindow = 5 * time.Millisecond
+	}
+	if minWindow < 0 {
+		minWindow = 0
+	}
+	if minWindow > maxWindow {
+		minWindow = maxWindow
 	}
+	w.EnableGroupCommit(0, maxWindow)
+	w.groupCommitMu.Lock()
+	w.adaptive = true
+	w.minWindow = minWindow
+	w.window = minWindow
+	w.groupCommitMu.Unlock()
+}
+
+// EnableLeaderGroupCommit turns on group commit without a window. The
+// first commit to wait becomes the leader and fsyncs for every commit
+// queued behind it; commits arriving during that fsync queue up, and when
+// it completes the first of them leads the next group. A lone commit
+// fsyncs at once, and under concurrent commits each group is as large as
+// the commits that arrive during one fsync.
+func (w *WAL) EnableLeaderGroupCommit() {
+	w.EnableGroupCommit(0, 0)
+	w.groupCommitMu.Lock()
+	w.leaderFollower = true
+	w.groupCommitMu.Unlock()
+}
+
+// SetGroupCommitBatch makes group commit fsync as soon as n commits are
+// waiting instead of when the window closes. Zero waits for the window.
+func (w *WAL) SetGroupCommitBatch(n int) {
+	if n < 0 {
+		n = 0
+	}
+	w.groupCommitMu.Lock()
+	w.batchSize = n
+	w.groupCommitMu.Unlock()
+}
+
+// groupCommitWindow returns how long the flusher waits after the first
+// commit of a group starts waiting.
+func (w *WAL) groupCommitWindow() time.Duration {
+	w.groupCommitMu.Lock()
+	defer w.groupCommitMu.Unlock()
+	if w.adaptive {
+		return w.window
+	}
+	return w.syncInterval
+}
+
+// addPendingSyncLocked registers a commit waiting for the next group fsync
+// and returns the channel that receives its result. Caller holds
+// groupCommitMu.
+func (w *WAL) addPendingSyncLocked() chan error {
+	now := time.Now()
+	if !w.lastArrival.IsZero() {
+		w.arrivalGap = movingAverage(w.arrivalGap, now.Sub(w.lastArrival))
+	}
+	w.lastArrival = now
+	done := make(chan error, 1)
+	w.pendingSyncs = append(w.pendingSyncs, done)
+	if len(w.pendingSyncs) == 1 && w.gcKick != nil {
+		select {
+		case w.gcKick <- struct{}{}:
+		default:
+		}
+	}
+	return done
+}
+
+// tuneWindowLocked recomputes the adaptive window after an fsync that took
+// d. Caller holds groupCommitMu.
+func (w *WAL) tuneWindowLocked(d time.Duration) {
+	w.fsyncTime = movingAverage(w.fsyncTime, d)
+	if !w.adaptive {
+		return
+	}
+	window := w.minWindow
+	if w.arrivalGap > 0 && w.arrivalGap < w.fsyncTime {
+		window = w.fsyncTime
+		if window < w.minWindow {
+			window = w.minWindow
+		}
+		if window > w.syncInterval {
+			window = w.syncInterval
+		}
+	}
+	w.window = window
+}
+
+// movingAverage folds sample into avg with weight 1/8.
+func movingAverage(avg, sample time.Duration) time.Duration {
+	if avg == 0 {
+		return sample
+	}
+	return avg + (sample-avg)/8
+}
+
+// WALStats reports how long durable appends (Append and AppendBatch) spend
+// in the log.
+type WALStats struct {
+	// AppendLatency is the time to write the records into the log buffer,
+	// including waits for the log mutex.
+	AppendLatency metrics.LatencySnapshot `json:"append_latency"`
+	// FsyncWait is the time from then until the records were on disk,
+	// alone or in a group commit.
+	FsyncWait metrics.LatencySnapshot `json:"fsync_wait"`
+	// Fsyncs counts fsyncs; SyncedAppends/Fsyncs is the average group size.
+	Fsyncs        uint64 `json:"fsyncs"`
+	SyncedAppends uint64 `json:"synced_appends"`
+	// BytesWritten is the size of all records written since the WAL was
+	// opened, checkpoint records included.
+	BytesWritten uint64 `json:"bytes_written"`
+	// GroupCommitWindow is how long the flusher currently waits for more
+	// commits, or 0 without a window.
+	GroupCommitWindow time.Duration `json:"group_commit_window"`
+	Adaptive          bool          `json:"adaptive"`
+	// LeaderFollower reports group commit led by the waiting commits
+	// themselves; see EnableLeaderGroupCommit.
+	LeaderFollower bool `json:"leader_follower"`
+}
+
+// Stats returns the WAL's commit latency statistics.
+func (w *WAL) Stats() WALStats {
+	stats := WALStats{
+		AppendLatency: w.appendLatency.Snapshot(),
+		FsyncWait:     w.fsyncWait.Snapshot(),
+		Fsyncs:        w.fsyncs.Load(),
+		SyncedAppends: w.syncedAppends.Load(),
+		BytesWritten:  w.bytesWritten.Load(),
+	}
+	w.groupCommitMu.Lock()
+	if w.groupCommitEnabled {
+		stats.Adaptive = w.adaptive
+		stats.LeaderFollower = w.leaderFollower
+		stats.GroupCommitWindow = w.syncInterval
+		if w.adaptive {
+			stats.GroupCommitWindow = w.window
+		}
+	}
+	w.groupCommitMu.Unlock()
+	return stats
 }
 
 // DisableGroupCommit turns off group commit and flushes any pending records.
@@ -883,7 +1168,7 @@ func (w *WAL) DisableGroupCommit() {
 
 // groupCommitAppend writes the record without sync and blocks until the next
 // batch sync. Must NOT be called while holding w.mu.
-func (w *WAL) groupCommitAppend(record *WALRecord) error {
+func (w *WAL) groupCommitAppend(record *WALRecord, start time.Time) error {
 	w.groupCommitMu.Lock()
 	if !w.groupCommitEnabled {
 		w.groupCommitMu.Unlock()
@@ -900,35 +1185,70 @@ func (w *WAL) groupCommitAppend(record *WALRecord) error {
 		w.groupCommitMu.Unlock()
 		return err
 	}
+	defer w.observeAppend(start, time.Now())
 
 	// SyncOff mode: don't wait for background sync
-	if w.syncInterval <= 0 && w.batchSize <= 0 {
+	if w.syncInterval <= 0 && w.batchSize <= 0 && !w.leaderFollower {
 		w.groupCommitMu.Unlock()
 		return nil
 	}
 
-	done := make(chan error, 1)
-	w.pendingSyncs = append(w.pendingSyncs, done)
+	return w.awaitGroupSync(w.addPendingSyncLocked())
+}
 
-	// Trigger immediate sync if batch is full
-	if w.batchSize > 0 && len(w.pendingSyncs) >= w.batchSize {
+// errGroupCommitLeader is sent to a waiting commit to hand it the lead of
+// the next leader/follower group.
+var errGroupCommitLeader = errors.New("group commit leader")
+
+// awaitGroupSync blocks until the group fsync covering done completes. It
+// is called with groupCommitMu held, just after done was registered, and
+// releases it. Under leader/follower group commit the caller leads the
+// group when no fsync is in flight; otherwise the batch size or the
+// flusher's window triggers the fsync.
+func (w *WAL) awaitGroupSync(done chan error) error {
+	switch {
+	case w.leaderFollower && !w.leading:
+		w.leading = true
+		w.groupCommitMu.Unlock()
+		w.leadGroupSync()
+	case w.batchSize > 0 && len(w.pendingSyncs) >= w.batchSize:
 		w.groupCommitMu.Unlock()
 		_ = w.flushPendingLocked()
-		if err := <-done; err != nil {
-			return fmt.Errorf("group commit failed: %w", err)
-		}
-		return nil
+	default:
+		w.groupCommitMu.Unlock()
 	}
 
-	w.groupCommitMu.Unlock()
-
-	// Wait for sync result
-	if err := <-done; err != nil {
+	err := <-done
+	if errors.Is(err, errGroupCommitLeader) {
+		// Handed the lead with done already dequeued: the fsync's result
+		// is this commit's own, and the fsync did not count it.
+		if err = w.leadGroupSync(); err == nil {
+			w.syncedAppends.Add(1)
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("group commit failed: %w", err)
 	}
 	return nil
 }
 
+// leadGroupSync fsyncs for every waiting commit, then hands the lead to the
+// first commit that queued up meanwhile, or gives it up if none did.
+func (w *WAL) leadGroupSync() error {
+	err := w.flushPendingLocked()
+	w.groupCommitMu.Lock()
+	if len(w.pendingSyncs) == 0 {
+		w.leading = false
+		w.groupCommitMu.Unlock()
+		return err
+	}
+	next := w.pendingSyncs[0]
+	w.pendingSyncs = append(w.pendingSyncs[:0], w.pendingSyncs[1:]...)
+	w.groupCommitMu.Unlock()
+	next <- errGroupCommitLeader
+	return err
+}
+
 // flushPendingLocked syncs the WAL and signals all pending callers.
 // It acquires w.groupCommitMu internally so callers need not hold it
 // across the long fsync critical section.
@@ -950,8 +1270,15 @@ func (w *WAL) flushPendingLocked() error {
 	w.mu.Unlock()
 
 	if file != nil && flushErr == nil {
+		start := time.Now()
 		if err := file.Sync(); err != nil {
 			flushErr = fmt.Errorf("WAL sync: %w", err)
+		} else {
+			w.fsyncs.Add(1)
+			w.syncedAppends.Add(uint64(len(pending)))
+			w.groupCommitMu.Lock()
+			w.tuneWindowLocked(time.Since(start))
+			w.groupCommitMu.Unlock()
 		}
 	}
 
@@ -974,20 +1301,36 @@ func signalPendingSyncs(pending []chan error, syncErr error) {
 	}
 }
 
-// groupCommitLoop runs a ticker that periodically syncs pending records.
-func (w *WAL) groupCommitLoop(interval time.Duration, stop <-chan struct{}) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// groupCommitLoop syncs pending records one window after the first of a
+// group starts waiting, so an idle log is never fsynced.
+func (w *WAL) groupCommitLoop(kick, stop <-chan struct{}) {
+	timer := time.NewTimer(0)
+	<-timer.C
 	for {
 		select {
 		case <-stop:
 			return
-		case <-ticker.C:
-			_ = w.flushPendingLocked()
+		case <-kick:
+		}
+		timer.Reset(w.groupCommitWindow())
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			if w.hasPendingSyncs() {
+				_ = w.flushPendingLocked()
+			}
 		}
 	}
 }
 
+func (w *WAL) hasPendingSyncs() bool {
+	w.groupCommitMu.Lock()
+	defer w.groupCommitMu.Unlock()
+	return len(w.pendingSyncs) > 0
+}
+
 // writeRecordHeader serializes the fixed-size WAL record header into dst[:walHeaderSize].
 // dataLen carries the length of the (possibly encrypted) payload that will follow the header.
 // zeroWALHeaderLSN clears the 8-byte LSN field at the start of a WAL record
@@ -1027,7 +1370,10 @@ func (w *WAL) encodeRecord(record *WALRecord) ([]byte, error) {
 }
 
 // Checkpoint flushes dirty pages to main DB file and truncates WAL
-func (w *WAL) Checkpoint(bp *BufferPool) error {
+func (w *WAL) Checkpoint(bp *BufferPool) (err error) {
+	span := tracing.StartBound("wal.Checkpoint")
+	defer func() { tracing.End(span, err) }()
+
 	if err := w.flushPendingLocked(); err != nil {
 		return err
 	}
@@ -1091,12 +1437,12 @@ func (w *WAL) Checkpoint(bp *BufferPool) error {
 	}
 	crc := crc32.ChecksumIEEE(buf)
 
-	if err := writeWALFull(w.bufWriter, buf); err != nil {
+	if err := w.writeLocked(buf); err != nil {
 		return err
 	}
 	var crcBuf [4]byte
 	binary.LittleEndian.PutUint32(crcBuf[:], crc)
-	if err := writeWALFull(w.bufWriter, crcBuf[:]); err != nil {
+	if err := w.writeLocked(crcBuf[:]); err != nil {
 		return err
 	}
 
@@ -1117,6 +1463,9 @@ func (w *WAL) Checkpoint(bp *BufferPool) error {
 // Recover replays WAL records after a crash.  Physical records (PageID > 0)
 // are applied directly to the buffer pool; logical records (PageID == 0) are
 // buffered in w.replayOps for catalog-level replay after catalog init.
+// Page images of a group logged by LogPageImages are installed when the
+// group's commit record is reached, so the pages hold their content as of
+// the last completed group.
 func (w *WAL) Recover(bp *BufferPool) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -1183,7 +1532,9 @@ func (w *WAL) Recover(bp *BufferPool) error {
 			pendingTracker.remove(pendingTxns[record.TxnID])
 			delete(pendingTxns, record.TxnID)
 
-		case WALInsert, WALUpdate, WALDelete:
+		case WALInsert, WALUpdate, WALDelete,
+			WALCreateTable, WALDropTable, WALAlterTable, WALCreateIndex, WALDropIndex,
+			WALCreateView, WALDropView, WALCreateTrigger, WALDropTrigger, WALPageImage:
 			if committedTxns[record.TxnID] {
 				// Transaction already committed, apply immediately
 				if err := w.recoverRecord(bp, record); err != nil {
@@ -1197,6 +1548,13 @@ func (w *WAL) Recover(bp *BufferPool) error {
 				pendingTxns[record.TxnID] = append(pendingTxns[record.TxnID], record)
 			}
 
+		case WALSequence:
+			// Applied whether or not its transaction committed: a rolled-back
+			// insert still consumed its sequence values.
+			if err := w.recoverRecord(bp, record); err != nil {
+				return err
+			}
+
 		case WALUpdateCommit:
 			committedTxns[record.TxnID] = true
 			// Apply pending records for this transaction
@@ -1225,6 +1583,9 @@ func (w *WAL) Recover(bp *BufferPool) error {
 // recoverRecord dispatches a WAL record to either page-level apply or logical
 // replay buffering.
 func (w *WAL) recoverRecord(bp *BufferPool, record *WALRecord) error {
+	if record.Type == WALPageImage {
+		return bp.installPageImage(record.PageID, record.Data)
+	}
 	if record.PageID == 0 && record.Offset == 0 {
 		// Logical record — buffer for catalog-level replay.
 		w.replayOps = append(w.replayOps, WALReplayOp{
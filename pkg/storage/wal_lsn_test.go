@@ -193,3 +193,53 @@ func TestWALAppendWithoutSyncValidation(t *testing.T) {
 		t.Error("AppendWithoutSync should reject oversized data")
 	}
 }
+
+// TestWALAppendHook verifies that the append hook sees every appended record
+// in LSN order with its plaintext data, including through encryption.
+func TestWALAppendHook(t *testing.T) {
+	wal, err := OpenWAL(filepath.Join(t.TempDir(), "test.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	wal.SetEncryptionCipher(makeTestCipher(t))
+
+	type seen struct {
+		lsn  uint64
+		data string
+	}
+	var got []seen
+	wal.SetAppendHook(func(records []*WALRecord) {
+		for _, r := range records {
+			got = append(got, seen{r.LSN, string(r.Data)})
+		}
+	})
+
+	if err := wal.Append(&WALRecord{TxnID: 1, Type: WALInsert, Data: []byte("a")}); err != nil {
+		t.Fatal(err)
+	}
+	batch := []*WALRecord{
+		{TxnID: 2, Type: WALInsert, Data: []byte("b")},
+		{TxnID: 2, Type: WALCommit},
+	}
+	if err := wal.AppendBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+	want := []seen{{1, "a"}, {2, "b"}, {3, ""}}
+	if len(got) != len(want) {
+		t.Fatalf("hook saw %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("hook saw %v, want %v", got, want)
+		}
+	}
+
+	wal.SetAppendHook(nil)
+	if err := wal.Append(&WALRecord{TxnID: 3, Type: WALInsert, Data: []byte("c")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("removed hook still called: %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+)
+
+func pageImage(pageID uint32, fill byte) PageImage {
+	data := make([]byte, PageSize)
+	binary.LittleEndian.PutUint32(data[0:4], pageID)
+	data[4] = byte(PageTypeLeaf)
+	for i := PageHeaderSize; i < PageSize; i++ {
+		data[i] = fill
+	}
+	return PageImage{PageID: pageID, Data: data}
+}
+
+func TestWALPageImagesRecover(t *testing.T) {
+	walPath := filepath.Join(t.TempDir(), "pages.wal")
+	wal, err := OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewMemory()
+	pool := NewBufferPool(16, backend)
+	p1, _ := pool.NewPage(PageTypeLeaf)
+	pool.Unpin(p1)
+	if err := pool.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	// Page 2 lies past the end of the file until the group is recovered.
+	p2ID := p1.ID() + 1
+
+	if err := wal.LogPageImages([]PageImage{pageImage(p1.ID(), 'a'), pageImage(p2ID, 'b')}); err != nil {
+		t.Fatalf("LogPageImages: %v", err)
+	}
+	// A group cut short by a crash has no commit record.
+	torn := pageImage(p1.ID(), 'x')
+	if err := wal.Append(&WALRecord{TxnID: pageGroupTxnBit | 1<<40, Type: WALPageImage, PageID: torn.PageID, Data: torn.Data}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.LogPageImages([]PageImage{{PageID: p1.ID(), Data: []byte("short")}}); err == nil {
+		t.Fatal("expected a short image to be rejected")
+	}
+	wal.Close()
+
+	wal2, err := OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal2.Close()
+	pool2 := NewBufferPool(16, backend)
+	defer pool2.Close()
+	if err := wal2.Recover(pool2); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+
+	for _, want := range []PageImage{pageImage(p1.ID(), 'a'), pageImage(p2ID, 'b')} {
+		page, err := pool2.GetPage(want.PageID)
+		if err != nil {
+			t.Fatalf("page %d: %v", want.PageID, err)
+		}
+		if !bytes.Equal(page.Data(), want.Data) {
+			t.Errorf("page %d was not restored from its image", want.PageID)
+		}
+		pool2.Unpin(page)
+	}
+	if got := pool2.AllocatedPageCount(); got <= p2ID {
+		t.Errorf("allocated pages = %d, want past page %d", got, p2ID)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -1461,3 +1462,132 @@ func TestWALCloseIdempotent(t *testing.T) {
 		t.Logf("Second close returned error (expected): %v", err)
 	}
 }
+
+func TestWALAdaptiveGroupCommit(t *testing.T) {
+	wal, err := OpenWAL(filepath.Join(t.TempDir(), "adaptive.wal"))
+	if err != nil {
+		t.Fatalf("Failed to open WAL: %v", err)
+	}
+	defer wal.Close()
+
+	wal.EnableAdaptiveGroupCommit(100*time.Microsecond, 5*time.Millisecond)
+	if stats := wal.Stats(); !stats.Adaptive || stats.GroupCommitWindow != 100*time.Microsecond {
+		t.Fatalf("stats after enable = %+v", stats)
+	}
+
+	const writers, perWriter = 8, 50
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				errs <- wal.AppendBatch([]*WALRecord{{TxnID: uint64(id), Type: WALCommit}})
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("AppendBatch: %v", err)
+		}
+	}
+
+	stats := wal.Stats()
+	if stats.AppendLatency.Count != writers*perWriter || stats.FsyncWait.Count != writers*perWriter {
+		t.Fatalf("observed %d appends and %d fsync waits, want %d", stats.AppendLatency.Count, stats.FsyncWait.Count, writers*perWriter)
+	}
+	if stats.SyncedAppends != writers*perWriter || stats.Fsyncs == 0 || stats.Fsyncs > stats.SyncedAppends {
+		t.Fatalf("fsyncs = %d for %d synced appends", stats.Fsyncs, stats.SyncedAppends)
+	}
+	if w := stats.GroupCommitWindow; w < 100*time.Microsecond || w > 5*time.Millisecond {
+		t.Fatalf("window %v outside its bounds", w)
+	}
+
+	// An idle log is not fsynced.
+	time.Sleep(20 * time.Millisecond)
+	if got := wal.Stats().Fsyncs; got != stats.Fsyncs {
+		t.Fatalf("idle WAL fsynced %d times", got-stats.Fsyncs)
+	}
+}
+
+func TestWALLeaderGroupCommit(t *testing.T) {
+	wal, err := OpenWAL(filepath.Join(t.TempDir(), "leader.wal"))
+	if err != nil {
+		t.Fatalf("Failed to open WAL: %v", err)
+	}
+	defer wal.Close()
+
+	wal.EnableLeaderGroupCommit()
+	if stats := wal.Stats(); !stats.LeaderFollower || stats.GroupCommitWindow != 0 {
+		t.Fatalf("stats after enable = %+v", stats)
+	}
+
+	// A lone commit leads its own group and is synced before returning.
+	if err := wal.Append(&WALRecord{TxnID: 1, Type: WALCommit}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if stats := wal.Stats(); stats.Fsyncs != 1 || stats.SyncedAppends != 1 {
+		t.Fatalf("lone commit: %d fsyncs for %d synced appends", stats.Fsyncs, stats.SyncedAppends)
+	}
+
+	const writers, perWriter = 8, 50
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				record := &WALRecord{TxnID: uint64(id), Type: WALCommit}
+				if j%2 == 0 {
+					errs <- wal.Append(record)
+				} else {
+					errs <- wal.AppendBatch([]*WALRecord{record})
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	stats := wal.Stats()
+	if stats.SyncedAppends != writers*perWriter+1 || stats.Fsyncs == 0 || stats.Fsyncs > stats.SyncedAppends {
+		t.Fatalf("fsyncs = %d for %d synced appends", stats.Fsyncs, stats.SyncedAppends)
+	}
+	wal.groupCommitMu.Lock()
+	leading, pending := wal.leading, len(wal.pendingSyncs)
+	wal.groupCommitMu.Unlock()
+	if leading || pending != 0 {
+		t.Fatalf("after the writers: leading %v with %d pending", leading, pending)
+	}
+}
+
+func TestWALAdaptiveWindowTuning(t *testing.T) {
+	w := &WAL{adaptive: true, minWindow: 100 * time.Microsecond, syncInterval: 5 * time.Millisecond}
+	// Commits arrive faster than an fsync completes: wait about one fsync.
+	w.arrivalGap = 200 * time.Microsecond
+	w.tuneWindowLocked(2 * time.Millisecond)
+	if w.window != 2*time.Millisecond {
+		t.Fatalf("window = %v, want the 2ms fsync time", w.window)
+	}
+	// Slow fsyncs are capped by the maximum window.
+	w.fsyncTime = 0
+	w.tuneWindowLocked(50 * time.Millisecond)
+	if w.window != 5*time.Millisecond {
+		t.Fatalf("window = %v, want the 5ms maximum", w.window)
+	}
+	// A lone committer gains nothing from waiting.
+	w.fsyncTime, w.arrivalGap = 0, 20*time.Millisecond
+	w.tuneWindowLocked(2 * time.Millisecond)
+	if w.window != 100*time.Microsecond {
+		t.Fatalf("window = %v, want the 100µs minimum", w.window)
+	}
+}
@@ -0,0 +1,150 @@
+// Package tracing reports CobaltDB's work as spans to a distributed tracing
+// system. It defines a minimal Tracer interface instead of depending on an
+// SDK; an OpenTelemetry tracer fits it with a few lines of glue:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+//		ctx, span := o.t.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+// where otelSpan forwards SetAttributes, RecordError and End. Spans are
+// started from the caller's context, so they nest under the span the caller
+// is in.
+package tracing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/petermattis/goid"
+)
+
+// Tracer starts spans.
+type Tracer interface {
+	// Start begins a span named name as a child of the span in ctx, if any,
+	// and returns a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one timed operation.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed with err.
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key/value pair attached to a span. Value is a string,
+// int64 or bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int64 returns an integer attribute.
+func Int64(key string, value int64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+type tracerKey struct{}
+
+// WithTracer returns a context whose operations are traced by t.
+func WithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// TracerFrom returns the tracer carried by ctx, or nil.
+func TracerFrom(ctx context.Context) Tracer {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(tracerKey{}).(Tracer)
+	return t
+}
+
+// Start begins a span with the tracer carried by ctx. Without one it returns
+// ctx unchanged and a span that does nothing.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	t := TracerFrom(ctx)
+	if t == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := t.Start(ctx, name)
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+	return ctx, span
+}
+
+// End records err, if any, on span and ends it.
+func End(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// Recording reports whether span is a real span rather than the no-op one
+// returned without a tracer. Hot paths check it before building attributes.
+func Recording(span Span) bool {
+	_, noop := span.(noopSpan)
+	return !noop
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// Code that takes no context, such as B+Tree and WAL operations, traces
+// through the context bound to the calling goroutine.
+var (
+	bound    sync.Map // goroutine id -> context.Context
+	bindings atomic.Int32
+)
+
+// Bind makes ctx the parent of spans that StartBound begins on the calling
+// goroutine until unbind is called. It does nothing when ctx carries no
+// tracer. A nested Bind replaces the outer context until it is unbound.
+func Bind(ctx context.Context) (unbind func()) {
+	if TracerFrom(ctx) == nil {
+		return func() {}
+	}
+	gid := goid.Get()
+	prev, nested := bound.Load(gid)
+	if !nested {
+		bindings.Add(1)
+	}
+	bound.Store(gid, ctx)
+	return func() {
+		if nested {
+			bound.Store(gid, prev)
+			return
+		}
+		bound.Delete(gid)
+		bindings.Add(-1)
+	}
+}
+
+// StartBound begins a span under the context bound to the calling
+// goroutine. Without one it returns a span that does nothing, at the cost
+// of an atomic load.
+func StartBound(name string, attrs ...Attribute) Span {
+	if bindings.Load() == 0 {
+		return noopSpan{}
+	}
+	v, ok := bound.Load(goid.Get())
+	if !ok {
+		return noopSpan{}
+	}
+	_, span := Start(v.(context.Context), name, attrs...)
+	return span
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+func (s *recordedSpan) End()                  { s.ended = true }
+
+type spanKey struct{}
+
+type recorder struct{ spans []*recordedSpan }
+
+func (r *recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestSpansNestThroughContextAndGoroutine(t *testing.T) {
+	if _, span := Start(context.Background(), "untraced"); Recording(span) {
+		t.Fatal("span without a tracer is recording")
+	}
+	if Recording(StartBound("unbound")) {
+		t.Fatal("unbound span is recording")
+	}
+
+	rec := &recorder{}
+	ctx, outer := Start(WithTracer(context.Background(), rec), "outer", String("k", "v"))
+	unbind := Bind(ctx)
+	inner := StartBound("inner", Int64("n", 3))
+	End(inner, errors.New("boom"))
+	unbind()
+	End(outer, nil)
+
+	if Recording(StartBound("after")) {
+		t.Fatal("span started after unbind is recording")
+	}
+	done := make(chan bool)
+	unbind = Bind(ctx)
+	go func() { done <- Recording(StartBound("other goroutine")) }()
+	if <-done {
+		t.Fatal("binding leaked to another goroutine")
+	}
+	unbind()
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(rec.spans))
+	}
+	o, i := rec.spans[0], rec.spans[1]
+	if o.name != "outer" || o.attrs["k"] != "v" || !o.ended || o.err != nil {
+		t.Errorf("outer span = %+v", o)
+	}
+	if i.name != "inner" || i.parent != o || i.attrs["n"] != int64(3) || !i.ended || i.err == nil {
+		t.Errorf("inner span = %+v", i)
+	}
+}
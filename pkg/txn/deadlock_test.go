@@ -1,6 +1,7 @@
 package txn
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -151,8 +152,8 @@ func TestLockTimeout(t *testing.T) {
 
 	// txn2 tries to acquire same lock with short timeout - should timeout
 	err = m.AcquireLock(txn2.ID, "key1", 50*time.Millisecond)
-	if err == nil {
-		t.Error("Expected timeout error, got nil")
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected ErrLockTimeout, got %v", err)
 	}
 
 	txn1.Rollback()
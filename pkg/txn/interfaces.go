@@ -6,8 +6,10 @@ import "time"
 type LockMode int
 
 const (
-	LockShared    LockMode = iota // Multiple readers can hold simultaneously
-	LockExclusive                 // Only one holder allowed
+	LockShared          LockMode = iota // Multiple readers can hold simultaneously
+	LockExclusive                       // Only one holder allowed
+	LockIntentShared                    // Held on a table while locking its rows shared
+	LockIntentExclusive                 // Held on a table while locking its rows exclusive
 )
 
 // LockManager defines the interface for lock acquisition and release.
@@ -0,0 +1,104 @@
+package txn
+
+import "strings"
+
+// Lock keys. Writers take LockIntentExclusive on TableLockKey and
+// LockExclusive on the RowLockKey of each row they write, so transactions
+// writing different rows of a table proceed together while a table-level
+// LockShared or LockExclusive still excludes every row writer.
+
+// TableLockKey returns the lock key of table.
+func TableLockKey(table string) string {
+	return "table:" + strings.ToLower(table)
+}
+
+// RowLockKey returns the lock key of the row stored under key in table.
+func RowLockKey(table, key string) string {
+	return "row:" + strings.ToLower(table) + "\x00" + key
+}
+
+// String returns the conventional abbreviation of the mode.
+func (m LockMode) String() string {
+	switch m {
+	case LockShared:
+		return "S"
+	case LockExclusive:
+		return "X"
+	case LockIntentShared:
+		return "IS"
+	case LockIntentExclusive:
+		return "IX"
+	}
+	return "unknown"
+}
+
+// lockEntry is the lock state of one key.
+type lockEntry struct {
+	holders map[uint64]LockMode // txnID → mode held
+}
+
+func newLockEntry() *lockEntry {
+	return &lockEntry{holders: make(map[uint64]LockMode, 1)}
+}
+
+// lockCompatible reports whether two transactions may hold a and b on the
+// same key at once.
+func lockCompatible(a, b LockMode) bool {
+	switch a {
+	case LockIntentShared:
+		return b != LockExclusive
+	case LockIntentExclusive:
+		return b == LockIntentShared || b == LockIntentExclusive
+	case LockShared:
+		return b == LockIntentShared || b == LockShared
+	}
+	return false
+}
+
+// lockUnion returns the weakest mode covering both a and b, the mode of a
+// transaction holding a that is granted b.
+func lockUnion(a, b LockMode) LockMode {
+	switch {
+	case a == b:
+		return a
+	case a == LockExclusive || b == LockExclusive:
+		return LockExclusive
+	case a == LockIntentShared:
+		return b
+	case b == LockIntentShared:
+		return a
+	}
+	// Shared plus intent-exclusive; without a SIX mode that is exclusive.
+	return LockExclusive
+}
+
+// blocker returns a transaction whose lock keeps txnID from being granted
+// mode, or 0 if the lock can be granted now.
+func (e *lockEntry) blocker(txnID uint64, mode LockMode) uint64 {
+	if held, ok := e.holders[txnID]; ok {
+		mode = lockUnion(held, mode)
+	}
+	for id, held := range e.holders {
+		if id != txnID && !lockCompatible(mode, held) {
+			return id
+		}
+	}
+	return 0
+}
+
+// grant records txnID as holding mode, upgrading a lock it already holds.
+func (e *lockEntry) grant(txnID uint64, mode LockMode) {
+	if held, ok := e.holders[txnID]; ok {
+		mode = lockUnion(held, mode)
+	}
+	e.holders[txnID] = mode
+}
+
+// release drops txnID's lock and reports whether it held one.
+func (e *lockEntry) release(txnID uint64) bool {
+	if _, ok := e.holders[txnID]; !ok {
+		return false
+	}
+	delete(e.holders, txnID)
+	return true
+}
@@ -0,0 +1,102 @@
+package txn
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockModeCompatibility(t *testing.T) {
+	m := NewManager(nil)
+	t1 := m.Begin(nil)
+	t2 := m.Begin(nil)
+	defer t1.Rollback()
+	defer t2.Rollback()
+
+	table := TableLockKey("Orders")
+	if table != TableLockKey("orders") {
+		t.Fatalf("TableLockKey is case-sensitive: %q", table)
+	}
+	row1, row2 := RowLockKey("orders", "1"), RowLockKey("orders", "2")
+
+	// Row writers share the table and lock different rows.
+	for _, tx := range []*Transaction{t1, t2} {
+		if err := m.AcquireLockMode(tx.ID, table, LockIntentExclusive, 0); err != nil {
+			t.Fatalf("IX for txn %d: %v", tx.ID, err)
+		}
+	}
+	if err := m.AcquireLockMode(t1.ID, row1, LockExclusive, 0); err != nil {
+		t.Fatalf("X on row 1: %v", err)
+	}
+	if err := m.AcquireLockMode(t2.ID, row2, LockExclusive, 0); err != nil {
+		t.Fatalf("X on row 2: %v", err)
+	}
+
+	// The same row, or the whole table, is not available.
+	if err := m.AcquireLockMode(t2.ID, row1, LockExclusive, 0); err == nil {
+		t.Fatal("second X on row 1 was granted")
+	}
+	if err := m.AcquireLockMode(t2.ID, row1, LockShared, 0); err == nil {
+		t.Fatal("S on an X-locked row was granted")
+	}
+	if err := m.AcquireLockMode(t2.ID, table, LockShared, 0); err == nil {
+		t.Fatal("S on a table with another IX holder was granted")
+	}
+
+	// Once t1 ends, t2 may take the row and upgrade its IX to X.
+	if err := t1.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if err := m.AcquireLockMode(t2.ID, row1, LockExclusive, 0); err != nil {
+		t.Fatalf("X on released row 1: %v", err)
+	}
+	if err := m.AcquireLockMode(t2.ID, table, LockShared, 0); err != nil {
+		t.Fatalf("S on table: %v", err)
+	}
+	if mode, ok := m.HeldLock(t2.ID, table); !ok || mode != LockExclusive {
+		t.Fatalf("table lock = %v, %v; want X", mode, ok)
+	}
+}
+
+func TestLockUnion(t *testing.T) {
+	tests := []struct {
+		held, req, want LockMode
+	}{
+		{LockIntentShared, LockIntentExclusive, LockIntentExclusive},
+		{LockIntentShared, LockShared, LockShared},
+		{LockShared, LockIntentShared, LockShared},
+		{LockShared, LockIntentExclusive, LockExclusive},
+		{LockIntentExclusive, LockExclusive, LockExclusive},
+		{LockShared, LockShared, LockShared},
+	}
+	for _, tt := range tests {
+		if got := lockUnion(tt.held, tt.req); got != tt.want {
+			t.Errorf("lockUnion(%v, %v) = %v, want %v", tt.held, tt.req, got, tt.want)
+		}
+	}
+}
+
+func TestRowLockWaitsForHolder(t *testing.T) {
+	m := NewManager(nil)
+	t1 := m.Begin(nil)
+	t2 := m.Begin(nil)
+	defer t2.Rollback()
+	row := RowLockKey("t", "k")
+
+	if err := m.AcquireLockMode(t1.ID, row, LockExclusive, time.Second); err != nil {
+		t.Fatalf("t1: %v", err)
+	}
+	if err := m.AcquireLockMode(t2.ID, row, LockExclusive, 30*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("t2 err = %v, want ErrLockTimeout", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.AcquireLockMode(t2.ID, row, LockExclusive, 5*time.Second) }()
+	time.Sleep(30 * time.Millisecond)
+	if err := t1.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("t2 after commit: %v", err)
+	}
+}
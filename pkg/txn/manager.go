@@ -13,17 +13,19 @@ import (
 	"time"
 
 	"github.com/cobaltdb/cobaltdb/pkg/metrics"
+	"github.com/cobaltdb/cobaltdb/pkg/sqlerr"
 	"github.com/cobaltdb/cobaltdb/pkg/storage"
 )
 
 var (
 	ErrTxnCommitted     = errors.New("transaction already committed")
 	ErrTxnAborted       = errors.New("transaction already aborted")
-	ErrConflict         = errors.New("transaction conflict")
+	ErrConflict         = sqlerr.ErrConflict
 	ErrTxnNotFound      = errors.New("transaction not found")
-	ErrDeadlockDetected = errors.New("deadlock detected")
+	ErrDeadlockDetected = sqlerr.ErrDeadlock
+	ErrLockTimeout      = sqlerr.ErrLockTimeout
 	ErrTxnTimeout       = errors.New("transaction timeout")
-	ErrReadOnlyTxn      = errors.New("read-only transaction cannot write")
+	ErrReadOnlyTxn      = sqlerr.ErrReadOnlyTxn
 )
 
 func checkedTxnUint32(n int, name string) (uint32, error) {
@@ -120,6 +122,9 @@ type Transaction struct {
 	StartTS   uint64
 	ReadSet   map[WriteKey]uint64 // key → version read
 	WriteSet  map[WriteKey][]byte // key → new value (buffered writes)
+	// CommitLSN is the LSN of the WAL record that committed the transaction,
+	// or 0 when it has not committed or the manager has no WAL.
+	CommitLSN uint64
 	mu        sync.Mutex
 	manager   *Manager
 
@@ -315,7 +320,9 @@ func (t *Transaction) rollbackLocked() error {
 	// lockMu acquire/release cycles that create a deadlock window with
 	// AcquireLockMode (which holds lockMu and may call SetWaitingFor).
 	t.mu.Unlock()
+	mgr.lockMu.Lock()
 	mgr.releaseAllLocksUnderLock(t.ID, locks)
+	mgr.lockMu.Unlock()
 	t.mu.Lock()
 
 	mgr.removeActive(t.ID)
@@ -461,18 +468,13 @@ type Manager struct {
 	stopOnce              sync.Once
 
 	// Lock management for deadlock detection
-	lockEntries map[string]*lockEntry // key → lock state (shared + exclusive)
+	lockEntries map[string]*lockEntry // key → lock state
 	lockMu      sync.RWMutex
 
 	// Transaction recycling pool to eliminate per-txn heap allocations.
 	txnPool sync.Pool
 }
 
-type lockEntry struct {
-	shared    map[uint64]bool // txnIDs holding shared locks
-	exclusive uint64          // txnID holding exclusive lock (0 if none)
-}
-
 // NewManager creates a new transaction manager
 func NewManager(wal interface{}) *Manager {
 	m := &Manager{
@@ -520,6 +522,18 @@ func (m *Manager) deadlockDetector() {
 	}
 }
 
+// ActiveCount returns the number of transactions that have begun and not
+// yet committed or aborted.
+func (m *Manager) ActiveCount() int {
+	n := 0
+	for i := range m.activeShards {
+		m.activeShards[i].RLock()
+		n += len(m.activeShards[i].m)
+		m.activeShards[i].RUnlock()
+	}
+	return n
+}
+
 // checkForDeadlocks detects cycles in the wait-for graph and aborts transactions to break them
 func (m *Manager) checkForDeadlocks() {
 	// Snapshot active transactions and their waiting states
@@ -630,7 +644,8 @@ func (m *Manager) AcquireLock(txnID uint64, key string, timeout time.Duration) e
 	return m.AcquireLockMode(txnID, key, LockExclusive, timeout)
 }
 
-// AcquireLockMode acquires a lock in the specified mode (shared or exclusive).
+// AcquireLockMode acquires a lock in the specified mode. A transaction that
+// already holds a lock on key is upgraded to a mode covering both.
 func (m *Manager) AcquireLockMode(txnID uint64, key string, mode LockMode, timeout time.Duration) error {
 	txn, exists := m.activeTxn(txnID)
 	if !exists {
@@ -644,37 +659,14 @@ func (m *Manager) AcquireLockMode(txnID uint64, key string, mode LockMode, timeo
 
 	entry := m.lockEntries[key]
 	if entry == nil {
-		entry = &lockEntry{shared: make(map[uint64]bool)}
+		entry = newLockEntry()
 		m.lockEntries[key] = entry
 	}
 
-	// Check if we can acquire immediately
-	canAcquire := false
-	switch mode {
-	case LockShared:
-		// Shared lock: allowed if no exclusive lock, or we hold the exclusive lock
-		if entry.exclusive == 0 || entry.exclusive == txnID {
-			canAcquire = true
-		}
-	case LockExclusive:
-		// Exclusive lock: allowed if no holders, or we already hold exclusive
-		if entry.exclusive == txnID {
-			canAcquire = true
-		} else if entry.exclusive == 0 && len(entry.shared) == 0 {
-			canAcquire = true
-		} else if len(entry.shared) == 1 && entry.shared[txnID] {
-			// Upgrade: we hold the only shared lock → exclusive
-			canAcquire = true
-		}
-	}
-
-	if canAcquire {
-		switch mode {
-		case LockShared:
-			entry.shared[txnID] = true
-		case LockExclusive:
-			entry.exclusive = txnID
-		}
+	// Determine who, if anyone, is blocking us
+	blockerID := entry.blocker(txnID, mode)
+	if blockerID == 0 {
+		entry.grant(txnID, mode)
 		m.lockMu.Unlock()
 
 		if err := txn.AddLockHeldIfActive(key); err != nil {
@@ -684,18 +676,6 @@ func (m *Manager) AcquireLockMode(txnID uint64, key string, mode LockMode, timeo
 		return nil
 	}
 
-	// Determine who is blocking us
-	blockerID := entry.exclusive
-	if blockerID == 0 {
-		// Blocked by shared lock holders — pick any
-		for id := range entry.shared {
-			if id != txnID {
-				blockerID = id
-				break
-			}
-		}
-	}
-
 	blockerShard := activeShardIdx(blockerID)
 	m.activeShards[blockerShard].RLock()
 	waitingTxn, waitingExists := m.activeShards[blockerShard].m[blockerID]
@@ -738,7 +718,7 @@ func (m *Manager) AcquireLockMode(txnID uint64, key string, mode LockMode, timeo
 				return ErrTxnTimeout
 			case <-timer.C:
 				txn.SetWaitingFor(0)
-				return fmt.Errorf("lock acquisition timeout")
+				return ErrLockTimeout
 			case <-ticker.C:
 				if err := txn.activeStateError(); err != nil {
 					txn.SetWaitingFor(0)
@@ -747,28 +727,12 @@ func (m *Manager) AcquireLockMode(txnID uint64, key string, mode LockMode, timeo
 				m.lockMu.Lock()
 				e := m.lockEntries[key]
 				if e == nil {
-					e = &lockEntry{shared: make(map[uint64]bool)}
+					e = newLockEntry()
 					m.lockEntries[key] = e
 				}
 				// Re-check if we can acquire
-				ok := false
-				switch mode {
-				case LockShared:
-					if e.exclusive == 0 || e.exclusive == txnID {
-						ok = true
-					}
-				case LockExclusive:
-					if e.exclusive == txnID || (e.exclusive == 0 && len(e.shared) == 0) || (len(e.shared) == 1 && e.shared[txnID]) {
-						ok = true
-					}
-				}
-				if ok {
-					switch mode {
-					case LockShared:
-						e.shared[txnID] = true
-					case LockExclusive:
-						e.exclusive = txnID
-					}
+				if e.blocker(txnID, mode) == 0 {
+					e.grant(txnID, mode)
 					m.lockMu.Unlock()
 					txn.SetWaitingFor(0)
 					if err := txn.AddLockHeldIfActive(key); err != nil {
@@ -786,6 +750,17 @@ func (m *Manager) AcquireLockMode(txnID uint64, key string, mode LockMode, timeo
 	return fmt.Errorf("lock is held by transaction %d", blockerID)
 }
 
+// HeldLock returns the mode of the lock txnID holds on key, if any.
+func (m *Manager) HeldLock(txnID uint64, key string) (LockMode, bool) {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
+	if entry := m.lockEntries[key]; entry != nil {
+		mode, ok := entry.holders[txnID]
+		return mode, ok
+	}
+	return 0, false
+}
+
 // wouldCauseDeadlock checks if txnID waiting for ownerID would create a cycle
 func (m *Manager) wouldCauseDeadlock(txnID, ownerID uint64) bool {
 	visited := make(map[uint64]bool)
@@ -830,18 +805,8 @@ func (m *Manager) ReleaseLock(txnID uint64, key string) {
 		return
 	}
 
-	released := false
-	if entry.exclusive == txnID {
-		entry.exclusive = 0
-		released = true
-	}
-	if entry.shared[txnID] {
-		delete(entry.shared, txnID)
-		released = true
-	}
-
-	if released {
-		if entry.exclusive == 0 && len(entry.shared) == 0 {
+	if entry.release(txnID) {
+		if len(entry.holders) == 0 {
 			delete(m.lockEntries, key)
 		}
 
@@ -865,11 +830,8 @@ func (m *Manager) ReleaseAllLocks(txnID uint64) {
 	if !exists {
 		m.lockMu.Lock()
 		for key, entry := range m.lockEntries {
-			if entry.exclusive == txnID {
-				entry.exclusive = 0
-			}
-			delete(entry.shared, txnID)
-			if entry.exclusive == 0 && len(entry.shared) == 0 {
+			entry.release(txnID)
+			if len(entry.holders) == 0 {
 				delete(m.lockEntries, key)
 			}
 		}
@@ -894,13 +856,8 @@ func (m *Manager) releaseAllLocksUnderLock(txnID uint64, keys []string) {
 		if entry == nil {
 			continue
 		}
-		if entry.exclusive == txnID {
-			entry.exclusive = 0
-		}
-		if entry.shared[txnID] {
-			delete(entry.shared, txnID)
-		}
-		if entry.exclusive == 0 && len(entry.shared) == 0 {
+		entry.release(txnID)
+		if len(entry.holders) == 0 {
 			delete(m.lockEntries, key)
 		}
 
@@ -1303,6 +1260,7 @@ func (m *Manager) writeWALForCommit(txn *Transaction) error {
 		if walDataBuf != nil {
 			walDataPool.Put(walDataBuf)
 		}
+		txn.CommitLSN = recArr[0].LSN
 	} else {
 		records := make([]*storage.WALRecord, 0, len(txn.WriteSet)+1)
 		for wk, value := range txn.WriteSet {
@@ -1337,6 +1295,7 @@ func (m *Manager) writeWALForCommit(txn *Transaction) error {
 		if err := wal.AppendBatch(records); err != nil {
 			return fmt.Errorf("failed to append WAL records: %w", err)
 		}
+		txn.CommitLSN = records[len(records)-1].LSN
 	}
 	return nil
 }
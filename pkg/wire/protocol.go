@@ -1,6 +1,7 @@
 package wire
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 
@@ -14,6 +15,8 @@ const (
 	MsgQuery       MsgType = 0x01 // SQL query string
 	MsgPrepare     MsgType = 0x02 // Prepared statement
 	MsgExecute     MsgType = 0x03 // Execute prepared
+	MsgFetch       MsgType = 0x04 // Fetch the next page of a result cursor
+	MsgCloseCursor MsgType = 0x05 // Release a result cursor
 	MsgResult      MsgType = 0x10 // Query result rows
 	MsgOK          MsgType = 0x11 // Execution success
 	MsgError       MsgType = 0x12 // Error response
@@ -37,6 +40,8 @@ type Message struct {
 type QueryMessage struct {
 	SQL    string        `msgpack:"sql"`
 	Params []interface{} `msgpack:"params,omitempty"`
+	// Named holds the values of :name and @name parameters.
+	Named map[string]interface{} `msgpack:"named,omitempty"`
 }
 
 // ResultMessage represents a query result
@@ -45,6 +50,21 @@ type ResultMessage struct {
 	Types   []string        `msgpack:"types"`
 	Rows    [][]interface{} `msgpack:"rows"`
 	Count   int64           `msgpack:"count"`
+	// CursorID is set when the result exceeded the server's page size; the
+	// client pages through the remaining rows with MsgFetch.
+	CursorID uint32 `msgpack:"cursor_id,omitempty"`
+	HasMore  bool   `msgpack:"has_more,omitempty"`
+	InTxn    bool   `msgpack:"in_txn,omitempty"` // The connection has a transaction open
+}
+
+// FetchMessage requests the next page of a result cursor
+type FetchMessage struct {
+	CursorID uint32 `msgpack:"cursor_id"`
+}
+
+// CloseCursorMessage releases a result cursor before it is exhausted
+type CloseCursorMessage struct {
+	CursorID uint32 `msgpack:"cursor_id"`
 }
 
 // OKMessage represents a successful execution
@@ -52,12 +72,18 @@ type OKMessage struct {
 	LastInsertID int64  `msgpack:"last_insert_id"`
 	RowsAffected int64  `msgpack:"rows_affected"`
 	StmtID       uint32 `msgpack:"stmt_id,omitempty"` // Set for prepared statement OK
+	InTxn        bool   `msgpack:"in_txn,omitempty"`  // The connection has a transaction open
 }
 
-// ErrorMessage represents an error response
+// ErrorMessage represents an error response. SQLState is the error's
+// SQLSTATE code (see pkg/sqlerr) when the engine classified it; Line and
+// Column locate a syntax error in the statement.
 type ErrorMessage struct {
-	Code    int    `msgpack:"code"`
-	Message string `msgpack:"message"`
+	Code     int    `msgpack:"code"`
+	Message  string `msgpack:"message"`
+	SQLState string `msgpack:"sqlstate,omitempty"`
+	Line     int    `msgpack:"line,omitempty"`
+	Column   int    `msgpack:"column,omitempty"`
 }
 
 // PrepareMessage represents a prepare statement request
@@ -67,8 +93,9 @@ type PrepareMessage struct {
 
 // ExecuteMessage represents an execute prepared statement request
 type ExecuteMessage struct {
-	StmtID uint32        `msgpack:"stmt_id"`
-	Params []interface{} `msgpack:"params"`
+	StmtID uint32                 `msgpack:"stmt_id"`
+	Params []interface{}          `msgpack:"params"`
+	Named  map[string]interface{} `msgpack:"named,omitempty"`
 }
 
 // Encode encodes a message using MessagePack
@@ -139,7 +166,7 @@ func DecodeMessage(data []byte) (*Message, error) {
 
 func isKnownMsgType(msgType MsgType) bool {
 	switch msgType {
-	case MsgQuery, MsgPrepare, MsgExecute, MsgResult, MsgOK, MsgError,
+	case MsgQuery, MsgPrepare, MsgExecute, MsgFetch, MsgCloseCursor, MsgResult, MsgOK, MsgError,
 		MsgPing, MsgPong, MsgAuth, MsgAuthSuccess, MsgAuthFailed:
 		return true
 	default:
@@ -147,12 +174,23 @@ func isKnownMsgType(msgType MsgType) bool {
 	}
 }
 
-// NewQueryMessage creates a new query message
-func NewQueryMessage(sql string, params ...interface{}) *QueryMessage {
-	return &QueryMessage{
-		SQL:    sql,
-		Params: cloneValues(params),
+// NewQueryMessage creates a new query message. sql.NamedArg parameters go
+// into Named and the rest into Params.
+func NewQueryMessage(query string, params ...interface{}) *QueryMessage {
+	msg := &QueryMessage{SQL: query}
+	var positional []interface{}
+	for _, param := range params {
+		if na, ok := param.(sql.NamedArg); ok {
+			if msg.Named == nil {
+				msg.Named = make(map[string]interface{})
+			}
+			msg.Named[na.Name] = cloneValue(na.Value, 1, make(map[wireCloneVisit]struct{}))
+			continue
+		}
+		positional = append(positional, param)
 	}
+	msg.Params = cloneValues(positional)
+	return msg
 }
 
 // NewResultMessage creates a new result message
@@ -312,6 +350,7 @@ type AuthMessage struct {
 type AuthSuccessMessage struct {
 	Token    string `msgpack:"token"`
 	Username string `msgpack:"username"`
+	IsAdmin  bool   `msgpack:"is_admin,omitempty"`
 }
 
 // AuthFailedMessage represents a failed authentication response
@@ -229,6 +229,8 @@ func TestMsgTypes(t *testing.T) {
 		{"Query", MsgQuery, 0x01},
 		{"Prepare", MsgPrepare, 0x02},
 		{"Execute", MsgExecute, 0x03},
+		{"Fetch", MsgFetch, 0x04},
+		{"CloseCursor", MsgCloseCursor, 0x05},
 		{"Result", MsgResult, 0x10},
 		{"OK", MsgOK, 0x11},
 		{"Error", MsgError, 0x12},
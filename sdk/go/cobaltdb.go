@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -428,6 +429,7 @@ type conn struct {
 	connector *connector
 	mu        sync.Mutex
 	closed    bool
+	session   engine.Session // last_insert_rowid() and other per-connection state
 }
 
 func (c *conn) Prepare(query string) (driver.Stmt, error) {
@@ -491,7 +493,7 @@ func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, e
 	if c.closed {
 		return nil, ErrConnClosed
 	}
-	_, err := c.db.Exec(ctx, "BEGIN")
+	_, err := c.db.Exec(engine.WithSession(ctx, &c.session), "BEGIN")
 	if err != nil {
 		return nil, err
 	}
@@ -508,13 +510,9 @@ func (c *conn) ExecContext(ctx context.Context, query string, args []driver.Name
 		return nil, ErrConnClosed
 	}
 
-	// Convert NamedValue to interface{}
-	values := make([]interface{}, len(args))
-	for i, arg := range args {
-		values[i] = arg.Value
-	}
+	values := driverArgs(args)
 
-	result, err := c.db.Exec(ctx, query, values...)
+	result, err := c.db.Exec(engine.WithSession(ctx, &c.session), query, values...)
 	if err != nil {
 		return nil, err
 	}
@@ -530,13 +528,9 @@ func (c *conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 		return nil, ErrConnClosed
 	}
 
-	// Convert NamedValue to interface{}
-	values := make([]interface{}, len(args))
-	for i, arg := range args {
-		values[i] = arg.Value
-	}
+	values := driverArgs(args)
 
-	rows, err := c.db.Query(ctx, query, values...)
+	rows, err := c.db.Query(engine.WithSession(ctx, &c.session), query, values...)
 	if err != nil {
 		return nil, err
 	}
@@ -679,8 +673,31 @@ func (r *driverRows) ColumnTypeDatabaseTypeName(index int) string {
 	return "TEXT" // Simplified
 }
 
+// CheckNamedValue accepts slices, which expand into IN (?) lists, and
+// leaves every other value to the default conversion.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.([]byte); !ok && nv.Value != nil && reflect.TypeOf(nv.Value).Kind() == reflect.Slice {
+		return nil
+	}
+	return driver.ErrSkip
+}
+
 // Helper functions
 
+// driverArgs converts driver arguments for the engine. Named ones become
+// sql.NamedArg so they bind :name and @name placeholders.
+func driverArgs(args []driver.NamedValue) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			values[i] = sql.Named(arg.Name, arg.Value)
+		} else {
+			values[i] = arg.Value
+		}
+	}
+	return values
+}
+
 func namedValues(args []driver.Value) []driver.NamedValue {
 	result := make([]driver.NamedValue, len(args))
 	for i, arg := range args {
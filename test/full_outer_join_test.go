@@ -1,6 +1,8 @@
 package test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/cobaltdb/cobaltdb/pkg/engine"
@@ -126,3 +128,55 @@ func TestFullOuterJoin_EmptyTables(t *testing.T) {
 		}
 	})
 }
+
+func TestFullOuterJoin_NullPadding(t *testing.T) {
+	db, err := engine.Open(":memory:", &engine.Options{CoreStorage: engine.CoreStorage{InMemory: true}})
+	if err != nil {
+		t.Fatalf("DB open: %v", err)
+	}
+	defer db.Close()
+	ctx := t.Context()
+
+	afExec(t, db, ctx, "CREATE TABLE a (id INTEGER, k INTEGER, name TEXT)")
+	afExec(t, db, ctx, "CREATE TABLE b (id INTEGER, k INTEGER, value TEXT)")
+	afExec(t, db, ctx, "CREATE TABLE c (id INTEGER, k INTEGER)")
+	afExec(t, db, ctx, "CREATE TABLE empty (id INTEGER, k INTEGER)")
+	afExec(t, db, ctx, "INSERT INTO a VALUES (1, 1, 'A'), (2, 2, 'B'), (3, NULL, 'C'), (4, 2, 'D')")
+	afExec(t, db, ctx, "INSERT INTO b VALUES (1, 2, 'X'), (2, 3, 'Y'), (3, NULL, 'Z')")
+	afExec(t, db, ctx, "INSERT INTO c VALUES (1, 3), (2, 1)")
+
+	check := func(sql, want string) {
+		t.Helper()
+		if got := fmt.Sprint(afQuery(t, db, ctx, sql)); got != want {
+			t.Errorf("%s = %s, want %s", sql, got, want)
+		}
+	}
+
+	// NULL keys match nothing, so both sides keep them, padded with NULLs.
+	// The hash join (plain equality) and the nested loop (any other
+	// condition) pad the same way.
+	const padded = "[[1 <nil>] [2 1] [3 <nil>] [4 1] [<nil> 2] [<nil> 3]]"
+	check("SELECT a.id, b.id FROM a FULL OUTER JOIN b ON a.k = b.k ORDER BY a.id, b.id", padded)
+	check("SELECT a.id, b.id FROM a FULL OUTER JOIN b ON a.k = b.k AND 1 = 1 ORDER BY a.id, b.id", padded)
+	check("SELECT a.id, b.id FROM a RIGHT JOIN b ON a.k = b.k ORDER BY a.id, b.id", "[[2 1] [4 1] [<nil> 2] [<nil> 3]]")
+	check("SELECT * FROM a FULL JOIN b ON a.k = b.k WHERE a.id IS NULL ORDER BY b.id",
+		"[[<nil> <nil> <nil> 2 3 Y] [<nil> <nil> <nil> 3 <nil> Z]]")
+	check("SELECT a.id, b.id, c.id FROM a FULL JOIN b ON a.k = b.k FULL JOIN c ON c.k = b.k ORDER BY 1, 2, 3",
+		"[[1 <nil> <nil>] [2 1 <nil>] [3 <nil> <nil>] [4 1 <nil>] [<nil> 2 1] [<nil> 3 <nil>] [<nil> <nil> 2]]")
+	check("SELECT b.k, COUNT(a.id), COUNT(*) FROM a FULL JOIN b ON a.k = b.k GROUP BY b.k ORDER BY 1",
+		"[[2 2 2] [3 0 1] [<nil> 2 3]]")
+
+	// An empty side pads every row of the other.
+	check("SELECT a.id, empty.id, empty.k FROM a FULL JOIN empty ON a.k = empty.k ORDER BY 1",
+		"[[1 <nil> <nil>] [2 <nil> <nil>] [3 <nil> <nil>] [4 <nil> <nil>]]")
+	check("SELECT empty.id, b.id FROM empty FULL JOIN b ON empty.k = b.k ORDER BY 2", "[[<nil> 1] [<nil> 2] [<nil> 3]]")
+
+	check("SELECT a.id, c.id FROM a CROSS JOIN c ORDER BY 1, 2",
+		"[[1 1] [1 2] [2 1] [2 2] [3 1] [3 2] [4 1] [4 2]]")
+	check("SELECT COUNT(*) FROM a CROSS JOIN b CROSS JOIN c", "[[24]]")
+	check("SELECT COUNT(*) FROM a CROSS JOIN empty", "[[0]]")
+
+	if _, err := db.Query(ctx, "SELECT * FROM a FULL OUTER JOIN b"); err == nil || !strings.Contains(err.Error(), "ON or USING") {
+		t.Errorf("FULL JOIN without a condition: err = %v", err)
+	}
+}
@@ -268,7 +268,7 @@ func TestV44NewFeatures(t *testing.T) {
 	// CF3: CTE + derived table + positional
 	check("CF3 CTE + derived + positional",
 		`WITH data AS (SELECT * FROM v44_items)
-		 SELECT cnt FROM (SELECT category, COUNT(*) AS cnt FROM data GROUP BY 1) AS grouped ORDER BY 2 DESC LIMIT 1`,
+		 SELECT cnt FROM (SELECT category, COUNT(*) AS cnt FROM data GROUP BY 1) AS grouped ORDER BY 1 DESC LIMIT 1`,
 		3)
 
 	// CF4: Derived table in subquery context
@@ -1729,8 +1729,8 @@ func TestV85_RegexExtract(t *testing.T) {
 	db, ctx := af(t)
 	defer db.Close()
 
-	// Returns array-like format
-	afExpectVal(t, db, ctx, "SELECT REGEXP_EXTRACT('hello123world', '[0-9]+')", "[123]")
+	// Returns the first match as text
+	afExpectVal(t, db, ctx, "SELECT REGEXP_EXTRACT('hello123world', '[0-9]+')", "123")
 }
 
 // ==================== DISTINCT WITH NULL ====================